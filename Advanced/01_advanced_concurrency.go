@@ -3,14 +3,27 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"hellogolang/Advanced/circuitbreaker"
+	"hellogolang/Advanced/lockfree"
+	"hellogolang/Advanced/metrics"
+	"hellogolang/Advanced/ratelimit"
+	"hellogolang/Advanced/scheduler"
+	"hellogolang/Advanced/syncx"
+	"hellogolang/Advanced/workerpool"
 )
 
 // Advanced Concurrency demonstrates advanced concurrency patterns and techniques
 
+// registry collects the metrics of the pool, limiter and breaker below
+var registry = metrics.NewRegistry()
+
 func main() {
 	workerPoolAdvanced()
 	rateLimitingAdvanced()
@@ -20,195 +33,123 @@ func main() {
 	atomicOperations()
 	runtimeControl()
 	contextPropagation()
+	scheduledJobs()
+	metricsExposition()
 }
 
 // workerPoolAdvanced demonstrates advanced worker pool with dynamic scaling
 func workerPoolAdvanced() {
-	type Job struct {
-		ID     int
-		Data   string
-		Result chan string
-	}
-
-	jobs := make(chan Job, 100)
-	results := make(chan string, 100)
-
-	// Dynamic worker pool
-	numWorkers := runtime.NumCPU()
-	var wg sync.WaitGroup
-
-	// Start workers
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			for job := range jobs {
-				// Simulate work
-				result := fmt.Sprintf("Worker %d processed job %d: %s", workerID, job.ID, job.Data)
-				results <- result
-				job.Result <- result
+	// Dynamic worker pool: one worker kept, growing to one per CPU while
+	// jobs queue up, and a full queue of 100 makes submitters wait
+	pool := workerpool.New[string](workerpool.Options{
+		MinWorkers: 1,
+		MaxWorkers: runtime.NumCPU(),
+		QueueSize:  100,
+		Policy:     workerpool.Block,
+	})
+	pool.RegisterMetrics(registry, "jobs")
+
+	// Submit jobs, each returning a future of its result
+	ctx := context.Background()
+	futures := []*workerpool.Future[string]{}
+	for i := 0; i < 10; i++ {
+		data := fmt.Sprintf("data-%d", i)
+		future, err := pool.Submit(ctx, func(ctx context.Context) (string, error) {
+			if i == 7 {
+				panic("corrupt job")
 			}
-		}(i)
+			return fmt.Sprintf("processed job %d: %s", i, data), nil
+		})
+		if err != nil {
+			fmt.Printf("Submit failed: %v\n", err)
+			continue
+		}
+		futures = append(futures, future)
 	}
 
-	// Submit jobs
-	go func() {
-		defer close(jobs)
-		for i := 0; i < 10; i++ {
-			jobs <- Job{
-				ID:     i,
-				Data:   fmt.Sprintf("data-%d", i),
-				Result: make(chan string, 1),
-			}
+	// Process results in submission order; the panic fails only its own job
+	for _, future := range futures {
+		if result, err := future.Wait(ctx); err == nil {
+			fmt.Printf("Result: %s\n", result)
+		} else {
+			fmt.Printf("Error: %v\n", err)
 		}
-	}()
-
-	// Collect results
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	}
 
-	// Process results
-	for result := range results {
-		fmt.Printf("Result: %s\n", result)
+	// Drain and stop the pool, giving up on stragglers after a second
+	shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := pool.Shutdown(shutdownCtx); err != nil {
+		fmt.Printf("Shutdown: %v\n", err)
 	}
+	stats := pool.Stats()
+	fmt.Printf("Completed: %d, panicked: %d\n", stats.Completed, stats.Panicked)
 }
 
 // rateLimitingAdvanced demonstrates advanced rate limiting patterns
 func rateLimitingAdvanced() {
-	// Token bucket rate limiter
-	type TokenBucket struct {
-		tokens     int64
-		capacity   int64
-		refillRate time.Duration
-		mu         sync.Mutex
-		lastRefill time.Time
-	}
-
-	newTokenBucket := func(capacity int64, refillRate time.Duration) *TokenBucket {
-		return &TokenBucket{
-			tokens:     capacity,
-			capacity:   capacity,
-			refillRate: refillRate,
-			lastRefill: time.Now(),
-		}
-	}
-
-	takeToken := func(tb *TokenBucket) bool {
-		tb.mu.Lock()
-		defer tb.mu.Unlock()
-
-		// Refill tokens
-		now := time.Now()
-		elapsed := now.Sub(tb.lastRefill)
-		tokensToAdd := int64(elapsed / tb.refillRate)
-
-		if tokensToAdd > 0 {
-			tb.tokens = min(tb.tokens+tokensToAdd, tb.capacity)
-			tb.lastRefill = now
-		}
-
-		if tb.tokens > 0 {
-			tb.tokens--
-			return true
-		}
-		return false
-	}
-
-	bucket := newTokenBucket(5, 100*time.Millisecond)
+	// Token bucket rate limiter: bursts of 5, refilled at 10 per second
+	bucket := ratelimit.Instrumented(ratelimit.NewTokenBucket(10, 5), registry, "api")
 
 	// Test rate limiting
 	for i := 0; i < 10; i++ {
-		if takeToken(bucket) {
+		if bucket.Allow() {
 			fmt.Printf("Request %d: Allowed\n", i)
 		} else {
 			fmt.Printf("Request %d: Rate limited\n", i)
 		}
 		time.Sleep(50 * time.Millisecond)
 	}
-}
 
-// min returns the minimum of two int64 values
-func min(a, b int64) int64 {
-	if a < b {
-		return a
+	// Wait blocks for a token instead of refusing, until the context ends
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	for range 3 {
+		if err := bucket.Wait(ctx); err != nil {
+			fmt.Printf("Wait failed: %v\n", err)
+			return
+		}
 	}
-	return b
+	fmt.Printf("3 more requests waited %v\n", time.Since(start).Round(10*time.Millisecond))
 }
 
 // circuitBreaker demonstrates circuit breaker pattern
 func circuitBreaker() {
-	type CircuitBreaker struct {
-		failures     int64
-		successes    int64
-		state        int32 // 0: closed, 1: open, 2: half-open
-		maxFailures  int64
-		resetTimeout time.Duration
-		lastFailTime time.Time
-		mu           sync.RWMutex
-	}
-
-	cb := &CircuitBreaker{
-		maxFailures:  5,
-		resetTimeout: 1 * time.Second,
-		state:        0, // closed
-	}
-
-	call := func() error {
-		cb.mu.RLock()
-		state := atomic.LoadInt32(&cb.state)
-		cb.mu.RUnlock()
-
-		if state == 1 { // open
-			if time.Since(cb.lastFailTime) > cb.resetTimeout {
-				atomic.StoreInt32(&cb.state, 2) // half-open
-				fmt.Println("Circuit breaker: half-open")
-			} else {
-				return fmt.Errorf("circuit breaker is open")
-			}
-		}
-
-		// Simulate operation
-		err := simulateOperation()
-
-		cb.mu.Lock()
-		defer cb.mu.Unlock()
-
-		if err != nil {
-			atomic.AddInt64(&cb.failures, 1)
-			cb.lastFailTime = time.Now()
-
-			if atomic.LoadInt64(&cb.failures) >= cb.maxFailures {
-				atomic.StoreInt32(&cb.state, 1) // open
-				fmt.Println("Circuit breaker: opened")
-			}
-			return err
-		}
-
-		// Success
-		atomic.StoreInt64(&cb.successes, 1)
-		atomic.StoreInt64(&cb.failures, 0)
-		atomic.StoreInt32(&cb.state, 0) // closed
-		return nil
-	}
-
-	// Test circuit breaker
+	cb := circuitbreaker.New(circuitbreaker.Settings{
+		FailureThreshold: 3,
+		OpenTimeout:      300 * time.Millisecond,
+		CallTimeout:      50 * time.Millisecond,
+		OnStateChange: func(from, to circuitbreaker.State) {
+			fmt.Printf("Circuit breaker: %v -> %v\n", from, to)
+		},
+	})
+	cb.RegisterMetrics(registry, "inventory")
+
+	// Test circuit breaker against a dependency that is down for the first
+	// few calls and then recovers
 	for i := 0; i < 10; i++ {
-		if err := call(); err != nil {
+		err := cb.Execute(context.Background(), func(ctx context.Context) error {
+			return simulateOperation(ctx, i < 4)
+		})
+		if err != nil {
 			fmt.Printf("Call %d failed: %v\n", i, err)
 		} else {
 			fmt.Printf("Call %d succeeded\n", i)
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
+	m := cb.Metrics()
+	fmt.Printf("Requests: %d, successes: %d, failures: %d (%d timeouts), rejections: %d\n",
+		m.Requests, m.Successes, m.Failures, m.Timeouts, m.Rejections)
 }
 
-// simulateOperation simulates an operation that might fail
-func simulateOperation() error {
-	// Simulate 30% failure rate
-	if time.Now().UnixNano()%10 < 3 {
-		return fmt.Errorf("operation failed")
+// simulateOperation simulates an operation that hangs until its context
+// ends while down, and otherwise succeeds
+func simulateOperation(ctx context.Context, down bool) error {
+	if down {
+		<-ctx.Done()
+		return ctx.Err()
 	}
 	return nil
 }
@@ -254,52 +195,54 @@ func semaphorePattern() {
 
 // barrierPattern demonstrates barrier synchronization pattern
 func barrierPattern() {
-	type Barrier struct {
-		count    int
-		required int
-		mu       sync.Mutex
-		cond     *sync.Cond
-	}
-
-	newBarrier := func(required int) *Barrier {
-		b := &Barrier{
-			required: required,
-		}
-		b.cond = sync.NewCond(&b.mu)
-		return b
-	}
-
-	wait := func(b *Barrier) {
-		b.mu.Lock()
-		b.count++
-		count := b.count
-		b.mu.Unlock()
-
-		if count >= b.required {
-			b.cond.Broadcast()
-		} else {
-			b.mu.Lock()
-			for b.count < b.required {
-				b.cond.Wait()
-			}
-			b.mu.Unlock()
-		}
-	}
-
-	barrier := newBarrier(5)
+	// A cyclic barrier holds each goroutine until all 5 arrive, round
+	// after round
+	barrier := syncx.NewCyclicBarrier(5, func() {
+		fmt.Println("All goroutines arrived")
+	})
+	ctx := context.Background()
 
 	var wg sync.WaitGroup
 	for i := 0; i < 5; i++ {
 		wg.Add(1)
 		go func(id int) {
 			defer wg.Done()
-			fmt.Printf("Goroutine %d: Waiting at barrier\n", id)
-			wait(barrier)
-			fmt.Printf("Goroutine %d: Passed barrier\n", id)
+			for round := 1; round <= 2; round++ {
+				if _, err := barrier.Await(ctx); err != nil {
+					fmt.Printf("Goroutine %d: %v\n", id, err)
+					return
+				}
+			}
+			fmt.Printf("Goroutine %d: Passed barrier twice\n", id)
 		}(i)
 	}
-
 	wg.Wait()
+
+	// A latch releases waiters once counted down, by other goroutines
+	ready := syncx.NewCountDownLatch(3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			time.Sleep(time.Duration(i) * 10 * time.Millisecond)
+			ready.CountDown()
+		}()
+	}
+	if err := ready.Wait(ctx); err == nil {
+		fmt.Println("Latch opened: all services ready")
+	}
+
+	// An error group waits for its goroutines and collects every error
+	var group syncx.ErrorGroup
+	for i := 0; i < 4; i++ {
+		group.Go(func() error {
+			if i%2 == 1 {
+				return fmt.Errorf("task %d failed", i)
+			}
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		fmt.Printf("Error group: %v\n", strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
 }
 
 // atomicOperations demonstrates atomic operations for lock-free programming
@@ -338,6 +281,38 @@ func atomicOperations() {
 
 	stored := config.Load().(Config)
 	fmt.Printf("Stored config: %+v\n", stored)
+
+	// Lock-free queue built from CAS: producers and consumers claim slots
+	// of a ring buffer without a mutex, and never block
+	queue := lockfree.NewMPMC[int](64)
+	var consumed, sum atomic.Int64
+	var producers, consumers sync.WaitGroup
+	for p := range 4 {
+		producers.Go(func() {
+			for i := 1; i <= 250; {
+				if queue.TryEnqueue(p*1000 + i) {
+					i++
+				} else {
+					runtime.Gosched() // Full: let a consumer run
+				}
+			}
+		})
+	}
+	for range 2 {
+		consumers.Go(func() {
+			for consumed.Load() < 1000 {
+				if v, ok := queue.TryDequeue(); ok {
+					sum.Add(int64(v))
+					consumed.Add(1)
+				} else {
+					runtime.Gosched() // Empty: let a producer run
+				}
+			}
+		})
+	}
+	producers.Wait()
+	consumers.Wait()
+	fmt.Printf("Lock-free queue: %d values consumed, sum %d, capacity %d\n", consumed.Load(), sum.Load(), queue.Cap())
 }
 
 // runtimeControl demonstrates runtime control and monitoring
@@ -414,3 +389,59 @@ func processWithContext(ctx context.Context, requestID string) {
 
 	fmt.Printf("Request %s: Completed\n", requestID)
 }
+
+// scheduledJobs demonstrates running jobs on a schedule: after a delay,
+// at a fixed interval, and by a cron expression
+func scheduledJobs() {
+	s := scheduler.New(scheduler.Options{
+		OnError: func(name string, err error) {
+			fmt.Printf("Job %s failed: %v\n", name, err)
+		},
+	})
+
+	// A fixed interval keeps to its grid however long each run takes; a run
+	// due while the last is still going is skipped
+	var ticks atomic.Int32
+	tick, _ := s.Add("tick", scheduler.Every(20*time.Millisecond), func(ctx context.Context) error {
+		if ticks.Add(1) == 2 {
+			time.Sleep(30 * time.Millisecond) // Overruns the next tick
+		}
+		return nil
+	}, scheduler.JobOptions{Overlap: scheduler.Skip})
+
+	// A delayed job runs once; its panic is recovered and reported
+	s.Delay("cleanup", 50*time.Millisecond, func(ctx context.Context) error {
+		panic("temp dir missing")
+	}, scheduler.JobOptions{})
+
+	// Cron expressions take 5 fields, or 6 with seconds first
+	report, err := s.Cron("report", "0 30 9 * * MON-FRI", func(ctx context.Context) error {
+		return nil
+	}, scheduler.JobOptions{Timeout: time.Minute})
+	if err != nil {
+		fmt.Printf("Cron: %v\n", err)
+	}
+	if e, ok := s.Entry(report); ok {
+		fmt.Printf("Report runs next at %v\n", e.Next.Format(time.DateTime))
+		for _, t := range scheduler.NextN(e.Schedule, e.Next, 3) {
+			fmt.Printf("  then %v\n", t.Format("Mon "+time.DateTime))
+		}
+	}
+
+	time.Sleep(110 * time.Millisecond)
+	if e, ok := s.Entry(tick); ok {
+		fmt.Printf("Tick: %d runs, %d skipped\n", e.Runs, e.Skipped)
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		fmt.Printf("Scheduler shutdown: %v\n", err)
+	}
+}
+
+// metricsExposition prints the metrics gathered above in the Prometheus
+// text format, as registry.Handler() serves them at /metrics
+func metricsExposition() {
+	fmt.Println("Metrics:")
+	if err := registry.WriteText(os.Stdout); err != nil {
+		fmt.Printf("Write metrics: %v\n", err)
+	}
+}
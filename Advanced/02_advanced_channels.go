@@ -3,8 +3,15 @@ package main
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"hellogolang/Advanced/future"
+	"hellogolang/Advanced/pipeline"
+	"hellogolang/Advanced/pubsub"
 )
 
 // Advanced Channels demonstrates advanced channel patterns and techniques
@@ -21,15 +28,41 @@ func main() {
 
 // channelPipelines demonstrates complex pipeline patterns
 func channelPipelines() {
-	// Multi-stage pipeline
-	numbers := generateNumbers(10)
-	squared := square(numbers)
-	doubled := double(squared)
-	filtered := filterEven(doubled)
+	// Multi-stage pipeline: squaring fans out to 3 workers yet keeps the
+	// input order, and each stage hands on through a small buffer
+	p := pipeline.New(context.Background())
+	numbers := pipeline.From(p, func(yield func(int) bool) {
+		for i := 1; i <= 10 && yield(i); i++ {
+		}
+	})
+	squared := pipeline.Map(numbers, func(ctx context.Context, n int) (int, error) {
+		time.Sleep(time.Duration(10-n) * time.Millisecond) // Early values finish last
+		return n * n, nil
+	}, pipeline.Options{Name: "square", Workers: 3, Buffer: 2, Ordered: true})
+	doubled := pipeline.Map(squared, func(ctx context.Context, n int) (int, error) {
+		return n * 2, nil
+	}, pipeline.Options{Name: "double"})
+	filtered := pipeline.Filter(doubled, func(ctx context.Context, n int) (bool, error) {
+		return n%4 == 0, nil
+	}, pipeline.Options{Name: "multiple of 4"})
 
 	fmt.Println("Pipeline results:")
-	for val := range filtered {
+	err := pipeline.ForEach(filtered, func(ctx context.Context, val int) error {
 		fmt.Printf("  %d\n", val)
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("Pipeline failed: %v\n", err)
+	}
+
+	// The first error of any stage cancels the rest and is returned
+	p = pipeline.New(context.Background())
+	parsed := pipeline.Map(pipeline.From(p, slices.Values([]string{"1", "2", "x", "4"})),
+		func(ctx context.Context, s string) (int, error) {
+			return strconv.Atoi(s)
+		}, pipeline.Options{Name: "parse", Workers: 2})
+	if _, err := pipeline.Collect(parsed); err != nil {
+		fmt.Printf("Pipeline failed: %v\n", err)
 	}
 }
 
@@ -45,99 +78,39 @@ func generateNumbers(count int) <-chan int {
 	return out
 }
 
-// square squares numbers
-func square(in <-chan int) <-chan int {
-	out := make(chan int)
-	go func() {
-		defer close(out)
-		for n := range in {
-			out <- n * n
-		}
-	}()
-	return out
-}
-
-// double doubles numbers
-func double(in <-chan int) <-chan int {
-	out := make(chan int)
-	go func() {
-		defer close(out)
-		for n := range in {
-			out <- n * 2
-		}
-	}()
-	return out
-}
-
-// filterEven filters even numbers
-func filterEven(in <-chan int) <-chan int {
-	out := make(chan int)
-	go func() {
-		defer close(out)
-		for n := range in {
-			if n%2 == 0 {
-				out <- n
-			}
-		}
-	}()
-	return out
-}
-
-// channelOrPattern demonstrates "or" pattern for multiple channels
+// channelOrPattern demonstrates "or" pattern for multiple channels: the
+// first of several sources to answer wins, and the rest are cancelled
 func channelOrPattern() {
-	or := func(channels ...<-chan interface{}) <-chan interface{} {
-		out := make(chan interface{})
-		var wg sync.WaitGroup
-
-		for _, ch := range channels {
-			wg.Add(1)
-			go func(c <-chan interface{}) {
-				defer wg.Done()
-				for val := range c {
-					select {
-					case out <- val:
-					case <-out:
-						return
-					}
-				}
-			}(ch)
-		}
-
-		go func() {
-			wg.Wait()
-			close(out)
-		}()
-
-		return out
+	source := func(name string, delay time.Duration) *future.Future[string] {
+		return future.Go(context.Background(), func(ctx context.Context) (string, error) {
+			select {
+			case <-time.After(delay):
+				return "from " + name, nil
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		})
 	}
 
-	ch1 := make(chan interface{})
-	ch2 := make(chan interface{})
-	ch3 := make(chan interface{})
-
-	go func() {
-		time.Sleep(100 * time.Millisecond)
-		ch1 <- "from ch1"
-		close(ch1)
-	}()
-
-	go func() {
-		time.Sleep(200 * time.Millisecond)
-		ch2 <- "from ch2"
-		close(ch2)
-	}()
+	ch1 := source("ch1", 100*time.Millisecond)
+	ch2 := source("ch2", 200*time.Millisecond)
+	ch3 := source("ch3", 50*time.Millisecond)
 
-	go func() {
-		time.Sleep(50 * time.Millisecond)
-		ch3 <- "from ch3"
-		close(ch3)
-	}()
-
-	merged := or(ch1, ch2, ch3)
 	fmt.Println("Or pattern results:")
-	for val := range merged {
+	if val, err := future.Race(ch1, ch2, ch3).Await(context.Background()); err == nil {
 		fmt.Printf("  Received: %v\n", val)
 	}
+	_, err := ch2.Await(context.Background())
+	fmt.Printf("  Slowest source: %v\n", err)
+
+	// All waits for every source, in order, and Then transforms the result
+	all := future.All(source("a", 10*time.Millisecond), source("b", 5*time.Millisecond))
+	joined := future.Then(all, func(vals []string) (string, error) {
+		return strings.Join(vals, ", "), nil
+	})
+	if val, err := joined.Await(context.Background()); err == nil {
+		fmt.Printf("  All: %s\n", val)
+	}
 }
 
 // channelMergePattern demonstrates merging multiple channels
@@ -185,69 +158,57 @@ func channelMergePattern() {
 
 // channelBroadcast demonstrates broadcast pattern
 func channelBroadcast() {
-	type Broadcaster struct {
-		mu        sync.RWMutex
-		listeners []chan string
-	}
+	topic := pubsub.NewTopic[string]()
+	ctx := context.Background()
 
-	broadcaster := &Broadcaster{
-		listeners: make([]chan string, 0),
-	}
+	// Subscribe listeners, each choosing what happens when it falls behind
+	ch1 := topic.Subscribe(pubsub.Options{Buffer: 4, Policy: pubsub.Block})
+	ch2 := topic.Subscribe(pubsub.Options{Buffer: 1, Policy: pubsub.DropOldest})
+	ch3 := topic.Subscribe(pubsub.Options{Buffer: 1, Policy: pubsub.CloseSlow})
 
-	subscribe := func(b *Broadcaster) <-chan string {
-		b.mu.Lock()
-		defer b.mu.Unlock()
-
-		ch := make(chan string, 1)
-		b.listeners = append(b.listeners, ch)
-		return ch
-	}
+	// Broadcast messages
+	topic.Publish(ctx, "Hello, subscribers!")
+	topic.Publish(ctx, "Second message")
 
-	broadcast := func(b *Broadcaster, message string) {
-		b.mu.RLock()
-		defer b.mu.RUnlock()
-
-		for _, listener := range b.listeners {
-			select {
-			case listener <- message:
-			default:
-				// Skip if listener is full
+	// Receive from listeners until each runs dry
+	fmt.Println("Broadcast results:")
+	for i, sub := range []*pubsub.Subscription[string]{ch1, ch2, ch3} {
+		for {
+			waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			msg, err := sub.Receive(waitCtx)
+			cancel()
+			if err != nil {
+				fmt.Printf("  Listener %d: done (%v)\n", i+1, err)
+				break
 			}
+			fmt.Printf("  Listener %d: %s\n", i+1, msg)
 		}
 	}
 
-	// Subscribe listeners
-	ch1 := subscribe(broadcaster)
-	ch2 := subscribe(broadcaster)
-	ch3 := subscribe(broadcaster)
-
-	// Broadcast message
-	broadcast(broadcaster, "Hello, subscribers!")
-
-	// Receive from listeners
-	fmt.Println("Broadcast results:")
-	fmt.Printf("  Listener 1: %s\n", <-ch1)
-	fmt.Printf("  Listener 2: %s\n", <-ch2)
-	fmt.Printf("  Listener 3: %s\n", <-ch3)
+	// Unsubscribed listeners stop receiving
+	ch1.Unsubscribe()
+	topic.Publish(ctx, "After unsubscribe")
+	stats := topic.Stats()
+	fmt.Printf("  Subscribers: %d, published: %d, delivered: %d, dropped: %d, evicted: %d\n",
+		stats.Subscribers, stats.Published, stats.Delivered, stats.Dropped, stats.Evicted)
 }
 
 // channelTimeout demonstrates timeout patterns
 func channelTimeout() {
-	// Operation with timeout
-	doWork := func() <-chan string {
-		result := make(chan string, 1)
-		go func() {
-			time.Sleep(2 * time.Second)
-			result <- "work completed"
-		}()
-		return result
-	}
+	// Operation with timeout: the future is cancelled when it times out
+	work := future.Go(context.Background(), func(ctx context.Context) (string, error) {
+		select {
+		case <-time.After(2 * time.Second):
+			return "work completed", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
 
-	select {
-	case result := <-doWork():
+	if result, err := future.WithTimeout(work, 1*time.Second).Await(context.Background()); err == nil {
 		fmt.Printf("Work result: %s\n", result)
-	case <-time.After(1 * time.Second):
-		fmt.Println("Work timed out")
+	} else {
+		fmt.Printf("Work timed out: %v\n", err)
 	}
 
 	// Context-based timeout
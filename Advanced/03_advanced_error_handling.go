@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"os"
 	"runtime"
-	"strings"
-	"sync"
 	"time"
+
+	"hellogolang/Advanced/errorsx"
+	"hellogolang/Advanced/logx"
+	"hellogolang/Advanced/metrics"
+	"hellogolang/Advanced/retry"
 )
 
 // Advanced Error Handling demonstrates advanced error handling patterns
@@ -19,6 +23,7 @@ func main() {
 	errorRecovery()
 	errorLogging()
 	errorMetrics()
+	advancedErrorPatterns()
 }
 
 // errorWrapping demonstrates error wrapping and unwrapping
@@ -132,6 +137,15 @@ func customErrorTypes() {
 	if tempErr.Temporary() {
 		fmt.Printf("Temporary error, retry after %d seconds\n", tempErr.RetryAfter)
 	}
+
+	// Coded error with a category that maps to HTTP and gRPC status codes
+	codedErr := errorsx.Wrap(errors.New("network unreachable"), errorsx.Unavailable, "DB_001",
+		"user store unreachable", "host", "db-1", "attempt", 3).WithStack()
+	err := fmt.Errorf("fetch user: %w", codedErr)
+	fmt.Printf("Coded error: %v\n", err)
+	fmt.Printf("Code %s, category %v, HTTP %d, gRPC %v\n",
+		errorsx.CodeOf(err), errorsx.CategoryOf(err), errorsx.HTTPStatusOf(err), errorsx.GRPCCodeOf(err))
+	fmt.Printf("Detailed:\n%+v\n", codedErr)
 }
 
 // ValidationError represents a validation error
@@ -213,125 +227,92 @@ func riskyCode() {
 
 // errorLogging demonstrates structured error logging
 func errorLogging() {
-	// Structured logging
-	logError := func(err error, context map[string]interface{}) {
-		var fields []string
-		fields = append(fields, fmt.Sprintf("error=%q", err.Error()))
-
-		for k, v := range context {
-			fields = append(fields, fmt.Sprintf("%s=%v", k, v))
-		}
-
-		log.Printf("[ERROR] %s", strings.Join(fields, " "))
-	}
-
-	err := errors.New("operation failed")
-	logError(err, map[string]interface{}{
-		"user_id":   123,
-		"operation": "transfer",
-		"amount":    100.0,
-		"timestamp": "2024-01-15T10:00:00Z",
-	})
-
-	// Error with stack trace
-	logErrorWithStack := func(err error) {
-		buf := make([]byte, 4096)
-		n := runtime.Stack(buf, false)
-		log.Printf("[ERROR] %v\nStack:\n%s", err, buf[:n])
-	}
-
-	logErrorWithStack(errors.New("critical error"))
+	// Structured logging, with the request ID added to every record
+	logger := logx.New(os.Stdout, logx.Options{Format: logx.JSON})
+	ctx := logx.WithRequestID(context.Background(), logx.NewRequestID())
+	ctx = logx.WithLogger(ctx, logger.With("operation", "transfer"))
+
+	logx.Error(ctx, "transfer failed", errors.New("operation failed"),
+		"user_id", 123,
+		"amount", 100.0,
+	)
+
+	// Error with its wrapped chain and stack trace
+	err := errorsx.Wrap(errors.New("disk full"), errorsx.Internal, "STO_001", "critical error").WithStack()
+	logx.Error(ctx, "write failed", fmt.Errorf("save ledger: %w", err))
 }
 
 // errorMetrics demonstrates error metrics and monitoring
 func errorMetrics() {
-	type ErrorMetrics struct {
-		counts map[string]int64
-		mu     sync.Mutex
-	}
+	registry := metrics.NewRegistry()
+	errorsTotal := registry.CounterVec("errors_total", "Errors by type and category", "type", "category")
 
-	metrics := &ErrorMetrics{
-		counts: make(map[string]int64),
-	}
-
-	recordError := func(metrics *ErrorMetrics, err error) {
-		metrics.mu.Lock()
-		defer metrics.mu.Unlock()
-
-		errorType := fmt.Sprintf("%T", err)
-		metrics.counts[errorType]++
+	recordError := func(err error) {
+		errorsTotal.With(fmt.Sprintf("%T", err), errorsx.CategoryOf(err).String()).Inc()
 	}
 
 	// Record some errors
-	recordError(metrics, &ValidationError{Field: "email", Message: "invalid"})
-	recordError(metrics, &BusinessError{Operation: "transfer", Reason: "insufficient funds"})
-	recordError(metrics, &ValidationError{Field: "password", Message: "too short"})
+	recordError(&ValidationError{Field: "email", Message: "invalid"})
+	recordError(&BusinessError{Operation: "transfer", Reason: "insufficient funds"})
+	recordError(&ValidationError{Field: "password", Message: "too short"})
+	recordError(errorsx.New(errorsx.NotFound, "USR_404", "user not found"))
 
-	// Get metrics
-	metrics.mu.Lock()
+	// Expose metrics in the Prometheus text format, as registry.Handler()
+	// serves them to a scraper
 	fmt.Println("Error metrics:")
-	for errType, count := range metrics.counts {
-		fmt.Printf("  %s: %d\n", errType, count)
+	if err := registry.WriteText(os.Stdout); err != nil {
+		fmt.Printf("Write metrics: %v\n", err)
 	}
-	metrics.mu.Unlock()
 }
 
 // Advanced error handling patterns
 func advancedErrorPatterns() {
-	// Pattern 1: Error aggregation
-	type ErrorList []error
-
-	aggregateErrors := func(errs []error) error {
-		if len(errs) == 0 {
-			return nil
-		}
-		if len(errs) == 1 {
-			return errs[0]
-		}
-
-		var messages []string
-		for _, err := range errs {
-			messages = append(messages, err.Error())
-		}
-		return fmt.Errorf("multiple errors: %s", strings.Join(messages, "; "))
+	// Pattern 1: Error aggregation, keeping each error's chain intact
+	var errs error
+	errs = errorsx.Append(errs, errors.New("error 1"))
+	errs = errorsx.Append(errs, fmt.Errorf("error 2: %w", &ValidationError{Field: "email", Message: "invalid", Code: "VAL_001"}))
+	errs = errorsx.Append(errs, nil, &BusinessError{Operation: "transfer", Reason: "insufficient funds", Code: "BIZ_001"})
+
+	fmt.Printf("Aggregated error: %v\n", errs)
+	var validationErr *ValidationError
+	if errors.As(errs, &validationErr) {
+		fmt.Printf("Found validation error on field '%s' among %d errors\n", validationErr.Field, len(errorsx.Errors(errs)))
 	}
-
-	errs := []error{
-		errors.New("error 1"),
-		errors.New("error 2"),
-		errors.New("error 3"),
+	businessOnly := errorsx.Filter(errs, func(err error) bool {
+		var businessErr *BusinessError
+		return errors.As(err, &businessErr)
+	})
+	fmt.Printf("Business errors only: %v\n", businessOnly)
+
+	// Pattern 2: Error retry with exponential backoff and jitter, retrying
+	// only temporary errors
+	policy := retry.Policy{
+		MaxAttempts:  4,
+		InitialDelay: 10 * time.Millisecond,
+		Jitter:       retry.EqualJitter,
+		RetryIf:      isRetryable,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			fmt.Printf("Attempt %d failed (%v), retrying in %v\n", attempt, err, delay.Round(time.Millisecond))
+		},
 	}
 
-	aggErr := aggregateErrors(errs)
-	fmt.Printf("Aggregated error: %v\n", aggErr)
-
-	// Pattern 2: Error retry with exponential backoff
-	retryWithBackoff := func(operation func() error, maxRetries int) error {
-		for i := 0; i < maxRetries; i++ {
-			err := operation()
-			if err == nil {
-				return nil
-			}
-
-			// Check if error is retryable
-			if !isRetryable(err) {
-				return err
-			}
-
-			// Exponential backoff
-			backoff := time.Duration(1<<uint(i)) * 100 * time.Millisecond
-			time.Sleep(backoff)
+	calls := 0
+	err := retry.Do(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return &TemporaryError{Message: "service temporarily unavailable", RetryAfter: 1}
 		}
-		return fmt.Errorf("operation failed after %d retries", maxRetries)
-	}
+		return nil
+	})
+	fmt.Printf("Retry result after %d calls: %v\n", calls, err)
 
-	_ = retryWithBackoff(func() error {
-		return errors.New("temporary failure")
-	}, 3)
+	err = retry.Do(context.Background(), policy, func(ctx context.Context) error {
+		return errors.New("permanent failure")
+	})
+	fmt.Printf("Non-retryable error: %v\n", err)
 }
 
 // isRetryable checks if error is retryable
 func isRetryable(err error) bool {
-	var tempErr *TemporaryError
-	return errors.As(err, &tempErr)
+	return retry.Temporary(err)
 }
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
-	"sync"
+
+	"hellogolang/Advanced/caches"
+	"hellogolang/Advanced/xslices"
 )
 
 // Advanced Generics demonstrates advanced generic patterns and techniques
@@ -208,51 +210,42 @@ func genericReflection() {
 	numbers := []int{1, 2, 3, 4, 5}
 	
 	// Map: square each number
-	squared := mapSliceGeneric(numbers, func(n int) int {
+	squared := xslices.Map(numbers, func(n int) int {
 		return n * n
 	})
 	fmt.Printf("Squared: %v\n", squared)
 	
 	// Filter: even numbers
-	evens := filterSliceGeneric(numbers, func(n int) bool {
+	evens := xslices.Filter(numbers, func(n int) bool {
 		return n%2 == 0
 	})
 	fmt.Printf("Evens: %v\n", evens)
 	
 	// Reduce: sum
-	sum := reduceGeneric(numbers, 0, func(acc, n int) int {
+	sum := xslices.Reduce(numbers, 0, func(acc, n int) int {
 		return acc + n
 	})
 	fmt.Printf("Sum: %d\n", sum)
-}
-
-// mapSliceGeneric maps slice elements
-func mapSliceGeneric[T any, U any](slice []T, fn func(T) U) []U {
-	result := make([]U, len(slice))
-	for i, v := range slice {
-		result[i] = fn(v)
-	}
-	return result
-}
 
-// filterSliceGeneric filters slice elements
-func filterSliceGeneric[T any](slice []T, predicate func(T) bool) []T {
-	var result []T
-	for _, v := range slice {
-		if predicate(v) {
-			result = append(result, v)
+	// Group and partition
+	byParity := xslices.GroupBy(numbers, func(n int) string {
+		if n%2 == 0 {
+			return "even"
 		}
+		return "odd"
+	})
+	small, large := xslices.Partition(numbers, func(n int) bool { return n < 3 })
+	fmt.Printf("Grouped: %v, partitioned: %v / %v\n", byParity, small, large)
+	fmt.Printf("Chunks: %v, zipped: %v, unique: %v\n",
+		xslices.Chunk(numbers, 2), xslices.Zip(numbers, []string{"a", "b", "c"}), xslices.Unique([]int{3, 1, 3, 2, 1}))
+
+	// Lazy pipeline: nothing runs until the range, which stops after three
+	// odd squares although the naturals never end
+	squares := xslices.MapSeq(xslices.Naturals(), func(n int) int { return n * n })
+	oddSquares := xslices.FilterSeq(squares, func(n int) bool { return n%2 == 1 })
+	for v := range xslices.Take(oddSquares, 3) {
+		fmt.Printf("Odd square: %d\n", v)
 	}
-	return result
-}
-
-// reduceGeneric reduces slice to single value
-func reduceGeneric[T any, U any](slice []T, initial U, fn func(U, T) U) U {
-	result := initial
-	for _, v := range slice {
-		result = fn(result, v)
-	}
-	return result
 }
 
 // genericPerformance demonstrates performance considerations
@@ -265,8 +258,8 @@ func genericPerformance() {
 	index := binarySearchGeneric(sortedInts, 7)
 	fmt.Printf("Found 7 at index: %d\n", index)
 	
-	// Generic cache with type safety
-	cache := NewCache[string, int]()
+	// Generic cache with type safety, instantiated per key and value type
+	cache := caches.NewLRU[string, int](caches.Options{MaxEntries: 100})
 	cache.Set("one", 1)
 	cache.Set("two", 2)
 	
@@ -295,31 +288,3 @@ func binarySearchGeneric[T interface{ ~int | ~float64 }](slice []T, target T) in
 	return -1
 }
 
-// Cache is a generic cache implementation
-type Cache[K comparable, V any] struct {
-	data map[K]V
-	mu   sync.RWMutex
-}
-
-// NewCache creates a new cache
-func NewCache[K comparable, V any]() *Cache[K, V] {
-	return &Cache[K, V]{
-		data: make(map[K]V),
-	}
-}
-
-// Get retrieves a value from cache
-func (c *Cache[K, V]) Get(key K) (V, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	val, ok := c.data[key]
-	return val, ok
-}
-
-// Set stores a value in cache
-func (c *Cache[K, V]) Set(key K, value V) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.data[key] = value
-}
-
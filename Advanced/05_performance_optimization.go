@@ -1,12 +1,23 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"runtime"
 	"strings"
 	"sync"
 	"time"
 	"unsafe"
+
+	"hellogolang/Advanced/bincodec"
+	"hellogolang/Advanced/caches"
+	"hellogolang/Advanced/collections"
+	"hellogolang/Advanced/pool"
+	"hellogolang/Advanced/workerpool"
 )
 
 // Performance Optimization demonstrates optimization techniques
@@ -16,7 +27,9 @@ func main() {
 	cpuOptimization()
 	allocationOptimization()
 	cachingPatterns()
+	shardedMapPattern()
 	poolingPatterns()
+	serializationFormats()
 	profilingTechniques()
 }
 
@@ -163,115 +176,105 @@ func allocationOptimization() {
 	processGood(testData)
 }
 
-// cachingPatterns demonstrates caching patterns
+// cachingPatterns demonstrates caching patterns with the caches package
 func cachingPatterns() {
-	// 1. Simple in-memory cache with TTL
-	type CacheEntry struct {
-		Value     interface{}
-		ExpiresAt time.Time
+	// 1. Cache with TTL: entries expire a fixed time after they are set
+	ttlCache := caches.NewLRU[string, string](caches.Options{TTL: 5 * time.Second})
+	ttlCache.Set("key1", "value1")
+	if val, ok := ttlCache.Get("key1"); ok {
+		fmt.Printf("Cache hit: %v\n", val)
 	}
 
-	cache := make(map[string]*CacheEntry)
-	var mu sync.RWMutex
-
-	get := func(key string) (interface{}, bool) {
-		mu.RLock()
-		defer mu.RUnlock()
-
-		entry, ok := cache[key]
-		if !ok {
-			return nil, false
+	// 2. Bounded caches evict by policy once full: least recently used,
+	// least frequently used, or ARC adapting between the two
+	lru := caches.NewLRU[int, int](caches.Options{MaxEntries: 100})
+	lfu := caches.NewLFU[int, int](caches.Options{MaxEntries: 100})
+	arc := caches.NewARC[int, int](caches.Options{MaxEntries: 100})
+	for i := range 10000 {
+		// A hot set of 80 keys in scrambled order, interleaved with a scan
+		// of keys used once
+		key := i * 2654435761 >> 8 % 80
+		if i%2 == 0 {
+			key = 1000 + i
 		}
-
-		if time.Now().After(entry.ExpiresAt) {
-			return nil, false
-		}
-
-		return entry.Value, true
-	}
-
-	set := func(key string, value interface{}, ttl time.Duration) {
-		mu.Lock()
-		defer mu.Unlock()
-
-		cache[key] = &CacheEntry{
-			Value:     value,
-			ExpiresAt: time.Now().Add(ttl),
+		for _, c := range []caches.Cache[int, int]{lru, lfu, arc} {
+			if _, ok := c.Get(key); !ok {
+				c.Set(key, key*key)
+			}
 		}
 	}
-
-	// Use cache
-	set("key1", "value1", 5*time.Second)
-	if val, ok := get("key1"); ok {
-		fmt.Printf("Cache hit: %v\n", val)
-	}
-
-	// 2. LRU Cache implementation
-	type LRUNode struct {
-		Key   string
-		Value interface{}
-		Prev  *LRUNode
-		Next  *LRUNode
-	}
-
-	type LRUCache struct {
-		capacity int
-		cache    map[string]*LRUNode
-		head     *LRUNode
-		tail     *LRUNode
-		mu       sync.Mutex
+	fmt.Printf("Hit rates with a scan: LRU %.2f, LFU %.2f, ARC %.2f\n",
+		lru.Stats().HitRate(), lfu.Stats().HitRate(), arc.Stats().HitRate())
+
+	// 3. Thread-safe cache shared between goroutines
+	shared := caches.NewSynchronized[int, int](caches.NewLRU[int, int](caches.Options{MaxEntries: 10}))
+	var wg sync.WaitGroup
+	for worker := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				shared.GetOrSet((worker+i)%20, func(k int) (int, error) { return k * 2, nil })
+			}
+		}()
 	}
+	wg.Wait()
+	fmt.Printf("Shared cache: %d entries, %d lookups\n", shared.Len(), shared.Stats().Hits+shared.Stats().Misses)
+}
 
-	newLRU := func(capacity int) *LRUCache {
-		return &LRUCache{
-			capacity: capacity,
-			cache:    make(map[string]*LRUNode),
-		}
+// shardedMapPattern demonstrates reducing lock contention by sharding
+func shardedMapPattern() {
+	// One lock per shard: goroutines updating keys in different shards
+	// do not wait for each other, as they would behind a single mutex
+	hits := collections.NewConcurrentMap[string, int](collections.MapOptions{Shards: 16})
+	pages := []string{"/", "/login", "/search", "/cart", "/checkout"}
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Go(func() {
+			for i := range 1000 {
+				page := pages[(w+i)%len(pages)]
+				// Compute reads and writes the entry atomically, so no
+				// increment is lost
+				hits.Compute(page, func(old int, _ bool) (int, bool) { return old + 1, true })
+			}
+		})
 	}
-
-	_ = newLRU(10)
+	wg.Wait()
+
+	// GetOrCompute computes a missing value once, however many goroutines
+	// ask for it at the same time
+	session, loaded := hits.GetOrCompute("/session", func() int { return 1 })
+	fmt.Printf("Session entry: %d (already present: %t)\n", session, loaded)
+
+	// Range copies one shard at a time, never holding every lock at once
+	total := 0
+	hits.Range(func(page string, n int) bool {
+		total += n
+		return true
+	})
+	st := hits.Stats()
+	fmt.Printf("Pages: %d, total hits: %d, shards: %d, largest shard: %d entries\n", st.Len, total, st.Shards, st.MaxShardLen)
 }
 
 // poolingPatterns demonstrates object pooling patterns
 func poolingPatterns() {
-	// 1. Worker pool for goroutines
-	type WorkerPool struct {
-		workers   int
-		taskQueue chan func()
-		wg        sync.WaitGroup
-	}
-
-	newWorkerPool := func(workers int) *WorkerPool {
-		wp := &WorkerPool{
-			workers:   workers,
-			taskQueue: make(chan func(), 100),
-		}
-
-		for i := 0; i < workers; i++ {
-			wp.wg.Add(1)
-			go func() {
-				defer wp.wg.Done()
-				for task := range wp.taskQueue {
-					task()
-				}
-			}()
-		}
-
-		return wp
-	}
-
-	pool := newWorkerPool(5)
+	// 1. Worker pool for goroutines: under the Reject policy a full queue
+	// fails fast instead of making the caller wait
+	workers := workerpool.New[int](workerpool.Options{MaxWorkers: 5, QueueSize: 100, Policy: workerpool.Reject})
 
 	// Submit tasks
 	for i := 0; i < 10; i++ {
 		taskID := i
-		pool.taskQueue <- func() {
+		if _, err := workers.Submit(context.Background(), func(context.Context) (int, error) {
 			fmt.Printf("Task %d executed\n", taskID)
+			return taskID, nil
+		}); err != nil {
+			fmt.Printf("Task %d not queued: %v\n", taskID, err)
 		}
 	}
 
-	close(pool.taskQueue)
-	pool.wg.Wait()
+	workers.Shutdown(context.Background())
 
 	// 2. Buffer pool
 	bufferPool := sync.Pool{
@@ -289,6 +292,133 @@ func poolingPatterns() {
 
 	// Return buffer
 	bufferPool.Put(buf)
+
+	// 3. Object pool for expensive values: unlike sync.Pool, it bounds the
+	// connections open at once, retires old ones, checks each one's health
+	// before reuse, and makes callers wait while all are in use
+	type conn struct {
+		id      int
+		healthy bool
+	}
+	dialed := 0
+	conns := pool.New(pool.Options[*conn]{
+		New: func(context.Context) (*conn, error) {
+			dialed++
+			return &conn{id: dialed, healthy: true}, nil
+		},
+		Destroy: func(c *conn) { fmt.Printf("Connection %d closed\n", c.id) },
+		Validate: func(_ context.Context, c *conn) error {
+			if !c.healthy {
+				return errors.New("connection reset")
+			}
+			return nil
+		},
+		MaxActive:   2,
+		MaxIdle:     2,
+		MaxLifetime: time.Minute,
+	})
+	defer conns.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	first, _ := conns.Get(ctx)
+	second, _ := conns.Get(ctx)
+	if _, err := conns.Get(ctx); err != nil {
+		fmt.Printf("Third Get while 2 are in use: %v\n", err)
+	}
+	first.Value.healthy = false // Broken while idle, so never handed out again
+	first.Release()
+	second.Release()
+	for range 2 {
+		c, err := conns.Get(context.Background())
+		if err != nil {
+			fmt.Printf("Get failed: %v\n", err)
+			continue
+		}
+		fmt.Printf("Got connection %d\n", c.Value.id)
+		defer c.Release()
+	}
+	stats := conns.Stats()
+	fmt.Printf("Connections created: %d, invalid: %d, waits: %d\n", stats.Created, stats.Invalid, stats.Waits)
+}
+
+// Order is a message with numbered fields for bincodec
+type Order struct {
+	ID       uint64            `bin:"1" json:"id"`
+	Customer string            `bin:"2" json:"customer"`
+	Items    []string          `bin:"3" json:"items"`
+	Cents    []int64           `bin:"4" json:"cents"`
+	Placed   time.Time         `bin:"5" json:"placed"`
+	Notes    map[string]string `bin:"6" json:"notes"`
+}
+
+// OrderV2 is a later version of Order: ID is narrowed, Items is gone,
+// and Coupon is new
+type OrderV2 struct {
+	ID       uint32  `bin:"1"`
+	Customer string  `bin:"2"`
+	Cents    []int32 `bin:"4"`
+	Coupon   *string `bin:"7"`
+}
+
+// serializationFormats compares the size and speed of binary encoding
+// with encoding/json and encoding/gob, and shows data moving between
+// versions of a type
+func serializationFormats() {
+	order := Order{
+		ID:       1042,
+		Customer: "Alice",
+		Items:    []string{"keyboard", "mouse"},
+		Cents:    []int64{4999, 1999},
+		Placed:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Notes:    map[string]string{"gift": "yes"},
+	}
+
+	// A Codec compiles the type once; Append reuses one buffer
+	codec := bincodec.NewCodec[Order]()
+	const rounds = 20000
+	var buf []byte
+	start := time.Now()
+	for range rounds {
+		buf, _ = codec.Append(buf[:0], &order)
+	}
+	binTime := time.Since(start)
+
+	var jsonData []byte
+	start = time.Now()
+	for range rounds {
+		jsonData, _ = json.Marshal(&order)
+	}
+	jsonTime := time.Since(start)
+
+	var gobData bytes.Buffer
+	start = time.Now()
+	for range rounds {
+		gobData.Reset()
+		gob.NewEncoder(&gobData).Encode(&order)
+	}
+	gobTime := time.Since(start)
+
+	fmt.Printf("bincodec: %d bytes, %v per message\n", len(buf), binTime/rounds)
+	fmt.Printf("json:     %d bytes, %v per message\n", len(jsonData), jsonTime/rounds)
+	fmt.Printf("gob:      %d bytes, %v per message\n", gobData.Len(), gobTime/rounds)
+
+	var decoded Order
+	if err := codec.Unmarshal(buf, &decoded); err != nil {
+		fmt.Printf("Unmarshal: %v\n", err)
+		return
+	}
+	fmt.Printf("Decoded: %d %s %v %v\n", decoded.ID, decoded.Customer, decoded.Items, decoded.Placed.Format(time.DateOnly))
+
+	// Newer code skips fields it no longer has and leaves new ones unset
+	var v2 OrderV2
+	err := bincodec.Unmarshal(buf, &v2)
+	fmt.Printf("As OrderV2: %+v, coupon set: %t, error: %v\n", v2.Cents, v2.Coupon != nil, err)
+
+	// A value too large for a narrowed field is an error, not truncation
+	order.ID = 1 << 40
+	data, _ := bincodec.Marshal(&order)
+	fmt.Printf("Overflow: %v\n", bincodec.Unmarshal(data, &v2))
 }
 
 // profilingTechniques demonstrates profiling techniques
@@ -1,8 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"os"
 	"sync"
+
+	"hellogolang/Advanced/caches"
+	"hellogolang/Advanced/di"
+	"hellogolang/Advanced/eval"
+	"hellogolang/Advanced/eventbus"
+	"hellogolang/Advanced/fsm"
+	"hellogolang/Advanced/logx"
+	"hellogolang/Advanced/metrics"
 )
 
 // Design Patterns demonstrates common design patterns in Go
@@ -12,8 +23,11 @@ func main() {
 	factoryPattern()
 	builderPattern()
 	observerPattern()
+	stateMachinePattern()
+	dependencyInjectionPattern()
 	strategyPattern()
 	adapterPattern()
+	interpreterPattern()
 }
 
 // singletonPattern demonstrates singleton pattern
@@ -145,52 +159,179 @@ func builderPattern() {
 
 // observerPattern demonstrates observer pattern
 func observerPattern() {
-	// Observer interface
-	type Observer interface {
-		Update(message string)
-	}
-	
-	// Subject
-	type Subject struct {
-		observers []Observer
-		mu        sync.Mutex
-	}
-	
-	attach := func(s *Subject, observer Observer) {
-		s.mu.Lock()
-		defer s.mu.Unlock()
-		s.observers = append(s.observers, observer)
-	}
-	
-	notify := func(s *Subject, message string) {
-		s.mu.Lock()
-		observers := make([]Observer, len(s.observers))
-		copy(observers, s.observers)
-		s.mu.Unlock()
-		
-		for _, observer := range observers {
-			observer.Update(message)
-		}
-	}
-	
-	subject := &Subject{}
+	// The bus is the subject: observers subscribe to an event type, and
+	// the subject publishes without knowing who listens
+	bus := eventbus.New(eventbus.Options{
+		Middleware: []eventbus.Middleware{eventbus.Recover()},
+	})
+
 	observer1 := &ConcreteObserver{name: "Observer1"}
 	observer2 := &ConcreteObserver{name: "Observer2"}
-	
-	attach(subject, observer1)
-	attach(subject, observer2)
-	
-	notify(subject, "State changed")
+	sub1 := eventbus.Subscribe(bus, observer1.Update)
+	eventbus.Subscribe(bus, observer2.Update)
+
+	eventbus.Publish(context.Background(), bus, StateChanged{From: "draft", To: "published"})
+
+	// A detached observer hears nothing more
+	sub1.Unsubscribe()
+	eventbus.Publish(context.Background(), bus, StateChanged{From: "published", To: "archived"})
+
+	// Asynchronously, each event type is delivered in order by its own
+	// goroutine, and Shutdown waits for those queued
+	async := eventbus.New(eventbus.Options{Mode: eventbus.Async})
+	eventbus.Subscribe(async, observer1.Update)
+	for _, to := range []string{"review", "approved", "live"} {
+		eventbus.Publish(context.Background(), async, StateChanged{To: to})
+	}
+	async.Shutdown(context.Background())
+}
+
+// StateChanged is the event observers are notified of
+type StateChanged struct {
+	From, To string
 }
 
-// ConcreteObserver implements Observer
+// ConcreteObserver observes state changes
 type ConcreteObserver struct {
 	name string
 }
 
-// Update implements Observer interface
-func (o *ConcreteObserver) Update(message string) {
-	fmt.Printf("Observer %s received: %s\n", o.name, message)
+// Update handles a state change
+func (o *ConcreteObserver) Update(ctx context.Context, e StateChanged) error {
+	fmt.Printf("Observer %s received: state changed %q -> %q\n", o.name, e.From, e.To)
+	return nil
+}
+
+// OrderState is a state of an order's workflow
+type OrderState string
+
+// OrderEvent moves an order between states
+type OrderEvent string
+
+// stateMachinePattern demonstrates a state machine for a workflow
+func stateMachinePattern() {
+	inStock := false
+	orders, err := fsm.Define(fsm.Config[OrderState, OrderEvent]{
+		Initial: "pending",
+		States: map[OrderState]fsm.State[OrderState, OrderEvent]{
+			"shipped": {OnEnter: func(ctx context.Context, c fsm.Change[OrderState, OrderEvent]) error {
+				fmt.Println("  Sending tracking email")
+				return nil
+			}},
+		},
+		Transitions: []fsm.Transition[OrderState, OrderEvent]{
+			{From: []OrderState{"pending"}, Event: "pay", To: "paid"},
+			{From: []OrderState{"paid"}, Event: "ship", To: "shipped",
+				Guard: func(ctx context.Context, c fsm.Change[OrderState, OrderEvent]) bool { return inStock }},
+			{From: []OrderState{"shipped"}, Event: "deliver", To: "delivered"},
+			{From: []OrderState{"pending", "paid"}, Event: "cancel", To: "cancelled"},
+		},
+		OnTransition: func(ctx context.Context, c fsm.Change[OrderState, OrderEvent]) {
+			fmt.Printf("Order %s -> %s on %s\n", c.From, c.To, c.Event)
+		},
+	})
+	if err != nil {
+		fmt.Printf("Define: %v\n", err)
+		return
+	}
+
+	ctx := context.Background()
+	order := orders.New()
+	order.Fire(ctx, "pay")
+	if _, err := order.Fire(ctx, "ship"); err != nil {
+		fmt.Printf("Ship: %v\n", err)
+	}
+	inStock = true
+	order.Fire(ctx, "ship")
+	if _, err := order.Fire(ctx, "cancel"); err != nil {
+		fmt.Printf("Cancel: %v\n", err)
+	}
+
+	// Events fired asynchronously still run one at a time, in order
+	delivery := order.FireAsync(ctx, "deliver")
+	if state, err := delivery.Await(ctx); err == nil {
+		fmt.Printf("Final state: %s, events left: %v\n", state, orders.Events(state))
+	}
+
+	// The machine renders for Graphviz: go run ... | dot -Tsvg
+	fmt.Print(order.DOT("order"))
+}
+
+// UserService looks users up through a cache, logging and counting
+type UserService struct {
+	logger *slog.Logger
+	cache  *caches.Synchronized[int, string]
+	hits   *metrics.CounterVec
+}
+
+// Start warms the cache
+func (s *UserService) Start(ctx context.Context) error {
+	s.cache.Set(1, "alice")
+	s.logger.InfoContext(ctx, "user service started")
+	return nil
+}
+
+// Stop reports the cache's hit rate
+func (s *UserService) Stop(ctx context.Context) error {
+	s.logger.InfoContext(ctx, "user service stopped", "hit_rate", s.cache.Stats().HitRate())
+	return nil
+}
+
+// Name returns the name of a user
+func (s *UserService) Name(id int) (string, bool) {
+	name, ok := s.cache.Get(id)
+	s.hits.With(fmt.Sprint(ok)).Inc()
+	return name, ok
+}
+
+// dependencyInjectionPattern demonstrates wiring the logger, metrics and
+// cache packages together with a dependency injection container
+func dependencyInjectionPattern() {
+	c := di.New()
+	di.Provide(c, di.Singleton, func(r di.Resolver) (*slog.Logger, error) {
+		return logx.New(os.Stdout, logx.Options{}), nil
+	})
+	di.Provide(c, di.Singleton, func(r di.Resolver) (*metrics.Registry, error) {
+		return metrics.NewRegistry(), nil
+	})
+	di.Provide(c, di.Singleton, func(r di.Resolver) (*caches.Synchronized[int, string], error) {
+		return caches.NewSynchronized[int, string](caches.NewLRU[int, string](caches.Options{MaxEntries: 100})), nil
+	})
+	di.Provide(c, di.Singleton, func(r di.Resolver) (*UserService, error) {
+		registry, err := di.Resolve[*metrics.Registry](r)
+		if err != nil {
+			return nil, err
+		}
+		return &UserService{
+			logger: di.MustResolve[*slog.Logger](r).With("component", "users"),
+			cache:  di.MustResolve[*caches.Synchronized[int, string]](r),
+			hits:   registry.CounterVec("user_cache_lookups_total", "User lookups by hit", "hit"),
+		}, nil
+	})
+
+	// Start builds every singleton, dependencies first, and starts them
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		fmt.Printf("Start: %v\n", err)
+		return
+	}
+	users := di.MustResolve[*UserService](c)
+	for _, id := range []int{1, 2} {
+		name, ok := users.Name(id)
+		fmt.Printf("User %d: %q, found %t\n", id, name, ok)
+	}
+	di.MustResolve[*metrics.Registry](c).WriteText(os.Stdout)
+	c.Stop(ctx)
+
+	// A missing dependency is reported with the chain that needed it
+	broken := di.New()
+	di.Provide(broken, di.Transient, func(r di.Resolver) (*UserService, error) {
+		_, err := di.Resolve[*slog.Logger](r)
+		return nil, err
+	})
+	if _, err := di.Resolve[*UserService](broken); err != nil {
+		fmt.Printf("Resolve: %v\n", err)
+	}
 }
 
 // SortStrategy interface
@@ -299,3 +440,50 @@ func newAdapter(adaptee *Adaptee) *Adapter {
 func (a *Adapter) Request() string {
 	return a.adaptee.specificRequest()
 }
+
+// interpreterPattern demonstrates the interpreter pattern: pricing rules
+// kept in configuration are compiled once and evaluated for each order
+func interpreterPattern() {
+	rules := eval.New()
+	rules.Register("tier", 1, func(args []any) (any, error) {
+		if total, ok := args[0].(float64); ok && total >= 500 {
+			return "gold", nil
+		}
+		return "standard", nil
+	})
+
+	config := map[string]string{
+		"free_shipping": `total >= 100 && (country == "DE" || country == "FR")`,
+		"discount":      `tier(total) == "gold" ? total * 0.1 : 0`,
+		"fraud_check":   `items > 20 or total / max(items, 1) > 1000`,
+		"vip":           `tier(total, items) == "gold"`,
+	}
+
+	programs := make(map[string]*eval.Program)
+	for _, name := range []string{"free_shipping", "discount", "fraud_check", "vip"} {
+		p, err := rules.Compile(config[name])
+		if err != nil {
+			// A bad rule is rejected when loaded, not for each order
+			fmt.Printf("Rule %s: %v\n", name, err)
+			continue
+		}
+		programs[name] = p
+		fmt.Printf("Rule %s reads %v\n", name, p.Vars())
+	}
+
+	orders := []map[string]any{
+		{"total": 120.0, "country": "DE", "items": 3},
+		{"total": 640.0, "country": "US", "items": 2},
+		{"total": 5000.0, "country": "FR", "items": 1},
+	}
+	for _, order := range orders {
+		shipping, _ := programs["free_shipping"].Bool(order)
+		discount, _ := programs["discount"].Float(order)
+		fraud, _ := programs["fraud_check"].Bool(order)
+		fmt.Printf("Order %v: free shipping %t, discount %.2f, review %t\n", order, shipping, discount, fraud)
+	}
+
+	if _, err := eval.Eval("1 / items", map[string]any{"items": 0}); err != nil {
+		fmt.Printf("Eval: %v\n", err)
+	}
+}
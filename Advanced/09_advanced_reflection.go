@@ -1,8 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"time"
+
+	"hellogolang/Advanced/config"
+	"hellogolang/Advanced/mapper"
+	"hellogolang/Advanced/validator"
 )
 
 // Advanced Reflection demonstrates advanced reflection techniques
@@ -11,7 +21,9 @@ func main() {
 	dynamicFunctionCalls()
 	structTagParsing()
 	typeValidation()
+	configurationLoading()
 	dynamicStructCreation()
+	objectMapping()
 	reflectionPerformance()
 }
 
@@ -98,84 +110,171 @@ func structTagParsing() {
 			fmt.Printf("    Validate tag: %s\n", validateTag)
 		}
 	}
+
+	// The validator package interprets the validate tags
+	user.Email = "alice"
+	if err := validator.Struct(user); err != nil {
+		fmt.Printf("Validate tags: %v\n", err)
+	}
 }
 
-// typeValidation demonstrates type validation
+// typeValidation demonstrates validating structs against their validate
+// tags
 func typeValidation() {
-	// Validate struct fields
-	type Config struct {
-		Host string `validate:"required"`
-		Port int    `validate:"required,min=1,max=65535"`
+	type Address struct {
+		City string `json:"city" validate:"required"`
+		Zip  string `json:"zip" validate:"len=5"`
 	}
-
-	config := Config{
-		Host: "localhost",
-		Port: 8080,
+	type Signup struct {
+		Username string            `json:"username" validate:"required,min=3,max=20,regexp=^[a-z0-9_]+$"`
+		Email    string            `json:"email" validate:"required,email"`
+		Age      int               `json:"age" validate:"min=13"`
+		Plan     string            `json:"plan" validate:"oneof=free pro team"`
+		Address  Address           `json:"address"`
+		Tags     []string          `json:"tags" validate:"max=3,dive,min=2"`
+		Referrer string            `json:"referrer" validate:"omitempty,username"`
+		Labels   map[string]string `json:"labels" validate:"dive,required"`
 	}
 
-	err := validateStruct(config)
-	if err != nil {
+	// Custom rules are registered by name and used like the built-in ones
+	v := validator.New()
+	v.Register("username", func(value reflect.Value, _ string) error {
+		if strings.HasPrefix(value.String(), "@") {
+			return nil
+		}
+		return errors.New("must start with @")
+	})
+
+	signup := Signup{
+		Username: "alice_01",
+		Email:    "alice@example.com",
+		Age:      30,
+		Plan:     "pro",
+		Address:  Address{City: "Lisbon", Zip: "10001"},
+		Tags:     []string{"go", "backend"},
+		Referrer: "@bob",
+	}
+	if err := v.Struct(signup); err != nil {
 		fmt.Printf("Validation error: %v\n", err)
 	} else {
 		fmt.Println("Validation passed")
 	}
-}
-
-// validateStruct validates a struct using reflection
-func validateStruct(s interface{}) error {
-	v := reflect.ValueOf(s)
-	t := reflect.TypeOf(s)
 
-	// Handle pointer
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem()
-		t = t.Elem()
+	// Every failing rule is reported, each naming its field by its path
+	signup = Signup{
+		Username: "Al",
+		Email:    "alice(at)example.com",
+		Age:      9,
+		Plan:     "enterprise",
+		Address:  Address{Zip: "123"},
+		Tags:     []string{"go", "x"},
+		Referrer: "bob",
+		Labels:   map[string]string{"team": ""},
+	}
+	err := v.Struct(signup)
+	fmt.Printf("Validation failed:\n%v\n", err)
+	var fieldErr *validator.ValidationError
+	if errors.As(err, &fieldErr) {
+		fmt.Printf("First failure: field %s, rule %s, code %s\n", fieldErr.Field, fieldErr.Rule, fieldErr.Code)
 	}
+	if errs, ok := err.(validator.Errors); ok {
+		fmt.Printf("Fields for an API response: %v\n", errs.Fields())
+	}
+}
 
-	// Must be struct
-	if v.Kind() != reflect.Struct {
-		return fmt.Errorf("not a struct")
+// AppConfig is a service configuration populated from struct tags
+type AppConfig struct {
+	Env    string `default:"dev" validate:"oneof=dev staging prod" usage:"deployment environment"`
+	Server struct {
+		Host         string        `default:"localhost"`
+		Port         int           `default:"8080" validate:"min=1,max=65535"`
+		ReadTimeout  time.Duration `default:"5s" validate:"min=100ms"`
+		AllowOrigins []string
+	}
+	Database struct {
+		URL      string `validate:"required"`
+		Password config.Secret
+		MaxConns int `default:"10" validate:"min=1"`
 	}
+}
 
-	for i := 0; i < v.NumField(); i++ {
-		field := t.Field(i)
-		fieldVal := v.Field(i)
+// Validate checks what the tags cannot: a rule across fields
+func (c *AppConfig) Validate() error {
+	if c.Env == "prod" && c.Database.Password == "" {
+		return errors.New("database.password is required in prod")
+	}
+	return nil
+}
 
-		validateTag := field.Tag.Get("validate")
-		if validateTag == "" {
-			continue
-		}
+// String renders the configuration with its secrets redacted
+func (c *AppConfig) String() string {
+	return config.Format(c)
+}
 
-		// Check required
-		if validateTag == "required" {
-			if isZero(fieldVal) {
-				return fmt.Errorf("field %s is required", field.Name)
-			}
+// configurationLoading demonstrates populating a struct from defaults,
+// files, environment variables and flags through its tags
+func configurationLoading() {
+	dir, err := os.MkdirTemp("", "config-demo")
+	if err != nil {
+		fmt.Printf("Temp dir: %v\n", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.yaml")
+	write := func(contents string) {
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			fmt.Printf("Write config: %v\n", err)
 		}
-
-		// Additional validation logic here
+	}
+	write(`server:
+  port: 9000
+  allow_origins: [https://example.com, https://admin.example.com]
+database:
+  url: postgres://db:5432/app
+`)
+
+	// Each source overrides the one before: defaults, the file, then the
+	// environment, then flags
+	env := map[string]string{"APP_DATABASE_PASSWORD": "hunter2", "APP_SERVER_HOST": "0.0.0.0"}
+	opts := config.Options{
+		Files:     []string{path},
+		EnvPrefix: "APP_",
+		LookupEnv: func(key string) (string, bool) { v, ok := env[key]; return v, ok },
+		Args:      []string{"-env", "staging", "-server.read-timeout", "2s"},
+	}
+	cfg, err := config.Load[AppConfig](opts)
+	if err != nil {
+		fmt.Printf("Load config: %v\n", err)
+		return
+	}
+	fmt.Printf("Loaded configuration:\n%v", cfg)
+	fmt.Printf("Password kept for use: %d characters\n", len(cfg.Database.Password.Reveal()))
+
+	// Validation reports every failing tag at once
+	bad := opts
+	bad.Args = []string{"-env", "qa", "-server.port", "0"}
+	if _, err := config.Load[AppConfig](bad); err != nil {
+		fmt.Printf("Invalid configuration:\n%v\n", err)
 	}
 
-	return nil
-}
-
-// isZero checks if value is zero
-func isZero(v reflect.Value) bool {
-	switch v.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return v.Int() == 0
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return v.Uint() == 0
-	case reflect.Float32, reflect.Float64:
-		return v.Float() == 0
-	case reflect.String:
-		return v.String() == ""
-	case reflect.Bool:
-		return !v.Bool()
-	case reflect.Slice, reflect.Map, reflect.Interface, reflect.Ptr:
-		return v.IsNil()
-	default:
-		return false
+	// A watcher reloads the file when it changes, keeping the last good
+	// configuration if the new one is invalid
+	changed := make(chan *AppConfig, 1)
+	w, err := config.Watch(context.Background(), opts, config.WatchOptions[AppConfig]{
+		Interval: 10 * time.Millisecond,
+		OnChange: func(cfg *AppConfig) { changed <- cfg },
+	})
+	if err != nil {
+		fmt.Printf("Watch config: %v\n", err)
+		return
+	}
+	defer w.Stop()
+	write("server:\n  port: 9100\ndatabase:\n  url: postgres://db:5432/app\n")
+	select {
+	case cfg := <-changed:
+		fmt.Printf("Reloaded: server.port is now %d\n", cfg.Server.Port)
+	case <-time.After(time.Second):
+		fmt.Println("No reload seen")
 	}
 }
 
@@ -217,6 +316,78 @@ func dynamicStructCreation() {
 	fmt.Printf("Dynamic slice: %+v\n", slice.Interface())
 }
 
+// Customer is a stored entity, with a reference back to itself through
+// its account manager
+type Customer struct {
+	ID       int64
+	Name     string
+	Email    string `map:"email"`
+	Since    time.Time
+	Tags     []string
+	Limits   map[string]int
+	Manager  *Customer
+	Password string `map:"-"`
+}
+
+// CustomerDTO is the shape a customer is sent to clients in
+type CustomerDTO struct {
+	ID     string
+	Name   string
+	Email  string `map:"email"`
+	Since  string
+	Tags   []string
+	Limits map[string]float64
+}
+
+// objectMapping demonstrates copying, converting and comparing objects by
+// reflection
+func objectMapping() {
+	c := &Customer{
+		ID: 7, Name: "Ada", Email: "ada@example.com",
+		Since:    time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC),
+		Tags:     []string{"vip"},
+		Limits:   map[string]int{"orders_per_day": 50},
+		Password: "hash",
+	}
+	c.Manager = c
+
+	// A deep copy shares nothing with the original, and keeps the cycle
+	edited := mapper.DeepCopy(c)
+	edited.Name = "Ada L."
+	edited.Tags = append(edited.Tags, "beta")
+	edited.Limits["orders_per_day"] = 80
+	fmt.Printf("Original unchanged: %s %v %v; copy's manager is the copy: %t\n",
+		c.Name, c.Tags, c.Limits, edited.Manager == edited)
+
+	// Diff lists the changed fields by path
+	fmt.Printf("Changed fields: %v\n", mapper.Diff(c, edited))
+
+	// Map converts between types field by field: int64 and time.Time to
+	// strings, int to float64, skipping the password
+	var dto CustomerDTO
+	if err := mapper.Map(&dto, c); err != nil {
+		fmt.Printf("Map: %v\n", err)
+		return
+	}
+	fmt.Printf("DTO: %+v\n", dto)
+
+	// ToMap refuses the cycle a map cannot hold; FromMap fills a struct
+	// from decoded JSON
+	if _, err := mapper.ToMap(c); err != nil {
+		fmt.Printf("ToMap: %v\n", err)
+	}
+	c.Manager = nil
+	m, err := mapper.ToMap(c)
+	if err != nil {
+		fmt.Printf("ToMap: %v\n", err)
+		return
+	}
+	fmt.Printf("As a map: name=%v email=%v limits=%v\n", m["Name"], m["email"], m["Limits"])
+	var fromJSON Customer
+	err = mapper.FromMap(&fromJSON, map[string]any{"ID": 8.0, "Name": "Grace", "Since": "2022-01-01T00:00:00Z"})
+	fmt.Printf("From a map: %d %s %s %v\n", fromJSON.ID, fromJSON.Name, fromJSON.Since.Format(time.DateOnly), err)
+}
+
 // reflectionPerformance demonstrates reflection performance considerations
 func reflectionPerformance() {
 	// Reflection has performance overhead
@@ -5,8 +5,9 @@ import (
 	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
-	"sync"
 	"time"
+
+	"hellogolang/Advanced/ratelimit"
 )
 
 // Security Patterns demonstrates secure coding patterns
@@ -207,54 +208,22 @@ func secureStorage() {
 
 // rateLimitingSecurity demonstrates rate limiting for security
 func rateLimitingSecurity() {
-	// Rate limiting to prevent brute force attacks
-	type RateLimiter struct {
-		attempts    map[string][]time.Time
-		maxAttempts int
-		window      time.Duration
-		mu          sync.Mutex
-	}
-
-	limiter := &RateLimiter{
-		attempts:    make(map[string][]time.Time),
-		maxAttempts: 5,
-		window:      15 * time.Minute,
-	}
-
-	checkRateLimit := func(rl *RateLimiter, key string) bool {
-		rl.mu.Lock()
-		defer rl.mu.Unlock()
-
-		now := time.Now()
-		attempts := rl.attempts[key]
-
-		// Remove old attempts outside window
-		validAttempts := []time.Time{}
-		for _, attempt := range attempts {
-			if now.Sub(attempt) < rl.window {
-				validAttempts = append(validAttempts, attempt)
-			}
-		}
-
-		if len(validAttempts) >= rl.maxAttempts {
-			return false // Rate limited
-		}
-
-		// Record new attempt
-		validAttempts = append(validAttempts, now)
-		rl.attempts[key] = validAttempts
-
-		return true // Allowed
-	}
+	// Rate limiting to prevent brute force attacks: at most 5 attempts per
+	// account in any 15 minutes, tracking at most 10000 accounts so that
+	// an attacker cycling through names cannot exhaust memory
+	limiter := ratelimit.NewKeyed[string](10000, func() ratelimit.Limiter {
+		return ratelimit.NewSlidingWindowLog(5, 15*time.Minute)
+	})
 
 	// Test rate limiting
 	key := "user@example.com"
 	for i := 0; i < 7; i++ {
-		allowed := checkRateLimit(limiter, key)
+		allowed := limiter.Allow(key)
 		if allowed {
 			fmt.Printf("Attempt %d: Allowed\n", i+1)
 		} else {
 			fmt.Printf("Attempt %d: Rate limited\n", i+1)
 		}
 	}
+	fmt.Printf("Other account allowed: %v\n", limiter.Allow("admin@example.com"))
 }
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hellogolang/Advanced/circuitbreaker"
+	"hellogolang/Advanced/framing"
+	"hellogolang/Advanced/httpclient"
+	"hellogolang/Advanced/httpmw"
+	"hellogolang/Advanced/logx"
+	"hellogolang/Advanced/metrics"
+	"hellogolang/Advanced/netserver"
+	"hellogolang/Advanced/ratelimit"
+	"hellogolang/Advanced/retry"
+)
+
+// Network Programming demonstrates a TCP server built on the netserver
+// package, exchanging raw lines and then typed messages framed by the
+// framing package, an HTTP server built with the httpmw middleware, and an HTTP
+// client with retries and circuit breakers from the httpclient package
+
+func main() {
+	echoServer()
+	messageServer()
+	httpServer()
+	httpClient()
+}
+
+// echoLines is a handler that writes back each line it reads, until the
+// client disconnects, the read times out or the server shuts down
+func echoLines(ctx context.Context, c *netserver.Conn) {
+	scanner := bufio.NewScanner(c)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "panic" {
+			panic("client asked for a panic")
+		}
+		if _, err := fmt.Fprintf(c, "echo: %s\n", line); err != nil {
+			return
+		}
+	}
+}
+
+// echoServer runs an echo server with connection limits, deadlines,
+// lifecycle hooks and metrics, then shuts it down gracefully
+func echoServer() {
+	var logMu sync.Mutex
+	logf := func(format string, args ...any) {
+		logMu.Lock()
+		defer logMu.Unlock()
+		fmt.Printf(format, args...)
+	}
+
+	server := netserver.New(netserver.HandlerFunc(echoLines), netserver.Options{
+		MaxConns:     2,
+		ReadTimeout:  2 * time.Second,
+		WriteTimeout: time.Second,
+		OnConnect: func(c *netserver.Conn) error {
+			logf("Connection %d opened\n", c.ID())
+			return nil
+		},
+		OnClose: func(c *netserver.Conn) {
+			logf("Connection %d closed after %d bytes in, %d bytes out\n", c.ID(), c.BytesRead(), c.BytesWritten())
+		},
+		OnError: func(c *netserver.Conn, err error) {
+			if c == nil {
+				logf("Accept failed: %v\n", err)
+				return
+			}
+			logf("Connection %d failed: %v\n", c.ID(), err)
+		},
+	})
+	registry := metrics.NewRegistry()
+	server.RegisterMetrics(registry, "echo")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Printf("Listen: %v\n", err)
+		return
+	}
+	fmt.Printf("Echo server listening on %s\n", listener.Addr())
+
+	// Run also shuts down on SIGINT or SIGTERM; here the context ends it
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan error, 1)
+	go func() {
+		ran <- server.Run(ctx, listener, 5*time.Second)
+	}()
+
+	// Three clients at once: only two are served at a time, and the third
+	// waits in the backlog until a slot frees up
+	var wg sync.WaitGroup
+	for i := 1; i <= 3; i++ {
+		wg.Go(func() {
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			if err != nil {
+				logf("Dial: %v\n", err)
+				return
+			}
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for j := 1; j <= 2; j++ {
+				fmt.Fprintf(conn, "client %d line %d\n", i, j)
+				reply, err := reader.ReadString('\n')
+				if err != nil {
+					logf("Client %d: %v\n", i, err)
+					return
+				}
+				logf("Client %d got %q\n", i, strings.TrimSpace(reply))
+			}
+		})
+	}
+	wg.Wait()
+
+	// A panicking handler loses its connection but not the server
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err == nil {
+		fmt.Fprintln(conn, "panic")
+		_, err = bufio.NewReader(conn).ReadString('\n')
+		fmt.Printf("Read after the handler panicked: %v\n", err)
+		conn.Close()
+	}
+
+	// Metrics as a Prometheus scrape would see them
+	for server.Stats().Active > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	var exposition strings.Builder
+	registry.WriteText(&exposition)
+	for line := range strings.Lines(exposition.String()) {
+		if !strings.HasPrefix(line, "#") {
+			fmt.Print(line)
+		}
+	}
+
+	cancel()
+	if err := <-ran; err != nil {
+		fmt.Fprintf(os.Stderr, "Run: %v\n", err)
+		return
+	}
+	fmt.Println("Server shut down cleanly")
+}
+
+// Messages of the calculator protocol, numbered by bin tags for bincodec
+type (
+	// sumRequest asks for the total of Numbers
+	sumRequest struct {
+		Numbers []int64 `bin:"1"`
+	}
+	// sumResponse answers a sumRequest
+	sumResponse struct {
+		Total int64 `bin:"1"`
+	}
+	// errorResponse answers a request the server could not serve
+	errorResponse struct {
+		Message string `bin:"1"`
+	}
+)
+
+// calculatorCodec returns the codec of the calculator protocol, shared by
+// server and client
+func calculatorCodec() *framing.TypedCodec {
+	codec := framing.NewBinaryCodec()
+	framing.Register[sumRequest](codec, 1)
+	framing.Register[sumResponse](codec, 2)
+	framing.Register[errorResponse](codec, 3)
+	return codec
+}
+
+// serveCalculator answers each sumRequest on c with its total, and
+// anything else with an errorResponse, while a heartbeat pings the client
+func serveCalculator(codec framing.Codec) netserver.HandlerFunc {
+	return func(ctx context.Context, c *netserver.Conn) {
+		conn := framing.NewMessageConn(framing.NewConn(c, framing.Options{MaxFrameSize: 64 << 10}), codec)
+		// A client that stops answering pings is cut off
+		go func() {
+			if err := conn.Heartbeat(ctx, 200*time.Millisecond, time.Second); err != nil && ctx.Err() == nil {
+				c.Close()
+			}
+		}()
+		for {
+			msg, err := conn.Receive()
+			switch {
+			case errors.Is(err, framing.ErrUnknownMessage):
+				conn.Send(errorResponse{Message: err.Error()})
+				continue
+			case err != nil:
+				return
+			}
+			switch m := msg.(type) {
+			case sumRequest:
+				var total int64
+				for _, n := range m.Numbers {
+					total += n
+				}
+				err = conn.Send(sumResponse{Total: total})
+			default:
+				err = conn.Send(errorResponse{Message: fmt.Sprintf("unexpected %T", msg)})
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// messageServer runs a calculator server exchanging typed messages in
+// length-prefixed frames instead of raw lines
+func messageServer() {
+	fmt.Println("\n=== Framed Messages ===")
+	codec := calculatorCodec()
+	server := netserver.New(serveCalculator(codec), netserver.Options{ReadTimeout: 2 * time.Second})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Printf("Listen: %v\n", err)
+		return
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	nc, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		fmt.Printf("Dial: %v\n", err)
+		return
+	}
+	defer nc.Close()
+	conn := framing.NewMessageConn(framing.NewConn(nc, framing.Options{}), codec)
+
+	requests := []any{
+		sumRequest{Numbers: []int64{1, 2, 3, 4}},
+		sumResponse{Total: 7}, // A message the server does not expect
+		sumRequest{Numbers: []int64{-10, 100}},
+	}
+	for _, req := range requests {
+		if err := conn.Send(req); err != nil {
+			fmt.Printf("Send: %v\n", err)
+			return
+		}
+		// Receive answers the server's pings while waiting for the reply
+		reply, err := conn.Receive()
+		if err != nil {
+			fmt.Printf("Receive: %v\n", err)
+			return
+		}
+		fmt.Printf("Sent %+v, got %T %+v\n", req, reply, reply)
+	}
+
+	// A frame type the codec does not know, written by hand
+	conn.WriteFrame(42, []byte("?"))
+	reply, _ := conn.Receive()
+	fmt.Printf("Sent frame type 42, got %+v\n", reply)
+
+	// A frame over the server's limit makes it drop the connection
+	conn.WriteFrame(1, make([]byte, 100<<10))
+	_, err = conn.Receive()
+	fmt.Printf("Receive after an oversized frame: %v\n", err)
+}
+
+// httpServer serves a small API through a Router with request IDs,
+// logging, panic recovery, CORS, gzip and a per-client rate limit, and
+// shows how each request fares
+func httpServer() {
+	fmt.Println("\n=== HTTP Middleware ===")
+	logger := logx.New(os.Stdout, logx.Options{Level: slog.LevelInfo})
+	withLogger := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(logx.WithLogger(r.Context(), logger)))
+		})
+	}
+	limits := ratelimit.NewKeyed[string](1000, func() ratelimit.Limiter {
+		return ratelimit.NewTokenBucket(1, 3)
+	})
+
+	router := httpmw.NewRouter(
+		withLogger,
+		httpmw.RequestID(),
+		httpmw.Logging(),
+		httpmw.Recover(),
+		httpmw.CORS(httpmw.CORSOptions{
+			AllowedOrigins: []string{"https://app.example"},
+			AllowedMethods: []string{http.MethodGet, http.MethodPost},
+			MaxAge:         time.Hour,
+		}),
+		httpmw.Gzip(gzip.DefaultCompression),
+	)
+	router.HandleFunc("GET /greet/{name}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Hello, %s! %s", r.PathValue("name"), strings.Repeat("Welcome. ", 50))
+	}, httpmw.RateLimit(limits, nil), httpmw.Timeout(time.Second))
+	router.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("handler bug")
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+	// A transport that leaves gzip responses compressed, to show the
+	// encoding
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	do := func(method, path string, header map[string]string) {
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		if err != nil {
+			fmt.Printf("NewRequest: %v\n", err)
+			return
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			fmt.Printf("%s %s: %v\n", method, path, err)
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%s %s -> %s, %d body bytes, encoding %q, CORS origin %q\n", method, path, resp.Status,
+			len(body), resp.Header.Get("Content-Encoding"), resp.Header.Get("Access-Control-Allow-Origin"))
+	}
+
+	do("GET", "/greet/gopher", map[string]string{"Accept-Encoding": "gzip", httpmw.RequestIDHeader: "demo-1"})
+	do("GET", "/greet/gopher", map[string]string{"Origin": "https://app.example"})
+	do("OPTIONS", "/greet/gopher", map[string]string{"Origin": "https://app.example", "Access-Control-Request-Method": "GET"})
+	do("OPTIONS", "/greet/gopher", map[string]string{"Origin": "https://evil.example", "Access-Control-Request-Method": "GET"})
+	do("GET", "/greet/gopher", nil)
+	do("GET", "/greet/gopher", nil) // Over the burst of 3
+	do("GET", "/panic", nil)
+	do("DELETE", "/greet/gopher", nil)
+}
+
+// httpClient calls a flaky server through an httpclient.Client, which
+// retries its failures, cuts off a host that keeps failing and caps the
+// size of responses
+func httpClient() {
+	fmt.Println("\n=== HTTP Client ===")
+	var calls atomic.Int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/big":
+			io.WriteString(w, strings.Repeat("x", 2048))
+		case r.URL.Path == "/down":
+			http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+		case calls.Add(1)%3 != 0:
+			// Two of every three requests fail
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+		default:
+			io.WriteString(w, "finally")
+		}
+	}))
+	defer flaky.Close()
+
+	registry := metrics.NewRegistry()
+	client := httpclient.New(httpclient.Options{
+		Timeout:        5 * time.Second,
+		AttemptTimeout: time.Second,
+		Retry: retry.Policy{
+			MaxAttempts:  3,
+			InitialDelay: 10 * time.Millisecond,
+			OnRetry: func(attempt int, err error, delay time.Duration) {
+				fmt.Printf("  attempt %d failed (%v), retrying in %v\n", attempt, err, delay)
+			},
+		},
+		Breaker:     &circuitbreaker.Settings{FailureThreshold: 4, OpenTimeout: time.Minute},
+		MaxBodySize: 1024,
+		Hooks:       []httpclient.Hook{httpclient.Metrics(registry, "demo")},
+	})
+	get := func(path string) {
+		fmt.Printf("GET %s\n", path)
+		resp, err := client.Get(context.Background(), flaky.URL+path)
+		if err != nil {
+			fmt.Printf("  failed: %v\n", err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		fmt.Printf("  %s: %q, %v\n", resp.Status, body, err)
+	}
+
+	get("/flaky") // Succeeds on the third attempt
+	get("/big")   // Over the body limit
+	get("/down")  // Fails three times in a row, returning the last response
+	get("/flaky") // The fourth failure in a row opens the breaker, refusing the retry
+
+	var exposition strings.Builder
+	registry.WriteText(&exposition)
+	for line := range strings.Lines(exposition.String()) {
+		if strings.HasPrefix(line, "httpclient_attempts_total") {
+			fmt.Print(line)
+		}
+	}
+}
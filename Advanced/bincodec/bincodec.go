@@ -0,0 +1,342 @@
+// Package bincodec encodes structs in a compact binary format and decodes
+// them back. Each field is written as its number and a value, so a message
+// holds only the fields that are set, and types can change between
+// versions of a program without breaking the data the others wrote.
+//
+// Fields are numbered by bin tags; fields without one are not encoded, so
+// every number in the data is one chosen on purpose:
+//
+//	type User struct {
+//		ID    uint64   `bin:"1"`
+//		Name  string   `bin:"2"`
+//		Email *string  `bin:"3"`
+//		Tags  []string `bin:"4"`
+//	}
+//
+// On the wire a field is a varint key, holding its number and how its
+// value is written, then the value: booleans and integers as varints,
+// signed ones zigzag-encoded so small negatives stay short, floats as 4 or
+// 8 little-endian bytes, and strings, byte slices, nested structs and
+// types implementing encoding.BinaryMarshaler, such as time.Time, as a
+// varint length and that many bytes. A slice of numbers is packed into
+// one length-prefixed run; other slices repeat the field once per
+// element. A map is a repeated field of entries, each holding its key as
+// field 1 and its value as field 2, in key order so encoding is
+// deterministic. Fields with zero values, and nil pointers, are left out;
+// a pointer to a zero value is written, so a pointer records presence.
+//
+// Data stays readable as types change if these rules are kept:
+//   - A reader skips fields it does not know, so old code reads new data
+//   - A field missing from the data decodes as its zero value, so new code
+//     reads old data
+//   - Never reuse the number of a removed field, and never change what a
+//     number means
+//   - Integers may widen or narrow within signed or within unsigned types;
+//     a value too large for its field fails with ErrOverflow. Signed and
+//     unsigned types do not mix, nor float32 and float64.
+//   - string and []byte are interchangeable, as are T and *T
+//   - A singular field may become a slice; a slice of strings, byte slices
+//     or structs may become singular, keeping the last element
+//
+// Marshal and Unmarshal interpret values with reflection on each call.
+// Codec compiles a type once into functions for each of its fields, which
+// is faster and reuses buffers, with no code generation step.
+package bincodec
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrTruncated is returned when data ends inside a field
+	ErrTruncated = errors.New("truncated data")
+	// ErrMalformed is returned for data that is not in the format, such
+	// as a varint longer than 64 bits or a field number of 0
+	ErrMalformed = errors.New("malformed data")
+	// ErrWireType is returned when a field is written in a way its Go
+	// type cannot be read from, such as a string for an int
+	ErrWireType = errors.New("wrong wire type")
+	// ErrOverflow is returned when a number does not fit its field
+	ErrOverflow = errors.New("value overflows field")
+	// ErrTooDeep is returned when structs nest deeper than MaxDepth,
+	// which also stops pointer cycles when encoding
+	ErrTooDeep = errors.New("nesting too deep")
+)
+
+// MaxDepth is how many levels of structs a message may nest, counting
+// the message itself. Secure: decoding recurses once per level, so
+// crafted data cannot exhaust the stack.
+const MaxDepth = 64
+
+// maxField is the largest field number, leaving room in a key for the
+// wire type
+const maxField = 1<<29 - 1
+
+// FieldError is a failure to encode or decode a field
+type FieldError struct {
+	Field  string // The path of the field, such as "Address.Zip", or "" for the message itself
+	Offset int    // The offset of the failure within the data, or -1 when encoding
+	Err    error
+}
+
+// Error names the field and what was wrong with it
+func (e *FieldError) Error() string {
+	var b strings.Builder
+	b.WriteString("bincodec: ")
+	if e.Field != "" {
+		fmt.Fprintf(&b, "field %s: ", e.Field)
+	}
+	if e.Offset >= 0 {
+		fmt.Fprintf(&b, "offset %d: ", e.Offset)
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+// Unwrap returns the cause
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// inField returns err as a failure of the field name at offset, adding
+// name to the path of a failure within it
+func inField(name string, offset int, err error) error {
+	if fe, ok := err.(*FieldError); ok {
+		if fe.Field == "" {
+			return &FieldError{Field: name, Offset: fe.Offset, Err: fe.Err}
+		}
+		return &FieldError{Field: name + "." + fe.Field, Offset: fe.Offset, Err: fe.Err}
+	}
+	return &FieldError{Field: name, Offset: offset, Err: err}
+}
+
+// messageError returns err as a failure of the message itself, unless it
+// is already a FieldError
+func messageError(offset int, err error) error {
+	if _, ok := err.(*FieldError); ok {
+		return err
+	}
+	return &FieldError{Offset: offset, Err: err}
+}
+
+// kind is how values of a Go type are encoded
+type kind uint8
+
+const (
+	kindInvalid kind = iota
+	kindBool
+	kindInt
+	kindUint
+	kindFloat32
+	kindFloat64
+	kindString
+	kindBytes
+	kindBinary // encoding.BinaryMarshaler and BinaryUnmarshaler
+	kindStruct
+	kindPointer
+	kindSlice
+	kindMap
+)
+
+// wire returns the wire type values of kind k are written with. Pointers
+// take the wire type of what they point to, which wireOf looks up.
+func (k kind) wire() wireType {
+	switch k {
+	case kindBool, kindInt, kindUint:
+		return wireVarint
+	case kindFloat32:
+		return wireFixed32
+	case kindFloat64:
+		return wireFixed64
+	}
+	return wireBytes
+}
+
+// wireOf returns the wire type values of t are written with
+func wireOf(t reflect.Type) wireType {
+	if kindOf(t) == kindPointer {
+		t = t.Elem()
+	}
+	return kindOf(t).wire()
+}
+
+// packable reports whether a slice of kind k is packed into one run
+func (k kind) packable() bool {
+	return k >= kindBool && k <= kindFloat64
+}
+
+var (
+	binaryMarshaler   = reflect.TypeFor[encoding.BinaryMarshaler]()
+	binaryUnmarshaler = reflect.TypeFor[encoding.BinaryUnmarshaler]()
+)
+
+// kinds caches the kind of each type
+var kinds sync.Map // reflect.Type to kind
+
+// kindOf returns the kind of t, or kindInvalid if t cannot be encoded.
+// It checks only t itself, not the types of its fields or elements.
+func kindOf(t reflect.Type) kind {
+	if k, ok := kinds.Load(t); ok {
+		return k.(kind)
+	}
+	k := classify(t)
+	kinds.Store(t, k)
+	return k
+}
+
+// classify works out the kind of t for kindOf
+func classify(t reflect.Type) kind {
+	if t.Implements(binaryMarshaler) && reflect.PointerTo(t).Implements(binaryUnmarshaler) {
+		return kindBinary
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return kindBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return kindInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return kindUint
+	case reflect.Float32:
+		return kindFloat32
+	case reflect.Float64:
+		return kindFloat64
+	case reflect.String:
+		return kindString
+	case reflect.Struct:
+		return kindStruct
+	case reflect.Pointer:
+		// A pointer must point to a single value
+		switch kindOf(t.Elem()) {
+		case kindInvalid, kindPointer, kindSlice, kindMap:
+			return kindInvalid
+		}
+		return kindPointer
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return kindBytes
+		}
+		// Elements are repeated fields, so they cannot be repeated
+		// themselves
+		switch kindOf(t.Elem()) {
+		case kindInvalid, kindSlice, kindMap:
+			return kindInvalid
+		}
+		return kindSlice
+	case reflect.Map:
+		switch kindOf(t.Key()) {
+		case kindBool, kindInt, kindUint, kindString:
+		default:
+			return kindInvalid
+		}
+		switch kindOf(t.Elem()) {
+		case kindInvalid, kindSlice, kindMap:
+			return kindInvalid
+		}
+		return kindMap
+	}
+	return kindInvalid
+}
+
+// field is a numbered field of a struct and the functions that encode and
+// decode it
+type field struct {
+	name  string
+	num   int
+	index int
+	kind  kind
+	enc   encodeFunc
+	dec   decodeFunc
+}
+
+// structInfo is the fields of a struct type, in number order
+type structInfo struct {
+	fields []field
+}
+
+// find returns the index of the field numbered num, or -1. hint is the index of the
+// field expected next, which is right for data written in number order.
+func (s *structInfo) find(num, hint int) int {
+	if hint < len(s.fields) && s.fields[hint].num == num {
+		return hint
+	}
+	if i, ok := slices.BinarySearchFunc(s.fields, num, func(f field, num int) int { return f.num - num }); ok {
+		return i
+	}
+	return -1
+}
+
+var (
+	// structs caches the fields of each struct type, once complete
+	structs sync.Map // reflect.Type to *structInfo
+	// buildMu serializes building, which must see the whole set of
+	// types in progress to handle recursive types
+	buildMu sync.Mutex
+)
+
+// structOf returns the fields of the struct type t. It panics if a field
+// has a bad tag or a type that cannot be encoded, since either is a
+// programmer mistake.
+func structOf(t reflect.Type) *structInfo {
+	if s, ok := structs.Load(t); ok {
+		return s.(*structInfo)
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("bincodec: messages of type %v, want a struct", t))
+	}
+	buildMu.Lock()
+	defer buildMu.Unlock()
+	building := make(map[reflect.Type]*structInfo)
+	s := build(t, building)
+	for t, s := range building {
+		structs.Store(t, s)
+	}
+	return s
+}
+
+// build works out the fields of t, adding t and the struct types it uses
+// to building before compiling them so that recursive types refer to
+// themselves
+func build(t reflect.Type, building map[reflect.Type]*structInfo) *structInfo {
+	if s, ok := structs.Load(t); ok {
+		return s.(*structInfo)
+	}
+	if s, ok := building[t]; ok {
+		return s
+	}
+	s := &structInfo{}
+	building[t] = s
+	seen := make(map[int]string)
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("bin")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if !sf.IsExported() {
+			panic(fmt.Sprintf("bincodec: field %v.%s is tagged but not exported", t, sf.Name))
+		}
+		num, err := strconv.Atoi(strings.TrimSpace(tag))
+		if err != nil || num < 1 || num > maxField {
+			panic(fmt.Sprintf("bincodec: field %v.%s has tag %q, want a number from 1 to %d", t, sf.Name, tag, maxField))
+		}
+		if other, ok := seen[num]; ok {
+			panic(fmt.Sprintf("bincodec: fields %v.%s and %s are both numbered %d", t, other, sf.Name, num))
+		}
+		seen[num] = sf.Name
+		f := field{name: sf.Name, num: num, index: i, kind: kindOf(sf.Type)}
+		if f.kind == kindInvalid {
+			panic(fmt.Sprintf("bincodec: field %v.%s has unsupported type %v", t, sf.Name, sf.Type))
+		}
+		f.enc, f.dec = compileField(sf.Type, building)
+		s.fields = append(s.fields, f)
+	}
+	slices.SortFunc(s.fields, func(a, b field) int { return a.num - b.num })
+	return s
+}
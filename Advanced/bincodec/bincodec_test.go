@@ -0,0 +1,261 @@
+package bincodec
+
+import (
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// UserV1 is the first version of a message
+type UserV1 struct {
+	ID   uint32 `bin:"1"`
+	Name string `bin:"2"`
+	Age  int8   `bin:"3"`
+}
+
+// UserV2 widens ID and Age, makes Name bytes and Age optional, and adds
+// fields
+type UserV2 struct {
+	ID     uint64   `bin:"1"`
+	Name   []byte   `bin:"2"`
+	Age    *int64   `bin:"3"`
+	Emails []string `bin:"4"`
+	Score  float64  `bin:"5"`
+	Home   *Address `bin:"6"`
+}
+
+// UserV3 turns the singular fields of UserV1 into slices
+type UserV3 struct {
+	Names []string `bin:"2"`
+	Ages  []int16  `bin:"3"`
+}
+
+// TestVersions tests reading data written by other versions of a type
+func TestVersions(t *testing.T) {
+	v1 := UserV1{ID: 7, Name: "Ada", Age: -36}
+	data, err := Marshal(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// New code reads old data, with new fields left zero
+	var v2 UserV2
+	if err := Unmarshal(data, &v2); err != nil {
+		t.Fatal(err)
+	}
+	if v2.ID != 7 || string(v2.Name) != "Ada" || v2.Age == nil || *v2.Age != -36 || v2.Emails != nil || v2.Home != nil {
+		t.Errorf("UserV2 from UserV1 data = %+v", v2)
+	}
+
+	// Old code reads new data, skipping the fields it does not know
+	v2.Emails = []string{"ada@example.com", "a@example.org"}
+	v2.Score = 0.5
+	v2.Home = &Address{Street: "Main St"}
+	data, _ = Marshal(v2)
+	var back UserV1
+	if err := Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back != v1 {
+		t.Errorf("UserV1 from UserV2 data = %+v, want %+v", back, v1)
+	}
+
+	// Singular fields read as slices of one element
+	var v3 UserV3
+	if err := NewCodec[UserV3]().Unmarshal(data, &v3); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v3, UserV3{Names: []string{"Ada"}, Ages: []int16{-36}}) {
+		t.Errorf("UserV3 from UserV2 data = %+v", v3)
+	}
+
+	// A repeated string read as a singular field keeps the last element
+	data, _ = Marshal(UserV3{Names: []string{"a", "b"}})
+	if err := Unmarshal(data, &back); err != nil || back.Name != "b" {
+		t.Errorf("UserV1 from UserV3 data = %+v, %v, want Name b", back, err)
+	}
+}
+
+// TestVersionErrors tests changes that break compatibility
+func TestVersionErrors(t *testing.T) {
+	type wrongType struct {
+		Name int `bin:"2"`
+	}
+	type packed struct {
+		Age int8 `bin:"3"`
+	}
+	big := UserV2{ID: 1 << 40}
+	age := int64(1000)
+	tests := []struct {
+		name  string
+		from  any
+		into  any
+		want  error
+		field string
+	}{
+		{"overflow", big, &UserV1{}, ErrOverflow, "ID"},
+		{"narrowed", UserV2{Age: &age}, &UserV1{}, ErrOverflow, "Age"},
+		{"wire type", UserV1{Name: "x"}, &wrongType{}, ErrWireType, "Name"},
+		{"packed to singular", UserV3{Ages: []int16{1, 2}}, &packed{}, ErrWireType, "Age"},
+		{"nested", Person{Manager: &Person{Homes: map[int8]*Address{1: {Zip: 1 << 20}}}}, &struct {
+			Manager *struct {
+				Homes map[int8]*struct {
+					Zip uint16 `bin:"2"`
+				} `bin:"15"`
+			} `bin:"14"`
+		}{}, ErrOverflow, "Manager.Homes.Zip"},
+	}
+	for _, tt := range tests {
+		data, err := Marshal(tt.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Unmarshal(data, tt.into)
+		var fe *FieldError
+		if !errors.Is(err, tt.want) || !errors.As(err, &fe) || fe.Field != tt.field {
+			t.Errorf("%s: Unmarshal = %v, want %v in field %s", tt.name, err, tt.want, tt.field)
+		}
+	}
+}
+
+// Node is a recursive type
+type Node struct {
+	Value int   `bin:"1"`
+	Next  *Node `bin:"2"`
+}
+
+// TestDepth tests that deep nesting and cycles fail rather than recurse
+// without end
+func TestDepth(t *testing.T) {
+	var list *Node
+	for i := range MaxDepth {
+		list = &Node{Value: i, Next: list}
+	}
+	data, err := Marshal(list)
+	if err != nil {
+		t.Fatalf("Marshal of %d levels: %v", MaxDepth, err)
+	}
+	var back Node
+	if err := Unmarshal(data, &back); err != nil || back.Value != MaxDepth-1 {
+		t.Fatalf("Unmarshal of %d levels = %v", MaxDepth, err)
+	}
+
+	deeper := &Node{Value: -1, Next: list}
+	if _, err := Marshal(deeper); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("Marshal of %d levels = %v, want ErrTooDeep", MaxDepth+1, err)
+	}
+	cycle := &Node{}
+	cycle.Next = cycle
+	if _, err := NewCodec[Node]().Marshal(cycle); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("Marshal of a cycle = %v, want ErrTooDeep", err)
+	}
+
+	// Data nesting deeper than any value written by Marshal
+	crafted := []byte{}
+	for range MaxDepth + 1 {
+		crafted = insertLen(append([]byte{0x12}, crafted...), 1)
+	}
+	if err := Unmarshal(crafted, &back); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("Unmarshal of crafted nesting = %v, want ErrTooDeep", err)
+	}
+	if err := NewCodec[Node]().Unmarshal(crafted, &back); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("Codec.Unmarshal of crafted nesting = %v, want ErrTooDeep", err)
+	}
+}
+
+// TestCorruptData tests that damaged data fails cleanly on both paths
+func TestCorruptData(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	codec := NewCodec[Person]()
+	for range 200 {
+		p := randomPerson(r, 2)
+		data, _ := Marshal(p)
+		if len(data) == 0 {
+			continue
+		}
+		corrupt := append([]byte(nil), data...)
+		for range 1 + r.IntN(3) {
+			corrupt[r.IntN(len(corrupt))] = byte(r.Uint32())
+		}
+		cut := data[:r.IntN(len(data))]
+		for _, d := range [][]byte{corrupt, cut} {
+			var a, b Person
+			errA, errB := Unmarshal(d, &a), codec.Unmarshal(d, &b)
+			if (errA == nil) != (errB == nil) {
+				t.Fatalf("paths disagree on % x: %v, %v", d, errA, errB)
+			}
+			if errA == nil && !reflect.DeepEqual(a, b) {
+				t.Fatalf("paths decoded % x differently", d)
+			}
+		}
+	}
+}
+
+// TestPanics tests types that cannot be encoded
+func TestPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func()
+		want string
+	}{
+		{"not a struct", func() { Marshal(42) }, "want a struct"},
+		{"nil", func() { Marshal(nil) }, "want a struct"},
+		{"not a pointer", func() { Unmarshal(nil, UserV1{}) }, "non-nil pointer"},
+		{"bad number", func() {
+			NewCodec[struct {
+				A int `bin:"0"`
+			}]()
+		}, "want a number"},
+		{"not a number", func() {
+			NewCodec[struct {
+				A int `bin:"a"`
+			}]()
+		}, "want a number"},
+		{"duplicate", func() {
+			NewCodec[struct {
+				A int `bin:"1"`
+				B int `bin:"1"`
+			}]()
+		}, "both numbered 1"},
+		{"unexported", func() {
+			NewCodec[struct {
+				a int `bin:"1"`
+			}]()
+		}, "not exported"},
+		{"channel", func() {
+			NewCodec[struct {
+				C chan int `bin:"1"`
+			}]()
+		}, "unsupported type"},
+		{"nested slices", func() {
+			NewCodec[struct {
+				M [][]int `bin:"1"`
+			}]()
+		}, "unsupported type"},
+		{"float keys", func() {
+			NewCodec[struct {
+				M map[float64]int `bin:"1"`
+			}]()
+		}, "unsupported type"},
+		{"bad nested field", func() {
+			NewCodec[struct {
+				Inner []struct {
+					F func() `bin:"1"`
+				} `bin:"1"`
+			}]()
+		}, "unsupported type"},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				r := recover()
+				if s, _ := r.(string); !strings.Contains(s, tt.want) {
+					t.Errorf("%s: panicked with %v, want %q", tt.name, r, tt.want)
+				}
+			}()
+			tt.f()
+		}()
+	}
+}
@@ -0,0 +1,415 @@
+package bincodec
+
+import (
+	"bytes"
+	"cmp"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+)
+
+// Codec encodes and decodes values of the struct type T with functions
+// compiled once for each of its fields, so no type is inspected while
+// encoding. A Codec is safe for concurrent use.
+type Codec[T any] struct {
+	s *structInfo
+}
+
+// NewCodec returns a Codec for T. It panics if T is not a struct or has a
+// field that cannot be encoded.
+func NewCodec[T any]() *Codec[T] {
+	return &Codec[T]{s: structOf(reflect.TypeFor[T]())}
+}
+
+// Append appends the encoding of v to dst, which lets a caller reuse one
+// buffer across messages
+func (c *Codec[T]) Append(dst []byte, v *T) ([]byte, error) {
+	b, err := c.s.encode(dst, reflect.ValueOf(v).Elem(), 0)
+	if err != nil {
+		return dst, messageError(-1, err)
+	}
+	return b, nil
+}
+
+// Marshal returns the encoding of v
+func (c *Codec[T]) Marshal(v *T) ([]byte, error) {
+	return c.Append(nil, v)
+}
+
+// Unmarshal decodes data into v, which is first set to its zero value.
+// The result shares no memory with data.
+func (c *Codec[T]) Unmarshal(data []byte, v *T) error {
+	var zero T
+	*v = zero
+	return c.s.decode(&reader{buf: data}, reflect.ValueOf(v).Elem(), 0)
+}
+
+// encode appends the fields of v, a struct of the type of s
+func (s *structInfo) encode(b []byte, v reflect.Value, depth int) ([]byte, error) {
+	if depth >= MaxDepth {
+		return b, ErrTooDeep
+	}
+	for i := range s.fields {
+		f := &s.fields[i]
+		var err error
+		if b, err = f.enc(b, f.num, v.Field(f.index), depth); err != nil {
+			return b, inField(f.name, -1, err)
+		}
+	}
+	return b, nil
+}
+
+// decode reads fields from r into v, a struct of the type of s, skipping
+// fields s does not have
+func (s *structInfo) decode(r *reader, v reflect.Value, depth int) error {
+	if depth >= MaxDepth {
+		return &FieldError{Offset: r.pos(), Err: ErrTooDeep}
+	}
+	hint := 0
+	for !r.done() {
+		at := r.pos()
+		num, wt, err := r.key()
+		if err != nil {
+			return &FieldError{Offset: at, Err: err}
+		}
+		i := s.find(num, hint)
+		if i < 0 {
+			if err := r.skip(wt); err != nil {
+				return &FieldError{Offset: at, Err: err}
+			}
+			continue
+		}
+		f := &s.fields[i]
+		hint = i + 1
+		at = r.pos()
+		if err := f.dec(r, wt, v.Field(f.index), depth); err != nil {
+			return inField(f.name, at, err)
+		}
+	}
+	return nil
+}
+
+// encodeFunc appends field num holding v, or nothing if v is left out
+type encodeFunc func(b []byte, num int, v reflect.Value, depth int) ([]byte, error)
+
+// decodeFunc decodes a value of field written as wt into v
+type decodeFunc func(r *reader, wt wireType, v reflect.Value, depth int) error
+
+// valueCoder encodes and decodes single values of a type, without keys
+type valueCoder struct {
+	wire wireType
+	enc  func(b []byte, v reflect.Value, depth int) ([]byte, error)
+	dec  func(r *reader, v reflect.Value, depth int) error
+}
+
+// compileField returns the functions for a field of type t
+func compileField(t reflect.Type, building map[reflect.Type]*structInfo) (encodeFunc, decodeFunc) {
+	switch kindOf(t) {
+	case kindSlice:
+		return compileSlice(t, building)
+	case kindMap:
+		return compileMap(t, building)
+	}
+	c := compileValue(t, building)
+	enc := func(b []byte, num int, v reflect.Value, depth int) ([]byte, error) {
+		if v.IsZero() {
+			return b, nil
+		}
+		return c.enc(appendKey(b, num, c.wire), v, depth)
+	}
+	dec := func(r *reader, wt wireType, v reflect.Value, depth int) error {
+		if err := checkWire(wt, c.wire); err != nil {
+			return err
+		}
+		return c.dec(r, v, depth)
+	}
+	return enc, dec
+}
+
+// compileSlice returns the functions for a slice field, packed into one
+// run for numbers and repeated otherwise. Packed fields also decode from
+// repeated elements, as written for a singular field.
+func compileSlice(t reflect.Type, building map[reflect.Type]*structInfo) (encodeFunc, decodeFunc) {
+	et := t.Elem()
+	elem := compileValue(et, building)
+	appendElem := func(r *reader, wt wireType, v reflect.Value, depth int) error {
+		if err := checkWire(wt, elem.wire); err != nil {
+			return err
+		}
+		e := reflect.New(et).Elem()
+		if err := elem.dec(r, e, depth); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, e))
+		return nil
+	}
+
+	if !kindOf(et).packable() {
+		enc := func(b []byte, num int, v reflect.Value, depth int) ([]byte, error) {
+			for i := range v.Len() {
+				var err error
+				if b, err = elem.enc(appendKey(b, num, elem.wire), v.Index(i), depth); err != nil {
+					return b, err
+				}
+			}
+			return b, nil
+		}
+		return enc, appendElem
+	}
+
+	enc := func(b []byte, num int, v reflect.Value, depth int) ([]byte, error) {
+		n := v.Len()
+		if n == 0 {
+			return b, nil
+		}
+		b = appendKey(b, num, wireBytes)
+		start := len(b)
+		for i := range n {
+			b, _ = elem.enc(b, v.Index(i), depth)
+		}
+		return insertLen(b, start), nil
+	}
+	dec := func(r *reader, wt wireType, v reflect.Value, depth int) error {
+		if wt != wireBytes {
+			return appendElem(r, wt, v, depth)
+		}
+		sub, err := r.sub()
+		if err != nil {
+			return err
+		}
+		for !sub.done() {
+			if err := appendElem(sub, elem.wire, v, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return enc, dec
+}
+
+// compileMap returns the functions for a map field, a repeated field of
+// entries holding a key as field 1 and a value as field 2
+func compileMap(t reflect.Type, building map[reflect.Type]*structInfo) (encodeFunc, decodeFunc) {
+	kt, vt := t.Key(), t.Elem()
+	key, val := compileValue(kt, building), compileValue(vt, building)
+	enc := func(b []byte, num int, v reflect.Value, depth int) ([]byte, error) {
+		if v.Len() == 0 {
+			return b, nil
+		}
+		keys := v.MapKeys()
+		slices.SortFunc(keys, compareKeys)
+		for _, k := range keys {
+			b = appendKey(b, num, wireBytes)
+			start := len(b)
+			if !k.IsZero() {
+				b, _ = key.enc(appendKey(b, 1, key.wire), k, depth)
+			}
+			if e := v.MapIndex(k); !e.IsZero() {
+				var err error
+				if b, err = val.enc(appendKey(b, 2, val.wire), e, depth); err != nil {
+					return b, err
+				}
+			}
+			b = insertLen(b, start)
+		}
+		return b, nil
+	}
+	dec := func(r *reader, wt wireType, v reflect.Value, depth int) error {
+		if err := checkWire(wt, wireBytes); err != nil {
+			return err
+		}
+		sub, err := r.sub()
+		if err != nil {
+			return err
+		}
+		k, e := reflect.New(kt).Elem(), reflect.New(vt).Elem()
+		for !sub.done() {
+			num, wt, err := sub.key()
+			if err != nil {
+				return err
+			}
+			switch num {
+			case 1:
+				if err = checkWire(wt, key.wire); err == nil {
+					err = key.dec(sub, k, depth)
+				}
+			case 2:
+				if err = checkWire(wt, val.wire); err == nil {
+					err = val.dec(sub, e, depth)
+				}
+			default:
+				err = sub.skip(wt)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		v.SetMapIndex(k, e)
+		return nil
+	}
+	return enc, dec
+}
+
+// compareKeys orders map keys so that maps encode deterministically
+func compareKeys(a, b reflect.Value) int {
+	switch kindOf(a.Type()) {
+	case kindBool:
+		switch {
+		case a.Bool() == b.Bool():
+			return 0
+		case b.Bool():
+			return -1
+		}
+		return 1
+	case kindInt:
+		return cmp.Compare(a.Int(), b.Int())
+	case kindUint:
+		return cmp.Compare(a.Uint(), b.Uint())
+	}
+	return cmp.Compare(a.String(), b.String())
+}
+
+// compileValue returns the functions for single values of type t
+func compileValue(t reflect.Type, building map[reflect.Type]*structInfo) valueCoder {
+	k := kindOf(t)
+	c := valueCoder{wire: k.wire()}
+	switch k {
+	case kindBool:
+		c.enc = func(b []byte, v reflect.Value, _ int) ([]byte, error) {
+			if v.Bool() {
+				return append(b, 1), nil
+			}
+			return append(b, 0), nil
+		}
+		c.dec = func(r *reader, v reflect.Value, _ int) error {
+			u, err := r.uvarint()
+			v.SetBool(u != 0)
+			return err
+		}
+	case kindInt:
+		c.enc = func(b []byte, v reflect.Value, _ int) ([]byte, error) {
+			return binary.AppendUvarint(b, zigzag(v.Int())), nil
+		}
+		c.dec = func(r *reader, v reflect.Value, _ int) error {
+			u, err := r.uvarint()
+			if err != nil {
+				return err
+			}
+			i := unzigzag(u)
+			if v.OverflowInt(i) {
+				return fmt.Errorf("%w: %d in %v", ErrOverflow, i, v.Type())
+			}
+			v.SetInt(i)
+			return nil
+		}
+	case kindUint:
+		c.enc = func(b []byte, v reflect.Value, _ int) ([]byte, error) {
+			return binary.AppendUvarint(b, v.Uint()), nil
+		}
+		c.dec = func(r *reader, v reflect.Value, _ int) error {
+			u, err := r.uvarint()
+			if err != nil {
+				return err
+			}
+			if v.OverflowUint(u) {
+				return fmt.Errorf("%w: %d in %v", ErrOverflow, u, v.Type())
+			}
+			v.SetUint(u)
+			return nil
+		}
+	case kindFloat32:
+		c.enc = func(b []byte, v reflect.Value, _ int) ([]byte, error) {
+			return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v.Float()))), nil
+		}
+		c.dec = func(r *reader, v reflect.Value, _ int) error {
+			u, err := r.fixed32()
+			v.SetFloat(float64(math.Float32frombits(u)))
+			return err
+		}
+	case kindFloat64:
+		c.enc = func(b []byte, v reflect.Value, _ int) ([]byte, error) {
+			return binary.LittleEndian.AppendUint64(b, math.Float64bits(v.Float())), nil
+		}
+		c.dec = func(r *reader, v reflect.Value, _ int) error {
+			u, err := r.fixed64()
+			v.SetFloat(math.Float64frombits(u))
+			return err
+		}
+	case kindString:
+		c.enc = func(b []byte, v reflect.Value, _ int) ([]byte, error) {
+			return appendString(b, v.String()), nil
+		}
+		c.dec = func(r *reader, v reflect.Value, _ int) error {
+			p, err := r.bytes()
+			v.SetString(string(p))
+			return err
+		}
+	case kindBytes:
+		c.enc = func(b []byte, v reflect.Value, _ int) ([]byte, error) {
+			return appendBytes(b, v.Bytes()), nil
+		}
+		c.dec = func(r *reader, v reflect.Value, _ int) error {
+			p, err := r.bytes()
+			v.SetBytes(bytes.Clone(p))
+			return err
+		}
+	case kindBinary:
+		c.enc = func(b []byte, v reflect.Value, _ int) ([]byte, error) {
+			p, err := v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+			if err != nil {
+				return b, err
+			}
+			return appendBytes(b, p), nil
+		}
+		c.dec = func(r *reader, v reflect.Value, _ int) error {
+			p, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			return v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(p)
+		}
+	case kindStruct:
+		s := build(t, building)
+		c.enc = func(b []byte, v reflect.Value, depth int) ([]byte, error) {
+			start := len(b)
+			b, err := s.encode(b, v, depth+1)
+			if err != nil {
+				return b, err
+			}
+			return insertLen(b, start), nil
+		}
+		c.dec = func(r *reader, v reflect.Value, depth int) error {
+			sub, err := r.sub()
+			if err != nil {
+				return err
+			}
+			return s.decode(sub, v, depth+1)
+		}
+	case kindPointer:
+		et := t.Elem()
+		elem := compileValue(et, building)
+		zero := reflect.Zero(et)
+		c.wire = elem.wire
+		// A nil element of a slice is written as the zero value, and
+		// read back as a pointer to one; nil map values are left out
+		c.enc = func(b []byte, v reflect.Value, depth int) ([]byte, error) {
+			if v.IsNil() {
+				return elem.enc(b, zero, depth)
+			}
+			return elem.enc(b, v.Elem(), depth)
+		}
+		c.dec = func(r *reader, v reflect.Value, depth int) error {
+			if v.IsNil() {
+				v.Set(reflect.New(et))
+			}
+			return elem.dec(r, v.Elem(), depth)
+		}
+	}
+	return c
+}
@@ -0,0 +1,150 @@
+package bincodec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"testing"
+)
+
+// TestCodecAppend tests encoding into a reused buffer
+func TestCodecAppend(t *testing.T) {
+	codec := NewCodec[UserV1]()
+	buf := []byte("prefix")
+	buf, err := codec.Append(buf, &UserV1{ID: 1, Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u UserV1
+	if !bytes.HasPrefix(buf, []byte("prefix")) || codec.Unmarshal(buf[6:], &u) != nil || u.Name != "a" {
+		t.Errorf("Append = %q decoding to %+v", buf, u)
+	}
+
+	// Nothing is appended on failure
+	cycle := &Node{}
+	cycle.Next = cycle
+	out, err := NewCodec[Node]().Append(buf[:6], cycle)
+	if !errors.Is(err, ErrTooDeep) || string(out) != "prefix" {
+		t.Errorf("Append of a cycle = %q, %v", out, err)
+	}
+}
+
+// TestCodecConcurrent tests sharing a Codec, and building types at once
+func TestCodecConcurrent(t *testing.T) {
+	type Fresh struct {
+		Items []Address `bin:"1"`
+		Owner Person    `bin:"2"`
+	}
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			codec := NewCodec[Fresh]()
+			in := Fresh{Items: []Address{{Zip: uint32(i)}}, Owner: Person{Name: "x"}}
+			data, err := codec.Marshal(&in)
+			var out Fresh
+			if err == nil {
+				err = codec.Unmarshal(data, &out)
+			}
+			if err != nil || out.Items[0].Zip != uint32(i) || out.Owner.Name != "x" {
+				t.Errorf("round trip = %+v, %v", out, err)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// benchmarkPerson returns a typical message for benchmarks, without
+// time.Time or maps, which gob and json treat differently
+func benchmarkPerson() Person {
+	r := rand.New(rand.NewPCG(5, 6))
+	email := "ada@example.com"
+	return Person{
+		ID:       r.Int64(),
+		Name:     "Ada Lovelace",
+		Email:    &email,
+		Scores:   []int32{90, 85, -3, 1000, 12},
+		Tags:     []string{"math", "engines", "poetry"},
+		Address:  Address{Street: "12 St James's Square", Zip: 10001},
+		Previous: []*Address{{Street: "Marylebone", Zip: 20002}},
+		Ratio:    0.618,
+		Weight:   55.5,
+		Active:   true,
+		Avatar:   bytes.Repeat([]byte{0xab}, 64),
+		Level:    3,
+	}
+}
+
+// BenchmarkMarshal compares encoding a message with Codec, with Marshal,
+// and with encoding/json and encoding/gob, reporting the encoded size
+func BenchmarkMarshal(b *testing.B) {
+	p := benchmarkPerson()
+	b.Run("Codec", func(b *testing.B) {
+		codec := NewCodec[Person]()
+		var buf []byte
+		for b.Loop() {
+			buf, _ = codec.Append(buf[:0], &p)
+		}
+		b.ReportMetric(float64(len(buf)), "bytes/msg")
+	})
+	b.Run("Reflect", func(b *testing.B) {
+		var data []byte
+		for b.Loop() {
+			data, _ = Marshal(&p)
+		}
+		b.ReportMetric(float64(len(data)), "bytes/msg")
+	})
+	b.Run("JSON", func(b *testing.B) {
+		var data []byte
+		for b.Loop() {
+			data, _ = json.Marshal(&p)
+		}
+		b.ReportMetric(float64(len(data)), "bytes/msg")
+	})
+	// Each gob message gets a new Encoder, so that like the others it
+	// carries everything needed to decode it, type information included
+	b.Run("Gob", func(b *testing.B) {
+		var buf bytes.Buffer
+		for b.Loop() {
+			buf.Reset()
+			gob.NewEncoder(&buf).Encode(&p)
+		}
+		b.ReportMetric(float64(buf.Len()), "bytes/msg")
+	})
+}
+
+// BenchmarkUnmarshal compares decoding a message in the same ways
+func BenchmarkUnmarshal(b *testing.B) {
+	p := benchmarkPerson()
+	data, _ := Marshal(&p)
+	jsonData, _ := json.Marshal(&p)
+	var gobData bytes.Buffer
+	gob.NewEncoder(&gobData).Encode(&p)
+	b.Run("Codec", func(b *testing.B) {
+		codec := NewCodec[Person]()
+		var out Person
+		for b.Loop() {
+			codec.Unmarshal(data, &out)
+		}
+	})
+	b.Run("Reflect", func(b *testing.B) {
+		var out Person
+		for b.Loop() {
+			Unmarshal(data, &out)
+		}
+	})
+	b.Run("JSON", func(b *testing.B) {
+		for b.Loop() {
+			var out Person
+			json.Unmarshal(jsonData, &out)
+		}
+	})
+	b.Run("Gob", func(b *testing.B) {
+		for b.Loop() {
+			var out Person
+			gob.NewDecoder(bytes.NewReader(gobData.Bytes())).Decode(&out)
+		}
+	})
+}
@@ -0,0 +1,311 @@
+package bincodec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+)
+
+// Marshal returns the encoding of v, a struct or a pointer to one. It
+// interprets v with reflection, looking up how to encode each value as it
+// reaches it; a Codec does the same work faster for a type known in
+// advance, and the two write the same bytes. It panics if v is not a
+// struct or has a field that cannot be encoded.
+func Marshal(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		panic(fmt.Sprintf("bincodec: Marshal of %T, want a struct", v))
+	}
+	b, err := encodeStruct(nil, rv, 0)
+	if err != nil {
+		return nil, messageError(-1, err)
+	}
+	return b, nil
+}
+
+// Unmarshal decodes data into v, which must be a non-nil pointer to a
+// struct, after setting the struct to its zero value. The result shares
+// no memory with data. It panics if v is not such a pointer or has a
+// field that cannot be encoded.
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		panic(fmt.Sprintf("bincodec: Unmarshal into %T, want a non-nil pointer to a struct", v))
+	}
+	rv = rv.Elem()
+	structOf(rv.Type())
+	rv.SetZero()
+	return decodeStruct(&reader{buf: data}, rv, 0)
+}
+
+// encodeStruct appends the fields of the struct v
+func encodeStruct(b []byte, v reflect.Value, depth int) ([]byte, error) {
+	if depth >= MaxDepth {
+		return b, ErrTooDeep
+	}
+	for _, f := range structOf(v.Type()).fields {
+		var err error
+		if b, err = encodeField(b, f.num, v.Field(f.index), depth); err != nil {
+			return b, inField(f.name, -1, err)
+		}
+	}
+	return b, nil
+}
+
+// encodeField appends field num holding v, or nothing if v is left out
+func encodeField(b []byte, num int, v reflect.Value, depth int) ([]byte, error) {
+	var err error
+	switch kindOf(v.Type()) {
+	case kindSlice:
+		if v.Len() == 0 {
+			return b, nil
+		}
+		et := v.Type().Elem()
+		if kindOf(et).packable() {
+			b = appendKey(b, num, wireBytes)
+			start := len(b)
+			for i := range v.Len() {
+				b, _ = encodeValue(b, v.Index(i), depth)
+			}
+			return insertLen(b, start), nil
+		}
+		for i := range v.Len() {
+			if b, err = encodeValue(appendKey(b, num, wireOf(et)), v.Index(i), depth); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case kindMap:
+		keys := v.MapKeys()
+		slices.SortFunc(keys, compareKeys)
+		for _, k := range keys {
+			b = appendKey(b, num, wireBytes)
+			start := len(b)
+			if !k.IsZero() {
+				b, _ = encodeValue(appendKey(b, 1, wireOf(k.Type())), k, depth)
+			}
+			if e := v.MapIndex(k); !e.IsZero() {
+				if b, err = encodeValue(appendKey(b, 2, wireOf(e.Type())), e, depth); err != nil {
+					return b, err
+				}
+			}
+			b = insertLen(b, start)
+		}
+		return b, nil
+	}
+	if v.IsZero() {
+		return b, nil
+	}
+	return encodeValue(appendKey(b, num, wireOf(v.Type())), v, depth)
+}
+
+// encodeValue appends v without a key
+func encodeValue(b []byte, v reflect.Value, depth int) ([]byte, error) {
+	switch kindOf(v.Type()) {
+	case kindBool:
+		if v.Bool() {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case kindInt:
+		return binary.AppendUvarint(b, zigzag(v.Int())), nil
+	case kindUint:
+		return binary.AppendUvarint(b, v.Uint()), nil
+	case kindFloat32:
+		return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v.Float()))), nil
+	case kindFloat64:
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v.Float())), nil
+	case kindString:
+		return appendString(b, v.String()), nil
+	case kindBytes:
+		return appendBytes(b, v.Bytes()), nil
+	case kindBinary:
+		p, err := v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			return b, err
+		}
+		return appendBytes(b, p), nil
+	case kindStruct:
+		start := len(b)
+		b, err := encodeStruct(b, v, depth+1)
+		if err != nil {
+			return b, err
+		}
+		return insertLen(b, start), nil
+	case kindPointer:
+		if v.IsNil() {
+			return encodeValue(b, reflect.Zero(v.Type().Elem()), depth)
+		}
+		return encodeValue(b, v.Elem(), depth)
+	}
+	panic(fmt.Sprintf("bincodec: cannot encode a single %v", v.Type()))
+}
+
+// decodeStruct reads fields from r into the struct v, skipping fields it
+// does not have
+func decodeStruct(r *reader, v reflect.Value, depth int) error {
+	if depth >= MaxDepth {
+		return &FieldError{Offset: r.pos(), Err: ErrTooDeep}
+	}
+	s := structOf(v.Type())
+	hint := 0
+	for !r.done() {
+		at := r.pos()
+		num, wt, err := r.key()
+		if err != nil {
+			return &FieldError{Offset: at, Err: err}
+		}
+		i := s.find(num, hint)
+		if i < 0 {
+			if err := r.skip(wt); err != nil {
+				return &FieldError{Offset: at, Err: err}
+			}
+			continue
+		}
+		f := &s.fields[i]
+		hint = i + 1
+		at = r.pos()
+		if err := decodeField(r, wt, v.Field(f.index), depth); err != nil {
+			return inField(f.name, at, err)
+		}
+	}
+	return nil
+}
+
+// decodeField decodes a value of a field written as wt into v, appending
+// to slices and adding to maps
+func decodeField(r *reader, wt wireType, v reflect.Value, depth int) error {
+	t := v.Type()
+	switch kindOf(t) {
+	case kindSlice:
+		if !kindOf(t.Elem()).packable() || wt != wireBytes {
+			return decodeElem(r, wt, v, depth)
+		}
+		sub, err := r.sub()
+		if err != nil {
+			return err
+		}
+		for !sub.done() {
+			if err := decodeElem(sub, wireOf(t.Elem()), v, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	case kindMap:
+		if err := checkWire(wt, wireBytes); err != nil {
+			return err
+		}
+		sub, err := r.sub()
+		if err != nil {
+			return err
+		}
+		k, e := reflect.New(t.Key()).Elem(), reflect.New(t.Elem()).Elem()
+		for !sub.done() {
+			num, wt, err := sub.key()
+			if err != nil {
+				return err
+			}
+			switch num {
+			case 1:
+				err = decodeValue(sub, wt, k, depth)
+			case 2:
+				err = decodeValue(sub, wt, e, depth)
+			default:
+				err = sub.skip(wt)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		v.SetMapIndex(k, e)
+		return nil
+	}
+	return decodeValue(r, wt, v, depth)
+}
+
+// decodeElem decodes an element written as wt and appends it to the
+// slice v
+func decodeElem(r *reader, wt wireType, v reflect.Value, depth int) error {
+	e := reflect.New(v.Type().Elem()).Elem()
+	if err := decodeValue(r, wt, e, depth); err != nil {
+		return err
+	}
+	v.Set(reflect.Append(v, e))
+	return nil
+}
+
+// decodeValue decodes a single value written as wt into v
+func decodeValue(r *reader, wt wireType, v reflect.Value, depth int) error {
+	if err := checkWire(wt, wireOf(v.Type())); err != nil {
+		return err
+	}
+	switch kindOf(v.Type()) {
+	case kindBool:
+		u, err := r.uvarint()
+		v.SetBool(u != 0)
+		return err
+	case kindInt:
+		u, err := r.uvarint()
+		if err != nil {
+			return err
+		}
+		i := unzigzag(u)
+		if v.OverflowInt(i) {
+			return fmt.Errorf("%w: %d in %v", ErrOverflow, i, v.Type())
+		}
+		v.SetInt(i)
+	case kindUint:
+		u, err := r.uvarint()
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(u) {
+			return fmt.Errorf("%w: %d in %v", ErrOverflow, u, v.Type())
+		}
+		v.SetUint(u)
+	case kindFloat32:
+		u, err := r.fixed32()
+		v.SetFloat(float64(math.Float32frombits(u)))
+		return err
+	case kindFloat64:
+		u, err := r.fixed64()
+		v.SetFloat(math.Float64frombits(u))
+		return err
+	case kindString:
+		p, err := r.bytes()
+		v.SetString(string(p))
+		return err
+	case kindBytes:
+		p, err := r.bytes()
+		v.SetBytes(bytes.Clone(p))
+		return err
+	case kindBinary:
+		p, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		return v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(p)
+	case kindStruct:
+		sub, err := r.sub()
+		if err != nil {
+			return err
+		}
+		return decodeStruct(sub, v, depth+1)
+	case kindPointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(r, wt, v.Elem(), depth)
+	}
+	return nil
+}
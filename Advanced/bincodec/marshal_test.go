@@ -0,0 +1,195 @@
+package bincodec
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Level is a named integer type
+type Level uint8
+
+// Address is a nested message
+type Address struct {
+	Street string `bin:"1"`
+	Zip    uint32 `bin:"2"`
+}
+
+// Person uses every kind of field
+type Person struct {
+	ID       int64               `bin:"1"`
+	Name     string              `bin:"2"`
+	Email    *string             `bin:"3"`
+	Scores   []int32             `bin:"4"`
+	Tags     []string            `bin:"5"`
+	Address  Address             `bin:"6"`
+	Previous []*Address          `bin:"7"`
+	Attrs    map[string]int      `bin:"8"`
+	Ratio    float64             `bin:"9"`
+	Weight   float32             `bin:"10"`
+	Active   bool                `bin:"11"`
+	Avatar   []byte              `bin:"12"`
+	Born     time.Time           `bin:"13"`
+	Manager  *Person             `bin:"14"`
+	Homes    map[int8]*Address   `bin:"15"`
+	Level    Level               `bin:"16"`
+	Flags    []bool              `bin:"17"`
+	Counts   *uint16             `bin:"18"`
+	Groups   map[bool][]byte     `bin:"19"`
+	Matrix   [][]byte            `bin:"20"`
+	Notes    string              // Untagged, so not encoded
+	Ignored  map[string]Person   `bin:"-"`
+	Scratch  map[uint64]struct{} `bin:"-"`
+	Children []Person            `bin:"100000"`
+}
+
+// randomPerson returns a Person with fields set at random, nesting at most
+// depth managers and children
+func randomPerson(r *rand.Rand, depth int) Person {
+	var p Person
+	if r.IntN(2) == 0 {
+		p.ID = r.Int64() - r.Int64()
+	}
+	p.Name = []string{"", "Ada", "Grace Hopper", "Ünïcode ✓"}[r.IntN(4)]
+	if r.IntN(3) == 0 {
+		email := []string{"", "ada@example.com"}[r.IntN(2)]
+		p.Email = &email
+	}
+	for range r.IntN(4) {
+		p.Scores = append(p.Scores, r.Int32()-r.Int32())
+		p.Tags = append(p.Tags, []string{"", "x", "long tag"}[r.IntN(3)])
+		p.Flags = append(p.Flags, r.IntN(2) == 0)
+		p.Matrix = append(p.Matrix, []byte("row"))
+	}
+	p.Address = Address{Street: []string{"", "Main St"}[r.IntN(2)], Zip: r.Uint32N(3) * 70000}
+	for range r.IntN(3) {
+		p.Previous = append(p.Previous, &Address{Zip: r.Uint32()})
+	}
+	if r.IntN(4) == 0 {
+		p.Previous = append(p.Previous, nil)
+	}
+	if r.IntN(2) == 0 {
+		p.Attrs = map[string]int{"": r.IntN(3), "b": -r.IntN(100000), "c": 0}
+		p.Homes = map[int8]*Address{-1: {Street: "x"}, 0: {}, 5: nil}
+		p.Groups = map[bool][]byte{true: []byte("yes"), false: nil}
+	}
+	p.Ratio = r.NormFloat64()
+	p.Weight = float32(r.Float64())
+	p.Active = r.IntN(2) == 0
+	if r.IntN(2) == 0 {
+		p.Avatar = make([]byte, r.IntN(300))
+		for i := range p.Avatar {
+			p.Avatar[i] = byte(r.Uint32())
+		}
+	}
+	if r.IntN(2) == 0 {
+		p.Born = time.Date(1900+r.IntN(200), 1, 2, 3, 4, 5, r.IntN(1e9), time.UTC)
+	}
+	p.Level = Level(r.UintN(256))
+	if r.IntN(3) == 0 {
+		n := uint16(r.UintN(3))
+		p.Counts = &n
+	}
+	if depth > 0 {
+		if r.IntN(2) == 0 {
+			m := randomPerson(r, depth-1)
+			p.Manager = &m
+		}
+		for range r.IntN(3) {
+			p.Children = append(p.Children, randomPerson(r, depth-1))
+		}
+	}
+	return p
+}
+
+// normalize changes p as encoding does: untagged fields are lost, and
+// nil elements of slices point to zero values
+func normalize(p *Person) {
+	if p == nil {
+		return
+	}
+	p.Notes = ""
+	for i, a := range p.Previous {
+		if a == nil {
+			p.Previous[i] = &Address{}
+		}
+	}
+	normalize(p.Manager)
+	for i := range p.Children {
+		normalize(&p.Children[i])
+	}
+}
+
+// TestRoundTrip tests that both paths write the same bytes and read back
+// the same values
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	codec := NewCodec[Person]()
+	for range 300 {
+		p := randomPerson(r, 2)
+		p.Notes = "dropped"
+		data, err := Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compiled, err := codec.Marshal(&p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, compiled) {
+			t.Fatalf("Marshal and Codec.Marshal differ for %+v:\n% x\n% x", p, data, compiled)
+		}
+
+		var viaReflect, viaCodec Person
+		if err := Unmarshal(data, &viaReflect); err != nil {
+			t.Fatal(err)
+		}
+		if err := codec.Unmarshal(data, &viaCodec); err != nil {
+			t.Fatal(err)
+		}
+		normalize(&p)
+		if !reflect.DeepEqual(viaReflect, p) {
+			t.Fatalf("Unmarshal = %+v, want %+v", viaReflect, p)
+		}
+		if !reflect.DeepEqual(viaCodec, p) {
+			t.Fatalf("Codec.Unmarshal = %+v, want %+v", viaCodec, p)
+		}
+	}
+}
+
+// TestUnmarshalResets tests that decoding replaces what was in the target
+// and keeps no memory of the data
+func TestUnmarshalResets(t *testing.T) {
+	data, _ := Marshal(Person{Name: "new", Avatar: []byte{1, 2}})
+	p := Person{ID: 7, Tags: []string{"old"}}
+	if err := Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != 0 || p.Tags != nil || p.Name != "new" {
+		t.Errorf("Unmarshal into a used value = %+v", p)
+	}
+	for i := range data {
+		data[i] = 0
+	}
+	if p.Name != "new" || !bytes.Equal(p.Avatar, []byte{1, 2}) {
+		t.Errorf("decoded value changed with the data: %+v", p)
+	}
+}
+
+// TestMapOrder tests that maps encode the same whatever their iteration
+// order
+func TestMapOrder(t *testing.T) {
+	m := make(map[string]int)
+	for i := range 50 {
+		m[string(rune('a'+i%26))+string(rune('a'+i/26))] = i
+	}
+	first, _ := Marshal(Person{Attrs: m})
+	for range 20 {
+		again, _ := Marshal(Person{Attrs: m})
+		if !bytes.Equal(first, again) {
+			t.Fatal("two encodings of one map differ")
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package bincodec
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// wireType is how a value is written, which lets a reader skip fields it
+// does not know
+type wireType uint8
+
+const (
+	wireVarint  wireType = 0 // A varint
+	wireFixed64 wireType = 1 // 8 little-endian bytes
+	wireBytes   wireType = 2 // A varint length and that many bytes
+	wireFixed32 wireType = 5 // 4 little-endian bytes
+)
+
+// String names wt
+func (wt wireType) String() string {
+	switch wt {
+	case wireVarint:
+		return "varint"
+	case wireFixed64:
+		return "fixed64"
+	case wireBytes:
+		return "bytes"
+	case wireFixed32:
+		return "fixed32"
+	}
+	return fmt.Sprintf("wireType(%d)", uint8(wt))
+}
+
+// appendKey appends the key of field num written as wt
+func appendKey(b []byte, num int, wt wireType) []byte {
+	return binary.AppendUvarint(b, uint64(num)<<3|uint64(wt))
+}
+
+// appendBytes appends p with its length
+func appendBytes(b, p []byte) []byte {
+	return append(binary.AppendUvarint(b, uint64(len(p))), p...)
+}
+
+// appendString appends s with its length
+func appendString(b []byte, s string) []byte {
+	return append(binary.AppendUvarint(b, uint64(len(s))), s...)
+}
+
+// insertLen prefixes what was appended to b from start with its length.
+// Writing the body first and moving it along avoids encoding it twice, once
+// to measure it.
+func insertLen(b []byte, start int) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(b)-start))
+	b = append(b, buf[:n]...)
+	copy(b[start+n:], b[start:len(b)-n])
+	copy(b[start:], buf[:n])
+	return b
+}
+
+// zigzag maps signed integers to unsigned ones so that numbers near zero,
+// negative or not, have short varints
+func zigzag(i int64) uint64 {
+	return uint64(i<<1) ^ uint64(i>>63)
+}
+
+// unzigzag reverses zigzag
+func unzigzag(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+// reader reads values from data, tracking offsets for errors
+type reader struct {
+	buf  []byte
+	off  int
+	base int // The offset of buf within the whole data
+}
+
+// done reports whether all of buf has been read
+func (r *reader) done() bool {
+	return r.off >= len(r.buf)
+}
+
+// pos returns the offset of the next byte within the whole data
+func (r *reader) pos() int {
+	return r.base + r.off
+}
+
+// uvarint reads a varint
+func (r *reader) uvarint() (uint64, error) {
+	u, n := binary.Uvarint(r.buf[r.off:])
+	if n == 0 {
+		return 0, ErrTruncated
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%w: varint overflows 64 bits", ErrMalformed)
+	}
+	r.off += n
+	return u, nil
+}
+
+// fixed32 reads 4 little-endian bytes
+func (r *reader) fixed32() (uint32, error) {
+	if len(r.buf)-r.off < 4 {
+		return 0, ErrTruncated
+	}
+	u := binary.LittleEndian.Uint32(r.buf[r.off:])
+	r.off += 4
+	return u, nil
+}
+
+// fixed64 reads 8 little-endian bytes
+func (r *reader) fixed64() (uint64, error) {
+	if len(r.buf)-r.off < 8 {
+		return 0, ErrTruncated
+	}
+	u := binary.LittleEndian.Uint64(r.buf[r.off:])
+	r.off += 8
+	return u, nil
+}
+
+// bytes reads a length-prefixed run of bytes, which shares memory with
+// the data
+func (r *reader) bytes() ([]byte, error) {
+	n, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	// Secure: the length is checked against the data left, so a crafted
+	// length cannot make a reader allocate or read past the end
+	if n > uint64(len(r.buf)-r.off) {
+		return nil, ErrTruncated
+	}
+	b := r.buf[r.off : r.off+int(n)]
+	r.off += int(n)
+	return b, nil
+}
+
+// sub reads a length-prefixed run of bytes as a reader of its own
+func (r *reader) sub() (*reader, error) {
+	b, err := r.bytes()
+	if err != nil {
+		return nil, err
+	}
+	return &reader{buf: b, base: r.pos() - len(b)}, nil
+}
+
+// key reads the key of a field
+func (r *reader) key() (int, wireType, error) {
+	u, err := r.uvarint()
+	if err != nil {
+		return 0, 0, err
+	}
+	num, wt := u>>3, wireType(u&7)
+	if num == 0 || num > maxField {
+		return 0, 0, fmt.Errorf("%w: field number %d", ErrMalformed, num)
+	}
+	switch wt {
+	case wireVarint, wireFixed64, wireBytes, wireFixed32:
+	default:
+		return 0, 0, fmt.Errorf("%w: %v", ErrMalformed, wt)
+	}
+	return int(num), wt, nil
+}
+
+// skip reads past a value written as wt
+func (r *reader) skip(wt wireType) error {
+	var err error
+	switch wt {
+	case wireVarint:
+		_, err = r.uvarint()
+	case wireFixed64:
+		_, err = r.fixed64()
+	case wireBytes:
+		_, err = r.bytes()
+	case wireFixed32:
+		_, err = r.fixed32()
+	}
+	return err
+}
+
+// checkWire returns an ErrWireType error if got is not want
+func checkWire(got, want wireType) error {
+	if got != want {
+		return fmt.Errorf("%w: %v, want %v", ErrWireType, got, want)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package bincodec
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestZigzag tests that small numbers of either sign map to small ones
+func TestZigzag(t *testing.T) {
+	tests := []struct {
+		i int64
+		u uint64
+	}{
+		{0, 0}, {-1, 1}, {1, 2}, {-2, 3}, {2, 4},
+		{math.MaxInt64, math.MaxUint64 - 1}, {math.MinInt64, math.MaxUint64},
+	}
+	for _, tt := range tests {
+		if got := zigzag(tt.i); got != tt.u {
+			t.Errorf("zigzag(%d) = %d, want %d", tt.i, got, tt.u)
+		}
+		if got := unzigzag(tt.u); got != tt.i {
+			t.Errorf("unzigzag(%d) = %d, want %d", tt.u, got, tt.i)
+		}
+	}
+}
+
+// TestInsertLen tests prefixing bodies with lengths of one or more bytes
+func TestInsertLen(t *testing.T) {
+	for _, n := range []int{0, 1, 127, 128, 300, 20000} {
+		body := bytes.Repeat([]byte{7}, n)
+		b := insertLen(append([]byte("key"), body...), 3)
+		r := reader{buf: b, off: 3}
+		got, err := r.bytes()
+		if err != nil || !bytes.Equal(got, body) || !r.done() {
+			t.Errorf("insertLen of %d bytes read back %d bytes, %v", n, len(got), err)
+		}
+	}
+}
+
+// TestWireFormat tests the bytes of a small message
+func TestWireFormat(t *testing.T) {
+	type message struct {
+		Name  string  `bin:"1"`
+		Zip   uint32  `bin:"2"`
+		Delta int     `bin:"3"`
+		Ratio float32 `bin:"4"`
+		Nums  []int   `bin:"5"`
+		Unset string  `bin:"6"`
+	}
+	got, err := Marshal(message{Name: "ab", Zip: 300, Delta: -2, Ratio: 1, Nums: []int{1, -1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0x0a, 2, 'a', 'b', // Field 1, bytes
+		0x10, 0xac, 0x02, // Field 2, varint 300
+		0x18, 3, // Field 3, varint zigzag(-2)
+		0x25, 0, 0, 0x80, 0x3f, // Field 4, fixed32 1.0
+		0x2a, 2, 2, 1, // Field 5, packed zigzag(1) zigzag(-1)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Marshal = % x, want % x", got, want)
+	}
+}
+
+// TestReaderErrors tests data that is cut short or malformed
+func TestReaderErrors(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want error
+	}{
+		{[]byte{0x08}, ErrTruncated},
+		{[]byte{0x80}, ErrTruncated},
+		{[]byte{0x0a, 5, 'a'}, ErrTruncated},
+		{[]byte{0x0d, 1, 2}, ErrTruncated},
+		{[]byte{0x09, 1, 2, 3, 4, 5, 6, 7}, ErrTruncated},
+		{[]byte{0x00}, ErrMalformed},
+		{[]byte{0x0b}, ErrMalformed},
+		{[]byte{0x0c}, ErrMalformed},
+		{bytes.Repeat([]byte{0xff}, 11), ErrMalformed},
+		{[]byte{0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, ErrTruncated},
+	}
+	type empty struct{}
+	for _, tt := range tests {
+		err := Unmarshal(tt.data, &empty{})
+		var fe *FieldError
+		if !errors.Is(err, tt.want) || !errors.As(err, &fe) {
+			t.Errorf("Unmarshal(% x) = %v, want %v", tt.data, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package caches
+
+import "time"
+
+// ARC is an adaptive replacement cache, after Megiddo and Modha. It keeps
+// entries seen once lately in a recency list T1 and those seen again in a
+// frequency list T2, and remembers the keys it evicted from each in ghost
+// lists B1 and B2. A miss on a key in B1 shows T1 was evicting too early
+// and grows its target share p of the cache; a miss on a key in B2 shrinks
+// it. ARC so tracks LRU on recency-heavy workloads, resists scans that
+// would flush an LRU cache, and needs no tuning. Ghosts hold keys only,
+// at most MaxEntries of them.
+type ARC[K comparable, V any] struct {
+	core[K, V]
+	t1, t2, b1, b2 *list[K, V]
+	ghosts         map[K]*entry[K, V] // Entries of B1 and B2, without values
+	p              int                // Target length of T1
+	size           int                // MaxEntries
+}
+
+// NewARC creates an empty ARC cache. ARC adapts relative to its size, so
+// MaxEntries below 1 is raised to 1.
+func NewARC[K comparable, V any](opts Options) *ARC[K, V] {
+	opts.MaxEntries = max(opts.MaxEntries, 1)
+	return &ARC[K, V]{
+		core:   newCore[K, V](opts),
+		t1:     newList[K, V](),
+		t2:     newList[K, V](),
+		b1:     newList[K, V](),
+		b2:     newList[K, V](),
+		ghosts: make(map[K]*entry[K, V]),
+		size:   opts.MaxEntries,
+	}
+}
+
+// drop removes the live entry e from the cache
+func (c *ARC[K, V]) drop(e *entry[K, V]) {
+	delete(c.entries, e.key)
+	e.list.remove(e)
+}
+
+// forget removes the ghost e
+func (c *ARC[K, V]) forget(e *entry[K, V]) {
+	delete(c.ghosts, e.key)
+	e.list.remove(e)
+}
+
+// Get returns the value of key and whether it was present and unexpired,
+// moving it to the frequency list
+// Time Complexity: O(1)
+func (c *ARC[K, V]) Get(key K) (V, bool) {
+	e := c.lookup(key, c.drop)
+	if e == nil {
+		var zero V
+		return zero, false
+	}
+	c.t2.moveToFront(e)
+	return e.value, true
+}
+
+// Set sets the value of key. A live key moves to the frequency list, a
+// ghost adapts p and returns to the frequency list, and a new key enters
+// the recency list, each evicting an entry into a ghost list if the cache
+// was full.
+// Time Complexity: O(1)
+func (c *ARC[K, V]) Set(key K, value V) {
+	if e, ok := c.entries[key]; ok {
+		e.value, e.expires = value, c.expiry()
+		c.t2.moveToFront(e)
+		return
+	}
+
+	if g, ok := c.ghosts[key]; ok {
+		inB2 := g.list == c.b2
+		if inB2 {
+			c.p = max(c.p-max(c.b1.len/c.b2.len, 1), 0)
+		} else {
+			c.p = min(c.p+max(c.b2.len/c.b1.len, 1), c.size)
+		}
+		c.forget(g)
+		c.replace(inB2)
+		c.insert(c.t2, key, value)
+		return
+	}
+
+	switch total := c.t1.len + c.t2.len + c.b1.len + c.b2.len; {
+	case c.t1.len+c.b1.len >= c.size:
+		if c.t1.len < c.size {
+			c.forget(c.b1.back())
+			c.replace(false)
+		} else {
+			// B1 is empty and T1 fills the cache: evict without a ghost
+			c.drop(c.t1.back())
+			c.stats.Evictions++
+		}
+	case total >= c.size:
+		if total >= 2*c.size {
+			c.forget(c.b2.back())
+		}
+		c.replace(false)
+	}
+	c.insert(c.t1, key, value)
+}
+
+// insert adds a live entry for key at the front of l
+func (c *ARC[K, V]) insert(l *list[K, V], key K, value V) {
+	e := &entry[K, V]{key: key, value: value, expires: c.expiry()}
+	c.entries[key] = e
+	l.pushFront(e)
+}
+
+// replace makes room for an entry if the cache is full, evicting the least
+// recently used entry of T1 into B1 if T1 is beyond its target p, or of T2
+// into B2 otherwise. A key returning from B2 also takes from T1 when T1 is
+// exactly at its target.
+func (c *ARC[K, V]) replace(inB2 bool) {
+	if c.t1.len+c.t2.len < c.size {
+		return
+	}
+	from, to := c.t2, c.b2
+	if c.t2.len == 0 || c.t1.len > 0 && (c.t1.len > c.p || inB2 && c.t1.len == c.p) {
+		from, to = c.t1, c.b1
+	}
+	e := from.back()
+	c.drop(e)
+	var zero V
+	e.value, e.expires = zero, time.Time{}
+	c.ghosts[e.key] = e
+	to.pushFront(e)
+	c.stats.Evictions++
+}
+
+// Delete removes key and reports whether it was present. The key is also
+// forgotten as a ghost, so setting it again counts as new.
+// Time Complexity: O(1)
+func (c *ARC[K, V]) Delete(key K) bool {
+	if g, ok := c.ghosts[key]; ok {
+		c.forget(g)
+	}
+	e, ok := c.entries[key]
+	if ok {
+		c.drop(e)
+	}
+	return ok
+}
+
+// Len returns the number of entries, including expired ones not yet
+// removed, but not ghosts
+func (c *ARC[K, V]) Len() int {
+	return len(c.entries)
+}
+
+// RemoveExpired removes every expired entry and returns how many
+// Time Complexity: O(n)
+func (c *ARC[K, V]) RemoveExpired() int {
+	return c.removeExpired(c.drop)
+}
+
+// Target returns the target length of the recency list, the share of the
+// cache ARC has learned to give keys seen only once lately
+func (c *ARC[K, V]) Target() int {
+	return c.p
+}
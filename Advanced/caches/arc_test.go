@@ -0,0 +1,76 @@
+package caches
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// checkARC checks the bounds ARC keeps on its lists and that its maps and
+// lists agree
+func checkARC(t *testing.T, c *ARC[int, int]) {
+	t.Helper()
+	t1, t2, b1, b2 := c.t1.len, c.t2.len, c.b1.len, c.b2.len
+	switch {
+	case t1+t2 > c.size, t1+b1 > c.size, t1+t2+b1+b2 > 2*c.size:
+		t.Fatalf("list lengths %d, %d, %d, %d exceed size %d", t1, t2, b1, b2, c.size)
+	case len(c.entries) != t1+t2 || len(c.ghosts) != b1+b2:
+		t.Fatalf("%d entries and %d ghosts in lists of %d, %d, %d, %d", len(c.entries), len(c.ghosts), t1, t2, b1, b2)
+	case c.p < 0 || c.p > c.size:
+		t.Fatalf("target %d outside [0, %d]", c.p, c.size)
+	}
+	for k, e := range c.entries {
+		if _, ok := c.ghosts[k]; ok || e.list != c.t1 && e.list != c.t2 {
+			t.Fatalf("entry %d is not in exactly T1 or T2", k)
+		}
+	}
+	for k, g := range c.ghosts {
+		if g.list != c.b1 && g.list != c.b2 {
+			t.Fatalf("ghost %d is not in B1 or B2", k)
+		}
+	}
+}
+
+// TestARC tests the invariants under random workloads of shifting skew
+func TestARC(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	for _, size := range []int{1, 2, 5, 32} {
+		c := NewARC[int, int](Options{MaxEntries: size})
+		for i := range 20000 {
+			var key int
+			if i/2000%2 == 0 {
+				key = int(rng.ExpFloat64() * float64(size))
+			} else {
+				key = rng.IntN(4 * size)
+			}
+			if rng.IntN(50) == 0 {
+				c.Delete(key)
+			} else {
+				access(c, key)
+			}
+			checkARC(t, c)
+		}
+	}
+}
+
+// TestARCScanResistance tests that a one-time scan does not flush a hot set
+// from ARC as it does from LRU
+func TestARCScanResistance(t *testing.T) {
+	const size = 100
+	arc := NewARC[int, int](Options{MaxEntries: size})
+	lru := NewLRU[int, int](Options{MaxEntries: size})
+	rng := rand.New(rand.NewPCG(9, 10))
+	scan := 1000
+	for i := range 50000 {
+		key := rng.IntN(size / 2) // A hot set of half the cache
+		if i%3 == 0 {
+			key, scan = scan, scan+1 // Interleaved with keys used once
+		}
+		access(arc, key)
+		access(lru, key)
+	}
+	a, l := arc.Stats().HitRate(), lru.Stats().HitRate()
+	if a <= l {
+		t.Errorf("ARC hit rate %.3f is not above LRU's %.3f", a, l)
+	}
+	t.Logf("hit rates: ARC %.3f, LRU %.3f, ARC target %d of %d", a, l, arc.Target(), size)
+}
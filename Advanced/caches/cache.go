@@ -0,0 +1,184 @@
+// Package caches provides bounded in-memory caches with three eviction
+// policies: LRU evicts the least recently used entry, LFU the least
+// frequently used, and ARC adapts between recency and frequency as the
+// workload shifts. Each can also expire entries a fixed time after they
+// are set, and counts its hits, misses, evictions and expirations.
+//
+// The caches are not safe for concurrent use, since even a Get reorders
+// entries; wrap one with NewSynchronized to share it between goroutines.
+package caches
+
+import "time"
+
+// Cache is the interface the eviction policies share
+type Cache[K comparable, V any] interface {
+	// Get returns the value of key and whether it was present and unexpired,
+	// counting a hit or a miss
+	Get(key K) (V, bool)
+	// Set sets the value of key, evicting an entry if the cache is full, and
+	// restarts its time to live
+	Set(key K, value V)
+	// Delete removes key and reports whether it was present
+	Delete(key K) bool
+	// Len returns the number of entries, including expired ones not yet
+	// removed
+	Len() int
+	// RemoveExpired removes every expired entry and returns how many
+	RemoveExpired() int
+	// Stats returns the counters accumulated so far
+	Stats() Stats
+}
+
+// Options configures a cache. The zero value gives an unbounded cache whose
+// entries never expire.
+type Options struct {
+	// MaxEntries bounds the number of entries; 0 or less means no bound,
+	// except for ARC, which needs one and raises it to 1
+	MaxEntries int
+	// TTL is how long an entry lives after it is set; 0 or less means
+	// forever. Expired entries are removed when next looked up, or by
+	// RemoveExpired.
+	TTL time.Duration
+	// Now is the clock for TTL, time.Now if nil
+	Now func() time.Time
+}
+
+// Stats counts the outcomes of cache operations
+type Stats struct {
+	Hits        uint64 // Gets finding a live entry
+	Misses      uint64 // Gets finding none, expired entries included
+	Evictions   uint64 // Entries removed to make room
+	Expirations uint64 // Entries removed because their TTL had passed
+}
+
+// HitRate returns the fraction of Gets that hit, 0 before any Get
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// entry is a cache entry, linked into one of the lists of its cache
+type entry[K comparable, V any] struct {
+	key        K
+	value      V
+	expires    time.Time // Zero if the entry never expires
+	freq       int       // Uses of the entry, for LFU
+	list       *list[K, V]
+	prev, next *entry[K, V]
+}
+
+// list is a doubly linked list of entries, most recently used at the front,
+// closed into a ring through a sentinel
+type list[K comparable, V any] struct {
+	root entry[K, V]
+	len  int
+}
+
+// newList creates an empty list
+func newList[K comparable, V any]() *list[K, V] {
+	l := &list[K, V]{}
+	l.root.prev, l.root.next = &l.root, &l.root
+	return l
+}
+
+// pushFront inserts e at the front of l
+func (l *list[K, V]) pushFront(e *entry[K, V]) {
+	e.list, e.prev, e.next = l, &l.root, l.root.next
+	e.prev.next, e.next.prev = e, e
+	l.len++
+}
+
+// remove unlinks e from its list
+func (l *list[K, V]) remove(e *entry[K, V]) {
+	e.prev.next, e.next.prev = e.next, e.prev
+	// Secure: clear the links so a removed entry keeps no others alive
+	e.list, e.prev, e.next = nil, nil, nil
+	l.len--
+}
+
+// back returns the least recently used entry of l, or nil if it is empty
+func (l *list[K, V]) back() *entry[K, V] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// moveToFront marks e, already in a list, as most recently used in l
+func (l *list[K, V]) moveToFront(e *entry[K, V]) {
+	e.list.remove(e)
+	l.pushFront(e)
+}
+
+// core holds what every policy keeps: the options, the counters and the
+// index of entries by key
+type core[K comparable, V any] struct {
+	opts    Options
+	stats   Stats
+	entries map[K]*entry[K, V]
+}
+
+// newCore creates a core for the options
+func newCore[K comparable, V any](opts Options) core[K, V] {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return core[K, V]{opts: opts, entries: make(map[K]*entry[K, V])}
+}
+
+// full reports whether adding an entry would exceed MaxEntries
+func (c *core[K, V]) full(n int) bool {
+	return c.opts.MaxEntries > 0 && n >= c.opts.MaxEntries
+}
+
+// expiry returns the expiry time of an entry set now
+func (c *core[K, V]) expiry() time.Time {
+	if c.opts.TTL <= 0 {
+		return time.Time{}
+	}
+	return c.opts.Now().Add(c.opts.TTL)
+}
+
+// expired reports whether e has outlived its TTL
+func (c *core[K, V]) expired(e *entry[K, V]) bool {
+	return !e.expires.IsZero() && !c.opts.Now().Before(e.expires)
+}
+
+// Stats returns the counters accumulated so far
+func (c *core[K, V]) Stats() Stats {
+	return c.stats
+}
+
+// lookup returns the live entry of key, or nil, counting a hit or a miss.
+// An expired entry is removed with drop, which must also delete it from
+// entries.
+func (c *core[K, V]) lookup(key K, drop func(*entry[K, V])) *entry[K, V] {
+	e := c.entries[key]
+	if e != nil && c.expired(e) {
+		drop(e)
+		c.stats.Expirations++
+		e = nil
+	}
+	if e == nil {
+		c.stats.Misses++
+	} else {
+		c.stats.Hits++
+	}
+	return e
+}
+
+// removeExpired removes every expired entry with drop and returns how many
+// Time Complexity: O(n)
+func (c *core[K, V]) removeExpired(drop func(*entry[K, V])) int {
+	n := 0
+	for _, e := range c.entries {
+		if c.expired(e) {
+			drop(e)
+			n++
+		}
+	}
+	c.stats.Expirations += uint64(n)
+	return n
+}
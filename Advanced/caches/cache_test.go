@@ -0,0 +1,150 @@
+package caches
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// clock is a manual clock for testing expiry
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// policies returns a constructor for each eviction policy, and the same
+// wrapped by Synchronized
+func policies() map[string]func(Options) Cache[int, int] {
+	return map[string]func(Options) Cache[int, int]{
+		"LRU": func(o Options) Cache[int, int] { return NewLRU[int, int](o) },
+		"LFU": func(o Options) Cache[int, int] { return NewLFU[int, int](o) },
+		"ARC": func(o Options) Cache[int, int] { return NewARC[int, int](o) },
+		"Synchronized LRU": func(o Options) Cache[int, int] {
+			return NewSynchronized[int, int](NewLRU[int, int](o))
+		},
+	}
+}
+
+// access looks up key, setting it on a miss, as a caller filling a cache
+// from slower storage would
+func access(c Cache[int, int], key int) {
+	if _, ok := c.Get(key); !ok {
+		c.Set(key, key)
+	}
+}
+
+// TestBasics tests Get, Set, Delete and the counters of every policy
+func TestBasics(t *testing.T) {
+	for name, newCache := range policies() {
+		c := newCache(Options{MaxEntries: 3})
+		c.Set(1, 10)
+		c.Set(2, 20)
+		c.Set(1, 11)
+		if v, ok := c.Get(1); v != 11 || !ok {
+			t.Errorf("%s: Get(1) = %d, %v, want 11", name, v, ok)
+		}
+		if _, ok := c.Get(3); ok {
+			t.Errorf("%s: Get(3) found a key never set", name)
+		}
+		if !c.Delete(2) || c.Delete(2) || c.Len() != 1 {
+			t.Errorf("%s: Delete(2) twice left %d entries", name, c.Len())
+		}
+		for k := range 10 {
+			c.Set(k, k)
+			if c.Len() > 3 {
+				t.Fatalf("%s: %d entries with MaxEntries 3", name, c.Len())
+			}
+		}
+		want := Stats{Hits: 1, Misses: 1, Evictions: 7}
+		if got := c.Stats(); got != want {
+			t.Errorf("%s: Stats() = %+v, want %+v", name, got, want)
+		}
+		if r := c.Stats().HitRate(); r != 0.5 {
+			t.Errorf("%s: HitRate() = %v, want 0.5", name, r)
+		}
+	}
+	if (Stats{}).HitRate() != 0 {
+		t.Error("HitRate() before any Get is not 0")
+	}
+}
+
+// TestBounded tests that random workloads never exceed MaxEntries, and
+// that only ARC lacks an unbounded mode
+func TestBounded(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for name, newCache := range policies() {
+		for _, size := range []int{1, 2, 7, 50} {
+			c := newCache(Options{MaxEntries: size})
+			for range 5000 {
+				key := rng.IntN(3 * size)
+				if rng.IntN(10) == 0 {
+					c.Delete(key)
+				} else {
+					access(c, key)
+				}
+				if c.Len() > size {
+					t.Fatalf("%s: %d entries with MaxEntries %d", name, c.Len(), size)
+				}
+			}
+		}
+		c := newCache(Options{})
+		for k := range 1000 {
+			c.Set(k, k)
+		}
+		if want := map[bool]int{true: 1, false: 1000}[name == "ARC"]; c.Len() != want {
+			t.Errorf("%s: unbounded cache holds %d of 1000 keys, want %d", name, c.Len(), want)
+		}
+	}
+}
+
+// TestTTL tests expiry on lookup, by RemoveExpired, and its restart by Set
+func TestTTL(t *testing.T) {
+	for name, newCache := range policies() {
+		clk := &clock{now: time.Unix(1000, 0)}
+		c := newCache(Options{MaxEntries: 10, TTL: time.Minute, Now: clk.Now})
+		c.Set(1, 1)
+		c.Set(2, 2)
+		c.Set(3, 3)
+		clk.Advance(40 * time.Second)
+		c.Set(2, 22)
+		if _, ok := c.Get(1); !ok {
+			t.Errorf("%s: entry expired before its TTL", name)
+		}
+		clk.Advance(20 * time.Second)
+		if _, ok := c.Get(1); ok {
+			t.Errorf("%s: entry outlived its TTL", name)
+		}
+		if c.Len() != 2 {
+			t.Errorf("%s: %d entries after an expired lookup, want 2", name, c.Len())
+		}
+		if n := c.RemoveExpired(); n != 1 || c.Len() != 1 {
+			t.Errorf("%s: RemoveExpired() = %d leaving %d, want 1 leaving 1", name, n, c.Len())
+		}
+		if v, ok := c.Get(2); v != 22 || !ok {
+			t.Errorf("%s: Get of the entry set again = %d, %v, want 22", name, v, ok)
+		}
+		if s := c.Stats(); s.Expirations != 2 || s.Hits != 2 || s.Misses != 1 {
+			t.Errorf("%s: Stats() = %+v, want 2 expirations, 2 hits and 1 miss", name, s)
+		}
+	}
+}
+
+// benchmarkPolicy measures lookups, filling misses, on a skewed workload
+func benchmarkPolicy(b *testing.B, newCache func(Options) Cache[int, int]) {
+	c := newCache(Options{MaxEntries: 1000})
+	rng := rand.New(rand.NewPCG(1, 1))
+	keys := make([]int, 1<<16)
+	for i := range keys {
+		keys[i] = int(rng.ExpFloat64() * 1000)
+	}
+	i := 0
+	for b.Loop() {
+		access(c, keys[i&(len(keys)-1)])
+		i++
+	}
+	b.ReportMetric(c.Stats().HitRate(), "hits/op")
+}
+
+func BenchmarkLRU(b *testing.B) { benchmarkPolicy(b, policies()["LRU"]) }
+func BenchmarkLFU(b *testing.B) { benchmarkPolicy(b, policies()["LFU"]) }
+func BenchmarkARC(b *testing.B) { benchmarkPolicy(b, policies()["ARC"]) }
@@ -0,0 +1,123 @@
+package caches
+
+// LFU is a cache evicting the least frequently used entry, the least
+// recently used among those tied. Entries are kept in one list per use
+// count, and the lowest count in use is tracked, so lookups and evictions
+// take O(1). Counts never decay, so entries popular long ago can outstay
+// newer ones; LRU or ARC suit workloads whose popular keys change.
+type LFU[K comparable, V any] struct {
+	core[K, V]
+	freqs   map[int]*list[K, V] // Entries by use count, none empty
+	minFreq int                 // Lowest use count, if its list is present
+}
+
+// NewLFU creates an empty LFU cache
+func NewLFU[K comparable, V any](opts Options) *LFU[K, V] {
+	return &LFU[K, V]{core: newCore[K, V](opts), freqs: make(map[int]*list[K, V])}
+}
+
+// unlink removes e from its frequency list, dropping the list if it empties
+func (c *LFU[K, V]) unlink(e *entry[K, V]) {
+	l := e.list
+	l.remove(e)
+	if l.len == 0 {
+		delete(c.freqs, e.freq)
+	}
+}
+
+// link adds e to the front of the list for its use count
+func (c *LFU[K, V]) link(e *entry[K, V]) {
+	l, ok := c.freqs[e.freq]
+	if !ok {
+		l = newList[K, V]()
+		c.freqs[e.freq] = l
+	}
+	l.pushFront(e)
+}
+
+// drop removes e from the cache
+func (c *LFU[K, V]) drop(e *entry[K, V]) {
+	delete(c.entries, e.key)
+	c.unlink(e)
+}
+
+// touch counts a use of e
+func (c *LFU[K, V]) touch(e *entry[K, V]) {
+	c.unlink(e)
+	if _, ok := c.freqs[e.freq]; !ok && e.freq == c.minFreq {
+		c.minFreq++
+	}
+	e.freq++
+	c.link(e)
+}
+
+// Get returns the value of key and whether it was present and unexpired,
+// counting a use of it
+// Time Complexity: O(1)
+func (c *LFU[K, V]) Get(key K) (V, bool) {
+	e := c.lookup(key, c.drop)
+	if e == nil {
+		var zero V
+		return zero, false
+	}
+	c.touch(e)
+	return e.value, true
+}
+
+// Set sets the value of key, counting a use of it, and evicts the least
+// frequently used entry if the cache was full
+// Time Complexity: O(1), or O(f) for f distinct use counts after a Delete
+// or expiry
+func (c *LFU[K, V]) Set(key K, value V) {
+	if e, ok := c.entries[key]; ok {
+		e.value, e.expires = value, c.expiry()
+		c.touch(e)
+		return
+	}
+	if c.full(len(c.entries)) {
+		c.evict()
+	}
+	e := &entry[K, V]{key: key, value: value, expires: c.expiry(), freq: 1}
+	c.entries[key] = e
+	c.link(e)
+	c.minFreq = 1
+}
+
+// evict removes the least recently used of the least frequently used
+// entries. Removals other than by touch can empty the list of minFreq
+// without advancing it, so then the lowest count is searched for.
+func (c *LFU[K, V]) evict() {
+	l, ok := c.freqs[c.minFreq]
+	if !ok {
+		first := true
+		for f := range c.freqs {
+			if first || f < c.minFreq {
+				c.minFreq, first = f, false
+			}
+		}
+		l = c.freqs[c.minFreq]
+	}
+	c.drop(l.back())
+	c.stats.Evictions++
+}
+
+// Delete removes key and reports whether it was present
+// Time Complexity: O(1)
+func (c *LFU[K, V]) Delete(key K) bool {
+	e, ok := c.entries[key]
+	if ok {
+		c.drop(e)
+	}
+	return ok
+}
+
+// Len returns the number of entries, including expired ones not yet removed
+func (c *LFU[K, V]) Len() int {
+	return len(c.entries)
+}
+
+// RemoveExpired removes every expired entry and returns how many
+// Time Complexity: O(n)
+func (c *LFU[K, V]) RemoveExpired() int {
+	return c.removeExpired(c.drop)
+}
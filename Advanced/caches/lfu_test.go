@@ -0,0 +1,61 @@
+package caches
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// TestLFU tests eviction order against a model recording each key's use
+// count and the time it reached that count
+func TestLFU(t *testing.T) {
+	type use struct{ freq, since int }
+	rng := rand.New(rand.NewPCG(5, 6))
+	for _, size := range []int{1, 3, 16} {
+		c := NewLFU[int, int](Options{MaxEntries: size})
+		model := map[int]use{}
+		for tick := range 5000 {
+			key := rng.IntN(3 * size)
+			if rng.IntN(20) == 0 {
+				if c.Delete(key) != (model[key] != use{}) {
+					t.Fatalf("size %d: Delete(%d) disagrees with the model", size, key)
+				}
+				delete(model, key)
+				continue
+			}
+			_, ok := c.Get(key)
+			if u, want := model[key]; ok != want {
+				t.Fatalf("size %d: Get(%d) found %v, want %v", size, key, ok, want)
+			} else if ok {
+				model[key] = use{u.freq + 1, tick}
+				continue
+			}
+			c.Set(key, key)
+			if len(model) == size {
+				victim, first := 0, true
+				for k, u := range model {
+					if v := model[victim]; first || u.freq < v.freq || u.freq == v.freq && u.since < v.since {
+						victim, first = k, false
+					}
+				}
+				delete(model, victim)
+			}
+			model[key] = use{1, tick}
+		}
+	}
+}
+
+// TestLFUKeepsFrequent tests that a frequently used key survives a stream
+// of keys used once
+func TestLFUKeepsFrequent(t *testing.T) {
+	c := NewLFU[string, int](Options{MaxEntries: 2})
+	c.Set("hot", 1)
+	for range 5 {
+		c.Get("hot")
+	}
+	for k := range 100 {
+		c.Set(string(rune('a'+k%26))+"x", k)
+	}
+	if _, ok := c.Get("hot"); !ok {
+		t.Error("the frequently used key was evicted")
+	}
+}
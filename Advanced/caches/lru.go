@@ -0,0 +1,72 @@
+package caches
+
+// LRU is a cache evicting the least recently used entry. Entries sit in a
+// list in order of use, so a lookup moves its entry to the front and an
+// eviction takes the back, each in O(1).
+type LRU[K comparable, V any] struct {
+	core[K, V]
+	order *list[K, V]
+}
+
+// NewLRU creates an empty LRU cache
+func NewLRU[K comparable, V any](opts Options) *LRU[K, V] {
+	return &LRU[K, V]{core: newCore[K, V](opts), order: newList[K, V]()}
+}
+
+// drop removes e from the cache
+func (c *LRU[K, V]) drop(e *entry[K, V]) {
+	delete(c.entries, e.key)
+	c.order.remove(e)
+}
+
+// Get returns the value of key and whether it was present and unexpired,
+// marking it most recently used
+// Time Complexity: O(1)
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	e := c.lookup(key, c.drop)
+	if e == nil {
+		var zero V
+		return zero, false
+	}
+	c.order.moveToFront(e)
+	return e.value, true
+}
+
+// Set sets the value of key, marking it most recently used, and evicts the
+// least recently used entry if the cache was full
+// Time Complexity: O(1)
+func (c *LRU[K, V]) Set(key K, value V) {
+	if e, ok := c.entries[key]; ok {
+		e.value, e.expires = value, c.expiry()
+		c.order.moveToFront(e)
+		return
+	}
+	if c.full(len(c.entries)) {
+		c.drop(c.order.back())
+		c.stats.Evictions++
+	}
+	e := &entry[K, V]{key: key, value: value, expires: c.expiry()}
+	c.entries[key] = e
+	c.order.pushFront(e)
+}
+
+// Delete removes key and reports whether it was present
+// Time Complexity: O(1)
+func (c *LRU[K, V]) Delete(key K) bool {
+	e, ok := c.entries[key]
+	if ok {
+		c.drop(e)
+	}
+	return ok
+}
+
+// Len returns the number of entries, including expired ones not yet removed
+func (c *LRU[K, V]) Len() int {
+	return len(c.entries)
+}
+
+// RemoveExpired removes every expired entry and returns how many
+// Time Complexity: O(n)
+func (c *LRU[K, V]) RemoveExpired() int {
+	return c.removeExpired(c.drop)
+}
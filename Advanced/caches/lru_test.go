@@ -0,0 +1,38 @@
+package caches
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestLRU tests eviction order against a slice of keys kept in order of use
+func TestLRU(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for _, size := range []int{1, 3, 16} {
+		c := NewLRU[int, int](Options{MaxEntries: size})
+		order := []int{} // Least recently used first
+		use := func(key int) {
+			if i := slices.Index(order, key); i >= 0 {
+				order = slices.Delete(order, i, i+1)
+			}
+			order = append(order, key)
+		}
+		for range 3000 {
+			key := rng.IntN(2 * size)
+			_, ok := c.Get(key)
+			if want := slices.Contains(order, key); ok != want {
+				t.Fatalf("size %d: Get(%d) found %v, want %v", size, key, ok, want)
+			}
+			if ok {
+				use(key)
+				continue
+			}
+			c.Set(key, key)
+			if len(order) == size {
+				order = order[1:]
+			}
+			use(key)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package caches
+
+import "sync"
+
+// Synchronized makes a Cache safe for concurrent use by holding a mutex
+// around each call. Every call locks exclusively, since even a Get updates
+// the order of entries and the counters.
+type Synchronized[K comparable, V any] struct {
+	mu    sync.Mutex
+	cache Cache[K, V]
+}
+
+// NewSynchronized wraps c, which must not be used directly afterwards
+func NewSynchronized[K comparable, V any](c Cache[K, V]) *Synchronized[K, V] {
+	return &Synchronized[K, V]{cache: c}
+}
+
+// Get returns the value of key and whether it was present and unexpired
+func (s *Synchronized[K, V]) Get(key K) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.Get(key)
+}
+
+// Set sets the value of key
+func (s *Synchronized[K, V]) Set(key K, value V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache.Set(key, value)
+}
+
+// GetOrSet returns the value of key, first setting it to the result of
+// load if it is absent. The lock is held while load runs, so concurrent
+// callers for any key wait rather than loading the same value twice; load
+// should therefore be quick, and must not use the cache.
+func (s *Synchronized[K, V]) GetOrSet(key K, load func(K) (V, error)) (V, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.cache.Get(key); ok {
+		return v, nil
+	}
+	v, err := load(key)
+	if err != nil {
+		return v, err
+	}
+	s.cache.Set(key, v)
+	return v, nil
+}
+
+// Delete removes key and reports whether it was present
+func (s *Synchronized[K, V]) Delete(key K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.Delete(key)
+}
+
+// Len returns the number of entries
+func (s *Synchronized[K, V]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.Len()
+}
+
+// RemoveExpired removes every expired entry and returns how many
+func (s *Synchronized[K, V]) RemoveExpired() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.RemoveExpired()
+}
+
+// Stats returns the counters accumulated so far
+func (s *Synchronized[K, V]) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache.Stats()
+}
@@ -0,0 +1,53 @@
+package caches
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// TestSynchronized tests concurrent use, run with -race to find data races
+func TestSynchronized(t *testing.T) {
+	c := NewSynchronized[int, int](NewARC[int, int](Options{MaxEntries: 64}))
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 2000 {
+				key := (g*31 + i*7) % 200
+				access(c, key)
+				if i%50 == 0 {
+					c.Delete(key)
+					c.RemoveExpired()
+				}
+			}
+		})
+	}
+	wg.Wait()
+	if s := c.Stats(); s.Hits+s.Misses != 8*2000 || c.Len() > 64 {
+		t.Errorf("Stats() = %+v with %d entries after 16000 lookups", s, c.Len())
+	}
+}
+
+// TestGetOrSet tests that GetOrSet loads each key once and caches no error
+func TestGetOrSet(t *testing.T) {
+	c := NewSynchronized[string, int](NewLRU[string, int](Options{}))
+	loads := 0
+	load := func(key string) (int, error) {
+		loads++
+		if key == "bad" {
+			return 0, errors.New("no such key")
+		}
+		return len(key), nil
+	}
+	for range 3 {
+		if v, err := c.GetOrSet("four", load); v != 4 || err != nil {
+			t.Errorf("GetOrSet(four) = %d, %v, want 4", v, err)
+		}
+		if _, err := c.GetOrSet("bad", load); err == nil {
+			t.Error("GetOrSet(bad) succeeded")
+		}
+	}
+	if loads != 4 || c.Len() != 1 {
+		t.Errorf("%d loads leaving %d entries, want 4 leaving 1", loads, c.Len())
+	}
+}
@@ -0,0 +1,287 @@
+// Package circuitbreaker provides a circuit breaker, which stops calling a
+// failing dependency so that it can recover and callers fail fast instead
+// of piling up behind it. The breaker is a state machine: Closed lets
+// calls through and counts consecutive failures; enough of them trip it
+// Open, where calls are rejected with ErrOpen; after a timeout it turns
+// HalfOpen and lets a few probe calls through, closing again once enough
+// succeed and reopening on the first failure.
+//
+// A Breaker is safe for concurrent use. Outcomes of calls that began
+// before the breaker last changed state are ignored, so a slow call from
+// a closed period cannot trip a breaker that has since been reset.
+// RegisterMetrics exposes its Metrics in a metrics.Registry.
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrOpen is returned for calls rejected while the breaker is open
+	ErrOpen = errors.New("circuit breaker is open")
+	// ErrTooManyProbes is returned for calls rejected while the breaker is
+	// half-open and its probes are all in flight
+	ErrTooManyProbes = errors.New("circuit breaker probes in flight")
+	// ErrTimeout is returned by Execute for calls that outlive CallTimeout
+	ErrTimeout = errors.New("circuit breaker call timed out")
+)
+
+// State is the state of a breaker
+type State int
+
+// The states of a breaker
+const (
+	Closed   State = iota // Calls pass, failures are counted
+	Open                  // Calls are rejected until OpenTimeout passes
+	HalfOpen              // A few probe calls pass to test recovery
+)
+
+// String returns the name of s
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Defaults for zero Settings fields
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+)
+
+// Settings configures a breaker. Zero fields take their defaults.
+type Settings struct {
+	// FailureThreshold is the consecutive failures that open a closed
+	// breaker, DefaultFailureThreshold if 0 or less
+	FailureThreshold int
+	// SuccessThreshold is the consecutive successful probes that close a
+	// half-open breaker, 1 if 0 or less
+	SuccessThreshold int
+	// MaxProbes bounds the probe calls in flight at once while half-open,
+	// 1 if 0 or less
+	MaxProbes int
+	// OpenTimeout is how long the breaker stays open before probing,
+	// DefaultOpenTimeout if 0 or less
+	OpenTimeout time.Duration
+	// CallTimeout bounds each call made through Execute; 0 or less means
+	// no bound beyond the caller's context
+	CallTimeout time.Duration
+	// IsFailure reports whether an error returned by a call counts as a
+	// failure of the dependency; if nil, every non-nil error does. Errors
+	// such as a caller's invalid input should not trip the breaker.
+	IsFailure func(err error) bool
+	// OnStateChange, if set, is called after each change of state. It is
+	// called without the breaker's lock held, so it may use the breaker,
+	// but changes made concurrently may be reported out of order.
+	OnStateChange func(from, to State)
+	// Now is the clock for OpenTimeout, time.Now if nil
+	Now func() time.Time
+}
+
+// Metrics counts the calls a breaker has seen
+type Metrics struct {
+	State                State
+	Since                time.Time // When the breaker entered State
+	Requests             uint64    // Calls let through
+	Successes            uint64    // Calls that succeeded
+	Failures             uint64    // Calls that failed, timeouts included
+	Timeouts             uint64    // Calls that outlived CallTimeout
+	Rejections           uint64    // Calls refused while open or out of probes
+	Transitions          uint64    // Changes of state
+	ConsecutiveFailures  int
+	ConsecutiveSuccesses int
+}
+
+// outcome is how a call ended
+type outcome int
+
+const (
+	success outcome = iota
+	failure
+	ignored // Neither, as when the caller gave up
+)
+
+// Breaker is a circuit breaker
+type Breaker struct {
+	mu         sync.Mutex
+	settings   Settings
+	metrics    Metrics
+	generation uint64 // Bumped on every change of state
+	probes     int    // Probe calls in flight while half-open
+}
+
+// New creates a closed breaker with the given settings
+func New(s Settings) *Breaker {
+	if s.FailureThreshold <= 0 {
+		s.FailureThreshold = DefaultFailureThreshold
+	}
+	s.SuccessThreshold = max(s.SuccessThreshold, 1)
+	s.MaxProbes = max(s.MaxProbes, 1)
+	if s.OpenTimeout <= 0 {
+		s.OpenTimeout = DefaultOpenTimeout
+	}
+	if s.IsFailure == nil {
+		s.IsFailure = func(err error) bool { return err != nil }
+	}
+	if s.Now == nil {
+		s.Now = time.Now
+	}
+	b := &Breaker{settings: s}
+	b.metrics.Since = s.Now()
+	return b
+}
+
+// transition is a change of state to report
+type transition struct {
+	from, to State
+}
+
+// notify reports a change of state, if there was one, to OnStateChange
+func (b *Breaker) notify(t transition) {
+	if t.from != t.to && b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(t.from, t.to)
+	}
+}
+
+// setState moves the breaker to state, starting a new generation and
+// clearing the consecutive counts. The caller holds mu.
+func (b *Breaker) setState(state State, now time.Time) transition {
+	t := transition{b.metrics.State, state}
+	if state == b.metrics.State {
+		return t
+	}
+	b.metrics.State, b.metrics.Since = state, now
+	b.metrics.Transitions++
+	b.metrics.ConsecutiveFailures, b.metrics.ConsecutiveSuccesses = 0, 0
+	b.generation++
+	b.probes = 0
+	return t
+}
+
+// current turns an open breaker whose timeout has passed half-open, and
+// returns the state. The caller holds mu.
+func (b *Breaker) current(now time.Time) (State, transition) {
+	t := transition{b.metrics.State, b.metrics.State}
+	if b.metrics.State == Open && now.Sub(b.metrics.Since) >= b.settings.OpenTimeout {
+		t = b.setState(HalfOpen, now)
+	}
+	return b.metrics.State, t
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	state, t := b.current(b.settings.Now())
+	b.mu.Unlock()
+	b.notify(t)
+	return state
+}
+
+// Metrics returns a snapshot of the breaker's counters
+func (b *Breaker) Metrics() Metrics {
+	b.mu.Lock()
+	_, t := b.current(b.settings.Now())
+	m := b.metrics
+	b.mu.Unlock()
+	b.notify(t)
+	return m
+}
+
+// Reset closes the breaker, forgetting the outcomes of calls in flight
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	t := b.setState(Closed, b.settings.Now())
+	b.mu.Unlock()
+	b.notify(t)
+}
+
+// Allow asks to make a call, for callers that make it themselves rather
+// than through Execute. If the breaker lets it through, the caller must
+// pass its error, nil on success, to done exactly once.
+func (b *Breaker) Allow() (done func(err error), err error) {
+	generation, err := b.begin()
+	if err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			result := success
+			if b.settings.IsFailure(err) {
+				result = failure
+			}
+			b.end(generation, result, false)
+		})
+	}, nil
+}
+
+// begin lets a call through or rejects it, returning the generation the
+// call belongs to
+func (b *Breaker) begin() (uint64, error) {
+	b.mu.Lock()
+	state, t := b.current(b.settings.Now())
+	var err error
+	switch {
+	case state == Open:
+		err = ErrOpen
+	case state == HalfOpen && b.probes >= b.settings.MaxProbes:
+		err = ErrTooManyProbes
+	case state == HalfOpen:
+		b.probes++
+	}
+	if err != nil {
+		b.metrics.Rejections++
+	} else {
+		b.metrics.Requests++
+	}
+	generation := b.generation
+	b.mu.Unlock()
+	b.notify(t)
+	return generation, err
+}
+
+// end records the outcome of a call let through in generation
+func (b *Breaker) end(generation uint64, result outcome, timedOut bool) {
+	b.mu.Lock()
+	now := b.settings.Now()
+	switch result {
+	case success:
+		b.metrics.Successes++
+	case failure:
+		b.metrics.Failures++
+		if timedOut {
+			b.metrics.Timeouts++
+		}
+	}
+	t := transition{b.metrics.State, b.metrics.State}
+	// Secure: a call from an earlier state must not move the current one
+	if generation == b.generation {
+		if b.metrics.State == HalfOpen {
+			b.probes--
+		}
+		switch result {
+		case success:
+			b.metrics.ConsecutiveFailures = 0
+			b.metrics.ConsecutiveSuccesses++
+			if b.metrics.State == HalfOpen && b.metrics.ConsecutiveSuccesses >= b.settings.SuccessThreshold {
+				t = b.setState(Closed, now)
+			}
+		case failure:
+			b.metrics.ConsecutiveSuccesses = 0
+			b.metrics.ConsecutiveFailures++
+			if b.metrics.State == HalfOpen || b.metrics.ConsecutiveFailures >= b.settings.FailureThreshold {
+				t = b.setState(Open, now)
+			}
+		}
+	}
+	b.mu.Unlock()
+	b.notify(t)
+}
@@ -0,0 +1,215 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock is a manual clock for testing, safe for concurrent use
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock { return &clock{now: time.Unix(1000, 0)} }
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var errDown = errors.New("dependency down")
+
+// call makes one call through b with Allow, failing if fail is set, and
+// returns the error of Allow
+func call(b *Breaker, fail bool) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	if fail {
+		done(errDown)
+	} else {
+		done(nil)
+	}
+	return nil
+}
+
+// TestStateMachine tests the transitions through every state and the
+// callbacks reporting them
+func TestStateMachine(t *testing.T) {
+	clk := newClock()
+	var changes []string
+	b := New(Settings{
+		FailureThreshold: 3,
+		SuccessThreshold: 2,
+		OpenTimeout:      time.Second,
+		Now:              clk.Now,
+		OnStateChange: func(from, to State) {
+			changes = append(changes, from.String()+">"+to.String())
+		},
+	})
+	expect := func(want State) {
+		t.Helper()
+		if got := b.State(); got != want {
+			t.Fatalf("state = %v, want %v", got, want)
+		}
+	}
+
+	// A success resets the count of consecutive failures
+	for _, fail := range []bool{true, true, false, true, true} {
+		call(b, fail)
+	}
+	expect(Closed)
+	call(b, true)
+	expect(Open)
+	if err := call(b, false); !errors.Is(err, ErrOpen) {
+		t.Fatalf("call while open error = %v, want ErrOpen", err)
+	}
+
+	// A failed probe reopens the breaker and restarts the timeout
+	clk.Advance(time.Second)
+	expect(HalfOpen)
+	call(b, true)
+	expect(Open)
+	clk.Advance(999 * time.Millisecond)
+	expect(Open)
+	clk.Advance(time.Millisecond)
+	call(b, false)
+	expect(HalfOpen)
+	call(b, false)
+	expect(Closed)
+
+	want := []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed"}
+	if !slices.Equal(changes, want) {
+		t.Errorf("state changes = %v, want %v", changes, want)
+	}
+	m := b.Metrics()
+	if m.Requests != 9 || m.Successes != 3 || m.Failures != 6 || m.Rejections != 1 || m.Transitions != 5 {
+		t.Errorf("metrics = %+v", m)
+	}
+	if !m.Since.Equal(clk.Now()) {
+		t.Errorf("Since = %v, want %v", m.Since, clk.Now())
+	}
+}
+
+// TestProbes tests that a half-open breaker lets at most MaxProbes calls
+// through at once
+func TestProbes(t *testing.T) {
+	clk := newClock()
+	b := New(Settings{FailureThreshold: 1, SuccessThreshold: 3, MaxProbes: 2, OpenTimeout: time.Second, Now: clk.Now})
+	call(b, true)
+	clk.Advance(time.Second)
+
+	first, err1 := b.Allow()
+	second, err2 := b.Allow()
+	if err1 != nil || err2 != nil {
+		t.Fatalf("probes refused: %v, %v", err1, err2)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrTooManyProbes) {
+		t.Fatalf("third probe error = %v, want ErrTooManyProbes", err)
+	}
+	first(nil)
+	first(errDown) // Later calls of done are ignored
+	third, err := b.Allow()
+	if err != nil {
+		t.Fatalf("probe after one ended refused: %v", err)
+	}
+	second(nil)
+	if b.State() != HalfOpen {
+		t.Fatal("closed before SuccessThreshold probes succeeded")
+	}
+	third(nil)
+	if b.State() != Closed {
+		t.Fatalf("state after 3 successful probes = %v, want closed", b.State())
+	}
+}
+
+// TestStaleOutcome tests that calls begun in an earlier state do not
+// move the breaker
+func TestStaleOutcome(t *testing.T) {
+	clk := newClock()
+	b := New(Settings{FailureThreshold: 1, Now: clk.Now})
+	slow, _ := b.Allow()
+	call(b, true)
+	b.Reset()
+	slow(errDown)
+	if m := b.Metrics(); m.State != Closed || m.ConsecutiveFailures != 0 || m.Failures != 2 {
+		t.Errorf("after a stale failure, metrics = %+v", m)
+	}
+
+	// A probe begun half-open and ending after a reset frees no probe
+	b = New(Settings{FailureThreshold: 1, OpenTimeout: time.Second, Now: clk.Now})
+	call(b, true)
+	clk.Advance(time.Second)
+	probe, _ := b.Allow()
+	b.Reset()
+	call(b, true)
+	clk.Advance(time.Second)
+	if _, err := b.Allow(); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	probe(nil)
+	if _, err := b.Allow(); !errors.Is(err, ErrTooManyProbes) {
+		t.Errorf("stale probe freed a probe of a later half-open state: %v", err)
+	}
+}
+
+// TestIsFailure tests that errors IsFailure rejects do not trip the
+// breaker
+func TestIsFailure(t *testing.T) {
+	errInput := errors.New("bad input")
+	b := New(Settings{
+		FailureThreshold: 1,
+		IsFailure:        func(err error) bool { return err != nil && !errors.Is(err, errInput) },
+	})
+	for range 10 {
+		done, err := b.Allow()
+		if err != nil {
+			t.Fatalf("breaker opened on caller errors: %v", err)
+		}
+		done(errInput)
+	}
+	if m := b.Metrics(); m.Successes != 10 || m.Failures != 0 {
+		t.Errorf("metrics = %+v, want 10 successes", m)
+	}
+}
+
+// TestConcurrent runs calls from many goroutines, for the race detector,
+// and checks that every call is accounted for
+func TestConcurrent(t *testing.T) {
+	b := New(Settings{FailureThreshold: 3, MaxProbes: 2, OpenTimeout: time.Millisecond})
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 500 {
+				call(b, (g+i)%4 == 0)
+				b.State()
+			}
+		})
+	}
+	wg.Wait()
+	m := b.Metrics()
+	if m.Requests+m.Rejections != 8*500 || m.Successes+m.Failures != m.Requests {
+		t.Errorf("calls unaccounted for: %+v", m)
+	}
+}
+
+// BenchmarkAllow measures a call through a closed breaker
+func BenchmarkAllow(b *testing.B) {
+	cb := New(Settings{})
+	for b.Loop() {
+		done, _ := cb.Allow()
+		done(nil)
+	}
+}
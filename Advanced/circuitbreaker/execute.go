@@ -0,0 +1,58 @@
+package circuitbreaker
+
+import (
+	"context"
+	"fmt"
+)
+
+// Execute calls fn through the breaker, returning ErrOpen or
+// ErrTooManyProbes without calling it if the breaker refuses, and
+// otherwise the error of fn, whose outcome it records.
+//
+// fn receives a context bounded by CallTimeout and must honour it, since
+// Execute waits for fn to return. An error after the timeout has passed
+// is returned wrapped in ErrTimeout and counts as a failure whatever
+// IsFailure says; an error after ctx itself ended counts as neither
+// success nor failure, since the dependency is not to blame for a caller
+// that gave up. A panic in fn counts as a failure and is passed on.
+func (b *Breaker) Execute(ctx context.Context, fn func(context.Context) error) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	generation, err := b.begin()
+	if err != nil {
+		return err
+	}
+	callCtx := ctx
+	if b.settings.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, b.settings.CallTimeout)
+		defer cancel()
+	}
+
+	// Secure: a panicking call must still end, or a half-open breaker
+	// would hold its probe forever
+	finished := false
+	defer func() {
+		if !finished {
+			b.end(generation, failure, false)
+		}
+	}()
+	err = fn(callCtx)
+	finished = true
+
+	switch {
+	case err == nil:
+		b.end(generation, success, false)
+	case ctx.Err() != nil:
+		b.end(generation, ignored, false)
+	case callCtx.Err() != nil:
+		b.end(generation, failure, true)
+		return fmt.Errorf("%w after %v: %w", ErrTimeout, b.settings.CallTimeout, err)
+	case b.settings.IsFailure(err):
+		b.end(generation, failure, false)
+	default:
+		b.end(generation, success, false)
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestExecute tests that Execute records outcomes and rejects calls while
+// open
+func TestExecute(t *testing.T) {
+	b := New(Settings{FailureThreshold: 2})
+	ctx := context.Background()
+	calls := 0
+	fn := func(context.Context) error {
+		calls++
+		return errDown
+	}
+	for range 2 {
+		if err := b.Execute(ctx, fn); err != errDown {
+			t.Fatalf("Execute error = %v, want the call's", err)
+		}
+	}
+	if err := b.Execute(ctx, fn); !errors.Is(err, ErrOpen) || calls != 2 {
+		t.Errorf("Execute while open error = %v after %d calls, want ErrOpen after 2", err, calls)
+	}
+}
+
+// TestExecuteTimeout tests that a call outliving CallTimeout fails with
+// ErrTimeout, and one whose caller gives up is not counted
+func TestExecuteTimeout(t *testing.T) {
+	b := New(Settings{FailureThreshold: 1, CallTimeout: 10 * time.Millisecond})
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.Execute(ctx, slow); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Execute with a done context error = %v, want context.Canceled", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := b.Execute(ctx, slow); !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrTimeout) {
+		t.Fatalf("Execute with a caller deadline error = %v, want only DeadlineExceeded", err)
+	}
+	if m := b.Metrics(); m.State != Closed || m.Failures != 0 || m.Requests != 1 {
+		t.Fatalf("a caller giving up counted against the breaker: %+v", m)
+	}
+
+	err := b.Execute(context.Background(), slow)
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Execute of a slow call error = %v, want ErrTimeout", err)
+	}
+	if m := b.Metrics(); m.State != Open || m.Timeouts != 1 {
+		t.Errorf("after a timeout, metrics = %+v", m)
+	}
+}
+
+// TestExecutePanic tests that a panicking probe still ends, freeing the
+// breaker to probe again
+func TestExecutePanic(t *testing.T) {
+	clk := newClock()
+	b := New(Settings{FailureThreshold: 1, OpenTimeout: time.Second, Now: clk.Now})
+	call(b, true)
+	clk.Advance(time.Second)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic was not passed on")
+			}
+		}()
+		b.Execute(context.Background(), func(context.Context) error { panic("boom") })
+	}()
+	if m := b.Metrics(); m.State != Open || m.Failures != 2 {
+		t.Errorf("after a panicking probe, metrics = %+v", m)
+	}
+	clk.Advance(time.Second)
+	if err := b.Execute(context.Background(), func(context.Context) error { return nil }); err != nil {
+		t.Errorf("probe after a panic failed: %v", err)
+	}
+}
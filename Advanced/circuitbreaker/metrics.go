@@ -0,0 +1,27 @@
+package circuitbreaker
+
+import "hellogolang/Advanced/metrics"
+
+// RegisterMetrics exposes the breaker's Metrics in r, labelled
+// breaker=name, read afresh on every scrape: counters of calls by outcome
+// and of state changes, and a circuitbreaker_state gauge of 0 closed,
+// 1 open and 2 half-open. Breakers registered with the same r share the
+// families and need distinct names.
+func (b *Breaker) RegisterMetrics(r *metrics.Registry, name string) {
+	counters := []struct {
+		name, help string
+		get        func(Metrics) uint64
+	}{
+		{"circuitbreaker_requests_total", "Calls let through by the breaker", func(m Metrics) uint64 { return m.Requests }},
+		{"circuitbreaker_successes_total", "Calls that succeeded", func(m Metrics) uint64 { return m.Successes }},
+		{"circuitbreaker_failures_total", "Calls that failed, timeouts included", func(m Metrics) uint64 { return m.Failures }},
+		{"circuitbreaker_timeouts_total", "Calls that outlived the call timeout", func(m Metrics) uint64 { return m.Timeouts }},
+		{"circuitbreaker_rejections_total", "Calls refused while open or out of probes", func(m Metrics) uint64 { return m.Rejections }},
+		{"circuitbreaker_transitions_total", "Changes of state", func(m Metrics) uint64 { return m.Transitions }},
+	}
+	for _, c := range counters {
+		r.CounterVec(c.name, c.help, "breaker").Func(func() float64 { return float64(c.get(b.Metrics())) }, name)
+	}
+	r.GaugeVec("circuitbreaker_state", "State of the breaker: 0 closed, 1 open, 2 half-open", "breaker").
+		Func(func() float64 { return float64(b.State()) }, name)
+}
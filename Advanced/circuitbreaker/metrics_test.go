@@ -0,0 +1,38 @@
+package circuitbreaker
+
+import (
+	"strings"
+	"testing"
+
+	"hellogolang/Advanced/metrics"
+)
+
+// TestRegisterMetrics tests that the exposed metrics follow the breaker
+func TestRegisterMetrics(t *testing.T) {
+	clk := newClock()
+	b := New(Settings{FailureThreshold: 2, Now: clk.Now})
+	r := metrics.NewRegistry()
+	b.RegisterMetrics(r, "db")
+
+	call(b, false)
+	call(b, true)
+	call(b, true)
+	call(b, false)
+
+	var out strings.Builder
+	if err := r.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`circuitbreaker_requests_total{breaker="db"} 3`,
+		`circuitbreaker_successes_total{breaker="db"} 1`,
+		`circuitbreaker_failures_total{breaker="db"} 2`,
+		`circuitbreaker_rejections_total{breaker="db"} 1`,
+		`circuitbreaker_transitions_total{breaker="db"} 1`,
+		`circuitbreaker_state{breaker="db"} 1`,
+	} {
+		if !strings.Contains(out.String(), want+"\n") {
+			t.Errorf("exposition lacks %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package collections
+
+import (
+	"hash/maphash"
+	"iter"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultShards is the number of shards of a ConcurrentMap when
+// MapOptions leave it unset
+const DefaultShards = 32
+
+// MapOptions configure a ConcurrentMap. Zero fields take their defaults.
+type MapOptions struct {
+	// Shards is the number of independently locked parts of the map,
+	// rounded up to a power of two; DefaultShards if 0 or less. More
+	// shards mean less contention between goroutines using different keys.
+	Shards int
+}
+
+// MapStats describes a ConcurrentMap's contents and use
+type MapStats struct {
+	Len         int    // Entries in the map
+	Shards      int    // Number of shards
+	MinShardLen int    // Entries in the emptiest shard
+	MaxShardLen int    // Entries in the fullest shard, showing skew
+	Hits        uint64 // Lookups that found their key
+	Misses      uint64 // Lookups that did not
+	Computes    uint64 // Values computed by GetOrCompute
+}
+
+// shard is one independently locked part of a ConcurrentMap. The padding
+// keeps neighbouring shards' locks off one cache line.
+type shard[K comparable, V any] struct {
+	mu       sync.RWMutex
+	m        map[K]V
+	hits     atomic.Uint64
+	misses   atomic.Uint64
+	computes atomic.Uint64
+	_        [64]byte
+}
+
+// ConcurrentMap is a map safe for concurrent use, split into shards by the
+// hash of each key so that goroutines using keys in different shards do
+// not contend. Unlike sync.Map it is typed, counts its entries in O(1)
+// and suits write-heavy use as well as read-heavy. Create one with
+// NewConcurrentMap; the zero value is not usable.
+type ConcurrentMap[K comparable, V any] struct {
+	shards []shard[K, V]
+	mask   uint64
+	seed   maphash.Seed
+	size   atomic.Int64
+}
+
+// NewConcurrentMap creates an empty map configured by opts
+func NewConcurrentMap[K comparable, V any](opts MapOptions) *ConcurrentMap[K, V] {
+	n := DefaultShards
+	if opts.Shards > 0 {
+		n = 1 << bits.Len(uint(opts.Shards-1))
+	}
+	m := &ConcurrentMap[K, V]{
+		shards: make([]shard[K, V], n),
+		mask:   uint64(n - 1),
+		seed:   maphash.MakeSeed(),
+	}
+	for i := range m.shards {
+		m.shards[i].m = make(map[K]V)
+	}
+	return m
+}
+
+// shard returns the shard holding key
+func (m *ConcurrentMap[K, V]) shard(key K) *shard[K, V] {
+	return &m.shards[maphash.Comparable(m.seed, key)&m.mask]
+}
+
+// Get returns the value of key and whether it is present
+func (m *ConcurrentMap[K, V]) Get(key K) (V, bool) {
+	s := m.shard(key)
+	s.mu.RLock()
+	v, ok := s.m[key]
+	s.mu.RUnlock()
+	if ok {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+	return v, ok
+}
+
+// Set sets the value of key
+func (m *ConcurrentMap[K, V]) Set(key K, value V) {
+	s := m.shard(key)
+	s.mu.Lock()
+	if _, ok := s.m[key]; !ok {
+		m.size.Add(1)
+	}
+	s.m[key] = value
+	s.mu.Unlock()
+}
+
+// Delete removes key, returning its value and whether it was present
+func (m *ConcurrentMap[K, V]) Delete(key K) (V, bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	v, ok := s.m[key]
+	if ok {
+		delete(s.m, key)
+		m.size.Add(-1)
+	}
+	s.mu.Unlock()
+	return v, ok
+}
+
+// GetOrCompute returns the value of key if present, with loaded true.
+// Otherwise it stores and returns compute(), with loaded false. The check
+// and the store are atomic, so concurrent callers for one key call compute
+// once between them; compute runs with the key's shard locked, so it
+// should be quick and must not use the map.
+func (m *ConcurrentMap[K, V]) GetOrCompute(key K, compute func() V) (value V, loaded bool) {
+	s := m.shard(key)
+	s.mu.RLock()
+	v, ok := s.m[key]
+	s.mu.RUnlock()
+	if ok {
+		s.hits.Add(1)
+		return v, true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.m[key]; ok {
+		s.hits.Add(1)
+		return v, true
+	}
+	s.misses.Add(1)
+	s.computes.Add(1)
+	v = compute()
+	s.m[key] = v
+	m.size.Add(1)
+	return v, false
+}
+
+// Compute sets key to the value fn returns given its current value and
+// whether it is present, or removes key if fn returns keep false, and
+// returns what fn returned. The read and the write are atomic, so Compute
+// suits read-modify-write updates such as counters; fn runs with the
+// key's shard locked and must not use the map.
+func (m *ConcurrentMap[K, V]) Compute(key K, fn func(old V, ok bool) (value V, keep bool)) (V, bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.m[key]
+	v, keep := fn(old, ok)
+	switch {
+	case keep:
+		s.m[key] = v
+		if !ok {
+			m.size.Add(1)
+		}
+	case ok:
+		delete(s.m, key)
+		m.size.Add(-1)
+	}
+	return v, keep
+}
+
+// Len returns the number of entries
+// Time Complexity: O(1)
+func (m *ConcurrentMap[K, V]) Len() int { return int(m.size.Load()) }
+
+// Range calls fn for each entry until fn returns false. It locks one
+// shard at a time, only to copy its entries, and calls fn without any
+// lock held, so fn may use the map; entries set or deleted during the
+// Range may or may not be seen, and no order is promised.
+func (m *ConcurrentMap[K, V]) Range(fn func(key K, value V) bool) {
+	type entry struct {
+		k K
+		v V
+	}
+	var batch []entry
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		batch = batch[:0]
+		for k, v := range s.m {
+			batch = append(batch, entry{k, v})
+		}
+		s.mu.RUnlock()
+		for _, e := range batch {
+			if !fn(e.k, e.v) {
+				return
+			}
+		}
+	}
+}
+
+// All iterates over the entries as Range does
+func (m *ConcurrentMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) { m.Range(yield) }
+}
+
+// Clear removes every entry
+func (m *ConcurrentMap[K, V]) Clear() {
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		m.size.Add(-int64(len(s.m)))
+		clear(s.m)
+		s.mu.Unlock()
+	}
+}
+
+// Stats returns the map's size, the spread of entries over its shards and
+// its lookup counters
+func (m *ConcurrentMap[K, V]) Stats() MapStats {
+	st := MapStats{Shards: len(m.shards), MinShardLen: -1}
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		n := len(s.m)
+		s.mu.RUnlock()
+		st.Len += n
+		st.MaxShardLen = max(st.MaxShardLen, n)
+		if st.MinShardLen < 0 || n < st.MinShardLen {
+			st.MinShardLen = n
+		}
+		st.Hits += s.hits.Load()
+		st.Misses += s.misses.Load()
+		st.Computes += s.computes.Load()
+	}
+	return st
+}
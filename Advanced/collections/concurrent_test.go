@@ -0,0 +1,209 @@
+package collections
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestConcurrentMap tests the basic operations and the counters
+func TestConcurrentMap(t *testing.T) {
+	m := NewConcurrentMap[string, int](MapOptions{Shards: 5})
+	if st := m.Stats(); st.Shards != 8 {
+		t.Errorf("shards = %d, want 5 rounded up to 8", st.Shards)
+	}
+	m.Set("a", 1)
+	m.Set("a", 2)
+	m.Set("b", 3)
+	if v, ok := m.Get("a"); !ok || v != 2 || m.Len() != 2 {
+		t.Errorf("Get(a) = %d, %v, len %d", v, ok, m.Len())
+	}
+	if _, ok := m.Get("z"); ok {
+		t.Error("Get(z) found a value")
+	}
+	if v, ok := m.Delete("b"); !ok || v != 3 || m.Len() != 1 {
+		t.Errorf("Delete(b) = %d, %v, len %d", v, ok, m.Len())
+	}
+	if _, ok := m.Delete("b"); ok {
+		t.Error("second Delete(b) found a value")
+	}
+
+	if v, loaded := m.GetOrCompute("c", func() int { return 7 }); loaded || v != 7 {
+		t.Errorf("GetOrCompute(c) = %d, %v", v, loaded)
+	}
+	if v, loaded := m.GetOrCompute("c", func() int { return 8 }); !loaded || v != 7 {
+		t.Errorf("second GetOrCompute(c) = %d, %v", v, loaded)
+	}
+
+	inc := func(old int, ok bool) (int, bool) { return old + 1, true }
+	m.Compute("n", inc)
+	m.Compute("n", inc)
+	if v, _ := m.Get("n"); v != 2 || m.Len() != 3 {
+		t.Errorf("counter = %d, len %d", v, m.Len())
+	}
+	m.Compute("n", func(int, bool) (int, bool) { return 0, false })
+	if _, ok := m.Get("n"); ok || m.Len() != 2 {
+		t.Errorf("Compute with keep false left the key, len %d", m.Len())
+	}
+
+	st := m.Stats()
+	if st.Len != 2 || st.Hits != 3 || st.Misses != 3 || st.Computes != 1 || st.MaxShardLen < 1 {
+		t.Errorf("stats = %+v", st)
+	}
+	m.Clear()
+	if m.Len() != 0 || m.Stats().Len != 0 {
+		t.Error("Clear left entries")
+	}
+}
+
+// TestConcurrentMapRange tests that Range sees every entry, stops early
+// and lets its callback use the map
+func TestConcurrentMapRange(t *testing.T) {
+	m := NewConcurrentMap[int, int](MapOptions{})
+	for i := range 100 {
+		m.Set(i, i*i)
+	}
+	sum := 0
+	m.Range(func(k, v int) bool {
+		if v != k*k {
+			t.Errorf("entry %d = %d", k, v)
+		}
+		sum += k
+		m.Delete(k)
+		return true
+	})
+	if sum != 4950 || m.Len() != 0 {
+		t.Errorf("sum of keys = %d, %d left after deleting all", sum, m.Len())
+	}
+
+	m.Set(1, 1)
+	m.Set(2, 2)
+	n := 0
+	for range m.All() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("Range went on after false: %d calls", n)
+	}
+}
+
+// TestConcurrentMapRace tests concurrent use, that GetOrCompute computes
+// once per key and that Compute loses no update; run with -race
+func TestConcurrentMapRace(t *testing.T) {
+	m := NewConcurrentMap[int, int](MapOptions{Shards: 4})
+	var computes atomic.Int64
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 200 {
+				m.GetOrCompute(i, func() int {
+					computes.Add(1)
+					return i
+				})
+				m.Compute(-1, func(old int, _ bool) (int, bool) { return old + 1, true })
+				if i%10 == g {
+					m.Delete(i)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	if total, _ := m.Get(-1); total != 1600 {
+		t.Errorf("counter = %d, want 1600", total)
+	}
+	if m.Len() != m.Stats().Len {
+		t.Errorf("Len %d disagrees with the shards' %d", m.Len(), m.Stats().Len)
+	}
+	if computes.Load() < 200 {
+		t.Errorf("computed %d values for 200 keys", computes.Load())
+	}
+}
+
+// benchmarkKeys is the key space of the benchmarks
+const benchmarkKeys = 1 << 12
+
+// mapUnderTest is what the benchmarks need of a concurrent map
+type mapUnderTest interface {
+	Load(key string) (int, bool)
+	Store(key string, value int)
+}
+
+// shardedMap adapts ConcurrentMap to mapUnderTest
+type shardedMap struct{ m *ConcurrentMap[string, int] }
+
+func (s shardedMap) Load(k string) (int, bool) { return s.m.Get(k) }
+func (s shardedMap) Store(k string, v int)     { s.m.Set(k, v) }
+
+// syncMap adapts sync.Map to mapUnderTest
+type syncMap struct{ m *sync.Map }
+
+func (s syncMap) Load(k string) (int, bool) {
+	v, ok := s.m.Load(k)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+func (s syncMap) Store(k string, v int) { s.m.Store(k, v) }
+
+// mutexMap adapts a map behind one RWMutex to mapUnderTest
+type mutexMap struct {
+	mu *sync.RWMutex
+	m  map[string]int
+}
+
+func (s mutexMap) Load(k string) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.m[k]
+	return v, ok
+}
+func (s mutexMap) Store(k string, v int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[k] = v
+}
+
+// benchmarkMaps runs a parallel mix of reads and writes, one write in
+// every writeEvery operations, against each map
+func benchmarkMaps(b *testing.B, writeEvery int) {
+	keys := make([]string, benchmarkKeys)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	maps := map[string]func() mapUnderTest{
+		"Sharded": func() mapUnderTest { return shardedMap{NewConcurrentMap[string, int](MapOptions{})} },
+		"SyncMap": func() mapUnderTest { return syncMap{new(sync.Map)} },
+		"Mutex":   func() mapUnderTest { return mutexMap{new(sync.RWMutex), make(map[string]int)} },
+	}
+	for _, name := range []string{"Sharded", "SyncMap", "Mutex"} {
+		b.Run(name, func(b *testing.B) {
+			m := maps[name]()
+			for i, k := range keys {
+				m.Store(k, i)
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					k := keys[i&(benchmarkKeys-1)]
+					if i%writeEvery == 0 {
+						m.Store(k, i)
+					} else {
+						m.Load(k)
+					}
+					i += 7
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkReadMostly compares the maps with 1 write in 100 operations,
+// the load sync.Map is designed for
+func BenchmarkReadMostly(b *testing.B) { benchmarkMaps(b, 100) }
+
+// BenchmarkWriteHeavy compares the maps with 1 write in 4 operations
+func BenchmarkWriteHeavy(b *testing.B) { benchmarkMaps(b, 4) }
@@ -0,0 +1,161 @@
+package collections
+
+import (
+	"cmp"
+	"iter"
+	"maps"
+	"slices"
+)
+
+// Multiset is a set that counts how many times each value was added. The
+// zero value is an empty multiset ready to use.
+type Multiset[T comparable] struct {
+	counts map[T]int
+	total  int
+}
+
+// NewMultiset returns a multiset of the given items, counting repeats
+func NewMultiset[T comparable](items ...T) *Multiset[T] {
+	m := &Multiset[T]{}
+	m.Add(items...)
+	return m
+}
+
+// Add adds one of each item
+func (m *Multiset[T]) Add(items ...T) {
+	for _, v := range items {
+		m.AddN(v, 1)
+	}
+}
+
+// AddN adds n of v; n of 0 or less adds nothing
+func (m *Multiset[T]) AddN(v T, n int) {
+	if n <= 0 {
+		return
+	}
+	if m.counts == nil {
+		m.counts = make(map[T]int)
+	}
+	m.counts[v] += n
+	m.total += n
+}
+
+// Remove removes one of v and reports whether there was one
+func (m *Multiset[T]) Remove(v T) bool {
+	return m.RemoveN(v, 1) == 1
+}
+
+// RemoveN removes up to n of v, returning how many it removed
+func (m *Multiset[T]) RemoveN(v T, n int) int {
+	c := m.counts[v]
+	n = min(max(n, 0), c)
+	if n == c {
+		delete(m.counts, v)
+	} else {
+		m.counts[v] = c - n
+	}
+	m.total -= n
+	return n
+}
+
+// RemoveAll removes every v, returning how many it removed
+func (m *Multiset[T]) RemoveAll(v T) int {
+	return m.RemoveN(v, m.counts[v])
+}
+
+// Count returns how many of v the multiset holds
+func (m *Multiset[T]) Count(v T) int { return m.counts[v] }
+
+// Contains reports whether the multiset holds at least one v
+func (m *Multiset[T]) Contains(v T) bool { return m.counts[v] > 0 }
+
+// Len returns the number of values, counting repeats
+func (m *Multiset[T]) Len() int { return m.total }
+
+// Distinct returns the number of distinct values
+func (m *Multiset[T]) Distinct() int { return len(m.counts) }
+
+// All iterates over the distinct values and their counts in no particular
+// order
+func (m *Multiset[T]) All() iter.Seq2[T, int] { return maps.All(m.counts) }
+
+// Entry is a value of a multiset and its count
+type Entry[T any] struct {
+	Value T
+	Count int
+}
+
+// MostCommon returns the n values with the greatest counts, or all of
+// them if n is less than 0 or more than there are, greatest count first.
+// Values with equal counts come in no particular order.
+// Time Complexity: O(d log d) for d distinct values
+func (m *Multiset[T]) MostCommon(n int) []Entry[T] {
+	entries := make([]Entry[T], 0, len(m.counts))
+	for v, c := range m.counts {
+		entries = append(entries, Entry[T]{v, c})
+	}
+	slices.SortStableFunc(entries, func(a, b Entry[T]) int { return cmp.Compare(b.Count, a.Count) })
+	if n >= 0 && n < len(entries) {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// combine returns a new multiset holding, for each value in m or o, the
+// count fn makes of its counts in each, if positive
+func (m *Multiset[T]) combine(o *Multiset[T], fn func(a, b int) int) *Multiset[T] {
+	out := &Multiset[T]{}
+	for v, c := range m.counts {
+		out.AddN(v, fn(c, o.counts[v]))
+	}
+	for v, c := range o.counts {
+		if _, ok := m.counts[v]; !ok {
+			out.AddN(v, fn(0, c))
+		}
+	}
+	return out
+}
+
+// Union returns a new multiset holding each value as many times as the
+// greater of its counts in m and o
+func (m *Multiset[T]) Union(o *Multiset[T]) *Multiset[T] {
+	return m.combine(o, func(a, b int) int { return max(a, b) })
+}
+
+// Sum returns a new multiset holding each value as many times as m and o
+// together
+func (m *Multiset[T]) Sum(o *Multiset[T]) *Multiset[T] {
+	return m.combine(o, func(a, b int) int { return a + b })
+}
+
+// Intersect returns a new multiset holding each value as many times as
+// the lesser of its counts in m and o
+func (m *Multiset[T]) Intersect(o *Multiset[T]) *Multiset[T] {
+	return m.combine(o, func(a, b int) int { return min(a, b) })
+}
+
+// Difference returns a new multiset holding each value of m as many
+// times as m holds it beyond o
+func (m *Multiset[T]) Difference(o *Multiset[T]) *Multiset[T] {
+	return m.combine(o, func(a, b int) int { return a - b })
+}
+
+// IsSubset reports whether o holds every value at least as many times as
+// m does
+func (m *Multiset[T]) IsSubset(o *Multiset[T]) bool {
+	for v, c := range m.counts {
+		if o.counts[v] < c {
+			return false
+		}
+	}
+	return true
+}
+
+// Set returns the distinct values as a Set
+func (m *Multiset[T]) Set() *Set[T] {
+	s := &Set[T]{m: make(map[T]struct{}, len(m.counts))}
+	for v := range m.counts {
+		s.m[v] = struct{}{}
+	}
+	return s
+}
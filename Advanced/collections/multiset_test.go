@@ -0,0 +1,69 @@
+package collections
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestMultiset tests counting values in and out
+func TestMultiset(t *testing.T) {
+	var m Multiset[string]
+	m.Add("a", "b", "a")
+	m.AddN("c", 3)
+	m.AddN("d", 0)
+	if m.Len() != 6 || m.Distinct() != 3 || m.Count("a") != 2 || m.Contains("d") {
+		t.Errorf("len %d, distinct %d, count(a) %d", m.Len(), m.Distinct(), m.Count("a"))
+	}
+	if !m.Remove("a") || m.Count("a") != 1 || m.Remove("z") {
+		t.Error("Remove mismatch")
+	}
+	if got := m.RemoveN("c", 5); got != 3 || m.Contains("c") || m.Distinct() != 2 {
+		t.Errorf("RemoveN = %d, distinct %d", got, m.Distinct())
+	}
+	if got := m.RemoveAll("b"); got != 1 || m.Len() != 1 {
+		t.Errorf("RemoveAll = %d, len %d", got, m.Len())
+	}
+}
+
+// TestMostCommon tests ranking values by count
+func TestMostCommon(t *testing.T) {
+	m := NewMultiset("x", "y", "y", "z", "z", "z")
+	want := []Entry[string]{{"z", 3}, {"y", 2}}
+	if got := m.MostCommon(2); !slices.Equal(got, want) {
+		t.Errorf("MostCommon(2) = %v, want %v", got, want)
+	}
+	if got := m.MostCommon(-1); len(got) != 3 || got[2] != (Entry[string]{"x", 1}) {
+		t.Errorf("MostCommon(-1) = %v", got)
+	}
+}
+
+// TestMultisetAlgebra tests the count-wise set operations
+func TestMultisetAlgebra(t *testing.T) {
+	a, b := NewMultiset(1, 1, 1, 2), NewMultiset(1, 2, 2, 3)
+	counts := func(m *Multiset[int]) [4]int {
+		return [4]int{m.Count(0), m.Count(1), m.Count(2), m.Count(3)}
+	}
+	cases := map[string]struct {
+		got  *Multiset[int]
+		want [4]int
+	}{
+		"union":      {a.Union(b), [4]int{0, 3, 2, 1}},
+		"sum":        {a.Sum(b), [4]int{0, 4, 3, 1}},
+		"intersect":  {a.Intersect(b), [4]int{0, 1, 1, 0}},
+		"difference": {a.Difference(b), [4]int{0, 2, 0, 0}},
+	}
+	for name, c := range cases {
+		if got := counts(c.got); got != c.want {
+			t.Errorf("%s counts = %v, want %v", name, got, c.want)
+		}
+	}
+	if got := a.Sum(b).Len(); got != 8 {
+		t.Errorf("Sum has %d values, want 8", got)
+	}
+	if a.IsSubset(b) || !NewMultiset(1, 2).IsSubset(a) {
+		t.Error("IsSubset mismatch")
+	}
+	if !a.Set().Equal(NewSet(1, 2)) {
+		t.Errorf("Set() = %v", a.Set())
+	}
+}
@@ -0,0 +1,207 @@
+package collections
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"hellogolang/Algorithms/trees"
+)
+
+// OrderedSet is a set of ordered values kept sorted in a red-black tree,
+// so lookups, additions and removals take O(log n) and iteration yields
+// the values in increasing order. The zero value is an empty set ready to
+// use.
+type OrderedSet[T cmp.Ordered] struct {
+	t *trees.RedBlackTree[T, struct{}]
+}
+
+// NewOrderedSet returns an ordered set of the given items
+func NewOrderedSet[T cmp.Ordered](items ...T) *OrderedSet[T] {
+	s := &OrderedSet[T]{}
+	s.Add(items...)
+	return s
+}
+
+// tree returns the set's tree, creating it if need be
+func (s *OrderedSet[T]) tree() *trees.RedBlackTree[T, struct{}] {
+	if s.t == nil {
+		s.t = trees.NewRedBlack[T, struct{}]()
+	}
+	return s.t
+}
+
+// Add adds the items, returning how many were not already present
+// Time Complexity: O(k log n) for k items
+func (s *OrderedSet[T]) Add(items ...T) int {
+	added := 0
+	for _, v := range items {
+		if s.tree().Insert(v, struct{}{}) {
+			added++
+		}
+	}
+	return added
+}
+
+// Remove removes the items, returning how many were present
+// Time Complexity: O(k log n) for k items
+func (s *OrderedSet[T]) Remove(items ...T) int {
+	removed := 0
+	for _, v := range items {
+		if s.tree().Delete(v) {
+			removed++
+		}
+	}
+	return removed
+}
+
+// Contains reports whether v is in the set
+// Time Complexity: O(log n)
+func (s *OrderedSet[T]) Contains(v T) bool {
+	_, ok := s.tree().Get(v)
+	return ok
+}
+
+// Len returns the number of values
+func (s *OrderedSet[T]) Len() int { return s.tree().Len() }
+
+// Min returns the least value, or ok false if the set is empty
+func (s *OrderedSet[T]) Min() (v T, ok bool) {
+	v, _, ok = s.tree().Min()
+	return v, ok
+}
+
+// Max returns the greatest value, or ok false if the set is empty
+func (s *OrderedSet[T]) Max() (v T, ok bool) {
+	v, _, ok = s.tree().Max()
+	return v, ok
+}
+
+// Floor returns the greatest value at most v, or ok false if none
+func (s *OrderedSet[T]) Floor(v T) (T, bool) {
+	f, _, ok := s.tree().Floor(v)
+	return f, ok
+}
+
+// Ceiling returns the least value at least v, or ok false if none
+func (s *OrderedSet[T]) Ceiling(v T) (T, bool) {
+	c, _, ok := s.tree().Ceiling(v)
+	return c, ok
+}
+
+// All iterates over the values in increasing order. The set must not be
+// modified during the iteration.
+func (s *OrderedSet[T]) All() iter.Seq[T] {
+	return keys(s.tree().All())
+}
+
+// Range iterates over the values in [lo, hi) in increasing order. The set
+// must not be modified during the iteration.
+func (s *OrderedSet[T]) Range(lo, hi T) iter.Seq[T] {
+	return keys(s.tree().Range(lo, hi))
+}
+
+// Slice returns the values in increasing order
+func (s *OrderedSet[T]) Slice() []T { return slices.Collect(s.All()) }
+
+// keys yields the keys of seq
+func keys[K, V any](seq iter.Seq2[K, V]) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range seq {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// merge walks s and o together in order, adding to a new set each value
+// that keep accepts given whether it is in s and whether it is in o
+// Time Complexity: O((n + m) log(n + m))
+func (s *OrderedSet[T]) merge(o *OrderedSet[T], keep func(inS, inO bool) bool) *OrderedSet[T] {
+	out := &OrderedSet[T]{}
+	a, b := s.Slice(), o.Slice()
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i] < b[j]):
+			if keep(true, false) {
+				out.Add(a[i])
+			}
+			i++
+		case i == len(a) || b[j] < a[i]:
+			if keep(false, true) {
+				out.Add(b[j])
+			}
+			j++
+		default:
+			if keep(true, true) {
+				out.Add(a[i])
+			}
+			i, j = i+1, j+1
+		}
+	}
+	return out
+}
+
+// Union returns a new set of the values in s or o
+func (s *OrderedSet[T]) Union(o *OrderedSet[T]) *OrderedSet[T] {
+	return s.merge(o, func(inS, inO bool) bool { return true })
+}
+
+// Intersect returns a new set of the values in both s and o
+func (s *OrderedSet[T]) Intersect(o *OrderedSet[T]) *OrderedSet[T] {
+	return s.merge(o, func(inS, inO bool) bool { return inS && inO })
+}
+
+// Difference returns a new set of the values in s but not in o
+func (s *OrderedSet[T]) Difference(o *OrderedSet[T]) *OrderedSet[T] {
+	return s.merge(o, func(inS, inO bool) bool { return inS && !inO })
+}
+
+// IsSubset reports whether every value in s is also in o
+func (s *OrderedSet[T]) IsSubset(o *OrderedSet[T]) bool {
+	if s.Len() > o.Len() {
+		return false
+	}
+	for v := range s.All() {
+		if !o.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether s and o hold the same values
+func (s *OrderedSet[T]) Equal(o *OrderedSet[T]) bool {
+	return s.Len() == o.Len() && s.IsSubset(o)
+}
+
+// String formats the set as {a b c}, in increasing order
+func (s *OrderedSet[T]) String() string {
+	items := make([]string, 0, s.Len())
+	for v := range s.All() {
+		items = append(items, fmt.Sprint(v))
+	}
+	return "{" + strings.Join(items, " ") + "}"
+}
+
+// MarshalJSON encodes the set as a JSON array in increasing order
+func (s *OrderedSet[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Slice())
+}
+
+// UnmarshalJSON replaces the set's values with those of a JSON array,
+// ignoring repeats
+func (s *OrderedSet[T]) UnmarshalJSON(data []byte) error {
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	s.t = nil
+	s.Add(items...)
+	return nil
+}
@@ -0,0 +1,87 @@
+package collections
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestOrderedSet tests that values stay sorted and the nearest-value
+// lookups
+func TestOrderedSet(t *testing.T) {
+	var s OrderedSet[int]
+	if _, ok := s.Min(); ok || s.Len() != 0 {
+		t.Error("zero value is not an empty set")
+	}
+	if got := s.Add(5, 1, 9, 3, 5); got != 4 {
+		t.Errorf("Add = %d, want 4", got)
+	}
+	if got := s.Slice(); !slices.Equal(got, []int{1, 3, 5, 9}) {
+		t.Errorf("Slice() = %v", got)
+	}
+	lo, _ := s.Min()
+	hi, _ := s.Max()
+	floor, _ := s.Floor(4)
+	ceiling, _ := s.Ceiling(6)
+	if lo != 1 || hi != 9 || floor != 3 || ceiling != 9 {
+		t.Errorf("min %d, max %d, floor(4) %d, ceiling(6) %d", lo, hi, floor, ceiling)
+	}
+	if _, ok := s.Ceiling(10); ok {
+		t.Error("Ceiling(10) found a value")
+	}
+	if got := slices.Collect(s.Range(3, 9)); !slices.Equal(got, []int{3, 5}) {
+		t.Errorf("Range(3, 9) = %v", got)
+	}
+	if s.Remove(3, 4) != 1 || s.Contains(3) {
+		t.Errorf("Remove left %v", &s)
+	}
+}
+
+// TestOrderedSetAlgebra tests the merged set operations against Set
+func TestOrderedSetAlgebra(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 50 {
+		a, b := NewOrderedSet[int](), NewOrderedSet[int]()
+		sa, sb := NewSet[int](), NewSet[int]()
+		for range rng.IntN(20) {
+			v := rng.IntN(30)
+			a.Add(v)
+			sa.Add(v)
+		}
+		for range rng.IntN(20) {
+			v := rng.IntN(30)
+			b.Add(v)
+			sb.Add(v)
+		}
+		check := func(name string, got *OrderedSet[int], want *Set[int]) {
+			if !slices.IsSorted(got.Slice()) || !NewSet(got.Slice()...).Equal(want) {
+				t.Fatalf("%s of %v and %v = %v, want %v", name, a, b, got, want)
+			}
+		}
+		check("union", a.Union(b), sa.Union(sb))
+		check("intersect", a.Intersect(b), sa.Intersect(sb))
+		check("difference", a.Difference(b), sa.Difference(sb))
+		if a.IsSubset(b) != sa.IsSubset(sb) || a.Equal(b) != sa.Equal(sb) {
+			t.Fatalf("subset or equality of %v and %v disagree with Set", a, b)
+		}
+	}
+}
+
+// TestOrderedSetJSON tests that a set round-trips through a JSON array
+func TestOrderedSetJSON(t *testing.T) {
+	data, err := json.Marshal(NewOrderedSet("pear", "apple", "fig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `["apple","fig","pear"]`; got != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+	s := NewOrderedSet("old")
+	if err := json.Unmarshal([]byte(`["b","a","b"]`), s); err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != "{a b}" {
+		t.Errorf("Unmarshal = %v, want {a b}", s)
+	}
+}
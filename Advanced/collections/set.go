@@ -0,0 +1,178 @@
+// Package collections provides generic set types. Set is an unordered set
+// of comparable values backed by a map; OrderedSet keeps ordered values
+// sorted in a red-black tree, for range queries and nearest-value lookups;
+// Multiset counts how many times each value was added. All support union,
+// intersection, difference and subset tests, and the sets marshal to and
+// from JSON arrays. None is safe for concurrent use.
+//
+// ConcurrentMap is a map that is, split into shards with a lock each so
+// that goroutines using different keys rarely contend.
+package collections
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Set is an unordered set of values. The zero value is an empty set ready
+// to use.
+type Set[T comparable] struct {
+	m map[T]struct{}
+}
+
+// NewSet returns a set of the given items
+func NewSet[T comparable](items ...T) *Set[T] {
+	s := &Set[T]{m: make(map[T]struct{}, len(items))}
+	s.Add(items...)
+	return s
+}
+
+// Add adds the items, returning how many were not already present
+func (s *Set[T]) Add(items ...T) int {
+	if s.m == nil {
+		s.m = make(map[T]struct{}, len(items))
+	}
+	added := 0
+	for _, v := range items {
+		if _, ok := s.m[v]; !ok {
+			s.m[v] = struct{}{}
+			added++
+		}
+	}
+	return added
+}
+
+// Remove removes the items, returning how many were present
+func (s *Set[T]) Remove(items ...T) int {
+	removed := 0
+	for _, v := range items {
+		if _, ok := s.m[v]; ok {
+			delete(s.m, v)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Contains reports whether v is in the set
+func (s *Set[T]) Contains(v T) bool {
+	_, ok := s.m[v]
+	return ok
+}
+
+// Len returns the number of values
+func (s *Set[T]) Len() int { return len(s.m) }
+
+// All iterates over the values in no particular order
+func (s *Set[T]) All() iter.Seq[T] { return maps.Keys(s.m) }
+
+// Slice returns the values in no particular order
+func (s *Set[T]) Slice() []T { return slices.Collect(s.All()) }
+
+// Clone returns a copy of the set
+func (s *Set[T]) Clone() *Set[T] {
+	c := maps.Clone(s.m)
+	if c == nil {
+		c = make(map[T]struct{})
+	}
+	return &Set[T]{m: c}
+}
+
+// Union returns a new set of the values in s or o
+// Time Complexity: O(len(s) + len(o))
+func (s *Set[T]) Union(o *Set[T]) *Set[T] {
+	u := s.Clone()
+	for v := range o.m {
+		u.m[v] = struct{}{}
+	}
+	return u
+}
+
+// Intersect returns a new set of the values in both s and o
+// Time Complexity: O(min(len(s), len(o)))
+func (s *Set[T]) Intersect(o *Set[T]) *Set[T] {
+	small, large := s, o
+	if small.Len() > large.Len() {
+		small, large = large, small
+	}
+	in := &Set[T]{m: make(map[T]struct{})}
+	for v := range small.m {
+		if large.Contains(v) {
+			in.m[v] = struct{}{}
+		}
+	}
+	return in
+}
+
+// Difference returns a new set of the values in s but not in o
+// Time Complexity: O(len(s))
+func (s *Set[T]) Difference(o *Set[T]) *Set[T] {
+	d := &Set[T]{m: make(map[T]struct{})}
+	for v := range s.m {
+		if !o.Contains(v) {
+			d.m[v] = struct{}{}
+		}
+	}
+	return d
+}
+
+// IsSubset reports whether every value in s is also in o
+func (s *Set[T]) IsSubset(o *Set[T]) bool {
+	if s.Len() > o.Len() {
+		return false
+	}
+	for v := range s.m {
+		if !o.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether s and o hold the same values
+func (s *Set[T]) Equal(o *Set[T]) bool {
+	return s.Len() == o.Len() && s.IsSubset(o)
+}
+
+// String formats the set as {a b c}, the values sorted by their
+// formatting so the output is stable
+func (s *Set[T]) String() string {
+	items := make([]string, 0, s.Len())
+	for v := range s.m {
+		items = append(items, fmt.Sprint(v))
+	}
+	slices.Sort(items)
+	return "{" + strings.Join(items, " ") + "}"
+}
+
+// MarshalJSON encodes the set as a JSON array. The values are sorted by
+// their encoding, so equal sets encode identically.
+func (s *Set[T]) MarshalJSON() ([]byte, error) {
+	items := make([][]byte, 0, s.Len())
+	for v := range s.m {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, b)
+	}
+	slices.SortFunc(items, bytes.Compare)
+	return append(append([]byte{'['}, bytes.Join(items, []byte{','})...), ']'), nil
+}
+
+// UnmarshalJSON replaces the set's values with those of a JSON array,
+// ignoring repeats
+func (s *Set[T]) UnmarshalJSON(data []byte) error {
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	s.m = make(map[T]struct{}, len(items))
+	s.Add(items...)
+	return nil
+}
@@ -0,0 +1,74 @@
+package collections
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// TestSet tests adding, removing and the zero value
+func TestSet(t *testing.T) {
+	var s Set[string]
+	if s.Contains("a") || s.Len() != 0 || s.Remove("a") != 0 {
+		t.Error("zero value is not an empty set")
+	}
+	if got := s.Add("a", "b", "a"); got != 2 || s.Len() != 2 || !s.Contains("b") {
+		t.Errorf("Add = %d, set %v", got, &s)
+	}
+	if got := s.Remove("a", "z"); got != 1 || s.Contains("a") {
+		t.Errorf("Remove = %d, set %v", got, &s)
+	}
+	c := s.Clone()
+	c.Add("c")
+	if s.Contains("c") {
+		t.Error("adding to a clone changed the original")
+	}
+}
+
+// TestSetAlgebra tests union, intersection, difference and subsets
+func TestSetAlgebra(t *testing.T) {
+	a, b := NewSet(1, 2, 3), NewSet(2, 3, 4)
+	cases := map[string]struct{ got, want *Set[int] }{
+		"union":      {a.Union(b), NewSet(1, 2, 3, 4)},
+		"intersect":  {a.Intersect(b), NewSet(2, 3)},
+		"difference": {a.Difference(b), NewSet(1)},
+		"empty":      {a.Intersect(&Set[int]{}), NewSet[int]()},
+	}
+	for name, c := range cases {
+		if !c.got.Equal(c.want) {
+			t.Errorf("%s = %v, want %v", name, c.got, c.want)
+		}
+	}
+	if !NewSet(2, 3).IsSubset(a) || a.IsSubset(b) || !(&Set[int]{}).IsSubset(a) {
+		t.Error("IsSubset mismatch")
+	}
+	if a.String() != "{1 2 3}" {
+		t.Errorf("String() = %q", a.String())
+	}
+	got := a.Slice()
+	slices.Sort(got)
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("Slice() = %v", got)
+	}
+}
+
+// TestSetJSON tests that a set round-trips through a sorted JSON array
+func TestSetJSON(t *testing.T) {
+	data, err := json.Marshal(struct{ Tags *Set[string] }{NewSet("go", "c", "rust")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `{"Tags":["c","go","rust"]}`; got != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+	var back struct{ Tags Set[string] }
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !back.Tags.Equal(NewSet("go", "c", "rust")) {
+		t.Errorf("Unmarshal = %v", &back.Tags)
+	}
+	if err := json.Unmarshal([]byte(`{"Tags":"go"}`), &back); err == nil {
+		t.Error("Unmarshal of a string succeeded")
+	}
+}
@@ -0,0 +1,356 @@
+// Package config populates a configuration struct from layered sources:
+// defaults from struct tags, then JSON, YAML or TOML files, then
+// environment variables, then command-line flags, each overriding the
+// last. The result is validated by tags and by Validate methods, fields
+// of type Secret print redacted, and Watch reloads the files as they
+// change.
+//
+// Fields are named by their tags:
+//
+//	config:"name"     the key in files, snake_case of the field name if unset, "-" to skip
+//	default:"value"   the value before any source sets one
+//	env:"NAME"        the environment variable, EnvPrefix and the key path in capitals if unset
+//	flag:"name"       the flag, the key path in kebab-case joined by dots if unset
+//	usage:"text"      the flag's help text
+//	validate:"rules"  comma-separated rules: required, min=N, max=N, oneof=a b c
+//	secret:"true"     redacted by Format, as Secret fields are
+//
+// Values are strings in environment variables, flags and defaults, with
+// lists separated by commas. Supported field types are strings, booleans,
+// integers, floats, time.Duration, types implementing
+// encoding.TextUnmarshaler, slices of those, and nested structs.
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// ErrInvalid is wrapped by the errors of validation
+	ErrInvalid = errors.New("invalid configuration")
+	// ErrUnknownKey is wrapped by the error for a key in a file that no
+	// field takes, which is usually a typo
+	ErrUnknownKey = errors.New("unknown configuration key")
+)
+
+// Options chooses the sources of a configuration
+type Options struct {
+	// Files are read in order, later ones overriding earlier ones. The
+	// format follows the extension: .json, .yaml, .yml or .toml.
+	Files []string
+	// EnvPrefix, if set, gives fields without an env tag the variable
+	// EnvPrefix followed by their key path in capitals, as APP_SERVER_PORT.
+	// Without it, only fields with an env tag read the environment.
+	EnvPrefix string
+	// LookupEnv looks up environment variables, os.LookupEnv if nil
+	LookupEnv func(key string) (string, bool)
+	// Args are the command-line arguments to parse as flags, without the
+	// program name; nil means no flags
+	Args []string
+	// Output receives flag errors and help, os.Stderr if nil
+	Output io.Writer
+}
+
+// Load returns a configuration of type T, a struct, populated from the
+// sources of opts and validated. Flag -h returns flag.ErrHelp after
+// printing the flags.
+func Load[T any](opts Options) (*T, error) {
+	cfg := new(T)
+	if err := load(cfg, opts); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// load populates cfg, a pointer to a struct, from the sources of opts
+func load(cfg any, opts Options) error {
+	root := reflect.ValueOf(cfg).Elem()
+	if root.Kind() != reflect.Struct {
+		return fmt.Errorf("config: %v is not a struct", root.Type())
+	}
+	fields := walk(root, nil)
+	for _, f := range fields {
+		if def, ok := f.tag.Lookup("default"); ok {
+			if err := f.set(def); err != nil {
+				return fmt.Errorf("config: default of %s: %w", f.key(), err)
+			}
+		}
+	}
+	for _, path := range opts.Files {
+		if err := loadFile(root, path); err != nil {
+			return err
+		}
+	}
+	if err := loadEnv(fields, opts); err != nil {
+		return err
+	}
+	if opts.Args != nil {
+		if err := loadFlags(fields, opts); err != nil {
+			return err
+		}
+	}
+	return validate(root, fields)
+}
+
+// field is a settable leaf of a configuration struct
+type field struct {
+	path  []string // Keys from the root
+	value reflect.Value
+	tag   reflect.StructTag
+}
+
+// key returns the dotted key path of f, as "server.port"
+func (f field) key() string {
+	return strings.Join(f.path, ".")
+}
+
+// textUnmarshaler is the type of encoding.TextUnmarshaler
+var textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// isLeaf reports whether a value of type t is set from one string, rather
+// than being a struct of fields
+func isLeaf(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct || reflect.PointerTo(t).Implements(textUnmarshaler)
+}
+
+// walk returns the leaves of the struct v, whose keys start with path
+func walk(v reflect.Value, path []string) []field {
+	var fields []field
+	for i := range v.NumField() {
+		sf := v.Type().Field(i)
+		name, ok := keyOf(sf)
+		if !ok {
+			continue
+		}
+		p := append(path[:len(path):len(path)], name)
+		if isLeaf(sf.Type) {
+			fields = append(fields, field{path: p, value: v.Field(i), tag: sf.Tag})
+		} else {
+			fields = append(fields, walk(v.Field(i), p)...)
+		}
+	}
+	return fields
+}
+
+// keyOf returns the key of a struct field, and false for fields skipped
+func keyOf(sf reflect.StructField) (string, bool) {
+	name := sf.Tag.Get("config")
+	if !sf.IsExported() || name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = snake(sf.Name)
+	}
+	return name, true
+}
+
+// snake converts a Go name to snake_case, keeping acronyms together:
+// ReadTimeout is read_timeout and DBHost is db_host
+func snake(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// set parses s into f, splitting it at commas for a slice
+func (f field) set(s string) error {
+	if f.value.Kind() == reflect.Slice && !f.value.Addr().Type().Implements(textUnmarshaler) {
+		var items []string
+		if s = strings.TrimSpace(s); s != "" {
+			items = strings.Split(s, ",")
+		}
+		return f.setList(items)
+	}
+	return setValue(f.value, s)
+}
+
+// setList sets the slice f to items, each parsed
+func (f field) setList(items []string) error {
+	list := reflect.MakeSlice(f.value.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := setValue(list.Index(i), strings.TrimSpace(item)); err != nil {
+			return err
+		}
+	}
+	f.value.Set(list)
+	return nil
+}
+
+// durationType is the type of time.Duration, which parses as "1m30s"
+var durationType = reflect.TypeFor[time.Duration]()
+
+// setValue parses s into v by the kind of v
+func setValue(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+// envName returns the environment variable of f, or "" if it has none
+func envName(f field, prefix string) string {
+	if name := f.tag.Get("env"); name != "" {
+		return name
+	}
+	if prefix == "" {
+		return ""
+	}
+	return prefix + strings.ToUpper(strings.Join(f.path, "_"))
+}
+
+// loadEnv sets the fields whose environment variables are set
+func loadEnv(fields []field, opts Options) error {
+	lookup := opts.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	for _, f := range fields {
+		name := envName(f, opts.EnvPrefix)
+		if name == "" {
+			continue
+		}
+		if s, ok := lookup(name); ok {
+			if err := f.set(s); err != nil {
+				return fmt.Errorf("config: environment variable %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// flagName returns the flag of f
+func flagName(f field) string {
+	if name := f.tag.Get("flag"); name != "" {
+		return name
+	}
+	return strings.ReplaceAll(f.key(), "_", "-")
+}
+
+// loadFlags sets the fields whose flags are given in opts.Args
+func loadFlags(fields []field, opts Options) error {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	if opts.Output != nil {
+		fs.SetOutput(opts.Output)
+	}
+	for _, f := range fields {
+		usage := f.tag.Get("usage")
+		if def, ok := f.tag.Lookup("default"); ok {
+			usage += fmt.Sprintf(" (default %q)", def)
+		}
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(flagName(f), usage, f.set)
+		} else {
+			fs.Func(flagName(f), usage, f.set)
+		}
+	}
+	if err := fs.Parse(opts.Args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("config: flags: %w", err)
+	}
+	return nil
+}
+
+// Secret is a string, such as a password, that prints redacted
+type Secret string
+
+// redacted replaces a secret's value when printed
+const redacted = "[REDACTED]"
+
+// String returns [REDACTED], or "" for an empty secret
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString returns the secret redacted, for %#v
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+// MarshalText returns the secret redacted, so encoders do not leak it
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Reveal returns the secret's value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// Format renders cfg, a struct or a pointer to one, as a line "key = value"
+// per field, with Secret fields and those tagged secret redacted. A
+// configuration's String method can return it.
+func Format(cfg any) string {
+	v := reflect.Indirect(reflect.ValueOf(cfg))
+	var b strings.Builder
+	for _, f := range walk(v, nil) {
+		value := fmt.Sprint(f.value.Interface())
+		if f.tag.Get("secret") == "true" && !f.value.IsZero() {
+			value = redacted
+		}
+		fmt.Fprintf(&b, "%s = %s\n", f.key(), value)
+	}
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serverConfig is a configuration exercising every kind of field
+type serverConfig struct {
+	Name    string     `default:"app"`
+	Debug   bool       `flag:"debug"`
+	Level   slog.Level `default:"INFO"`
+	Tags    []string   `default:"a,b"`
+	Weights []float64  `config:"weights"`
+	Skipped string     `config:"-"`
+	hidden  string
+	Server  struct {
+		Host        string        `default:"localhost" env:"HOST"`
+		Port        int           `default:"8080" validate:"min=1,max=65535" usage:"port to listen on"`
+		ReadTimeout time.Duration `default:"5s"`
+	}
+	DB struct {
+		URL      string `validate:"required"`
+		Password Secret
+		Token    string `secret:"true"`
+	}
+}
+
+// writeFile writes a file under a test's temporary directory and returns
+// its path
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// env returns a LookupEnv over vars
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+// TestSnake tests naming keys after fields
+func TestSnake(t *testing.T) {
+	for name, want := range map[string]string{
+		"Port": "port", "ReadTimeout": "read_timeout", "DBHost": "db_host",
+		"URL": "url", "HTTP2Port": "http2_port", "userID": "user_id",
+	} {
+		if got := snake(name); got != want {
+			t.Errorf("snake(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// TestDefaults tests that defaults fill fields no source sets
+func TestDefaults(t *testing.T) {
+	cfg, err := Load[serverConfig](Options{LookupEnv: env(map[string]string{"HOST": "", "DB": "x"})})
+	if err == nil {
+		t.Fatalf("Load without db.url = %+v, want an error", cfg)
+	}
+	cfg, err = Load[serverConfig](Options{LookupEnv: env(map[string]string{"APP_DB_URL": "postgres://db"}), EnvPrefix: "APP_"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "app" || cfg.Level != slog.LevelInfo || !reflect.DeepEqual(cfg.Tags, []string{"a", "b"}) ||
+		cfg.Server.Host != "localhost" || cfg.Server.Port != 8080 || cfg.Server.ReadTimeout != 5*time.Second {
+		t.Errorf("Load = %+v, want the defaults", cfg)
+	}
+}
+
+// TestPriority tests that each source overrides the ones before it
+func TestPriority(t *testing.T) {
+	base := writeFile(t, "base.json", `{"name": "base", "server": {"port": 9000, "host": "file"}, "weights": [0.5, 1e2],
+		"db": {"url": "postgres://file"}}`)
+	local := writeFile(t, "local.yaml", "server:\n  port: 9001\n")
+	opts := Options{
+		Files:     []string{base, local},
+		EnvPrefix: "APP_",
+		LookupEnv: env(map[string]string{"APP_SERVER_PORT": "9002", "HOST": "env", "APP_TAGS": "x, y"}),
+		Args:      []string{"-server.port", "9003", "-debug", "-db.password=hunter2"},
+	}
+	cfg, err := Load[serverConfig](opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "base" || cfg.Server.Host != "env" || cfg.Server.Port != 9003 || !cfg.Debug ||
+		cfg.DB.Password.Reveal() != "hunter2" || !reflect.DeepEqual(cfg.Tags, []string{"x", "y"}) ||
+		!reflect.DeepEqual(cfg.Weights, []float64{0.5, 100}) {
+		t.Errorf("Load = %+v, want flags over env over files over defaults", cfg)
+	}
+
+	opts.Args = nil
+	if cfg, err = Load[serverConfig](opts); err != nil || cfg.Server.Port != 9002 || cfg.Debug {
+		t.Errorf("Load without flags = %+v, %v, want port 9002 from the environment", cfg, err)
+	}
+	opts.LookupEnv = env(nil)
+	if cfg, err = Load[serverConfig](opts); err != nil || cfg.Server.Port != 9001 || cfg.Server.Host != "file" {
+		t.Errorf("Load without env = %+v, %v, want port 9001 from the later file", cfg, err)
+	}
+}
+
+// TestLoadErrors tests that bad values name their source
+func TestLoadErrors(t *testing.T) {
+	good := Options{LookupEnv: env(map[string]string{"APP_DB_URL": "x"}), EnvPrefix: "APP_"}
+	tests := []struct {
+		name string
+		opts func(o Options) Options
+		want string
+	}{
+		{"env", func(o Options) Options {
+			o.LookupEnv = env(map[string]string{"APP_DB_URL": "x", "APP_SERVER_PORT": "eighty"})
+			return o
+		}, "APP_SERVER_PORT"},
+		{"flag", func(o Options) Options { o.Args = []string{"-server.read-timeout", "soon"}; return o }, "read-timeout"},
+		{"unknown flag", func(o Options) Options { o.Args = []string{"-nope"}; return o }, "nope"},
+		{"missing file", func(o Options) Options { o.Files = []string{filepath.Join(t.TempDir(), "none.json")}; return o }, "none.json"},
+		{"format", func(o Options) Options { o.Files = []string{writeFile(t, "c.ini", "")}; return o }, "unsupported format"},
+		{"file value", func(o Options) Options {
+			o.Files = []string{writeFile(t, "c.toml", "[server]\nport = \"x\"\n")}
+			return o
+		}, "server.port"},
+	}
+	for _, tt := range tests {
+		opts := tt.opts(good)
+		opts.Output = io.Discard
+		if _, err := Load[serverConfig](opts); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Load error = %v, want one naming %q", tt.name, err, tt.want)
+		}
+	}
+
+	opts := good
+	opts.Args, opts.Output = []string{"-h"}, io.Discard
+	if _, err := Load[serverConfig](opts); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Load -h = %v, want flag.ErrHelp", err)
+	}
+	if _, err := Load[int](Options{}); err == nil {
+		t.Error("Load[int] succeeded, want an error for a non-struct")
+	}
+}
+
+// TestSecret tests that secrets do not print
+func TestSecret(t *testing.T) {
+	var cfg serverConfig
+	cfg.DB.Password, cfg.DB.Token, cfg.DB.URL = "hunter2", "t0ken", "postgres://db"
+	for _, s := range []string{Format(cfg), Format(&cfg)} {
+		if strings.Contains(s, "hunter2") || strings.Contains(s, "t0ken") {
+			t.Errorf("Format = %q, want the secrets redacted", s)
+		}
+	}
+	// A Secret stays hidden however the struct holding it prints, unlike
+	// a string tagged secret, which only Format redacts
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+		if s := fmt.Sprintf(verb, cfg.DB); strings.Contains(s, "hunter2") {
+			t.Errorf("Sprintf(%q) = %q, want the Secret redacted", verb, s)
+		}
+	}
+	out := Format(cfg)
+	for _, want := range []string{"db.url = postgres://db\n", "db.password = [REDACTED]\n", "db.token = [REDACTED]\n", "server.port = 0\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Format = %q, want it to contain %q", out, want)
+		}
+	}
+	if Secret("").String() != "" {
+		t.Error("empty Secret prints redacted, want empty")
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parseFile reads the file at path into a tree of maps, lists and scalars,
+// choosing the format by its extension
+func parseFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	var tree map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&tree)
+	case ".yaml", ".yml":
+		tree, err = parseYAML(data)
+	case ".toml":
+		tree, err = parseTOML(data)
+	default:
+		return nil, fmt.Errorf("config: %s: unsupported format %q", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return tree, nil
+}
+
+// loadFile sets the fields of root from the file at path
+func loadFile(root reflect.Value, path string) error {
+	tree, err := parseFile(path)
+	if err != nil {
+		return err
+	}
+	if err := apply(root, tree, nil); err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	return nil
+}
+
+// apply sets the fields of the struct v from the map tree, whose keys
+// start with path, and fails on keys no field takes
+func apply(v reflect.Value, tree map[string]any, path []string) error {
+	used := make(map[string]bool, len(tree))
+	for i := range v.NumField() {
+		sf := v.Type().Field(i)
+		name, ok := keyOf(sf)
+		if !ok {
+			continue
+		}
+		node, ok := tree[name]
+		if !ok {
+			continue
+		}
+		used[name] = true
+		p := append(path[:len(path):len(path)], name)
+		if node == nil {
+			continue
+		}
+		if !isLeaf(sf.Type) {
+			sub, ok := node.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s: want a table of keys, got %v", strings.Join(p, "."), node)
+			}
+			if err := apply(v.Field(i), sub, p); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := setNode(field{path: p, value: v.Field(i), tag: sf.Tag}, node); err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(p, "."), err)
+		}
+	}
+	var unknown []string
+	for name := range tree {
+		if !used[name] {
+			unknown = append(unknown, strings.Join(append(path[:len(path):len(path)], name), "."))
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%w: %s", ErrUnknownKey, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// setNode sets the leaf f from a node of a parsed file
+func setNode(f field, node any) error {
+	switch node := node.(type) {
+	case []any:
+		if f.value.Kind() != reflect.Slice {
+			return fmt.Errorf("want a single value, got a list")
+		}
+		items := make([]string, len(node))
+		for i, item := range node {
+			s, err := scalar(item)
+			if err != nil {
+				return err
+			}
+			items[i] = s
+		}
+		return f.setList(items)
+	case map[string]any:
+		return fmt.Errorf("want a value, got a table of keys")
+	default:
+		s, err := scalar(node)
+		if err != nil {
+			return err
+		}
+		return f.set(s)
+	}
+}
+
+// scalar renders a scalar node as the string it would be in a flag
+func scalar(node any) (string, error) {
+	switch node := node.(type) {
+	case string:
+		return node, nil
+	case json.Number:
+		return node.String(), nil
+	case bool:
+		return strconv.FormatBool(node), nil
+	}
+	return "", fmt.Errorf("want a value, got %v", node)
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestFormats tests that each file format fills the same configuration
+func TestFormats(t *testing.T) {
+	files := map[string]string{
+		"c.json": `{"name": "svc", "tags": ["x", "y"], "server": {"port": 81}, "db": {"url": "u"}}`,
+		"c.yaml": "name: svc\ntags:\n  - x\n  - y\nserver:\n  port: 81\ndb:\n  url: u\n",
+		"c.yml":  "name: svc\ntags: [x, y]\nserver: {port: 81}\n",
+		"c.toml": "name = \"svc\"\ntags = [\"x\", \"y\"]\n[server]\nport = 81\n[db]\nurl = \"u\"\n",
+	}
+	for name, contents := range files {
+		cfg, err := Load[serverConfig](Options{Files: []string{writeFile(t, name, contents)}})
+		if name == "c.yml" {
+			// Flow mappings are outside the subset
+			if err == nil {
+				t.Errorf("%s: Load succeeded, want an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if cfg.Name != "svc" || !reflect.DeepEqual(cfg.Tags, []string{"x", "y"}) || cfg.Server.Port != 81 || cfg.DB.URL != "u" {
+			t.Errorf("%s: Load = %+v", name, cfg)
+		}
+	}
+}
+
+// TestUnknownKeys tests that keys no field takes are rejected
+func TestUnknownKeys(t *testing.T) {
+	path := writeFile(t, "c.yaml", "db:\n  url: u\nserver:\n  prot: 80\nskipped: x\n")
+	_, err := Load[serverConfig](Options{Files: []string{path}})
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Load = %v, want ErrUnknownKey", err)
+	}
+	path = writeFile(t, "c.json", `{"db": {"url": "u"}, "skipped": "x", "hidden": "y"}`)
+	if _, err := Load[serverConfig](Options{Files: []string{path}}); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Load = %v, want ErrUnknownKey for keys of skipped fields", err)
+	}
+}
+
+// TestShapeMismatch tests values of the wrong shape for their fields
+func TestShapeMismatch(t *testing.T) {
+	for _, contents := range []string{
+		`{"server": 1}`,
+		`{"name": ["a"]}`,
+		`{"name": {"a": 1}}`,
+		`{"tags": [{"a": 1}]}`,
+	} {
+		path := writeFile(t, "c.json", contents)
+		if _, err := Load[serverConfig](Options{Files: []string{path}}); err == nil {
+			t.Errorf("Load %s succeeded, want an error", contents)
+		}
+	}
+	// A null leaves the field as it was
+	path := writeFile(t, "c.json", `{"name": null, "db": {"url": "u"}}`)
+	if cfg, err := Load[serverConfig](Options{Files: []string{path}}); err != nil || cfg.Name != "app" {
+		t.Errorf("Load with null = %+v, %v, want the default kept", cfg, err)
+	}
+}
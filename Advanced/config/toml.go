@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML parses the subset of TOML that configuration files use:
+// tables, dotted keys, strings, numbers, booleans, dates and
+// arrays of scalars, which may span lines. Inline tables and arrays of
+// tables are not supported. Scalars are returned as strings.
+func parseTOML(data []byte) (map[string]any, error) {
+	root := make(map[string]any)
+	table := root
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		num := i + 1
+		text := strings.TrimSpace(stripComment(strings.TrimRight(lines[i], "\r")))
+		switch {
+		case text == "":
+			continue
+		case strings.HasPrefix(text, "[["):
+			return nil, fmt.Errorf("line %d: arrays of tables are not supported", num)
+		case strings.HasPrefix(text, "["):
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("line %d: unterminated table header", num)
+			}
+			keys, err := tomlKeys(text[1 : len(text)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			if table, err = subtable(root, keys); err != nil {
+				return nil, fmt.Errorf("line %d: %w", num, err)
+			}
+			continue
+		}
+		eq := indexUnquoted(text, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: want key = value, got %q", num, text)
+		}
+		keys, err := tomlKeys(text[:eq])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		raw := strings.TrimSpace(text[eq+1:])
+		// An array runs until its brackets close
+		for strings.HasPrefix(raw, "[") && !closed(raw) && i+1 < len(lines) {
+			i++
+			raw += " " + strings.TrimSpace(stripComment(strings.TrimRight(lines[i], "\r")))
+		}
+		value, err := tomlValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		parent, err := subtable(table, keys[:len(keys)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		key := keys[len(keys)-1]
+		if _, dup := parent[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", num, key)
+		}
+		parent[key] = value
+	}
+	return root, nil
+}
+
+// closed reports whether the brackets of an array are balanced, outside
+// strings
+func closed(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// indexUnquoted returns the index of the first c in s outside quotes, or
+// -1 if there is none
+func indexUnquoted(s string, c byte) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' && quote == '"' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == c:
+			return i
+		}
+	}
+	return -1
+}
+
+// tomlKeys splits a dotted key from left to right, unquoting its parts. A
+// quoted part is a single key, dots and all.
+func tomlKeys(s string) ([]string, error) {
+	var keys []string
+	for rest := s; ; {
+		dot := indexUnquoted(rest, '.')
+		if dot < 0 {
+			dot = len(rest)
+		}
+		part := strings.TrimSpace(rest[:dot])
+		key := part
+		if strings.HasPrefix(part, `"`) || strings.HasPrefix(part, "'") {
+			var err error
+			if key, err = tomlScalar(part); err != nil {
+				return nil, err
+			}
+		} else if strings.ContainsAny(part, "\"' \t") {
+			return nil, fmt.Errorf("invalid key %q", part)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("empty key in %q", strings.TrimSpace(s))
+		}
+		keys = append(keys, key)
+		if dot == len(rest) {
+			return keys, nil
+		}
+		rest = rest[dot+1:]
+	}
+}
+
+// subtable returns the table at keys below table, creating missing ones
+func subtable(table map[string]any, keys []string) (map[string]any, error) {
+	for _, key := range keys {
+		switch next := table[key].(type) {
+		case map[string]any:
+			table = next
+		case nil:
+			sub := make(map[string]any)
+			table[key] = sub
+			table = sub
+		default:
+			return nil, fmt.Errorf("key %q is a value, not a table", key)
+		}
+	}
+	return table, nil
+}
+
+// tomlValue parses the value of a key: an array or a scalar
+func tomlValue(s string) (any, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("missing value")
+	case strings.HasPrefix(s, "{"):
+		return nil, fmt.Errorf("inline tables are not supported")
+	case strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, "'''"):
+		return nil, fmt.Errorf("multi-line strings are not supported")
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("unterminated array %q", s)
+		}
+		items, err := splitList(s[1 : len(s)-1])
+		if err != nil {
+			return nil, err
+		}
+		list := make([]any, 0, len(items))
+		for _, item := range items {
+			v, err := tomlScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+	return tomlScalar(s)
+}
+
+// tomlScalar unquotes a string, or drops the underscores of a number
+func tomlScalar(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		if len(s) < 2 || !strings.HasSuffix(s, `"`) {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return s[1 : len(s)-1], nil
+	case s == "true" || s == "false":
+		return s, nil
+	case strings.ContainsAny(s, " \t") && !strings.ContainsAny(s, ":-"):
+		return "", fmt.Errorf("invalid value %q", s)
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64); err == nil ||
+		strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0o") || strings.HasPrefix(s, "0b") {
+		return strings.ReplaceAll(s, "_", ""), nil
+	}
+	// Dates and times stay as written, for a TextUnmarshaler to parse
+	if s != "" && s[0] >= '0' && s[0] <= '9' {
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid value %q; strings must be quoted", s)
+}
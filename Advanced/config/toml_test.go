@@ -0,0 +1,104 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseTOML tests the supported subset of TOML
+func TestParseTOML(t *testing.T) {
+	doc := `# A service
+name = "svc # not a comment" # a comment
+path = 'C:\dir'
+debug = true
+size = 1_000
+ratio = 0.5
+hex = 0xff
+born = 1979-05-27T07:32:00Z
+server.host = "localhost"
+
+[server.limits]
+rps = 10
+hosts = [
+  "a", # first
+  "b",
+]
+
+["db"]
+url = "postgres://\u0064b"
+`
+	got, err := parseTOML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"name":  "svc # not a comment",
+		"path":  `C:\dir`,
+		"debug": "true",
+		"size":  "1000",
+		"ratio": "0.5",
+		"hex":   "0xff",
+		"born":  "1979-05-27T07:32:00Z",
+		"server": map[string]any{
+			"host":   "localhost",
+			"limits": map[string]any{"rps": "10", "hosts": []any{"a", "b"}},
+		},
+		"db": map[string]any{"url": "postgres://db"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTOML =\n%v\nwant\n%v", got, want)
+	}
+}
+
+// TestParseTOMLQuotedKeys tests that a quoted part of a key is one key,
+// even when it holds dots or an equals sign
+func TestParseTOMLQuotedKeys(t *testing.T) {
+	doc := `"a.b" = 1
+site."example.com" = "x"
+site.'x=y'.port = 2
+
+[hosts."db.local"]
+user = "admin"
+`
+	got, err := parseTOML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"a.b": "1",
+		"site": map[string]any{
+			"example.com": "x",
+			"x=y":         map[string]any{"port": "2"},
+		},
+		"hosts": map[string]any{"db.local": map[string]any{"user": "admin"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTOML =\n%v\nwant\n%v", got, want)
+	}
+}
+
+// TestParseTOMLErrors tests that TOML outside the subset is rejected
+func TestParseTOMLErrors(t *testing.T) {
+	for _, doc := range []string{
+		"a = bare\n",
+		"a\n",
+		"a = \n",
+		"a = 1\na = 2\n",
+		"a = 1\n[a]\n",
+		"[a\n",
+		"[[a]]\n",
+		"a = {b = 1}\n",
+		"a = \"\"\"\ntext\n\"\"\"\n",
+		"a = \"open\n",
+		"a = [1, [2]]\n",
+		"a = [1, 2\n",
+		". = 1\n",
+		"a. = 1\n",
+		"a b = 1\n",
+		"site.\"open = 1\n",
+	} {
+		if got, err := parseTOML([]byte(doc)); err == nil {
+			t.Errorf("parseTOML(%q) = %v, want an error", doc, got)
+		}
+	}
+}
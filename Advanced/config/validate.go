@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validator is implemented by configuration structs, at any depth, that
+// check themselves beyond what validate tags can say, such as a rule
+// between two fields. Validate is called after the tags pass.
+type Validator interface {
+	Validate() error
+}
+
+// validate checks the validate tags of fields, then the Validate methods
+// of root and its nested structs, and joins every failure
+func validate(root reflect.Value, fields []field) error {
+	var errs []error
+	for _, f := range fields {
+		rules, ok := f.tag.Lookup("validate")
+		if !ok {
+			continue
+		}
+		for rule := range strings.SplitSeq(rules, ",") {
+			if err := check(f, strings.TrimSpace(rule)); err != nil {
+				errs = append(errs, fmt.Errorf("config: %s: %w", f.key(), err))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		errs = validators(root, nil)
+	}
+	return errors.Join(errs...)
+}
+
+// validators calls the Validate methods of the struct v and those nested
+// in it, the deepest first
+func validators(v reflect.Value, path []string) []error {
+	var errs []error
+	for i := range v.NumField() {
+		sf := v.Type().Field(i)
+		name, ok := keyOf(sf)
+		if ok && !isLeaf(sf.Type) {
+			errs = append(errs, validators(v.Field(i), append(path[:len(path):len(path)], name))...)
+		}
+	}
+	if val, ok := v.Addr().Interface().(Validator); ok {
+		if err := val.Validate(); err != nil {
+			if len(path) > 0 {
+				err = fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+			}
+			errs = append(errs, fmt.Errorf("config: %w: %w", ErrInvalid, err))
+		}
+	}
+	return errs
+}
+
+// check applies one validate rule to f
+func check(f field, rule string) error {
+	name, arg, _ := strings.Cut(rule, "=")
+	v := f.value
+	switch name {
+	case "":
+		return nil
+	case "required":
+		if v.IsZero() || (v.Kind() == reflect.Slice && v.Len() == 0) {
+			return fmt.Errorf("%w: required", ErrInvalid)
+		}
+	case "min", "max":
+		limit, size, err := measure(v, arg)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", rule, err)
+		}
+		if name == "min" && size < limit {
+			return fmt.Errorf("%w: must be at least %s", ErrInvalid, arg)
+		}
+		if name == "max" && size > limit {
+			return fmt.Errorf("%w: must be at most %s", ErrInvalid, arg)
+		}
+	case "oneof":
+		choices := strings.Fields(arg)
+		if !slices.Contains(choices, fmt.Sprint(v.Interface())) {
+			return fmt.Errorf("%w: must be one of %s", ErrInvalid, strings.Join(choices, ", "))
+		}
+	default:
+		// Secure: a misspelt rule must not pass silently
+		return fmt.Errorf("unknown rule %q", rule)
+	}
+	return nil
+}
+
+// measure returns the size of v that min and max bound, its value for a
+// number and its length for a string or slice, and the limit arg parsed
+// to compare with it
+func measure(v reflect.Value, arg string) (limit, size float64, err error) {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(arg)
+		return float64(d), float64(v.Int()), err
+	}
+	limit, err = strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return limit, float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return limit, float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return limit, v.Float(), nil
+	case reflect.String, reflect.Slice:
+		return limit, float64(v.Len()), nil
+	}
+	return 0, 0, fmt.Errorf("unsupported type %v", v.Type())
+}
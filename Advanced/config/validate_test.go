@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// limits is a configuration with validate tags and a Validate method
+type limits struct {
+	Mode    string        `default:"fast" validate:"oneof=fast safe"`
+	Workers int           `default:"4" validate:"min=1,max=64"`
+	Ratio   float64       `default:"0.5" validate:"max=1"`
+	Name    string        `default:"pool" validate:"required, min=2"`
+	Hosts   []string      `default:"a" validate:"required,max=3"`
+	Timeout time.Duration `default:"1s" validate:"min=10ms,max=1m"`
+	Retry   retryLimits
+}
+
+// retryLimits checks a rule between its fields
+type retryLimits struct {
+	Min time.Duration `default:"1ms"`
+	Max time.Duration `default:"1s"`
+}
+
+var errOrder = errors.New("min above max")
+
+func (r *retryLimits) Validate() error {
+	if r.Min > r.Max {
+		return errOrder
+	}
+	return nil
+}
+
+// TestValidate tests validate tags and Validate methods
+func TestValidate(t *testing.T) {
+	if _, err := Load[limits](Options{}); err != nil {
+		t.Fatalf("Load of the defaults = %v, want success", err)
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-mode", "slow"}, "mode: invalid configuration: must be one of fast, safe"},
+		{[]string{"-workers", "0"}, "workers: invalid configuration: must be at least 1"},
+		{[]string{"-workers", "65"}, "must be at most 64"},
+		{[]string{"-ratio", "1.5"}, "ratio"},
+		{[]string{"-name", ""}, "name: invalid configuration: required"},
+		{[]string{"-name", "x"}, "must be at least 2"},
+		{[]string{"-hosts", ""}, "hosts: invalid configuration: required"},
+		{[]string{"-hosts", "a,b,c,d"}, "must be at most 3"},
+		{[]string{"-timeout", "1ms"}, "must be at least 10ms"},
+		{[]string{"-retry.min", "2s"}, "retry: min above max"},
+	}
+	for _, tt := range tests {
+		_, err := Load[limits](Options{Args: tt.args})
+		if !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load %v = %v, want ErrInvalid with %q", tt.args, err, tt.want)
+		}
+	}
+
+	_, err := Load[limits](Options{Args: []string{"-workers", "0", "-mode", "slow", "-retry.min", "2s"}})
+	if n := strings.Count(err.Error(), "\n") + 1; n != 2 || errors.Is(err, errOrder) {
+		t.Errorf("Load = %v, want both tag failures joined, and Validate not called", err)
+	}
+}
+
+// TestUnknownRule tests that a misspelt rule is an error, not a pass
+func TestUnknownRule(t *testing.T) {
+	type bad struct {
+		Port int `validate:"mni=1"`
+	}
+	if _, err := Load[bad](Options{}); err == nil || !strings.Contains(err.Error(), "unknown rule") {
+		t.Errorf("Load = %v, want an unknown rule error", err)
+	}
+}
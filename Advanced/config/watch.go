@@ -0,0 +1,174 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultInterval is how often a Watcher polls its files if
+// WatchOptions.Interval is 0 or less
+const DefaultInterval = time.Second
+
+// WatchOptions configures a Watcher. Zero fields take their defaults.
+type WatchOptions[T any] struct {
+	// Interval is how often the files are polled, DefaultInterval if 0 or
+	// less. A change takes effect within two intervals.
+	Interval time.Duration
+	// OnChange, if set, is called with each configuration reloaded after
+	// its files changed, once it is current
+	OnChange func(cfg *T)
+	// OnError, if set, is called when a reload fails, as when a file is
+	// invalid or half written; the last good configuration stays current
+	OnError func(err error)
+}
+
+// stamp identifies the contents of a file without keeping them
+type stamp struct {
+	info    os.FileInfo
+	sum     [sha256.Size]byte
+	missing bool
+}
+
+// Watcher holds a configuration and reloads it when its files change.
+// Polling rather than file system events keeps it portable and lets it
+// follow files replaced by a rename, as editors and orchestrators do.
+type Watcher[T any] struct {
+	opts    Options
+	wopts   WatchOptions[T]
+	current atomic.Pointer[T]
+	stamps  []stamp // the files as last loaded, or last failed to load
+	pending []stamp // the files at the last poll, if they differ from stamps
+
+	stop context.CancelFunc
+	done chan struct{}
+	once sync.Once
+}
+
+// Watch loads a configuration as Load does, then reloads it whenever the
+// contents of one of opts.Files change, until ctx ends or Stop is called.
+// Environment variables and flags are applied again on each reload.
+func Watch[T any](ctx context.Context, opts Options, wopts WatchOptions[T]) (*Watcher[T], error) {
+	if wopts.Interval <= 0 {
+		wopts.Interval = DefaultInterval
+	}
+	w := &Watcher[T]{opts: opts, wopts: wopts, done: make(chan struct{})}
+	w.stamps = w.poll(nil)
+	cfg, err := Load[T](opts)
+	if err != nil {
+		return nil, err
+	}
+	w.current.Store(cfg)
+	ctx, w.stop = context.WithCancel(ctx)
+	go w.run(ctx)
+	return w, nil
+}
+
+// Current returns the configuration last loaded successfully. It must be
+// treated as read-only, as other goroutines may hold it too.
+func (w *Watcher[T]) Current() *T {
+	return w.current.Load()
+}
+
+// Stop stops polling and waits for a reload in progress to finish. Stop
+// may be called more than once.
+func (w *Watcher[T]) Stop() {
+	w.once.Do(w.stop)
+	<-w.done
+}
+
+// run polls the files every Interval until ctx ends
+func (w *Watcher[T]) run(ctx context.Context) {
+	defer close(w.done)
+	ticker := time.NewTicker(w.wopts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// check reloads the configuration if the files have changed since the
+// last load. A file written in place may be caught empty or half written,
+// so a change is acted on only once the files read the same on two polls
+// in a row. A failed reload is retried only after a further change, so
+// the same broken contents are reported once.
+func (w *Watcher[T]) check() {
+	prev := w.stamps
+	if w.pending != nil {
+		prev = w.pending
+	}
+	stamps := w.poll(prev)
+	if equalStamps(stamps, w.stamps) {
+		w.pending = nil
+		return
+	}
+	if w.pending == nil || !equalStamps(stamps, w.pending) {
+		w.pending = stamps
+		return
+	}
+	w.stamps, w.pending = stamps, nil
+	cfg, err := Load[T](w.opts)
+	if err != nil {
+		if w.wopts.OnError != nil {
+			w.wopts.OnError(err)
+		}
+		return
+	}
+	w.current.Store(cfg)
+	if w.wopts.OnChange != nil {
+		w.wopts.OnChange(cfg)
+	}
+}
+
+// poll stamps each file. The hash is taken only when the file was replaced
+// or its modification time or size moved since prev, so an unchanged file
+// costs a stat per poll.
+func (w *Watcher[T]) poll(prev []stamp) []stamp {
+	stamps := make([]stamp, len(w.opts.Files))
+	for i, path := range w.opts.Files {
+		info, err := os.Stat(path)
+		if err != nil {
+			stamps[i].missing = true
+			continue
+		}
+		stamps[i].info = info
+		if i < len(prev) && !prev[i].missing && sameFile(prev[i].info, info) {
+			stamps[i].sum = prev[i].sum
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			stamps[i].missing = true
+			continue
+		}
+		stamps[i].sum = sha256.Sum256(data)
+	}
+	return stamps
+}
+
+// sameFile reports whether a and b describe the same file, unmodified.
+// Modification times are coarse, so a file replaced by a rename within
+// one tick is told apart by its identity.
+func sameFile(a, b os.FileInfo) bool {
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+// equalStamps reports whether the contents of the files are unchanged;
+// a touched file with the same contents is not a change
+func equalStamps(a, b []stamp) bool {
+	for i := range a {
+		if a[i].missing != b[i].missing || !bytes.Equal(a[i].sum[:], b[i].sum[:]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor fails the test unless cond becomes true within a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// watched is the configuration the watcher tests reload
+type watched struct {
+	Port int `validate:"min=1"`
+}
+
+// replaceFile replaces the contents of path atomically, as editors and
+// orchestrators do, so no poll sees it half written
+func replaceFile(t *testing.T, path, contents string) {
+	t.Helper()
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tmp.WriteString(contents)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		t.Fatal(err)
+	}
+}
+
+// TestWatch tests reloading on change and keeping the last good
+// configuration on a bad one
+func TestWatch(t *testing.T) {
+	path := writeFile(t, "c.yaml", "port: 1\n")
+	var changes, failures atomic.Int32
+	w, err := Watch(context.Background(), Options{Files: []string{path}}, WatchOptions[watched]{
+		Interval: time.Millisecond,
+		OnChange: func(*watched) { changes.Add(1) },
+		OnError:  func(error) { failures.Add(1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if w.Current().Port != 1 {
+		t.Fatalf("Current = %+v, want port 1", w.Current())
+	}
+
+	// A change with a different size, then one with the same size and the
+	// modification time of an earlier version
+	stat, _ := os.Stat(path)
+	replaceFile(t, path, "port: 22\n")
+	waitFor(t, "port 22", func() bool { return w.Current().Port == 22 })
+	replaceFile(t, path, "port: 33\n")
+	os.Chtimes(path, stat.ModTime(), stat.ModTime())
+	waitFor(t, "port 33", func() bool { return w.Current().Port == 33 })
+
+	replaceFile(t, path, "port: 0\n")
+	waitFor(t, "a failed reload", func() bool { return failures.Load() > 0 })
+	if w.Current().Port != 33 {
+		t.Errorf("Current after a bad file = %+v, want port 33 kept", w.Current())
+	}
+
+	// Rewriting a file with the same contents is not a change
+	changed, failed := changes.Load(), failures.Load()
+	replaceFile(t, path, "port: 0\n")
+	time.Sleep(20 * time.Millisecond)
+	if changes.Load() != changed || failures.Load() != failed {
+		t.Errorf("Rewriting the same contents reloaded: %d changes and %d failures, had %d and %d",
+			changes.Load(), failures.Load(), changed, failed)
+	}
+	os.Remove(path)
+	waitFor(t, "a failed reload of a missing file", func() bool { return failures.Load() > failed })
+	if w.Current().Port != 33 {
+		t.Errorf("Current after removing the file = %+v, want port 33 kept", w.Current())
+	}
+}
+
+// TestWatchPartialWrite tests that a file caught half written is not
+// loaded: the watcher waits until it reads the same on two polls
+func TestWatchPartialWrite(t *testing.T) {
+	path := writeFile(t, "c.yaml", "port: 1\n")
+	var failures atomic.Int32
+	w, err := Watch(context.Background(), Options{Files: []string{path}}, WatchOptions[watched]{
+		Interval: time.Hour, // polled by hand below
+		OnError:  func(error) { failures.Add(1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// Truncated, as os.WriteFile leaves it before writing
+	replaceFile(t, path, "")
+	w.check()
+	replaceFile(t, path, "port: 2\n")
+	w.check()
+	if w.Current().Port != 1 || failures.Load() != 0 {
+		t.Fatalf("Reloaded before the file settled: %+v, %d failures", w.Current(), failures.Load())
+	}
+	w.check()
+	if w.Current().Port != 2 || failures.Load() != 0 {
+		t.Errorf("After two equal polls: %+v, %d failures, want port 2", w.Current(), failures.Load())
+	}
+}
+
+// TestWatchStop tests that the watcher stops with Stop or its context
+func TestWatchStop(t *testing.T) {
+	path := writeFile(t, "c.json", `{"port": 1}`)
+	if _, err := Watch(context.Background(), Options{Files: []string{path + ".missing"}}, WatchOptions[watched]{}); err == nil {
+		t.Error("Watch of a missing file succeeded, want the error of Load")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := Watch(ctx, Options{Files: []string{path}}, WatchOptions[watched]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-w.done
+	w.Stop()
+	w.Stop()
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a line of YAML without its indentation and comment
+type yamlLine struct {
+	num    int // 1-based, for errors
+	indent int
+	text   string
+}
+
+// parseYAML parses the subset of YAML that configuration files use:
+// nested mappings by indentation, sequences of scalars in block or flow
+// style, plain and quoted scalars, and comments. Anchors, multi-line
+// scalars and multiple documents are not supported. Scalars are returned
+// as strings, null as nil.
+func parseYAML(data []byte) (map[string]any, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(string(data), "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		body := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(body, "\t") {
+			return nil, fmt.Errorf("line %d: tab in indentation", i+1)
+		}
+		text := strings.TrimSpace(stripComment(body))
+		if text == "" || (len(lines) == 0 && text == "---") {
+			continue
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(raw) - len(body), text: text})
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+	if lines[0].indent != 0 {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[0].num)
+	}
+	p := &yamlParser{lines: lines}
+	tree, err := p.mapping(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[p.i].num)
+	}
+	return tree, nil
+}
+
+// stripComment removes a comment, a # at the start or after a space,
+// outside quotes
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// yamlParser walks the lines of a document
+type yamlParser struct {
+	lines []yamlLine
+	i     int // The next line
+}
+
+// isItem reports whether a line is a sequence item
+func isItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// block parses the nested block after a key or item on a line of
+// indentation parent, or returns nil if there is none
+func (p *yamlParser) block(parent int, allowSameIndentSeq bool) (any, error) {
+	if p.i >= len(p.lines) {
+		return nil, nil
+	}
+	next := p.lines[p.i]
+	switch {
+	case next.indent > parent && isItem(next.text):
+		return p.sequence(next.indent)
+	case next.indent > parent:
+		return p.mapping(next.indent)
+	case next.indent == parent && allowSameIndentSeq && isItem(next.text):
+		// A sequence may sit at the indentation of its key
+		return p.sequence(next.indent)
+	}
+	return nil, nil
+}
+
+// mapping parses the keys at indentation indent
+func (p *yamlParser) mapping(indent int) (map[string]any, error) {
+	m := make(map[string]any)
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent || isItem(line.text) {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		key, rest, err := splitKey(line.text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.num, key)
+		}
+		p.i++
+		var value any
+		if rest == "" {
+			value, err = p.block(indent, true)
+		} else {
+			value, err = yamlValue(rest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+// sequence parses the items at indentation indent
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	var list []any
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		if line.indent != indent || !isItem(line.text) {
+			break
+		}
+		p.i++
+		rest := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		var value any
+		var err error
+		if rest == "" {
+			value, err = p.block(indent, false)
+		} else {
+			value, err = yamlValue(rest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.num, err)
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+// splitKey splits "key: value" into its key and value, unquoting the key
+func splitKey(text string) (key, rest string, err error) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			key, err = yamlScalar(strings.TrimSpace(text[:i]))
+			return key, strings.TrimSpace(text[i+1:]), err
+		}
+	}
+	return "", "", fmt.Errorf("want key: value, got %q", text)
+}
+
+// yamlValue parses the value after a key or item: a flow sequence or a
+// scalar
+func yamlValue(s string) (any, error) {
+	switch {
+	case strings.HasPrefix(s, "|") || strings.HasPrefix(s, ">"):
+		return nil, fmt.Errorf("multi-line scalars are not supported")
+	case strings.HasPrefix(s, "&") || strings.HasPrefix(s, "*"):
+		return nil, fmt.Errorf("anchors are not supported")
+	case strings.HasPrefix(s, "{"):
+		return nil, fmt.Errorf("flow mappings are not supported")
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("unterminated flow sequence %q", s)
+		}
+		items, err := splitList(s[1 : len(s)-1])
+		if err != nil {
+			return nil, err
+		}
+		list := make([]any, 0, len(items))
+		for _, item := range items {
+			v, err := yamlScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case s == "~" || s == "null" || s == "Null" || s == "NULL":
+		return nil, nil
+	}
+	return yamlScalar(s)
+}
+
+// yamlScalar unquotes a scalar
+func yamlScalar(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		return strconv.Unquote(s)
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'"):
+		return "", fmt.Errorf("unterminated string %s", s)
+	}
+	return s, nil
+}
+
+// splitList splits the inside of a flow sequence or array at commas
+// outside quotes, dropping a trailing empty item. Nested lists are not
+// supported.
+func splitList(s string) ([]string, error) {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			return nil, fmt.Errorf("nested lists are not supported")
+		case c == ',':
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated string in list")
+	}
+	// A trailing comma leaves nothing after it
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	for _, item := range items {
+		if item == "" {
+			return nil, fmt.Errorf("empty item in list")
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseYAML tests the supported subset of YAML
+func TestParseYAML(t *testing.T) {
+	doc := `---
+# A service
+name: "my: svc"   # quoted, with a colon
+quote: 'it''s'
+empty:
+nothing: ~
+url: http://host:80/#anchor
+server:
+  host: localhost
+  limits:
+    rps: 10
+hosts:
+- a
+- "b # not a comment"
+ports: [80, "443", ]
+nested:
+  - x
+  -
+    y: 1
+`
+	got, err := parseYAML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"name":    "my: svc",
+		"quote":   "it's",
+		"empty":   nil,
+		"nothing": nil,
+		"url":     "http://host:80/#anchor",
+		"server":  map[string]any{"host": "localhost", "limits": map[string]any{"rps": "10"}},
+		"hosts":   []any{"a", "b # not a comment"},
+		"ports":   []any{"80", "443"},
+		"nested":  []any{"x", map[string]any{"y": "1"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseYAML =\n%v\nwant\n%v", got, want)
+	}
+	if got, err := parseYAML([]byte("# only a comment\n")); err != nil || len(got) != 0 {
+		t.Errorf("parseYAML of a comment = %v, %v, want an empty map", got, err)
+	}
+}
+
+// TestParseYAMLErrors tests that YAML outside the subset is rejected
+func TestParseYAMLErrors(t *testing.T) {
+	for _, doc := range []string{
+		"a: 1\n  b: 2\n",
+		"  a: 1\n",
+		"a:\n\tb: 1\n",
+		"a: 1\na: 2\n",
+		"just text\n",
+		"- a\n",
+		"a: |\n  text\n",
+		"a: &x 1\n",
+		"a: {b: 1}\n",
+		"a: [b, [c]]\n",
+		"a: [b\n",
+		"a: \"open\n",
+		"a: [,]\n",
+	} {
+		if got, err := parseYAML([]byte(doc)); err == nil {
+			t.Errorf("parseYAML(%q) = %v, want an error", doc, got)
+		}
+	}
+}
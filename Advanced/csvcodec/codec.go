@@ -0,0 +1,258 @@
+// Package csvcodec encodes slices of structs as CSV and decodes them back,
+// one row per struct and one column per field. Columns are named by csv
+// tags and matched by name when decoding, so their order in a file does
+// not matter, and the header is checked for missing, unknown and repeated
+// columns. Encoder and Decoder stream rows one at a time, through
+// iterators or a channel, for files too large to hold in memory.
+//
+// Tags name a column and set options after a comma:
+//
+//	csv:"name"                       the column, the field name if unset; "-" skips the field
+//	csv:"created,layout=2006-01-02"  the time layout of a time.Time field
+//	csv:"note,optional"              a column that may be missing from a file
+//
+// Supported field types are strings, booleans, integers, floats,
+// time.Time, time.Duration, types implementing encoding.TextMarshaler and
+// encoding.TextUnmarshaler, and pointers to any of those, which are
+// written as an empty cell when nil and read as nil from one.
+package csvcodec
+
+import (
+	"cmp"
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrMissingColumn is returned when a header lacks the column of a
+	// field not tagged optional
+	ErrMissingColumn = errors.New("missing column")
+	// ErrUnknownColumn is returned when a header has a column no field
+	// takes, unless Options.AllowUnknown is set
+	ErrUnknownColumn = errors.New("unknown column")
+	// ErrDuplicateColumn is returned when a header names a column twice
+	ErrDuplicateColumn = errors.New("duplicate column")
+)
+
+// ParseError is a cell that could not be decoded
+type ParseError struct {
+	Line   int    // The line of the file, from 1
+	Column string // The name of the column
+	Err    error
+}
+
+// Error names the cell and what was wrong with it
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("csvcodec: line %d, column %q: %v", e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the cause
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Options configures an Encoder or Decoder. Zero fields take their
+// defaults.
+type Options struct {
+	// Comma separates the cells, ',' if 0
+	Comma rune
+	// TimeLayout formats and parses time.Time fields without a layout
+	// tag, time.RFC3339 if ""
+	TimeLayout string
+	// AllowUnknown lets a Decoder skip columns no field takes instead of
+	// failing with ErrUnknownColumn
+	AllowUnknown bool
+	// EscapeFormulas makes an Encoder prefix with ' the cells starting
+	// with =, +, -, @, tab or carriage return, which spreadsheets would
+	// otherwise run as formulas. Decoders do not remove the prefix.
+	EscapeFormulas bool
+}
+
+// withDefaults returns opts with zero fields set to their defaults
+func (opts Options) withDefaults() Options {
+	if opts.Comma == 0 {
+		opts.Comma = ','
+	}
+	if opts.TimeLayout == "" {
+		opts.TimeLayout = time.RFC3339
+	}
+	return opts
+}
+
+// column is a field of a struct and how its cells are written
+type column struct {
+	name     string
+	index    int
+	layout   string // For time.Time, "" for Options.TimeLayout
+	optional bool
+}
+
+var (
+	textMarshaler   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+	timeType        = reflect.TypeFor[time.Time]()
+	durationType    = reflect.TypeFor[time.Duration]()
+)
+
+// plans caches the columns of each struct type
+var plans sync.Map // reflect.Type to []column
+
+// columnsOf returns the columns of the struct type t. It panics if t is
+// not a struct, or a field has a type no cell can hold, since either is a
+// programmer mistake.
+func columnsOf(t reflect.Type) []column {
+	if cols, ok := plans.Load(t); ok {
+		return cols.([]column)
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("csvcodec: rows of type %v, want a struct", t))
+	}
+	var cols []column
+	seen := make(map[string]bool)
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("csv")
+		if !sf.IsExported() || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = sf.Name
+		}
+		col := column{name: name, index: i}
+		for opt := range strings.SplitSeq(opts, ",") {
+			switch {
+			case opt == "optional":
+				col.optional = true
+			case strings.HasPrefix(opt, "layout="):
+				col.layout = strings.TrimPrefix(opt, "layout=")
+			case opt != "":
+				panic(fmt.Sprintf("csvcodec: unknown option %q on %v.%s", opt, t, sf.Name))
+			}
+		}
+		if !supported(sf.Type) {
+			panic(fmt.Sprintf("csvcodec: field %v.%s has unsupported type %v", t, sf.Name, sf.Type))
+		}
+		if seen[name] {
+			panic(fmt.Sprintf("csvcodec: two fields of %v are named %q", t, name))
+		}
+		seen[name] = true
+		cols = append(cols, col)
+	}
+	plans.Store(t, cols)
+	return cols
+}
+
+// supported reports whether a cell can hold a value of type t
+func supported(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType || reflect.PointerTo(t).Implements(textUnmarshaler) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// format renders the value of a field as a cell
+func format(v reflect.Value, col column, opts Options) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Type() == timeType:
+		return v.Interface().(time.Time).Format(cmp.Or(col.layout, opts.TimeLayout)), nil
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String(), nil
+	case v.Type().Implements(textMarshaler):
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	default: // Floats, as supported allows nothing else
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+}
+
+// parse sets the field v from a cell. An empty cell leaves a pointer nil.
+func parse(v reflect.Value, cell string, col column, opts Options) error {
+	if v.Kind() == reflect.Pointer {
+		if cell == "" {
+			v.SetZero()
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	switch {
+	case v.Type() == timeType:
+		t, err := time.Parse(cmp.Or(col.layout, opts.TimeLayout), cell)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(cell)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case reflect.PointerTo(v.Type()).Implements(textUnmarshaler):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(cell))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(cell, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		f, err := strconv.ParseFloat(cell, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	}
+	return nil
+}
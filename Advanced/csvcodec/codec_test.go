@@ -0,0 +1,178 @@
+package csvcodec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// trade is a row exercising every kind of field
+type trade struct {
+	ID       int64         `csv:"id"`
+	Symbol   string        `csv:"symbol"`
+	Price    float64       `csv:"price"`
+	Qty      uint32        `csv:"qty"`
+	Buy      bool          `csv:"buy"`
+	At       time.Time     `csv:"at"`
+	Day      time.Time     `csv:"day,layout=2006-01-02"`
+	Hold     time.Duration `csv:"hold"`
+	Venue    netip.Addr    `csv:"venue"`
+	Limit    *float64      `csv:"limit"`
+	Note     *string       `csv:"note,optional"`
+	Internal string        `csv:"-"`
+	hidden   int
+}
+
+// trades returns two rows, one with its optional fields set
+func trades() []trade {
+	at := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	limit, note := 101.5, "a, \"quoted\"\nnote"
+	return []trade{
+		{ID: 1, Symbol: "ACME", Price: 100.25, Qty: 10, Buy: true, At: at, Day: at.Truncate(24 * time.Hour),
+			Hold: 90 * time.Second, Venue: netip.MustParseAddr("10.0.0.1"), Limit: &limit, Note: &note},
+		{ID: 2, Symbol: "INIT", Price: -1e-7, At: at, Day: at.Truncate(24 * time.Hour), Venue: netip.MustParseAddr("::1")},
+	}
+}
+
+// TestRoundTrip tests that decoding an encoding gives the rows back
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Marshal(&buf, trades(), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	header, _, _ := strings.Cut(buf.String(), "\n")
+	if header != "id,symbol,price,qty,buy,at,day,hold,venue,limit,note" {
+		t.Errorf("header = %q", header)
+	}
+	if !strings.Contains(buf.String(), ",2024-05-01T09:30:00Z,2024-05-01,1m30s,") {
+		t.Errorf("encoding %q, want the time layouts and duration", buf.String())
+	}
+	got, err := Unmarshal[trade](&buf, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, trades()) {
+		t.Errorf("Unmarshal =\n%+v\nwant\n%+v", got, trades())
+	}
+
+	// Another separator and time layout
+	buf.Reset()
+	opts := Options{Comma: ';', TimeLayout: time.Kitchen}
+	if err := Marshal(&buf, trades()[1:], opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), ";9:30AM;") {
+		t.Errorf("encoding %q, want ; and the kitchen layout", buf.String())
+	}
+	if got, err := Unmarshal[trade](&buf, opts); err != nil || len(got) != 1 || got[0].At.Format(time.Kitchen) != "9:30AM" {
+		t.Errorf("Unmarshal = %+v, %v", got, err)
+	}
+
+	// No rows still gives a header
+	buf.Reset()
+	if err := Marshal(&buf, []trade(nil), Options{}); err != nil || !strings.HasPrefix(buf.String(), "id,") {
+		t.Errorf("Marshal of no rows = %q, %v, want a header", buf.String(), err)
+	}
+}
+
+// TestHeader tests matching columns by name, in any order
+func TestHeader(t *testing.T) {
+	in := "\xef\xbb\xbfvenue,qty,id,symbol,price,buy,at,day,hold,limit\n" +
+		"::1,5,7,X,1.5,false,2024-01-01T00:00:00Z,2024-01-01,0s,\n"
+	got, err := Unmarshal[trade](strings.NewReader(in), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != 7 || got[0].Qty != 5 || got[0].Limit != nil || got[0].Note != nil {
+		t.Errorf("Unmarshal = %+v", got)
+	}
+
+	tests := []struct {
+		header string
+		want   []error
+	}{
+		{"id,symbol", []error{ErrMissingColumn}},
+		{"id,symbol,price,qty,buy,at,day,hold,venue,limit,extra", []error{ErrUnknownColumn}},
+		{"id,id,symbol,price,qty,buy,at,day,hold,venue,limit", []error{ErrDuplicateColumn}},
+		{"id,id,other", []error{ErrDuplicateColumn, ErrUnknownColumn, ErrMissingColumn}},
+	}
+	for _, tt := range tests {
+		_, err := Unmarshal[trade](strings.NewReader(tt.header+"\n"), Options{})
+		for _, want := range tt.want {
+			if !errors.Is(err, want) {
+				t.Errorf("header %q: error %v, want %v", tt.header, err, want)
+			}
+		}
+	}
+	in = "id,symbol,price,qty,buy,at,day,hold,venue,limit,extra\n1,A,1,1,true,2024-01-01T00:00:00Z,2024-01-01,1s,::1,,x\n"
+	if got, err := Unmarshal[trade](strings.NewReader(in), Options{AllowUnknown: true}); err != nil || len(got) != 1 {
+		t.Errorf("Unmarshal with AllowUnknown = %+v, %v", got, err)
+	}
+	if _, err := Unmarshal[trade](strings.NewReader(""), Options{}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Unmarshal of nothing = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+// TestParseError tests that a bad cell names its line and column
+func TestParseError(t *testing.T) {
+	in := "id,symbol,price,qty,buy,at,day,hold,venue,limit\n" +
+		"1,A,1,1,true,2024-01-01T00:00:00Z,2024-01-01,1s,::1,\n" +
+		"2,B,1,-1,true,2024-01-01T00:00:00Z,2024-01-01,1s,::1,\n"
+	_, err := Unmarshal[trade](strings.NewReader(in), Options{})
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 3 || pe.Column != "qty" {
+		t.Fatalf("Unmarshal = %v, want a ParseError at line 3, column qty", err)
+	}
+	if !strings.HasPrefix(err.Error(), `csvcodec: line 3, column "qty": `) {
+		t.Errorf("Error = %q", err)
+	}
+	short := "id,symbol,price,qty,buy,at,day,hold,venue,limit\n1,A\n"
+	if _, err := Unmarshal[trade](strings.NewReader(short), Options{}); err == nil {
+		t.Error("Unmarshal of a short row succeeded, want an error")
+	}
+}
+
+// TestEscapeFormulas tests that formula cells are defused
+func TestEscapeFormulas(t *testing.T) {
+	type row struct{ A, B, C string }
+	var buf bytes.Buffer
+	rows := []row{{"=HYPERLINK(\"x\")", "-1", "safe"}}
+	if err := Marshal(&buf, rows, Options{EscapeFormulas: true}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "A,B,C\n\"'=HYPERLINK(\"\"x\"\")\",'-1,safe\n"; buf.String() != want {
+		t.Errorf("Marshal = %q, want %q", buf.String(), want)
+	}
+}
+
+// TestMisuse tests that types no CSV can hold panic
+func TestMisuse(t *testing.T) {
+	type nested struct{ Inner struct{ A int } }
+	type badOption struct {
+		A int `csv:"a,nope"`
+	}
+	type twice struct {
+		A int `csv:"x"`
+		B int `csv:"x"`
+	}
+	for name, f := range map[string]func(){
+		"not a struct": func() { NewEncoder[int](io.Discard, Options{}) },
+		"nested":       func() { NewDecoder[nested](strings.NewReader(""), Options{}) },
+		"bad option":   func() { NewEncoder[badOption](io.Discard, Options{}) },
+		"twice":        func() { NewEncoder[twice](io.Discard, Options{}) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
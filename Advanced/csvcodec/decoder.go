@@ -0,0 +1,181 @@
+package csvcodec
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+)
+
+// Decoder reads rows of CSV into structs of type T, matching the columns
+// of its header row to fields by name
+type Decoder[T any] struct {
+	r      *csv.Reader
+	opts   Options
+	header []string
+	fields []*column // The column of each cell, nil for one skipped
+	err    error     // The error of reading the header, returned by every Decode
+}
+
+// NewDecoder returns a Decoder reading from r. The header is read and
+// checked on the first call to Decode or Header. It panics if T is not a
+// struct, or has a field no cell can hold.
+func NewDecoder[T any](r io.Reader, opts Options) *Decoder[T] {
+	opts = opts.withDefaults()
+	cr := csv.NewReader(r)
+	cr.Comma = opts.Comma
+	cr.ReuseRecord = true
+	columnsOf(reflect.TypeFor[T]())
+	return &Decoder[T]{r: cr, opts: opts}
+}
+
+// Header reads the header row if it has not been read, checks it against
+// the fields of T and returns it
+func (d *Decoder[T]) Header() ([]string, error) {
+	if d.header != nil || d.err != nil {
+		return d.header, d.err
+	}
+	record, err := d.r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = fmt.Errorf("csvcodec: no header: %w", io.ErrUnexpectedEOF)
+		}
+		d.err = err
+		return nil, err
+	}
+	header := append([]string(nil), record...)
+	if len(header) > 0 {
+		// A byte order mark, as spreadsheets write, is not part of the name
+		header[0] = trimBOM(header[0])
+	}
+	if d.fields, err = d.match(header); err != nil {
+		d.err = err
+		return nil, err
+	}
+	d.header = header
+	return header, nil
+}
+
+// trimBOM removes a UTF-8 byte order mark from the start of s
+func trimBOM(s string) string {
+	if len(s) >= 3 && s[:3] == "\xef\xbb\xbf" {
+		return s[3:]
+	}
+	return s
+}
+
+// match finds the column of each cell of header
+func (d *Decoder[T]) match(header []string) ([]*column, error) {
+	cols := columnsOf(reflect.TypeFor[T]())
+	byName := make(map[string]*column, len(cols))
+	for i := range cols {
+		byName[cols[i].name] = &cols[i]
+	}
+	fields := make([]*column, len(header))
+	seen := make(map[string]bool, len(header))
+	var errs []error
+	for i, name := range header {
+		if seen[name] {
+			errs = append(errs, fmt.Errorf("csvcodec: %w %q", ErrDuplicateColumn, name))
+			continue
+		}
+		seen[name] = true
+		col, ok := byName[name]
+		if !ok && !d.opts.AllowUnknown {
+			errs = append(errs, fmt.Errorf("csvcodec: %w %q", ErrUnknownColumn, name))
+		}
+		fields[i] = col
+	}
+	for _, col := range cols {
+		if !seen[col.name] && !col.optional {
+			errs = append(errs, fmt.Errorf("csvcodec: %w %q", ErrMissingColumn, col.name))
+		}
+	}
+	return fields, errors.Join(errs...)
+}
+
+// Decode reads the next row. It returns io.EOF after the last one, and a
+// *ParseError for a cell that does not parse.
+func (d *Decoder[T]) Decode() (T, error) {
+	var row T
+	if _, err := d.Header(); err != nil {
+		return row, err
+	}
+	record, err := d.r.Read()
+	if err != nil {
+		return row, err
+	}
+	v := reflect.ValueOf(&row).Elem()
+	for i, cell := range record {
+		col := d.fields[i]
+		if col == nil {
+			continue
+		}
+		if err := parse(v.Field(col.index), cell, *col, d.opts); err != nil {
+			line, _ := d.r.FieldPos(i)
+			return row, &ParseError{Line: line, Column: col.name, Err: err}
+		}
+	}
+	return row, nil
+}
+
+// All returns an iterator over the remaining rows. It stops after the
+// first error, which it yields with the zero T.
+func (d *Decoder[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			row, err := d.Decode()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(row, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Stream decodes the remaining rows in a goroutine and sends them on the
+// returned channel, which is closed after the last row, the first error
+// or the end of ctx. The error channel then receives the error, if any,
+// and is closed. The caller must drain rows or cancel ctx.
+func (d *Decoder[T]) Stream(ctx context.Context, buffer int) (<-chan T, <-chan error) {
+	rows := make(chan T, buffer)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(rows)
+		for row, err := range d.All() {
+			if err != nil {
+				errc <- err
+				return
+			}
+			select {
+			case rows <- row:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return rows, errc
+}
+
+// Unmarshal reads every row of r
+func Unmarshal[T any](r io.Reader, opts Options) ([]T, error) {
+	d := NewDecoder[T](r, opts)
+	if _, err := d.Header(); err != nil {
+		return nil, err
+	}
+	var rows []T
+	for row, err := range d.All() {
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
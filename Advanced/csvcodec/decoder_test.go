@@ -0,0 +1,117 @@
+package csvcodec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// point is a small row for streaming tests
+type point struct {
+	X int `csv:"x"`
+	Y int `csv:"y"`
+}
+
+// points returns CSV of n points, with a bad cell at row bad if bad > 0
+func points(n, bad int) string {
+	var b strings.Builder
+	b.WriteString("x,y\n")
+	for i := 1; i <= n; i++ {
+		if i == bad {
+			b.WriteString("oops,0\n")
+			continue
+		}
+		fmt.Fprintf(&b, "%d,%d\n", i, i*i)
+	}
+	return b.String()
+}
+
+// TestDecode tests reading rows one at a time
+func TestDecode(t *testing.T) {
+	d := NewDecoder[point](strings.NewReader(points(2, 0)), Options{})
+	header, err := d.Header()
+	if err != nil || !slices.Equal(header, []string{"x", "y"}) {
+		t.Fatalf("Header = %v, %v", header, err)
+	}
+	for i := 1; i <= 2; i++ {
+		if p, err := d.Decode(); err != nil || p != (point{i, i * i}) {
+			t.Errorf("Decode = %+v, %v", p, err)
+		}
+	}
+	if _, err := d.Decode(); !errors.Is(err, io.EOF) {
+		t.Errorf("Decode at the end = %v, want io.EOF", err)
+	}
+}
+
+// TestAll tests the iterator, stopping early and at an error
+func TestAll(t *testing.T) {
+	var sum int
+	for p, err := range NewDecoder[point](strings.NewReader(points(1000, 0)), Options{}).All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum += p.X
+	}
+	if sum != 500500 {
+		t.Errorf("sum = %d, want 500500", sum)
+	}
+
+	var seen int
+	for range NewDecoder[point](strings.NewReader(points(10, 0)), Options{}).All() {
+		if seen++; seen == 3 {
+			break
+		}
+	}
+	if seen != 3 {
+		t.Errorf("saw %d rows, want to stop at 3", seen)
+	}
+
+	var errs []error
+	rows := 0
+	for _, err := range NewDecoder[point](strings.NewReader(points(10, 4)), Options{}).All() {
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			rows++
+		}
+	}
+	var pe *ParseError
+	if rows != 3 || len(errs) != 1 || !errors.As(errs[0], &pe) || pe.Line != 5 {
+		t.Errorf("All gave %d rows and errors %v, want 3 rows then a ParseError at line 5", rows, errs)
+	}
+}
+
+// TestStream tests decoding through a channel
+func TestStream(t *testing.T) {
+	rows, errc := NewDecoder[point](strings.NewReader(points(100, 0)), Options{}).Stream(context.Background(), 4)
+	n := 0
+	for range rows {
+		n++
+	}
+	if err := <-errc; err != nil || n != 100 {
+		t.Errorf("Stream gave %d rows and %v, want 100 and nil", n, err)
+	}
+
+	rows, errc = NewDecoder[point](strings.NewReader(points(100, 50)), Options{}).Stream(context.Background(), 0)
+	n = 0
+	for range rows {
+		n++
+	}
+	if err := <-errc; n != 49 || err == nil {
+		t.Errorf("Stream gave %d rows and %v, want 49 and a ParseError", n, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rows, errc = NewDecoder[point](strings.NewReader(points(100, 0)), Options{}).Stream(ctx, 0)
+	<-rows
+	cancel()
+	for range rows {
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("Stream after cancel = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,102 @@
+package csvcodec
+
+import (
+	"encoding/csv"
+	"io"
+	"iter"
+	"reflect"
+	"strings"
+)
+
+// Encoder writes structs of type T as rows of CSV, after a header row
+type Encoder[T any] struct {
+	w       *csv.Writer
+	opts    Options
+	cols    []column
+	record  []string
+	started bool
+}
+
+// NewEncoder returns an Encoder writing to w. It panics if T is not a
+// struct, or has a field no cell can hold.
+func NewEncoder[T any](w io.Writer, opts Options) *Encoder[T] {
+	opts = opts.withDefaults()
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.Comma
+	cols := columnsOf(reflect.TypeFor[T]())
+	return &Encoder[T]{w: cw, opts: opts, cols: cols, record: make([]string, len(cols))}
+}
+
+// Header writes the header row, if no row has been written yet; Encode
+// does so before the first row, so Header is only needed for a file with
+// no rows
+func (e *Encoder[T]) Header() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	for i, col := range e.cols {
+		e.record[i] = col.name
+	}
+	return e.w.Write(e.record)
+}
+
+// Encode writes row. Rows are buffered; call Flush when done.
+func (e *Encoder[T]) Encode(row T) error {
+	if err := e.Header(); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(row)
+	for i, col := range e.cols {
+		cell, err := format(v.Field(col.index), col, e.opts)
+		if err != nil {
+			return err
+		}
+		e.record[i] = e.escape(cell)
+	}
+	return e.w.Write(e.record)
+}
+
+// escape prefixes a cell a spreadsheet would run as a formula, if
+// EscapeFormulas is set
+func (e *Encoder[T]) escape(cell string) string {
+	// Secure: cells from untrusted input must not become formulas when the
+	// file is opened in a spreadsheet
+	if e.opts.EscapeFormulas && cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// EncodeAll writes each row of rows, then flushes
+func (e *Encoder[T]) EncodeAll(rows iter.Seq[T]) error {
+	if err := e.Header(); err != nil {
+		return err
+	}
+	for row := range rows {
+		if err := e.Encode(row); err != nil {
+			return err
+		}
+	}
+	return e.Flush()
+}
+
+// Flush writes any buffered rows to the underlying writer
+func (e *Encoder[T]) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// Marshal writes rows to w as CSV with a header row
+func Marshal[T any](w io.Writer, rows []T, opts Options) error {
+	e := NewEncoder[T](w, opts)
+	if err := e.Header(); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := e.Encode(row); err != nil {
+			return err
+		}
+	}
+	return e.Flush()
+}
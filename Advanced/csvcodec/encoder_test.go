@@ -0,0 +1,60 @@
+package csvcodec
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestEncoder tests writing rows one at a time and from an iterator
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder[point](&buf, Options{})
+	for i := range 3 {
+		if err := e.Encode(point{i, -i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Encode wrote %q before Flush, want buffering", buf.String())
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "x,y\n0,0\n1,-1\n2,-2\n"; buf.String() != want {
+		t.Errorf("encoded %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	e = NewEncoder[point](&buf, Options{Comma: '\t'})
+	if err := e.EncodeAll(slices.Values([]point{{1, 2}, {3, 4}})); err != nil {
+		t.Fatal(err)
+	}
+	if want := "x\ty\n1\t2\n3\t4\n"; buf.String() != want {
+		t.Errorf("EncodeAll wrote %q, want %q", buf.String(), want)
+	}
+	// The output decodes back
+	got, err := Unmarshal[point](strings.NewReader(buf.String()), Options{Comma: '\t'})
+	if err != nil || !slices.Equal(got, []point{{1, 2}, {3, 4}}) {
+		t.Errorf("Unmarshal = %v, %v", got, err)
+	}
+}
+
+// failingText is a value whose MarshalText fails
+type failingText struct{}
+
+var errText = errors.New("cannot marshal")
+
+func (failingText) MarshalText() ([]byte, error) { return nil, errText }
+func (*failingText) UnmarshalText([]byte) error  { return nil }
+
+// TestEncodeError tests that a failing MarshalText stops encoding
+func TestEncodeError(t *testing.T) {
+	type row struct{ V failingText }
+	var buf bytes.Buffer
+	if err := Marshal(&buf, []row{{}}, Options{}); !errors.Is(err, errText) {
+		t.Errorf("Marshal = %v, want the MarshalText error", err)
+	}
+}
@@ -0,0 +1,223 @@
+// Package di provides a small dependency injection container. Provide
+// registers a constructor for a type, which gets a Resolver to resolve
+// the types it depends on; Resolve builds a type with its dependencies,
+// typed through generics rather than reflection on arguments. A Singleton
+// is built once and shared, a Transient anew for each resolution, and a
+// dependency cycle is reported rather than followed forever.
+//
+// Start builds every singleton, which checks the whole graph, and starts
+// those that are Starters in the order they were built, so dependencies
+// start before their dependents; Stop stops Stoppers in the reverse order.
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrNotProvided is returned for a type no constructor was provided for
+	ErrNotProvided = errors.New("no provider for type")
+	// ErrCycle is returned when a type depends on itself, directly or not
+	ErrCycle = errors.New("dependency cycle")
+)
+
+// Lifetime chooses how often a provider's constructor runs
+type Lifetime int
+
+// The lifetimes
+const (
+	Singleton Lifetime = iota // Once, the value shared by every resolution
+	Transient                 // For every resolution
+)
+
+// Starter is a singleton that Start starts
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is a singleton that Stop stops
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Resolver resolves types: the Container, or inside a constructor the
+// Resolver it is given, which knows what is being built
+type Resolver interface {
+	resolve(key reflect.Type) (any, error)
+}
+
+// provider is the constructor of a type and, for a singleton, its value
+type provider struct {
+	lifetime Lifetime
+	build    func(r Resolver) (any, error)
+	built    bool
+	value    any
+}
+
+// Container holds the providers of types and the singletons built. It is
+// safe for concurrent use, though resolutions run one at a time.
+type Container struct {
+	mu        sync.Mutex // Held for a whole resolution
+	providers map[reflect.Type]*provider
+	order     []reflect.Type // Types provided, in order
+	built     []any          // Singletons, in the order built
+}
+
+// New creates an empty container
+func New() *Container {
+	return &Container{providers: map[reflect.Type]*provider{}}
+}
+
+// Provide registers ctor to build values of type T with lifetime. It
+// panics if T already has a provider, since which one wins would be
+// unclear.
+func Provide[T any](c *Container, lifetime Lifetime, ctor func(r Resolver) (T, error)) {
+	key := reflect.TypeFor[T]()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.providers[key]; ok {
+		panic(fmt.Sprintf("di: %v provided twice", key))
+	}
+	c.providers[key] = &provider{
+		lifetime: lifetime,
+		build:    func(r Resolver) (any, error) { return ctor(r) },
+	}
+	c.order = append(c.order, key)
+}
+
+// Supply registers value as the singleton of type T
+func Supply[T any](c *Container, value T) {
+	Provide(c, Singleton, func(Resolver) (T, error) { return value, nil })
+}
+
+// Resolve returns the value of type T, building it and its dependencies
+// as need be. It returns ErrNotProvided or ErrCycle, with the chain of
+// types that led there, or the error of a constructor, wrapped.
+func Resolve[T any](r Resolver) (T, error) {
+	v, err := r.resolve(reflect.TypeFor[T]())
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v.(T), nil
+}
+
+// MustResolve returns the value of type T as Resolve does, panicking if
+// it fails, for wiring that cannot go on without it
+func MustResolve[T any](r Resolver) T {
+	v, err := Resolve[T](r)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// resolve builds key for a resolution starting at the container
+func (c *Container) resolve(key reflect.Type) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.build(key, nil)
+}
+
+// resolution is the Resolver of a constructor: the container, already
+// held, and the chain of types being built
+type resolution struct {
+	c     *Container
+	chain []reflect.Type
+}
+
+// resolve builds key as a dependency of the chain
+func (r *resolution) resolve(key reflect.Type) (any, error) {
+	return r.c.build(key, r.chain)
+}
+
+// build returns the value of key, built by its provider if need be, as a
+// dependency of chain. The caller holds mu.
+func (c *Container) build(key reflect.Type, chain []reflect.Type) (any, error) {
+	if slices.Contains(chain, key) {
+		return nil, fmt.Errorf("%w: %s", ErrCycle, path(append(chain, key)))
+	}
+	p, ok := c.providers[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotProvided, path(append(chain, key)))
+	}
+	if p.built {
+		return p.value, nil
+	}
+	v, err := p.build(&resolution{c: c, chain: append(slices.Clip(chain), key)})
+	if err != nil {
+		if errors.Is(err, ErrCycle) || errors.Is(err, ErrNotProvided) {
+			return nil, err // The chain already says where
+		}
+		return nil, fmt.Errorf("di: build %v: %w", key, err)
+	}
+	if p.lifetime == Singleton {
+		p.built, p.value = true, v
+		c.built = append(c.built, v)
+	}
+	return v, nil
+}
+
+// path renders a chain of types as "A -> B -> C"
+func path(chain []reflect.Type) string {
+	names := make([]string, len(chain))
+	for i, t := range chain {
+		names[i] = t.String()
+	}
+	return strings.Join(names, " -> ")
+}
+
+// Start builds every singleton not yet built, in the order provided, then
+// starts each Starter in the order built. If one fails, it stops those it
+// started, in reverse, and returns the error.
+func (c *Container) Start(ctx context.Context) error {
+	c.mu.Lock()
+	for _, key := range c.order {
+		if c.providers[key].lifetime != Singleton {
+			continue
+		}
+		if _, err := c.build(key, nil); err != nil {
+			c.mu.Unlock()
+			return err
+		}
+	}
+	built := slices.Clone(c.built)
+	c.mu.Unlock()
+
+	for i, v := range built {
+		if s, ok := v.(Starter); ok {
+			if err := s.Start(ctx); err != nil {
+				return errors.Join(fmt.Errorf("di: start %T: %w", v, err), stop(ctx, built[:i]))
+			}
+		}
+	}
+	return nil
+}
+
+// Stop stops each Stopper among the singletons built, in the reverse of
+// the order built, and returns their errors joined
+func (c *Container) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	built := slices.Clone(c.built)
+	c.mu.Unlock()
+	return stop(ctx, built)
+}
+
+// stop stops the Stoppers of built, last first
+func stop(ctx context.Context, built []any) error {
+	var errs []error
+	for _, v := range slices.Backward(built) {
+		if s, ok := v.(Stopper); ok {
+			if err := s.Stop(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("di: stop %T: %w", v, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,201 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Types for the tests: a config, a database needing it, and a service
+// needing the database
+type (
+	config struct{ dsn string }
+	db     struct {
+		dsn string
+		log *[]string
+	}
+	service struct{ db *db }
+	// request is built anew for each resolution
+	request struct{ id int }
+)
+
+// Start records that the database started
+func (d *db) Start(context.Context) error {
+	*d.log = append(*d.log, "start db")
+	return nil
+}
+
+// Stop records that the database stopped
+func (d *db) Stop(context.Context) error {
+	*d.log = append(*d.log, "stop db")
+	return nil
+}
+
+// Start records that the service started
+func (s *service) Start(context.Context) error {
+	*s.db.log = append(*s.db.log, "start service")
+	return nil
+}
+
+// Stop records that the service stopped
+func (s *service) Stop(context.Context) error {
+	*s.db.log = append(*s.db.log, "stop service")
+	return nil
+}
+
+// newContainer returns a container of the test types, counting the
+// databases built
+func newContainer(log *[]string, builds *int) *Container {
+	c := New()
+	Supply(c, config{dsn: "postgres://db"})
+	Provide(c, Singleton, func(r Resolver) (*service, error) {
+		d, err := Resolve[*db](r)
+		return &service{db: d}, err
+	})
+	Provide(c, Singleton, func(r Resolver) (*db, error) {
+		*builds++
+		cfg, err := Resolve[config](r)
+		return &db{dsn: cfg.dsn, log: log}, err
+	})
+	ids := 0
+	Provide(c, Transient, func(Resolver) (request, error) {
+		ids++
+		return request{id: ids}, nil
+	})
+	return c
+}
+
+// TestResolve tests that singletons are shared and transients are not
+func TestResolve(t *testing.T) {
+	var log []string
+	builds := 0
+	c := newContainer(&log, &builds)
+	s1, err := Resolve[*service](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2 := MustResolve[*service](c)
+	if s1 != s2 || s1.db.dsn != "postgres://db" || builds != 1 {
+		t.Errorf("services %p and %p, dsn %q, %d databases built", s1, s2, s1.db.dsn, builds)
+	}
+	if MustResolve[request](c).id == MustResolve[request](c).id {
+		t.Error("a transient was shared")
+	}
+}
+
+// TestResolveErrors tests missing providers, cycles and failed
+// constructors
+func TestResolveErrors(t *testing.T) {
+	type a struct{}
+	type b struct{}
+	c := New()
+	Provide(c, Singleton, func(r Resolver) (*a, error) {
+		_, err := Resolve[*b](r)
+		return &a{}, err
+	})
+	Provide(c, Singleton, func(r Resolver) (*b, error) {
+		_, err := Resolve[*a](r)
+		return &b{}, err
+	})
+	_, err := Resolve[*a](c)
+	if !errors.Is(err, ErrCycle) || !strings.Contains(err.Error(), "*di.a -> *di.b -> *di.a") {
+		t.Errorf("cycle error = %v, want the chain", err)
+	}
+
+	Provide(c, Transient, func(r Resolver) (string, error) {
+		_, err := Resolve[int](r)
+		return "", err
+	})
+	if _, err := Resolve[string](c); !errors.Is(err, ErrNotProvided) || !strings.Contains(err.Error(), "string -> int") {
+		t.Errorf("missing error = %v, want the chain", err)
+	}
+
+	errDown := errors.New("connection refused")
+	attempts := 0
+	Provide(c, Singleton, func(Resolver) (float64, error) {
+		attempts++
+		if attempts == 1 {
+			return 0, errDown
+		}
+		return 1, nil
+	})
+	if _, err := Resolve[float64](c); !errors.Is(err, errDown) {
+		t.Errorf("constructor error = %v, want it wrapped", err)
+	}
+	if v, err := Resolve[float64](c); err != nil || v != 1 {
+		t.Errorf("retry = %v, %v, want a failed singleton built again", v, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("providing a type twice did not panic")
+		}
+	}()
+	Supply(c, 2.0)
+}
+
+// TestLifecycle tests that Start builds and starts singletons in
+// dependency order and Stop stops them in reverse
+func TestLifecycle(t *testing.T) {
+	var log []string
+	builds := 0
+	c := newContainer(&log, &builds)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := "start db,start service,stop service,stop db"
+	if got := strings.Join(log, ","); got != want {
+		t.Errorf("lifecycle %s, want %s", got, want)
+	}
+}
+
+// failing is a singleton whose Start fails
+type failing struct{}
+
+func (failing) Start(context.Context) error { return errors.New("port in use") }
+
+// TestStartFailure tests that a failed Start stops what had started
+func TestStartFailure(t *testing.T) {
+	var log []string
+	builds := 0
+	c := newContainer(&log, &builds)
+	Provide(c, Singleton, func(r Resolver) (failing, error) {
+		_, err := Resolve[*service](r)
+		return failing{}, err
+	})
+	err := c.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "port in use") {
+		t.Errorf("Start = %v, want the failure", err)
+	}
+	want := "start db,start service,stop service,stop db"
+	if got := strings.Join(log, ","); got != want {
+		t.Errorf("lifecycle %s, want %s", got, want)
+	}
+}
+
+// TestConcurrentResolve tests that a singleton is built once however many
+// resolve it at once; run with -race
+func TestConcurrentResolve(t *testing.T) {
+	var log []string
+	builds := 0
+	c := newContainer(&log, &builds)
+	var wg sync.WaitGroup
+	services := make([]*service, 8)
+	for i := range services {
+		wg.Go(func() { services[i] = MustResolve[*service](c) })
+	}
+	wg.Wait()
+	for _, s := range services {
+		if s != services[0] {
+			t.Fatal("a singleton was built twice")
+		}
+	}
+	if builds != 1 {
+		t.Errorf("%d databases built, want 1", builds)
+	}
+}
@@ -0,0 +1,202 @@
+package errorsx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Category classifies an error by what the caller can do about it, which
+// decides its HTTP status and gRPC code
+type Category int
+
+// The categories of error
+const (
+	Unknown          Category = iota // Not classified; treated as Internal
+	Validation                       // The request was malformed or invalid
+	NotFound                         // Something requested does not exist
+	Conflict                         // The request conflicts with current state
+	Unauthenticated                  // The caller's identity is missing or invalid
+	PermissionDenied                 // The caller may not do this
+	Unavailable                      // A dependency is down; retrying may help
+	Timeout                          // The operation ran out of time
+	Canceled                         // The caller gave up
+	Internal                         // A bug or broken invariant
+)
+
+var categoryNames = [...]string{
+	Unknown:          "unknown",
+	Validation:       "validation",
+	NotFound:         "not-found",
+	Conflict:         "conflict",
+	Unauthenticated:  "unauthenticated",
+	PermissionDenied: "permission-denied",
+	Unavailable:      "unavailable",
+	Timeout:          "timeout",
+	Canceled:         "canceled",
+	Internal:         "internal",
+}
+
+// String returns the name of c
+func (c Category) String() string {
+	if c < 0 || int(c) >= len(categoryNames) {
+		return fmt.Sprintf("Category(%d)", int(c))
+	}
+	return categoryNames[c]
+}
+
+// maxStack bounds the frames a Coded error captures
+const maxStack = 32
+
+// Coded is an error with a machine-readable code, a Category, an optional
+// cause it wraps, key-value metadata and, if captured, the stack where it
+// was created. Its methods return modified copies, so a Coded error can be
+// shared and extended safely.
+type Coded struct {
+	Code     string         // A stable identifier for clients, such as "BIZ_001"
+	Category Category       // What kind of failure this is
+	Message  string         // A description for people
+	Err      error          // The cause, if any
+	Fields   map[string]any // Metadata, such as the ID of what was not found
+	stack    []uintptr
+}
+
+// New creates a Coded error without a cause. kv lists metadata as
+// alternating keys and values, as for With.
+func New(category Category, code, message string, kv ...any) *Coded {
+	return (&Coded{Code: code, Category: category, Message: message}).With(kv...)
+}
+
+// Wrap creates a Coded error caused by err
+func Wrap(err error, category Category, code, message string, kv ...any) *Coded {
+	return (&Coded{Code: code, Category: category, Message: message, Err: err}).With(kv...)
+}
+
+// Error returns "[code] message: cause", leaving out the parts not set
+func (e *Coded) Error() string {
+	var b strings.Builder
+	if e.Code != "" {
+		fmt.Fprintf(&b, "[%s] ", e.Code)
+	}
+	b.WriteString(e.Message)
+	if e.Err != nil {
+		if e.Message != "" {
+			b.WriteString(": ")
+		}
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the cause
+func (e *Coded) Unwrap() error {
+	return e.Err
+}
+
+// With returns a copy of e with metadata added from kv, alternating keys
+// and values. A key that is not a string is formatted with %v, and a final
+// key without a value gets the value nil.
+func (e *Coded) With(kv ...any) *Coded {
+	c := *e
+	if len(kv) == 0 {
+		return &c
+	}
+	c.Fields = maps.Clone(e.Fields)
+	if c.Fields == nil {
+		c.Fields = make(map[string]any, len(kv)/2)
+	}
+	for i := 0; i < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		var value any
+		if i+1 < len(kv) {
+			value = kv[i+1]
+		}
+		c.Fields[key] = value
+	}
+	return &c
+}
+
+// WithStack returns a copy of e carrying the stack of its caller. Capture
+// costs a walk of the stack, so it is left to the places that want it,
+// such as the boundary where an unexpected error is first wrapped.
+func (e *Coded) WithStack() *Coded {
+	c := *e
+	pcs := make([]uintptr, maxStack)
+	c.stack = pcs[:runtime.Callers(2, pcs)]
+	return &c
+}
+
+// Stack returns the frames captured by WithStack, innermost first, or nil
+func (e *Coded) Stack() []runtime.Frame {
+	if len(e.stack) == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(e.stack)
+	var stack []runtime.Frame
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, frame)
+		if !more {
+			return stack
+		}
+	}
+}
+
+// Format formats e as its message, adding for %+v its metadata and its
+// stack, one frame per line
+func (e *Coded) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		fmt.Fprint(s, e.Error())
+		for _, key := range slices.Sorted(maps.Keys(e.Fields)) {
+			fmt.Fprintf(s, "\n  %s=%v", key, e.Fields[key])
+		}
+		for _, frame := range e.Stack() {
+			fmt.Fprintf(s, "\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		}
+	case verb == 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	default:
+		fmt.Fprint(s, e.Error())
+	}
+}
+
+// CodeOf returns the code of the outermost Coded error in the chain of
+// err, or "" if there is none
+func CodeOf(err error) string {
+	var coded *Coded
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return ""
+}
+
+// CategoryOf returns the category of the outermost Coded error in the
+// chain of err with one set. Without one, context errors are Timeout or
+// Canceled, other errors Unknown, and nil Unknown too.
+func CategoryOf(err error) Category {
+	for e := err; e != nil; {
+		var coded *Coded
+		if !errors.As(e, &coded) {
+			break
+		}
+		if coded.Category != Unknown {
+			return coded.Category
+		}
+		e = coded.Err
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	}
+	return Unknown
+}
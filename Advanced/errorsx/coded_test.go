@@ -0,0 +1,74 @@
+package errorsx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var errNoRows = errors.New("no rows")
+
+// TestCoded tests the message, the chain and the metadata of a Coded error
+func TestCoded(t *testing.T) {
+	err := Wrap(errNoRows, NotFound, "USR_404", "user not found", "user_id", 42)
+	if got, want := err.Error(), "[USR_404] user not found: no rows"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	wrapped := fmt.Errorf("handler: %w", err)
+	if !errors.Is(wrapped, errNoRows) || CodeOf(wrapped) != "USR_404" || CategoryOf(wrapped) != NotFound {
+		t.Errorf("chain lost: code %q, category %v", CodeOf(wrapped), CategoryOf(wrapped))
+	}
+
+	more := err.With("tenant", "acme", 7)
+	if len(err.Fields) != 1 || more.Fields["tenant"] != "acme" || more.Fields["user_id"] != 42 {
+		t.Errorf("With changed the original or lost fields: %v, %v", err.Fields, more.Fields)
+	}
+	if v, ok := more.Fields["7"]; !ok || v != nil {
+		t.Errorf("dangling key = %v, %v, want nil value", v, ok)
+	}
+	if got := New(Validation, "", "bad input").Error(); got != "bad input" {
+		t.Errorf("Error() without a code = %q", got)
+	}
+}
+
+// TestCategoryOf tests that the outermost set category wins, and the
+// fallbacks for unclassified errors
+func TestCategoryOf(t *testing.T) {
+	inner := New(Conflict, "C1", "version mismatch")
+	if got := CategoryOf(Wrap(inner, Unknown, "", "save failed")); got != Conflict {
+		t.Errorf("category through an unclassified wrapper = %v, want conflict", got)
+	}
+	if got := CategoryOf(Wrap(inner, Internal, "", "save failed")); got != Internal {
+		t.Errorf("outer category = %v, want internal", got)
+	}
+	if got := CategoryOf(fmt.Errorf("call: %w", context.DeadlineExceeded)); got != Timeout {
+		t.Errorf("category of DeadlineExceeded = %v, want timeout", got)
+	}
+	if got := CategoryOf(errNoRows); got != Unknown || CodeOf(errNoRows) != "" {
+		t.Errorf("plain error category = %v", got)
+	}
+	if Category(99).String() != "Category(99)" || PermissionDenied.String() != "permission-denied" {
+		t.Error("Category.String mismatch")
+	}
+}
+
+// TestWithStack tests capturing and formatting the stack
+func TestWithStack(t *testing.T) {
+	if New(Internal, "", "x").Stack() != nil {
+		t.Error("stack captured without WithStack")
+	}
+	err := Wrap(errNoRows, Internal, "DB_1", "query failed", "table", "users").WithStack()
+	stack := err.Stack()
+	if len(stack) == 0 || !strings.HasSuffix(stack[0].Function, "TestWithStack") {
+		t.Fatalf("innermost frame = %v, want TestWithStack", stack)
+	}
+	verbose := fmt.Sprintf("%+v", err)
+	if !strings.HasPrefix(verbose, "[DB_1] query failed: no rows\n  table=users\n") || !strings.Contains(verbose, "coded_test.go:") {
+		t.Errorf("%%+v =\n%s", verbose)
+	}
+	if plain := fmt.Sprintf("%v", err); plain != err.Error() {
+		t.Errorf("%%v = %q, want the message", plain)
+	}
+}
@@ -0,0 +1,109 @@
+package errorsx
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// StatusClientClosedRequest is the nonstandard status, from nginx, for a
+// request the client abandoned; net/http has no name for it
+const StatusClientClosedRequest = 499
+
+// HTTPStatusOf returns the HTTP status for err by its category: 200 for nil,
+// 4xx for the caller's mistakes, and 5xx for the server's, with Unknown
+// errors treated as internal
+func HTTPStatusOf(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	switch CategoryOf(err) {
+	case Validation:
+		return http.StatusBadRequest
+	case NotFound:
+		return http.StatusNotFound
+	case Conflict:
+		return http.StatusConflict
+	case Unauthenticated:
+		return http.StatusUnauthorized
+	case PermissionDenied:
+		return http.StatusForbidden
+	case Unavailable:
+		return http.StatusServiceUnavailable
+	case Timeout:
+		return http.StatusGatewayTimeout
+	case Canceled:
+		return StatusClientClosedRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCCode is a gRPC status code. The values are those of the codes
+// package of google.golang.org/grpc, so they convert directly.
+type GRPCCode uint32
+
+// The gRPC codes the categories map to
+const (
+	CodeOK               GRPCCode = 0
+	CodeCanceled         GRPCCode = 1
+	CodeUnknown          GRPCCode = 2
+	CodeInvalidArgument  GRPCCode = 3
+	CodeDeadlineExceeded GRPCCode = 4
+	CodeNotFound         GRPCCode = 5
+	CodePermissionDenied GRPCCode = 7
+	CodeAborted          GRPCCode = 10
+	CodeInternal         GRPCCode = 13
+	CodeUnavailable      GRPCCode = 14
+	CodeUnauthenticated  GRPCCode = 16
+)
+
+var grpcNames = map[GRPCCode]string{
+	CodeOK:               "OK",
+	CodeCanceled:         "Canceled",
+	CodeUnknown:          "Unknown",
+	CodeInvalidArgument:  "InvalidArgument",
+	CodeDeadlineExceeded: "DeadlineExceeded",
+	CodeNotFound:         "NotFound",
+	CodePermissionDenied: "PermissionDenied",
+	CodeAborted:          "Aborted",
+	CodeInternal:         "Internal",
+	CodeUnavailable:      "Unavailable",
+	CodeUnauthenticated:  "Unauthenticated",
+}
+
+// String returns the name of c as gRPC spells it
+func (c GRPCCode) String() string {
+	if name, ok := grpcNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("Code(%d)", uint32(c))
+}
+
+// GRPCCodeOf returns the gRPC code for err by its category: OK for nil,
+// Aborted for a conflict, as for a failed concurrent update, and Unknown
+// for errors not classified
+func GRPCCodeOf(err error) GRPCCode {
+	if err == nil {
+		return CodeOK
+	}
+	switch CategoryOf(err) {
+	case Validation:
+		return CodeInvalidArgument
+	case NotFound:
+		return CodeNotFound
+	case Conflict:
+		return CodeAborted
+	case Unauthenticated:
+		return CodeUnauthenticated
+	case PermissionDenied:
+		return CodePermissionDenied
+	case Unavailable:
+		return CodeUnavailable
+	case Timeout:
+		return CodeDeadlineExceeded
+	case Canceled:
+		return CodeCanceled
+	case Internal:
+		return CodeInternal
+	}
+	return CodeUnknown
+}
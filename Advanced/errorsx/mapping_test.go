@@ -0,0 +1,45 @@
+package errorsx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestMapping tests the HTTP status and gRPC code of each category
+func TestMapping(t *testing.T) {
+	cases := []struct {
+		err  error
+		http int
+		grpc GRPCCode
+	}{
+		{nil, 200, CodeOK},
+		{New(Validation, "", "x"), 400, CodeInvalidArgument},
+		{New(NotFound, "", "x"), 404, CodeNotFound},
+		{New(Conflict, "", "x"), 409, CodeAborted},
+		{New(Unauthenticated, "", "x"), 401, CodeUnauthenticated},
+		{New(PermissionDenied, "", "x"), 403, CodePermissionDenied},
+		{New(Unavailable, "", "x"), 503, CodeUnavailable},
+		{New(Internal, "", "x"), 500, CodeInternal},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), 504, CodeDeadlineExceeded},
+		{context.Canceled, StatusClientClosedRequest, CodeCanceled},
+		{errors.New("plain"), 500, CodeUnknown},
+	}
+	for _, tc := range cases {
+		if got := HTTPStatusOf(tc.err); got != tc.http {
+			t.Errorf("HTTPStatusOf(%v) = %d, want %d", tc.err, got, tc.http)
+		}
+		if got := GRPCCodeOf(tc.err); got != tc.grpc {
+			t.Errorf("GRPCCodeOf(%v) = %v, want %v", tc.err, got, tc.grpc)
+		}
+	}
+
+	// A Multi answers for its first classified member
+	if got := HTTPStatusOf(Append(errors.New("plain"), New(NotFound, "", "x"))); got != 404 {
+		t.Errorf("HTTPStatusOf(Multi) = %d, want 404", got)
+	}
+	if CodeNotFound.String() != "NotFound" || GRPCCode(42).String() != "Code(42)" {
+		t.Error("GRPCCode.String mismatch")
+	}
+}
@@ -0,0 +1,115 @@
+// Package errorsx extends the errors package. Multi is an error made of
+// several others: like the result of errors.Join, it unwraps to all its
+// members, so errors.Is and errors.As search each of their chains; unlike
+// it, Multi formats its members as an indented list, and Append, Combine
+// and Filter build and prune one without flattening errors to strings.
+//
+// Coded is an error with a machine-readable code, a Category such as
+// Validation or NotFound, metadata and optionally a stack trace;
+// HTTPStatusOf and GRPCCodeOf map any error chain containing one to the
+// status a server should answer with.
+package errorsx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Multi is an error made of several errors, none nil
+type Multi struct {
+	errs []error
+}
+
+// Error lists the members one per line, indenting the continuation lines
+// of multi-line messages, such as those of nested Multis, under their
+// bullet
+func (m *Multi) Error() string {
+	if len(m.errs) == 1 {
+		return m.errs[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d errors occurred:", len(m.errs))
+	for _, err := range m.errs {
+		b.WriteString("\n  * ")
+		b.WriteString(strings.ReplaceAll(err.Error(), "\n", "\n    "))
+	}
+	return b.String()
+}
+
+// Unwrap returns the members, for errors.Is and errors.As. The slice is a
+// copy, so changing it does not change m.
+func (m *Multi) Unwrap() []error {
+	return append([]error(nil), m.errs...)
+}
+
+// Len returns the number of members
+func (m *Multi) Len() int {
+	return len(m.errs)
+}
+
+// Append returns a Multi of the members of err followed by the errors of
+// errs, skipping nils; a Multi among them contributes its members rather
+// than nesting. It returns nil if there are no errors at all. err itself
+// is never modified, so appending to a shared Multi is safe.
+func Append(err error, errs ...error) error {
+	var all []error
+	all = appendFlat(all, err)
+	for _, e := range errs {
+		all = appendFlat(all, e)
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	return &Multi{errs: all}
+}
+
+// appendFlat appends err to errs, or its members if it is a Multi, unless
+// it is nil
+func appendFlat(errs []error, err error) []error {
+	if m, ok := err.(*Multi); ok {
+		return append(errs, m.errs...)
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Combine returns the errors of errs that are not nil as one error: nil if
+// there are none, the error itself if there is one, and a Multi otherwise
+func Combine(errs ...error) error {
+	return collapse(Append(nil, errs...))
+}
+
+// collapse returns a Multi of one member as that member
+func collapse(err error) error {
+	if m, ok := err.(*Multi); ok && len(m.errs) == 1 {
+		return m.errs[0]
+	}
+	return err
+}
+
+// Errors returns the members of err if it is a Multi, err alone if it is
+// another error, and nil if it is nil
+func Errors(err error) []error {
+	if m, ok := err.(*Multi); ok {
+		return m.Unwrap()
+	}
+	if err == nil {
+		return nil
+	}
+	return []error{err}
+}
+
+// Filter returns the members of err for which keep is true, combined as
+// by Combine. Use it to drop expected errors, such as context.Canceled
+// from goroutines stopped on purpose.
+func Filter(err error, keep func(error) bool) error {
+	kept := []error{}
+	for _, e := range Errors(err) {
+		if keep(e) {
+			kept = append(kept, e)
+		}
+	}
+	return Combine(kept...)
+}
@@ -0,0 +1,110 @@
+package errorsx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"testing"
+)
+
+var (
+	errA = errors.New("a")
+	errB = errors.New("b")
+	errC = errors.New("c")
+)
+
+// TestAppend tests that Append skips nils, flattens Multis and never
+// modifies its first argument
+func TestAppend(t *testing.T) {
+	if err := Append(nil, nil, nil); err != nil {
+		t.Errorf("Append of nils = %v, want nil", err)
+	}
+	ab := Append(errA, nil, errB)
+	abc := Append(ab, errC)
+	abd := Append(ab, errA)
+	if got := Errors(abc); !slices.Equal(got, []error{errA, errB, errC}) {
+		t.Errorf("Errors(abc) = %v", got)
+	}
+	if got := Errors(abd); !slices.Equal(got, []error{errA, errB, errA}) {
+		t.Errorf("appending to a shared Multi changed another: %v", got)
+	}
+	if got := Errors(Append(errC, ab)); !slices.Equal(got, []error{errC, errA, errB}) {
+		t.Errorf("a Multi appended was nested: %v", got)
+	}
+	if n := ab.(*Multi).Len(); n != 2 {
+		t.Errorf("Len = %d, want 2", n)
+	}
+}
+
+// TestCombine tests that Combine collapses to nil or a lone error
+func TestCombine(t *testing.T) {
+	if err := Combine(); err != nil {
+		t.Errorf("Combine() = %v, want nil", err)
+	}
+	if err := Combine(nil, errA, nil); err != errA {
+		t.Errorf("Combine of one error = %v, want it unchanged", err)
+	}
+	if err := Combine(errA, errB); len(Errors(err)) != 2 {
+		t.Errorf("Combine of two = %v, want a Multi", err)
+	}
+	if got := Errors(errA); !slices.Equal(got, []error{errA}) || Errors(nil) != nil {
+		t.Errorf("Errors of a plain error = %v", got)
+	}
+}
+
+// TestIsAs tests that errors.Is and errors.As search every member's chain
+func TestIsAs(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "/x", Err: fs.ErrNotExist}
+	err := fmt.Errorf("loading: %w", Append(errA, fmt.Errorf("config: %w", pathErr)))
+	if !errors.Is(err, errA) || !errors.Is(err, fs.ErrNotExist) || errors.Is(err, errB) {
+		t.Error("errors.Is did not search every member")
+	}
+	var target *fs.PathError
+	if !errors.As(err, &target) || target.Path != "/x" {
+		t.Error("errors.As did not find the wrapped PathError")
+	}
+
+	// A Multi mixes with errors.Join in both directions
+	joined := errors.Join(Append(errA, errB), errC)
+	if !errors.Is(joined, errB) || !errors.Is(Append(errors.Join(errA), errC), errA) {
+		t.Error("Multi and errors.Join do not interoperate")
+	}
+
+	m := Append(errA, errB).(*Multi)
+	m.Unwrap()[0] = errC
+	if !errors.Is(m, errA) {
+		t.Error("changing the result of Unwrap changed the Multi")
+	}
+}
+
+// TestFilter tests dropping members
+func TestFilter(t *testing.T) {
+	err := Append(context.Canceled, errA, fmt.Errorf("worker: %w", context.Canceled))
+	keep := func(err error) bool { return !errors.Is(err, context.Canceled) }
+	if got := Filter(err, keep); got != errA {
+		t.Errorf("Filter = %v, want a", got)
+	}
+	if got := Filter(context.Canceled, keep); got != nil {
+		t.Errorf("Filter of a dropped error = %v, want nil", got)
+	}
+}
+
+// TestFormat tests the indented list, nested Multis included
+func TestFormat(t *testing.T) {
+	nested := &Multi{errs: []error{errB, errors.New("c\nsecond line")}}
+	err := &Multi{errs: []error{errA, nested}}
+	want := `2 errors occurred:
+  * a
+  * 2 errors occurred:
+      * b
+      * c
+        second line`
+	if got := err.Error(); got != want {
+		t.Errorf("Error() =\n%s\nwant\n%s", got, want)
+	}
+	if got := Append(errA).Error(); got != "a" {
+		t.Errorf("Error() of one member = %q, want a", got)
+	}
+}
@@ -0,0 +1,96 @@
+package eval
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Node is a node of a parsed expression: a *Literal, *Ident, *Unary,
+// *Binary, *Cond or *Call
+type Node interface {
+	// String formats the node as an expression, fully parenthesized
+	String() string
+	node()
+}
+
+// Literal is a constant: an int64, float64, bool or string
+type Literal struct{ Value any }
+
+// Ident is a variable
+type Ident struct{ Name string }
+
+// Unary is - or ! applied to an operand
+type Unary struct {
+	Op string
+	X  Node
+}
+
+// Binary is an operator applied to two operands
+type Binary struct {
+	Op   string // ||, &&, ==, !=, <, <=, >, >=, +, -, *, /, % or **
+	L, R Node
+}
+
+// Cond is Cond ? Then : Else
+type Cond struct{ Cond, Then, Else Node }
+
+// Call is a call of a function
+type Call struct {
+	Name string
+	Args []Node
+}
+
+func (*Literal) node() {}
+func (*Ident) node()   {}
+func (*Unary) node()   {}
+func (*Binary) node()  {}
+func (*Cond) node()    {}
+func (*Call) node()    {}
+
+func (n *Literal) String() string { return format(n.Value) }
+
+func (n *Ident) String() string { return n.Name }
+
+func (n *Unary) String() string {
+	// The space keeps a minus from folding into a number literal
+	return "(" + n.Op + " " + n.X.String() + ")"
+}
+
+func (n *Binary) String() string {
+	return "(" + n.L.String() + " " + n.Op + " " + n.R.String() + ")"
+}
+
+func (n *Cond) String() string {
+	return "(" + n.Cond.String() + " ? " + n.Then.String() + " : " + n.Else.String() + ")"
+}
+
+func (n *Call) String() string {
+	args := make([]string, len(n.Args))
+	for i, a := range n.Args {
+		args[i] = a.String()
+	}
+	return n.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// format writes a value as it would be written in an expression
+func format(v any) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		// Keep a point, so the literal reads back as a float
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		return s
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return `"` + quoter.Replace(v) + `"`
+	}
+	return "<invalid>"
+}
+
+// quoter quotes a string with the escapes the lexer reads
+var quoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
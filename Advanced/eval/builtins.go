@@ -0,0 +1,141 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// builtins are the functions of every new Evaluator
+var builtins = map[string]function{
+	"abs": {1, func(args []any) (any, error) {
+		switch v := args[0].(type) {
+		case int64:
+			if v == math.MinInt64 {
+				return nil, fmt.Errorf("%w: abs(%d)", ErrOverflow, v)
+			}
+			return max(v, -v), nil
+		case float64:
+			return math.Abs(v), nil
+		}
+		return nil, argError(args[0], "a number")
+	}},
+	"min":   {Variadic, extreme(-1)},
+	"max":   {Variadic, extreme(1)},
+	"floor": {1, rounding(math.Floor)},
+	"ceil":  {1, rounding(math.Ceil)},
+	"round": {1, rounding(math.Round)},
+	"sqrt": {1, func(args []any) (any, error) {
+		f, ok := toFloat(args[0])
+		if !ok {
+			return nil, argError(args[0], "a number")
+		}
+		return math.Sqrt(f), nil
+	}},
+	"pow": {2, func(args []any) (any, error) { return pow(args[0], args[1]) }},
+	"len": {1, func(args []any) (any, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, argError(args[0], "a string")
+		}
+		return int64(utf8.RuneCountInString(s)), nil
+	}},
+	"lower": {1, stringFunc(strings.ToLower)},
+	"upper": {1, stringFunc(strings.ToUpper)},
+	"int": {1, func(args []any) (any, error) {
+		switch v := args[0].(type) {
+		case int64:
+			return v, nil
+		case float64:
+			// Secure: A float outside int64 has no integer to truncate to
+			if math.IsNaN(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+				return nil, fmt.Errorf("%w: int(%g)", ErrOverflow, v)
+			}
+			return int64(v), nil
+		case string:
+			i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: int(%q)", ErrType, v)
+			}
+			return i, nil
+		}
+		return nil, argError(args[0], "a number or string")
+	}},
+	"float": {1, func(args []any) (any, error) {
+		if f, ok := toFloat(args[0]); ok {
+			return f, nil
+		}
+		if s, ok := args[0].(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: float(%q)", ErrType, s)
+			}
+			return f, nil
+		}
+		return nil, argError(args[0], "a number or string")
+	}},
+	"string": {1, func(args []any) (any, error) {
+		switch v := args[0].(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'g', -1, 64), nil
+		}
+		return fmt.Sprint(args[0]), nil
+	}},
+}
+
+// argError describes an argument of the wrong type
+func argError(v any, want string) error {
+	return fmt.Errorf("%w: got %s, want %s", ErrType, typeName(v), want)
+}
+
+// extreme returns min, if sign is -1, or max of one or more numbers or
+// strings
+func extreme(sign int) Func {
+	return func(args []any) (any, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%w: need at least one", ErrArguments)
+		}
+		best := args[0]
+		for _, v := range args[1:] {
+			c, ok := compare(v, best)
+			if !ok {
+				return nil, fmt.Errorf("%w: cannot compare %s with %s", ErrType, typeName(v), typeName(best))
+			}
+			if c*sign > 0 {
+				best = v
+			}
+		}
+		if _, ok := compare(best, best); !ok {
+			return nil, argError(best, "a number or string")
+		}
+		return best, nil
+	}
+}
+
+// rounding adapts a float rounding function, leaving integers as they are
+func rounding(f func(float64) float64) Func {
+	return func(args []any) (any, error) {
+		switch v := args[0].(type) {
+		case int64:
+			return v, nil
+		case float64:
+			return f(v), nil
+		}
+		return nil, argError(args[0], "a number")
+	}
+}
+
+// stringFunc adapts a function of a string
+func stringFunc(f func(string) string) Func {
+	return func(args []any) (any, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, argError(args[0], "a string")
+		}
+		return f(s), nil
+	}
+}
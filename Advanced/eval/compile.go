@@ -0,0 +1,172 @@
+package eval
+
+import (
+	"fmt"
+)
+
+// evalFunc evaluates a compiled node with the variables of an evaluation
+type evalFunc func(vars map[string]any) (any, error)
+
+// compiled is a compiled node, and whether it is constant: a literal, or
+// an operator applied to constants, which is evaluated while compiling
+type compiled struct {
+	eval     evalFunc
+	constant bool
+}
+
+// compiler compiles the nodes of one expression
+type compiler struct {
+	e    *Evaluator
+	vars []string
+	seen map[string]bool
+}
+
+// compile turns a node into a closure, so that the tree is walked and its
+// functions looked up once rather than at every evaluation
+func (c *compiler) compile(n Node) (compiled, error) {
+	switch n := n.(type) {
+	case *Literal:
+		return constant(n.Value), nil
+	case *Ident:
+		if !c.seen[n.Name] {
+			c.seen[n.Name] = true
+			c.vars = append(c.vars, n.Name)
+		}
+		name := n.Name
+		return compiled{eval: func(vars map[string]any) (any, error) {
+			v, ok := vars[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUndefined, name)
+			}
+			return normalize(v)
+		}}, nil
+	case *Unary:
+		x, err := c.compile(n.X)
+		if err != nil {
+			return compiled{}, err
+		}
+		op := unaryOps[n.Op]
+		return fold(func(vars map[string]any) (any, error) {
+			v, err := x.eval(vars)
+			if err != nil {
+				return nil, err
+			}
+			return op(v)
+		}, x)
+	case *Binary:
+		l, err := c.compile(n.L)
+		if err != nil {
+			return compiled{}, err
+		}
+		r, err := c.compile(n.R)
+		if err != nil {
+			return compiled{}, err
+		}
+		switch n.Op {
+		case "&&", "||":
+			return fold(logical(n.Op, l.eval, r.eval), l, r)
+		}
+		op := binaryOps[n.Op]
+		return fold(func(vars map[string]any) (any, error) {
+			a, err := l.eval(vars)
+			if err != nil {
+				return nil, err
+			}
+			b, err := r.eval(vars)
+			if err != nil {
+				return nil, err
+			}
+			return op(a, b)
+		}, l, r)
+	case *Cond:
+		cond, err := c.compile(n.Cond)
+		if err != nil {
+			return compiled{}, err
+		}
+		then, err := c.compile(n.Then)
+		if err != nil {
+			return compiled{}, err
+		}
+		els, err := c.compile(n.Else)
+		if err != nil {
+			return compiled{}, err
+		}
+		// Only the branch taken is evaluated
+		return fold(func(vars map[string]any) (any, error) {
+			v, err := cond.eval(vars)
+			if err != nil {
+				return nil, err
+			}
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%w: condition of ?: is %s, not bool", ErrType, typeName(v))
+			}
+			if b {
+				return then.eval(vars)
+			}
+			return els.eval(vars)
+		}, cond, then, els)
+	case *Call:
+		return c.call(n)
+	}
+	return compiled{}, fmt.Errorf("eval: unknown node %T", n)
+}
+
+// call compiles a call, checking the function exists and its arity.
+// Calls are never folded, since a registered function need not return
+// the same result each time.
+func (c *compiler) call(n *Call) (compiled, error) {
+	f, ok := c.e.lookup(n.Name)
+	if !ok {
+		return compiled{}, fmt.Errorf("%w: %s", ErrUnknownFunction, n.Name)
+	}
+	if f.arity != Variadic && len(n.Args) != f.arity {
+		return compiled{}, fmt.Errorf("%w: %s takes %d, got %d", ErrArguments, n.Name, f.arity, len(n.Args))
+	}
+	args := make([]evalFunc, len(n.Args))
+	for i, a := range n.Args {
+		arg, err := c.compile(a)
+		if err != nil {
+			return compiled{}, err
+		}
+		args[i] = arg.eval
+	}
+	name, fn := n.Name, f.fn
+	return compiled{eval: func(vars map[string]any) (any, error) {
+		values := make([]any, len(args))
+		for i, arg := range args {
+			var err error
+			if values[i], err = arg(vars); err != nil {
+				return nil, err
+			}
+		}
+		v, err := fn(values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if v, err = normalize(v); err != nil {
+			return nil, fmt.Errorf("%s returned %w", name, err)
+		}
+		return v, nil
+	}}, nil
+}
+
+// constant returns a compiled constant
+func constant(v any) compiled {
+	return compiled{eval: func(map[string]any) (any, error) { return v, nil }, constant: true}
+}
+
+// fold evaluates f now if all its operands are constant, replacing it by
+// its value. An error, such as 1 / 0, fails the compilation.
+func fold(f evalFunc, operands ...compiled) (compiled, error) {
+	for _, o := range operands {
+		if !o.constant {
+			return compiled{eval: f}, nil
+		}
+	}
+	v, err := f(nil)
+	if err != nil {
+		return compiled{}, err
+	}
+	return constant(v), nil
+}
@@ -0,0 +1,186 @@
+// Package eval parses and evaluates expressions such as
+//
+//	price * qty * (1 - discount) > 100 && region == "eu"
+//
+// for configuration rules, filters and calculators. Values are int64,
+// float64, bool and string. Variables are bound from a map at each
+// evaluation, and functions, built in or registered on an Evaluator, are
+// called by name.
+//
+// Compile parses an expression once into a Program, resolving its
+// functions and folding its constant parts, and a Program evaluates many
+// times, concurrently if need be, without parsing again. Integer
+// arithmetic fails with ErrOverflow rather than wrapping, and division
+// by zero fails with ErrDivideByZero.
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+var (
+	// ErrType is returned for an operator or function applied to values
+	// of the wrong type
+	ErrType = errors.New("type mismatch")
+	// ErrUndefined is returned for a variable missing from the variables
+	// an expression is evaluated with
+	ErrUndefined = errors.New("undefined variable")
+	// ErrUnknownFunction is returned for a call of a function that is not
+	// registered
+	ErrUnknownFunction = errors.New("unknown function")
+	// ErrArguments is returned for a call with the wrong number of
+	// arguments
+	ErrArguments = errors.New("wrong number of arguments")
+	// ErrDivideByZero is returned for division or remainder by zero
+	ErrDivideByZero = errors.New("division by zero")
+	// ErrOverflow is returned for integer arithmetic out of range
+	ErrOverflow = errors.New("integer overflow")
+)
+
+// Variadic is the arity of a function taking any number of arguments
+const Variadic = -1
+
+// Func is a function callable from expressions. Its arguments are int64,
+// float64, bool or string, and so must its result be, or another integer
+// or float type, which is converted.
+type Func func(args []any) (any, error)
+
+// function is a registered function and its arity
+type function struct {
+	arity int
+	fn    Func
+}
+
+// Evaluator compiles expressions calling the built-in functions and those
+// registered on it. It is safe for concurrent use.
+type Evaluator struct {
+	mu    sync.RWMutex
+	funcs map[string]function
+}
+
+// New returns an Evaluator with the built-in functions: abs, min, max,
+// floor, ceil, round, sqrt, pow, len, lower, upper, int, float and string
+func New() *Evaluator {
+	e := &Evaluator{funcs: make(map[string]function, len(builtins))}
+	for name, f := range builtins {
+		e.funcs[name] = f
+	}
+	return e
+}
+
+// funcName matches the names a function may be registered under
+var funcName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Register adds a function taking arity arguments, or any number if arity
+// is Variadic, or replaces a built-in one. Programs already compiled keep
+// the function they were compiled with. It panics if name is not a plain
+// identifier, is true, false, and, or or not, or arity is below Variadic.
+func (e *Evaluator) Register(name string, arity int, fn Func) {
+	if !funcName.MatchString(name) || slices.Contains([]string{"true", "false", "and", "or", "not"}, name) || arity < Variadic {
+		panic(fmt.Sprintf("eval: invalid function %q of arity %d", name, arity))
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.funcs[name] = function{arity, fn}
+}
+
+// lookup returns a registered function
+func (e *Evaluator) lookup(name string) (function, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	f, ok := e.funcs[name]
+	return f, ok
+}
+
+// Program is a compiled expression. It is safe for concurrent use.
+type Program struct {
+	src  string
+	root evalFunc
+	vars []string
+}
+
+// Compile parses an expression and compiles it into a Program. Calls of
+// unknown functions, with the wrong number of arguments, and errors in
+// constant parts such as 1 / 0 are reported here rather than at each
+// evaluation.
+func (e *Evaluator) Compile(src string) (*Program, error) {
+	n, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &compiler{e: e, seen: make(map[string]bool)}
+	root, err := c.compile(n)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{src: src, root: root.eval, vars: c.vars}, nil
+}
+
+// Eval evaluates the program with variables bound from vars, which may
+// be nil if it uses none. Values of vars may be of any integer or float
+// type, bool or string. The result is an int64, float64, bool or string.
+func (p *Program) Eval(vars map[string]any) (any, error) {
+	return p.root(vars)
+}
+
+// Vars returns the names of the variables the program reads, in the order
+// they first appear
+func (p *Program) Vars() []string { return slices.Clone(p.vars) }
+
+// Bool evaluates the program and requires a boolean result, as a rule or
+// filter does
+func (p *Program) Bool(vars map[string]any) (bool, error) {
+	v, err := p.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w: %s is %s, not bool", ErrType, p.src, typeName(v))
+	}
+	return b, nil
+}
+
+// Float evaluates the program and requires a numeric result, returned as
+// a float64, as a calculator does
+func (p *Program) Float(vars map[string]any) (float64, error) {
+	v, err := p.Eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	switch v := v.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	}
+	return 0, fmt.Errorf("%w: %s is %s, not a number", ErrType, p.src, typeName(v))
+}
+
+// String returns the source of the program
+func (p *Program) String() string { return p.src }
+
+// std is the Evaluator of the package-level functions
+var std = New()
+
+// Register adds a function to the Evaluator used by the package-level
+// Compile and Eval
+func Register(name string, arity int, fn Func) { std.Register(name, arity, fn) }
+
+// Compile compiles an expression with the built-in functions and those
+// added by Register
+func Compile(src string) (*Program, error) { return std.Compile(src) }
+
+// Eval compiles and evaluates an expression once. A program evaluated
+// repeatedly should be compiled once instead.
+func Eval(src string, vars map[string]any) (any, error) {
+	p, err := std.Compile(src)
+	if err != nil {
+		return nil, err
+	}
+	return p.Eval(vars)
+}
@@ -0,0 +1,312 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestEval tests the operators and built-in functions
+func TestEval(t *testing.T) {
+	vars := map[string]any{
+		"i": 7, "u8": uint8(200), "f32": float32(0.5), "f": 2.5,
+		"s": "héllo", "yes": true, "no": false, "user.age": 42,
+	}
+	tests := []struct {
+		src  string
+		want any
+	}{
+		{"1 + 2 * 3", int64(7)},
+		{"i - 10", int64(-3)},
+		{"i * u8", int64(1400)},
+		{"i / 2", 3.5},
+		{"8 / 2", int64(4)},
+		{"-7 % 3", int64(-1)},
+		{"7.5 % 2", 1.5},
+		{"i + f", 9.5},
+		{"f32 * 4", 2.0},
+		{"2 ** 10", int64(1024)},
+		{"2 ** -1", 0.5},
+		{"2 ** 0.5 == sqrt(2)", true},
+		{"-2 ** 2", int64(-4)},
+		{"(-2) ** 2", int64(4)},
+		{"-9223372036854775808 % -1", int64(0)},
+		{`s + "!"`, "héllo!"},
+		{`s == "héllo" && s != "x"`, true},
+		{`"a" < "b"`, true},
+		{"1 == 1.0", true},
+		{`1 == "1"`, false},
+		{"yes == true", true},
+		{"yes == 1", false},
+		{"i >= 7 and not no", true},
+		{"user.age > 40 ? \"old\" : \"young\"", "old"},
+		{"i > 10 ? 1 : i > 5 ? 2 : 3", int64(2)},
+		{"abs(-3) + abs(-1.5)", 4.5},
+		{"min(3, 1.5, 2)", 1.5},
+		{"max(i, 3)", int64(7)},
+		{`max("b", "a", "c")`, "c"},
+		{"floor(2.7) + ceil(2.1) + round(2.5) + floor(4)", 12.0},
+		{"pow(3, 3)", int64(27)},
+		{"len(s)", int64(5)},
+		{`upper(s) + lower("ABC")`, "HÉLLOabc"},
+		{"int(f) + int(\" 12 \")", int64(14)},
+		{`float("1.5") + float(1)`, 2.5},
+		{`string(i) + string(f) + string(yes) + string("x")`, "72.5truex"},
+	}
+	for _, tt := range tests {
+		got, err := Eval(tt.src, vars)
+		if err != nil {
+			t.Errorf("Eval(%q) = %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%q) = %v (%T), want %v (%T)", tt.src, got, got, tt.want, tt.want)
+		}
+	}
+}
+
+// TestErrors tests errors at compilation and at evaluation
+func TestErrors(t *testing.T) {
+	vars := map[string]any{"i": 1, "zero": 0, "big": uint64(math.MaxUint64), "s": "x", "m": map[string]int{}}
+	compileErrors := []struct {
+		src  string
+		want error
+	}{
+		{"1 / 0", ErrDivideByZero},
+		{"1 + true", ErrType},
+		{"9223372036854775807 + 1", ErrOverflow},
+		{"2 ** 63", ErrOverflow},
+		{"nope(1)", ErrUnknownFunction},
+		{"abs(1, 2)", ErrArguments},
+		{"1 ? 2 : 3", ErrType},
+		{"-true", ErrType},
+		{"!1", ErrType},
+	}
+	for _, tt := range compileErrors {
+		if _, err := Compile(tt.src); !errors.Is(err, tt.want) {
+			t.Errorf("Compile(%q) = %v, want %v", tt.src, err, tt.want)
+		}
+	}
+
+	evalErrors := []struct {
+		src  string
+		want error
+	}{
+		{"i / zero", ErrDivideByZero},
+		{"i % zero", ErrDivideByZero},
+		{"1.5 / zero", ErrDivideByZero},
+		{"missing + 1", ErrUndefined},
+		{"big", ErrOverflow},
+		{"m", ErrType},
+		{"s * 2", ErrType},
+		{"s < 1", ErrType},
+		{"i && true", ErrType},
+		{"abs(s)", ErrType},
+		{"int(s)", ErrType},
+		{"int(1e300 * i)", ErrOverflow},
+		{"abs(-9223372036854775807 - i)", ErrOverflow},
+		{"i - 9223372036854775807 - 3", ErrOverflow},
+		{"min()", ErrArguments},
+		{"i * 9223372036854775807 * 2", ErrOverflow},
+	}
+	for _, tt := range evalErrors {
+		p, err := Compile(tt.src)
+		if err != nil {
+			t.Errorf("Compile(%q) = %v", tt.src, err)
+			continue
+		}
+		if _, err := p.Eval(vars); !errors.Is(err, tt.want) {
+			t.Errorf("Eval(%q) = %v, want %v", tt.src, err, tt.want)
+		}
+	}
+
+	// A failing function is named in the error
+	if _, err := Eval("abs(s)", vars); err == nil || err.Error() != "abs: type mismatch: got string, want a number" {
+		t.Errorf("Eval(abs(s)) = %v", err)
+	}
+}
+
+// TestShortCircuit tests that && and || and ?: evaluate only what decides
+// the result
+func TestShortCircuit(t *testing.T) {
+	vars := map[string]any{"n": 0}
+	for _, src := range []string{
+		"n == 0 || 1 / n > 1",
+		"!(n != 0 && 1 / n > 1)",
+		"(n == 0 ? 0 : 1 / n) == 0",
+		"n != 0 ? missing : true",
+	} {
+		if b, err := mustCompile(t, std, src).Bool(vars); err != nil || !b {
+			t.Errorf("Bool(%q) = %v, %v, want true", src, b, err)
+		}
+	}
+	// The right operand must still be a bool when evaluated
+	if _, err := Eval("n == 0 && n", vars); !errors.Is(err, ErrType) {
+		t.Errorf("Eval(n == 0 && n) = %v, want %v", err, ErrType)
+	}
+}
+
+// mustCompile compiles an expression with an Evaluator or fails the test
+func mustCompile(t testing.TB, e *Evaluator, src string) *Program {
+	t.Helper()
+	p, err := e.Compile(src)
+	if err != nil {
+		t.Fatalf("Compile(%q) = %v", src, err)
+	}
+	return p
+}
+
+// TestProgram tests reuse of a program, its variables and typed results
+func TestProgram(t *testing.T) {
+	p := mustCompile(t, std, "price * qty * (1 - discount) > 100 && region == 'eu' && price > 0")
+	if got, want := p.Vars(), []string{"price", "qty", "discount", "region"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Vars = %v, want %v", got, want)
+	}
+	p.Vars()[0] = "changed"
+	if p.Vars()[0] != "price" {
+		t.Error("Vars shares its slice with the program")
+	}
+	for _, tt := range []struct {
+		price any
+		qty   int
+		want  bool
+	}{{10, 20, true}, {10.5, 2, false}, {uint16(60), 2, true}} {
+		got, err := p.Bool(map[string]any{"price": tt.price, "qty": tt.qty, "discount": 0.1, "region": "eu"})
+		if err != nil || got != tt.want {
+			t.Errorf("Bool with price %v, qty %d = %v, %v, want %v", tt.price, tt.qty, got, err, tt.want)
+		}
+	}
+
+	if f, err := mustCompile(t, std, "1 + 2").Float(nil); err != nil || f != 3 {
+		t.Errorf("Float(1 + 2) = %v, %v", f, err)
+	}
+	if _, err := mustCompile(t, std, "'x'").Float(nil); !errors.Is(err, ErrType) {
+		t.Errorf("Float('x') = %v, want %v", err, ErrType)
+	}
+	if _, err := mustCompile(t, std, "1").Bool(nil); !errors.Is(err, ErrType) {
+		t.Errorf("Bool(1) = %v, want %v", err, ErrType)
+	}
+	if p.String() != "price * qty * (1 - discount) > 100 && region == 'eu' && price > 0" {
+		t.Errorf("String = %q", p.String())
+	}
+}
+
+// TestRegister tests user functions
+func TestRegister(t *testing.T) {
+	e := New()
+	calls := 0
+	e.Register("tick", 0, func([]any) (any, error) {
+		calls++
+		return calls, nil
+	})
+	e.Register("join", Variadic, func(args []any) (any, error) {
+		s := ""
+		for _, a := range args {
+			s += fmt.Sprint(a)
+		}
+		return s, nil
+	})
+	e.Register("fail", 1, func(args []any) (any, error) { return nil, errors.New("boom") })
+	e.Register("bad", 0, func([]any) (any, error) { return []int{}, nil })
+
+	// Calls are not folded, even of constant arguments
+	p := mustCompile(t, e, "tick() + tick()")
+	if calls != 0 {
+		t.Errorf("Compile called tick %d times", calls)
+	}
+	if v, err := p.Eval(nil); err != nil || v != int64(3) {
+		t.Errorf("Eval(tick() + tick()) = %v, %v, want 3", v, err)
+	}
+	if v, err := mustCompile(t, e, "join(1, 'a', true)").Eval(nil); err != nil || v != "1atrue" {
+		t.Errorf("join = %v, %v", v, err)
+	}
+	if _, err := mustCompile(t, e, "fail(1)").Eval(nil); err == nil || err.Error() != "fail: boom" {
+		t.Errorf("fail(1) = %v", err)
+	}
+	if _, err := mustCompile(t, e, "bad()").Eval(nil); !errors.Is(err, ErrType) {
+		t.Errorf("bad() = %v, want %v", err, ErrType)
+	}
+
+	// Functions are per Evaluator
+	if _, err := Compile("tick()"); !errors.Is(err, ErrUnknownFunction) {
+		t.Errorf("package Compile(tick()) = %v, want %v", err, ErrUnknownFunction)
+	}
+
+	// A compiled program keeps the function it was compiled with
+	e.Register("tick", 0, func([]any) (any, error) { return -1, nil })
+	if v, _ := p.Eval(nil); v != int64(7) {
+		t.Errorf("Eval after replacing tick = %v, want 7", v)
+	}
+
+	for _, name := range []string{"", "1x", "a.b", "true", "not", "a-b"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) did not panic", name)
+				}
+			}()
+			e.Register(name, 0, nil)
+		}()
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Register with arity -2 did not panic")
+			}
+		}()
+		e.Register("x", -2, nil)
+	}()
+}
+
+// TestConcurrent tests a program evaluated from many goroutines, while
+// functions are registered; run with -race
+func TestConcurrent(t *testing.T) {
+	e := New()
+	e.Register("double", 1, func(args []any) (any, error) { return args[0].(int64) * 2, nil })
+	p := mustCompile(t, e, "double(x) + 1")
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 100 {
+				v, err := p.Eval(map[string]any{"x": g*1000 + i})
+				if err != nil || v != int64(2*(g*1000+i)+1) {
+					t.Errorf("Eval = %v, %v", v, err)
+					return
+				}
+			}
+		})
+	}
+	wg.Go(func() {
+		for i := range 100 {
+			e.Register(fmt.Sprintf("f%d", i), 0, func([]any) (any, error) { return 0, nil })
+			if _, err := e.Compile("double(1)"); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	wg.Wait()
+}
+
+// BenchmarkProgram measures evaluating a compiled rule
+func BenchmarkProgram(b *testing.B) {
+	p := mustCompile(b, std, "price * qty * (1 - discount) > 100 && region == 'eu'")
+	vars := map[string]any{"price": 10.5, "qty": 20, "discount": 0.1, "region": "eu"}
+	for b.Loop() {
+		if _, err := p.Eval(vars); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEval measures compiling and evaluating the rule each time
+func BenchmarkEval(b *testing.B) {
+	vars := map[string]any{"price": 10.5, "qty": 20, "discount": 0.1, "region": "eu"}
+	for b.Loop() {
+		if _, err := Eval("price * qty * (1 - discount) > 100 && region == 'eu'", vars); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SyntaxError is an expression that does not lex or parse
+type SyntaxError struct {
+	Pos int // Byte offset in the expression
+	Msg string
+}
+
+// Error describes the error and where it is
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at offset %d: %s", e.Pos, e.Msg)
+}
+
+// tokenKind is the class of a token
+type tokenKind uint8
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString // Its text unquoted
+	tokOp     // Operators and punctuation, with and, or and not as &&, || and !
+)
+
+// token is a lexeme of an expression
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// String describes the token for error messages
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return fmt.Sprintf("string %q", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators are the operators and punctuation, longest first so that
+// ** is not read as two *
+var operators = []string{
+	"**", "==", "!=", "<=", ">=", "&&", "||",
+	"+", "-", "*", "/", "%", "<", ">", "!", "?", ":", "(", ")", ",",
+}
+
+// wordOperators are the operators spelled as words
+var wordOperators = map[string]string{"and": "&&", "or": "||", "not": "!"}
+
+// lex splits an expression into tokens, ending with tokEOF
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isIdentStart(src[i:]):
+			start := i
+			i = scanIdent(src, i)
+			word := src[start:i]
+			if op, ok := wordOperators[word]; ok {
+				toks = append(toks, token{tokOp, op, start})
+			} else {
+				toks = append(toks, token{tokIdent, word, start})
+			}
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			i = scanNumber(src, i)
+			toks = append(toks, token{tokNumber, src[start:i], start})
+		case c == '"' || c == '\'':
+			text, end, err := scanString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{tokString, text, i})
+			i = end
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				r, _ := utf8.DecodeRuneInString(src[i:])
+				return nil, &SyntaxError{i, fmt.Sprintf("unexpected character %q", r)}
+			}
+			toks = append(toks, token{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{tokEOF, "", len(src)}), nil
+}
+
+// isIdentStart reports whether s starts with a letter or underscore
+func isIdentStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// scanIdent returns the end of the name starting at i: letters, digits
+// and underscores, with dots joining parts such as user.age
+func scanIdent(src string, i int) int {
+	for i < len(src) {
+		r, n := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			i += n
+		case r == '.' && isIdentStart(src[i+1:]):
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// scanNumber returns the end of the number starting at i: digits, an
+// optional fraction and an optional exponent
+func scanNumber(src string, i int) int {
+	digits := func() {
+		for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+			i++
+		}
+	}
+	digits()
+	if i < len(src) && src[i] == '.' {
+		i++
+		digits()
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && src[j] >= '0' && src[j] <= '9' {
+			i = j
+			digits()
+		}
+	}
+	return i
+}
+
+// escapes are the characters a backslash may escape in a string
+var escapes = map[byte]byte{'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '"': '"', '\'': '\''}
+
+// scanString reads the string quoted by the ' or " at i, returning its
+// text and the offset after it
+func scanString(src string, i int) (string, int, error) {
+	quote := src[i]
+	var b strings.Builder
+	for j := i + 1; j < len(src); j++ {
+		switch c := src[j]; c {
+		case quote:
+			return b.String(), j + 1, nil
+		case '\\':
+			if j+1 == len(src) {
+				break
+			}
+			e, ok := escapes[src[j+1]]
+			if !ok {
+				return "", 0, &SyntaxError{j, fmt.Sprintf("unknown escape %q", src[j:j+2])}
+			}
+			b.WriteByte(e)
+			j++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, &SyntaxError{i, "unterminated string"}
+}
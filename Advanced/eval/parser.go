@@ -0,0 +1,234 @@
+package eval
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxDepth bounds the nesting of expressions, so a hostile expression
+// cannot exhaust the stack
+const maxDepth = 200
+
+// Binding powers of the infix operators, loosest first. Each binds its
+// right operand at its own power, or one less if right-associative.
+const (
+	bpNone   = iota
+	bpCond   // ?: (right-associative)
+	bpOr     // ||
+	bpAnd    // &&
+	bpEqual  // == !=
+	bpOrder  // < <= > >=
+	bpAdd    // + -
+	bpMul    // * / %
+	bpPrefix // Unary - and !
+	bpPow    // ** (right-associative)
+)
+
+// infix gives the binding power of each infix operator
+var infix = map[string]int{
+	"?":  bpCond,
+	"||": bpOr,
+	"&&": bpAnd,
+	"==": bpEqual, "!=": bpEqual,
+	"<": bpOrder, "<=": bpOrder, ">": bpOrder, ">=": bpOrder,
+	"+": bpAdd, "-": bpAdd,
+	"*": bpMul, "/": bpMul, "%": bpMul,
+	"**": bpPow,
+}
+
+// parser is a Pratt parser over the tokens of an expression
+type parser struct {
+	toks  []token
+	pos   int
+	depth int
+}
+
+// Parse parses an expression. Errors are *SyntaxError.
+//
+// Operators bind from loosest to tightest: c ? a : b; ||; &&; == and !=;
+// < <= > >=; + and -; * / and %; unary - and !; ** (so -2 ** 2 is -4).
+// and, or and not are the same as &&, || and !.
+func Parse(src string) (Node, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	n, err := p.expr(bpNone)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	return n, nil
+}
+
+// peek returns the current token
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+// next consumes and returns the current token
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// errorf returns a syntax error at a token
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &SyntaxError{t.pos, fmt.Sprintf(format, args...)}
+}
+
+// expect consumes the operator, or fails
+func (p *parser) expect(op string) error {
+	if t := p.peek(); t.kind != tokOp || t.text != op {
+		return p.errorf(t, "expected %q, found %s", op, t)
+	}
+	p.pos++
+	return nil
+}
+
+// expr parses an expression whose infix operators bind tighter than
+// power: a prefix part, then each operator binding tighter in turn
+func (p *parser) expr(power int) (Node, error) {
+	// Secure: Bound recursion, which every nesting passes through
+	if p.depth++; p.depth > maxDepth {
+		return nil, p.errorf(p.peek(), "expression nested too deeply")
+	}
+	defer func() { p.depth-- }()
+
+	left, err := p.prefix()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		bp, ok := infix[t.text]
+		if t.kind != tokOp || !ok || bp <= power {
+			return left, nil
+		}
+		p.pos++
+		switch t.text {
+		case "?":
+			left, err = p.cond(left)
+		case "**":
+			var right Node
+			if right, err = p.expr(bp - 1); err == nil {
+				left = &Binary{t.text, left, right}
+			}
+		default:
+			var right Node
+			if right, err = p.expr(bp); err == nil {
+				left = &Binary{t.text, left, right}
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// cond parses the rest of c ? a : b after the ?
+func (p *parser) cond(c Node) (Node, error) {
+	then, err := p.expr(bpNone)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	// Right-associative: a ? b : c ? d : e is a ? b : (c ? d : e)
+	els, err := p.expr(bpCond - 1)
+	if err != nil {
+		return nil, err
+	}
+	return &Cond{c, then, els}, nil
+}
+
+// prefix parses a literal, variable, call, parenthesized expression or
+// prefix operator
+func (p *parser) prefix() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return number(t, "")
+	case tokString:
+		return &Literal{t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &Literal{true}, nil
+		case "false":
+			return &Literal{false}, nil
+		}
+		if open := p.peek(); open.kind != tokOp || open.text != "(" {
+			return &Ident{t.text}, nil
+		}
+		p.pos++
+		return p.call(t.text)
+	case tokOp:
+		switch t.text {
+		case "(":
+			n, err := p.expr(bpNone)
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "-", "!":
+			// A minus directly before a number folds into it, so the
+			// smallest integer can be written, unless ** follows
+			if n := p.peek(); t.text == "-" && n.kind == tokNumber && n.pos == t.pos+1 && p.toks[p.pos+1].text != "**" {
+				p.pos++
+				return number(n, "-")
+			}
+			x, err := p.expr(bpPrefix)
+			if err != nil {
+				return nil, err
+			}
+			return &Unary{t.text, x}, nil
+		}
+	}
+	return nil, p.errorf(t, "expected an operand, found %s", t)
+}
+
+// call parses the arguments of a call after its opening parenthesis
+func (p *parser) call(name string) (Node, error) {
+	c := &Call{Name: name}
+	if t := p.peek(); t.kind == tokOp && t.text == ")" {
+		p.pos++
+		return c, nil
+	}
+	for {
+		arg, err := p.expr(bpNone)
+		if err != nil {
+			return nil, err
+		}
+		c.Args = append(c.Args, arg)
+		t := p.next()
+		if t.kind == tokOp && t.text == ")" {
+			return c, nil
+		}
+		if t.kind != tokOp || t.text != "," {
+			return nil, p.errorf(t, "expected \",\" or \")\", found %s", t)
+		}
+	}
+}
+
+// number parses a number literal with an optional sign: an integer if it
+// has no point or exponent and fits, otherwise a float
+func number(t token, sign string) (Node, error) {
+	text := sign + t.text
+	if !strings.ContainsAny(text, ".eE") {
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return &Literal{i}, nil
+		}
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("bad number %s", t.text)}
+	}
+	return &Literal{f}, nil
+}
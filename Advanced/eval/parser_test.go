@@ -0,0 +1,120 @@
+package eval
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestParse tests precedence and associativity through the printed tree
+func TestParse(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{"1 + 2 * 3", "(1 + (2 * 3))"},
+		{"(1 + 2) * 3", "((1 + 2) * 3)"},
+		{"a - b - c", "((a - b) - c)"},
+		{"2 ** 3 ** 2", "(2 ** (3 ** 2))"},
+		{"-2 ** 2", "(- (2 ** 2))"},
+		{"-2 * 3", "(-2 * 3)"},
+		{"- 2", "(- 2)"},
+		{"2 ** -1", "(2 ** -1)"},
+		{"a < b == c >= d", "((a < b) == (c >= d))"},
+		{"a || b && !c", "(a || (b && (! c)))"},
+		{"a or b and not c", "(a || (b && (! c)))"},
+		{"a ? b : c ? d : e", "(a ? b : (c ? d : e))"},
+		{"x > 0 ? x : -x", "((x > 0) ? x : (- x))"},
+		{"max(a, 1.5, min(b))", "max(a, 1.5, min(b))"},
+		{"now()", "now()"},
+		{`user.name + "\t\"x\"" + 'y'`, `((user.name + "\t\"x\"") + "y")`},
+		{"true != false", "(true != false)"},
+		{"1e3 + 2.5 + 3.0", "((1000.0 + 2.5) + 3.0)"},
+		{"9223372036854775807", "9223372036854775807"},
+		{"-9223372036854775808", "-9223372036854775808"},
+		{"9223372036854775808", "9.223372036854776e+18"},
+		{"a % b / c", "((a % b) / c)"},
+	}
+	for _, tt := range tests {
+		n, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", tt.src, err)
+			continue
+		}
+		if got := n.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.src, got, tt.want)
+		}
+		// The printed tree parses to itself
+		again, err := Parse(n.String())
+		if err != nil || again.String() != n.String() {
+			t.Errorf("Parse(%q) = %v, %v, want %s", n.String(), again, err, n.String())
+		}
+	}
+}
+
+// TestParseErrors tests syntax errors and their positions
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		pos int
+	}{
+		{"", 0},
+		{"1 +", 3},
+		{"1 2", 2},
+		{"(1 + 2", 6},
+		{"a ? b", 5},
+		{"f(1 2)", 4},
+		{"f(1,)", 4},
+		{`"abc`, 0},
+		{`"a\qb"`, 2},
+		{"1 # 2", 2},
+		{"a = b", 2},
+		{")", 0},
+		{"1e", 1},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Parse(%q) = %v, want a *SyntaxError", tt.src, err)
+			continue
+		}
+		if se.Pos != tt.pos {
+			t.Errorf("Parse(%q) failed at %d (%v), want %d", tt.src, se.Pos, err, tt.pos)
+		}
+	}
+}
+
+// TestParseDepth tests that deep nesting fails rather than exhausting the
+// stack
+func TestParseDepth(t *testing.T) {
+	deep := strings.Repeat("(", maxDepth+1) + "1" + strings.Repeat(")", maxDepth+1)
+	if _, err := Parse(deep); err == nil || !strings.Contains(err.Error(), "too deeply") {
+		t.Errorf("Parse of %d parentheses = %v, want too deeply", maxDepth+1, err)
+	}
+	if _, err := Parse(strings.Repeat("- ", 10*maxDepth) + "1"); err == nil {
+		t.Error("Parse of many minus signs succeeded")
+	}
+	ok := strings.Repeat("(", maxDepth/2) + "1" + strings.Repeat(")", maxDepth/2)
+	if _, err := Parse(ok); err != nil {
+		t.Errorf("Parse of %d parentheses = %v", maxDepth/2, err)
+	}
+}
+
+// FuzzParse tests that parsing never panics and that a parsed tree
+// prints as an expression parsing to the same tree
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{"1 + 2 * x", "-2 ** 2", "a ? b : c", `f("x", 'y\n')`, "not a or b", "1.5e-3 % -7"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		n, err := Parse(src)
+		if err != nil {
+			return
+		}
+		again, err := Parse(n.String())
+		if err != nil {
+			t.Fatalf("Parse(%q) of the tree of %q = %v", n.String(), src, err)
+		}
+		if again.String() != n.String() {
+			t.Fatalf("tree of %q printed %s, reparsed %s", src, n, again)
+		}
+	})
+}
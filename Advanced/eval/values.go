@@ -0,0 +1,299 @@
+package eval
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+)
+
+// typeName names the type of a value in error messages
+func typeName(v any) string {
+	switch v.(type) {
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// normalize converts a variable or function result to int64, float64,
+// bool or string
+func normalize(v any) (any, error) {
+	switch v := v.(type) {
+	case int64, float64, bool, string:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint:
+		return uintValue(uint64(v))
+	case uint64:
+		return uintValue(v)
+	case float32:
+		return float64(v), nil
+	}
+	return nil, fmt.Errorf("%w: unsupported value of type %T", ErrType, v)
+}
+
+// uintValue converts an unsigned integer, failing if it exceeds int64
+func uintValue(u uint64) (any, error) {
+	if u > math.MaxInt64 {
+		return nil, fmt.Errorf("%w: %d exceeds int64", ErrOverflow, u)
+	}
+	return int64(u), nil
+}
+
+// toFloat returns a number as a float64
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// unaryOps are the prefix operators
+var unaryOps = map[string]func(any) (any, error){
+	"-": func(v any) (any, error) {
+		switch v := v.(type) {
+		case int64:
+			if v == math.MinInt64 {
+				return nil, fmt.Errorf("%w: -(%d)", ErrOverflow, v)
+			}
+			return -v, nil
+		case float64:
+			return -v, nil
+		}
+		return nil, fmt.Errorf("%w: cannot negate %s", ErrType, typeName(v))
+	},
+	"!": func(v any) (any, error) {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: ! of %s", ErrType, typeName(v))
+		}
+		return !b, nil
+	},
+}
+
+// logical returns && or ||, evaluating the right operand only if the left
+// does not decide the result
+func logical(op string, l, r evalFunc) evalFunc {
+	decides := op == "||"
+	operand := func(f evalFunc, vars map[string]any) (bool, error) {
+		v, err := f(vars)
+		if err != nil {
+			return false, err
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return false, fmt.Errorf("%w: %s of %s", ErrType, op, typeName(v))
+		}
+		return b, nil
+	}
+	return func(vars map[string]any) (any, error) {
+		a, err := operand(l, vars)
+		if err != nil || a == decides {
+			return a, err
+		}
+		return operand(r, vars)
+	}
+}
+
+// binaryOps are the infix operators other than && and ||
+var binaryOps = map[string]func(a, b any) (any, error){
+	"==": func(a, b any) (any, error) { return equal(a, b), nil },
+	"!=": func(a, b any) (any, error) { return !equal(a, b), nil },
+	"<":  ordering(func(c int) bool { return c < 0 }, "<"),
+	"<=": ordering(func(c int) bool { return c <= 0 }, "<="),
+	">":  ordering(func(c int) bool { return c > 0 }, ">"),
+	">=": ordering(func(c int) bool { return c >= 0 }, ">="),
+	"+":  add,
+	"-":  sub,
+	"*":  arith("*"),
+	"/":  arith("/"),
+	"%":  arith("%"),
+	"**": pow,
+}
+
+// equal reports whether two values are equal. Integers and floats compare
+// by value; values of other differing types are unequal.
+func equal(a, b any) bool {
+	if x, ok := a.(bool); ok {
+		y, ok := b.(bool)
+		return ok && x == y
+	}
+	c, ok := compare(a, b)
+	return ok && c == 0
+}
+
+// compare orders two numbers or two strings
+func compare(a, b any) (int, bool) {
+	if x, ok := a.(int64); ok {
+		if y, ok := b.(int64); ok {
+			return cmp.Compare(x, y), true
+		}
+	}
+	if s, ok := a.(string); ok {
+		t, ok := b.(string)
+		return cmp.Compare(s, t), ok
+	}
+	x, okA := toFloat(a)
+	y, okB := toFloat(b)
+	return cmp.Compare(x, y), okA && okB
+}
+
+// ordering returns a comparison operator, defined on two numbers or two
+// strings
+func ordering(holds func(int) bool, op string) func(a, b any) (any, error) {
+	return func(a, b any) (any, error) {
+		c, ok := compare(a, b)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s %s %s", ErrType, typeName(a), op, typeName(b))
+		}
+		return holds(c), nil
+	}
+}
+
+// add adds two numbers or concatenates two strings
+func add(a, b any) (any, error) {
+	if s, ok := a.(string); ok {
+		if t, ok := b.(string); ok {
+			return s + t, nil
+		}
+	}
+	return addNumbers(a, b)
+}
+
+// addNumbers and sub are + on numbers and -
+var addNumbers, sub = arith("+"), arith("-")
+
+// arith returns an arithmetic operator. Two integers give an integer,
+// failing rather than wrapping on overflow, except that / gives a float
+// unless the division is exact. A float operand gives a float.
+func arith(op string) func(a, b any) (any, error) {
+	return func(a, b any) (any, error) {
+		x, okX := a.(int64)
+		y, okY := b.(int64)
+		if okX && okY {
+			return intArith(x, y, op)
+		}
+		f, okA := toFloat(a)
+		g, okB := toFloat(b)
+		if !okA || !okB {
+			return nil, fmt.Errorf("%w: %s %s %s", ErrType, typeName(a), op, typeName(b))
+		}
+		switch op {
+		case "+":
+			return f + g, nil
+		case "-":
+			return f - g, nil
+		case "*":
+			return f * g, nil
+		}
+		if g == 0 {
+			return nil, ErrDivideByZero
+		}
+		if op == "/" {
+			return f / g, nil
+		}
+		return math.Mod(f, g), nil
+	}
+}
+
+// intArith applies an arithmetic operator to two integers
+func intArith(x, y int64, op string) (any, error) {
+	var r int64
+	overflow := false
+	switch op {
+	case "+":
+		r = x + y
+		overflow = (r > x) != (y > 0)
+	case "-":
+		r = x - y
+		overflow = (r < x) != (y > 0)
+	case "*":
+		r = x * y
+		overflow = x != 0 && (r/x != y || (x == -1 && y == math.MinInt64))
+	case "/", "%":
+		if y == 0 {
+			return nil, ErrDivideByZero
+		}
+		if x == math.MinInt64 && y == -1 {
+			if op == "%" {
+				return int64(0), nil
+			}
+			overflow = true
+			break
+		}
+		if op == "%" {
+			r = x % y
+		} else if x%y != 0 {
+			return float64(x) / float64(y), nil
+		} else {
+			r = x / y
+		}
+	}
+	if overflow {
+		return nil, fmt.Errorf("%w: %d %s %d", ErrOverflow, x, op, y)
+	}
+	return r, nil
+}
+
+// pow raises a number to a power: an integer to a non-negative integer
+// power by squaring, failing on overflow, and anything else as floats
+//
+// Time Complexity: O(log n) multiplications for an integer power n
+func pow(a, b any) (any, error) {
+	x, okX := a.(int64)
+	n, okN := b.(int64)
+	if okX && okN && n >= 0 {
+		result := int64(1)
+		for n > 0 {
+			var err error
+			if n&1 == 1 {
+				if result, err = mulChecked(result, x); err != nil {
+					return nil, err
+				}
+			}
+			if n >>= 1; n > 0 {
+				if x, err = mulChecked(x, x); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return result, nil
+	}
+	f, okA := toFloat(a)
+	g, okB := toFloat(b)
+	if !okA || !okB {
+		return nil, fmt.Errorf("%w: %s ** %s", ErrType, typeName(a), typeName(b))
+	}
+	return math.Pow(f, g), nil
+}
+
+// mulChecked multiplies two integers, failing on overflow
+func mulChecked(x, y int64) (int64, error) {
+	r, err := intArith(x, y, "*")
+	if err != nil {
+		return 0, err
+	}
+	return r.(int64), nil
+}
@@ -0,0 +1,233 @@
+// Package eventbus provides an in-process event bus. Handlers subscribe
+// to events by their Go type with Subscribe, and Publish delivers each
+// event to the handlers of its type: in the publisher's goroutine under
+// Sync, returning their errors, or from a queue per event type under
+// Async, so that events of one type reach their handlers in the order
+// published while different types are handled in parallel. Middleware
+// wraps every handler, as Recover, Logging and Metrics do.
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed is returned by Publish once Shutdown has begun
+var ErrClosed = errors.New("event bus closed")
+
+// Mode chooses how Publish delivers events
+type Mode int
+
+// The dispatch modes
+const (
+	Sync  Mode = iota // Run the handlers before Publish returns
+	Async             // Queue the event for the handlers of its type
+)
+
+// DefaultBuffer is the queue size of each event type under Async when
+// Options leave it unset
+const DefaultBuffer = 64
+
+// Event is an event as handlers and middleware see it
+type Event struct {
+	Topic   string    // The name of the event's Go type, as "main.UserCreated"
+	Payload any       // The event itself
+	Time    time.Time // When it was published
+}
+
+// Handler handles an event of any type, as middleware sees it
+type Handler func(ctx context.Context, e Event) error
+
+// Middleware wraps a handler, to act before and after it
+type Middleware func(next Handler) Handler
+
+// Options configures a bus. Zero fields take their defaults.
+type Options struct {
+	// Mode chooses how Publish delivers events
+	Mode Mode
+	// Buffer bounds the events of each type waiting for their handlers
+	// under Async, DefaultBuffer if 0 or less; Publish waits while it is
+	// full
+	Buffer int
+	// Middleware wraps every handler, the first outermost
+	Middleware []Middleware
+	// OnError, if set, is called with each event whose handlers failed
+	// under Async, and their joined errors
+	OnError func(e Event, err error)
+}
+
+// topic holds the handlers of one event type
+type topic struct {
+	name  string
+	subs  atomic.Pointer[[]*Subscription] // Replaced, never changed in place
+	queue chan delivery                   // Nil under Sync
+}
+
+// delivery is an event queued under Async, with the context to handle it
+type delivery struct {
+	ctx   context.Context
+	event Event
+}
+
+// Bus delivers events to the handlers subscribed to their types. It is
+// safe for concurrent use.
+type Bus struct {
+	opts Options
+
+	mu     sync.Mutex // Guards topics and changes to their handlers
+	topics map[reflect.Type]*topic
+
+	// closing guards sending on the queues against their closing:
+	// publishers hold it for reading, and Shutdown takes it for writing
+	closing sync.RWMutex
+	closed  bool
+	quit    chan struct{} // Closed first, to release blocked publishers
+	once    sync.Once
+	wg      sync.WaitGroup // The goroutines draining the queues
+}
+
+// New creates a bus without subscribers
+func New(opts Options) *Bus {
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultBuffer
+	}
+	return &Bus{opts: opts, topics: map[reflect.Type]*topic{}, quit: make(chan struct{})}
+}
+
+// topic returns the topic of typ, creating it if create is set, and
+// starting its queue under Async unless the bus is closed. The caller
+// holds mu.
+func (b *Bus) topic(typ reflect.Type, create bool) *topic {
+	t, ok := b.topics[typ]
+	if ok || !create {
+		return t
+	}
+	t = &topic{name: typ.String()}
+	t.subs.Store(&[]*Subscription{})
+	if b.opts.Mode == Async && !b.closed {
+		t.queue = make(chan delivery, b.opts.Buffer)
+		b.wg.Go(func() {
+			for d := range t.queue {
+				if err := t.deliver(d.ctx, d.event); err != nil && b.opts.OnError != nil {
+					b.opts.OnError(d.event, err)
+				}
+			}
+		})
+	}
+	b.topics[typ] = t
+	return t
+}
+
+// deliver runs the handlers of t, in the order they subscribed, and joins
+// their errors
+func (t *topic) deliver(ctx context.Context, e Event) error {
+	var errs []error
+	for _, s := range *t.subs.Load() {
+		if err := s.handler(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Subscription is a handler subscribed to a bus
+type Subscription struct {
+	bus     *Bus
+	topic   *topic
+	handler Handler
+}
+
+// Subscribe calls handler with every event of type T published from now
+// on, until the subscription is cancelled. Handlers of one type run in the
+// order they subscribed. It is a function rather than a method because
+// methods cannot have type parameters.
+func Subscribe[T any](b *Bus, handler func(ctx context.Context, event T) error) *Subscription {
+	h := Handler(func(ctx context.Context, e Event) error {
+		return handler(ctx, e.Payload.(T))
+	})
+	for _, mw := range slices.Backward(b.opts.Middleware) {
+		h = mw(h)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := &Subscription{bus: b, topic: b.topic(reflect.TypeFor[T](), true), handler: h}
+	subs := append(slices.Clone(*s.topic.subs.Load()), s)
+	s.topic.subs.Store(&subs)
+	return s
+}
+
+// Unsubscribe stops the handler receiving events not yet delivered to it.
+// Unsubscribe may be called more than once.
+func (s *Subscription) Unsubscribe() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	subs := slices.DeleteFunc(slices.Clone(*s.topic.subs.Load()), func(o *Subscription) bool { return o == s })
+	s.topic.subs.Store(&subs)
+}
+
+// Publish delivers event to the handlers of its type T. Under Sync it runs
+// them and returns their joined errors. Under Async it queues the event,
+// waiting while the queue is full until ctx ends, and the handlers get a
+// context with the values of ctx but not its cancellation. An event no
+// handler subscribes to is dropped.
+func Publish[T any](ctx context.Context, b *Bus, event T) error {
+	b.closing.RLock()
+	defer b.closing.RUnlock()
+	if b.closed {
+		return ErrClosed
+	}
+	b.mu.Lock()
+	t := b.topic(reflect.TypeFor[T](), false)
+	b.mu.Unlock()
+	if t == nil || len(*t.subs.Load()) == 0 {
+		return nil
+	}
+	e := Event{Topic: t.name, Payload: event, Time: time.Now()}
+	if t.queue == nil {
+		return t.deliver(ctx, e)
+	}
+	select {
+	case t.queue <- delivery{ctx: context.WithoutCancel(ctx), event: e}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.quit:
+		return ErrClosed
+	}
+}
+
+// Shutdown stops the bus accepting events and waits for those queued to
+// be handled. If ctx ends first, it returns the error of ctx without
+// waiting further, leaving the handlers to finish in the background.
+// Shutdown may be called more than once.
+func (b *Bus) Shutdown(ctx context.Context) error {
+	b.once.Do(func() {
+		close(b.quit)
+		b.closing.Lock()
+		b.mu.Lock()
+		b.closed = true
+		for _, t := range b.topics {
+			if t.queue != nil {
+				close(t.queue)
+			}
+		}
+		b.mu.Unlock()
+		b.closing.Unlock()
+	})
+	drained := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
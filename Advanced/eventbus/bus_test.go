@@ -0,0 +1,226 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// userCreated and orderPlaced are events for the tests
+type (
+	userCreated struct{ ID int }
+	orderPlaced struct{ ID int }
+)
+
+// recorder collects what handlers saw, safe for concurrent use
+type recorder struct {
+	mu   sync.Mutex
+	seen []string
+}
+
+func (r *recorder) add(s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen = append(r.seen, s)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.seen)
+}
+
+// TestSync tests that Sync runs the handlers of the event's type in
+// order and returns their errors
+func TestSync(t *testing.T) {
+	errFull := errors.New("mailbox full")
+	b := New(Options{})
+	var rec recorder
+	Subscribe(b, func(_ context.Context, e userCreated) error {
+		rec.add("audit")
+		return nil
+	})
+	Subscribe(b, func(_ context.Context, e userCreated) error {
+		rec.add("welcome")
+		return errFull
+	})
+	Subscribe(b, func(_ context.Context, e orderPlaced) error {
+		rec.add("order")
+		return nil
+	})
+
+	if err := Publish(context.Background(), b, userCreated{ID: 1}); !errors.Is(err, errFull) {
+		t.Errorf("Publish = %v, want the handler's error", err)
+	}
+	if got := rec.get(); !slices.Equal(got, []string{"audit", "welcome"}) {
+		t.Errorf("handlers ran %v, want audit then welcome", got)
+	}
+	if err := Publish(context.Background(), b, "no subscribers"); err != nil {
+		t.Errorf("Publish without subscribers = %v, want nil", err)
+	}
+}
+
+// TestUnsubscribe tests that a cancelled subscription gets no more events
+func TestUnsubscribe(t *testing.T) {
+	b := New(Options{})
+	count := 0
+	s := Subscribe(b, func(context.Context, userCreated) error {
+		count++
+		return nil
+	})
+	Publish(context.Background(), b, userCreated{})
+	s.Unsubscribe()
+	s.Unsubscribe()
+	Publish(context.Background(), b, userCreated{})
+	if count != 1 {
+		t.Errorf("handler ran %d times, want once before Unsubscribe", count)
+	}
+}
+
+// TestAsyncOrder tests that Async keeps each type's events in order while
+// a slow type does not hold up another
+func TestAsyncOrder(t *testing.T) {
+	b := New(Options{Mode: Async})
+	var users recorder
+	release := make(chan struct{})
+	Subscribe(b, func(_ context.Context, e userCreated) error {
+		if e.ID == 0 {
+			<-release
+		}
+		users.add(string(rune('a' + e.ID)))
+		return nil
+	})
+	orders := make(chan int, 1)
+	Subscribe(b, func(_ context.Context, e orderPlaced) error {
+		orders <- e.ID
+		return nil
+	})
+
+	ctx := context.Background()
+	for i := range 5 {
+		if err := Publish(ctx, b, userCreated{ID: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	Publish(ctx, b, orderPlaced{ID: 7})
+	select {
+	case <-orders:
+	case <-time.After(time.Second):
+		t.Fatal("a blocked user handler held up orders")
+	}
+	close(release)
+	if err := b.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := users.get(); !slices.Equal(got, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("users handled %v, want in published order", got)
+	}
+}
+
+// ctxKey is a context key for the tests
+type ctxKey struct{}
+
+// TestAsyncContext tests that handlers get the publisher's context values
+// but outlive its cancellation, and that errors go to OnError
+func TestAsyncContext(t *testing.T) {
+	errs := make(chan error, 1)
+	b := New(Options{Mode: Async, OnError: func(e Event, err error) {
+		if e.Topic != "eventbus.userCreated" || e.Payload.(userCreated).ID != 3 {
+			t.Errorf("OnError got event %+v", e)
+		}
+		errs <- err
+	}})
+	Subscribe(b, func(ctx context.Context, e userCreated) error {
+		if ctx.Value(ctxKey{}) != "req-1" || ctx.Err() != nil {
+			t.Errorf("handler context has value %v and error %v", ctx.Value(ctxKey{}), ctx.Err())
+		}
+		return errors.New("bounced")
+	})
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "req-1"))
+	Publish(ctx, b, userCreated{ID: 3})
+	cancel()
+	if err := <-errs; err == nil || err.Error() != "bounced" {
+		t.Errorf("OnError got %v", err)
+	}
+	b.Shutdown(context.Background())
+}
+
+// TestBackpressure tests that Publish waits for room in a full queue
+// until its context ends
+func TestBackpressure(t *testing.T) {
+	b := New(Options{Mode: Async, Buffer: 1})
+	release := make(chan struct{})
+	Subscribe(b, func(context.Context, userCreated) error {
+		<-release
+		return nil
+	})
+	ctx := context.Background()
+	Publish(ctx, b, userCreated{ID: 1}) // Taken by the handler
+	Publish(ctx, b, userCreated{ID: 2}) // Fits once the first is taken
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := Publish(timeout, b, userCreated{ID: 3}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Publish on a full queue = %v, want DeadlineExceeded", err)
+	}
+	close(release)
+	b.Shutdown(ctx)
+}
+
+// TestShutdown tests that Shutdown drains the queues, gives up with its
+// context, and refuses new events
+func TestShutdown(t *testing.T) {
+	b := New(Options{Mode: Async})
+	release := make(chan struct{})
+	var handled recorder
+	Subscribe(b, func(_ context.Context, e userCreated) error {
+		<-release
+		handled.add("user")
+		return nil
+	})
+	ctx := context.Background()
+	Publish(ctx, b, userCreated{})
+	Publish(ctx, b, userCreated{})
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := b.Shutdown(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+	if err := Publish(ctx, b, userCreated{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish after Shutdown = %v, want ErrClosed", err)
+	}
+	close(release)
+	if err := b.Shutdown(ctx); err != nil || len(handled.get()) != 2 {
+		t.Errorf("Shutdown = %v after %d events, want both queued events handled", err, len(handled.get()))
+	}
+
+	// Subscribing after Shutdown starts no queue
+	Subscribe(b, func(context.Context, orderPlaced) error { return nil })
+	if err := b.Shutdown(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestConcurrent tests publishing and subscribing at once; run with -race
+func TestConcurrent(t *testing.T) {
+	for _, mode := range []Mode{Sync, Async} {
+		b := New(Options{Mode: mode})
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Go(func() {
+				for i := range 50 {
+					s := Subscribe(b, func(context.Context, userCreated) error { return nil })
+					Publish(context.Background(), b, userCreated{ID: i})
+					s.Unsubscribe()
+				}
+			})
+		}
+		wg.Wait()
+		if err := b.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}
+}
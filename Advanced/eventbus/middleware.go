@@ -0,0 +1,69 @@
+package eventbus
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"hellogolang/Advanced/logx"
+	"hellogolang/Advanced/metrics"
+	"hellogolang/Advanced/syncx"
+)
+
+// Recover returns middleware turning a panicking handler's panic into a
+// syncx.PanicError, so that it fails only its own delivery. Listed first,
+// it also covers the middleware after it.
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, e Event) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = &syncx.PanicError{Value: r, Stack: debug.Stack()}
+				}
+			}()
+			return next(ctx, e)
+		}
+	}
+}
+
+// Logging returns middleware logging each delivery with the logger the
+// context carries, as logx.FromContext finds it: at debug level when the
+// handler succeeds, and with logx.Error when it fails
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, e Event) error {
+			start := time.Now()
+			err := next(ctx, e)
+			elapsed := time.Since(start)
+			if err != nil {
+				logx.Error(ctx, "event handler failed", err, "topic", e.Topic, "elapsed", elapsed)
+			} else {
+				logx.FromContext(ctx).DebugContext(ctx, "event handled", slog.String("topic", e.Topic), slog.Duration("elapsed", elapsed))
+			}
+			return err
+		}
+	}
+}
+
+// Metrics returns middleware recording each delivery in r, labelled
+// bus=name and topic: eventbus_deliveries_total counts deliveries by
+// result, ok or error, and eventbus_handler_seconds times them. Buses
+// registered with the same r share the families and need distinct names.
+func Metrics(r *metrics.Registry, name string) Middleware {
+	deliveries := r.CounterVec("eventbus_deliveries_total", "Events delivered to handlers", "bus", "topic", "result")
+	seconds := r.HistogramVec("eventbus_handler_seconds", "Time spent in event handlers", nil, "bus", "topic")
+	return func(next Handler) Handler {
+		return func(ctx context.Context, e Event) error {
+			start := time.Now()
+			err := next(ctx, e)
+			seconds.With(name, e.Topic).Observe(time.Since(start).Seconds())
+			result := "ok"
+			if err != nil {
+				result = "error"
+			}
+			deliveries.With(name, e.Topic, result).Inc()
+			return err
+		}
+	}
+}
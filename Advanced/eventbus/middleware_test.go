@@ -0,0 +1,117 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"hellogolang/Advanced/logx"
+	"hellogolang/Advanced/metrics"
+	"hellogolang/Advanced/syncx"
+)
+
+// TestMiddlewareOrder tests that the first middleware is outermost
+func TestMiddlewareOrder(t *testing.T) {
+	var rec recorder
+	tag := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, e Event) error {
+				rec.add(name + " in")
+				defer rec.add(name + " out")
+				return next(ctx, e)
+			}
+		}
+	}
+	b := New(Options{Middleware: []Middleware{tag("a"), tag("b")}})
+	Subscribe(b, func(context.Context, userCreated) error {
+		rec.add("handler")
+		return nil
+	})
+	Publish(context.Background(), b, userCreated{})
+	want := "a in,b in,handler,b out,a out"
+	if got := strings.Join(rec.get(), ","); got != want {
+		t.Errorf("ran %s, want %s", got, want)
+	}
+}
+
+// TestRecover tests that a panic fails only its own handler
+func TestRecover(t *testing.T) {
+	b := New(Options{Middleware: []Middleware{Recover()}})
+	ran := false
+	Subscribe(b, func(context.Context, userCreated) error { panic("nil user") })
+	Subscribe(b, func(context.Context, userCreated) error {
+		ran = true
+		return nil
+	})
+	err := Publish(context.Background(), b, userCreated{})
+	var panicErr *syncx.PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "nil user" || len(panicErr.Stack) == 0 {
+		t.Errorf("Publish = %v, want a syncx.PanicError", err)
+	}
+	if !ran {
+		t.Error("the panic stopped the next handler")
+	}
+}
+
+// TestLogging tests that deliveries are logged with the context's logger
+func TestLogging(t *testing.T) {
+	var out bytes.Buffer
+	logger := logx.New(&out, logx.Options{Level: slog.LevelDebug})
+	ctx := logx.WithRequestID(logx.WithLogger(context.Background(), logger), "req-9")
+
+	b := New(Options{Middleware: []Middleware{Logging()}})
+	Subscribe(b, func(_ context.Context, e userCreated) error {
+		if e.ID == 2 {
+			return errors.New("duplicate email")
+		}
+		return nil
+	})
+	Publish(ctx, b, userCreated{ID: 1})
+	Publish(ctx, b, userCreated{ID: 2})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %q, want 2 lines", out.String())
+	}
+	for i, want := range []string{`level=DEBUG msg="event handled" topic=eventbus.userCreated`,
+		`level=ERROR msg="event handler failed"`} {
+		if !strings.Contains(lines[i], want) || !strings.Contains(lines[i], "request_id=req-9") {
+			t.Errorf("line %d = %s, want %s and the request ID", i, lines[i], want)
+		}
+	}
+	if !strings.Contains(lines[1], `error.msg="duplicate email"`) {
+		t.Errorf("error line = %s, want the error", lines[1])
+	}
+}
+
+// TestMetrics tests that deliveries are counted and timed by topic and
+// result
+func TestMetrics(t *testing.T) {
+	r := metrics.NewRegistry()
+	b := New(Options{Middleware: []Middleware{Metrics(r, "app")}})
+	Subscribe(b, func(_ context.Context, e userCreated) error {
+		if e.ID < 0 {
+			return errors.New("invalid id")
+		}
+		return nil
+	})
+	for _, id := range []int{1, 2, -1} {
+		Publish(context.Background(), b, userCreated{ID: id})
+	}
+	var out bytes.Buffer
+	if err := r.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`eventbus_deliveries_total{bus="app",topic="eventbus.userCreated",result="ok"} 2`,
+		`eventbus_deliveries_total{bus="app",topic="eventbus.userCreated",result="error"} 1`,
+		`eventbus_handler_seconds_count{bus="app",topic="eventbus.userCreated"} 3`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics missing %s in:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package framing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"hellogolang/Advanced/bincodec"
+)
+
+// ErrUnknownMessage is returned when encoding a message of a type, or
+// decoding a frame of a Type, that the codec does not know
+var ErrUnknownMessage = errors.New("unknown message type")
+
+// Codec converts between messages and the type and payload of frames
+type Codec interface {
+	// Encode returns the frame type and payload of msg
+	Encode(msg any) (Type, []byte, error)
+	// Decode returns the message held by a frame of type t
+	Decode(t Type, payload []byte) (any, error)
+}
+
+// TypedCodec is a Codec of registered struct types, each with its own
+// frame Type, encoding payloads with a marshal and unmarshal function
+// pair. It is safe for concurrent use.
+type TypedCodec struct {
+	marshal   func(v any) ([]byte, error)
+	unmarshal func(data []byte, v any) error
+
+	mu     sync.RWMutex
+	types  map[Type]reflect.Type
+	frames map[reflect.Type]Type
+}
+
+// NewTypedCodec creates a TypedCodec encoding payloads with marshal and
+// decoding them with unmarshal, which is given a pointer to a new value
+// of the registered type
+func NewTypedCodec(marshal func(v any) ([]byte, error), unmarshal func(data []byte, v any) error) *TypedCodec {
+	return &TypedCodec{
+		marshal:   marshal,
+		unmarshal: unmarshal,
+		types:     make(map[Type]reflect.Type),
+		frames:    make(map[reflect.Type]Type),
+	}
+}
+
+// NewBinaryCodec creates a TypedCodec encoding payloads with bincodec,
+// whose messages number their fields with bin tags
+func NewBinaryCodec() *TypedCodec {
+	return NewTypedCodec(bincodec.Marshal, bincodec.Unmarshal)
+}
+
+// NewJSONCodec creates a TypedCodec encoding payloads as JSON
+func NewJSONCodec() *TypedCodec {
+	return NewTypedCodec(json.Marshal, json.Unmarshal)
+}
+
+// Register registers messages of type T, a struct, as frames of type t.
+// It panics if T is not a struct, t is reserved for control frames, or
+// either is registered already.
+func Register[T any](c *TypedCodec, t Type) {
+	rt := reflect.TypeFor[T]()
+	if rt.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("framing: message type %v, want a struct", rt))
+	}
+	if t >= TypeReserved {
+		panic(fmt.Sprintf("framing: frame type %#x is reserved", t))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev, ok := c.types[t]; ok {
+		panic(fmt.Sprintf("framing: frame type %#x already registered for %v", t, prev))
+	}
+	if prev, ok := c.frames[rt]; ok {
+		panic(fmt.Sprintf("framing: %v already registered as frame type %#x", rt, prev))
+	}
+	c.types[t] = rt
+	c.frames[rt] = t
+}
+
+// Encode returns the frame type and payload of msg, a registered type or
+// a pointer to one
+func (c *TypedCodec) Encode(msg any) (Type, []byte, error) {
+	rt := reflect.TypeOf(msg)
+	if rt != nil && rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	c.mu.RLock()
+	t, ok := c.frames[rt]
+	c.mu.RUnlock()
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: %T", ErrUnknownMessage, msg)
+	}
+	payload, err := c.marshal(msg)
+	if err != nil {
+		return 0, nil, fmt.Errorf("framing: encoding %T: %w", msg, err)
+	}
+	return t, payload, nil
+}
+
+// Decode returns the message held by a frame of type t, a value of the
+// type registered for t
+func (c *TypedCodec) Decode(t Type, payload []byte) (any, error) {
+	c.mu.RLock()
+	rt, ok := c.types[t]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: frame type %#x", ErrUnknownMessage, t)
+	}
+	v := reflect.New(rt)
+	if err := c.unmarshal(payload, v.Interface()); err != nil {
+		return nil, fmt.Errorf("framing: decoding %v: %w", rt, err)
+	}
+	return v.Elem().Interface(), nil
+}
+
+// MessageConn sends and receives messages over a Conn, one per frame
+type MessageConn struct {
+	*Conn
+	codec Codec
+}
+
+// NewMessageConn creates a MessageConn encoding messages with codec
+func NewMessageConn(c *Conn, codec Codec) *MessageConn {
+	return &MessageConn{Conn: c, codec: codec}
+}
+
+// Send encodes msg and writes it as one frame
+func (m *MessageConn) Send(msg any) error {
+	t, payload, err := m.codec.Encode(msg)
+	if err != nil {
+		return err
+	}
+	return m.WriteFrame(t, payload)
+}
+
+// Receive reads the next frame that is not a control frame, as
+// Conn.Receive does, and returns the message it holds. A frame the codec
+// cannot decode fails with its error; the stream stays in step, so
+// receiving may go on.
+func (m *MessageConn) Receive() (any, error) {
+	f, err := m.Conn.Receive()
+	if err != nil {
+		return nil, err
+	}
+	return m.codec.Decode(f.Type, f.Payload)
+}
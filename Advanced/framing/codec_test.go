@@ -0,0 +1,99 @@
+package framing
+
+import (
+	"errors"
+	"testing"
+)
+
+type greet struct {
+	Name string `bin:"1" json:"name"`
+}
+
+type sum struct {
+	Numbers []int64 `bin:"1" json:"numbers"`
+}
+
+// newCodec returns a codec of greet and sum messages
+func newCodec(c *TypedCodec) *TypedCodec {
+	Register[greet](c, 1)
+	Register[sum](c, 2)
+	return c
+}
+
+// TestMessageConn tests exchanging typed messages with both codecs
+func TestMessageConn(t *testing.T) {
+	for name, codec := range map[string]*TypedCodec{
+		"binary": newCodec(NewBinaryCodec()),
+		"json":   newCodec(NewJSONCodec()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			a, b := pipe(t, Options{})
+			ma, mb := NewMessageConn(a, codec), NewMessageConn(b, codec)
+			go func() {
+				ma.Send(greet{Name: "gopher"})
+				ma.Send(&sum{Numbers: []int64{1, 2, 3}})
+			}()
+			msg, err := mb.Receive()
+			if g, ok := msg.(greet); err != nil || !ok || g.Name != "gopher" {
+				t.Errorf("Receive = %#v, %v, want a greet", msg, err)
+			}
+			msg, err = mb.Receive()
+			if s, ok := msg.(sum); err != nil || !ok || len(s.Numbers) != 3 {
+				t.Errorf("Receive = %#v, %v, want a sum", msg, err)
+			}
+		})
+	}
+}
+
+// TestCodecUnknown tests that unregistered types and frames fail
+func TestCodecUnknown(t *testing.T) {
+	codec := newCodec(NewJSONCodec())
+	if _, _, err := codec.Encode(struct{}{}); !errors.Is(err, ErrUnknownMessage) {
+		t.Errorf("Encode of an unknown type = %v, want ErrUnknownMessage", err)
+	}
+	if _, _, err := codec.Encode(nil); !errors.Is(err, ErrUnknownMessage) {
+		t.Errorf("Encode(nil) = %v, want ErrUnknownMessage", err)
+	}
+	if _, err := codec.Decode(9, nil); !errors.Is(err, ErrUnknownMessage) {
+		t.Errorf("Decode of an unknown frame = %v, want ErrUnknownMessage", err)
+	}
+	if _, err := codec.Decode(1, []byte("{bad")); err == nil {
+		t.Error("Decode of a bad payload succeeded")
+	}
+}
+
+// TestReceiveBadPayload tests that a bad message leaves the stream in
+// step
+func TestReceiveBadPayload(t *testing.T) {
+	a, b := pipe(t, Options{})
+	mb := NewMessageConn(b, newCodec(NewJSONCodec()))
+	go func() {
+		a.WriteFrame(1, []byte("{bad"))
+		a.WriteFrame(1, []byte(`{"name":"next"}`))
+	}()
+	if _, err := mb.Receive(); err == nil {
+		t.Error("a bad payload was decoded")
+	}
+	if msg, err := mb.Receive(); err != nil || msg.(greet).Name != "next" {
+		t.Errorf("Receive after a bad payload = %#v, %v", msg, err)
+	}
+}
+
+// TestRegisterPanics tests the registrations refused
+func TestRegisterPanics(t *testing.T) {
+	for name, register := range map[string]func(c *TypedCodec){
+		"not a struct":   func(c *TypedCodec) { Register[int](c, 3) },
+		"reserved type":  func(c *TypedCodec) { Register[struct{ A int }](c, TypePing) },
+		"type reused":    func(c *TypedCodec) { Register[struct{ B int }](c, 1) },
+		"message reused": func(c *TypedCodec) { Register[greet](c, 3) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Register did not panic")
+				}
+			}()
+			register(newCodec(NewJSONCodec()))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package framing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrHeartbeatTimeout is returned by Heartbeat when the peer has sent
+// nothing for too long
+var ErrHeartbeatTimeout = errors.New("heartbeat timed out")
+
+// Options configures a Conn. Zero fields take their defaults.
+type Options struct {
+	// MaxFrameSize bounds the payload of frames read and written,
+	// DefaultMaxFrameSize if 0 or less
+	MaxFrameSize int
+	// Now overrides the clock, for tests
+	Now func() time.Time
+}
+
+// Conn reads and writes frames over a stream. One goroutine may read
+// while any number write: each frame is written whole, under a lock.
+type Conn struct {
+	r    io.Reader
+	w    io.Writer
+	opts Options
+
+	wmu  sync.Mutex
+	wbuf []byte // Reused for each frame written
+
+	lastRead atomic.Int64 // When the last frame was read, in Unix nanoseconds
+}
+
+// NewConn creates a Conn reading and writing frames over rw
+func NewConn(rw io.ReadWriter, opts Options) *Conn {
+	if opts.MaxFrameSize <= 0 {
+		opts.MaxFrameSize = DefaultMaxFrameSize
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	c := &Conn{r: rw, w: rw, opts: opts}
+	c.lastRead.Store(opts.Now().UnixNano())
+	return c
+}
+
+// WriteFrame writes a frame of type t with payload in one Write, failing
+// with ErrFrameTooLarge if payload is longer than MaxFrameSize
+func (c *Conn) WriteFrame(t Type, payload []byte) error {
+	if len(payload) > c.opts.MaxFrameSize {
+		return fmt.Errorf("%w: %d bytes, at most %d", ErrFrameTooLarge, len(payload), c.opts.MaxFrameSize)
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.wbuf = AppendFrame(c.wbuf[:0], Frame{Type: t, Payload: payload})
+	_, err := c.w.Write(c.wbuf)
+	return err
+}
+
+// ReadFrame reads the next frame, whatever its type, as the package
+// function ReadFrame does
+func (c *Conn) ReadFrame() (Frame, error) {
+	f, err := ReadFrame(c.r, c.opts.MaxFrameSize)
+	if err == nil {
+		c.lastRead.Store(c.opts.Now().UnixNano())
+	}
+	return f, err
+}
+
+// Receive reads frames until one is not a control frame, which it
+// returns. It answers each ping with a pong and drops pongs, which have
+// served their purpose by arriving. Unknown control frames are dropped.
+func (c *Conn) Receive() (Frame, error) {
+	for {
+		f, err := c.ReadFrame()
+		if err != nil {
+			return Frame{}, err
+		}
+		if f.Type < TypeReserved {
+			return f, nil
+		}
+		if f.Type == TypePing {
+			if err := c.WriteFrame(TypePong, f.Payload); err != nil {
+				return Frame{}, err
+			}
+		}
+	}
+}
+
+// Ping sends a ping frame, which the peer's Receive answers
+func (c *Conn) Ping() error {
+	return c.WriteFrame(TypePing, nil)
+}
+
+// LastRead returns when the last frame was read, or when the Conn was
+// created if none has been
+func (c *Conn) LastRead() time.Time {
+	return time.Unix(0, c.lastRead.Load())
+}
+
+// Heartbeat sends a ping every interval, returning ErrHeartbeatTimeout
+// once no frame has been read for timeout, the error of a failed ping,
+// or ctx.Err() when ctx ends. It relies on another goroutine reading with
+// Receive or ReadFrame, for the pongs to be read. It is typically run on
+// its own goroutine, closing the stream when it returns an error so the
+// reader fails too.
+func (c *Conn) Heartbeat(ctx context.Context, interval, timeout time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if idle := c.opts.Now().Sub(c.LastRead()); idle > timeout {
+			return fmt.Errorf("%w: nothing read for %v", ErrHeartbeatTimeout, idle.Round(time.Millisecond))
+		}
+		if err := c.Ping(); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package framing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pipe returns the two ends of an in-memory connection as Conns
+func pipe(t *testing.T, opts Options) (*Conn, *Conn) {
+	t.Helper()
+	a, b := net.Pipe()
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return NewConn(a, opts), NewConn(b, opts)
+}
+
+// TestConnWriteFrame tests writing and the size limit on both sides
+func TestConnWriteFrame(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewConn(&buf, Options{MaxFrameSize: 4})
+	if err := c.WriteFrame(7, []byte("toolong")); !errors.Is(err, ErrFrameTooLarge) || buf.Len() != 0 {
+		t.Errorf("oversized write gave %v and wrote %d bytes", err, buf.Len())
+	}
+	if err := c.WriteFrame(7, []byte("ok")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := c.ReadFrame()
+	if err != nil || f.Type != 7 || string(f.Payload) != "ok" {
+		t.Errorf("ReadFrame = %v, %v", f, err)
+	}
+}
+
+// TestConnConcurrentWrites tests that frames written at once are not
+// interleaved
+func TestConnConcurrentWrites(t *testing.T) {
+	a, b := pipe(t, Options{})
+	const writers, each = 8, 50
+	go func() {
+		var wg sync.WaitGroup
+		for w := range writers {
+			wg.Go(func() {
+				payload := bytes.Repeat([]byte{byte(w)}, 100+w)
+				for range each {
+					a.WriteFrame(Type(w), payload)
+				}
+			})
+		}
+		wg.Wait()
+	}()
+	for range writers * each {
+		f, err := b.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := bytes.Repeat([]byte{byte(f.Type)}, 100+int(f.Type)); !bytes.Equal(f.Payload, want) {
+			t.Fatalf("frame of type %d has a corrupted payload", f.Type)
+		}
+	}
+}
+
+// TestReceivePing tests that Receive answers pings and skips control
+// frames
+func TestReceivePing(t *testing.T) {
+	a, b := pipe(t, Options{})
+	go func() {
+		a.WriteFrame(TypePing, []byte("p1"))
+		a.WriteFrame(TypeReserved, nil) // Unknown control frames are dropped
+		a.WriteFrame(1, []byte("data"))
+	}()
+	pong := make(chan Frame, 1)
+	go func() {
+		f, _ := a.ReadFrame()
+		pong <- f
+	}()
+	f, err := b.Receive()
+	if err != nil || f.Type != 1 || string(f.Payload) != "data" {
+		t.Errorf("Receive = %v, %v, want the data frame", f, err)
+	}
+	if p := <-pong; p.Type != TypePong || string(p.Payload) != "p1" {
+		t.Errorf("answer to the ping was %v", p)
+	}
+}
+
+// TestHeartbeat tests that a responsive peer keeps the heartbeat going
+// and a silent one times it out
+func TestHeartbeat(t *testing.T) {
+	a, b := pipe(t, Options{})
+	go func() {
+		for {
+			if _, err := b.Receive(); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		for {
+			if _, err := a.Receive(); err != nil {
+				return
+			}
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := a.Heartbeat(ctx, 5*time.Millisecond, 50*time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("Heartbeat with a live peer = %v, want the context's end", err)
+	}
+	if time.Since(a.LastRead()) > 50*time.Millisecond {
+		t.Error("pongs did not update LastRead")
+	}
+}
+
+// TestHeartbeatTimeout tests that a peer that stops answering is noticed
+func TestHeartbeatTimeout(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var mu sync.Mutex
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	conn, peer := net.Pipe()
+	defer conn.Close()
+	defer peer.Close()
+	c := NewConn(conn, Options{Now: clock})
+	go func() {
+		// The peer reads pings but never answers, and time passes
+		buf := make([]byte, HeaderSize)
+		for {
+			if _, err := peer.Read(buf); err != nil {
+				return
+			}
+			mu.Lock()
+			now = now.Add(time.Second)
+			mu.Unlock()
+		}
+	}()
+	err := c.Heartbeat(context.Background(), time.Millisecond, 3*time.Second)
+	if !errors.Is(err, ErrHeartbeatTimeout) {
+		t.Errorf("Heartbeat = %v, want ErrHeartbeatTimeout", err)
+	}
+}
@@ -0,0 +1,85 @@
+// Package framing splits a byte stream, such as a TCP connection, into
+// frames. Each frame is a 4-byte big-endian payload length, a type byte
+// and the payload:
+//
+//	+--------+--------+--------+--------+--------+------------------+
+//	|       payload length, big-endian  |  type  |  payload ...     |
+//	+--------+--------+--------+--------+--------+------------------+
+//
+// Frames longer than a maximum are refused before their payload is read,
+// so a peer cannot make the reader allocate without bound. A Conn reads
+// and writes frames over an io.ReadWriter, answering ping frames with
+// pongs and sending pings of its own on a Heartbeat; a MessageConn adds a
+// Codec, sending and receiving typed messages, one per frame.
+package framing
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrFrameTooLarge is returned for a frame whose payload is longer than
+// the maximum. After reading one, the stream is out of step and must be
+// closed.
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// HeaderSize is the size of a frame's length and type
+const HeaderSize = 5
+
+// DefaultMaxFrameSize is the default maximum payload length
+const DefaultMaxFrameSize = 1 << 20
+
+// Type says what a frame's payload holds. Types from TypeReserved up are
+// control frames of this package; applications use the others.
+type Type uint8
+
+// The control frame types
+const (
+	// TypeReserved is the first type reserved for control frames
+	TypeReserved Type = 0xF0
+	// TypePing asks the peer to answer with a TypePong frame carrying the
+	// same payload
+	TypePing Type = 0xFE
+	// TypePong answers a TypePing frame
+	TypePong Type = 0xFF
+)
+
+// Frame is a typed payload
+type Frame struct {
+	Type    Type
+	Payload []byte
+}
+
+// AppendFrame appends the encoding of f to dst
+func AppendFrame(dst []byte, f Frame) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(f.Payload)))
+	dst = append(dst, byte(f.Type))
+	return append(dst, f.Payload...)
+}
+
+// ReadFrame reads a frame from r, refusing a payload longer than maxSize
+// with ErrFrameTooLarge. It returns io.EOF if r ends before the frame
+// starts, and io.ErrUnexpectedEOF if it ends within it. The payload is
+// newly allocated.
+func ReadFrame(r io.Reader, maxSize int) (Frame, error) {
+	var header [HeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Frame{}, err
+	}
+	n := binary.BigEndian.Uint32(header[:4])
+	// Secure: the length is checked before allocating for it, so a forged
+	// length cannot exhaust memory
+	if uint64(n) > uint64(max(maxSize, 0)) {
+		return Frame{}, fmt.Errorf("%w: %d bytes, at most %d", ErrFrameTooLarge, n, maxSize)
+	}
+	f := Frame{Type: Type(header[4]), Payload: make([]byte, n)}
+	if _, err := io.ReadFull(r, f.Payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Frame{}, err
+	}
+	return f, nil
+}
@@ -0,0 +1,59 @@
+package framing
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestFrameRoundTrip tests that frames read back as written
+func TestFrameRoundTrip(t *testing.T) {
+	frames := []Frame{
+		{Type: 1, Payload: []byte("hello")},
+		{Type: 2, Payload: []byte{}},
+		{Type: TypePing, Payload: bytes.Repeat([]byte{0xAB}, 300)},
+	}
+	var buf []byte
+	for _, f := range frames {
+		buf = AppendFrame(buf, f)
+	}
+	if want := []byte{0, 0, 0, 5, 1, 'h', 'e', 'l', 'l', 'o'}; !bytes.HasPrefix(buf, want) {
+		t.Errorf("encoding starts %v, want %v", buf[:len(want)], want)
+	}
+	r := bytes.NewReader(buf)
+	for _, want := range frames {
+		got, err := ReadFrame(r, 1024)
+		if err != nil || got.Type != want.Type || !bytes.Equal(got.Payload, want.Payload) {
+			t.Errorf("ReadFrame = %v, %v, want %v", got, err, want)
+		}
+	}
+	if _, err := ReadFrame(r, 1024); err != io.EOF {
+		t.Errorf("ReadFrame at the end = %v, want io.EOF", err)
+	}
+}
+
+// TestReadFrameErrors tests oversized and truncated frames
+func TestReadFrameErrors(t *testing.T) {
+	frame := AppendFrame(nil, Frame{Type: 1, Payload: []byte("0123456789")})
+	if _, err := ReadFrame(bytes.NewReader(frame), 9); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("oversized frame gave %v, want ErrFrameTooLarge", err)
+	}
+	if _, err := ReadFrame(bytes.NewReader(frame), 10); err != nil {
+		t.Errorf("frame at the limit gave %v", err)
+	}
+	// A forged length is refused before anything is allocated for it
+	huge := []byte{0xFF, 0xFF, 0xFF, 0xFF, 1}
+	if _, err := ReadFrame(bytes.NewReader(huge), DefaultMaxFrameSize); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("forged length gave %v, want ErrFrameTooLarge", err)
+	}
+	for _, n := range []int{3, HeaderSize, len(frame) - 1} {
+		if _, err := ReadFrame(bytes.NewReader(frame[:n]), 1024); err != io.ErrUnexpectedEOF {
+			t.Errorf("frame cut at %d bytes gave %v, want io.ErrUnexpectedEOF", n, err)
+		}
+	}
+	if _, err := ReadFrame(strings.NewReader(""), 1024); err != io.EOF {
+		t.Errorf("empty stream gave %v, want io.EOF", err)
+	}
+}
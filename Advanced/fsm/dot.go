@@ -0,0 +1,54 @@
+package fsm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DOT renders the definition in the Graphviz DOT language, as a digraph
+// named name: a point marks the initial state, and a guarded transition's
+// event is labelled with [guard]. Render it with `dot -Tsvg`.
+func (d *Definition[S, E]) DOT(name string) string {
+	return d.dot(name, nil)
+}
+
+// DOT renders the machine's definition as Definition.DOT does, with the
+// current state filled
+func (m *Machine[S, E]) DOT(name string) string {
+	state := m.State()
+	return m.def.dot(name, &state)
+}
+
+// dot renders the definition, filling current if it is not nil
+func (d *Definition[S, E]) dot(name string, current *S) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", strconv.Quote(name))
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box, style=rounded];\n")
+	b.WriteString("\t__start [shape=point];\n")
+	for _, s := range d.states {
+		attrs := ""
+		if current != nil && s == *current {
+			attrs = " [style=\"rounded,filled\", fillcolor=lightblue]"
+		}
+		fmt.Fprintf(&b, "\t%s%s;\n", quote(s), attrs)
+	}
+	fmt.Fprintf(&b, "\t__start -> %s;\n", quote(d.config.Initial))
+	for _, t := range d.config.Transitions {
+		label := fmt.Sprint(t.Event)
+		if t.Guard != nil {
+			label += " [guard]"
+		}
+		for _, from := range t.From {
+			fmt.Fprintf(&b, "\t%s -> %s [label=%s];\n", quote(from), quote(t.To), strconv.Quote(label))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// quote returns v printed as a quoted DOT identifier
+func quote(v any) string {
+	return strconv.Quote(fmt.Sprint(v))
+}
@@ -0,0 +1,31 @@
+package fsm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestDOT tests the rendering of a definition and a machine's state
+func TestDOT(t *testing.T) {
+	d := mustDefine(t, orderConfig(func() bool { return true }))
+	m := d.New()
+	m.Fire(context.Background(), pay)
+
+	got := m.DOT("order")
+	for _, want := range []string{
+		`digraph "order" {`,
+		`__start -> "pending";`,
+		`"paid" [style="rounded,filled", fillcolor=lightblue];`,
+		`"paid" -> "shipped" [label="ship [guard]"];`,
+		`"pending" -> "cancelled" [label="cancel"];`,
+		`"paid" -> "cancelled" [label="cancel"];`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("DOT missing %s in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(d.DOT("order"), "filled") {
+		t.Error("a definition's DOT fills a current state")
+	}
+}
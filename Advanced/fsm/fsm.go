@@ -0,0 +1,167 @@
+// Package fsm provides finite state machines for workflows. A Definition
+// declares the states, the events that move between them, guards that may
+// refuse a transition, and actions run on leaving a state, on the
+// transition itself and on entering the next state. Many Machines, one
+// per order or job, share a Definition, each starting in the initial
+// state or one restored from storage.
+//
+// A Machine runs one transition at a time, in the order events are fired,
+// whether by Fire, which waits for the transition, or FireAsync, which
+// returns a future of it. Waiting for a turn and the actions themselves
+// honor the caller's context. DOT renders a definition, and a machine's
+// current state, for Graphviz.
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrDefinition is wrapped by the errors of Define
+	ErrDefinition = errors.New("invalid state machine definition")
+	// ErrInvalidEvent is returned for an event the current state has no
+	// transition for
+	ErrInvalidEvent = errors.New("event not allowed in state")
+	// ErrGuardRejected is returned when every transition for an event was
+	// refused by its guard
+	ErrGuardRejected = errors.New("transition refused by guard")
+)
+
+// Change describes a transition as it happens
+type Change[S, E comparable] struct {
+	From  S
+	To    S
+	Event E
+}
+
+// Action runs as part of a transition. An error stops the transition,
+// leaving the machine in its From state.
+type Action[S, E comparable] func(ctx context.Context, c Change[S, E]) error
+
+// Guard reports whether a transition may happen
+type Guard[S, E comparable] func(ctx context.Context, c Change[S, E]) bool
+
+// Transition moves the machine from any of From to To on Event
+type Transition[S, E comparable] struct {
+	From  []S
+	Event E
+	To    S
+	// Guard, if set, must allow the transition. Of several transitions
+	// for the same state and event, the first allowed is taken.
+	Guard Guard[S, E]
+	// Action, if set, runs between leaving From and entering To
+	Action Action[S, E]
+}
+
+// State holds the actions of a state
+type State[S, E comparable] struct {
+	// OnExit, if set, runs first when a transition leaves the state
+	OnExit Action[S, E]
+	// OnEnter, if set, runs last when a transition enters the state
+	OnEnter Action[S, E]
+}
+
+// Config declares a state machine
+type Config[S, E comparable] struct {
+	// Initial is the state new machines start in
+	Initial S
+	// States holds the actions of the states that have any
+	States map[S]State[S, E]
+	// Transitions lists every transition, in order of precedence
+	Transitions []Transition[S, E]
+	// OnTransition, if set, is called after every completed transition
+	OnTransition func(ctx context.Context, c Change[S, E])
+}
+
+// key is a state and an event
+type key[S, E comparable] struct {
+	state S
+	event E
+}
+
+// Definition is a validated state machine declaration, shared by the
+// machines created from it. It is safe for concurrent use.
+type Definition[S, E comparable] struct {
+	config Config[S, E]
+	states []S                               // In order of first mention
+	table  map[key[S, E]][]*Transition[S, E] // Candidates in order
+}
+
+// Define validates config and returns its definition. Each transition
+// needs at least one From state, and an unguarded transition must be the
+// last for its state and event, since any after it could never be taken.
+func Define[S, E comparable](config Config[S, E]) (*Definition[S, E], error) {
+	d := &Definition[S, E]{config: config, table: map[key[S, E]][]*Transition[S, E]{}}
+	d.addState(config.Initial)
+	for i := range config.Transitions {
+		t := &config.Transitions[i]
+		if len(t.From) == 0 {
+			return nil, fmt.Errorf("%w: transition %d on %v has no From state", ErrDefinition, i, t.Event)
+		}
+		for _, from := range t.From {
+			d.addState(from)
+			k := key[S, E]{from, t.Event}
+			if prev := d.table[k]; len(prev) > 0 && prev[len(prev)-1].Guard == nil {
+				return nil, fmt.Errorf("%w: %v on %v follows an unguarded transition", ErrDefinition, from, t.Event)
+			}
+			d.table[k] = append(d.table[k], t)
+		}
+		d.addState(t.To)
+	}
+	return d, nil
+}
+
+// addState records s if it is new
+func (d *Definition[S, E]) addState(s S) {
+	if !slices.Contains(d.states, s) {
+		d.states = append(d.states, s)
+	}
+}
+
+// States returns every state mentioned, the initial one first
+func (d *Definition[S, E]) States() []S {
+	return slices.Clone(d.states)
+}
+
+// Events returns the events state has transitions for, in declaration
+// order, whether or not their guards would allow them
+func (d *Definition[S, E]) Events(state S) []E {
+	var events []E
+	for _, t := range d.config.Transitions {
+		if slices.Contains(t.From, state) && !slices.Contains(events, t.Event) {
+			events = append(events, t.Event)
+		}
+	}
+	return events
+}
+
+// New creates a machine in the initial state
+func (d *Definition[S, E]) New() *Machine[S, E] {
+	return d.NewAt(d.config.Initial)
+}
+
+// NewAt creates a machine in state, as when restoring one from storage.
+// No OnEnter action runs.
+func (d *Definition[S, E]) NewAt(state S) *Machine[S, E] {
+	tail := make(chan struct{})
+	close(tail)
+	return &Machine[S, E]{def: d, state: state, tail: tail}
+}
+
+// choose returns the first transition for event from state that its
+// guard allows
+func (d *Definition[S, E]) choose(ctx context.Context, state S, event E) (*Transition[S, E], error) {
+	candidates := d.table[key[S, E]{state, event}]
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: %v in %v", ErrInvalidEvent, event, state)
+	}
+	for _, t := range candidates {
+		if t.Guard == nil || t.Guard(ctx, Change[S, E]{From: state, To: t.To, Event: event}) {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %v in %v", ErrGuardRejected, event, state)
+}
@@ -0,0 +1,77 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// The states and events of an order, for the tests
+type (
+	state string
+	event string
+)
+
+const (
+	pending   state = "pending"
+	paid      state = "paid"
+	shipped   state = "shipped"
+	delivered state = "delivered"
+	cancelled state = "cancelled"
+
+	pay     event = "pay"
+	ship    event = "ship"
+	deliver event = "deliver"
+	cancel  event = "cancel"
+)
+
+// orderConfig returns the order workflow: cancellable until shipped, and
+// shippable only while inStock reports true
+func orderConfig(inStock func() bool) Config[state, event] {
+	return Config[state, event]{
+		Initial: pending,
+		Transitions: []Transition[state, event]{
+			{From: []state{pending}, Event: pay, To: paid},
+			{From: []state{paid}, Event: ship, To: shipped,
+				Guard: func(context.Context, Change[state, event]) bool { return inStock() }},
+			{From: []state{shipped}, Event: deliver, To: delivered},
+			{From: []state{pending, paid}, Event: cancel, To: cancelled},
+		},
+	}
+}
+
+// TestDefine tests the states and events a definition reports
+func TestDefine(t *testing.T) {
+	d, err := Define(orderConfig(func() bool { return true }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.States(); !slices.Equal(got, []state{pending, paid, shipped, delivered, cancelled}) {
+		t.Errorf("States = %v", got)
+	}
+	if got := d.Events(paid); !slices.Equal(got, []event{ship, cancel}) {
+		t.Errorf("Events(paid) = %v, want ship and cancel", got)
+	}
+	if got := d.Events(delivered); len(got) != 0 {
+		t.Errorf("Events(delivered) = %v, want none", got)
+	}
+}
+
+// TestDefineErrors tests that unusable definitions are refused
+func TestDefineErrors(t *testing.T) {
+	configs := map[string]Config[state, event]{
+		"no From": {Initial: pending, Transitions: []Transition[state, event]{
+			{Event: pay, To: paid},
+		}},
+		"shadowed": {Initial: pending, Transitions: []Transition[state, event]{
+			{From: []state{pending}, Event: pay, To: paid},
+			{From: []state{pending}, Event: pay, To: cancelled},
+		}},
+	}
+	for name, config := range configs {
+		if _, err := Define(config); !errors.Is(err, ErrDefinition) {
+			t.Errorf("%s: Define error = %v, want ErrDefinition", name, err)
+		}
+	}
+}
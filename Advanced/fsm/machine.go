@@ -0,0 +1,110 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"hellogolang/Advanced/future"
+)
+
+// Machine is a state machine of a Definition. It is safe for concurrent
+// use; transitions run one at a time, in the order fired.
+type Machine[S, E comparable] struct {
+	def *Definition[S, E]
+
+	mu    sync.Mutex
+	state S
+	tail  chan struct{} // Closed once the last transition queued is done
+}
+
+// State returns the current state
+func (m *Machine[S, E]) State() S {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Can reports whether the current state has a transition for event that
+// its guard allows
+func (m *Machine[S, E]) Can(ctx context.Context, event E) bool {
+	_, err := m.def.choose(ctx, m.State(), event)
+	return err == nil
+}
+
+// queue takes a turn for a transition, returning the channel closed when
+// the one before is done, and the one to close when this one is
+func (m *Machine[S, E]) queue() (prev, done chan struct{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prev, done = m.tail, make(chan struct{})
+	m.tail = done
+	return prev, done
+}
+
+// Fire runs the transition for event from the current state, once the
+// transitions fired before it are done, and returns the new state. It
+// returns the current state with ErrInvalidEvent or ErrGuardRejected if
+// no transition is allowed, with the error of an action that failed, or
+// with the error of ctx if it ends while waiting for its turn.
+func (m *Machine[S, E]) Fire(ctx context.Context, event E) (S, error) {
+	prev, done := m.queue()
+	return m.run(ctx, event, prev, done)
+}
+
+// FireAsync queues the transition for event, as Fire would run it, and
+// returns the future of the new state. Transitions fired asynchronously
+// run in the order FireAsync was called; cancelling the future cancels
+// the context of the transition.
+func (m *Machine[S, E]) FireAsync(ctx context.Context, event E) *future.Future[S] {
+	prev, done := m.queue()
+	return future.Go(ctx, func(ctx context.Context) (S, error) {
+		return m.run(ctx, event, prev, done)
+	})
+}
+
+// run waits for prev, then runs the transition and closes done
+func (m *Machine[S, E]) run(ctx context.Context, event E, prev, done chan struct{}) (S, error) {
+	select {
+	case <-prev:
+	case <-ctx.Done():
+		// Pass the turn on once it comes, without taking it
+		go func() {
+			<-prev
+			close(done)
+		}()
+		return m.State(), ctx.Err()
+	}
+	defer close(done)
+	return m.transition(ctx, event)
+}
+
+// transition moves the machine on event, running the actions: OnExit of
+// the current state, the transition's Action, then OnEnter of the next.
+// Only this transition runs, so the state cannot change underneath it.
+func (m *Machine[S, E]) transition(ctx context.Context, event E) (S, error) {
+	from := m.State()
+	if err := ctx.Err(); err != nil {
+		return from, err
+	}
+	t, err := m.def.choose(ctx, from, event)
+	if err != nil {
+		return from, err
+	}
+	c := Change[S, E]{From: from, To: t.To, Event: event}
+	for _, action := range []Action[S, E]{m.def.config.States[from].OnExit, t.Action, m.def.config.States[t.To].OnEnter} {
+		if action == nil {
+			continue
+		}
+		if err := action(ctx, c); err != nil {
+			return from, fmt.Errorf("%v -> %v on %v: %w", from, t.To, event, err)
+		}
+	}
+	m.mu.Lock()
+	m.state = t.To
+	m.mu.Unlock()
+	if m.def.config.OnTransition != nil {
+		m.def.config.OnTransition(ctx, c)
+	}
+	return t.To, nil
+}
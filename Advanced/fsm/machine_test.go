@@ -0,0 +1,183 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"hellogolang/Advanced/future"
+)
+
+// mustDefine defines config or fails t
+func mustDefine(t *testing.T, config Config[state, event]) *Definition[state, event] {
+	t.Helper()
+	d, err := Define(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// TestFire tests transitions, guards and events the state does not allow
+func TestFire(t *testing.T) {
+	inStock := false
+	m := mustDefine(t, orderConfig(func() bool { return inStock })).New()
+	ctx := context.Background()
+
+	if s, err := m.Fire(ctx, ship); !errors.Is(err, ErrInvalidEvent) || s != pending {
+		t.Errorf("ship while pending = %v, %v, want ErrInvalidEvent", s, err)
+	}
+	if s, err := m.Fire(ctx, pay); err != nil || s != paid {
+		t.Errorf("pay = %v, %v, want paid", s, err)
+	}
+	if m.Can(ctx, ship) {
+		t.Error("Can ship while out of stock")
+	}
+	if s, err := m.Fire(ctx, ship); !errors.Is(err, ErrGuardRejected) || s != paid {
+		t.Errorf("ship out of stock = %v, %v, want ErrGuardRejected", s, err)
+	}
+	inStock = true
+	for _, e := range []event{ship, deliver} {
+		if _, err := m.Fire(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.State() != delivered || m.Can(ctx, cancel) {
+		t.Errorf("state %v, want delivered and not cancellable", m.State())
+	}
+}
+
+// TestGuardPrecedence tests that the first allowed transition is taken
+func TestGuardPrecedence(t *testing.T) {
+	large := true
+	d := mustDefine(t, Config[state, event]{
+		Initial: paid,
+		Transitions: []Transition[state, event]{
+			{From: []state{paid}, Event: ship, To: pending,
+				Guard: func(context.Context, Change[state, event]) bool { return large }},
+			{From: []state{paid}, Event: ship, To: shipped},
+		},
+	})
+	if s, _ := d.New().Fire(context.Background(), ship); s != pending {
+		t.Errorf("large order went to %v, want back to pending for review", s)
+	}
+	large = false
+	if s, _ := d.New().Fire(context.Background(), ship); s != shipped {
+		t.Errorf("small order went to %v, want shipped", s)
+	}
+}
+
+// TestActions tests the order of the actions and that a failed one
+// leaves the state unchanged
+func TestActions(t *testing.T) {
+	var log []string
+	errDeclined := errors.New("card declined")
+	declined := true
+	record := func(what string) Action[state, event] {
+		return func(_ context.Context, c Change[state, event]) error {
+			log = append(log, fmt.Sprintf("%s %s->%s", what, c.From, c.To))
+			if what == "charge" && declined {
+				return errDeclined
+			}
+			return nil
+		}
+	}
+	config := orderConfig(func() bool { return true })
+	config.Transitions[0].Action = record("charge")
+	config.States = map[state]State[state, event]{
+		pending: {OnExit: record("exit")},
+		paid:    {OnEnter: record("enter")},
+	}
+	var changes []Change[state, event]
+	config.OnTransition = func(_ context.Context, c Change[state, event]) {
+		changes = append(changes, c)
+	}
+	m := mustDefine(t, config).New()
+
+	if s, err := m.Fire(context.Background(), pay); !errors.Is(err, errDeclined) || s != pending {
+		t.Errorf("pay with a declined card = %v, %v, want pending and the action's error", s, err)
+	}
+	declined = false
+	if s, err := m.Fire(context.Background(), pay); err != nil || s != paid {
+		t.Errorf("pay = %v, %v, want paid", s, err)
+	}
+	want := []string{"exit pending->paid", "charge pending->paid", "exit pending->paid", "charge pending->paid", "enter pending->paid"}
+	if !slices.Equal(log, want) {
+		t.Errorf("actions ran %v, want %v", log, want)
+	}
+	if len(changes) != 1 || changes[0] != (Change[state, event]{pending, paid, pay}) {
+		t.Errorf("OnTransition saw %v, want the one completed transition", changes)
+	}
+}
+
+// TestFireAsync tests that asynchronous transitions run in the order
+// fired, and that one giving up its turn passes it on
+func TestFireAsync(t *testing.T) {
+	release := make(chan struct{})
+	config := orderConfig(func() bool { return true })
+	config.Transitions[0].Action = func(context.Context, Change[state, event]) error {
+		<-release // Hold the first transition so the others queue
+		return nil
+	}
+	m := mustDefine(t, config).New()
+	ctx := context.Background()
+
+	first := m.FireAsync(ctx, pay)
+	timeout, stop := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer stop()
+	gaveUp := m.FireAsync(timeout, cancel)
+	rest := []*future.Future[state]{m.FireAsync(ctx, ship), m.FireAsync(ctx, deliver)}
+	if _, err := gaveUp.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("cancel waiting past its deadline = %v, want DeadlineExceeded", err)
+	}
+	close(release)
+	if s, err := first.Await(ctx); err != nil || s != paid {
+		t.Errorf("pay = %v, %v", s, err)
+	}
+	for i, want := range []state{shipped, delivered} {
+		if s, err := rest[i].Await(ctx); err != nil || s != want {
+			t.Errorf("transition %d = %v, %v, want %v", i, s, err, want)
+		}
+	}
+}
+
+// TestConcurrentFire tests that concurrent transitions do not interleave;
+// run with -race
+func TestConcurrentFire(t *testing.T) {
+	d := mustDefine(t, Config[state, event]{
+		Initial: pending,
+		Transitions: []Transition[state, event]{
+			{From: []state{pending}, Event: pay, To: paid},
+			{From: []state{paid}, Event: cancel, To: pending},
+		},
+	})
+	m := d.New()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok := 0
+	for range 8 {
+		wg.Go(func() {
+			for range 50 {
+				for _, e := range []event{pay, cancel} {
+					if _, err := m.Fire(context.Background(), e); err == nil {
+						mu.Lock()
+						ok++
+						mu.Unlock()
+					}
+				}
+			}
+		})
+	}
+	wg.Wait()
+	want := pending
+	if ok%2 == 1 {
+		want = paid
+	}
+	if m.State() != want {
+		t.Errorf("state %v after %d transitions, want %v", m.State(), ok, want)
+	}
+}
@@ -0,0 +1,172 @@
+package future
+
+import (
+	"errors"
+	"time"
+)
+
+// Then returns a future of fn applied to the value of f once f resolves,
+// or rejected with the error of f if it is rejected. It is a function
+// rather than a method because methods cannot have type parameters.
+// Cancelling the returned future cancels f.
+func Then[T, U any](f *Future[T], fn func(T) (U, error)) *Future[U] {
+	out := newFuture[U](f.Cancel)
+	go func() {
+		select {
+		case <-f.done:
+		case <-out.done:
+			return
+		}
+		if f.err != nil {
+			out.settle(*new(U), f.err)
+			return
+		}
+		out.settle(call(func() (U, error) { return fn(f.value) }))
+	}()
+	return out
+}
+
+// Catch returns a future with the value of f if it resolves, or of fn
+// applied to its error if it is rejected, so fn may recover with a
+// fallback or return a different error. Cancelling the returned future
+// cancels f.
+func (f *Future[T]) Catch(fn func(error) (T, error)) *Future[T] {
+	out := newFuture[T](f.Cancel)
+	go func() {
+		select {
+		case <-f.done:
+		case <-out.done:
+			return
+		}
+		if f.err == nil {
+			out.settle(f.value, nil)
+			return
+		}
+		out.settle(call(func() (T, error) { return fn(f.err) }))
+	}()
+	return out
+}
+
+// cancelAll cancels every future in fs
+func cancelAll[T any](fs []*Future[T]) func() {
+	return func() {
+		for _, f := range fs {
+			f.Cancel()
+		}
+	}
+}
+
+// settled returns a channel receiving the index of each future in fs as
+// it settles, stopping early once stop is closed
+func settled[T any](fs []*Future[T], stop <-chan struct{}) <-chan int {
+	indexes := make(chan int, len(fs))
+	for i, f := range fs {
+		go func() {
+			select {
+			case <-f.done:
+				indexes <- i
+			case <-stop:
+			}
+		}()
+	}
+	return indexes
+}
+
+// All returns a future of the values of fs in order, once all resolve. It
+// is rejected with the error of the first future rejected, and the others
+// are then cancelled. Cancelling the returned future cancels them all.
+func All[T any](fs ...*Future[T]) *Future[[]T] {
+	out := newFuture[[]T](cancelAll(fs))
+	go func() {
+		indexes := settled(fs, out.done)
+		for range fs {
+			var i int
+			select {
+			case i = <-indexes:
+			case <-out.done:
+				return
+			}
+			if err := fs[i].err; err != nil {
+				out.settle(nil, err)
+				cancelAll(fs)()
+				return
+			}
+		}
+		values := make([]T, len(fs))
+		for i, f := range fs {
+			values[i] = f.value
+		}
+		out.settle(values, nil)
+	}()
+	return out
+}
+
+// Any returns a future with the value of the first of fs to resolve, then
+// cancelling the rest. If all are rejected, it is rejected with their
+// errors joined in the order they failed. Cancelling the returned future
+// cancels them all.
+func Any[T any](fs ...*Future[T]) *Future[T] {
+	if len(fs) == 0 {
+		return Rejected[T](ErrNoFutures)
+	}
+	out := newFuture[T](cancelAll(fs))
+	go func() {
+		indexes := settled(fs, out.done)
+		errs := []error{}
+		for range fs {
+			var i int
+			select {
+			case i = <-indexes:
+			case <-out.done:
+				return
+			}
+			if fs[i].err == nil {
+				out.settle(fs[i].value, nil)
+				cancelAll(fs)()
+				return
+			}
+			errs = append(errs, fs[i].err)
+		}
+		out.settle(*new(T), errors.Join(errs...))
+	}()
+	return out
+}
+
+// Race returns a future settled like the first of fs to settle, resolved
+// or rejected, then cancelling the rest. Cancelling the returned future
+// cancels them all.
+func Race[T any](fs ...*Future[T]) *Future[T] {
+	if len(fs) == 0 {
+		return Rejected[T](ErrNoFutures)
+	}
+	out := newFuture[T](cancelAll(fs))
+	go func() {
+		select {
+		case i := <-settled(fs, out.done):
+			out.settle(fs[i].value, fs[i].err)
+			cancelAll(fs)()
+		case <-out.done:
+		}
+	}()
+	return out
+}
+
+// WithTimeout returns a future settled like f, unless f takes longer than
+// d, in which case it is rejected with ErrTimeout and f is cancelled.
+// Cancelling the returned future cancels f.
+func WithTimeout[T any](f *Future[T], d time.Duration) *Future[T] {
+	out := newFuture[T](f.Cancel)
+	go func() {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-f.done:
+			out.settle(f.value, f.err)
+		case <-timer.C:
+			out.settle(*new(T), ErrTimeout)
+			f.Cancel()
+		case <-out.done:
+		}
+	}()
+	return out
+}
@@ -0,0 +1,119 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestThenCatch tests chaining on success and recovery from failure
+func TestThenCatch(t *testing.T) {
+	length := Then(Resolved("hello"), func(s string) (int, error) { return len(s), nil })
+	text := Then(length, func(n int) (string, error) { return strconv.Itoa(n * 2), nil })
+	if v, err := await(t, text); v != "10" || err != nil {
+		t.Errorf("chain = %q, %v, want 10", v, err)
+	}
+
+	called := false
+	skipped := Then(Rejected[int](errFailed), func(n int) (int, error) {
+		called = true
+		return n, nil
+	})
+	recovered := skipped.Catch(func(err error) (int, error) {
+		if err != errFailed {
+			return 0, err
+		}
+		return -1, nil
+	})
+	if v, err := await(t, recovered); v != -1 || err != nil || called {
+		t.Errorf("recovered = %d, %v (Then called: %v), want -1", v, err, called)
+	}
+	if v, _ := await(t, Resolved(5).Catch(func(error) (int, error) { return 0, nil })); v != 5 {
+		t.Errorf("Catch of a resolved future = %d, want 5", v)
+	}
+
+	// Cancelling the end of a chain cancels its start
+	slow := after(time.Hour, 1)
+	Then(slow, func(n int) (int, error) { return n, nil }).Cancel()
+	if _, err := await(t, slow); !errors.Is(err, context.Canceled) {
+		t.Errorf("start of a cancelled chain error = %v, want context.Canceled", err)
+	}
+}
+
+// TestAll tests that All collects values in order, and fails fast,
+// cancelling the others
+func TestAll(t *testing.T) {
+	all := All(after(3*time.Millisecond, 1), Resolved(2), after(time.Millisecond, 3))
+	if v, err := await(t, all); !slices.Equal(v, []int{1, 2, 3}) || err != nil {
+		t.Errorf("All = %v, %v, want [1 2 3]", v, err)
+	}
+	slow := after(time.Hour, 1)
+	if _, err := await(t, All(slow, failAfter[int](time.Millisecond))); err != errFailed {
+		t.Errorf("All with a failure error = %v, want failed", err)
+	}
+	if _, err := await(t, slow); !errors.Is(err, context.Canceled) {
+		t.Errorf("other future after All failed error = %v, want context.Canceled", err)
+	}
+	if v, err := await(t, All[int]()); len(v) != 0 || err != nil {
+		t.Errorf("All of none = %v, %v, want empty", v, err)
+	}
+}
+
+// TestAny tests that Any takes the first success and joins the errors if
+// all fail
+func TestAny(t *testing.T) {
+	slow := after(time.Hour, 1)
+	if v, err := await(t, Any(failAfter[int](0), slow, after(2*time.Millisecond, 2))); v != 2 || err != nil {
+		t.Errorf("Any = %d, %v, want 2", v, err)
+	}
+	if _, err := await(t, slow); !errors.Is(err, context.Canceled) {
+		t.Errorf("loser of Any error = %v, want context.Canceled", err)
+	}
+	errOther := errors.New("other")
+	_, err := await(t, Any(failAfter[int](0), Rejected[int](errOther)))
+	if !errors.Is(err, errFailed) || !errors.Is(err, errOther) {
+		t.Errorf("Any with all failing error = %v, want both errors", err)
+	}
+	if _, err := await(t, Any[int]()); !errors.Is(err, ErrNoFutures) {
+		t.Errorf("Any of none error = %v, want ErrNoFutures", err)
+	}
+}
+
+// TestRace tests that Race settles like the first future, even a failure
+func TestRace(t *testing.T) {
+	if _, err := await(t, Race(after(time.Hour, 1), failAfter[int](time.Millisecond))); err != errFailed {
+		t.Errorf("Race won by a failure error = %v, want failed", err)
+	}
+	if v, _ := await(t, Race(after(time.Hour, "slow"), after(time.Millisecond, "fast"))); v != "fast" {
+		t.Errorf("Race = %q, want fast", v)
+	}
+	if _, err := await(t, Race[int]()); !errors.Is(err, ErrNoFutures) {
+		t.Errorf("Race of none error = %v, want ErrNoFutures", err)
+	}
+}
+
+// TestWithTimeout tests that a slow future times out and is cancelled,
+// and a fast one is unaffected
+func TestWithTimeout(t *testing.T) {
+	slow := after(time.Hour, 1)
+	if _, err := await(t, WithTimeout(slow, time.Millisecond)); !errors.Is(err, ErrTimeout) {
+		t.Errorf("WithTimeout of a slow future error = %v, want ErrTimeout", err)
+	}
+	if _, err := await(t, slow); !errors.Is(err, context.Canceled) {
+		t.Errorf("timed out future error = %v, want context.Canceled", err)
+	}
+	if v, err := await(t, WithTimeout(Resolved(7), time.Hour)); v != 7 || err != nil {
+		t.Errorf("WithTimeout of a settled future = %d, %v, want 7", v, err)
+	}
+}
+
+// BenchmarkThen measures chaining a step onto a resolved future
+func BenchmarkThen(b *testing.B) {
+	ctx := context.Background()
+	for b.Loop() {
+		Then(Resolved(1), func(n int) (int, error) { return n + 1, nil }).Await(ctx)
+	}
+}
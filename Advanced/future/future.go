@@ -0,0 +1,155 @@
+// Package future provides futures: values that a computation running
+// elsewhere settles later, either resolved with a result or rejected with
+// an error. Go starts a computation and returns its Future; a Promise is
+// settled by hand. Futures compose: Then and Catch chain steps on success
+// and failure, All waits for every future, Any for the first success and
+// Race for the first to settle, and WithTimeout bounds one.
+//
+// Cancelling a future rejects it with context.Canceled and cancels the
+// context of the computation behind it, including, for a composed future,
+// the futures it was built from. Combinators cancel the inputs they no
+// longer need, such as the losers of a Race.
+package future
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+
+	"hellogolang/Advanced/syncx"
+)
+
+var (
+	// ErrNoFutures rejects Any and Race called without futures
+	ErrNoFutures = errors.New("no futures")
+	// ErrTimeout rejects a future from WithTimeout that did not settle in
+	// time
+	ErrTimeout = errors.New("future timed out")
+)
+
+// Future is a result of type T that becomes available once settled. It
+// settles exactly once; later attempts are ignored.
+type Future[T any] struct {
+	mu      sync.Mutex
+	done    chan struct{}
+	settled bool
+	value   T
+	err     error
+	cancel  func() // Cancels the computation behind the future, if any
+}
+
+// newFuture creates an unsettled future, cancelling the computation
+// behind it with cancel
+func newFuture[T any](cancel func()) *Future[T] {
+	return &Future[T]{done: make(chan struct{}), cancel: cancel}
+}
+
+// settle settles the future unless it already is, and reports whether it
+// did
+func (f *Future[T]) settle(value T, err error) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.settled {
+		return false
+	}
+	f.settled, f.value, f.err = true, value, err
+	close(f.done)
+	return true
+}
+
+// Resolved returns a future already resolved with value
+func Resolved[T any](value T) *Future[T] {
+	f := newFuture[T](nil)
+	f.settle(value, nil)
+	return f
+}
+
+// Rejected returns a future already rejected with err
+func Rejected[T any](err error) *Future[T] {
+	f := newFuture[T](nil)
+	f.settle(*new(T), err)
+	return f
+}
+
+// Go runs fn in a new goroutine and returns the future of its result.
+// fn gets a context derived from ctx that is cancelled when the future is
+// cancelled. A panic in fn rejects the future with a syncx.PanicError.
+func Go[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) *Future[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	f := newFuture[T](cancel)
+	go func() {
+		defer cancel()
+		f.settle(call(func() (T, error) { return fn(ctx) }))
+	}()
+	return f
+}
+
+// call calls fn, turning a panic into a syncx.PanicError
+func call[T any](fn func() (T, error)) (value T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			value, err = *new(T), &syncx.PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// Done returns a channel closed once the future settles
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Await blocks until the future settles and returns its result, or
+// returns the error of ctx if it ends first. The future itself is not
+// cancelled by ctx ending.
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	default:
+	}
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		return *new(T), ctx.Err()
+	}
+}
+
+// Cancel rejects the future with context.Canceled if it has not settled,
+// and cancels the computation behind it
+func (f *Future[T]) Cancel() {
+	f.settle(*new(T), context.Canceled)
+	if f.cancel != nil {
+		f.cancel()
+	}
+}
+
+// Promise is the settling side of a future, for results produced by code
+// that does not return them, such as a callback
+type Promise[T any] struct {
+	future *Future[T]
+}
+
+// NewPromise creates a promise with an unsettled future
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{future: newFuture[T](nil)}
+}
+
+// Future returns the future the promise settles
+func (p *Promise[T]) Future() *Future[T] {
+	return p.future
+}
+
+// Resolve resolves the future with value, and reports whether it was
+// still unsettled
+func (p *Promise[T]) Resolve(value T) bool {
+	return p.future.settle(value, nil)
+}
+
+// Reject rejects the future with err, and reports whether it was still
+// unsettled
+func (p *Promise[T]) Reject(err error) bool {
+	return p.future.settle(*new(T), err)
+}
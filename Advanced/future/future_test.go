@@ -0,0 +1,116 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hellogolang/Advanced/syncx"
+)
+
+var errFailed = errors.New("failed")
+
+// after returns a future resolving to value after d, or rejected with the
+// error of its context if cancelled first
+func after[T any](d time.Duration, value T) *Future[T] {
+	return Go(context.Background(), func(ctx context.Context) (T, error) {
+		select {
+		case <-time.After(d):
+			return value, nil
+		case <-ctx.Done():
+			return *new(T), ctx.Err()
+		}
+	})
+}
+
+// failAfter returns a future rejected with errFailed after d
+func failAfter[T any](d time.Duration) *Future[T] {
+	return Go(context.Background(), func(context.Context) (T, error) {
+		time.Sleep(d)
+		return *new(T), errFailed
+	})
+}
+
+// await awaits f for at most a second, failing the test if it does not
+// settle
+func await[T any](t *testing.T, f *Future[T]) (T, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	v, err := f.Await(ctx)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		t.Fatal("future did not settle")
+	}
+	return v, err
+}
+
+// TestGo tests that Go settles with the result of its function, and turns
+// a panic into a syncx.PanicError
+func TestGo(t *testing.T) {
+	if v, err := await(t, after(time.Millisecond, 42)); v != 42 || err != nil {
+		t.Errorf("Go = %d, %v, want 42", v, err)
+	}
+	if _, err := await(t, failAfter[int](0)); err != errFailed {
+		t.Errorf("failing Go error = %v, want failed", err)
+	}
+	f := Go(context.Background(), func(context.Context) (int, error) { panic("boom") })
+	var perr *syncx.PanicError
+	if _, err := await(t, f); !errors.As(err, &perr) || perr.Value != "boom" {
+		t.Errorf("panicking Go error = %v, want a syncx.PanicError", err)
+	}
+}
+
+// TestCancel tests that Cancel rejects the future and cancels the context
+// of its computation
+func TestCancel(t *testing.T) {
+	stopped := make(chan error, 1)
+	f := Go(context.Background(), func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return 1, nil
+	})
+	f.Cancel()
+	if _, err := await(t, f); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled future error = %v, want context.Canceled", err)
+	}
+	if err := <-stopped; !errors.Is(err, context.Canceled) {
+		t.Errorf("computation context error = %v, want context.Canceled", err)
+	}
+
+	done := Resolved("kept")
+	done.Cancel()
+	if v, err := await(t, done); v != "kept" || err != nil {
+		t.Errorf("Cancel changed a settled future to %q, %v", v, err)
+	}
+}
+
+// TestAwait tests that Await gives up when its context ends, leaving the
+// future unsettled
+func TestAwait(t *testing.T) {
+	p := NewPromise[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := p.Future().Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Await of an unsettled future error = %v, want DeadlineExceeded", err)
+	}
+	p.Resolve(3)
+	if v, err := p.Future().Await(ctx); v != 3 || err != nil {
+		t.Errorf("Await of a resolved future with an ended context = %d, %v, want 3", v, err)
+	}
+}
+
+// TestPromise tests that a promise settles its future once
+func TestPromise(t *testing.T) {
+	p := NewPromise[string]()
+	go func() { p.Resolve("first") }()
+	if v, _ := await(t, p.Future()); v != "first" {
+		t.Errorf("promised value = %q, want first", v)
+	}
+	if p.Resolve("second") || p.Reject(errFailed) {
+		t.Error("settled a promise twice")
+	}
+	if v, err := await(t, Rejected[int](errFailed)); v != 0 || err != errFailed {
+		t.Errorf("Rejected = %d, %v", v, err)
+	}
+}
@@ -0,0 +1,44 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// body is a response body that fails reads past a limit and releases its
+// call's context when closed
+type body struct {
+	io.ReadCloser
+	remaining int64 // Bytes left to read, or negative for no limit
+	cancel    context.CancelFunc
+	once      sync.Once
+}
+
+// Read reads from the body, returning ErrBodyTooLarge once more than the
+// limit has been read
+func (b *body) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return b.ReadCloser.Read(p)
+	}
+	// Read one byte past the limit, to tell a body of exactly the limit
+	// from a longer one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		return n, ErrBodyTooLarge
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// Close closes the body and cancels its context
+func (b *body) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.cancel)
+	return err
+}
@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestBodyLimit tests that bodies over MaxBodySize are refused, whether
+// their length is declared or not
+func TestBodyLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := strings.Repeat("x", 10)
+		if r.URL.Path == "/long" {
+			body += "y"
+		}
+		if r.URL.Query().Has("chunked") {
+			// Flushing first leaves the length undeclared
+			http.NewResponseController(w).Flush()
+		}
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+	c := New(Options{MaxBodySize: 10})
+
+	if _, err := c.Get(context.Background(), server.URL+"/long"); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("declared long body gave %v, want ErrBodyTooLarge", err)
+	}
+
+	resp, err := c.Get(context.Background(), server.URL+"/long?chunked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !errors.Is(err, ErrBodyTooLarge) || len(b) != 10 {
+		t.Errorf("chunked long body read %d bytes and %v, want 10 and ErrBodyTooLarge", len(b), err)
+	}
+
+	for _, path := range []string{"/", "/?chunked"} {
+		resp, err := c.Get(context.Background(), server.URL+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body := readBody(t, resp); len(body) != 10 {
+			t.Errorf("%s read %q, want the whole body at the limit", path, body)
+		}
+	}
+}
+
+// TestBodyUnlimited tests that a negative MaxBodySize lifts the limit
+func TestBodyUnlimited(t *testing.T) {
+	b := &body{ReadCloser: io.NopCloser(strings.NewReader(strings.Repeat("x", DefaultMaxBodySize+1))), remaining: -1, cancel: func() {}}
+	if n, err := io.Copy(io.Discard, b); err != nil || n != DefaultMaxBodySize+1 {
+		t.Errorf("read %d bytes and %v", n, err)
+	}
+}
+
+// TestBodyClose tests that closing the body cancels its context once
+func TestBodyClose(t *testing.T) {
+	cancels := 0
+	b := &body{ReadCloser: io.NopCloser(strings.NewReader("")), cancel: func() { cancels++ }}
+	b.Close()
+	b.Close()
+	if cancels != 1 {
+		t.Errorf("cancelled %d times, want 1", cancels)
+	}
+}
@@ -0,0 +1,328 @@
+// Package httpclient wraps http.Client for calling other services. Each
+// call is bounded by a Timeout and each attempt by an AttemptTimeout;
+// idempotent requests that fail with a network error or a status such as
+// 503 are retried with the retry package's backoff; each host gets its own
+// circuitbreaker.Breaker, so a failing host is cut off without affecting
+// the others; response bodies are capped at MaxBodySize; and Hooks see
+// every attempt, for logging through logx or counting in a
+// metrics.Registry.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"hellogolang/Advanced/circuitbreaker"
+	"hellogolang/Advanced/retry"
+)
+
+var (
+	// ErrBodyTooLarge is returned by Do, or by reads of the body, when a
+	// response body is longer than MaxBodySize
+	ErrBodyTooLarge = errors.New("response body too large")
+	// ErrAttemptTimeout wraps the error of an attempt that outlived
+	// AttemptTimeout
+	ErrAttemptTimeout = errors.New("attempt timed out")
+)
+
+// Defaults for zero Options fields
+const (
+	DefaultMaxBodySize = 10 << 20
+)
+
+// Options configures a Client. Zero fields take their defaults.
+type Options struct {
+	// Client makes the requests, a new http.Client if nil
+	Client *http.Client
+	// Timeout bounds a whole call, from the first attempt to the body
+	// being closed; 0 or less means no bound beyond the request's context
+	Timeout time.Duration
+	// AttemptTimeout bounds each attempt until the response headers
+	// arrive; 0 or less means no bound. An attempt that times out is
+	// retried.
+	AttemptTimeout time.Duration
+	// Retry configures the retries of idempotent requests. Its
+	// AttemptTimeout and RetryIf are replaced by the Client's own.
+	Retry retry.Policy
+	// RetryStatus reports whether a response status is worth retrying,
+	// by default 429 Too Many Requests, 502 Bad Gateway, 503 Service
+	// Unavailable and 504 Gateway Timeout
+	RetryStatus func(status int) bool
+	// Breaker, if set, configures a circuit breaker for each host. Its
+	// CallTimeout is replaced by AttemptTimeout; network errors and the
+	// statuses RetryStatus accepts count as failures.
+	Breaker *circuitbreaker.Settings
+	// MaxBodySize bounds the bytes of a response body, DefaultMaxBodySize
+	// if 0 and unbounded if negative
+	MaxBodySize int64
+	// Hooks are called after every attempt, in order
+	Hooks []Hook
+}
+
+// Client makes HTTP requests with timeouts, retries, per-host circuit
+// breakers and body limits. It is safe for concurrent use.
+type Client struct {
+	client *http.Client
+	opts   Options
+
+	mu       sync.Mutex
+	breakers map[string]*circuitbreaker.Breaker // By host, created on first use
+}
+
+// New creates a client configured by opts
+func New(opts Options) *Client {
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+	if opts.RetryStatus == nil {
+		opts.RetryStatus = retryStatus
+	}
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if opts.Breaker != nil {
+		settings := *opts.Breaker
+		settings.CallTimeout = 0
+		opts.Breaker = &settings
+	}
+	return &Client{client: client, opts: opts, breakers: make(map[string]*circuitbreaker.Breaker)}
+}
+
+// retryStatus reports whether status is one that later attempts may
+// succeed past
+func retryStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// statusError is the error of an attempt answered with a status worth
+// retrying, holding on to the response in case no retry succeeds
+type statusError struct {
+	resp *http.Response
+}
+
+func (e *statusError) Error() string {
+	return "http status " + e.resp.Status
+}
+
+// discard closes the response of err if it is a statusError, reading a
+// little of its body first so the connection can be reused
+func discard(err error) {
+	var se *statusError
+	if errors.As(err, &se) {
+		io.CopyN(io.Discard, se.resp.Body, 4<<10)
+		se.resp.Body.Close()
+	}
+}
+
+// Breaker returns the circuit breaker of host, as in the Host of a
+// request URL, or nil if the client has no breakers
+func (c *Client) Breaker(host string) *circuitbreaker.Breaker {
+	if c.opts.Breaker == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.breakers[host]
+	if !ok {
+		b = circuitbreaker.New(*c.opts.Breaker)
+		c.breakers[host] = b
+	}
+	return b
+}
+
+// Get makes a GET request to url, which is retried as Do says
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Do sends req, retrying it as the Retry policy says if it is
+// idempotent, its method being GET, HEAD, OPTIONS, TRACE, PUT or DELETE or
+// it having an Idempotency-Key header, and its body can be sent again,
+// being empty or having GetBody set as http.NewRequest does. Network
+// errors, attempt timeouts and the statuses RetryStatus accepts are
+// retried; if the retries run out on such a status, its response is
+// returned without error. Do returns ErrBodyTooLarge if the response
+// declares a length over MaxBodySize, and circuitbreaker.ErrOpen or
+// ErrTooManyProbes, without retrying, if the host's breaker refuses.
+//
+// As with http.Client, the caller must close the response body, which
+// ends the call's timeouts; reading past MaxBodySize fails with
+// ErrBodyTooLarge.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if c.opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.opts.Timeout)
+	}
+
+	policy := c.opts.Retry
+	policy.AttemptTimeout = 0
+	policy.RetryIf = retryable
+	if !replayable(req) {
+		policy.MaxAttempts = 1
+	}
+	onRetry := policy.OnRetry
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		discard(err)
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+	}
+
+	attempt := 0
+	resp, err := retry.DoValue(ctx, policy, func(ctx context.Context) (*http.Response, error) {
+		attempt++
+		return c.attempt(ctx, req, attempt)
+	})
+	if se := (*statusError)(nil); errors.As(err, &se) {
+		if ctx.Err() == nil {
+			// The retries ran out, so the caller gets the last answer
+			resp, err = se.resp, nil
+		} else {
+			discard(err)
+		}
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// Secure: a server cannot make the client buffer unbounded data
+	if c.opts.MaxBodySize >= 0 && resp.ContentLength > c.opts.MaxBodySize {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("%w: %d bytes, at most %d allowed", ErrBodyTooLarge, resp.ContentLength, c.opts.MaxBodySize)
+	}
+	resp.Body = &body{ReadCloser: resp.Body, remaining: c.opts.MaxBodySize, cancel: cancel}
+	return resp, nil
+}
+
+// attempt sends req once, through the breaker of its host if any. It
+// returns a statusError for a status worth retrying and marks the
+// breaker's refusals permanent.
+func (c *Client) attempt(ctx context.Context, req *http.Request, n int) (*http.Response, error) {
+	start := time.Now()
+	var resp *http.Response
+	send := func(ctx context.Context) error {
+		var err error
+		resp, err = c.send(ctx, req, n)
+		if err == nil && c.opts.RetryStatus(resp.StatusCode) {
+			return &statusError{resp}
+		}
+		return err
+	}
+
+	var err error
+	if b := c.Breaker(req.URL.Host); b != nil {
+		err = b.Execute(ctx, send)
+		if isRejection(err) {
+			err = retry.Permanent(err)
+		}
+	} else {
+		err = send(ctx)
+	}
+
+	a := &Attempt{Request: req, Number: n, Err: err, Elapsed: time.Since(start)}
+	if err == nil || errors.As(err, new(*statusError)) {
+		a.Response = resp
+	}
+	for _, h := range c.opts.Hooks {
+		h(a)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// send makes one round trip of req with a fresh body, bounded by
+// AttemptTimeout until the headers arrive
+func (c *Client) send(ctx context.Context, req *http.Request, n int) (*http.Response, error) {
+	ctx, stop := context.WithCancel(ctx)
+	r := req.Clone(ctx)
+	if n > 1 && req.GetBody != nil {
+		b, err := req.GetBody()
+		if err != nil {
+			stop()
+			return nil, retry.Permanent(err)
+		}
+		r.Body = b
+	}
+
+	var timer *time.Timer
+	if c.opts.AttemptTimeout > 0 {
+		timer = time.AfterFunc(c.opts.AttemptTimeout, stop)
+	}
+	resp, err := c.client.Do(r)
+	if timer != nil && !timer.Stop() && ctx.Err() != nil {
+		if err == nil {
+			resp.Body.Close()
+			err = context.DeadlineExceeded
+		}
+		stop()
+		return nil, fmt.Errorf("%w after %v: %w", ErrAttemptTimeout, c.opts.AttemptTimeout, err)
+	}
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	// The attempt's context must outlive Do, until the body is closed
+	resp.Body = &body{ReadCloser: resp.Body, remaining: -1, cancel: stop}
+	return resp, nil
+}
+
+// isRejection reports whether err is a breaker's refusal of a call
+func isRejection(err error) bool {
+	return errors.Is(err, circuitbreaker.ErrOpen) || errors.Is(err, circuitbreaker.ErrTooManyProbes)
+}
+
+// idempotent lists the methods whose requests may be sent twice
+var idempotent = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// replayable reports whether req may be sent again: it is idempotent and
+// its body, if any, can be recreated
+func replayable(req *http.Request) bool {
+	// Secure: retrying a POST could, say, charge a card twice, unless the
+	// server deduplicates it by its Idempotency-Key
+	if !idempotent[req.Method] && req.Header.Get("Idempotency-Key") == "" {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryable reports whether an attempt's error is worth retrying: a
+// status worth retrying, a timed out attempt, or a network failure
+func retryable(err error) bool {
+	if errors.As(err, new(*statusError)) || errors.Is(err, ErrAttemptTimeout) {
+		return true
+	}
+	// Every error of http.Client.Do is a url.Error, itself a net.Error
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
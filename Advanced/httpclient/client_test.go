@@ -0,0 +1,255 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hellogolang/Advanced/circuitbreaker"
+	"hellogolang/Advanced/retry"
+)
+
+// fastRetry retries up to three attempts with short delays
+var fastRetry = retry.Policy{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+// failing returns a server answering status with body to the first n
+// requests and 200 "ok" after, and the count of requests it got
+func failing(t *testing.T, n int, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= n {
+			http.Error(w, "down", status)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// readBody reads and closes the body of resp
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading the body: %v", err)
+	}
+	return string(b)
+}
+
+// TestRetryStatus tests that statuses worth retrying are retried
+func TestRetryStatus(t *testing.T) {
+	server, calls := failing(t, 2, http.StatusServiceUnavailable)
+	c := New(Options{Retry: fastRetry})
+	resp, err := c.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || body != "ok" || calls.Load() != 3 {
+		t.Errorf("got %d %q after %d calls, want 200 ok after 3", resp.StatusCode, body, calls.Load())
+	}
+
+	// Other failing statuses are the caller's to handle
+	server, calls = failing(t, 1, http.StatusInternalServerError)
+	resp, err = c.Get(context.Background(), server.URL)
+	if err != nil || resp.StatusCode != http.StatusInternalServerError || calls.Load() != 1 {
+		t.Errorf("500 got %v, %v after %d calls, want it returned at once", resp, err, calls.Load())
+	}
+	resp.Body.Close()
+}
+
+// TestRetryExhausted tests that the last response is returned when the
+// retries run out
+func TestRetryExhausted(t *testing.T) {
+	server, calls := failing(t, 100, http.StatusTooManyRequests)
+	var retries []int
+	policy := fastRetry
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) { retries = append(retries, attempt) }
+	resp, err := New(Options{Retry: policy}).Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusTooManyRequests || body != "down\n" {
+		t.Errorf("got %d %q, want the last 429", resp.StatusCode, body)
+	}
+	if calls.Load() != 3 || len(retries) != 2 {
+		t.Errorf("%d calls and retries after %v, want 3 and the user's OnRetry called twice", calls.Load(), retries)
+	}
+}
+
+// TestIdempotency tests that only requests safe to repeat are retried,
+// each with its whole body
+func TestIdempotency(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	c := New(Options{Retry: fastRetry})
+
+	for _, tt := range []struct {
+		name, method, key string
+		body              io.Reader
+		calls             int
+	}{
+		{"post", "POST", "", strings.NewReader("payment"), 1},
+		{"post with key", "POST", "k1", strings.NewReader("payment"), 3},
+		{"put", "PUT", "", strings.NewReader("payment"), 3},
+		{"put without GetBody", "PUT", "", io.MultiReader(strings.NewReader("payment")), 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies = nil
+			req, _ := http.NewRequest(tt.method, server.URL, tt.body)
+			if tt.key != "" {
+				req.Header.Set("Idempotency-Key", tt.key)
+			}
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if len(bodies) != tt.calls {
+				t.Errorf("%d calls, want %d", len(bodies), tt.calls)
+			}
+			for _, b := range bodies {
+				if b != "payment" {
+					t.Errorf("a retry sent body %q", b)
+				}
+			}
+		})
+	}
+}
+
+// TestNetworkError tests that network failures are retried and other
+// errors are not
+func TestNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	var attempts int
+	c := New(Options{Retry: fastRetry, Hooks: []Hook{func(*Attempt) { attempts++ }}})
+	if _, err := c.Get(context.Background(), url); !errors.Is(err, retry.ErrExhausted) || attempts != 3 {
+		t.Errorf("refused connection gave %v after %d attempts, want ErrExhausted after 3", err, attempts)
+	}
+	attempts = 0
+	if _, err := c.Get(context.Background(), "ftp://example.com/"); err == nil || attempts != 1 {
+		t.Errorf("unsupported scheme gave %v after %d attempts, want an error after 1", err, attempts)
+	}
+}
+
+// TestAttemptTimeout tests that a stalled attempt is cut off and retried,
+// while the body of a timely one can be read after AttemptTimeout
+func TestAttemptTimeout(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		io.WriteString(w, "head ")
+		http.NewResponseController(w).Flush()
+		time.Sleep(50 * time.Millisecond)
+		io.WriteString(w, "tail")
+	}))
+	defer server.Close()
+
+	var errs []error
+	c := New(Options{
+		Retry:          fastRetry,
+		AttemptTimeout: 20 * time.Millisecond,
+		Hooks:          []Hook{func(a *Attempt) { errs = append(errs, a.Err) }},
+	})
+	resp, err := c.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := readBody(t, resp); body != "head tail" {
+		t.Errorf("body = %q, want it whole", body)
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], ErrAttemptTimeout) || errs[1] != nil {
+		t.Errorf("attempt errors %v, want a timeout then success", errs)
+	}
+}
+
+// TestTimeout tests that Timeout bounds the whole call
+func TestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	c := New(Options{Retry: fastRetry, Timeout: 30 * time.Millisecond, AttemptTimeout: 10 * time.Millisecond})
+	start := time.Now()
+	_, err := c.Get(context.Background(), server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, retry.ErrExhausted) {
+		t.Errorf("Get = %v, want a deadline or exhausted retries", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Get took %v", elapsed)
+	}
+
+	c = New(Options{Timeout: 30 * time.Millisecond})
+	if _, err := c.Get(context.Background(), server.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+// TestBreakerPerHost tests that a failing host's breaker opens without
+// affecting other hosts
+func TestBreakerPerHost(t *testing.T) {
+	bad, badCalls := failing(t, 100, http.StatusServiceUnavailable)
+	good, _ := failing(t, 0, http.StatusOK)
+	c := New(Options{
+		Retry:   retry.Policy{MaxAttempts: 1},
+		Breaker: &circuitbreaker.Settings{FailureThreshold: 2, OpenTimeout: time.Hour, CallTimeout: time.Nanosecond},
+	})
+	for range 2 {
+		resp, err := c.Get(context.Background(), bad.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := c.Get(context.Background(), bad.URL); !errors.Is(err, circuitbreaker.ErrOpen) || badCalls.Load() != 2 {
+		t.Errorf("third call gave %v after %d calls, want ErrOpen without calling", err, badCalls.Load())
+	}
+	resp, err := c.Get(context.Background(), good.URL)
+	if err != nil {
+		t.Fatalf("another host was refused: %v", err)
+	}
+	resp.Body.Close()
+	if c.Breaker(strings.TrimPrefix(good.URL, "http://")).State() != circuitbreaker.Closed {
+		t.Error("the healthy host's breaker is not closed")
+	}
+	if New(Options{}).Breaker("example.com") != nil {
+		t.Error("a client without breakers returned one")
+	}
+}
+
+// TestBreakerOpenNotRetried tests that a breaker's refusal ends the call
+func TestBreakerOpenNotRetried(t *testing.T) {
+	server, _ := failing(t, 100, http.StatusServiceUnavailable)
+	var attempts int
+	c := New(Options{
+		Retry:   fastRetry,
+		Breaker: &circuitbreaker.Settings{FailureThreshold: 1, OpenTimeout: time.Hour},
+		Hooks:   []Hook{func(*Attempt) { attempts++ }},
+	})
+	if _, err := c.Get(context.Background(), server.URL); !errors.Is(err, circuitbreaker.ErrOpen) || attempts != 2 {
+		t.Errorf("Get = %v after %d attempts, want ErrOpen after 2", err, attempts)
+	}
+}
@@ -0,0 +1,74 @@
+package httpclient
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hellogolang/Advanced/logx"
+	"hellogolang/Advanced/metrics"
+)
+
+// Attempt describes one attempt at a request, for Hooks
+type Attempt struct {
+	Request *http.Request
+	// Number counts the attempts at Request, from 1
+	Number int
+	// Response is the response received, nil if Err is a failure with
+	// none. Its body belongs to the caller or is discarded before a
+	// retry, so hooks must not read it.
+	Response *http.Response
+	// Err is the error of the attempt: a network error, an attempt
+	// timeout, a breaker's refusal, or a status worth retrying
+	Err     error
+	Elapsed time.Duration
+}
+
+// Hook is called after each attempt
+type Hook func(a *Attempt)
+
+// Logging returns a hook logging each attempt with the logger of the
+// request's context, as logx.FromContext finds it: at debug level when
+// it got a response not worth retrying, and with logx.Error otherwise
+func Logging() Hook {
+	return func(a *Attempt) {
+		ctx := a.Request.Context()
+		args := []any{
+			"method", a.Request.Method,
+			"url", a.Request.URL.Redacted(),
+			"attempt", a.Number,
+			"elapsed", a.Elapsed,
+		}
+		if a.Response != nil {
+			args = append(args, "status", a.Response.StatusCode)
+		}
+		if a.Err != nil {
+			logx.Error(ctx, "http attempt failed", a.Err, args...)
+			return
+		}
+		logx.FromContext(ctx).Log(ctx, slog.LevelDebug, "http attempt", args...)
+	}
+}
+
+// Metrics returns a hook recording each attempt in r, labelled
+// client=name and host: httpclient_attempts_total counts attempts by
+// result, the status code, "error" or "rejected" for a breaker's
+// refusal, and httpclient_attempt_seconds times them. Clients registered
+// with the same r share the families and need distinct names.
+func Metrics(r *metrics.Registry, name string) Hook {
+	attempts := r.CounterVec("httpclient_attempts_total", "HTTP request attempts", "client", "host", "result")
+	seconds := r.HistogramVec("httpclient_attempt_seconds", "Time taken by HTTP request attempts", nil, "client", "host")
+	return func(a *Attempt) {
+		host := a.Request.URL.Host
+		result := "error"
+		switch {
+		case a.Response != nil:
+			result = strconv.Itoa(a.Response.StatusCode)
+		case isRejection(a.Err):
+			result = "rejected"
+		}
+		attempts.With(name, host, result).Inc()
+		seconds.With(name, host).Observe(a.Elapsed.Seconds())
+	}
+}
@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"hellogolang/Advanced/circuitbreaker"
+	"hellogolang/Advanced/logx"
+	"hellogolang/Advanced/metrics"
+)
+
+// TestLogging tests that attempts are logged with the context's logger,
+// failures at error level and without URL passwords
+func TestLogging(t *testing.T) {
+	server, _ := failing(t, 1, http.StatusServiceUnavailable)
+	var out bytes.Buffer
+	ctx := logx.WithLogger(context.Background(), logx.New(&out, logx.Options{Level: slog.LevelDebug}))
+	url := strings.Replace(server.URL, "http://", "http://user:secret@", 1)
+	resp, err := New(Options{Retry: fastRetry, Hooks: []Hook{Logging()}}).Get(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %q, want two records", out.String())
+	}
+	for i, want := range [][]string{
+		{"level=ERROR", "http attempt failed", "503 Service Unavailable", "attempt=1", "status=503"},
+		{"level=DEBUG", "method=GET", "attempt=2", "status=200"},
+	} {
+		for _, w := range want {
+			if !strings.Contains(lines[i], w) {
+				t.Errorf("record %q lacks %s", lines[i], w)
+			}
+		}
+	}
+	if strings.Contains(out.String(), "secret") {
+		t.Errorf("logged the URL password: %s", out.String())
+	}
+}
+
+// TestMetrics tests that attempts are counted by host and result
+func TestMetrics(t *testing.T) {
+	server, _ := failing(t, 1, http.StatusServiceUnavailable)
+	r := metrics.NewRegistry()
+	c := New(Options{
+		Retry:   fastRetry,
+		Breaker: &circuitbreaker.Settings{FailureThreshold: 2},
+		Hooks:   []Hook{Metrics(r, "api")},
+	})
+	resp, err := c.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	c.Breaker(host).RegisterMetrics(r, host) // Breakers export their own
+	c.Breaker(host).Metrics()
+
+	var text strings.Builder
+	r.WriteText(&text)
+	for _, want := range []string{
+		`httpclient_attempts_total{client="api",host="` + host + `",result="503"} 1`,
+		`httpclient_attempts_total{client="api",host="` + host + `",result="200"} 1`,
+		`httpclient_attempt_seconds_count{client="api",host="` + host + `"} 2`,
+		`circuitbreaker_failures_total{breaker="` + host + `"} 1`,
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("exposition lacks %s:\n%s", want, text.String())
+		}
+	}
+}
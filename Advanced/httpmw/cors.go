@@ -0,0 +1,121 @@
+package httpmw
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures CORS
+type CORSOptions struct {
+	// AllowedOrigins lists the origins allowed, as scheme://host[:port],
+	// or "*" for any
+	AllowedOrigins []string
+	// AllowedMethods lists the methods allowed, GET, HEAD and POST if
+	// empty
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers allowed beyond the
+	// CORS-safelisted ones
+	AllowedHeaders []string
+	// ExposedHeaders lists the response headers scripts may read beyond
+	// the CORS-safelisted ones
+	ExposedHeaders []string
+	// AllowCredentials lets requests carry cookies and authorization
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response, not sent
+	// if 0
+	MaxAge time.Duration
+}
+
+// CORS returns middleware answering cross-origin requests as opts says.
+// Preflight requests, OPTIONS requests with Access-Control-Request-Method,
+// are answered with 204 No Content if the origin, method and headers are
+// allowed and 403 Forbidden otherwise, and go no further. Other requests
+// from allowed origins get the CORS headers and go on to the handler;
+// those from other origins go on without them, so browsers withhold the
+// response from the script. CORS panics if AllowCredentials is set with
+// the "*" origin.
+func CORS(opts CORSOptions) Middleware {
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+	// Secure: any site could make credentialed requests and read the
+	// responses, which is what the same-origin policy forbids
+	if anyOrigin && opts.AllowCredentials {
+		panic("httpmw: CORS credentials cannot be allowed for any origin")
+	}
+	methods := opts.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	allowedHeaders := make(map[string]bool, len(opts.AllowedHeaders))
+	for _, h := range opts.AllowedHeaders {
+		allowedHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(opts.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(opts.ExposedHeaders, ", ")
+
+	originAllowed := func(origin string) bool {
+		return anyOrigin || slices.Contains(opts.AllowedOrigins, origin)
+	}
+	headersAllowed := func(requested string) bool {
+		for h := range strings.SplitSeq(requested, ",") {
+			if h = strings.TrimSpace(h); h != "" && !allowedHeaders[http.CanonicalHeaderKey(h)] {
+				return false
+			}
+		}
+		return true
+	}
+	// setOrigin sets the headers common to all allowed requests
+	setOrigin := func(h http.Header, origin string) {
+		if anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if opts.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			h := w.Header()
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !preflight {
+				if !anyOrigin {
+					h.Add("Vary", "Origin")
+				}
+				if origin != "" && originAllowed(origin) {
+					setOrigin(h, origin)
+					if exposeHeaders != "" {
+						h.Set("Access-Control-Expose-Headers", exposeHeaders)
+					}
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Origin")
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			if !originAllowed(origin) ||
+				!slices.Contains(methods, r.Header.Get("Access-Control-Request-Method")) ||
+				!headersAllowed(r.Header.Get("Access-Control-Request-Headers")) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			setOrigin(h, origin)
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				h.Set("Access-Control-Allow-Headers", allowHeaders)
+			}
+			if opts.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
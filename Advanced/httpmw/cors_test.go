@@ -0,0 +1,116 @@
+package httpmw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// corsRequest returns a request from origin, a preflight if method is
+// OPTIONS
+func corsRequest(method, origin, requestMethod, requestHeaders string) *http.Request {
+	r := httptest.NewRequest(method, "/", nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	if requestMethod != "" {
+		r.Header.Set("Access-Control-Request-Method", requestMethod)
+	}
+	if requestHeaders != "" {
+		r.Header.Set("Access-Control-Request-Headers", requestHeaders)
+	}
+	return r
+}
+
+// TestCORSPreflight tests that preflights are answered without reaching
+// the handler
+func TestCORSPreflight(t *testing.T) {
+	reached := false
+	h := CORS(CORSOptions{
+		AllowedOrigins:   []string{"https://app.example"},
+		AllowedMethods:   []string{"GET", "PUT"},
+		AllowedHeaders:   []string{"Content-Type", "X-Token"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { reached = true }))
+
+	w := serve(h, corsRequest("OPTIONS", "https://app.example", "PUT", "x-token, content-type"))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("allowed preflight got %d, want 204", w.Code)
+	}
+	for name, want := range map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example",
+		"Access-Control-Allow-Methods":     "GET, PUT",
+		"Access-Control-Allow-Headers":     "Content-Type, X-Token",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+	} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	for _, r := range []*http.Request{
+		corsRequest("OPTIONS", "https://evil.example", "PUT", ""),
+		corsRequest("OPTIONS", "https://app.example", "DELETE", ""),
+		corsRequest("OPTIONS", "https://app.example", "GET", "X-Other"),
+	} {
+		w := serve(h, r)
+		if w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("disallowed preflight %v got %d with %v", r.Header, w.Code, w.Header())
+		}
+	}
+	if reached {
+		t.Error("a preflight reached the handler")
+	}
+}
+
+// TestCORSSimple tests that allowed origins get the CORS headers and
+// others do not, both reaching the handler
+func TestCORSSimple(t *testing.T) {
+	h := CORS(CORSOptions{
+		AllowedOrigins: []string{"https://app.example"},
+		ExposedHeaders: []string{"X-Total"},
+	})(ok)
+
+	w := serve(h, corsRequest("GET", "https://app.example", "", ""))
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://app.example" ||
+		w.Header().Get("Access-Control-Expose-Headers") != "X-Total" ||
+		w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("allowed origin got headers %v", w.Header())
+	}
+	if w.Header().Get("Vary") != "Origin" {
+		t.Errorf("Vary = %q, want Origin", w.Header().Get("Vary"))
+	}
+
+	for _, origin := range []string{"https://evil.example", ""} {
+		w := serve(h, corsRequest("GET", origin, "", ""))
+		if w.Header().Get("Access-Control-Allow-Origin") != "" || w.Body.String() != "ok" {
+			t.Errorf("origin %q got headers %v and body %q", origin, w.Header(), w.Body.String())
+		}
+	}
+	// OPTIONS without Access-Control-Request-Method is no preflight
+	if w := serve(h, corsRequest("OPTIONS", "https://app.example", "", "")); w.Body.String() != "ok" {
+		t.Error("a plain OPTIONS request did not reach the handler")
+	}
+}
+
+// TestCORSAnyOrigin tests the "*" origin
+func TestCORSAnyOrigin(t *testing.T) {
+	h := CORS(CORSOptions{AllowedOrigins: []string{"*"}})(ok)
+	w := serve(h, corsRequest("GET", "https://anywhere.example", "", ""))
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Vary") != "" {
+		t.Errorf("any origin got headers %v", w.Header())
+	}
+	if w := serve(h, corsRequest("OPTIONS", "https://anywhere.example", "POST", "")); w.Code != http.StatusNoContent {
+		t.Errorf("preflight for a default method got %d, want 204", w.Code)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("credentials for any origin did not panic")
+		}
+	}()
+	CORS(CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+}
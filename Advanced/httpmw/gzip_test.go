@@ -0,0 +1,144 @@
+package httpmw
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// gunzip decompresses b, failing the test if it is not gzip
+func gunzip(t *testing.T, b io.Reader) string {
+	t.Helper()
+	zr, err := gzip.NewReader(b)
+	if err != nil {
+		t.Fatalf("response is not gzip: %v", err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("reading the gzip stream: %v", err)
+	}
+	return string(out)
+}
+
+// TestGzip tests that responses are compressed for clients accepting
+// gzip, with the headers adjusted
+func TestGzip(t *testing.T) {
+	body := strings.Repeat("<p>hello</p>", 100)
+	h := Gzip(gzip.DefaultCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1200")
+		io.WriteString(w, body)
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "deflate, gzip;q=0.8")
+	w := serve(h, r)
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") != "" {
+		t.Errorf("headers %v, want gzip encoding and no length", w.Header())
+	}
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", w.Header().Get("Vary"))
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q, sniffed from the compressed data", ct)
+	}
+	if w.Body.Len() >= len(body) {
+		t.Errorf("compressed %d bytes to %d", len(body), w.Body.Len())
+	}
+	if got := gunzip(t, w.Body); got != body {
+		t.Errorf("decompressed %q, want %q", got, body)
+	}
+}
+
+// TestGzipPassThrough tests the responses left uncompressed
+func TestGzipPassThrough(t *testing.T) {
+	h := Gzip(gzip.BestSpeed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoded":
+			w.Header().Set("Content-Encoding", "br")
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		io.WriteString(w, "plain")
+	}))
+	for _, tt := range []struct {
+		name, path, accept, method string
+	}{
+		{"not accepted", "/", "", "GET"},
+		{"refused", "/", "gzip;q=0, *", "GET"},
+		{"already encoded", "/encoded", "gzip", "GET"},
+		{"no content", "/empty", "gzip", "GET"},
+		{"head", "/", "gzip", "HEAD"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.Header.Set("Accept-Encoding", tt.accept)
+			w := serve(h, r)
+			if w.Header().Get("Content-Encoding") == "gzip" {
+				t.Error("response was compressed")
+			}
+			if tt.path == "/" && tt.method == "GET" && w.Body.String() != "plain" {
+				t.Errorf("body = %q, want plain", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestGzipFlush tests that flushing sends what was compressed so far
+func TestGzipFlush(t *testing.T) {
+	server := httptest.NewServer(Gzip(gzip.DefaultCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "first\n")
+		http.NewResponseController(w).Flush()
+		<-r.Context().Done()
+	})))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip") // Stops the transport decompressing
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("response is not gzip: %v", err)
+	}
+	line := make([]byte, len("first\n"))
+	if _, err := io.ReadFull(zr, line); err != nil || string(line) != "first\n" {
+		t.Errorf("read %q, %v before the handler returned", line, err)
+	}
+}
+
+// TestAcceptsGzip tests parsing of Accept-Encoding
+func TestAcceptsGzip(t *testing.T) {
+	for header, want := range map[string]bool{
+		"":                 false,
+		"gzip":             true,
+		"GZIP":             true,
+		"br, gzip":         true,
+		"gzip;q=0":         false,
+		"gzip; q=0.5":      true,
+		"*":                true,
+		"*;q=0":            false,
+		"*, gzip;q=0":      false,
+		"deflate":          false,
+		"identity;q=1, br": false,
+	} {
+		if got := acceptsGzip(header); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+// TestGzipInvalidLevel tests that an invalid level panics
+func TestGzipInvalidLevel(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Gzip(42) did not panic")
+		}
+	}()
+	Gzip(42)
+}
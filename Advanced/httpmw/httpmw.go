@@ -0,0 +1,88 @@
+// Package httpmw provides composable net/http middleware: Logging logs
+// each request through logx, Recover turns a panicking handler into a 500,
+// Timeout bounds how long a handler may take, RateLimit refuses clients
+// over their ratelimit.Keyed limit, RequestID puts a request ID in the
+// context, Gzip compresses responses and CORS answers cross-origin
+// requests. Chain composes middleware around any http.Handler, such as an
+// http.ServeMux:
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("GET /items/{id}", getItem)
+//	handler := httpmw.Chain(httpmw.RequestID(), httpmw.Logging(), httpmw.Recover())(mux)
+//	http.ListenAndServe(addr, handler)
+//
+// Router does the same with middleware for single routes as well.
+package httpmw
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"slices"
+)
+
+// Middleware wraps a handler, to act before and after it
+type Middleware func(next http.Handler) http.Handler
+
+// Chain composes mw into one Middleware, the first outermost: it sees the
+// request first and the response last
+func Chain(mw ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for _, m := range slices.Backward(mw) {
+			next = m(next)
+		}
+		return next
+	}
+}
+
+// responseWriter records the status and size of the response written
+// through it
+type responseWriter struct {
+	http.ResponseWriter
+	status int   // The status written, 0 until then
+	bytes  int64 // The body bytes written
+}
+
+// WriteHeader records status and passes it on, once
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 && status >= 200 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes b, recording a 200 status if none was written
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Status returns the status written, or 200 if the handler wrote none
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Flush flushes the underlying writer, if it can
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack hijacks the underlying connection, if the writer allows it
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,64 @@
+package httpmw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ok is a handler writing "ok"
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+})
+
+// serve passes r through h and returns the recorded response
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// TestChainOrder tests that the first middleware is outermost
+func TestChainOrder(t *testing.T) {
+	var ran []string
+	tag := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ran = append(ran, name+" in")
+				next.ServeHTTP(w, r)
+				ran = append(ran, name+" out")
+			})
+		}
+	}
+	h := Chain(tag("a"), tag("b"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ran = append(ran, "handler")
+	}))
+	serve(h, httptest.NewRequest("GET", "/", nil))
+	want := "a in,b in,handler,b out,a out"
+	if got := strings.Join(ran, ","); got != want {
+		t.Errorf("ran %s, want %s", got, want)
+	}
+	if h := Chain()(ok); serve(h, httptest.NewRequest("GET", "/", nil)).Body.String() != "ok" {
+		t.Error("an empty chain changed the handler")
+	}
+}
+
+// TestResponseWriter tests that the status and size are recorded and that
+// the writer can still be flushed through
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &responseWriter{ResponseWriter: rec}
+	if w.Status() != http.StatusOK {
+		t.Errorf("Status() before writing = %d, want 200", w.Status())
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusTeapot) // Ignored by the recorder and by w
+	w.Write([]byte("hello"))
+	if w.Status() != http.StatusCreated || w.bytes != 5 {
+		t.Errorf("recorded %d and %d bytes, want 201 and 5", w.Status(), w.bytes)
+	}
+	if err := http.NewResponseController(w).Flush(); err != nil || !rec.Flushed {
+		t.Errorf("Flush through the writer failed: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package httpmw
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"hellogolang/Advanced/logx"
+	"hellogolang/Advanced/ratelimit"
+	"hellogolang/Advanced/syncx"
+)
+
+// RequestIDHeader is the header carrying request IDs in and out
+const RequestIDHeader = "X-Request-ID"
+
+// Logging returns middleware logging each request once it is served, with
+// the logger the context carries as logx.FromContext finds it: its
+// method, path, status, response size and duration, at error level for
+// 5xx statuses and info level otherwise. Placed after RequestID, the
+// records carry the request ID.
+func Logging() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+			level := slog.LevelInfo
+			if rw.Status() >= 500 {
+				level = slog.LevelError
+			}
+			ctx := r.Context()
+			logx.FromContext(ctx).LogAttrs(ctx, level, "http request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rw.Status()),
+				slog.Int64("bytes", rw.bytes),
+				slog.Duration("elapsed", time.Since(start)),
+			)
+		})
+	}
+}
+
+// Recover returns middleware turning a panicking handler into a 500
+// response, if the handler had not yet written its status, and logging
+// the panic as a syncx.PanicError with logx.Error. http.ErrAbortHandler
+// panics on, to abort the response as the server expects. Placed after
+// Logging, the request is also logged with its 500 status.
+func Recover() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseWriter{ResponseWriter: w}
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(v)
+				}
+				logx.Error(r.Context(), "http handler panicked", &syncx.PanicError{Value: v, Stack: debug.Stack()},
+					"method", r.Method, "path", r.URL.Path)
+				// Secure: the panic value stays in the log, out of the
+				// response
+				if rw.status == 0 {
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// Timeout returns middleware giving each handler d to respond. The
+// handler's context is cancelled after d, and if it has not responded by
+// then the client gets a 503 Service Unavailable; what the handler writes
+// later is discarded. The handler's writer cannot be flushed or hijacked.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, d, "request timed out")
+	}
+}
+
+// RateLimit returns middleware refusing requests over the limit of their
+// key in limits with 429 Too Many Requests. key picks a request's key,
+// ClientIP if nil.
+func RateLimit(limits *ratelimit.Keyed[string], key func(r *http.Request) string) Middleware {
+	if key == nil {
+		key = ClientIP
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limits.Allow(key(r)) {
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns the IP address the request came from, without its
+// port
+func ClientIP(r *http.Request) string {
+	// Secure: X-Forwarded-For is set by clients as they please, so only
+	// the address of the connection itself is trusted
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// maxRequestIDLen bounds the length of request IDs taken from clients
+const maxRequestIDLen = 128
+
+// RequestID returns middleware giving each request an ID, put in its
+// context with logx.WithRequestID and in the RequestIDHeader of the
+// response. A request's own RequestIDHeader is kept, so that a request
+// can be traced across services, if it is a valid ID; otherwise a new one
+// is made with logx.NewRequestID.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = logx.NewRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(w, r.WithContext(logx.WithRequestID(r.Context(), id)))
+		})
+	}
+}
+
+// validRequestID reports whether id is 1 to maxRequestIDLen letters,
+// digits, dashes, underscores and dots
+func validRequestID(id string) bool {
+	// Secure: the ID goes into logs and headers, so nothing that could
+	// forge log lines or flood them is accepted
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range []byte(id) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package httpmw
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hellogolang/Advanced/logx"
+	"hellogolang/Advanced/ratelimit"
+)
+
+// TestLogging tests that requests are logged with their status, size and
+// request ID, server errors at error level
+func TestLogging(t *testing.T) {
+	var out bytes.Buffer
+	logger := logx.New(&out, logx.Options{})
+	h := Chain(RequestID(), Logging())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			http.Error(w, "broken", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	for _, path := range []string{"/hello", "/fail"} {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set(RequestIDHeader, "req-"+path[1:])
+		serve(h, r.WithContext(logx.WithLogger(r.Context(), logger)))
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %q, want two records", out.String())
+	}
+	for i, want := range [][]string{
+		{"level=INFO", "method=GET", "path=/hello", "status=200", "bytes=5", "elapsed=", "request_id=req-hello"},
+		{"level=ERROR", "path=/fail", "status=500", "request_id=req-fail"},
+	} {
+		for _, w := range want {
+			if !strings.Contains(lines[i], w) {
+				t.Errorf("record %q lacks %s", lines[i], w)
+			}
+		}
+	}
+}
+
+// TestRecover tests that a panic becomes a logged 500 response, unless
+// the handler had already responded
+func TestRecover(t *testing.T) {
+	var out bytes.Buffer
+	ctx := logx.WithLogger(context.Background(), logx.New(&out, logx.Options{}))
+	h := Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/late" {
+			w.WriteHeader(http.StatusAccepted)
+		}
+		panic("secret detail")
+	}))
+
+	w := serve(h, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("got %d %q, want a 500 without the panic value", w.Code, w.Body.String())
+	}
+	if !strings.Contains(out.String(), "panic: secret detail") {
+		t.Errorf("logged %q, want the panic", out.String())
+	}
+
+	if w := serve(h, httptest.NewRequest("GET", "/late", nil).WithContext(ctx)); w.Code != http.StatusAccepted {
+		t.Errorf("got %d after the handler responded, want 202", w.Code)
+	}
+}
+
+// TestRecoverAbort tests that http.ErrAbortHandler is not recovered
+func TestRecoverAbort(t *testing.T) {
+	h := Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", r)
+		}
+	}()
+	serve(h, httptest.NewRequest("GET", "/", nil))
+}
+
+// TestTimeout tests that a slow handler is cancelled and answered with a
+// 503, while a fast one is served
+func TestTimeout(t *testing.T) {
+	cancelled := make(chan struct{})
+	h := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			close(cancelled)
+			return
+		}
+		w.Write([]byte("fast"))
+	}))
+	if w := serve(h, httptest.NewRequest("GET", "/fast", nil)); w.Code != http.StatusOK || w.Body.String() != "fast" {
+		t.Errorf("fast handler got %d %q", w.Code, w.Body.String())
+	}
+	if w := serve(h, httptest.NewRequest("GET", "/slow", nil)); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("slow handler got %d, want 503", w.Code)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("the slow handler's context was not cancelled")
+	}
+}
+
+// TestRateLimit tests that each client is limited separately
+func TestRateLimit(t *testing.T) {
+	limits := ratelimit.NewKeyed[string](0, func() ratelimit.Limiter {
+		return ratelimit.NewSlidingWindowLog(2, time.Hour)
+	})
+	h := RateLimit(limits, nil)(ok)
+	request := func(addr string) int {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = addr
+		r.Header.Set("X-Forwarded-For", "203.0.113.9")
+		return serve(h, r).Code
+	}
+	for range 2 {
+		if code := request("192.0.2.1:1000"); code != http.StatusOK {
+			t.Fatalf("request within the limit got %d", code)
+		}
+	}
+	if code := request("192.0.2.1:2000"); code != http.StatusTooManyRequests {
+		t.Errorf("request over the limit from another port got %d, want 429", code)
+	}
+	if code := request("192.0.2.2:1000"); code != http.StatusOK {
+		t.Errorf("another client got %d, want 200", code)
+	}
+}
+
+// TestRateLimitKey tests that a custom key function is used
+func TestRateLimitKey(t *testing.T) {
+	limits := ratelimit.NewKeyed[string](0, func() ratelimit.Limiter {
+		return ratelimit.NewSlidingWindowLog(1, time.Hour)
+	})
+	h := RateLimit(limits, func(r *http.Request) string { return r.Header.Get("X-API-Key") })(ok)
+	request := func(key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-API-Key", key)
+		return serve(h, r)
+	}
+	request("a")
+	if w := request("a"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("second request for a key got %d without Retry-After", w.Code)
+	}
+	if w := request("b"); w.Code != http.StatusOK {
+		t.Errorf("another key got %d, want 200", w.Code)
+	}
+}
+
+// TestRequestID tests that valid incoming IDs are kept and others
+// replaced, in the context and the response
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logx.RequestID(r.Context())
+	}))
+	for _, tt := range []struct {
+		in   string
+		keep bool
+	}{
+		{"abc-123_X.y", true},
+		{"", false},
+		{"bad id\nlevel=ERROR", false},
+		{strings.Repeat("a", maxRequestIDLen+1), false},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(RequestIDHeader, tt.in)
+		w := serve(h, r)
+		if got := w.Header().Get(RequestIDHeader); got != seen || seen == "" {
+			t.Errorf("for %q the response carried %q and the context %q", tt.in, got, seen)
+		}
+		if (seen == tt.in) != tt.keep {
+			t.Errorf("for %q the ID was %q, kept = %v", tt.in, seen, !tt.keep)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package httpmw
+
+import "net/http"
+
+// Router routes requests with an http.ServeMux, so patterns take its
+// syntax, such as "GET /items/{id}", and unmatched methods get 405 Method
+// Not Allowed. Middleware given to NewRouter wraps the whole mux, seeing
+// every request including those no route matches; middleware given to
+// Handle wraps its route alone.
+type Router struct {
+	mux     *http.ServeMux
+	handler http.Handler
+}
+
+// NewRouter creates a router whose requests all pass through mw, the
+// first outermost
+func NewRouter(mw ...Middleware) *Router {
+	mux := http.NewServeMux()
+	return &Router{mux: mux, handler: Chain(mw...)(mux)}
+}
+
+// Handle routes requests matching pattern to h through mw, the first
+// outermost. Like http.ServeMux.Handle, it panics if pattern is invalid
+// or conflicts with one already routed.
+func (rt *Router) Handle(pattern string, h http.Handler, mw ...Middleware) {
+	rt.mux.Handle(pattern, Chain(mw...)(h))
+}
+
+// HandleFunc routes requests matching pattern to f through mw
+func (rt *Router) HandleFunc(pattern string, f http.HandlerFunc, mw ...Middleware) {
+	rt.Handle(pattern, f, mw...)
+}
+
+// ServeHTTP passes r through the router's middleware to its route
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.handler.ServeHTTP(w, r)
+}
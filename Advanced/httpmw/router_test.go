@@ -0,0 +1,44 @@
+package httpmw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRouter tests routing with patterns and the two kinds of middleware
+func TestRouter(t *testing.T) {
+	header := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Middleware", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	rt := NewRouter(header("global"))
+	rt.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("item " + r.PathValue("id")))
+	}, header("route"))
+	rt.Handle("POST /items", ok)
+
+	for _, tt := range []struct {
+		method, path string
+		code         int
+		body         string
+		middleware   []string
+	}{
+		{"GET", "/items/7", http.StatusOK, "item 7", []string{"global", "route"}},
+		{"POST", "/items", http.StatusOK, "ok", []string{"global"}},
+		{"DELETE", "/items/7", http.StatusMethodNotAllowed, "", []string{"global"}},
+		{"GET", "/nowhere", http.StatusNotFound, "", []string{"global"}},
+	} {
+		w := serve(rt, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.code || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("%s %s got %d %q, want %d %q", tt.method, tt.path, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+		if got := w.Header().Values("X-Middleware"); len(got) != len(tt.middleware) {
+			t.Errorf("%s %s ran middleware %q, want %q", tt.method, tt.path, got, tt.middleware)
+		}
+	}
+}
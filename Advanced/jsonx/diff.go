@@ -0,0 +1,112 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"hellogolang/Advanced/mapper"
+)
+
+// Diff returns a patch that turns the document a into b. Objects are
+// compared member by member and arrays element by element after their
+// common start and end, so an edit deep inside a document gives an
+// operation at its path rather than a replacement of the whole. The
+// values of the patch share no memory with b, and equal documents give
+// an empty patch.
+func Diff(a, b any) Patch {
+	p := Patch{}
+	diff(&p, Pointer{}, a, b)
+	return p
+}
+
+// diff appends to p the operations turning a into b at path
+func diff(p *Patch, path Pointer, a, b any) {
+	if Equal(a, b) {
+		return
+	}
+	switch a := a.(type) {
+	case map[string]any:
+		if b, ok := b.(map[string]any); ok {
+			diffObjects(p, path, a, b)
+			return
+		}
+	case []any:
+		if b, ok := b.([]any); ok {
+			diffArrays(p, path, a, b)
+			return
+		}
+	}
+	*p = append(*p, Operation{Op: OpReplace, Path: path.String(), Value: mapper.DeepCopy(b)})
+}
+
+// diffObjects appends the operations turning the object a into b: removals,
+// then changes and additions, in the order of the member names
+func diffObjects(p *Patch, path Pointer, a, b map[string]any) {
+	for _, k := range sortedKeys(a) {
+		if _, ok := b[k]; !ok {
+			*p = append(*p, Operation{Op: OpRemove, Path: path.Append(k).String()})
+		}
+	}
+	for _, k := range sortedKeys(b) {
+		if av, ok := a[k]; ok {
+			diff(p, path.Append(k), av, b[k])
+		} else {
+			*p = append(*p, Operation{Op: OpAdd, Path: path.Append(k).String(), Value: mapper.DeepCopy(b[k])})
+		}
+	}
+}
+
+// sortedKeys returns the member names of m in order, so diffs are
+// deterministic
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// diffArrays appends the operations turning the array a into b. Elements
+// equal at the start and end are skipped; in between, elements at the
+// same index are diffed, and the longer side's extra elements removed or
+// added.
+func diffArrays(p *Patch, path Pointer, a, b []any) {
+	start := 0
+	for start < len(a) && start < len(b) && Equal(a[start], b[start]) {
+		start++
+	}
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && Equal(a[endA-1], b[endB-1]) {
+		endA--
+		endB--
+	}
+	common := min(endA, endB) - start
+	for i := range common {
+		diff(p, path.Append(strconv.Itoa(start+i)), a[start+i], b[start+i])
+	}
+	at := start + common
+	// Each removal shifts what follows down, so the same index is removed
+	// until the extra elements are gone
+	for range endA - at {
+		*p = append(*p, Operation{Op: OpRemove, Path: path.Append(strconv.Itoa(at)).String()})
+	}
+	for i := at; i < endB; i++ {
+		*p = append(*p, Operation{Op: OpAdd, Path: path.Append(strconv.Itoa(i)).String(), Value: mapper.DeepCopy(b[i])})
+	}
+}
+
+// DiffJSON returns the encoded patch turning the encoded document a into
+// b
+func DiffJSON(a, b []byte) ([]byte, error) {
+	var da, db any
+	if err := decode(a, &da); err != nil {
+		return nil, fmt.Errorf("jsonx: first document: %w", err)
+	}
+	if err := decode(b, &db); err != nil {
+		return nil, fmt.Errorf("jsonx: second document: %w", err)
+	}
+	return json.Marshal(Diff(da, db))
+}
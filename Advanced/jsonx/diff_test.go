@@ -0,0 +1,76 @@
+package jsonx
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// randomDoc returns a random document of at most the given depth, drawing
+// from few keys and values so that documents overlap
+func randomDoc(r *rand.Rand, depth int) any {
+	kind := r.IntN(6)
+	if depth == 0 {
+		kind %= 3
+	}
+	switch kind {
+	case 0:
+		return float64(r.IntN(4))
+	case 1:
+		return []any{"a", "b", nil, true}[r.IntN(4)]
+	case 2:
+		return nil
+	case 3, 4:
+		a := make([]any, r.IntN(5))
+		for i := range a {
+			a[i] = randomDoc(r, depth-1)
+		}
+		return a
+	}
+	m := make(map[string]any)
+	for range r.IntN(4) {
+		m[fmt.Sprint("k", r.IntN(4))] = randomDoc(r, depth-1)
+	}
+	return m
+}
+
+// TestDiff tests that applying a diff of a to a gives b
+func TestDiff(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 2000 {
+		a, b := randomDoc(r, 3), randomDoc(r, 3)
+		p := Diff(a, b)
+		got, err := p.Apply(a)
+		if err != nil || !Equal(got, b) {
+			t.Fatalf("Diff(%v, %v) = %v, which gives %v, %v", a, b, p, got, err)
+		}
+		if p := Diff(a, a); len(p) != 0 {
+			t.Fatalf("Diff(%v, itself) = %v, want empty", a, p)
+		}
+	}
+}
+
+// TestDiffJSON tests that a diff is small and encodes to a patch
+func TestDiffJSON(t *testing.T) {
+	a := `{"name": "a", "tags": ["x", "y", "z"], "n": 1}`
+	b := `{"name": "b", "tags": ["x", "z"], "n": 1.0, "new": {}}`
+	data, err := DiffJSON([]byte(a), []byte(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"op":"replace","path":"/name","value":"b"},{"op":"add","path":"/new","value":{}},{"op":"remove","path":"/tags/1"}]`
+	if !equalJSON(t, string(data), want) {
+		t.Errorf("DiffJSON = %s, want %s", data, want)
+	}
+	got, err := ApplyJSON([]byte(a), data)
+	if err != nil || !equalJSON(t, string(got), b) {
+		t.Errorf("ApplyJSON = %s, %v, want %s", got, err, b)
+	}
+
+	if data, err := DiffJSON([]byte(`[]`), []byte(`[]`)); err != nil || string(data) != "[]" {
+		t.Errorf("DiffJSON of equal documents = %s, %v, want []", data, err)
+	}
+	if _, err := DiffJSON([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("DiffJSON of a bad document succeeded")
+	}
+}
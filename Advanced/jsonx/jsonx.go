@@ -0,0 +1,117 @@
+// Package jsonx adds streaming and editing to encoding/json. Reader and
+// Writer handle NDJSON, one value per line; Elements decodes the elements
+// of a top-level array one at a time, so a huge array never sits in
+// memory whole. Pointer resolves RFC 6901 JSON Pointers such as
+// "/users/0/name", and Patch applies RFC 6902 JSON Patches, which Diff
+// generates from two documents.
+//
+// Pointers and patches work on documents decoded into any: maps of
+// map[string]any, arrays of []any, and strings, booleans, nil and numbers
+// as float64 or json.Number. Numbers compare by value whichever form they
+// take.
+package jsonx
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+var (
+	// ErrNotFound is wrapped by the error for a pointer to a member or
+	// element that does not exist
+	ErrNotFound = errors.New("json pointer target not found")
+	// ErrInvalidPointer is wrapped by the error for a malformed pointer
+	ErrInvalidPointer = errors.New("invalid json pointer")
+	// ErrInvalidPatch is wrapped by the error for a malformed operation
+	ErrInvalidPatch = errors.New("invalid json patch")
+	// ErrTestFailed is wrapped by the error of a test operation whose
+	// value differs
+	ErrTestFailed = errors.New("json patch test failed")
+)
+
+// Equal reports whether two decoded documents are equal: objects with the
+// same members, arrays with the same elements in order, and numbers of
+// the same value, whether float64, json.Number or a Go integer
+func Equal(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			bv, ok := b[k]
+			if !ok || !Equal(av, bv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !Equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x.Cmp(y) == 0
+	}
+	if _, ok := number(b); ok {
+		return false
+	}
+	switch a.(type) {
+	case nil, string, bool:
+		return a == b
+	}
+	return false
+}
+
+// number returns the value of a JSON number held as float64, json.Number
+// or a Go integer or float, exactly
+func number(v any) (*big.Rat, bool) {
+	r := new(big.Rat)
+	switch v := v.(type) {
+	case float64:
+		if r.SetFloat64(v) == nil {
+			return nil, false
+		}
+	case float32:
+		if r.SetFloat64(float64(v)) == nil {
+			return nil, false
+		}
+	case json.Number:
+		if _, ok := r.SetString(string(v)); !ok {
+			return nil, false
+		}
+	case int:
+		r.SetInt64(int64(v))
+	case int8:
+		r.SetInt64(int64(v))
+	case int16:
+		r.SetInt64(int64(v))
+	case int32:
+		r.SetInt64(int64(v))
+	case int64:
+		r.SetInt64(v)
+	case uint:
+		r.SetUint64(uint64(v))
+	case uint8:
+		r.SetUint64(uint64(v))
+	case uint16:
+		r.SetUint64(uint64(v))
+	case uint32:
+		r.SetUint64(uint64(v))
+	case uint64:
+		r.SetUint64(v)
+	default:
+		return nil, false
+	}
+	return r, true
+}
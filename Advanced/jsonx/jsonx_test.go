@@ -0,0 +1,34 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestEqual tests comparing documents, numbers by value
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b any
+		want bool
+	}{
+		{nil, nil, true},
+		{1.0, json.Number("1"), true},
+		{json.Number("1e2"), 100, true},
+		{json.Number("9007199254740993"), json.Number("9007199254740992"), false},
+		{uint8(3), 3.0, true},
+		{1.0, "1", false},
+		{"1", 1.0, false},
+		{true, true, true},
+		{nil, false, false},
+		{map[string]any{"a": []any{1.0, "x"}}, map[string]any{"a": []any{json.Number("1"), "x"}}, true},
+		{map[string]any{"a": 1.0}, map[string]any{"b": 1.0}, false},
+		{[]any{1.0}, []any{1.0, 2.0}, false},
+		{[]any{}, map[string]any{}, false},
+		{struct{}{}, struct{}{}, false},
+	}
+	for _, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%#v, %#v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
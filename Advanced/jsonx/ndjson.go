@@ -0,0 +1,143 @@
+package jsonx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// DefaultMaxLineSize bounds the lines a Reader reads if
+// ReaderOptions.MaxLineSize is 0 or less
+const DefaultMaxLineSize = 1 << 20
+
+// ReaderOptions configures a Reader. Zero fields take their defaults.
+type ReaderOptions struct {
+	// MaxLineSize bounds the bytes of a line, DefaultMaxLineSize if 0 or
+	// less; a longer line fails with bufio.ErrTooLong
+	MaxLineSize int
+	// DisallowUnknownFields fails lines with members no field of T takes
+	DisallowUnknownFields bool
+	// UseNumber decodes numbers into any as json.Number, keeping integers
+	// beyond 2^53 exact
+	UseNumber bool
+}
+
+// LineError is a line of NDJSON that could not be read or decoded
+type LineError struct {
+	Line int // The line of the input, from 1
+	Err  error
+}
+
+// Error names the line and what was wrong with it
+func (e *LineError) Error() string {
+	return fmt.Sprintf("jsonx: line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the cause
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Reader decodes NDJSON, one value of type T per line. Blank lines are
+// skipped.
+type Reader[T any] struct {
+	scanner *bufio.Scanner
+	opts    ReaderOptions
+	line    int
+}
+
+// NewReader returns a Reader decoding from r
+func NewReader[T any](r io.Reader, opts ReaderOptions) *Reader[T] {
+	if opts.MaxLineSize <= 0 {
+		opts.MaxLineSize = DefaultMaxLineSize
+	}
+	s := bufio.NewScanner(r)
+	// Secure: a line without an end must not grow the buffer without bound
+	s.Buffer(make([]byte, 0, min(opts.MaxLineSize, 64<<10)), opts.MaxLineSize)
+	return &Reader[T]{scanner: s, opts: opts}
+}
+
+// Read decodes the next line. It returns io.EOF after the last one, and a
+// *LineError for a line that is too long or not exactly one JSON value.
+func (r *Reader[T]) Read() (T, error) {
+	var v T
+	for r.scanner.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(line))
+		if r.opts.DisallowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
+		if r.opts.UseNumber {
+			dec.UseNumber()
+		}
+		if err := dec.Decode(&v); err != nil {
+			return v, &LineError{Line: r.line, Err: err}
+		}
+		if dec.More() {
+			return v, &LineError{Line: r.line, Err: errors.New("more than one value")}
+		}
+		return v, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return v, &LineError{Line: r.line + 1, Err: err}
+	}
+	return v, io.EOF
+}
+
+// All returns an iterator over the remaining values. It stops after the
+// first error, which it yields with the zero T.
+func (r *Reader[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			v, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Writer encodes values of type T as NDJSON, one per line
+type Writer[T any] struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewWriter returns a Writer encoding to w. Values are buffered; call
+// Flush when done.
+func NewWriter[T any](w io.Writer) *Writer[T] {
+	bw := bufio.NewWriter(w)
+	return &Writer[T]{w: bw, enc: json.NewEncoder(bw)}
+}
+
+// Write encodes v on a line of its own. Encoding never breaks a value
+// across lines, as strings escape their newlines.
+func (w *Writer[T]) Write(v T) error {
+	return w.enc.Encode(v)
+}
+
+// WriteAll writes each value of values, then flushes
+func (w *Writer[T]) WriteAll(values iter.Seq[T]) error {
+	for v := range values {
+		if err := w.Write(v); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Flush writes any buffered values to the underlying writer
+func (w *Writer[T]) Flush() error {
+	return w.w.Flush()
+}
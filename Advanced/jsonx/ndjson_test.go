@@ -0,0 +1,91 @@
+package jsonx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// event is a value written as NDJSON
+type event struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+// TestNDJSON tests writing values and reading them back
+func TestNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter[event](&buf)
+	events := []event{{1, "a"}, {2, "multi\nline"}, {3, ""}}
+	if err := w.WriteAll(slices.Values(events)); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Errorf("wrote %d lines, want 3: %q", lines, buf.String())
+	}
+
+	var got []event
+	for e, err := range NewReader[event](&buf, ReaderOptions{}).All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e)
+	}
+	if !slices.Equal(got, events) {
+		t.Errorf("read %v, want %v", got, events)
+	}
+}
+
+// TestReaderErrors tests that bad lines name their line number
+func TestReaderErrors(t *testing.T) {
+	in := "{\"id\": 1}\n\n  \r\n{\"id\": 2} {\"id\": 3}\n"
+	r := NewReader[event](strings.NewReader(in), ReaderOptions{})
+	if e, err := r.Read(); err != nil || e.ID != 1 {
+		t.Fatalf("Read = %+v, %v", e, err)
+	}
+	_, err := r.Read()
+	var le *LineError
+	if !errors.As(err, &le) || le.Line != 4 {
+		t.Errorf("Read = %v, want a LineError at line 4", err)
+	}
+
+	tests := []struct {
+		in   string
+		opts ReaderOptions
+		line int
+		want error
+	}{
+		{"{\"id\": 1}\n{\"id\": \"x\"}\n", ReaderOptions{}, 2, nil},
+		{"{\"id\": 1, \"extra\": true}\n", ReaderOptions{DisallowUnknownFields: true}, 1, nil},
+		{"{}\n" + strings.Repeat(" ", 100) + "{}\n", ReaderOptions{MaxLineSize: 64}, 2, bufio.ErrTooLong},
+		{"{\"id\":", ReaderOptions{}, 1, io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		r := NewReader[event](strings.NewReader(tt.in), tt.opts)
+		var err error
+		for _, err = range r.All() {
+		}
+		if !errors.As(err, &le) || le.Line != tt.line || (tt.want != nil && !errors.Is(err, tt.want)) {
+			t.Errorf("reading %q = %v, want a LineError at line %d", tt.in, err, tt.line)
+		}
+	}
+
+	r = NewReader[event](strings.NewReader(""), ReaderOptions{})
+	if _, err := r.Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("Read of nothing = %v, want io.EOF", err)
+	}
+}
+
+// TestReaderUseNumber tests keeping large integers exact
+func TestReaderUseNumber(t *testing.T) {
+	r := NewReader[map[string]any](strings.NewReader(`{"n": 9007199254740993}`), ReaderOptions{UseNumber: true})
+	m, err := r.Read()
+	if err != nil || m["n"] != json.Number("9007199254740993") {
+		t.Errorf("Read = %v, %v, want the exact number", m, err)
+	}
+}
@@ -0,0 +1,293 @@
+package jsonx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"hellogolang/Advanced/mapper"
+)
+
+// The operations of a patch
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// Operation is one step of a JSON Patch
+type Operation struct {
+	Op    string // One of the Op constants
+	Path  string // A JSON Pointer to the target
+	From  string // The source pointer of move and copy
+	Value any    // The value of add, replace and test, which may be nil
+}
+
+// hasValue reports whether op carries a value member
+func hasValue(op string) bool {
+	return op == OpAdd || op == OpReplace || op == OpTest
+}
+
+// MarshalJSON encodes op with only the members its kind uses, keeping a
+// nil value as null
+func (op Operation) MarshalJSON() ([]byte, error) {
+	wire := struct {
+		Op    string  `json:"op"`
+		From  *string `json:"from,omitempty"`
+		Path  string  `json:"path"`
+		Value *any    `json:"value,omitempty"`
+	}{Op: op.Op, Path: op.Path}
+	if op.Op == OpMove || op.Op == OpCopy {
+		wire.From = &op.From
+	}
+	if hasValue(op.Op) {
+		wire.Value = &op.Value
+	}
+	return json.Marshal(wire)
+}
+
+// UnmarshalJSON decodes an operation, numbers as json.Number, failing if
+// a member its kind needs is missing
+func (op *Operation) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var o Operation
+	for name, dst := range map[string]*string{"op": &o.Op, "path": &o.Path, "from": &o.From} {
+		if msg, ok := raw[name]; ok {
+			if err := json.Unmarshal(msg, dst); err != nil {
+				return fmt.Errorf("jsonx: %w: member %s: %w", ErrInvalidPatch, name, err)
+			}
+		}
+	}
+	if _, ok := raw["path"]; !ok {
+		return fmt.Errorf("jsonx: %w: missing path", ErrInvalidPatch)
+	}
+	if _, ok := raw["from"]; !ok && (o.Op == OpMove || o.Op == OpCopy) {
+		return fmt.Errorf("jsonx: %w: %s without from", ErrInvalidPatch, o.Op)
+	}
+	if msg, ok := raw["value"]; ok {
+		dec := json.NewDecoder(bytes.NewReader(msg))
+		dec.UseNumber()
+		if err := dec.Decode(&o.Value); err != nil {
+			return err
+		}
+	} else if hasValue(o.Op) {
+		return fmt.Errorf("jsonx: %w: %s without value", ErrInvalidPatch, o.Op)
+	}
+	*op = o
+	return nil
+}
+
+// Patch is an RFC 6902 JSON Patch: operations applied in order
+type Patch []Operation
+
+// PatchError is an operation of a patch that failed
+type PatchError struct {
+	Index int // The position of the operation in the patch
+	Op    Operation
+	Err   error
+}
+
+// Error names the operation and why it failed
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("jsonx: operation %d (%s %s): %v", e.Index, e.Op.Op, e.Op.Path, e.Err)
+}
+
+// Unwrap returns the cause
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// Apply returns the result of applying p to doc. The patch is atomic: if
+// an operation fails, Apply returns a *PatchError and no result. Neither
+// doc nor the values of p are modified, and the result shares no memory
+// with them.
+func (p Patch) Apply(doc any) (any, error) {
+	doc = mapper.DeepCopy(doc)
+	for i, op := range p {
+		var err error
+		if doc, err = apply(doc, op); err != nil {
+			return nil, &PatchError{Index: i, Op: op, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+// apply applies one operation to doc, which it may modify, returning the
+// new document
+func apply(doc any, op Operation) (any, error) {
+	path, err := ParsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case OpAdd:
+		return add(doc, path, mapper.DeepCopy(op.Value))
+	case OpRemove:
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case OpReplace:
+		if len(path) == 0 {
+			return mapper.DeepCopy(op.Value), nil
+		}
+		if _, err := path.Get(doc); err != nil {
+			return nil, err
+		}
+		if doc, _, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, mapper.DeepCopy(op.Value))
+	case OpMove, OpCopy:
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var v any
+		if op.Op == OpMove {
+			if len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+				return nil, fmt.Errorf("%w: cannot move %s into itself", ErrInvalidPatch, from)
+			}
+			if doc, v, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if v, err = from.Get(doc); err != nil {
+				return nil, err
+			}
+			v = mapper.DeepCopy(v)
+		}
+		return add(doc, path, v)
+	case OpTest:
+		v, err := path.Get(doc)
+		if err != nil {
+			return nil, err
+		}
+		if !Equal(v, op.Value) {
+			return nil, fmt.Errorf("%w: %s is not the value tested", ErrTestFailed, path)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+// update calls leaf with the container of the last token of path within
+// node, and returns node with that container replaced by what leaf
+// returns. Arrays change length, so every level is reassigned.
+func update(node any, path Pointer, leaf func(container any, tok string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return leaf(node, path[0])
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q", ErrNotFound, path[0])
+		}
+		c, err := update(child, path[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = c
+		return n, nil
+	case []any:
+		i, err := index(path[0], len(n), false)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNotFound, err)
+		}
+		c, err := update(n[i], path[1:], leaf)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = c
+		return n, nil
+	}
+	return nil, fmt.Errorf("%w: %q is inside a value that is not an object or array", ErrNotFound, path[0])
+}
+
+// add sets the member, or inserts the element, path refers to
+func add(doc any, path Pointer, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return update(doc, path, func(container any, tok string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			c[tok] = v
+			return c, nil
+		case []any:
+			i, err := index(tok, len(c), true)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrNotFound, err)
+			}
+			return slices.Insert(c, i, v), nil
+		}
+		return nil, fmt.Errorf("%w: cannot add %q to a value that is not an object or array", ErrNotFound, tok)
+	})
+}
+
+// remove deletes the member or element path refers to, returning it
+func remove(doc any, path Pointer) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	var removed any
+	doc, err := update(doc, path, func(container any, tok string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			v, ok := c[tok]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q", ErrNotFound, tok)
+			}
+			removed = v
+			delete(c, tok)
+			return c, nil
+		case []any:
+			i, err := index(tok, len(c), false)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrNotFound, err)
+			}
+			removed = c[i]
+			return slices.Delete(c, i, i+1), nil
+		}
+		return nil, fmt.Errorf("%w: %q is inside a value that is not an object or array", ErrNotFound, tok)
+	})
+	return doc, removed, err
+}
+
+// decode decodes a document, keeping numbers exact as json.Number
+func decode(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("jsonx: data after the document")
+	}
+	return nil
+}
+
+// ApplyJSON applies the encoded patch to the encoded document, returning
+// the encoded result. Numbers pass through exactly.
+func ApplyJSON(doc, patch []byte) ([]byte, error) {
+	var d any
+	if err := decode(doc, &d); err != nil {
+		return nil, fmt.Errorf("jsonx: document: %w", err)
+	}
+	var p Patch
+	if err := decode(patch, &p); err != nil {
+		return nil, fmt.Errorf("jsonx: patch: %w", err)
+	}
+	result, err := p.Apply(d)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
@@ -0,0 +1,147 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// equalJSON reports whether two encoded documents are equal
+func equalJSON(t *testing.T, a, b string) bool {
+	t.Helper()
+	var x, y any
+	if err := json.Unmarshal([]byte(a), &x); err != nil {
+		t.Fatalf("%s: %v", a, err)
+	}
+	if err := json.Unmarshal([]byte(b), &y); err != nil {
+		t.Fatalf("%s: %v", b, err)
+	}
+	return Equal(x, y)
+}
+
+// TestApplyJSON tests the examples of RFC 6902 appendix A
+func TestApplyJSON(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+		err                    error
+	}{
+		{"add member", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			`{"baz": "qux", "foo": "bar"}`, nil},
+		{"add element", `{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			`{"foo": ["bar", "qux", "baz"]}`, nil},
+		{"remove member", `{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`,
+			`{"foo": "bar"}`, nil},
+		{"remove element", `{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`,
+			`{"foo": ["bar", "baz"]}`, nil},
+		{"replace", `{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			`{"baz": "boo", "foo": "bar"}`, nil},
+		{"move member", `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`, nil},
+		{"move element", `{"foo": ["all", "grass", "cows", "eat"]}`,
+			`[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			`{"foo": ["all", "cows", "eat", "grass"]}`, nil},
+		{"test", `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`, nil},
+		{"test failure", `{"baz": "qux"}`, `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			``, ErrTestFailed},
+		{"add nested", `{"foo": "bar"}`, `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			`{"foo": "bar", "child": {"grandchild": {}}}`, nil},
+		{"ignore unknown members", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			`{"foo": "bar", "baz": "qux"}`, nil},
+		{"add to missing parent", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			``, ErrNotFound},
+		{"escaped tokens", `{"/": 9, "~1": 10}`, `[{"op": "test", "path": "/~01", "value": 10}]`,
+			`{"/": 9, "~1": 10}`, nil},
+		{"number compare", `{"/": 9, "~1": 10}`, `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			``, ErrTestFailed},
+		{"append array", `{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			`{"foo": ["bar", ["abc", "def"]]}`, nil},
+		{"replace root", `{"foo": 1}`, `[{"op": "replace", "path": "", "value": [1]}]`, `[1]`, nil},
+		{"copy", `{"a": {"b": 1}}`, `[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "add", "path": "/c/b", "value": 2}]`,
+			`{"a": {"b": 1}, "c": {"b": 2}}`, nil},
+		{"null value", `{}`, `[{"op": "add", "path": "/a", "value": null}]`, `{"a": null}`, nil},
+		{"replace missing", `{}`, `[{"op": "replace", "path": "/a", "value": 1}]`, ``, ErrNotFound},
+		{"remove past end", `[1]`, `[{"op": "remove", "path": "/1"}]`, ``, ErrNotFound},
+		{"add past end", `[1]`, `[{"op": "add", "path": "/2", "value": 3}]`, ``, ErrNotFound},
+		{"move into child", `{"a": {"b": {}}}`, `[{"op": "move", "from": "/a", "path": "/a/b/c"}]`, ``, ErrInvalidPatch},
+		{"remove root", `{}`, `[{"op": "remove", "path": ""}]`, ``, ErrInvalidPatch},
+		{"unknown op", `{}`, `[{"op": "merge", "path": "/a"}]`, ``, ErrInvalidPatch},
+		{"missing value", `{}`, `[{"op": "add", "path": "/a"}]`, ``, ErrInvalidPatch},
+		{"missing from", `{}`, `[{"op": "copy", "path": "/a"}]`, ``, ErrInvalidPatch},
+		{"missing path", `{}`, `[{"op": "remove"}]`, ``, ErrInvalidPatch},
+		{"bad pointer", `{}`, `[{"op": "remove", "path": "a"}]`, ``, ErrInvalidPointer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyJSON([]byte(tt.doc), []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ApplyJSON = %s, %v, want %v", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalJSON(t, string(got), tt.want) {
+				t.Errorf("ApplyJSON = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestApplyAtomic tests that a failed patch leaves nothing changed
+func TestApplyAtomic(t *testing.T) {
+	doc := map[string]any{"list": []any{1.0, 2.0}, "name": "a"}
+	p := Patch{
+		{Op: OpAdd, Path: "/list/0", Value: 0.0},
+		{Op: OpRemove, Path: "/name"},
+		{Op: OpTest, Path: "/list/0", Value: 1.0},
+	}
+	got, err := p.Apply(doc)
+	var pe *PatchError
+	if !errors.As(err, &pe) || pe.Index != 2 || !errors.Is(err, ErrTestFailed) || got != nil {
+		t.Fatalf("Apply = %v, %v, want a test failure at operation 2", got, err)
+	}
+	want := map[string]any{"list": []any{1.0, 2.0}, "name": "a"}
+	if !Equal(doc, want) {
+		t.Errorf("document changed to %v", doc)
+	}
+
+	// The result shares nothing with the patch values
+	value := map[string]any{"x": 1.0}
+	got, err = Patch{{Op: OpAdd, Path: "/v", Value: value}}.Apply(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value["x"] = 2.0
+	if v, _ := Resolve(got, "/v/x"); v != 1.0 {
+		t.Errorf("result changed with the patch value: %v", v)
+	}
+}
+
+// TestOperationJSON tests encoding operations with only their members
+func TestOperationJSON(t *testing.T) {
+	p := Patch{
+		{Op: OpAdd, Path: "/a", Value: nil},
+		{Op: OpRemove, Path: "/b", Value: "ignored"},
+		{Op: OpMove, From: "/c", Path: "/d"},
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"op":"add","path":"/a","value":null},{"op":"remove","path":"/b"},{"op":"move","from":"/c","path":"/d"}]`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+	var back Patch
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != 3 || back[0].Value != nil || back[2].From != "/c" || back[1].Value != nil {
+		t.Errorf("Unmarshal = %+v", back)
+	}
+}
@@ -0,0 +1,108 @@
+package jsonx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pointer is an RFC 6901 JSON Pointer, split into its reference tokens;
+// the empty Pointer refers to the whole document
+type Pointer []string
+
+// ParsePointer parses a pointer such as "/users/0/name". Within a token,
+// ~1 stands for / and ~0 for ~.
+func ParsePointer(s string) (Pointer, error) {
+	if s == "" {
+		return Pointer{}, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("jsonx: %w %q: must start with /", ErrInvalidPointer, s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, tok := range tokens {
+		for j := 0; j < len(tok); j++ {
+			if tok[j] != '~' {
+				continue
+			}
+			if j+1 == len(tok) || (tok[j+1] != '0' && tok[j+1] != '1') {
+				return nil, fmt.Errorf("jsonx: %w %q: ~ must be followed by 0 or 1", ErrInvalidPointer, s)
+			}
+			j++
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+	}
+	return Pointer(tokens), nil
+}
+
+// String returns the pointer in its written form, escaping its tokens
+func (p Pointer) String() string {
+	var b strings.Builder
+	for _, tok := range p {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(tok, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// Append returns a pointer to the member or element tok of the value p
+// refers to
+func (p Pointer) Append(tok string) Pointer {
+	return append(p[:len(p):len(p)], tok)
+}
+
+// Get returns the value p refers to within doc
+func (p Pointer) Get(doc any) (any, error) {
+	node := doc
+	for i, tok := range p {
+		switch n := node.(type) {
+		case map[string]any:
+			v, ok := n[tok]
+			if !ok {
+				return nil, p.notFound(i)
+			}
+			node = v
+		case []any:
+			idx, err := index(tok, len(n), false)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", p.notFound(i), err)
+			}
+			node = n[idx]
+		default:
+			return nil, p.notFound(i)
+		}
+	}
+	return node, nil
+}
+
+// notFound returns the error for a pointer whose token i has no target
+func (p Pointer) notFound(i int) error {
+	return fmt.Errorf("jsonx: %w: %s at %s", ErrNotFound, p, p[:i+1])
+}
+
+// index parses an array index token for an array of length n. The token
+// "-", past the last element, and index n are allowed only to add.
+func index(tok string, n int, adding bool) (int, error) {
+	if tok == "-" && adding {
+		return n, nil
+	}
+	// Secure: leading zeros and signs are not indexes, so "01" and "+1"
+	// cannot alias element 1
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') || strings.TrimLeft(tok, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i > n || (i == n && !adding) {
+		return 0, fmt.Errorf("array index %s out of range", tok)
+	}
+	return i, nil
+}
+
+// Resolve returns the value the pointer ptr refers to within doc
+func Resolve(doc any, ptr string) (any, error) {
+	p, err := ParsePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Get(doc)
+}
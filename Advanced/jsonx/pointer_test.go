@@ -0,0 +1,106 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// rfc6901 is the example document of RFC 6901
+const rfc6901 = `{
+	"foo": ["bar", "baz"],
+	"": 0,
+	"a/b": 1,
+	"c%d": 2,
+	"e^f": 3,
+	"g|h": 4,
+	"i\\j": 5,
+	"k\"l": 6,
+	" ": 7,
+	"m~n": 8
+}`
+
+// TestResolve tests the examples of RFC 6901
+func TestResolve(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(rfc6901), &doc); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ptr  string
+		want string
+	}{
+		{"", rfc6901},
+		{"/foo", `["bar", "baz"]`},
+		{"/foo/0", `"bar"`},
+		{"/", `0`},
+		{"/a~1b", `1`},
+		{"/c%d", `2`},
+		{"/e^f", `3`},
+		{"/g|h", `4`},
+		{"/i\\j", `5`},
+		{"/k\"l", `6`},
+		{"/ ", `7`},
+		{"/m~0n", `8`},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(doc, tt.ptr)
+		if err != nil {
+			t.Errorf("Resolve(%q): %v", tt.ptr, err)
+			continue
+		}
+		var want any
+		json.Unmarshal([]byte(tt.want), &want)
+		if !Equal(got, want) {
+			t.Errorf("Resolve(%q) = %v, want %v", tt.ptr, got, want)
+		}
+	}
+}
+
+// TestResolveErrors tests pointers that are malformed or miss
+func TestResolveErrors(t *testing.T) {
+	doc := map[string]any{"foo": []any{"bar"}, "n": 1.0}
+	tests := []struct {
+		ptr  string
+		want error
+	}{
+		{"foo", ErrInvalidPointer},
+		{"/m~2n", ErrInvalidPointer},
+		{"/m~", ErrInvalidPointer},
+		{"/missing", ErrNotFound},
+		{"/foo/1", ErrNotFound},
+		{"/foo/-", ErrNotFound},
+		{"/foo/00", ErrNotFound},
+		{"/foo/+0", ErrNotFound},
+		{"/foo/", ErrNotFound},
+		{"/n/0", ErrNotFound},
+	}
+	for _, tt := range tests {
+		if _, err := Resolve(doc, tt.ptr); !errors.Is(err, tt.want) {
+			t.Errorf("Resolve(%q) = %v, want %v", tt.ptr, err, tt.want)
+		}
+	}
+}
+
+// TestPointerString tests that tokens are escaped on the way out
+func TestPointerString(t *testing.T) {
+	p := Pointer{}.Append("a/b").Append("m~n").Append("")
+	if got, want := p.String(), "/a~1b/m~0n/"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	back, err := ParsePointer(p.String())
+	if err != nil || len(back) != 3 || back[0] != "a/b" || back[1] != "m~n" || back[2] != "" {
+		t.Errorf("ParsePointer(%q) = %q, %v", p, back, err)
+	}
+	// ~01 is an escaped ~ followed by 1, not a /
+	if back, _ := ParsePointer("/~01"); back[0] != "~1" {
+		t.Errorf("ParsePointer(/~01) = %q, want [~1]", back)
+	}
+
+	// Appending never writes into a shared backing array
+	base := make(Pointer, 1, 4)
+	x, y := base.Append("x"), base.Append("y")
+	if x[1] != "x" || y[1] != "y" {
+		t.Errorf("Append shared memory: %q, %q", x, y)
+	}
+}
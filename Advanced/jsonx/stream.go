@@ -0,0 +1,46 @@
+package jsonx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// Elements returns an iterator decoding the elements of the JSON array
+// that makes up r, one at a time, so only one element is in memory at
+// once. It yields an error, and stops, if r is not an array, an element
+// does not decode into T, or anything follows the array.
+func Elements[T any](r io.Reader) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		dec := json.NewDecoder(r)
+		tok, err := dec.Token()
+		if err != nil {
+			yield(zero, fmt.Errorf("jsonx: reading array: %w", err))
+			return
+		}
+		if tok != json.Delim('[') {
+			yield(zero, fmt.Errorf("jsonx: want an array, got %v", tok))
+			return
+		}
+		for i := 0; dec.More(); i++ {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				yield(zero, fmt.Errorf("jsonx: element %d: %w", i, err))
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			yield(zero, fmt.Errorf("jsonx: closing array: %w", err))
+			return
+		}
+		if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+			yield(zero, errors.New("jsonx: data after the array"))
+		}
+	}
+}
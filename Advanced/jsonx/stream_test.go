@@ -0,0 +1,72 @@
+package jsonx
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// hugeArray streams a JSON array of n events without building it
+func hugeArray(n int) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		fmt.Fprint(pw, "[")
+		for i := range n {
+			if i > 0 {
+				fmt.Fprint(pw, ",\n")
+			}
+			fmt.Fprintf(pw, `{"id": %d, "text": %q}`, i, strings.Repeat("x", 100))
+		}
+		fmt.Fprint(pw, "]\n")
+		pw.Close()
+	}()
+	return pr
+}
+
+// TestElements tests decoding a large array element by element
+func TestElements(t *testing.T) {
+	n, sum := 0, 0
+	for e, err := range Elements[event](hugeArray(20000)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+		sum += e.ID
+	}
+	if n != 20000 || sum != 20000*19999/2 {
+		t.Errorf("decoded %d elements summing to %d", n, sum)
+	}
+
+	// Stopping early leaves the rest unread
+	n = 0
+	for range Elements[event](strings.NewReader(`[{"id":1},{"id":2},{"id":3}]`)) {
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("saw %d elements, want to stop at 2", n)
+	}
+	for _, err := range Elements[any](strings.NewReader(`[]`)) {
+		t.Errorf("empty array yielded %v", err)
+	}
+}
+
+// TestElementsErrors tests input that is not a single array
+func TestElementsErrors(t *testing.T) {
+	for _, in := range []string{``, `{"a": 1}`, `[1, "x"]`, `[1, 2`, `[1] [2]`, `[1,]`} {
+		var err error
+		n := 0
+		for _, e := range Elements[int](strings.NewReader(in)) {
+			if e != nil {
+				err = e
+			} else {
+				n++
+			}
+		}
+		if err == nil {
+			t.Errorf("Elements(%q) gave %d elements and no error", in, n)
+		}
+	}
+}
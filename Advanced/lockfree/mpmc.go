@@ -0,0 +1,122 @@
+// Package lockfree provides bounded ring-buffer queues that coordinate
+// with atomic operations instead of locks. MPMC may be used by any number
+// of producers and consumers at once; SPSC allows one of each, and is
+// cheaper for it. Both are non-blocking: TryEnqueue fails when the queue
+// is full and TryDequeue when it is empty, leaving the caller to choose
+// whether to spin, back off or do something else.
+package lockfree
+
+import "sync/atomic"
+
+// cacheLine is the size of the padding that keeps fields written by
+// different goroutines on separate cache lines, so that they do not
+// invalidate each other's caches (false sharing)
+const cacheLine = 64
+
+// cell is a slot of an MPMC queue. Its sequence number says whose turn it
+// is: equal to the position of the next enqueue that may fill it, or one
+// past the position of the dequeue that may empty it.
+type cell[T any] struct {
+	seq atomic.Uint64
+	val T
+}
+
+// MPMC is a bounded multi-producer multi-consumer FIFO queue, after
+// Dmitry Vyukov's design: each operation claims a position with one
+// compare-and-swap and then hands its slot over through the slot's
+// sequence number. A producer or consumer preempted between the two
+// steps holds up the others only at that one slot.
+type MPMC[T any] struct {
+	_       [cacheLine]byte
+	enqueue atomic.Uint64 // Next position to fill
+	_       [cacheLine - 8]byte
+	dequeue atomic.Uint64 // Next position to empty
+	_       [cacheLine - 8]byte
+	mask    uint64
+	cells   []cell[T]
+}
+
+// NewMPMC creates a queue holding up to capacity values, rounded up to a
+// power of two of at least 2
+func NewMPMC[T any](capacity int) *MPMC[T] {
+	n := roundUp(max(capacity, 2))
+	q := &MPMC[T]{mask: uint64(n - 1), cells: make([]cell[T], n)}
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// roundUp returns the least power of two at least n, for n at least 1
+func roundUp(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// TryEnqueue adds v at the tail and reports true, or reports false
+// without waiting if the queue is full
+func (q *MPMC[T]) TryEnqueue(v T) bool {
+	pos := q.enqueue.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		switch dif := int64(c.seq.Load() - pos); {
+		case dif == 0:
+			// The slot is free for this position: claim it
+			if q.enqueue.CompareAndSwap(pos, pos+1) {
+				c.val = v
+				c.seq.Store(pos + 1)
+				return true
+			}
+			pos = q.enqueue.Load()
+		case dif < 0:
+			// The slot still holds the value from a lap ago
+			return false
+		default:
+			// Another producer claimed the position first
+			pos = q.enqueue.Load()
+		}
+	}
+}
+
+// TryDequeue removes and returns the value at the head and reports true,
+// or reports false without waiting if the queue is empty
+func (q *MPMC[T]) TryDequeue() (T, bool) {
+	pos := q.dequeue.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		switch dif := int64(c.seq.Load() - (pos + 1)); {
+		case dif == 0:
+			// The slot holds the value for this position: claim it
+			if q.dequeue.CompareAndSwap(pos, pos+1) {
+				v := c.val
+				var zero T
+				c.val = zero // Secure: drop the reference for the GC
+				c.seq.Store(pos + q.mask + 1)
+				return v, true
+			}
+			pos = q.dequeue.Load()
+		case dif < 0:
+			// No producer has filled the slot yet
+			var zero T
+			return zero, false
+		default:
+			// Another consumer claimed the position first
+			pos = q.dequeue.Load()
+		}
+	}
+}
+
+// Len returns the number of values queued. Under concurrent use it is a
+// snapshot that may already be stale.
+func (q *MPMC[T]) Len() int {
+	// Reading head first keeps tail-head from going negative, as the tail
+	// never falls behind the head
+	head := q.dequeue.Load()
+	return int(min(q.enqueue.Load()-head, q.mask+1))
+}
+
+// Cap returns the number of values the queue can hold
+func (q *MPMC[T]) Cap() int { return len(q.cells) }
@@ -0,0 +1,132 @@
+package lockfree
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// TestMPMC tests FIFO order and the full and empty cases
+func TestMPMC(t *testing.T) {
+	q := NewMPMC[int](3)
+	if q.Cap() != 4 {
+		t.Errorf("Cap() = %d, want 3 rounded up to 4", q.Cap())
+	}
+	if _, ok := q.TryDequeue(); ok {
+		t.Error("TryDequeue on an empty queue succeeded")
+	}
+	for lap := range 3 {
+		for i := range 4 {
+			if !q.TryEnqueue(lap*10 + i) {
+				t.Fatalf("lap %d: TryEnqueue %d failed", lap, i)
+			}
+		}
+		if q.TryEnqueue(99) || q.Len() != 4 {
+			t.Fatalf("lap %d: TryEnqueue on a full queue succeeded, len %d", lap, q.Len())
+		}
+		for i := range 4 {
+			if v, ok := q.TryDequeue(); !ok || v != lap*10+i {
+				t.Fatalf("lap %d: TryDequeue = %d, %v, want %d", lap, v, ok, lap*10+i)
+			}
+		}
+	}
+	if q.Len() != 0 || NewMPMC[int](0).Cap() != 2 {
+		t.Error("Len or minimum capacity mismatch")
+	}
+}
+
+// TestMPMCConcurrent tests that with several producers and consumers each
+// value comes out exactly once, and each producer's values in order; run
+// with -race
+func TestMPMCConcurrent(t *testing.T) {
+	const producers, consumers, perProducer = 4, 4, 2000
+	q := NewMPMC[[2]int](64)
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Go(func() {
+			for i := 0; i < perProducer; {
+				if q.TryEnqueue([2]int{p, i}) {
+					i++
+				} else {
+					runtime.Gosched()
+				}
+			}
+		})
+	}
+
+	var mu sync.Mutex
+	seen := make([][]int, producers)
+	var done sync.WaitGroup
+	for range consumers {
+		done.Go(func() {
+			last := make([]int, producers)
+			for i := range last {
+				last[i] = -1
+			}
+			got := make([][]int, producers)
+			for n := 0; n < producers*perProducer/consumers; {
+				v, ok := q.TryDequeue()
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				n++
+				if v[1] <= last[v[0]] {
+					t.Errorf("producer %d: %d after %d", v[0], v[1], last[v[0]])
+				}
+				last[v[0]] = v[1]
+				got[v[0]] = append(got[v[0]], v[1])
+			}
+			mu.Lock()
+			for p := range got {
+				seen[p] = append(seen[p], got[p]...)
+			}
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	done.Wait()
+
+	for p, values := range seen {
+		count := make([]int, perProducer)
+		for _, v := range values {
+			count[v]++
+		}
+		for i, c := range count {
+			if c != 1 {
+				t.Fatalf("producer %d value %d dequeued %d times", p, i, c)
+			}
+		}
+	}
+}
+
+// BenchmarkMPMC measures an enqueue and a dequeue by each of several
+// goroutines at once
+func BenchmarkMPMC(b *testing.B) {
+	q := NewMPMC[int](1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for !q.TryEnqueue(1) {
+				runtime.Gosched()
+			}
+			for {
+				if _, ok := q.TryDequeue(); ok {
+					break
+				}
+				runtime.Gosched()
+			}
+		}
+	})
+}
+
+// BenchmarkMPMCChannel measures the same with a buffered channel, the
+// queue Go programs reach for first
+func BenchmarkMPMCChannel(b *testing.B) {
+	ch := make(chan int, 1024)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ch <- 1
+			<-ch
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package lockfree
+
+import "sync/atomic"
+
+// SPSC is a bounded single-producer single-consumer FIFO queue. One
+// goroutine at a time may call TryEnqueue and one TryDequeue; each owns
+// one index and only reads the other's, keeping a cached copy so that it
+// touches the other's cache line only when the queue looks full or empty.
+type SPSC[T any] struct {
+	_          [cacheLine]byte
+	head       atomic.Uint64 // Next position to read, written by the consumer
+	cachedTail uint64        // The consumer's last view of tail
+	_          [cacheLine - 16]byte
+	tail       atomic.Uint64 // Next position to write, written by the producer
+	cachedHead uint64        // The producer's last view of head
+	_          [cacheLine - 16]byte
+	mask       uint64
+	buf        []T
+}
+
+// NewSPSC creates a queue holding up to capacity values, rounded up to a
+// power of two of at least 1
+func NewSPSC[T any](capacity int) *SPSC[T] {
+	n := roundUp(max(capacity, 1))
+	return &SPSC[T]{mask: uint64(n - 1), buf: make([]T, n)}
+}
+
+// TryEnqueue adds v at the tail and reports true, or reports false if the
+// queue is full. Only the producer may call it.
+func (q *SPSC[T]) TryEnqueue(v T) bool {
+	tail := q.tail.Load()
+	if tail-q.cachedHead > q.mask {
+		q.cachedHead = q.head.Load()
+		if tail-q.cachedHead > q.mask {
+			return false
+		}
+	}
+	q.buf[tail&q.mask] = v
+	q.tail.Store(tail + 1)
+	return true
+}
+
+// TryDequeue removes and returns the value at the head and reports true,
+// or reports false if the queue is empty. Only the consumer may call it.
+func (q *SPSC[T]) TryDequeue() (T, bool) {
+	var zero T
+	head := q.head.Load()
+	if head == q.cachedTail {
+		q.cachedTail = q.tail.Load()
+		if head == q.cachedTail {
+			return zero, false
+		}
+	}
+	v := q.buf[head&q.mask]
+	q.buf[head&q.mask] = zero // Secure: drop the reference for the GC
+	q.head.Store(head + 1)
+	return v, true
+}
+
+// Len returns the number of values queued. Under concurrent use it is a
+// snapshot that may already be stale.
+func (q *SPSC[T]) Len() int {
+	head := q.head.Load()
+	return int(min(q.tail.Load()-head, q.mask+1))
+}
+
+// Cap returns the number of values the queue can hold
+func (q *SPSC[T]) Cap() int { return len(q.buf) }
@@ -0,0 +1,94 @@
+package lockfree
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// TestSPSC tests FIFO order and the full and empty cases
+func TestSPSC(t *testing.T) {
+	q := NewSPSC[string](2)
+	if !q.TryEnqueue("a") || !q.TryEnqueue("b") || q.TryEnqueue("c") {
+		t.Fatal("capacity of 2 not respected")
+	}
+	if v, ok := q.TryDequeue(); !ok || v != "a" || q.Len() != 1 {
+		t.Errorf("TryDequeue = %q, %v, len %d", v, ok, q.Len())
+	}
+	if !q.TryEnqueue("c") {
+		t.Error("TryEnqueue after a dequeue failed")
+	}
+	for _, want := range []string{"b", "c"} {
+		if v, ok := q.TryDequeue(); !ok || v != want {
+			t.Errorf("TryDequeue = %q, %v, want %q", v, ok, want)
+		}
+	}
+	if _, ok := q.TryDequeue(); ok || NewSPSC[int](0).Cap() != 1 {
+		t.Error("empty queue or minimum capacity mismatch")
+	}
+}
+
+// TestSPSCConcurrent tests one producer and one consumer running at once;
+// run with -race
+func TestSPSCConcurrent(t *testing.T) {
+	const n = 20000
+	q := NewSPSC[int](128)
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for i := 0; i < n; {
+			if q.TryEnqueue(i) {
+				i++
+			} else {
+				runtime.Gosched()
+			}
+		}
+	})
+	for want := 0; want < n; {
+		v, ok := q.TryDequeue()
+		if !ok {
+			runtime.Gosched()
+			continue
+		}
+		if v != want {
+			t.Fatalf("dequeued %d, want %d", v, want)
+		}
+		want++
+	}
+	wg.Wait()
+}
+
+// BenchmarkSPSC measures passing values from one goroutine to another
+func BenchmarkSPSC(b *testing.B) {
+	q := NewSPSC[int](1024)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range b.N {
+			for !q.TryEnqueue(1) {
+				runtime.Gosched()
+			}
+		}
+	}()
+	for range b.N {
+		for {
+			if _, ok := q.TryDequeue(); ok {
+				break
+			}
+			runtime.Gosched()
+		}
+	}
+	<-done
+}
+
+// BenchmarkSPSCChannel measures the same with a buffered channel
+func BenchmarkSPSCChannel(b *testing.B) {
+	ch := make(chan int, 1024)
+	go func() {
+		for range b.N {
+			ch <- 1
+		}
+	}()
+	for range b.N {
+		<-ch
+	}
+}
@@ -0,0 +1,30 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestContext tests carrying loggers and request IDs in contexts
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if FromContext(ctx) != slog.Default() || RequestID(ctx) != "" {
+		t.Error("empty context should give the default logger and no request ID")
+	}
+
+	var buf bytes.Buffer
+	ctx = WithLogger(ctx, New(&buf, Options{}))
+	ctx = With(WithRequestID(ctx, "abc"), "user", "ann")
+	FromContext(ctx).InfoContext(ctx, "hello")
+	if got := buf.String(); !strings.Contains(got, "user=ann") || !strings.Contains(got, "request_id=abc") {
+		t.Errorf("record = %q", got)
+	}
+
+	a, b := NewRequestID(), NewRequestID()
+	if len(a) != 32 || a == b {
+		t.Errorf("NewRequestID gave %q and %q", a, b)
+	}
+}
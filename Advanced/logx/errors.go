@@ -0,0 +1,109 @@
+package logx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"runtime"
+	"slices"
+	"time"
+
+	"hellogolang/Advanced/errorsx"
+)
+
+// maxChain bounds how many errors of a chain Err lists, in case of a
+// cyclic or very deep chain
+const maxChain = 32
+
+// Err returns an "error" group attribute describing err: its message, the
+// messages of the errors it wraps, and the code, category, fields and
+// stack of the outermost errorsx.Coded in the chain. A nil err gives an
+// empty attribute, which handlers drop
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	attrs := []slog.Attr{slog.String("msg", err.Error())}
+	if chain := chainOf(err); len(chain) > 1 {
+		attrs = append(attrs, slog.Any("chain", chain[1:]))
+	}
+
+	var coded *errorsx.Coded
+	if errors.As(err, &coded) {
+		if coded.Code != "" {
+			attrs = append(attrs, slog.String("code", coded.Code))
+		}
+		attrs = append(attrs, slog.String("category", errorsx.CategoryOf(err).String()))
+		if len(coded.Fields) > 0 {
+			fields := make([]any, 0, len(coded.Fields))
+			for _, k := range slices.Sorted(maps.Keys(coded.Fields)) {
+				fields = append(fields, slog.Any(k, coded.Fields[k]))
+			}
+			attrs = append(attrs, slog.Group("fields", fields...))
+		}
+	}
+	if stack := stackOf(err); len(stack) > 0 {
+		attrs = append(attrs, slog.Any("stack", stack))
+	}
+	return slog.Attr{Key: "error", Value: slog.GroupValue(attrs...)}
+}
+
+// Error logs msg at LevelError with the logger ctx carries, adding Err(err)
+// and args. The record's source is the caller of Error
+func Error(ctx context.Context, msg string, err error, args ...any) {
+	l := FromContext(ctx)
+	if !l.Enabled(ctx, slog.LevelError) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	r := slog.NewRecord(time.Now(), slog.LevelError, msg, pcs[0])
+	r.AddAttrs(Err(err))
+	r.Add(args...)
+	_ = l.Handler().Handle(ctx, r)
+}
+
+// chainOf returns the messages of err and the errors it wraps, depth
+// first, so a Multi lists each of its members' chains in turn
+func chainOf(err error) []string {
+	var chain []string
+	var walk func(error)
+	walk = func(err error) {
+		for err != nil && len(chain) < maxChain {
+			chain = append(chain, err.Error())
+			switch u := err.(type) {
+			case interface{ Unwrap() error }:
+				err = u.Unwrap()
+			case interface{ Unwrap() []error }:
+				for _, member := range u.Unwrap() {
+					walk(member)
+				}
+				return
+			default:
+				return
+			}
+		}
+	}
+	walk(err)
+	return chain
+}
+
+// stackOf returns the stack of the first errorsx.Coded in err's chain that
+// recorded one, one "function file:line" entry per frame
+func stackOf(err error) []string {
+	for err != nil {
+		if coded, ok := err.(*errorsx.Coded); ok {
+			if frames := coded.Stack(); frames != nil {
+				stack := make([]string, len(frames))
+				for i, f := range frames {
+					stack[i] = fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line)
+				}
+				return stack
+			}
+		}
+		err = errors.Unwrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"hellogolang/Advanced/errorsx"
+)
+
+// TestErr tests the attribute describing a wrapped Coded error
+func TestErr(t *testing.T) {
+	root := errors.New("connection refused")
+	coded := errorsx.Wrap(root, errorsx.Unavailable, "DB_001", "store unreachable", "host", "db-1").WithStack()
+	err := fmt.Errorf("fetch user: %w", coded)
+
+	var buf bytes.Buffer
+	New(&buf, Options{Format: JSON}).Error("request failed", Err(err))
+	var rec struct {
+		Error struct {
+			Msg      string
+			Chain    []string
+			Code     string
+			Category string
+			Fields   map[string]any
+			Stack    []string
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	e := rec.Error
+	if e.Msg != err.Error() || e.Code != "DB_001" || e.Category != "unavailable" || e.Fields["host"] != "db-1" {
+		t.Errorf("error attribute = %+v", e)
+	}
+	if want := []string{coded.Error(), root.Error()}; fmt.Sprint(e.Chain) != fmt.Sprint(want) {
+		t.Errorf("chain = %q, want %q", e.Chain, want)
+	}
+	if len(e.Stack) == 0 || !strings.Contains(e.Stack[0], "TestErr") {
+		t.Errorf("stack = %q, want it to start in TestErr", e.Stack)
+	}
+
+	multi := errorsx.Append(fmt.Errorf("a: %w", root), errors.New("b"))
+	if got := chainOf(multi); len(got) != 4 || got[2] != root.Error() || got[3] != "b" {
+		t.Errorf("chain of a Multi = %q", got)
+	}
+	if !Err(nil).Equal(slog.Attr{}) {
+		t.Error("Err(nil) should be empty")
+	}
+}
+
+// TestError tests logging an error with the context's logger, and that
+// the source is the caller
+func TestError(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), New(&buf, Options{AddSource: true}))
+	ctx = WithRequestID(ctx, "r1")
+	Error(ctx, "save failed", errors.New("disk full"), "attempt", 2)
+	got := buf.String()
+	for _, want := range []string{"level=ERROR", `error.msg="disk full"`, "attempt=2", "request_id=r1", "errors_test.go:"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("record %q lacks %q", got, want)
+		}
+	}
+
+	buf.Reset()
+	quiet := WithLogger(ctx, New(&buf, Options{Level: slog.LevelError + 1}))
+	Error(quiet, "dropped", errors.New("x"))
+	if buf.Len() != 0 {
+		t.Errorf("record below the level was written: %q", buf.String())
+	}
+}
@@ -0,0 +1,85 @@
+// Package logx is a thin layer over log/slog for structured, leveled
+// logging. New builds a text or JSON logger from Options; the logger adds
+// the request ID carried by a context to every record logged with it, and
+// WithLogger and FromContext carry a logger itself through a request.
+// Err and Error log an error with its whole wrapped chain, and the code,
+// category, fields and stack trace of an errorsx.Coded within it.
+package logx
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Format selects how records are encoded
+type Format int
+
+const (
+	// Text writes records as key=value pairs, one record per line
+	Text Format = iota
+	// JSON writes records as JSON objects, one record per line
+	JSON
+)
+
+// Options configure a logger. The zero value logs text at LevelInfo and
+// above
+type Options struct {
+	// Level is the minimum level logged, defaulting to slog.LevelInfo; a
+	// *slog.LevelVar changes it while the logger is in use
+	Level slog.Leveler
+	// Format is Text or JSON
+	Format Format
+	// AddSource adds the file and line of the logging call
+	AddSource bool
+	// Now overrides the time recorded for each record, for tests
+	Now func() time.Time
+}
+
+// New returns a logger writing records to w as opts says
+func New(w io.Writer, opts Options) *slog.Logger {
+	ho := &slog.HandlerOptions{Level: opts.Level, AddSource: opts.AddSource}
+	if now := opts.Now; now != nil {
+		ho.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Time(slog.TimeKey, now())
+			}
+			return a
+		}
+	}
+
+	var h slog.Handler
+	switch opts.Format {
+	case JSON:
+		h = slog.NewJSONHandler(w, ho)
+	default:
+		h = slog.NewTextHandler(w, ho)
+	}
+	return slog.New(contextHandler{h})
+}
+
+// contextHandler adds the request ID of the context passed to the logger
+// to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds the request ID, if any, and passes r on
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler adding attrs, still adding request IDs
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler nesting later attributes under name, still
+// adding request IDs
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,77 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedNow is the time of every record in the tests
+func fixedNow() time.Time { return time.Unix(1000, 0).UTC() }
+
+// TestNew tests both formats, level filtering and changing the level
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	l := New(&buf, Options{Level: level, Format: JSON, Now: fixedNow})
+
+	l.Debug("hidden")
+	l.Info("user created", "user_id", 42, slog.Group("plan", "name", "pro"))
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("output %q is not one JSON record: %v", buf.String(), err)
+	}
+	if rec["msg"] != "user created" || rec["level"] != "INFO" || rec["user_id"] != 42.0 ||
+		rec["time"] != "1970-01-01T00:16:40Z" || rec["plan"].(map[string]any)["name"] != "pro" {
+		t.Errorf("record = %v", rec)
+	}
+
+	buf.Reset()
+	level.Set(slog.LevelDebug)
+	l.Debug("shown")
+	if !strings.Contains(buf.String(), `"msg":"shown"`) {
+		t.Errorf("debug record after lowering the level = %q", buf.String())
+	}
+
+	buf.Reset()
+	New(&buf, Options{Now: fixedNow}).Warn("disk low", "free", "5%")
+	if got, want := buf.String(), "time=1970-01-01T00:16:40.000Z level=WARN msg=\"disk low\" free=5%\n"; got != want {
+		t.Errorf("text record = %q, want %q", got, want)
+	}
+}
+
+// TestAddSource tests that the source is the logging call
+func TestAddSource(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, Options{AddSource: true}).Info("here")
+	if !strings.Contains(buf.String(), "logx_test.go:") {
+		t.Errorf("record %q has no source in the test file", buf.String())
+	}
+}
+
+// TestRequestID tests that records logged with a context carry its
+// request ID, also through derived loggers
+func TestRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Options{Format: JSON})
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	l.With("component", "api").InfoContext(ctx, "handled")
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec[RequestIDKey] != "req-1" || rec["component"] != "api" {
+		t.Errorf("record = %v", rec)
+	}
+
+	buf.Reset()
+	l.Info("no context")
+	if strings.Contains(buf.String(), RequestIDKey) {
+		t.Errorf("record without a context = %q", buf.String())
+	}
+}
@@ -0,0 +1,124 @@
+// Package mapper copies, converts and compares values by reflection.
+// DeepCopy copies a value and everything it points to; Map copies a
+// struct into a struct of another type field by field, converting values
+// as it goes; ToMap and FromMap convert between structs and maps; Diff
+// lists the paths of the fields that differ between two values.
+//
+// Fields are matched by the name in their map tag, or by their Go name
+// without one; map:"-" skips a field. All of them follow cycles of
+// pointers without looping, and none of them reads or writes unexported
+// fields through unsafe means: DeepCopy copies them as a plain assignment
+// would, and the others leave them alone.
+package mapper
+
+import (
+	"errors"
+	"reflect"
+)
+
+var (
+	// ErrConvert is wrapped by the error for a value that cannot be
+	// converted to the type of the field it is mapped to
+	ErrConvert = errors.New("cannot convert")
+	// ErrCycle is returned by ToMap for a value that refers to itself,
+	// which a tree of maps cannot represent
+	ErrCycle = errors.New("cycle of pointers")
+)
+
+// ptrKey identifies a pointer or map already copied: the same address
+// seen as two types is two different values
+type ptrKey struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// DeepCopy returns a copy of v sharing no memory with it through pointers,
+// slices, maps or interfaces. Pointers to the same value stay pointers to
+// one copy, so cycles are copied as cycles. Channels and functions are
+// shared, and unexported fields are copied shallowly, as by assignment,
+// since they cannot be reached safely.
+func DeepCopy[T any](v T) T {
+	src := reflect.ValueOf(&v).Elem()
+	dst := reflect.New(src.Type()).Elem()
+	c := copier{seen: make(map[ptrKey]reflect.Value)}
+	c.copy(dst, src)
+	return *dst.Addr().Interface().(*T)
+}
+
+// copier is one run of DeepCopy
+type copier struct {
+	seen map[ptrKey]reflect.Value // Copies of the pointers and maps met so far
+}
+
+// copy sets dst to a deep copy of src, of the same type
+func (c *copier) copy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			dst.SetZero()
+			return
+		}
+		key := ptrKey{src.Pointer(), src.Type()}
+		if p, ok := c.seen[key]; ok {
+			dst.Set(p)
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		c.seen[key] = p
+		c.copy(p.Elem(), src.Elem())
+		dst.Set(p)
+	case reflect.Interface:
+		if src.IsNil() {
+			dst.SetZero()
+			return
+		}
+		elem := reflect.New(src.Elem().Type()).Elem()
+		c.copy(elem, src.Elem())
+		dst.Set(elem)
+	case reflect.Struct:
+		// Unexported fields come along with the assignment; exported ones
+		// are then replaced by deep copies
+		dst.Set(src)
+		for i := range src.NumField() {
+			if src.Type().Field(i).IsExported() {
+				c.copy(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			dst.SetZero()
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := range src.Len() {
+			c.copy(s.Index(i), src.Index(i))
+		}
+		dst.Set(s)
+	case reflect.Array:
+		for i := range src.Len() {
+			c.copy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			dst.SetZero()
+			return
+		}
+		key := ptrKey{src.Pointer(), src.Type()}
+		if m, ok := c.seen[key]; ok {
+			dst.Set(m)
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		c.seen[key] = m
+		for iter := src.MapRange(); iter.Next(); {
+			k := reflect.New(src.Type().Key()).Elem()
+			c.copy(k, iter.Key())
+			v := reflect.New(src.Type().Elem()).Elem()
+			c.copy(v, iter.Value())
+			m.SetMapIndex(k, v)
+		}
+		dst.Set(m)
+	default:
+		dst.Set(src)
+	}
+}
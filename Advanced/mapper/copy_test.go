@@ -0,0 +1,73 @@
+package mapper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type address struct {
+	Street string
+	City   string `map:"city"`
+}
+
+type person struct {
+	Name     string
+	Age      int
+	Born     time.Time
+	Address  *address
+	Tags     []string
+	Scores   map[string][]int
+	Friends  []*person
+	Extra    any
+	Grid     [2][]int
+	OnChange func()
+	secret   *string
+}
+
+// TestDeepCopy tests that a copy shares no memory with the original
+func TestDeepCopy(t *testing.T) {
+	s := "hidden"
+	p := &person{
+		Name: "Ann", Age: 30, Born: time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC),
+		Address: &address{Street: "Main", City: "Oslo"},
+		Tags:    []string{"a", "b"},
+		Scores:  map[string][]int{"go": {1, 2}},
+		Extra:   map[string]any{"k": []int{1}},
+		Grid:    [2][]int{{1}, {2}},
+		secret:  &s,
+	}
+	p.Friends = []*person{p}
+	c := DeepCopy(p)
+	if !reflect.DeepEqual(c, p) {
+		t.Fatalf("DeepCopy = %+v, want equal to %+v", c, p)
+	}
+	if c == p || c.Address == p.Address || &c.Tags[0] == &p.Tags[0] || &c.Scores["go"][0] == &p.Scores["go"][0] ||
+		&c.Grid[0][0] == &p.Grid[0][0] {
+		t.Error("DeepCopy shares memory with the original")
+	}
+	if c.Friends[0] != c {
+		t.Error("DeepCopy broke the cycle, want a pointer to the copy itself")
+	}
+	if c.secret != p.secret {
+		t.Error("DeepCopy copied an unexported field deeply, want it assigned")
+	}
+
+	c.Extra.(map[string]any)["k"].([]int)[0] = 9
+	c.Address.City = "Bergen"
+	if p.Extra.(map[string]any)["k"].([]int)[0] != 1 || p.Address.City != "Oslo" {
+		t.Error("changing the copy changed the original")
+	}
+
+	if got := DeepCopy[any](nil); got != nil {
+		t.Errorf("DeepCopy(nil) = %v", got)
+	}
+	if got := DeepCopy([]int(nil)); got != nil {
+		t.Errorf("DeepCopy of a nil slice = %v, want nil", got)
+	}
+	m := map[string]any{}
+	m["self"] = m
+	if c := DeepCopy(m); reflect.ValueOf(c["self"]).Pointer() != reflect.ValueOf(c).Pointer() {
+		t.Error("DeepCopy of a map holding itself did not keep the cycle")
+	}
+}
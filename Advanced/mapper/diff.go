@@ -0,0 +1,143 @@
+package mapper
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Diff returns the paths of the values that differ between a and b, as
+// "Address.City", "Tags[2]" or "Meta[key]", in field order, or nil if
+// they are equal. A slice longer on one side differs at each index beyond
+// the other, and a map at each key only one side has. The path of a
+// difference at the top, as between two unequal numbers, is "".
+//
+// Values of a type with an Equal method, such as time.Time, are compared
+// with it; other structs field by field, skipping unexported fields, which
+// Diff does not read. Functions are equal only if both are nil.
+func Diff[T any](a, b T) []string {
+	d := differ{seen: make(map[[2]uintptr]bool)}
+	d.diff("", reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+	return d.paths
+}
+
+// differ is one run of Diff
+type differ struct {
+	paths []string
+	seen  map[[2]uintptr]bool // Pairs of pointers compared, or being compared
+}
+
+// changed records a difference at path p
+func (d *differ) changed(p string) {
+	d.paths = append(d.paths, p)
+}
+
+// diff compares a and b, of the same type, at path p
+func (d *differ) diff(p string, a, b reflect.Value) {
+	if eq, ok := equalMethod(a, b); ok {
+		if !eq {
+			d.changed(p)
+		}
+		return
+	}
+	switch a.Kind() {
+	case reflect.Pointer:
+		switch {
+		case a.IsNil() || b.IsNil():
+			if a.IsNil() != b.IsNil() {
+				d.changed(p)
+			}
+			return
+		case a.Pointer() == b.Pointer():
+			return
+		}
+		// A pair met again is being compared further up; any difference
+		// in it is reported there
+		pair := [2]uintptr{a.Pointer(), b.Pointer()}
+		if d.seen[pair] {
+			return
+		}
+		d.seen[pair] = true
+		d.diff(p, a.Elem(), b.Elem())
+	case reflect.Interface:
+		switch {
+		case a.IsNil() || b.IsNil():
+			if a.IsNil() != b.IsNil() {
+				d.changed(p)
+			}
+		case a.Elem().Type() != b.Elem().Type():
+			d.changed(p)
+		default:
+			d.diff(p, a.Elem(), b.Elem())
+		}
+	case reflect.Struct:
+		fields := 0
+		for i := range a.NumField() {
+			name, ok := key(a.Type().Field(i))
+			if !ok {
+				continue
+			}
+			fields++
+			d.diff(path(p, name), a.Field(i), b.Field(i))
+		}
+		// A struct whose fields are all hidden is compared whole, if it can
+		// be
+		if fields == 0 && a.Type().Comparable() && a.CanInterface() && a.Interface() != b.Interface() {
+			d.changed(p)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range max(a.Len(), b.Len()) {
+			elem := fmt.Sprintf("%s[%d]", p, i)
+			if i >= a.Len() || i >= b.Len() {
+				d.changed(elem)
+				continue
+			}
+			d.diff(elem, a.Index(i), b.Index(i))
+		}
+	case reflect.Map:
+		keys := a.MapKeys()
+		for _, k := range b.MapKeys() {
+			if !a.MapIndex(k).IsValid() {
+				keys = append(keys, k)
+			}
+		}
+		slices.SortFunc(keys, func(x, y reflect.Value) int {
+			return cmp.Compare(fmt.Sprint(x), fmt.Sprint(y))
+		})
+		for _, k := range keys {
+			elem := fmt.Sprintf("%s[%v]", p, k)
+			av, bv := a.MapIndex(k), b.MapIndex(k)
+			if !av.IsValid() || !bv.IsValid() {
+				d.changed(elem)
+				continue
+			}
+			d.diff(elem, av, bv)
+		}
+	case reflect.Func:
+		if !a.IsNil() || !b.IsNil() {
+			d.changed(p)
+		}
+	default:
+		if !a.Equal(b) {
+			d.changed(p)
+		}
+	}
+}
+
+// equalMethod compares a and b with their type's Equal method, if it has
+// one taking the same type, reporting whether it did
+func equalMethod(a, b reflect.Value) (equal, ok bool) {
+	if !a.CanInterface() {
+		return false, false
+	}
+	m, ok := a.Type().MethodByName("Equal")
+	if !ok || m.Type.NumIn() != 2 || m.Type.In(1) != a.Type() ||
+		m.Type.NumOut() != 1 || m.Type.Out(0).Kind() != reflect.Bool {
+		return false, false
+	}
+	if a.Kind() == reflect.Pointer && (a.IsNil() || b.IsNil()) {
+		return false, false
+	}
+	return m.Func.Call([]reflect.Value{a, b})[0].Bool(), true
+}
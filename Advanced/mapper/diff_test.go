@@ -0,0 +1,61 @@
+package mapper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestDiff tests the paths of differences
+func TestDiff(t *testing.T) {
+	born := time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+	a := person{
+		Name: "Ann", Born: born, Address: &address{City: "Oslo"},
+		Tags: []string{"a", "b"}, Scores: map[string][]int{"go": {1}, "py": {2}},
+		Extra: 1,
+	}
+	b := DeepCopy(a)
+	if got := Diff(a, b); got != nil {
+		t.Errorf("Diff of copies = %v, want nil", got)
+	}
+
+	b.Name = "Anne"
+	b.Born = born.In(time.FixedZone("X", 3600)) // The same instant
+	b.Address.City = "Bergen"
+	b.Tags = append(b.Tags, "c")
+	b.Tags[0] = "z"
+	delete(b.Scores, "py")
+	b.Scores["go"] = []int{5}
+	b.Scores["rs"] = nil
+	b.Extra = "1"
+	s := "x"
+	b.secret = &s
+	want := []string{"Name", "Address.city", "Tags[0]", "Tags[2]", "Scores[go][0]", "Scores[py]", "Scores[rs]", "Extra"}
+	if got := Diff(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %v, want %v", got, want)
+	}
+
+	b = DeepCopy(a)
+	b.Address = nil
+	b.OnChange = func() {}
+	if got := Diff(a, b); !reflect.DeepEqual(got, []string{"Address", "OnChange"}) {
+		t.Errorf("Diff = %v, want Address and OnChange", got)
+	}
+	if got := Diff(1, 2); !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("Diff(1, 2) = %v, want the top path", got)
+	}
+}
+
+// TestDiffCycle tests that cycles are compared once
+func TestDiffCycle(t *testing.T) {
+	a := &person{Name: "a"}
+	a.Friends = []*person{a}
+	b := DeepCopy(a)
+	if got := Diff(a, b); got != nil {
+		t.Errorf("Diff of copied cycles = %v, want nil", got)
+	}
+	b.Name = "b"
+	if got := Diff(a, b); !reflect.DeepEqual(got, []string{"Name"}) {
+		t.Errorf("Diff = %v, want Name once", got)
+	}
+}
@@ -0,0 +1,404 @@
+package mapper
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Map copies the fields of src, a struct or a pointer to one, into the
+// fields of the same name of dst, a pointer to a struct of any type.
+// Fields only one side has are left alone. Values are converted as their
+// types require:
+//
+//   - numbers of any kind to each other, failing rather than overflowing
+//     or dropping a fraction
+//   - numbers and booleans to and from strings
+//   - values with MarshalText to strings, and strings to values with
+//     UnmarshalText
+//   - structs to structs field by field, and maps with string keys to
+//     structs key by key
+//   - slices, arrays and maps element by element
+//   - through pointers, on either side
+//
+// Values of the same type are deep copied. Map stops at the first value it
+// cannot convert, returning an error naming its path and wrapping
+// ErrConvert; dst may then be partly written.
+func Map(dst, src any) error {
+	d := reflect.ValueOf(dst)
+	if d.Kind() != reflect.Pointer || d.IsNil() {
+		panic(fmt.Sprintf("mapper: Map into %T, want a non-nil pointer", dst))
+	}
+	m := newMapping()
+	return m.convert("", d.Elem(), reflect.ValueOf(src))
+}
+
+// key returns the name a field is matched by, and false if it is skipped
+func key(sf reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(sf.Tag.Get("map"), ",")
+	if !sf.IsExported() || name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = sf.Name
+	}
+	return name, true
+}
+
+// fieldsOf returns the exported fields of the struct type t by key
+func fieldsOf(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := range t.NumField() {
+		if name, ok := key(t.Field(i)); ok {
+			fields[name] = i
+		}
+	}
+	return fields
+}
+
+// mapping is one run of Map or FromMap
+type mapping struct {
+	copier copier
+	// seen maps each source pointer converted so far, and the type it was
+	// converted to, to its converted pointer
+	seen map[ptrKey]map[reflect.Type]reflect.Value
+}
+
+// newMapping returns a mapping with nothing seen yet
+func newMapping() *mapping {
+	return &mapping{
+		copier: copier{seen: make(map[ptrKey]reflect.Value)},
+		seen:   make(map[ptrKey]map[reflect.Type]reflect.Value),
+	}
+}
+
+var (
+	textMarshaler   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// path joins a path and a field name
+func path(p, name string) string {
+	if p == "" {
+		return name
+	}
+	return p + "." + name
+}
+
+// fail returns the error for src that cannot be converted to dst
+func fail(p string, dst reflect.Value, src reflect.Value, cause error) error {
+	where := p
+	if where == "" {
+		where = "value"
+	}
+	if cause != nil {
+		return fmt.Errorf("mapper: %s: %w %v to %v: %v", where, ErrConvert, src.Type(), dst.Type(), cause)
+	}
+	return fmt.Errorf("mapper: %s: %w %v to %v", where, ErrConvert, src.Type(), dst.Type())
+}
+
+// convert sets dst from src, converting as Map describes
+func (m *mapping) convert(p string, dst, src reflect.Value) error {
+	for src.Kind() == reflect.Interface && !src.IsNil() {
+		src = src.Elem()
+	}
+	if !src.IsValid() || (src.Kind() == reflect.Interface && src.IsNil()) {
+		dst.SetZero()
+		return nil
+	}
+	if src.Type() == dst.Type() {
+		m.copier.copy(dst, src)
+		return nil
+	}
+	if dst.Kind() == reflect.Pointer {
+		return m.toPointer(p, dst, src)
+	}
+	if src.Kind() == reflect.Pointer {
+		if src.IsNil() {
+			dst.SetZero()
+			return nil
+		}
+		return m.convert(p, dst, src.Elem())
+	}
+	if dst.Kind() == reflect.Interface {
+		if !src.Type().AssignableTo(dst.Type()) {
+			return fail(p, dst, src, nil)
+		}
+		v := reflect.New(src.Type()).Elem()
+		m.copier.copy(v, src)
+		dst.Set(v)
+		return nil
+	}
+	if ok, err := m.text(dst, src); ok {
+		if err != nil {
+			return fail(p, dst, src, err)
+		}
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.Struct:
+		return m.toStruct(p, dst, src)
+	case reflect.Slice, reflect.Array:
+		return m.toList(p, dst, src)
+	case reflect.Map:
+		return m.toMap(p, dst, src)
+	case reflect.String:
+		return toString(p, dst, src)
+	case reflect.Bool:
+		switch src.Kind() {
+		case reflect.Bool:
+			dst.SetBool(src.Bool())
+			return nil
+		case reflect.String:
+			b, err := strconv.ParseBool(src.String())
+			if err != nil {
+				return fail(p, dst, src, err)
+			}
+			dst.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return toNumber(p, dst, src)
+	}
+	if src.Type().ConvertibleTo(dst.Type()) && src.Kind() == dst.Kind() {
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+	return fail(p, dst, src, nil)
+}
+
+// toPointer sets the pointer dst to a new value converted from src. A
+// source pointer converted before to the same type gives the same new
+// pointer, which keeps cycles finite.
+func (m *mapping) toPointer(p string, dst, src reflect.Value) error {
+	if src.Kind() == reflect.Pointer {
+		if src.IsNil() {
+			dst.SetZero()
+			return nil
+		}
+		k := ptrKey{src.Pointer(), src.Type()}
+		if prev, ok := m.seen[k][dst.Type()]; ok {
+			dst.Set(prev)
+			return nil
+		}
+		if m.seen[k] == nil {
+			m.seen[k] = make(map[reflect.Type]reflect.Value)
+		}
+		ptr := reflect.New(dst.Type().Elem())
+		m.seen[k][dst.Type()] = ptr
+		dst.Set(ptr)
+		return m.convert(p, ptr.Elem(), src.Elem())
+	}
+	ptr := reflect.New(dst.Type().Elem())
+	if err := m.convert(p, ptr.Elem(), src); err != nil {
+		return err
+	}
+	dst.Set(ptr)
+	return nil
+}
+
+// text converts through MarshalText or UnmarshalText, reporting whether
+// either applied
+func (m *mapping) text(dst, src reflect.Value) (bool, error) {
+	if src.Kind() == reflect.String && reflect.PointerTo(dst.Type()).Implements(textUnmarshaler) {
+		return true, dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(src.String()))
+	}
+	if dst.Kind() == reflect.String && src.Type().Implements(textMarshaler) {
+		text, err := src.Interface().(encoding.TextMarshaler).MarshalText()
+		if err == nil {
+			dst.SetString(string(text))
+		}
+		return true, err
+	}
+	return false, nil
+}
+
+// toStruct sets the struct dst from a struct or a map with string keys
+func (m *mapping) toStruct(p string, dst, src reflect.Value) error {
+	switch {
+	case src.Kind() == reflect.Struct:
+		srcFields := fieldsOf(src.Type())
+		for i := range dst.NumField() {
+			name, ok := key(dst.Type().Field(i))
+			if !ok {
+				continue
+			}
+			j, ok := srcFields[name]
+			if !ok {
+				continue
+			}
+			if err := m.convert(path(p, name), dst.Field(i), src.Field(j)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case src.Kind() == reflect.Map && src.Type().Key().Kind() == reflect.String:
+		fields := fieldsOf(dst.Type())
+		for iter := src.MapRange(); iter.Next(); {
+			name := iter.Key().String()
+			i, ok := fields[name]
+			if !ok {
+				continue
+			}
+			if err := m.convert(path(p, name), dst.Field(i), iter.Value()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fail(p, dst, src, nil)
+}
+
+// toList sets the slice or array dst from a slice or array, element by
+// element
+func (m *mapping) toList(p string, dst, src reflect.Value) error {
+	if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+		return fail(p, dst, src, nil)
+	}
+	if dst.Kind() == reflect.Array {
+		if src.Len() != dst.Len() {
+			return fail(p, dst, src, fmt.Errorf("%d elements for %d", src.Len(), dst.Len()))
+		}
+	} else if src.Kind() == reflect.Slice && src.IsNil() {
+		dst.SetZero()
+		return nil
+	} else {
+		dst.Set(reflect.MakeSlice(dst.Type(), src.Len(), src.Len()))
+	}
+	for i := range src.Len() {
+		if err := m.convert(fmt.Sprintf("%s[%d]", p, i), dst.Index(i), src.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toMap sets the map dst from a map, converting its keys and values
+func (m *mapping) toMap(p string, dst, src reflect.Value) error {
+	if src.Kind() != reflect.Map {
+		return fail(p, dst, src, nil)
+	}
+	if src.IsNil() {
+		dst.SetZero()
+		return nil
+	}
+	out := reflect.MakeMapWithSize(dst.Type(), src.Len())
+	for iter := src.MapRange(); iter.Next(); {
+		elemPath := fmt.Sprintf("%s[%v]", p, iter.Key())
+		k := reflect.New(dst.Type().Key()).Elem()
+		if err := m.convert(elemPath, k, iter.Key()); err != nil {
+			return err
+		}
+		v := reflect.New(dst.Type().Elem()).Elem()
+		if err := m.convert(elemPath, v, iter.Value()); err != nil {
+			return err
+		}
+		out.SetMapIndex(k, v)
+	}
+	dst.Set(out)
+	return nil
+}
+
+// toString sets the string dst from a string, number or boolean
+func toString(p string, dst, src reflect.Value) error {
+	switch src.Kind() {
+	case reflect.String:
+		dst.SetString(src.String())
+	case reflect.Bool:
+		dst.SetString(strconv.FormatBool(src.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		dst.SetString(strconv.FormatInt(src.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		dst.SetString(strconv.FormatUint(src.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		dst.SetString(strconv.FormatFloat(src.Float(), 'g', -1, src.Type().Bits()))
+	default:
+		return fail(p, dst, src, nil)
+	}
+	return nil
+}
+
+// toNumber sets the number dst from a number or a string, failing if the
+// value does not fit exactly
+func toNumber(p string, dst, src reflect.Value) error {
+	var f float64
+	var i int64
+	var u uint64
+	var isInt, isUint, isFloat bool
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, isInt = src.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, isUint = src.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		f, isFloat = src.Float(), true
+	case reflect.String:
+		s := strings.TrimSpace(src.String())
+		var err error
+		if i, err = strconv.ParseInt(s, 0, 64); err == nil {
+			isInt = true
+		} else if u, err = strconv.ParseUint(s, 0, 64); err == nil {
+			isUint = true
+		} else if f, err = strconv.ParseFloat(s, 64); err == nil {
+			isFloat = true
+		} else {
+			return fail(p, dst, src, err)
+		}
+	default:
+		return fail(p, dst, src, nil)
+	}
+	overflow := func() error { return fail(p, dst, src, fmt.Errorf("value out of range")) }
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch {
+		case isUint:
+			if u > math.MaxInt64 {
+				return overflow()
+			}
+			i = int64(u)
+		case isFloat:
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return overflow()
+			}
+			i = int64(f)
+		}
+		if dst.OverflowInt(i) {
+			return overflow()
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch {
+		case isInt:
+			if i < 0 {
+				return overflow()
+			}
+			u = uint64(i)
+		case isFloat:
+			if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+				return overflow()
+			}
+			u = uint64(f)
+		}
+		if dst.OverflowUint(u) {
+			return overflow()
+		}
+		dst.SetUint(u)
+	default:
+		switch {
+		case isInt:
+			f = float64(i)
+		case isUint:
+			f = float64(u)
+		}
+		if dst.OverflowFloat(f) {
+			return overflow()
+		}
+		dst.SetFloat(f)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package mapper
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type userRow struct {
+	ID       int64
+	Name     string
+	Email    string `map:"mail"`
+	Age      string
+	Score    float64
+	IP       string
+	Created  time.Time
+	Home     *address
+	Tags     []string
+	Limits   map[string]int32
+	Password string `map:"-"`
+	internal int
+}
+
+type userDTO struct {
+	ID       string
+	Name     *string
+	Mail     string `map:"mail"`
+	Age      uint8
+	Score    int
+	IP       netip.Addr
+	Created  time.Time
+	Home     addressDTO
+	Tags     [2]string
+	Limits   map[string]float64
+	Password string
+	Missing  bool
+	internal int
+}
+
+type addressDTO struct {
+	City string `map:"city"`
+}
+
+// TestMap tests mapping fields by name and tag with conversions
+func TestMap(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	row := userRow{
+		ID: 42, Name: "Ann", Email: "ann@example.com", Age: "31", Score: 9, IP: "10.0.0.1",
+		Created: now, Home: &address{Street: "Main", City: "Oslo"}, Tags: []string{"a", "b"},
+		Limits: map[string]int32{"rps": 10}, Password: "hunter2", internal: 7,
+	}
+	var dto userDTO
+	if err := Map(&dto, &row); err != nil {
+		t.Fatal(err)
+	}
+	want := userDTO{
+		ID: "42", Mail: "ann@example.com", Age: 31, Score: 9, IP: netip.MustParseAddr("10.0.0.1"),
+		Created: now, Home: addressDTO{City: "Oslo"}, Tags: [2]string{"a", "b"},
+		Limits: map[string]float64{"rps": 10},
+	}
+	if dto.Name == nil || *dto.Name != "Ann" {
+		t.Errorf("Name = %v, want a pointer to Ann", dto.Name)
+	}
+	dto.Name = nil
+	if !reflect.DeepEqual(dto, want) {
+		t.Errorf("Map =\n%+v\nwant\n%+v", dto, want)
+	}
+
+	// And back, through MarshalText and string parsing
+	var back userRow
+	if err := Map(&back, dto); err != nil {
+		t.Fatal(err)
+	}
+	if back.ID != 42 || back.IP != "10.0.0.1" || back.Age != "31" || back.Home.City != "Oslo" || back.Limits["rps"] != 10 {
+		t.Errorf("Map back = %+v", back)
+	}
+}
+
+// TestMapErrors tests values that cannot be converted
+func TestMapErrors(t *testing.T) {
+	tests := []struct {
+		src  userRow
+		path string
+	}{
+		{userRow{Age: "300"}, "Age"},
+		{userRow{Age: "-1"}, "Age"},
+		{userRow{Age: "old"}, "Age"},
+		{userRow{}, "Age"},
+		{userRow{Age: "1", Score: 1.5}, "Score"},
+		{userRow{Age: "1", IP: "nope"}, "IP"},
+		{userRow{Age: "1", Tags: []string{"a", "b", "c"}}, "Tags"},
+	}
+	for _, tt := range tests {
+		var dto userDTO
+		err := Map(&dto, tt.src)
+		if !errors.Is(err, ErrConvert) || !strings.Contains(err.Error(), "mapper: "+tt.path+":") {
+			t.Errorf("Map %+v = %v, want ErrConvert at %s", tt.src, err, tt.path)
+		}
+	}
+
+	type nested struct{ Items []struct{ N int8 } }
+	src := struct{ Items []struct{ N int } }{Items: []struct{ N int }{{1}, {200}}}
+	var dst nested
+	if err := Map(&dst, src); err == nil || !strings.Contains(err.Error(), "Items[1].N") {
+		t.Errorf("Map = %v, want an error at Items[1].N", err)
+	}
+	var bad struct{ Home int }
+	if err := Map(&bad, userRow{Home: &address{}}); !errors.Is(err, ErrConvert) {
+		t.Errorf("Map of a struct to an int = %v, want ErrConvert", err)
+	}
+}
+
+// TestMapCycle tests mapping a cycle of pointers between types
+func TestMapCycle(t *testing.T) {
+	type nodeA struct {
+		Name string
+		Next *nodeA
+	}
+	type nodeB struct {
+		Name string
+		Next *nodeB
+	}
+	a := &nodeA{Name: "a"}
+	a.Next = &nodeA{Name: "b", Next: a}
+	var b nodeB
+	if err := Map(&b, a); err != nil {
+		t.Fatal(err)
+	}
+	if b.Name != "a" || b.Next.Name != "b" || b.Next.Next.Name != "a" || b.Next.Next.Next != b.Next {
+		t.Errorf("Map of a cycle = %+v, want the cycle kept", b)
+	}
+}
+
+// TestNumbers tests the bounds of numeric conversion
+func TestNumbers(t *testing.T) {
+	var i8 struct{ N int8 }
+	var u struct{ N uint }
+	var f32 struct{ N float32 }
+	tests := []struct {
+		dst any
+		src any
+		ok  bool
+	}{
+		{&i8, struct{ N int }{127}, true},
+		{&i8, struct{ N int }{128}, false},
+		{&i8, struct{ N uint64 }{1 << 63}, false},
+		{&i8, struct{ N float64 }{-3}, true},
+		{&i8, struct{ N string }{"0x10"}, true},
+		{&u, struct{ N int }{-1}, false},
+		{&u, struct{ N float64 }{1e30}, false},
+		{&u, struct{ N string }{"18446744073709551615"}, true},
+		{&f32, struct{ N float64 }{1e300}, false},
+		{&f32, struct{ N int }{3}, true},
+		{&f32, struct{ N bool }{true}, false},
+	}
+	for _, tt := range tests {
+		if err := Map(tt.dst, tt.src); (err == nil) != tt.ok {
+			t.Errorf("Map(%T, %+v) = %v, want ok %v", tt.dst, tt.src, err, tt.ok)
+		}
+	}
+}
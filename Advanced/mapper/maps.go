@@ -0,0 +1,127 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ToMap converts v, a struct or a pointer to one, to a map from the keys
+// of its fields to their values. Fields holding structs with exported
+// fields become nested maps, as do such structs in slices, arrays and
+// maps, which become []any and map[string]any; other values, time.Time
+// among them, are deep copied as they are. A nil pointer to a struct
+// becomes nil. It returns an error wrapping ErrCycle for a value that
+// contains itself.
+func ToMap(v any) (map[string]any, error) {
+	t := toMapper{onPath: make(map[ptrKey]bool), copier: copier{seen: make(map[ptrKey]reflect.Value)}}
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		t.onPath[ptrKey{value.Pointer(), value.Type()}] = true
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("mapper: ToMap of %T, want a struct", v))
+	}
+	return t.structMap("", value)
+}
+
+// toMapper is one run of ToMap
+type toMapper struct {
+	onPath map[ptrKey]bool // Pointers being converted, to detect cycles
+	copier copier
+}
+
+// nests reports whether values of type t become maps: structs with
+// exported fields, or slices, arrays, maps and pointers of them
+func nests(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return nests(t.Elem())
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && nests(t.Elem())
+	case reflect.Struct:
+		return len(fieldsOf(t)) > 0
+	}
+	return false
+}
+
+// structMap converts the struct v at path p
+func (t *toMapper) structMap(p string, v reflect.Value) (map[string]any, error) {
+	out := make(map[string]any, v.NumField())
+	for i := range v.NumField() {
+		name, ok := key(v.Type().Field(i))
+		if !ok {
+			continue
+		}
+		value, err := t.value(path(p, name), v.Field(i))
+		if err != nil {
+			return nil, err
+		}
+		out[name] = value
+	}
+	return out, nil
+}
+
+// value converts one value at path p
+func (t *toMapper) value(p string, v reflect.Value) (any, error) {
+	if !nests(v.Type()) {
+		c := reflect.New(v.Type()).Elem()
+		t.copier.copy(c, v)
+		return c.Interface(), nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, nil
+		}
+		k := ptrKey{v.Pointer(), v.Type()}
+		if t.onPath[k] {
+			return nil, fmt.Errorf("mapper: %s: %w", p, ErrCycle)
+		}
+		t.onPath[k] = true
+		defer delete(t.onPath, k)
+		return t.value(p, v.Elem())
+	case reflect.Struct:
+		return t.structMap(p, v)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return []any(nil), nil
+		}
+		out := make([]any, v.Len())
+		for i := range v.Len() {
+			elem, err := t.value(fmt.Sprintf("%s[%d]", p, i), v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = elem
+		}
+		return out, nil
+	default: // A map with string keys
+		if v.IsNil() {
+			return map[string]any(nil), nil
+		}
+		out := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			k := iter.Key().String()
+			elem, err := t.value(fmt.Sprintf("%s[%s]", p, k), iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			out[k] = elem
+		}
+		return out, nil
+	}
+}
+
+// FromMap sets the fields of dst, a pointer to a struct, from the values
+// of m under their keys, converting them as Map does: nested maps fill
+// nested structs, []any fills slices, and float64, as JSON decodes
+// numbers, fills integer fields if it holds a whole number in range. Keys
+// no field takes are ignored.
+func FromMap(dst any, m map[string]any) error {
+	d := reflect.ValueOf(dst)
+	if d.Kind() != reflect.Pointer || d.IsNil() || d.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("mapper: FromMap into %T, want a non-nil pointer to a struct", dst))
+	}
+	return newMapping().convert("", d.Elem(), reflect.ValueOf(m))
+}
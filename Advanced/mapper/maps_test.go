@@ -0,0 +1,89 @@
+package mapper
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestToMap tests converting a struct to nested maps
+func TestToMap(t *testing.T) {
+	born := time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+	p := person{
+		Name: "Ann", Age: 30, Born: born, Address: &address{Street: "Main", City: "Oslo"},
+		Tags: []string{"a"}, Friends: []*person{{Name: "Bob"}},
+	}
+	m, err := ToMap(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["Name"] != "Ann" || m["Born"] != born || m["secret"] != nil {
+		t.Errorf("ToMap = %v", m)
+	}
+	if !reflect.DeepEqual(m["Address"], map[string]any{"Street": "Main", "city": "Oslo"}) {
+		t.Errorf("Address = %v, want a map keyed by tag", m["Address"])
+	}
+	if friends, ok := m["Friends"].([]any); !ok || len(friends) != 1 || friends[0].(map[string]any)["Name"] != "Bob" {
+		t.Errorf("Friends = %#v, want a list of maps", m["Friends"])
+	}
+	if _, ok := m["secret"]; ok {
+		t.Error("ToMap included an unexported field")
+	}
+
+	// The copy is deep
+	m["Tags"].([]string)[0] = "z"
+	if p.Tags[0] != "a" {
+		t.Error("changing the map changed the struct")
+	}
+
+	p.Friends[0].Friends = []*person{&p}
+	if _, err := ToMap(&p); !errors.Is(err, ErrCycle) {
+		t.Errorf("ToMap of a cycle = %v, want ErrCycle", err)
+	}
+	// The same pointer twice, not in a cycle, is fine
+	shared := &address{City: "Oslo"}
+	type two struct{ A, B *address }
+	if _, err := ToMap(two{shared, shared}); err != nil {
+		t.Errorf("ToMap of a shared pointer = %v", err)
+	}
+}
+
+// TestFromMap tests filling a struct from decoded JSON
+func TestFromMap(t *testing.T) {
+	var m map[string]any
+	data := `{"Name": "Ann", "Age": 30, "Born": "1990-01-02T00:00:00Z", "Address": {"city": "Oslo"},
+		"Tags": ["a", "b"], "Scores": {"go": [1, 2]}, "Friends": [{"Name": "Bob"}, null], "Unknown": 1}`
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatal(err)
+	}
+	var p person
+	if err := FromMap(&p, m); err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Ann" || p.Age != 30 || !p.Born.Equal(time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)) ||
+		p.Address.City != "Oslo" || !reflect.DeepEqual(p.Tags, []string{"a", "b"}) ||
+		!reflect.DeepEqual(p.Scores, map[string][]int{"go": {1, 2}}) ||
+		len(p.Friends) != 2 || p.Friends[0].Name != "Bob" || p.Friends[1] != nil {
+		t.Errorf("FromMap = %+v", p)
+	}
+
+	if err := FromMap(&p, map[string]any{"Age": 30.5}); !errors.Is(err, ErrConvert) {
+		t.Errorf("FromMap of a fraction = %v, want ErrConvert", err)
+	}
+	if err := FromMap(&p, map[string]any{"Tags": "a"}); !errors.Is(err, ErrConvert) {
+		t.Errorf("FromMap of a string to a slice = %v, want ErrConvert", err)
+	}
+
+	// ToMap and FromMap round-trip
+	orig := person{Name: "Cy", Address: &address{City: "Rome"}, Scores: map[string][]int{"x": {3}}}
+	m, err := ToMap(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var round person
+	if err := FromMap(&round, m); err != nil || !reflect.DeepEqual(round, orig) {
+		t.Errorf("round trip = %+v, %v, want %+v", round, err, orig)
+	}
+}
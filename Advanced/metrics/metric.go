@@ -0,0 +1,125 @@
+// Package metrics provides counters, gauges and histograms for
+// instrumenting code, and exposes them in the Prometheus text format.
+// Updating a metric is a few atomic operations with no allocation, so it
+// is cheap enough for hot paths. A Registry names the metrics, optionally
+// split by labels such as a pool or a route, and WriteText and Handler
+// expose the current values for scraping.
+package metrics
+
+import (
+	"math"
+	"slices"
+	"sync/atomic"
+)
+
+// DefaultBuckets are histogram bucket bounds suited to latencies in
+// seconds, from 5ms to 10s
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// atomicFloat is a float64 updated atomically through its bits
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) Load() float64 { return math.Float64frombits(f.bits.Load()) }
+
+func (f *atomicFloat) Store(v float64) { f.bits.Store(math.Float64bits(v)) }
+
+// Add adds delta, retrying if another goroutine changed the value first
+func (f *atomicFloat) Add(delta float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// Counter is a value that only goes up, such as requests served
+type Counter struct {
+	whole atomic.Uint64 // Integer increments, kept apart to avoid CAS loops
+	frac  atomicFloat   // Other increments
+}
+
+// Inc adds 1
+func (c *Counter) Inc() { c.whole.Add(1) }
+
+// Add adds delta, ignoring a negative or NaN delta, since a counter only
+// goes up
+func (c *Counter) Add(delta float64) {
+	if !(delta > 0) {
+		return
+	}
+	if delta < 1<<53 && delta == math.Trunc(delta) {
+		c.whole.Add(uint64(delta))
+		return
+	}
+	c.frac.Add(delta)
+}
+
+// Value returns the current count
+func (c *Counter) Value() float64 { return float64(c.whole.Load()) + c.frac.Load() }
+
+// Gauge is a value that goes up and down, such as items in a queue
+type Gauge struct {
+	v atomicFloat
+}
+
+// Set sets the gauge to v
+func (g *Gauge) Set(v float64) { g.v.Store(v) }
+
+// Add adds delta, which may be negative
+func (g *Gauge) Add(delta float64) { g.v.Add(delta) }
+
+// Inc adds 1
+func (g *Gauge) Inc() { g.v.Add(1) }
+
+// Dec subtracts 1
+func (g *Gauge) Dec() { g.v.Add(-1) }
+
+// Value returns the current value
+func (g *Gauge) Value() float64 { return g.v.Load() }
+
+// Histogram counts observations, such as request latencies, in buckets
+// by value
+type Histogram struct {
+	bounds []float64       // Upper bounds of the buckets, ascending
+	counts []atomic.Uint64 // Per bucket, the last for values above all bounds
+	sum    atomicFloat
+}
+
+// newHistogram returns a histogram with the given bucket bounds, which
+// must be sorted, finite and distinct
+func newHistogram(bounds []float64) *Histogram {
+	return &Histogram{bounds: bounds, counts: make([]atomic.Uint64, len(bounds)+1)}
+}
+
+// Observe records v, counting it in the first bucket whose bound is at
+// least v
+func (h *Histogram) Observe(v float64) {
+	i, _ := slices.BinarySearch(h.bounds, v)
+	h.counts[i].Add(1)
+	h.sum.Add(v)
+}
+
+// HistogramSnapshot is the state of a histogram at one moment
+type HistogramSnapshot struct {
+	Bounds []float64 // Upper bounds of the buckets
+	Counts []uint64  // Cumulative counts of values at most each bound
+	Count  uint64    // Count of all values, including those above every bound
+	Sum    float64   // Sum of all values
+}
+
+// Snapshot returns the bucket counts, count and sum. Observations made
+// while it runs may be counted in the buckets but not yet in the sum.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{Bounds: h.bounds, Counts: make([]uint64, len(h.bounds))}
+	for i := range h.counts {
+		s.Count += h.counts[i].Load()
+		if i < len(h.bounds) {
+			s.Counts[i] = s.Count
+		}
+	}
+	s.Sum = h.sum.Load()
+	return s
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+// TestCounter tests whole, fractional and ignored increments
+func TestCounter(t *testing.T) {
+	var c Counter
+	c.Inc()
+	c.Add(2)
+	c.Add(0.5)
+	c.Add(-3)
+	c.Add(math.NaN())
+	if got := c.Value(); got != 3.5 {
+		t.Errorf("Value() = %v, want 3.5", got)
+	}
+}
+
+// TestGauge tests moving a gauge both ways
+func TestGauge(t *testing.T) {
+	var g Gauge
+	g.Set(10)
+	g.Inc()
+	g.Dec()
+	g.Dec()
+	g.Add(-0.25)
+	if got := g.Value(); got != 8.75 {
+		t.Errorf("Value() = %v, want 8.75", got)
+	}
+}
+
+// TestHistogram tests that observations land in the right buckets, a
+// value equal to a bound counting in that bound's bucket
+func TestHistogram(t *testing.T) {
+	h := newHistogram([]float64{1, 5, 10})
+	for _, v := range []float64{0.5, 1, 3, 5, 7, 20} {
+		h.Observe(v)
+	}
+	s := h.Snapshot()
+	if want := []uint64{2, 4, 5}; s.Counts[0] != want[0] || s.Counts[1] != want[1] || s.Counts[2] != want[2] {
+		t.Errorf("cumulative counts = %v, want %v", s.Counts, want)
+	}
+	if s.Count != 6 || s.Sum != 36.5 {
+		t.Errorf("count, sum = %d, %v, want 6, 36.5", s.Count, s.Sum)
+	}
+}
+
+// TestConcurrentUpdates tests that no update is lost, run with -race to
+// find data races
+func TestConcurrentUpdates(t *testing.T) {
+	var c Counter
+	var g Gauge
+	h := newHistogram(DefaultBuckets)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 1000 {
+				c.Inc()
+				c.Add(0.5)
+				g.Add(1)
+				h.Observe(0.01)
+			}
+		})
+	}
+	wg.Wait()
+	if c.Value() != 12000 || g.Value() != 8000 || h.Snapshot().Count != 8000 || h.Snapshot().Sum < 79.99 {
+		t.Errorf("counter %v, gauge %v, histogram %+v", c.Value(), g.Value(), h.Snapshot())
+	}
+}
+
+// BenchmarkCounterInc measures the cost of counting on a hot path
+func BenchmarkCounterInc(b *testing.B) {
+	var c Counter
+	b.ReportAllocs()
+	for b.Loop() {
+		c.Inc()
+	}
+}
+
+// BenchmarkHistogramObserve measures the cost of an observation
+func BenchmarkHistogramObserve(b *testing.B) {
+	h := newHistogram(DefaultBuckets)
+	b.ReportAllocs()
+	for b.Loop() {
+		h.Observe(0.042)
+	}
+}
@@ -0,0 +1,265 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// kind is the type of a metric family
+type kind int
+
+const (
+	counterKind kind = iota
+	gaugeKind
+	histogramKind
+)
+
+func (k kind) String() string {
+	return [...]string{"counter", "gauge", "histogram"}[k]
+}
+
+var (
+	metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelName  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Registry holds named metric families. Asking for a family that already
+// exists returns it, so independent components can share one, told apart
+// by their labels. Asking for an existing name with a different type,
+// labels or buckets, or for an invalid name, panics: these are mistakes
+// in the program, not conditions to handle at run time.
+//
+// A Registry is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// family is the metrics sharing one name, one per combination of label
+// values
+type family struct {
+	name, help string
+	kind       kind
+	labels     []string
+	buckets    []float64
+
+	mu       sync.RWMutex
+	children map[string]*child // By joined label values
+}
+
+// child is one metric of a family: one of a counter, a gauge, a
+// histogram or a function read on every scrape
+type child struct {
+	values  []string
+	counter *Counter
+	gauge   *Gauge
+	hist    *Histogram
+	fn      func() float64
+}
+
+// value returns the value of a counter or gauge child
+func (c *child) value() float64 {
+	switch {
+	case c.fn != nil:
+		return c.fn()
+	case c.counter != nil:
+		return c.counter.Value()
+	case c.gauge != nil:
+		return c.gauge.Value()
+	}
+	return 0
+}
+
+// family returns the family name, creating it if need be, and panics if
+// it exists with a different shape
+func (r *Registry) family(name, help string, k kind, buckets []float64, labels []string) *family {
+	if !metricName.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	for _, l := range labels {
+		if !labelName.MatchString(l) || strings.HasPrefix(l, "__") || (k == histogramKind && l == "le") {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %s", l, name))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		if f.kind != k || !slices.Equal(f.labels, labels) || !slices.Equal(f.buckets, buckets) {
+			panic(fmt.Sprintf("metrics: %s already registered as a %s with labels %q", name, f.kind, f.labels))
+		}
+		return f
+	}
+	f := &family{
+		name:     name,
+		help:     help,
+		kind:     k,
+		labels:   slices.Clone(labels),
+		buckets:  buckets,
+		children: make(map[string]*child),
+	}
+	r.families[name] = f
+	return f
+}
+
+// child returns the child with the given label values, creating it with
+// create if need be, and panics if the values do not match the labels
+func (f *family) child(values []string, create func() *child) *child {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	f.mu.RLock()
+	c, ok := f.children[key]
+	f.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.children[key]; ok {
+		return c
+	}
+	c = create()
+	c.values = slices.Clone(values)
+	f.children[key] = c
+	return c
+}
+
+// setFunc makes the child with the given label values read fn. It panics
+// if the child already exists, since it could not then keep its promise
+// to whoever holds it.
+func (f *family) setFunc(values []string, fn func() float64) {
+	created := false
+	c := f.child(values, func() *child {
+		created = true
+		return &child{fn: fn}
+	})
+	if !created && c.fn == nil {
+		panic(fmt.Sprintf("metrics: %s%q already has a value of its own", f.name, values))
+	}
+	if !created {
+		f.mu.Lock()
+		c.fn = fn
+		f.mu.Unlock()
+	}
+}
+
+// metric returns the field of c that get picks, panicking if c reads a
+// function instead
+func metric[M any](f *family, c *child, get func(*child) *M) *M {
+	m := get(c)
+	if m == nil {
+		panic(fmt.Sprintf("metrics: %s%q reads a function", f.name, c.values))
+	}
+	return m
+}
+
+// CounterVec is a family of counters split by labels
+type CounterVec struct{ f *family }
+
+// CounterVec returns the counter family name, split by the given labels
+func (r *Registry) CounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{r.family(name, help, counterKind, nil, labels)}
+}
+
+// Counter returns the counter name, which has no labels
+func (r *Registry) Counter(name, help string) *Counter {
+	return r.CounterVec(name, help).With()
+}
+
+// CounterFunc makes the counter name, which has no labels, read its value
+// from fn
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.CounterVec(name, help).Func(fn)
+}
+
+// With returns the counter with the given label values, in the order of
+// the family's labels. Looking it up takes a lock and a map lookup, so
+// hot paths should keep the counter rather than call With each time.
+func (v *CounterVec) With(values ...string) *Counter {
+	c := v.f.child(values, func() *child { return &child{counter: new(Counter)} })
+	return metric(v.f, c, func(c *child) *Counter { return c.counter })
+}
+
+// Func makes the counter with the given label values read its value from
+// fn, for counts kept elsewhere. fn must not go down, and is called on
+// every scrape. Calling Func again for the same values replaces fn.
+func (v *CounterVec) Func(fn func() float64, values ...string) {
+	v.f.setFunc(values, fn)
+}
+
+// GaugeVec is a family of gauges split by labels
+type GaugeVec struct{ f *family }
+
+// GaugeVec returns the gauge family name, split by the given labels
+func (r *Registry) GaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{r.family(name, help, gaugeKind, nil, labels)}
+}
+
+// Gauge returns the gauge name, which has no labels
+func (r *Registry) Gauge(name, help string) *Gauge {
+	return r.GaugeVec(name, help).With()
+}
+
+// GaugeFunc makes the gauge name, which has no labels, read its value
+// from fn
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.GaugeVec(name, help).Func(fn)
+}
+
+// With returns the gauge with the given label values, in the order of
+// the family's labels
+func (v *GaugeVec) With(values ...string) *Gauge {
+	c := v.f.child(values, func() *child { return &child{gauge: new(Gauge)} })
+	return metric(v.f, c, func(c *child) *Gauge { return c.gauge })
+}
+
+// Func makes the gauge with the given label values read its value from
+// fn, which is called on every scrape. Calling Func again for the same
+// values replaces fn.
+func (v *GaugeVec) Func(fn func() float64, values ...string) {
+	v.f.setFunc(values, fn)
+}
+
+// HistogramVec is a family of histograms split by labels
+type HistogramVec struct{ f *family }
+
+// HistogramVec returns the histogram family name with the given bucket
+// bounds, DefaultBuckets if none, split by the given labels. It panics if
+// the bounds are not finite and strictly ascending.
+func (r *Registry) HistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	for i, b := range buckets {
+		if math.IsNaN(b) || math.IsInf(b, 0) || (i > 0 && b <= buckets[i-1]) {
+			panic(fmt.Sprintf("metrics: buckets of %s must be finite and ascending, got %v", name, buckets))
+		}
+	}
+	return &HistogramVec{r.family(name, help, histogramKind, slices.Clone(buckets), labels)}
+}
+
+// Histogram returns the histogram name, which has no labels
+func (r *Registry) Histogram(name, help string, buckets []float64) *Histogram {
+	return r.HistogramVec(name, help, buckets).With()
+}
+
+// With returns the histogram with the given label values, in the order of
+// the family's labels
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.f.child(values, func() *child {
+		return &child{hist: newHistogram(v.f.buckets)}
+	}).hist
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+// mustPanic fails t unless fn panics with a message containing want
+func mustPanic(t *testing.T, want string, fn func()) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if msg, _ := r.(string); !strings.Contains(msg, want) {
+			t.Errorf("panic = %v, want one mentioning %q", r, want)
+		}
+	}()
+	fn()
+}
+
+// TestRegistry tests that families and their children are shared by name
+// and label values
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	requests := r.CounterVec("http_requests_total", "Requests served", "route", "code")
+	requests.With("/users", "200").Inc()
+	r.CounterVec("http_requests_total", "Requests served", "route", "code").With("/users", "200").Inc()
+	if got := requests.With("/users", "200").Value(); got != 2 {
+		t.Errorf("shared counter = %v, want 2", got)
+	}
+	if r.Gauge("temperature", "") != r.Gauge("temperature", "") {
+		t.Error("Gauge returned a different gauge for the same name")
+	}
+	if r.Histogram("latency", "", nil) != r.HistogramVec("latency", "", DefaultBuckets).With() {
+		t.Error("nil buckets should mean DefaultBuckets")
+	}
+}
+
+// TestRegistryMisuse tests the panics for mistakes in the program
+func TestRegistryMisuse(t *testing.T) {
+	r := NewRegistry()
+	r.CounterVec("jobs_total", "", "queue")
+	mustPanic(t, "already registered", func() { r.GaugeVec("jobs_total", "", "queue") })
+	mustPanic(t, "already registered", func() { r.CounterVec("jobs_total", "", "worker") })
+	mustPanic(t, "takes 1 label values", func() { r.CounterVec("jobs_total", "", "queue").With() })
+	mustPanic(t, "invalid metric name", func() { r.Counter("jobs-total", "") })
+	mustPanic(t, "invalid label name", func() { r.CounterVec("x", "", "__name") })
+	mustPanic(t, "invalid label name", func() { r.HistogramVec("y", "", nil, "le") })
+	mustPanic(t, "ascending", func() { r.Histogram("z", "", []float64{1, 1}) })
+
+	r.GaugeFunc("queue_depth", "", func() float64 { return 1 })
+	mustPanic(t, "reads a function", func() { r.Gauge("queue_depth", "") })
+	r.Gauge("workers", "")
+	mustPanic(t, "value of its own", func() { r.GaugeFunc("workers", "", func() float64 { return 0 }) })
+}
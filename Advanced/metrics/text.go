@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of the Prometheus text format WriteText
+// writes
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// WriteText writes every metric to w in the Prometheus text format,
+// families sorted by name and metrics by label values
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+	slices.SortFunc(families, func(a, b *family) int { return strings.Compare(a.name, b.name) })
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler returns an HTTP handler serving the metrics in the Prometheus
+// text format, for a scraper to poll
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// Secure: only reads are served
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		if req.Method == http.MethodHead {
+			return
+		}
+		_ = r.WriteText(w)
+	})
+}
+
+// write writes the family's header and metrics. A family without metrics
+// writes nothing.
+func (f *family) write(w *bufio.Writer) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if len(f.children) == 0 {
+		return
+	}
+	children := make([]*child, 0, len(f.children))
+	for _, c := range f.children {
+		children = append(children, c)
+	}
+	slices.SortFunc(children, func(a, b *child) int { return slices.Compare(a.values, b.values) })
+
+	if f.help != "" {
+		w.WriteString("# HELP " + f.name + " " + helpEscaper.Replace(f.help) + "\n")
+	}
+	w.WriteString("# TYPE " + f.name + " " + f.kind.String() + "\n")
+	for _, c := range children {
+		if c.hist == nil {
+			f.sample(w, "", c.values, "", 0, c.value())
+			continue
+		}
+		s := c.hist.Snapshot()
+		for i, bound := range s.Bounds {
+			f.sample(w, "_bucket", c.values, "le", bound, float64(s.Counts[i]))
+		}
+		f.sample(w, "_bucket", c.values, "le", math.Inf(1), float64(s.Count))
+		f.sample(w, "_sum", c.values, "", 0, s.Sum)
+		f.sample(w, "_count", c.values, "", 0, float64(s.Count))
+	}
+}
+
+// sample writes one line: the name with suffix, the labels with values,
+// then extra=bound if extra is set, and v
+func (f *family) sample(w *bufio.Writer, suffix string, values []string, extra string, bound, v float64) {
+	w.WriteString(f.name)
+	w.WriteString(suffix)
+	if len(values) > 0 || extra != "" {
+		w.WriteByte('{')
+		for i, l := range f.labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(l + `="` + labelEscaper.Replace(values[i]) + `"`)
+		}
+		if extra != "" {
+			if len(values) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extra + `="` + formatFloat(bound) + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+// formatFloat formats v as the text format wants, with +Inf, -Inf and NaN
+// spelled out
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestWriteText tests the exposition of every kind of metric
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.CounterVec("requests_total", "Requests served,\nby route", "route")
+	requests.With("/b").Add(2)
+	requests.With(`/a"\`).Inc()
+	r.GaugeFunc("up", "", func() float64 { return 1 })
+	r.Gauge("idle", "Idle ratio").Set(math.Inf(-1))
+	latency := r.HistogramVec("latency_seconds", "Latency", []float64{0.1, 1}, "route")
+	latency.With("/a").Observe(0.05)
+	latency.With("/a").Observe(2)
+	r.CounterVec("unused_total", "Never incremented", "x")
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP idle Idle ratio
+# TYPE idle gauge
+idle -Inf
+# HELP latency_seconds Latency
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 1
+latency_seconds_bucket{route="/a",le="1"} 1
+latency_seconds_bucket{route="/a",le="+Inf"} 2
+latency_seconds_sum{route="/a"} 2.05
+latency_seconds_count{route="/a"} 2
+# HELP requests_total Requests served,\nby route
+# TYPE requests_total counter
+requests_total{route="/a\"\\"} 1
+requests_total{route="/b"} 2
+# TYPE up gauge
+up 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestHandler tests serving the metrics over HTTP
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("hits_total", "").Inc()
+	h := r.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != ContentType || !strings.Contains(rec.Body.String(), "hits_total 1\n") {
+		t.Errorf("GET = %d %q %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,94 @@
+package netserver
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Conn is a connection being served. Each Read and Write sets a deadline
+// from the server's ReadTimeout or WriteTimeout before it starts, so the
+// timeouts bound inactivity rather than the life of the connection, and
+// is counted in the connection's and the server's Stats. Deadlines set
+// directly are replaced by the next Read or Write.
+type Conn struct {
+	net.Conn
+	s      *Server
+	id     uint64
+	opened time.Time
+
+	mu       sync.Mutex // Orders setting read deadlines against drain
+	draining bool
+
+	read, written atomic.Uint64
+}
+
+// ID returns the number of the connection, counting from 1 for each
+// server
+func (c *Conn) ID() uint64 {
+	return c.id
+}
+
+// Opened returns when the connection was accepted
+func (c *Conn) Opened() time.Time {
+	return c.opened
+}
+
+// BytesRead returns the bytes read from the connection so far
+func (c *Conn) BytesRead() uint64 {
+	return c.read.Load()
+}
+
+// BytesWritten returns the bytes written to the connection so far
+func (c *Conn) BytesWritten() uint64 {
+	return c.written.Load()
+}
+
+// Read reads from the connection, failing with ErrServerClosed once the
+// server is shutting down
+func (c *Conn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	if c.draining {
+		c.mu.Unlock()
+		return 0, ErrServerClosed
+	}
+	if c.s.opts.ReadTimeout > 0 {
+		// Secure: a client that stops sending, slowly or on purpose,
+		// loses its connection instead of holding a slot forever
+		c.Conn.SetReadDeadline(time.Now().Add(c.s.opts.ReadTimeout))
+	}
+	c.mu.Unlock()
+	n, err := c.Conn.Read(p)
+	c.read.Add(uint64(n))
+	c.s.bytesRead.Add(uint64(n))
+	if err != nil && errors.Is(err, os.ErrDeadlineExceeded) {
+		c.mu.Lock()
+		if c.draining {
+			err = ErrServerClosed
+		}
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+// Write writes to the connection
+func (c *Conn) Write(p []byte) (int, error) {
+	if c.s.opts.WriteTimeout > 0 {
+		c.Conn.SetWriteDeadline(time.Now().Add(c.s.opts.WriteTimeout))
+	}
+	n, err := c.Conn.Write(p)
+	c.written.Add(uint64(n))
+	c.s.bytesWritten.Add(uint64(n))
+	return n, err
+}
+
+// drain cuts off reads, waking one blocked now
+func (c *Conn) drain() {
+	c.mu.Lock()
+	c.draining = true
+	c.Conn.SetReadDeadline(time.Now())
+	c.mu.Unlock()
+}
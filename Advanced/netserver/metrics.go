@@ -0,0 +1,26 @@
+package netserver
+
+import "hellogolang/Advanced/metrics"
+
+// RegisterMetrics exposes the server's Stats in r, labelled server=name,
+// read afresh on every scrape: a gauge of open connections, and counters
+// of connections by fate and of bytes each way. Servers registered with
+// the same r share the families and need distinct names.
+func (s *Server) RegisterMetrics(r *metrics.Registry, name string) {
+	r.GaugeVec("netserver_active_connections", "Connections open", "server").
+		Func(func() float64 { return float64(s.Stats().Active) }, name)
+
+	counters := []struct {
+		name, help string
+		get        func(Stats) uint64
+	}{
+		{"netserver_accepted_connections_total", "Connections accepted", func(s Stats) uint64 { return s.Accepted }},
+		{"netserver_refused_connections_total", "Connections refused by OnConnect", func(s Stats) uint64 { return s.Refused }},
+		{"netserver_panicked_handlers_total", "Handlers that panicked", func(s Stats) uint64 { return s.Panicked }},
+		{"netserver_read_bytes_total", "Bytes read from connections", func(s Stats) uint64 { return s.BytesRead }},
+		{"netserver_written_bytes_total", "Bytes written to connections", func(s Stats) uint64 { return s.BytesWritten }},
+	}
+	for _, c := range counters {
+		r.CounterVec(c.name, c.help, "server").Func(func() float64 { return float64(c.get(s.Stats())) }, name)
+	}
+}
@@ -0,0 +1,38 @@
+package netserver
+
+import (
+	"strings"
+	"testing"
+
+	"hellogolang/Advanced/metrics"
+)
+
+// TestRegisterMetrics tests that the exposed metrics follow the server
+func TestRegisterMetrics(t *testing.T) {
+	l := newPipeListener()
+	s := New(HandlerFunc(echo), Options{})
+	r := metrics.NewRegistry()
+	s.RegisterMetrics(r, "echo")
+	serve(s, l)
+	defer s.Close()
+	if err := roundTrip(l.dial(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+	// The client can read the echo before the handler's Write returns
+	waitFor(t, "the write to be counted", func() bool { return s.Stats().BytesWritten == 5 })
+
+	var out strings.Builder
+	if err := r.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`netserver_active_connections{server="echo"} 1`,
+		`netserver_accepted_connections_total{server="echo"} 1`,
+		`netserver_read_bytes_total{server="echo"} 5`,
+		`netserver_written_bytes_total{server="echo"} 5`,
+	} {
+		if !strings.Contains(out.String(), want+"\n") {
+			t.Errorf("exposition lacks %q:\n%s", want, out.String())
+		}
+	}
+}
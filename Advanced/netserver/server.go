@@ -0,0 +1,321 @@
+// Package netserver runs TCP servers. A Server accepts connections from
+// net.Listeners and serves each on its own goroutine through a Handler,
+// wrapping it in a Conn that sets read and write deadlines on every call
+// so stalled or malicious clients cannot hold it forever. At most
+// MaxConns connections are open at once, further ones waiting in the
+// listen backlog, a panicking handler loses only its own connection, and
+// hooks see each connection open and close. Shutdown stops accepting and
+// drains the open connections, closing them once its context ends; Run
+// does the same on SIGINT or SIGTERM. RegisterMetrics exposes its Stats
+// in a metrics.Registry.
+package netserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/signal"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"hellogolang/Advanced/syncx"
+)
+
+// ErrServerClosed is returned by Serve after Shutdown, and by the reads
+// of a Conn being drained
+var ErrServerClosed = errors.New("server closed")
+
+// Defaults for zero Options fields
+const (
+	DefaultMaxConns     = 1024
+	DefaultReadTimeout  = time.Minute
+	DefaultWriteTimeout = 30 * time.Second
+)
+
+// Options configures a Server. Zero fields take their defaults.
+type Options struct {
+	// MaxConns bounds the connections open at once, DefaultMaxConns if 0
+	// or less. Once it is reached the server stops accepting, leaving new
+	// connections in the listen backlog until one closes.
+	MaxConns int
+	// ReadTimeout bounds how long each Read of a Conn waits for data,
+	// DefaultReadTimeout if 0 and unbounded if negative
+	ReadTimeout time.Duration
+	// WriteTimeout bounds how long each Write of a Conn may take,
+	// DefaultWriteTimeout if 0 and unbounded if negative
+	WriteTimeout time.Duration
+	// OnConnect, if set, is called with each new connection before it is
+	// served. An error closes the connection unserved, to refuse an
+	// address for instance.
+	OnConnect func(c *Conn) error
+	// OnClose, if set, is called with each connection once it is closed,
+	// served or not
+	OnClose func(c *Conn)
+	// OnError, if set, is called with errors accepting connections, with c
+	// nil, and with a *syncx.PanicError when the handler of c panics
+	OnError func(c *Conn, err error)
+}
+
+// Stats counts a server's connections and traffic
+type Stats struct {
+	Active       int    // Connections open
+	Accepted     uint64 // Connections accepted
+	Refused      uint64 // Connections refused by OnConnect
+	Panicked     uint64 // Handlers that panicked
+	BytesRead    uint64 // Bytes read from all connections
+	BytesWritten uint64 // Bytes written to all connections
+}
+
+// Handler serves a connection, returning when done with it; the server
+// then closes it. ctx is cancelled when the server starts shutting down.
+type Handler interface {
+	ServeConn(ctx context.Context, c *Conn)
+}
+
+// HandlerFunc adapts a function to a Handler
+type HandlerFunc func(ctx context.Context, c *Conn)
+
+// ServeConn calls f
+func (f HandlerFunc) ServeConn(ctx context.Context, c *Conn) {
+	f(ctx, c)
+}
+
+// Server serves connections from any number of listeners with one
+// Handler
+type Server struct {
+	handler Handler
+	opts    Options
+	slots   chan struct{} // A semaphore of MaxConns slots, one per connection
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu        sync.Mutex
+	closed    bool
+	listeners map[net.Listener]struct{}
+	conns     map[*Conn]struct{}
+	wg        sync.WaitGroup // One per connection being served
+	nextID    uint64
+
+	accepted, refused, panicked atomic.Uint64
+	bytesRead, bytesWritten     atomic.Uint64
+}
+
+// New creates a server serving connections with h
+func New(h Handler, opts Options) *Server {
+	if opts.MaxConns <= 0 {
+		opts.MaxConns = DefaultMaxConns
+	}
+	if opts.ReadTimeout == 0 {
+		opts.ReadTimeout = DefaultReadTimeout
+	}
+	if opts.WriteTimeout == 0 {
+		opts.WriteTimeout = DefaultWriteTimeout
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		handler:   h,
+		opts:      opts,
+		slots:     make(chan struct{}, opts.MaxConns),
+		ctx:       ctx,
+		cancel:    cancel,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[*Conn]struct{}),
+	}
+}
+
+// Maximum delay between retries of a failing Accept
+const maxAcceptDelay = time.Second
+
+// Serve accepts connections from l, serving each on its own goroutine,
+// until l fails or the server shuts down, when it returns
+// ErrServerClosed. It closes l before returning. Errors accepting a
+// connection, such as running out of file descriptors, are passed to
+// OnError and retried after a delay growing up to a second.
+func (s *Server) Serve(l net.Listener) error {
+	defer l.Close()
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, l)
+		s.mu.Unlock()
+	}()
+
+	var delay time.Duration
+	for {
+		// Secure: a slot is taken before accepting, so a flood of clients
+		// waits in the backlog instead of exhausting goroutines and file
+		// descriptors
+		select {
+		case s.slots <- struct{}{}:
+		case <-s.ctx.Done():
+			return ErrServerClosed
+		}
+		nc, err := l.Accept()
+		if err != nil {
+			<-s.slots
+			if s.ctx.Err() != nil {
+				return ErrServerClosed
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			s.report(nil, err)
+			delay = min(max(2*delay, 5*time.Millisecond), maxAcceptDelay)
+			select {
+			case <-time.After(delay):
+			case <-s.ctx.Done():
+				return ErrServerClosed
+			}
+			continue
+		}
+		delay = 0
+		s.accepted.Add(1)
+		if c := s.track(nc); c != nil {
+			go s.serve(c)
+		}
+	}
+}
+
+// track wraps nc and records it as open, or closes it if the server is
+// shutting down
+func (s *Server) track(nc net.Conn) *Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		nc.Close()
+		<-s.slots
+		return nil
+	}
+	s.nextID++
+	c := &Conn{Conn: nc, s: s, id: s.nextID, opened: time.Now()}
+	s.conns[c] = struct{}{}
+	s.wg.Add(1)
+	return c
+}
+
+// serve runs the handler on c, then closes it and frees its slot
+func (s *Server) serve(c *Conn) {
+	defer func() {
+		c.Conn.Close()
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		<-s.slots
+		if s.opts.OnClose != nil {
+			s.opts.OnClose(c)
+		}
+		s.wg.Done()
+	}()
+	if s.opts.OnConnect != nil {
+		if err := s.opts.OnConnect(c); err != nil {
+			s.refused.Add(1)
+			return
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			s.panicked.Add(1)
+			s.report(c, &syncx.PanicError{Value: r, Stack: debug.Stack()})
+		}
+	}()
+	s.handler.ServeConn(s.ctx, c)
+}
+
+// report passes err to OnError, if set
+func (s *Server) report(c *Conn, err error) {
+	if s.opts.OnError != nil {
+		s.opts.OnError(c, err)
+	}
+}
+
+// Shutdown stops the server gracefully. It closes the listeners, cancels
+// the handlers' context and cuts off their reads, which then fail with
+// ErrServerClosed, but lets writes continue, so a handler can finish the
+// response it is writing. It waits for the handlers to return; if ctx
+// ends first, it closes their connections and returns ctx.Err() once
+// they have. Handlers must return when their connection fails.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.cancel()
+	for l := range s.listeners {
+		l.Close()
+	}
+	for c := range s.conns {
+		c.drain()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Conn.Close()
+	}
+	s.mu.Unlock()
+	<-done
+	return ctx.Err()
+}
+
+// Close stops the server at once, closing its listeners and connections,
+// and waits for the handlers to return
+func (s *Server) Close() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Shutdown(ctx)
+}
+
+// Run serves l until ctx ends or the process receives SIGINT or SIGTERM,
+// then shuts down, giving connections up to drain to finish. It returns
+// nil after a clean shutdown, the error of Shutdown if connections had to
+// be closed, or the error of Serve if l failed first.
+func (s *Server) Run(ctx context.Context, l net.Listener, drain time.Duration) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(l)
+	}()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drain)
+	defer cancel()
+	err := s.Shutdown(shutdownCtx)
+	<-served
+	return err
+}
+
+// Stats returns a snapshot of the server's counters
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	active := len(s.conns)
+	s.mu.Unlock()
+	return Stats{
+		Active:       active,
+		Accepted:     s.accepted.Load(),
+		Refused:      s.refused.Load(),
+		Panicked:     s.panicked.Load(),
+		BytesRead:    s.bytesRead.Load(),
+		BytesWritten: s.bytesWritten.Load(),
+	}
+}
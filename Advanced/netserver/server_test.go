@@ -0,0 +1,398 @@
+package netserver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"hellogolang/Advanced/syncx"
+)
+
+// waitFor polls cond until it holds, failing the test after a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// pipeListener accepts in-memory connections, whose writes block until
+// read, which makes limits and timeouts easy to test
+type pipeListener struct {
+	conns  chan net.Conn // The backlog
+	errs   chan error    // Errors for Accept to return first
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns:  make(chan net.Conn, 16),
+		errs:   make(chan error, 16),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case err := <-l.errs:
+		return nil, err
+	default:
+	}
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: "pipe", Net: "pipe"}
+}
+
+// dial queues a connection in the backlog and returns the client's end
+func (l *pipeListener) dial() net.Conn {
+	client, server := net.Pipe()
+	l.conns <- server
+	return client
+}
+
+// echo is a handler writing back what it reads
+func echo(_ context.Context, c *Conn) {
+	io.Copy(c, c)
+}
+
+// serve starts s on l, returning a channel of the error of Serve
+func serve(s *Server, l net.Listener) <-chan error {
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(l)
+	}()
+	return served
+}
+
+// roundTrip writes msg on c and reads it back
+func roundTrip(c net.Conn, msg string) error {
+	if _, err := io.WriteString(c, msg); err != nil {
+		return err
+	}
+	buf := make([]byte, len(msg))
+	if _, err := io.ReadFull(c, buf); err != nil {
+		return err
+	}
+	if string(buf) != msg {
+		return fmt.Errorf("read %q, want %q", buf, msg)
+	}
+	return nil
+}
+
+// TestEcho tests serving clients at once over TCP
+func TestEcho(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(HandlerFunc(echo), Options{})
+	served := serve(s, l)
+
+	var wg sync.WaitGroup
+	for i := range 5 {
+		wg.Go(func() {
+			c, err := net.Dial("tcp", l.Addr().String())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer c.Close()
+			for j := range 10 {
+				if err := roundTrip(c, fmt.Sprintf("client %d message %d\n", i, j)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+	waitFor(t, "connections to close", func() bool { return s.Stats().Active == 0 })
+	stats := s.Stats()
+	if stats.Accepted != 5 || stats.BytesRead != stats.BytesWritten || stats.BytesRead != uint64(5*10*len("client 0 message 0\n")) {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Serve = %v, want ErrServerClosed", err)
+	}
+	if err := s.Serve(newPipeListener()); !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Serve after Shutdown = %v, want ErrServerClosed", err)
+	}
+}
+
+// TestMaxConns tests that connections past the limit wait to be served
+func TestMaxConns(t *testing.T) {
+	l := newPipeListener()
+	s := New(HandlerFunc(echo), Options{MaxConns: 1})
+	serve(s, l)
+	defer s.Close()
+
+	first := l.dial()
+	if err := roundTrip(first, "a"); err != nil {
+		t.Fatal(err)
+	}
+	second := l.dial()
+	second.SetWriteDeadline(time.Now().Add(20 * time.Millisecond))
+	if _, err := second.Write([]byte("b")); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Write past the limit = %v, want it to wait", err)
+	}
+	first.Close()
+	second.SetWriteDeadline(time.Time{})
+	if err := roundTrip(second, "b"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTimeouts tests that idle clients and stalled writes are cut off
+func TestTimeouts(t *testing.T) {
+	l := newPipeListener()
+	closed := make(chan *Conn, 1)
+	s := New(HandlerFunc(echo), Options{ReadTimeout: 20 * time.Millisecond, OnClose: func(c *Conn) { closed <- c }})
+	serve(s, l)
+	defer s.Close()
+
+	c := l.dial()
+	if err := roundTrip(c, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read from an idle connection = %v, want io.EOF", err)
+	}
+	if conn := <-closed; conn.BytesRead() != 5 || conn.BytesWritten() != 5 || conn.ID() != 1 {
+		t.Errorf("closed connection %d read %d and wrote %d bytes", conn.ID(), conn.BytesRead(), conn.BytesWritten())
+	}
+
+	// A client that stops reading
+	l = newPipeListener()
+	writeErr := make(chan error, 1)
+	s = New(HandlerFunc(func(_ context.Context, c *Conn) {
+		_, err := c.Write([]byte("unread"))
+		writeErr <- err
+	}), Options{WriteTimeout: 20 * time.Millisecond})
+	serve(s, l)
+	defer s.Close()
+	l.dial()
+	if err := <-writeErr; !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Write to a client not reading = %v, want a deadline error", err)
+	}
+}
+
+// TestHooks tests refusing connections and recovering from panics
+func TestHooks(t *testing.T) {
+	l := newPipeListener()
+	var mu sync.Mutex
+	var errs []error
+	var closedIDs []uint64
+	s := New(HandlerFunc(func(ctx context.Context, c *Conn) {
+		b := make([]byte, 1)
+		c.Read(b)
+		if b[0] == 'p' {
+			panic("boom")
+		}
+		c.Write(b)
+	}), Options{
+		OnConnect: func(c *Conn) error {
+			if c.ID() == 1 {
+				return errors.New("refused")
+			}
+			return nil
+		},
+		OnClose: func(c *Conn) {
+			mu.Lock()
+			closedIDs = append(closedIDs, c.ID())
+			mu.Unlock()
+		},
+		OnError: func(c *Conn, err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+	serve(s, l)
+	defer s.Close()
+
+	if _, err := l.dial().Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read from a refused connection = %v, want io.EOF", err)
+	}
+	panicking := l.dial()
+	panicking.Write([]byte("p"))
+	if _, err := panicking.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read after a panic = %v, want io.EOF", err)
+	}
+	if err := roundTrip(l.dial(), "x"); err != nil {
+		t.Fatalf("serving after a panic: %v", err)
+	}
+
+	waitFor(t, "three connections to close", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(closedIDs) == 3
+	})
+	var pe *syncx.PanicError
+	if len(errs) != 1 || !errors.As(errs[0], &pe) || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Errorf("OnError got %v, want one syncx.PanicError", errs)
+	}
+	if stats := s.Stats(); stats.Refused != 1 || stats.Panicked != 1 || stats.Accepted != 3 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+// TestAcceptErrors tests that failing Accepts are reported and retried
+func TestAcceptErrors(t *testing.T) {
+	l := newPipeListener()
+	l.errs <- errors.New("too many open files")
+	l.errs <- errors.New("too many open files")
+	reported := make(chan error, 2)
+	s := New(HandlerFunc(echo), Options{OnError: func(c *Conn, err error) {
+		if c != nil {
+			t.Errorf("OnError for an Accept error got connection %d", c.ID())
+		}
+		reported <- err
+	}})
+	serve(s, l)
+	defer s.Close()
+	if err := roundTrip(l.dial(), "after errors"); err != nil {
+		t.Fatal(err)
+	}
+	if len(reported) != 2 {
+		t.Errorf("OnError called %d times, want 2", len(reported))
+	}
+}
+
+// TestShutdown tests that Shutdown cuts off reads but lets handlers finish
+func TestShutdown(t *testing.T) {
+	l := newPipeListener()
+	handlerErr := make(chan error, 1)
+	var handlerCtx context.Context
+	s := New(HandlerFunc(func(ctx context.Context, c *Conn) {
+		handlerCtx = ctx
+		r := bufio.NewReader(c)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				handlerErr <- err
+				io.WriteString(c, "bye\n")
+				return
+			}
+			io.WriteString(c, line)
+		}
+	}), Options{})
+	served := serve(s, l)
+
+	c := l.dial()
+	if err := roundTrip(c, "request\n"); err != nil {
+		t.Fatal(err)
+	}
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- s.Shutdown(context.Background())
+	}()
+	if line, err := bufio.NewReader(c).ReadString('\n'); line != "bye\n" {
+		t.Errorf("read %q, %v after Shutdown, want the handler's last write", line, err)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown = %v", err)
+	}
+	if err := <-handlerErr; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("handler read %v, want ErrServerClosed", err)
+	}
+	if handlerCtx.Err() == nil {
+		t.Error("handler context not cancelled")
+	}
+	if err := <-served; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Serve = %v, want ErrServerClosed", err)
+	}
+}
+
+// TestShutdownTimeout tests that Shutdown closes connections that outlast
+// its context
+func TestShutdownTimeout(t *testing.T) {
+	l := newPipeListener()
+	returned := make(chan struct{})
+	s := New(HandlerFunc(func(_ context.Context, c *Conn) {
+		defer close(returned)
+		for {
+			if _, err := c.Write([]byte("never read")); err != nil {
+				return
+			}
+		}
+	}), Options{WriteTimeout: -1})
+	serve(s, l)
+	l.dial()
+	waitFor(t, "the connection to open", func() bool { return s.Stats().Active == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+	select {
+	case <-returned:
+	default:
+		t.Error("Shutdown returned before the handler")
+	}
+}
+
+// TestRun tests that Run shuts down on SIGTERM and on its context
+func TestRun(t *testing.T) {
+	for _, signal := range []bool{true, false} {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := New(HandlerFunc(echo), Options{})
+		ctx, cancel := context.WithCancel(context.Background())
+		ran := make(chan error, 1)
+		go func() {
+			ran <- s.Run(ctx, l, time.Second)
+		}()
+
+		// Once a connection is served, Run is listening for signals
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := roundTrip(c, "ping"); err != nil {
+			t.Fatal(err)
+		}
+		if signal {
+			syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		} else {
+			cancel()
+		}
+		if err := <-ran; err != nil {
+			t.Errorf("Run = %v, want a clean shutdown", err)
+		}
+		if _, err := c.Read(make([]byte, 1)); err != io.EOF {
+			t.Errorf("Read after Run returned = %v, want io.EOF", err)
+		}
+		c.Close()
+		cancel()
+	}
+}
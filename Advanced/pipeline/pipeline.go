@@ -0,0 +1,148 @@
+// Package pipeline composes typed stages into a concurrent pipeline. A
+// source feeds a Stream, Map and Filter run a Stage over each value with
+// as many workers as asked, and Collect or ForEach drain the result. Each
+// stage may keep its output in input order or pass values on as they
+// finish. The first error, or panic, of any stage cancels the pipeline's
+// context so that every stage stops, and is what the sink returns.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"runtime/debug"
+	"sync"
+
+	"hellogolang/Advanced/syncx"
+)
+
+// StageError is the error of a stage that failed, naming the stage
+type StageError struct {
+	Stage string // The Name of the stage, if it has one
+	Err   error
+}
+
+// Error returns the stage name and the error
+func (e *StageError) Error() string {
+	if e.Stage == "" {
+		return fmt.Sprintf("pipeline stage: %v", e.Err)
+	}
+	return fmt.Sprintf("pipeline stage %s: %v", e.Stage, e.Err)
+}
+
+// Unwrap returns the error of the stage
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Pipeline is the context and error shared by the stages of one run.
+// It is safe for concurrent use.
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup // The goroutines of every stage
+
+	once sync.Once
+	err  error
+}
+
+// New creates a pipeline whose stages run until ctx ends or one fails
+func New(ctx context.Context) *Pipeline {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Pipeline{ctx: ctx, cancel: cancel}
+}
+
+// Context returns the context of the stages, cancelled on the first error
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// fail cancels the pipeline with err, unless it has failed already
+func (p *Pipeline) fail(err error) {
+	p.cancel(err)
+}
+
+// Wait waits for every stage to stop and returns the first error of the
+// pipeline: that of a stage, or of the context New was given if it ended
+// first. The sinks call it; callers need not.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.once.Do(func() {
+		p.err = context.Cause(p.ctx)
+		p.cancel(nil)
+	})
+	return p.err
+}
+
+// Stream is a channel of values between two stages. Each stream feeds
+// exactly one stage or sink.
+type Stream[T any] struct {
+	p  *Pipeline
+	ch <-chan T
+}
+
+// From returns a stream of the values of seq, which stops early if the
+// pipeline is cancelled
+func From[T any](p *Pipeline, seq iter.Seq[T]) *Stream[T] {
+	out := make(chan T)
+	p.wg.Go(func() {
+		defer close(out)
+		for v := range seq {
+			if !send(p.ctx, out, v) {
+				return
+			}
+		}
+	})
+	return &Stream[T]{p: p, ch: out}
+}
+
+// Collect drains s and returns its values, or nil and the pipeline's
+// error if it failed
+func Collect[T any](s *Stream[T]) ([]T, error) {
+	var values []T
+	for v := range s.ch {
+		values = append(values, v)
+	}
+	if err := s.p.Wait(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ForEach calls fn with each value of s, then returns the pipeline's
+// error. An error from fn fails the pipeline, and fn is not called again.
+func ForEach[T any](s *Stream[T], fn func(ctx context.Context, v T) error) error {
+	for v := range s.ch {
+		if s.p.ctx.Err() != nil {
+			continue // Drain what the stopping stages still send
+		}
+		if err := fn(s.p.ctx, v); err != nil {
+			s.p.fail(err)
+		}
+	}
+	return s.p.Wait()
+}
+
+// send sends v on ch, or returns false if ctx ends first
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// call runs a stage on v, turning an error or panic into a StageError
+func call[In, Out any](ctx context.Context, name string, fn Stage[In, Out], v In) (out Out, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &StageError{Stage: name, Err: &syncx.PanicError{Value: r, Stack: debug.Stack()}}
+		}
+	}()
+	out, err = fn(ctx, v)
+	if err != nil {
+		err = &StageError{Stage: name, Err: err}
+	}
+	return out, err
+}
@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestCollect tests a source drained straight into a sink
+func TestCollect(t *testing.T) {
+	p := New(context.Background())
+	got, err := Collect(From(p, slices.Values([]int{3, 1, 2})))
+	if err != nil || !slices.Equal(got, []int{3, 1, 2}) {
+		t.Errorf("Collect = %v, %v, want [3 1 2]", got, err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Errorf("second Wait = %v, want nil", err)
+	}
+}
+
+// TestForEachError tests that an error from the sink stops the source
+func TestForEachError(t *testing.T) {
+	errFull := errors.New("disk full")
+	p := New(context.Background())
+	var seen []int
+	err := ForEach(From(p, naturals()), func(_ context.Context, v int) error {
+		seen = append(seen, v)
+		if v == 3 {
+			return errFull
+		}
+		return nil
+	})
+	if !errors.Is(err, errFull) || !slices.Equal(seen, []int{0, 1, 2, 3}) {
+		t.Errorf("ForEach = %v after %v, want the sink's error after 0 to 3", err, seen)
+	}
+}
+
+// TestParentCancel tests that ending the parent context stops an endless
+// pipeline with its error
+func TestParentCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	p := New(ctx)
+	s := Map(From(p, naturals()), func(_ context.Context, v int) (int, error) {
+		return v + 1, nil
+	}, Options{Workers: 4})
+	if _, err := Collect(s); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Collect error = %v, want DeadlineExceeded", err)
+	}
+}
+
+// naturals yields 0, 1, 2, ... until its consumer stops
+func naturals() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := 0; yield(i); i++ {
+		}
+	}
+}
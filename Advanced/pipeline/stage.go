@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// Stage transforms one value. An error fails the whole pipeline.
+type Stage[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// Options configures a stage. Zero fields take their defaults.
+type Options struct {
+	// Name identifies the stage in its StageError
+	Name string
+	// Workers is the number of goroutines running the stage at once, 1 if
+	// 0 or less
+	Workers int
+	// Buffer is the number of finished values the stage holds for the next
+	// one before waiting, 0 if negative
+	Buffer int
+	// Ordered keeps the output in input order when Workers is above 1, at
+	// the cost of holding values that finish early; otherwise each value
+	// passes on as soon as it is done
+	Ordered bool
+}
+
+// Map returns a stream of stage applied to each value of s
+func Map[In, Out any](s *Stream[In], stage Stage[In, Out], opts Options) *Stream[Out] {
+	return run(s, opts, func(ctx context.Context, v In) (Out, bool, error) {
+		out, err := call(ctx, opts.Name, stage, v)
+		return out, err == nil, err
+	})
+}
+
+// Filter returns a stream of the values of s that keep reports true for
+func Filter[T any](s *Stream[T], keep Stage[T, bool], opts Options) *Stream[T] {
+	return run(s, opts, func(ctx context.Context, v T) (T, bool, error) {
+		ok, err := call(ctx, opts.Name, keep, v)
+		return v, ok && err == nil, err
+	})
+}
+
+// step is a stage as run: its output, whether to pass it on, and its
+// error
+type step[In, Out any] func(ctx context.Context, v In) (Out, bool, error)
+
+// run starts the workers of a stage over s
+func run[In, Out any](s *Stream[In], opts Options, fn step[In, Out]) *Stream[Out] {
+	opts.Workers = max(opts.Workers, 1)
+	opts.Buffer = max(opts.Buffer, 0)
+	out := make(chan Out, opts.Buffer)
+	if opts.Ordered && opts.Workers > 1 {
+		runOrdered(s, opts, fn, out)
+	} else {
+		runUnordered(s, opts, fn, out)
+	}
+	return &Stream[Out]{p: s.p, ch: out}
+}
+
+// runUnordered has each worker take values from s and send on its own
+// results, closing out once all are done
+func runUnordered[In, Out any](s *Stream[In], opts Options, fn step[In, Out], out chan<- Out) {
+	p := s.p
+	var workers sync.WaitGroup
+	for range opts.Workers {
+		workers.Go(func() {
+			for v := range s.ch {
+				if p.ctx.Err() != nil {
+					return // Upstream stops sending once cancelled
+				}
+				result, keep, err := fn(p.ctx, v)
+				if err != nil {
+					p.fail(err)
+					return
+				}
+				if keep && !send(p.ctx, out, result) {
+					return
+				}
+			}
+		})
+	}
+	p.wg.Go(func() {
+		workers.Wait()
+		close(out)
+	})
+}
+
+// slot holds the result of one value in an ordered stage
+type slot[Out any] struct {
+	value Out
+	keep  bool
+}
+
+// job is a value for a worker of an ordered stage, and the slot for its
+// result
+type job[In, Out any] struct {
+	value In
+	slot  chan slot[Out]
+}
+
+// runOrdered keeps a queue of slots in input order: a dispatcher hands
+// each value to the workers with a slot for its result and queues the
+// slot, and an emitter sends the results on as their slots fill, in
+// queue order. The queue bounds the values in flight, so a slow value
+// holds back at most Workers+Buffer others.
+func runOrdered[In, Out any](s *Stream[In], opts Options, fn step[In, Out], out chan<- Out) {
+	p := s.p
+	jobs := make(chan job[In, Out])
+	queue := make(chan chan slot[Out], opts.Workers+opts.Buffer)
+	p.wg.Go(func() {
+		defer close(queue)
+		defer close(jobs)
+		for v := range s.ch {
+			j := job[In, Out]{value: v, slot: make(chan slot[Out], 1)}
+			if !send(p.ctx, queue, j.slot) || !send(p.ctx, jobs, j) {
+				return
+			}
+		}
+	})
+	for range opts.Workers {
+		p.wg.Go(func() {
+			for j := range jobs {
+				if p.ctx.Err() != nil {
+					j.slot <- slot[Out]{}
+					continue
+				}
+				result, keep, err := fn(p.ctx, j.value)
+				if err != nil {
+					p.fail(err)
+				}
+				j.slot <- slot[Out]{value: result, keep: keep}
+			}
+		})
+	}
+	p.wg.Go(func() {
+		defer close(out)
+		for pending := range queue {
+			var r slot[Out]
+			select {
+			case r = <-pending:
+			case <-p.ctx.Done():
+				return
+			}
+			if p.ctx.Err() != nil {
+				return // Secure: nothing after a failed value passes on
+			}
+			if r.keep && !send(p.ctx, out, r.value) {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"math/rand/v2"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hellogolang/Advanced/syncx"
+)
+
+// jitter sleeps a random few hundred microseconds, so that workers finish
+// out of order
+func jitter(r *rand.Rand) time.Duration {
+	return time.Duration(r.IntN(300)) * time.Microsecond
+}
+
+// TestOrdered tests that an ordered stage keeps input order across
+// workers that finish out of order
+func TestOrdered(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	delays := make([]time.Duration, 200)
+	for i := range delays {
+		delays[i] = jitter(r)
+	}
+	p := New(context.Background())
+	squared := Map(From(p, naturalsTo(200)),
+		func(_ context.Context, v int) (int, error) {
+			time.Sleep(delays[v])
+			return v * v, nil
+		}, Options{Workers: 8, Buffer: 4, Ordered: true})
+	even := Filter(squared, func(_ context.Context, v int) (bool, error) {
+		return v%2 == 0, nil
+	}, Options{Workers: 3, Ordered: true})
+	got, err := Collect(even)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []int
+	for v := 0; v < 200; v += 2 {
+		want = append(want, v*v)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ordered output = %v, want %v", got, want)
+	}
+}
+
+// TestUnordered tests that an unordered stage passes every value on, and
+// runs its workers at once
+func TestUnordered(t *testing.T) {
+	var running, peak atomic.Int32
+	p := New(context.Background())
+	s := Map(From(p, naturalsTo(40)), func(_ context.Context, v int) (int, error) {
+		n := running.Add(1)
+		for old := peak.Load(); n > old && !peak.CompareAndSwap(old, n); old = peak.Load() {
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return v, nil
+	}, Options{Workers: 4})
+	got, err := Collect(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, slices.Collect(naturalsTo(40))) {
+		t.Errorf("unordered output = %v, want 0 to 39 in some order", got)
+	}
+	if peak.Load() < 2 || peak.Load() > 4 {
+		t.Errorf("peak concurrency %d, want 2 to 4 workers", peak.Load())
+	}
+}
+
+// TestFirstError tests that the first stage error cancels the other
+// stages and is returned, naming the stage
+func TestFirstError(t *testing.T) {
+	errBad := errors.New("bad record")
+	for _, ordered := range []bool{false, true} {
+		var after atomic.Int32
+		p := New(context.Background())
+		parsed := Map(From(p, naturals()), func(_ context.Context, v int) (int, error) {
+			if v == 10 {
+				return 0, errBad
+			}
+			return v, nil
+		}, Options{Name: "parse", Workers: 4, Ordered: ordered})
+		stored := Map(parsed, func(ctx context.Context, v int) (int, error) {
+			if v > 10 {
+				after.Add(1)
+			}
+			return v, ctx.Err()
+		}, Options{Name: "store"})
+		_, err := Collect(stored)
+
+		var stageErr *StageError
+		if !errors.As(err, &stageErr) || stageErr.Stage != "parse" || !errors.Is(err, errBad) {
+			t.Errorf("ordered %t: error = %v, want the parse stage's error", ordered, err)
+		}
+		if ordered && after.Load() > 0 {
+			t.Errorf("ordered: %d values after the failed one passed on", after.Load())
+		}
+	}
+}
+
+// TestPanic tests that a panicking stage fails the pipeline
+func TestPanic(t *testing.T) {
+	p := New(context.Background())
+	s := Map(From(p, naturalsTo(5)), func(_ context.Context, v int) (int, error) {
+		if v == 2 {
+			panic("nil record")
+		}
+		return v, nil
+	}, Options{Workers: 2})
+	_, err := Collect(s)
+	var panicErr *syncx.PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "nil record" || len(panicErr.Stack) == 0 {
+		t.Errorf("error = %v, want a syncx.PanicError with its stack", err)
+	}
+}
+
+// naturalsTo yields 0 to n-1
+func naturalsTo(n int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := range n {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+// BenchmarkMap compares ordered and unordered stages of 4 workers
+func BenchmarkMap(b *testing.B) {
+	for _, ordered := range []bool{false, true} {
+		name := "unordered"
+		if ordered {
+			name = "ordered"
+		}
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				p := New(context.Background())
+				s := Map(From(p, naturalsTo(1000)), func(_ context.Context, v int) (int, error) {
+					return v * 2, nil
+				}, Options{Workers: 4, Buffer: 16, Ordered: ordered})
+				if _, err := Collect(s); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package pool
+
+import (
+	"sync"
+	"time"
+)
+
+// Item is a value checked out of a pool. Its holder must give it back
+// with Release, or with Discard if it is broken, exactly once; later
+// calls do nothing.
+type Item[T any] struct {
+	Value T
+
+	pool    *Pool[T]
+	created time.Time
+	once    sync.Once
+}
+
+// Release returns the value to the pool for reuse
+func (it *Item[T]) Release() {
+	it.once.Do(func() { it.pool.put(it.Value, it.created, true) })
+}
+
+// Discard destroys the value, as for a connection found broken, freeing
+// its place in the pool for a new one
+func (it *Item[T]) Discard() {
+	it.once.Do(func() { it.pool.put(it.Value, it.created, false) })
+}
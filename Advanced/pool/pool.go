@@ -0,0 +1,280 @@
+// Package pool provides a generic object pool for expensive, stateful
+// values such as connections, in the manner of database/sql rather than
+// sync.Pool: the pool bounds how many values are checked out at once and
+// how many it keeps idle, retires values past their lifetime, checks each
+// value's health before handing it out, and makes callers wait, up to
+// their context's deadline, while every value is in use. Hooks create,
+// reset and destroy the values.
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by Get once the pool is closed
+var ErrClosed = errors.New("pool closed")
+
+// DefaultMaxIdle is the number of idle values kept when Options leave it
+// unset
+const DefaultMaxIdle = 2
+
+// Options configure a pool. New is required; other zero fields take their
+// defaults.
+type Options[T any] struct {
+	// New creates a value. Get returns its error, wrapped.
+	New func(ctx context.Context) (T, error)
+	// Reset, if set, readies a released value for its next user. A value
+	// it fails is destroyed instead of kept.
+	Reset func(v T) error
+	// Destroy, if set, frees a value the pool discards
+	Destroy func(v T)
+	// Validate, if set, checks an idle value's health before Get hands it
+	// out. A value it fails is destroyed and Get tries the next.
+	Validate func(ctx context.Context, v T) error
+	// MaxActive bounds the values checked out at once; Get waits while it
+	// is reached. 0 or less means no bound.
+	MaxActive int
+	// MaxIdle bounds the values kept for reuse, DefaultMaxIdle if 0 and
+	// none if negative
+	MaxIdle int
+	// MaxLifetime retires values this long after they were created, when
+	// next released or checked out. 0 or less means no limit.
+	MaxLifetime time.Duration
+	// Now is the clock for MaxLifetime, time.Now if nil
+	Now func() time.Time
+}
+
+// Stats describes a pool's values and how callers fared
+type Stats struct {
+	Active    int           // Values checked out
+	Idle      int           // Values kept for reuse
+	Created   uint64        // Values made by New
+	Destroyed uint64        // Values discarded, for whatever reason
+	Expired   uint64        // Values retired by MaxLifetime
+	Invalid   uint64        // Values failed by Validate or Reset
+	Waits     uint64        // Calls to Get that had to wait
+	WaitTime  time.Duration // Time those calls spent waiting
+}
+
+// idleValue is a value kept for reuse and when it was created
+type idleValue[T any] struct {
+	value   T
+	created time.Time
+}
+
+// Pool is a pool of values of type T. It is safe for concurrent use.
+type Pool[T any] struct {
+	opts   Options[T]
+	slots  chan struct{} // Holds a token per checked-out value, nil if unbounded
+	closed chan struct{}
+
+	mu    sync.Mutex
+	idle  []idleValue[T] // Most recently released last
+	stats Stats
+	done  bool
+}
+
+// New creates an empty pool configured by opts. It panics if opts.New is
+// nil, since the pool could never make a value.
+func New[T any](opts Options[T]) *Pool[T] {
+	if opts.New == nil {
+		panic("pool: Options.New is nil")
+	}
+	if opts.MaxIdle == 0 {
+		opts.MaxIdle = DefaultMaxIdle
+	}
+	if opts.MaxActive > 0 {
+		// Idle values beyond MaxActive could never all be in use
+		opts.MaxIdle = min(opts.MaxIdle, opts.MaxActive)
+	}
+	opts.MaxIdle = max(opts.MaxIdle, 0)
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	p := &Pool[T]{opts: opts, closed: make(chan struct{})}
+	if opts.MaxActive > 0 {
+		p.slots = make(chan struct{}, opts.MaxActive)
+	}
+	return p
+}
+
+// Get checks out a value: the most recently released idle one that is
+// alive and valid, or else a new one. While MaxActive values are checked
+// out it waits for one to come back, returning the error of ctx if ctx
+// ends first, or ErrClosed if the pool closes.
+func (p *Pool[T]) Get(ctx context.Context) (*Item[T], error) {
+	if err := p.acquire(ctx); err != nil {
+		return nil, err
+	}
+	item, err := p.checkout(ctx)
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+	return item, nil
+}
+
+// acquire takes a slot for a checked-out value, waiting if need be
+func (p *Pool[T]) acquire(ctx context.Context) error {
+	select {
+	case <-p.closed:
+		return ErrClosed
+	default:
+	}
+	if p.slots == nil {
+		return ctx.Err()
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	start := p.opts.Now()
+	defer func() {
+		p.mu.Lock()
+		p.stats.Waits++
+		p.stats.WaitTime += p.opts.Now().Sub(start)
+		p.mu.Unlock()
+	}()
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-p.closed:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release gives back a slot taken by acquire
+func (p *Pool[T]) release() {
+	if p.slots != nil {
+		<-p.slots
+	}
+}
+
+// checkout returns an idle value that is alive and valid, or a new one
+func (p *Pool[T]) checkout(ctx context.Context) (*Item[T], error) {
+	for {
+		p.mu.Lock()
+		if p.done {
+			p.mu.Unlock()
+			return nil, ErrClosed
+		}
+		n := len(p.idle)
+		if n == 0 {
+			p.mu.Unlock()
+			break
+		}
+		iv := p.idle[n-1]
+		p.idle[n-1] = idleValue[T]{} // Secure: drop the reference for the GC
+		p.idle = p.idle[:n-1]
+		if p.expired(iv.created) {
+			p.stats.Expired++
+			p.mu.Unlock()
+			p.destroy(iv.value)
+			continue
+		}
+		p.stats.Active++
+		p.mu.Unlock()
+
+		if p.opts.Validate != nil {
+			if err := p.opts.Validate(ctx, iv.value); err != nil {
+				p.mu.Lock()
+				p.stats.Active--
+				p.stats.Invalid++
+				p.mu.Unlock()
+				p.destroy(iv.value)
+				continue
+			}
+		}
+		return &Item[T]{Value: iv.value, pool: p, created: iv.created}, nil
+	}
+
+	v, err := p.opts.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("pool: create value: %w", err)
+	}
+	p.mu.Lock()
+	p.stats.Created++
+	p.stats.Active++
+	p.mu.Unlock()
+	return &Item[T]{Value: v, pool: p, created: p.opts.Now()}, nil
+}
+
+// expired reports whether a value created at created has outlived
+// MaxLifetime
+func (p *Pool[T]) expired(created time.Time) bool {
+	return p.opts.MaxLifetime > 0 && p.opts.Now().Sub(created) >= p.opts.MaxLifetime
+}
+
+// destroy discards v, calling Destroy
+func (p *Pool[T]) destroy(v T) {
+	p.mu.Lock()
+	p.stats.Destroyed++
+	p.mu.Unlock()
+	if p.opts.Destroy != nil {
+		p.opts.Destroy(v)
+	}
+}
+
+// put takes back a checked-out value, keeping it if keep is set and the
+// pool has room and a use for it, and destroying it otherwise
+func (p *Pool[T]) put(v T, created time.Time, keep bool) {
+	defer p.release()
+	if keep && p.opts.Reset != nil {
+		if err := p.opts.Reset(v); err != nil {
+			keep = false
+			p.mu.Lock()
+			p.stats.Invalid++
+			p.mu.Unlock()
+		}
+	}
+
+	p.mu.Lock()
+	p.stats.Active--
+	expired := p.expired(created)
+	if expired {
+		p.stats.Expired++
+	}
+	if keep && !expired && !p.done && len(p.idle) < p.opts.MaxIdle {
+		p.idle = append(p.idle, idleValue[T]{v, created})
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	p.destroy(v)
+}
+
+// Stats returns a snapshot of the pool's counters
+func (p *Pool[T]) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.stats
+	s.Idle = len(p.idle)
+	return s
+}
+
+// Close destroys the idle values and makes Get fail with ErrClosed,
+// waking callers waiting in it. Values checked out are destroyed when
+// released. Close may be called more than once.
+func (p *Pool[T]) Close() {
+	p.mu.Lock()
+	if p.done {
+		p.mu.Unlock()
+		return
+	}
+	p.done = true
+	close(p.closed)
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+	for _, iv := range idle {
+		p.destroy(iv.value)
+	}
+}
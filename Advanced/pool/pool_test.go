@@ -0,0 +1,287 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// clock is a manual clock for testing, safe for concurrent use
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock { return &clock{now: time.Unix(1000, 0)} }
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// conn is a fake connection
+type conn struct {
+	id      int
+	healthy bool
+	dirty   bool
+	closed  bool
+}
+
+// fakeConns returns pool options making numbered conns, and a function
+// listing the conns destroyed so far
+func fakeConns() (Options[*conn], func() []int) {
+	var mu sync.Mutex
+	var next int
+	var destroyed []int
+	opts := Options[*conn]{
+		New: func(context.Context) (*conn, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			next++
+			return &conn{id: next, healthy: true}, nil
+		},
+		Reset: func(c *conn) error {
+			if c.dirty {
+				return errors.New("cannot roll back")
+			}
+			return nil
+		},
+		Destroy: func(c *conn) {
+			mu.Lock()
+			defer mu.Unlock()
+			c.closed = true
+			destroyed = append(destroyed, c.id)
+		},
+		Validate: func(_ context.Context, c *conn) error {
+			if !c.healthy {
+				return errors.New("connection reset")
+			}
+			return nil
+		},
+	}
+	return opts, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), destroyed...)
+	}
+}
+
+// mustGet checks out a value or fails t
+func mustGet(t *testing.T, p *Pool[*conn]) *Item[*conn] {
+	t.Helper()
+	it, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	return it
+}
+
+// TestReuse tests that released values are reused, most recent first, and
+// that MaxIdle bounds those kept
+func TestReuse(t *testing.T) {
+	opts, destroyed := fakeConns()
+	opts.MaxIdle = 1
+	p := New(opts)
+
+	a, b := mustGet(t, p), mustGet(t, p)
+	a.Release()
+	b.Release()
+	b.Release() // A second release does nothing
+	if got := destroyed(); len(got) != 1 || got[0] != 2 {
+		t.Errorf("destroyed %v, want conn 2 beyond MaxIdle", got)
+	}
+	if c := mustGet(t, p); c.Value.id != 1 {
+		t.Errorf("reused conn %d, want 1", c.Value.id)
+	}
+	st := p.Stats()
+	if st.Created != 2 || st.Active != 1 || st.Idle != 0 || st.Destroyed != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+// TestHealth tests that Validate, Reset and Discard keep broken values
+// out of the pool
+func TestHealth(t *testing.T) {
+	opts, destroyed := fakeConns()
+	opts.MaxIdle = 5
+	p := New(opts)
+
+	a, b, c := mustGet(t, p), mustGet(t, p), mustGet(t, p)
+	a.Value.healthy = false
+	a.Release()
+	b.Value.dirty = true
+	b.Release()
+	c.Discard()
+	if got := destroyed(); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Errorf("destroyed %v, want the dirty and discarded conns 2 and 3", got)
+	}
+
+	// The idle conn 1 fails validation, so Get makes a new one
+	if d := mustGet(t, p); d.Value.id != 4 {
+		t.Errorf("Get returned conn %d, want a new conn 4", d.Value.id)
+	}
+	if st := p.Stats(); st.Invalid != 2 || st.Destroyed != 3 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+// TestMaxLifetime tests that values are retired when released or checked
+// out after their lifetime
+func TestMaxLifetime(t *testing.T) {
+	clk := newClock()
+	opts, destroyed := fakeConns()
+	opts.MaxLifetime = time.Minute
+	opts.Now = clk.Now
+	p := New(opts)
+
+	a := mustGet(t, p)
+	clk.Advance(30 * time.Second)
+	b := mustGet(t, p)
+	a.Release()
+	b.Release()
+	clk.Advance(70 * time.Second)
+	if c := mustGet(t, p); c.Value.id != 3 {
+		t.Errorf("Get returned conn %d, want a new conn after both expired", c.Value.id)
+	}
+	if got := destroyed(); len(got) != 2 || p.Stats().Expired != 2 {
+		t.Errorf("destroyed %v, stats %+v", got, p.Stats())
+	}
+
+	d := mustGet(t, p)
+	clk.Advance(2 * time.Minute)
+	d.Release()
+	if p.Stats().Idle != 0 {
+		t.Error("a value released after its lifetime was kept")
+	}
+}
+
+// TestMaxActive tests waiting for a value, giving up with the context, and
+// being woken by a release
+func TestMaxActive(t *testing.T) {
+	opts, _ := fakeConns()
+	opts.MaxActive = 1
+	p := New(opts)
+	a := mustGet(t, p)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get on an exhausted pool error = %v, want DeadlineExceeded", err)
+	}
+
+	got := make(chan *Item[*conn])
+	go func() {
+		it, err := p.Get(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		got <- it
+	}()
+	time.Sleep(10 * time.Millisecond)
+	a.Release()
+	if it := <-got; it.Value.id != 1 {
+		t.Errorf("waiter got conn %d, want the released conn 1", it.Value.id)
+	}
+	if st := p.Stats(); st.Waits != 2 || st.Created != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+// TestNewError tests that a failing New frees its place in the pool
+func TestNewError(t *testing.T) {
+	errDial := errors.New("dial failed")
+	p := New(Options[int]{
+		New:       func(context.Context) (int, error) { return 0, errDial },
+		MaxActive: 1,
+	})
+	for range 3 {
+		if _, err := p.Get(context.Background()); !errors.Is(err, errDial) {
+			t.Fatalf("Get error = %v, want the error of New", err)
+		}
+	}
+}
+
+// TestClose tests that Close wakes waiters, destroys idle values and
+// destroys values released afterwards
+func TestClose(t *testing.T) {
+	opts, destroyed := fakeConns()
+	opts.MaxActive = 2
+	p := New(opts)
+	a, b := mustGet(t, p), mustGet(t, p)
+
+	waiter := make(chan error)
+	go func() {
+		_, err := p.Get(context.Background())
+		waiter <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	a.Release()
+	if err := <-waiter; err != nil {
+		t.Fatalf("waiting Get failed: %v", err)
+	}
+	go func() {
+		_, err := p.Get(context.Background())
+		waiter <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	p.Close()
+	p.Close()
+	if err := <-waiter; !errors.Is(err, ErrClosed) {
+		t.Errorf("waiting Get error = %v, want ErrClosed", err)
+	}
+	b.Release()
+	if got := destroyed(); len(got) != 1 || got[0] != 2 {
+		t.Errorf("destroyed %v, want conn 2 released after Close", got)
+	}
+	if _, err := p.Get(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get after Close error = %v, want ErrClosed", err)
+	}
+
+	opts, destroyed = fakeConns()
+	p = New(opts)
+	mustGet(t, p).Release()
+	p.Close()
+	if got := destroyed(); len(got) != 1 || p.Stats().Idle != 0 {
+		t.Errorf("destroyed %v, want the idle conn destroyed by Close", got)
+	}
+}
+
+// TestConcurrent tests that MaxActive holds under concurrent use; run with
+// -race
+func TestConcurrent(t *testing.T) {
+	var active, peak atomic.Int64
+	opts, _ := fakeConns()
+	opts.MaxActive = 3
+	p := New(opts)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			for range 50 {
+				it, err := p.Get(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n := active.Add(1)
+				for old := peak.Load(); n > old && !peak.CompareAndSwap(old, n); old = peak.Load() {
+				}
+				active.Add(-1)
+				it.Release()
+			}
+		})
+	}
+	wg.Wait()
+	if peak.Load() > 3 || p.Stats().Created > 3 || p.Stats().Active != 0 {
+		t.Errorf("peak %d, stats %+v", peak.Load(), p.Stats())
+	}
+}
@@ -0,0 +1,172 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// SubscriptionStats counts the messages of one subscription
+type SubscriptionStats struct {
+	Pending  int    // Messages buffered, not yet received
+	Received uint64 // Messages returned by Receive
+	Dropped  uint64 // Messages discarded under DropOldest
+}
+
+// Subscription receives the messages published to a topic
+type Subscription[T any] struct {
+	topic  *Topic[T]
+	policy Policy
+
+	mu     sync.Mutex
+	buf    []T // A ring of pending messages
+	head   int
+	n      int
+	reason error // Why the subscription closed, nil while open
+	stats  SubscriptionStats
+
+	ready chan struct{} // Signalled when a message arrives
+	space chan struct{} // Signalled when a message leaves, for Block
+	done  chan struct{} // Closed when the subscription closes
+}
+
+// newSubscription creates an open subscription to t
+func newSubscription[T any](t *Topic[T], opts Options) *Subscription[T] {
+	return &Subscription[T]{
+		topic:  t,
+		policy: opts.Policy,
+		buf:    make([]T, opts.Buffer),
+		ready:  make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+// signal wakes a goroutine waiting on c, if one is not already due to wake
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// offer buffers msg by the subscription's policy, reporting whether it
+// was buffered and whether an older message was dropped for it. Under
+// CloseSlow a full buffer returns ErrSlowSubscriber; under Block, the
+// error of ctx if it ends while the buffer stays full.
+func (s *Subscription[T]) offer(ctx context.Context, msg T) (delivered, dropped bool, err error) {
+	for {
+		s.mu.Lock()
+		if s.reason != nil {
+			s.mu.Unlock()
+			return false, false, nil
+		}
+		if s.n == len(s.buf) {
+			switch s.policy {
+			case DropOldest:
+				s.pop()
+				s.stats.Dropped++
+				dropped = true
+			case CloseSlow:
+				s.mu.Unlock()
+				return false, false, ErrSlowSubscriber
+			default:
+				s.mu.Unlock()
+				select {
+				case <-s.space:
+				case <-s.done:
+				case <-ctx.Done():
+					return false, false, ctx.Err()
+				}
+				continue
+			}
+		}
+		s.buf[(s.head+s.n)%len(s.buf)] = msg
+		s.n++
+		room := s.n < len(s.buf)
+		s.mu.Unlock()
+		signal(s.ready)
+		if room {
+			// Pass the wakeup on to any other blocked publisher
+			signal(s.space)
+		}
+		return true, dropped, nil
+	}
+}
+
+// pop removes and returns the oldest message. The caller holds mu and
+// has checked the buffer is not empty.
+func (s *Subscription[T]) pop() T {
+	var zero T
+	msg := s.buf[s.head]
+	// Secure: clear the slot so a received message is not kept alive
+	s.buf[s.head] = zero
+	s.head = (s.head + 1) % len(s.buf)
+	s.n--
+	return msg
+}
+
+// Receive returns the next message, waiting for one until ctx ends. Once
+// the subscription is closed and its buffer drained, it returns why:
+// ErrUnsubscribed, ErrTopicClosed or ErrSlowSubscriber.
+func (s *Subscription[T]) Receive(ctx context.Context) (T, error) {
+	for {
+		s.mu.Lock()
+		if s.n > 0 {
+			msg := s.pop()
+			s.stats.Received++
+			more := s.n > 0
+			s.mu.Unlock()
+			signal(s.space)
+			if more {
+				// Pass the wakeup on to any other receiver
+				signal(s.ready)
+			}
+			return msg, nil
+		}
+		reason := s.reason
+		s.mu.Unlock()
+		if reason != nil {
+			var zero T
+			return zero, reason
+		}
+		select {
+		case <-s.ready:
+		case <-s.done:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// Unsubscribe removes the subscription from its topic; see
+// Topic.Unsubscribe
+func (s *Subscription[T]) Unsubscribe() bool {
+	return s.topic.Unsubscribe(s)
+}
+
+// Done returns a channel closed when the subscription closes, though
+// messages may still be buffered for Receive
+func (s *Subscription[T]) Done() <-chan struct{} {
+	return s.done
+}
+
+// Stats returns a snapshot of the subscription's counters
+func (s *Subscription[T]) Stats() SubscriptionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stats
+	st.Pending = s.n
+	return st
+}
+
+// close closes the subscription with reason, waking its receivers and
+// any publisher blocked on it
+func (s *Subscription[T]) close(reason error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reason == nil {
+		s.reason = reason
+		close(s.done)
+	}
+}
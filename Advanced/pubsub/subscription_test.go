@@ -0,0 +1,43 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestReceive tests that Receive waits for a message, honours its context
+// and ends with the reason the subscription closed
+func TestReceive(t *testing.T) {
+	topic := NewTopic[int]()
+	s := topic.Subscribe(Options{})
+	short, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := s.Receive(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Receive with nothing published error = %v, want DeadlineExceeded", err)
+	}
+
+	got := make(chan int)
+	go func() {
+		msg, _ := s.Receive(context.Background())
+		got <- msg
+	}()
+	time.Sleep(time.Millisecond)
+	topic.Publish(context.Background(), 7)
+	if msg := <-got; msg != 7 {
+		t.Errorf("waiting Receive = %d, want 7", msg)
+	}
+
+	if !s.Unsubscribe() || s.Unsubscribe() {
+		t.Error("Unsubscribe did not report the subscription once")
+	}
+	select {
+	case <-s.Done():
+	default:
+		t.Error("Done not closed after Unsubscribe")
+	}
+	if _, err := s.Receive(context.Background()); !errors.Is(err, ErrUnsubscribed) {
+		t.Errorf("Receive after Unsubscribe error = %v, want ErrUnsubscribed", err)
+	}
+}
@@ -0,0 +1,181 @@
+// Package pubsub provides typed publish/subscribe topics. A Topic[T]
+// fans each published message out to every Subscription, each with its
+// own buffer and a Policy for when that buffer is full: drop the oldest
+// message, block the publisher, or close the slow subscriber so it cannot
+// hold the others back. Receive waits for a message under a context, and
+// both topics and subscriptions count what they deliver and lose.
+//
+// Messages from one publisher reach each subscriber in the order
+// published; messages published concurrently may interleave differently
+// for different subscribers.
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrTopicClosed is returned by Publish on a closed topic, and by
+	// Receive once a subscription of a closed topic is drained
+	ErrTopicClosed = errors.New("topic closed")
+	// ErrUnsubscribed is returned by Receive once an unsubscribed
+	// subscription is drained
+	ErrUnsubscribed = errors.New("unsubscribed")
+	// ErrSlowSubscriber is returned by Receive once a subscription closed
+	// for falling behind under CloseSlow is drained
+	ErrSlowSubscriber = errors.New("subscriber too slow")
+)
+
+// Policy chooses what Publish does when a subscription's buffer is full
+type Policy int
+
+// The policies for a full buffer
+const (
+	DropOldest Policy = iota // Discard the oldest buffered message
+	Block                    // Wait for room until Publish's context ends
+	CloseSlow                // Close the subscription with ErrSlowSubscriber
+)
+
+// DefaultBuffer is the buffer of a subscription with a zero Buffer
+const DefaultBuffer = 16
+
+// Options configures a subscription. The zero value buffers
+// DefaultBuffer messages and drops the oldest when full.
+type Options struct {
+	// Buffer bounds the messages waiting for Receive, DefaultBuffer if 0
+	// or less
+	Buffer int
+	// Policy chooses what happens when the buffer is full
+	Policy Policy
+}
+
+// TopicStats counts the messages a topic has fanned out
+type TopicStats struct {
+	Subscribers int    // Current subscriptions
+	Published   uint64 // Calls of Publish that ran
+	Delivered   uint64 // Messages buffered for a subscription
+	Dropped     uint64 // Messages discarded under DropOldest
+	Evicted     uint64 // Subscriptions closed under CloseSlow
+}
+
+// Topic fans messages of type T out to its subscriptions
+type Topic[T any] struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription[T]]struct{}
+	closed bool
+	stats  TopicStats
+	smu    sync.Mutex // Guards stats, updated while mu is only read-held
+}
+
+// NewTopic creates a topic without subscriptions
+func NewTopic[T any]() *Topic[T] {
+	return &Topic[T]{subs: make(map[*Subscription[T]]struct{})}
+}
+
+// Subscribe creates a subscription receiving every message published from
+// now on. On a closed topic the subscription is already closed.
+func (t *Topic[T]) Subscribe(opts Options) *Subscription[T] {
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultBuffer
+	}
+	s := newSubscription(t, opts)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		s.close(ErrTopicClosed)
+		return s
+	}
+	t.subs[s] = struct{}{}
+	return s
+}
+
+// Unsubscribe removes s from the topic and closes it, letting its
+// receiver drain what is buffered, and reports whether it was subscribed
+func (t *Topic[T]) Unsubscribe(s *Subscription[T]) bool {
+	return t.remove(s, ErrUnsubscribed)
+}
+
+// remove removes s, closing it with reason, if it is still subscribed
+func (t *Topic[T]) remove(s *Subscription[T], reason error) bool {
+	t.mu.Lock()
+	_, ok := t.subs[s]
+	delete(t.subs, s)
+	t.mu.Unlock()
+	if ok {
+		s.close(reason)
+	}
+	return ok
+}
+
+// Publish delivers msg to every subscription, by its policy when its
+// buffer is full. It returns ErrTopicClosed on a closed topic, or the
+// error of ctx if a Block subscription stays full until ctx ends, in
+// which case the subscriptions not yet reached miss msg.
+func (t *Topic[T]) Publish(ctx context.Context, msg T) error {
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return ErrTopicClosed
+	}
+	subs := make([]*Subscription[T], 0, len(t.subs))
+	for s := range t.subs {
+		subs = append(subs, s)
+	}
+	t.mu.RUnlock()
+
+	t.count(func(st *TopicStats) { st.Published++ })
+	for _, s := range subs {
+		delivered, dropped, err := s.offer(ctx, msg)
+		switch {
+		case errors.Is(err, ErrSlowSubscriber):
+			if t.remove(s, ErrSlowSubscriber) {
+				t.count(func(st *TopicStats) { st.Evicted++ })
+			}
+		case err != nil:
+			return err
+		}
+		t.count(func(st *TopicStats) {
+			if delivered {
+				st.Delivered++
+			}
+			if dropped {
+				st.Dropped++
+			}
+		})
+	}
+	return nil
+}
+
+// count updates the topic's counters
+func (t *Topic[T]) count(update func(*TopicStats)) {
+	t.smu.Lock()
+	update(&t.stats)
+	t.smu.Unlock()
+}
+
+// Stats returns a snapshot of the topic's counters
+func (t *Topic[T]) Stats() TopicStats {
+	t.mu.RLock()
+	n := len(t.subs)
+	t.mu.RUnlock()
+	t.smu.Lock()
+	defer t.smu.Unlock()
+	st := t.stats
+	st.Subscribers = n
+	return st
+}
+
+// Close closes the topic and all its subscriptions, whose receivers get
+// what is buffered and then ErrTopicClosed
+func (t *Topic[T]) Close() {
+	t.mu.Lock()
+	subs := t.subs
+	t.subs = make(map[*Subscription[T]]struct{})
+	t.closed = true
+	t.mu.Unlock()
+	for s := range subs {
+		s.close(ErrTopicClosed)
+	}
+}
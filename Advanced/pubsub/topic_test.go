@@ -0,0 +1,177 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiveAll receives the messages buffered in s until it would block
+func receiveAll[T any](s *Subscription[T]) []T {
+	msgs := []T{}
+	for s.Stats().Pending > 0 {
+		msg, err := s.Receive(context.Background())
+		if err != nil {
+			break
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// TestFanOut tests that every subscriber gets every message, in order
+func TestFanOut(t *testing.T) {
+	topic := NewTopic[int]()
+	ctx := context.Background()
+	subs := []*Subscription[int]{}
+	for range 3 {
+		subs = append(subs, topic.Subscribe(Options{Buffer: 100}))
+	}
+	for i := range 50 {
+		if err := topic.Publish(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for n, s := range subs {
+		for i := range 50 {
+			if msg, err := s.Receive(ctx); msg != i || err != nil {
+				t.Fatalf("subscriber %d: message %d = %d, %v", n, i, msg, err)
+			}
+		}
+	}
+	if st := topic.Stats(); st.Subscribers != 3 || st.Published != 50 || st.Delivered != 150 {
+		t.Errorf("topic stats = %+v", st)
+	}
+}
+
+// TestPolicies tests each policy for a full buffer
+func TestPolicies(t *testing.T) {
+	ctx := context.Background()
+	topic := NewTopic[int]()
+	drop := topic.Subscribe(Options{Buffer: 3, Policy: DropOldest})
+	slow := topic.Subscribe(Options{Buffer: 3, Policy: CloseSlow})
+	for i := range 5 {
+		topic.Publish(ctx, i)
+	}
+	if got := receiveAll(drop); len(got) != 3 || got[0] != 2 || got[2] != 4 {
+		t.Errorf("DropOldest kept %v, want [2 3 4]", got)
+	}
+	if st := drop.Stats(); st.Dropped != 2 || st.Received != 3 {
+		t.Errorf("DropOldest stats = %+v", st)
+	}
+	if got := receiveAll(slow); len(got) != 3 || got[0] != 0 {
+		t.Errorf("CloseSlow buffered %v, want [0 1 2]", got)
+	}
+	if _, err := slow.Receive(ctx); !errors.Is(err, ErrSlowSubscriber) {
+		t.Errorf("CloseSlow: Receive error = %v, want ErrSlowSubscriber", err)
+	}
+	if st := topic.Stats(); st.Subscribers != 1 || st.Evicted != 1 || st.Dropped != 2 {
+		t.Errorf("topic stats = %+v", st)
+	}
+
+	block := topic.Subscribe(Options{Buffer: 1, Policy: Block})
+	topic.Publish(ctx, 10)
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := topic.Publish(short, 11); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Block: Publish to a full subscriber error = %v, want DeadlineExceeded", err)
+	}
+	done := make(chan error)
+	go func() { done <- topic.Publish(ctx, 12) }()
+	if msg, _ := block.Receive(ctx); msg != 10 {
+		t.Errorf("Block: first message = %d, want 10", msg)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Block: Publish after Receive failed: %v", err)
+	}
+	if msg, _ := block.Receive(ctx); msg != 12 {
+		t.Errorf("Block: second message = %d, want 12", msg)
+	}
+
+	// Unsubscribing releases a publisher blocked on the subscription
+	topic.Publish(ctx, 13)
+	go func() { done <- topic.Publish(ctx, 14) }()
+	time.Sleep(5 * time.Millisecond)
+	block.Unsubscribe()
+	if err := <-done; err != nil {
+		t.Errorf("Block: Publish to an unsubscribed subscriber failed: %v", err)
+	}
+}
+
+// TestClose tests that subscribers drain their buffers before learning
+// the topic closed, and that a closed topic refuses messages
+func TestClose(t *testing.T) {
+	ctx := context.Background()
+	topic := NewTopic[string]()
+	s := topic.Subscribe(Options{})
+	topic.Publish(ctx, "last")
+	topic.Close()
+	if msg, err := s.Receive(ctx); msg != "last" || err != nil {
+		t.Errorf("Receive after Close = %q, %v, want the buffered message", msg, err)
+	}
+	if _, err := s.Receive(ctx); !errors.Is(err, ErrTopicClosed) {
+		t.Errorf("Receive of a drained subscription error = %v, want ErrTopicClosed", err)
+	}
+	if err := topic.Publish(ctx, "late"); !errors.Is(err, ErrTopicClosed) {
+		t.Errorf("Publish after Close error = %v, want ErrTopicClosed", err)
+	}
+	if _, err := topic.Subscribe(Options{}).Receive(ctx); !errors.Is(err, ErrTopicClosed) {
+		t.Errorf("Receive of a late subscription error = %v, want ErrTopicClosed", err)
+	}
+}
+
+// TestConcurrent publishes and receives from many goroutines, for the race
+// detector, checking that Block loses nothing and each publisher's order
+// holds
+func TestConcurrent(t *testing.T) {
+	type msg struct{ publisher, seq int }
+	topic := NewTopic[msg]()
+	ctx := context.Background()
+	const publishers, perPublisher = 4, 300
+	var wg sync.WaitGroup
+	for range 3 {
+		s := topic.Subscribe(Options{Buffer: 2, Policy: Block})
+		wg.Go(func() {
+			next := make([]int, publishers)
+			for range publishers * perPublisher {
+				m, err := s.Receive(ctx)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if m.seq != next[m.publisher] {
+					t.Errorf("publisher %d: got %d, want %d", m.publisher, m.seq, next[m.publisher])
+				}
+				next[m.publisher]++
+			}
+		})
+	}
+	lossy := topic.Subscribe(Options{Buffer: 1})
+	var pubs sync.WaitGroup
+	for p := range publishers {
+		pubs.Go(func() {
+			for i := range perPublisher {
+				topic.Publish(ctx, msg{p, i})
+			}
+		})
+	}
+	pubs.Wait()
+	wg.Wait()
+	if st := lossy.Stats(); st.Dropped+uint64(st.Pending) != publishers*perPublisher {
+		t.Errorf("lossy subscriber stats = %+v", st)
+	}
+}
+
+// BenchmarkPublish measures fanning a message out to 10 subscribers
+func BenchmarkPublish(b *testing.B) {
+	topic := NewTopic[int]()
+	for range 10 {
+		topic.Subscribe(Options{Buffer: 1})
+	}
+	ctx := context.Background()
+	for b.Loop() {
+		topic.Publish(ctx, 1)
+	}
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+
+	"hellogolang/Advanced/caches"
+)
+
+// Keyed keeps a separate limiter for each key, created on first use. It
+// holds at most maxKeys limiters, discarding the least recently used when
+// a new key needs room; a discarded key starts afresh when it returns, so
+// maxKeys should exceed the keys active in any one window.
+type Keyed[K comparable] struct {
+	mu         sync.Mutex
+	limiters   *caches.LRU[K, Limiter]
+	newLimiter func() Limiter
+}
+
+// NewKeyed creates a Keyed limiter making each key's limiter with
+// newLimiter and keeping up to maxKeys of them, or any number if maxKeys
+// is 0 or less
+func NewKeyed[K comparable](maxKeys int, newLimiter func() Limiter) *Keyed[K] {
+	return &Keyed[K]{
+		limiters:   caches.NewLRU[K, Limiter](caches.Options{MaxEntries: maxKeys}),
+		newLimiter: newLimiter,
+	}
+}
+
+// Limiter returns the limiter of key, creating it if need be
+func (k *Keyed[K]) Limiter(key K) Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l, ok := k.limiters.Get(key)
+	if !ok {
+		l = k.newLimiter()
+		k.limiters.Set(key, l)
+	}
+	return l
+}
+
+// Allow reports whether a request for key may proceed
+func (k *Keyed[K]) Allow(key K) bool {
+	return k.Limiter(key).Allow()
+}
+
+// AllowN reports whether n requests for key may proceed
+func (k *Keyed[K]) AllowN(key K, n int) bool {
+	return k.Limiter(key).AllowN(n)
+}
+
+// Wait blocks until a request for key may proceed
+func (k *Keyed[K]) Wait(ctx context.Context, key K) error {
+	return k.Limiter(key).Wait(ctx)
+}
+
+// Len returns the number of keys with a limiter
+func (k *Keyed[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.limiters.Len()
+}
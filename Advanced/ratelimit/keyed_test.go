@@ -0,0 +1,29 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestKeyed tests that keys are limited separately and that the least
+// recently used key is discarded beyond maxKeys
+func TestKeyed(t *testing.T) {
+	k := NewKeyed[string](2, func() Limiter { return NewSlidingWindowLog(3, time.Hour) })
+	for range 5 {
+		k.Allow("alice")
+	}
+	if k.Allow("alice") || !k.Allow("bob") || !k.AllowN("bob", 2) || k.Allow("bob") {
+		t.Error("keys were not limited separately")
+	}
+	if err := k.Wait(context.Background(), "carol"); err != nil {
+		t.Errorf("Wait for a new key failed: %v", err)
+	}
+	if k.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", k.Len())
+	}
+	// Alice was least recently used, so she starts afresh
+	if !k.Allow("alice") {
+		t.Error("a discarded key kept its limit")
+	}
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LeakyBucket is a leaky bucket used as a queue: requests leave it one at
+// a time, evenly spaced at rate per second, however they arrive. Unlike a
+// TokenBucket it never allows a burst, which suits smoothing traffic to a
+// service that cannot absorb one. Allow admits a request only if it can
+// leave at once; Wait queues it for its turn, refusing once capacity
+// requests are waiting.
+type LeakyBucket struct {
+	mu       sync.Mutex
+	interval time.Duration // Between requests leaving, 0 if none ever do
+	capacity int
+	next     time.Time // When the next request may leave
+	now      func() time.Time
+}
+
+// NewLeakyBucket creates a leaky bucket letting requests leave at rate per
+// second, with up to capacity more waiting. A rate of 0 or less lets no
+// request leave; a negative capacity is taken as 0.
+func NewLeakyBucket(rate float64, capacity int) *LeakyBucket {
+	b := &LeakyBucket{capacity: max(capacity, 0), now: time.Now}
+	if rate > 0 {
+		b.interval = max(seconds(1/rate), 1)
+	}
+	return b
+}
+
+// Allow reports whether a request may leave now, taking its turn if so
+func (b *LeakyBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN reports whether n requests may leave now, in which case they take
+// the next n turns and later requests wait for them
+func (b *LeakyBucket) AllowN(n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	if b.interval == 0 || b.next.After(now) {
+		return false
+	}
+	b.next = now.Add(time.Duration(max(n, 0)) * b.interval)
+	return true
+}
+
+// Wait takes the next turn and blocks until it comes. It returns
+// ErrQueueFull at once if capacity requests are already waiting, and the
+// error of ctx if it is done first, giving up the turn if no later one has
+// been taken.
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	if b.interval == 0 {
+		b.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	now := b.now()
+	turn := b.next
+	if turn.Before(now) {
+		turn = now
+	}
+	if waiting := turn.Sub(now); waiting > time.Duration(b.capacity)*b.interval {
+		b.mu.Unlock()
+		return fmt.Errorf("%w: next turn in %v", ErrQueueFull, waiting)
+	}
+	b.next = turn.Add(b.interval)
+	b.mu.Unlock()
+
+	timer := time.NewTimer(turn.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		if b.next.Equal(turn.Add(b.interval)) {
+			b.next = turn
+		}
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestLeakyBucket tests that requests are spaced evenly without bursts
+func TestLeakyBucket(t *testing.T) {
+	clk := newClock()
+	b := NewLeakyBucket(10, 3)
+	b.now = clk.Now
+	if n := countAllowed(b, clk, 10, 0); n != 1 {
+		t.Errorf("burst allowed %d of 10, want 1", n)
+	}
+	clk.Advance(time.Hour)
+	if n := countAllowed(b, clk, 100, 25*time.Millisecond); n != 25 {
+		t.Errorf("a request every 25ms at 10 per second allowed %d of 100, want 25", n)
+	}
+	clk.Advance(time.Hour)
+	if !b.AllowN(3) {
+		t.Fatal("AllowN(3) refused on an idle bucket")
+	}
+	clk.Advance(299 * time.Millisecond)
+	if b.Allow() {
+		t.Error("allowed a request before 3 turns had passed")
+	}
+	clk.Advance(time.Millisecond)
+	if !b.Allow() {
+		t.Error("refused a request after 3 turns had passed")
+	}
+
+	if NewLeakyBucket(0, 5).Allow() {
+		t.Error("a bucket with rate 0 allowed a request")
+	}
+}
+
+// TestLeakyBucketQueue tests the queue bound of Wait and that a cancelled
+// waiter gives back its turn
+func TestLeakyBucketQueue(t *testing.T) {
+	clk := newClock()
+	b := NewLeakyBucket(1, 2)
+	b.now = clk.Now
+	ctx := context.Background()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatalf("first Wait failed: %v", err)
+	}
+	turn := b.next
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait for a turn 1s away error = %v, want DeadlineExceeded", err)
+	}
+	if !b.next.Equal(turn) {
+		t.Errorf("a cancelled Wait kept its turn")
+	}
+
+	// Two waiters fill the queue, and a third is refused
+	waiting, stop := context.WithCancel(ctx)
+	done := make(chan error, 2)
+	for range 2 {
+		go func() { done <- b.Wait(waiting) }()
+	}
+	for full := turn.Add(2 * time.Second); ; time.Sleep(time.Millisecond) {
+		b.mu.Lock()
+		queued := b.next.Equal(full)
+		b.mu.Unlock()
+		if queued {
+			break
+		}
+	}
+	if err := b.Wait(ctx); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Wait with 2 waiting error = %v, want ErrQueueFull", err)
+	}
+	stop()
+	for range 2 {
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled waiter error = %v, want context.Canceled", err)
+		}
+	}
+
+	never, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := NewLeakyBucket(0, 1).Wait(never); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait on a bucket with rate 0 error = %v, want context.Canceled", err)
+	}
+}
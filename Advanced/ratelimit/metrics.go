@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"hellogolang/Advanced/metrics"
+)
+
+// instrumented is a Limiter counting the decisions of another
+type instrumented struct {
+	Limiter
+	allowed, limited *metrics.Counter
+	wait             *metrics.Histogram
+}
+
+// Instrumented returns a Limiter deciding as l does and recording each
+// decision in r, labelled limiter=name: ratelimit_requests_total counts
+// requests by result, allowed or limited, and ratelimit_wait_seconds
+// times calls to Wait, whether or not they end allowed. Limiters
+// registered with the same r share the families and need distinct names.
+func Instrumented(l Limiter, r *metrics.Registry, name string) Limiter {
+	requests := r.CounterVec("ratelimit_requests_total", "Requests decided by the rate limiter", "limiter", "result")
+	return &instrumented{
+		Limiter: l,
+		allowed: requests.With(name, "allowed"),
+		limited: requests.With(name, "limited"),
+		wait:    r.HistogramVec("ratelimit_wait_seconds", "Time spent in Wait", nil, "limiter").With(name),
+	}
+}
+
+// Allow reports whether one request may proceed now, counting it
+func (l *instrumented) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN reports whether n requests may proceed now, counting all n
+func (l *instrumented) AllowN(n int) bool {
+	ok := l.Limiter.AllowN(n)
+	if ok {
+		l.allowed.Add(float64(n))
+	} else {
+		l.limited.Add(float64(n))
+	}
+	return ok
+}
+
+// Wait blocks until one request may proceed, counting and timing it
+func (l *instrumented) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := l.Limiter.Wait(ctx)
+	l.wait.Observe(time.Since(start).Seconds())
+	if err != nil {
+		l.limited.Inc()
+	} else {
+		l.allowed.Inc()
+	}
+	return err
+}
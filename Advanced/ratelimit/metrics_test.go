@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hellogolang/Advanced/metrics"
+)
+
+// TestInstrumented tests that an instrumented limiter decides as the one
+// it wraps and counts each decision
+func TestInstrumented(t *testing.T) {
+	clk := newClock()
+	b := NewTokenBucket(1, 3)
+	b.now = clk.Now
+	r := metrics.NewRegistry()
+	l := Instrumented(b, r, "api")
+
+	if got := countAllowed(l, clk, 5, 0); got != 3 {
+		t.Errorf("allowed %d of 5, want the burst of 3", got)
+	}
+	if l.AllowN(2) {
+		t.Error("AllowN(2) allowed on an empty bucket")
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(cancelled); err == nil {
+		t.Error("Wait with a cancelled context succeeded")
+	}
+
+	var out strings.Builder
+	if err := r.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`ratelimit_requests_total{limiter="api",result="allowed"} 3`,
+		`ratelimit_requests_total{limiter="api",result="limited"} 5`,
+		`ratelimit_wait_seconds_count{limiter="api"} 1`,
+	} {
+		if !strings.Contains(out.String(), want+"\n") {
+			t.Errorf("exposition lacks %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Package ratelimit provides rate limiters behind one Limiter interface:
+// TokenBucket allows bursts up to its capacity and a steady rate after,
+// LeakyBucket spaces requests evenly with no bursts, and SlidingWindowLog
+// and SlidingWindowCounter cap the requests in any window of time, exactly
+// or approximately. Keyed keeps a limiter per key, such as a client
+// address or an account. All are safe for concurrent use. Instrumented
+// wraps any Limiter to count its decisions in a metrics.Registry.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrQueueFull is returned by LeakyBucket.Wait when too many requests are
+// already waiting
+var ErrQueueFull = errors.New("rate limit queue full")
+
+// never is the delay reported when no request will ever be allowed
+const never = time.Duration(math.MaxInt64)
+
+// Limiter is the interface the rate limiters share
+type Limiter interface {
+	// Allow reports whether one request may proceed now, counting it if so
+	Allow() bool
+	// AllowN reports whether n requests may proceed now, counting them all
+	// if so and none otherwise
+	AllowN(n int) bool
+	// Wait blocks until one request may proceed, or returns the error of
+	// ctx if it is done first
+	Wait(ctx context.Context) error
+}
+
+// wait blocks until allow succeeds, sleeping between attempts for the
+// delay that delay estimates. Concurrent waiters race for each request
+// freed, so the losers sleep again.
+func wait(ctx context.Context, allow func() bool, delay func() time.Duration) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if allow() {
+			return nil
+		}
+		timer := time.NewTimer(max(delay(), 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// seconds converts a number of seconds to a Duration, saturating
+func seconds(s float64) time.Duration {
+	if d := s * float64(time.Second); d < float64(never) {
+		return time.Duration(math.Ceil(d))
+	}
+	return never
+}
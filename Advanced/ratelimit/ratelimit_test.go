@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock is a manual clock for testing, safe for concurrent use
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock { return &clock{now: time.Unix(1000, 0)} }
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// countAllowed returns how many of n requests l allows, spaced by step on
+// clk
+func countAllowed(l Limiter, clk *clock, n int, step time.Duration) int {
+	allowed := 0
+	for range n {
+		if l.Allow() {
+			allowed++
+		}
+		clk.Advance(step)
+	}
+	return allowed
+}
+
+// TestWait tests that Wait paces requests at the limiter's rate on the real
+// clock, and returns when its context is done
+func TestWait(t *testing.T) {
+	limiters := map[string]func() Limiter{
+		"TokenBucket":          func() Limiter { return NewTokenBucket(200, 1) },
+		"LeakyBucket":          func() Limiter { return NewLeakyBucket(200, 100) },
+		"SlidingWindowLog":     func() Limiter { return NewSlidingWindowLog(2, 10*time.Millisecond) },
+		"SlidingWindowCounter": func() Limiter { return NewSlidingWindowCounter(2, 10*time.Millisecond) },
+	}
+	for name, newLimiter := range limiters {
+		l := newLimiter()
+		start := time.Now()
+		for range 11 {
+			if err := l.Wait(context.Background()); err != nil {
+				t.Fatalf("%s: Wait failed: %v", name, err)
+			}
+		}
+		// Ten intervals of 5ms after the first request
+		if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+			t.Errorf("%s: 11 waits took %v, want about 50ms", name, elapsed)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		for range 5 {
+			l.Allow()
+		}
+		err := l.Wait(ctx)
+		cancel()
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrQueueFull) {
+			t.Errorf("%s: Wait with an expiring context error = %v", name, err)
+		}
+		cancelled, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := l.Wait(cancelled); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: Wait with a cancelled context error = %v, want context.Canceled", name, err)
+		}
+	}
+}
+
+// TestConcurrent tests that concurrent callers together get no more than
+// the limit, run with -race to find data races
+func TestConcurrent(t *testing.T) {
+	clk := newClock()
+	limiters := map[string]Limiter{
+		"TokenBucket":          NewTokenBucket(1, 100),
+		"LeakyBucket":          NewLeakyBucket(1, 0),
+		"SlidingWindowLog":     NewSlidingWindowLog(100, time.Hour),
+		"SlidingWindowCounter": NewSlidingWindowCounter(100, time.Hour),
+	}
+	want := map[string]int{"TokenBucket": 100, "LeakyBucket": 1, "SlidingWindowLog": 100, "SlidingWindowCounter": 100}
+	for name, l := range limiters {
+		setClock(l, clk)
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		allowed := 0
+		for range 8 {
+			wg.Go(func() {
+				for range 50 {
+					if l.Allow() {
+						mu.Lock()
+						allowed++
+						mu.Unlock()
+					}
+				}
+			})
+		}
+		wg.Wait()
+		if allowed != want[name] {
+			t.Errorf("%s: allowed %d of 400 concurrent requests, want %d", name, allowed, want[name])
+		}
+	}
+}
+
+// setClock makes l read the time from clk
+func setClock(l Limiter, clk *clock) {
+	switch l := l.(type) {
+	case *TokenBucket:
+		l.now = clk.Now
+	case *LeakyBucket:
+		l.now = clk.Now
+	case *SlidingWindowLog:
+		l.now = clk.Now
+	case *SlidingWindowCounter:
+		l.now = clk.Now
+	}
+}
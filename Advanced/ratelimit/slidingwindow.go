@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SlidingWindowLog allows at most limit requests in any window of time,
+// exactly: it logs the time of each request allowed and counts those
+// within the last window. The log holds up to limit times, so memory grows
+// with the limit; SlidingWindowCounter needs constant memory.
+type SlidingWindowLog struct {
+	mu     sync.Mutex
+	window time.Duration
+	times  []time.Time // A ring of the allowed requests, oldest at head
+	head   int
+	count  int
+	now    func() time.Time
+}
+
+// NewSlidingWindowLog creates a limiter allowing limit requests per window.
+// A limit below 1 is raised to 1.
+func NewSlidingWindowLog(limit int, window time.Duration) *SlidingWindowLog {
+	return &SlidingWindowLog{window: window, times: make([]time.Time, max(limit, 1)), now: time.Now}
+}
+
+// expire drops the logged requests that have left the window
+func (l *SlidingWindowLog) expire(now time.Time) {
+	for l.count > 0 && !now.Before(l.times[l.head].Add(l.window)) {
+		l.head = (l.head + 1) % len(l.times)
+		l.count--
+	}
+}
+
+// Allow reports whether a request may proceed, logging it if so
+func (l *SlidingWindowLog) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN reports whether n requests may proceed, logging them if so. It is
+// never true for more than limit requests.
+func (l *SlidingWindowLog) AllowN(n int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.expire(now)
+	if l.count+n > len(l.times) {
+		return false
+	}
+	for range n {
+		l.times[(l.head+l.count)%len(l.times)] = now
+		l.count++
+	}
+	return true
+}
+
+// Wait blocks until a request may proceed and logs it
+func (l *SlidingWindowLog) Wait(ctx context.Context) error {
+	return wait(ctx, l.Allow, l.delay)
+}
+
+// delay returns how long until the oldest logged request leaves the window
+func (l *SlidingWindowLog) delay() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.expire(now)
+	if l.count < len(l.times) {
+		return 0
+	}
+	return l.times[l.head].Add(l.window).Sub(now)
+}
+
+// SlidingWindowCounter allows about limit requests in any window of time,
+// in constant memory. It counts requests in fixed windows and estimates
+// the sliding window's count as the current window's count plus the
+// previous window's, weighted by how much of the previous window the
+// sliding one still covers. The estimate assumes the previous window's
+// requests were spread evenly, so it can be off either way when they were
+// not.
+type SlidingWindowCounter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	start     time.Time // Start of the current fixed window
+	cur, prev int       // Requests in the current and previous windows
+	now       func() time.Time
+}
+
+// NewSlidingWindowCounter creates a limiter allowing about limit requests
+// per window. A limit below 1 is raised to 1, and a window below 1ns to
+// 1ns.
+func NewSlidingWindowCounter(limit int, window time.Duration) *SlidingWindowCounter {
+	return &SlidingWindowCounter{limit: max(limit, 1), window: max(window, 1), now: time.Now}
+}
+
+// advance moves the fixed windows up to now and returns how far into the
+// current window now is, as a fraction
+func (c *SlidingWindowCounter) advance(now time.Time) float64 {
+	if c.start.IsZero() {
+		c.start = now
+	}
+	if passed := now.Sub(c.start) / c.window; passed > 0 {
+		c.prev, c.cur = c.cur, 0
+		if passed > 1 {
+			c.prev = 0
+		}
+		c.start = c.start.Add(passed * c.window)
+	}
+	return float64(now.Sub(c.start)) / float64(c.window)
+}
+
+// estimate returns the estimated requests in the window ending now, which
+// is a fraction f into the current fixed window
+func (c *SlidingWindowCounter) estimate(f float64) float64 {
+	return float64(c.prev)*(1-f) + float64(c.cur)
+}
+
+// Allow reports whether a request may proceed, counting it if so
+func (c *SlidingWindowCounter) Allow() bool {
+	return c.AllowN(1)
+}
+
+// AllowN reports whether n requests may proceed, counting them if so
+func (c *SlidingWindowCounter) AllowN(n int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f := c.advance(c.now())
+	if c.estimate(f)+float64(n) > float64(c.limit) {
+		return false
+	}
+	c.cur += max(n, 0)
+	return true
+}
+
+// Wait blocks until a request may proceed and counts it
+func (c *SlidingWindowCounter) Wait(ctx context.Context) error {
+	return wait(ctx, c.Allow, c.delay)
+}
+
+// delay returns how long until the estimate leaves room for a request.
+// The previous window's share shrinks as time passes; if the current
+// window alone is full, room comes only once it has become the previous
+// one and shrunk in turn.
+func (c *SlidingWindowCounter) delay() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	f := c.advance(now)
+	room := float64(c.limit - 1)
+	if c.estimate(f) <= room {
+		return 0
+	}
+	if float64(c.cur) <= room {
+		// prev·(1-g) + cur <= room from g = 1 - (room-cur)/prev on
+		g := 1 - (room-float64(c.cur))/float64(c.prev)
+		return seconds((g - f) * c.window.Seconds())
+	}
+	g := 1 - room/float64(c.cur)
+	return seconds((1 - f + g) * c.window.Seconds())
+}
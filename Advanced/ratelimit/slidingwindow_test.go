@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// TestSlidingWindowLog tests the exact limit against counting a record of
+// the allowed requests
+func TestSlidingWindowLog(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	clk := newClock()
+	const limit, window = 7, time.Second
+	l := NewSlidingWindowLog(limit, window)
+	l.now = clk.Now
+	allowed := []time.Time{}
+	for range 5000 {
+		clk.Advance(time.Duration(rng.IntN(300)) * time.Millisecond)
+		n := 1 + rng.IntN(3)
+		now := clk.Now()
+		for len(allowed) > 0 && now.Sub(allowed[0]) >= window {
+			allowed = allowed[1:]
+		}
+		want := len(allowed)+n <= limit
+		if got := l.AllowN(n); got != want {
+			t.Fatalf("AllowN(%d) with %d in the window = %v, want %v", n, len(allowed), got, want)
+		}
+		if want {
+			for range n {
+				allowed = append(allowed, now)
+			}
+		}
+		if d := l.delay(); (d == 0) != (len(allowed) < limit) {
+			t.Fatalf("delay() = %v with %d in the window", d, len(allowed))
+		}
+	}
+	if l.AllowN(limit + 1) {
+		t.Error("AllowN above the limit succeeded")
+	}
+}
+
+// TestSlidingWindowCounter tests the weighted estimate and that a steady
+// stream is held to the limit
+func TestSlidingWindowCounter(t *testing.T) {
+	clk := newClock()
+	c := NewSlidingWindowCounter(10, time.Second)
+	c.now = clk.Now
+	if n := countAllowed(c, clk, 20, 0); n != 10 {
+		t.Errorf("burst allowed %d of 20, want 10", n)
+	}
+	// A quarter into the next window, the previous one counts 3/4: 7.5
+	clk.Advance(1250 * time.Millisecond)
+	if n := countAllowed(c, clk, 5, 0); n != 2 {
+		t.Errorf("a quarter into the next window allowed %d, want 2", n)
+	}
+	clk.Advance(2 * time.Second)
+	if n := countAllowed(c, clk, 20, 0); n != 10 {
+		t.Errorf("after two idle windows allowed %d of 20, want 10", n)
+	}
+	// A quarter into this window, room comes when it is the previous one
+	// and has shrunk by one
+	if d := c.delay(); d != 850*time.Millisecond {
+		t.Errorf("delay = %v, want 850ms", d)
+	}
+	clk.Advance(time.Hour)
+	if n := countAllowed(c, clk, 1000, 10*time.Millisecond); n < 90 || n > 105 {
+		t.Errorf("a request every 10ms for 10s allowed %d, want about 100", n)
+	}
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucket is a token bucket: it holds up to burst tokens, refilled at
+// rate per second, and each request takes a token. A bucket left idle
+// fills, so it allows a burst of up to burst requests at once while
+// holding the long-run rate.
+//
+// Rather than a count of tokens that would drift as fractions accumulate,
+// the bucket keeps the time at which it will be full again, as in the
+// generic cell rate algorithm: taking a token pushes that time one
+// interval later, and the tokens held are the intervals it lies short of
+// burst ahead of now.
+type TokenBucket struct {
+	mu       sync.Mutex
+	interval time.Duration // Time to earn a token, 0 if none are earned
+	burst    int
+	full     time.Time // When the bucket will be full again
+	left     int       // Tokens left when none are earned
+	now      func() time.Time
+}
+
+// NewTokenBucket creates a full token bucket refilled at rate tokens per
+// second up to burst tokens. A burst below 1 is raised to 1; a rate of 0
+// or less never refills, allowing burst requests in all.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	b := &TokenBucket{burst: max(burst, 1), now: time.Now}
+	b.left = b.burst
+	if rate > 0 {
+		b.interval = max(seconds(1/rate), 1)
+	}
+	return b
+}
+
+// scale returns n intervals, saturating rather than overflowing
+func (b *TokenBucket) scale(n int) time.Duration {
+	if n > int(never/b.interval) {
+		return never
+	}
+	return time.Duration(n) * b.interval
+}
+
+// start returns when the tokens taken so far will have been earned back
+// from now: the time the bucket is next full, or now if it already is
+func (b *TokenBucket) start(now time.Time) time.Time {
+	if b.full.After(now) {
+		return b.full
+	}
+	return now
+}
+
+// Allow reports whether a request may proceed, taking a token if so
+func (b *TokenBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN reports whether n requests may proceed, taking n tokens if so. It
+// is never true for more than burst requests.
+func (b *TokenBucket) AllowN(n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// Secure: a negative n must not add tokens
+	if n <= 0 {
+		return true
+	}
+	if n > b.burst {
+		return false
+	}
+	if b.interval == 0 {
+		if n > b.left {
+			return false
+		}
+		b.left -= n
+		return true
+	}
+	now := b.now()
+	full := b.start(now).Add(b.scale(n))
+	if full.Sub(now) > b.scale(b.burst) {
+		return false
+	}
+	b.full = full
+	return true
+}
+
+// Wait blocks until a token is available and takes it
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return wait(ctx, b.Allow, b.delay)
+}
+
+// delay returns how long until a whole token is available
+func (b *TokenBucket) delay() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.interval == 0 {
+		if b.left > 0 {
+			return 0
+		}
+		return never
+	}
+	now := b.now()
+	return max(b.start(now).Sub(now)+b.interval-b.scale(b.burst), 0)
+}
+
+// Tokens returns the tokens now available, fractions included
+func (b *TokenBucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.interval == 0 {
+		return float64(b.left)
+	}
+	now := b.now()
+	short := b.start(now).Sub(now)
+	return float64(b.burst) - float64(short)/float64(b.interval)
+}
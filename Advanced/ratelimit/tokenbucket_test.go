@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestTokenBucket tests bursts, refill and AllowN
+func TestTokenBucket(t *testing.T) {
+	clk := newClock()
+	b := NewTokenBucket(10, 5)
+	b.now = clk.Now
+	if n := countAllowed(b, clk, 10, 0); n != 5 {
+		t.Errorf("burst allowed %d of 10, want 5", n)
+	}
+	// At 10 per second, a request every 50ms over 5s gets every other one
+	// from the first token earned, 100ms in
+	if n := countAllowed(b, clk, 100, 50*time.Millisecond); n != 49 {
+		t.Errorf("steady state allowed %d of 100, want 49", n)
+	}
+	clk.Advance(time.Hour)
+	if tokens := b.Tokens(); tokens != 5 {
+		t.Errorf("idle bucket holds %v tokens, want 5", tokens)
+	}
+	if b.AllowN(6) || !b.AllowN(5) || b.AllowN(1) {
+		t.Error("AllowN did not take exactly the available tokens")
+	}
+	if !b.AllowN(-3) || b.Tokens() != 0 {
+		t.Error("AllowN(-3) added tokens")
+	}
+	clk.Advance(50 * time.Millisecond)
+	if d := b.delay(); d != 50*time.Millisecond {
+		t.Errorf("delay with half a token = %v, want 50ms", d)
+	}
+
+	fixed := NewTokenBucket(0, 2)
+	fixed.now = clk.Now
+	if n := countAllowed(fixed, clk, 10, time.Hour); n != 2 {
+		t.Errorf("bucket without refill allowed %d, want 2", n)
+	}
+	if d := fixed.delay(); d != time.Duration(math.MaxInt64) || fixed.Tokens() != 0 {
+		t.Errorf("delay without refill = %v, want forever", d)
+	}
+}
@@ -0,0 +1,47 @@
+package retry
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Jitter chooses how the delays between attempts are randomised
+type Jitter int
+
+// The kinds of jitter
+const (
+	// NoJitter waits exactly the exponential delay
+	NoJitter Jitter = iota
+	// FullJitter waits a uniform random time up to the delay, spreading
+	// clients the most
+	FullJitter
+	// EqualJitter waits half the delay plus a uniform random time up to
+	// the other half, so it never retries too soon
+	EqualJitter
+)
+
+// Backoff returns the delay after the given failed attempt, counting from
+// 1, before jitter: InitialDelay multiplied by Multiplier for each attempt
+// after the first, capped at MaxDelay
+func (p Policy) Backoff(attempt int) time.Duration {
+	p = p.withDefaults()
+	d := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(max(attempt, 1)-1))
+	// Secure: cap before converting, so huge exponents cannot overflow
+	if d >= float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	return time.Duration(d)
+}
+
+// delay returns the jittered delay after the given failed attempt
+func (p Policy) delay(attempt int) time.Duration {
+	d := p.Backoff(attempt)
+	switch p.Jitter {
+	case FullJitter:
+		return rand.N(d + 1)
+	case EqualJitter:
+		return d/2 + rand.N(d-d/2+1)
+	}
+	return d
+}
@@ -0,0 +1,50 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBackoff tests the exponential growth and the cap
+func TestBackoff(t *testing.T) {
+	p := Policy{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2}
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, w := range want {
+		if d := p.Backoff(i + 1); d != w*time.Millisecond {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, d, w*time.Millisecond)
+		}
+	}
+	if d := p.Backoff(5000); d != time.Second {
+		t.Errorf("Backoff(5000) = %v, want the cap", d)
+	}
+	if d := (Policy{}).Backoff(2); d != 2*DefaultInitialDelay {
+		t.Errorf("default Backoff(2) = %v, want %v", d, 2*DefaultInitialDelay)
+	}
+}
+
+// TestJitter tests that jittered delays stay in range and spread out
+func TestJitter(t *testing.T) {
+	const d = time.Second
+	for _, tc := range []struct {
+		jitter   Jitter
+		low, mid time.Duration
+	}{
+		{NoJitter, d, d},
+		{FullJitter, 0, d / 2},
+		{EqualJitter, d / 2, 3 * d / 4},
+	} {
+		p := Policy{InitialDelay: d, Jitter: tc.jitter}
+		var sum time.Duration
+		const n = 5000
+		for range n {
+			got := p.delay(1)
+			if got < tc.low || got > d {
+				t.Fatalf("jitter %d: delay %v outside [%v, %v]", tc.jitter, got, tc.low, d)
+			}
+			sum += got
+		}
+		if mean := sum / n; mean < tc.mid-d/20 || mean > tc.mid+d/20 {
+			t.Errorf("jitter %d: mean delay %v, want about %v", tc.jitter, mean, tc.mid)
+		}
+	}
+}
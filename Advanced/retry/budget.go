@@ -0,0 +1,62 @@
+package retry
+
+import "sync"
+
+// Budget caps retries across calls sharing it, in the manner of gRPC's
+// retry throttling. It holds up to capacity tokens, starting full; each failed
+// attempt takes one and each success returns ratio of one. Retries are
+// allowed only while more than half the tokens remain, so once failures
+// outrun successes by enough, clients stop retrying until the dependency
+// recovers, rather than multiplying its load.
+type Budget struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	ratio  float64
+}
+
+// NewBudget creates a full budget of capacity tokens, raised to 1 if
+// less, returning ratio of a token per success, 0 if negative
+func NewBudget(capacity, ratio float64) *Budget {
+	capacity = max(capacity, 1)
+	return &Budget{tokens: capacity, max: capacity, ratio: max(ratio, 0)}
+}
+
+// Tokens returns the tokens left
+func (b *Budget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+// success returns ratio of a token for a successful attempt. A nil budget
+// does nothing.
+func (b *Budget) success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.tokens = min(b.tokens+b.ratio, b.max)
+	b.mu.Unlock()
+}
+
+// failure takes a token for a failed attempt
+func (b *Budget) failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.tokens = max(b.tokens-1, 0)
+	b.mu.Unlock()
+}
+
+// allowRetry reports whether more than half the tokens remain. A nil
+// budget always allows.
+func (b *Budget) allowRetry() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.max/2
+}
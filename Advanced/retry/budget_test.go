@@ -0,0 +1,38 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestBudget tests that failures exhaust the budget and successes restore
+// it
+func TestBudget(t *testing.T) {
+	b := NewBudget(10, 0.5)
+	p := Policy{MaxAttempts: 100, InitialDelay: 1, Budget: b}
+	attempts := 0
+	err := Do(context.Background(), p, func(context.Context) error {
+		attempts++
+		return errors.New("down")
+	})
+	// Retries stop once the tokens fall to half
+	if !errors.Is(err, ErrBudgetExhausted) || attempts != 5 {
+		t.Fatalf("Do error = %v after %d attempts, want ErrBudgetExhausted after 5", err, attempts)
+	}
+	if b.allowRetry() {
+		t.Error("retry allowed at half the tokens")
+	}
+	for range 2 {
+		b.success()
+	}
+	if !b.allowRetry() || b.Tokens() != 6 {
+		t.Errorf("after successes, %v tokens; retry allowed: %v", b.Tokens(), b.allowRetry())
+	}
+	for range 100 {
+		b.success()
+	}
+	if b.Tokens() != 10 {
+		t.Errorf("tokens = %v, want at most the capacity 10", b.Tokens())
+	}
+}
@@ -0,0 +1,183 @@
+// Package retry retries failing operations with exponential backoff. A
+// Policy sets the attempts, the growth and jitter of the delays between
+// them, a bound on the total time, a timeout for each attempt and which
+// errors are worth retrying; a shared Budget caps retries across calls, so
+// that a struggling dependency is not buried under a retry storm.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrExhausted wraps the last error when the attempts or the elapsed
+	// time allowed have run out
+	ErrExhausted = errors.New("retries exhausted")
+	// ErrBudgetExhausted wraps the last error when the Budget refuses a
+	// retry
+	ErrBudgetExhausted = errors.New("retry budget exhausted")
+)
+
+// Defaults for zero Policy fields
+const (
+	DefaultMaxAttempts  = 5
+	DefaultInitialDelay = 100 * time.Millisecond
+	DefaultMaxDelay     = 10 * time.Second
+	DefaultMultiplier   = 2
+)
+
+// Policy configures retries. Zero fields take their defaults.
+type Policy struct {
+	// MaxAttempts bounds the attempts, the first included,
+	// DefaultMaxAttempts if 0 or less
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry,
+	// DefaultInitialDelay if 0 or less
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between attempts, DefaultMaxDelay if 0 or
+	// less
+	MaxDelay time.Duration
+	// Multiplier scales the delay after each retry, DefaultMultiplier if
+	// below 1
+	Multiplier float64
+	// Jitter randomises the delays, so clients that failed together do not
+	// retry together
+	Jitter Jitter
+	// MaxElapsed bounds the time from the first attempt to the start of
+	// the last; 0 or less means no bound
+	MaxElapsed time.Duration
+	// AttemptTimeout bounds each attempt; 0 or less means no bound beyond
+	// the caller's context. An attempt that times out is always retried.
+	AttemptTimeout time.Duration
+	// RetryIf reports whether an error is worth retrying; if nil, every
+	// error is except those marked with Permanent. Temporary suits errors
+	// that say whether they are temporary.
+	RetryIf func(err error) bool
+	// Budget, if set, is drawn on by every retry and may refuse one
+	Budget *Budget
+	// OnRetry, if set, is called before each retry with the number of the
+	// attempt that failed, its error and the delay before the next
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// withDefaults returns p with its zero fields set to their defaults
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultMaxAttempts
+	}
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = DefaultInitialDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultMaxDelay
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultMultiplier
+	}
+	if p.RetryIf == nil {
+		p.RetryIf = func(error) bool { return true }
+	}
+	return p
+}
+
+// permanentError marks an error as not worth retrying
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err so that Do returns it at once, unwrapped, whatever
+// RetryIf says
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// Temporary reports whether err, or an error it wraps, has a Temporary
+// method returning true, for use as RetryIf
+func Temporary(err error) bool {
+	var temp interface{ Temporary() bool }
+	return errors.As(err, &temp) && temp.Temporary()
+}
+
+// Do calls fn until it succeeds, returns an error not worth retrying, or
+// the policy gives up, waiting between attempts as the policy says. fn
+// gets a context bounded by AttemptTimeout. Do returns nil on success; the
+// error itself if it is not worth retrying; the last error wrapped in
+// ErrExhausted or ErrBudgetExhausted if the policy gives up; and the error
+// of ctx, with the last error, if ctx ends first.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	_, err := DoValue(ctx, p, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// DoValue is Do for operations returning a value, which it returns from
+// the attempt that succeeds
+func DoValue[T any](ctx context.Context, p Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	p = p.withDefaults()
+	start := time.Now()
+	var zero T
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return zero, err
+		}
+		value, timedOut, err := try(ctx, p.AttemptTimeout, fn)
+		if err == nil {
+			p.Budget.success()
+			return value, nil
+		}
+		p.Budget.failure()
+
+		var permanent *permanentError
+		switch {
+		case ctx.Err() != nil:
+			return zero, fmt.Errorf("%w; last error: %w", ctx.Err(), err)
+		case errors.As(err, &permanent):
+			return zero, permanent.err
+		case !timedOut && !p.RetryIf(err):
+			return zero, err
+		case attempt >= p.MaxAttempts:
+			return zero, fmt.Errorf("%w after %d attempts: %w", ErrExhausted, attempt, err)
+		}
+		delay := p.delay(attempt)
+		if p.MaxElapsed > 0 && time.Since(start)+delay > p.MaxElapsed {
+			return zero, fmt.Errorf("%w after %v: %w", ErrExhausted, time.Since(start).Round(time.Millisecond), err)
+		}
+		if !p.Budget.allowRetry() {
+			return zero, fmt.Errorf("%w: %w", ErrBudgetExhausted, err)
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, fmt.Errorf("%w; last error: %w", ctx.Err(), err)
+		}
+	}
+}
+
+// try makes one attempt bounded by timeout, reporting whether it failed
+// by running out of time
+func try[T any](ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) (T, bool, error) {
+	if timeout <= 0 {
+		value, err := fn(ctx)
+		return value, false, err
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	value, err := fn(attemptCtx)
+	return value, err != nil && ctx.Err() == nil && attemptCtx.Err() != nil, err
+}
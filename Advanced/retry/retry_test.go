@@ -0,0 +1,114 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("down")
+
+// fast is a policy with delays short enough for tests
+var fast = Policy{InitialDelay: time.Microsecond, MaxDelay: time.Millisecond}
+
+// failing returns an operation failing with err for its first n calls,
+// and a pointer to the count of calls
+func failing(n int, err error) (func(context.Context) error, *int) {
+	calls := 0
+	return func(context.Context) error {
+		calls++
+		if calls <= n {
+			return err
+		}
+		return nil
+	}, &calls
+}
+
+// TestDo tests retrying until success and giving up
+func TestDo(t *testing.T) {
+	p := fast
+	retries := []int{}
+	p.OnRetry = func(attempt int, err error, delay time.Duration) {
+		retries = append(retries, attempt)
+	}
+	fn, calls := failing(3, errDown)
+	if err := Do(context.Background(), p, fn); err != nil || *calls != 4 || len(retries) != 3 {
+		t.Errorf("Do = %v after %d calls and %d retries, want success after 4 and 3", err, *calls, len(retries))
+	}
+
+	fn, calls = failing(10, errDown)
+	err := Do(context.Background(), fast, fn)
+	if !errors.Is(err, ErrExhausted) || !errors.Is(err, errDown) || *calls != DefaultMaxAttempts {
+		t.Errorf("Do = %v after %d calls, want ErrExhausted after %d", err, *calls, DefaultMaxAttempts)
+	}
+
+	v, err := DoValue(context.Background(), fast, func(context.Context) (string, error) { return "ok", nil })
+	if v != "ok" || err != nil {
+		t.Errorf("DoValue = %q, %v, want ok", v, err)
+	}
+}
+
+// temporaryError is an error saying whether it is temporary
+type temporaryError struct{ temporary bool }
+
+func (e temporaryError) Error() string   { return "temporary error" }
+func (e temporaryError) Temporary() bool { return e.temporary }
+
+// TestRetryIf tests that errors not worth retrying are returned at once
+func TestRetryIf(t *testing.T) {
+	fn, calls := failing(10, errDown)
+	if err := Do(context.Background(), fast, func(ctx context.Context) error {
+		return Permanent(fn(ctx))
+	}); err != errDown || *calls != 1 {
+		t.Errorf("permanent error: Do = %v after %d calls, want it unwrapped after 1", err, *calls)
+	}
+
+	p := fast
+	p.RetryIf = Temporary
+	fn, calls = failing(2, temporaryError{true})
+	if err := Do(context.Background(), p, fn); err != nil || *calls != 3 {
+		t.Errorf("temporary error: Do = %v after %d calls, want success after 3", err, *calls)
+	}
+	fn, calls = failing(2, errors.Join(errDown, temporaryError{false}))
+	if err := Do(context.Background(), p, fn); !errors.Is(err, errDown) || *calls != 1 {
+		t.Errorf("lasting error: Do = %v after %d calls, want it after 1", err, *calls)
+	}
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) is not nil")
+	}
+}
+
+// TestTimeouts tests the attempt timeout, the elapsed time bound and the
+// caller's context
+func TestTimeouts(t *testing.T) {
+	p := fast
+	p.AttemptTimeout = time.Millisecond
+	p.RetryIf = func(error) bool { return false }
+	calls := 0
+	err := Do(context.Background(), p, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("attempt timeouts: Do = %v after %d calls, want success after 3", err, calls)
+	}
+
+	p = Policy{MaxAttempts: 1000, InitialDelay: 5 * time.Millisecond, Multiplier: 1, MaxElapsed: 22 * time.Millisecond}
+	fn, n := failing(1000, errDown)
+	if err := Do(context.Background(), p, fn); !errors.Is(err, ErrExhausted) || *n < 3 || *n > 5 {
+		t.Errorf("MaxElapsed: Do = %v after %d calls, want ErrExhausted after about 5", err, *n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	fn, _ = failing(1000, errDown)
+	err = Do(ctx, Policy{InitialDelay: time.Hour}, fn)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, errDown) {
+		t.Errorf("cancelled wait: Do = %v, want DeadlineExceeded and the last error", err)
+	}
+}
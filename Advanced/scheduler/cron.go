@@ -0,0 +1,244 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCron is wrapped by the errors of ParseCron
+var ErrCron = errors.New("invalid cron expression")
+
+// Cron is a Schedule parsed from a cron expression. It runs at the times,
+// in the location of the time passed to Next, whose fields all match.
+type Cron struct {
+	expr                 string
+	second, minute, hour field
+	dom, month, dow      field
+	domAny, dowAny       bool // The day fields were * or ?
+}
+
+// field is a set of allowed values, bit v set for value v
+type field uint64
+
+// has reports whether v is allowed
+func (f field) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// bounds is the range and value names of a field
+type bounds struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	seconds = bounds{name: "second", min: 0, max: 59}
+	minutes = bounds{name: "minute", min: 0, max: 59}
+	hours   = bounds{name: "hour", min: 0, max: 23}
+	doms    = bounds{name: "day of month", min: 1, max: 31}
+	months  = bounds{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Day of week 7 is Sunday too, folded into 0 once parsed
+	dows = bounds{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors are the shorthands for common expressions
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression of 5 fields, minute hour day-of-month
+// month day-of-week, or of 6 with a leading second field. Each field is *
+// or a comma-separated list of values, ranges a-b, and steps */n or a-b/n;
+// months and days of the week may be named, as JAN or MON, and ? is * in
+// the day fields. The shorthands @yearly, @monthly, @weekly, @daily and
+// @hourly are accepted too. As in cron, a time matches if either day field
+// does when both are restricted.
+func ParseCron(expr string) (*Cron, error) {
+	spec := strings.TrimSpace(expr)
+	if d, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("%w %q: %d fields, want 5 or 6", ErrCron, expr, len(fields))
+	}
+
+	c := &Cron{expr: expr}
+	var err error
+	parse := func(s string, b bounds) field {
+		var f field
+		if err == nil {
+			f, err = parseField(s, b)
+		}
+		return f
+	}
+	c.second = parse(fields[0], seconds)
+	c.minute = parse(fields[1], minutes)
+	c.hour = parse(fields[2], hours)
+	c.dom = parse(fields[3], doms)
+	c.month = parse(fields[4], months)
+	c.dow = parse(fields[5], dows)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrCron, expr, err)
+	}
+	if c.dow.has(7) {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	c.domAny = fields[3] == "*" || fields[3] == "?"
+	c.dowAny = fields[5] == "*" || fields[5] == "?"
+	return c, nil
+}
+
+// parseField parses one field of an expression
+func parseField(s string, b bounds) (field, error) {
+	var f field
+	for part := range strings.SplitSeq(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		lo, hi := b.min, b.max
+		switch {
+		case rng == "*" || rng == "?" && (b.name == doms.name || b.name == dows.name):
+		default:
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = b.value(from); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = b.value(to); err != nil {
+					return 0, err
+				}
+				if hi < lo {
+					return 0, fmt.Errorf("%s range %s runs backwards", b.name, rng)
+				}
+			} else if hasStep {
+				hi = b.max // a/n steps from a to the end
+			}
+		}
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("%s step %q is not a positive number", b.name, stepStr)
+			}
+			step = n
+		}
+		for v := lo; v <= hi; v += step {
+			f |= 1 << uint(v)
+		}
+	}
+	return f, nil
+}
+
+// value parses a number or name within b
+func (b bounds) value(s string) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s %q is not a number", b.name, s)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("%s %d is outside %d-%d", b.name, v, b.min, b.max)
+	}
+	return v, nil
+}
+
+// String returns the expression c was parsed from
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// dayMatches reports whether the day of t matches the day fields
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom, dow := c.dom.has(t.Day()), c.dow.has(int(t.Weekday()))
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first second after after that matches every field, in
+// the location of after, or the zero time if none does within five years.
+// It moves to the next value of the largest field that does not match,
+// resetting the smaller ones, and starts over when a field wraps.
+// Time Complexity: O(1), bounded by the field sizes and five years
+func (c *Cron) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + 5
+	reset := false // Whether the smaller fields have been zeroed
+
+wrap:
+	for t.Year() <= limit {
+		for !c.month.has(int(t.Month())) {
+			if !reset {
+				reset = true
+				t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+			}
+			t = t.AddDate(0, 1, 0)
+			if t.Month() == time.January {
+				continue wrap
+			}
+		}
+		for !c.dayMatches(t) {
+			if !reset {
+				reset = true
+				t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+			}
+			t = t.AddDate(0, 0, 1)
+			if t.Day() == 1 {
+				continue wrap
+			}
+		}
+		for !c.hour.has(t.Hour()) {
+			if !reset {
+				reset = true
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+			}
+			t = t.Add(time.Hour)
+			if t.Hour() == 0 {
+				continue wrap
+			}
+		}
+		for !c.minute.has(t.Minute()) {
+			if !reset {
+				reset = true
+				t = t.Truncate(time.Minute)
+			}
+			t = t.Add(time.Minute)
+			if t.Minute() == 0 {
+				continue wrap
+			}
+		}
+		for !c.second.has(t.Second()) {
+			reset = true
+			t = t.Add(time.Second)
+			if t.Second() == 0 {
+				continue wrap
+			}
+		}
+		return t
+	}
+	return time.Time{}
+}
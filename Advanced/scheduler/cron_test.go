@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// date returns a UTC time to the second
+func date(year int, month time.Month, day, hour, min, sec int) time.Time {
+	return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+}
+
+// TestCronNext tests the next run of expressions of both lengths
+func TestCronNext(t *testing.T) {
+	from := date(2024, time.January, 31, 10, 15, 30) // A Wednesday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", date(2024, time.January, 31, 10, 16, 0)},
+		{"* * * * * *", date(2024, time.January, 31, 10, 15, 31)},
+		{"*/15 * * * * *", date(2024, time.January, 31, 10, 15, 45)},
+		{"*/20 * * * *", date(2024, time.January, 31, 10, 20, 0)},
+		{"0 */20 * * *", date(2024, time.January, 31, 20, 0, 0)},
+		{"30 9 * * *", date(2024, time.February, 1, 9, 30, 0)},
+		{"0 0 29 2 *", date(2024, time.February, 29, 0, 0, 0)},
+		{"0 0 30 * *", date(2024, time.March, 30, 0, 0, 0)},
+		{"0 12 * * MON-FRI", date(2024, time.January, 31, 12, 0, 0)},
+		{"0 12 * * sat,sun", date(2024, time.February, 3, 12, 0, 0)},
+		{"0 12 * * 7", date(2024, time.February, 4, 12, 0, 0)},
+		{"0 0 1 JAN ?", date(2025, time.January, 1, 0, 0, 0)},
+		{"5/20 10 * * *", date(2024, time.January, 31, 10, 25, 0)},
+		{"0 8-10/2 * * *", date(2024, time.February, 1, 8, 0, 0)},
+		{"@hourly", date(2024, time.January, 31, 11, 0, 0)},
+		{"@weekly", date(2024, time.February, 4, 0, 0, 0)},
+		{"@yearly", date(2025, time.January, 1, 0, 0, 0)},
+		// Both day fields restricted: either matches, here the 1st
+		{"0 0 1 * FRI", date(2024, time.February, 1, 0, 0, 0)},
+		{"0 0 31 2 *", time.Time{}}, // Never
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := c.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q Next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+// TestCronLocation tests that matching happens in the location of the
+// time passed in, across a change to daylight saving time
+func TestCronLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+	c, _ := ParseCron("30 2 * * *")
+	// 02:30 does not exist on 10 March 2024 in New York
+	got := c.Next(time.Date(2024, time.March, 9, 12, 0, 0, 0, ny))
+	if got.Day() != 11 || got.Hour() != 2 || got.Minute() != 30 || got.Location() != ny {
+		t.Errorf("Next = %v, want 02:30 on 11 March in New York", got)
+	}
+}
+
+// TestParseCronErrors tests that malformed expressions are rejected
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"", "* * * *", "* * * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "*/x * * * *",
+		"? * * * *", "a * * * *", "* * * FOO *",
+	} {
+		if _, err := ParseCron(expr); !errors.Is(err, ErrCron) {
+			t.Errorf("ParseCron(%q) error = %v, want ErrCron", expr, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import "time"
+
+// Schedule decides when a job runs
+type Schedule interface {
+	// Next returns the first time after after that the job runs, or the
+	// zero time if it never runs again
+	Next(after time.Time) time.Time
+}
+
+// interval is the Schedule of Every
+type interval time.Duration
+
+// Every returns a schedule running every d. The scheduler steps from each
+// run's scheduled time rather than from when it ran, so late timers and
+// slow runs do not push later runs back. It panics if d is not positive.
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("scheduler: Every needs a positive interval")
+	}
+	return interval(d)
+}
+
+// Next returns after plus the interval
+func (i interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(i))
+}
+
+// String returns the interval, as "@every 1m0s"
+func (i interval) String() string {
+	return "@every " + time.Duration(i).String()
+}
+
+// once is the Schedule of At
+type once time.Time
+
+// At returns a schedule running once, at t. Added to a scheduler once t
+// has passed, it never runs; Delay runs a job once without that race.
+func At(t time.Time) Schedule {
+	return once(t)
+}
+
+// Next returns the time of the run if it is after after
+func (o once) Next(after time.Time) time.Time {
+	if t := time.Time(o); t.After(after) {
+		return t
+	}
+	return time.Time{}
+}
+
+// NextN returns up to n times that s runs after after, fewer if it stops
+func NextN(s Schedule, after time.Time, n int) []time.Time {
+	var times []time.Time
+	for len(times) < n {
+		after = s.Next(after)
+		if after.IsZero() {
+			break
+		}
+		times = append(times, after)
+	}
+	return times
+}
@@ -0,0 +1,38 @@
+package scheduler
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestEvery tests interval steps and their String
+func TestEvery(t *testing.T) {
+	start := date(2024, time.January, 1, 0, 0, 0)
+	got := NextN(Every(90*time.Second), start, 3)
+	want := []time.Time{start.Add(90 * time.Second), start.Add(3 * time.Minute), start.Add(270 * time.Second)}
+	if !slices.EqualFunc(got, want, time.Time.Equal) {
+		t.Errorf("NextN = %v, want %v", got, want)
+	}
+	if s := Every(time.Minute).(interface{ String() string }).String(); s != "@every 1m0s" {
+		t.Errorf("String = %q", s)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Every(0) did not panic")
+		}
+	}()
+	Every(0)
+}
+
+// TestAt tests that a one-off schedule runs once
+func TestAt(t *testing.T) {
+	at := date(2024, time.January, 1, 12, 0, 0)
+	got := NextN(At(at), at.Add(-time.Hour), 5)
+	if len(got) != 1 || !got[0].Equal(at) {
+		t.Errorf("NextN = %v, want just %v", got, at)
+	}
+	if next := At(at).Next(at); !next.IsZero() {
+		t.Errorf("Next at the time itself = %v, want never", next)
+	}
+}
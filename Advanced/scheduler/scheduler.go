@@ -0,0 +1,361 @@
+// Package scheduler runs jobs on schedules: once after a delay, at a
+// fixed interval that does not drift, or by a cron expression of 5 or 6
+// fields. Each job runs with its own context, cancelled when it is
+// removed or the scheduler shuts down; a panicking run is recovered and
+// reported like an error. An Overlap policy decides what happens when a
+// run comes due while the last is still going, and Entries reports each
+// job's next and previous runs and its counts.
+package scheduler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"hellogolang/Advanced/syncx"
+)
+
+// ErrClosed is returned for jobs added after Shutdown
+var ErrClosed = errors.New("scheduler closed")
+
+// Job is the work of one run. Its context is cancelled when the job is
+// removed, when the scheduler gives up waiting for it in Shutdown, and
+// after JobOptions.Timeout.
+type Job func(ctx context.Context) error
+
+// Overlap chooses what happens to a run that comes due while the job's
+// last run is still going
+type Overlap int
+
+// The overlap policies
+const (
+	Skip       Overlap = iota // Drop the run
+	Queue                     // Run it as soon as the last finishes
+	Concurrent                // Run it alongside
+)
+
+// JobOptions configures a job. Zero fields take their defaults.
+type JobOptions struct {
+	// Overlap decides what happens to a run due while the last is going
+	Overlap Overlap
+	// Timeout bounds each run; 0 or less means no bound
+	Timeout time.Duration
+}
+
+// Options configures a scheduler. Zero fields take their defaults.
+type Options struct {
+	// Location is the time zone of cron schedules and of the times in
+	// Entries, time.Local if nil
+	Location *time.Location
+	// OnError, if set, is called with the name of a job and the error of
+	// each of its failed runs, a *syncx.PanicError for a panic
+	OnError func(name string, err error)
+}
+
+// ID identifies a job in its scheduler
+type ID uint64
+
+// Entry describes a job and its runs
+type Entry struct {
+	ID       ID
+	Name     string
+	Schedule Schedule
+	Next     time.Time // When the job next runs, zero if never
+	Prev     time.Time // When the job last came due, zero if never
+	Running  int       // Runs going now
+	Queued   int       // Runs waiting for the last to finish, under Queue
+	Runs     uint64    // Runs started
+	Failures uint64    // Runs that returned an error or panicked
+	Panics   uint64    // Runs that panicked
+	Skipped  uint64    // Runs dropped under Skip
+	Missed   uint64    // Runs that came due while the scheduler lagged
+}
+
+// job is a scheduled job and its state
+type job struct {
+	Entry
+	run    Job
+	opts   JobOptions
+	ctx    context.Context // Cancelled when the job is removed
+	cancel context.CancelFunc
+	done   bool // Removed, or never due again
+}
+
+// Scheduler runs jobs on their schedules. It is safe for concurrent use.
+type Scheduler struct {
+	opts   Options
+	ctx    context.Context // Parent of the jobs' contexts
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	jobs   map[ID]*job
+	lastID ID
+	closed bool
+
+	wake     chan struct{} // Tells the loop the jobs changed
+	quit     chan struct{}
+	loopDone chan struct{}
+	once     sync.Once
+	runs     sync.WaitGroup
+}
+
+// New creates a scheduler and starts its loop
+func New(opts Options) *Scheduler {
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		opts:     opts,
+		ctx:      ctx,
+		cancel:   cancel,
+		jobs:     map[ID]*job{},
+		wake:     make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+		loopDone: make(chan struct{}),
+	}
+	go s.loop()
+	return s
+}
+
+// now returns the current time in the scheduler's location
+func (s *Scheduler) now() time.Time {
+	return time.Now().In(s.opts.Location)
+}
+
+// Add schedules job under name, which need not be unique, and returns its
+// ID
+func (s *Scheduler) Add(name string, schedule Schedule, run Job, opts JobOptions) (ID, error) {
+	return s.add(name, schedule, schedule.Next(s.now()), run, opts)
+}
+
+// add schedules job to run first at next
+func (s *Scheduler) add(name string, schedule Schedule, next time.Time, run Job, opts JobOptions) (ID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, ErrClosed
+	}
+	s.lastID++
+	ctx, cancel := context.WithCancel(s.ctx)
+	j := &job{run: run, opts: opts, ctx: ctx, cancel: cancel}
+	j.ID, j.Name, j.Schedule, j.Next = s.lastID, name, schedule, next
+	s.jobs[j.ID] = j
+	s.notify()
+	return j.ID, nil
+}
+
+// Cron schedules job by a cron expression, as parsed by ParseCron
+func (s *Scheduler) Cron(name, expr string, run Job, opts JobOptions) (ID, error) {
+	c, err := ParseCron(expr)
+	if err != nil {
+		return 0, err
+	}
+	return s.Add(name, c, run, opts)
+}
+
+// Delay schedules job to run once, after d, or right away if d is not
+// positive
+func (s *Scheduler) Delay(name string, d time.Duration, run Job, opts JobOptions) (ID, error) {
+	at := s.now().Add(max(d, 0))
+	return s.add(name, At(at), at, run, opts)
+}
+
+// Remove unschedules a job, dropping its queued runs and cancelling the
+// context of those going. It reports whether the job was scheduled.
+func (s *Scheduler) Remove(id ID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return false
+	}
+	s.retire(j)
+	j.cancel()
+	s.notify()
+	return true
+}
+
+// retire removes j from the jobs. The caller holds mu.
+func (s *Scheduler) retire(j *job) {
+	j.done = true
+	j.Next, j.Queued = time.Time{}, 0
+	delete(s.jobs, j.ID)
+}
+
+// Entry returns a snapshot of the job with the given ID
+func (s *Scheduler) Entry(id ID) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j, ok := s.jobs[id]; ok {
+		return j.Entry, true
+	}
+	return Entry{}, false
+}
+
+// Entries returns a snapshot of every job, the next due first
+func (s *Scheduler) Entries() []Entry {
+	s.mu.Lock()
+	entries := make([]Entry, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		entries = append(entries, j.Entry)
+	}
+	s.mu.Unlock()
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return cmp.Or(a.Next.Compare(b.Next), cmp.Compare(a.ID, b.ID))
+	})
+	return entries
+}
+
+// notify wakes the loop to look at the jobs again. The caller holds mu.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop starts the runs that come due, sleeping until the next one
+func (s *Scheduler) loop() {
+	defer close(s.loopDone)
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		s.mu.Lock()
+		next := s.dispatch(s.now())
+		s.mu.Unlock()
+		wait := time.Hour // Until a job is added, if none is due
+		if !next.IsZero() {
+			wait = time.Until(next)
+		}
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-s.wake:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// dispatch starts the runs due by now, moves each job on to its next run,
+// and returns the earliest of those. The caller holds mu.
+func (s *Scheduler) dispatch(now time.Time) time.Time {
+	var earliest time.Time
+	for _, j := range s.jobs {
+		if !j.Next.IsZero() && !j.Next.After(now) {
+			s.due(j)
+			j.Prev = j.Next
+			j.Next = j.Schedule.Next(j.Prev)
+			// Step over the runs missed while lagging, from the scheduled
+			// times so that intervals keep to their grid
+			for !j.Next.IsZero() && !j.Next.After(now) {
+				j.Missed++
+				j.Next = j.Schedule.Next(j.Next)
+			}
+		}
+		if j.Next.IsZero() {
+			s.retire(j)
+			continue
+		}
+		if earliest.IsZero() || j.Next.Before(earliest) {
+			earliest = j.Next
+		}
+	}
+	return earliest
+}
+
+// due starts a run of j, or skips or queues it under its Overlap policy.
+// The caller holds mu.
+func (s *Scheduler) due(j *job) {
+	if j.Running > 0 {
+		switch j.opts.Overlap {
+		case Skip:
+			j.Skipped++
+			return
+		case Queue:
+			j.Queued++
+			return
+		}
+	}
+	j.Running++
+	j.Runs++
+	s.runs.Go(func() { s.execute(j) })
+}
+
+// execute runs j, then its queued runs one after another
+func (s *Scheduler) execute(j *job) {
+	for {
+		err := s.call(j)
+		s.mu.Lock()
+		j.Running--
+		if err != nil {
+			j.Failures++
+			var panicErr *syncx.PanicError
+			if errors.As(err, &panicErr) {
+				j.Panics++
+			}
+		}
+		again := j.Queued > 0 && !j.done && !s.closed
+		if again {
+			j.Queued--
+			j.Running++
+			j.Runs++
+		}
+		s.mu.Unlock()
+		if err != nil && s.opts.OnError != nil {
+			s.opts.OnError(j.Name, err)
+		}
+		if !again {
+			return
+		}
+	}
+}
+
+// call runs j once under its timeout, turning a panic into a
+// syncx.PanicError
+func (s *Scheduler) call(j *job) (err error) {
+	ctx := j.ctx
+	if j.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.opts.Timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = &syncx.PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return j.run(ctx)
+}
+
+// Shutdown stops the scheduler starting runs and waits for those going to
+// finish. If ctx ends first, it cancels their contexts and returns the
+// error of ctx without waiting further. Shutdown may be called more than
+// once.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(s.quit)
+		<-s.loopDone
+	})
+	drained := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,259 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"hellogolang/Advanced/syncx"
+)
+
+// waitFor polls cond until it holds, failing t after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(3 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// entry returns the entry of id or fails t
+func entry(t *testing.T, s *Scheduler, id ID) Entry {
+	t.Helper()
+	e, ok := s.Entry(id)
+	if !ok {
+		t.Fatalf("job %d is not scheduled", id)
+	}
+	return e
+}
+
+// shutdown stops s for a test's cleanup
+func shutdown(t *testing.T, s *Scheduler) {
+	t.Cleanup(func() {
+		if err := s.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// TestEveryGrid tests that interval runs stay on the grid of the first
+// due time, however late each run starts
+func TestEveryGrid(t *testing.T) {
+	s := New(Options{Location: time.UTC})
+	shutdown(t, s)
+	id, err := s.Add("tick", Every(10*time.Millisecond), func(context.Context) error {
+		time.Sleep(3 * time.Millisecond) // Slow runs must not push later ones back
+		return nil
+	}, JobOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := entry(t, s, id).Next
+	waitFor(t, "5 runs", func() bool { return entry(t, s, id).Runs >= 5 })
+	e := entry(t, s, id)
+	if off := e.Prev.Sub(first) % (10 * time.Millisecond); off != 0 {
+		t.Errorf("run due at %v is %v off the grid from %v", e.Prev, off, first)
+	}
+	if e.Next.Sub(e.Prev) != 10*time.Millisecond || e.Next.Location() != time.UTC {
+		t.Errorf("next run %v, want 10ms after %v in UTC", e.Next, e.Prev)
+	}
+}
+
+// TestDelay tests that a delayed job runs once and is then unscheduled
+func TestDelay(t *testing.T) {
+	s := New(Options{})
+	shutdown(t, s)
+	ran := make(chan time.Time, 2)
+	start := time.Now()
+	id, _ := s.Delay("once", 20*time.Millisecond, func(context.Context) error {
+		ran <- time.Now()
+		return nil
+	}, JobOptions{})
+	if at := <-ran; at.Sub(start) < 20*time.Millisecond {
+		t.Errorf("ran after %v, want at least 20ms", at.Sub(start))
+	}
+	waitFor(t, "the job to be unscheduled", func() bool {
+		_, ok := s.Entry(id)
+		return !ok
+	})
+	time.Sleep(30 * time.Millisecond)
+	if len(ran) != 0 {
+		t.Error("a delayed job ran twice")
+	}
+}
+
+// TestOverlap tests each policy for runs due while a run is going
+func TestOverlap(t *testing.T) {
+	tests := []struct {
+		overlap Overlap
+		check   func(e Entry) bool
+	}{
+		{Skip, func(e Entry) bool { return e.Running == 1 && e.Skipped >= 2 && e.Queued == 0 }},
+		{Queue, func(e Entry) bool { return e.Running == 1 && e.Queued >= 2 && e.Skipped == 0 }},
+		{Concurrent, func(e Entry) bool { return e.Running >= 3 }},
+	}
+	for _, tt := range tests {
+		s := New(Options{})
+		release := make(chan struct{})
+		var mu sync.Mutex
+		runs := 0
+		id, _ := s.Add("slow", Every(5*time.Millisecond), func(context.Context) error {
+			mu.Lock()
+			runs++
+			mu.Unlock()
+			<-release
+			return nil
+		}, JobOptions{Overlap: tt.overlap})
+		waitFor(t, "overlapping runs", func() bool { return tt.check(entry(t, s, id)) })
+
+		// Queued runs follow once the blocked one finishes
+		queued := entry(t, s, id).Queued
+		s.Remove(id)
+		close(release)
+		if err := s.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if tt.overlap == Queue && runs != 1 {
+			t.Errorf("%d runs after Remove dropped %d queued, want 1", runs, queued)
+		}
+	}
+}
+
+// TestQueueDrains tests that queued runs run one after another
+func TestQueueDrains(t *testing.T) {
+	s := New(Options{})
+	shutdown(t, s)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	running, peak := 0, 0
+	id, _ := s.Add("slow", Every(5*time.Millisecond), func(context.Context) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}, JobOptions{Overlap: Queue})
+	waitFor(t, "queued runs", func() bool { return entry(t, s, id).Queued >= 2 })
+	close(release)
+	waitFor(t, "queued runs to run", func() bool { return entry(t, s, id).Runs >= 3 })
+	mu.Lock()
+	defer mu.Unlock()
+	if peak != 1 {
+		t.Errorf("%d runs at once under Queue, want 1", peak)
+	}
+}
+
+// TestErrors tests that failed and panicking runs are counted, reported
+// and do not stop the job
+func TestErrors(t *testing.T) {
+	errDown := errors.New("backend down")
+	var mu sync.Mutex
+	var reported []error
+	s := New(Options{OnError: func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if name == "flaky" {
+			reported = append(reported, err)
+		}
+	}})
+	shutdown(t, s)
+	calls := 0
+	id, _ := s.Add("flaky", Every(5*time.Millisecond), func(context.Context) error {
+		calls++
+		switch calls {
+		case 1:
+			return errDown
+		case 2:
+			panic("nil config")
+		}
+		return nil
+	}, JobOptions{})
+	waitFor(t, "3 runs", func() bool { return entry(t, s, id).Runs >= 3 })
+	waitFor(t, "3 runs to finish", func() bool { return entry(t, s, id).Running == 0 })
+
+	e := entry(t, s, id)
+	if e.Failures != 2 || e.Panics != 1 {
+		t.Errorf("failures %d, panics %d, want 2 and 1", e.Failures, e.Panics)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var panicErr *syncx.PanicError
+	if len(reported) != 2 || !errors.Is(reported[0], errDown) || !errors.As(reported[1], &panicErr) ||
+		panicErr.Value != "nil config" {
+		t.Errorf("reported %v, want the error then the panic", reported)
+	}
+}
+
+// TestCancellation tests that a run's context ends on its timeout and on
+// Remove
+func TestCancellation(t *testing.T) {
+	s := New(Options{})
+	shutdown(t, s)
+	errs := make(chan error, 2)
+	waitDone := func(ctx context.Context) error {
+		<-ctx.Done()
+		errs <- ctx.Err()
+		return ctx.Err()
+	}
+	s.Delay("timed", 0, waitDone, JobOptions{Timeout: 10 * time.Millisecond})
+	if err := <-errs; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("timed run ended with %v, want DeadlineExceeded", err)
+	}
+
+	id, _ := s.Add("removed", Every(time.Millisecond), waitDone, JobOptions{})
+	waitFor(t, "a run", func() bool { return entry(t, s, id).Running == 1 })
+	if !s.Remove(id) || s.Remove(id) {
+		t.Error("Remove did not report the job scheduled just once")
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("removed run ended with %v, want Canceled", err)
+	}
+}
+
+// TestShutdown tests that Shutdown waits for runs, cancels them once its
+// context ends, and refuses new jobs
+func TestShutdown(t *testing.T) {
+	s := New(Options{})
+	started := make(chan struct{})
+	s.Delay("stuck", 0, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return nil
+	}, JobOptions{})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want DeadlineExceeded", err)
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("second Shutdown = %v, want nil once the run saw its cancellation", err)
+	}
+	if _, err := s.Add("late", Every(time.Second), nil, JobOptions{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Add after Shutdown = %v, want ErrClosed", err)
+	}
+}
+
+// TestEntries tests that entries come back soonest first
+func TestEntries(t *testing.T) {
+	s := New(Options{})
+	shutdown(t, s)
+	noop := func(context.Context) error { return nil }
+	s.Add("hourly", Every(time.Hour), noop, JobOptions{})
+	s.Add("minutely", Every(time.Minute), noop, JobOptions{})
+	if _, err := s.Cron("bad", "61 * * * *", noop, JobOptions{}); !errors.Is(err, ErrCron) {
+		t.Errorf("Cron error = %v, want ErrCron", err)
+	}
+	entries := s.Entries()
+	if len(entries) != 2 || entries[0].Name != "minutely" || entries[1].Name != "hourly" {
+		t.Errorf("entries = %+v, want minutely then hourly", entries)
+	}
+}
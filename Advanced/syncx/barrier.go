@@ -0,0 +1,135 @@
+// Package syncx extends the sync package with three coordination
+// primitives: a CyclicBarrier, at which a fixed number of goroutines wait
+// for each other before all proceed, reusable round after round; a
+// CountDownLatch, which opens for good once counted down to zero; and an
+// ErrorGroup, a WaitGroup that collects the errors of its goroutines.
+// Waits take a context, so no goroutine need wait forever. PanicError is
+// the error of a recovered panic, shared by the packages that run code
+// for their callers.
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrBrokenBarrier is returned by CyclicBarrier.Await when a round cannot
+// complete, because a waiter gave up or the barrier was reset
+var ErrBrokenBarrier = errors.New("barrier broken")
+
+// round is one use of a barrier, ended by closing done
+type round struct {
+	done   chan struct{}
+	broken bool // Set before done is closed, if the round failed
+}
+
+// CyclicBarrier makes a fixed number of goroutines, its parties, wait for
+// each other. Each round ends when the last party arrives, and the barrier
+// is then ready for the next. If a waiting party gives up, the round is
+// broken: every party waiting, and any arriving later, gets
+// ErrBrokenBarrier until Reset.
+type CyclicBarrier struct {
+	mu      sync.Mutex
+	parties int
+	arrived int
+	current *round
+	action  func()
+}
+
+// NewCyclicBarrier creates a barrier for parties goroutines, raised to 1
+// if fewer. If action is not nil, the last party to arrive runs it before
+// the others are released.
+func NewCyclicBarrier(parties int, action func()) *CyclicBarrier {
+	return &CyclicBarrier{
+		parties: max(parties, 1),
+		current: &round{done: make(chan struct{})},
+		action:  action,
+	}
+}
+
+// Await waits until all parties have arrived, and returns the arrival
+// index of the caller: parties-1 for the first to arrive and 0 for the
+// last. If ctx ends first, it breaks the round and returns the error of
+// ctx; if the round breaks, it returns ErrBrokenBarrier.
+//
+// The count of arrivals and the check for the last are made under one
+// lock, and each round waits on its own channel, so a round cannot be
+// missed by a party that arrives just as it ends.
+func (b *CyclicBarrier) Await(ctx context.Context) (int, error) {
+	b.mu.Lock()
+	r := b.current
+	if r.broken {
+		b.mu.Unlock()
+		return 0, ErrBrokenBarrier
+	}
+	b.arrived++
+	index := b.parties - b.arrived
+	if index == 0 {
+		b.next()
+		b.mu.Unlock()
+		if b.action != nil {
+			b.action()
+		}
+		close(r.done)
+		return 0, nil
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-r.done:
+		if r.broken {
+			return 0, ErrBrokenBarrier
+		}
+		return index, nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		// The round may have ended as ctx did, in which case it completed
+		if r != b.current {
+			return index, nil
+		}
+		b.breakRound()
+		return 0, ctx.Err()
+	}
+}
+
+// next starts a new round. The caller holds mu.
+func (b *CyclicBarrier) next() {
+	b.current = &round{done: make(chan struct{})}
+	b.arrived = 0
+}
+
+// breakRound breaks the current round, if not already broken. The caller
+// holds mu.
+func (b *CyclicBarrier) breakRound() {
+	if !b.current.broken {
+		b.current.broken = true
+		close(b.current.done)
+	}
+}
+
+// Reset breaks the current round, failing its waiting parties, and starts
+// a new one
+func (b *CyclicBarrier) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.arrived > 0 || b.current.broken {
+		b.breakRound()
+		b.next()
+	}
+}
+
+// Waiting returns the number of parties waiting in the current round
+func (b *CyclicBarrier) Waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.arrived
+}
+
+// Broken reports whether the current round is broken
+func (b *CyclicBarrier) Broken() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current.broken
+}
@@ -0,0 +1,108 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCyclicBarrier tests that no party passes a round before all have
+// arrived, over many rounds
+func TestCyclicBarrier(t *testing.T) {
+	const parties, rounds = 5, 200
+	var actions atomic.Int64
+	b := NewCyclicBarrier(parties, func() { actions.Add(1) })
+	var arrived [rounds]atomic.Int64
+	var wg sync.WaitGroup
+	indexes := make(chan int, parties*rounds)
+	for range parties {
+		wg.Go(func() {
+			for r := range rounds {
+				arrived[r].Add(1)
+				index, err := b.Await(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				indexes <- index
+				if n := arrived[r].Load(); n != parties {
+					t.Errorf("round %d: passed with %d of %d arrived", r, n, parties)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(indexes)
+	if actions.Load() != rounds {
+		t.Errorf("action ran %d times in %d rounds", actions.Load(), rounds)
+	}
+	counts := make([]int, parties)
+	for i := range indexes {
+		counts[i]++
+	}
+	if !slices.Equal(counts, slices.Repeat([]int{rounds}, parties)) {
+		t.Errorf("arrival index counts = %v, want %d each", counts, rounds)
+	}
+}
+
+// TestCyclicBarrierBroken tests that a party giving up breaks the round
+// for the others until Reset
+func TestCyclicBarrierBroken(t *testing.T) {
+	b := NewCyclicBarrier(3, nil)
+	errc := make(chan error)
+	go func() {
+		_, err := b.Await(context.Background())
+		errc <- err
+	}()
+	for b.Waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := b.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Await that timed out error = %v, want DeadlineExceeded", err)
+	}
+	if err := <-errc; !errors.Is(err, ErrBrokenBarrier) {
+		t.Errorf("waiting party error = %v, want ErrBrokenBarrier", err)
+	}
+	if _, err := b.Await(context.Background()); !errors.Is(err, ErrBrokenBarrier) || !b.Broken() {
+		t.Errorf("Await on a broken barrier error = %v, want ErrBrokenBarrier", err)
+	}
+
+	b.Reset()
+	if b.Broken() {
+		t.Fatal("barrier still broken after Reset")
+	}
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() {
+			if _, err := b.Await(context.Background()); err != nil {
+				t.Errorf("Await after Reset failed: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// BenchmarkCyclicBarrier measures a round of 4 parties
+func BenchmarkCyclicBarrier(b *testing.B) {
+	barrier := NewCyclicBarrier(4, nil)
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	n := b.N
+	for range 3 {
+		wg.Go(func() {
+			for range n {
+				barrier.Await(ctx)
+			}
+		})
+	}
+	for range n {
+		barrier.Await(ctx)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,53 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrorGroup is a WaitGroup whose goroutines return errors, all of which
+// Wait returns together. The zero value is ready to use; WithContext
+// creates one that also cancels a context on the first error, so the
+// other goroutines can stop early.
+type ErrorGroup struct {
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	errs   []error
+	cancel context.CancelCauseFunc
+}
+
+// WithContext creates an ErrorGroup and a context derived from ctx that
+// is cancelled, with the error as its cause, when a goroutine first fails,
+// or when Wait returns
+func WithContext(ctx context.Context) (*ErrorGroup, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &ErrorGroup{cancel: cancel}, ctx
+}
+
+// Go runs fn in a new goroutine, recording its error if it fails
+func (g *ErrorGroup) Go(fn func() error) {
+	g.wg.Go(func() {
+		if err := fn(); err != nil {
+			g.mu.Lock()
+			g.errs = append(g.errs, err)
+			g.mu.Unlock()
+			if g.cancel != nil {
+				g.cancel(err)
+			}
+		}
+	})
+}
+
+// Wait blocks until every goroutine started by Go has returned, and
+// returns their errors joined with errors.Join in the order they failed,
+// or nil if none did
+func (g *ErrorGroup) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(nil)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return errors.Join(g.errs...)
+}
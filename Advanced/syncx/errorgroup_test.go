@@ -0,0 +1,58 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestErrorGroup tests that Wait returns every error, and nil if none
+func TestErrorGroup(t *testing.T) {
+	var g ErrorGroup
+	for i := range 10 {
+		g.Go(func() error {
+			if i%3 == 0 {
+				return fmt.Errorf("task %d", i)
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) || len(joined.Unwrap()) != 4 {
+		t.Fatalf("Wait error = %v, want 4 joined errors", err)
+	}
+
+	var ok ErrorGroup
+	ok.Go(func() error { return nil })
+	if err := ok.Wait(); err != nil {
+		t.Errorf("Wait with no failures error = %v", err)
+	}
+}
+
+// TestErrorGroupWithContext tests that the first failure cancels the
+// group's context with its error as the cause
+func TestErrorGroupWithContext(t *testing.T) {
+	errFirst := errors.New("first")
+	g, ctx := WithContext(context.Background())
+	g.Go(func() error { return errFirst })
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	err := g.Wait()
+	if !errors.Is(err, errFirst) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait error = %v, want first and context.Canceled", err)
+	}
+	if cause := context.Cause(ctx); cause != errFirst {
+		t.Errorf("cause = %v, want first", cause)
+	}
+
+	g, ctx = WithContext(context.Background())
+	g.Go(func() error { return nil })
+	g.Wait()
+	if ctx.Err() == nil {
+		t.Error("context not cancelled after Wait")
+	}
+}
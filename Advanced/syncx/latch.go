@@ -0,0 +1,67 @@
+package syncx
+
+import (
+	"context"
+	"sync"
+)
+
+// CountDownLatch opens once counted down to zero, releasing every waiter
+// then and after. Unlike a WaitGroup, the goroutines counting down need
+// not be those waiting, and the count cannot go back up.
+type CountDownLatch struct {
+	mu    sync.Mutex
+	count int
+	open  chan struct{}
+}
+
+// NewCountDownLatch creates a latch opening after count calls of
+// CountDown, already open if count is 0 or less
+func NewCountDownLatch(count int) *CountDownLatch {
+	l := &CountDownLatch{count: max(count, 0), open: make(chan struct{})}
+	if l.count == 0 {
+		close(l.open)
+	}
+	return l
+}
+
+// CountDown decrements the count, opening the latch when it reaches zero.
+// Calls once the latch is open do nothing.
+func (l *CountDownLatch) CountDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count == 0 {
+		return
+	}
+	l.count--
+	if l.count == 0 {
+		close(l.open)
+	}
+}
+
+// Count returns the count still to go
+func (l *CountDownLatch) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+// Wait blocks until the latch opens, or returns the error of ctx if it
+// ends first. An open latch returns nil even if ctx has ended.
+func (l *CountDownLatch) Wait(ctx context.Context) error {
+	select {
+	case <-l.open:
+		return nil
+	default:
+	}
+	select {
+	case <-l.open:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Done returns a channel closed when the latch opens
+func (l *CountDownLatch) Done() <-chan struct{} {
+	return l.open
+}
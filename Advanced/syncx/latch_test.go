@@ -0,0 +1,50 @@
+package syncx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestCountDownLatch tests that waiters are released only at zero, and
+// that the latch stays open
+func TestCountDownLatch(t *testing.T) {
+	l := NewCountDownLatch(3)
+	var wg sync.WaitGroup
+	var released sync.Map
+	for i := range 4 {
+		wg.Go(func() {
+			if err := l.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+			released.Store(i, l.Count())
+		})
+	}
+	for range 2 {
+		l.CountDown()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait at count 1 error = %v, want DeadlineExceeded", err)
+	}
+	l.CountDown()
+	l.CountDown()
+	wg.Wait()
+	released.Range(func(i, count any) bool {
+		if count != 0 {
+			t.Errorf("waiter %v released at count %v", i, count)
+		}
+		return true
+	})
+	select {
+	case <-l.Done():
+	default:
+		t.Error("Done not closed at zero")
+	}
+	if err := NewCountDownLatch(0).Wait(ctx); err != nil {
+		t.Errorf("Wait on a latch of 0 failed: %v", err)
+	}
+}
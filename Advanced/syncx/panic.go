@@ -0,0 +1,24 @@
+package syncx
+
+import "fmt"
+
+// PanicError is the error of a goroutine that panicked, recovered by the
+// packages that run code for their callers: a task, job, stage, handler
+// or computation. Being one type, errors.As finds it whichever package
+// recovered the panic.
+type PanicError struct {
+	Value any    // The value passed to panic
+	Stack []byte // The stack of the goroutine when it panicked
+}
+
+// Error returns the panic value as an error message
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, as when code panics
+// with an error it could not return
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
@@ -0,0 +1,26 @@
+package syncx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// TestPanicError tests the message of a PanicError and that an error
+// passed to panic is found through it
+func TestPanicError(t *testing.T) {
+	var err error = &PanicError{Value: "boom"}
+	if err.Error() != "panic: boom" {
+		t.Errorf("Error = %q, want %q", err.Error(), "panic: boom")
+	}
+	if errors.Unwrap(err) != nil {
+		t.Errorf("Unwrap = %v, want nil for a value that is not an error", errors.Unwrap(err))
+	}
+
+	err = fmt.Errorf("run: %w", &PanicError{Value: io.ErrUnexpectedEOF})
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("%v: want a PanicError wrapping io.ErrUnexpectedEOF", err)
+	}
+}
@@ -0,0 +1,140 @@
+package validator
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// builtins are the rules of every new Validator, besides required,
+// omitempty and dive, which the Validator handles itself
+var builtins = map[string]Rule{
+	"min":    bound("min"),
+	"max":    bound("max"),
+	"len":    bound("len"),
+	"email":  email,
+	"oneof":  oneof,
+	"regexp": matches,
+}
+
+// durationType is the type of time.Duration, whose bounds are durations
+// such as 1m30s
+var durationType = reflect.TypeFor[time.Duration]()
+
+// size returns what min, max and len bound in v: the number itself, the
+// characters of a string or the elements of a slice, array or map
+func size(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
+
+// parseBound parses the parameter of min, max or len for a value of type t
+func parseBound(t reflect.Type, param string) (float64, error) {
+	if t == durationType {
+		d, err := time.ParseDuration(param)
+		return float64(d), err
+	}
+	return strconv.ParseFloat(param, 64)
+}
+
+// bound returns the rule min, max or len
+func bound(name string) Rule {
+	return func(v reflect.Value, param string) error {
+		n, ok := size(v)
+		if !ok {
+			panic(fmt.Sprintf("validator: %s on a value of type %v", name, v.Type()))
+		}
+		limit, err := parseBound(v.Type(), param)
+		if err != nil {
+			panic(fmt.Sprintf("validator: %s=%s: %v", name, param, err))
+		}
+		var fails bool
+		var want string
+		switch name {
+		case "min":
+			fails, want = n < limit, "at least "+param
+		case "max":
+			fails, want = n > limit, "at most "+param
+		default:
+			fails, want = n != limit, "exactly "+param
+		}
+		if !fails {
+			return nil
+		}
+		switch v.Kind() {
+		case reflect.String:
+			return fmt.Errorf("must be %s characters long", want)
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Errorf("must have %s elements", want)
+		}
+		if name == "len" {
+			return fmt.Errorf("must be %s", param)
+		}
+		return fmt.Errorf("must be %s", want)
+	}
+}
+
+// asString returns the string in v, panicking for other kinds
+func asString(v reflect.Value, rule string) string {
+	if v.Kind() != reflect.String {
+		panic(fmt.Sprintf("validator: %s on a value of type %v", rule, v.Type()))
+	}
+	return v.String()
+}
+
+// email requires a bare address, without a display name or angle
+// brackets
+func email(v reflect.Value, _ string) error {
+	s := asString(v, "email")
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" || addr.Address != s {
+		return fmt.Errorf("must be an email address")
+	}
+	return nil
+}
+
+// oneof requires the value, as printed, to be one of the words of param
+func oneof(v reflect.Value, param string) error {
+	choices := strings.Fields(param)
+	if !slices.Contains(choices, fmt.Sprint(v.Interface())) {
+		return fmt.Errorf("must be one of %s", strings.Join(choices, ", "))
+	}
+	return nil
+}
+
+// patterns caches the compiled patterns of regexp rules
+var patterns sync.Map // string to *regexp.Regexp
+
+// matches requires a string to match the pattern param
+func matches(v reflect.Value, param string) error {
+	re, ok := patterns.Load(param)
+	if !ok {
+		compiled, err := regexp.Compile(param)
+		if err != nil {
+			panic(fmt.Sprintf("validator: regexp=%s: %v", param, err))
+		}
+		re, _ = patterns.LoadOrStore(param, compiled)
+	}
+	if !re.(*regexp.Regexp).MatchString(asString(v, "regexp")) {
+		return fmt.Errorf("must match %s", param)
+	}
+	return nil
+}
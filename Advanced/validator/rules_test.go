@@ -0,0 +1,72 @@
+package validator
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRules tests each built-in rule on values that pass and fail
+func TestRules(t *testing.T) {
+	type s struct {
+		Min      int               `validate:"min=2"`
+		Max      float64           `validate:"max=1.5"`
+		Len      string            `validate:"len=3"`
+		Runes    string            `validate:"max=2"`
+		Slice    []int             `validate:"min=1"`
+		Map      map[string]int    `validate:"max=1"`
+		Uint     uint8             `validate:"len=7"`
+		Timeout  time.Duration     `validate:"min=1s,max=1m"`
+		Ptr      *int              `validate:"min=2"`
+		Email    string            `validate:"omitempty,email"`
+		Mode     string            `validate:"oneof=fast safe"`
+		Level    int               `validate:"oneof=1 2 3"`
+		Code     string            `validate:"regexp=^[A-Z]{2,3}-\\d+$"`
+		Comma    string            `validate:"omitempty, regexp=^a{1,2}$"`
+		Optional *int              `validate:"omitempty,min=5"`
+		Any      any               `validate:"required"`
+		Nested   map[string][]item `validate:"dive,max=1"`
+	}
+	two, one := 2, 1
+	valid := s{
+		Min: 2, Max: 1.5, Len: "abc", Runes: "éé", Slice: []int{1}, Map: map[string]int{"a": 1},
+		Uint: 7, Timeout: time.Second, Ptr: &two, Email: "a@b.co", Mode: "safe", Level: 3,
+		Code: "AB-12", Comma: "aa", Any: 0, Nested: map[string][]item{"a": {{SKU: "x", Qty: 1}}},
+	}
+	if err := Struct(valid); err != nil {
+		t.Fatalf("Struct of valid values = %v", err)
+	}
+
+	tests := []struct {
+		field   string
+		set     func(*s)
+		message string
+	}{
+		{"Min", func(v *s) { v.Min = 1 }, "must be at least 2"},
+		{"Max", func(v *s) { v.Max = 1.6 }, "must be at most 1.5"},
+		{"Len", func(v *s) { v.Len = "ab" }, "must be exactly 3 characters long"},
+		{"Runes", func(v *s) { v.Runes = "ééé" }, "must be at most 2 characters long"},
+		{"Slice", func(v *s) { v.Slice = nil }, "must have at least 1 elements"},
+		{"Map", func(v *s) { v.Map["b"] = 2 }, "must have at most 1 elements"},
+		{"Uint", func(v *s) { v.Uint = 8 }, "must be 7"},
+		{"Timeout", func(v *s) { v.Timeout = 2 * time.Minute }, "must be at most 1m"},
+		{"Ptr", func(v *s) { v.Ptr = &one }, "must be at least 2"},
+		{"Email", func(v *s) { v.Email = "a@" }, "must be an email address"},
+		{"Mode", func(v *s) { v.Mode = "slow" }, "must be one of fast, safe"},
+		{"Level", func(v *s) { v.Level = 4 }, "must be one of 1, 2, 3"},
+		{"Code", func(v *s) { v.Code = "A-1" }, `must match ^[A-Z]{2,3}-\d+$`},
+		{"Comma", func(v *s) { v.Comma = "aaa" }, "must match ^a{1,2}$"},
+		{"Optional", func(v *s) { v.Optional = &one }, "must be at least 5"},
+		{"Any", func(v *s) { v.Any = nil }, "is required"},
+		{"Nested[a]", func(v *s) { v.Nested["a"] = append(v.Nested["a"], item{}) }, "must have at most 1 elements"},
+	}
+	for _, tt := range tests {
+		v := valid
+		v.Map = map[string]int{"a": 1}
+		v.Nested = map[string][]item{"a": {{SKU: "x", Qty: 1}}}
+		tt.set(&v)
+		errs, _ := Struct(v).(Errors)
+		if len(errs) != 1 || errs[0].Field != tt.field || errs[0].Message != tt.message {
+			t.Errorf("%s: Struct = %v, want %q", tt.field, errs, tt.message)
+		}
+	}
+}
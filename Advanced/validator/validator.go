@@ -0,0 +1,377 @@
+// Package validator checks structs against the rules in their validate
+// tags, such as `validate:"required,min=3,email"`. Nested structs, and the
+// structs inside slices, arrays, maps and pointers, are checked too; the
+// dive rule applies the rules after it to each element of a slice or each
+// value of a map. Register adds rules of your own.
+//
+// Every failing rule is reported, not just the first: Struct returns
+// Errors, a ValidationError per failure naming the field by its path, as
+// "address.zip" or "items[2].sku", with a code such as VAL_REQUIRED.
+//
+// The built-in rules are:
+//
+//	required     not the zero value; a slice or map must not be empty
+//	omitempty    skip the other rules when the value is the zero value
+//	min=N max=N  bound a number, or the length of a string, slice or map
+//	len=N        the exact length, or the exact number
+//	email        an address alone, as alice@example.com
+//	oneof=a b c  one of the values separated by spaces
+//	regexp=re    a string matching re; it takes the rest of the tag,
+//	             commas included, so it must come last
+//	dive         apply the rules after it to each element instead
+//
+// Rules on a field of a type they do not apply to are a programmer mistake
+// and panic, as unknown rules do.
+package validator
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ValidationError is a rule a field failed
+type ValidationError struct {
+	Field   string // The path of the field, as "items[2].sku"
+	Rule    string // The rule that failed, as "min"
+	Param   string // The rule's parameter, as "3" for min=3
+	Value   any    // The value that failed
+	Message string // What was wrong, as "must be at least 3 characters"
+	Code    string // VAL_ and the rule in capitals, as "VAL_MIN"
+}
+
+// Error describes the failure without the value, which may be a secret
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation error [%s] on field '%s': %s", e.Code, e.Field, e.Message)
+}
+
+// Errors is every rule a struct failed, in field order
+type Errors []*ValidationError
+
+// Error lists the failures, one per line when there are several
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d validation errors:", len(e))
+	for _, err := range e {
+		b.WriteString("\n  * ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the failures, so errors.As finds a *ValidationError
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Fields maps each failing field to its messages, the shape an API
+// returns for a rejected request
+func (e Errors) Fields() map[string][]string {
+	fields := make(map[string][]string, len(e))
+	for _, err := range e {
+		fields[err.Field] = append(fields[err.Field], err.Message)
+	}
+	return fields
+}
+
+// Rule checks value against a parameter, such as "3" for min=3, and
+// returns an error whose message says what is wrong, or nil if the value
+// passes. Pointers are dereferenced before a rule sees them, except by
+// required and omitempty; a rule is not called on a nil pointer.
+type Rule func(value reflect.Value, param string) error
+
+// Validator checks structs against their validate tags. Its zero value is
+// not usable; call New. A Validator is safe for concurrent use.
+type Validator struct {
+	mu    sync.RWMutex
+	rules map[string]Rule
+	plans map[reflect.Type][]fieldPlan // The compiled tags of each struct
+}
+
+// New returns a Validator with the built-in rules
+func New() *Validator {
+	v := &Validator{rules: make(map[string]Rule), plans: make(map[reflect.Type][]fieldPlan)}
+	for name, rule := range builtins {
+		v.rules[name] = rule
+	}
+	return v
+}
+
+// reserved are the names that are not rules but steer the others
+var reserved = []string{"omitempty", "dive", "required"}
+
+// Register adds a rule, or replaces a built-in one, under name. It panics
+// if name is empty, contains '=' or ',', or is omitempty, dive or
+// required, whose meaning is fixed.
+func (v *Validator) Register(name string, rule Rule) {
+	if name == "" || strings.ContainsAny(name, "=,") || slices.Contains(reserved, name) {
+		panic(fmt.Sprintf("validator: invalid rule name %q", name))
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules[name] = rule
+	clear(v.plans)
+}
+
+// std is the Validator of the package-level Struct
+var std = New()
+
+// Struct checks s, a struct or a pointer to one, with the built-in rules.
+// It returns nil or Errors.
+func Struct(s any) error {
+	return std.Struct(s)
+}
+
+// Struct checks s, a struct or a pointer to one, returning nil or Errors.
+// It panics if s is neither, or if a tag uses an unknown rule.
+func (v *Validator) Struct(s any) error {
+	value := reflect.ValueOf(s)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validator: Struct of %T, want a struct", s))
+	}
+	c := &check{v: v, seen: make(map[any]bool)}
+	c.structValue("", value)
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return c.errs
+}
+
+// ruleCall is a rule of a tag with its parameter
+type ruleCall struct {
+	name, param string
+	rule        Rule
+}
+
+// ruleSet is the rules for one level of a field: the field itself, or,
+// after dive, its elements
+type ruleSet struct {
+	omitempty bool
+	required  bool
+	calls     []ruleCall
+}
+
+// fieldPlan is the compiled tag of a struct field
+type fieldPlan struct {
+	index int
+	name  string
+	self  ruleSet
+	dive  *ruleSet // The rules for each element, if the tag dives
+}
+
+// plan returns the compiled tags of the struct type t
+func (v *Validator) plan(t reflect.Type) []fieldPlan {
+	v.mu.RLock()
+	p, ok := v.plans[t]
+	v.mu.RUnlock()
+	if ok {
+		return p
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fp := fieldPlan{index: i, name: fieldName(sf)}
+		set := &fp.self
+		for _, item := range splitTag(sf.Tag.Get("validate")) {
+			name, param, _ := strings.Cut(item, "=")
+			switch name {
+			case "omitempty":
+				set.omitempty = true
+			case "required":
+				set.required = true
+			case "dive":
+				fp.dive = &ruleSet{}
+				set = fp.dive
+			default:
+				rule, ok := v.rules[name]
+				if !ok {
+					panic(fmt.Sprintf("validator: unknown rule %q on %v.%s", name, t, sf.Name))
+				}
+				set.calls = append(set.calls, ruleCall{name: name, param: param, rule: rule})
+			}
+		}
+		p = append(p, fp)
+	}
+	v.plans[t] = p
+	return p
+}
+
+// splitTag splits a validate tag at commas, except within the parameter
+// of regexp, which runs to the end of the tag
+func splitTag(tag string) []string {
+	var items []string
+	for tag != "" {
+		if strings.HasPrefix(tag, "regexp=") {
+			return append(items, tag)
+		}
+		item, rest, _ := strings.Cut(tag, ",")
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+		tag = strings.TrimLeft(rest, " ")
+	}
+	return items
+}
+
+// fieldName names a field in errors by its JSON name, which is what the
+// client that sent the value knows it as, or by its Go name without one
+func fieldName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
+
+// check is one run of Struct, gathering errors
+type check struct {
+	v    *Validator
+	errs Errors
+	seen map[any]bool // Pointers on the current path, to stop at cycles
+}
+
+// structValue checks the fields of the struct s at path
+func (c *check) structValue(path string, s reflect.Value) {
+	for _, fp := range c.v.plan(s.Type()) {
+		f := s.Field(fp.index)
+		p := join(path, fp.name)
+		if !c.apply(p, f, fp.self) {
+			continue
+		}
+		if fp.dive != nil {
+			c.each(p, f, func(p string, elem reflect.Value) {
+				if c.apply(p, elem, *fp.dive) {
+					c.descend(p, elem)
+				}
+			})
+			continue
+		}
+		c.descend(p, f)
+	}
+}
+
+// join appends a field name to a path
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// apply runs the rules of set on value, and reports whether to look inside
+// it: not if omitempty skipped it or a rule failed
+func (c *check) apply(path string, value reflect.Value, set ruleSet) bool {
+	zero := isEmpty(value)
+	if zero && set.omitempty {
+		return false
+	}
+	if set.required && zero {
+		c.fail(path, value, "required", "", errors.New("is required"))
+		return false
+	}
+	value = indirect(value)
+	if !value.IsValid() {
+		return false
+	}
+	ok := true
+	for _, call := range set.calls {
+		if err := call.rule(value, call.param); err != nil {
+			c.fail(path, value, call.name, call.param, err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// fail records a failed rule
+func (c *check) fail(path string, value reflect.Value, rule, param string, err error) {
+	e := &ValidationError{Field: path, Rule: rule, Param: param, Message: err.Error(),
+		Code: "VAL_" + strings.ToUpper(rule)}
+	if value.IsValid() && value.CanInterface() {
+		e.Value = value.Interface()
+	}
+	c.errs = append(c.errs, e)
+}
+
+// descend checks the structs within value: itself, or the elements of a
+// slice, array or map, through pointers and interfaces
+func (c *check) descend(path string, value reflect.Value) {
+	if value.Kind() == reflect.Pointer && !value.IsNil() {
+		key := value.Interface()
+		if c.seen[key] {
+			return
+		}
+		c.seen[key] = true
+		defer delete(c.seen, key)
+	}
+	value = indirect(value)
+	switch value.Kind() {
+	case reflect.Struct:
+		c.structValue(path, value)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		c.each(path, value, c.descend)
+	}
+}
+
+// each calls fn with the path and value of each element of a slice or
+// array, or each value of a map in the order of its keys
+func (c *check) each(path string, value reflect.Value, fn func(path string, elem reflect.Value)) {
+	value = indirect(value)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range value.Len() {
+			fn(fmt.Sprintf("%s[%d]", path, i), value.Index(i))
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return cmp.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+		for _, k := range keys {
+			fn(fmt.Sprintf("%s[%v]", path, k.Interface()), value.MapIndex(k))
+		}
+	default:
+		panic(fmt.Sprintf("validator: dive on %s of kind %v, want a slice, array or map", path, value.Kind()))
+	}
+}
+
+// indirect follows pointers and interfaces to the value they hold, or
+// returns the zero Value for nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isEmpty reports whether v is its zero value, or an empty slice or map
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Invalid:
+		return true
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,206 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type address struct {
+	Street string `json:"street" validate:"required"`
+	Zip    string `json:"zip" validate:"len=5"`
+}
+
+type item struct {
+	SKU string `json:"sku" validate:"required"`
+	Qty int    `json:"qty" validate:"min=1"`
+}
+
+type order struct {
+	ID       int               `json:"id" validate:"required"`
+	Email    string            `json:"email,omitempty" validate:"required,email"`
+	Note     string            `json:"-" validate:"omitempty,min=3"`
+	Address  address           `json:"address"`
+	Billing  *address          `json:"billing"`
+	Items    []item            `json:"items" validate:"required,max=3"`
+	Tags     []string          `json:"tags" validate:"dive,min=2"`
+	Meta     map[string]string `json:"meta" validate:"dive,required"`
+	Extra    map[string]*item  `json:"extra"`
+	internal string            `validate:"required"`
+}
+
+// validOrder returns an order that passes
+func validOrder() order {
+	return order{
+		ID: 1, Email: "alice@example.com",
+		Address: address{Street: "Main St", Zip: "12345"},
+		Items:   []item{{SKU: "A1", Qty: 1}},
+		Tags:    []string{"gift"},
+		Meta:    map[string]string{"source": "web"},
+	}
+}
+
+// fields returns the fields and codes of err, as "items[0].qty:VAL_MIN"
+func fields(err error) []string {
+	var errs Errors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	var out []string
+	for _, e := range errs {
+		out = append(out, e.Field+":"+e.Code)
+	}
+	return out
+}
+
+// TestStruct tests nested structs, pointers, slices and maps
+func TestStruct(t *testing.T) {
+	o := validOrder()
+	if err := Struct(o); err != nil {
+		t.Fatalf("Struct of a valid order = %v", err)
+	}
+	if err := Struct(&o); err != nil {
+		t.Fatalf("Struct of a pointer = %v", err)
+	}
+
+	o = order{
+		Email:   "Alice <alice@example.com>",
+		Note:    "ok",
+		Address: address{Zip: "123"},
+		Billing: &address{Street: "x", Zip: "1"},
+		Items:   []item{{SKU: "A1", Qty: 1}, {Qty: 0}, {SKU: "C"}, {SKU: "D", Qty: 1}},
+		Tags:    []string{"ok", "x"},
+		Meta:    map[string]string{"b": "", "a": "1"},
+		Extra:   map[string]*item{"z": {SKU: "Z"}, "nil": nil},
+	}
+	want := []string{
+		"id:VAL_REQUIRED",
+		"email:VAL_EMAIL",
+		"Note:VAL_MIN",
+		"address.street:VAL_REQUIRED",
+		"address.zip:VAL_LEN",
+		"billing.zip:VAL_LEN",
+		"items:VAL_MAX",
+		"tags[1]:VAL_MIN",
+		"meta[b]:VAL_REQUIRED",
+		"extra[z].qty:VAL_MIN",
+	}
+	if got := fields(Struct(o)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Struct failed\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Elements are checked only when the collection passes its own rules
+	o.Items = o.Items[:3]
+	got := fields(Struct(o))
+	if !strings.Contains(strings.Join(got, " "), "items[1].sku:VAL_REQUIRED items[1].qty:VAL_MIN items[2].qty:VAL_MIN") {
+		t.Errorf("Struct failed %v, want the invalid items reported", got)
+	}
+}
+
+// TestErrors tests the error and its conversions
+func TestErrors(t *testing.T) {
+	o := validOrder()
+	o.ID, o.Email = 0, "bad"
+	err := Struct(o)
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Field != "id" || ve.Rule != "required" || ve.Value != 0 {
+		t.Errorf("errors.As = %+v, want the error of field id", ve)
+	}
+	want := "2 validation errors:\n  * validation error [VAL_REQUIRED] on field 'id': is required\n" +
+		"  * validation error [VAL_EMAIL] on field 'email': must be an email address"
+	if err.Error() != want {
+		t.Errorf("Error =\n%s\nwant\n%s", err, want)
+	}
+	if f := err.(Errors).Fields(); !reflect.DeepEqual(f, map[string][]string{
+		"id": {"is required"}, "email": {"must be an email address"},
+	}) {
+		t.Errorf("Fields = %v", f)
+	}
+	o.ID = 1
+	if err := Struct(o); err.Error() != "validation error [VAL_EMAIL] on field 'email': must be an email address" {
+		t.Errorf("Error of one = %q", err)
+	}
+}
+
+// TestRegister tests custom rules
+func TestRegister(t *testing.T) {
+	type account struct {
+		Name string `validate:"lower,min=2"`
+		Age  int    `validate:"even"`
+	}
+	v := New()
+	v.Register("lower", func(value reflect.Value, _ string) error {
+		if s := value.String(); s != strings.ToLower(s) {
+			return fmt.Errorf("must be lower case")
+		}
+		return nil
+	})
+	v.Register("even", func(value reflect.Value, _ string) error {
+		if value.Int()%2 != 0 {
+			return fmt.Errorf("must be even")
+		}
+		return nil
+	})
+	if got := fields(v.Struct(account{Name: "Ab", Age: 3})); !reflect.DeepEqual(got, []string{"Name:VAL_LOWER", "Age:VAL_EVEN"}) {
+		t.Errorf("Struct failed %v", got)
+	}
+
+	// Replacing a rule after use takes effect
+	v.Register("even", func(reflect.Value, string) error { return nil })
+	if err := v.Struct(account{Name: "ab", Age: 3}); err != nil {
+		t.Errorf("Struct after replacing even = %v", err)
+	}
+
+	for _, name := range []string{"", "dive", "omitempty", "required", "a=b", "a,b"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) did not panic", name)
+				}
+			}()
+			v.Register(name, nil)
+		}()
+	}
+}
+
+// TestCycle tests that a cycle of pointers is checked once
+func TestCycle(t *testing.T) {
+	type node struct {
+		Name string `validate:"required"`
+		Next *node
+	}
+	a := &node{Name: "a"}
+	b := &node{Next: a}
+	a.Next = b
+	if got := fields(Struct(a)); !reflect.DeepEqual(got, []string{"Next.Name:VAL_REQUIRED"}) {
+		t.Errorf("Struct of a cycle failed %v", got)
+	}
+}
+
+// TestMisuse tests that programmer mistakes panic
+func TestMisuse(t *testing.T) {
+	type unknown struct {
+		A string `validate:"nope"`
+	}
+	type wrongType struct {
+		A int `validate:"email"`
+	}
+	type badParam struct {
+		A int `validate:"min=x"`
+	}
+	type diveScalar struct {
+		A int `validate:"dive,min=1"`
+	}
+	for _, s := range []any{1, nil, unknown{}, wrongType{}, badParam{}, diveScalar{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Struct(%#v) did not panic", s)
+				}
+			}()
+			Struct(s)
+		}()
+	}
+}
@@ -0,0 +1,25 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestFuture tests that Wait returns the result once resolved, or the
+// error of its context before
+func TestFuture(t *testing.T) {
+	f := newFuture[string]()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := f.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait on an unresolved future error = %v, want DeadlineExceeded", err)
+	}
+	errFailed := errors.New("failed")
+	go f.resolve("partial", errFailed)
+	<-f.Done()
+	if v, err := f.Wait(context.Background()); v != "partial" || err != errFailed {
+		t.Errorf("Wait = %q, %v, want partial, failed", v, err)
+	}
+}
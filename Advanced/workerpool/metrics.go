@@ -0,0 +1,34 @@
+package workerpool
+
+import "hellogolang/Advanced/metrics"
+
+// RegisterMetrics exposes the pool's Stats in r, labelled pool=name, read
+// afresh on every scrape: gauges of workers, idle workers and queued
+// tasks, and counters of tasks by fate. Pools registered with the same r
+// share the families and need distinct names.
+func (p *Pool[T]) RegisterMetrics(r *metrics.Registry, name string) {
+	gauges := []struct {
+		name, help string
+		get        func(Stats) int
+	}{
+		{"workerpool_workers", "Workers running", func(s Stats) int { return s.Workers }},
+		{"workerpool_idle_workers", "Workers waiting for a task", func(s Stats) int { return s.Idle }},
+		{"workerpool_queued_tasks", "Tasks waiting for a worker", func(s Stats) int { return s.Queued }},
+	}
+	for _, g := range gauges {
+		r.GaugeVec(g.name, g.help, "pool").Func(func() float64 { return float64(g.get(p.Stats())) }, name)
+	}
+
+	counters := []struct {
+		name, help string
+		get        func(Stats) uint64
+	}{
+		{"workerpool_completed_tasks_total", "Tasks that returned, with or without an error", func(s Stats) uint64 { return s.Completed }},
+		{"workerpool_panicked_tasks_total", "Tasks that panicked", func(s Stats) uint64 { return s.Panicked }},
+		{"workerpool_dropped_tasks_total", "Tasks pushed out of a full queue", func(s Stats) uint64 { return s.Dropped }},
+		{"workerpool_rejected_tasks_total", "Tasks refused by a full queue", func(s Stats) uint64 { return s.Rejected }},
+	}
+	for _, c := range counters {
+		r.CounterVec(c.name, c.help, "pool").Func(func() float64 { return float64(c.get(p.Stats())) }, name)
+	}
+}
@@ -0,0 +1,38 @@
+package workerpool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hellogolang/Advanced/metrics"
+)
+
+// TestRegisterMetrics tests that the exposed metrics follow the pool
+func TestRegisterMetrics(t *testing.T) {
+	release := make(chan struct{})
+	p, _ := fullPool(t, Reject, release)
+	r := metrics.NewRegistry()
+	p.RegisterMetrics(r, "images")
+	if _, err := p.Submit(context.Background(), square(2)); err == nil {
+		t.Fatal("Submit to a full pool succeeded")
+	}
+
+	var out strings.Builder
+	if err := r.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`workerpool_workers{pool="images"} 1`,
+		`workerpool_queued_tasks{pool="images"} 2`,
+		`workerpool_rejected_tasks_total{pool="images"} 1`,
+	} {
+		if !strings.Contains(out.String(), want+"\n") {
+			t.Errorf("exposition lacks %q:\n%s", want, out.String())
+		}
+	}
+	close(release)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
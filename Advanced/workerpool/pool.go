@@ -0,0 +1,326 @@
+// Package workerpool provides a pool of goroutines running submitted
+// tasks. The queue of waiting tasks is bounded, with a Policy choosing
+// whether a full queue blocks, drops its oldest task or rejects the new
+// one. The pool grows towards MaxWorkers while tasks queue faster than
+// its idle workers take them, and shrinks towards MinWorkers as workers
+// stay idle. A panicking task fails its own Future without taking down
+// its worker, and Shutdown drains the queue, or cancels what is left of
+// it once its context ends. RegisterMetrics exposes its Stats in a
+// metrics.Registry.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"hellogolang/Advanced/syncx"
+)
+
+var (
+	// ErrClosed is returned for tasks submitted after Shutdown, and fails
+	// tasks still queued when Shutdown gives up draining
+	ErrClosed = errors.New("worker pool closed")
+	// ErrQueueFull is returned by Submit with the Reject policy when the
+	// queue is full
+	ErrQueueFull = errors.New("worker pool queue full")
+	// ErrDropped fails a queued task pushed out by a newer one under the
+	// DropOldest policy
+	ErrDropped = errors.New("task dropped from full worker pool queue")
+)
+
+// Policy chooses what Submit does when the queue is full
+type Policy int
+
+// The policies for a full queue
+const (
+	Block      Policy = iota // Wait for room until the context ends
+	DropOldest               // Fail the oldest queued task with ErrDropped
+	Reject                   // Return ErrQueueFull
+)
+
+// Defaults for zero Options fields
+const (
+	DefaultQueueSize   = 64
+	DefaultIdleTimeout = time.Second
+)
+
+// Options configures a pool. Zero fields take their defaults.
+type Options struct {
+	// MinWorkers is the number of workers kept however idle, 0 if negative
+	MinWorkers int
+	// MaxWorkers bounds the workers, GOMAXPROCS if 0 or less and raised to
+	// MinWorkers if below it
+	MaxWorkers int
+	// QueueSize bounds the tasks waiting for a worker, DefaultQueueSize if
+	// 0 or less
+	QueueSize int
+	// Policy chooses what Submit does when the queue is full
+	Policy Policy
+	// IdleTimeout is how long a worker beyond MinWorkers waits for a task
+	// before exiting, DefaultIdleTimeout if 0 or less
+	IdleTimeout time.Duration
+}
+
+// Stats describes a pool's workers and the fate of its tasks
+type Stats struct {
+	Workers   int    // Workers running
+	Idle      int    // Workers waiting for a task
+	Queued    int    // Tasks waiting for a worker
+	Completed uint64 // Tasks that returned, with or without an error
+	Panicked  uint64 // Tasks that panicked
+	Dropped   uint64 // Tasks pushed out of the queue under DropOldest
+	Rejected  uint64 // Tasks refused under Reject
+}
+
+// Task is a unit of work. Its context is cancelled when Shutdown gives up
+// draining the pool.
+type Task[T any] func(ctx context.Context) (T, error)
+
+// job is a queued task and the future it resolves
+type job[T any] struct {
+	task   Task[T]
+	future *Future[T]
+}
+
+// Pool runs tasks returning T on a varying number of workers
+type Pool[T any] struct {
+	opts   Options
+	tasks  chan job[T]
+	ctx    context.Context // Cancelled when Shutdown gives up draining
+	cancel context.CancelFunc
+
+	// closing guards sending on tasks against its closing: senders hold it
+	// for reading, and Shutdown takes it for writing to close tasks
+	closing sync.RWMutex
+	closed  bool
+	quit    chan struct{} // Closed first, to release blocked senders
+	once    sync.Once
+
+	mu    sync.Mutex
+	stats Stats
+	wg    sync.WaitGroup
+}
+
+// New creates a pool and starts its MinWorkers workers
+func New[T any](opts Options) *Pool[T] {
+	opts.MinWorkers = max(opts.MinWorkers, 0)
+	if opts.MaxWorkers <= 0 {
+		opts.MaxWorkers = runtime.GOMAXPROCS(0)
+	}
+	opts.MaxWorkers = max(opts.MaxWorkers, opts.MinWorkers)
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultIdleTimeout
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool[T]{
+		opts:   opts,
+		tasks:  make(chan job[T], opts.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		quit:   make(chan struct{}),
+	}
+	p.mu.Lock()
+	for range opts.MinWorkers {
+		p.spawn()
+	}
+	p.mu.Unlock()
+	return p
+}
+
+// Submit queues task and returns the Future of its result. If the queue
+// is full, the Policy decides; ctx bounds only how long Block waits for
+// room, not the task, which runs under the pool's own context.
+func (p *Pool[T]) Submit(ctx context.Context, task Task[T]) (*Future[T], error) {
+	j := job[T]{task: task, future: newFuture[T]()}
+	p.closing.RLock()
+	defer p.closing.RUnlock()
+	if p.closed {
+		return nil, ErrClosed
+	}
+	switch p.opts.Policy {
+	case DropOldest:
+		for !p.trySend(j) {
+			select {
+			case old := <-p.tasks:
+				old.future.resolve(*new(T), ErrDropped)
+				p.count(&p.stats.Dropped)
+			default:
+			}
+		}
+	case Reject:
+		if !p.trySend(j) {
+			p.count(&p.stats.Rejected)
+			return nil, ErrQueueFull
+		}
+	default:
+		select {
+		case p.tasks <- j:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-p.quit:
+			return nil, ErrClosed
+		}
+	}
+	p.scale()
+	return j.future, nil
+}
+
+// trySend queues j if there is room
+func (p *Pool[T]) trySend(j job[T]) bool {
+	select {
+	case p.tasks <- j:
+		return true
+	default:
+		return false
+	}
+}
+
+// count adds one to a counter of stats
+func (p *Pool[T]) count(counter *uint64) {
+	p.mu.Lock()
+	*counter++
+	p.mu.Unlock()
+}
+
+// scale starts a worker if more tasks are queued than workers are idle
+// to take them, and the pool has room to grow
+func (p *Pool[T]) scale() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.tasks) > p.stats.Idle && p.stats.Workers < p.opts.MaxWorkers {
+		p.spawn()
+	}
+}
+
+// spawn starts an idle worker. The caller holds mu.
+func (p *Pool[T]) spawn() {
+	p.stats.Workers++
+	p.stats.Idle++
+	p.wg.Add(1)
+	go p.worker()
+}
+
+// worker runs queued tasks until the queue is closed and empty, or it has
+// been idle for IdleTimeout while the pool has more than MinWorkers
+func (p *Pool[T]) worker() {
+	defer p.wg.Done()
+	timer := time.NewTimer(p.opts.IdleTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case j, ok := <-p.tasks:
+			if !ok {
+				p.retire(true)
+				return
+			}
+			p.setIdle(-1)
+			p.run(j)
+			p.setIdle(1)
+			timer.Reset(p.opts.IdleTimeout)
+		case <-timer.C:
+			if p.retire(false) {
+				return
+			}
+			timer.Reset(p.opts.IdleTimeout)
+		}
+	}
+}
+
+// setIdle adjusts the count of idle workers
+func (p *Pool[T]) setIdle(delta int) {
+	p.mu.Lock()
+	p.stats.Idle += delta
+	p.mu.Unlock()
+}
+
+// retire removes an idle worker from the counts, unless it is not forced
+// to go and the pool would drop below MinWorkers or has tasks queued.
+// Checking the queue under mu pairs with scale: a task queued as the
+// worker retires either stops it retiring or sees it gone and starts
+// another.
+func (p *Pool[T]) retire(force bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !force && (p.stats.Workers <= p.opts.MinWorkers || len(p.tasks) > 0) {
+		return false
+	}
+	p.stats.Workers--
+	p.stats.Idle--
+	return true
+}
+
+// run runs a task and resolves its future, turning a panic into a
+// syncx.PanicError
+func (p *Pool[T]) run(j job[T]) {
+	// Secure: tasks left in the queue after Shutdown gives up are failed,
+	// not run
+	if p.ctx.Err() != nil {
+		j.future.resolve(*new(T), ErrClosed)
+		return
+	}
+	var value T
+	var err error
+	panicked := true
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &syncx.PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		value, err = j.task(p.ctx)
+		panicked = false
+	}()
+	if panicked {
+		p.count(&p.stats.Panicked)
+	} else {
+		p.count(&p.stats.Completed)
+	}
+	j.future.resolve(value, err)
+}
+
+// Stats returns a snapshot of the pool's workers and counters
+func (p *Pool[T]) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.stats
+	s.Queued = len(p.tasks)
+	return s
+}
+
+// Shutdown stops the pool accepting tasks and waits for the workers to
+// finish those queued. If ctx ends first, it cancels the context of the
+// running tasks, fails those still queued with ErrClosed and returns the
+// error of ctx without waiting further. Shutdown may be called more than
+// once.
+func (p *Pool[T]) Shutdown(ctx context.Context) error {
+	p.once.Do(func() {
+		close(p.quit)
+		p.closing.Lock()
+		p.closed = true
+		close(p.tasks)
+		p.closing.Unlock()
+	})
+	drained := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		for j := range p.tasks {
+			j.future.resolve(*new(T), ErrClosed)
+		}
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,275 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"hellogolang/Advanced/syncx"
+)
+
+// square returns a task squaring n
+func square(n int) Task[int] {
+	return func(context.Context) (int, error) { return n * n, nil }
+}
+
+// blocker returns a task that waits for release, and a channel receiving
+// once it has started
+func blocker(release <-chan struct{}) (Task[int], <-chan struct{}) {
+	started := make(chan struct{})
+	return func(ctx context.Context) (int, error) {
+		close(started)
+		select {
+		case <-release:
+			return 0, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}, started
+}
+
+// waitFor polls cond until it holds, failing after a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// TestSubmit tests that every task runs once and its future gets its
+// result
+func TestSubmit(t *testing.T) {
+	p := New[int](Options{MaxWorkers: 4, QueueSize: 8})
+	ctx := context.Background()
+	futures := []*Future[int]{}
+	for i := range 200 {
+		f, err := p.Submit(ctx, square(i))
+		if err != nil {
+			t.Fatalf("Submit %d failed: %v", i, err)
+		}
+		futures = append(futures, f)
+	}
+	for i, f := range futures {
+		if v, err := f.Wait(ctx); v != i*i || err != nil {
+			t.Fatalf("task %d = %d, %v, want %d", i, v, err, i*i)
+		}
+	}
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Stats(); s.Completed != 200 || s.Workers != 0 {
+		t.Errorf("stats after shutdown = %+v", s)
+	}
+}
+
+// fullPool returns a pool of one worker held by a blocking task, with its
+// queue of 2 full, and the futures of the queued tasks
+func fullPool(t *testing.T, policy Policy, release <-chan struct{}) (*Pool[int], []*Future[int]) {
+	t.Helper()
+	p := New[int](Options{MaxWorkers: 1, QueueSize: 2, Policy: policy})
+	task, started := blocker(release)
+	if _, err := p.Submit(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	queued := []*Future[int]{}
+	for i := range 2 {
+		f, err := p.Submit(context.Background(), square(i+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		queued = append(queued, f)
+	}
+	return p, queued
+}
+
+// TestPolicies tests each policy for a full queue
+func TestPolicies(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	defer close(release)
+
+	p, _ := fullPool(t, Block, release)
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Submit(short, square(3)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Block: Submit error = %v, want DeadlineExceeded", err)
+	}
+
+	p, _ = fullPool(t, Reject, release)
+	if _, err := p.Submit(ctx, square(3)); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Reject: Submit error = %v, want ErrQueueFull", err)
+	}
+	if s := p.Stats(); s.Rejected != 1 || s.Queued != 2 {
+		t.Errorf("Reject: stats = %+v", s)
+	}
+
+	p, queued := fullPool(t, DropOldest, release)
+	if _, err := p.Submit(ctx, square(3)); err != nil {
+		t.Errorf("DropOldest: Submit failed: %v", err)
+	}
+	if _, err := queued[0].Wait(ctx); !errors.Is(err, ErrDropped) {
+		t.Errorf("DropOldest: oldest task error = %v, want ErrDropped", err)
+	}
+	if s := p.Stats(); s.Dropped != 1 || s.Queued != 2 {
+		t.Errorf("DropOldest: stats = %+v", s)
+	}
+}
+
+// TestBlockedSubmitShutdown tests that Shutdown releases a blocked Submit
+func TestBlockedSubmitShutdown(t *testing.T) {
+	release := make(chan struct{})
+	p, _ := fullPool(t, Block, release)
+	errc := make(chan error)
+	go func() {
+		_, err := p.Submit(context.Background(), square(3))
+		errc <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	done := make(chan error)
+	go func() { done <- p.Shutdown(context.Background()) }()
+	if err := <-errc; !errors.Is(err, ErrClosed) {
+		t.Errorf("blocked Submit error = %v, want ErrClosed", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Shutdown failed: %v", err)
+	}
+}
+
+// TestScaling tests that the pool grows under a backlog and shrinks to
+// MinWorkers when idle
+func TestScaling(t *testing.T) {
+	p := New[int](Options{MinWorkers: 1, MaxWorkers: 4, QueueSize: 16, IdleTimeout: 10 * time.Millisecond})
+	if s := p.Stats(); s.Workers != 1 {
+		t.Fatalf("new pool has %d workers, want 1", s.Workers)
+	}
+	release := make(chan struct{})
+	futures := []*Future[int]{}
+	for range 8 {
+		task, _ := blocker(release)
+		f, err := p.Submit(context.Background(), task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		futures = append(futures, f)
+	}
+	waitFor(t, "4 busy workers", func() bool {
+		s := p.Stats()
+		return s.Workers == 4 && s.Idle == 0
+	})
+	close(release)
+	for _, f := range futures {
+		f.Wait(context.Background())
+	}
+	waitFor(t, "idle workers to retire", func() bool { return p.Stats().Workers == 1 })
+	p.Shutdown(context.Background())
+}
+
+// TestPanic tests that a panicking task fails its future and leaves its
+// worker running
+func TestPanic(t *testing.T) {
+	p := New[int](Options{MaxWorkers: 1})
+	ctx := context.Background()
+	f, _ := p.Submit(ctx, func(context.Context) (int, error) { panic("boom") })
+	var perr *syncx.PanicError
+	if _, err := f.Wait(ctx); !errors.As(err, &perr) || perr.Value != "boom" || len(perr.Stack) == 0 {
+		t.Fatalf("panicking task error = %v, want a syncx.PanicError", err)
+	}
+	f, _ = p.Submit(ctx, square(7))
+	if v, err := f.Wait(ctx); v != 49 || err != nil {
+		t.Errorf("task after a panic = %d, %v, want 49", v, err)
+	}
+	if s := p.Stats(); s.Panicked != 1 || s.Completed != 1 {
+		t.Errorf("stats = %+v", s)
+	}
+	p.Shutdown(ctx)
+}
+
+// TestShutdown tests that Shutdown drains the queue, refuses new tasks,
+// and cancels outstanding work when its context ends
+func TestShutdown(t *testing.T) {
+	ctx := context.Background()
+	p := New[int](Options{MaxWorkers: 2})
+	futures := []*Future[int]{}
+	for i := range 20 {
+		f, _ := p.Submit(ctx, func(context.Context) (int, error) {
+			time.Sleep(time.Millisecond)
+			return i, nil
+		})
+		futures = append(futures, f)
+	}
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	for i, f := range futures {
+		select {
+		case <-f.Done():
+		default:
+			t.Fatalf("task %d unfinished after Shutdown", i)
+		}
+	}
+	if _, err := p.Submit(ctx, square(1)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after Shutdown error = %v, want ErrClosed", err)
+	}
+	if err := p.Shutdown(ctx); err != nil {
+		t.Errorf("second Shutdown failed: %v", err)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	p, queued := fullPool(t, Block, release)
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown of a stuck pool error = %v, want DeadlineExceeded", err)
+	}
+	for _, f := range queued {
+		if _, err := f.Wait(ctx); !errors.Is(err, ErrClosed) {
+			t.Errorf("queued task error = %v, want ErrClosed", err)
+		}
+	}
+	waitFor(t, "the running task to be cancelled", func() bool { return p.Stats().Workers == 0 })
+}
+
+// TestConcurrent submits from many goroutines under DropOldest, for the
+// race detector, and checks every task is accounted for
+func TestConcurrent(t *testing.T) {
+	p := New[int](Options{MaxWorkers: 3, QueueSize: 4, Policy: DropOldest, IdleTimeout: time.Millisecond})
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for i := range 200 {
+				f, err := p.Submit(ctx, square(i))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if v, err := f.Wait(ctx); err == nil && v != i*i {
+					t.Errorf("task = %d, want %d", v, i*i)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	p.Shutdown(ctx)
+	if s := p.Stats(); s.Completed+s.Dropped != 8*200 {
+		t.Errorf("tasks unaccounted for: %+v", s)
+	}
+}
+
+// BenchmarkSubmit measures submitting a task and waiting for its result
+func BenchmarkSubmit(b *testing.B) {
+	p := New[int](Options{})
+	ctx := context.Background()
+	for b.Loop() {
+		f, _ := p.Submit(ctx, square(3))
+		f.Wait(ctx)
+	}
+	p.Shutdown(ctx)
+}
@@ -0,0 +1,134 @@
+package xslices
+
+import "iter"
+
+// MapSeq yields fn applied to each value of seq
+func MapSeq[E, R any](seq iter.Seq[E], fn func(E) R) iter.Seq[R] {
+	return func(yield func(R) bool) {
+		for v := range seq {
+			if !yield(fn(v)) {
+				return
+			}
+		}
+	}
+}
+
+// FilterSeq yields the values of seq that keep reports true for
+func FilterSeq[E any](seq iter.Seq[E], keep func(E) bool) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		for v := range seq {
+			if keep(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// ReduceSeq folds seq into one value, starting from init. It ranges over
+// all of seq, so seq must be finite.
+func ReduceSeq[E, A any](seq iter.Seq[E], init A, fn func(A, E) A) A {
+	acc := init
+	for v := range seq {
+		acc = fn(acc, v)
+	}
+	return acc
+}
+
+// UniqueSeq yields the values of seq without repeats, remembering every
+// value yielded so far
+func UniqueSeq[E comparable](seq iter.Seq[E]) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		seen := make(map[E]struct{})
+		for v := range seq {
+			if _, ok := seen[v]; ok {
+				continue
+			}
+			seen[v] = struct{}{}
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// ChunkSeq yields consecutive chunks of size values from seq, the last one
+// shorter if seq runs out. Each chunk is a new slice. It panics if size is
+// less than 1.
+func ChunkSeq[E any](seq iter.Seq[E], size int) iter.Seq[[]E] {
+	if size < 1 {
+		panic("xslices: chunk size must be at least 1")
+	}
+	return func(yield func([]E) bool) {
+		chunk := make([]E, 0, size)
+		for v := range seq {
+			chunk = append(chunk, v)
+			if len(chunk) == size {
+				if !yield(chunk) {
+					return
+				}
+				chunk = make([]E, 0, size)
+			}
+		}
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
+
+// ZipSeq yields the values of a and b in pairs, stopping when either runs
+// out
+func ZipSeq[A, B any](a iter.Seq[A], b iter.Seq[B]) iter.Seq2[A, B] {
+	return func(yield func(A, B) bool) {
+		nextB, stop := iter.Pull(b)
+		defer stop()
+		for va := range a {
+			vb, ok := nextB()
+			if !ok || !yield(va, vb) {
+				return
+			}
+		}
+	}
+}
+
+// Take yields the first n values of seq, or all of them if there are fewer
+func Take[E any](seq iter.Seq[E], n int) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		if n <= 0 {
+			return
+		}
+		taken := 0
+		for v := range seq {
+			if !yield(v) {
+				return
+			}
+			if taken++; taken == n {
+				return
+			}
+		}
+	}
+}
+
+// Skip yields the values of seq after the first n
+func Skip[E any](seq iter.Seq[E], n int) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		skipped := 0
+		for v := range seq {
+			if skipped < n {
+				skipped++
+				continue
+			}
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Naturals yields 0, 1, 2, ... without end, for use with Take and the
+// like
+func Naturals() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := 0; yield(i); i++ {
+		}
+	}
+}
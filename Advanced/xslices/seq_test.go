@@ -0,0 +1,65 @@
+package xslices
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// TestPipeline tests composing lazy steps, and that they do only the work
+// the consumer asks for
+func TestPipeline(t *testing.T) {
+	calls := 0
+	squares := MapSeq(Naturals(), func(n int) int {
+		calls++
+		return n * n
+	})
+	odd := FilterSeq(squares, func(n int) bool { return n%2 == 1 })
+	got := slices.Collect(Take(Skip(odd, 1), 3))
+	if !slices.Equal(got, []int{9, 25, 49}) {
+		t.Errorf("pipeline = %v", got)
+	}
+	if calls != 8 {
+		t.Errorf("mapped %d values, want the 8 needed", calls)
+	}
+	if sum := ReduceSeq(Take(Naturals(), 5), 0, func(a, n int) int { return a + n }); sum != 10 {
+		t.Errorf("ReduceSeq = %d", sum)
+	}
+	if len(slices.Collect(Take(Naturals(), 0))) != 0 {
+		t.Error("Take(0) yielded values")
+	}
+}
+
+// TestUniqueChunkSeq tests lazy deduplication and chunking, stopping
+// early included
+func TestUniqueChunkSeq(t *testing.T) {
+	if got := slices.Collect(UniqueSeq(slices.Values([]int{1, 1, 2, 1, 3}))); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("UniqueSeq = %v", got)
+	}
+	chunks := slices.Collect(ChunkSeq(slices.Values([]int{1, 2, 3, 4, 5}), 2))
+	if fmt.Sprint(chunks) != "[[1 2] [3 4] [5]]" {
+		t.Errorf("ChunkSeq = %v", chunks)
+	}
+	for chunk := range ChunkSeq(Naturals(), 3) {
+		if !slices.Equal(chunk, []int{0, 1, 2}) {
+			t.Errorf("first chunk = %v", chunk)
+		}
+		break
+	}
+}
+
+// TestZipSeq tests pairing two sequences of different lengths
+func TestZipSeq(t *testing.T) {
+	got := maps.Collect(ZipSeq(slices.Values([]string{"a", "b", "c"}), Naturals()))
+	if len(got) != 3 || got["a"] != 0 || got["c"] != 2 {
+		t.Errorf("ZipSeq = %v", got)
+	}
+	n := 0
+	for range ZipSeq(Naturals(), slices.Values([]int{7, 8})) {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("ZipSeq yielded %d pairs, want 2", n)
+	}
+}
@@ -0,0 +1,107 @@
+// Package xslices provides the generic slice functions the slices package
+// leaves out: Map, Filter, Reduce, GroupBy, Chunk, Zip, Unique and
+// Partition, which build a new slice eagerly, and in seq.go their lazy
+// counterparts over iter.Seq, which compose into pipelines that do no
+// work until ranged over and stop as soon as the range does.
+package xslices
+
+// Pair holds one element from each of two slices
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Map returns fn applied to each element of s, in order
+func Map[S ~[]E, E, R any](s S, fn func(E) R) []R {
+	out := make([]R, len(s))
+	for i, v := range s {
+		out[i] = fn(v)
+	}
+	return out
+}
+
+// Filter returns the elements of s that keep reports true for, in order.
+// s is left unchanged.
+func Filter[S ~[]E, E any](s S, keep func(E) bool) S {
+	var out S
+	for _, v := range s {
+		if keep(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Reduce folds s into one value, starting from init and combining it with
+// each element in turn
+func Reduce[S ~[]E, E, A any](s S, init A, fn func(A, E) A) A {
+	acc := init
+	for _, v := range s {
+		acc = fn(acc, v)
+	}
+	return acc
+}
+
+// GroupBy returns the elements of s grouped by key, each group keeping the
+// order of s
+func GroupBy[S ~[]E, E any, K comparable](s S, key func(E) K) map[K]S {
+	groups := make(map[K]S)
+	for _, v := range s {
+		k := key(v)
+		groups[k] = append(groups[k], v)
+	}
+	return groups
+}
+
+// Chunk splits s into consecutive chunks of size elements, the last one
+// shorter if len(s) is not a multiple of size. The chunks share s's
+// backing array but are capped, so appending to one cannot overwrite the
+// next. Like slices.Chunk, it panics if size is less than 1.
+func Chunk[S ~[]E, E any](s S, size int) []S {
+	if size < 1 {
+		panic("xslices: chunk size must be at least 1")
+	}
+	chunks := make([]S, 0, (len(s)+size-1)/size)
+	for i := 0; i < len(s); i += size {
+		end := min(i+size, len(s))
+		chunks = append(chunks, s[i:end:end])
+	}
+	return chunks
+}
+
+// Zip pairs the elements of a and b by index, stopping at the end of the
+// shorter
+func Zip[A, B any](a []A, b []B) []Pair[A, B] {
+	out := make([]Pair[A, B], min(len(a), len(b)))
+	for i := range out {
+		out[i] = Pair[A, B]{a[i], b[i]}
+	}
+	return out
+}
+
+// Unique returns the elements of s without repeats, keeping the first of
+// each in order. Unlike slices.Compact, the repeats need not be adjacent.
+func Unique[S ~[]E, E comparable](s S) S {
+	seen := make(map[E]struct{}, len(s))
+	var out S
+	for _, v := range s {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Partition splits s into the elements pred reports true for and the
+// rest, both in order
+func Partition[S ~[]E, E any](s S, pred func(E) bool) (yes, no S) {
+	for _, v := range s {
+		if pred(v) {
+			yes = append(yes, v)
+		} else {
+			no = append(no, v)
+		}
+	}
+	return yes, no
+}
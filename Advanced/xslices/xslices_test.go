@@ -0,0 +1,79 @@
+package xslices
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// TestMapFilterReduce tests the three basic transformations
+func TestMapFilterReduce(t *testing.T) {
+	numbers := []int{1, 2, 3, 4, 5}
+	if got := Map(numbers, strconv.Itoa); !slices.Equal(got, []string{"1", "2", "3", "4", "5"}) {
+		t.Errorf("Map = %q", got)
+	}
+	if got := Filter(numbers, func(n int) bool { return n%2 == 0 }); !slices.Equal(got, []int{2, 4}) {
+		t.Errorf("Filter = %v", got)
+	}
+	if got := Reduce(numbers, "", func(acc string, n int) string { return acc + strconv.Itoa(n) }); got != "12345" {
+		t.Errorf("Reduce = %q", got)
+	}
+	if Map([]int(nil), strconv.Itoa) == nil || len(Filter(numbers, func(int) bool { return false })) != 0 {
+		t.Error("empty results")
+	}
+}
+
+// TestGroupByPartition tests splitting a slice by key and by predicate
+func TestGroupByPartition(t *testing.T) {
+	words := []string{"go", "rust", "c", "zig", "java", "d"}
+	groups := GroupBy(words, func(w string) int { return len(w) })
+	if len(groups) != 4 || !slices.Equal(groups[1], []string{"c", "d"}) || !slices.Equal(groups[4], []string{"rust", "java"}) {
+		t.Errorf("GroupBy = %v", groups)
+	}
+	short, long := Partition(words, func(w string) bool { return len(w) < 3 })
+	if !slices.Equal(short, []string{"go", "c", "d"}) || !slices.Equal(long, []string{"rust", "zig", "java"}) {
+		t.Errorf("Partition = %v, %v", short, long)
+	}
+}
+
+// TestChunk tests chunk sizes and that chunks cannot overwrite each other
+func TestChunk(t *testing.T) {
+	s := []int{1, 2, 3, 4, 5}
+	chunks := Chunk(s, 2)
+	if fmt.Sprint(chunks) != "[[1 2] [3 4] [5]]" {
+		t.Errorf("Chunk = %v", chunks)
+	}
+	_ = append(chunks[0], 99)
+	if s[2] != 3 {
+		t.Error("appending to a chunk overwrote the next")
+	}
+	if len(Chunk([]int{}, 3)) != 0 {
+		t.Error("Chunk of an empty slice is not empty")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Chunk with size 0 did not panic")
+		}
+	}()
+	Chunk(s, 0)
+}
+
+// TestZipUnique tests pairing and deduplicating
+func TestZipUnique(t *testing.T) {
+	pairs := Zip([]string{"a", "b", "c"}, []int{1, 2})
+	if want := []Pair[string, int]{{"a", 1}, {"b", 2}}; !slices.Equal(pairs, want) {
+		t.Errorf("Zip = %v, want %v", pairs, want)
+	}
+	if got := Unique([]int{3, 1, 3, 2, 1}); !slices.Equal(got, []int{3, 1, 2}) {
+		t.Errorf("Unique = %v", got)
+	}
+}
+
+// BenchmarkMap measures mapping a slice
+func BenchmarkMap(b *testing.B) {
+	s := make([]int, 1000)
+	for b.Loop() {
+		Map(s, func(n int) int { return n * 2 })
+	}
+}
@@ -2,414 +2,117 @@ package main
 
 import (
 	"fmt"
-	"math"
+
+	"hellogolang/Algorithms/dp"
 )
 
-// Dynamic Programming - Comprehensive implementations of DP algorithms
+// Dynamic Programming - Demonstrates the dp package, which solves classic DP
+// problems and reconstructs the solutions behind the optimal values
 
 func main() {
 	demonstrateDynamicProgramming()
 }
 
 func demonstrateDynamicProgramming() {
-	// Fibonacci
-	fmt.Println("Fibonacci(10):", Fibonacci(10))
-	fmt.Println("Fibonacci(20):", Fibonacci(20))
+	// Fibonacci: an int holds up to F(92); past it the result is reported as
+	// an overflow, and FibonacciBig computes it exactly
+	for _, n := range []int{10, 20, dp.MaxFibonacci, 100} {
+		if f, err := dp.Fibonacci(n); err == nil {
+			fmt.Printf("Fibonacci(%d): %d\n", n, f)
+		} else {
+			fmt.Printf("Fibonacci(%d): %v\n", n, err)
+		}
+	}
+	if f, err := dp.FibonacciBig(100); err == nil {
+		fmt.Println("FibonacciBig(100):", f)
+	}
+	if f, err := dp.FactorialBig(30); err == nil {
+		fmt.Println("FactorialBig(30):", f)
+	}
 
 	// Longest Common Subsequence
 	s1, s2 := "ABCDGH", "AEDFHR"
-	fmt.Printf("LCS of '%s' and '%s': %d\n", s1, s2, LongestCommonSubsequence(s1, s2))
+	fmt.Printf("LCS of '%s' and '%s': %d (%s)\n", s1, s2,
+		dp.LongestCommonSubsequence(s1, s2), dp.LongestCommonSubsequenceString(s1, s2))
 
 	// Longest Increasing Subsequence
 	arr := []int{10, 22, 9, 33, 21, 50, 41, 60, 80}
-	fmt.Println("LIS length:", LongestIncreasingSubsequence(arr))
+	fmt.Println("LIS length:", dp.LongestIncreasingSubsequence(arr))
 
-	// Edit Distance
+	// Edit Distance in O(min(m, n)) space, and the script of operations from
+	// the full table
 	s3, s4 := "sunday", "saturday"
-	fmt.Printf("Edit distance between '%s' and '%s': %d\n", s3, s4, EditDistance(s3, s4))
+	fmt.Printf("Edit distance between '%s' and '%s': %d\n", s3, s4, dp.EditDistance(s3, s4))
+	_, ops := dp.EditScript(s3, s4)
+	for _, op := range ops {
+		switch op.Kind {
+		case dp.EditSubstitute:
+			fmt.Printf("  substitute '%c' with '%c'\n", s3[op.I], s4[op.J])
+		case dp.EditInsert:
+			fmt.Printf("  insert '%c'\n", s4[op.J])
+		case dp.EditDelete:
+			fmt.Printf("  delete '%c'\n", s3[op.I])
+		}
+	}
 
-	// 0/1 Knapsack
+	// 0/1 Knapsack in O(W) space, and the items taken from the full table
 	weights := []int{10, 20, 30}
 	values := []int{60, 100, 120}
 	capacity := 50
-	fmt.Printf("Knapsack (capacity %d): %d\n", capacity, Knapsack01(weights, values, capacity))
-
-	// Coin Change
-	coins := []int{1, 3, 4}
-	amount := 6
-	fmt.Printf("Coin change for %d: %d ways\n", amount, CoinChange(coins, amount))
-
-	// Matrix Chain Multiplication
-	p := []int{1, 2, 3, 4, 3}
-	fmt.Printf("Matrix chain multiplication cost: %d\n", MatrixChainMultiplication(p))
-}
-
-// Fibonacci calculates nth Fibonacci number using DP
-// Time Complexity: O(n), Space Complexity: O(n)
-func Fibonacci(n int) int {
-	// Secure: validate input
-	if n < 0 {
-		return 0
-	}
-	if n <= 1 {
-		return n
+	if best, err := dp.Knapsack01(weights, values, capacity); err == nil {
+		fmt.Printf("Knapsack (capacity %d): %d\n", capacity, best)
 	}
-
-	// DP table
-	dp := make([]int, n+1)
-	dp[0] = 0
-	dp[1] = 1
-
-	for i := 2; i <= n; i++ {
-		// Secure: prevent integer overflow
-		dp[i] = dp[i-1] + dp[i-2]
-	}
-
-	return dp[n]
-}
-
-// FibonacciOptimized calculates Fibonacci with O(1) space
-func FibonacciOptimized(n int) int {
-	// Secure: validate input
-	if n < 0 {
-		return 0
-	}
-	if n <= 1 {
-		return n
-	}
-
-	a, b := 0, 1
-	for i := 2; i <= n; i++ {
-		a, b = b, a+b
-	}
-	return b
-}
-
-// LongestCommonSubsequence finds LCS of two strings
-// Time Complexity: O(m * n), Space Complexity: O(m * n)
-func LongestCommonSubsequence(s1, s2 string) int {
-	m, n := len(s1), len(s2)
-
-	// Secure: validate input
-	if m == 0 || n == 0 {
-		return 0
-	}
-
-	// DP table
-	dp := make([][]int, m+1)
-	for i := range dp {
-		dp[i] = make([]int, n+1)
+	if _, items, err := dp.Knapsack01Items(weights, values, capacity); err == nil {
+		fmt.Printf("Knapsack items taken: %v\n", items)
 	}
 
-	for i := 1; i <= m; i++ {
-		for j := 1; j <= n; j++ {
-			// Secure: bounds checking
-			if i-1 < len(s1) && j-1 < len(s2) {
-				if s1[i-1] == s2[j-1] {
-					dp[i][j] = dp[i-1][j-1] + 1
-				} else {
-					dp[i][j] = max(dp[i-1][j], dp[i][j-1])
-				}
-			}
-		}
-	}
-
-	return dp[m][n]
-}
-
-// max returns maximum of two integers
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
-// LongestIncreasingSubsequence finds length of LIS
-// Time Complexity: O(n²), Space Complexity: O(n)
-func LongestIncreasingSubsequence(arr []int) int {
-	n := len(arr)
-
-	// Secure: validate input
-	if n == 0 {
-		return 0
-	}
-
-	// DP table
-	dp := make([]int, n)
-
-	// Initialize
-	for i := range dp {
-		dp[i] = 1
-	}
-
-	for i := 1; i < n; i++ {
-		for j := 0; j < i; j++ {
-			// Secure: bounds checking
-			if j < len(arr) && i < len(arr) && arr[j] < arr[i] {
-				dp[i] = max(dp[i], dp[j]+1)
-			}
-		}
-	}
-
-	// Find maximum
-	result := 1
-	for _, val := range dp {
-		if val > result {
-			result = val
-		}
-	}
-
-	return result
-}
-
-// EditDistance calculates edit distance (Levenshtein distance)
-// Time Complexity: O(m * n), Space Complexity: O(m * n)
-func EditDistance(s1, s2 string) int {
-	m, n := len(s1), len(s2)
-
-	// Secure: validate input
-	if m == 0 {
-		return n
-	}
-	if n == 0 {
-		return m
-	}
-
-	// DP table
-	dp := make([][]int, m+1)
-	for i := range dp {
-		dp[i] = make([]int, n+1)
-	}
-
-	// Base cases
-	for i := 0; i <= m; i++ {
-		dp[i][0] = i
-	}
-	for j := 0; j <= n; j++ {
-		dp[0][j] = j
-	}
-
-	for i := 1; i <= m; i++ {
-		for j := 1; j <= n; j++ {
-			// Secure: bounds checking
-			if i-1 < len(s1) && j-1 < len(s2) {
-				if s1[i-1] == s2[j-1] {
-					dp[i][j] = dp[i-1][j-1]
-				} else {
-					dp[i][j] = 1 + min3(dp[i-1][j], dp[i][j-1], dp[i-1][j-1])
-				}
-			}
-		}
-	}
-
-	return dp[m][n]
-}
-
-// min3 returns minimum of three integers
-func min3(a, b, c int) int {
-	if a < b {
-		if a < c {
-			return a
-		}
-		return c
-	}
-	if b < c {
-		return b
-	}
-	return c
-}
-
-// Knapsack01 solves 0/1 knapsack problem
-// Time Complexity: O(n * W), Space Complexity: O(n * W)
-func Knapsack01(weights, values []int, capacity int) int {
-	n := len(weights)
-
-	// Secure: validate input
-	if n == 0 || capacity < 0 || len(values) != n {
-		return 0
-	}
-
-	// Secure: validate weights and values
-	for i := range weights {
-		if weights[i] < 0 || values[i] < 0 {
-			return 0
-		}
-	}
-
-	// DP table
-	dp := make([][]int, n+1)
-	for i := range dp {
-		dp[i] = make([]int, capacity+1)
-	}
-
-	for i := 1; i <= n; i++ {
-		for w := 1; w <= capacity; w++ {
-			// Secure: bounds checking
-			if i-1 < len(weights) {
-				if weights[i-1] <= w {
-					// Secure: prevent integer overflow
-					dp[i][w] = max(dp[i-1][w], dp[i-1][w-weights[i-1]]+values[i-1])
-				} else {
-					dp[i][w] = dp[i-1][w]
-				}
-			}
-		}
-	}
-
-	return dp[n][capacity]
-}
-
-// CoinChange counts ways to make amount using coins
-// Time Complexity: O(n * amount), Space Complexity: O(amount)
-func CoinChange(coins []int, amount int) int {
-	// Secure: validate input
-	if amount < 0 {
-		return 0
-	}
-	if amount == 0 {
-		return 1
-	}
-	if len(coins) == 0 {
-		return 0
-	}
-
-	// Secure: validate coins
-	for _, coin := range coins {
-		if coin <= 0 {
-			return 0
-		}
-	}
-
-	// DP table
-	dp := make([]int, amount+1)
-	dp[0] = 1
-
-	for _, coin := range coins {
-		// Secure: validate coin
-		if coin <= 0 {
-			continue
-		}
-		for j := coin; j <= amount; j++ {
-			// Secure: prevent integer overflow
-			dp[j] += dp[j-coin]
-		}
-	}
-
-	return dp[amount]
-}
-
-// MatrixChainMultiplication finds minimum cost of matrix chain multiplication
-// Time Complexity: O(n³), Space Complexity: O(n²)
-func MatrixChainMultiplication(p []int) int {
-	n := len(p) - 1
-
-	// Secure: validate input
-	if n <= 0 {
-		return 0
-	}
-
-	// Secure: validate dimensions
-	for i := range p {
-		if p[i] < 0 {
-			return 0
-		}
+	// Coin Change: the number of ways, and the fewest coins
+	coins := []int{1, 3, 4}
+	amount := 6
+	if ways, err := dp.CoinChange(coins, amount); err == nil {
+		fmt.Printf("Coin change for %d: %d ways\n", amount, ways)
 	}
-
-	// DP table
-	dp := make([][]int, n)
-	for i := range dp {
-		dp[i] = make([]int, n)
+	if used, err := dp.CoinChangeMin(coins, amount); err == nil {
+		fmt.Printf("Fewest coins for %d: %v\n", amount, used)
 	}
 
-	// Fill table
-	for length := 2; length <= n; length++ {
-		for i := 0; i < n-length+1; i++ {
-			j := i + length - 1
-			dp[i][j] = math.MaxInt32
-
-			for k := i; k < j; k++ {
-				// Secure: bounds checking
-				if i < len(p) && k+1 < len(p) && j+1 < len(p) {
-					cost := dp[i][k] + dp[k+1][j] + p[i]*p[k+1]*p[j+1]
-					if cost < dp[i][j] {
-						dp[i][j] = cost
-					}
-				}
-			}
-		}
+	// Rod Cutting, with the pieces to cut
+	prices := []int{1, 5, 8, 9, 10, 17, 17, 20}
+	if price, cuts, err := dp.RodCuttingCuts(prices, 8); err == nil {
+		fmt.Printf("Rod cutting for length 8: %d, cutting %v\n", price, cuts)
 	}
 
-	return dp[0][n-1]
-}
-
-// LongestPalindromicSubsequence finds length of longest palindromic subsequence
-// Time Complexity: O(n²), Space Complexity: O(n²)
-func LongestPalindromicSubsequence(s string) int {
-	n := len(s)
-
-	// Secure: validate input
-	if n == 0 {
-		return 0
+	// Matrix Chain Multiplication
+	p := []int{1, 2, 3, 4, 3}
+	if cost, err := dp.MatrixChainMultiplication(p); err == nil {
+		fmt.Printf("Matrix chain multiplication cost: %d\n", cost)
 	}
 
-	// DP table
-	dp := make([][]int, n)
-	for i := range dp {
-		dp[i] = make([]int, n)
-		dp[i][i] = 1
+	// Top-down DP: each subproblem is solved once and cached in a Memo
+	if f, err := dp.FibonacciMemo(50); err == nil {
+		fmt.Println("FibonacciMemo(50):", f)
 	}
-
-	for length := 2; length <= n; length++ {
-		for i := 0; i < n-length+1; i++ {
-			j := i + length - 1
-
-			// Secure: bounds checking
-			if i < len(s) && j < len(s) {
-				if s[i] == s[j] {
-					if length == 2 {
-						dp[i][j] = 2
-					} else {
-						dp[i][j] = dp[i+1][j-1] + 2
-					}
-				} else {
-					dp[i][j] = max(dp[i+1][j], dp[i][j-1])
-				}
-			}
-		}
+	grid := [][]bool{
+		{false, false, false, false},
+		{false, true, false, false},
+		{false, false, false, true},
+		{false, false, false, false},
 	}
-
-	return dp[0][n-1]
-}
-
-// RodCutting solves rod cutting problem
-// Time Complexity: O(n²), Space Complexity: O(n)
-func RodCutting(prices []int, n int) int {
-	// Secure: validate input
-	if n <= 0 || len(prices) == 0 {
-		return 0
-	}
-
-	// Secure: validate prices
-	for _, price := range prices {
-		if price < 0 {
-			return 0
-		}
+	if paths, err := dp.GridPaths(grid); err == nil {
+		fmt.Printf("Grid paths around 2 blocked cells in a 4x4 grid: %d\n", paths)
 	}
-
-	// DP table
-	dp := make([]int, n+1)
-
-	for i := 1; i <= n; i++ {
-		maxVal := 0
-		for j := 0; j < i; j++ {
-			// Secure: bounds checking
-			if j < len(prices) {
-				maxVal = max(maxVal, prices[j]+dp[i-j-1])
-			}
-		}
-		dp[i] = maxVal
+	jobs := []dp.Job{{Start: 1, End: 3, Weight: 5}, {Start: 2, End: 5, Weight: 6}, {Start: 4, End: 6, Weight: 5},
+		{Start: 6, End: 7, Weight: 4}, {Start: 5, End: 8, Weight: 11}, {Start: 7, End: 9, Weight: 2}}
+	if weight, taken, err := dp.WeightedIntervalScheduling(jobs); err == nil {
+		fmt.Printf("Weighted interval scheduling: weight %d, taking jobs %v\n", weight, taken)
 	}
 
-	return dp[n]
+	// A bounded Memo evicts the least recently used entries
+	cache := dp.NewMemo[string, int](2)
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	cache.Get("a")
+	cache.Put("c", 3)
+	_, hasB := cache.Get("b")
+	fmt.Printf("Memo of 2 after using a and adding c: holds b = %v\n", hasB)
 }
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"sort"
+	"strings"
+
+	"hellogolang/Algorithms/compress"
+	"hellogolang/Algorithms/graphs"
+	"hellogolang/Algorithms/intervals"
 )
 
 // Greedy Algorithms - Comprehensive implementations of greedy algorithms
@@ -19,6 +25,28 @@ func demonstrateGreedyAlgorithms() {
 	selected := ActivitySelection(activities)
 	fmt.Println("Activity Selection:", selected)
 
+	// Interval algorithms from the intervals package: merging, weighted
+	// scheduling, stabbing queries and the area of a union of rectangles
+	meetings := []intervals.Interval{{Start: 1, End: 3}, {Start: 2, End: 6}, {Start: 8, End: 10}, {Start: 15, End: 18}}
+	fmt.Println("Merged intervals:", intervals.Merge(meetings))
+	weighted := []intervals.WeightedInterval{
+		{Interval: intervals.Interval{Start: 1, End: 4}, Weight: 5},
+		{Interval: intervals.Interval{Start: 3, End: 5}, Weight: 1},
+		{Interval: intervals.Interval{Start: 0, End: 6}, Weight: 8},
+		{Interval: intervals.Interval{Start: 4, End: 7}, Weight: 4},
+	}
+	weight, taken := intervals.MaxWeightSchedule(weighted)
+	fmt.Printf("Weighted interval schedule: weight %d, taking %v\n", weight, taken)
+	tree := intervals.NewTree(meetings)
+	fmt.Println("Intervals containing 2:", tree.Stab(2))
+	rects := []intervals.Rect{
+		{X: intervals.Interval{Start: 0, End: 2}, Y: intervals.Interval{Start: 0, End: 2}},
+		{X: intervals.Interval{Start: 1, End: 3}, Y: intervals.Interval{Start: 1, End: 3}},
+	}
+	if area, err := intervals.UnionArea(rects); err == nil {
+		fmt.Println("Union area of two overlapping squares:", area)
+	}
+
 	// Fractional Knapsack
 	items := []Item{
 		{60, 10}, {100, 20}, {120, 30},
@@ -39,6 +67,68 @@ func demonstrateGreedyAlgorithms() {
 	amount := 67
 	change := MinimumCoinChange(coins, amount)
 	fmt.Printf("Minimum coins for %d: %v\n", amount, change)
+
+	// Huffman coding from the compress package: frequent bytes get short
+	// codes, and the code lengths alone describe the canonical code
+	message := "abracadabra"
+	freq := make([]int, 256)
+	for i := range len(message) {
+		freq[message[i]]++
+	}
+	if lengths, err := compress.CodeLengths(freq, 15); err == nil {
+		if codes, err := compress.CanonicalCodes(lengths); err == nil {
+			fmt.Print("Huffman codes for abracadabra:")
+			for _, c := range "abcdr" {
+				fmt.Printf(" %c=%0*b", c, codes[c].Len, codes[c].Bits)
+			}
+			fmt.Println()
+		}
+	}
+	text := strings.Repeat("she sells sea shells by the sea shore. ", 100)
+	var packed, unpacked bytes.Buffer
+	if err := compress.HuffmanEncode(&packed, strings.NewReader(text)); err == nil {
+		fmt.Printf("Huffman compressed %d bytes to %d", len(text), packed.Len())
+		if err := compress.HuffmanDecode(&unpacked, &packed); err == nil {
+			fmt.Printf(", round trip intact: %v", unpacked.String() == text)
+		}
+		fmt.Println()
+	}
+
+	// A compression pipeline: LZSS replaces repeats with references back
+	// into a window, and Huffman coding then shortens what remains
+	var events strings.Builder
+	actions := []string{"added", "removed", "viewed"}
+	for i := range 300 {
+		fmt.Fprintf(&events, "event %d: user %d %s item %d\n", i, i*7%13, actions[i*i%3], i*31%97)
+	}
+	log := events.String()
+	var lz, stacked, restored bytes.Buffer
+	if err := compress.LZSSEncode(&lz, strings.NewReader(log), compress.LZSSOptions{WindowSize: 4096}); err == nil {
+		fmt.Printf("LZSS compressed a %d-byte log to %d", len(log), lz.Len())
+		if err := compress.HuffmanEncode(&stacked, bytes.NewReader(lz.Bytes())); err == nil {
+			fmt.Printf(", and Huffman coding its output to %d", stacked.Len())
+		}
+		if err := compress.LZSSDecode(&restored, &lz); err == nil {
+			fmt.Printf(", round trip intact: %v", restored.String() == log)
+		}
+		fmt.Println()
+	}
+	var rle bytes.Buffer
+	runs := strings.Repeat("a", 50) + strings.Repeat("b", 30) + "cdefg" + strings.Repeat("h", 20)
+	if err := compress.RLEEncode(&rle, strings.NewReader(runs)); err == nil {
+		fmt.Printf("Run-length encoded %d bytes of runs to %d\n", len(runs), rle.Len())
+	}
+
+	// Kruskal's MST
+	graph := graphs.NewUndirected[int](4)
+	graph.AddEdge(0, 1, 10)
+	graph.AddEdge(0, 2, 6)
+	graph.AddEdge(0, 3, 5)
+	graph.AddEdge(1, 3, 15)
+	graph.AddEdge(2, 3, 4)
+	if tree, err := graph.Kruskal(); err == nil {
+		fmt.Printf("Kruskal's MST: %v (weight %d)\n", tree.Edges, tree.Weight)
+	}
 }
 
 // Activity represents an activity with start and finish time
@@ -251,138 +341,3 @@ func MinimumCoinChange(coins []int, amount int) []int {
 
 	return result
 }
-
-// HuffmanNode represents a node in Huffman tree
-type HuffmanNode struct {
-	Char   rune
-	Freq   int
-	Left   *HuffmanNode
-	Right  *HuffmanNode
-	IsLeaf bool
-}
-
-// HuffmanCoding implements Huffman coding algorithm
-// Time Complexity: O(n log n), Space Complexity: O(n)
-func HuffmanCoding(freq map[rune]int) *HuffmanNode {
-	// Secure: validate input
-	if len(freq) == 0 {
-		return nil
-	}
-
-	// Secure: validate frequencies
-	for char, f := range freq {
-		if f < 0 {
-			delete(freq, char)
-		}
-	}
-
-	if len(freq) == 0 {
-		return nil
-	}
-
-	// Create priority queue (simplified with slice)
-	nodes := []*HuffmanNode{}
-	for char, f := range freq {
-		nodes = append(nodes, &HuffmanNode{
-			Char:   char,
-			Freq:   f,
-			IsLeaf: true,
-		})
-	}
-
-	// Build Huffman tree
-	for len(nodes) > 1 {
-		// Sort by frequency
-		sort.Slice(nodes, func(i, j int) bool {
-			return nodes[i].Freq < nodes[j].Freq
-		})
-
-		// Take two nodes with minimum frequency
-		left := nodes[0]
-		right := nodes[1]
-		nodes = nodes[2:]
-
-		// Create new internal node
-		merged := &HuffmanNode{
-			Freq:   left.Freq + right.Freq,
-			Left:   left,
-			Right:  right,
-			IsLeaf: false,
-		}
-
-		nodes = append(nodes, merged)
-	}
-
-	if len(nodes) == 0 {
-		return nil
-	}
-
-	return nodes[0]
-}
-
-// KruskalMST finds Minimum Spanning Tree using Kruskal's algorithm
-// Time Complexity: O(E log E), Space Complexity: O(V)
-func KruskalMST(edges []Edge, vertices int) []Edge {
-	// Secure: validate input
-	if vertices <= 0 || len(edges) == 0 {
-		return nil
-	}
-
-	// Secure: validate edges
-	for _, edge := range edges {
-		if edge.From < 0 || edge.To < 0 || edge.From >= vertices || edge.To >= vertices {
-			return nil
-		}
-		if edge.Weight < 0 {
-			return nil
-		}
-	}
-
-	// Sort edges by weight
-	sort.Slice(edges, func(i, j int) bool {
-		return edges[i].Weight < edges[j].Weight
-	})
-
-	// Union-Find data structure
-	parent := make([]int, vertices)
-	for i := range parent {
-		parent[i] = i
-	}
-
-	var findFunc func(int) int
-	findFunc = func(x int) int {
-		if parent[x] != x {
-			parent[x] = findFunc(parent[x]) // Path compression
-		}
-		return parent[x]
-	}
-
-	union := func(x, y int) bool {
-		px, py := findFunc(x), findFunc(y)
-		if px == py {
-			return false // Cycle detected
-		}
-		parent[px] = py
-		return true
-	}
-
-	result := []Edge{}
-
-	for _, edge := range edges {
-		if union(edge.From, edge.To) {
-			result = append(result, edge)
-			if len(result) == vertices-1 {
-				break
-			}
-		}
-	}
-
-	return result
-}
-
-// Edge represents an edge with from, to, and weight
-type Edge struct {
-	From   int
-	To     int
-	Weight int
-}
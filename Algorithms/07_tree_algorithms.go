@@ -2,9 +2,15 @@ package main
 
 import (
 	"fmt"
+	"math"
+
+	"hellogolang/Algorithms/datastructures"
+	"hellogolang/Algorithms/trees"
 )
 
-// Tree Algorithms - Comprehensive implementations of tree algorithms
+// Tree Algorithms - Comprehensive implementations of tree algorithms, with
+// balanced search trees from the trees package and ordered containers from
+// the datastructures package
 
 func main() {
 	demonstrateTreeAlgorithms()
@@ -42,6 +48,82 @@ func demonstrateTreeAlgorithms() {
 	fmt.Print("After deleting 20, Inorder: ")
 	root.Inorder()
 	fmt.Println()
+
+	// Sorted insertion degenerates an unbalanced BST into a list, while
+	// balanced trees stay logarithmic
+	bst := &TreeNode{Value: 0}
+	avl := trees.NewAVL[int, string]()
+	rb := trees.NewRedBlack[int, string]()
+	for i := range 1000 {
+		bst.Insert(i)
+		avl.Insert(i, fmt.Sprint(i))
+		rb.Insert(i, fmt.Sprint(i))
+	}
+	fmt.Printf("Height after 1000 sorted inserts: BST %d, AVL %d, red-black %d\n", bst.Height()+1, avl.Height(), rb.Height())
+	for i := 0; i < 1000; i += 2 {
+		avl.Delete(i)
+		rb.Delete(i)
+	}
+	fmt.Printf("After deleting the even keys: %d keys, AVL valid: %v, red-black valid: %v\n", rb.Len(), avl.Check() == nil, rb.Check() == nil)
+
+	// An ordered map with floor, ceiling and range scans
+	scores := trees.NewMap[string, int]()
+	for name, score := range map[string]int{"carol": 88, "alice": 92, "erin": 75, "bob": 67, "dave": 81} {
+		scores.Insert(name, score)
+	}
+	fmt.Print("Scores in name order:")
+	for name, score := range scores.All() {
+		fmt.Printf(" %s=%d", name, score)
+	}
+	fmt.Println()
+	if name, _, ok := scores.Floor("cat"); ok {
+		fmt.Printf("Floor(cat): %s\n", name)
+	}
+	fmt.Print("Names in [b, d):")
+	for name := range scores.Range("b", "d") {
+		fmt.Printf(" %s", name)
+	}
+	fmt.Println()
+
+	// A B-tree keeps up to 2·degree-1 keys per node, so it stays shallow
+	btree := datastructures.NewBTree[int, int](16)
+	skip := datastructures.NewSkipList[int, int]()
+	for i := range 100000 {
+		btree.Insert(i, i*i)
+		skip.Insert(i, i*i)
+	}
+	fmt.Printf("B-tree of degree 16 with %d keys has height %d\n", btree.Len(), btree.Height())
+	fmt.Print("B-tree squares in [500, 505):")
+	for k, v := range btree.Range(500, 505) {
+		fmt.Printf(" %d=%d", k, v)
+	}
+	fmt.Println()
+	fmt.Print("Skip list keys in [99997, 200000):")
+	for k := range skip.Range(99997, 200000) {
+		fmt.Printf(" %d", k)
+	}
+	fmt.Println()
+
+	// Segment trees answer range queries under any monoid while values change
+	readings := []int{5, 3, 8, 6, 1, 9, 2, 7}
+	maximum := datastructures.Monoid[int]{Identity: math.MinInt, Combine: func(a, b int) int { return max(a, b) }}
+	peaks := datastructures.NewSegmentTree(readings, maximum)
+	if v, err := peaks.Query(2, 6); err == nil {
+		fmt.Printf("Max of readings[2:6]: %d\n", v)
+	}
+	peaks.Set(3, 12)
+	if v, err := peaks.Query(2, 6); err == nil {
+		fmt.Printf("After setting readings[3] to 12: %d\n", v)
+	}
+	lazy := datastructures.NewLazySegmentTree(readings, datastructures.Sum[int](), datastructures.RangeAdd[int]())
+	lazy.Update(0, 4, 10)
+	if v, err := lazy.Query(2, 6); err == nil {
+		fmt.Printf("Sum of readings[2:6] after adding 10 to [0, 4): %d\n", v)
+	}
+	fenwick := datastructures.FenwickFrom(readings)
+	if v, err := fenwick.RangeSum(2, 6); err == nil {
+		fmt.Printf("Fenwick sum of readings[2:6]: %d, first prefix reaching 20 ends at %d\n", v, fenwick.Search(20))
+	}
 }
 
 // TreeNode represents a node in binary tree
@@ -126,6 +208,11 @@ func (n *TreeNode) Delete(value int) *TreeNode {
 
 // MinValue finds minimum value node
 func (n *TreeNode) MinValue() *TreeNode {
+	// Secure: validate input
+	if n == nil {
+		return nil
+	}
+
 	current := n
 	for current.Left != nil {
 		current = current.Left
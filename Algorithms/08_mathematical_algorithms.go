@@ -3,9 +3,16 @@ package main
 import (
 	"fmt"
 	"math"
+	"math/big"
+
+	"hellogolang/Algorithms/matrix"
+	"hellogolang/Algorithms/numtheory"
 )
 
-// Mathematical Algorithms - Comprehensive implementations of mathematical algorithms
+// Mathematical Algorithms - Comprehensive implementations of mathematical
+// algorithms, with primes, factorization, modular arithmetic and exact
+// combinatorics from the numtheory package, and linear algebra from the
+// matrix package
 
 func main() {
 	demonstrateMathematicalAlgorithms()
@@ -16,22 +23,100 @@ func demonstrateMathematicalAlgorithms() {
 	fmt.Printf("GCD(48, 18): %d\n", GCD(48, 18))
 	fmt.Printf("LCM(12, 18): %d\n", LCM(12, 18))
 
-	// Prime numbers
-	fmt.Printf("Is 17 prime: %t\n", IsPrime(17))
-	fmt.Printf("Primes up to 30: %v\n", SieveOfEratosthenes(30))
+	// Prime numbers: Miller-Rabin decides any 64-bit number
+	fmt.Printf("Is 17 prime: %t\n", numtheory.IsPrime(17))
+	fmt.Printf("Is 2^61-1 prime: %t\n", numtheory.IsPrime(1<<61-1))
+	if primes, err := numtheory.Sieve(30); err == nil {
+		fmt.Printf("Primes up to 30: %v\n", primes)
+	}
+	// A segmented sieve needs no memory for the numbers below the range
+	if primes, err := numtheory.PrimesInRange(1_000_000_000_000, 1_000_000_000_100); err == nil {
+		fmt.Printf("Primes in [10^12, 10^12+100]: %v\n", primes)
+	}
 
-	// Factorial
-	fmt.Printf("Factorial(5): %d\n", Factorial(5))
+	// Factorization by Pollard's rho, and Euler's totient
+	if factors, err := numtheory.Factorize(600851475143); err == nil {
+		fmt.Printf("Factors of 600851475143: %v\n", factors)
+	}
+	if factors, err := numtheory.Factorize(18446744073709551615); err == nil {
+		fmt.Printf("Factors of 2^64-1: %v\n", factors)
+	}
+	if phi, err := numtheory.Totient(36); err == nil {
+		fmt.Printf("Totient(36): %d\n", phi)
+	}
 
 	// Power
 	fmt.Printf("Power(2, 10): %d\n", Power(2, 10))
 
-	// Fibonacci
+	// Fibonacci, iteratively and in O(log n) by matrix exponentiation
 	fmt.Printf("Fibonacci(10): %d\n", Fibonacci(10))
+	if f, err := matrix.Recurrence([]int{1, 1}, []int{0, 1}, 92); err == nil {
+		fmt.Printf("Fibonacci(92) by matrix power: %d\n", f)
+	}
+	if _, err := matrix.Recurrence([]int{1, 1}, []int{0, 1}, 93); err != nil {
+		fmt.Printf("Fibonacci(93): %v\n", err)
+	}
+	if f, err := matrix.RecurrenceMod([]int{1, 1}, []int{0, 1}, 1_000_000_000_000_000_000, 1_000_000_007); err == nil {
+		fmt.Printf("Fibonacci(10^18) mod 1e9+7: %d\n", f)
+	}
+
+	// Linear systems: 2x + y - z = 8, -3x - y + 2z = -11, -2x + y + 2z = -3
+	if a, err := matrix.FromRows([][]float64{{2, 1, -1}, {-3, -1, 2}, {-2, 1, 2}}); err == nil {
+		if x, err := a.Solve([]float64{8, -11, -3}); err == nil {
+			fmt.Printf("Solution of the 3x3 system: %.4g\n", x)
+		}
+		if det, err := a.Det(); err == nil {
+			fmt.Printf("Its determinant: %.4g\n", det)
+		}
+		if _, err := a.Mul(matrix.New(2, 2)); err != nil {
+			fmt.Printf("3x3 times 2x2: %v\n", err)
+		}
+	}
+	if a, err := matrix.IntFromRows([][]int{{3, 1, 4}, {1, 5, 9}, {2, 6, 5}}); err == nil {
+		if det, err := a.Det(); err == nil {
+			fmt.Printf("Exact determinant of [[3 1 4] [1 5 9] [2 6 5]]: %d\n", det)
+		}
+	}
+
+	// Exact combinatorics on big integers, past the range of int
+	if f, err := numtheory.Factorial(25); err == nil {
+		fmt.Printf("Factorial(25): %v\n", f)
+	}
+	if p, err := numtheory.Permutations(5, 3); err == nil {
+		fmt.Printf("Permutations(5, 3): %v\n", p)
+	}
+	if c, err := numtheory.Combinations(100, 50); err == nil {
+		fmt.Printf("Combinations(100, 50): %v\n", c)
+	}
+	if c, err := numtheory.Catalan(30); err == nil {
+		fmt.Printf("Catalan(30): %v\n", c)
+	}
 
-	// Permutations and Combinations
-	fmt.Printf("Permutations(5, 3): %d\n", Permutations(5, 3))
-	fmt.Printf("Combinations(5, 3): %d\n", Combinations(5, 3))
+	// Multiplication by FFT: (1 + 2x + 3x^2)(4 + 5x) and 2^200 squared
+	fmt.Printf("(1 + 2x + 3x^2)(4 + 5x): %.4g\n", numtheory.MultiplyPolynomials([]float64{1, 2, 3}, []float64{4, 5}))
+	if p, err := numtheory.MultiplyPolynomialsMod([]uint64{1, 2, 3}, []uint64{4, 5}); err == nil {
+		fmt.Printf("The same product by NTT: %v\n", p)
+	}
+	if p, err := numtheory.MultiplyBig(new(big.Int).Lsh(big.NewInt(1), 200), new(big.Int).Lsh(big.NewInt(1), 200)); err == nil {
+		fmt.Printf("(2^200)^2 has %d bits\n", p.BitLen())
+	}
+
+	// Modular arithmetic
+	if p, err := numtheory.PowMod(3, 1<<62, 1_000_000_007); err == nil {
+		fmt.Printf("3^(2^62) mod 1e9+7: %d\n", p)
+	}
+	if inv, err := numtheory.ModInverse(17, 3120); err == nil {
+		fmt.Printf("ModInverse(17, 3120): %d\n", inv)
+	}
+	if _, err := numtheory.ModInverse(12, 18); err != nil {
+		fmt.Printf("ModInverse(12, 18): %v\n", err)
+	}
+	if x, lcm, err := numtheory.CRT([]uint64{2, 3, 2}, []uint64{3, 5, 7}); err == nil {
+		fmt.Printf("x = 2 mod 3, 3 mod 5, 2 mod 7: x = %d mod %d\n", x, lcm)
+	}
+	if x, err := numtheory.DiscreteLog(5, 3_001, 1_000_000_007); err == nil {
+		fmt.Printf("5^x = 3001 mod 1e9+7: x = %d\n", x)
+	}
 }
 
 // GCD calculates Greatest Common Divisor using Euclidean algorithm
@@ -103,101 +188,6 @@ func LCM(a, b int) int {
 	return (a / gcd) * b
 }
 
-// IsPrime checks if number is prime
-// Time Complexity: O(√n), Space Complexity: O(1)
-func IsPrime(n int) bool {
-	// Secure: validate input
-	if n < 2 {
-		return false
-	}
-	if n == 2 {
-		return true
-	}
-	if n%2 == 0 {
-		return false
-	}
-
-	// Check up to √n
-	sqrt := int(math.Sqrt(float64(n)))
-	for i := 3; i <= sqrt; i += 2 {
-		if n%i == 0 {
-			return false
-		}
-	}
-
-	return true
-}
-
-// SieveOfEratosthenes finds all primes up to n
-// Time Complexity: O(n log log n), Space Complexity: O(n)
-func SieveOfEratosthenes(n int) []int {
-	// Secure: validate input
-	if n < 2 {
-		return nil
-	}
-
-	// Create boolean array
-	isPrime := make([]bool, n+1)
-	for i := 2; i <= n; i++ {
-		isPrime[i] = true
-	}
-
-	// Sieve
-	for p := 2; p*p <= n; p++ {
-		if isPrime[p] {
-			for i := p * p; i <= n; i += p {
-				isPrime[i] = false
-			}
-		}
-	}
-
-	// Collect primes
-	primes := []int{}
-	for i := 2; i <= n; i++ {
-		if isPrime[i] {
-			primes = append(primes, i)
-		}
-	}
-
-	return primes
-}
-
-// Factorial calculates factorial
-// Time Complexity: O(n), Space Complexity: O(1)
-func Factorial(n int) int {
-	// Secure: validate input
-	if n < 0 {
-		return 0
-	}
-	if n == 0 || n == 1 {
-		return 1
-	}
-
-	result := 1
-	for i := 2; i <= n; i++ {
-		// Secure: prevent integer overflow (simplified check)
-		if result > math.MaxInt32/i {
-			return 0 // Overflow
-		}
-		result *= i
-	}
-
-	return result
-}
-
-// FactorialRecursive calculates factorial recursively
-func FactorialRecursive(n int) int {
-	// Secure: validate input
-	if n < 0 {
-		return 0
-	}
-	if n == 0 || n == 1 {
-		return 1
-	}
-
-	return n * FactorialRecursive(n-1)
-}
-
 // Power calculates base^exponent
 // Time Complexity: O(log n), Space Complexity: O(1)
 func Power(base, exponent int) int {
@@ -255,91 +245,6 @@ func Fibonacci(n int) int {
 	return b
 }
 
-// Permutations calculates nPr = n! / (n-r)!
-// Time Complexity: O(n), Space Complexity: O(1)
-func Permutations(n, r int) int {
-	// Secure: validate input
-	if n < 0 || r < 0 || r > n {
-		return 0
-	}
-
-	result := 1
-	for i := 0; i < r; i++ {
-		// Secure: prevent integer overflow
-		if result > math.MaxInt32/(n-i) {
-			return 0 // Overflow
-		}
-		result *= (n - i)
-	}
-
-	return result
-}
-
-// Combinations calculates nCr = n! / (r! * (n-r)!)
-// Time Complexity: O(min(r, n-r)), Space Complexity: O(1)
-func Combinations(n, r int) int {
-	// Secure: validate input
-	if n < 0 || r < 0 || r > n {
-		return 0
-	}
-
-	// Optimize: C(n, r) = C(n, n-r)
-	if r > n-r {
-		r = n - r
-	}
-
-	result := 1
-	for i := 0; i < r; i++ {
-		// Secure: prevent integer overflow
-		if result > math.MaxInt32/(n-i) {
-			return 0 // Overflow
-		}
-		result = result * (n - i) / (i + 1)
-	}
-
-	return result
-}
-
-// CatalanNumber calculates nth Catalan number
-// Time Complexity: O(n), Space Complexity: O(1)
-func CatalanNumber(n int) int {
-	// Secure: validate input
-	if n < 0 {
-		return 0
-	}
-
-	// C(n) = (2n)! / ((n+1)! * n!)
-	return Combinations(2*n, n) / (n + 1)
-}
-
-// ModularExponentiation calculates (base^exponent) % mod
-// Time Complexity: O(log exponent), Space Complexity: O(1)
-func ModularExponentiation(base, exponent, mod int) int {
-	// Secure: validate input
-	if mod <= 0 {
-		return 0
-	}
-	if exponent < 0 {
-		return 0
-	}
-	if exponent == 0 {
-		return 1
-	}
-
-	result := 1
-	base = base % mod
-
-	for exponent > 0 {
-		if exponent%2 == 1 {
-			result = (result * base) % mod
-		}
-		exponent /= 2
-		base = (base * base) % mod
-	}
-
-	return result
-}
-
 // ExtendedGCD calculates GCD and coefficients using Extended Euclidean algorithm
 // Returns: gcd, x, y such that ax + by = gcd(a, b)
 func ExtendedGCD(a, b int) (int, int, int) {
@@ -354,20 +259,3 @@ func ExtendedGCD(a, b int) (int, int, int) {
 
 	return gcd, x, y
 }
-
-// ModularInverse calculates modular multiplicative inverse
-// Returns x such that (a * x) % m = 1
-func ModularInverse(a, m int) int {
-	// Secure: validate input
-	if m <= 0 {
-		return 0
-	}
-
-	gcd, x, _ := ExtendedGCD(a, m)
-	if gcd != 1 {
-		return 0 // Inverse doesn't exist
-	}
-
-	// Make x positive
-	return (x%m + m) % m
-}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"hellogolang/Algorithms/geometry"
+)
+
+// Computational Geometry - Demonstrates the geometry package, which works on
+// integer points so that collinear and touching cases are decided exactly
+
+func main() {
+	demonstrateComputationalGeometry()
+}
+
+func demonstrateComputationalGeometry() {
+	points := []geometry.Point{
+		{X: 0, Y: 3}, {X: 1, Y: 1}, {X: 2, Y: 2}, {X: 4, Y: 4}, {X: 0, Y: 0},
+		{X: 1, Y: 2}, {X: 3, Y: 1}, {X: 3, Y: 3}, {X: 2, Y: 0}, {X: 4, Y: 0},
+	}
+
+	// Convex Hull (Andrew's monotone chain), with and without the points in
+	// the middle of hull edges
+	hull := geometry.ConvexHull(points)
+	fmt.Println("Convex Hull:", hull)
+	fmt.Println("Convex Hull boundary points:", geometry.ConvexHullBoundary(points))
+	fmt.Println("Hull area:", float64(geometry.Area2(hull))/2)
+
+	// Closest Pair of Points
+	if i, j, dist2, ok := geometry.ClosestPair(points); ok {
+		fmt.Printf("Closest Pair: %v and %v, squared distance %d\n", points[i], points[j], dist2)
+	}
+
+	// Segment Intersection, including collinear overlaps
+	segments := [][2]geometry.Segment{
+		{{A: geometry.Point{X: 0, Y: 0}, B: geometry.Point{X: 1, Y: 3}}, {A: geometry.Point{X: 0, Y: 1}, B: geometry.Point{X: 3, Y: 0}}},
+		{{A: geometry.Point{X: 0, Y: 0}, B: geometry.Point{X: 4, Y: 0}}, {A: geometry.Point{X: 6, Y: 0}, B: geometry.Point{X: 2, Y: 0}}},
+		{{A: geometry.Point{X: 0, Y: 0}, B: geometry.Point{X: 2, Y: 0}}, {A: geometry.Point{X: 3, Y: 0}, B: geometry.Point{X: 5, Y: 0}}},
+	}
+	for _, pair := range segments {
+		if x, y, ok := pair[0].Intersection(pair[1]); ok {
+			fmt.Printf("Segments %v and %v meet at (%.4g, %.4g)\n", pair[0], pair[1], x, y)
+		} else {
+			fmt.Printf("Segments %v and %v do not meet\n", pair[0], pair[1])
+		}
+	}
+
+	// Point in Polygon, for a concave polygon
+	polygon := []geometry.Point{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}, {X: 2, Y: 2}, {X: 0, Y: 4}}
+	for _, p := range []geometry.Point{{X: 1, Y: 1}, {X: 2, Y: 3}, {X: 3, Y: 3}, {X: 4, Y: 2}} {
+		fmt.Printf("Point %v relative to the polygon: %v\n", p, geometry.PointInPolygon(p, polygon))
+	}
+}
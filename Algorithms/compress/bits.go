@@ -0,0 +1,146 @@
+// Package compress provides lossless compression algorithms over byte
+// streams, with the pieces they are built from: bit-level readers and
+// writers and canonical Huffman codes. Besides its own formats, each
+// self-describing, starting with a magic number and carrying what its
+// decoder needs, it reads and writes the standard DEFLATE and gzip
+// formats. Decoders treat their input as untrusted: malformed data is
+// reported with ErrCorrupt rather than causing a panic or an allocation
+// sized by a length read from the stream.
+package compress
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrCorrupt is returned when compressed data is malformed or truncated
+	ErrCorrupt = errors.New("corrupt compressed data")
+	// ErrBitCount is returned when a bit reader or writer is asked for a
+	// number of bits outside [0, MaxBits]
+	ErrBitCount = errors.New("invalid bit count")
+	// ErrCodeLength is returned when code lengths do not describe a prefix
+	// code, or no prefix code of the requested maximum length exists
+	ErrCodeLength = errors.New("invalid code lengths")
+)
+
+// MaxBits is the most bits read or written in one call
+const MaxBits = 56
+
+// BitWriter writes bits to an io.Writer, packing them into each byte from
+// the least significant bit up, the order DEFLATE uses. Bits are buffered;
+// Flush writes them out.
+type BitWriter struct {
+	w   io.Writer
+	acc uint64 // Pending bits, the oldest lowest
+	n   int    // Number of pending bits, below 8 between calls
+	buf []byte
+	err error // First write error, returned from then on
+}
+
+// NewBitWriter creates a BitWriter writing to w
+func NewBitWriter(w io.Writer) *BitWriter {
+	return &BitWriter{w: w, buf: make([]byte, 0, 4096)}
+}
+
+// WriteBits writes the low n bits of v, least significant first
+func (b *BitWriter) WriteBits(v uint64, n int) error {
+	// Secure: bound n so the accumulator cannot overflow
+	if n < 0 || n > MaxBits {
+		return fmt.Errorf("%w: %d", ErrBitCount, n)
+	}
+	if b.err != nil {
+		return b.err
+	}
+	b.acc |= (v & (1<<n - 1)) << b.n
+	for b.n += n; b.n >= 8; b.n -= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+	}
+	if len(b.buf) == cap(b.buf) {
+		return b.writeOut()
+	}
+	return nil
+}
+
+// WriteCode writes a Huffman code, most significant bit first, as DEFLATE
+// sends codes so that a decoder can follow them one bit at a time
+func (b *BitWriter) WriteCode(c Code) error {
+	return b.WriteBits(uint64(reverseBits(c.Bits, c.Len)), int(c.Len))
+}
+
+// Flush pads the bits written so far with zeros to a whole byte and writes
+// out everything buffered
+func (b *BitWriter) Flush() error {
+	if b.err != nil {
+		return b.err
+	}
+	if b.n > 0 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc, b.n = 0, 0
+	}
+	return b.writeOut()
+}
+
+// writeOut writes the buffered bytes to w
+func (b *BitWriter) writeOut() error {
+	if _, err := b.w.Write(b.buf); err != nil {
+		b.err = err
+		return err
+	}
+	b.buf = b.buf[:0]
+	return nil
+}
+
+// BitReader reads bits written by a BitWriter. It reads its source a byte
+// at a time and never beyond the byte holding the last bit asked for, so
+// after Align the source is positioned just past the bits read.
+type BitReader struct {
+	r   io.ByteReader
+	acc uint64 // Bits read from r but not yet returned, the oldest lowest
+	n   int
+}
+
+// NewBitReader creates a BitReader reading from r, directly if it is an
+// io.ByteReader and through a bufio.Reader otherwise
+func NewBitReader(r io.Reader) *BitReader {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &BitReader{r: br}
+}
+
+// ReadBits reads n bits and returns them as the low bits of the result,
+// the first read lowest. It returns io.EOF if the source ends before the
+// first bit and io.ErrUnexpectedEOF if it ends after it.
+func (b *BitReader) ReadBits(n int) (uint64, error) {
+	// Secure: bound n so the accumulator cannot overflow
+	if n < 0 || n > MaxBits {
+		return 0, fmt.Errorf("%w: %d", ErrBitCount, n)
+	}
+	for b.n < n {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && b.n > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		b.acc |= uint64(c) << b.n
+		b.n += 8
+	}
+	v := b.acc & (1<<n - 1)
+	b.acc >>= n
+	b.n -= n
+	return v, nil
+}
+
+// Align discards the bits left in the current byte, so that the next read
+// starts on a byte boundary
+func (b *BitReader) Align() {
+	b.acc >>= b.n % 8
+	b.n -= b.n % 8
+}
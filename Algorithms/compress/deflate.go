@@ -0,0 +1,393 @@
+package compress
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// DEFLATE (RFC 1951) constants
+const (
+	deflateWindow     = 1 << 15 // Matches reach at most 32 KiB back
+	deflateMaxLength  = 15      // Longest literal/length and distance code
+	deflateMaxCLength = 7       // Longest code of the code length alphabet
+	deflateEndOfBlock = 256
+	deflateMaxStored  = 1<<16 - 1 // Most bytes in a stored block
+	deflateMaxTokens  = 1 << 14   // Most literals and matches in a block
+	deflateNumLitLen  = 286       // Literal/length symbols in use; 286 and 287 are invalid
+	deflateNumDist    = 30        // Distance symbols in use; 30 and 31 are invalid
+)
+
+// Block types, the BTYPE field of a block header
+const (
+	blockStored  = 0
+	blockFixed   = 1
+	blockDynamic = 2
+)
+
+// Length symbols 257..285 stand for a base length plus extra bits, and
+// distance symbols 0..29 for a base distance plus extra bits
+var (
+	lengthBase = [29]int{
+		3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31,
+		35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258,
+	}
+	lengthExtra = [29]int{
+		0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2,
+		3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0,
+	}
+	distanceBase = [30]int{
+		1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193,
+		257, 385, 513, 769, 1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577,
+	}
+	distanceExtra = [30]int{
+		0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6,
+		7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13,
+	}
+	// codeLengthOrder is the order code length code lengths are sent in,
+	// the ones most likely to be 0 last so they can be left out
+	codeLengthOrder = [19]int{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+)
+
+// lengthCode returns the index into lengthBase of the symbol for a match
+// length of 3 to 258
+func lengthCode(length int) int {
+	return sort.Search(len(lengthBase), func(i int) bool { return lengthBase[i] > length }) - 1
+}
+
+// distanceCode returns the distance symbol for a distance of 1 to 32768
+func distanceCode(distance int) int {
+	return sort.Search(len(distanceBase), func(i int) bool { return distanceBase[i] > distance }) - 1
+}
+
+// fixedLengths returns the code lengths of the fixed Huffman codes: 8 or 9
+// bits for literals, 7 or 8 for lengths and 5 for every distance
+func fixedLengths() (litLen, dist []uint8) {
+	litLen = make([]uint8, 288)
+	for s := range litLen {
+		switch {
+		case s < 144:
+			litLen[s] = 8
+		case s < 256:
+			litLen[s] = 9
+		case s < 280:
+			litLen[s] = 7
+		default:
+			litLen[s] = 8
+		}
+	}
+	dist = make([]uint8, 32)
+	for s := range dist {
+		dist[s] = 5
+	}
+	return litLen, dist
+}
+
+// fixedCodes returns the fixed literal/length and distance codes, built on
+// first use
+var fixedCodes = sync.OnceValues(func() ([]Code, []Code) {
+	litLen, dist := fixedLengths()
+	litCodes, _ := CanonicalCodes(litLen)
+	distCodes, _ := CanonicalCodes(dist)
+	return litCodes, distCodes
+})
+
+// DeflateOptions configures DeflateEncode. The zero value searches 64
+// candidates per position.
+type DeflateOptions struct {
+	// MaxChain bounds the earlier positions tried for each match, trading
+	// compression for speed
+	MaxChain int
+}
+
+// deflateToken is a literal byte, when length is 0, or a match of length
+// bytes distance back
+type deflateToken struct {
+	length, value uint16 // value is the literal or the distance
+}
+
+// DeflateEncode compresses the bytes of r to w as a raw DEFLATE stream
+// (RFC 1951), readable by any inflater: zlib, the gzip tool, or
+// compress/flate. Matches of 3 to 258 bytes up to 32 KiB back are found
+// greedily through the hash chains LZSSEncode uses. The resulting literals
+// and matches are cut into blocks of at most 16384 items and 65535 input
+// bytes, and each block is sent in whichever form is smallest: with the
+// fixed Huffman codes of the standard, with dynamic codes built from the
+// block's frequencies and described in its header, or stored uncompressed.
+// Time Complexity: O(n · MaxChain · 258) worst case, typically near O(n)
+// Space Complexity: O(1), under 512 KiB of buffers
+func DeflateEncode(w io.Writer, r io.Reader, opts DeflateOptions) error {
+	if opts.MaxChain == 0 {
+		opts.MaxChain = defaultLZSSChain
+	}
+	if opts.MaxChain < 1 {
+		return fmt.Errorf("deflate: need MaxChain >= 1, have %d", opts.MaxChain)
+	}
+
+	m := newLZSSMatcher(deflateWindow)
+	bw := NewBitWriter(w)
+	tokens := make([]deflateToken, 0, deflateMaxTokens)
+	raw := make([]byte, 0, deflateMaxStored) // The input the tokens stand for
+	for {
+		if err := m.fill(r); err != nil {
+			return err
+		}
+		if m.pos == m.end {
+			break
+		}
+
+		length, distance := m.longestMatch(deflateWindow, opts.MaxChain)
+		token := deflateToken{length: uint16(length), value: uint16(distance)}
+		if length < lzssMinMatch {
+			length = 1
+			token = deflateToken{value: uint16(m.buf[m.pos])}
+		}
+		if len(tokens) == deflateMaxTokens || len(raw)+length > deflateMaxStored {
+			if err := writeBlock(bw, w, tokens, raw, false); err != nil {
+				return err
+			}
+			tokens, raw = tokens[:0], raw[:0]
+		}
+		tokens = append(tokens, token)
+		raw = append(raw, m.buf[m.pos:m.pos+length]...)
+		for range length {
+			m.insert()
+			m.pos++
+		}
+	}
+	if err := writeBlock(bw, w, tokens, raw, true); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// blockCost is the size in bits of the literals and matches of a block
+// coded with the given code lengths, including the end of block. Symbols
+// that do not occur may lie beyond the lengths.
+func blockCost(litFreq, distFreq []int, litLen, dist []uint8, extraBits int) int {
+	cost := extraBits
+	for s, f := range litFreq {
+		if f > 0 {
+			cost += f * int(litLen[s])
+		}
+	}
+	for s, f := range distFreq {
+		if f > 0 {
+			cost += f * int(dist[s])
+		}
+	}
+	return cost
+}
+
+// writeBlock writes one block holding tokens, which stand for raw, in the
+// smallest of the three forms
+func writeBlock(bw *BitWriter, w io.Writer, tokens []deflateToken, raw []byte, final bool) error {
+	litFreq := make([]int, deflateNumLitLen)
+	distFreq := make([]int, deflateNumDist)
+	extraBits := 0
+	for _, t := range tokens {
+		if t.length == 0 {
+			litFreq[t.value]++
+			continue
+		}
+		lc, dc := lengthCode(int(t.length)), distanceCode(int(t.value))
+		litFreq[257+lc]++
+		distFreq[dc]++
+		extraBits += lengthExtra[lc] + distanceExtra[dc]
+	}
+	litFreq[deflateEndOfBlock] = 1
+
+	fixedLit, fixedDist := fixedLengths()
+	fixedCost := 3 + blockCost(litFreq, distFreq, fixedLit, fixedDist, extraBits)
+
+	dyn, err := newDynamicHeader(litFreq, distFreq)
+	if err != nil {
+		return err
+	}
+	dynamicCost := 3 + dyn.cost() + blockCost(litFreq, distFreq, dyn.litLen, dyn.dist, extraBits)
+
+	// A stored block starts on a byte boundary, after up to 7 bits of
+	// padding, with its length and the length's complement
+	storedCost := 3 + 7 + 32 + 8*len(raw)
+
+	finalBit := uint64(0)
+	if final {
+		finalBit = 1
+	}
+	switch {
+	case storedCost < fixedCost && storedCost < dynamicCost:
+		bw.WriteBits(finalBit|blockStored<<1, 3)
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		n := len(raw)
+		header := []byte{byte(n), byte(n >> 8), ^byte(n), ^byte(n >> 8)}
+		_, err := w.Write(append(header, raw...))
+		return err
+	case fixedCost <= dynamicCost:
+		bw.WriteBits(finalBit|blockFixed<<1, 3)
+		litCodes, distCodes := fixedCodes()
+		return writeTokens(bw, tokens, litCodes, distCodes)
+	default:
+		bw.WriteBits(finalBit|blockDynamic<<1, 3)
+		if err := dyn.write(bw); err != nil {
+			return err
+		}
+		litCodes, err := CanonicalCodes(dyn.litLen)
+		if err != nil {
+			return err
+		}
+		distCodes, err := CanonicalCodes(dyn.dist)
+		if err != nil {
+			return err
+		}
+		return writeTokens(bw, tokens, litCodes, distCodes)
+	}
+}
+
+// writeTokens writes the literals and matches of a block and its end
+func writeTokens(bw *BitWriter, tokens []deflateToken, litCodes, distCodes []Code) error {
+	for _, t := range tokens {
+		if t.length == 0 {
+			bw.WriteCode(litCodes[t.value])
+			continue
+		}
+		length, distance := int(t.length), int(t.value)
+		lc, dc := lengthCode(length), distanceCode(distance)
+		bw.WriteCode(litCodes[257+lc])
+		bw.WriteBits(uint64(length-lengthBase[lc]), lengthExtra[lc])
+		bw.WriteCode(distCodes[dc])
+		bw.WriteBits(uint64(distance-distanceBase[dc]), distanceExtra[dc])
+	}
+	// The BitWriter keeps its first error, so checking the last write is enough
+	return bw.WriteCode(litCodes[deflateEndOfBlock])
+}
+
+// dynamicHeader holds the codes of a dynamic block and how its header
+// describes them: the code lengths of both codes run-length coded with
+// symbols 0..18 of the code length alphabet, itself Huffman coded
+type dynamicHeader struct {
+	litLen, dist []uint8
+	runs         []codeLengthRun
+	clLengths    []uint8 // Lengths of the code length code
+	numCL        int     // Code length code lengths sent, in codeLengthOrder
+}
+
+// codeLengthRun is a symbol of the code length alphabet: a length of 0 to
+// 15, or 16 (repeat the previous length 3-6 times), 17 (repeat 0 3-10
+// times) or 18 (repeat 0 11-138 times), with its repeat count less the
+// minimum
+type codeLengthRun struct {
+	symbol, extra uint8
+}
+
+// codeLengthExtra is the number of extra bits after symbols 16, 17 and 18
+var codeLengthExtra = [3]int{2, 3, 7}
+
+// newDynamicHeader builds length-limited Huffman codes for a block's
+// frequencies and the header describing them
+func newDynamicHeader(litFreq, distFreq []int) (*dynamicHeader, error) {
+	h := &dynamicHeader{}
+	var err error
+	if h.litLen, err = CodeLengths(litFreq, deflateMaxLength); err != nil {
+		return nil, err
+	}
+	// A block without matches still sends one distance code
+	hasDistance := false
+	for _, f := range distFreq {
+		hasDistance = hasDistance || f > 0
+	}
+	if !hasDistance {
+		distFreq = []int{1}
+	}
+	if h.dist, err = CodeLengths(distFreq, deflateMaxLength); err != nil {
+		return nil, err
+	}
+
+	// Trailing unused symbols are left out, down to 257 and 1 codes
+	numLit, numDist := len(h.litLen), len(h.dist)
+	for numLit > 257 && h.litLen[numLit-1] == 0 {
+		numLit--
+	}
+	for numDist > 1 && h.dist[numDist-1] == 0 {
+		numDist--
+	}
+	h.litLen, h.dist = h.litLen[:numLit], h.dist[:numDist]
+
+	// Both sets of lengths are run-length coded as one sequence
+	lengths := append(append([]uint8{}, h.litLen...), h.dist...)
+	for i := 0; i < len(lengths); {
+		l := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == l {
+			run++
+		}
+		i += run
+		if l == 0 {
+			for ; run >= 11; run -= min(run, 138) {
+				h.runs = append(h.runs, codeLengthRun{18, uint8(min(run, 138) - 11)})
+			}
+			if run >= 3 {
+				h.runs = append(h.runs, codeLengthRun{17, uint8(run - 3)})
+				run = 0
+			}
+		} else {
+			h.runs = append(h.runs, codeLengthRun{l, 0})
+			for run--; run >= 3; run -= min(run, 6) {
+				h.runs = append(h.runs, codeLengthRun{16, uint8(min(run, 6) - 3)})
+			}
+		}
+		for ; run > 0; run-- {
+			h.runs = append(h.runs, codeLengthRun{l, 0})
+		}
+	}
+
+	clFreq := make([]int, len(codeLengthOrder))
+	for _, run := range h.runs {
+		clFreq[run.symbol]++
+	}
+	if h.clLengths, err = CodeLengths(clFreq, deflateMaxCLength); err != nil {
+		return nil, err
+	}
+	h.numCL = len(codeLengthOrder)
+	for h.numCL > 4 && h.clLengths[codeLengthOrder[h.numCL-1]] == 0 {
+		h.numCL--
+	}
+	return h, nil
+}
+
+// cost returns the size of the header in bits
+func (h *dynamicHeader) cost() int {
+	cost := 5 + 5 + 4 + 3*h.numCL
+	for _, run := range h.runs {
+		cost += int(h.clLengths[run.symbol])
+		if run.symbol >= 16 {
+			cost += codeLengthExtra[run.symbol-16]
+		}
+	}
+	return cost
+}
+
+// write writes the header: the counts of codes, the code length code
+// lengths in 3 bits each, and the coded lengths
+func (h *dynamicHeader) write(bw *BitWriter) error {
+	bw.WriteBits(uint64(len(h.litLen)-257), 5)
+	bw.WriteBits(uint64(len(h.dist)-1), 5)
+	bw.WriteBits(uint64(h.numCL-4), 4)
+	for _, s := range codeLengthOrder[:h.numCL] {
+		bw.WriteBits(uint64(h.clLengths[s]), 3)
+	}
+	codes, err := CanonicalCodes(h.clLengths)
+	if err != nil {
+		return err
+	}
+	for _, run := range h.runs {
+		bw.WriteCode(codes[run.symbol])
+		if run.symbol >= 16 {
+			bw.WriteBits(uint64(run.extra), codeLengthExtra[run.symbol-16])
+		}
+	}
+	// Write errors are kept by the BitWriter and returned by writeTokens
+	return nil
+}
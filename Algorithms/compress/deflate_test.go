@@ -0,0 +1,198 @@
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// deflateWith returns a DeflateEncode using the given options
+func deflateWith(opts DeflateOptions) func(io.Writer, io.Reader) error {
+	return func(w io.Writer, r io.Reader) error { return DeflateEncode(w, r, opts) }
+}
+
+// stdlibInflate decompresses with compress/flate
+func stdlibInflate(w io.Writer, r io.Reader) error {
+	fr := flate.NewReader(r)
+	defer fr.Close()
+	_, err := io.Copy(w, fr)
+	return err
+}
+
+// stdlibDeflate compresses with compress/flate at the given level
+func stdlibDeflate(level int) func(io.Writer, io.Reader) error {
+	return func(w io.Writer, r io.Reader) error {
+		fw, err := flate.NewWriter(w, level)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(fw, r); err != nil {
+			return err
+		}
+		return fw.Close()
+	}
+}
+
+// TestLengthAndDistanceCodes tests the symbols of the edges of each range
+func TestLengthAndDistanceCodes(t *testing.T) {
+	for length, want := range map[int]int{3: 0, 10: 7, 11: 8, 12: 8, 227: 27, 257: 27, 258: 28} {
+		if got := lengthCode(length); got != want {
+			t.Errorf("lengthCode(%d) = %d, want %d", length, got, want)
+		}
+	}
+	for distance, want := range map[int]int{1: 0, 4: 3, 5: 4, 6: 4, 24576: 28, 24577: 29, 32768: 29} {
+		if got := distanceCode(distance); got != want {
+			t.Errorf("distanceCode(%d) = %d, want %d", distance, got, want)
+		}
+	}
+}
+
+// TestDeflate tests round trips, and that both this package and
+// compress/flate read what the other writes
+func TestDeflate(t *testing.T) {
+	inputs := testInputs()
+	inputs["repetitive"] = repetitive(rand.New(rand.NewPCG(13, 14)), 300000)
+	for name, data := range inputs {
+		t.Run(name, func(t *testing.T) {
+			packed := roundTrip(t, deflateWith(DeflateOptions{}), DeflateDecode, data)
+			if (name == "repeated" || name == "text") && len(packed) > len(data)/10 {
+				t.Errorf("compressed to %d of %d bytes", len(packed), len(data))
+			}
+			// Random data goes into stored blocks, costing 5 bytes in 65535
+			if name == "random" && len(packed) > len(data)+len(data)/1000+5 {
+				t.Errorf("random data grew to %d of %d bytes", len(packed), len(data))
+			}
+			roundTrip(t, deflateWith(DeflateOptions{MaxChain: 1}), stdlibInflate, data)
+			for _, level := range []int{flate.NoCompression, flate.BestSpeed, flate.BestCompression, flate.HuffmanOnly} {
+				roundTrip(t, stdlibDeflate(level), DeflateDecode, data)
+			}
+		})
+	}
+
+	// Reading a byte at a time exercises partial reads in both directions
+	data := inputs["repetitive"][:50000]
+	var packed, out bytes.Buffer
+	if err := DeflateEncode(&packed, iotest.OneByteReader(bytes.NewReader(data)), DeflateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeflateDecode(&out, iotest.OneByteReader(&packed)); err != nil || !bytes.Equal(out.Bytes(), data) {
+		t.Fatalf("byte-at-a-time round trip failed: %v", err)
+	}
+
+	if err := DeflateEncode(io.Discard, strings.NewReader("x"), DeflateOptions{MaxChain: -1}); err == nil {
+		t.Error("DeflateEncode with MaxChain -1 succeeded")
+	}
+}
+
+// TestDeflateBlockTypes tests that the encoder picks fixed codes for short
+// input, dynamic codes for skewed data and stored blocks for noise
+func TestDeflateBlockTypes(t *testing.T) {
+	noise := make([]byte, 1000)
+	rng := rand.New(rand.NewPCG(15, 16))
+	for i := range noise {
+		noise[i] = byte(rng.Uint32())
+	}
+	tests := []struct {
+		data []byte
+		want uint64
+	}{
+		{[]byte("hello, hello"), blockFixed},
+		{testInputs()["skewed"][:5000], blockDynamic},
+		{noise, blockStored},
+	}
+	for _, tt := range tests {
+		var packed bytes.Buffer
+		DeflateEncode(&packed, bytes.NewReader(tt.data), DeflateOptions{})
+		header, _ := NewBitReader(&packed).ReadBits(3)
+		if header != 1|tt.want<<1 {
+			t.Errorf("%.20q: block header %03b, want a final block of type %d", tt.data, header, tt.want)
+		}
+	}
+}
+
+// TestDeflateStopsAtEnd tests that decoding leaves a byte reader just past
+// the stream, for a container to read its trailer
+func TestDeflateStopsAtEnd(t *testing.T) {
+	var stream bytes.Buffer
+	DeflateEncode(&stream, strings.NewReader(strings.Repeat("trailer follows ", 100)), DeflateOptions{})
+	stream.WriteString("TRAILER")
+	r := bytes.NewReader(stream.Bytes())
+	if err := DeflateDecode(io.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+	if rest, _ := io.ReadAll(r); string(rest) != "TRAILER" {
+		t.Errorf("after the stream: %q, want TRAILER", rest)
+	}
+}
+
+// TestDeflateCorrupt tests that malformed streams are rejected with
+// ErrCorrupt, and that damaged streams never panic
+func TestDeflateCorrupt(t *testing.T) {
+	var good bytes.Buffer
+	DeflateEncode(&good, strings.NewReader(strings.Repeat("abcabcabd, dabcab ", 50)), DeflateOptions{})
+	stream := good.Bytes()
+
+	// build writes a stream of bit fields, each a value and its width
+	build := func(fields ...int) []byte {
+		var b bytes.Buffer
+		bw := NewBitWriter(&b)
+		for i := 0; i < len(fields); i += 2 {
+			bw.WriteBits(uint64(fields[i]), fields[i+1])
+		}
+		bw.Flush()
+		return b.Bytes()
+	}
+	litCodes, distCodes := fixedCodes()
+	code := func(c Code) (int, int) { return int(reverseBits(c.Bits, c.Len)), int(c.Len) }
+	a, aLen := code(litCodes['a'])
+	match, matchLen := code(litCodes[257])
+	dist, distLen := code(distCodes[1])
+	cases := map[string][]byte{
+		"empty":          {},
+		"reserved type":  build(1|3<<1, 3),
+		"no final block": build(0|blockFixed<<1, 3, 0, 7),
+		"stored length":  build(1, 3, 0, 5, 5, 16, 5, 16),
+		"stored short":   build(1, 3, 0, 5, 5, 16, 0xfffa, 16, 'a', 8),
+		"far match":      build(1|blockFixed<<1, 3, a, aLen, match, matchLen, dist, distLen, 0, 7),
+		"too many codes": build(1|blockDynamic<<1, 3, 30, 5, 0, 5, 0, 4),
+		"truncated":      stream[:len(stream)-1],
+	}
+	for name, data := range cases {
+		if err := DeflateDecode(io.Discard, bytes.NewReader(data)); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: error = %v, want ErrCorrupt", name, err)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(17, 18))
+	for range 2000 {
+		damaged := slices.Clone(stream)
+		damaged[rng.IntN(len(damaged))] ^= byte(1 + rng.IntN(255))
+		DeflateDecode(io.Discard, bytes.NewReader(damaged))
+	}
+}
+
+// BenchmarkDeflateEncode measures compressing repetitive data
+func BenchmarkDeflateEncode(b *testing.B) {
+	data := repetitive(rand.New(rand.NewPCG(1, 1)), 1<<20)
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		DeflateEncode(io.Discard, bytes.NewReader(data), DeflateOptions{})
+	}
+}
+
+// BenchmarkDeflateDecode measures decompressing repetitive data
+func BenchmarkDeflateDecode(b *testing.B) {
+	data := repetitive(rand.New(rand.NewPCG(1, 1)), 1<<20)
+	var packed bytes.Buffer
+	DeflateEncode(&packed, bytes.NewReader(data), DeflateOptions{})
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		DeflateDecode(io.Discard, bytes.NewReader(packed.Bytes()))
+	}
+}
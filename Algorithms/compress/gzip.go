@@ -0,0 +1,208 @@
+package compress
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"hellogolang/Algorithms/hashing"
+)
+
+// gzip (RFC 1952) header fields
+const (
+	gzipMagic      = "\x1f\x8b"
+	gzipDeflate    = 8 // The only compression method defined
+	gzipHeaderSize = 10
+	gzipOSUnknown  = 255
+	gzipMaxString  = 1 << 16 // Longest name or comment accepted
+)
+
+// gzip header flags
+const (
+	gzipFlagText    = 1 << 0
+	gzipFlagHCRC    = 1 << 1
+	gzipFlagExtra   = 1 << 2
+	gzipFlagName    = 1 << 3
+	gzipFlagComment = 1 << 4
+	gzipFlagsKnown  = gzipFlagText | gzipFlagHCRC | gzipFlagExtra | gzipFlagName | gzipFlagComment
+)
+
+// GzipHeader is the optional metadata of a gzip member
+type GzipHeader struct {
+	Name    string    // Name of the original file, without directories
+	Comment string    // Free text
+	ModTime time.Time // Modification time of the original file, zero if unknown
+}
+
+// gzipDigest tracks the CRC-32 and length, modulo 2^32, of the data of a
+// member, as its trailer holds them
+type gzipDigest struct {
+	crc  hash.Hash32
+	size uint32
+}
+
+// Write adds p to the checksum and length
+func (d *gzipDigest) Write(p []byte) (int, error) {
+	d.size += uint32(len(p))
+	return d.crc.Write(p)
+}
+
+// GzipEncode compresses the bytes of r to w as a gzip file of one member:
+// a header with the magic 1f 8b, method 8 and the fields of h, the data
+// compressed by DeflateEncode, and a trailer holding the CRC-32 and length
+// of the data, both little-endian.
+// Time Complexity: O(n) typically, as DeflateEncode
+// Space Complexity: O(1)
+func GzipEncode(w io.Writer, r io.Reader, h GzipHeader) error {
+	header := make([]byte, gzipHeaderSize, gzipHeaderSize+len(h.Name)+len(h.Comment)+2)
+	copy(header, gzipMagic)
+	header[2] = gzipDeflate
+	// Times outside the 32-bit field are stored as unknown
+	if t := h.ModTime.Unix(); t > 0 && t <= math.MaxUint32 {
+		binary.LittleEndian.PutUint32(header[4:], uint32(t))
+	}
+	header[9] = gzipOSUnknown
+	// Secure: the fields are NUL-terminated, so may not hold a NUL
+	if strings.IndexByte(h.Name+h.Comment, 0) >= 0 {
+		return fmt.Errorf("gzip: name or comment contains a NUL byte")
+	}
+	if h.Name != "" {
+		header[3] |= gzipFlagName
+		header = append(append(header, h.Name...), 0)
+	}
+	if h.Comment != "" {
+		header[3] |= gzipFlagComment
+		header = append(append(header, h.Comment...), 0)
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	d := &gzipDigest{crc: hashing.NewIEEE()}
+	if err := DeflateEncode(w, io.TeeReader(r, d), DeflateOptions{}); err != nil {
+		return err
+	}
+	trailer := binary.LittleEndian.AppendUint32(nil, d.crc.Sum32())
+	trailer = binary.LittleEndian.AppendUint32(trailer, d.size)
+	_, err := w.Write(trailer)
+	return err
+}
+
+// GzipDecode decompresses a gzip file from r to w and returns the header
+// of its first member. A file of several members, as concatenating gzip
+// files makes, decompresses to their data in order. Each member's data is
+// checked against the CRC-32 and length in its trailer, and a header
+// checksum, when present, against the header.
+// Time Complexity: O(n · 15), Space Complexity: O(1)
+func GzipDecode(w io.Writer, r io.Reader) (GzipHeader, error) {
+	in := bufio.NewReader(r)
+	var first GzipHeader
+	for member := 0; ; member++ {
+		if member > 0 {
+			if _, err := in.Peek(1); err == io.EOF {
+				return first, nil
+			}
+		}
+		h, err := readGzipHeader(in)
+		if err != nil {
+			return first, err
+		}
+		if member == 0 {
+			first = h
+		}
+
+		d := &gzipDigest{crc: hashing.NewIEEE()}
+		if err := inflate(io.MultiWriter(w, d), in); err != nil {
+			return first, err
+		}
+		trailer := make([]byte, 8)
+		if _, err := io.ReadFull(in, trailer); err != nil {
+			return first, truncated(err)
+		}
+		if crc := binary.LittleEndian.Uint32(trailer); crc != d.crc.Sum32() {
+			return first, fmt.Errorf("%w: CRC-32 %08x, trailer has %08x", ErrCorrupt, d.crc.Sum32(), crc)
+		}
+		if size := binary.LittleEndian.Uint32(trailer[4:]); size != d.size {
+			return first, fmt.Errorf("%w: length %d, trailer has %d", ErrCorrupt, d.size, size)
+		}
+	}
+}
+
+// readGzipHeader reads and checks the header of a member
+func readGzipHeader(in *bufio.Reader) (GzipHeader, error) {
+	var h GzipHeader
+	raw := make([]byte, gzipHeaderSize) // The header as read, for its checksum
+	if _, err := io.ReadFull(in, raw); err != nil || string(raw[:2]) != gzipMagic {
+		return h, fmt.Errorf("%w: not a gzip stream", ErrCorrupt)
+	}
+	if raw[2] != gzipDeflate {
+		return h, fmt.Errorf("%w: compression method %d", ErrCorrupt, raw[2])
+	}
+	flags := raw[3]
+	if flags&^gzipFlagsKnown != 0 {
+		return h, fmt.Errorf("%w: reserved flags %#02x", ErrCorrupt, flags)
+	}
+	if t := binary.LittleEndian.Uint32(raw[4:]); t != 0 {
+		h.ModTime = time.Unix(int64(t), 0)
+	}
+
+	if flags&gzipFlagExtra != 0 {
+		// The extra field is at most 65535 bytes, so it is read whole
+		size := make([]byte, 2)
+		if _, err := io.ReadFull(in, size); err != nil {
+			return h, truncated(err)
+		}
+		extra := make([]byte, binary.LittleEndian.Uint16(size))
+		if _, err := io.ReadFull(in, extra); err != nil {
+			return h, truncated(err)
+		}
+		raw = append(append(raw, size...), extra...)
+	}
+	// readString reads a NUL-terminated field
+	readString := func() (string, error) {
+		field := []byte{}
+		for {
+			c, err := in.ReadByte()
+			if err != nil {
+				return "", truncated(err)
+			}
+			raw = append(raw, c)
+			if c == 0 {
+				return string(field), nil
+			}
+			// Secure: bound the field held in memory
+			if len(field) == gzipMaxString {
+				return "", fmt.Errorf("%w: header field over %d bytes", ErrCorrupt, gzipMaxString)
+			}
+			field = append(field, c)
+		}
+	}
+	var err error
+	if flags&gzipFlagName != 0 {
+		if h.Name, err = readString(); err != nil {
+			return h, err
+		}
+	}
+	if flags&gzipFlagComment != 0 {
+		if h.Comment, err = readString(); err != nil {
+			return h, err
+		}
+	}
+	if flags&gzipFlagHCRC != 0 {
+		sum := make([]byte, 2)
+		if _, err := io.ReadFull(in, sum); err != nil {
+			return h, truncated(err)
+		}
+		// The header checksum is the low 16 bits of the CRC-32 of the
+		// header before it
+		if want := uint16(hashing.ChecksumIEEE(raw)); binary.LittleEndian.Uint16(sum) != want {
+			return h, fmt.Errorf("%w: header checksum %04x, want %04x", ErrCorrupt, binary.LittleEndian.Uint16(sum), want)
+		}
+	}
+	return h, nil
+}
@@ -0,0 +1,113 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGzip tests round trips of data and header, and that both this
+// package and compress/gzip read what the other writes
+func TestGzip(t *testing.T) {
+	h := GzipHeader{Name: "notes.txt", Comment: "a comment", ModTime: time.Unix(1700000000, 0)}
+	for name, data := range testInputs() {
+		t.Run(name, func(t *testing.T) {
+			var packed, out bytes.Buffer
+			if err := GzipEncode(&packed, bytes.NewReader(data), h); err != nil {
+				t.Fatal(err)
+			}
+			zr, err := gzip.NewReader(bytes.NewReader(packed.Bytes()))
+			if err != nil {
+				t.Fatalf("compress/gzip rejected the header: %v", err)
+			}
+			if std, err := io.ReadAll(zr); err != nil || !bytes.Equal(std, data) {
+				t.Fatalf("compress/gzip read %d bytes, %v", len(std), err)
+			}
+			if zr.Name != h.Name || zr.Comment != h.Comment || !zr.ModTime.Equal(h.ModTime) {
+				t.Errorf("compress/gzip read header %+v", zr.Header)
+			}
+
+			got, err := GzipDecode(&out, &packed)
+			if err != nil || !bytes.Equal(out.Bytes(), data) {
+				t.Fatalf("GzipDecode = %d bytes, %v", out.Len(), err)
+			}
+			if got.Name != h.Name || got.Comment != h.Comment || !got.ModTime.Equal(h.ModTime) {
+				t.Errorf("header = %+v, want %+v", got, h)
+			}
+
+			var std bytes.Buffer
+			zw := gzip.NewWriter(&std)
+			zw.Name, zw.Extra = "std", []byte("ex\x02\x00hi")
+			zw.Write(data)
+			zw.Close()
+			out.Reset()
+			if got, err := GzipDecode(&out, &std); err != nil || !bytes.Equal(out.Bytes(), data) || got.Name != "std" {
+				t.Errorf("GzipDecode of compress/gzip output = %+v, %d bytes, %v", got, out.Len(), err)
+			}
+		})
+	}
+
+	if err := GzipEncode(io.Discard, strings.NewReader("x"), GzipHeader{Name: "a\x00b"}); err == nil {
+		t.Error("GzipEncode accepted a name holding a NUL")
+	}
+}
+
+// TestGzipMembers tests that concatenated members decompress in order
+func TestGzipMembers(t *testing.T) {
+	var packed, out bytes.Buffer
+	GzipEncode(&packed, strings.NewReader("first "), GzipHeader{Name: "one"})
+	GzipEncode(&packed, strings.NewReader("second"), GzipHeader{Name: "two"})
+	h, err := GzipDecode(&out, &packed)
+	if err != nil || out.String() != "first second" || h.Name != "one" {
+		t.Errorf("GzipDecode = %+v, %q, %v", h, out.String(), err)
+	}
+}
+
+// TestGzipCorrupt tests that malformed files, or files whose data do not
+// match their checksums, are rejected with ErrCorrupt
+func TestGzipCorrupt(t *testing.T) {
+	var good bytes.Buffer
+	GzipEncode(&good, strings.NewReader(strings.Repeat("checked data ", 40)), GzipHeader{Name: "f"})
+	stream := good.Bytes()
+	damage := func(offset int, value byte) []byte {
+		d := slices.Clone(stream)
+		d[(offset+len(d))%len(d)] ^= value
+		return d
+	}
+
+	var hcrc bytes.Buffer
+	zw := gzip.NewWriter(&hcrc)
+	zw.Close()
+	withHCRC := slices.Clone(hcrc.Bytes())
+	withHCRC[3] |= gzipFlagHCRC // A header checksum the header does not have
+	cases := map[string][]byte{
+		"empty":          {},
+		"bad magic":      damage(1, 0x01),
+		"method":         damage(2, 0x01),
+		"reserved flags": damage(3, 0x80),
+		"CRC":            damage(-8, 0x01),
+		"length":         damage(-1, 0x01),
+		"no trailer":     stream[:len(stream)-8],
+		"header CRC":     withHCRC,
+		"garbage after":  append(slices.Clone(stream), "junk"...),
+		"long name":      append([]byte("\x1f\x8b\x08\x08\x00\x00\x00\x00\x00\xff"), bytes.Repeat([]byte("n"), gzipMaxString+1)...),
+	}
+	for name, data := range cases {
+		if _, err := GzipDecode(io.Discard, bytes.NewReader(data)); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: error = %v, want ErrCorrupt", name, err)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(19, 20))
+	for range 2000 {
+		damaged := slices.Clone(stream)
+		damaged[rng.IntN(len(damaged))] ^= byte(1 + rng.IntN(255))
+		GzipDecode(io.Discard, bytes.NewReader(damaged))
+	}
+}
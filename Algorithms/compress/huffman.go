@@ -0,0 +1,319 @@
+package compress
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"slices"
+)
+
+// MaxCodeLength is the longest code CodeLengths and CanonicalCodes build
+const MaxCodeLength = 32
+
+// Code is a codeword of a prefix code: the low Len bits of Bits, read from
+// the most significant
+type Code struct {
+	Bits uint32
+	Len  uint8
+}
+
+// CodeLengths returns the lengths of a Huffman code for symbols occurring
+// with the given frequencies, no code longer than maxLen; symbols of zero
+// or negative frequency get no code, length 0. Huffman's algorithm runs on
+// the symbols sorted by frequency with two queues, leaves and merged nodes,
+// whose heads are the two least frequent nodes. If a code comes out longer
+// than maxLen, the long codes are cut to maxLen and the lengths then
+// rebalanced to a complete code, close to but not always the optimum under
+// the limit. A lone symbol gets a 1-bit code.
+// Time Complexity: O(n log n), plus O(n²) when the limit binds
+// Space Complexity: O(n)
+func CodeLengths(freq []int, maxLen int) ([]uint8, error) {
+	lengths := make([]uint8, len(freq))
+	symbols := []int{}
+	for s, f := range freq {
+		// Secure: negative frequencies count as unused
+		if f > 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	m := len(symbols)
+	if maxLen < 1 || maxLen > MaxCodeLength || m > 1<<maxLen {
+		return nil, fmt.Errorf("%w: %d symbols in codes of at most %d bits", ErrCodeLength, m, maxLen)
+	}
+	switch m {
+	case 0:
+		return lengths, nil
+	case 1:
+		lengths[symbols[0]] = 1
+		return lengths, nil
+	}
+	slices.SortStableFunc(symbols, func(a, b int) int { return freq[a] - freq[b] })
+
+	// Nodes 0..m-1 are the leaves in order of frequency, and each merge
+	// creates the next node from m on, so merged nodes come out in order of
+	// weight too
+	weight := make([]int, 2*m-1)
+	parent := make([]int, 2*m-1)
+	for i, s := range symbols {
+		weight[i] = freq[s]
+	}
+	leaf, merged := 0, m
+	pick := func(next int) int {
+		if leaf < m && (merged == next || weight[leaf] <= weight[merged]) {
+			leaf++
+			return leaf - 1
+		}
+		merged++
+		return merged - 1
+	}
+	for next := m; next < 2*m-1; next++ {
+		a, b := pick(next), pick(next)
+		weight[next] = weight[a] + weight[b]
+		parent[a], parent[b] = next, next
+	}
+	depth := make([]int, 2*m-1)
+	for i := 2*m - 3; i >= 0; i-- {
+		depth[i] = depth[parent[i]] + 1
+	}
+
+	// The Kraft sum, in units of 2^-maxLen, is exactly 1 for a complete
+	// prefix code and more than 1 for lengths no prefix code has
+	limit := uint64(1) << maxLen
+	var kraft uint64
+	for i := range m {
+		depth[i] = min(depth[i], maxLen)
+		kraft += limit >> depth[i]
+	}
+	// Lengthen the longest codes below the limit, rarest first, until the
+	// lengths fit
+	for kraft > limit {
+		best := -1
+		for i := range m {
+			if depth[i] < maxLen && (best < 0 || depth[i] > depth[best]) {
+				best = i
+			}
+		}
+		depth[best]++
+		kraft -= limit >> depth[best]
+	}
+	// Then shorten codes, most frequent first, to use up any slack; the
+	// longest code always fits it, so the code ends complete
+	for kraft < limit {
+		best := -1
+		for i := range m {
+			if depth[i] > 1 && kraft+limit>>depth[i] <= limit {
+				best = i
+			}
+		}
+		kraft += limit >> depth[best]
+		depth[best]--
+	}
+	for i, s := range symbols {
+		lengths[s] = uint8(depth[i])
+	}
+	return lengths, nil
+}
+
+// CanonicalCodes returns the canonical prefix code with the given lengths:
+// codes of each length are consecutive numbers in symbol order, following
+// on from the codes one bit shorter, so the lengths alone describe the
+// code. Symbols of length 0 get no code. It returns ErrCodeLength if the
+// lengths are too long for any prefix code.
+// Time Complexity: O(n)
+func CanonicalCodes(lengths []uint8) ([]Code, error) {
+	var count [MaxCodeLength + 1]int
+	if err := countLengths(lengths, &count); err != nil {
+		return nil, err
+	}
+	var next [MaxCodeLength + 1]uint32
+	code := uint32(0)
+	for l := 1; l <= MaxCodeLength; l++ {
+		code = (code + uint32(count[l-1])) << 1
+		next[l] = code
+	}
+	codes := make([]Code, len(lengths))
+	for s, l := range lengths {
+		if l > 0 {
+			codes[s] = Code{Bits: next[l], Len: l}
+			next[l]++
+		}
+	}
+	return codes, nil
+}
+
+// countLengths counts the codes of each length into count, and checks that
+// some prefix code has those lengths
+func countLengths(lengths []uint8, count *[MaxCodeLength + 1]int) error {
+	for _, l := range lengths {
+		// Secure: bound lengths before indexing by them
+		if l > MaxCodeLength {
+			return fmt.Errorf("%w: length %d", ErrCodeLength, l)
+		}
+		count[l]++
+	}
+	count[0] = 0
+	left := int64(1) // Codes of the current length still unassigned
+	for l := 1; l <= MaxCodeLength; l++ {
+		left = left<<1 - int64(count[l])
+		if left < 0 {
+			return fmt.Errorf("%w: oversubscribed at length %d", ErrCodeLength, l)
+		}
+	}
+	return nil
+}
+
+// reverseBits returns the low n bits of v in reverse order
+func reverseBits(v uint32, n uint8) uint32 {
+	return bits.Reverse32(v) >> (32 - n)
+}
+
+// huffmanDecoder decodes a canonical prefix code a bit at a time. After
+// reading l bits, the codes of length l are the count[l] consecutive
+// numbers from first, so one comparison per bit finds the symbol.
+type huffmanDecoder struct {
+	count   [MaxCodeLength + 1]int
+	symbols []int // Symbols in canonical order: by length, then value
+	maxLen  int
+}
+
+// newHuffmanDecoder creates a decoder for the canonical code with the
+// given lengths. An incomplete code, which would leave some bit sequences
+// undecodable, is rejected unless it has at most one symbol.
+func newHuffmanDecoder(lengths []uint8) (*huffmanDecoder, error) {
+	d := &huffmanDecoder{}
+	if err := countLengths(lengths, &d.count); err != nil {
+		return nil, err
+	}
+	total, kraft := 0, uint64(0)
+	for l := 1; l <= MaxCodeLength; l++ {
+		total += d.count[l]
+		kraft += uint64(d.count[l]) << (MaxCodeLength - l)
+		if d.count[l] > 0 {
+			d.maxLen = l
+		}
+	}
+	if total > 1 && kraft != 1<<MaxCodeLength {
+		return nil, fmt.Errorf("%w: incomplete code", ErrCodeLength)
+	}
+	d.symbols = make([]int, 0, total)
+	for l := 1; l <= d.maxLen; l++ {
+		for s, sl := range lengths {
+			if int(sl) == l {
+				d.symbols = append(d.symbols, s)
+			}
+		}
+	}
+	return d, nil
+}
+
+// decode reads one code and returns its symbol
+func (d *huffmanDecoder) decode(br *BitReader) (int, error) {
+	code, first, index := 0, 0, 0
+	for l := 1; l <= d.maxLen; l++ {
+		bit, err := br.ReadBits(1)
+		if err != nil {
+			return 0, err
+		}
+		code |= int(bit)
+		if code-first < d.count[l] {
+			return d.symbols[index+code-first], nil
+		}
+		index += d.count[l]
+		first = (first + d.count[l]) << 1
+		code <<= 1
+	}
+	return 0, fmt.Errorf("%w: invalid code", ErrCorrupt)
+}
+
+const (
+	huffmanMagic     = "HUF1"
+	huffmanMaxLength = 15 // Lengths are stored in 4 bits each
+)
+
+// HuffmanEncode compresses the bytes of r to w with a canonical Huffman
+// code built from their frequencies. The stream holds the magic "HUF1",
+// the number of bytes as a uvarint, the 256 code lengths in 4 bits each,
+// high nibble first, and then the codes packed by a BitWriter. Building
+// the code takes a pass over all the bytes before the first is encoded, so
+// the input is read whole into memory.
+// Time Complexity: O(n), Space Complexity: O(n)
+func HuffmanEncode(w io.Writer, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var freq [256]int
+	for _, c := range data {
+		freq[c]++
+	}
+	lengths, err := CodeLengths(freq[:], huffmanMaxLength)
+	if err != nil {
+		return err
+	}
+	codes, err := CanonicalCodes(lengths)
+	if err != nil {
+		return err
+	}
+
+	header := binary.AppendUvarint([]byte(huffmanMagic), uint64(len(data)))
+	for i := 0; i < len(lengths); i += 2 {
+		header = append(header, lengths[i]<<4|lengths[i+1])
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	bw := NewBitWriter(w)
+	for _, c := range data {
+		if err := bw.WriteCode(codes[c]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// HuffmanDecode decompresses a stream written by HuffmanEncode from r to
+// w. The output is written as it is decoded, so memory use does not depend
+// on the length the stream claims.
+// Time Complexity: O(n · L) for codes of at most L bits, Space Complexity: O(1)
+func HuffmanDecode(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(huffmanMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != huffmanMagic {
+		return fmt.Errorf("%w: not a Huffman stream", ErrCorrupt)
+	}
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return fmt.Errorf("%w: length: %w", ErrCorrupt, err)
+	}
+	var packed [128]byte
+	if _, err := io.ReadFull(br, packed[:]); err != nil {
+		return fmt.Errorf("%w: code lengths: %w", ErrCorrupt, io.ErrUnexpectedEOF)
+	}
+	lengths := make([]uint8, 256)
+	for i, b := range packed {
+		lengths[2*i], lengths[2*i+1] = b>>4, b&0xf
+	}
+	dec, err := newHuffmanDecoder(lengths)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	bits := NewBitReader(br)
+	out := bufio.NewWriter(w)
+	for range n {
+		s, err := dec.decode(bits)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("%w: %w", ErrCorrupt, io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return err
+		}
+		if err := out.WriteByte(byte(s)); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
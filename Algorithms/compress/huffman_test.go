@@ -0,0 +1,285 @@
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestBits tests that a BitReader reads back what a BitWriter wrote, in
+// chunks of every width
+func TestBits(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	var buf bytes.Buffer
+	bw := NewBitWriter(&buf)
+	values, widths := []uint64{}, []int{}
+	total := 0
+	for range 5000 {
+		n := rng.IntN(MaxBits + 1)
+		v := rng.Uint64() & (1<<n - 1)
+		values, widths = append(values, v), append(widths, n)
+		total += n
+		if err := bw.WriteBits(v|1<<n, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != (total+7)/8 {
+		t.Fatalf("wrote %d bytes for %d bits", buf.Len(), total)
+	}
+	br := NewBitReader(&buf)
+	for i, n := range widths {
+		if v, err := br.ReadBits(n); v != values[i] || err != nil {
+			t.Fatalf("read %d: ReadBits(%d) = %x, %v, want %x", i, n, v, err, values[i])
+		}
+	}
+
+	if err := bw.WriteBits(0, MaxBits+1); !errors.Is(err, ErrBitCount) {
+		t.Errorf("WriteBits(%d) error = %v, want ErrBitCount", MaxBits+1, err)
+	}
+	if _, err := br.ReadBits(-1); !errors.Is(err, ErrBitCount) {
+		t.Errorf("ReadBits(-1) error = %v, want ErrBitCount", err)
+	}
+}
+
+// TestBitReaderAlign tests Align and the end of input
+func TestBitReaderAlign(t *testing.T) {
+	src := bytes.NewReader([]byte{0b10110101, 0xAB, 0xCD})
+	br := NewBitReader(src)
+	if v, _ := br.ReadBits(3); v != 0b101 {
+		t.Errorf("ReadBits(3) = %b, want 101", v)
+	}
+	br.Align()
+	if src.Len() != 2 {
+		t.Errorf("source has %d bytes left after Align, want 2", src.Len())
+	}
+	if v, _ := br.ReadBits(8); v != 0xAB {
+		t.Errorf("ReadBits(8) after Align = %x, want ab", v)
+	}
+	if _, err := br.ReadBits(12); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadBits past the end error = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if _, err := NewBitReader(bytes.NewReader(nil)).ReadBits(1); err != io.EOF {
+		t.Errorf("ReadBits of empty input error = %v, want io.EOF", err)
+	}
+}
+
+// huffmanCost returns the total length of an optimal prefix code for the
+// frequencies, the sum of the weights of the merged nodes
+func huffmanCost(freq []int) int {
+	weights := []int{}
+	for _, f := range freq {
+		if f > 0 {
+			weights = append(weights, f)
+		}
+	}
+	if len(weights) == 1 {
+		return weights[0]
+	}
+	cost := 0
+	for len(weights) > 1 {
+		slices.Sort(weights)
+		merged := weights[0] + weights[1]
+		cost += merged
+		weights = append(weights[2:], merged)
+	}
+	return cost
+}
+
+// checkCode checks that lengths describe a complete prefix code within
+// maxLen for the used symbols, and returns its total encoded length
+func checkCode(t *testing.T, freq []int, lengths []uint8, maxLen int) int {
+	t.Helper()
+	used, kraft, cost := 0, 0.0, 0
+	for s, f := range freq {
+		if (f > 0) != (lengths[s] > 0) || int(lengths[s]) > maxLen {
+			t.Fatalf("symbol %d of frequency %d has length %d, limit %d", s, f, lengths[s], maxLen)
+		}
+		if f > 0 {
+			used++
+			kraft += 1 / float64(uint64(1)<<lengths[s])
+			cost += f * int(lengths[s])
+		}
+	}
+	if used > 1 && kraft != 1 {
+		t.Fatalf("Kraft sum of %v is %v, want 1", lengths, kraft)
+	}
+	return cost
+}
+
+// TestCodeLengths tests that codes are optimal without a binding limit
+// and valid complete codes with one
+func TestCodeLengths(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for range 300 {
+		freq := make([]int, 1+rng.IntN(300))
+		for i := range freq {
+			if rng.IntN(3) > 0 {
+				freq[i] = rng.IntN(1000) - 100
+			}
+		}
+		lengths, err := CodeLengths(freq, MaxCodeLength)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := checkCode(t, freq, lengths, MaxCodeLength), huffmanCost(freq); got != want {
+			t.Fatalf("code of %v costs %d, want %d", freq, got, want)
+		}
+		limited, err := CodeLengths(freq, 9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkCode(t, freq, limited, 9)
+	}
+
+	// Fibonacci frequencies give the deepest Huffman tree
+	fib := []int{1, 1}
+	for len(fib) < 30 {
+		fib = append(fib, fib[len(fib)-1]+fib[len(fib)-2])
+	}
+	for _, maxLen := range []int{5, 7, 15} {
+		lengths, err := CodeLengths(fib, maxLen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkCode(t, fib, lengths, maxLen)
+	}
+
+	if _, err := CodeLengths(fib, 4); !errors.Is(err, ErrCodeLength) {
+		t.Errorf("30 symbols in 4 bits error = %v, want ErrCodeLength", err)
+	}
+	if lengths, _ := CodeLengths([]int{0, 7, 0}, 15); !slices.Equal(lengths, []uint8{0, 1, 0}) {
+		t.Errorf("lengths for one symbol = %v, want [0 1 0]", lengths)
+	}
+}
+
+// TestCanonicalCodes tests the canonical code assignment against the
+// example in RFC 1951
+func TestCanonicalCodes(t *testing.T) {
+	codes, err := CanonicalCodes([]uint8{3, 3, 3, 3, 3, 2, 4, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Code{{2, 3}, {3, 3}, {4, 3}, {5, 3}, {6, 3}, {0, 2}, {14, 4}, {15, 4}}
+	if !slices.Equal(codes, want) {
+		t.Errorf("CanonicalCodes = %v, want %v", codes, want)
+	}
+	if _, err := CanonicalCodes([]uint8{1, 1, 1}); !errors.Is(err, ErrCodeLength) {
+		t.Errorf("oversubscribed lengths error = %v, want ErrCodeLength", err)
+	}
+	if _, err := CanonicalCodes([]uint8{MaxCodeLength + 1}); !errors.Is(err, ErrCodeLength) {
+		t.Errorf("overlong length error = %v, want ErrCodeLength", err)
+	}
+}
+
+// roundTrip compresses and decompresses data with the given functions
+func roundTrip(t *testing.T, encode, decode func(io.Writer, io.Reader) error, data []byte) []byte {
+	t.Helper()
+	var compressed, out bytes.Buffer
+	if err := encode(&compressed, bytes.NewReader(data)); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	packed := slices.Clone(compressed.Bytes())
+	if err := decode(&out, &compressed); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Fatalf("round trip of %d bytes gave %d different bytes", len(data), out.Len())
+	}
+	return packed
+}
+
+// testInputs returns named inputs for round-trip tests: empty, repetitive,
+// text, skewed and random binary data
+func testInputs() map[string][]byte {
+	rng := rand.New(rand.NewPCG(5, 6))
+	random := make([]byte, 100000)
+	for i := range random {
+		random[i] = byte(rng.Uint32())
+	}
+	skewed := make([]byte, 100000)
+	for i := range skewed {
+		skewed[i] = byte(min(rng.ExpFloat64()*4, 255))
+	}
+	every := make([]byte, 256*3)
+	for i := range every {
+		every[i] = byte(i)
+	}
+	return map[string][]byte{
+		"empty":    {},
+		"one byte": {42},
+		"repeated": bytes.Repeat([]byte{0}, 10000),
+		"all":      every,
+		"text":     []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 500)),
+		"skewed":   skewed,
+		"random":   random,
+	}
+}
+
+// TestHuffman tests round trips and that skewed data shrinks
+func TestHuffman(t *testing.T) {
+	for name, data := range testInputs() {
+		t.Run(name, func(t *testing.T) {
+			packed := roundTrip(t, HuffmanEncode, HuffmanDecode, data)
+			if name == "skewed" && len(packed) > len(data)/2 {
+				t.Errorf("skewed data compressed to %d of %d bytes", len(packed), len(data))
+			}
+		})
+	}
+}
+
+// TestHuffmanCorrupt tests that malformed streams are rejected with
+// ErrCorrupt, and that damaged streams never panic
+func TestHuffmanCorrupt(t *testing.T) {
+	var good bytes.Buffer
+	HuffmanEncode(&good, strings.NewReader("abracadabra, abracadabra"))
+	stream := good.Bytes()
+
+	oversubscribed := slices.Clone(stream)
+	oversubscribed[5+'a'/2] = 0x11 // Lengths of 1 for two more symbols
+	cases := map[string][]byte{
+		"empty":          {},
+		"bad magic":      append([]byte("HUF2"), stream[4:]...),
+		"no lengths":     stream[:10],
+		"truncated":      stream[:len(stream)-2],
+		"oversubscribed": oversubscribed,
+	}
+	for name, data := range cases {
+		if err := HuffmanDecode(io.Discard, bytes.NewReader(data)); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: error = %v, want ErrCorrupt", name, err)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(7, 8))
+	for range 2000 {
+		damaged := slices.Clone(stream)
+		damaged[rng.IntN(len(damaged))] ^= byte(1 + rng.IntN(255))
+		HuffmanDecode(io.Discard, bytes.NewReader(damaged))
+	}
+}
+
+// BenchmarkHuffmanEncode measures compressing skewed data
+func BenchmarkHuffmanEncode(b *testing.B) {
+	data := testInputs()["skewed"]
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		HuffmanEncode(io.Discard, bytes.NewReader(data))
+	}
+}
+
+// BenchmarkHuffmanDecode measures decompressing skewed data
+func BenchmarkHuffmanDecode(b *testing.B) {
+	data := testInputs()["skewed"]
+	var packed bytes.Buffer
+	HuffmanEncode(&packed, bytes.NewReader(data))
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		HuffmanDecode(io.Discard, bytes.NewReader(packed.Bytes()))
+	}
+}
@@ -0,0 +1,281 @@
+package compress
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// deflateSource is what the inflater reads: bits through a BitReader, and
+// the bytes of stored blocks directly once the bits are aligned
+type deflateSource interface {
+	io.Reader
+	io.ByteReader
+}
+
+// fixedDecoders returns decoders for the fixed literal/length and distance
+// codes, built on first use
+var fixedDecoders = sync.OnceValues(func() (*huffmanDecoder, *huffmanDecoder) {
+	litLen, dist := fixedLengths()
+	litDec, _ := newHuffmanDecoder(litLen)
+	distDec, _ := newHuffmanDecoder(dist)
+	return litDec, distDec
+})
+
+// DeflateDecode decompresses a raw DEFLATE stream (RFC 1951) from r to w,
+// as written by DeflateEncode or any other deflater, in all three block
+// forms. It reads no further into r than the byte holding the end of the
+// last block when r is an io.ByteReader, so a container format can read
+// what follows. Only the 32 KiB of history that matches can reach is kept.
+// Time Complexity: O(n · 15), Space Complexity: O(1)
+func DeflateDecode(w io.Writer, r io.Reader) error {
+	in, ok := r.(deflateSource)
+	if !ok {
+		in = bufio.NewReader(r)
+	}
+	return inflate(w, in)
+}
+
+// inflater holds the state of a decompression: the bits of the stream and
+// a ring of the last output, the window matches copy from
+type inflater struct {
+	in      deflateSource
+	bits    *BitReader
+	out     *bufio.Writer
+	history []byte
+	written int
+}
+
+// inflate decodes blocks from in to w until the final one
+func inflate(w io.Writer, in deflateSource) error {
+	f := &inflater{
+		in:      in,
+		bits:    NewBitReader(in),
+		out:     bufio.NewWriter(w),
+		history: make([]byte, deflateWindow),
+	}
+	for {
+		header, err := f.readBits(3)
+		if err != nil {
+			return err
+		}
+		switch header >> 1 {
+		case blockStored:
+			err = f.stored()
+		case blockFixed:
+			err = f.huffman(fixedDecoders())
+		case blockDynamic:
+			var litDec, distDec *huffmanDecoder
+			if litDec, distDec, err = f.dynamicDecoders(); err == nil {
+				err = f.huffman(litDec, distDec)
+			}
+		default:
+			err = fmt.Errorf("%w: reserved block type 3", ErrCorrupt)
+		}
+		if err != nil {
+			return err
+		}
+		if header&1 == 1 {
+			return f.out.Flush()
+		}
+	}
+}
+
+// truncated reports the end of the input within a stream as corruption
+func truncated(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %w", ErrCorrupt, io.ErrUnexpectedEOF)
+	}
+	return err
+}
+
+// readBits reads n bits of the stream
+func (f *inflater) readBits(n int) (int, error) {
+	v, err := f.bits.ReadBits(n)
+	return int(v), truncated(err)
+}
+
+// readSymbol reads one code of d
+func (f *inflater) readSymbol(d *huffmanDecoder) (int, error) {
+	s, err := d.decode(f.bits)
+	return s, truncated(err)
+}
+
+// write appends p to the output and the history
+func (f *inflater) write(p []byte) error {
+	for _, c := range p {
+		f.history[f.written%deflateWindow] = c
+		f.written++
+	}
+	_, err := f.out.Write(p)
+	return err
+}
+
+// stored copies a stored block: after padding to a byte boundary, its
+// length, the length's complement and the bytes themselves
+func (f *inflater) stored() error {
+	f.bits.Align()
+	n, err := f.readBits(16)
+	if err != nil {
+		return err
+	}
+	complement, err := f.readBits(16)
+	if err != nil {
+		return err
+	}
+	if n != ^complement&0xffff {
+		return fmt.Errorf("%w: stored block length %#04x and complement %#04x", ErrCorrupt, n, complement)
+	}
+	// The bits are aligned and none are buffered, so the bytes come
+	// straight from the source
+	buf := make([]byte, min(n, 4096))
+	for n > 0 {
+		m, err := io.ReadFull(f.in, buf[:min(n, len(buf))])
+		if err != nil {
+			return truncated(err)
+		}
+		if err := f.write(buf[:m]); err != nil {
+			return err
+		}
+		n -= m
+	}
+	return nil
+}
+
+// huffman decodes the literals and matches of a block coded with the given
+// codes, up to its end of block symbol
+func (f *inflater) huffman(litDec, distDec *huffmanDecoder) error {
+	for {
+		s, err := f.readSymbol(litDec)
+		if err != nil {
+			return err
+		}
+		switch {
+		case s < deflateEndOfBlock:
+			f.history[f.written%deflateWindow] = byte(s)
+			f.written++
+			if err := f.out.WriteByte(byte(s)); err != nil {
+				return err
+			}
+			continue
+		case s == deflateEndOfBlock:
+			return nil
+		case s-257 >= len(lengthBase):
+			return fmt.Errorf("%w: length symbol %d", ErrCorrupt, s)
+		}
+
+		lc := s - 257
+		extra, err := f.readBits(lengthExtra[lc])
+		if err != nil {
+			return err
+		}
+		length := lengthBase[lc] + extra
+		dc, err := f.readSymbol(distDec)
+		if err != nil {
+			return err
+		}
+		if dc >= len(distanceBase) {
+			return fmt.Errorf("%w: distance symbol %d", ErrCorrupt, dc)
+		}
+		if extra, err = f.readBits(distanceExtra[dc]); err != nil {
+			return err
+		}
+		distance := distanceBase[dc] + extra
+		// Secure: a match may not reach before the start of the output
+		if distance > f.written {
+			return fmt.Errorf("%w: distance %d after %d bytes", ErrCorrupt, distance, f.written)
+		}
+		// Copy a byte at a time, so a match may overlap its own output
+		for range length {
+			c := f.history[(f.written-distance)%deflateWindow]
+			f.history[f.written%deflateWindow] = c
+			f.written++
+			if err := f.out.WriteByte(c); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// dynamicDecoders reads the header of a dynamic block and returns decoders
+// for the codes it describes
+func (f *inflater) dynamicDecoders() (*huffmanDecoder, *huffmanDecoder, error) {
+	counts := [3]int{}
+	for i, width := range []int{5, 5, 4} {
+		n, err := f.readBits(width)
+		if err != nil {
+			return nil, nil, err
+		}
+		counts[i] = n
+	}
+	numLit, numDist, numCL := counts[0]+257, counts[1]+1, counts[2]+4
+	// Secure: the counts may name symbols that do not exist
+	if numLit > deflateNumLitLen || numDist > deflateNumDist {
+		return nil, nil, fmt.Errorf("%w: %d literal/length and %d distance codes", ErrCorrupt, numLit, numDist)
+	}
+
+	clLengths := make([]uint8, len(codeLengthOrder))
+	for _, s := range codeLengthOrder[:numCL] {
+		l, err := f.readBits(3)
+		if err != nil {
+			return nil, nil, err
+		}
+		clLengths[s] = uint8(l)
+	}
+	clDec, err := newHuffmanDecoder(clLengths)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: code length code: %w", ErrCorrupt, err)
+	}
+
+	// The lengths of both codes form one run-length coded sequence, so a
+	// run may cross from one to the other
+	lengths := make([]uint8, numLit+numDist)
+	for i := 0; i < len(lengths); {
+		s, err := f.readSymbol(clDec)
+		if err != nil {
+			return nil, nil, err
+		}
+		if s < 16 {
+			lengths[i] = uint8(s)
+			i++
+			continue
+		}
+		if s == 16 && i == 0 {
+			return nil, nil, fmt.Errorf("%w: repeat of no previous length", ErrCorrupt)
+		}
+		extra, err := f.readBits(codeLengthExtra[s-16])
+		if err != nil {
+			return nil, nil, err
+		}
+		repeat, value := 3+extra, uint8(0)
+		switch s {
+		case 16:
+			value = lengths[i-1]
+		case 18:
+			repeat = 11 + extra
+		}
+		// Secure: a run may not overflow the lengths
+		if i+repeat > len(lengths) {
+			return nil, nil, fmt.Errorf("%w: code length run past %d lengths", ErrCorrupt, len(lengths))
+		}
+		for range repeat {
+			lengths[i] = value
+			i++
+		}
+	}
+	if lengths[deflateEndOfBlock] == 0 {
+		return nil, nil, fmt.Errorf("%w: no end of block code", ErrCorrupt)
+	}
+
+	litDec, err := newHuffmanDecoder(lengths[:numLit])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: literal/length code: %w", ErrCorrupt, err)
+	}
+	distDec, err := newHuffmanDecoder(lengths[numLit:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: distance code: %w", ErrCorrupt, err)
+	}
+	return litDec, distDec, nil
+}
@@ -0,0 +1,276 @@
+package compress
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// Bounds and defaults for LZSSOptions
+const (
+	MinLZSSWindow     = 1 << 8
+	MaxLZSSWindow     = 1 << 20
+	defaultLZSSWindow = 1 << 15
+	defaultLZSSChain  = 64
+)
+
+const (
+	lzssMagic      = "LZS1"
+	lzssMinMatch   = 3 // Shorter matches cost more than their literals
+	lzssLengthBits = 8
+	lzssMaxMatch   = lzssMinMatch + 1<<lzssLengthBits - 1
+	lzssHashBits   = 15
+)
+
+// LZSSOptions configures LZSSEncode. The zero value gives a 32 KiB window
+// searched through 64 candidates per position.
+type LZSSOptions struct {
+	// WindowSize is how far back matches may reach, a power of two from
+	// MinLZSSWindow to MaxLZSSWindow. A larger window finds more repeats
+	// but spends more bits on each distance.
+	WindowSize int
+	// MaxChain bounds the earlier positions tried for each match, trading
+	// compression for speed
+	MaxChain int
+}
+
+// LZSSEncode compresses the bytes of r to w with LZSS, the variant of
+// LZ77 that spends a flag bit to choose between a literal and a match, so
+// that no match is sent where a literal is shorter. After the magic
+// "LZS1" and the base-2 logarithm of the window size as one byte, a
+// BitWriter packs a sequence of items: a 1 bit and 8 bits of a literal
+// byte, or a 0 bit, a distance back into the window in log2(WindowSize)
+// bits and a length of 3 to 258 bytes, less 3, in 8 bits. A distance of 0
+// ends the stream.
+//
+// Matches are found greedily through hash chains: positions are chained
+// by the hash of their first 3 bytes, and the chain for the current
+// position is followed for at most MaxChain candidates. Input is read
+// through a buffer of twice the window, so memory is O(WindowSize)
+// whatever the input length.
+// Time Complexity: O(n · MaxChain · 258) worst case, typically near O(n)
+// Space Complexity: O(WindowSize)
+func LZSSEncode(w io.Writer, r io.Reader, opts LZSSOptions) error {
+	if opts.WindowSize == 0 {
+		opts.WindowSize = defaultLZSSWindow
+	}
+	if opts.MaxChain == 0 {
+		opts.MaxChain = defaultLZSSChain
+	}
+	// Secure: validate the options, so the stream header can describe them
+	window := opts.WindowSize
+	if window < MinLZSSWindow || window > MaxLZSSWindow || window&(window-1) != 0 || opts.MaxChain < 1 {
+		return fmt.Errorf("lzss: need a power of two WindowSize in [%d, %d] and MaxChain >= 1, have %d, %d",
+			MinLZSSWindow, MaxLZSSWindow, opts.WindowSize, opts.MaxChain)
+	}
+	windowBits := bits.Len(uint(window)) - 1
+
+	if _, err := w.Write(append([]byte(lzssMagic), byte(windowBits))); err != nil {
+		return err
+	}
+	m := newLZSSMatcher(window)
+	bw := NewBitWriter(w)
+	for {
+		if err := m.fill(r); err != nil {
+			return err
+		}
+		if m.pos == m.end {
+			break
+		}
+
+		length, distance := m.longestMatch(window-1, opts.MaxChain)
+		var err error
+		if length >= lzssMinMatch {
+			item := uint64(distance)<<1 | uint64(length-lzssMinMatch)<<(1+windowBits)
+			err = bw.WriteBits(item, 1+windowBits+lzssLengthBits)
+		} else {
+			length = 1
+			err = bw.WriteBits(uint64(m.buf[m.pos])<<1|1, 9)
+		}
+		if err != nil {
+			return err
+		}
+		for range length {
+			m.insert()
+			m.pos++
+		}
+	}
+	bw.WriteBits(0, 1+windowBits)
+	return bw.Flush()
+}
+
+// lzssMatcher finds matches in a buffer of input, the window of history
+// before pos and the lookahead from it. It is shared by LZSSEncode and
+// DeflateEncode.
+type lzssMatcher struct {
+	buf      []byte
+	pos, end int     // The next byte to encode, and the end of input read
+	prev     []int32 // prev[i] is the last position before i with i's hash, or -1
+	head     []int32 // head[h] is the last position inserted with hash h, or -1
+	window   int
+	eof      bool
+}
+
+// newLZSSMatcher creates a matcher keeping window bytes of history
+func newLZSSMatcher(window int) *lzssMatcher {
+	m := &lzssMatcher{
+		buf:    make([]byte, 2*window+lzssMaxMatch),
+		prev:   make([]int32, 2*window+lzssMaxMatch),
+		head:   make([]int32, 1<<lzssHashBits),
+		window: window,
+	}
+	for i := range m.head {
+		m.head[i] = -1
+	}
+	return m
+}
+
+// fill keeps a full match of lookahead after pos until r is exhausted,
+// sliding out history beyond the window to make room
+func (m *lzssMatcher) fill(r io.Reader) error {
+	if m.eof || m.end-m.pos >= lzssMaxMatch {
+		return nil
+	}
+	if m.pos > m.window {
+		m.slide(m.pos - m.window)
+	}
+	n, err := io.ReadFull(r, m.buf[m.end:])
+	m.end += n
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		m.eof = true
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// hash returns the hash of the 3 bytes at i
+func (m *lzssMatcher) hash(i int) uint32 {
+	v := uint32(m.buf[i])<<16 | uint32(m.buf[i+1])<<8 | uint32(m.buf[i+2])
+	return v * 2654435761 >> (32 - lzssHashBits)
+}
+
+// insert adds pos to the chain of its hash
+func (m *lzssMatcher) insert() {
+	if m.pos+lzssMinMatch > m.end {
+		return
+	}
+	h := m.hash(m.pos)
+	m.prev[m.pos] = m.head[h]
+	m.head[h] = int32(m.pos)
+}
+
+// longestMatch returns the longest match for the bytes at pos starting at
+// most maxDistance back, and its distance, trying at most maxChain
+// candidates
+func (m *lzssMatcher) longestMatch(maxDistance, maxChain int) (length, distance int) {
+	if m.pos+lzssMinMatch > m.end {
+		return 0, 0
+	}
+	limit := min(lzssMaxMatch, m.end-m.pos)
+	for cand := m.head[m.hash(m.pos)]; cand >= 0 && maxChain > 0; cand = m.prev[cand] {
+		c := int(cand)
+		if m.pos-c > maxDistance {
+			break
+		}
+		n := 0
+		for n < limit && m.buf[c+n] == m.buf[m.pos+n] {
+			n++
+		}
+		if n > length {
+			length, distance = n, m.pos-c
+			if n == limit {
+				break
+			}
+		}
+		maxChain--
+	}
+	return length, distance
+}
+
+// slide drops the first shift bytes of the buffer, renumbering the chains
+// and forgetting positions that fall off the front
+func (m *lzssMatcher) slide(shift int) {
+	copy(m.buf, m.buf[shift:m.end])
+	copy(m.prev, m.prev[shift:m.end])
+	m.pos -= shift
+	m.end -= shift
+	rebase := func(links []int32) {
+		for i, p := range links {
+			links[i] = max(p-int32(shift), -1)
+		}
+	}
+	rebase(m.prev[:m.end])
+	rebase(m.head)
+}
+
+// LZSSDecode decompresses a stream written by LZSSEncode from r to w,
+// keeping only the window of history the stream's header names
+// Time Complexity: O(n), Space Complexity: O(WindowSize)
+func LZSSDecode(w io.Writer, r io.Reader) error {
+	in := bufio.NewReader(r)
+	header := make([]byte, len(lzssMagic)+1)
+	if _, err := io.ReadFull(in, header); err != nil || string(header[:len(lzssMagic)]) != lzssMagic {
+		return fmt.Errorf("%w: not an LZSS stream", ErrCorrupt)
+	}
+	windowBits := int(header[len(lzssMagic)])
+	// Secure: bound the window before allocating it
+	if windowBits < bits.Len(MinLZSSWindow)-1 || windowBits > bits.Len(MaxLZSSWindow)-1 {
+		return fmt.Errorf("%w: window of 2^%d bytes", ErrCorrupt, windowBits)
+	}
+
+	history := make([]byte, 1<<windowBits) // A ring of the last bytes written
+	mask := len(history) - 1
+	written := 0
+	out := bufio.NewWriter(w)
+	br := NewBitReader(in)
+	emit := func(c byte) error {
+		history[written&mask] = c
+		written++
+		return out.WriteByte(c)
+	}
+	truncated := func(err error) error {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("%w: %w", ErrCorrupt, io.ErrUnexpectedEOF)
+		}
+		return err
+	}
+	for {
+		literal, err := br.ReadBits(1)
+		if err != nil {
+			return truncated(err)
+		}
+		if literal == 1 {
+			c, err := br.ReadBits(8)
+			if err != nil {
+				return truncated(err)
+			}
+			if err := emit(byte(c)); err != nil {
+				return err
+			}
+			continue
+		}
+		distance, err := br.ReadBits(windowBits)
+		if err != nil {
+			return truncated(err)
+		}
+		if distance == 0 {
+			return out.Flush()
+		}
+		n, err := br.ReadBits(lzssLengthBits)
+		if err != nil {
+			return truncated(err)
+		}
+		// Secure: a match may not reach before the start of the output
+		if int(distance) > written {
+			return fmt.Errorf("%w: distance %d after %d bytes", ErrCorrupt, distance, written)
+		}
+		// Copy a byte at a time, so a match may overlap its own output
+		for range int(n) + lzssMinMatch {
+			if err := emit(history[(written-int(distance))&mask]); err != nil {
+				return err
+			}
+		}
+	}
+}
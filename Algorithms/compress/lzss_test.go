@@ -0,0 +1,144 @@
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// lzssWith returns an LZSSEncode using the given options
+func lzssWith(opts LZSSOptions) func(io.Writer, io.Reader) error {
+	return func(w io.Writer, r io.Reader) error { return LZSSEncode(w, r, opts) }
+}
+
+// repetitive returns n bytes built from copies of a few random phrases,
+// with some noise, so that matches occur at every distance
+func repetitive(rng *rand.Rand, n int) []byte {
+	phrases := make([][]byte, 20)
+	for i := range phrases {
+		phrases[i] = make([]byte, 3+rng.IntN(300))
+		for j := range phrases[i] {
+			phrases[i][j] = byte('a' + rng.IntN(8))
+		}
+	}
+	data := []byte{}
+	for len(data) < n {
+		if rng.IntN(4) == 0 {
+			data = append(data, byte(rng.Uint32()))
+		}
+		data = append(data, phrases[rng.IntN(len(phrases))]...)
+	}
+	return data[:n]
+}
+
+// TestLZSS tests round trips across window sizes, including inputs many
+// windows long that make the encoder slide its buffer
+func TestLZSS(t *testing.T) {
+	inputs := testInputs()
+	inputs["repetitive"] = repetitive(rand.New(rand.NewPCG(9, 10)), 300000)
+	for _, opts := range []LZSSOptions{{}, {WindowSize: MinLZSSWindow}, {WindowSize: 4096, MaxChain: 1}, {WindowSize: MaxLZSSWindow}} {
+		for name, data := range inputs {
+			packed := roundTrip(t, lzssWith(opts), LZSSDecode, data)
+			if (name == "repeated" || name == "text") && len(packed) > len(data)/10 {
+				t.Errorf("%+v: %s compressed to %d of %d bytes", opts, name, len(packed), len(data))
+			}
+		}
+	}
+
+	// Reading a byte at a time exercises partial reads in the encoder
+	data := inputs["repetitive"][:20000]
+	var packed, out bytes.Buffer
+	if err := LZSSEncode(&packed, iotest.OneByteReader(bytes.NewReader(data)), LZSSOptions{WindowSize: 512}); err != nil {
+		t.Fatal(err)
+	}
+	if err := LZSSDecode(&out, iotest.OneByteReader(&packed)); err != nil || !bytes.Equal(out.Bytes(), data) {
+		t.Fatalf("byte-at-a-time round trip failed: %v", err)
+	}
+
+	for _, opts := range []LZSSOptions{{WindowSize: 100}, {WindowSize: 1000}, {WindowSize: 2 * MaxLZSSWindow}, {MaxChain: -1}} {
+		if err := LZSSEncode(io.Discard, strings.NewReader("x"), opts); err == nil {
+			t.Errorf("LZSSEncode with %+v succeeded", opts)
+		}
+	}
+}
+
+// TestLZSSWithHuffman tests stacking Huffman coding on LZSS output
+func TestLZSSWithHuffman(t *testing.T) {
+	data := []byte(strings.Repeat("to be or not to be, that is the question; ", 300))
+	stacked := func(w io.Writer, r io.Reader) error {
+		var lz bytes.Buffer
+		if err := LZSSEncode(&lz, r, LZSSOptions{}); err != nil {
+			return err
+		}
+		return HuffmanEncode(w, &lz)
+	}
+	unstacked := func(w io.Writer, r io.Reader) error {
+		var lz bytes.Buffer
+		if err := HuffmanDecode(&lz, r); err != nil {
+			return err
+		}
+		return LZSSDecode(w, &lz)
+	}
+	roundTrip(t, stacked, unstacked, data)
+}
+
+// TestLZSSCorrupt tests that malformed streams are rejected with
+// ErrCorrupt, and that damaged streams never panic
+func TestLZSSCorrupt(t *testing.T) {
+	var good bytes.Buffer
+	LZSSEncode(&good, strings.NewReader(strings.Repeat("abcabcabd", 50)), LZSSOptions{WindowSize: 256})
+	stream := good.Bytes()
+
+	var far bytes.Buffer
+	far.WriteString(lzssMagic + "\x08")
+	bw := NewBitWriter(&far)
+	bw.WriteBits('a'<<1|1, 9)
+	bw.WriteBits(2<<1, 9) // A match 2 back after 1 byte
+	bw.WriteBits(0, 8)
+	bw.Flush()
+	cases := map[string][]byte{
+		"empty":        {},
+		"bad magic":    append([]byte("LZS2"), stream[4:]...),
+		"small window": append([]byte(lzssMagic+"\x07"), stream[5:]...),
+		"huge window":  append([]byte(lzssMagic+"\x30"), stream[5:]...),
+		"truncated":    stream[:len(stream)-1],
+		"far match":    far.Bytes(),
+	}
+	for name, data := range cases {
+		if err := LZSSDecode(io.Discard, bytes.NewReader(data)); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: error = %v, want ErrCorrupt", name, err)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(11, 12))
+	for range 2000 {
+		damaged := slices.Clone(stream)
+		damaged[rng.IntN(len(damaged))] ^= byte(1 + rng.IntN(255))
+		LZSSDecode(io.Discard, bytes.NewReader(damaged))
+	}
+}
+
+// BenchmarkLZSSEncode measures compressing repetitive data
+func BenchmarkLZSSEncode(b *testing.B) {
+	data := repetitive(rand.New(rand.NewPCG(1, 1)), 1<<20)
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		LZSSEncode(io.Discard, bytes.NewReader(data), LZSSOptions{})
+	}
+}
+
+// BenchmarkLZSSDecode measures decompressing repetitive data
+func BenchmarkLZSSDecode(b *testing.B) {
+	data := repetitive(rand.New(rand.NewPCG(1, 1)), 1<<20)
+	var packed bytes.Buffer
+	LZSSEncode(&packed, bytes.NewReader(data), LZSSOptions{})
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		LZSSDecode(io.Discard, bytes.NewReader(packed.Bytes()))
+	}
+}
@@ -0,0 +1,114 @@
+package compress
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+const (
+	rleMagic   = "RLE1"
+	rleMinRun  = 3   // Shorter runs cost no more as literals
+	rleMaxRun  = 130 // rleMinRun plus the 128 counts of a run header
+	rleMaxLits = 128
+)
+
+// RLEEncode compresses the bytes of r to w by run-length encoding, in the
+// style of PackBits. After the magic "RLE1" the stream is a sequence of
+// packets, each a header byte h and its data: for h below 128, the next
+// h+1 bytes are literals; otherwise the next byte repeats h-125 times.
+// Runs of 3 to 130 equal bytes shrink to 2 bytes, and data without runs
+// grows by at most 1 byte in 128.
+// Time Complexity: O(n), Space Complexity: O(1)
+func RLEEncode(w io.Writer, r io.Reader) error {
+	in := bufio.NewReader(r)
+	out := bufio.NewWriter(w)
+	out.WriteString(rleMagic)
+	literals := make([]byte, 0, rleMaxLits)
+	flush := func() {
+		if len(literals) > 0 {
+			out.WriteByte(byte(len(literals) - 1))
+			out.Write(literals)
+			literals = literals[:0]
+		}
+	}
+	for {
+		c, err := in.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		run := 1
+		for run < rleMaxRun {
+			next, err := in.ReadByte()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if next != c {
+				in.UnreadByte()
+				break
+			}
+			run++
+		}
+		if run >= rleMinRun {
+			flush()
+			out.WriteByte(byte(run - rleMinRun + 128))
+			out.WriteByte(c)
+			continue
+		}
+		for range run {
+			if literals = append(literals, c); len(literals) == rleMaxLits {
+				flush()
+			}
+		}
+	}
+	flush()
+	// Write errors are sticky in out, so the last one reports them all
+	return out.Flush()
+}
+
+// RLEDecode decompresses a stream written by RLEEncode from r to w
+// Time Complexity: O(n), Space Complexity: O(1)
+func RLEDecode(w io.Writer, r io.Reader) error {
+	in := bufio.NewReader(r)
+	magic := make([]byte, len(rleMagic))
+	if _, err := io.ReadFull(in, magic); err != nil || string(magic) != rleMagic {
+		return fmt.Errorf("%w: not a run-length stream", ErrCorrupt)
+	}
+	out := bufio.NewWriter(w)
+	for {
+		h, err := in.ReadByte()
+		if err == io.EOF {
+			return out.Flush()
+		}
+		if err != nil {
+			return err
+		}
+		if h < 128 {
+			if n, err := io.CopyN(out, in, int64(h)+1); err != nil {
+				if err == io.EOF {
+					return fmt.Errorf("%w: %d of %d literals: %w", ErrCorrupt, n, int(h)+1, io.ErrUnexpectedEOF)
+				}
+				return err
+			}
+			continue
+		}
+		c, err := in.ReadByte()
+		if err == io.EOF {
+			return fmt.Errorf("%w: run without a byte: %w", ErrCorrupt, io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return err
+		}
+		for range int(h) - 128 + rleMinRun {
+			if err := out.WriteByte(c); err != nil {
+				return err
+			}
+		}
+	}
+}
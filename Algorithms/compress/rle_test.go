@@ -0,0 +1,40 @@
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestRLE tests round trips, the packet boundaries and corrupt streams
+func TestRLE(t *testing.T) {
+	inputs := testInputs()
+	for _, n := range []int{1, 2, 3, 128, 129, 130, 131, 256, 260} {
+		inputs[fmt.Sprintf("run of %d", n)] = bytes.Repeat([]byte{7}, n)
+	}
+	for name, data := range inputs {
+		packed := roundTrip(t, RLEEncode, RLEDecode, data)
+		if limit := len(rleMagic) + len(data) + (len(data)+127)/128; len(packed) > limit {
+			t.Errorf("%s: %d bytes grew to %d, limit %d", name, len(data), len(packed), limit)
+		}
+	}
+	var packed bytes.Buffer
+	RLEEncode(&packed, bytes.NewReader(bytes.Repeat([]byte{0}, 10000)))
+	if packed.Len() > len(rleMagic)+2*(10000/rleMaxRun+1) {
+		t.Errorf("10000 zeros encoded to %d bytes", packed.Len())
+	}
+
+	cases := map[string]string{
+		"bad magic":      "RLE0",
+		"short literals": rleMagic + "\x05abc",
+		"run no byte":    rleMagic + "\x80",
+	}
+	for name, data := range cases {
+		if err := RLEDecode(io.Discard, strings.NewReader(data)); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: error = %v, want ErrCorrupt", name, err)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package datastructures
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// BTree is a B-tree mapping ordered keys to values. Each node holds
+// between degree-1 and 2·degree-1 keys in a sorted slice, the root as few
+// as one, and all leaves lie at the same depth, so the height is about
+// log_degree n. Wide nodes mean few nodes are visited per operation, the
+// property that suits B-trees to storage read a block at a time; in memory
+// they also make searches cache-friendly.
+type BTree[K, V any] struct {
+	root    *btreeNode[K, V]
+	degree  int
+	size    int
+	compare func(a, b K) int
+}
+
+// btreeNode is a node of a BTree; a leaf has no children, and an internal
+// node one more child than keys, children[i] holding the keys between
+// keys[i-1] and keys[i]
+type btreeNode[K, V any] struct {
+	keys     []K
+	values   []V
+	children []*btreeNode[K, V]
+}
+
+// leaf reports whether n is a leaf
+func (n *btreeNode[K, V]) leaf() bool {
+	return len(n.children) == 0
+}
+
+// NewBTree creates an empty B-tree of the given minimum degree, ordering
+// keys by cmp.Compare. A degree below 2 is raised to 2, making a 2-3-4
+// tree.
+func NewBTree[K cmp.Ordered, V any](degree int) *BTree[K, V] {
+	return NewBTreeFunc[K, V](degree, cmp.Compare[K])
+}
+
+// NewBTreeFunc creates an empty B-tree of the given minimum degree,
+// ordering keys by compare, which returns a negative number, zero or a
+// positive number as a is less than, equal to or greater than b
+func NewBTreeFunc[K, V any](degree int, compare func(a, b K) int) *BTree[K, V] {
+	return &BTree[K, V]{degree: max(degree, 2), compare: compare}
+}
+
+// Len returns the number of keys
+func (b *BTree[K, V]) Len() int {
+	return b.size
+}
+
+// Degree returns the minimum degree of the tree
+func (b *BTree[K, V]) Degree() int {
+	return b.degree
+}
+
+// search returns the index of the first key of n at least key, and
+// whether it equals key
+func (b *BTree[K, V]) search(n *btreeNode[K, V], key K) (int, bool) {
+	return slices.BinarySearchFunc(n.keys, key, b.compare)
+}
+
+// Get returns the value of key and whether it is present
+// Time Complexity: O(log n), Space Complexity: O(1)
+func (b *BTree[K, V]) Get(key K) (V, bool) {
+	for n := b.root; n != nil; {
+		i, found := b.search(n, key)
+		if found {
+			return n.values[i], true
+		}
+		if n.leaf() {
+			break
+		}
+		n = n.children[i]
+	}
+	var zero V
+	return zero, false
+}
+
+// Min returns the least key and its value, or ok false if the tree is
+// empty
+// Time Complexity: O(log n)
+func (b *BTree[K, V]) Min() (key K, value V, ok bool) {
+	n := b.root
+	if n == nil {
+		return key, value, false
+	}
+	for !n.leaf() {
+		n = n.children[0]
+	}
+	return n.keys[0], n.values[0], true
+}
+
+// Max returns the greatest key and its value, or ok false if the tree is
+// empty
+// Time Complexity: O(log n)
+func (b *BTree[K, V]) Max() (key K, value V, ok bool) {
+	n := b.root
+	if n == nil {
+		return key, value, false
+	}
+	for !n.leaf() {
+		n = n.children[len(n.children)-1]
+	}
+	return n.keys[len(n.keys)-1], n.values[len(n.values)-1], true
+}
+
+// Height returns the number of levels of nodes, 0 for an empty tree
+func (b *BTree[K, V]) Height() int {
+	height := 0
+	for n := b.root; n != nil; height++ {
+		if n.leaf() {
+			n = nil
+		} else {
+			n = n.children[0]
+		}
+	}
+	return height
+}
+
+// Insert sets the value of key and reports whether key is new. Full nodes
+// are split on the way down, so the leaf reached always has room and no
+// split ever has to travel back up.
+// Time Complexity: O(degree · log n), Space Complexity: O(degree)
+func (b *BTree[K, V]) Insert(key K, value V) bool {
+	if b.root == nil {
+		b.root = &btreeNode[K, V]{keys: []K{key}, values: []V{value}}
+		b.size = 1
+		return true
+	}
+	if len(b.root.keys) == 2*b.degree-1 {
+		b.root = &btreeNode[K, V]{children: []*btreeNode[K, V]{b.root}}
+		b.splitChild(b.root, 0)
+	}
+
+	n := b.root
+	for {
+		i, found := b.search(n, key)
+		if found {
+			n.values[i] = value
+			return false
+		}
+		if n.leaf() {
+			n.keys = slices.Insert(n.keys, i, key)
+			n.values = slices.Insert(n.values, i, value)
+			b.size++
+			return true
+		}
+		if len(n.children[i].keys) == 2*b.degree-1 {
+			b.splitChild(n, i)
+			// The median moved up to keys[i]; go right of it if key is
+			// greater, or stop if it is the key
+			switch c := b.compare(key, n.keys[i]); {
+			case c == 0:
+				n.values[i] = value
+				return false
+			case c > 0:
+				i++
+			}
+		}
+		n = n.children[i]
+	}
+}
+
+// splitChild splits the full child i of n around its median, which moves
+// up into n between the two halves
+func (b *BTree[K, V]) splitChild(n *btreeNode[K, V], i int) {
+	t := b.degree
+	child := n.children[i]
+	right := &btreeNode[K, V]{
+		keys:   slices.Clone(child.keys[t:]),
+		values: slices.Clone(child.values[t:]),
+	}
+	if !child.leaf() {
+		right.children = slices.Clone(child.children[t:])
+		clear(child.children[t:])
+		child.children = child.children[:t]
+	}
+	n.keys = slices.Insert(n.keys, i, child.keys[t-1])
+	n.values = slices.Insert(n.values, i, child.values[t-1])
+	n.children = slices.Insert(n.children, i+1, right)
+
+	// Secure: clear the moved entries so the halves share no references
+	clear(child.keys[t-1:])
+	clear(child.values[t-1:])
+	child.keys, child.values = child.keys[:t-1], child.values[:t-1]
+}
+
+// Delete removes key and reports whether it was present. Before
+// descending into a child the child is given at least degree keys, by
+// borrowing through its parent from a sibling or merging with one, so the
+// key can be removed from whichever node it reaches without underflow.
+// Time Complexity: O(degree · log n), Space Complexity: O(1)
+func (b *BTree[K, V]) Delete(key K) bool {
+	if b.root == nil {
+		return false
+	}
+	t := b.degree
+	removed := false
+	for n := b.root; n != nil; {
+		i, found := b.search(n, key)
+		switch {
+		case n.leaf():
+			if found {
+				n.keys = slices.Delete(n.keys, i, i+1)
+				n.values = slices.Delete(n.values, i, i+1)
+				removed = true
+			}
+			n = nil
+		case found && len(n.children[i].keys) >= t:
+			// Replace the key by its predecessor, then delete that below
+			pred := n.children[i]
+			for !pred.leaf() {
+				pred = pred.children[len(pred.children)-1]
+			}
+			last := len(pred.keys) - 1
+			n.keys[i], n.values[i] = pred.keys[last], pred.values[last]
+			key = pred.keys[last]
+			n = n.children[i]
+		case found && len(n.children[i+1].keys) >= t:
+			// Or by its successor
+			succ := n.children[i+1]
+			for !succ.leaf() {
+				succ = succ.children[0]
+			}
+			n.keys[i], n.values[i] = succ.keys[0], succ.values[0]
+			key = succ.keys[0]
+			n = n.children[i+1]
+		case found:
+			// Both neighbors are minimal: merge them around the key and
+			// delete it from the merged node
+			b.merge(n, i)
+			n = n.children[i]
+		default:
+			if len(n.children[i].keys) < t {
+				i = b.fill(n, i)
+			}
+			n = n.children[i]
+		}
+	}
+
+	// A root emptied by a merge gives way to its only child
+	if len(b.root.keys) == 0 {
+		if b.root.leaf() {
+			b.root = nil
+		} else {
+			b.root = b.root.children[0]
+		}
+	}
+	if removed {
+		b.size--
+	}
+	return removed
+}
+
+// fill gives the minimal child i of n another key, borrowing from a
+// sibling with keys to spare or else merging with one, and returns the
+// index of the child now holding its keys
+func (b *BTree[K, V]) fill(n *btreeNode[K, V], i int) int {
+	t := b.degree
+	child := n.children[i]
+	switch {
+	case i > 0 && len(n.children[i-1].keys) >= t:
+		// Rotate right: the separator comes down, the left sibling's last
+		// key goes up
+		left := n.children[i-1]
+		last := len(left.keys) - 1
+		child.keys = slices.Insert(child.keys, 0, n.keys[i-1])
+		child.values = slices.Insert(child.values, 0, n.values[i-1])
+		n.keys[i-1], n.values[i-1] = left.keys[last], left.values[last]
+		left.keys, left.values = left.keys[:last], left.values[:last]
+		if !left.leaf() {
+			child.children = slices.Insert(child.children, 0, left.children[last+1])
+			left.children = left.children[:last+1]
+		}
+	case i < len(n.children)-1 && len(n.children[i+1].keys) >= t:
+		// Rotate left
+		right := n.children[i+1]
+		child.keys = append(child.keys, n.keys[i])
+		child.values = append(child.values, n.values[i])
+		n.keys[i], n.values[i] = right.keys[0], right.values[0]
+		right.keys = slices.Delete(right.keys, 0, 1)
+		right.values = slices.Delete(right.values, 0, 1)
+		if !right.leaf() {
+			child.children = append(child.children, right.children[0])
+			right.children = slices.Delete(right.children, 0, 1)
+		}
+	case i < len(n.children)-1:
+		b.merge(n, i)
+	default:
+		b.merge(n, i-1)
+		i--
+	}
+	return i
+}
+
+// merge joins child i+1 of n and the separator key i onto child i
+func (b *BTree[K, V]) merge(n *btreeNode[K, V], i int) {
+	left, right := n.children[i], n.children[i+1]
+	left.keys = append(append(left.keys, n.keys[i]), right.keys...)
+	left.values = append(append(left.values, n.values[i]), right.values...)
+	left.children = append(left.children, right.children...)
+	n.keys = slices.Delete(n.keys, i, i+1)
+	n.values = slices.Delete(n.values, i, i+1)
+	n.children = slices.Delete(n.children, i+1, i+2)
+}
+
+// All iterates over the keys and values in increasing key order. The tree
+// must not be modified during the iteration.
+// Time Complexity: O(n) for the whole iteration, Space Complexity: O(log n)
+func (b *BTree[K, V]) All() iter.Seq2[K, V] {
+	return b.ascend(nil, nil)
+}
+
+// Range iterates over the keys in [lo, hi), with their values, in
+// increasing key order. The tree must not be modified during the
+// iteration.
+// Time Complexity: O(log n + k) for k keys, Space Complexity: O(log n)
+func (b *BTree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return b.ascend(&lo, &hi)
+}
+
+// ascend iterates over the keys from lo up to hi, either bound being
+// absent if nil. Each frame of the stack is a node and the index of its
+// next key, whose left subtree has already been visited.
+func (b *BTree[K, V]) ascend(lo, hi *K) iter.Seq2[K, V] {
+	type frame struct {
+		n *btreeNode[K, V]
+		i int
+	}
+	return func(yield func(K, V) bool) {
+		stack := []frame{}
+		for n := b.root; n != nil; {
+			i, found := 0, false
+			if lo != nil {
+				i, found = b.search(n, *lo)
+			}
+			stack = append(stack, frame{n, i})
+			// The keys left of an exact match are all below lo
+			if found || n.leaf() {
+				break
+			}
+			n = n.children[i]
+		}
+		for len(stack) > 0 {
+			f := &stack[len(stack)-1]
+			if f.i == len(f.n.keys) {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			key, value := f.n.keys[f.i], f.n.values[f.i]
+			if hi != nil && b.compare(key, *hi) >= 0 {
+				return
+			}
+			if !yield(key, value) {
+				return
+			}
+			f.i++
+			if !f.n.leaf() {
+				for n := f.n.children[f.i]; n != nil; {
+					stack = append(stack, frame{n, 0})
+					if n.leaf() {
+						break
+					}
+					n = n.children[0]
+				}
+			}
+		}
+	}
+}
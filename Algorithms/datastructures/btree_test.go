@@ -0,0 +1,200 @@
+package datastructures
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// checkBTree checks the B-tree invariants: key counts within the degree's
+// bounds, one more child than keys, all leaves at one depth, and keys in
+// strictly increasing order numbering Len
+func checkBTree[K cmp.Ordered, V any](b *BTree[K, V]) error {
+	t := b.degree
+	leafDepth := -1
+	var check func(n *btreeNode[K, V], depth int) error
+	check = func(n *btreeNode[K, V], depth int) error {
+		if len(n.keys) != len(n.values) {
+			return fmt.Errorf("node with %d keys has %d values", len(n.keys), len(n.values))
+		}
+		if len(n.keys) > 2*t-1 || (n != b.root && len(n.keys) < t-1) || len(n.keys) == 0 {
+			return fmt.Errorf("node at depth %d has %d keys", depth, len(n.keys))
+		}
+		if n.leaf() {
+			if leafDepth >= 0 && depth != leafDepth {
+				return fmt.Errorf("leaves at depths %d and %d", leafDepth, depth)
+			}
+			leafDepth = depth
+			return nil
+		}
+		if len(n.children) != len(n.keys)+1 {
+			return fmt.Errorf("node with %d keys has %d children", len(n.keys), len(n.children))
+		}
+		for _, c := range n.children {
+			if err := check(c, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if b.root != nil {
+		if err := check(b.root, 0); err != nil {
+			return err
+		}
+	}
+	keys := []K{}
+	for k := range b.All() {
+		keys = append(keys, k)
+	}
+	if len(keys) != b.Len() || !slices.IsSorted(keys) || len(slices.Compact(slices.Clone(keys))) != len(keys) {
+		return fmt.Errorf("All yields %d keys, Len %d, sorted %t", len(keys), b.Len(), slices.IsSorted(keys))
+	}
+	return nil
+}
+
+// TestBTreeRandom tests random inserts and deletes against a map for
+// several degrees, checking the invariants after each change
+func TestBTreeRandom(t *testing.T) {
+	for _, degree := range []int{0, 2, 3, 5, 16} {
+		t.Run(fmt.Sprint("degree ", degree), func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(1, uint64(degree)))
+			b := NewBTree[int, int](degree)
+			want := map[int]int{}
+			for step := range 4000 {
+				key := rng.IntN(500)
+				_, present := want[key]
+				if rng.IntN(5) < 2 {
+					delete(want, key)
+					if got := b.Delete(key); got != present {
+						t.Fatalf("step %d: Delete(%d) = %t, want %t", step, key, got, present)
+					}
+				} else {
+					want[key] = step
+					if got := b.Insert(key, step); got == present {
+						t.Fatalf("step %d: Insert(%d) = %t, want %t", step, key, got, !present)
+					}
+				}
+				if err := checkBTree(b); err != nil {
+					t.Fatalf("step %d: %v", step, err)
+				}
+				probe := rng.IntN(520) - 10
+				_, present = want[probe]
+				if v, ok := b.Get(probe); v != want[probe] || ok != present {
+					t.Fatalf("step %d: Get(%d) = %d, %t", step, probe, v, ok)
+				}
+			}
+
+			for key := range want {
+				if !b.Delete(key) {
+					t.Fatalf("Delete(%d) of a present key failed", key)
+				}
+			}
+			if b.Len() != 0 || b.root != nil || b.Height() != 0 {
+				t.Errorf("emptied tree has Len %d and height %d", b.Len(), b.Height())
+			}
+		})
+	}
+}
+
+// TestBTreeRange tests range scans, Min and Max against a sorted slice
+func TestBTreeRange(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	b := NewBTree[int, string](3)
+	if _, _, ok := b.Min(); ok {
+		t.Error("Min of an empty tree reports a key")
+	}
+	if _, _, ok := b.Max(); ok {
+		t.Error("Max of an empty tree reports a key")
+	}
+	want := map[int]string{}
+	for range 1000 {
+		k := rng.IntN(5000)
+		want[k] = fmt.Sprint(k)
+		b.Insert(k, want[k])
+	}
+	keys := slices.Sorted(maps.Keys(want))
+
+	if k, v, ok := b.Min(); !ok || k != keys[0] || v != want[k] {
+		t.Errorf("Min = %d, %q, %t, want %d", k, v, ok, keys[0])
+	}
+	if k, _, ok := b.Max(); !ok || k != keys[len(keys)-1] {
+		t.Errorf("Max = %d, %t, want %d", k, ok, keys[len(keys)-1])
+	}
+	for range 500 {
+		lo, hi := rng.IntN(5200)-100, rng.IntN(5200)-100
+		if rng.IntN(4) == 0 {
+			lo = keys[rng.IntN(len(keys))]
+		}
+		wantKeys := []int{}
+		for _, k := range keys {
+			if lo <= k && k < hi {
+				wantKeys = append(wantKeys, k)
+			}
+		}
+		got := []int{}
+		for k, v := range b.Range(lo, hi) {
+			if v != want[k] {
+				t.Fatalf("Range yields %d: %q", k, v)
+			}
+			got = append(got, k)
+		}
+		if !slices.Equal(got, wantKeys) {
+			t.Fatalf("Range(%d, %d) = %v, want %v", lo, hi, got, wantKeys)
+		}
+	}
+
+	count := 0
+	for range b.All() {
+		if count++; count == 10 {
+			break
+		}
+	}
+	if count != 10 {
+		t.Errorf("breaking out of All after 10 keys visited %d", count)
+	}
+}
+
+// TestBTreeHeight tests that a wide tree stays shallow under sorted
+// insertion, and a comparator ordering
+func TestBTreeHeight(t *testing.T) {
+	b := NewBTree[int, struct{}](64)
+	for i := range 100000 {
+		b.Insert(i, struct{}{})
+	}
+	// Each node below the root has at least 63 keys and 64 children
+	if h := b.Height(); h > 3 {
+		t.Errorf("height %d for 100000 keys at degree 64, want at most 3", h)
+	}
+	if err := checkBTree(b); err != nil {
+		t.Error(err)
+	}
+
+	r := NewBTreeFunc[string, int](2, func(a, b string) int { return cmp.Compare(b, a) })
+	for i, s := range []string{"a", "c", "b", "e", "d"} {
+		r.Insert(s, i)
+	}
+	got := []string{}
+	for k := range r.All() {
+		got = append(got, k)
+	}
+	if !slices.Equal(got, []string{"e", "d", "c", "b", "a"}) || r.Degree() != 2 {
+		t.Errorf("reverse-ordered keys = %v", got)
+	}
+}
+
+func BenchmarkBTreeInsert(b *testing.B) {
+	for _, degree := range []int{2, 16, 128} {
+		b.Run(fmt.Sprint("degree ", degree), func(b *testing.B) {
+			rng := rand.New(rand.NewPCG(1, 2))
+			for b.Loop() {
+				tree := NewBTree[int, int](degree)
+				for range 10000 {
+					tree.Insert(rng.Int(), 0)
+				}
+			}
+		})
+	}
+}
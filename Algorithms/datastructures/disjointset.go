@@ -0,0 +1,81 @@
+// Package datastructures provides general-purpose data structures shared by
+// the algorithm packages: union-find, the ordered containers BTree and
+// SkipList with range-scan iterators, and the range-query structures
+// SegmentTree, LazySegmentTree and Fenwick.
+package datastructures
+
+// DisjointSet is a union-find structure over the elements 0..n-1, using
+// union by rank and path compression so that operations take nearly
+// constant amortized time
+type DisjointSet struct {
+	parent []int
+	rank   []byte
+	count  int
+}
+
+// NewDisjointSet creates n singleton sets
+func NewDisjointSet(n int) *DisjointSet {
+	n = max(n, 0)
+	d := &DisjointSet{parent: make([]int, n), rank: make([]byte, n), count: n}
+	for i := range d.parent {
+		d.parent[i] = i
+	}
+	return d
+}
+
+// Len returns the number of elements
+func (d *DisjointSet) Len() int {
+	return len(d.parent)
+}
+
+// Count returns the number of disjoint sets
+func (d *DisjointSet) Count() int {
+	return d.count
+}
+
+// Find returns the representative of the set containing x, or -1 if x is
+// not an element
+// Time Complexity: O(α(n)) amortized
+func (d *DisjointSet) Find(x int) int {
+	// Secure: bounds checking
+	if x < 0 || x >= len(d.parent) {
+		return -1
+	}
+
+	root := x
+	for d.parent[root] != root {
+		root = d.parent[root]
+	}
+	// Path compression
+	for d.parent[x] != root {
+		d.parent[x], x = root, d.parent[x]
+	}
+	return root
+}
+
+// Union merges the sets containing x and y. It reports false if they were
+// already in the same set or either is not an element.
+// Time Complexity: O(α(n)) amortized
+func (d *DisjointSet) Union(x, y int) bool {
+	rx, ry := d.Find(x), d.Find(y)
+	if rx < 0 || ry < 0 || rx == ry {
+		return false
+	}
+
+	// Union by rank: hang the shallower tree below the deeper one
+	if d.rank[rx] < d.rank[ry] {
+		rx, ry = ry, rx
+	}
+	d.parent[ry] = rx
+	if d.rank[rx] == d.rank[ry] {
+		d.rank[rx]++
+	}
+	d.count--
+	return true
+}
+
+// Connected reports whether x and y are in the same set
+func (d *DisjointSet) Connected(x, y int) bool {
+	rx := d.Find(x)
+	return rx >= 0 && rx == d.Find(y)
+}
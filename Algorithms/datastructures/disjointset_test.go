@@ -0,0 +1,46 @@
+package datastructures
+
+import "testing"
+
+// TestDisjointSet tests unions, finds and the set count
+func TestDisjointSet(t *testing.T) {
+	d := NewDisjointSet(6)
+	if d.Count() != 6 || d.Len() != 6 {
+		t.Fatalf("New set: count %d, len %d; want 6, 6", d.Count(), d.Len())
+	}
+
+	if !d.Union(0, 1) || !d.Union(2, 3) || !d.Union(1, 3) {
+		t.Fatal("Union of disjoint sets failed")
+	}
+	if d.Union(0, 2) {
+		t.Error("Union of joined sets reported a merge")
+	}
+	if d.Count() != 3 {
+		t.Errorf("Count = %d, want 3", d.Count())
+	}
+	if !d.Connected(0, 3) || d.Connected(0, 4) {
+		t.Error("Connected gives wrong answers")
+	}
+
+	if d.Find(6) != -1 || d.Find(-1) != -1 || d.Union(0, 6) || d.Connected(6, 6) {
+		t.Error("Out-of-range elements accepted")
+	}
+}
+
+// TestDisjointSetChain tests that long chains of unions stay consistent
+func TestDisjointSetChain(t *testing.T) {
+	const n = 100000
+	d := NewDisjointSet(n)
+	for i := 1; i < n; i++ {
+		d.Union(i-1, i)
+	}
+	root := d.Find(0)
+	for i := 0; i < n; i++ {
+		if d.Find(i) != root {
+			t.Fatalf("Find(%d) = %d, want %d", i, d.Find(i), root)
+		}
+	}
+	if d.Count() != 1 {
+		t.Errorf("Count = %d, want 1", d.Count())
+	}
+}
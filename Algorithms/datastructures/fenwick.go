@@ -0,0 +1,99 @@
+package datastructures
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Fenwick is a Fenwick tree, or binary indexed tree, keeping prefix sums of
+// n values while they change. Entry i (counting from 1) holds the sum of
+// the values in (i - lowbit(i), i], where lowbit(i) is the lowest set bit
+// of i, so a prefix sum adds one entry per set bit of its length and an
+// update touches one entry per level. It needs only the n entries and an
+// addition, and is simpler and faster than a segment tree when sums are
+// all that is needed.
+type Fenwick[T Number] struct {
+	tree []T // tree[0] is unused
+}
+
+// NewFenwick creates a Fenwick tree of n zeros; a negative n is taken as 0
+func NewFenwick[T Number](n int) *Fenwick[T] {
+	return &Fenwick[T]{tree: make([]T, max(n, 0)+1)}
+}
+
+// FenwickFrom builds a Fenwick tree over the values, adding each entry
+// into its parent once
+// Time Complexity: O(n), Space Complexity: O(n)
+func FenwickFrom[T Number](values []T) *Fenwick[T] {
+	f := &Fenwick[T]{tree: make([]T, len(values)+1)}
+	copy(f.tree[1:], values)
+	for i := 1; i < len(f.tree); i++ {
+		if parent := i + i&-i; parent < len(f.tree) {
+			f.tree[parent] += f.tree[i]
+		}
+	}
+	return f
+}
+
+// Len returns the number of values
+func (f *Fenwick[T]) Len() int {
+	return len(f.tree) - 1
+}
+
+// Add adds delta to the value at index i
+// Time Complexity: O(log n)
+func (f *Fenwick[T]) Add(i int, delta T) error {
+	// Secure: bounds checking
+	if i < 0 || i >= f.Len() {
+		return fmt.Errorf("%w: %d of %d", ErrIndex, i, f.Len())
+	}
+	for i++; i < len(f.tree); i += i & -i {
+		f.tree[i] += delta
+	}
+	return nil
+}
+
+// PrefixSum returns the sum of the values in [0, i)
+// Time Complexity: O(log n)
+func (f *Fenwick[T]) PrefixSum(i int) (T, error) {
+	var sum T
+	// Secure: bounds checking
+	if i < 0 || i > f.Len() {
+		return sum, fmt.Errorf("%w: prefix of %d of %d", ErrIndex, i, f.Len())
+	}
+	for ; i > 0; i -= i & -i {
+		sum += f.tree[i]
+	}
+	return sum, nil
+}
+
+// RangeSum returns the sum of the values in [lo, hi)
+// Time Complexity: O(log n)
+func (f *Fenwick[T]) RangeSum(lo, hi int) (T, error) {
+	if lo < 0 || hi > f.Len() || lo > hi {
+		var zero T
+		return zero, fmt.Errorf("%w: [%d, %d) of %d", ErrRange, lo, hi, f.Len())
+	}
+	high, _ := f.PrefixSum(hi)
+	low, _ := f.PrefixSum(lo)
+	return high - low, nil
+}
+
+// Search returns the least i such that the sum of [0, i] is at least
+// target, or Len if there is none, for non-negative values only. It
+// descends the implicit tree from its largest power of two, taking each
+// entry whose sum still falls short.
+// Time Complexity: O(log n)
+func (f *Fenwick[T]) Search(target T) int {
+	if f.Len() == 0 {
+		return 0
+	}
+	pos := 0
+	for step := 1 << (bits.Len(uint(f.Len())) - 1); step > 0; step >>= 1 {
+		if next := pos + step; next < len(f.tree) && f.tree[next] < target {
+			pos = next
+			target -= f.tree[next]
+		}
+	}
+	return pos
+}
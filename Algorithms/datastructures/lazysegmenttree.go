@@ -0,0 +1,173 @@
+package datastructures
+
+import "fmt"
+
+// Action describes updates of type U applied to whole ranges of values of
+// a monoid, such as adding to or assigning every value in a range. Apply
+// must distribute over the monoid, so that updating a combined range is
+// the same as combining the updated values, and Compose must combine two
+// updates into one with the effect of applying earlier and then later.
+type Action[T, U any] struct {
+	// Identity is the update that changes nothing
+	Identity U
+	// Apply returns the combination of length values, whose combination
+	// was v, after each has been updated by u
+	Apply func(u U, v T, length int) T
+	// Compose returns the update applying earlier, then later
+	Compose func(later, earlier U) U
+}
+
+// RangeAdd returns the action adding a constant to each value of a range,
+// on the Sum monoid
+func RangeAdd[T Number]() Action[T, T] {
+	return Action[T, T]{
+		Apply:   func(u, v T, length int) T { return v + u*T(length) },
+		Compose: func(later, earlier T) T { return later + earlier },
+	}
+}
+
+// LazySegmentTree is a segment tree that also updates whole ranges of
+// values. An update covering a node's range is applied to the node and
+// left pending there rather than pushed to its descendants; the pending
+// update is pushed down one level only when a later operation needs the
+// node's children, so an update, like a query, visits O(log n) nodes.
+type LazySegmentTree[T, U any] struct {
+	n       int
+	monoid  Monoid[T]
+	action  Action[T, U]
+	tree    []T // tree[1] is the root, covering [0, n), over children 2i, 2i+1
+	pending []U // The update not yet pushed to the children of each node
+}
+
+// NewLazySegmentTree builds a lazy segment tree over a copy of the values
+// Time Complexity: O(n), Space Complexity: O(n)
+func NewLazySegmentTree[T, U any](values []T, monoid Monoid[T], action Action[T, U]) *LazySegmentTree[T, U] {
+	n := len(values)
+	s := &LazySegmentTree[T, U]{n: n, monoid: monoid, action: action}
+	// Secure: a tree over [0, n) split at midpoints has fewer than 4n nodes
+	s.tree = make([]T, 4*max(n, 1))
+	s.pending = make([]U, len(s.tree))
+	for i := range s.pending {
+		s.pending[i] = action.Identity
+	}
+	if n > 0 {
+		s.build(1, 0, n, values)
+	}
+	return s
+}
+
+// build fills node, which covers [lo, hi), from the values
+func (s *LazySegmentTree[T, U]) build(node, lo, hi int, values []T) {
+	if hi-lo == 1 {
+		s.tree[node] = values[lo]
+		return
+	}
+	mid := lo + (hi-lo)/2
+	s.build(2*node, lo, mid, values)
+	s.build(2*node+1, mid, hi, values)
+	s.tree[node] = s.monoid.Combine(s.tree[2*node], s.tree[2*node+1])
+}
+
+// Len returns the number of values
+func (s *LazySegmentTree[T, U]) Len() int {
+	return s.n
+}
+
+// apply updates node, which covers length values, leaving the update
+// pending for its children
+func (s *LazySegmentTree[T, U]) apply(node, length int, u U) {
+	s.tree[node] = s.action.Apply(u, s.tree[node], length)
+	s.pending[node] = s.action.Compose(u, s.pending[node])
+}
+
+// push passes the pending update of node, covering [lo, hi), to its
+// children
+func (s *LazySegmentTree[T, U]) push(node, lo, hi int) {
+	mid := lo + (hi-lo)/2
+	s.apply(2*node, mid-lo, s.pending[node])
+	s.apply(2*node+1, hi-mid, s.pending[node])
+	s.pending[node] = s.action.Identity
+}
+
+// Update applies u to every value in [lo, hi)
+// Time Complexity: O(log n)
+func (s *LazySegmentTree[T, U]) Update(lo, hi int, u U) error {
+	// Secure: bounds checking
+	if lo < 0 || hi > s.n || lo > hi {
+		return fmt.Errorf("%w: [%d, %d) of %d", ErrRange, lo, hi, s.n)
+	}
+	if lo < hi {
+		s.update(1, 0, s.n, lo, hi, u)
+	}
+	return nil
+}
+
+// update applies u to [l, r) below node, which covers [lo, hi)
+func (s *LazySegmentTree[T, U]) update(node, lo, hi, l, r int, u U) {
+	if r <= lo || hi <= l {
+		return
+	}
+	if l <= lo && hi <= r {
+		s.apply(node, hi-lo, u)
+		return
+	}
+	s.push(node, lo, hi)
+	mid := lo + (hi-lo)/2
+	s.update(2*node, lo, mid, l, r, u)
+	s.update(2*node+1, mid, hi, l, r, u)
+	s.tree[node] = s.monoid.Combine(s.tree[2*node], s.tree[2*node+1])
+}
+
+// Query returns the combination of the values in [lo, hi) in index order,
+// the identity for an empty range
+// Time Complexity: O(log n)
+func (s *LazySegmentTree[T, U]) Query(lo, hi int) (T, error) {
+	// Secure: bounds checking
+	if lo < 0 || hi > s.n || lo > hi {
+		return s.monoid.Identity, fmt.Errorf("%w: [%d, %d) of %d", ErrRange, lo, hi, s.n)
+	}
+	if lo == hi {
+		return s.monoid.Identity, nil
+	}
+	return s.query(1, 0, s.n, lo, hi), nil
+}
+
+// query combines the values in [l, r) below node, which covers [lo, hi)
+func (s *LazySegmentTree[T, U]) query(node, lo, hi, l, r int) T {
+	if r <= lo || hi <= l {
+		return s.monoid.Identity
+	}
+	if l <= lo && hi <= r {
+		return s.tree[node]
+	}
+	s.push(node, lo, hi)
+	mid := lo + (hi-lo)/2
+	return s.monoid.Combine(s.query(2*node, lo, mid, l, r), s.query(2*node+1, mid, hi, l, r))
+}
+
+// Set sets the value at index i
+// Time Complexity: O(log n)
+func (s *LazySegmentTree[T, U]) Set(i int, value T) error {
+	// Secure: bounds checking
+	if i < 0 || i >= s.n {
+		return fmt.Errorf("%w: %d of %d", ErrIndex, i, s.n)
+	}
+	s.set(1, 0, s.n, i, value)
+	return nil
+}
+
+// set sets the value at i below node, which covers [lo, hi)
+func (s *LazySegmentTree[T, U]) set(node, lo, hi, i int, value T) {
+	if hi-lo == 1 {
+		s.tree[node] = value
+		return
+	}
+	s.push(node, lo, hi)
+	mid := lo + (hi-lo)/2
+	if i < mid {
+		s.set(2*node, lo, mid, i, value)
+	} else {
+		s.set(2*node+1, mid, hi, i, value)
+	}
+	s.tree[node] = s.monoid.Combine(s.tree[2*node], s.tree[2*node+1])
+}
@@ -0,0 +1,114 @@
+package datastructures
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrIndex is returned when an index lies outside a structure
+	ErrIndex = errors.New("index out of range")
+	// ErrRange is returned when a range [lo, hi) is reversed or reaches
+	// outside a structure
+	ErrRange = errors.New("invalid range")
+)
+
+// Number constraint for the values of a Fenwick tree and the Sum monoid
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// Monoid is an associative operation with an identity element: Combine(a,
+// Combine(b, c)) equals Combine(Combine(a, b), c), and combining with
+// Identity on either side changes nothing. Combine need not be
+// commutative; segment trees combine values in index order.
+type Monoid[T any] struct {
+	Identity T
+	Combine  func(a, b T) T
+}
+
+// Sum returns the monoid of addition
+func Sum[T Number]() Monoid[T] {
+	return Monoid[T]{Combine: func(a, b T) T { return a + b }}
+}
+
+// SegmentTree answers queries combining a range of values under a monoid,
+// such as sums, minimums or products of matrices, while single values
+// change. Each node holds the combination of a range of values, and the
+// ranges of the nodes at each level halve those of the level above.
+type SegmentTree[T any] struct {
+	n      int
+	monoid Monoid[T]
+	// tree[1] is the root and tree[i] the parent of tree[2i] and
+	// tree[2i+1]; the values themselves are the leaves tree[n:2n]
+	tree []T
+}
+
+// NewSegmentTree builds a segment tree over a copy of the values
+// Time Complexity: O(n), Space Complexity: O(n)
+func NewSegmentTree[T any](values []T, monoid Monoid[T]) *SegmentTree[T] {
+	n := len(values)
+	s := &SegmentTree[T]{n: n, monoid: monoid, tree: make([]T, 2*n)}
+	copy(s.tree[n:], values)
+	for i := n - 1; i > 0; i-- {
+		s.tree[i] = monoid.Combine(s.tree[2*i], s.tree[2*i+1])
+	}
+	return s
+}
+
+// Len returns the number of values
+func (s *SegmentTree[T]) Len() int {
+	return s.n
+}
+
+// Get returns the value at index i
+// Time Complexity: O(1)
+func (s *SegmentTree[T]) Get(i int) (T, error) {
+	// Secure: bounds checking
+	if i < 0 || i >= s.n {
+		var zero T
+		return zero, fmt.Errorf("%w: %d of %d", ErrIndex, i, s.n)
+	}
+	return s.tree[s.n+i], nil
+}
+
+// Set sets the value at index i, recombining the nodes above it
+// Time Complexity: O(log n)
+func (s *SegmentTree[T]) Set(i int, value T) error {
+	// Secure: bounds checking
+	if i < 0 || i >= s.n {
+		return fmt.Errorf("%w: %d of %d", ErrIndex, i, s.n)
+	}
+	i += s.n
+	s.tree[i] = value
+	for i /= 2; i > 0; i /= 2 {
+		s.tree[i] = s.monoid.Combine(s.tree[2*i], s.tree[2*i+1])
+	}
+	return nil
+}
+
+// Query returns the combination of the values in [lo, hi) in index order,
+// the identity for an empty range. The bounds climb the tree together;
+// a bound that is a right child on its way up contributes the whole node,
+// kept on its own side so that the order of combination is preserved.
+// Time Complexity: O(log n)
+func (s *SegmentTree[T]) Query(lo, hi int) (T, error) {
+	left, right := s.monoid.Identity, s.monoid.Identity
+	// Secure: bounds checking
+	if lo < 0 || hi > s.n || lo > hi {
+		return left, fmt.Errorf("%w: [%d, %d) of %d", ErrRange, lo, hi, s.n)
+	}
+	for lo, hi = lo+s.n, hi+s.n; lo < hi; lo, hi = lo/2, hi/2 {
+		if lo&1 == 1 {
+			left = s.monoid.Combine(left, s.tree[lo])
+			lo++
+		}
+		if hi&1 == 1 {
+			hi--
+			right = s.monoid.Combine(s.tree[hi], right)
+		}
+	}
+	return s.monoid.Combine(left, right), nil
+}
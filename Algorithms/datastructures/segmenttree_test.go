@@ -0,0 +1,217 @@
+package datastructures
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// concat is a monoid that is not commutative, so that queries must
+// combine values in index order
+var concat = Monoid[string]{Combine: func(a, b string) string { return a + b }}
+
+// TestSegmentTree tests queries and updates against recombining the
+// values directly, under a sum and a non-commutative monoid
+func TestSegmentTree(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, n := range []int{0, 1, 2, 3, 7, 8, 13, 64, 100} {
+		values := make([]int, n)
+		letters := make([]string, n)
+		for i := range values {
+			values[i] = rng.IntN(100) - 50
+			letters[i] = string(rune('a' + rng.IntN(26)))
+		}
+		sums := NewSegmentTree(values, Sum[int]())
+		words := NewSegmentTree(letters, concat)
+		for range 300 {
+			if n > 0 && rng.IntN(2) == 0 {
+				i := rng.IntN(n)
+				values[i] = rng.IntN(100) - 50
+				letters[i] = string(rune('a' + rng.IntN(26)))
+				if sums.Set(i, values[i]) != nil || words.Set(i, letters[i]) != nil {
+					t.Fatalf("Set(%d) failed", i)
+				}
+			}
+			lo := rng.IntN(n + 1)
+			hi := lo + rng.IntN(n-lo+1)
+			wantSum, wantWord := 0, ""
+			for i := lo; i < hi; i++ {
+				wantSum += values[i]
+				wantWord += letters[i]
+			}
+			if got, err := sums.Query(lo, hi); got != wantSum || err != nil {
+				t.Fatalf("n=%d: sum of [%d, %d) = %d, %v, want %d", n, lo, hi, got, err, wantSum)
+			}
+			if got, err := words.Query(lo, hi); got != wantWord || err != nil {
+				t.Fatalf("n=%d: concatenation of [%d, %d) = %q, %v, want %q", n, lo, hi, got, err, wantWord)
+			}
+		}
+	}
+
+	s := NewSegmentTree([]int{5, 6, 7}, Sum[int]())
+	if v, err := s.Get(1); v != 6 || err != nil || s.Len() != 3 {
+		t.Errorf("Get(1) = %d, %v, want 6", v, err)
+	}
+	if _, err := s.Get(3); !errors.Is(err, ErrIndex) {
+		t.Errorf("Get(3) error = %v, want ErrIndex", err)
+	}
+	if err := s.Set(-1, 0); !errors.Is(err, ErrIndex) {
+		t.Errorf("Set(-1) error = %v, want ErrIndex", err)
+	}
+	for _, r := range [][2]int{{-1, 2}, {0, 4}, {2, 1}} {
+		if _, err := s.Query(r[0], r[1]); !errors.Is(err, ErrRange) {
+			t.Errorf("Query(%d, %d) error = %v, want ErrRange", r[0], r[1], err)
+		}
+	}
+}
+
+// minAssign is the action assigning a value to each element of a range,
+// on the minimum monoid; a negative update means no assignment
+var minAssign = Action[int, int]{
+	Identity: -1,
+	Apply: func(u, v int, length int) int {
+		if u < 0 {
+			return v
+		}
+		return u
+	},
+	Compose: func(later, earlier int) int {
+		if later < 0 {
+			return earlier
+		}
+		return later
+	},
+}
+
+// TestLazySegmentTree tests range updates and queries against updating
+// and recombining the values directly
+func TestLazySegmentTree(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	minimum := Monoid[int]{Identity: math.MaxInt, Combine: func(a, b int) int { return min(a, b) }}
+	for _, n := range []int{0, 1, 2, 5, 16, 37} {
+		values := make([]int, n)
+		for i := range values {
+			values[i] = rng.IntN(1000)
+		}
+		sums := NewLazySegmentTree(values, Sum[int](), RangeAdd[int]())
+		mins := NewLazySegmentTree(values, minimum, minAssign)
+		added, assigned := append([]int(nil), values...), append([]int(nil), values...)
+		for range 500 {
+			lo := rng.IntN(n + 1)
+			hi := lo + rng.IntN(n-lo+1)
+			switch rng.IntN(3) {
+			case 0:
+				delta := rng.IntN(21) - 10
+				if err := sums.Update(lo, hi, delta); err != nil {
+					t.Fatal(err)
+				}
+				for i := lo; i < hi; i++ {
+					added[i] += delta
+				}
+				v := rng.IntN(1000)
+				if err := mins.Update(lo, hi, v); err != nil {
+					t.Fatal(err)
+				}
+				for i := lo; i < hi; i++ {
+					assigned[i] = v
+				}
+			case 1:
+				if n > 0 {
+					i, v := rng.IntN(n), rng.IntN(1000)
+					sums.Set(i, v)
+					mins.Set(i, v)
+					added[i], assigned[i] = v, v
+				}
+			}
+			wantSum, wantMin := 0, math.MaxInt
+			for i := lo; i < hi; i++ {
+				wantSum += added[i]
+				wantMin = min(wantMin, assigned[i])
+			}
+			if got, err := sums.Query(lo, hi); got != wantSum || err != nil {
+				t.Fatalf("n=%d: sum of [%d, %d) = %d, %v, want %d", n, lo, hi, got, err, wantSum)
+			}
+			if got, err := mins.Query(lo, hi); got != wantMin || err != nil {
+				t.Fatalf("n=%d: min of [%d, %d) = %d, %v, want %d", n, lo, hi, got, err, wantMin)
+			}
+		}
+	}
+
+	s := NewLazySegmentTree([]int{1, 2, 3}, Sum[int](), RangeAdd[int]())
+	if err := s.Update(1, 4, 1); !errors.Is(err, ErrRange) {
+		t.Errorf("Update(1, 4) error = %v, want ErrRange", err)
+	}
+	if _, err := s.Query(2, 1); !errors.Is(err, ErrRange) {
+		t.Errorf("Query(2, 1) error = %v, want ErrRange", err)
+	}
+	if err := s.Set(3, 0); !errors.Is(err, ErrIndex) || s.Len() != 3 {
+		t.Errorf("Set(3) error = %v, want ErrIndex", err)
+	}
+}
+
+// TestFenwick tests prefix and range sums and searches against summing
+// the values directly
+func TestFenwick(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	for _, n := range []int{0, 1, 2, 9, 16, 100} {
+		values := make([]int, n)
+		for i := range values {
+			values[i] = rng.IntN(10)
+		}
+		f := FenwickFrom(values)
+		g := NewFenwick[int](n)
+		for i, v := range values {
+			g.Add(i, v)
+		}
+		for range 300 {
+			if n > 0 {
+				i, delta := rng.IntN(n), rng.IntN(10)
+				values[i] += delta
+				f.Add(i, delta)
+				g.Add(i, delta)
+			}
+			lo := rng.IntN(n + 1)
+			hi := lo + rng.IntN(n-lo+1)
+			want := 0
+			for i := lo; i < hi; i++ {
+				want += values[i]
+			}
+			if got, err := f.RangeSum(lo, hi); got != want || err != nil {
+				t.Fatalf("n=%d: RangeSum(%d, %d) = %d, %v, want %d", n, lo, hi, got, err, want)
+			}
+			if got, _ := g.RangeSum(lo, hi); got != want {
+				t.Fatalf("n=%d: RangeSum(%d, %d) of the tree built by Add = %d, want %d", n, lo, hi, got, want)
+			}
+
+			target := rng.IntN(10*n + 20)
+			wantIndex, sum := n, 0
+			for i, v := range values {
+				if sum += v; sum >= target {
+					wantIndex = i
+					break
+				}
+			}
+			if got := f.Search(target); got != wantIndex {
+				t.Fatalf("n=%d: Search(%d) = %d, want %d in %v", n, target, got, wantIndex, values)
+			}
+		}
+	}
+
+	floats := FenwickFrom([]float64{0.5, 0.25, 0.125})
+	if s, err := floats.PrefixSum(3); s != 0.875 || err != nil {
+		t.Errorf("PrefixSum(3) = %v, %v, want 0.875", s, err)
+	}
+	if _, err := floats.PrefixSum(4); !errors.Is(err, ErrIndex) {
+		t.Errorf("PrefixSum(4) error = %v, want ErrIndex", err)
+	}
+	if err := floats.Add(3, 1); !errors.Is(err, ErrIndex) {
+		t.Errorf("Add(3) error = %v, want ErrIndex", err)
+	}
+	if _, err := floats.RangeSum(2, 1); !errors.Is(err, ErrRange) {
+		t.Errorf("RangeSum(2, 1) error = %v, want ErrRange", err)
+	}
+	if NewFenwick[int](-1).Len() != 0 {
+		t.Error("NewFenwick(-1) is not empty")
+	}
+}
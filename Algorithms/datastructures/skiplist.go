@@ -0,0 +1,213 @@
+package datastructures
+
+import (
+	"cmp"
+	"iter"
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+)
+
+// maxSkipLevel bounds the levels of a skip list; with a quarter of the
+// nodes reaching each next level, it suffices for 4^32 keys
+const maxSkipLevel = 32
+
+// SkipList is a skip list mapping ordered keys to values: a sorted linked
+// list in which each node also links forward at a random number of higher
+// levels, a quarter as many nodes reaching each level as the one below, so
+// that searches skip ahead in O(log n) expected steps. A SkipList is safe
+// for concurrent use; lookups and iterations share a read lock, and only
+// updates take it exclusively.
+type SkipList[K, V any] struct {
+	mu      sync.RWMutex
+	head    skipNode[K, V] // Sentinel before the first node, at every level
+	level   int            // Levels in use
+	size    int
+	compare func(a, b K) int
+}
+
+// skipNode is a node of a SkipList, with a forward link at each of its
+// levels
+type skipNode[K, V any] struct {
+	key   K
+	value V
+	next  []*skipNode[K, V]
+}
+
+// NewSkipList creates an empty skip list ordering keys by cmp.Compare
+func NewSkipList[K cmp.Ordered, V any]() *SkipList[K, V] {
+	return NewSkipListFunc[K, V](cmp.Compare[K])
+}
+
+// NewSkipListFunc creates an empty skip list ordering keys by compare,
+// which returns a negative number, zero or a positive number as a is less
+// than, equal to or greater than b
+func NewSkipListFunc[K, V any](compare func(a, b K) int) *SkipList[K, V] {
+	s := &SkipList[K, V]{level: 1, compare: compare}
+	s.head.next = make([]*skipNode[K, V], maxSkipLevel)
+	return s
+}
+
+// Len returns the number of keys
+func (s *SkipList[K, V]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.size
+}
+
+// seek returns the last node before key at each level, filling update if
+// it is not nil, and the node after it at the bottom level. The caller
+// must hold the lock.
+func (s *SkipList[K, V]) seek(key K, update []*skipNode[K, V]) *skipNode[K, V] {
+	n := &s.head
+	for level := s.level - 1; level >= 0; level-- {
+		for next := n.next[level]; next != nil && s.compare(next.key, key) < 0; next = n.next[level] {
+			n = next
+		}
+		if update != nil {
+			update[level] = n
+		}
+	}
+	return n.next[0]
+}
+
+// Get returns the value of key and whether it is present
+// Time Complexity: O(log n) expected
+func (s *SkipList[K, V]) Get(key K) (V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if n := s.seek(key, nil); n != nil && s.compare(n.key, key) == 0 {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Insert sets the value of key and reports whether key is new
+// Time Complexity: O(log n) expected
+func (s *SkipList[K, V]) Insert(key K, value V) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var update [maxSkipLevel]*skipNode[K, V]
+	if n := s.seek(key, update[:]); n != nil && s.compare(n.key, key) == 0 {
+		n.value = value
+		return false
+	}
+
+	level := randomLevel()
+	for ; s.level < level; s.level++ {
+		update[s.level] = &s.head
+	}
+	n := &skipNode[K, V]{key: key, value: value, next: make([]*skipNode[K, V], level)}
+	for i := range level {
+		n.next[i], update[i].next[i] = update[i].next[i], n
+	}
+	s.size++
+	return true
+}
+
+// randomLevel returns the level of a new node: level k with probability
+// 3/4^k, counting pairs of trailing zero bits of a random word
+func randomLevel() int {
+	return min(1+bits.TrailingZeros64(rand.Uint64())/2, maxSkipLevel)
+}
+
+// Delete removes key and reports whether it was present. The removed node
+// keeps its forward links, so an iteration standing on it carries on to
+// the nodes that follow.
+// Time Complexity: O(log n) expected
+func (s *SkipList[K, V]) Delete(key K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var update [maxSkipLevel]*skipNode[K, V]
+	n := s.seek(key, update[:])
+	if n == nil || s.compare(n.key, key) != 0 {
+		return false
+	}
+	for i := range n.next {
+		update[i].next[i] = n.next[i]
+	}
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+	s.size--
+	return true
+}
+
+// Min returns the least key and its value, or ok false if the list is
+// empty
+// Time Complexity: O(1)
+func (s *SkipList[K, V]) Min() (key K, value V, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if n := s.head.next[0]; n != nil {
+		return n.key, n.value, true
+	}
+	return key, value, false
+}
+
+// Max returns the greatest key and its value, or ok false if the list is
+// empty
+// Time Complexity: O(log n) expected
+func (s *SkipList[K, V]) Max() (key K, value V, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := &s.head
+	for level := s.level - 1; level >= 0; level-- {
+		for n.next[level] != nil {
+			n = n.next[level]
+		}
+	}
+	if n == &s.head {
+		return key, value, false
+	}
+	return n.key, n.value, true
+}
+
+// All iterates over the keys and values in increasing key order
+// Time Complexity: O(n) for the whole iteration, Space Complexity: O(1)
+func (s *SkipList[K, V]) All() iter.Seq2[K, V] {
+	return s.ascend(nil, nil)
+}
+
+// Range iterates over the keys in [lo, hi), with their values, in
+// increasing key order
+// Time Complexity: O(log n + k) expected for k keys, Space Complexity: O(1)
+func (s *SkipList[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return s.ascend(&lo, &hi)
+}
+
+// From iterates over the keys from lo up, with their values, in increasing
+// key order
+// Time Complexity: O(log n + k) expected for k keys, Space Complexity: O(1)
+func (s *SkipList[K, V]) From(lo K) iter.Seq2[K, V] {
+	return s.ascend(&lo, nil)
+}
+
+// ascend iterates over the keys from lo up to hi, either bound being
+// absent if nil. The read lock is held only to step from node to node, not
+// while the loop body runs, so the body may update the list. Iteration is
+// weakly consistent: it yields each key present throughout, in order, and
+// may or may not yield keys inserted or deleted meanwhile.
+func (s *SkipList[K, V]) ascend(lo, hi *K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		s.mu.RLock()
+		n := s.head.next[0]
+		if lo != nil {
+			n = s.seek(*lo, nil)
+		}
+		for n != nil {
+			key, value := n.key, n.value
+			s.mu.RUnlock()
+			if hi != nil && s.compare(key, *hi) >= 0 {
+				return
+			}
+			if !yield(key, value) {
+				return
+			}
+			s.mu.RLock()
+			n = n.next[0]
+		}
+		s.mu.RUnlock()
+	}
+}
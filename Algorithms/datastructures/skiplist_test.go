@@ -0,0 +1,188 @@
+package datastructures
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// skipKeys returns the keys of the skip list by walking its bottom level,
+// checking that every level is sorted and a subsequence of the one below
+func skipKeys(t *testing.T, s *SkipList[int, int]) []int {
+	t.Helper()
+	var below []int
+	for level := range s.level {
+		keys := []int{}
+		for n := s.head.next[level]; n != nil; n = n.next[level] {
+			keys = append(keys, n.key)
+		}
+		if !slices.IsSorted(keys) {
+			t.Fatalf("level %d is not sorted: %v", level, keys)
+		}
+		for _, k := range keys {
+			if level > 0 && !slices.Contains(below, k) {
+				t.Fatalf("key %d at level %d is missing below", k, level)
+			}
+		}
+		if level == 0 {
+			below = keys
+		}
+	}
+	return below
+}
+
+// TestSkipListRandom tests random inserts and deletes against a map
+func TestSkipListRandom(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	s := NewSkipList[int, int]()
+	want := map[int]int{}
+	for step := range 3000 {
+		key := rng.IntN(400)
+		_, present := want[key]
+		if rng.IntN(3) == 0 {
+			delete(want, key)
+			if got := s.Delete(key); got != present {
+				t.Fatalf("step %d: Delete(%d) = %t, want %t", step, key, got, present)
+			}
+		} else {
+			want[key] = step
+			if got := s.Insert(key, step); got == present {
+				t.Fatalf("step %d: Insert(%d) = %t, want %t", step, key, got, !present)
+			}
+		}
+		probe := rng.IntN(420) - 10
+		_, present = want[probe]
+		if v, ok := s.Get(probe); v != want[probe] || ok != present {
+			t.Fatalf("step %d: Get(%d) = %d, %t", step, probe, v, ok)
+		}
+		if step%100 == 0 && len(skipKeys(t, s)) != len(want) {
+			t.Fatalf("step %d: %d keys linked, want %d", step, len(skipKeys(t, s)), len(want))
+		}
+	}
+	if s.Len() != len(want) {
+		t.Errorf("Len = %d, want %d", s.Len(), len(want))
+	}
+
+	keys := skipKeys(t, s)
+	if k, v, ok := s.Min(); !ok || k != keys[0] || v != want[k] {
+		t.Errorf("Min = %d, %d, %t, want %d", k, v, ok, keys[0])
+	}
+	if k, _, ok := s.Max(); !ok || k != keys[len(keys)-1] {
+		t.Errorf("Max = %d, %t, want %d", k, ok, keys[len(keys)-1])
+	}
+	for range 300 {
+		lo, hi := rng.IntN(450)-20, rng.IntN(450)-20
+		wantKeys := []int{}
+		for _, k := range keys {
+			if lo <= k && k < hi {
+				wantKeys = append(wantKeys, k)
+			}
+		}
+		got := []int{}
+		for k := range s.Range(lo, hi) {
+			got = append(got, k)
+		}
+		if !slices.Equal(got, wantKeys) {
+			t.Fatalf("Range(%d, %d) = %v, want %v", lo, hi, got, wantKeys)
+		}
+		wantKeys = wantKeys[:0]
+		for _, k := range keys {
+			if lo <= k {
+				wantKeys = append(wantKeys, k)
+			}
+		}
+		got = got[:0]
+		for k := range s.From(lo) {
+			got = append(got, k)
+		}
+		if !slices.Equal(got, wantKeys) {
+			t.Fatalf("From(%d) = %v, want %v", lo, got, wantKeys)
+		}
+	}
+
+	for k := range want {
+		s.Delete(k)
+	}
+	if _, _, ok := s.Max(); ok || s.Len() != 0 || s.level != 1 {
+		t.Errorf("emptied list: Len %d, level %d", s.Len(), s.level)
+	}
+}
+
+// TestSkipListModifyWhileIterating tests that the loop body may update
+// the list, and that keys present throughout are all yielded in order
+func TestSkipListModifyWhileIterating(t *testing.T) {
+	s := NewSkipListFunc[int, int](func(a, b int) int { return cmp.Compare(a, b) })
+	for i := range 100 {
+		s.Insert(2*i, i)
+	}
+	got := []int{}
+	deletedAhead := map[int]bool{}
+	for k := range s.All() {
+		got = append(got, k)
+		s.Delete(k) // Delete the node being visited
+		if k%8 == 0 && s.Delete(k+4) {
+			deletedAhead[k+4] = true
+		}
+		s.Insert(k+1, -1) // Insert just ahead
+	}
+	for i, k := range got[1:] {
+		if k <= got[i] {
+			t.Fatalf("iteration went backwards: %v", got)
+		}
+	}
+	for i := 0; i < 200; i += 2 {
+		if !deletedAhead[i] && !slices.Contains(got, i) {
+			t.Fatalf("key %d, present until visited, was not yielded: %v", i, got)
+		}
+	}
+}
+
+// TestSkipListConcurrent tests concurrent readers, writers and iterators;
+// run with -race to check the locking
+func TestSkipListConcurrent(t *testing.T) {
+	s := NewSkipList[int, int]()
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Go(func() {
+			rng := rand.New(rand.NewPCG(uint64(w), 7))
+			for range 2000 {
+				k := rng.IntN(256)
+				switch rng.IntN(4) {
+				case 0:
+					s.Delete(k)
+				case 1:
+					s.Get(k)
+				default:
+					s.Insert(k, w)
+				}
+			}
+		})
+	}
+	wg.Go(func() {
+		for range 20 {
+			prev := -1
+			for k := range s.All() {
+				if k <= prev {
+					t.Errorf("concurrent iteration out of order: %d after %d", k, prev)
+				}
+				prev = k
+			}
+		}
+	})
+	wg.Wait()
+	if keys := skipKeys(t, s); len(keys) != s.Len() {
+		t.Errorf("%d keys linked, Len %d", len(keys), s.Len())
+	}
+}
+
+func BenchmarkSkipListInsert(b *testing.B) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for b.Loop() {
+		s := NewSkipList[int, int]()
+		for range 10000 {
+			s.Insert(rng.Int(), 0)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Package dp provides dynamic programming algorithms. Functions returning
+// only an optimal value have companions that also reconstruct a solution
+// attaining it: the subsequence, edit script, item set, coins or cuts.
+//
+// Functions whose result can exceed an int return ErrOverflow rather than a
+// wrapped-around value, and those whose tables grow with a numeric argument
+// (a capacity or an amount) return ErrTableTooLarge rather than exhausting
+// memory. FibonacciBig and FactorialBig have no such limit.
+package dp
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+)
+
+// MaxFibonacci is the largest n whose Fibonacci number fits in an int64
+const MaxFibonacci = 92
+
+// MaxFactorial is the largest n whose factorial fits in an int64
+const MaxFactorial = 20
+
+// maxTableCells bounds the cells of a table sized by a numeric argument
+const maxTableCells = 1 << 26
+
+var (
+	// ErrNegative is returned when a size, index, weight, value, price or
+	// amount is negative, a coin is not positive or a job ends before it
+	// starts
+	ErrNegative = errors.New("negative argument")
+	// ErrLengthMismatch is returned when slices that describe the same items,
+	// or the rows of a grid, differ in length
+	ErrLengthMismatch = errors.New("slice lengths differ")
+	// ErrOverflow is returned when a result does not fit in an int
+	ErrOverflow = errors.New("result overflows int")
+	// ErrTableTooLarge is returned when the DP table would need more than
+	// maxTableCells cells
+	ErrTableTooLarge = errors.New("DP table too large")
+	// ErrNoSolution is returned when no solution exists, such as for an
+	// amount no multiset of coins makes
+	ErrNoSolution = errors.New("no solution")
+)
+
+// Fibonacci calculates the nth Fibonacci number using a DP table. It returns
+// ErrOverflow for n above MaxFibonacci; FibonacciBig has no limit.
+// Time Complexity: O(n), Space Complexity: O(n)
+func Fibonacci(n int) (int, error) {
+	// Secure: validate input
+	if n < 0 {
+		return 0, ErrNegative
+	}
+	// Secure: F(93) exceeds math.MaxInt64
+	if n > MaxFibonacci {
+		return 0, ErrOverflow
+	}
+	if n <= 1 {
+		return n, nil
+	}
+
+	// DP table
+	dp := make([]int, n+1)
+	dp[1] = 1
+	for i := 2; i <= n; i++ {
+		dp[i] = dp[i-1] + dp[i-2]
+	}
+	return dp[n], nil
+}
+
+// FibonacciOptimized calculates the nth Fibonacci number keeping only the
+// last two values. It returns ErrOverflow for n above MaxFibonacci.
+// Time Complexity: O(n), Space Complexity: O(1)
+func FibonacciOptimized(n int) (int, error) {
+	// Secure: validate input
+	if n < 0 {
+		return 0, ErrNegative
+	}
+	// Secure: F(93) exceeds math.MaxInt64
+	if n > MaxFibonacci {
+		return 0, ErrOverflow
+	}
+	if n <= 1 {
+		return n, nil
+	}
+
+	a, b := 0, 1
+	for i := 2; i <= n; i++ {
+		a, b = b, a+b
+	}
+	return b, nil
+}
+
+// FibonacciBig calculates the nth Fibonacci number exactly by fast doubling:
+// F(2k) = F(k)(2F(k+1) - F(k)) and F(2k+1) = F(k)² + F(k+1)²
+// Time Complexity: O(log n) multiplications, Space Complexity: O(n) bits
+func FibonacciBig(n int) (*big.Int, error) {
+	// Secure: validate input
+	if n < 0 {
+		return nil, ErrNegative
+	}
+
+	// a, b hold F(k), F(k+1) for k the bits of n read so far
+	a, b := big.NewInt(0), big.NewInt(1)
+	t := new(big.Int)
+	for bit := bits.Len(uint(n)) - 1; bit >= 0; bit-- {
+		// a, b = F(2k), F(2k+1)
+		t.Lsh(b, 1).Sub(t, a).Mul(t, a)
+		b.Mul(b, b).Add(b, a.Mul(a, a))
+		a, t = t, a
+		if n>>bit&1 == 1 {
+			// a, b = F(2k+1), F(2k+2)
+			a.Add(a, b)
+			a, b = b, a
+		}
+	}
+	return a, nil
+}
+
+// Factorial calculates n!. It returns ErrOverflow for n above MaxFactorial;
+// FactorialBig has no limit.
+// Time Complexity: O(n), Space Complexity: O(1)
+func Factorial(n int) (int, error) {
+	// Secure: validate input
+	if n < 0 {
+		return 0, ErrNegative
+	}
+	// Secure: 21! exceeds math.MaxInt64
+	if n > MaxFactorial {
+		return 0, ErrOverflow
+	}
+
+	result := 1
+	for i := 2; i <= n; i++ {
+		result *= i
+	}
+	return result, nil
+}
+
+// FactorialBig calculates n! exactly
+// Time Complexity: O(n) multiplications, Space Complexity: O(n log n) bits
+func FactorialBig(n int) (*big.Int, error) {
+	// Secure: validate input
+	if n < 0 {
+		return nil, ErrNegative
+	}
+	// MulRange multiplies the range by splitting it in halves, keeping the
+	// operands balanced
+	return new(big.Int).MulRange(1, int64(n)), nil
+}
@@ -0,0 +1,81 @@
+package dp
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// TestFibonacci tests the Fibonacci implementations against each other and
+// their limits
+func TestFibonacci(t *testing.T) {
+	want := []int{0, 1, 1, 2, 3, 5, 8, 13, 21, 34, 55}
+	for n, w := range want {
+		if got, err := Fibonacci(n); got != w || err != nil {
+			t.Errorf("Fibonacci(%d) = %d, %v, want %d", n, got, err, w)
+		}
+	}
+	for n := range MaxFibonacci + 1 {
+		table, err1 := Fibonacci(n)
+		optimized, err2 := FibonacciOptimized(n)
+		exact, err3 := FibonacciBig(n)
+		if err := errors.Join(err1, err2, err3); err != nil || table != optimized || !exact.IsInt64() || exact.Int64() != int64(table) {
+			t.Fatalf("Fibonacci(%d) = %d, FibonacciOptimized = %d, FibonacciBig = %v (%v)", n, table, optimized, exact, err)
+		}
+	}
+	if got, _ := Fibonacci(MaxFibonacci); got != 7540113804746346429 {
+		t.Errorf("Fibonacci(%d) = %d", MaxFibonacci, got)
+	}
+
+	for _, n := range []int{MaxFibonacci + 1, 1000} {
+		if _, err := Fibonacci(n); !errors.Is(err, ErrOverflow) {
+			t.Errorf("Fibonacci(%d) error = %v, want ErrOverflow", n, err)
+		}
+		if _, err := FibonacciOptimized(n); !errors.Is(err, ErrOverflow) {
+			t.Errorf("FibonacciOptimized(%d) error = %v, want ErrOverflow", n, err)
+		}
+	}
+	if _, err := Fibonacci(-1); !errors.Is(err, ErrNegative) {
+		t.Errorf("Fibonacci(-1) error = %v, want ErrNegative", err)
+	}
+	if _, err := FibonacciBig(-1); !errors.Is(err, ErrNegative) {
+		t.Errorf("FibonacciBig(-1) error = %v, want ErrNegative", err)
+	}
+
+	// F(n+1) = F(n) + F(n-1) well beyond the int range
+	a, _ := FibonacciBig(299)
+	b, _ := FibonacciBig(300)
+	c, _ := FibonacciBig(301)
+	if new(big.Int).Add(a, b).Cmp(c) != 0 {
+		t.Errorf("FibonacciBig(299) + FibonacciBig(300) != FibonacciBig(301)")
+	}
+	f100, _ := FibonacciBig(100)
+	if f100.String() != "354224848179261915075" {
+		t.Errorf("FibonacciBig(100) = %v", f100)
+	}
+}
+
+// TestFactorial tests Factorial against FactorialBig and their limits
+func TestFactorial(t *testing.T) {
+	exact := big.NewInt(1)
+	for n := range MaxFactorial + 1 {
+		if n > 0 {
+			exact.Mul(exact, big.NewInt(int64(n)))
+		}
+		got, err := Factorial(n)
+		gotBig, errBig := FactorialBig(n)
+		if err != nil || errBig != nil || int64(got) != exact.Int64() || gotBig.Cmp(exact) != 0 {
+			t.Fatalf("Factorial(%d) = %d, FactorialBig = %v, want %v", n, got, gotBig, exact)
+		}
+	}
+	if _, err := Factorial(MaxFactorial + 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Factorial(%d) error = %v, want ErrOverflow", MaxFactorial+1, err)
+	}
+	if _, err := Factorial(-1); !errors.Is(err, ErrNegative) {
+		t.Errorf("Factorial(-1) error = %v, want ErrNegative", err)
+	}
+	f25, _ := FactorialBig(25)
+	if f25.String() != "15511210043330985984000000" {
+		t.Errorf("FactorialBig(25) = %v", f25)
+	}
+}
@@ -0,0 +1,104 @@
+package dp
+
+import "sync"
+
+// Memo caches the results of a function by key, for top-down dynamic
+// programming. A bounded Memo evicts the least recently used entry once it
+// holds capacity entries. A Memo is safe for concurrent use.
+type Memo[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[K]*memoNode[K, V]
+	// root is the sentinel of a circular list of the entries, the most
+	// recently used at root.next and the least at root.prev
+	root memoNode[K, V]
+}
+
+// memoNode is an entry of a Memo's recency list
+type memoNode[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *memoNode[K, V]
+}
+
+// NewMemo creates an empty Memo holding at most capacity entries, or any
+// number of them if capacity is zero or negative
+func NewMemo[K comparable, V any](capacity int) *Memo[K, V] {
+	m := &Memo[K, V]{capacity: capacity, entries: make(map[K]*memoNode[K, V])}
+	m.root.prev, m.root.next = &m.root, &m.root
+	return m
+}
+
+// Len returns the number of cached entries
+func (m *Memo[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// Get returns the value cached for key, marking it the most recently used
+// Time Complexity: O(1), Space Complexity: O(1)
+func (m *Memo[K, V]) Get(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	node, ok := m.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	m.moveToFront(node)
+	return node.value, true
+}
+
+// Put caches value for key, evicting the least recently used entry if a
+// bounded Memo is full
+// Time Complexity: O(1), Space Complexity: O(1)
+func (m *Memo[K, V]) Put(key K, value V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if node, ok := m.entries[key]; ok {
+		node.value = value
+		m.moveToFront(node)
+		return
+	}
+
+	if m.capacity > 0 && len(m.entries) >= m.capacity {
+		oldest := m.root.prev
+		m.unlink(oldest)
+		delete(m.entries, oldest.key)
+	}
+	node := &memoNode[K, V]{key: key, value: value}
+	m.entries[key] = node
+	m.pushFront(node)
+}
+
+// Do returns the value cached for key, computing and caching it with fn if
+// there is none. The lock is not held while fn runs, so fn may call Do
+// recursively; concurrent calls for a missing key may each run fn, which
+// must therefore be a pure function of the key.
+func (m *Memo[K, V]) Do(key K, fn func() V) V {
+	if value, ok := m.Get(key); ok {
+		return value
+	}
+	value := fn()
+	m.Put(key, value)
+	return value
+}
+
+// moveToFront marks node the most recently used
+func (m *Memo[K, V]) moveToFront(node *memoNode[K, V]) {
+	m.unlink(node)
+	m.pushFront(node)
+}
+
+// pushFront inserts node at the front of the recency list
+func (m *Memo[K, V]) pushFront(node *memoNode[K, V]) {
+	node.prev, node.next = &m.root, m.root.next
+	node.prev.next, node.next.prev = node, node
+}
+
+// unlink removes node from the recency list
+func (m *Memo[K, V]) unlink(node *memoNode[K, V]) {
+	node.prev.next, node.next.prev = node.next, node.prev
+	node.prev, node.next = nil, nil
+}
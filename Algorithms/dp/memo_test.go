@@ -0,0 +1,98 @@
+package dp
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestMemo tests caching and least recently used eviction
+func TestMemo(t *testing.T) {
+	m := NewMemo[string, int](2)
+	m.Put("a", 1)
+	m.Put("b", 2)
+	if v, ok := m.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %d, %v, want 1, true", v, ok)
+	}
+	// b is now the least recently used, so adding c evicts it
+	m.Put("c", 3)
+	if _, ok := m.Get("b"); ok {
+		t.Errorf("Get(b) found an evicted entry")
+	}
+	if v, ok := m.Get("a"); !ok || v != 1 || m.Len() != 2 {
+		t.Errorf("Get(a) = %d, %v with Len %d, want 1, true with Len 2", v, ok, m.Len())
+	}
+	// Updating an entry keeps one copy and marks it recently used
+	m.Put("c", 30)
+	m.Put("d", 4)
+	if v, ok := m.Get("c"); !ok || v != 30 {
+		t.Errorf("Get(c) = %d, %v, want 30, true", v, ok)
+	}
+	if _, ok := m.Get("a"); ok || m.Len() != 2 {
+		t.Errorf("Get(a) found an evicted entry, Len %d", m.Len())
+	}
+
+	unbounded := NewMemo[int, int](0)
+	for i := range 1000 {
+		unbounded.Put(i, i*i)
+	}
+	if v, ok := unbounded.Get(0); !ok || v != 0 || unbounded.Len() != 1000 {
+		t.Errorf("unbounded Memo lost entries: Get(0) = %d, %v, Len %d", v, ok, unbounded.Len())
+	}
+}
+
+// TestMemoDo tests that Do computes each value once and may recurse
+func TestMemoDo(t *testing.T) {
+	m := NewMemo[int, int](0)
+	calls := 0
+	var triangle func(n int) int
+	triangle = func(n int) int {
+		if n == 0 {
+			return 0
+		}
+		return m.Do(n, func() int {
+			calls++
+			return n + triangle(n-1)
+		})
+	}
+	if got := triangle(100); got != 5050 || calls != 100 {
+		t.Errorf("triangle(100) = %d with %d calls, want 5050 with 100", got, calls)
+	}
+	if got := triangle(100); got != 5050 || calls != 100 {
+		t.Errorf("cached triangle(100) = %d with %d calls, want 5050 with 100", got, calls)
+	}
+
+	// A bounded memo still gives correct results, recomputing what it evicted
+	bounded := NewMemo[int, int](3)
+	var fib func(n int) int
+	fib = func(n int) int {
+		if n <= 1 {
+			return n
+		}
+		return bounded.Do(n, func() int { return fib(n-1) + fib(n-2) })
+	}
+	if got := fib(40); got != 102334155 || bounded.Len() > 3 {
+		t.Errorf("fib(40) with a bounded memo = %d, Len %d", got, bounded.Len())
+	}
+}
+
+// TestMemoConcurrent tests a bounded Memo shared by many goroutines
+func TestMemoConcurrent(t *testing.T) {
+	m := NewMemo[int, int](50)
+	var calls atomic.Int64
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 1000 {
+				key := (i * (g + 1)) % 100
+				if got := m.Do(key, func() int { calls.Add(1); return key * 2 }); got != key*2 {
+					t.Errorf("Do(%d) = %d, want %d", key, got, key*2)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	if m.Len() > 50 || calls.Load() == 0 {
+		t.Errorf("Len = %d after %d calls, want at most 50", m.Len(), calls.Load())
+	}
+}
@@ -0,0 +1,164 @@
+package dp
+
+import (
+	"cmp"
+	"slices"
+
+	"hellogolang/Algorithms/searching"
+)
+
+// FibonacciMemo calculates the nth Fibonacci number top-down, caching each
+// F(i) in a Memo. It returns ErrOverflow for n above MaxFibonacci.
+// Time Complexity: O(n), Space Complexity: O(n)
+func FibonacciMemo(n int) (int, error) {
+	// Secure: validate input
+	if n < 0 {
+		return 0, ErrNegative
+	}
+	// Secure: F(93) exceeds math.MaxInt64
+	if n > MaxFibonacci {
+		return 0, ErrOverflow
+	}
+
+	memo := NewMemo[int, int](0)
+	var fib func(i int) int
+	fib = func(i int) int {
+		if i <= 1 {
+			return i
+		}
+		return memo.Do(i, func() int { return fib(i-1) + fib(i-2) })
+	}
+	return fib(n), nil
+}
+
+// GridPaths counts the paths from the top-left to the bottom-right cell of
+// a grid moving only right or down, avoiding the cells where blocked is
+// true. It is computed top-down, caching the count for each cell in a Memo,
+// and returns ErrOverflow if the count does not fit in an int.
+// Time Complexity: O(rows * cols), Space Complexity: O(rows * cols)
+func GridPaths(blocked [][]bool) (int, error) {
+	rows := len(blocked)
+	if rows == 0 || len(blocked[0]) == 0 {
+		return 0, nil
+	}
+	cols := len(blocked[0])
+
+	// Secure: validate the grid is rectangular
+	for _, row := range blocked {
+		if len(row) != cols {
+			return 0, ErrLengthMismatch
+		}
+	}
+	// Secure: the memo holds up to one entry per cell
+	if rows > maxTableCells/cols {
+		return 0, ErrTableTooLarge
+	}
+
+	// paths(r, c) counts the paths from the top-left to cell (r, c), or is
+	// -1 once that count overflows
+	type cell struct{ r, c int }
+	memo := NewMemo[cell, int](0)
+	var paths func(r, c int) int
+	paths = func(r, c int) int {
+		if r < 0 || c < 0 || blocked[r][c] {
+			return 0
+		}
+		if r == 0 && c == 0 {
+			return 1
+		}
+		return memo.Do(cell{r, c}, func() int {
+			up, left := paths(r-1, c), paths(r, c-1)
+			if up < 0 || left < 0 {
+				return -1
+			}
+			if total, ok := checkedAdd(up, left); ok {
+				return total
+			}
+			return -1
+		})
+	}
+
+	total := paths(rows-1, cols-1)
+	if total < 0 {
+		return 0, ErrOverflow
+	}
+	return total, nil
+}
+
+// Job is an interval of time [Start, End) earning Weight if scheduled
+type Job struct {
+	Start, End, Weight int
+}
+
+// WeightedIntervalScheduling finds the greatest total weight of pairwise
+// non-overlapping jobs, and the indices of the jobs attaining it in
+// increasing order. Jobs are half-open, so one may start when another
+// ends. Ordered by end, the best of the first j jobs either skips job j or
+// takes it with the best of the jobs ending by its start; these are
+// computed top-down and cached in a Memo.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func WeightedIntervalScheduling(jobs []Job) (int, []int, error) {
+	// Secure: validate jobs
+	for _, job := range jobs {
+		if job.End < job.Start || job.Weight < 0 {
+			return 0, nil, ErrNegative
+		}
+	}
+
+	// order lists the jobs by end, then start, so that a job of zero length
+	// follows the jobs ending where it is; compatible[j] is the number of
+	// jobs before order[j] that end by its start
+	order := make([]int, len(jobs))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Or(cmp.Compare(jobs[a].End, jobs[b].End), cmp.Compare(jobs[a].Start, jobs[b].Start))
+	})
+	compatible := make([]int, len(jobs))
+	for j, i := range order {
+		// A job of zero length ends by its own start, and so would count
+		// itself and the jobs of zero length following it
+		p := searching.SearchMonotonic(func(k int) bool { return jobs[order[k]].End > jobs[i].Start }, 0, len(order))
+		compatible[j] = min(p, j)
+	}
+
+	// best(j) is the greatest weight of the first j jobs, or -1 once it
+	// overflows; any schedule that overflows means the best one does
+	memo := NewMemo[int, int](0)
+	var best func(j int) int
+	best = func(j int) int {
+		if j == 0 {
+			return 0
+		}
+		return memo.Do(j, func() int {
+			skip, take := best(j-1), best(compatible[j-1])
+			if skip < 0 || take < 0 {
+				return -1
+			}
+			take, ok := checkedAdd(take, jobs[order[j-1]].Weight)
+			if !ok {
+				return -1
+			}
+			return max(skip, take)
+		})
+	}
+
+	total := best(len(jobs))
+	if total < 0 {
+		return 0, nil, ErrOverflow
+	}
+
+	// Job j was taken wherever taking it attains the best weight
+	taken := []int{}
+	for j := len(jobs); j > 0; {
+		if best(compatible[j-1])+jobs[order[j-1]].Weight >= best(j-1) {
+			taken = append(taken, order[j-1])
+			j = compatible[j-1]
+		} else {
+			j--
+		}
+	}
+	slices.Sort(taken)
+	return total, taken, nil
+}
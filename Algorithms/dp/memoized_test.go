@@ -0,0 +1,147 @@
+package dp
+
+import (
+	"errors"
+	"math/big"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestFibonacciMemo tests the memoized Fibonacci against the table one
+func TestFibonacciMemo(t *testing.T) {
+	for n := range MaxFibonacci + 1 {
+		want, _ := Fibonacci(n)
+		if got, err := FibonacciMemo(n); got != want || err != nil {
+			t.Fatalf("FibonacciMemo(%d) = %d, %v, want %d", n, got, err, want)
+		}
+	}
+	if _, err := FibonacciMemo(MaxFibonacci + 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("FibonacciMemo(%d) error = %v, want ErrOverflow", MaxFibonacci+1, err)
+	}
+	if _, err := FibonacciMemo(-1); !errors.Is(err, ErrNegative) {
+		t.Errorf("FibonacciMemo(-1) error = %v, want ErrNegative", err)
+	}
+}
+
+// TestGridPaths tests open grids against binomial coefficients and blocked
+// grids against a bottom-up count
+func TestGridPaths(t *testing.T) {
+	grid := func(rows, cols int) [][]bool {
+		g := make([][]bool, rows)
+		for r := range g {
+			g[r] = make([]bool, cols)
+		}
+		return g
+	}
+
+	// An open rows x cols grid has C(rows+cols-2, rows-1) paths
+	for rows := 1; rows <= 12; rows++ {
+		for cols := 1; cols <= 12; cols++ {
+			want := new(big.Int).Binomial(int64(rows+cols-2), int64(rows-1)).Int64()
+			if got, err := GridPaths(grid(rows, cols)); got != int(want) || err != nil {
+				t.Fatalf("GridPaths(%dx%d) = %d, %v, want %d", rows, cols, got, err, want)
+			}
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(7, 8))
+	for range 100 {
+		rows, cols := 1+rng.IntN(8), 1+rng.IntN(8)
+		g := grid(rows, cols)
+		for r := range rows {
+			for c := range cols {
+				g[r][c] = rng.IntN(4) == 0
+			}
+		}
+		count := make([][]int, rows)
+		for r := range rows {
+			count[r] = make([]int, cols)
+			for c := range cols {
+				switch {
+				case g[r][c]:
+				case r == 0 && c == 0:
+					count[r][c] = 1
+				default:
+					if r > 0 {
+						count[r][c] += count[r-1][c]
+					}
+					if c > 0 {
+						count[r][c] += count[r][c-1]
+					}
+				}
+			}
+		}
+		if got, err := GridPaths(g); got != count[rows-1][cols-1] || err != nil {
+			t.Fatalf("GridPaths(%v) = %d, %v, want %d", g, got, err, count[rows-1][cols-1])
+		}
+	}
+
+	if got, err := GridPaths(nil); got != 0 || err != nil {
+		t.Errorf("GridPaths(nil) = %d, %v, want 0", got, err)
+	}
+	if _, err := GridPaths([][]bool{{false, false}, {false}}); !errors.Is(err, ErrLengthMismatch) {
+		t.Errorf("GridPaths of a ragged grid: error = %v, want ErrLengthMismatch", err)
+	}
+	// C(68, 34) exceeds an int
+	if _, err := GridPaths(grid(35, 35)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("GridPaths(35x35) error = %v, want ErrOverflow", err)
+	}
+}
+
+// TestWeightedIntervalScheduling tests the best weight and schedule against
+// trying every subset of jobs
+func TestWeightedIntervalScheduling(t *testing.T) {
+	jobs := []Job{{1, 3, 5}, {2, 5, 7}, {4, 6, 5}, {6, 7, 4}, {5, 8, 11}, {7, 9, 2}}
+	if total, taken, err := WeightedIntervalScheduling(jobs); total != 18 || !slices.Equal(taken, []int{1, 4}) || err != nil {
+		t.Errorf("WeightedIntervalScheduling = %d, %v, %v, want 18, [1 4]", total, taken, err)
+	}
+
+	rng := rand.New(rand.NewPCG(9, 10))
+	for range 300 {
+		jobs := make([]Job, rng.IntN(10))
+		for i := range jobs {
+			start := rng.IntN(20)
+			jobs[i] = Job{start, start + rng.IntN(6), rng.IntN(10)}
+		}
+
+		best := 0
+		for mask := range 1 << len(jobs) {
+			if weight, ok := scheduleWeight(jobs, mask); ok {
+				best = max(best, weight)
+			}
+		}
+
+		total, taken, err := WeightedIntervalScheduling(jobs)
+		mask := 0
+		for _, i := range taken {
+			mask |= 1 << i
+		}
+		weight, ok := scheduleWeight(jobs, mask)
+		if total != best || weight != best || !ok || !slices.IsSorted(taken) || err != nil {
+			t.Fatalf("WeightedIntervalScheduling(%v) = %d, %v, %v; want weight %d", jobs, total, taken, err, best)
+		}
+	}
+
+	if _, _, err := WeightedIntervalScheduling([]Job{{5, 3, 1}}); !errors.Is(err, ErrNegative) {
+		t.Errorf("WeightedIntervalScheduling of a backward job: error = %v, want ErrNegative", err)
+	}
+}
+
+// scheduleWeight returns the weight of the jobs in mask, and whether they
+// are pairwise non-overlapping
+func scheduleWeight(jobs []Job, mask int) (int, bool) {
+	weight := 0
+	for i := range jobs {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		weight += jobs[i].Weight
+		for j := range i {
+			if mask&(1<<j) != 0 && jobs[i].Start < jobs[j].End && jobs[j].Start < jobs[i].End {
+				return 0, false
+			}
+		}
+	}
+	return weight, true
+}
@@ -0,0 +1,306 @@
+package dp
+
+import (
+	"math"
+	"slices"
+)
+
+// Knapsack01 solves the 0/1 knapsack problem: the greatest total value of
+// items, each taken at most once, whose weights fit in capacity. A single
+// row of the table is kept, filled from the largest weight down so that
+// each item is counted once.
+// Time Complexity: O(n * W), Space Complexity: O(W)
+func Knapsack01(weights, values []int, capacity int) (int, error) {
+	if err := validateKnapsack(weights, values, capacity); err != nil {
+		return 0, err
+	}
+	// Secure: the row is sized by capacity
+	if capacity >= maxTableCells {
+		return 0, ErrTableTooLarge
+	}
+
+	// best[w] is the best value of the items seen so far within weight w
+	best := make([]int, capacity+1)
+	for i, weight := range weights {
+		for w := capacity; w >= weight; w-- {
+			// Secure: a feasible value that overflows means the best one does
+			value, ok := checkedAdd(best[w-weight], values[i])
+			if !ok {
+				return 0, ErrOverflow
+			}
+			best[w] = max(best[w], value)
+		}
+	}
+	return best[capacity], nil
+}
+
+// Knapsack01Items solves the 0/1 knapsack problem and returns the best
+// value with the indices of the items taken, in increasing order. Unlike
+// Knapsack01 it keeps the whole table to trace the items back.
+// Time Complexity: O(n * W), Space Complexity: O(n * W)
+func Knapsack01Items(weights, values []int, capacity int) (int, []int, error) {
+	if err := validateKnapsack(weights, values, capacity); err != nil {
+		return 0, nil, err
+	}
+	n := len(weights)
+	// Secure: the table has (n+1) * (capacity+1) cells
+	if capacity >= maxTableCells/(n+1) {
+		return 0, nil, ErrTableTooLarge
+	}
+
+	// dp[i][w] is the best value of the first i items within weight w
+	dp := make([][]int, n+1)
+	for i := range dp {
+		dp[i] = make([]int, capacity+1)
+	}
+	for i := 1; i <= n; i++ {
+		for w := 0; w <= capacity; w++ {
+			dp[i][w] = dp[i-1][w]
+			if weights[i-1] <= w {
+				value, ok := checkedAdd(dp[i-1][w-weights[i-1]], values[i-1])
+				if !ok {
+					return 0, nil, ErrOverflow
+				}
+				dp[i][w] = max(dp[i][w], value)
+			}
+		}
+	}
+
+	// An item was taken wherever including it changed the best value
+	items := []int{}
+	for i, w := n, capacity; i > 0; i-- {
+		if dp[i][w] != dp[i-1][w] {
+			items = append(items, i-1)
+			w -= weights[i-1]
+		}
+	}
+	slices.Reverse(items)
+	return dp[n][capacity], items, nil
+}
+
+// validateKnapsack checks the arguments shared by the knapsack functions
+func validateKnapsack(weights, values []int, capacity int) error {
+	// Secure: validate input
+	if len(values) != len(weights) {
+		return ErrLengthMismatch
+	}
+	if capacity < 0 {
+		return ErrNegative
+	}
+
+	// Secure: validate weights and values
+	for i := range weights {
+		if weights[i] < 0 || values[i] < 0 {
+			return ErrNegative
+		}
+	}
+	return nil
+}
+
+// CoinChange counts the ways to make amount from coins, each usable any
+// number of times, disregarding order. The count grows exponentially with
+// amount, so it returns ErrOverflow once it no longer fits in an int.
+// Time Complexity: O(n * amount), Space Complexity: O(amount)
+func CoinChange(coins []int, amount int) (int, error) {
+	if err := validateCoins(coins, amount); err != nil {
+		return 0, err
+	}
+
+	// dp[a] counts the ways to make a from the coins seen so far, or is -1
+	// once that count overflows; counts for amounts other than the one asked
+	// may overflow without it doing so
+	dp := make([]int, amount+1)
+	dp[0] = 1
+	for _, coin := range coins {
+		for j := coin; j <= amount; j++ {
+			if dp[j] < 0 || dp[j-coin] < 0 {
+				dp[j] = -1
+			} else if ways, ok := checkedAdd(dp[j], dp[j-coin]); ok {
+				dp[j] = ways
+			} else {
+				dp[j] = -1
+			}
+		}
+	}
+	if dp[amount] < 0 {
+		return 0, ErrOverflow
+	}
+	return dp[amount], nil
+}
+
+// CoinChangeMin returns a smallest multiset of coins, in increasing order,
+// that makes amount, each coin usable any number of times. It returns
+// ErrNoSolution if amount cannot be made.
+// Time Complexity: O(n * amount), Space Complexity: O(amount)
+func CoinChangeMin(coins []int, amount int) ([]int, error) {
+	if err := validateCoins(coins, amount); err != nil {
+		return nil, err
+	}
+
+	// fewest[a] is the fewest coins making a, and last[a] a coin ending
+	// such a multiset
+	fewest := make([]int, amount+1)
+	last := make([]int, amount+1)
+	for a := 1; a <= amount; a++ {
+		fewest[a] = math.MaxInt
+		for _, coin := range coins {
+			if coin <= a && fewest[a-coin] != math.MaxInt && fewest[a-coin]+1 < fewest[a] {
+				fewest[a], last[a] = fewest[a-coin]+1, coin
+			}
+		}
+	}
+	if fewest[amount] == math.MaxInt {
+		return nil, ErrNoSolution
+	}
+
+	used := make([]int, 0, fewest[amount])
+	for a := amount; a > 0; a -= last[a] {
+		used = append(used, last[a])
+	}
+	slices.Sort(used)
+	return used, nil
+}
+
+// validateCoins checks the arguments shared by the coin change functions
+func validateCoins(coins []int, amount int) error {
+	// Secure: validate input
+	if amount < 0 {
+		return ErrNegative
+	}
+	// Secure: the tables are sized by amount
+	if amount >= maxTableCells {
+		return ErrTableTooLarge
+	}
+
+	// Secure: validate coins
+	for _, coin := range coins {
+		if coin <= 0 {
+			return ErrNegative
+		}
+	}
+	return nil
+}
+
+// MatrixChainMultiplication finds the fewest scalar multiplications needed
+// to multiply a chain of matrices, matrix i being p[i] x p[i+1]. It returns
+// ErrOverflow if every order costs more than an int holds.
+// Time Complexity: O(n³), Space Complexity: O(n²)
+func MatrixChainMultiplication(p []int) (int, error) {
+	n := len(p) - 1
+
+	// Secure: validate dimensions
+	for i := range p {
+		if p[i] < 0 {
+			return 0, ErrNegative
+		}
+	}
+	if n <= 0 {
+		return 0, nil
+	}
+
+	// dp[i][j] is the cost of multiplying matrices i through j, or
+	// math.MaxInt if every order overflows
+	dp := make([][]int, n)
+	for i := range dp {
+		dp[i] = make([]int, n)
+	}
+	for length := 2; length <= n; length++ {
+		for i := 0; i < n-length+1; i++ {
+			j := i + length - 1
+			dp[i][j] = math.MaxInt
+			for k := i; k < j; k++ {
+				// Secure: an order whose cost overflows is never the best
+				// one unless they all do
+				cost, ok := checkedMul(p[i], p[k+1])
+				if ok {
+					cost, ok = checkedMul(cost, p[j+1])
+				}
+				if ok {
+					cost, ok = checkedAdd(cost, dp[i][k])
+				}
+				if ok {
+					cost, ok = checkedAdd(cost, dp[k+1][j])
+				}
+				if ok {
+					dp[i][j] = min(dp[i][j], cost)
+				}
+			}
+		}
+	}
+	if dp[0][n-1] == math.MaxInt {
+		return 0, ErrOverflow
+	}
+	return dp[0][n-1], nil
+}
+
+// RodCutting finds the best price for a rod of length n cut into pieces,
+// prices[i] being the price of a piece of length i+1
+// Time Complexity: O(n²), Space Complexity: O(n)
+func RodCutting(prices []int, n int) (int, error) {
+	value, _, err := RodCuttingCuts(prices, n)
+	return value, err
+}
+
+// RodCuttingCuts finds the best price for a rod of length n and the piece
+// lengths attaining it, in decreasing order
+// Time Complexity: O(n²), Space Complexity: O(n)
+func RodCuttingCuts(prices []int, n int) (int, []int, error) {
+	// Secure: validate input
+	if n < 0 {
+		return 0, nil, ErrNegative
+	}
+	// Secure: the tables are sized by n
+	if n >= maxTableCells {
+		return 0, nil, ErrTableTooLarge
+	}
+
+	// Secure: validate prices
+	for _, price := range prices {
+		if price < 0 {
+			return 0, nil, ErrNegative
+		}
+	}
+
+	// best[i] is the best price for length i, and first[i] the length of a
+	// piece to cut from it
+	best := make([]int, n+1)
+	first := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= min(i, len(prices)); j++ {
+			// Secure: a feasible price that overflows means the best one does
+			price, ok := checkedAdd(prices[j-1], best[i-j])
+			if !ok {
+				return 0, nil, ErrOverflow
+			}
+			if price > best[i] {
+				best[i], first[i] = price, j
+			}
+		}
+	}
+
+	// A remainder no price improves on is left over rather than cut
+	cuts := []int{}
+	for i := n; i > 0 && first[i] > 0; i -= first[i] {
+		cuts = append(cuts, first[i])
+	}
+	slices.SortFunc(cuts, func(a, b int) int { return b - a })
+	return best[n], cuts, nil
+}
+
+// checkedAdd returns a + b for non-negative a and b, and whether it fits in
+// an int
+func checkedAdd(a, b int) (int, bool) {
+	if a > math.MaxInt-b {
+		return 0, false
+	}
+	return a + b, true
+}
+
+// checkedMul returns a * b for non-negative a and b, and whether it fits in
+// an int
+func checkedMul(a, b int) (int, bool) {
+	if a != 0 && b > math.MaxInt/a {
+		return 0, false
+	}
+	return a * b, true
+}
@@ -0,0 +1,207 @@
+package dp
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestKnapsack01 tests the best value and item set against trying every
+// subset
+func TestKnapsack01(t *testing.T) {
+	if got, err := Knapsack01([]int{10, 20, 30}, []int{60, 100, 120}, 50); got != 220 || err != nil {
+		t.Errorf("Knapsack01 = %d, %v, want 220", got, err)
+	}
+
+	rng := rand.New(rand.NewPCG(5, 6))
+	for range 200 {
+		n := rng.IntN(9)
+		weights, values := make([]int, n), make([]int, n)
+		for i := range n {
+			weights[i], values[i] = rng.IntN(10), rng.IntN(20)
+		}
+		capacity := rng.IntN(30)
+
+		best := 0
+		for mask := range 1 << n {
+			weight, value := 0, 0
+			for i := range n {
+				if mask&(1<<i) != 0 {
+					weight, value = weight+weights[i], value+values[i]
+				}
+			}
+			if weight <= capacity {
+				best = max(best, value)
+			}
+		}
+
+		compact, err := Knapsack01(weights, values, capacity)
+		if compact != best || err != nil {
+			t.Fatalf("Knapsack01(%v, %v, %d) = %d, %v; want %d", weights, values, capacity, compact, err, best)
+		}
+		value, items, err := Knapsack01Items(weights, values, capacity)
+		weight, total := 0, 0
+		for _, i := range items {
+			weight, total = weight+weights[i], total+values[i]
+		}
+		if value != best || total != best || weight > capacity || !slices.IsSorted(items) || err != nil {
+			t.Fatalf("Knapsack01Items(%v, %v, %d) = %d, %v, %v; want value %d", weights, values, capacity, value, items, err, best)
+		}
+	}
+}
+
+// TestKnapsack01Limits tests that invalid and oversized inputs are rejected
+// rather than answered wrongly
+func TestKnapsack01Limits(t *testing.T) {
+	tests := []struct {
+		name            string
+		weights, values []int
+		capacity        int
+		want            error
+	}{
+		{"mismatched lengths", []int{1}, []int{1, 2}, 5, ErrLengthMismatch},
+		{"negative capacity", []int{1}, []int{1}, -1, ErrNegative},
+		{"negative weight", []int{-1}, []int{1}, 5, ErrNegative},
+		{"negative value", []int{1}, []int{-1}, 5, ErrNegative},
+		{"overflowing value", []int{1, 1}, []int{math.MaxInt, 1}, 2, ErrOverflow},
+		{"huge capacity", []int{1}, []int{1}, math.MaxInt, ErrTableTooLarge},
+	}
+	for _, tt := range tests {
+		if _, err := Knapsack01(tt.weights, tt.values, tt.capacity); !errors.Is(err, tt.want) {
+			t.Errorf("Knapsack01 with %s: error = %v, want %v", tt.name, err, tt.want)
+		}
+		if _, _, err := Knapsack01Items(tt.weights, tt.values, tt.capacity); !errors.Is(err, tt.want) {
+			t.Errorf("Knapsack01Items with %s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Overflow only counts if the items fit together
+	if got, err := Knapsack01([]int{1, 1}, []int{math.MaxInt, 1}, 1); got != math.MaxInt || err != nil {
+		t.Errorf("Knapsack01 fitting one item = %d, %v, want MaxInt", got, err)
+	}
+	// The compact row fits a capacity the full table would not
+	if _, err := Knapsack01([]int{1}, []int{1}, maxTableCells/2); err != nil {
+		t.Errorf("Knapsack01 with a large capacity: %v", err)
+	}
+	if _, _, err := Knapsack01Items(make([]int, 64), make([]int, 64), maxTableCells/2); !errors.Is(err, ErrTableTooLarge) {
+		t.Errorf("Knapsack01Items with a large table: error = %v, want ErrTableTooLarge", err)
+	}
+}
+
+// TestCoinChange tests counting ways and finding the fewest coins
+func TestCoinChange(t *testing.T) {
+	for _, tt := range []struct {
+		coins        []int
+		amount, want int
+	}{
+		{[]int{1, 3, 4}, 6, 4}, {[]int{2}, 3, 0}, {[]int{5}, 0, 1}, {nil, 4, 0},
+	} {
+		if got, err := CoinChange(tt.coins, tt.amount); got != tt.want || err != nil {
+			t.Errorf("CoinChange(%v, %d) = %d, %v, want %d", tt.coins, tt.amount, got, err, tt.want)
+		}
+	}
+
+	tests := []struct {
+		coins  []int
+		amount int
+		want   []int
+		err    error
+	}{
+		{[]int{1, 3, 4}, 6, []int{3, 3}, nil}, // greedy would take 4, 1, 1
+		{[]int{1, 5, 10, 25}, 63, []int{1, 1, 1, 10, 25, 25}, nil},
+		{[]int{2}, 3, nil, ErrNoSolution},
+		{[]int{7}, 0, []int{}, nil},
+		{[]int{0, 1}, 3, nil, ErrNegative},
+		{[]int{1}, -1, nil, ErrNegative},
+	}
+	for _, tt := range tests {
+		used, err := CoinChangeMin(tt.coins, tt.amount)
+		if !errors.Is(err, tt.err) || !slices.Equal(used, tt.want) {
+			t.Errorf("CoinChangeMin(%v, %d) = %v, %v, want %v, %v", tt.coins, tt.amount, used, err, tt.want, tt.err)
+		}
+	}
+}
+
+// TestCoinChangeLimits tests that counts past the int range are reported
+func TestCoinChangeLimits(t *testing.T) {
+	coins := make([]int, 50)
+	for i := range coins {
+		coins[i] = i + 1
+	}
+	// The partitions of 1000 into parts of at most 50 far exceed an int
+	if _, err := CoinChange(coins, 1000); !errors.Is(err, ErrOverflow) {
+		t.Errorf("CoinChange(1..50, 1000) error = %v, want ErrOverflow", err)
+	}
+	// Overflowing counts for amounts never used do not matter
+	if got, err := CoinChange([]int{2, 4, 6, 8, 10, 12, 14, 16, 18, 20}, 999); got != 0 || err != nil {
+		t.Errorf("CoinChange of even coins for an odd amount = %d, %v, want 0", got, err)
+	}
+	for _, amount := range []int{-1, math.MaxInt} {
+		if _, err := CoinChange([]int{1}, amount); err == nil {
+			t.Errorf("CoinChange(1, %d) succeeded", amount)
+		}
+	}
+	if _, err := CoinChange([]int{-2}, 4); !errors.Is(err, ErrNegative) {
+		t.Errorf("CoinChange(-2, 4) error = %v, want ErrNegative", err)
+	}
+}
+
+// TestRodCutting tests the best price and the cuts attaining it
+func TestRodCutting(t *testing.T) {
+	prices := []int{1, 5, 8, 9, 10, 17, 17, 20}
+	tests := []struct {
+		n, want int
+		cuts    []int
+	}{
+		{8, 22, []int{6, 2}}, {4, 10, []int{2, 2}}, {1, 1, []int{1}}, {0, 0, []int{}}, {10, 27, []int{6, 2, 2}},
+	}
+	for _, tt := range tests {
+		value, cuts, err := RodCuttingCuts(prices, tt.n)
+		if best, _ := RodCutting(prices, tt.n); value != tt.want || best != tt.want || err != nil {
+			t.Errorf("RodCutting(%d) = %d, %v, want %d", tt.n, value, err, tt.want)
+		}
+		sum := 0
+		for _, c := range cuts {
+			sum += prices[c-1]
+		}
+		if sum != value || !slices.Equal(cuts, tt.cuts) {
+			t.Errorf("RodCuttingCuts(%d) = %v, worth %d, want %v", tt.n, cuts, sum, tt.cuts)
+		}
+	}
+
+	if _, err := RodCutting(prices, -1); !errors.Is(err, ErrNegative) {
+		t.Errorf("RodCutting(-1) error = %v, want ErrNegative", err)
+	}
+	if _, err := RodCutting([]int{1, -5}, 3); !errors.Is(err, ErrNegative) {
+		t.Errorf("RodCutting with a negative price: error = %v, want ErrNegative", err)
+	}
+	if _, err := RodCutting([]int{math.MaxInt / 2}, 3); !errors.Is(err, ErrOverflow) {
+		t.Errorf("RodCutting past MaxInt: error = %v, want ErrOverflow", err)
+	}
+}
+
+// TestMatrixChainMultiplication tests known chain costs and overflow
+func TestMatrixChainMultiplication(t *testing.T) {
+	for _, tt := range []struct {
+		p    []int
+		want int
+	}{
+		{[]int{1, 2, 3, 4, 3}, 30}, {[]int{40, 20, 30, 10, 30}, 26000}, {[]int{10, 20}, 0}, {nil, 0},
+		// Multiplying the big matrices first overflows; the best order
+		// stays in range
+		{[]int{1 << 30, 1 << 30, 1 << 30, 1}, 1<<60 + 1<<60},
+	} {
+		if got, err := MatrixChainMultiplication(tt.p); got != tt.want || err != nil {
+			t.Errorf("MatrixChainMultiplication(%v) = %d, %v, want %d", tt.p, got, err, tt.want)
+		}
+	}
+
+	if _, err := MatrixChainMultiplication([]int{1 << 31, 1 << 31, 1 << 31}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MatrixChainMultiplication past MaxInt: error = %v, want ErrOverflow", err)
+	}
+	if _, err := MatrixChainMultiplication([]int{2, -3, 4}); !errors.Is(err, ErrNegative) {
+		t.Errorf("MatrixChainMultiplication with a negative dimension: error = %v, want ErrNegative", err)
+	}
+}
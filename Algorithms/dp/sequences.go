@@ -0,0 +1,233 @@
+package dp
+
+import "slices"
+
+// LongestCommonSubsequence returns the length of the longest common
+// subsequence of s1 and s2
+// Time Complexity: O(m * n), Space Complexity: O(m * n)
+func LongestCommonSubsequence(s1, s2 string) int {
+	return lcsTable(s1, s2)[len(s1)][len(s2)]
+}
+
+// LongestCommonSubsequenceString returns a longest common subsequence of s1
+// and s2, read back from the DP table
+// Time Complexity: O(m * n), Space Complexity: O(m * n)
+func LongestCommonSubsequenceString(s1, s2 string) string {
+	dp := lcsTable(s1, s2)
+	i, j := len(s1), len(s2)
+	result := make([]byte, dp[i][j])
+	k := len(result)
+	for i > 0 && j > 0 {
+		switch {
+		case s1[i-1] == s2[j-1]:
+			k--
+			result[k] = s1[i-1]
+			i, j = i-1, j-1
+		case dp[i-1][j] >= dp[i][j-1]:
+			i--
+		default:
+			j--
+		}
+	}
+	return string(result)
+}
+
+// lcsTable fills the table of LCS lengths: dp[i][j] is the LCS length of
+// s1[:i] and s2[:j]
+func lcsTable(s1, s2 string) [][]int {
+	m, n := len(s1), len(s2)
+	dp := make([][]int, m+1)
+	for i := range dp {
+		dp[i] = make([]int, n+1)
+	}
+	for i := 1; i <= m; i++ {
+		for j := 1; j <= n; j++ {
+			if s1[i-1] == s2[j-1] {
+				dp[i][j] = dp[i-1][j-1] + 1
+			} else {
+				dp[i][j] = max(dp[i-1][j], dp[i][j-1])
+			}
+		}
+	}
+	return dp
+}
+
+// LongestIncreasingSubsequence finds the length of the longest strictly
+// increasing subsequence of arr
+// Time Complexity: O(n²), Space Complexity: O(n)
+func LongestIncreasingSubsequence(arr []int) int {
+	n := len(arr)
+
+	// Secure: validate input
+	if n == 0 {
+		return 0
+	}
+
+	// dp[i] is the length of the longest increasing subsequence ending at i
+	dp := make([]int, n)
+	for i := range dp {
+		dp[i] = 1
+	}
+	for i := 1; i < n; i++ {
+		for j := 0; j < i; j++ {
+			if arr[j] < arr[i] {
+				dp[i] = max(dp[i], dp[j]+1)
+			}
+		}
+	}
+	return slices.Max(dp)
+}
+
+// EditKind is the kind of an edit operation
+type EditKind int
+
+// Edit operation kinds
+const (
+	EditKeep       EditKind = iota // s1[I] is kept as s2[J]
+	EditSubstitute                 // s1[I] is replaced by s2[J]
+	EditInsert                     // s2[J] is inserted before s1[I]
+	EditDelete                     // s1[I] is deleted
+)
+
+// String returns the name of the edit kind
+func (k EditKind) String() string {
+	switch k {
+	case EditKeep:
+		return "keep"
+	case EditSubstitute:
+		return "substitute"
+	case EditInsert:
+		return "insert"
+	case EditDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// EditOp is one step of an edit script turning s1 into s2. I indexes s1 and
+// J indexes s2; for an insertion I is the position in s1 it precedes, for
+// a deletion J is the position in s2 it would have held.
+type EditOp struct {
+	Kind EditKind
+	I, J int
+}
+
+// EditDistance calculates the edit (Levenshtein) distance between s1 and
+// s2: the fewest insertions, deletions and substitutions turning one into
+// the other. Only one row of the table is kept, along the shorter string;
+// EditScript keeps the whole table to recover the operations.
+// Time Complexity: O(m * n), Space Complexity: O(min(m, n))
+func EditDistance(s1, s2 string) int {
+	// The distance is symmetric, so let s2 be the shorter string
+	if len(s2) > len(s1) {
+		s1, s2 = s2, s1
+	}
+
+	// row[j] is the distance between s1[:i] and s2[:j]; diag holds the
+	// overwritten row[j-1] of the previous row
+	row := make([]int, len(s2)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(s1); i++ {
+		diag := row[0]
+		row[0] = i
+		for j := 1; j <= len(s2); j++ {
+			above := row[j]
+			if s1[i-1] == s2[j-1] {
+				row[j] = diag
+			} else {
+				row[j] = 1 + min(above, row[j-1], diag)
+			}
+			diag = above
+		}
+	}
+	return row[len(s2)]
+}
+
+// EditScript returns the edit distance between s1 and s2 and an edit script
+// attaining it: applied in order, the kept, substituted and inserted bytes
+// spell s2. Every operation but EditKeep costs one.
+// Time Complexity: O(m * n), Space Complexity: O(m * n)
+func EditScript(s1, s2 string) (int, []EditOp) {
+	dp := editTable(s1, s2)
+	ops := []EditOp{}
+	// Walk back from the full strings, following a move that produced
+	// each cell's value
+	i, j := len(s1), len(s2)
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && s1[i-1] == s2[j-1] && dp[i][j] == dp[i-1][j-1]:
+			ops = append(ops, EditOp{EditKeep, i - 1, j - 1})
+			i, j = i-1, j-1
+		case i > 0 && j > 0 && dp[i][j] == dp[i-1][j-1]+1:
+			ops = append(ops, EditOp{EditSubstitute, i - 1, j - 1})
+			i, j = i-1, j-1
+		case i > 0 && dp[i][j] == dp[i-1][j]+1:
+			ops = append(ops, EditOp{EditDelete, i - 1, j})
+			i--
+		default:
+			ops = append(ops, EditOp{EditInsert, i, j - 1})
+			j--
+		}
+	}
+	slices.Reverse(ops)
+	return dp[len(s1)][len(s2)], ops
+}
+
+// editTable fills the table of edit distances: dp[i][j] is the distance
+// between s1[:i] and s2[:j]
+func editTable(s1, s2 string) [][]int {
+	m, n := len(s1), len(s2)
+	dp := make([][]int, m+1)
+	for i := range dp {
+		dp[i] = make([]int, n+1)
+		dp[i][0] = i
+	}
+	for j := range n + 1 {
+		dp[0][j] = j
+	}
+	for i := 1; i <= m; i++ {
+		for j := 1; j <= n; j++ {
+			if s1[i-1] == s2[j-1] {
+				dp[i][j] = dp[i-1][j-1]
+			} else {
+				dp[i][j] = 1 + min(dp[i-1][j], dp[i][j-1], dp[i-1][j-1])
+			}
+		}
+	}
+	return dp
+}
+
+// LongestPalindromicSubsequence finds the length of the longest palindromic
+// subsequence of s
+// Time Complexity: O(n²), Space Complexity: O(n²)
+func LongestPalindromicSubsequence(s string) int {
+	n := len(s)
+
+	// Secure: validate input
+	if n == 0 {
+		return 0
+	}
+
+	// dp[i][j] is the answer for s[i:j+1]
+	dp := make([][]int, n)
+	for i := range dp {
+		dp[i] = make([]int, n)
+		dp[i][i] = 1
+	}
+	for length := 2; length <= n; length++ {
+		for i := 0; i < n-length+1; i++ {
+			j := i + length - 1
+			switch {
+			case s[i] == s[j] && length == 2:
+				dp[i][j] = 2
+			case s[i] == s[j]:
+				dp[i][j] = dp[i+1][j-1] + 2
+			default:
+				dp[i][j] = max(dp[i+1][j], dp[i][j-1])
+			}
+		}
+	}
+	return dp[0][n-1]
+}
@@ -0,0 +1,133 @@
+package dp
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// isSubsequence reports whether sub can be obtained by deleting bytes of s
+func isSubsequence(sub, s string) bool {
+	i := 0
+	for j := 0; i < len(sub) && j < len(s); j++ {
+		if sub[i] == s[j] {
+			i++
+		}
+	}
+	return i == len(sub)
+}
+
+// randomString returns n bytes drawn from alphabet
+func randomString(rng *rand.Rand, n int, alphabet string) string {
+	var b strings.Builder
+	for range n {
+		b.WriteByte(alphabet[rng.IntN(len(alphabet))])
+	}
+	return b.String()
+}
+
+// TestLongestCommonSubsequence tests LCS lengths and reconstructed strings
+func TestLongestCommonSubsequence(t *testing.T) {
+	tests := []struct {
+		s1, s2 string
+		want   int
+	}{
+		{"ABCDGH", "AEDFHR", 3}, {"AGGTAB", "GXTXAYB", 4}, {"", "abc", 0}, {"abc", "def", 0}, {"same", "same", 4},
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		tests = append(tests, struct {
+			s1, s2 string
+			want   int
+		}{randomString(rng, rng.IntN(12), "abc"), randomString(rng, rng.IntN(12), "abc"), -1})
+	}
+
+	for _, tt := range tests {
+		length := LongestCommonSubsequence(tt.s1, tt.s2)
+		if tt.want >= 0 && length != tt.want {
+			t.Errorf("LongestCommonSubsequence(%q, %q) = %d, want %d", tt.s1, tt.s2, length, tt.want)
+		}
+		lcs := LongestCommonSubsequenceString(tt.s1, tt.s2)
+		if len(lcs) != length || !isSubsequence(lcs, tt.s1) || !isSubsequence(lcs, tt.s2) {
+			t.Errorf("LongestCommonSubsequenceString(%q, %q) = %q, not a common subsequence of length %d", tt.s1, tt.s2, lcs, length)
+		}
+	}
+}
+
+// TestEditScript tests that edit scripts turn s1 into s2 at the cost of the
+// edit distance
+func TestEditScript(t *testing.T) {
+	tests := []struct {
+		s1, s2 string
+		want   int
+	}{
+		{"sunday", "saturday", 3}, {"kitten", "sitting", 3}, {"", "abc", 3}, {"abc", "", 3}, {"", "", 0}, {"flaw", "lawn", 2},
+	}
+	rng := rand.New(rand.NewPCG(3, 4))
+	for range 200 {
+		tests = append(tests, struct {
+			s1, s2 string
+			want   int
+		}{randomString(rng, rng.IntN(10), "ab"), randomString(rng, rng.IntN(10), "ab"), -1})
+	}
+
+	for _, tt := range tests {
+		distance := EditDistance(tt.s1, tt.s2)
+		if tt.want >= 0 && distance != tt.want {
+			t.Errorf("EditDistance(%q, %q) = %d, want %d", tt.s1, tt.s2, distance, tt.want)
+		}
+		scriptDistance, ops := EditScript(tt.s1, tt.s2)
+		if scriptDistance != distance {
+			t.Errorf("EditScript(%q, %q) distance = %d, want %d", tt.s1, tt.s2, scriptDistance, distance)
+		}
+
+		// Apply the script, checking each operation's positions
+		var out strings.Builder
+		cost, i := 0, 0
+		for _, op := range ops {
+			if op.J != out.Len() || op.I != i {
+				t.Fatalf("EditScript(%q, %q): %v %v at s1 %d, s2 %d out of order", tt.s1, tt.s2, op.Kind, op, i, out.Len())
+			}
+			switch op.Kind {
+			case EditKeep:
+				if tt.s1[op.I] != tt.s2[op.J] {
+					t.Fatalf("EditScript(%q, %q) keeps differing bytes at %v", tt.s1, tt.s2, op)
+				}
+				out.WriteByte(tt.s1[op.I])
+				i++
+			case EditSubstitute:
+				out.WriteByte(tt.s2[op.J])
+				i++
+				cost++
+			case EditInsert:
+				out.WriteByte(tt.s2[op.J])
+				cost++
+			case EditDelete:
+				i++
+				cost++
+			}
+		}
+		if out.String() != tt.s2 || i != len(tt.s1) || cost != distance {
+			t.Errorf("EditScript(%q, %q) gives %q at cost %d, want %q at cost %d", tt.s1, tt.s2, out.String(), cost, tt.s2, distance)
+		}
+	}
+
+	if EditInsert.String() != "insert" || EditKind(9).String() != "unknown" {
+		t.Errorf("EditKind names: %v, %v", EditInsert, EditKind(9))
+	}
+}
+
+// TestLongestSubsequences tests LIS and the longest palindromic subsequence
+func TestLongestSubsequences(t *testing.T) {
+	if got := LongestIncreasingSubsequence([]int{10, 22, 9, 33, 21, 50, 41, 60, 80}); got != 6 {
+		t.Errorf("LongestIncreasingSubsequence = %d, want 6", got)
+	}
+	if got := LongestIncreasingSubsequence(nil); got != 0 {
+		t.Errorf("LongestIncreasingSubsequence(nil) = %d, want 0", got)
+	}
+	for s, want := range map[string]int{"": 0, "a": 1, "bbbab": 4, "cbbd": 2, "agbdba": 5} {
+		if got := LongestPalindromicSubsequence(s); got != want {
+			t.Errorf("LongestPalindromicSubsequence(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package geometry
+
+import (
+	"math"
+	"slices"
+)
+
+// ClosestPair returns the indices i < j of two closest points and their
+// squared distance, dividing the points by X and merging the halves by Y.
+// ok is false if there are fewer than two points.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func ClosestPair(points []Point) (i, j, dist2 int, ok bool) {
+	if len(points) < 2 {
+		return 0, 0, 0, false
+	}
+	order := make([]int, len(points))
+	for k := range order {
+		order[k] = k
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		switch {
+		case points[a].Less(points[b]):
+			return -1
+		case points[b].Less(points[a]):
+			return 1
+		default:
+			return a - b
+		}
+	})
+
+	c := &closest{points: points, buf: make([]int, len(points)), dist2: math.MaxInt}
+	c.search(order)
+	return min(c.i, c.j), max(c.i, c.j), c.dist2, true
+}
+
+// closest holds the best pair found so far by ClosestPair
+type closest struct {
+	points []Point
+	buf    []int // Scratch space for merging and the strip
+	i, j   int
+	dist2  int
+}
+
+// consider records the pair a, b if it is closer than the best so far
+func (c *closest) consider(a, b int) {
+	if d := c.points[a].Dist2(c.points[b]); d < c.dist2 {
+		c.i, c.j, c.dist2 = a, b, d
+	}
+}
+
+// search finds the closest pair among the indices in order, sorted by X,
+// and leaves them sorted by Y
+func (c *closest) search(order []int) {
+	n := len(order)
+	if n <= 3 {
+		for a := range n {
+			for b := a + 1; b < n; b++ {
+				c.consider(order[a], order[b])
+			}
+		}
+		c.sortByY(order)
+		return
+	}
+
+	mid := n / 2
+	midX := c.points[order[mid]].X
+	c.search(order[:mid])
+	c.search(order[mid:])
+	c.mergeByY(order, mid)
+
+	// Only points within the best distance of the dividing line can form a
+	// closer pair, and each need only be checked against the few strip
+	// points above it within that distance
+	strip := c.buf[:0]
+	for _, k := range order {
+		if dx := c.points[k].X - midX; dx*dx < c.dist2 {
+			for _, s := range slices.Backward(strip) {
+				if dy := c.points[k].Y - c.points[s].Y; dy*dy >= c.dist2 {
+					break
+				}
+				c.consider(s, k)
+			}
+			strip = append(strip, k)
+		}
+	}
+}
+
+// sortByY sorts a short run of indices by Y
+func (c *closest) sortByY(order []int) {
+	slices.SortFunc(order, func(a, b int) int { return c.points[a].Y - c.points[b].Y })
+}
+
+// mergeByY merges the runs order[:mid] and order[mid:], each sorted by Y
+func (c *closest) mergeByY(order []int, mid int) {
+	merged := c.buf[:0]
+	a, b := 0, mid
+	for a < mid && b < len(order) {
+		if c.points[order[b]].Y < c.points[order[a]].Y {
+			merged = append(merged, order[b])
+			b++
+		} else {
+			merged = append(merged, order[a])
+			a++
+		}
+	}
+	merged = append(merged, order[a:mid]...)
+	merged = append(merged, order[b:]...)
+	copy(order, merged)
+}
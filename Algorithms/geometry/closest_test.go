@@ -0,0 +1,47 @@
+package geometry
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestClosestPair tests the closest pair against comparing every pair
+func TestClosestPair(t *testing.T) {
+	if _, _, _, ok := ClosestPair([]Point{{1, 1}}); ok {
+		t.Errorf("ClosestPair of one point succeeded")
+	}
+	i, j, d, ok := ClosestPair([]Point{{0, 0}, {5, 5}, {1, 1}, {9, 0}})
+	if i != 0 || j != 2 || d != 2 || !ok {
+		t.Errorf("ClosestPair = %d, %d, %d, %v, want 0, 2, 2, true", i, j, d, ok)
+	}
+
+	rng := rand.New(rand.NewPCG(5, 6))
+	for range 300 {
+		points := make([]Point, 2+rng.IntN(200))
+		span := 1 + rng.IntN(1000)
+		for k := range points {
+			points[k] = randomPoint(rng, span)
+		}
+		want := math.MaxInt
+		for a := range points {
+			for b := a + 1; b < len(points); b++ {
+				want = min(want, points[a].Dist2(points[b]))
+			}
+		}
+		i, j, d, ok := ClosestPair(points)
+		if !ok || d != want || i >= j || points[i].Dist2(points[j]) != d {
+			t.Fatalf("ClosestPair of %d points = %d, %d, %d, want distance %d", len(points), i, j, d, want)
+		}
+	}
+
+	// Points on a vertical line all fall in one strip
+	line := make([]Point, 1000)
+	for k := range line {
+		line[k] = Point{0, 3 * k}
+	}
+	line[700].Y = line[699].Y + 1
+	if i, j, d, _ := ClosestPair(line); i != 699 || j != 700 || d != 1 {
+		t.Errorf("ClosestPair on a line = %d, %d, %d, want 699, 700, 1", i, j, d)
+	}
+}
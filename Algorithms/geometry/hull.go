@@ -0,0 +1,82 @@
+package geometry
+
+import "slices"
+
+// ConvexHull returns the vertices of the convex hull of the points in
+// counter-clockwise order, starting from the least point by X, then Y,
+// using Andrew's monotone chain. Duplicate points and points in the middle
+// of a hull edge are left out, so collinear input gives its two extreme
+// points, and a single distinct point gives itself.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func ConvexHull(points []Point) []Point {
+	return monotoneChain(points, false)
+}
+
+// ConvexHullBoundary returns every distinct point on the boundary of the
+// convex hull in counter-clockwise order, starting from the least point by
+// X, then Y: the vertices of ConvexHull and the points in the middle of its
+// edges. Collinear input gives its points in increasing order.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func ConvexHullBoundary(points []Point) []Point {
+	return monotoneChain(points, true)
+}
+
+// monotoneChain builds the lower hull from left to right and the upper hull
+// from right to left, popping points that do not turn counter-clockwise, or
+// with collinear set, points that turn clockwise
+func monotoneChain(points []Point, collinear bool) []Point {
+	sorted := slices.Clone(points)
+	slices.SortFunc(sorted, func(a, b Point) int {
+		switch {
+		case a.Less(b):
+			return -1
+		case b.Less(a):
+			return 1
+		default:
+			return 0
+		}
+	})
+	sorted = slices.Compact(sorted)
+	if len(sorted) <= 2 {
+		return sorted
+	}
+
+	// Collinear input has no upper hull apart from its lower one; walking
+	// back over it would list its middle points twice
+	allCollinear := true
+	for _, p := range sorted[2:] {
+		if Cross(sorted[0], sorted[1], p) != 0 {
+			allCollinear = false
+			break
+		}
+	}
+	if allCollinear {
+		if collinear {
+			return sorted
+		}
+		return []Point{sorted[0], sorted[len(sorted)-1]}
+	}
+
+	// pops reports whether the turn a, b, c removes b from the hull
+	pops := func(a, b, c Point) bool {
+		cross := Cross(a, b, c)
+		return cross < 0 || (cross == 0 && !collinear)
+	}
+	hull := make([]Point, 0, 2*len(sorted))
+	for _, p := range sorted {
+		for len(hull) >= 2 && pops(hull[len(hull)-2], hull[len(hull)-1], p) {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		p := sorted[i]
+		for len(hull) >= lower && pops(hull[len(hull)-2], hull[len(hull)-1], p) {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	// The last point is the first one again
+	return hull[:len(hull)-1]
+}
@@ -0,0 +1,85 @@
+package geometry
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestConvexHull tests degenerate inputs and fixed shapes
+func TestConvexHull(t *testing.T) {
+	square := []Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {1, 1}, {1, 0}, {0, 1}, {2, 2}}
+	tests := []struct {
+		points         []Point
+		hull, boundary []Point
+	}{
+		{nil, []Point{}, []Point{}},
+		{[]Point{{3, 3}, {3, 3}}, []Point{{3, 3}}, []Point{{3, 3}}},
+		{[]Point{{2, 2}, {0, 0}, {1, 1}, {3, 3}}, []Point{{0, 0}, {3, 3}}, []Point{{0, 0}, {1, 1}, {2, 2}, {3, 3}}},
+		{square, []Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}, []Point{{0, 0}, {1, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 1}}},
+	}
+	for _, tt := range tests {
+		if got := ConvexHull(tt.points); !slices.Equal(got, tt.hull) {
+			t.Errorf("ConvexHull(%v) = %v, want %v", tt.points, got, tt.hull)
+		}
+		if got := ConvexHullBoundary(tt.points); !slices.Equal(got, tt.boundary) {
+			t.Errorf("ConvexHullBoundary(%v) = %v, want %v", tt.points, got, tt.boundary)
+		}
+	}
+}
+
+// TestConvexHullRandom tests hulls of random points on a small grid, where
+// collinear points abound: the hull is strictly convex and counter-clockwise
+// and contains every point, and the boundary lists exactly the points on it
+func TestConvexHullRandom(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for range 1000 {
+		points := make([]Point, 3+rng.IntN(20))
+		for i := range points {
+			points[i] = randomPoint(rng, 6)
+		}
+		hull := ConvexHull(points)
+		if len(hull) < 3 {
+			continue // collinear input, covered above
+		}
+
+		least := slices.MinFunc(points, func(a, b Point) int {
+			if a.Less(b) {
+				return -1
+			}
+			return 1
+		})
+		if hull[0] != least {
+			t.Fatalf("ConvexHull(%v) starts at %v, want %v", points, hull[0], least)
+		}
+		for i := range hull {
+			a, b, c := hull[i], hull[(i+1)%len(hull)], hull[(i+2)%len(hull)]
+			if Cross(a, b, c) <= 0 || !slices.Contains(points, a) {
+				t.Fatalf("ConvexHull(%v) = %v is not strictly convex at %v", points, hull, b)
+			}
+		}
+
+		onBoundary := []Point{}
+		for _, p := range points {
+			switch PointInPolygon(p, hull) {
+			case Outside:
+				t.Fatalf("ConvexHull(%v) = %v leaves out %v", points, hull, p)
+			case OnBoundary:
+				if !slices.Contains(onBoundary, p) {
+					onBoundary = append(onBoundary, p)
+				}
+			}
+		}
+
+		boundary := ConvexHullBoundary(points)
+		if len(boundary) != len(onBoundary) || boundary[0] != least || Area2(boundary) != Area2(hull) {
+			t.Fatalf("ConvexHullBoundary(%v) = %v, want the points %v on %v", points, boundary, onBoundary, hull)
+		}
+		for i, p := range boundary {
+			next := boundary[(i+1)%len(boundary)]
+			if !slices.Contains(onBoundary, p) || Cross(p, next, boundary[(i+2)%len(boundary)]) < 0 {
+				t.Fatalf("ConvexHullBoundary(%v) = %v is out of order at %v", points, boundary, p)
+			}
+		}
+	}
+}
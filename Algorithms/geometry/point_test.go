@@ -0,0 +1,111 @@
+package geometry
+
+import (
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"testing"
+)
+
+// randomPoint returns a point with coordinates in [0, span)
+func randomPoint(rng *rand.Rand, span int) Point {
+	return Point{rng.IntN(span), rng.IntN(span)}
+}
+
+// TestOrientation tests turns, collinear points and the coordinate limit
+func TestOrientation(t *testing.T) {
+	o, a := Point{0, 0}, Point{4, 0}
+	for _, tt := range []struct {
+		c    Point
+		want int
+	}{
+		{Point{2, 3}, 1}, {Point{2, -3}, -1}, {Point{8, 0}, 0}, {Point{-2, 0}, 0}, {Point{2, 0}, 0},
+	} {
+		if got := Orientation(o, a, tt.c); got != tt.want {
+			t.Errorf("Orientation(%v, %v, %v) = %d, want %d", o, a, tt.c, got, tt.want)
+		}
+	}
+	// The extreme cross product and distance at the coordinate limit fit
+	lo, hi := -MaxCoordinate, MaxCoordinate
+	if got := Cross(Point{lo, lo}, Point{hi, lo}, Point{lo, hi}); got != 4*MaxCoordinate*MaxCoordinate {
+		t.Errorf("Cross at the coordinate limit = %d", got)
+	}
+	if got := (Point{lo, lo}).Dist2(Point{hi, hi}); got != 8*MaxCoordinate*MaxCoordinate || got <= 0 {
+		t.Errorf("Dist2 at the coordinate limit = %d", got)
+	}
+}
+
+// intersectsRat decides whether two segments meet by solving for the
+// crossing parameters with exact rationals
+func intersectsRat(s, t Segment) bool {
+	r, q, d := s.B.Sub(s.A), t.B.Sub(t.A), t.A.Sub(s.A)
+	denom := r.X*q.Y - r.Y*q.X
+	if denom != 0 {
+		u := big.NewRat(int64(d.X*q.Y-d.Y*q.X), int64(denom))
+		v := big.NewRat(int64(d.X*r.Y-d.Y*r.X), int64(denom))
+		zero, one := new(big.Rat), big.NewRat(1, 1)
+		return u.Cmp(zero) >= 0 && u.Cmp(one) <= 0 && v.Cmp(zero) >= 0 && v.Cmp(one) <= 0
+	}
+	// Parallel: they meet only on a common line, where their projections
+	// onto both axes must overlap
+	if d.X*r.Y-d.Y*r.X != 0 || d.X*q.Y-d.Y*q.X != 0 {
+		return false
+	}
+	overlap := func(a1, a2, b1, b2 int) bool {
+		return max(min(a1, a2), min(b1, b2)) <= min(max(a1, a2), max(b1, b2))
+	}
+	return overlap(s.A.X, s.B.X, t.A.X, t.B.X) && overlap(s.A.Y, s.B.Y, t.A.Y, t.B.Y)
+}
+
+// TestSegmentIntersection tests Intersects against exact rational solving,
+// and that intersection points lie on both segments
+func TestSegmentIntersection(t *testing.T) {
+	tests := []struct {
+		s, t Segment
+		want bool
+		x, y float64
+	}{
+		{Segment{Point{0, 0}, Point{4, 4}}, Segment{Point{0, 4}, Point{4, 0}}, true, 2, 2},
+		{Segment{Point{0, 0}, Point{2, 2}}, Segment{Point{2, 2}, Point{4, 0}}, true, 2, 2},     // touching ends
+		{Segment{Point{0, 0}, Point{4, 0}}, Segment{Point{2, 0}, Point{2, 3}}, true, 2, 0},     // T junction
+		{Segment{Point{0, 0}, Point{4, 0}}, Segment{Point{6, 0}, Point{2, 0}}, true, 2, 0},     // collinear overlap
+		{Segment{Point{0, 0}, Point{2, 0}}, Segment{Point{3, 0}, Point{5, 0}}, false, 0, 0},    // collinear gap
+		{Segment{Point{0, 0}, Point{2, 2}}, Segment{Point{0, 1}, Point{2, 3}}, false, 0, 0},    // parallel
+		{Segment{Point{0, 0}, Point{1, 3}}, Segment{Point{0, 1}, Point{3, 0}}, true, 0.3, 0.9}, // non-integral
+		{Segment{Point{1, 1}, Point{1, 1}}, Segment{Point{0, 0}, Point{2, 2}}, true, 1, 1},     // a point
+	}
+	for _, tt := range tests {
+		x, y, ok := tt.s.Intersection(tt.t)
+		if tt.s.Intersects(tt.t) != tt.want || ok != tt.want || math.Abs(x-tt.x) > 1e-9 || math.Abs(y-tt.y) > 1e-9 {
+			t.Errorf("Intersection(%v, %v) = %v, %v, %v, want %v, %v, %v", tt.s, tt.t, x, y, ok, tt.x, tt.y, tt.want)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 20000 {
+		s := Segment{randomPoint(rng, 6), randomPoint(rng, 6)}
+		u := Segment{randomPoint(rng, 6), randomPoint(rng, 6)}
+		want := intersectsRat(s, u)
+		if got := s.Intersects(u); got != want || u.Intersects(s) != want {
+			t.Fatalf("Intersects(%v, %v) = %v, want %v", s, u, got, want)
+		}
+		x, y, ok := s.Intersection(u)
+		if ok != want {
+			t.Fatalf("Intersection(%v, %v) ok = %v, want %v", s, u, ok, want)
+		}
+		if ok && (distToSegment(x, y, s) > 1e-9 || distToSegment(x, y, u) > 1e-9) {
+			t.Fatalf("Intersection(%v, %v) = (%v, %v) is off the segments", s, u, x, y)
+		}
+	}
+}
+
+// distToSegment returns the distance from (x, y) to the segment
+func distToSegment(x, y float64, s Segment) float64 {
+	ax, ay, bx, by := float64(s.A.X), float64(s.A.Y), float64(s.B.X), float64(s.B.Y)
+	dx, dy := bx-ax, by-ay
+	f := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		f = max(0, min(1, ((x-ax)*dx+(y-ay)*dy)/l))
+	}
+	return math.Hypot(x-ax-f*dx, y-ay-f*dy)
+}
@@ -0,0 +1,68 @@
+package geometry
+
+// Location is where a point lies relative to a polygon
+type Location int
+
+// Locations of a point relative to a polygon
+const (
+	Outside Location = iota
+	Inside
+	OnBoundary
+)
+
+// String returns the name of the location
+func (l Location) String() string {
+	switch l {
+	case Outside:
+		return "outside"
+	case Inside:
+		return "inside"
+	case OnBoundary:
+		return "on boundary"
+	default:
+		return "unknown"
+	}
+}
+
+// PointInPolygon locates p relative to the polygon whose vertices are
+// given in order, either direction, the last joined back to the first.
+// Points on an edge or vertex are OnBoundary. Otherwise p is Inside if the
+// polygon winds around it, by the nonzero winding rule, so
+// self-intersecting polygons are handled too. Counting only edges that
+// cross p's row upward from below, or downward to below, decides a ray
+// through a vertex the same way every time.
+// Time Complexity: O(n), Space Complexity: O(1)
+func PointInPolygon(p Point, polygon []Point) Location {
+	winding := 0
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		if (Segment{a, b}).Contains(p) {
+			return OnBoundary
+		}
+		switch {
+		case a.Y <= p.Y && p.Y < b.Y && Cross(a, b, p) > 0:
+			// An upward edge with p on its left
+			winding++
+		case b.Y <= p.Y && p.Y < a.Y && Cross(a, b, p) < 0:
+			// A downward edge with p on its right
+			winding--
+		}
+	}
+	if winding != 0 {
+		return Inside
+	}
+	return Outside
+}
+
+// Area2 returns twice the signed area of the polygon by the shoelace
+// formula: positive if its vertices run counter-clockwise. Twice the area
+// of an integer polygon is always an integer.
+// Time Complexity: O(n), Space Complexity: O(1)
+func Area2(polygon []Point) int {
+	area := 0
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		area += a.X*b.Y - a.Y*b.X
+	}
+	return area
+}
@@ -0,0 +1,87 @@
+package geometry
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// TestPointInPolygon tests boundaries, rays through vertices, concave and
+// self-intersecting polygons
+func TestPointInPolygon(t *testing.T) {
+	// A concave "C" shape, clockwise
+	c := []Point{{0, 0}, {0, 4}, {4, 4}, {4, 3}, {1, 3}, {1, 1}, {4, 1}, {4, 0}}
+	// A pentagram, whose center the boundary winds around twice
+	star := []Point{{0, 10}, {6, -8}, {-10, 3}, {10, 3}, {-6, -8}}
+	tests := []struct {
+		polygon []Point
+		p       Point
+		want    Location
+	}{
+		{c, Point{0, 2}, OnBoundary}, {c, Point{4, 4}, OnBoundary}, {c, Point{2, 3}, OnBoundary},
+		{c, Point{2, 2}, Outside}, {c, Point{5, 2}, Outside}, {c, Point{-1, 3}, Outside},
+		// Rays from these points pass through the vertices at y = 1 and 3
+		{c, Point{2, 1}, OnBoundary}, {c, Point{-1, 1}, Outside}, {c, Point{0, 1}, OnBoundary},
+		{[]Point{{0, 0}, {4, 0}, {4, 4}, {0, 4}}, Point{2, 2}, Inside},
+		{[]Point{{0, 0}, {2, 2}, {4, 0}, {4, 4}, {0, 4}}, Point{1, 2}, Inside},
+		{[]Point{{0, 0}, {2, 2}, {4, 0}, {4, 4}, {0, 4}}, Point{2, 1}, Outside},
+		{star, Point{0, 0}, Inside}, {star, Point{0, 7}, Inside}, {star, Point{0, 3}, OnBoundary}, {star, Point{9, 0}, Outside},
+		{nil, Point{0, 0}, Outside},
+	}
+	for _, tt := range tests {
+		if got := PointInPolygon(tt.p, tt.polygon); got != tt.want {
+			t.Errorf("PointInPolygon(%v, %v) = %v, want %v", tt.p, tt.polygon, got, tt.want)
+		}
+	}
+	if Location(7).String() != "unknown" || OnBoundary.String() != "on boundary" {
+		t.Errorf("Location names: %v, %v", Location(7), OnBoundary)
+	}
+}
+
+// TestPointInConvexPolygon tests random points against convex hulls, where
+// a point is inside exactly when every edge turns counter-clockwise around
+// it
+func TestPointInConvexPolygon(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	for range 200 {
+		points := make([]Point, 10)
+		for i := range points {
+			points[i] = randomPoint(rng, 20)
+		}
+		hull := ConvexHull(points)
+		if len(hull) < 3 {
+			continue
+		}
+		reversed := make([]Point, len(hull))
+		for i, p := range hull {
+			reversed[len(hull)-1-i] = p
+		}
+		for range 50 {
+			p := Point{rng.IntN(24) - 2, rng.IntN(24) - 2}
+			want := Inside
+			for i, a := range hull {
+				switch cross := Cross(a, hull[(i+1)%len(hull)], p); {
+				case cross < 0:
+					want = Outside
+				case cross == 0 && want == Inside:
+					if (Segment{a, hull[(i+1)%len(hull)]}).Contains(p) {
+						want = OnBoundary
+					} else {
+						want = Outside
+					}
+				}
+				if want == Outside {
+					break
+				}
+			}
+			if got := PointInPolygon(p, hull); got != want {
+				t.Fatalf("PointInPolygon(%v, %v) = %v, want %v", p, hull, got, want)
+			}
+			if got := PointInPolygon(p, reversed); got != want {
+				t.Fatalf("PointInPolygon(%v, clockwise %v) = %v, want %v", p, reversed, got, want)
+			}
+		}
+	}
+	if got := Area2([]Point{{0, 0}, {4, 0}, {4, 3}}); got != 12 {
+		t.Errorf("Area2 of a 4x3 right triangle = %d, want 12", got)
+	}
+}
@@ -0,0 +1,119 @@
+package graphs
+
+import "slices"
+
+// Vertex colorings ignore edge directions and loops, since no coloring can
+// give a vertex a different color from itself.
+
+// GreedyColoring colors the vertices in order of decreasing degree
+// (Welsh-Powell), giving each the smallest color, from 0, not used by a
+// neighbor. It returns the color of each vertex.
+// Time Complexity: O(V log V + E), Space Complexity: O(V + E)
+func (g *Graph[T]) GreedyColoring() []int {
+	neighbors := g.undirectedNeighbors()
+	order := make([]int, len(g.adj))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return len(neighbors[b]) - len(neighbors[a])
+	})
+
+	colors := make([]int, len(g.adj))
+	for i := range colors {
+		colors[i] = -1
+	}
+	used := make([]bool, len(g.adj)+1)
+	for _, u := range order {
+		for _, v := range neighbors[u] {
+			if colors[v] >= 0 {
+				used[colors[v]] = true
+			}
+		}
+		color := 0
+		for used[color] {
+			color++
+		}
+		colors[u] = color
+		for _, v := range neighbors[u] {
+			if colors[v] >= 0 {
+				used[colors[v]] = false
+			}
+		}
+	}
+	return colors
+}
+
+// ChromaticNumberUpperBound returns the number of colors used by
+// GreedyColoring, which is at least the chromatic number
+func (g *Graph[T]) ChromaticNumberUpperBound() int {
+	colors := 0
+	for _, c := range g.GreedyColoring() {
+		colors = max(colors, c+1)
+	}
+	return colors
+}
+
+// ColorWith finds a coloring with at most k colors by backtracking,
+// reporting false if there is none.
+// Time Complexity: O(k^V) worst case, Space Complexity: O(V + E)
+func (g *Graph[T]) ColorWith(k int) ([]int, bool) {
+	n := len(g.adj)
+	if k <= 0 {
+		return nil, n == 0
+	}
+	neighbors := g.undirectedNeighbors()
+
+	// Color high-degree vertices first, so conflicts surface early
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return len(neighbors[b]) - len(neighbors[a])
+	})
+
+	colors := make([]int, n)
+	for i := range colors {
+		colors[i] = -1
+	}
+	// place colors the vertices from order[i] on, given that colors up to
+	// highest are in use. Unused colors are interchangeable, so only the
+	// first of them is tried.
+	var place func(i, highest int) bool
+	place = func(i, highest int) bool {
+		if i == n {
+			return true
+		}
+		u := order[i]
+		for color := 0; color < k && color <= highest+1; color++ {
+			if slices.ContainsFunc(neighbors[u], func(v int) bool { return colors[v] == color }) {
+				continue
+			}
+			colors[u] = color
+			if place(i+1, max(highest, color)) {
+				return true
+			}
+			colors[u] = -1
+		}
+		return false
+	}
+
+	if !place(0, -1) {
+		return nil, false
+	}
+	return colors, true
+}
+
+// MinimumColoring finds a coloring with the fewest colors, trying ColorWith
+// with increasing k up to the greedy bound.
+// Time Complexity: exponential in V, Space Complexity: O(V + E)
+func (g *Graph[T]) MinimumColoring() []int {
+	bound := g.ChromaticNumberUpperBound()
+	for k := 1; k < bound; k++ {
+		if colors, ok := g.ColorWith(k); ok {
+			return colors
+		}
+	}
+	return g.GreedyColoring()
+}
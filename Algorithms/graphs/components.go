@@ -0,0 +1,74 @@
+package graphs
+
+// StronglyConnectedComponents returns the strongly connected components of
+// the graph using Tarjan's algorithm. Components are listed in reverse
+// topological order: no edge leads from a component to a later one. In an
+// undirected graph they are the connected components.
+// Time Complexity: O(V + E), Space Complexity: O(V)
+func (g *Graph[T]) StronglyConnectedComponents() [][]int {
+	n := len(g.adj)
+	index := make([]int, n) // DFS discovery order, from 1; 0 if unvisited
+	low := make([]int, n)   // Lowest index reachable through the DFS subtree
+	onStack := make([]bool, n)
+	stack := []int{}
+	components := [][]int{}
+	counter := 0
+
+	type frame struct {
+		vertex int
+		next   int
+	}
+
+	for start := range g.adj {
+		if index[start] != 0 {
+			continue
+		}
+		counter++
+		index[start], low[start] = counter, counter
+		stack = append(stack, start)
+		onStack[start] = true
+		// Secure: an explicit call stack keeps deep graphs from exhausting
+		// the goroutine stack
+		calls := []frame{{vertex: start}}
+
+		for len(calls) > 0 {
+			top := &calls[len(calls)-1]
+			u := top.vertex
+			if top.next < len(g.adj[u]) {
+				v := g.adj[u][top.next].To
+				top.next++
+				if index[v] == 0 {
+					counter++
+					index[v], low[v] = counter, counter
+					stack = append(stack, v)
+					onStack[v] = true
+					calls = append(calls, frame{vertex: v})
+				} else if onStack[v] {
+					low[u] = min(low[u], index[v])
+				}
+				continue
+			}
+
+			// u is finished: pop its component if it is the root of one
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				caller := calls[len(calls)-1].vertex
+				low[caller] = min(low[caller], low[u])
+			}
+			if low[u] == index[u] {
+				component := []int{}
+				for {
+					w := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[w] = false
+					component = append(component, w)
+					if w == u {
+						break
+					}
+				}
+				components = append(components, component)
+			}
+		}
+	}
+	return components
+}
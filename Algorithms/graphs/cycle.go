@@ -0,0 +1,93 @@
+package graphs
+
+import (
+	"fmt"
+	"slices"
+)
+
+// CycleError reports a cycle that prevents an operation, such as a
+// topological sort. It matches ErrCycle with errors.Is.
+type CycleError struct {
+	Cycle []int
+}
+
+// Error lists the vertices of the cycle
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrCycle, e.Cycle)
+}
+
+// Is reports whether target is ErrCycle
+func (e *CycleError) Is(target error) bool {
+	return target == ErrCycle
+}
+
+// HasCycle reports whether the graph has a cycle
+func (g *Graph[T]) HasCycle() bool {
+	return g.FindCycle() != nil
+}
+
+// FindCycle returns the vertices of a cycle in the order its edges run,
+// without repeating the first vertex, or nil if the graph is acyclic. In an
+// undirected graph an edge only forms a cycle with itself if it is a loop;
+// parallel edges form a cycle of two vertices.
+// Time Complexity: O(V + E), Space Complexity: O(V)
+func (g *Graph[T]) FindCycle() []int {
+	const (
+		unvisited = iota
+		active    // On the current DFS path
+		finished
+	)
+	state := make([]byte, len(g.adj))
+	parent := make([]int, len(g.adj))
+
+	// frame is a vertex on the DFS path and the next edge to follow from it
+	type frame struct {
+		vertex     int
+		next       int
+		skipParent bool // Undirected: the edge back to the parent is still to be skipped
+	}
+
+	for start := range g.adj {
+		if state[start] != unvisited {
+			continue
+		}
+		parent[start] = -1
+		state[start] = active
+		// Secure: an explicit stack keeps deep graphs from exhausting the
+		// goroutine stack
+		stack := []frame{{vertex: start}}
+
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			u := top.vertex
+			if top.next == len(g.adj[u]) {
+				state[u] = finished
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			v := g.adj[u][top.next].To
+			top.next++
+
+			if !g.directed && top.skipParent && v == parent[u] {
+				top.skipParent = false
+				continue
+			}
+			switch state[v] {
+			case unvisited:
+				parent[v] = u
+				state[v] = active
+				stack = append(stack, frame{vertex: v, skipParent: true})
+			case active:
+				// A back edge u->v closes the path v..u into a cycle
+				cycle := []int{}
+				for w := u; w != v; w = parent[w] {
+					cycle = append(cycle, w)
+				}
+				cycle = append(cycle, v)
+				slices.Reverse(cycle)
+				return cycle
+			}
+		}
+	}
+	return nil
+}
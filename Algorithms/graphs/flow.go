@@ -0,0 +1,263 @@
+package graphs
+
+import "fmt"
+
+// FlowNetwork is a directed graph of capacity edges for maximum-flow
+// problems. Each edge is stored with a reverse residual edge, so edge i and
+// edge i^1 form a pair.
+type FlowNetwork[T Numeric] struct {
+	from     []int
+	to       []int
+	capacity []T
+	adj      [][]int // Indices of the edges and reverse edges leaving each vertex
+}
+
+// FlowEdge is an edge of a flow network and the flow through it
+type FlowEdge[T Numeric] struct {
+	From     int
+	To       int
+	Capacity T
+	Flow     T
+}
+
+// Flow is a maximum flow and the minimum cut that limits it
+type Flow[T Numeric] struct {
+	Value T
+	Edges []FlowEdge[T] // Every edge in the order added, with its flow
+
+	// SourceSide holds the vertices still reachable from the source in the
+	// residual graph, SinkSide the others; the saturated edges from one to
+	// the other form a minimum cut
+	SourceSide []int
+	SinkSide   []int
+	Cut        []FlowEdge[T]
+}
+
+// NewFlowNetwork creates a flow network with the given number of vertices
+func NewFlowNetwork[T Numeric](vertices int) *FlowNetwork[T] {
+	return &FlowNetwork[T]{adj: make([][]int, max(vertices, 0))}
+}
+
+// AddVertex adds a vertex and returns its number
+func (f *FlowNetwork[T]) AddVertex() int {
+	f.adj = append(f.adj, nil)
+	return len(f.adj) - 1
+}
+
+// Vertices returns the number of vertices
+func (f *FlowNetwork[T]) Vertices() int {
+	return len(f.adj)
+}
+
+// AddEdge adds an edge of the given capacity
+func (f *FlowNetwork[T]) AddEdge(from, to int, capacity T) error {
+	// Secure: bounds checking
+	if err := f.check(from); err != nil {
+		return err
+	}
+	if err := f.check(to); err != nil {
+		return err
+	}
+	var zero T
+	if capacity < zero {
+		return ErrNegativeCapacity
+	}
+
+	f.adj[from] = append(f.adj[from], len(f.to))
+	f.from, f.to, f.capacity = append(f.from, from), append(f.to, to), append(f.capacity, capacity)
+	f.adj[to] = append(f.adj[to], len(f.to))
+	f.from, f.to, f.capacity = append(f.from, to), append(f.to, from), append(f.capacity, zero)
+	return nil
+}
+
+// EdmondsKarp finds a maximum flow from source to sink by augmenting along
+// shortest paths found with BFS.
+// Time Complexity: O(V * E²), Space Complexity: O(V + E)
+func (f *FlowNetwork[T]) EdmondsKarp(source, sink int) (*Flow[T], error) {
+	residual, err := f.start(source, sink)
+	if err != nil {
+		return nil, err
+	}
+
+	var total, zero T
+	via := make([]int, len(f.adj)) // Edge used to reach each vertex
+	for {
+		for i := range via {
+			via[i] = -1
+		}
+		queue := []int{source}
+		for len(queue) > 0 && via[sink] < 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, e := range f.adj[u] {
+				v := f.to[e]
+				if residual[e] > zero && via[v] < 0 && v != source {
+					via[v] = e
+					queue = append(queue, v)
+				}
+			}
+		}
+		if via[sink] < 0 {
+			break
+		}
+
+		// Push the bottleneck capacity along the path
+		push := residual[via[sink]]
+		for v := sink; v != source; v = f.from[via[v]] {
+			push = min(push, residual[via[v]])
+		}
+		for v := sink; v != source; v = f.from[via[v]] {
+			residual[via[v]] -= push
+			residual[via[v]^1] += push
+		}
+		total += push
+	}
+	return f.result(source, total, residual), nil
+}
+
+// Dinic finds a maximum flow from source to sink with Dinic's algorithm,
+// sending blocking flows through BFS level graphs.
+// Time Complexity: O(V² * E), Space Complexity: O(V + E)
+func (f *FlowNetwork[T]) Dinic(source, sink int) (*Flow[T], error) {
+	residual, err := f.start(source, sink)
+	if err != nil {
+		return nil, err
+	}
+
+	var total, zero T
+	level := make([]int, len(f.adj))
+	next := make([]int, len(f.adj)) // Next edge to try from each vertex
+
+	// push sends up to limit units from u towards the sink along edges
+	// that go one level deeper, returning the amount sent
+	var push func(u int, limit T) T
+	push = func(u int, limit T) T {
+		if u == sink {
+			return limit
+		}
+		for ; next[u] < len(f.adj[u]); next[u]++ {
+			e := f.adj[u][next[u]]
+			v := f.to[e]
+			if residual[e] == zero || level[v] != level[u]+1 {
+				continue
+			}
+			if sent := push(v, min(limit, residual[e])); sent > zero {
+				residual[e] -= sent
+				residual[e^1] += sent
+				return sent
+			}
+		}
+		return zero
+	}
+
+	for {
+		// Build the level graph
+		for i := range level {
+			level[i] = -1
+		}
+		level[source] = 0
+		queue := []int{source}
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, e := range f.adj[u] {
+				if v := f.to[e]; residual[e] > zero && level[v] < 0 {
+					level[v] = level[u] + 1
+					queue = append(queue, v)
+				}
+			}
+		}
+		if level[sink] < 0 {
+			break
+		}
+
+		// Send a blocking flow, each edge being tried at most once
+		for i := range next {
+			next[i] = 0
+		}
+		for {
+			sent := push(source, f.sourceCapacity(source, residual))
+			if sent == zero {
+				break
+			}
+			total += sent
+		}
+	}
+	return f.result(source, total, residual), nil
+}
+
+// start validates the terminals and returns the initial residual capacities
+func (f *FlowNetwork[T]) start(source, sink int) ([]T, error) {
+	// Secure: bounds checking
+	if err := f.check(source); err != nil {
+		return nil, err
+	}
+	if err := f.check(sink); err != nil {
+		return nil, err
+	}
+	if source == sink {
+		return nil, fmt.Errorf("%w: %d", ErrSourceIsSink, source)
+	}
+	return append([]T(nil), f.capacity...), nil
+}
+
+// sourceCapacity returns the residual capacity leaving the source, which
+// bounds any single push
+func (f *FlowNetwork[T]) sourceCapacity(source int, residual []T) T {
+	var total T
+	for _, e := range f.adj[source] {
+		total += residual[e]
+	}
+	return total
+}
+
+// result collects the flow on each edge and the minimum cut from the final
+// residual capacities
+func (f *FlowNetwork[T]) result(source int, total T, residual []T) *Flow[T] {
+	var zero T
+	flow := &Flow[T]{Value: total, Edges: []FlowEdge[T]{}, SourceSide: []int{}, SinkSide: []int{}, Cut: []FlowEdge[T]{}}
+	for e := 0; e < len(f.to); e += 2 {
+		flow.Edges = append(flow.Edges, FlowEdge[T]{
+			From:     f.from[e],
+			To:       f.to[e],
+			Capacity: f.capacity[e],
+			Flow:     f.capacity[e] - residual[e],
+		})
+	}
+
+	// The source side is what the source still reaches in the residual graph
+	reached := make([]bool, len(f.adj))
+	reached[source] = true
+	queue := []int{source}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, e := range f.adj[u] {
+			if v := f.to[e]; residual[e] > zero && !reached[v] {
+				reached[v] = true
+				queue = append(queue, v)
+			}
+		}
+	}
+	for v, onSource := range reached {
+		if onSource {
+			flow.SourceSide = append(flow.SourceSide, v)
+		} else {
+			flow.SinkSide = append(flow.SinkSide, v)
+		}
+	}
+	for _, edge := range flow.Edges {
+		if reached[edge.From] && !reached[edge.To] {
+			flow.Cut = append(flow.Cut, edge)
+		}
+	}
+	return flow
+}
+
+// check returns ErrVertexRange unless v is a vertex of the network
+func (f *FlowNetwork[T]) check(v int) error {
+	if v < 0 || v >= len(f.adj) {
+		return fmt.Errorf("%w: %d", ErrVertexRange, v)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package graphs
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+)
+
+// checkFlow verifies capacity limits, conservation at inner vertices and
+// that the cut capacity equals the flow value
+func checkFlow(t *testing.T, name string, n, source, sink int, flow *Flow[int]) {
+	t.Helper()
+	balance := make([]int, n)
+	for _, e := range flow.Edges {
+		if e.Flow < 0 || e.Flow > e.Capacity {
+			t.Fatalf("%s: edge %d->%d carries %d of %d", name, e.From, e.To, e.Flow, e.Capacity)
+		}
+		balance[e.From] -= e.Flow
+		balance[e.To] += e.Flow
+	}
+	for v, b := range balance {
+		if v != source && v != sink && b != 0 {
+			t.Fatalf("%s: vertex %d is unbalanced by %d", name, v, b)
+		}
+	}
+	if balance[sink] != flow.Value {
+		t.Fatalf("%s: sink receives %d, value is %d", name, balance[sink], flow.Value)
+	}
+
+	cut := 0
+	for _, e := range flow.Cut {
+		cut += e.Capacity
+	}
+	if cut != flow.Value || len(flow.SourceSide)+len(flow.SinkSide) != n {
+		t.Fatalf("%s: cut of %d for a flow of %d", name, cut, flow.Value)
+	}
+}
+
+// TestMaxFlow tests both algorithms on the classic six-vertex network
+func TestMaxFlow(t *testing.T) {
+	f := NewFlowNetwork[int](6)
+	for _, e := range [][3]int{{0, 1, 16}, {0, 2, 13}, {1, 3, 12}, {2, 1, 4}, {2, 4, 14}, {3, 2, 9}, {3, 5, 20}, {4, 3, 7}, {4, 5, 4}} {
+		if err := f.AddEdge(e[0], e[1], e[2]); err != nil {
+			t.Fatalf("AddEdge failed: %v", err)
+		}
+	}
+
+	for name, solve := range map[string]func(int, int) (*Flow[int], error){"EdmondsKarp": f.EdmondsKarp, "Dinic": f.Dinic} {
+		flow, err := solve(0, 5)
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		if flow.Value != 23 {
+			t.Errorf("%s: max flow %d, want 23", name, flow.Value)
+		}
+		checkFlow(t, name, 6, 0, 5, flow)
+	}
+
+	if _, err := f.Dinic(0, 0); !errors.Is(err, ErrSourceIsSink) {
+		t.Errorf("Dinic(0, 0): got %v, want ErrSourceIsSink", err)
+	}
+	if _, err := f.EdmondsKarp(0, 6); !errors.Is(err, ErrVertexRange) {
+		t.Errorf("EdmondsKarp(0, 6): got %v, want ErrVertexRange", err)
+	}
+	if err := f.AddEdge(0, 1, -1); !errors.Is(err, ErrNegativeCapacity) {
+		t.Errorf("AddEdge with negative capacity: got %v, want ErrNegativeCapacity", err)
+	}
+}
+
+// TestMaxFlowRandom checks that the algorithms agree on random networks
+func TestMaxFlowRandom(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	for round := 0; round < 100; round++ {
+		n := 2 + rng.IntN(10)
+		f := NewFlowNetwork[int](n)
+		for range rng.IntN(4 * n) {
+			f.AddEdge(rng.IntN(n), rng.IntN(n), rng.IntN(20))
+		}
+		ek, _ := f.EdmondsKarp(0, n-1)
+		dinic, _ := f.Dinic(0, n-1)
+		if ek.Value != dinic.Value {
+			t.Fatalf("Round %d: Edmonds-Karp %d, Dinic %d", round, ek.Value, dinic.Value)
+		}
+		checkFlow(t, "EdmondsKarp", n, 0, n-1, ek)
+		checkFlow(t, "Dinic", n, 0, n-1, dinic)
+	}
+}
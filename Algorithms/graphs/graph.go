@@ -0,0 +1,117 @@
+// Package graphs provides graph algorithms over weighted adjacency-list
+// graphs: traversals, topological sorting, shortest paths and minimum
+// spanning trees. Algorithms return their results rather than printing them.
+package graphs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Numeric constraint for edge weight types
+type Numeric interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Errors returned by the graph algorithms
+var (
+	ErrVertexRange      = errors.New("vertex out of range")
+	ErrNegativeWeight   = errors.New("negative edge weight")
+	ErrNegativeCycle    = errors.New("negative cycle")
+	ErrCycle            = errors.New("graph has a cycle")
+	ErrNoPath           = errors.New("no path")
+	ErrNotBipartite     = errors.New("graph is not bipartite")
+	ErrNegativeCapacity = errors.New("negative capacity")
+	ErrSourceIsSink     = errors.New("source and sink are the same vertex")
+	ErrDirected         = errors.New("graph must be undirected")
+	ErrUndirected       = errors.New("graph must be directed")
+)
+
+// Edge is a weighted edge from one vertex to another
+type Edge[T Numeric] struct {
+	From   int
+	To     int
+	Weight T
+}
+
+// Graph is a weighted graph stored as adjacency lists. Vertices are
+// numbered from 0. An undirected graph stores each edge in both directions.
+type Graph[T Numeric] struct {
+	directed bool
+	adj      [][]Edge[T]
+}
+
+// New creates a directed graph with the given number of vertices
+func New[T Numeric](vertices int) *Graph[T] {
+	return &Graph[T]{directed: true, adj: make([][]Edge[T], max(vertices, 0))}
+}
+
+// NewUndirected creates an undirected graph with the given number of vertices
+func NewUndirected[T Numeric](vertices int) *Graph[T] {
+	return &Graph[T]{adj: make([][]Edge[T], max(vertices, 0))}
+}
+
+// AddVertex adds a vertex and returns its number
+func (g *Graph[T]) AddVertex() int {
+	g.adj = append(g.adj, nil)
+	return len(g.adj) - 1
+}
+
+// AddEdge adds an edge, in both directions if the graph is undirected
+func (g *Graph[T]) AddEdge(from, to int, weight T) error {
+	// Secure: bounds checking
+	if err := g.check(from); err != nil {
+		return err
+	}
+	if err := g.check(to); err != nil {
+		return err
+	}
+
+	g.adj[from] = append(g.adj[from], Edge[T]{From: from, To: to, Weight: weight})
+	if !g.directed && from != to {
+		g.adj[to] = append(g.adj[to], Edge[T]{From: to, To: from, Weight: weight})
+	}
+	return nil
+}
+
+// Directed reports whether the graph is directed
+func (g *Graph[T]) Directed() bool {
+	return g.directed
+}
+
+// Vertices returns the number of vertices
+func (g *Graph[T]) Vertices() int {
+	return len(g.adj)
+}
+
+// Neighbors returns the edges leaving vertex v
+func (g *Graph[T]) Neighbors(v int) []Edge[T] {
+	if v < 0 || v >= len(g.adj) {
+		return nil
+	}
+	return g.adj[v]
+}
+
+// Edges returns every edge of the graph. Undirected edges are returned once,
+// from the lower-numbered vertex.
+func (g *Graph[T]) Edges() []Edge[T] {
+	edges := []Edge[T]{}
+	for _, list := range g.adj {
+		for _, edge := range list {
+			if g.directed || edge.From <= edge.To {
+				edges = append(edges, edge)
+			}
+		}
+	}
+	return edges
+}
+
+// check returns ErrVertexRange unless v is a vertex of the graph
+func (g *Graph[T]) check(v int) error {
+	if v < 0 || v >= len(g.adj) {
+		return fmt.Errorf("%w: %d", ErrVertexRange, v)
+	}
+	return nil
+}
@@ -0,0 +1,410 @@
+package graphs
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// sampleGraph builds the weighted directed graph used by the examples
+func sampleGraph(t *testing.T) *Graph[int] {
+	g := New[int](5)
+	edges := []Edge[int]{{0, 1, 4}, {0, 2, 1}, {1, 3, 1}, {2, 1, 2}, {2, 3, 5}, {3, 4, 3}}
+	for _, e := range edges {
+		if err := g.AddEdge(e.From, e.To, e.Weight); err != nil {
+			t.Fatalf("AddEdge(%d, %d) failed: %v", e.From, e.To, err)
+		}
+	}
+	return g
+}
+
+// TestBuilders tests vertex and edge construction
+func TestBuilders(t *testing.T) {
+	g := NewUndirected[float64](2)
+	if v := g.AddVertex(); v != 2 || g.Vertices() != 3 {
+		t.Fatalf("AddVertex = %d with %d vertices, want 2 with 3", v, g.Vertices())
+	}
+	if err := g.AddEdge(0, 2, 1.5); err != nil {
+		t.Fatalf("AddEdge failed: %v", err)
+	}
+	if err := g.AddEdge(0, 3, 1); !errors.Is(err, ErrVertexRange) {
+		t.Errorf("AddEdge to a missing vertex: got %v, want ErrVertexRange", err)
+	}
+	if n := g.Neighbors(2); len(n) != 1 || n[0].To != 0 {
+		t.Errorf("Neighbors(2) = %v, want the reverse edge", n)
+	}
+	if edges := g.Edges(); len(edges) != 1 {
+		t.Errorf("Edges = %v, want one undirected edge", edges)
+	}
+}
+
+// TestTraversals tests BFS, DFS and topological sorting
+func TestTraversals(t *testing.T) {
+	g := sampleGraph(t)
+
+	if order, _ := g.BFS(0); !slices.Equal(order, []int{0, 1, 2, 3, 4}) {
+		t.Errorf("BFS = %v", order)
+	}
+	if order, _ := g.DFS(0); !slices.Equal(order, []int{0, 1, 3, 4, 2}) {
+		t.Errorf("DFS = %v", order)
+	}
+	if _, err := g.BFS(9); !errors.Is(err, ErrVertexRange) {
+		t.Errorf("BFS(9): got %v, want ErrVertexRange", err)
+	}
+
+	order, err := g.TopologicalSort()
+	if err != nil {
+		t.Fatalf("TopologicalSort failed: %v", err)
+	}
+	position := make([]int, len(order))
+	for i, v := range order {
+		position[v] = i
+	}
+	for _, e := range g.Edges() {
+		if position[e.From] > position[e.To] {
+			t.Errorf("Edge %d->%d points backwards in %v", e.From, e.To, order)
+		}
+	}
+
+	g.AddEdge(4, 0, 1)
+	if _, err := g.TopologicalSort(); !errors.Is(err, ErrCycle) {
+		t.Errorf("TopologicalSort of a cycle: got %v, want ErrCycle", err)
+	}
+}
+
+// TestShortestPaths tests that Dijkstra, Bellman-Ford and Floyd-Warshall agree
+func TestShortestPaths(t *testing.T) {
+	g := sampleGraph(t)
+	g.AddVertex() // Unreachable vertex 5
+	want := []int{0, 3, 1, 4, 7}
+
+	dijkstra, err := g.Dijkstra(0)
+	if err != nil {
+		t.Fatalf("Dijkstra failed: %v", err)
+	}
+	bellmanFord, err := g.BellmanFord(0)
+	if err != nil {
+		t.Fatalf("BellmanFord failed: %v", err)
+	}
+	all, err := g.FloydWarshall()
+	if err != nil {
+		t.Fatalf("FloydWarshall failed: %v", err)
+	}
+	for v, dist := range want {
+		if dijkstra.Dist[v] != dist || bellmanFord.Dist[v] != dist || all.Dist[0][v] != dist {
+			t.Errorf("Distance to %d: Dijkstra %d, BellmanFord %d, FloydWarshall %d; want %d",
+				v, dijkstra.Dist[v], bellmanFord.Dist[v], all.Dist[0][v], dist)
+		}
+	}
+	if dijkstra.Reached[5] || bellmanFord.Reached[5] || all.Reached[0][5] {
+		t.Error("Vertex 5 reported reachable")
+	}
+
+	// A negative edge is rejected by Dijkstra; a negative cycle by the others
+	g.AddEdge(4, 1, -8)
+	if _, err := g.Dijkstra(0); !errors.Is(err, ErrNegativeWeight) {
+		t.Errorf("Dijkstra: got %v, want ErrNegativeWeight", err)
+	}
+	if _, err := g.BellmanFord(0); !errors.Is(err, ErrNegativeCycle) {
+		t.Errorf("BellmanFord: got %v, want ErrNegativeCycle", err)
+	}
+	if _, err := g.FloydWarshall(); !errors.Is(err, ErrNegativeCycle) {
+		t.Errorf("FloydWarshall: got %v, want ErrNegativeCycle", err)
+	}
+}
+
+// TestSpanningTrees tests that Prim and Kruskal find spanning forests of
+// the same minimum weight
+func TestSpanningTrees(t *testing.T) {
+	g := NewUndirected[uint](6)
+	g.AddEdge(0, 1, 2)
+	g.AddEdge(0, 3, 6)
+	g.AddEdge(1, 2, 3)
+	g.AddEdge(1, 3, 8)
+	g.AddEdge(1, 4, 5)
+	g.AddEdge(2, 4, 7)
+	g.AddEdge(3, 4, 9)
+
+	prim, err := g.Prim()
+	if err != nil {
+		t.Fatalf("Prim failed: %v", err)
+	}
+	want := []Edge[uint]{{0, 1, 2}, {1, 2, 3}, {1, 4, 5}, {0, 3, 6}}
+	if !slices.Equal(prim.Edges, want) || prim.Weight != 16 {
+		t.Errorf("Prim = %v weighing %d, want %v weighing 16", prim.Edges, prim.Weight, want)
+	}
+
+	kruskal, err := g.Kruskal()
+	if err != nil {
+		t.Fatalf("Kruskal failed: %v", err)
+	}
+	if !slices.Equal(kruskal.Edges, want) || kruskal.Weight != 16 {
+		t.Errorf("Kruskal = %v weighing %d, want %v weighing 16", kruskal.Edges, kruskal.Weight, want)
+	}
+
+	rng := rand.New(rand.NewPCG(3, 4))
+	for round := 0; round < 50; round++ {
+		g := NewUndirected[float64](15)
+		for range 30 {
+			g.AddEdge(rng.IntN(15), rng.IntN(15), float64(rng.IntN(100))/4)
+		}
+		prim, _ := g.Prim()
+		kruskal, _ := g.Kruskal()
+		if prim.Weight != kruskal.Weight || len(prim.Edges) != len(kruskal.Edges) {
+			t.Fatalf("Round %d: Prim %v (%d edges), Kruskal %v (%d edges)",
+				round, prim.Weight, len(prim.Edges), kruskal.Weight, len(kruskal.Edges))
+		}
+	}
+
+	if _, err := New[int](2).Prim(); !errors.Is(err, ErrDirected) {
+		t.Errorf("Prim of a directed graph: got %v, want ErrDirected", err)
+	}
+	if _, err := New[int](2).Kruskal(); !errors.Is(err, ErrDirected) {
+		t.Errorf("Kruskal of a directed graph: got %v, want ErrDirected", err)
+	}
+}
+
+// TestShortestPath tests path reconstruction, and checks the heap-based
+// Dijkstra against Bellman-Ford on random graphs
+func TestShortestPath(t *testing.T) {
+	g := sampleGraph(t)
+	g.AddVertex()
+
+	path, length, err := g.ShortestPath(0, 4)
+	if err != nil {
+		t.Fatalf("ShortestPath failed: %v", err)
+	}
+	if want := []int{0, 2, 1, 3, 4}; !slices.Equal(path, want) || length != 7 {
+		t.Errorf("ShortestPath(0, 4) = %v, %d; want %v, 7", path, length, want)
+	}
+	if _, _, err := g.ShortestPath(0, 5); !errors.Is(err, ErrNoPath) {
+		t.Errorf("ShortestPath to an unreachable vertex: got %v, want ErrNoPath", err)
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for round := 0; round < 50; round++ {
+		g := New[int](20)
+		for range 60 {
+			g.AddEdge(rng.IntN(20), rng.IntN(20), rng.IntN(10))
+		}
+		dijkstra, _ := g.Dijkstra(0)
+		bellmanFord, _ := g.BellmanFord(0)
+		for v := range 20 {
+			if dijkstra.Reached[v] != bellmanFord.Reached[v] || dijkstra.Dist[v] != bellmanFord.Dist[v] {
+				t.Fatalf("Round %d: vertex %d: Dijkstra %d, Bellman-Ford %d", round, v, dijkstra.Dist[v], bellmanFord.Dist[v])
+			}
+			// The path must follow edges and add up to the distance
+			path := dijkstra.PathTo(v)
+			total := 0
+			for i := 1; i < len(path); i++ {
+				best := -1
+				for _, e := range g.Neighbors(path[i-1]) {
+					if e.To == path[i] && (best < 0 || e.Weight < best) {
+						best = e.Weight
+					}
+				}
+				total += best
+			}
+			if dijkstra.Reached[v] && (path[0] != 0 || total != dijkstra.Dist[v]) {
+				t.Fatalf("Round %d: path %v to %d has length %d, want %d", round, path, v, total, dijkstra.Dist[v])
+			}
+		}
+	}
+}
+
+// isCycle reports whether consecutive vertices of cycle, wrapping around,
+// are joined by edges of g
+func isCycle[T Numeric](g *Graph[T], cycle []int) bool {
+	if len(cycle) == 0 {
+		return false
+	}
+	for i, u := range cycle {
+		v := cycle[(i+1)%len(cycle)]
+		if !slices.ContainsFunc(g.Neighbors(u), func(e Edge[T]) bool { return e.To == v }) {
+			return false
+		}
+	}
+	return true
+}
+
+// TestFindCycle tests cycle detection in directed and undirected graphs
+func TestFindCycle(t *testing.T) {
+	g := sampleGraph(t)
+	if g.HasCycle() {
+		t.Fatalf("Acyclic graph has cycle %v", g.FindCycle())
+	}
+	g.AddEdge(4, 2, 1)
+	if cycle := g.FindCycle(); !isCycle(g, cycle) || len(cycle) != 4 {
+		t.Errorf("FindCycle = %v, want 2 -> 1 -> 3 -> 4", cycle)
+	}
+
+	// The cycle is reported by TopologicalSort too
+	_, err := g.TopologicalSort()
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) || !errors.Is(err, ErrCycle) || !isCycle(g, cycleErr.Cycle) {
+		t.Errorf("TopologicalSort: got %v, want a CycleError", err)
+	}
+
+	loop := New[int](2)
+	loop.AddEdge(1, 1, 0)
+	if cycle := loop.FindCycle(); !slices.Equal(cycle, []int{1}) {
+		t.Errorf("Self-loop: FindCycle = %v, want [1]", cycle)
+	}
+
+	// An undirected edge is not a cycle, but a parallel edge is
+	u := NewUndirected[int](4)
+	u.AddEdge(0, 1, 1)
+	u.AddEdge(1, 2, 1)
+	u.AddEdge(1, 3, 1)
+	if u.HasCycle() {
+		t.Errorf("Tree has cycle %v", u.FindCycle())
+	}
+	u.AddEdge(3, 1, 2)
+	if cycle := u.FindCycle(); len(cycle) != 2 || !isCycle(u, cycle) {
+		t.Errorf("Parallel edges: FindCycle = %v", cycle)
+	}
+	u = NewUndirected[int](4)
+	u.AddEdge(0, 1, 1)
+	u.AddEdge(1, 2, 1)
+	u.AddEdge(2, 0, 1)
+	if cycle := u.FindCycle(); len(cycle) != 3 || !isCycle(u, cycle) {
+		t.Errorf("Triangle: FindCycle = %v", cycle)
+	}
+}
+
+// TestStronglyConnectedComponents tests Tarjan's algorithm, including on a
+// path too long for a recursive implementation
+func TestStronglyConnectedComponents(t *testing.T) {
+	g := New[int](8)
+	for _, e := range [][2]int{{0, 1}, {1, 2}, {2, 0}, {2, 3}, {3, 4}, {4, 5}, {5, 3}, {6, 5}, {6, 7}} {
+		g.AddEdge(e[0], e[1], 1)
+	}
+	components := g.StronglyConnectedComponents()
+	for _, c := range components {
+		slices.Sort(c)
+	}
+	want := [][]int{{3, 4, 5}, {0, 1, 2}, {7}, {6}}
+	if !slices.EqualFunc(components, want, slices.Equal) {
+		t.Errorf("StronglyConnectedComponents = %v, want %v", components, want)
+	}
+
+	const n = 200000
+	long := New[int](n)
+	for i := 1; i < n; i++ {
+		long.AddEdge(i-1, i, 1)
+	}
+	long.AddEdge(n-1, 0, 1)
+	if components := long.StronglyConnectedComponents(); len(components) != 1 || len(components[0]) != n {
+		t.Errorf("Ring of %d vertices gave %d components", n, len(components))
+	}
+	if cycle := long.FindCycle(); len(cycle) != n {
+		t.Errorf("Ring of %d vertices gave a cycle of %d", n, len(cycle))
+	}
+}
+
+// TestBipartiteMatching checks Hopcroft-Karp against the maximum flow of the
+// equivalent unit-capacity network
+func TestBipartiteMatching(t *testing.T) {
+	triangle := NewUndirected[int](3)
+	triangle.AddEdge(0, 1, 1)
+	triangle.AddEdge(1, 2, 1)
+	triangle.AddEdge(2, 0, 1)
+	if _, ok := triangle.IsBipartite(); ok {
+		t.Error("Triangle reported bipartite")
+	}
+	if _, err := triangle.HopcroftKarp(); !errors.Is(err, ErrNotBipartite) {
+		t.Errorf("HopcroftKarp of a triangle: got %v, want ErrNotBipartite", err)
+	}
+
+	rng := rand.New(rand.NewPCG(7, 8))
+	for round := 0; round < 100; round++ {
+		left, right := 1+rng.IntN(8), 1+rng.IntN(8)
+		g := New[int](left + right)
+		network := NewFlowNetwork[int](left + right + 2)
+		source, sink := left+right, left+right+1
+		for u := range left {
+			network.AddEdge(source, u, 1)
+		}
+		for v := left; v < left+right; v++ {
+			network.AddEdge(v, sink, 1)
+		}
+		for range rng.IntN(3 * (left + right)) {
+			u, v := rng.IntN(left), left+rng.IntN(right)
+			if rng.IntN(2) == 0 {
+				g.AddEdge(u, v, 1)
+			} else {
+				g.AddEdge(v, u, 1)
+			}
+			network.AddEdge(u, v, 1)
+		}
+
+		side, ok := g.IsBipartite()
+		if !ok {
+			t.Fatalf("Round %d: bipartite graph rejected", round)
+		}
+		matching, err := g.HopcroftKarp()
+		if err != nil {
+			t.Fatalf("Round %d: HopcroftKarp failed: %v", round, err)
+		}
+		flow, _ := network.Dinic(source, sink)
+		if len(matching) != flow.Value {
+			t.Fatalf("Round %d: matching of %d, max flow %d", round, len(matching), flow.Value)
+		}
+		used := make(map[int]bool)
+		for _, e := range matching {
+			if used[e.From] || used[e.To] || side[e.From] != 0 || side[e.To] != 1 {
+				t.Fatalf("Round %d: invalid matching %v", round, matching)
+			}
+			used[e.From], used[e.To] = true, true
+		}
+	}
+}
+
+// validColoring reports whether no edge of g joins two vertices of one color
+func validColoring[T Numeric](g *Graph[T], colors []int) bool {
+	for _, e := range g.Edges() {
+		if e.From != e.To && colors[e.From] == colors[e.To] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestColoring tests greedy and exact vertex coloring
+func TestColoring(t *testing.T) {
+	// The Petersen graph has chromatic number 3
+	petersen := NewUndirected[int](10)
+	for i := range 5 {
+		petersen.AddEdge(i, (i+1)%5, 1)
+		petersen.AddEdge(i, i+5, 1)
+		petersen.AddEdge(i+5, (i+2)%5+5, 1)
+	}
+	if colors := petersen.GreedyColoring(); !validColoring(petersen, colors) {
+		t.Errorf("GreedyColoring = %v is not proper", colors)
+	}
+	if bound := petersen.ChromaticNumberUpperBound(); bound < 3 {
+		t.Errorf("ChromaticNumberUpperBound = %d, below the chromatic number 3", bound)
+	}
+	if _, ok := petersen.ColorWith(2); ok {
+		t.Error("Petersen graph colored with 2 colors")
+	}
+	colors := petersen.MinimumColoring()
+	if !validColoring(petersen, colors) || slices.Max(colors) != 2 {
+		t.Errorf("MinimumColoring = %v, want a proper 3-coloring", colors)
+	}
+
+	// A crown graph is bipartite, so two colors suffice
+	crown := NewUndirected[int](8)
+	for i := range 4 {
+		for j := range 4 {
+			if i != j {
+				crown.AddEdge(i, j+4, 1)
+			}
+		}
+	}
+	colors, ok := crown.ColorWith(2)
+	if !ok || !validColoring(crown, colors) {
+		t.Errorf("ColorWith(2) of a crown graph = %v, %v", colors, ok)
+	}
+}
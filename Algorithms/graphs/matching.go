@@ -0,0 +1,162 @@
+package graphs
+
+// undirectedNeighbors returns, for each vertex, the vertices joined to it by
+// an edge in either direction, without loops or repeats
+func (g *Graph[T]) undirectedNeighbors() [][]int {
+	n := len(g.adj)
+	seen := make(map[[2]int]bool)
+	neighbors := make([][]int, n)
+	for u, list := range g.adj {
+		for _, edge := range list {
+			v := edge.To
+			if u == v || seen[[2]int{u, v}] {
+				continue
+			}
+			seen[[2]int{u, v}], seen[[2]int{v, u}] = true, true
+			neighbors[u] = append(neighbors[u], v)
+			neighbors[v] = append(neighbors[v], u)
+		}
+	}
+	return neighbors
+}
+
+// IsBipartite reports whether the vertices can be split into two sides with
+// every edge running between them, and returns the side, 0 or 1, of each
+// vertex. Edge directions are ignored.
+// Time Complexity: O(V + E), Space Complexity: O(V + E)
+func (g *Graph[T]) IsBipartite() ([]int, bool) {
+	neighbors := g.undirectedNeighbors()
+	side := make([]int, len(g.adj))
+	for i := range side {
+		side[i] = -1
+	}
+
+	for start := range g.adj {
+		if side[start] >= 0 {
+			continue
+		}
+		side[start] = 0
+		queue := []int{start}
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, v := range neighbors[u] {
+				if side[v] < 0 {
+					side[v] = 1 - side[u]
+					queue = append(queue, v)
+				} else if side[v] == side[u] {
+					return nil, false
+				}
+			}
+		}
+	}
+
+	// A loop joins a vertex to its own side
+	for _, list := range g.adj {
+		for _, edge := range list {
+			if edge.From == edge.To {
+				return nil, false
+			}
+		}
+	}
+	return side, true
+}
+
+// HopcroftKarp finds a maximum matching of a bipartite graph: the largest
+// set of edges of which no two share a vertex. Each matched edge is
+// returned from its side-0 vertex, as sides are given by IsBipartite.
+// Time Complexity: O(E * √V), Space Complexity: O(V + E)
+func (g *Graph[T]) HopcroftKarp() ([]Edge[T], error) {
+	side, ok := g.IsBipartite()
+	if !ok {
+		return nil, ErrNotBipartite
+	}
+
+	// Keep the first edge between each pair, from the side-0 vertex
+	n := len(g.adj)
+	adj := make([][]Edge[T], n)
+	seen := make(map[[2]int]bool)
+	for _, list := range g.adj {
+		for _, edge := range list {
+			if side[edge.From] == 1 {
+				edge.From, edge.To = edge.To, edge.From
+			}
+			if !seen[[2]int{edge.From, edge.To}] {
+				seen[[2]int{edge.From, edge.To}] = true
+				adj[edge.From] = append(adj[edge.From], edge)
+			}
+		}
+	}
+
+	const free = -1
+	match := make([]int, n) // Partner of each vertex, or free
+	for i := range match {
+		match[i] = free
+	}
+	dist := make([]int, n)
+
+	// layer runs a BFS from the free left vertices through alternating
+	// paths, reporting whether a free right vertex was reached
+	layer := func() bool {
+		queue := []int{}
+		for u := range n {
+			dist[u] = -1
+			if side[u] == 0 && match[u] == free {
+				dist[u] = 0
+				queue = append(queue, u)
+			}
+		}
+		found := false
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, edge := range adj[u] {
+				w := match[edge.To]
+				if w == free {
+					found = true
+				} else if dist[w] < 0 {
+					dist[w] = dist[u] + 1
+					queue = append(queue, w)
+				}
+			}
+		}
+		return found
+	}
+
+	// augment looks for an augmenting path from left vertex u along the
+	// layers and flips it
+	var augment func(u int) bool
+	augment = func(u int) bool {
+		for _, edge := range adj[u] {
+			w := match[edge.To]
+			if w == free || (dist[w] == dist[u]+1 && augment(w)) {
+				match[u], match[edge.To] = edge.To, u
+				return true
+			}
+		}
+		dist[u] = -1 // Dead end for this phase
+		return false
+	}
+
+	for layer() {
+		for u := range n {
+			if side[u] == 0 && match[u] == free {
+				augment(u)
+			}
+		}
+	}
+
+	matching := []Edge[T]{}
+	for u := range n {
+		if side[u] != 0 || match[u] == free {
+			continue
+		}
+		for _, edge := range adj[u] {
+			if edge.To == match[u] {
+				matching = append(matching, edge)
+				break
+			}
+		}
+	}
+	return matching, nil
+}
@@ -0,0 +1,90 @@
+package graphs
+
+import (
+	"cmp"
+	"slices"
+
+	"hellogolang/Algorithms/datastructures"
+)
+
+// SpanningTree is a minimum spanning tree, or a spanning forest when the
+// graph is disconnected: its edges and their total weight
+type SpanningTree[T Numeric] struct {
+	Edges  []Edge[T]
+	Weight T
+}
+
+// add appends an edge to the tree
+func (st *SpanningTree[T]) add(edge Edge[T]) {
+	st.Edges = append(st.Edges, edge)
+	st.Weight += edge.Weight
+}
+
+// Prim finds a minimum spanning tree with Prim's algorithm, growing a tree
+// from each vertex not yet covered. Edges are listed in the order they join
+// the tree, directed away from its root.
+// Time Complexity: O(V² + E), Space Complexity: O(V)
+func (g *Graph[T]) Prim() (*SpanningTree[T], error) {
+	if g.directed {
+		return nil, ErrDirected
+	}
+
+	n := len(g.adj)
+	best := make([]Edge[T], n) // Lightest known edge into each vertex
+	keyed := make([]bool, n)
+	inTree := make([]bool, n)
+	tree := &SpanningTree[T]{Edges: []Edge[T]{}}
+
+	for range n {
+		// Take the lightest edge into the tree, or start a new tree
+		u := -1
+		for v := range n {
+			if inTree[v] {
+				continue
+			}
+			if u < 0 || (keyed[v] && (!keyed[u] || best[v].Weight < best[u].Weight)) {
+				u = v
+			}
+		}
+
+		inTree[u] = true
+		if keyed[u] {
+			tree.add(best[u])
+		}
+		for _, edge := range g.adj[u] {
+			v := edge.To
+			if !inTree[v] && (!keyed[v] || edge.Weight < best[v].Weight) {
+				best[v] = edge
+				keyed[v] = true
+			}
+		}
+	}
+	return tree, nil
+}
+
+// Kruskal finds a minimum spanning tree with Kruskal's algorithm, taking
+// edges in order of weight unless they would close a cycle. Edges are
+// listed in that order.
+// Time Complexity: O(E log E), Space Complexity: O(V + E)
+func (g *Graph[T]) Kruskal() (*SpanningTree[T], error) {
+	if g.directed {
+		return nil, ErrDirected
+	}
+
+	edges := g.Edges()
+	slices.SortStableFunc(edges, func(a, b Edge[T]) int {
+		return cmp.Compare(a.Weight, b.Weight)
+	})
+
+	sets := datastructures.NewDisjointSet(len(g.adj))
+	tree := &SpanningTree[T]{Edges: []Edge[T]{}}
+	for _, edge := range edges {
+		if sets.Count() == 1 {
+			break
+		}
+		if sets.Union(edge.From, edge.To) {
+			tree.add(edge)
+		}
+	}
+	return tree, nil
+}
@@ -0,0 +1,234 @@
+package graphs
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+)
+
+// Paths holds the shortest distances from one source vertex and the
+// predecessor of each vertex on its shortest path. Dist[v] is only
+// meaningful when Reached[v] is true; Parent[v] is -1 for the source and
+// unreached vertices.
+type Paths[T Numeric] struct {
+	Source  int
+	Dist    []T
+	Reached []bool
+	Parent  []int
+}
+
+// AllPairs holds the shortest distance between every pair of vertices.
+// Dist[u][v] is only meaningful when Reached[u][v] is true.
+type AllPairs[T Numeric] struct {
+	Dist    [][]T
+	Reached [][]bool
+}
+
+// newPaths creates the paths of a source that has reached only itself
+func newPaths[T Numeric](source, vertices int) *Paths[T] {
+	p := &Paths[T]{
+		Source:  source,
+		Dist:    make([]T, vertices),
+		Reached: make([]bool, vertices),
+		Parent:  make([]int, vertices),
+	}
+	for i := range p.Parent {
+		p.Parent[i] = -1
+	}
+	p.Reached[source] = true
+	return p
+}
+
+// PathTo returns the vertices of the shortest path from the source to
+// target, or nil if target was not reached
+func (p *Paths[T]) PathTo(target int) []int {
+	if target < 0 || target >= len(p.Reached) || !p.Reached[target] {
+		return nil
+	}
+
+	path := []int{}
+	// Secure: a path visits each vertex at most once
+	for v := target; v >= 0 && len(path) <= len(p.Parent); v = p.Parent[v] {
+		path = append(path, v)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// relax shortens the path to edge.To through edge if that is shorter,
+// reporting whether it did
+func (p *Paths[T]) relax(edge Edge[T]) bool {
+	if !p.Reached[edge.From] {
+		return false
+	}
+	dist := p.Dist[edge.From] + edge.Weight
+	if p.Reached[edge.To] && dist >= p.Dist[edge.To] {
+		return false
+	}
+	p.Dist[edge.To] = dist
+	p.Reached[edge.To] = true
+	p.Parent[edge.To] = edge.From
+	return true
+}
+
+// queueItem is a vertex waiting in the Dijkstra priority queue
+type queueItem[T Numeric] struct {
+	vertex int
+	dist   T
+}
+
+// distQueue is a min-heap of vertices ordered by distance, for container/heap
+type distQueue[T Numeric] []queueItem[T]
+
+func (q distQueue[T]) Len() int           { return len(q) }
+func (q distQueue[T]) Less(i, j int) bool { return q[i].dist < q[j].dist }
+func (q distQueue[T]) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *distQueue[T]) Push(x any)        { *q = append(*q, x.(queueItem[T])) }
+func (q *distQueue[T]) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// Dijkstra finds the shortest paths from source using a binary heap. Edge
+// weights must not be negative.
+// Time Complexity: O((V + E) log V), Space Complexity: O(V + E)
+func (g *Graph[T]) Dijkstra(source int) (*Paths[T], error) {
+	// Secure: bounds checking
+	if err := g.check(source); err != nil {
+		return nil, err
+	}
+	var zero T
+	for _, list := range g.adj {
+		for _, edge := range list {
+			if edge.Weight < zero {
+				return nil, ErrNegativeWeight
+			}
+		}
+	}
+
+	paths := newPaths[T](source, len(g.adj))
+	done := make([]bool, len(g.adj))
+	queue := &distQueue[T]{{vertex: source}}
+	for queue.Len() > 0 {
+		// Vertices are queued again when their distance improves, so
+		// skip the stale entries of finished ones
+		u := heap.Pop(queue).(queueItem[T]).vertex
+		if done[u] {
+			continue
+		}
+
+		done[u] = true
+		for _, edge := range g.adj[u] {
+			if !done[edge.To] && paths.relax(edge) {
+				heap.Push(queue, queueItem[T]{vertex: edge.To, dist: paths.Dist[edge.To]})
+			}
+		}
+	}
+	return paths, nil
+}
+
+// ShortestPath returns the vertices of the shortest path from source to
+// target and its length, using Dijkstra's algorithm
+func (g *Graph[T]) ShortestPath(source, target int) ([]int, T, error) {
+	var zero T
+	// Secure: bounds checking
+	if err := g.check(target); err != nil {
+		return nil, zero, err
+	}
+	paths, err := g.Dijkstra(source)
+	if err != nil {
+		return nil, zero, err
+	}
+	if !paths.Reached[target] {
+		return nil, zero, fmt.Errorf("%w from %d to %d", ErrNoPath, source, target)
+	}
+	return paths.PathTo(target), paths.Dist[target], nil
+}
+
+// BellmanFord finds the shortest paths from source, allowing negative edge
+// weights. It returns ErrNegativeCycle if a negative cycle is reachable.
+// Time Complexity: O(V * E), Space Complexity: O(V)
+func (g *Graph[T]) BellmanFord(source int) (*Paths[T], error) {
+	// Secure: bounds checking
+	if err := g.check(source); err != nil {
+		return nil, err
+	}
+
+	paths := newPaths[T](source, len(g.adj))
+
+	// Relax edges V-1 times, stopping early once nothing changes
+	for i := 0; i < len(g.adj)-1; i++ {
+		changed := false
+		for _, list := range g.adj {
+			for _, edge := range list {
+				if paths.relax(edge) {
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			return paths, nil
+		}
+	}
+
+	// Any further improvement means a negative cycle
+	for _, list := range g.adj {
+		for _, edge := range list {
+			if paths.relax(edge) {
+				return nil, ErrNegativeCycle
+			}
+		}
+	}
+	return paths, nil
+}
+
+// FloydWarshall finds the shortest paths between all pairs of vertices. It
+// returns ErrNegativeCycle if the graph has a negative cycle.
+// Time Complexity: O(V³), Space Complexity: O(V²)
+func (g *Graph[T]) FloydWarshall() (*AllPairs[T], error) {
+	n := len(g.adj)
+	all := &AllPairs[T]{Dist: make([][]T, n), Reached: make([][]bool, n)}
+	for i := range n {
+		all.Dist[i] = make([]T, n)
+		all.Reached[i] = make([]bool, n)
+		all.Reached[i][i] = true
+	}
+
+	// Initialize with the lightest edge between each pair
+	for u, list := range g.adj {
+		for _, edge := range list {
+			if !all.Reached[u][edge.To] || edge.Weight < all.Dist[u][edge.To] {
+				all.Dist[u][edge.To] = edge.Weight
+				all.Reached[u][edge.To] = true
+			}
+		}
+	}
+
+	for k := range n {
+		for i := range n {
+			if !all.Reached[i][k] {
+				continue
+			}
+			for j := range n {
+				if !all.Reached[k][j] {
+					continue
+				}
+				dist := all.Dist[i][k] + all.Dist[k][j]
+				if !all.Reached[i][j] || dist < all.Dist[i][j] {
+					all.Dist[i][j] = dist
+					all.Reached[i][j] = true
+				}
+			}
+		}
+	}
+
+	var zero T
+	for i := range n {
+		if all.Dist[i][i] < zero {
+			return nil, ErrNegativeCycle
+		}
+	}
+	return all, nil
+}
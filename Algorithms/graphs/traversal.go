@@ -0,0 +1,97 @@
+package graphs
+
+// BFS returns the vertices reachable from start in breadth-first order
+// Time Complexity: O(V + E), Space Complexity: O(V)
+func (g *Graph[T]) BFS(start int) ([]int, error) {
+	// Secure: bounds checking
+	if err := g.check(start); err != nil {
+		return nil, err
+	}
+
+	visited := make([]bool, len(g.adj))
+	order := []int{}
+	queue := []int{start}
+	visited[start] = true
+
+	for len(queue) > 0 {
+		vertex := queue[0]
+		queue = queue[1:]
+		order = append(order, vertex)
+
+		for _, edge := range g.adj[vertex] {
+			if !visited[edge.To] {
+				visited[edge.To] = true
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+	return order, nil
+}
+
+// DFS returns the vertices reachable from start in depth-first preorder
+// Time Complexity: O(V + E), Space Complexity: O(V)
+func (g *Graph[T]) DFS(start int) ([]int, error) {
+	// Secure: bounds checking
+	if err := g.check(start); err != nil {
+		return nil, err
+	}
+
+	visited := make([]bool, len(g.adj))
+	order := []int{}
+	var visit func(vertex int)
+	visit = func(vertex int) {
+		visited[vertex] = true
+		order = append(order, vertex)
+		for _, edge := range g.adj[vertex] {
+			if !visited[edge.To] {
+				visit(edge.To)
+			}
+		}
+	}
+	visit(start)
+	return order, nil
+}
+
+// TopologicalSort orders the vertices so that every edge points forward,
+// using Kahn's algorithm. If no such order exists it returns a *CycleError
+// holding one of the cycles.
+// Time Complexity: O(V + E), Space Complexity: O(V)
+func (g *Graph[T]) TopologicalSort() ([]int, error) {
+	if !g.directed {
+		return nil, ErrUndirected
+	}
+
+	inDegree := make([]int, len(g.adj))
+	for _, list := range g.adj {
+		for _, edge := range list {
+			inDegree[edge.To]++
+		}
+	}
+
+	// Queue for vertices with no incoming edges
+	queue := []int{}
+	for v, degree := range inDegree {
+		if degree == 0 {
+			queue = append(queue, v)
+		}
+	}
+
+	result := []int{}
+	for len(queue) > 0 {
+		vertex := queue[0]
+		queue = queue[1:]
+		result = append(result, vertex)
+
+		for _, edge := range g.adj[vertex] {
+			inDegree[edge.To]--
+			if inDegree[edge.To] == 0 {
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	if len(result) != len(g.adj) {
+		return nil, &CycleError{Cycle: g.FindCycle()}
+	}
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package hashing
+
+import (
+	"encoding/binary"
+	"hash"
+)
+
+const (
+	// adlerMod is the largest prime below 2^16
+	adlerMod = 65521
+	// adlerNMax is the most bytes summed before the sums must be reduced:
+	// the largest n with 255·n(n+1)/2 + (n+1)(adlerMod-1) below 2^32
+	adlerNMax = 5552
+)
+
+// adlerDigest is a streaming Adler-32
+type adlerDigest struct {
+	a, b uint32 // Sum of the bytes plus one, and sum of those sums
+}
+
+// NewAdler32 returns a streaming Adler-32, the checksum of zlib: two sums
+// modulo 65521, of the bytes and of the running first sum, which makes it
+// sensitive to order. It is faster than a CRC but weak on short inputs,
+// whose sums cover little of their range.
+func NewAdler32() hash.Hash32 { return &adlerDigest{a: 1} }
+
+// Adler32 returns the Adler-32 checksum of p
+func Adler32(p []byte) uint32 {
+	d := adlerDigest{a: 1}
+	d.Write(p)
+	return d.Sum32()
+}
+
+func (d *adlerDigest) Size() int      { return Size32 }
+func (d *adlerDigest) BlockSize() int { return 4 }
+func (d *adlerDigest) Reset()         { d.a, d.b = 1, 0 }
+func (d *adlerDigest) Sum32() uint32  { return d.b<<16 | d.a }
+
+// Write adds p to the sums, taking the modulo once per adlerNMax bytes
+// rather than per byte
+// Time Complexity: O(n)
+func (d *adlerDigest) Write(p []byte) (int, error) {
+	n := len(p)
+	a, b := d.a, d.b
+	for len(p) > 0 {
+		chunk := p[:min(len(p), adlerNMax)]
+		p = p[len(chunk):]
+		for _, c := range chunk {
+			a += uint32(c)
+			b += a
+		}
+		a %= adlerMod
+		b %= adlerMod
+	}
+	d.a, d.b = a, b
+	return n, nil
+}
+
+// Sum appends the big-endian checksum to b
+func (d *adlerDigest) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint32(b, d.Sum32())
+}
@@ -0,0 +1,82 @@
+package hashing
+
+import (
+	"encoding/binary"
+	"hash"
+	"sync"
+)
+
+// posixPoly is the CRC-32 polynomial of POSIX cksum in normal form, with
+// the coefficient of x^31 in the most significant bit
+const posixPoly = 0x04c11db7
+
+// posixTable gives the CRC of each byte value, shifting bits out at the top
+var posixTable = sync.OnceValue(func() *[256]uint32 {
+	t := new([256]uint32)
+	for i := range 256 {
+		crc := uint32(i) << 24
+		for range 8 {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ posixPoly
+			} else {
+				crc <<= 1
+			}
+		}
+		t[i] = crc
+	}
+	return t
+})
+
+// cksumDigest is a streaming POSIX cksum CRC
+type cksumDigest struct {
+	crc uint32
+	n   uint64 // Bytes written
+}
+
+// NewCksum returns a streaming CRC in the form POSIX specifies for cksum:
+// the IEEE polynomial, unreflected, over the data followed by its length
+// in as few little-endian bytes as hold it, then complemented. Unlike the
+// CRC-32 of gzip it does not start from all ones, so leading zero bytes
+// change it only through the length.
+func NewCksum() hash.Hash32 { return &cksumDigest{} }
+
+// Cksum returns the POSIX cksum CRC of p
+func Cksum(p []byte) uint32 {
+	d := cksumDigest{}
+	d.Write(p)
+	return d.Sum32()
+}
+
+func (d *cksumDigest) Size() int      { return Size32 }
+func (d *cksumDigest) BlockSize() int { return 1 }
+func (d *cksumDigest) Reset()         { d.crc, d.n = 0, 0 }
+
+// update returns crc advanced over p
+func (d *cksumDigest) update(crc uint32, p []byte) uint32 {
+	t := posixTable()
+	for _, b := range p {
+		crc = crc<<8 ^ t[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+func (d *cksumDigest) Write(p []byte) (int, error) {
+	d.crc = d.update(d.crc, p)
+	d.n += uint64(len(p))
+	return len(p), nil
+}
+
+// Sum32 folds in the length without changing the state, so that writing
+// may continue
+func (d *cksumDigest) Sum32() uint32 {
+	crc := d.crc
+	for n := d.n; n != 0; n >>= 8 {
+		crc = d.update(crc, []byte{byte(n)})
+	}
+	return ^crc
+}
+
+// Sum appends the big-endian checksum to b
+func (d *cksumDigest) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint32(b, d.Sum32())
+}
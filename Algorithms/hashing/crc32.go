@@ -0,0 +1,127 @@
+// Package hashing provides non-cryptographic checksums and hashes over byte
+// streams: cyclic redundancy checks (CRC-32, CRC-64 and the CRC of POSIX
+// cksum), Adler-32, and XXH64, a fast 64-bit hash. Each is written from its
+// definition, is streaming through the hash.Hash32 or hash.Hash64
+// interface, and gives the same values as the standard library packages
+// hash/crc32, hash/crc64 and hash/adler32 where they overlap.
+//
+// None of them resist an adversary: they detect accidental corruption and
+// spread keys over buckets, but anyone can construct data with a chosen
+// checksum.
+package hashing
+
+import (
+	"encoding/binary"
+	"hash"
+	"sync"
+)
+
+// Size32 is the size of a 32-bit checksum in bytes
+const Size32 = 4
+
+// Reversed CRC-32 polynomials, with the coefficient of x^0 in the most
+// significant bit, for use with MakeTable32
+const (
+	// IEEE is the polynomial of Ethernet, gzip, zip and PNG
+	IEEE = 0xedb88320
+	// Castagnoli is the polynomial of iSCSI, SCTP and ext4, with better
+	// error detection than IEEE
+	Castagnoli = 0x82f63b78
+)
+
+// Table32 holds the lookup tables of a CRC-32 polynomial. Row 0 gives the
+// CRC of each byte value; row k gives it followed by k zero bytes, so that
+// eight bytes can be folded in at once ("slicing-by-8").
+type Table32 [8][256]uint32
+
+// MakeTable32 builds the tables of a reversed polynomial
+// Time Complexity: O(8 * 256 * 8)
+func MakeTable32(poly uint32) *Table32 {
+	t := new(Table32)
+	for i := range 256 {
+		crc := uint32(i)
+		for range 8 {
+			// Divide by the polynomial one bit at a time, least
+			// significant (highest degree) first
+			if crc&1 == 1 {
+				crc = crc>>1 ^ poly
+			} else {
+				crc >>= 1
+			}
+		}
+		t[0][i] = crc
+	}
+	for i := range 256 {
+		crc := t[0][i]
+		for k := 1; k < 8; k++ {
+			crc = t[0][crc&0xff] ^ crc>>8
+			t[k][i] = crc
+		}
+	}
+	return t
+}
+
+var (
+	ieeeTable       = sync.OnceValue(func() *Table32 { return MakeTable32(IEEE) })
+	castagnoliTable = sync.OnceValue(func() *Table32 { return MakeTable32(Castagnoli) })
+)
+
+// IEEETable returns the tables of the IEEE polynomial, built on first use
+func IEEETable() *Table32 { return ieeeTable() }
+
+// CastagnoliTable returns the tables of the Castagnoli polynomial, built
+// on first use
+func CastagnoliTable() *Table32 { return castagnoliTable() }
+
+// Update32 returns the CRC-32 of data following p, given the CRC of the
+// data so far
+// Time Complexity: O(n), eight bytes per step
+func Update32(crc uint32, t *Table32, p []byte) uint32 {
+	// The register is kept complemented, so that leading zero bytes change
+	// the result
+	crc = ^crc
+	for len(p) >= 8 {
+		crc ^= binary.LittleEndian.Uint32(p)
+		// Byte i of the block is followed by 7-i more
+		crc = t[7][crc&0xff] ^ t[6][crc>>8&0xff] ^ t[5][crc>>16&0xff] ^ t[4][crc>>24] ^
+			t[3][p[4]] ^ t[2][p[5]] ^ t[1][p[6]] ^ t[0][p[7]]
+		p = p[8:]
+	}
+	for _, b := range p {
+		crc = t[0][byte(crc)^b] ^ crc>>8
+	}
+	return ^crc
+}
+
+// Checksum32 returns the CRC-32 of p
+func Checksum32(p []byte, t *Table32) uint32 { return Update32(0, t, p) }
+
+// ChecksumIEEE returns the CRC-32 of p with the IEEE polynomial
+func ChecksumIEEE(p []byte) uint32 { return Update32(0, IEEETable(), p) }
+
+// crc32Digest is a streaming CRC-32
+type crc32Digest struct {
+	crc uint32
+	tab *Table32
+}
+
+// New32 returns a streaming CRC-32 with the given tables
+func New32(t *Table32) hash.Hash32 { return &crc32Digest{tab: t} }
+
+// NewIEEE returns a streaming CRC-32 with the IEEE polynomial
+func NewIEEE() hash.Hash32 { return New32(IEEETable()) }
+
+func (d *crc32Digest) Size() int      { return Size32 }
+func (d *crc32Digest) BlockSize() int { return 1 }
+func (d *crc32Digest) Reset()         { d.crc = 0 }
+func (d *crc32Digest) Sum32() uint32  { return d.crc }
+
+func (d *crc32Digest) Write(p []byte) (int, error) {
+	d.crc = Update32(d.crc, d.tab, p)
+	return len(p), nil
+}
+
+// Sum appends the big-endian checksum to b
+func (d *crc32Digest) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint32(b, d.crc)
+}
@@ -0,0 +1,102 @@
+package hashing
+
+import (
+	"encoding/binary"
+	"hash"
+	"sync"
+)
+
+// Size64 is the size of a 64-bit checksum or hash in bytes
+const Size64 = 8
+
+// Reversed CRC-64 polynomials, for use with MakeTable64
+const (
+	// ISO is the polynomial of ISO 3309, used by HDLC
+	ISO = 0xd800000000000000
+	// ECMA is the polynomial of ECMA-182, used by xz
+	ECMA = 0xc96c5795d7870f42
+)
+
+// Table64 holds the lookup tables of a CRC-64 polynomial, laid out as
+// Table32
+type Table64 [8][256]uint64
+
+// MakeTable64 builds the tables of a reversed polynomial
+// Time Complexity: O(8 * 256 * 8)
+func MakeTable64(poly uint64) *Table64 {
+	t := new(Table64)
+	for i := range 256 {
+		crc := uint64(i)
+		for range 8 {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ poly
+			} else {
+				crc >>= 1
+			}
+		}
+		t[0][i] = crc
+	}
+	for i := range 256 {
+		crc := t[0][i]
+		for k := 1; k < 8; k++ {
+			crc = t[0][crc&0xff] ^ crc>>8
+			t[k][i] = crc
+		}
+	}
+	return t
+}
+
+var (
+	isoTable  = sync.OnceValue(func() *Table64 { return MakeTable64(ISO) })
+	ecmaTable = sync.OnceValue(func() *Table64 { return MakeTable64(ECMA) })
+)
+
+// ISOTable returns the tables of the ISO polynomial, built on first use
+func ISOTable() *Table64 { return isoTable() }
+
+// ECMATable returns the tables of the ECMA polynomial, built on first use
+func ECMATable() *Table64 { return ecmaTable() }
+
+// Update64 returns the CRC-64 of data following p, given the CRC of the
+// data so far
+// Time Complexity: O(n), eight bytes per step
+func Update64(crc uint64, t *Table64, p []byte) uint64 {
+	crc = ^crc
+	for len(p) >= 8 {
+		crc ^= binary.LittleEndian.Uint64(p)
+		crc = t[7][crc&0xff] ^ t[6][crc>>8&0xff] ^ t[5][crc>>16&0xff] ^ t[4][crc>>24&0xff] ^
+			t[3][crc>>32&0xff] ^ t[2][crc>>40&0xff] ^ t[1][crc>>48&0xff] ^ t[0][crc>>56]
+		p = p[8:]
+	}
+	for _, b := range p {
+		crc = t[0][byte(crc)^b] ^ crc>>8
+	}
+	return ^crc
+}
+
+// Checksum64 returns the CRC-64 of p
+func Checksum64(p []byte, t *Table64) uint64 { return Update64(0, t, p) }
+
+// crc64Digest is a streaming CRC-64
+type crc64Digest struct {
+	crc uint64
+	tab *Table64
+}
+
+// New64 returns a streaming CRC-64 with the given tables
+func New64(t *Table64) hash.Hash64 { return &crc64Digest{tab: t} }
+
+func (d *crc64Digest) Size() int      { return Size64 }
+func (d *crc64Digest) BlockSize() int { return 1 }
+func (d *crc64Digest) Reset()         { d.crc = 0 }
+func (d *crc64Digest) Sum64() uint64  { return d.crc }
+
+func (d *crc64Digest) Write(p []byte) (int, error) {
+	d.crc = Update64(d.crc, d.tab, p)
+	return len(p), nil
+}
+
+// Sum appends the big-endian checksum to b
+func (d *crc64Digest) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint64(b, d.crc)
+}
@@ -0,0 +1,176 @@
+package hashing
+
+import (
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+	"hash/crc64"
+	"math/rand/v2"
+	"testing"
+)
+
+// randomInputs returns inputs of every length up to 300 and a few long
+// ones, covering every tail length and the block boundaries
+func randomInputs() [][]byte {
+	rng := rand.New(rand.NewPCG(1, 2))
+	var inputs [][]byte
+	for _, n := range []int{5551, 5552, 5553, 100000} {
+		inputs = append(inputs, randomBytes(rng, n))
+	}
+	for n := range 300 {
+		inputs = append(inputs, randomBytes(rng, n))
+	}
+	return inputs
+}
+
+// randomBytes returns n bytes from rng
+func randomBytes(rng *rand.Rand, n int) []byte {
+	p := make([]byte, n)
+	for i := range p {
+		p[i] = byte(rng.Uint32())
+	}
+	return p
+}
+
+// TestCRC32 compares CRC-32 against hash/crc32 for several polynomials
+func TestCRC32(t *testing.T) {
+	for _, poly := range []uint32{IEEE, Castagnoli, 0xeb31d82e} {
+		ours, theirs := MakeTable32(poly), crc32.MakeTable(poly)
+		for _, p := range randomInputs() {
+			if got, want := Checksum32(p, ours), crc32.Checksum(p, theirs); got != want {
+				t.Fatalf("poly %#x, %d bytes: got %#08x, want %#08x", poly, len(p), got, want)
+			}
+		}
+	}
+	if got := ChecksumIEEE([]byte("123456789")); got != 0xcbf43926 {
+		t.Errorf("CRC-32 check value = %#08x, want 0xcbf43926", got)
+	}
+	if got := Checksum32([]byte("123456789"), CastagnoliTable()); got != 0xe3069283 {
+		t.Errorf("CRC-32C check value = %#08x, want 0xe3069283", got)
+	}
+}
+
+// TestCRC64 compares CRC-64 against hash/crc64 for both polynomials
+func TestCRC64(t *testing.T) {
+	tables := []struct {
+		ours   *Table64
+		theirs *crc64.Table
+	}{
+		{ISOTable(), crc64.MakeTable(crc64.ISO)},
+		{ECMATable(), crc64.MakeTable(crc64.ECMA)},
+	}
+	for _, tt := range tables {
+		for _, p := range randomInputs() {
+			if got, want := Checksum64(p, tt.ours), crc64.Checksum(p, tt.theirs); got != want {
+				t.Fatalf("%d bytes: got %#016x, want %#016x", len(p), got, want)
+			}
+		}
+	}
+}
+
+// TestAdler32 compares Adler-32 against hash/adler32, including runs of
+// 0xff that overflow the sums fastest
+func TestAdler32(t *testing.T) {
+	inputs := randomInputs()
+	ones := make([]byte, 20000)
+	for i := range ones {
+		ones[i] = 0xff
+	}
+	inputs = append(inputs, ones)
+	for _, p := range inputs {
+		if got, want := Adler32(p), adler32.Checksum(p); got != want {
+			t.Fatalf("%d bytes: got %#08x, want %#08x", len(p), got, want)
+		}
+	}
+	if got := Adler32([]byte("Wikipedia")); got != 0x11e60398 {
+		t.Errorf("Adler32(Wikipedia) = %#08x, want 0x11e60398", got)
+	}
+}
+
+// TestCksum tests the POSIX CRC against values from GNU cksum
+func TestCksum(t *testing.T) {
+	long := make([]byte, 100000)
+	for i := range long {
+		long[i] = 'x'
+	}
+	tests := []struct {
+		data []byte
+		want uint32
+	}{
+		{nil, 4294967295},
+		{[]byte("abc"), 1219131554},
+		{[]byte("123456789"), 930766865},
+		{long, 1627735810},
+	}
+	for _, tt := range tests {
+		if got := Cksum(tt.data); got != tt.want {
+			t.Errorf("Cksum(%d bytes) = %d, want %d", len(tt.data), got, tt.want)
+		}
+	}
+}
+
+// TestStreaming tests that every hash gives the same result however its
+// input is split, and that Sum leaves the state usable
+func TestStreaming(t *testing.T) {
+	hashes := map[string]func() hash.Hash{
+		"crc32":   func() hash.Hash { return NewIEEE() },
+		"crc32c":  func() hash.Hash { return New32(CastagnoliTable()) },
+		"crc64":   func() hash.Hash { return New64(ECMATable()) },
+		"adler32": func() hash.Hash { return NewAdler32() },
+		"cksum":   func() hash.Hash { return NewCksum() },
+		"xxh64":   func() hash.Hash { return NewXXH64(7) },
+	}
+	rng := rand.New(rand.NewPCG(3, 4))
+	data := randomBytes(rng, 4000)
+	for name, newHash := range hashes {
+		whole := newHash()
+		whole.Write(data)
+		want := string(whole.Sum(nil))
+
+		for range 50 {
+			h := newHash()
+			for p := data; len(p) > 0; {
+				n := min(len(p), rng.IntN(100))
+				h.Write(p[:n])
+				h.Sum(nil)
+				p = p[n:]
+			}
+			if got := string(h.Sum(nil)); got != want {
+				t.Fatalf("%s: split writes give %x, want %x", name, got, want)
+			}
+		}
+
+		whole.Reset()
+		whole.Write(data)
+		if got := string(whole.Sum(nil)); got != want {
+			t.Errorf("%s: after Reset got %x, want %x", name, got, want)
+		}
+		if whole.Size() != len(want) {
+			t.Errorf("%s: Size() = %d, want %d", name, whole.Size(), len(want))
+		}
+	}
+}
+
+// BenchmarkHashes measures the throughput of each hash over 64 KiB
+func BenchmarkHashes(b *testing.B) {
+	data := randomBytes(rand.New(rand.NewPCG(5, 6)), 64*1024)
+	hashes := []struct {
+		name string
+		h    hash.Hash
+	}{
+		{"crc32", NewIEEE()},
+		{"crc64", New64(ECMATable())},
+		{"adler32", NewAdler32()},
+		{"cksum", NewCksum()},
+		{"xxh64", NewXXH64(0)},
+	}
+	for _, tt := range hashes {
+		b.Run(tt.name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for b.Loop() {
+				tt.h.Reset()
+				tt.h.Write(data)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package hashing
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// Primes of XXH64, chosen for their well-mixed bits
+const (
+	prime64x1 = 0x9e3779b185ebca87
+	prime64x2 = 0xc2b2ae3d27d4eb4f
+	prime64x3 = 0x165667b19e3779f9
+	prime64x4 = 0x85ebca77c2b2ae63
+	prime64x5 = 0x27d4eb2f165667c5
+)
+
+// xxhStripe is the bytes consumed by one round of the four lanes
+const xxhStripe = 32
+
+// xxhDigest is a streaming XXH64
+type xxhDigest struct {
+	seed  uint64
+	v     [4]uint64 // Lane accumulators
+	total uint64    // Bytes written
+	buf   [xxhStripe]byte
+	n     int // Bytes buffered in buf
+}
+
+// NewXXH64 returns a streaming XXH64 with the given seed. XXH64 runs four
+// independent lanes over 32-byte stripes, each lane a multiply-rotate-
+// multiply per 8 bytes, so that a CPU can overlap them; it merges the lanes
+// and folds in the tail, then scrambles the bits so that every input bit
+// affects every output bit.
+func NewXXH64(seed uint64) hash.Hash64 {
+	d := &xxhDigest{seed: seed}
+	d.Reset()
+	return d
+}
+
+// XXH64 returns the XXH64 hash of p with the given seed
+// Time Complexity: O(n)
+func XXH64(p []byte, seed uint64) uint64 {
+	d := xxhDigest{seed: seed}
+	d.Reset()
+	d.Write(p)
+	return d.Sum64()
+}
+
+func (d *xxhDigest) Size() int      { return Size64 }
+func (d *xxhDigest) BlockSize() int { return xxhStripe }
+
+func (d *xxhDigest) Reset() {
+	d.v = [4]uint64{d.seed + prime64x1 + prime64x2, d.seed + prime64x2, d.seed, d.seed - prime64x1}
+	d.total, d.n = 0, 0
+}
+
+// xxhRound mixes 8 bytes of input into a lane
+func xxhRound(acc, input uint64) uint64 {
+	acc += input * prime64x2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * prime64x1
+}
+
+// xxhMerge folds a lane into the hash
+func xxhMerge(h, lane uint64) uint64 {
+	h ^= xxhRound(0, lane)
+	return h*prime64x1 + prime64x4
+}
+
+// stripes runs the lanes over whole stripes of p, returning what is left
+func (d *xxhDigest) stripes(p []byte) []byte {
+	v0, v1, v2, v3 := d.v[0], d.v[1], d.v[2], d.v[3]
+	for len(p) >= xxhStripe {
+		v0 = xxhRound(v0, binary.LittleEndian.Uint64(p[0:]))
+		v1 = xxhRound(v1, binary.LittleEndian.Uint64(p[8:]))
+		v2 = xxhRound(v2, binary.LittleEndian.Uint64(p[16:]))
+		v3 = xxhRound(v3, binary.LittleEndian.Uint64(p[24:]))
+		p = p[xxhStripe:]
+	}
+	d.v = [4]uint64{v0, v1, v2, v3}
+	return p
+}
+
+func (d *xxhDigest) Write(p []byte) (int, error) {
+	n := len(p)
+	d.total += uint64(n)
+	if d.n > 0 {
+		// Complete the buffered stripe first
+		c := copy(d.buf[d.n:], p)
+		d.n += c
+		p = p[c:]
+		if d.n < xxhStripe {
+			return n, nil
+		}
+		d.stripes(d.buf[:])
+		d.n = 0
+	}
+	p = d.stripes(p)
+	d.n = copy(d.buf[:], p)
+	return n, nil
+}
+
+// Sum64 returns the hash of the data written so far, leaving the state
+// unchanged so that writing may continue
+func (d *xxhDigest) Sum64() uint64 {
+	var h uint64
+	if d.total >= xxhStripe {
+		v := d.v
+		h = bits.RotateLeft64(v[0], 1) + bits.RotateLeft64(v[1], 7) +
+			bits.RotateLeft64(v[2], 12) + bits.RotateLeft64(v[3], 18)
+		for _, lane := range v {
+			h = xxhMerge(h, lane)
+		}
+	} else {
+		// Too short for a stripe: the lanes were never used
+		h = d.seed + prime64x5
+	}
+	h += d.total
+
+	p := d.buf[:d.n]
+	for ; len(p) >= 8; p = p[8:] {
+		h ^= xxhRound(0, binary.LittleEndian.Uint64(p))
+		h = bits.RotateLeft64(h, 27)*prime64x1 + prime64x4
+	}
+	if len(p) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(p)) * prime64x1
+		h = bits.RotateLeft64(h, 23)*prime64x2 + prime64x3
+		p = p[4:]
+	}
+	for _, b := range p {
+		h ^= uint64(b) * prime64x5
+		h = bits.RotateLeft64(h, 11) * prime64x1
+	}
+
+	// Avalanche
+	h ^= h >> 33
+	h *= prime64x2
+	h ^= h >> 29
+	h *= prime64x3
+	h ^= h >> 32
+	return h
+}
+
+// Sum appends the big-endian hash to b, the byte order of its canonical
+// hexadecimal form
+func (d *xxhDigest) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint64(b, d.Sum64())
+}
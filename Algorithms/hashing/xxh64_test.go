@@ -0,0 +1,52 @@
+package hashing
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// TestXXH64 tests known hashes of the reference implementation, short and
+// long enough to use the lanes
+func TestXXH64(t *testing.T) {
+	tests := []struct {
+		data string
+		seed uint64
+		want uint64
+	}{
+		{"", 0, 0xef46db3751d8e999},
+		{"a", 0, 0xd24ec4f1a98c6e5b},
+		{"abc", 0, 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0, 0xfbcea83c8a378bf1},
+	}
+	for _, tt := range tests {
+		if got := XXH64([]byte(tt.data), tt.seed); got != tt.want {
+			t.Errorf("XXH64(%q, %d) = %#016x, want %#016x", tt.data, tt.seed, got, tt.want)
+		}
+	}
+}
+
+// TestXXH64Avalanche tests that flipping any input bit flips about half
+// the output bits
+func TestXXH64Avalanche(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	for _, n := range []int{3, 8, 31, 32, 100} {
+		data := randomBytes(rng, n)
+		base := XXH64(data, 0)
+		flipped, trials := 0, 0
+		for i := range n * 8 {
+			data[i/8] ^= 1 << (i % 8)
+			diff := base ^ XXH64(data, 0)
+			data[i/8] ^= 1 << (i % 8)
+			for ; diff != 0; diff &= diff - 1 {
+				flipped++
+			}
+			trials++
+		}
+		if mean := float64(flipped) / float64(trials); mean < 28 || mean > 36 {
+			t.Errorf("%d bytes: flipping one bit flips %.1f output bits on average", n, mean)
+		}
+	}
+	if XXH64([]byte("seed"), 0) == XXH64([]byte("seed"), 1) {
+		t.Error("the seed does not change the hash")
+	}
+}
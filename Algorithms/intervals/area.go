@@ -0,0 +1,133 @@
+package intervals
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+)
+
+// ErrOverflow is returned when an area or a span of coordinates does not fit
+// in an int
+var ErrOverflow = errors.New("area overflows int")
+
+// Rect is the axis-aligned rectangle X × Y, empty if either side is
+type Rect struct {
+	X, Y Interval
+}
+
+// Empty reports whether the rectangle contains no points
+func (r Rect) Empty() bool {
+	return r.X.Empty() || r.Y.Empty()
+}
+
+// UnionArea returns the area covered by the union of the rectangles. A
+// vertical line sweeps across them, stopping at each left and right side;
+// between stops the covered area grows by the covered length of the line,
+// kept by a segment tree over the distinct y coordinates.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func UnionArea(rects []Rect) (int, error) {
+	type event struct {
+		x     int
+		y     Interval
+		delta int // +1 where a rectangle begins, -1 where it ends
+	}
+	events := []event{}
+	ys := []int{}
+	for _, r := range rects {
+		if r.Empty() {
+			continue
+		}
+		events = append(events, event{r.X.Start, r.Y, 1}, event{r.X.End, r.Y, -1})
+		ys = append(ys, r.Y.Start, r.Y.End)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	slices.SortFunc(events, func(a, b event) int { return cmp.Compare(a.x, b.x) })
+	slices.Sort(ys)
+	ys = slices.Compact(ys)
+
+	// Secure: every width and covered length is bounded by the span of the
+	// coordinates, so checking the spans keeps them from overflowing
+	if _, ok := span(events[0].x, events[len(events)-1].x); !ok {
+		return 0, ErrOverflow
+	}
+	if _, ok := span(ys[0], ys[len(ys)-1]); !ok {
+		return 0, ErrOverflow
+	}
+
+	cover := newCoverTree(ys)
+	area := 0
+	for i, e := range events {
+		if i > 0 {
+			width := e.x - events[i-1].x
+			// Secure: check the product and the sum for overflow
+			if width > 0 && cover.covered() > math.MaxInt/width {
+				return 0, ErrOverflow
+			}
+			strip := width * cover.covered()
+			if area > math.MaxInt-strip {
+				return 0, ErrOverflow
+			}
+			area += strip
+		}
+		lo, _ := slices.BinarySearch(ys, e.y.Start)
+		hi, _ := slices.BinarySearch(ys, e.y.End)
+		cover.add(1, 0, len(ys)-1, lo, hi, e.delta)
+	}
+	return area, nil
+}
+
+// span returns hi - lo for lo <= hi, and whether it fits in an int
+func span(lo, hi int) (int, bool) {
+	if lo < 0 && hi > math.MaxInt+lo {
+		return 0, false
+	}
+	return hi - lo, true
+}
+
+// coverTree is a segment tree over the elementary ranges [ys[i], ys[i+1]),
+// counting the rectangles covering each range and the covered length below
+// each node. Counts are only ever added to and removed from whole nodes,
+// so they need no pushing down.
+type coverTree struct {
+	ys     []int
+	count  []int
+	length []int
+}
+
+// newCoverTree creates a coverTree over the sorted, distinct ys
+func newCoverTree(ys []int) *coverTree {
+	size := 4 * len(ys)
+	return &coverTree{ys: ys, count: make([]int, size), length: make([]int, size)}
+}
+
+// covered returns the total covered length
+func (t *coverTree) covered() int {
+	return t.length[1]
+}
+
+// add adds delta to the count of the elementary ranges [l, r) below node,
+// which spans ranges [lo, hi)
+func (t *coverTree) add(node, lo, hi, l, r, delta int) {
+	if r <= lo || hi <= l {
+		return
+	}
+	if l <= lo && hi <= r {
+		t.count[node] += delta
+	} else {
+		mid := lo + (hi-lo)/2
+		t.add(2*node, lo, mid, l, r, delta)
+		t.add(2*node+1, mid, hi, l, r, delta)
+	}
+
+	switch {
+	case t.count[node] > 0:
+		t.length[node] = t.ys[hi] - t.ys[lo]
+	case hi-lo == 1:
+		t.length[node] = 0
+	default:
+		t.length[node] = t.length[2*node] + t.length[2*node+1]
+	}
+}
@@ -0,0 +1,66 @@
+package intervals
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestUnionArea tests union areas against counting covered unit cells
+func TestUnionArea(t *testing.T) {
+	tests := []struct {
+		rects []Rect
+		want  int
+	}{
+		{nil, 0},
+		{[]Rect{{Interval{0, 2}, Interval{0, 2}}}, 4},
+		{[]Rect{{Interval{0, 2}, Interval{0, 2}}, {Interval{1, 3}, Interval{1, 3}}}, 7},
+		{[]Rect{{Interval{0, 4}, Interval{0, 4}}, {Interval{1, 2}, Interval{1, 2}}}, 16},
+		{[]Rect{{Interval{0, 2}, Interval{0, 2}}, {Interval{2, 4}, Interval{0, 2}}}, 8},
+		{[]Rect{{Interval{0, 0}, Interval{0, 5}}, {Interval{-3, -1}, Interval{-3, -1}}}, 4},
+	}
+	for _, tt := range tests {
+		if got, err := UnionArea(tt.rects); got != tt.want || err != nil {
+			t.Errorf("UnionArea(%v) = %d, %v, want %d", tt.rects, got, err, tt.want)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(7, 8))
+	for range 200 {
+		rects := make([]Rect, rng.IntN(8))
+		xs, ys := randomIntervals(rng, len(rects), 30), randomIntervals(rng, len(rects), 30)
+		for i := range rects {
+			rects[i] = Rect{xs[i], ys[i]}
+		}
+		want := 0
+		for x := range 50 {
+			for y := range 50 {
+				for _, r := range rects {
+					if r.X.Contains(x) && r.Y.Contains(y) {
+						want++
+						break
+					}
+				}
+			}
+		}
+		if got, err := UnionArea(rects); got != want || err != nil {
+			t.Fatalf("UnionArea(%v) = %d, %v, want %d", rects, got, err, want)
+		}
+	}
+}
+
+// TestUnionAreaOverflow tests that areas beyond an int are reported
+func TestUnionAreaOverflow(t *testing.T) {
+	big := Interval{0, math.MaxInt / 2}
+	if _, err := UnionArea([]Rect{{big, big}}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("UnionArea of a huge square: error = %v, want ErrOverflow", err)
+	}
+	wide := Interval{math.MinInt / 2, math.MaxInt/2 + 2}
+	if _, err := UnionArea([]Rect{{wide, Interval{0, 1}}}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("UnionArea of a too wide rectangle: error = %v, want ErrOverflow", err)
+	}
+	if got, err := UnionArea([]Rect{{big, Interval{0, 2}}}); got != big.End*2 || err != nil {
+		t.Errorf("UnionArea of a wide strip = %d, %v, want %d", got, err, big.End*2)
+	}
+}
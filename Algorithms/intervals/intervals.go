@@ -0,0 +1,111 @@
+// Package intervals provides algorithms on intervals of the integer line:
+// merging, weighted scheduling, stabbing queries with an interval tree, and
+// the area of a union of rectangles by a sweep line. Intervals are
+// half-open, so [1, 3) and [3, 5) touch without overlapping, and an
+// interval whose End is not after its Start is empty.
+package intervals
+
+import (
+	"cmp"
+	"slices"
+
+	"hellogolang/Algorithms/searching"
+)
+
+// Interval is the half-open range [Start, End)
+type Interval struct {
+	Start, End int
+}
+
+// Empty reports whether the interval contains no points
+func (iv Interval) Empty() bool {
+	return iv.End <= iv.Start
+}
+
+// Contains reports whether x lies in the interval
+func (iv Interval) Contains(x int) bool {
+	return iv.Start <= x && x < iv.End
+}
+
+// Overlaps reports whether the intervals share a point
+func (iv Interval) Overlaps(other Interval) bool {
+	return iv.Start < other.End && other.Start < iv.End && !iv.Empty() && !other.Empty()
+}
+
+// Merge returns the union of the intervals as disjoint intervals sorted by
+// start. Overlapping and touching intervals are combined, and empty ones
+// are dropped. The input is not modified.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func Merge(intervals []Interval) []Interval {
+	sorted := make([]Interval, 0, len(intervals))
+	for _, iv := range intervals {
+		if !iv.Empty() {
+			sorted = append(sorted, iv)
+		}
+	}
+	slices.SortFunc(sorted, func(a, b Interval) int { return cmp.Compare(a.Start, b.Start) })
+
+	merged := []Interval{}
+	for _, iv := range sorted {
+		if last := len(merged) - 1; last >= 0 && iv.Start <= merged[last].End {
+			merged[last].End = max(merged[last].End, iv.End)
+		} else {
+			merged = append(merged, iv)
+		}
+	}
+	return merged
+}
+
+// WeightedInterval is an interval earning Weight if scheduled
+type WeightedInterval struct {
+	Interval
+	Weight int
+}
+
+// MaxWeightSchedule finds the greatest total weight of pairwise
+// non-overlapping intervals, and the indices of the intervals attaining it
+// in increasing order. Ordered by end, the best of the first j intervals
+// either skips interval j or takes it with the best of those ending by its
+// start, found by binary search. Intervals of weight zero or less are never
+// taken, empty intervals of positive weight always are, and the total
+// weight must fit in an int.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func MaxWeightSchedule(intervals []WeightedInterval) (int, []int) {
+	// An empty interval overlaps nothing, so it needs no scheduling
+	total := 0
+	taken := []int{}
+	order := []int{}
+	for i, iv := range intervals {
+		switch {
+		case iv.Weight <= 0:
+		case iv.Empty():
+			total += iv.Weight
+			taken = append(taken, i)
+		default:
+			order = append(order, i)
+		}
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return cmp.Compare(intervals[a].End, intervals[b].End) })
+
+	// best[j] is the greatest weight of the first j intervals in order, and
+	// compatible[j] the number of them ending by the start of order[j]
+	n := len(order)
+	best := make([]int, n+1)
+	compatible := make([]int, n)
+	for j, i := range order {
+		compatible[j] = searching.SearchMonotonic(func(k int) bool { return intervals[order[k]].End > intervals[i].Start }, 0, j)
+		best[j+1] = max(best[j], best[compatible[j]]+intervals[i].Weight)
+	}
+
+	// Interval j was taken wherever skipping it falls short of the best
+	for j := n; j > 0; {
+		if best[j] > best[j-1] {
+			taken = append(taken, order[j-1])
+			j = compatible[j-1]
+		} else {
+			j--
+		}
+	}
+	slices.Sort(taken)
+	return total + best[n], taken
+}
@@ -0,0 +1,123 @@
+package intervals
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// randomIntervals returns n intervals within [0, span), some of them empty
+func randomIntervals(rng *rand.Rand, n, span int) []Interval {
+	intervals := make([]Interval, n)
+	for i := range intervals {
+		start := rng.IntN(span)
+		intervals[i] = Interval{start, start + rng.IntN(span/3+1)}
+	}
+	return intervals
+}
+
+// TestMerge tests merging against marking the covered points
+func TestMerge(t *testing.T) {
+	got := Merge([]Interval{{8, 10}, {1, 3}, {2, 6}, {15, 18}, {6, 7}, {20, 20}})
+	if want := []Interval{{1, 7}, {8, 10}, {15, 18}}; !slices.Equal(got, want) {
+		t.Errorf("Merge = %v, want %v", got, want)
+	}
+	if got := Merge(nil); len(got) != 0 {
+		t.Errorf("Merge(nil) = %v", got)
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		intervals := randomIntervals(rng, rng.IntN(12), 40)
+		covered := make([]bool, 60)
+		for _, iv := range intervals {
+			for x := iv.Start; x < iv.End; x++ {
+				covered[x] = true
+			}
+		}
+
+		merged := Merge(intervals)
+		got := make([]bool, 60)
+		for k, iv := range merged {
+			if iv.Empty() || (k > 0 && iv.Start <= merged[k-1].End) {
+				t.Fatalf("Merge(%v) = %v is not disjoint and sorted", intervals, merged)
+			}
+			for x := iv.Start; x < iv.End; x++ {
+				got[x] = true
+			}
+		}
+		if !slices.Equal(got, covered) {
+			t.Fatalf("Merge(%v) = %v covers different points", intervals, merged)
+		}
+	}
+}
+
+// TestOverlaps tests the interval predicates on touching and empty intervals
+func TestOverlaps(t *testing.T) {
+	a, b, c, empty := Interval{1, 3}, Interval{3, 5}, Interval{2, 4}, Interval{2, 2}
+	if a.Overlaps(b) || !a.Overlaps(c) || a.Overlaps(empty) || empty.Overlaps(a) {
+		t.Errorf("Overlaps gives wrong results for %v, %v, %v, %v", a, b, c, empty)
+	}
+	if !a.Contains(1) || a.Contains(3) || empty.Contains(2) || !empty.Empty() {
+		t.Errorf("Contains gives wrong results for %v, %v", a, empty)
+	}
+}
+
+// TestMaxWeightSchedule tests the best weight and schedule against trying
+// every subset of intervals
+func TestMaxWeightSchedule(t *testing.T) {
+	intervals := []WeightedInterval{
+		{Interval{1, 3}, 5}, {Interval{2, 5}, 7}, {Interval{4, 6}, 5},
+		{Interval{6, 7}, 4}, {Interval{5, 8}, 11}, {Interval{7, 9}, 2},
+	}
+	if total, taken := MaxWeightSchedule(intervals); total != 18 || !slices.Equal(taken, []int{1, 4}) {
+		t.Errorf("MaxWeightSchedule = %d, %v, want 18, [1 4]", total, taken)
+	}
+
+	rng := rand.New(rand.NewPCG(3, 4))
+	for range 300 {
+		n := rng.IntN(11)
+		intervals := make([]WeightedInterval, n)
+		for i, iv := range randomIntervals(rng, n, 20) {
+			intervals[i] = WeightedInterval{iv, rng.IntN(12) - 2}
+		}
+
+		best := 0
+		for mask := range 1 << n {
+			if weight, ok := scheduleWeight(intervals, mask); ok {
+				best = max(best, weight)
+			}
+		}
+
+		total, taken := MaxWeightSchedule(intervals)
+		mask := 0
+		for _, i := range taken {
+			mask |= 1 << i
+			if intervals[i].Weight <= 0 {
+				t.Fatalf("MaxWeightSchedule(%v) takes %d of weight %d", intervals, i, intervals[i].Weight)
+			}
+		}
+		weight, ok := scheduleWeight(intervals, mask)
+		if total != best || weight != best || !ok || !slices.IsSorted(taken) {
+			t.Fatalf("MaxWeightSchedule(%v) = %d, %v; want weight %d", intervals, total, taken, best)
+		}
+	}
+}
+
+// scheduleWeight returns the weight of the intervals in mask, and whether
+// they are pairwise non-overlapping
+func scheduleWeight(intervals []WeightedInterval, mask int) (int, bool) {
+	weight := 0
+	for i := range intervals {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		weight += intervals[i].Weight
+		for j := range i {
+			if mask&(1<<j) != 0 && intervals[i].Overlaps(intervals[j].Interval) {
+				return 0, false
+			}
+		}
+	}
+	return weight, true
+}
@@ -0,0 +1,113 @@
+package intervals
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Tree is a static centered interval tree answering stabbing queries: which
+// intervals contain a point. Each node holds the intervals containing its
+// center, sorted by start and by end, with those wholly before the center
+// in its left subtree and those wholly after in its right.
+type Tree struct {
+	intervals []Interval
+	root      *treeNode
+}
+
+// treeNode is a node of a Tree, holding indices into Tree.intervals
+type treeNode struct {
+	center      int
+	byStart     []int // Intervals containing center, by increasing start
+	byEnd       []int // The same intervals, by decreasing end
+	left, right *treeNode
+}
+
+// NewTree builds an interval tree over a copy of the intervals. Queries
+// return indices into the intervals given; empty intervals contain no point
+// and are never returned.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func NewTree(intervals []Interval) *Tree {
+	t := &Tree{intervals: slices.Clone(intervals)}
+	indices := []int{}
+	for i, iv := range t.intervals {
+		if !iv.Empty() {
+			indices = append(indices, i)
+		}
+	}
+	t.root = t.build(indices)
+	return t
+}
+
+// Len returns the number of intervals in the tree, including empty ones
+func (t *Tree) Len() int {
+	return len(t.intervals)
+}
+
+// build builds the subtree over the non-empty intervals at indices. The
+// center is their median start, so the node holds at least the interval
+// starting there, and each side receives at most half of them.
+func (t *Tree) build(indices []int) *treeNode {
+	if len(indices) == 0 {
+		return nil
+	}
+	starts := make([]int, len(indices))
+	for k, i := range indices {
+		starts[k] = t.intervals[i].Start
+	}
+	slices.Sort(starts)
+	node := &treeNode{center: starts[len(starts)/2]}
+
+	var left, right []int
+	for _, i := range indices {
+		switch iv := t.intervals[i]; {
+		case iv.End <= node.center:
+			left = append(left, i)
+		case iv.Start > node.center:
+			right = append(right, i)
+		default:
+			node.byStart = append(node.byStart, i)
+		}
+	}
+	node.byEnd = slices.Clone(node.byStart)
+	slices.SortFunc(node.byStart, func(a, b int) int { return cmp.Compare(t.intervals[a].Start, t.intervals[b].Start) })
+	slices.SortFunc(node.byEnd, func(a, b int) int { return cmp.Compare(t.intervals[b].End, t.intervals[a].End) })
+	node.left, node.right = t.build(left), t.build(right)
+	return node
+}
+
+// Stab returns the indices of the intervals containing x, in increasing
+// order
+// Time Complexity: O(log n + k log k) for k results, Space Complexity: O(k)
+func (t *Tree) Stab(x int) []int {
+	found := []int{}
+	for node := t.root; node != nil; {
+		switch {
+		case x < node.center:
+			// Every interval here ends after the center, so it contains x
+			// if it starts by x
+			for _, i := range node.byStart {
+				if t.intervals[i].Start > x {
+					break
+				}
+				found = append(found, i)
+			}
+			node = node.left
+		case x > node.center:
+			// Every interval here starts by the center, so it contains x
+			// if it ends after x
+			for _, i := range node.byEnd {
+				if t.intervals[i].End <= x {
+					break
+				}
+				found = append(found, i)
+			}
+			node = node.right
+		default:
+			// Intervals in the subtrees lie wholly before or after the center
+			found = append(found, node.byStart...)
+			node = nil
+		}
+	}
+	slices.Sort(found)
+	return found
+}
@@ -0,0 +1,65 @@
+package intervals
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestTreeStab tests stabbing queries against scanning every interval
+func TestTreeStab(t *testing.T) {
+	tree := NewTree([]Interval{{15, 20}, {10, 30}, {17, 19}, {5, 20}, {12, 15}, {30, 40}})
+	if got, want := tree.Stab(18), []int{0, 1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("Stab(18) = %v, want %v", got, want)
+	}
+	if got, want := tree.Stab(30), []int{5}; !slices.Equal(got, want) {
+		t.Errorf("Stab(30) = %v, want %v", got, want)
+	}
+	if got := tree.Stab(40); len(got) != 0 {
+		t.Errorf("Stab(40) = %v, want none", got)
+	}
+
+	rng := rand.New(rand.NewPCG(5, 6))
+	for range 100 {
+		intervals := randomIntervals(rng, rng.IntN(60), 100)
+		tree := NewTree(intervals)
+		if tree.Len() != len(intervals) {
+			t.Fatalf("Len = %d, want %d", tree.Len(), len(intervals))
+		}
+		for x := -1; x <= 140; x++ {
+			want := []int{}
+			for i, iv := range intervals {
+				if iv.Contains(x) {
+					want = append(want, i)
+				}
+			}
+			if got := tree.Stab(x); !slices.Equal(got, want) {
+				t.Fatalf("Stab(%d) over %v = %v, want %v", x, intervals, got, want)
+			}
+		}
+	}
+}
+
+// TestTreeDepth tests that the tree stays shallow on nested and disjoint
+// intervals
+func TestTreeDepth(t *testing.T) {
+	var depth func(*treeNode) int
+	depth = func(node *treeNode) int {
+		if node == nil {
+			return 0
+		}
+		return 1 + max(depth(node.left), depth(node.right))
+	}
+
+	n := 1 << 12
+	disjoint, nested := make([]Interval, n), make([]Interval, n)
+	for i := range n {
+		disjoint[i] = Interval{2 * i, 2*i + 1}
+		nested[i] = Interval{i, 2*n - i}
+	}
+	for _, intervals := range [][]Interval{disjoint, nested} {
+		if d := depth(NewTree(intervals).root); d > 13 {
+			t.Errorf("tree depth %d for %d intervals, want at most 13", d, n)
+		}
+	}
+}
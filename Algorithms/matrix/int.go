@@ -0,0 +1,309 @@
+package matrix
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// IntMatrix is a dense matrix of int, stored by rows. Its arithmetic is
+// exact: results that overflow return ErrOverflow.
+type IntMatrix struct {
+	rows, cols int
+	data       []int
+}
+
+// NewInt creates a rows × cols matrix of zeros; negative sizes are taken as
+// 0
+func NewInt(rows, cols int) *IntMatrix {
+	rows, cols = max(rows, 0), max(cols, 0)
+	return &IntMatrix{rows: rows, cols: cols, data: make([]int, rows*cols)}
+}
+
+// IntIdentity creates the n × n identity matrix
+func IntIdentity(n int) *IntMatrix {
+	m := NewInt(n, n)
+	for i := range m.rows {
+		m.data[i*m.cols+i] = 1
+	}
+	return m
+}
+
+// IntFromRows creates a matrix from a copy of its rows, which must all have
+// the same length
+func IntFromRows(rows [][]int) (*IntMatrix, error) {
+	m := NewInt(len(rows), 0)
+	if len(rows) > 0 {
+		m.cols = len(rows[0])
+	}
+	m.data = make([]int, 0, m.rows*m.cols)
+	for i, row := range rows {
+		if len(row) != m.cols {
+			return nil, fmt.Errorf("%w: row %d has %d columns, want %d", ErrDimension, i, len(row), m.cols)
+		}
+		m.data = append(m.data, row...)
+	}
+	return m, nil
+}
+
+// Rows returns the number of rows
+func (m *IntMatrix) Rows() int {
+	return m.rows
+}
+
+// Cols returns the number of columns
+func (m *IntMatrix) Cols() int {
+	return m.cols
+}
+
+// At returns the element in row i and column j
+func (m *IntMatrix) At(i, j int) (int, error) {
+	// Secure: bounds checking
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		return 0, fmt.Errorf("%w: (%d, %d) in a %dx%d matrix", ErrIndex, i, j, m.rows, m.cols)
+	}
+	return m.data[i*m.cols+j], nil
+}
+
+// Set sets the element in row i and column j
+func (m *IntMatrix) Set(i, j int, v int) error {
+	// Secure: bounds checking
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		return fmt.Errorf("%w: (%d, %d) in a %dx%d matrix", ErrIndex, i, j, m.rows, m.cols)
+	}
+	m.data[i*m.cols+j] = v
+	return nil
+}
+
+// ToRows returns a copy of the rows of the matrix
+func (m *IntMatrix) ToRows() [][]int {
+	rows := make([][]int, m.rows)
+	for i := range rows {
+		rows[i] = append([]int(nil), m.data[i*m.cols:(i+1)*m.cols]...)
+	}
+	return rows
+}
+
+// Float returns the matrix converted to a Matrix, to solve systems with it
+func (m *IntMatrix) Float() *Matrix {
+	f := New(m.rows, m.cols)
+	for i, v := range m.data {
+		f.data[i] = float64(v)
+	}
+	return f
+}
+
+// Mul returns the product m × other. It returns ErrOverflow if any product
+// of elements or partial sum of products overflows, even one that later
+// terms would bring back into range.
+// Time Complexity: O(n·m·p), Space Complexity: O(n·p)
+func (m *IntMatrix) Mul(other *IntMatrix) (*IntMatrix, error) {
+	if m.cols != other.rows {
+		return nil, fmt.Errorf("%w: %dx%d times %dx%d", ErrDimension, m.rows, m.cols, other.rows, other.cols)
+	}
+	product := NewInt(m.rows, other.cols)
+	for i := range m.rows {
+		row := product.data[i*product.cols : (i+1)*product.cols]
+		for k := range m.cols {
+			a := m.data[i*m.cols+k]
+			if a == 0 {
+				continue
+			}
+			for j, b := range other.data[k*other.cols : (k+1)*other.cols] {
+				// Secure: check the product and the sum for overflow
+				term, ok := checkedMul(a, b)
+				if !ok {
+					return nil, ErrOverflow
+				}
+				if row[j], ok = checkedAdd(row[j], term); !ok {
+					return nil, ErrOverflow
+				}
+			}
+		}
+	}
+	return product, nil
+}
+
+// Pow returns m^n for a square matrix by repeated squaring, returning
+// ErrOverflow if any product on the way overflows. The base is not squared
+// past the highest bit of n, so no square larger than needed is formed.
+// Time Complexity: O(k³ log n) for a k × k matrix, Space Complexity: O(k²)
+func (m *IntMatrix) Pow(n int) (*IntMatrix, error) {
+	if m.rows != m.cols {
+		return nil, fmt.Errorf("%w: %dx%d", ErrNotSquare, m.rows, m.cols)
+	}
+	if n < 0 {
+		return nil, ErrNegative
+	}
+	result, base := IntIdentity(m.rows), m
+	var err error
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			if result, err = result.Mul(base); err != nil {
+				return nil, err
+			}
+		}
+		if n > 1 {
+			if base, err = base.Mul(base); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// MulMod returns the product m × other with its elements reduced modulo
+// mod into [0, mod). Products are formed in 128 bits, so none overflows.
+// Time Complexity: O(n·m·p), Space Complexity: O(n·p)
+func (m *IntMatrix) MulMod(other *IntMatrix, mod int) (*IntMatrix, error) {
+	if mod <= 0 {
+		return nil, ErrModulus
+	}
+	if m.cols != other.rows {
+		return nil, fmt.Errorf("%w: %dx%d times %dx%d", ErrDimension, m.rows, m.cols, other.rows, other.cols)
+	}
+	a, b := m.reduce(mod), other.reduce(mod)
+	product := NewInt(m.rows, other.cols)
+	for i := range m.rows {
+		row := product.data[i*product.cols : (i+1)*product.cols]
+		for k := range m.cols {
+			x := a.data[i*a.cols+k]
+			if x == 0 {
+				continue
+			}
+			for j, y := range b.data[k*b.cols : (k+1)*b.cols] {
+				row[j] = addMod(row[j], mulMod(x, y, mod), mod)
+			}
+		}
+	}
+	return product, nil
+}
+
+// PowMod returns m^n for a square matrix with its elements reduced modulo
+// mod into [0, mod)
+// Time Complexity: O(k³ log n) for a k × k matrix, Space Complexity: O(k²)
+func (m *IntMatrix) PowMod(n, mod int) (*IntMatrix, error) {
+	if m.rows != m.cols {
+		return nil, fmt.Errorf("%w: %dx%d", ErrNotSquare, m.rows, m.cols)
+	}
+	if n < 0 {
+		return nil, ErrNegative
+	}
+	if mod <= 0 {
+		return nil, ErrModulus
+	}
+	result, base := IntIdentity(m.rows).reduce(mod), m.reduce(mod)
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			result, _ = result.MulMod(base, mod)
+		}
+		if n > 1 {
+			base, _ = base.MulMod(base, mod)
+		}
+	}
+	return result, nil
+}
+
+// reduce returns a copy of the matrix with its elements reduced modulo mod
+// into [0, mod)
+func (m *IntMatrix) reduce(mod int) *IntMatrix {
+	r := NewInt(m.rows, m.cols)
+	for i, v := range m.data {
+		if r.data[i] = v % mod; r.data[i] < 0 {
+			r.data[i] += mod
+		}
+	}
+	return r
+}
+
+// Det returns the exact determinant of a square matrix by Bareiss's
+// fraction-free elimination, in which every division is exact. The
+// elements grow to minors of the matrix, so they are held as big integers;
+// ErrOverflow is returned only if the determinant itself does not fit.
+// Time Complexity: O(n³) big-integer operations, Space Complexity: O(n²)
+func (m *IntMatrix) Det() (int, error) {
+	if m.rows != m.cols {
+		return 0, fmt.Errorf("%w: %dx%d", ErrNotSquare, m.rows, m.cols)
+	}
+	n := m.rows
+	if n == 0 {
+		return 1, nil
+	}
+	a := make([]*big.Int, len(m.data))
+	for i, v := range m.data {
+		a[i] = big.NewInt(int64(v))
+	}
+
+	negate := false
+	prev := big.NewInt(1)
+	t := new(big.Int)
+	for k := range n - 1 {
+		if a[k*n+k].Sign() == 0 {
+			pivot := k + 1
+			for pivot < n && a[pivot*n+k].Sign() == 0 {
+				pivot++
+			}
+			if pivot == n {
+				return 0, nil
+			}
+			for j := range n {
+				a[k*n+j], a[pivot*n+j] = a[pivot*n+j], a[k*n+j]
+			}
+			negate = !negate
+		}
+		// Each element below and right of the pivot becomes a (k+2)-minor
+		for i := k + 1; i < n; i++ {
+			for j := k + 1; j < n; j++ {
+				a[i*n+j].Mul(a[i*n+j], a[k*n+k])
+				a[i*n+j].Sub(a[i*n+j], t.Mul(a[i*n+k], a[k*n+j]))
+				a[i*n+j].Quo(a[i*n+j], prev)
+			}
+		}
+		prev = a[k*n+k]
+	}
+
+	det := a[n*n-1]
+	if negate {
+		det.Neg(det)
+	}
+	if !det.IsInt64() || det.Int64() > math.MaxInt || det.Int64() < math.MinInt {
+		return 0, ErrOverflow
+	}
+	return int(det.Int64()), nil
+}
+
+// checkedAdd returns a + b and whether it fits in an int
+func checkedAdd(a, b int) (int, bool) {
+	if (b > 0 && a > math.MaxInt-b) || (b < 0 && a < math.MinInt-b) {
+		return 0, false
+	}
+	return a + b, true
+}
+
+// checkedMul returns a * b and whether it fits in an int
+func checkedMul(a, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	p := a * b
+	// Secure: -1 × MinInt wraps to MinInt, which division would not catch
+	if p/b != a || (a == -1 && b == math.MinInt) || (b == -1 && a == math.MinInt) {
+		return 0, false
+	}
+	return p, true
+}
+
+// mulMod returns a * b mod m for a, b in [0, m), using a 128-bit product
+func mulMod(a, b, m int) int {
+	hi, lo := bits.Mul64(uint64(a), uint64(b))
+	return int(bits.Rem64(hi, lo, uint64(m)))
+}
+
+// addMod returns a + b mod m for a, b in [0, m), without overflowing
+func addMod(a, b, m int) int {
+	if a >= m-b {
+		return a - (m - b)
+	}
+	return a + b
+}
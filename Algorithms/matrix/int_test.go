@@ -0,0 +1,174 @@
+package matrix
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// randomIntMatrix returns a rows × cols matrix of integers in [-r, r]
+func randomIntMatrix(rng *rand.Rand, rows, cols, r int) *IntMatrix {
+	m := NewInt(rows, cols)
+	for i := range m.data {
+		m.data[i] = rng.IntN(2*r+1) - r
+	}
+	return m
+}
+
+// bigRows converts the matrix to big integers
+func bigRows(m *IntMatrix) [][]*big.Int {
+	rows := make([][]*big.Int, m.rows)
+	for i := range rows {
+		for j := range m.cols {
+			rows[i] = append(rows[i], big.NewInt(int64(m.data[i*m.cols+j])))
+		}
+	}
+	return rows
+}
+
+// bigMul multiplies matrices of big integers by the definition
+func bigMul(a, b [][]*big.Int, inner, cols int) [][]*big.Int {
+	product := make([][]*big.Int, len(a))
+	for i := range a {
+		for j := range cols {
+			sum := new(big.Int)
+			for k := range inner {
+				sum.Add(sum, new(big.Int).Mul(a[i][k], b[k][j]))
+			}
+			product[i] = append(product[i], sum)
+		}
+	}
+	return product
+}
+
+// TestIntMul tests exact products, and overflow of a product or a sum
+func TestIntMul(t *testing.T) {
+	rng := rand.New(rand.NewPCG(9, 10))
+	for range 200 {
+		n, k, p := rng.IntN(5), rng.IntN(5), rng.IntN(5)
+		a, b := randomIntMatrix(rng, n, k, 1<<30), randomIntMatrix(rng, k, p, 1<<30)
+		got, err := a.Mul(b)
+		if err != nil {
+			t.Fatalf("Mul: %v", err)
+		}
+		want := bigMul(bigRows(a), bigRows(b), k, p)
+		for i := range n {
+			for j := range p {
+				if int64(got.data[i*p+j]) != want[i][j].Int64() {
+					t.Fatalf("Mul(%v, %v) = %v", a.ToRows(), b.ToRows(), got.ToRows())
+				}
+			}
+		}
+	}
+
+	big1, _ := IntFromRows([][]int{{math.MaxInt / 2, 3}})
+	if _, err := big1.Mul(NewInt(1, 1)); !errors.Is(err, ErrDimension) {
+		t.Errorf("Mul of 1x2 and 1x1: error = %v, want ErrDimension", err)
+	}
+	col, _ := IntFromRows([][]int{{3}, {1}})
+	if _, err := big1.Mul(col); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Mul with an overflowing product: error = %v, want ErrOverflow", err)
+	}
+	sum, _ := IntFromRows([][]int{{math.MaxInt, 1}})
+	ones, _ := IntFromRows([][]int{{1}, {1}})
+	if _, err := sum.Mul(ones); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Mul with an overflowing sum: error = %v, want ErrOverflow", err)
+	}
+	minInt, _ := IntFromRows([][]int{{math.MinInt}})
+	negOne, _ := IntFromRows([][]int{{-1}})
+	if _, err := minInt.Mul(negOne); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MinInt × -1: error = %v, want ErrOverflow", err)
+	}
+}
+
+// TestIntPow tests powers against big integers, with and without a modulus
+func TestIntPow(t *testing.T) {
+	rng := rand.New(rand.NewPCG(11, 12))
+	for range 100 {
+		n := 1 + rng.IntN(3)
+		m := randomIntMatrix(rng, n, n, 5)
+		mod := 1 + rng.IntN(1000)
+		want := bigRows(IntIdentity(n))
+		for e := range 20 {
+			got, err := m.Pow(e)
+			fits := true
+			for _, row := range want {
+				for _, v := range row {
+					fits = fits && v.IsInt64()
+				}
+			}
+			if fits && (err != nil || !slices.EqualFunc(got.data, slices.Concat(want...), func(a int, b *big.Int) bool { return int64(a) == b.Int64() })) {
+				t.Fatalf("Pow(%v, %d) = %v, %v", m.ToRows(), e, got, err)
+			}
+
+			gotMod, err := m.PowMod(e, mod)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range slices.Concat(want...) {
+				if int64(gotMod.data[i]) != new(big.Int).Mod(v, big.NewInt(int64(mod))).Int64() {
+					t.Fatalf("PowMod(%v, %d, %d) = %v", m.ToRows(), e, mod, gotMod.ToRows())
+				}
+			}
+			want = bigMul(want, bigRows(m), n, n)
+		}
+	}
+
+	fib, _ := IntFromRows([][]int{{1, 1}, {1, 0}})
+	// fib^n holds F(n+1), so F93 overflows at n = 92
+	if p, err := fib.Pow(91); err != nil || p.data[0] != 7540113804746346429 {
+		t.Errorf("Pow(fib, 91) = %v, %v, want F92 = 7540113804746346429", p, err)
+	}
+	if _, err := fib.Pow(92); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Pow(fib, 92): error = %v, want ErrOverflow", err)
+	}
+	if p, err := fib.PowMod(1<<62, math.MaxInt); err != nil || p.data[0] < 0 {
+		t.Errorf("PowMod(fib, 2^62, MaxInt) = %v, %v", p, err)
+	}
+	if _, err := fib.Pow(-1); !errors.Is(err, ErrNegative) {
+		t.Errorf("Pow(-1): error = %v, want ErrNegative", err)
+	}
+	if _, err := fib.PowMod(2, 0); !errors.Is(err, ErrModulus) {
+		t.Errorf("PowMod modulo 0: error = %v, want ErrModulus", err)
+	}
+	if _, err := NewInt(1, 2).Pow(2); !errors.Is(err, ErrNotSquare) {
+		t.Errorf("Pow of a 1x2 matrix: error = %v, want ErrNotSquare", err)
+	}
+}
+
+// TestIntDet tests exact determinants against the float cofactor expansion
+// on small entries, and overflow of the determinant itself
+func TestIntDet(t *testing.T) {
+	rng := rand.New(rand.NewPCG(13, 14))
+	for range 300 {
+		n := rng.IntN(6)
+		m := randomIntMatrix(rng, n, n, 3)
+		if rng.IntN(4) == 0 && n > 1 {
+			// Repeat a row to make the matrix singular
+			copy(m.data[:n], m.data[n:2*n])
+		}
+		got, err := m.Det()
+		if want := cofactorDet(m.Float().ToRows()); err != nil || float64(got) != want {
+			t.Fatalf("Det(%v) = %d, %v, want %v", m.ToRows(), got, err, want)
+		}
+	}
+
+	// Elimination passes through products far beyond an int
+	large, _ := IntFromRows([][]int{{math.MaxInt, math.MaxInt - 1}, {math.MaxInt - 1, math.MaxInt - 2}})
+	if det, err := large.Det(); det != -1 || err != nil {
+		t.Errorf("Det of a large unimodular matrix = %d, %v, want -1", det, err)
+	}
+	overflow, _ := IntFromRows([][]int{{math.MaxInt, 0}, {0, 2}})
+	if _, err := overflow.Det(); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Det beyond an int: error = %v, want ErrOverflow", err)
+	}
+	if det, err := NewInt(0, 0).Det(); det != 1 || err != nil {
+		t.Errorf("Det of the empty matrix = %d, %v, want 1", det, err)
+	}
+	if _, err := NewInt(2, 1).Det(); !errors.Is(err, ErrNotSquare) {
+		t.Errorf("Det of a 2x1 matrix: error = %v, want ErrNotSquare", err)
+	}
+}
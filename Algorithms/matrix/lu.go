@@ -0,0 +1,158 @@
+package matrix
+
+import (
+	"fmt"
+	"math"
+)
+
+// LU is the LU decomposition of a square matrix A with partial pivoting:
+// PA = LU for a permutation P, a unit lower triangular L and an upper
+// triangular U. Once computed, it solves systems with A in O(n²) each.
+type LU struct {
+	n int
+	// lu holds L below the diagonal, its unit diagonal implied, and U on
+	// and above it
+	lu []float64
+	// perm[i] is the row of A that became row i of PA
+	perm []int
+	// sign is the determinant of P, 1 or -1
+	sign float64
+	// singular records a pivot too small to divide by
+	singular bool
+}
+
+// LU decomposes a square matrix by Gaussian elimination, taking as pivot
+// the largest remaining element of each column. A matrix whose pivot
+// falls to rounding error, n·ε times its largest element, is taken to be
+// singular: its decomposition has determinant 0 and cannot solve.
+// Time Complexity: O(n³), Space Complexity: O(n²)
+func (m *Matrix) LU() (*LU, error) {
+	if m.rows != m.cols {
+		return nil, fmt.Errorf("%w: %dx%d", ErrNotSquare, m.rows, m.cols)
+	}
+	n := m.rows
+	f := &LU{n: n, lu: append([]float64(nil), m.data...), perm: make([]int, n), sign: 1}
+	for i := range f.perm {
+		f.perm[i] = i
+	}
+	largest := 0.0
+	for _, v := range m.data {
+		largest = max(largest, math.Abs(v))
+	}
+	tolerance := float64(n) * 0x1p-52 * largest
+
+	a := f.lu
+	for k := range n {
+		pivot := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(a[i*n+k]) > math.Abs(a[pivot*n+k]) {
+				pivot = i
+			}
+		}
+		// Secure: never divide by a pivot of rounding error
+		if math.Abs(a[pivot*n+k]) <= tolerance {
+			f.singular = true
+			continue
+		}
+		if pivot != k {
+			for j := range n {
+				a[k*n+j], a[pivot*n+j] = a[pivot*n+j], a[k*n+j]
+			}
+			f.perm[k], f.perm[pivot] = f.perm[pivot], f.perm[k]
+			f.sign = -f.sign
+		}
+		for i := k + 1; i < n; i++ {
+			factor := a[i*n+k] / a[k*n+k]
+			a[i*n+k] = factor
+			for j := k + 1; j < n; j++ {
+				a[i*n+j] -= factor * a[k*n+j]
+			}
+		}
+	}
+	return f, nil
+}
+
+// Det returns the determinant of the decomposed matrix, the product of the
+// pivots signed by the permutation, or 0 if it is singular
+// Time Complexity: O(n), Space Complexity: O(1)
+func (f *LU) Det() float64 {
+	if f.singular {
+		return 0
+	}
+	det := f.sign
+	for i := range f.n {
+		det *= f.lu[i*f.n+i]
+	}
+	return det
+}
+
+// Solve returns the x with Ax = b, by forward substitution with L and back
+// substitution with U
+// Time Complexity: O(n²), Space Complexity: O(n)
+func (f *LU) Solve(b []float64) ([]float64, error) {
+	if len(b) != f.n {
+		return nil, fmt.Errorf("%w: %d values for %d equations", ErrDimension, len(b), f.n)
+	}
+	if f.singular {
+		return nil, ErrSingular
+	}
+	n, a := f.n, f.lu
+	x := make([]float64, n)
+	for i, p := range f.perm {
+		x[i] = b[p]
+		for j := range i {
+			x[i] -= a[i*n+j] * x[j]
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j < n; j++ {
+			x[i] -= a[i*n+j] * x[j]
+		}
+		x[i] /= a[i*n+i]
+	}
+	return x, nil
+}
+
+// Solve returns the x with mx = b for a square, non-singular m
+// Time Complexity: O(n³), Space Complexity: O(n²)
+func (m *Matrix) Solve(b []float64) ([]float64, error) {
+	f, err := m.LU()
+	if err != nil {
+		return nil, err
+	}
+	return f.Solve(b)
+}
+
+// Det returns the determinant of a square matrix
+// Time Complexity: O(n³), Space Complexity: O(n²)
+func (m *Matrix) Det() (float64, error) {
+	f, err := m.LU()
+	if err != nil {
+		return 0, err
+	}
+	return f.Det(), nil
+}
+
+// Inverse returns the inverse of a square, non-singular matrix, solving
+// for each column of the identity
+// Time Complexity: O(n³), Space Complexity: O(n²)
+func (m *Matrix) Inverse() (*Matrix, error) {
+	f, err := m.LU()
+	if err != nil {
+		return nil, err
+	}
+	if f.singular {
+		return nil, ErrSingular
+	}
+	inv := New(m.rows, m.cols)
+	e := make([]float64, m.rows)
+	for j := range m.cols {
+		clear(e)
+		e[j] = 1
+		column, _ := f.Solve(e)
+		for i, v := range column {
+			inv.data[i*inv.cols+j] = v
+		}
+	}
+	return inv, nil
+}
@@ -0,0 +1,116 @@
+package matrix
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// cofactorDet computes a determinant by cofactor expansion along the first
+// row
+func cofactorDet(rows [][]float64) float64 {
+	n := len(rows)
+	if n == 0 {
+		return 1
+	}
+	det, sign := 0.0, 1.0
+	for j := range n {
+		minor := make([][]float64, 0, n-1)
+		for _, row := range rows[1:] {
+			minor = append(minor, append(append([]float64(nil), row[:j]...), row[j+1:]...))
+		}
+		det += sign * rows[0][j] * cofactorDet(minor)
+		sign = -sign
+	}
+	return det
+}
+
+// TestDet tests determinants against cofactor expansion
+func TestDet(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	for range 300 {
+		n := rng.IntN(6)
+		m := randomMatrix(rng, n, n)
+		got, err := m.Det()
+		want := cofactorDet(m.ToRows())
+		if err != nil || math.Abs(got-want) > 1e-9*max(1, math.Abs(want)) {
+			t.Fatalf("Det(%v) = %v, %v, want %v", m.ToRows(), got, err, want)
+		}
+	}
+
+	singular, _ := FromRows([][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}})
+	if det, _ := singular.Det(); det != 0 {
+		t.Errorf("Det of a singular matrix = %v, want 0", det)
+	}
+	if _, err := New(2, 3).Det(); !errors.Is(err, ErrNotSquare) {
+		t.Errorf("Det of a 2x3 matrix: error = %v, want ErrNotSquare", err)
+	}
+}
+
+// TestSolve tests that solutions satisfy their systems, and the errors
+func TestSolve(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	for range 300 {
+		n := 1 + rng.IntN(8)
+		m := randomMatrix(rng, n, n)
+		if det, _ := m.Det(); det == 0 {
+			continue
+		}
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = rng.NormFloat64()
+		}
+		b := make([]float64, n)
+		for i := range n {
+			for j := range n {
+				b[i] += m.data[i*n+j] * x[j]
+			}
+		}
+		got, err := m.Solve(b)
+		if err != nil {
+			t.Fatalf("Solve(%v, %v): %v", m.ToRows(), b, err)
+		}
+		for i := range x {
+			if math.Abs(got[i]-x[i]) > 1e-6 {
+				t.Fatalf("Solve(%v, %v) = %v, want %v", m.ToRows(), b, got, x)
+			}
+		}
+
+		inv, err := m.Inverse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if product, _ := m.Mul(inv); !equalRows(product.ToRows(), Identity(n).ToRows(), 1e-6) {
+			t.Fatalf("m × Inverse(m) = %v, want the identity", product.ToRows())
+		}
+	}
+
+	// A zero on the diagonal needs a row swap
+	m, _ := FromRows([][]float64{{0, 1}, {1, 0}})
+	if x, err := m.Solve([]float64{3, 4}); err != nil || x[0] != 4 || x[1] != 3 {
+		t.Errorf("Solve with a zero pivot = %v, %v, want [4 3]", x, err)
+	}
+
+	singular, _ := FromRows([][]float64{{1, 2}, {2, 4}})
+	if _, err := singular.Solve([]float64{1, 2}); !errors.Is(err, ErrSingular) {
+		t.Errorf("Solve of a singular system: error = %v, want ErrSingular", err)
+	}
+	if _, err := singular.Inverse(); !errors.Is(err, ErrSingular) {
+		t.Errorf("Inverse of a singular matrix: error = %v, want ErrSingular", err)
+	}
+	if _, err := m.Solve([]float64{1, 2, 3}); !errors.Is(err, ErrDimension) {
+		t.Errorf("Solve with 3 values for 2 equations: error = %v, want ErrDimension", err)
+	}
+	if _, err := New(3, 2).Solve([]float64{1, 2, 3}); !errors.Is(err, ErrNotSquare) {
+		t.Errorf("Solve with a 3x2 matrix: error = %v, want ErrNotSquare", err)
+	}
+
+	// One decomposition solves many systems
+	f, _ := m.LU()
+	for _, b := range [][]float64{{1, 0}, {0, 1}, {5, -5}} {
+		if x, err := f.Solve(b); err != nil || x[0] != b[1] || x[1] != b[0] {
+			t.Errorf("LU.Solve(%v) = %v, %v", b, x, err)
+		}
+	}
+}
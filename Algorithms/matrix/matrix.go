@@ -0,0 +1,165 @@
+// Package matrix provides dense matrices: Matrix of float64 and IntMatrix
+// of int. Both multiply and raise to powers by repeated squaring, which
+// computes linear recurrences such as the Fibonacci numbers in O(log n)
+// products; Matrix solves linear systems and finds determinants and
+// inverses by LU decomposition, and IntMatrix finds exact determinants.
+// Operations on matrices of incompatible shapes return ErrDimension rather
+// than truncating, and integer results that overflow return ErrOverflow.
+package matrix
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrDimension is returned when the shapes of matrices or vectors do
+	// not fit an operation, or rows passed to FromRows differ in length
+	ErrDimension = errors.New("dimension mismatch")
+	// ErrIndex is returned when a row or column index is out of range
+	ErrIndex = errors.New("index out of range")
+	// ErrNotSquare is returned when an operation needs a square matrix
+	ErrNotSquare = errors.New("matrix is not square")
+	// ErrSingular is returned when solving with or inverting a singular
+	// matrix
+	ErrSingular = errors.New("matrix is singular")
+	// ErrNegative is returned for a negative exponent of an IntMatrix or a
+	// negative index into a recurrence
+	ErrNegative = errors.New("negative argument")
+	// ErrModulus is returned when a modulus is not positive
+	ErrModulus = errors.New("modulus must be positive")
+	// ErrOverflow is returned when an integer result does not fit in an int
+	ErrOverflow = errors.New("result overflows int")
+)
+
+// Matrix is a dense matrix of float64, stored by rows
+type Matrix struct {
+	rows, cols int
+	data       []float64
+}
+
+// New creates a rows × cols matrix of zeros; negative sizes are taken as 0
+func New(rows, cols int) *Matrix {
+	rows, cols = max(rows, 0), max(cols, 0)
+	return &Matrix{rows: rows, cols: cols, data: make([]float64, rows*cols)}
+}
+
+// Identity creates the n × n identity matrix
+func Identity(n int) *Matrix {
+	m := New(n, n)
+	for i := range m.rows {
+		m.data[i*m.cols+i] = 1
+	}
+	return m
+}
+
+// FromRows creates a matrix from a copy of its rows, which must all have
+// the same length
+func FromRows(rows [][]float64) (*Matrix, error) {
+	m := New(len(rows), 0)
+	if len(rows) > 0 {
+		m.cols = len(rows[0])
+	}
+	m.data = make([]float64, 0, m.rows*m.cols)
+	for i, row := range rows {
+		if len(row) != m.cols {
+			return nil, fmt.Errorf("%w: row %d has %d columns, want %d", ErrDimension, i, len(row), m.cols)
+		}
+		m.data = append(m.data, row...)
+	}
+	return m, nil
+}
+
+// Rows returns the number of rows
+func (m *Matrix) Rows() int {
+	return m.rows
+}
+
+// Cols returns the number of columns
+func (m *Matrix) Cols() int {
+	return m.cols
+}
+
+// At returns the element in row i and column j
+func (m *Matrix) At(i, j int) (float64, error) {
+	// Secure: bounds checking
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		return 0, fmt.Errorf("%w: (%d, %d) in a %dx%d matrix", ErrIndex, i, j, m.rows, m.cols)
+	}
+	return m.data[i*m.cols+j], nil
+}
+
+// Set sets the element in row i and column j
+func (m *Matrix) Set(i, j int, v float64) error {
+	// Secure: bounds checking
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		return fmt.Errorf("%w: (%d, %d) in a %dx%d matrix", ErrIndex, i, j, m.rows, m.cols)
+	}
+	m.data[i*m.cols+j] = v
+	return nil
+}
+
+// ToRows returns a copy of the rows of the matrix
+func (m *Matrix) ToRows() [][]float64 {
+	rows := make([][]float64, m.rows)
+	for i := range rows {
+		rows[i] = append([]float64(nil), m.data[i*m.cols:(i+1)*m.cols]...)
+	}
+	return rows
+}
+
+// clone returns a copy of the matrix
+func (m *Matrix) clone() *Matrix {
+	return &Matrix{rows: m.rows, cols: m.cols, data: append([]float64(nil), m.data...)}
+}
+
+// Mul returns the product m × other. The loops run in i, k, j order, so
+// the inner loop walks rows of both other and the result.
+// Time Complexity: O(n·m·p), Space Complexity: O(n·p)
+func (m *Matrix) Mul(other *Matrix) (*Matrix, error) {
+	if m.cols != other.rows {
+		return nil, fmt.Errorf("%w: %dx%d times %dx%d", ErrDimension, m.rows, m.cols, other.rows, other.cols)
+	}
+	product := New(m.rows, other.cols)
+	for i := range m.rows {
+		row := product.data[i*product.cols : (i+1)*product.cols]
+		for k := range m.cols {
+			a := m.data[i*m.cols+k]
+			if a == 0 {
+				continue
+			}
+			for j, b := range other.data[k*other.cols : (k+1)*other.cols] {
+				row[j] += a * b
+			}
+		}
+	}
+	return product, nil
+}
+
+// Pow returns m^n for a square matrix by repeated squaring; a negative n
+// raises the inverse, returning ErrSingular if there is none
+// Time Complexity: O(k³ log n) for a k × k matrix, Space Complexity: O(k²)
+func (m *Matrix) Pow(n int) (*Matrix, error) {
+	if m.rows != m.cols {
+		return nil, fmt.Errorf("%w: %dx%d", ErrNotSquare, m.rows, m.cols)
+	}
+	base := m
+	if n < 0 {
+		inv, err := m.Inverse()
+		if err != nil {
+			return nil, err
+		}
+		// Secure: -n overflows for the least int, but its bits are the same
+		base, n = inv, -n
+	}
+	result := Identity(m.rows)
+	for u := uint(n); u > 0; u >>= 1 {
+		if u&1 == 1 {
+			result, _ = result.Mul(base)
+		}
+		if u > 1 {
+			base, _ = base.Mul(base)
+		}
+	}
+	return result, nil
+}
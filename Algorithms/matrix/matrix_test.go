@@ -0,0 +1,128 @@
+package matrix
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// randomMatrix returns a rows × cols matrix of small random integers, as
+// floats so that products are exact
+func randomMatrix(rng *rand.Rand, rows, cols int) *Matrix {
+	m := New(rows, cols)
+	for i := range m.data {
+		m.data[i] = float64(rng.IntN(21) - 10)
+	}
+	return m
+}
+
+// naiveMul multiplies by the definition of the product
+func naiveMul(a, b [][]float64, inner, cols int) [][]float64 {
+	product := make([][]float64, len(a))
+	for i := range a {
+		product[i] = make([]float64, cols)
+		for j := range product[i] {
+			for k := range inner {
+				product[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return product
+}
+
+// equalRows reports whether the rows agree to within tolerance
+func equalRows(a, b [][]float64, tolerance float64) bool {
+	return slices.EqualFunc(a, b, func(x, y []float64) bool {
+		return slices.EqualFunc(x, y, func(u, v float64) bool { return math.Abs(u-v) <= tolerance })
+	})
+}
+
+// TestConstruction tests building, reading and writing matrices
+func TestConstruction(t *testing.T) {
+	m, err := FromRows([][]float64{{1, 2, 3}, {4, 5, 6}})
+	if err != nil || m.Rows() != 2 || m.Cols() != 3 {
+		t.Fatalf("FromRows = %v, %v, want a 2x3 matrix", m, err)
+	}
+	if v, err := m.At(1, 2); v != 6 || err != nil {
+		t.Errorf("At(1, 2) = %v, %v, want 6", v, err)
+	}
+	if err := m.Set(0, 1, 9); err != nil {
+		t.Fatal(err)
+	}
+	if rows := m.ToRows(); !equalRows(rows, [][]float64{{1, 9, 3}, {4, 5, 6}}, 0) {
+		t.Errorf("ToRows after Set = %v", rows)
+	}
+	if _, err := m.At(2, 0); !errors.Is(err, ErrIndex) {
+		t.Errorf("At(2, 0) error = %v, want ErrIndex", err)
+	}
+	if err := m.Set(0, -1, 1); !errors.Is(err, ErrIndex) {
+		t.Errorf("Set(0, -1) error = %v, want ErrIndex", err)
+	}
+	if _, err := FromRows([][]float64{{1, 2}, {3}}); !errors.Is(err, ErrDimension) {
+		t.Errorf("FromRows of ragged rows: error = %v, want ErrDimension", err)
+	}
+	if m := New(-1, 3); m.Rows() != 0 || m.Cols() != 3 {
+		t.Errorf("New(-1, 3) is %dx%d, want 0x3", m.Rows(), m.Cols())
+	}
+
+	// ToRows returns a copy
+	m.ToRows()[0][0] = 100
+	if v, _ := m.At(0, 0); v != 1 {
+		t.Errorf("writing to ToRows changed the matrix to %v", v)
+	}
+}
+
+// TestMul tests products against the definition, and shape checking
+func TestMul(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		n, k, p := rng.IntN(6), rng.IntN(6), rng.IntN(6)
+		a, b := randomMatrix(rng, n, k), randomMatrix(rng, k, p)
+		got, err := a.Mul(b)
+		if err != nil {
+			t.Fatalf("Mul of %dx%d and %dx%d: %v", n, k, k, p, err)
+		}
+		if got.Rows() != n || got.Cols() != p || !equalRows(got.ToRows(), naiveMul(a.ToRows(), b.ToRows(), k, p), 0) {
+			t.Fatalf("Mul(%v, %v) = %v", a.ToRows(), b.ToRows(), got.ToRows())
+		}
+	}
+	if _, err := New(2, 3).Mul(New(2, 3)); !errors.Is(err, ErrDimension) {
+		t.Errorf("Mul of 2x3 and 2x3: error = %v, want ErrDimension", err)
+	}
+}
+
+// TestPow tests powers against repeated multiplication, and negative powers
+// against the inverse
+func TestPow(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for range 50 {
+		n := 1 + rng.IntN(4)
+		m := randomMatrix(rng, n, n)
+		want := Identity(n)
+		for e := range 7 {
+			got, err := m.Pow(e)
+			if err != nil || !equalRows(got.ToRows(), want.ToRows(), 0) {
+				t.Fatalf("Pow(%v, %d) = %v, %v, want %v", m.ToRows(), e, got, err, want.ToRows())
+			}
+			want, _ = want.Mul(m)
+		}
+	}
+
+	m, _ := FromRows([][]float64{{2, 1}, {1, 1}})
+	inv3, err := m.Pow(-3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cube, _ := m.Pow(3)
+	if product, _ := inv3.Mul(cube); !equalRows(product.ToRows(), Identity(2).ToRows(), 1e-9) {
+		t.Errorf("Pow(-3) × Pow(3) = %v, want the identity", product.ToRows())
+	}
+	if _, err := New(2, 2).Pow(-1); !errors.Is(err, ErrSingular) {
+		t.Errorf("Pow(-1) of a zero matrix: error = %v, want ErrSingular", err)
+	}
+	if _, err := New(2, 3).Pow(2); !errors.Is(err, ErrNotSquare) {
+		t.Errorf("Pow of a 2x3 matrix: error = %v, want ErrNotSquare", err)
+	}
+}
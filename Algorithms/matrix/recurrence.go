@@ -0,0 +1,84 @@
+package matrix
+
+import "fmt"
+
+// Recurrence returns a(n) for the linear recurrence
+//
+//	a(k) = coeffs[0]·a(k-1) + coeffs[1]·a(k-2) + … + coeffs[d-1]·a(k-d)
+//
+// with a(k) = initial[k] for k < d. The state (a(k), …, a(k-d+1)) is
+// advanced by the d × d companion matrix, so a(n) takes O(log n) matrix
+// products; the Fibonacci numbers are Recurrence([]int{1, 1}, []int{0, 1},
+// n). It returns ErrOverflow if a(n) or a power of the companion matrix
+// overflows.
+// Time Complexity: O(d³ log n), Space Complexity: O(d²)
+func Recurrence(coeffs, initial []int, n int) (int, error) {
+	companion, state, err := recurrence(coeffs, initial, n)
+	if err != nil || n < len(initial) {
+		return state, err
+	}
+	power, err := companion.Pow(n - len(initial) + 1)
+	if err != nil {
+		return 0, err
+	}
+	return dotLast(power, initial, checkedMul, checkedAdd)
+}
+
+// RecurrenceMod returns a(n) mod mod, in [0, mod), for the recurrence of
+// Recurrence. Nothing overflows, so any n can be reached.
+// Time Complexity: O(d³ log n), Space Complexity: O(d²)
+func RecurrenceMod(coeffs, initial []int, n, mod int) (int, error) {
+	if mod <= 0 {
+		return 0, ErrModulus
+	}
+	companion, state, err := recurrence(coeffs, initial, n)
+	if err != nil || n < len(initial) {
+		if state %= mod; state < 0 {
+			state += mod
+		}
+		return state, err
+	}
+	power, _ := companion.PowMod(n-len(initial)+1, mod)
+	reduced := (&IntMatrix{rows: 1, cols: len(initial), data: initial}).reduce(mod)
+	return dotLast(power, reduced.data,
+		func(a, b int) (int, bool) { return mulMod(a, b, mod), true },
+		func(a, b int) (int, bool) { return addMod(a, b, mod), true })
+}
+
+// recurrence validates a recurrence, returning its companion matrix, or
+// a(n) itself if n indexes the initial values
+func recurrence(coeffs, initial []int, n int) (*IntMatrix, int, error) {
+	if len(coeffs) != len(initial) {
+		return nil, 0, fmt.Errorf("%w: %d coefficients for %d initial values", ErrDimension, len(coeffs), len(initial))
+	}
+	if n < 0 {
+		return nil, 0, ErrNegative
+	}
+	d := len(coeffs)
+	if n < d {
+		return nil, initial[n], nil
+	}
+	companion := NewInt(d, d)
+	copy(companion.data, coeffs)
+	for i := 1; i < d; i++ {
+		companion.data[i*d+i-1] = 1
+	}
+	return companion, 0, nil
+}
+
+// dotLast returns the first element of power applied to the state
+// (initial[d-1], …, initial[0]) using the given arithmetic
+func dotLast(power *IntMatrix, initial []int, mul, add func(a, b int) (int, bool)) (int, error) {
+	d := len(initial)
+	sum := 0
+	for j := range d {
+		term, ok := mul(power.data[j], initial[d-1-j])
+		if !ok {
+			return 0, ErrOverflow
+		}
+		if sum, ok = add(sum, term); !ok {
+			return 0, ErrOverflow
+		}
+	}
+	return sum, nil
+}
@@ -0,0 +1,72 @@
+package matrix
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// TestRecurrence tests Fibonacci and Tribonacci numbers against iteration
+// on big integers
+func TestRecurrence(t *testing.T) {
+	tests := []struct {
+		name            string
+		coeffs, initial []int
+	}{
+		{"fibonacci", []int{1, 1}, []int{0, 1}},
+		{"tribonacci", []int{1, 1, 1}, []int{0, 0, 1}},
+		{"alternating", []int{-2, 3}, []int{5, -7}},
+		{"constant", []int{1}, []int{4}},
+	}
+	for _, tt := range tests {
+		d := len(tt.coeffs)
+		seq := []*big.Int{}
+		for _, v := range tt.initial {
+			seq = append(seq, big.NewInt(int64(v)))
+		}
+		for n := range 200 {
+			if n >= d {
+				next := new(big.Int)
+				for i, c := range tt.coeffs {
+					next.Add(next, new(big.Int).Mul(big.NewInt(int64(c)), seq[n-1-i]))
+				}
+				seq = append(seq, next)
+			}
+			want := seq[n]
+
+			got, err := Recurrence(tt.coeffs, tt.initial, n)
+			if want.IsInt64() && (err != nil || int64(got) != want.Int64()) {
+				t.Fatalf("%s: Recurrence(%d) = %d, %v, want %v", tt.name, n, got, err, want)
+			}
+			const mod = 1_000_000_007
+			gotMod, err := RecurrenceMod(tt.coeffs, tt.initial, n, mod)
+			if err != nil || int64(gotMod) != new(big.Int).Mod(want, big.NewInt(mod)).Int64() {
+				t.Fatalf("%s: RecurrenceMod(%d) = %d, %v, want %v mod p", tt.name, n, gotMod, err, want)
+			}
+		}
+	}
+
+	if f, err := Recurrence([]int{1, 1}, []int{0, 1}, 92); f != 7540113804746346429 || err != nil {
+		t.Errorf("F92 = %d, %v", f, err)
+	}
+	if _, err := Recurrence([]int{1, 1}, []int{0, 1}, 93); !errors.Is(err, ErrOverflow) {
+		t.Errorf("F93: error = %v, want ErrOverflow", err)
+	}
+	// F(10^18) mod 10^9+7, from the Pisano period 2·10^9+16
+	want, _ := RecurrenceMod([]int{1, 1}, []int{0, 1}, 1_000_000_000_000_000_000%2_000_000_016, 1e9+7)
+	if f, err := RecurrenceMod([]int{1, 1}, []int{0, 1}, 1e18, 1e9+7); f != want || err != nil {
+		t.Errorf("F(10^18) mod 10^9+7 = %d, %v, want %d", f, err, want)
+	}
+	if v, err := Recurrence(nil, nil, 5); v != 0 || err != nil {
+		t.Errorf("empty recurrence = %d, %v, want 0", v, err)
+	}
+	if _, err := Recurrence([]int{1}, []int{0, 1}, 5); !errors.Is(err, ErrDimension) {
+		t.Errorf("mismatched lengths: error = %v, want ErrDimension", err)
+	}
+	if _, err := Recurrence([]int{1}, []int{1}, -1); !errors.Is(err, ErrNegative) {
+		t.Errorf("negative index: error = %v, want ErrNegative", err)
+	}
+	if _, err := RecurrenceMod([]int{1}, []int{1}, 1, 0); !errors.Is(err, ErrModulus) {
+		t.Errorf("modulus 0: error = %v, want ErrModulus", err)
+	}
+}
@@ -0,0 +1,56 @@
+package numtheory
+
+import "math/big"
+
+// Factorial returns n! exactly. It returns ErrDomain for negative n.
+// Time Complexity: O(n) multiplications, Space Complexity: O(n log n) bits
+func Factorial(n int) (*big.Int, error) {
+	// Secure: validate input
+	if n < 0 {
+		return nil, ErrDomain
+	}
+	// MulRange splits the range in halves, keeping the operands balanced
+	return new(big.Int).MulRange(1, int64(n)), nil
+}
+
+// Permutations returns P(n, k) = n! / (n-k)!, the ordered selections of k of
+// n items, exactly; it is 0 for k > n. It returns ErrDomain for negative n
+// or k.
+// Time Complexity: O(k) multiplications, Space Complexity: O(k log n) bits
+func Permutations(n, k int) (*big.Int, error) {
+	// Secure: validate input
+	if n < 0 || k < 0 {
+		return nil, ErrDomain
+	}
+	if k > n {
+		return new(big.Int), nil
+	}
+	return new(big.Int).MulRange(int64(n-k+1), int64(n)), nil
+}
+
+// Combinations returns C(n, k) = n! / (k! (n-k)!), the unordered
+// selections of k of n items, exactly; it is 0 for k > n. It returns
+// ErrDomain for negative n or k.
+// Time Complexity: O(min(k, n-k)) multiplications, Space Complexity:
+// O(n) bits
+func Combinations(n, k int) (*big.Int, error) {
+	// Secure: validate input
+	if n < 0 || k < 0 {
+		return nil, ErrDomain
+	}
+	if k > n {
+		return new(big.Int), nil
+	}
+	return new(big.Int).Binomial(int64(n), int64(k)), nil
+}
+
+// Catalan returns the nth Catalan number C(2n, n) / (n+1) exactly. It
+// returns ErrDomain for negative n.
+// Time Complexity: O(n) multiplications, Space Complexity: O(n) bits
+func Catalan(n int) (*big.Int, error) {
+	c, err := Combinations(2*n, n)
+	if err != nil {
+		return nil, err
+	}
+	return c.Quo(c, big.NewInt(int64(n)+1)), nil
+}
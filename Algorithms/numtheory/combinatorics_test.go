@@ -0,0 +1,58 @@
+package numtheory
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// TestCombinatorics tests exact values past the int range, identities and
+// the domain
+func TestCombinatorics(t *testing.T) {
+	f, _ := Factorial(25)
+	if f.String() != "15511210043330985984000000" {
+		t.Errorf("Factorial(25) = %v", f)
+	}
+	if f, _ := Factorial(0); f.Int64() != 1 {
+		t.Errorf("Factorial(0) = %v, want 1", f)
+	}
+	c, _ := Combinations(100, 50)
+	if c.String() != "100891344545564193334812497256" {
+		t.Errorf("Combinations(100, 50) = %v", c)
+	}
+
+	// P(n, k) = C(n, k) * k! and the Catalan numbers from C(2n, n)
+	for n := range 40 {
+		for k := range n + 1 {
+			p, _ := Permutations(n, k)
+			c, _ := Combinations(n, k)
+			f, _ := Factorial(k)
+			if p.Cmp(new(big.Int).Mul(c, f)) != 0 {
+				t.Fatalf("Permutations(%d, %d) = %v, want C * k! = %v", n, k, p, new(big.Int).Mul(c, f))
+			}
+		}
+	}
+	catalans := []int64{1, 1, 2, 5, 14, 42, 132, 429, 1430, 4862}
+	for n, want := range catalans {
+		if got, err := Catalan(n); got.Int64() != want || err != nil {
+			t.Errorf("Catalan(%d) = %v, %v, want %d", n, got, err, want)
+		}
+	}
+
+	if c, err := Combinations(3, 5); c.Sign() != 0 || err != nil {
+		t.Errorf("Combinations(3, 5) = %v, %v, want 0", c, err)
+	}
+	if p, err := Permutations(3, 5); p.Sign() != 0 || err != nil {
+		t.Errorf("Permutations(3, 5) = %v, %v, want 0", p, err)
+	}
+	for _, err := range []error{
+		func() error { _, err := Factorial(-1); return err }(),
+		func() error { _, err := Combinations(-1, 0); return err }(),
+		func() error { _, err := Permutations(3, -1); return err }(),
+		func() error { _, err := Catalan(-2); return err }(),
+	} {
+		if !errors.Is(err, ErrDomain) {
+			t.Errorf("negative argument: error = %v, want ErrDomain", err)
+		}
+	}
+}
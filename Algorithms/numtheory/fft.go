@@ -0,0 +1,284 @@
+package numtheory
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// NTTModulus is the prime 119·2^23 + 1 that NTT and MultiplyPolynomialsMod
+// work modulo. Its multiplicative group has a subgroup of order 2^23, so it
+// has the roots of unity of every power-of-two length up to MaxNTTLength.
+const NTTModulus = 998244353
+
+// MaxNTTLength is the longest number-theoretic transform, and so bounds the
+// length of a product computed with one
+const MaxNTTLength = 1 << 23
+
+const (
+	// nttModulus2 is the prime 7·2^26 + 1, with which MultiplyBig recovers
+	// coefficients too large for NTTModulus alone
+	nttModulus2 = 469762049
+	// nttRoot is a primitive root of both NTTModulus and nttModulus2
+	nttRoot = 3
+	// limbBits is the width of the digits MultiplyBig convolves
+	limbBits = 16
+)
+
+// FFT replaces a with its discrete Fourier transform, or with the inverse
+// transform, scaled by 1/n, if inverse is set. The length of a must be a
+// power of two. The iterative radix-2 Cooley-Tukey algorithm permutes a into
+// bit-reversed order, then combines transforms of doubling length in place.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func FFT(a []complex128, inverse bool) error {
+	n := len(a)
+	if n == 0 || n&(n-1) != 0 {
+		return fmt.Errorf("%w: transform length %d is not a power of two", ErrDomain, n)
+	}
+
+	// Secure: computing each root directly, rather than as a power of the
+	// first, keeps the rounding error of the transform at O(log n)
+	roots := make([]complex128, n/2)
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	for k := range roots {
+		sin, cos := math.Sincos(sign * 2 * math.Pi * float64(k) / float64(n))
+		roots[k] = complex(cos, sin)
+	}
+
+	bitReverse(a)
+	for size := 2; size <= n; size <<= 1 {
+		half, stride := size/2, n/size
+		for start := 0; start < n; start += size {
+			for k := range half {
+				u, v := a[start+k], a[start+k+half]*roots[k*stride]
+				a[start+k], a[start+k+half] = u+v, u-v
+			}
+		}
+	}
+	if inverse {
+		for i := range a {
+			a[i] /= complex(float64(n), 0)
+		}
+	}
+	return nil
+}
+
+// NTT replaces a with its number-theoretic transform modulo NTTModulus, or
+// with the inverse transform if inverse is set: the discrete Fourier
+// transform with a root of unity of the integers modulo the prime in place
+// of e^(2πi/n), so that it is exact. The elements of a are reduced modulo
+// NTTModulus, and its length must be a power of two of at most
+// MaxNTTLength.
+// Time Complexity: O(n log n), Space Complexity: O(1)
+func NTT(a []uint64, inverse bool) error {
+	n := len(a)
+	if n == 0 || n&(n-1) != 0 {
+		return fmt.Errorf("%w: transform length %d is not a power of two", ErrDomain, n)
+	}
+	if n > MaxNTTLength {
+		return fmt.Errorf("%w: transform length %d exceeds %d", ErrRange, n, MaxNTTLength)
+	}
+	for i := range a {
+		a[i] %= NTTModulus
+	}
+	ntt(a, inverse, NTTModulus)
+	return nil
+}
+
+// ntt transforms a in place modulo the prime p, for which nttRoot is a
+// primitive root and len(a) a power of two dividing p-1. The elements must
+// be reduced; since p < 2^32, their products fit in a uint64.
+func ntt(a []uint64, inverse bool, p uint64) {
+	n := len(a)
+	bitReverse(a)
+	twiddles := make([]uint64, n/2)
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		w := powMod(nttRoot, (p-1)/uint64(size), p)
+		if inverse {
+			w = powMod(w, p-2, p)
+		}
+		twiddles[0] = 1
+		for k := 1; k < half; k++ {
+			twiddles[k] = twiddles[k-1] * w % p
+		}
+		for start := 0; start < n; start += size {
+			for k := range half {
+				u, v := a[start+k], a[start+k+half]*twiddles[k]%p
+				a[start+k], a[start+k+half] = addMod(u, v, p), subMod(u, v, p)
+			}
+		}
+	}
+	if inverse {
+		nInv := powMod(uint64(n), p-2, p)
+		for i := range a {
+			a[i] = a[i] * nInv % p
+		}
+	}
+}
+
+// bitReverse permutes a, of power-of-two length, so that each element moves
+// to the index whose bits are those of its own in reverse
+func bitReverse[T any](a []T) {
+	for i, j := 1, 0; i < len(a); i++ {
+		bit := len(a) >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+}
+
+// transformLength returns the least power of two holding a product of
+// length n
+func transformLength(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
+
+// MultiplyPolynomials returns the product of the polynomials with the given
+// coefficients, lowest degree first, by transforming both, multiplying
+// pointwise and transforming back. Each coefficient of the product carries
+// a rounding error of roughly 1e-15 times the sum of the magnitudes of the
+// terms contributing to it; round the result if the inputs are integers
+// small enough for that error to stay below 1/2, or use
+// MultiplyPolynomialsMod. The product of an empty polynomial is empty.
+// Time Complexity: O((n+m) log(n+m)), Space Complexity: O(n+m)
+func MultiplyPolynomials(a, b []float64) []float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return []float64{}
+	}
+	size := len(a) + len(b) - 1
+	n := transformLength(size)
+	fa, fb := make([]complex128, n), make([]complex128, n)
+	for i, c := range a {
+		fa[i] = complex(c, 0)
+	}
+	for i, c := range b {
+		fb[i] = complex(c, 0)
+	}
+	// The lengths are powers of two, so the transforms cannot fail
+	_ = FFT(fa, false)
+	_ = FFT(fb, false)
+	for i := range fa {
+		fa[i] *= fb[i]
+	}
+	_ = FFT(fa, true)
+
+	product := make([]float64, size)
+	for i := range product {
+		product[i] = real(fa[i])
+	}
+	return product
+}
+
+// MultiplyPolynomialsMod returns the product of the polynomials with the
+// given coefficients, lowest degree first, modulo NTTModulus. The
+// arithmetic is exact, and the product may have at most MaxNTTLength
+// coefficients.
+// Time Complexity: O((n+m) log(n+m)), Space Complexity: O(n+m)
+func MultiplyPolynomialsMod(a, b []uint64) ([]uint64, error) {
+	if len(a) == 0 || len(b) == 0 {
+		return []uint64{}, nil
+	}
+	size := len(a) + len(b) - 1
+	if size > MaxNTTLength {
+		return nil, fmt.Errorf("%w: product of %d coefficients exceeds %d", ErrRange, size, MaxNTTLength)
+	}
+	return convolve(a, b, transformLength(size), NTTModulus)[:size], nil
+}
+
+// convolve returns the cyclic convolution of length n of a and b modulo p,
+// which is their product when n is at least len(a)+len(b)-1
+func convolve(a, b []uint64, n int, p uint64) []uint64 {
+	fa, fb := make([]uint64, n), make([]uint64, n)
+	for i, c := range a {
+		fa[i] = c % p
+	}
+	for i, c := range b {
+		fb[i] = c % p
+	}
+	ntt(fa, false, p)
+	ntt(fb, false, p)
+	for i := range fa {
+		fa[i] = fa[i] * fb[i] % p
+	}
+	ntt(fa, true, p)
+	return fa
+}
+
+// MultiplyBig returns x·y, computed by splitting both into 16-bit digits
+// and convolving the digits exactly. A coefficient of the convolution is
+// below 2^55, too large for one prime modulus, so it is computed modulo two
+// and recovered by the Chinese remainder theorem before the carries are
+// propagated. The product may have at most 16·MaxNTTLength bits. It is
+// far faster than schoolbook multiplication, but big.Int.Mul, multiplying
+// whole machine words by Karatsuba's method, remains faster at the sizes
+// benchmarked.
+// Time Complexity: O(n log n) for n-bit factors, Space Complexity: O(n)
+func MultiplyBig(x, y *big.Int) (*big.Int, error) {
+	if x.Sign() == 0 || y.Sign() == 0 {
+		return new(big.Int), nil
+	}
+	// Secure: check the length from the bit lengths, before allocating
+	la := (x.BitLen() + limbBits - 1) / limbBits
+	lb := (y.BitLen() + limbBits - 1) / limbBits
+	size := la + lb - 1
+	if size > MaxNTTLength {
+		return nil, fmt.Errorf("%w: product of %d-bit and %d-bit integers exceeds %d bits", ErrRange, x.BitLen(), y.BitLen(), limbBits*MaxNTTLength)
+	}
+	a, b := toLimbs(x.Bits(), la), toLimbs(y.Bits(), lb)
+	n := transformLength(size)
+	r1 := convolve(a, b, n, NTTModulus)
+	r2 := convolve(a, b, n, nttModulus2)
+
+	// Each coefficient c is below min(la, lb)·(2^16-1)^2 < 2^55, and so
+	// below the product of the moduli: with c ≡ r1 (mod p1) and c ≡ r2
+	// (mod p2), c = r1 + p1·((r2 - r1)·p1⁻¹ mod p2)
+	inv := powMod(NTTModulus, nttModulus2-2, nttModulus2)
+	limbs := make([]uint64, size+1)
+	carry := uint64(0)
+	for i := range size {
+		t := subMod(r2[i], r1[i]%nttModulus2, nttModulus2) * inv % nttModulus2
+		carry += r1[i] + NTTModulus*t
+		limbs[i] = carry & (1<<limbBits - 1)
+		carry >>= limbBits
+	}
+	limbs[size] = carry
+
+	z := new(big.Int).SetBits(fromLimbs(limbs))
+	if x.Sign() != y.Sign() {
+		z.Neg(z)
+	}
+	return z, nil
+}
+
+// toLimbs splits the magnitude held by words, least significant first, into
+// n 16-bit limbs
+func toLimbs(words []big.Word, n int) []uint64 {
+	const perWord = bits.UintSize / limbBits
+	limbs := make([]uint64, n)
+	for i := range limbs {
+		limbs[i] = uint64(words[i/perWord]>>(limbBits*(i%perWord))) & (1<<limbBits - 1)
+	}
+	return limbs
+}
+
+// fromLimbs joins 16-bit limbs, least significant first, into words
+func fromLimbs(limbs []uint64) []big.Word {
+	const perWord = bits.UintSize / limbBits
+	words := make([]big.Word, (len(limbs)+perWord-1)/perWord)
+	for i, limb := range limbs {
+		words[i/perWord] |= big.Word(limb) << (limbBits * (i % perWord))
+	}
+	return words
+}
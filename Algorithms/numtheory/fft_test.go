@@ -0,0 +1,260 @@
+package numtheory
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"math/rand/v2"
+	"testing"
+)
+
+// naiveMultiply multiplies polynomials term by term, the O(n·m) baseline
+func naiveMultiply(a, b []float64) []float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return []float64{}
+	}
+	product := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		for j, y := range b {
+			product[i+j] += x * y
+		}
+	}
+	return product
+}
+
+// naiveMultiplyMod multiplies polynomials term by term modulo NTTModulus
+func naiveMultiplyMod(a, b []uint64) []uint64 {
+	if len(a) == 0 || len(b) == 0 {
+		return []uint64{}
+	}
+	product := make([]uint64, len(a)+len(b)-1)
+	for i, x := range a {
+		for j, y := range b {
+			product[i+j] = (product[i+j] + x%NTTModulus*(y%NTTModulus)) % NTTModulus
+		}
+	}
+	return product
+}
+
+// TestFFT tests the transform against the definition of the DFT, and that
+// the inverse undoes it
+func TestFFT(t *testing.T) {
+	rng := rand.New(rand.NewPCG(11, 12))
+	for _, n := range []int{1, 2, 4, 8, 64, 256} {
+		a := make([]complex128, n)
+		for i := range a {
+			a[i] = complex(rng.NormFloat64(), rng.NormFloat64())
+		}
+		got := append([]complex128(nil), a...)
+		if err := FFT(got, false); err != nil {
+			t.Fatalf("FFT of length %d: %v", n, err)
+		}
+		for k := range n {
+			want := complex128(0)
+			for j, x := range a {
+				want += x * cmplx.Exp(complex(0, -2*math.Pi*float64(j*k)/float64(n)))
+			}
+			if cmplx.Abs(got[k]-want) > 1e-9 {
+				t.Fatalf("FFT of length %d: X[%d] = %v, want %v", n, k, got[k], want)
+			}
+		}
+		if err := FFT(got, true); err != nil {
+			t.Fatal(err)
+		}
+		for i := range a {
+			if cmplx.Abs(got[i]-a[i]) > 1e-12 {
+				t.Fatalf("inverse FFT of length %d: x[%d] = %v, want %v", n, i, got[i], a[i])
+			}
+		}
+	}
+
+	for _, n := range []int{0, 3, 12} {
+		if err := FFT(make([]complex128, n), false); !errors.Is(err, ErrDomain) {
+			t.Errorf("FFT of length %d: error = %v, want ErrDomain", n, err)
+		}
+		if err := NTT(make([]uint64, n), false); !errors.Is(err, ErrDomain) {
+			t.Errorf("NTT of length %d: error = %v, want ErrDomain", n, err)
+		}
+	}
+}
+
+// TestNTT tests the transform against the definition, and that the inverse
+// restores the reduced input
+func TestNTT(t *testing.T) {
+	rng := rand.New(rand.NewPCG(13, 14))
+	for _, n := range []int{1, 2, 16, 128} {
+		a := make([]uint64, n)
+		for i := range a {
+			a[i] = rng.Uint64()
+		}
+		got := append([]uint64(nil), a...)
+		if err := NTT(got, false); err != nil {
+			t.Fatalf("NTT of length %d: %v", n, err)
+		}
+		w := powMod(nttRoot, (NTTModulus-1)/uint64(n), NTTModulus)
+		for k := range n {
+			want := uint64(0)
+			for j, x := range a {
+				want = (want + x%NTTModulus*powMod(w, uint64(j*k), NTTModulus)) % NTTModulus
+			}
+			if got[k] != want {
+				t.Fatalf("NTT of length %d: X[%d] = %d, want %d", n, k, got[k], want)
+			}
+		}
+		if err := NTT(got, true); err != nil {
+			t.Fatal(err)
+		}
+		for i := range a {
+			if got[i] != a[i]%NTTModulus {
+				t.Fatalf("inverse NTT of length %d: x[%d] = %d, want %d", n, i, got[i], a[i]%NTTModulus)
+			}
+		}
+	}
+}
+
+// TestMultiplyPolynomials tests both products against the naive ones
+func TestMultiplyPolynomials(t *testing.T) {
+	rng := rand.New(rand.NewPCG(15, 16))
+	for range 200 {
+		a, b := make([]float64, rng.IntN(70)), make([]float64, rng.IntN(70))
+		am, bm := make([]uint64, len(a)), make([]uint64, len(b))
+		for i := range a {
+			a[i] = float64(rng.IntN(2001) - 1000)
+			am[i] = rng.Uint64()
+		}
+		for i := range b {
+			b[i] = float64(rng.IntN(2001) - 1000)
+			bm[i] = rng.Uint64()
+		}
+
+		got, want := MultiplyPolynomials(a, b), naiveMultiply(a, b)
+		if len(got) != len(want) {
+			t.Fatalf("MultiplyPolynomials: %d coefficients, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if math.Round(got[i]) != want[i] {
+				t.Fatalf("MultiplyPolynomials(%v, %v)[%d] = %v, want %v", a, b, i, got[i], want[i])
+			}
+		}
+
+		gotMod, err := MultiplyPolynomialsMod(am, bm)
+		wantMod := naiveMultiplyMod(am, bm)
+		if err != nil || len(gotMod) != len(wantMod) {
+			t.Fatalf("MultiplyPolynomialsMod: %d coefficients, %v, want %d", len(gotMod), err, len(wantMod))
+		}
+		for i := range wantMod {
+			if gotMod[i] != wantMod[i] {
+				t.Fatalf("MultiplyPolynomialsMod[%d] = %d, want %d", i, gotMod[i], wantMod[i])
+			}
+		}
+	}
+
+	if _, err := MultiplyPolynomialsMod(make([]uint64, MaxNTTLength), []uint64{1, 1}); !errors.Is(err, ErrRange) {
+		t.Errorf("MultiplyPolynomialsMod past MaxNTTLength: error = %v, want ErrRange", err)
+	}
+}
+
+// TestMultiplyBig tests products against big.Int.Mul, including signs, zero
+// and the largest digits, where the coefficients need both moduli
+func TestMultiplyBig(t *testing.T) {
+	rng := rand.New(rand.NewPCG(17, 18))
+	random := func(bits int) *big.Int {
+		x := new(big.Int)
+		for range (bits + 63) / 64 {
+			x.Lsh(x, 64).Or(x, new(big.Int).SetUint64(rng.Uint64()))
+		}
+		x.Rsh(x, uint(64-bits%64)%64)
+		if rng.IntN(2) == 0 {
+			x.Neg(x)
+		}
+		return x
+	}
+	ones := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 200_000), big.NewInt(1))
+
+	cases := [][2]*big.Int{
+		{big.NewInt(0), big.NewInt(12345)},
+		{big.NewInt(-7), big.NewInt(0)},
+		{big.NewInt(-1), big.NewInt(-1)},
+		{big.NewInt(65535), big.NewInt(65536)},
+		{ones, ones},
+		{ones, new(big.Int).Neg(ones)},
+	}
+	for range 100 {
+		cases = append(cases, [2]*big.Int{random(1 + rng.IntN(5000)), random(1 + rng.IntN(5000))})
+	}
+	for _, c := range cases {
+		got, err := MultiplyBig(c[0], c[1])
+		want := new(big.Int).Mul(c[0], c[1])
+		if err != nil || got.Cmp(want) != 0 {
+			t.Fatalf("MultiplyBig of %d-bit and %d-bit integers = %v, want %v", c[0].BitLen(), c[1].BitLen(), err, want)
+		}
+		if got := schoolbookMultiply(c[0], c[1]); got.CmpAbs(want) != 0 {
+			t.Fatalf("schoolbookMultiply of %d-bit and %d-bit integers = %v, want |%v|", c[0].BitLen(), c[1].BitLen(), got, want)
+		}
+	}
+
+	huge := new(big.Int).Lsh(big.NewInt(1), limbBits*MaxNTTLength/2)
+	if _, err := MultiplyBig(huge, huge); !errors.Is(err, ErrRange) {
+		t.Errorf("MultiplyBig past the transform length: error = %v, want ErrRange", err)
+	}
+}
+
+// benchmarkMultiply measures multiplying two random polynomials of n
+// coefficients with multiply
+func benchmarkMultiply(b *testing.B, n int, multiply func(a, b []float64) []float64) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	x, y := make([]float64, n), make([]float64, n)
+	for i := range n {
+		x[i], y[i] = rng.Float64(), rng.Float64()
+	}
+	for b.Loop() {
+		multiply(x, y)
+	}
+}
+
+func BenchmarkMultiplyPolynomials1K(b *testing.B)  { benchmarkMultiply(b, 1<<10, MultiplyPolynomials) }
+func BenchmarkNaiveMultiply1K(b *testing.B)        { benchmarkMultiply(b, 1<<10, naiveMultiply) }
+func BenchmarkMultiplyPolynomials16K(b *testing.B) { benchmarkMultiply(b, 1<<14, MultiplyPolynomials) }
+func BenchmarkNaiveMultiply16K(b *testing.B)       { benchmarkMultiply(b, 1<<14, naiveMultiply) }
+
+// benchmarkMultiplyBig measures multiplying two integers of n bits with
+// multiply
+func benchmarkMultiplyBig(b *testing.B, n int, multiply func(x, y *big.Int) *big.Int) {
+	x := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(n)), big.NewInt(12345))
+	y := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(n)), big.NewInt(67890))
+	for b.Loop() {
+		multiply(x, y)
+	}
+}
+
+// schoolbookMultiply multiplies the magnitudes of integers in 16-bit digits,
+// the O(n²) baseline
+func schoolbookMultiply(x, y *big.Int) *big.Int {
+	a, b := toLimbs(x.Bits(), (x.BitLen()+15)/16), toLimbs(y.Bits(), (y.BitLen()+15)/16)
+	limbs := make([]uint64, len(a)+len(b))
+	for i, d := range a {
+		carry := uint64(0)
+		for j, e := range b {
+			carry += limbs[i+j] + d*e
+			limbs[i+j] = carry & 0xffff
+			carry >>= 16
+		}
+		for k := i + len(b); carry > 0; k++ {
+			carry += limbs[k]
+			limbs[k] = carry & 0xffff
+			carry >>= 16
+		}
+	}
+	return new(big.Int).SetBits(fromLimbs(limbs))
+}
+
+func mustMultiplyBig(x, y *big.Int) *big.Int {
+	z, _ := MultiplyBig(x, y)
+	return z
+}
+
+func BenchmarkMultiplyBig1M(b *testing.B)  { benchmarkMultiplyBig(b, 1<<20, mustMultiplyBig) }
+func BenchmarkBigIntMul1M(b *testing.B)    { benchmarkMultiplyBig(b, 1<<20, new(big.Int).Mul) }
+func BenchmarkMultiplyBig64K(b *testing.B) { benchmarkMultiplyBig(b, 1<<16, mustMultiplyBig) }
+func BenchmarkSchoolbook64K(b *testing.B)  { benchmarkMultiplyBig(b, 1<<16, schoolbookMultiply) }
@@ -0,0 +1,164 @@
+package numtheory
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// MaxDiscreteLogModulus is the largest modulus DiscreteLog accepts; its
+// table of baby steps holds about √m entries
+const MaxDiscreteLogModulus = 1 << 40
+
+// MulMod returns a * b mod m, computing the product in 128 bits. It returns
+// ErrDomain for m = 0.
+// Time Complexity: O(1), Space Complexity: O(1)
+func MulMod(a, b, m uint64) (uint64, error) {
+	// Secure: validate the modulus
+	if m == 0 {
+		return 0, ErrDomain
+	}
+	return mulMod(a%m, b%m, m), nil
+}
+
+// PowMod returns b^e mod m by binary exponentiation. It returns ErrDomain
+// for m = 0.
+// Time Complexity: O(log e), Space Complexity: O(1)
+func PowMod(b, e, m uint64) (uint64, error) {
+	// Secure: validate the modulus
+	if m == 0 {
+		return 0, ErrDomain
+	}
+	return powMod(b, e, m), nil
+}
+
+// ModInverse returns the x in [0, m) with a * x ≡ 1 (mod m), by the
+// extended Euclidean algorithm with its coefficients kept modulo m. It
+// returns an error matching ErrNotInvertible, giving gcd(a, m), if a and m
+// are not coprime, and ErrDomain for m = 0.
+// Time Complexity: O(log m), Space Complexity: O(1)
+func ModInverse(a, m uint64) (uint64, error) {
+	// Secure: validate the modulus
+	if m == 0 {
+		return 0, ErrDomain
+	}
+
+	// Invariant: r0 ≡ t0 * a and r1 ≡ t1 * a (mod m)
+	r0, r1 := m, a%m
+	t0, t1 := uint64(0), uint64(1)%m
+	for r1 != 0 {
+		q := r0 / r1
+		r0, r1 = r1, r0-q*r1
+		t0, t1 = t1, subMod(t0, mulMod(q%m, t1, m), m)
+	}
+	if r0 != 1 {
+		return 0, fmt.Errorf("%w: gcd(%d, %d) = %d", ErrNotInvertible, a, m, r0)
+	}
+	return t0, nil
+}
+
+// CRT solves the system x ≡ residues[i] (mod moduli[i]) by the Chinese
+// remainder theorem, merging one congruence at a time. Moduli need not be
+// coprime. It returns the least solution x and the modulus of all the
+// solutions, the lcm of the moduli, so that they are x + k * lcm. It returns
+// ErrNoSolution if the congruences conflict, ErrOverflow if the lcm exceeds
+// a uint64, and ErrDomain if the slices differ in length or a modulus is 0.
+// Time Complexity: O(n log m), Space Complexity: O(1)
+func CRT(residues, moduli []uint64) (x, lcm uint64, err error) {
+	// Secure: validate input
+	if len(residues) != len(moduli) {
+		return 0, 0, ErrDomain
+	}
+
+	// x ≡ r (mod l) holds the congruences merged so far; big.Int keeps
+	// the intermediate products exact
+	r, l := new(big.Int), big.NewInt(1)
+	g, k, diff := new(big.Int), new(big.Int), new(big.Int)
+	for i, m := range moduli {
+		if m == 0 {
+			return 0, 0, ErrDomain
+		}
+		mi := new(big.Int).SetUint64(m)
+		ri := new(big.Int).SetUint64(residues[i] % m)
+
+		// Solve r + l*k ≡ ri (mod mi): l*k ≡ ri - r, solvable when
+		// gcd(l, mi) divides ri - r
+		g.GCD(nil, nil, l, mi)
+		diff.Sub(ri, r)
+		if new(big.Int).Mod(diff, g).Sign() != 0 {
+			return 0, 0, ErrNoSolution
+		}
+		step := new(big.Int).Quo(mi, g)
+		inverse := new(big.Int).ModInverse(new(big.Int).Quo(l, g), step)
+		if inverse == nil {
+			// Only possible when step is 1, where any k works
+			inverse = new(big.Int)
+		}
+		k.Quo(diff, g).Mul(k, inverse).Mod(k, step)
+		r.Add(r, k.Mul(k, l))
+		l.Mul(l, step)
+		if !l.IsUint64() {
+			return 0, 0, ErrOverflow
+		}
+		r.Mod(r, l)
+	}
+	return r.Uint64(), l.Uint64(), nil
+}
+
+// DiscreteLog returns the least x >= 0 with g^x ≡ h (mod m), by the
+// baby-step giant-step algorithm. Common factors of g and m are first
+// divided out of the congruence, so g need not be coprime to m. It returns
+// ErrNoSolution if there is no such x, ErrDomain for m = 0, and ErrRange if
+// m exceeds MaxDiscreteLogModulus.
+// Time Complexity: O(√m), Space Complexity: O(√m)
+func DiscreteLog(g, h, m uint64) (uint64, error) {
+	// Secure: validate the modulus
+	if m == 0 {
+		return 0, ErrDomain
+	}
+	// Secure: bound the table of baby steps
+	if m > MaxDiscreteLogModulus {
+		return 0, ErrRange
+	}
+	g, h = g%m, h%m
+	if h == 1%m {
+		return 0, nil
+	}
+
+	// While d = gcd(g, m) > 1, g^x ≡ h becomes (g/d) g^(x-1) ≡ h/d
+	// (mod m/d), which needs d | h; coef accumulates the (g/d) factors
+	offset, coef := uint64(0), uint64(1)
+	for d := gcd(g, m); d > 1; d = gcd(g, m) {
+		if h%d != 0 {
+			return 0, ErrNoSolution
+		}
+		h, m = h/d, m/d
+		offset++
+		coef = mulMod(coef%m, (g/d)%m, m)
+		g %= m
+		if coef == h%m {
+			return offset, nil
+		}
+	}
+
+	// Now coef * g^y ≡ h with g invertible; write y = i*n - j for
+	// 1 <= i <= n and 0 <= j < n, and meet g^(i*n) * coef ≡ h * g^j
+	n := isqrt(m)
+	if n*n < m {
+		n++
+	}
+	baby := make(map[uint64]uint64, n)
+	for j, v := uint64(0), h%m; j < n; j++ {
+		// The largest j for each value gives the least y
+		baby[v] = j
+		v = mulMod(v, g, m)
+	}
+	giant := powMod(g, n, m)
+	v := coef % m
+	for i := uint64(1); i <= n; i++ {
+		v = mulMod(v, giant, m)
+		if j, ok := baby[v]; ok {
+			return offset + i*n - j, nil
+		}
+	}
+	return 0, ErrNoSolution
+}
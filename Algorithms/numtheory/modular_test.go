@@ -0,0 +1,155 @@
+package numtheory
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// TestModInverse tests inverses against big.Int, and the gcd reported for
+// non-coprime arguments
+func TestModInverse(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	for range 2000 {
+		m := rng.Uint64()>>rng.IntN(64) | 1
+		a := rng.Uint64()
+		got, err := ModInverse(a, m)
+		want := new(big.Int).ModInverse(new(big.Int).SetUint64(a), new(big.Int).SetUint64(m))
+		switch {
+		case m == 1:
+			if got != 0 || err != nil {
+				t.Fatalf("ModInverse(%d, 1) = %d, %v, want 0", a, got, err)
+			}
+		case want == nil:
+			if !errors.Is(err, ErrNotInvertible) {
+				t.Fatalf("ModInverse(%d, %d) error = %v, want ErrNotInvertible", a, m, err)
+			}
+		case err != nil || got != want.Uint64():
+			t.Fatalf("ModInverse(%d, %d) = %d, %v, want %v", a, m, got, err, want)
+		}
+	}
+
+	_, err := ModInverse(12, 18)
+	if !errors.Is(err, ErrNotInvertible) || !strings.Contains(err.Error(), "= 6") {
+		t.Errorf("ModInverse(12, 18) error = %v, want ErrNotInvertible with gcd 6", err)
+	}
+	if got, err := ModInverse(math.MaxUint64-1, math.MaxUint64); got != math.MaxUint64-1 || err != nil {
+		t.Errorf("ModInverse(-1, MaxUint64) = %d, %v", got, err)
+	}
+	if _, err := ModInverse(3, 0); !errors.Is(err, ErrDomain) {
+		t.Errorf("ModInverse(3, 0) error = %v, want ErrDomain", err)
+	}
+	for _, f := range []func() (uint64, error){
+		func() (uint64, error) { return MulMod(2, 3, 0) },
+		func() (uint64, error) { return PowMod(2, 3, 0) },
+	} {
+		if _, err := f(); !errors.Is(err, ErrDomain) {
+			t.Errorf("modulus 0: error = %v, want ErrDomain", err)
+		}
+	}
+	if got, _ := PowMod(2, 64, math.MaxUint64); got != 1 {
+		t.Errorf("PowMod(2, 64, 2^64-1) = %d, want 1", got)
+	}
+	if got, _ := MulMod(math.MaxUint64, math.MaxUint64, 1e9+7); got != new(big.Int).Mod(new(big.Int).Mul(new(big.Int).SetUint64(math.MaxUint64), new(big.Int).SetUint64(math.MaxUint64)), big.NewInt(1e9+7)).Uint64() {
+		t.Errorf("MulMod of unreduced arguments = %d", got)
+	}
+}
+
+// TestCRT tests systems with coprime, shared and conflicting moduli
+// against searching every candidate
+func TestCRT(t *testing.T) {
+	tests := []struct {
+		residues, moduli []uint64
+		x, lcm           uint64
+		err              error
+	}{
+		{[]uint64{2, 3, 2}, []uint64{3, 5, 7}, 23, 105, nil},
+		{[]uint64{3, 5}, []uint64{4, 6}, 11, 12, nil},
+		{[]uint64{1, 2}, []uint64{4, 6}, 0, 0, ErrNoSolution},
+		{nil, nil, 0, 1, nil},
+		{[]uint64{7}, []uint64{5}, 2, 5, nil},
+		{[]uint64{1}, []uint64{0}, 0, 0, ErrDomain},
+		{[]uint64{1}, []uint64{2, 3}, 0, 0, ErrDomain},
+		{[]uint64{1, 1}, []uint64{1 << 40, 1<<40 - 1}, 0, 0, ErrOverflow},
+		{[]uint64{0, 1}, []uint64{math.MaxUint64, 1 << 32}, 0, 0, ErrOverflow},
+	}
+	for _, tt := range tests {
+		x, lcm, err := CRT(tt.residues, tt.moduli)
+		if !errors.Is(err, tt.err) || (err == nil && (x != tt.x || lcm != tt.lcm)) {
+			t.Errorf("CRT(%v, %v) = %d, %d, %v, want %d, %d, %v", tt.residues, tt.moduli, x, lcm, err, tt.x, tt.lcm, tt.err)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(9, 10))
+	for range 500 {
+		n := 1 + rng.IntN(3)
+		residues, moduli := make([]uint64, n), make([]uint64, n)
+		for i := range n {
+			moduli[i] = 1 + rng.Uint64N(12)
+			residues[i] = rng.Uint64N(30)
+		}
+		want, lcm := uint64(0), uint64(1)
+		for _, m := range moduli {
+			lcm = lcm / gcd(lcm, m) * m
+		}
+		found := false
+		for c := range lcm {
+			ok := true
+			for i := range n {
+				ok = ok && c%moduli[i] == residues[i]%moduli[i]
+			}
+			if ok {
+				want, found = c, true
+				break
+			}
+		}
+		x, l, err := CRT(residues, moduli)
+		if found != (err == nil) || (found && (x != want || l != lcm)) {
+			t.Fatalf("CRT(%v, %v) = %d, %d, %v, want %d, %d (solvable %v)", residues, moduli, x, l, err, want, lcm, found)
+		}
+	}
+}
+
+// TestDiscreteLog tests the least exponent against trying each in turn, for
+// moduli sharing factors with the base as well as prime ones
+func TestDiscreteLog(t *testing.T) {
+	for m := uint64(1); m <= 60; m++ {
+		for g := range m + 1 {
+			for h := range m {
+				want, found := uint64(0), false
+				v := 1 % m
+				for x := range 2 * m {
+					if v == h {
+						want, found = x, true
+						break
+					}
+					v = v * g % m
+				}
+				got, err := DiscreteLog(g, h, m)
+				if found != (err == nil) || (found && got != want) {
+					t.Fatalf("DiscreteLog(%d, %d, %d) = %d, %v, want %d (exists %v)", g, h, m, got, err, want, found)
+				}
+			}
+		}
+	}
+
+	// A large prime modulus: recover a known exponent
+	const p = 1_000_000_007
+	for _, x := range []uint64{0, 1, 12345, 500_000_003, p - 2} {
+		h := powMod(5, x, p)
+		got, err := DiscreteLog(5, h, p)
+		if err != nil || powMod(5, got, p) != h || got > x {
+			t.Errorf("DiscreteLog(5, 5^%d, p) = %d, %v", x, got, err)
+		}
+	}
+
+	if _, err := DiscreteLog(2, 3, 0); !errors.Is(err, ErrDomain) {
+		t.Errorf("DiscreteLog modulo 0: error = %v, want ErrDomain", err)
+	}
+	if _, err := DiscreteLog(2, 3, MaxDiscreteLogModulus+1); !errors.Is(err, ErrRange) {
+		t.Errorf("DiscreteLog past MaxDiscreteLogModulus: error = %v, want ErrRange", err)
+	}
+}
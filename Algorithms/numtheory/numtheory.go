@@ -0,0 +1,90 @@
+// Package numtheory provides number theory algorithms on 64-bit integers:
+// primality testing, factorization, prime sieves, arithmetic functions and
+// modular arithmetic, with exact combinatorics on *big.Int and fast
+// multiplication of polynomials and big integers by FFT. Products are
+// computed in 128 bits with math/bits, so no result is reduced by a silent
+// overflow; arguments outside a function's domain are reported with
+// ErrDomain.
+package numtheory
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+var (
+	// ErrDomain is returned when an argument lies outside the domain of a
+	// function, such as the factorization of zero
+	ErrDomain = errors.New("argument outside the domain")
+	// ErrRange is returned when a range is reversed or too large to sieve,
+	// a modulus too large to search, or a product too long to transform
+	ErrRange = errors.New("invalid range")
+	// ErrNotInvertible is returned by ModInverse when a and m share a
+	// factor; the error also gives their gcd
+	ErrNotInvertible = errors.New("not invertible")
+	// ErrNoSolution is returned when a system of congruences or a discrete
+	// logarithm has no solution
+	ErrNoSolution = errors.New("no solution")
+	// ErrOverflow is returned when a result does not fit in a uint64
+	ErrOverflow = errors.New("result overflows uint64")
+)
+
+// mulMod returns a * b mod m using a 128-bit product
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	// Secure: Rem64 requires hi < m, which holds once a and b are reduced
+	return bits.Rem64(hi%m, lo, m)
+}
+
+// addMod returns a + b mod m for a, b < m, without overflowing
+func addMod(a, b, m uint64) uint64 {
+	if a >= m-b {
+		return a - (m - b)
+	}
+	return a + b
+}
+
+// subMod returns a - b mod m for a, b < m
+func subMod(a, b, m uint64) uint64 {
+	if a >= b {
+		return a - b
+	}
+	return m - (b - a)
+}
+
+// powMod returns b^e mod m by binary exponentiation
+// Time Complexity: O(log e), Space Complexity: O(1)
+func powMod(b, e, m uint64) uint64 {
+	result := 1 % m
+	b %= m
+	for e > 0 {
+		if e&1 == 1 {
+			result = mulMod(result, b, m)
+		}
+		b = mulMod(b, b, m)
+		e >>= 1
+	}
+	return result
+}
+
+// isqrt returns the integer square root of n: the largest r with r*r <= n
+func isqrt(n uint64) uint64 {
+	r := uint64(math.Sqrt(float64(n)))
+	// The float estimate may be off by one either way
+	for r > 0 && (r > math.MaxUint32 || r*r > n) {
+		r--
+	}
+	for r < math.MaxUint32 && (r+1)*(r+1) <= n {
+		r++
+	}
+	return r
+}
+
+// gcd returns the greatest common divisor of a and b
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
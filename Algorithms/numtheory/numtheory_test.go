@@ -0,0 +1,51 @@
+package numtheory
+
+import (
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestModularArithmetic tests the 128-bit modular helpers against big.Int
+func TestModularArithmetic(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	for range 2000 {
+		m := rng.Uint64() | 1
+		a, b, e := rng.Uint64()%m, rng.Uint64()%m, rng.Uint64()
+		bm := new(big.Int).SetUint64(m)
+		ba, bb := new(big.Int).SetUint64(a), new(big.Int).SetUint64(b)
+
+		if got, want := mulMod(a, b, m), new(big.Int).Mod(new(big.Int).Mul(ba, bb), bm).Uint64(); got != want {
+			t.Fatalf("mulMod(%d, %d, %d) = %d, want %d", a, b, m, got, want)
+		}
+		if got, want := addMod(a, b, m), new(big.Int).Mod(new(big.Int).Add(ba, bb), bm).Uint64(); got != want {
+			t.Fatalf("addMod(%d, %d, %d) = %d, want %d", a, b, m, got, want)
+		}
+		if got, want := powMod(a, e, m), new(big.Int).Exp(ba, new(big.Int).SetUint64(e), bm).Uint64(); got != want {
+			t.Fatalf("powMod(%d, %d, %d) = %d, want %d", a, e, m, got, want)
+		}
+	}
+	if got := powMod(5, 0, 1); got != 0 {
+		t.Errorf("powMod(5, 0, 1) = %d, want 0", got)
+	}
+}
+
+// TestIsqrt tests integer square roots around perfect squares and the
+// top of the range
+func TestIsqrt(t *testing.T) {
+	for _, r := range []uint64{0, 1, 2, 3, 1000, 1 << 26, math.MaxUint32 - 1, math.MaxUint32} {
+		sq := r * r
+		if got := isqrt(sq); got != r {
+			t.Errorf("isqrt(%d) = %d, want %d", sq, got, r)
+		}
+		if sq > 0 {
+			if got := isqrt(sq - 1); got != r-1 {
+				t.Errorf("isqrt(%d) = %d, want %d", sq-1, got, r-1)
+			}
+		}
+	}
+	if got := isqrt(math.MaxUint64); got != math.MaxUint32 {
+		t.Errorf("isqrt(MaxUint64) = %d, want %d", got, uint64(math.MaxUint32))
+	}
+}
@@ -0,0 +1,155 @@
+package numtheory
+
+import "slices"
+
+// millerRabinBases are the first 12 primes, which as witnesses decide
+// primality for every n below 3.3 * 10^24, and so for every uint64
+var millerRabinBases = [...]uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+// IsPrime reports whether n is prime using the Miller-Rabin test with a
+// fixed set of bases, which is deterministic for every 64-bit n
+// Time Complexity: O(log³ n), Space Complexity: O(1)
+func IsPrime(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	for _, p := range millerRabinBases {
+		if n%p == 0 {
+			return n == p
+		}
+	}
+
+	// n - 1 = d * 2^s with d odd
+	d, s := n-1, 0
+	for d%2 == 0 {
+		d /= 2
+		s++
+	}
+	for _, a := range millerRabinBases {
+		x := powMod(a, d, n)
+		if x == 1 || x == n-1 {
+			continue
+		}
+		composite := true
+		for range s - 1 {
+			x = mulMod(x, x, n)
+			if x == n-1 {
+				composite = false
+				break
+			}
+		}
+		if composite {
+			return false
+		}
+	}
+	return true
+}
+
+// trialDivisionLimit bounds the primes Factorize divides out before
+// resorting to Pollard's rho
+const trialDivisionLimit = 1000
+
+// Factorize returns the prime factors of n in increasing order, repeated by
+// multiplicity, so 360 gives [2 2 2 3 3 5]. Small factors are found by
+// trial division and the rest by Pollard's rho with Brent's cycle finding,
+// each composite cofactor being split until Miller-Rabin reports it prime.
+// It returns ErrDomain for 0, and no factors for 1.
+// Time Complexity: O(n^(1/4)) expected per factor, Space Complexity: O(log n)
+func Factorize(n uint64) ([]uint64, error) {
+	// Secure: zero has no factorization
+	if n == 0 {
+		return nil, ErrDomain
+	}
+
+	factors := []uint64{}
+	for p := uint64(2); p < trialDivisionLimit && p*p <= n; p++ {
+		for n%p == 0 {
+			factors = append(factors, p)
+			n /= p
+		}
+	}
+	if n == 1 {
+		return factors, nil
+	}
+
+	// Every factor left exceeds trialDivisionLimit
+	pending := []uint64{n}
+	for len(pending) > 0 {
+		m := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if IsPrime(m) {
+			factors = append(factors, m)
+			continue
+		}
+		d := pollardRho(m)
+		pending = append(pending, d, m/d)
+	}
+	slices.Sort(factors)
+	return factors, nil
+}
+
+// pollardRho returns a nontrivial factor of the odd composite n using
+// Brent's variant of Pollard's rho. The pseudo-random walks x² + c are tried
+// for c = 1, 2, ... in turn, so the result is deterministic.
+func pollardRho(n uint64) uint64 {
+	// A perfect square defeats no walk, but its root is a factor outright
+	if r := isqrt(n); r*r == n {
+		return r
+	}
+	const batch = 128 // Steps whose differences are multiplied before a gcd
+	for c := uint64(1); ; c++ {
+		f := func(x uint64) uint64 { return addMod(mulMod(x, x, n), c, n) }
+		y, x, ys := uint64(2), uint64(2), uint64(2)
+		g, q := uint64(1), uint64(1)
+		for r := 1; g == 1; r *= 2 {
+			x = y
+			for range r {
+				y = f(y)
+			}
+			for k := 0; k < r && g == 1; k += batch {
+				ys = y
+				for range min(batch, r-k) {
+					y = f(y)
+					q = mulMod(q, absDiff(x, y), n)
+				}
+				g = gcd(q, n)
+			}
+		}
+		if g == n {
+			// The batch overshot: step back one at a time from its start
+			for g = 1; g == 1; {
+				ys = f(ys)
+				g = gcd(absDiff(x, ys), n)
+			}
+		}
+		if g != n {
+			return g
+		}
+	}
+}
+
+// absDiff returns |a - b|
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// Totient returns Euler's totient φ(n), the count of integers in [1, n]
+// coprime to n, from the prime factorization: φ(n) = n ∏ (1 - 1/p). It
+// returns ErrDomain for 0.
+// Time Complexity: that of Factorize, Space Complexity: O(log n)
+func Totient(n uint64) (uint64, error) {
+	factors, err := Factorize(n)
+	if err != nil {
+		return 0, err
+	}
+	phi := n
+	for i, p := range factors {
+		if i == 0 || p != factors[i-1] {
+			phi = phi / p * (p - 1)
+		}
+	}
+	return phi, nil
+}
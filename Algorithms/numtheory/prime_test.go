@@ -0,0 +1,115 @@
+package numtheory
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestIsPrime tests Miller-Rabin against trial division and big.Int, and on
+// strong pseudoprimes to many bases
+func TestIsPrime(t *testing.T) {
+	for n := range uint64(20000) {
+		want := n >= 2
+		for d := uint64(2); d*d <= n; d++ {
+			if n%d == 0 {
+				want = false
+				break
+			}
+		}
+		if got := IsPrime(n); got != want {
+			t.Fatalf("IsPrime(%d) = %v, want %v", n, got, want)
+		}
+	}
+
+	for _, tt := range []struct {
+		n    uint64
+		want bool
+	}{
+		{3215031751, false},          // strong pseudoprime to bases 2, 3, 5, 7
+		{3825123056546413051, false}, // strong pseudoprime to bases 2 through 23
+		{2305843009213693951, true},  // the Mersenne prime 2^61 - 1
+	} {
+		if got := IsPrime(tt.n); got != tt.want {
+			t.Errorf("IsPrime(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+	for _, n := range []uint64{math.MaxUint64, math.MaxUint64 - 58, 1<<61 - 1, 1<<63 - 25, 4294967291} {
+		want := new(big.Int).SetUint64(n).ProbablyPrime(20)
+		if got := IsPrime(n); got != want {
+			t.Errorf("IsPrime(%d) = %v, want %v", n, got, want)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 2000 {
+		n := rng.Uint64() | 1
+		if got, want := IsPrime(n), new(big.Int).SetUint64(n).ProbablyPrime(20); got != want {
+			t.Fatalf("IsPrime(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+// TestFactorize tests that factorizations are sorted, prime and multiply
+// back to n, including semiprimes of two large primes
+func TestFactorize(t *testing.T) {
+	if got, err := Factorize(360); !slices.Equal(got, []uint64{2, 2, 2, 3, 3, 5}) || err != nil {
+		t.Errorf("Factorize(360) = %v, %v", got, err)
+	}
+	if got, err := Factorize(1); len(got) != 0 || err != nil {
+		t.Errorf("Factorize(1) = %v, %v, want none", got, err)
+	}
+	if _, err := Factorize(0); !errors.Is(err, ErrDomain) {
+		t.Errorf("Factorize(0) error = %v, want ErrDomain", err)
+	}
+
+	rng := rand.New(rand.NewPCG(3, 4))
+	tests := []uint64{
+		math.MaxUint64, 4294967291 * 4294967279, 1000003 * 1000003 * 1000003,
+		(1<<31 - 1) * (1<<31 - 1), 600851475143, 1 << 63, 1009 * 1013,
+	}
+	for range 300 {
+		tests = append(tests, rng.Uint64())
+	}
+	for _, n := range tests {
+		factors, err := Factorize(n)
+		if err != nil || !slices.IsSorted(factors) {
+			t.Fatalf("Factorize(%d) = %v, %v", n, factors, err)
+		}
+		product := uint64(1)
+		for _, p := range factors {
+			if !IsPrime(p) {
+				t.Fatalf("Factorize(%d) = %v has composite %d", n, factors, p)
+			}
+			product *= p
+		}
+		if product != n {
+			t.Fatalf("Factorize(%d) = %v multiplies to %d", n, factors, product)
+		}
+	}
+}
+
+// TestTotient tests φ(n) against counting coprime integers
+func TestTotient(t *testing.T) {
+	for n := uint64(1); n <= 2000; n++ {
+		want := uint64(0)
+		for k := uint64(1); k <= n; k++ {
+			if gcd(k, n) == 1 {
+				want++
+			}
+		}
+		if got, err := Totient(n); got != want || err != nil {
+			t.Fatalf("Totient(%d) = %d, %v, want %d", n, got, err, want)
+		}
+	}
+	// φ(pq) = (p-1)(q-1) for distinct primes
+	if got, _ := Totient(4294967291 * 4294967279); got != 4294967290*4294967278 {
+		t.Errorf("Totient of a semiprime = %d", got)
+	}
+	if _, err := Totient(0); !errors.Is(err, ErrDomain) {
+		t.Errorf("Totient(0) error = %v, want ErrDomain", err)
+	}
+}
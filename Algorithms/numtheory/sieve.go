@@ -0,0 +1,101 @@
+package numtheory
+
+// MaxSieve is the largest bound SegmentedSieve accepts. The primes up to
+// its square root are kept in memory to cross off multiples; IsPrime tests
+// larger numbers one at a time.
+const MaxSieve = 1 << 48
+
+// segmentSize is the length of each sieved block, small enough to stay in
+// the L1 cache
+const segmentSize = 1 << 15
+
+// Sieve returns the primes up to n in increasing order, sieving segment by
+// segment so that memory beyond the result is O(√n). It returns ErrRange if
+// n exceeds MaxSieve.
+// Time Complexity: O(n log log n), Space Complexity: O(√n) beyond the result
+func Sieve(n int) ([]int, error) {
+	primes := []int{}
+	if n < 2 {
+		return primes, nil
+	}
+	err := SegmentedSieve(2, uint64(n), func(p uint64) bool {
+		primes = append(primes, int(p))
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return primes, nil
+}
+
+// PrimesInRange returns the primes in [lo, hi] in increasing order. It
+// returns ErrRange if lo > hi or hi exceeds MaxSieve.
+// Time Complexity: O((hi - lo) log log hi + √hi), Space Complexity: O(√hi)
+// beyond the result
+func PrimesInRange(lo, hi uint64) ([]uint64, error) {
+	primes := []uint64{}
+	err := SegmentedSieve(lo, hi, func(p uint64) bool {
+		primes = append(primes, p)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return primes, nil
+}
+
+// SegmentedSieve calls fn for each prime in [lo, hi] in increasing order,
+// stopping early if fn returns false. The primes up to √hi are found by a
+// plain sieve, then [lo, hi] is sieved in blocks of segmentSize numbers, so
+// a range far from zero costs no memory for the numbers below it. It
+// returns ErrRange if lo > hi or hi exceeds MaxSieve.
+// Time Complexity: O((hi - lo) log log hi + √hi), Space Complexity: O(√hi)
+func SegmentedSieve(lo, hi uint64, fn func(p uint64) bool) error {
+	// Secure: validate the range
+	if lo > hi || hi > MaxSieve {
+		return ErrRange
+	}
+	lo = max(lo, 2)
+	if lo > hi {
+		return nil
+	}
+
+	base := basePrimes(isqrt(hi))
+	composite := make([]bool, segmentSize)
+	for start := lo; start <= hi; start += segmentSize {
+		length := min(segmentSize, hi-start+1)
+		clear(composite)
+		for _, p := range base {
+			if p*p > start+length-1 {
+				break
+			}
+			// The first multiple of p in the segment, skipping p itself
+			first := max(p*p, (start+p-1)/p*p)
+			for m := first - start; m < length; m += p {
+				composite[m] = true
+			}
+		}
+		for i := range length {
+			if !composite[i] && !fn(start+i) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// basePrimes returns the primes up to n with a plain sieve of Eratosthenes
+func basePrimes(n uint64) []uint64 {
+	composite := make([]bool, n+1)
+	primes := []uint64{}
+	for i := uint64(2); i <= n; i++ {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, i)
+		for m := i * i; m <= n; m += i {
+			composite[m] = true
+		}
+	}
+	return primes
+}
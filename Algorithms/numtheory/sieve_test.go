@@ -0,0 +1,61 @@
+package numtheory
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// TestSieve tests the primes up to n against IsPrime
+func TestSieve(t *testing.T) {
+	want := []int{}
+	for n := range 100000 {
+		if IsPrime(uint64(n)) {
+			want = append(want, n)
+		}
+	}
+	for _, n := range []int{-5, 0, 1, 2, 3, 30, 97, segmentSize - 1, segmentSize, segmentSize + 1, 99999} {
+		got, err := Sieve(n)
+		end, _ := slices.BinarySearch(want, n+1)
+		if err != nil || !slices.Equal(got, want[:end]) {
+			t.Errorf("Sieve(%d) = %d primes, %v, want %d", n, len(got), err, end)
+		}
+	}
+}
+
+// TestSegmentedSieve tests ranges far from zero, spanning several segments,
+// against IsPrime
+func TestSegmentedSieve(t *testing.T) {
+	for _, r := range [][2]uint64{
+		{0, 1}, {0, 2}, {5, 5}, {24, 28}, {1e9, 1e9 + 3*segmentSize + 17}, {MaxSieve - 5000, MaxSieve},
+	} {
+		want := []uint64{}
+		for n := r[0]; n <= r[1]; n++ {
+			if IsPrime(n) {
+				want = append(want, n)
+			}
+		}
+		if got, err := PrimesInRange(r[0], r[1]); err != nil || !slices.Equal(got, want) {
+			t.Errorf("PrimesInRange(%d, %d) = %d primes, %v, want %d", r[0], r[1], len(got), err, len(want))
+		}
+	}
+
+	// fn stops the sieve early
+	count := 0
+	SegmentedSieve(0, 1000, func(p uint64) bool {
+		count++
+		return count < 10
+	})
+	if count != 10 {
+		t.Errorf("SegmentedSieve stopped after %d primes, want 10", count)
+	}
+
+	for _, r := range [][2]uint64{{10, 5}, {0, MaxSieve + 1}} {
+		if _, err := PrimesInRange(r[0], r[1]); !errors.Is(err, ErrRange) {
+			t.Errorf("PrimesInRange(%d, %d) error = %v, want ErrRange", r[0], r[1], err)
+		}
+	}
+	if _, err := Sieve(MaxSieve + 1); !errors.Is(err, ErrRange) {
+		t.Errorf("Sieve past MaxSieve: error = %v, want ErrRange", err)
+	}
+}
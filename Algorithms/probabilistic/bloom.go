@@ -0,0 +1,165 @@
+// Package probabilistic provides probabilistic data structures, which
+// trade exact answers for far less memory than an exact structure would
+// take. Their errors are one-sided and bounded: a Bloom filter may claim
+// a key it never saw, at a rate chosen when it is built, but never denies
+// one it did.
+package probabilistic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// ErrCorrupt is returned by UnmarshalBinary for malformed data
+var ErrCorrupt = errors.New("corrupt filter data")
+
+// maxBloomHashes bounds the hash functions of a Bloom filter; more never
+// pays, as the optimum for a 1e-9 false positive rate is 30
+const maxBloomHashes = 32
+
+// bloomHeaderSize is the size of the bit count and hash count that start
+// a marshaled Bloom filter
+const bloomHeaderSize = 12
+
+// Bloom is a Bloom filter: a set of m bits of which each key added sets k,
+// chosen by hashing it. A key is reported present if all its k bits are
+// set, which they are for every key added and, by chance, for others at a
+// rate that grows with the fraction of bits set. The k bits come from two
+// hashes by double hashing, h1 + i·h2 for i below k, which costs one hash
+// of the key and performs as well as k independent hashes. A Bloom is not
+// safe for concurrent use with Add.
+type Bloom struct {
+	bits   []uint64
+	m      uint64 // Bits in the filter
+	hashes uint32 // Bits set per key
+}
+
+// NewBloom creates a Bloom filter sized to hold n keys with a false
+// positive rate of about p, using m = -n·ln p / ln² 2 bits and
+// k = m/n · ln 2 hashes. An n below 1 is taken as 1. It panics unless
+// 0 < p < 1.
+func NewBloom(n int, p float64) *Bloom {
+	if !(p > 0 && p < 1) {
+		panic(fmt.Sprintf("probabilistic: false positive rate %v outside (0, 1)", p))
+	}
+	n = max(n, 1)
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+	return NewBloomSize(uint64(m), int(k))
+}
+
+// NewBloomSize creates a Bloom filter of m bits setting k per key. Both
+// are raised to at least 1, and k is capped at 32.
+func NewBloomSize(m uint64, k int) *Bloom {
+	m = max(m, 1)
+	k = min(max(k, 1), maxBloomHashes)
+	return &Bloom{bits: make([]uint64, (m+63)/64), m: m, hashes: uint32(k)}
+}
+
+// Bits returns the number of bits in the filter
+func (b *Bloom) Bits() uint64 {
+	return b.m
+}
+
+// Hashes returns the number of bits set per key
+func (b *Bloom) Hashes() int {
+	return int(b.hashes)
+}
+
+// Hash returns the hash a Bloom filter derives a key's bits from, for
+// callers that hash keys once and add them later with AddHash
+func Hash(key []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(key)
+	return h.Sum64()
+}
+
+// Add adds key to the filter
+// Time Complexity: O(len(key) + k), Space Complexity: O(1)
+func (b *Bloom) Add(key []byte) {
+	b.AddHash(Hash(key))
+}
+
+// AddHash adds the key whose Hash is h
+func (b *Bloom) AddHash(h uint64) {
+	h1, h2 := split(h)
+	for i := range uint64(b.hashes) {
+		bit := (h1 + i*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Contains reports whether key may have been added: false means it
+// certainly was not
+// Time Complexity: O(len(key) + k), Space Complexity: O(1)
+func (b *Bloom) Contains(key []byte) bool {
+	return b.ContainsHash(Hash(key))
+}
+
+// ContainsHash reports whether the key whose Hash is h may have been added
+func (b *Bloom) ContainsHash(h uint64) bool {
+	h1, h2 := split(h)
+	for i := range uint64(b.hashes) {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// split derives the two hashes of double hashing from h: h itself, and h
+// remixed by the SplitMix64 finalizer and made odd, so that its multiples
+// do not repeat early when m is even
+func split(h uint64) (uint64, uint64) {
+	z := h
+	z = (z ^ z>>30) * 0xBF58476D1CE4E5B9
+	z = (z ^ z>>27) * 0x94D049BB133111EB
+	z ^= z >> 31
+	return h, z | 1
+}
+
+// FalsePositiveRate estimates the false positive rate after n keys have
+// been added, (1 - e^(-k·n/m))^k
+func (b *Bloom) FalsePositiveRate(n int) float64 {
+	k := float64(b.hashes)
+	return math.Pow(1-math.Exp(-k*float64(n)/float64(b.m)), k)
+}
+
+// MarshalBinary encodes the filter: the bit count as a little-endian
+// uint64, the hash count as a uint32, then the bits in little-endian
+// 64-bit words
+func (b *Bloom) MarshalBinary() ([]byte, error) {
+	out := make([]byte, bloomHeaderSize, bloomHeaderSize+8*len(b.bits))
+	binary.LittleEndian.PutUint64(out, b.m)
+	binary.LittleEndian.PutUint32(out[8:], b.hashes)
+	for _, w := range b.bits {
+		out = binary.LittleEndian.AppendUint64(out, w)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary, returning
+// ErrCorrupt if the data is malformed
+func (b *Bloom) UnmarshalBinary(data []byte) error {
+	if len(data) < bloomHeaderSize {
+		return fmt.Errorf("%w: %d bytes", ErrCorrupt, len(data))
+	}
+	m := binary.LittleEndian.Uint64(data)
+	k := binary.LittleEndian.Uint32(data[8:])
+	data = data[bloomHeaderSize:]
+	// The bit count is checked against the data actually present before
+	// anything is allocated for it
+	if m == 0 || k == 0 || k > maxBloomHashes || uint64(len(data)) != (m+63)/64*8 {
+		return fmt.Errorf("%w: %d bits and %d hashes in %d bytes", ErrCorrupt, m, k, len(data))
+	}
+	bits := make([]uint64, len(data)/8)
+	for i := range bits {
+		bits[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	b.bits, b.m, b.hashes = bits, m, k
+	return nil
+}
@@ -0,0 +1,107 @@
+package probabilistic
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestBloomNoFalseNegatives tests that every key added is found
+func TestBloomNoFalseNegatives(t *testing.T) {
+	b := NewBloom(1000, 0.01)
+	for i := range 1000 {
+		b.Add(fmt.Appendf(nil, "key-%d", i))
+	}
+	for i := range 1000 {
+		if !b.Contains(fmt.Appendf(nil, "key-%d", i)) {
+			t.Fatalf("key-%d added but not found", i)
+		}
+	}
+}
+
+// TestBloomFalsePositiveRate tests that the measured false positive rate
+// is near the one asked for
+func TestBloomFalsePositiveRate(t *testing.T) {
+	for _, p := range []float64{0.1, 0.01, 0.001} {
+		const n = 10000
+		b := NewBloom(n, p)
+		for i := range n {
+			b.Add(fmt.Appendf(nil, "in-%d", i))
+		}
+		falses := 0
+		const probes = 100000
+		for i := range probes {
+			if b.Contains(fmt.Appendf(nil, "out-%d", i)) {
+				falses++
+			}
+		}
+		got := float64(falses) / probes
+		if got > 1.5*p {
+			t.Errorf("p = %v: measured false positive rate %v", p, got)
+		}
+		if est := b.FalsePositiveRate(n); est > 1.2*p || est < 0.8*p {
+			t.Errorf("p = %v: estimated rate %v", p, est)
+		}
+	}
+}
+
+// TestBloomSize tests the sizing formulas and their bounds
+func TestBloomSize(t *testing.T) {
+	b := NewBloom(1000, 0.01)
+	// 9.59 bits and 6.64 hashes per key
+	if b.Bits() != 9586 || b.Hashes() != 7 {
+		t.Errorf("NewBloom(1000, 0.01) has %d bits and %d hashes", b.Bits(), b.Hashes())
+	}
+	if b := NewBloomSize(0, 100); b.Bits() != 1 || b.Hashes() != maxBloomHashes {
+		t.Errorf("NewBloomSize(0, 100) has %d bits and %d hashes", b.Bits(), b.Hashes())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("NewBloom with p = 1 did not panic")
+		}
+	}()
+	NewBloom(10, 1)
+}
+
+// TestBloomMarshal tests that a filter round-trips and malformed data is
+// rejected
+func TestBloomMarshal(t *testing.T) {
+	b := NewBloom(100, 0.01)
+	b.Add([]byte("present"))
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Bloom
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Contains([]byte("present")) || got.Bits() != b.Bits() || got.Hashes() != b.Hashes() {
+		t.Error("unmarshaled filter differs")
+	}
+
+	bad := [][]byte{
+		nil,
+		data[:len(data)-1],
+		append(append([]byte{}, data...), 0),
+		append([]byte{0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0}, data[bloomHeaderSize:]...),
+		append([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 1, 0, 0, 0}, data[bloomHeaderSize:]...),
+	}
+	for i, d := range bad {
+		if err := new(Bloom).UnmarshalBinary(d); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("case %d: UnmarshalBinary = %v, want ErrCorrupt", i, err)
+		}
+	}
+}
+
+// BenchmarkBloomContains measures lookups of absent keys
+func BenchmarkBloomContains(b *testing.B) {
+	f := NewBloom(1<<16, 0.01)
+	for i := range 1 << 16 {
+		f.Add(fmt.Appendf(nil, "key-%d", i))
+	}
+	key := []byte("absent")
+	for b.Loop() {
+		f.Contains(key)
+	}
+}
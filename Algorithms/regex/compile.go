@@ -0,0 +1,179 @@
+package regex
+
+// maxProgram bounds the instructions of a compiled pattern, which repeat
+// counts multiply, so a short pattern cannot demand unbounded memory
+const maxProgram = 100_000
+
+// opcode is the operation of an NFA instruction
+type opcode uint8
+
+const (
+	opRune  opcode = iota // Consume a rune in ranges, then go to out
+	opSplit               // Go to out and, with lower priority, to alt
+	opJump                // Go to out
+	opBegin               // Go to out at the start of the text
+	opEnd                 // Go to out at the end of the text
+	opMatch               // Report a match
+)
+
+// inst is an NFA instruction. The NFA is a program whose states are the
+// instructions, following Thompson's construction: each operator of the
+// pattern becomes a few states joined by empty transitions.
+type inst struct {
+	op     opcode
+	out    int
+	alt    int    // Of a split
+	ranges []rune // Of a rune: sorted, disjoint inclusive ranges lo, hi, ...
+}
+
+// matches reports whether r is in the ranges of the instruction
+// Time Complexity: O(log k) for k ranges
+func (in *inst) matches(r rune) bool {
+	lo, hi := 0, len(in.ranges)/2
+	for lo < hi {
+		m := int(uint(lo+hi) >> 1)
+		switch {
+		case r < in.ranges[2*m]:
+			hi = m
+		case r > in.ranges[2*m+1]:
+			lo = m + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// size returns the number of instructions n compiles to, saturating past
+// maxProgram
+func size(n *node) int {
+	total := 0
+	switch n.kind {
+	case kindClass, kindBegin, kindEnd:
+		total = 1
+	case kindConcat, kindAlternate:
+		for _, sub := range n.subs {
+			total += size(sub)
+			if n.kind == kindAlternate {
+				total += 2 // A split and a jump
+			}
+		}
+	case kindRepeat:
+		sub := size(n.subs[0]) + 2 // With a split and a jump or another split
+		copies := max(n.min, n.max)
+		if n.max < 0 {
+			copies = n.min + 1
+		}
+		total = sub * copies
+	}
+	return min(total, maxProgram+1)
+}
+
+// compiler emits the instructions of a pattern. Each node is compiled so
+// that its instructions end by continuing at the next one emitted.
+type compiler struct {
+	prog []inst
+}
+
+// compile compiles a parsed pattern into a program ending in opMatch
+func compile(n *node) ([]inst, error) {
+	if size(n) > maxProgram {
+		return nil, ErrTooLarge
+	}
+	c := &compiler{}
+	c.node(n)
+	c.emit(opMatch)
+	return c.prog, nil
+}
+
+// emit appends an instruction continuing at the next one, returning its
+// index
+func (c *compiler) emit(op opcode) int {
+	pc := len(c.prog)
+	c.prog = append(c.prog, inst{op: op, out: pc + 1})
+	return pc
+}
+
+// node emits the instructions of n
+func (c *compiler) node(n *node) {
+	switch n.kind {
+	case kindClass:
+		pc := c.emit(opRune)
+		c.prog[pc].ranges = n.ranges
+	case kindBegin:
+		c.emit(opBegin)
+	case kindEnd:
+		c.emit(opEnd)
+	case kindConcat:
+		for _, sub := range n.subs {
+			c.node(sub)
+		}
+	case kindAlternate:
+		// split L1, L2; L1: a; jump end; L2: split L2', L3; ...; end
+		var jumps []int
+		for i, sub := range n.subs {
+			if i == len(n.subs)-1 {
+				c.node(sub)
+				break
+			}
+			split := c.emit(opSplit)
+			c.node(sub)
+			jumps = append(jumps, c.emit(opJump))
+			c.prog[split].alt = len(c.prog)
+		}
+		for _, j := range jumps {
+			c.prog[j].out = len(c.prog)
+		}
+	case kindRepeat:
+		c.repeat(n.subs[0], n.min, n.max)
+	}
+}
+
+// repeat emits x{lo,hi} as lo copies of x followed by x* if hi is
+// unbounded, or by hi - lo nested optional copies: x{2,4} is
+// xx(x(x)?)?
+func (c *compiler) repeat(x *node, lo, hi int) {
+	if hi < 0 {
+		switch {
+		case lo > 0:
+			for range lo - 1 {
+				c.node(x)
+			}
+			c.plus(x)
+		case x.nullable():
+			// As (x+)?, so that x is preferred to matching nothing where
+			// x matches the empty string, as in regexp
+			split := c.emit(opSplit)
+			c.plus(x)
+			c.prog[split].alt = len(c.prog)
+		default:
+			// L: split L1, end; L1: x; jump L; end
+			split := c.emit(opSplit)
+			c.node(x)
+			jump := c.emit(opJump)
+			c.prog[jump].out = split
+			c.prog[split].alt = len(c.prog)
+		}
+		return
+	}
+	for range lo {
+		c.node(x)
+	}
+	var splits []int
+	for range hi - lo {
+		splits = append(splits, c.emit(opSplit))
+		c.node(x)
+	}
+	for _, s := range splits {
+		c.prog[s].alt = len(c.prog)
+	}
+}
+
+// plus emits x+: L: x; split L, end; end
+func (c *compiler) plus(x *node) {
+	start := len(c.prog)
+	c.node(x)
+	split := c.emit(opSplit)
+	c.prog[split].out = start
+	c.prog[split].alt = split + 1
+}
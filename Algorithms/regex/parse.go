@@ -0,0 +1,401 @@
+package regex
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDepth bounds the nesting of groups, so a hostile pattern cannot
+// exhaust the stack
+const maxDepth = 1000
+
+// maxRepeat is the largest count of a {n,m} repetition, as in regexp
+const maxRepeat = 1000
+
+// nodeKind is the kind of a node of a parsed pattern
+type nodeKind uint8
+
+const (
+	kindEmpty     nodeKind = iota // Matches the empty string
+	kindClass                     // Matches one rune in ranges
+	kindBegin                     // ^, matches at the start of the text
+	kindEnd                       // $, matches at the end of the text
+	kindConcat                    // Matches subs in sequence
+	kindAlternate                 // Matches one of subs, preferring the first
+	kindRepeat                    // Matches subs[0] min to max times, preferring more
+)
+
+// node is a node of a parsed pattern
+type node struct {
+	kind     nodeKind
+	ranges   []rune // Of a class: sorted, disjoint inclusive ranges lo, hi, ...
+	subs     []*node
+	min, max int // Of a repeat; max is -1 if unbounded
+}
+
+// nullable reports whether n can match the empty string
+func (n *node) nullable() bool {
+	switch n.kind {
+	case kindClass:
+		return false
+	case kindConcat:
+		for _, sub := range n.subs {
+			if !sub.nullable() {
+				return false
+			}
+		}
+		return true
+	case kindAlternate:
+		return slices.ContainsFunc(n.subs, (*node).nullable)
+	case kindRepeat:
+		return n.min == 0 || n.subs[0].nullable()
+	}
+	return true
+}
+
+// Classes of the escapes \d, \s and \w, and of . (any rune but newline)
+var (
+	digitRanges = []rune{'0', '9'}
+	spaceRanges = []rune{'\t', '\n', '\f', '\r', ' ', ' '}
+	wordRanges  = []rune{'0', '9', 'A', 'Z', '_', '_', 'a', 'z'}
+	dotRanges   = []rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}
+)
+
+// parser is a recursive descent parser of a pattern
+type parser struct {
+	src   string
+	pos   int
+	depth int
+}
+
+// parse parses a pattern
+func parse(src string) (*node, error) {
+	if !utf8.ValidString(src) {
+		return nil, fmt.Errorf("%w: invalid UTF-8", ErrSyntax)
+	}
+	p := &parser{src: src}
+	n, err := p.alternate()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(src) {
+		return nil, p.errorf("unexpected )")
+	}
+	return n, nil
+}
+
+// errorf returns a syntax error at the current position
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at offset %d: %s", ErrSyntax, p.pos, fmt.Sprintf(format, args...))
+}
+
+// more reports whether the next byte is not one of stop
+func (p *parser) more(stop string) bool {
+	return p.pos < len(p.src) && !strings.ContainsRune(stop, rune(p.src[p.pos]))
+}
+
+// alternate parses alternatives separated by |
+func (p *parser) alternate() (*node, error) {
+	// Secure: Bound recursion, which every group passes through
+	if p.depth++; p.depth > maxDepth {
+		return nil, p.errorf("groups nested too deeply")
+	}
+	defer func() { p.depth-- }()
+
+	var subs []*node
+	for {
+		n, err := p.concat()
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, n)
+		if !p.more(")") {
+			break
+		}
+		p.pos++ // |
+	}
+	if len(subs) == 1 {
+		return subs[0], nil
+	}
+	return &node{kind: kindAlternate, subs: subs}, nil
+}
+
+// concat parses a sequence of repeated atoms
+func (p *parser) concat() (*node, error) {
+	var subs []*node
+	for p.more("|)") {
+		n, err := p.atom()
+		if err != nil {
+			return nil, err
+		}
+		if n, err = p.repeat(n); err != nil {
+			return nil, err
+		}
+		subs = append(subs, n)
+	}
+	switch len(subs) {
+	case 0:
+		return &node{kind: kindEmpty}, nil
+	case 1:
+		return subs[0], nil
+	}
+	return &node{kind: kindConcat, subs: subs}, nil
+}
+
+// repeat parses the quantifier following an atom, if any: *, +, ?, {n},
+// {n,} or {n,m}
+func (p *parser) repeat(n *node) (*node, error) {
+	start := p.pos
+	lo, hi, ok := p.quantifier()
+	if !ok {
+		return n, nil
+	}
+	if lo > maxRepeat || hi > maxRepeat || (hi >= 0 && lo > hi) {
+		return nil, fmt.Errorf("%w at offset %d: invalid repeat count %s", ErrSyntax, start, p.src[start:p.pos])
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '?' {
+		return nil, p.errorf("non-greedy repetition is not supported")
+	}
+	if _, _, ok := p.quantifier(); ok {
+		return nil, fmt.Errorf("%w at offset %d: invalid nested repetition operator", ErrSyntax, start)
+	}
+	return &node{kind: kindRepeat, subs: []*node{n}, min: lo, max: hi}, nil
+}
+
+// quantifier consumes a quantifier and returns its bounds, or reports
+// false and consumes nothing. A { that does not start a valid {n,m} is
+// an ordinary character.
+func (p *parser) quantifier() (lo, hi int, ok bool) {
+	if p.pos >= len(p.src) {
+		return 0, 0, false
+	}
+	switch p.src[p.pos] {
+	case '*':
+		p.pos++
+		return 0, -1, true
+	case '+':
+		p.pos++
+		return 1, -1, true
+	case '?':
+		p.pos++
+		return 0, 1, true
+	case '{':
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end < 0 {
+			return 0, 0, false
+		}
+		body := p.src[p.pos+1 : p.pos+end]
+		first, rest, comma := strings.Cut(body, ",")
+		if lo, ok = count(first); !ok {
+			return 0, 0, false
+		}
+		switch hi = lo; {
+		case comma && rest == "":
+			hi = -1
+		case comma:
+			if hi, ok = count(rest); !ok {
+				return 0, 0, false
+			}
+		}
+		p.pos += end + 1
+		return lo, hi, true
+	}
+	return 0, 0, false
+}
+
+// count parses the decimal count of a repetition. Counts past maxRepeat
+// saturate, to be rejected as invalid rather than taken as literal text.
+func count(s string) (int, bool) {
+	if s == "" || strings.Trim(s, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n > maxRepeat {
+		return maxRepeat + 1, true
+	}
+	return n, true
+}
+
+// atom parses a character, class, anchor or group
+func (p *parser) atom() (*node, error) {
+	if _, _, ok := p.quantifier(); ok {
+		return nil, p.errorf("missing argument to repetition operator")
+	}
+	c := p.src[p.pos]
+	switch c {
+	case '(':
+		p.pos++
+		if strings.HasPrefix(p.src[p.pos:], "?:") {
+			p.pos += 2
+		} else if strings.HasPrefix(p.src[p.pos:], "?") {
+			return nil, p.errorf("unsupported group flags")
+		}
+		n, err := p.alternate()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("missing closing )")
+		}
+		p.pos++
+		return n, nil
+	case '[':
+		return p.class()
+	case '.':
+		p.pos++
+		return &node{kind: kindClass, ranges: dotRanges}, nil
+	case '^':
+		p.pos++
+		return &node{kind: kindBegin}, nil
+	case '$':
+		p.pos++
+		return &node{kind: kindEnd}, nil
+	case '\\':
+		ranges, err := p.escape()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: kindClass, ranges: ranges}, nil
+	}
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	return &node{kind: kindClass, ranges: []rune{r, r}}, nil
+}
+
+// escape parses an escape sequence after a backslash: a class such as \d
+// or \W, a control character such as \n, or an escaped punctuation mark
+func (p *parser) escape() ([]rune, error) {
+	p.pos++
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("trailing backslash")
+	}
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	switch r {
+	case 'd':
+		return digitRanges, nil
+	case 'D':
+		return negate(digitRanges), nil
+	case 's':
+		return spaceRanges, nil
+	case 'S':
+		return negate(spaceRanges), nil
+	case 'w':
+		return wordRanges, nil
+	case 'W':
+		return negate(wordRanges), nil
+	case 'n':
+		r = '\n'
+	case 't':
+		r = '\t'
+	case 'r':
+		r = '\r'
+	case 'f':
+		r = '\f'
+	case 'v':
+		r = '\v'
+	default:
+		if r >= utf8.RuneSelf || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			p.pos -= size + 1
+			return nil, p.errorf("unsupported escape \\%c", r)
+		}
+	}
+	return []rune{r, r}, nil
+}
+
+// class parses a character class: [abc], [a-z0-9_] or [^\s,]. A ] first
+// in the class and a - first or last are ordinary characters.
+func (p *parser) class() (*node, error) {
+	start := p.pos
+	p.pos++
+	negated := strings.HasPrefix(p.src[p.pos:], "^")
+	if negated {
+		p.pos++
+	}
+	var ranges []rune
+	for first := true; ; first = false {
+		if p.pos >= len(p.src) {
+			p.pos = start
+			return nil, p.errorf("missing closing ]")
+		}
+		if p.src[p.pos] == ']' && !first {
+			p.pos++
+			break
+		}
+		lo, single, err := p.classItem()
+		if err != nil {
+			return nil, err
+		}
+		if !single || !strings.HasPrefix(p.src[p.pos:], "-") || strings.HasPrefix(p.src[p.pos:], "-]") {
+			ranges = append(ranges, lo...)
+			continue
+		}
+		p.pos++ // -
+		itemStart := p.pos
+		hi, single, err := p.classItem()
+		if err != nil {
+			return nil, err
+		}
+		if !single || hi[0] < lo[0] {
+			p.pos = itemStart
+			return nil, p.errorf("invalid character class range")
+		}
+		ranges = append(ranges, lo[0], hi[0])
+	}
+	ranges = canonical(ranges)
+	if negated {
+		ranges = negate(ranges)
+	}
+	return &node{kind: kindClass, ranges: ranges}, nil
+}
+
+// classItem parses a character or escape in a class, reporting whether it
+// is a single character that may start or end a range
+func (p *parser) classItem() ([]rune, bool, error) {
+	if p.src[p.pos] == '\\' {
+		ranges, err := p.escape()
+		return ranges, err == nil && len(ranges) == 2 && ranges[0] == ranges[1], err
+	}
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	return []rune{r, r}, true, nil
+}
+
+// canonical sorts ranges and merges those that overlap or touch
+func canonical(ranges []rune) []rune {
+	type span struct{ lo, hi rune }
+	spans := make([]span, 0, len(ranges)/2)
+	for i := 0; i < len(ranges); i += 2 {
+		spans = append(spans, span{ranges[i], ranges[i+1]})
+	}
+	slices.SortFunc(spans, func(a, b span) int { return int(a.lo - b.lo) })
+	out := ranges[:0:0]
+	for _, s := range spans {
+		if n := len(out); n > 0 && s.lo <= out[n-1]+1 {
+			out[n-1] = max(out[n-1], s.hi)
+			continue
+		}
+		out = append(out, s.lo, s.hi)
+	}
+	return out
+}
+
+// negate returns the complement of canonical ranges
+func negate(ranges []rune) []rune {
+	var out []rune
+	next := rune(0)
+	for i := 0; i < len(ranges); i += 2 {
+		if ranges[i] > next {
+			out = append(out, next, ranges[i]-1)
+		}
+		next = ranges[i+1] + 1
+	}
+	if next <= unicode.MaxRune {
+		out = append(out, next, unicode.MaxRune)
+	}
+	return out
+}
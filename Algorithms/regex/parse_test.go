@@ -0,0 +1,85 @@
+package regex
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestSyntaxErrors tests that malformed and unsupported patterns are
+// rejected, with the offset of the problem
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct{ pattern, want string }{
+		{"(ab", "offset 3: missing closing )"},
+		{"ab)", "offset 2: unexpected )"},
+		{"[ab", "offset 0: missing closing ]"},
+		{"[]", "offset 0: missing closing ]"},
+		{"[z-a]", "offset 3: invalid character class range"},
+		{`[a-\d]`, "offset 3: invalid character class range"},
+		{"*a", "offset 1: missing argument to repetition operator"},
+		{"a|+", "offset 3: missing argument to repetition operator"},
+		{"(*)", "offset 2: missing argument to repetition operator"},
+		{"a**", "offset 1: invalid nested repetition operator"},
+		{"a*{2}", "offset 1: invalid nested repetition operator"},
+		{"a*?", "offset 2: non-greedy repetition is not supported"},
+		{"a{2,1}", "offset 1: invalid repeat count {2,1}"},
+		{"a{1001}", "offset 1: invalid repeat count {1001}"},
+		{"a{99999999999999999999}", "offset 1: invalid repeat count"},
+		{`a\`, "offset 2: trailing backslash"},
+		{`\b`, `offset 0: unsupported escape \b`},
+		{`\1`, `offset 0: unsupported escape \1`},
+		{"(?i)a", "offset 1: unsupported group flags"},
+		{"\xff", "invalid UTF-8"},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.pattern)
+		if !errors.Is(err, ErrSyntax) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compile(%q) = %v, want %v %s", tt.pattern, err, ErrSyntax, tt.want)
+		}
+	}
+}
+
+// TestLimits tests the bounds on nesting and program size
+func TestLimits(t *testing.T) {
+	deep := strings.Repeat("(", maxDepth+1) + "a" + strings.Repeat(")", maxDepth+1)
+	if _, err := Compile(deep); !errors.Is(err, ErrSyntax) {
+		t.Errorf("Compile of %d groups = %v, want %v", maxDepth+1, err, ErrSyntax)
+	}
+	if _, err := Compile("((a{1000}){1000}){1000}"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Compile of a nested repeat = %v, want %v", err, ErrTooLarge)
+	}
+	if _, err := Compile("(abc|def){1000}"); err != nil {
+		t.Errorf("Compile of a large repeat = %v", err)
+	}
+}
+
+// TestClasses tests canonical and negated class ranges
+func TestClasses(t *testing.T) {
+	if got := canonical([]rune{'x', 'z', 'a', 'c', 'b', 'd', 'e', 'e'}); string(got) != "aexz" {
+		t.Errorf("canonical = %q, want aexz", string(got))
+	}
+	in := inst{ranges: negate([]rune{'b', 'c', 'x', 'x'})}
+	for r, want := range map[rune]bool{'a': true, 'b': false, 'c': false, 'd': true, 'x': false, 'y': true, 0: true, '\U0010FFFF': true} {
+		if got := in.matches(r); got != want {
+			t.Errorf("[^b-cx] matches %q = %v, want %v", r, got, want)
+		}
+	}
+}
+
+// FuzzCompile tests that compiling never panics, and that a pattern
+// regexp accepts too gives the same matches
+func FuzzCompile(f *testing.F) {
+	for _, s := range []string{"a(b|c)*d", "[^a-z]+$", `\d{2,3}`, "^(a?)*$", "(?:x|)+"} {
+		f.Add(s, "abc 123 xx")
+	}
+	f.Fuzz(func(t *testing.T, pattern, s string) {
+		re, err := Compile(pattern)
+		if err != nil {
+			return
+		}
+		if len(re.prog) > 2000 {
+			return // Past the size regexp accepts
+		}
+		check(t, pattern, s)
+	})
+}
@@ -0,0 +1,260 @@
+// Package regex implements regular expressions by Thompson's construction:
+// a pattern is compiled to a nondeterministic finite automaton, which is
+// run by simulating every state it can be in at once. Unlike a
+// backtracking matcher, whose time can grow exponentially with the text
+// on patterns such as (a|a)*b, matching takes O(n * m) time for a text of
+// n bytes and a pattern of m instructions, whatever the pattern.
+//
+// The syntax is a subset of that of regexp: concatenation, alternation
+// with |, the repetitions *, +, ? and {n,m}, groups (...) and (?:...),
+// the classes ., [...], [^...], \d, \s, \w and their negations, the
+// anchors ^ and $ (of the whole text), and escaped punctuation. Matches
+// are leftmost-first, preferring the earlier alternative and the longer
+// repetition, so results are those of regexp for every pattern accepted.
+// Text is UTF-8; an invalid byte matches as U+FFFD.
+package regex
+
+import (
+	"errors"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	// ErrSyntax is returned when a pattern is malformed or uses syntax
+	// that is not supported
+	ErrSyntax = errors.New("invalid regular expression")
+	// ErrTooLarge is returned when a pattern compiles to more than
+	// 100,000 instructions
+	ErrTooLarge = errors.New("regular expression too large")
+)
+
+// Regexp is a compiled regular expression. It is safe for concurrent use.
+type Regexp struct {
+	expr     string
+	prog     []inst
+	machines sync.Pool // Of *machine, reused across matches
+}
+
+// Compile parses a regular expression and compiles it to an NFA
+// Time Complexity: O(m) for a pattern compiling to m instructions
+func Compile(expr string) (*Regexp, error) {
+	n, err := parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := compile(n)
+	if err != nil {
+		return nil, err
+	}
+	return &Regexp{expr: expr, prog: prog}, nil
+}
+
+// MustCompile is like Compile but panics if the expression is invalid,
+// for patterns known to be valid
+func MustCompile(expr string) *Regexp {
+	re, err := Compile(expr)
+	if err != nil {
+		panic("regex: Compile(" + expr + "): " + err.Error())
+	}
+	return re
+}
+
+// MatchString reports whether s contains a match of the expression
+func MatchString(expr, s string) (bool, error) {
+	re, err := Compile(expr)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s), nil
+}
+
+// String returns the source of the expression
+func (re *Regexp) String() string { return re.expr }
+
+// MatchString reports whether s contains a match
+// Time Complexity: O(n * m)
+func (re *Regexp) MatchString(s string) bool {
+	start, _ := re.find(s, 0, true)
+	return start >= 0
+}
+
+// FindStringIndex returns the start and end of the leftmost match in s,
+// or nil if there is none
+// Time Complexity: O(n * m)
+func (re *Regexp) FindStringIndex(s string) []int {
+	start, end := re.find(s, 0, false)
+	if start < 0 {
+		return nil
+	}
+	return []int{start, end}
+}
+
+// FindString returns the text of the leftmost match in s, or "" if there
+// is none
+func (re *Regexp) FindString(s string) string {
+	start, end := re.find(s, 0, false)
+	if start < 0 {
+		return ""
+	}
+	return s[start:end]
+}
+
+// FindAllStringIndex returns the start and end of up to n successive
+// non-overlapping matches in s, or of all of them if n is negative. As in
+// regexp, an empty match right after a previous match is skipped.
+// Time Complexity: O(n * m) per match
+func (re *Regexp) FindAllStringIndex(s string, n int) [][]int {
+	if n < 0 {
+		n = len(s) + 1
+	}
+	var out [][]int
+	for pos, prevEnd := 0, -1; len(out) < n && pos <= len(s); {
+		start, end := re.find(s, pos, false)
+		if start < 0 {
+			break
+		}
+		accept := true
+		if end == pos {
+			// An empty match: step over a rune so the search advances
+			accept = start != prevEnd
+			_, width := utf8.DecodeRuneInString(s[pos:])
+			pos += max(width, 1)
+		} else {
+			pos = end
+		}
+		prevEnd = end
+		if accept {
+			out = append(out, []int{start, end})
+		}
+	}
+	return out
+}
+
+// thread is a state of the NFA and the start of the match reaching it
+type thread struct {
+	pc, start int
+}
+
+// queue is a set of threads in priority order, with at most one per
+// state: a sparse set, cleared in O(1) and tested for a state in O(1)
+type queue struct {
+	sparse []int
+	dense  []thread
+}
+
+// contains reports whether the queue has a thread at pc
+func (q *queue) contains(pc int) bool {
+	i := q.sparse[pc]
+	return i < len(q.dense) && q.dense[i].pc == pc
+}
+
+// insert appends a thread at pc, which must not be in the queue
+func (q *queue) insert(pc, start int) {
+	q.sparse[pc] = len(q.dense)
+	q.dense = append(q.dense, thread{pc, start})
+}
+
+// machine holds the state of one simulation
+type machine struct {
+	queues [2]queue
+	stack  []int
+}
+
+// machine returns a machine for the program, reusing an idle one
+func (re *Regexp) machine() *machine {
+	if m, ok := re.machines.Get().(*machine); ok {
+		return m
+	}
+	m := &machine{}
+	for i := range m.queues {
+		m.queues[i] = queue{sparse: make([]int, len(re.prog)), dense: make([]thread, 0, len(re.prog))}
+	}
+	return m
+}
+
+// find returns the leftmost-first match in s starting at or after pos, or
+// -1, -1. If early is set it returns as soon as some match is known, which
+// may not be the leftmost-first one.
+//
+// The simulation steps through s a rune at a time, keeping the threads
+// that are alive in priority order. A thread that reaches opMatch records
+// the match and ends the threads of lower priority, and once a match is
+// found no new threads start; those of higher priority continue, as they
+// may yet find a preferred match.
+func (re *Regexp) find(s string, pos int, early bool) (start, end int) {
+	m := re.machine()
+	defer re.machines.Put(m)
+	clist, nlist := &m.queues[0], &m.queues[1]
+	clist.dense, nlist.dense = clist.dense[:0], nlist.dense[:0]
+
+	start, end = -1, -1
+	for {
+		if start < 0 {
+			// A new thread tries a match starting here, with the lowest
+			// priority
+			re.add(m, clist, 0, pos, pos, s)
+		}
+		if len(clist.dense) == 0 {
+			break
+		}
+		r, width := rune(-1), 0
+		if pos < len(s) {
+			r, width = utf8.DecodeRuneInString(s[pos:])
+		}
+	threads:
+		for _, t := range clist.dense {
+			in := &re.prog[t.pc]
+			switch in.op {
+			case opMatch:
+				start, end = t.start, pos
+				if early {
+					return start, end
+				}
+				break threads
+			case opRune:
+				if width > 0 && in.matches(r) {
+					re.add(m, nlist, in.out, t.start, pos+width, s)
+				}
+			}
+		}
+		if pos >= len(s) {
+			break
+		}
+		pos += width
+		clist, nlist = nlist, clist
+		nlist.dense = nlist.dense[:0]
+	}
+	return start, end
+}
+
+// add adds a thread at pc to q, with the threads it reaches without
+// consuming a rune at pos, in priority order. Each state is added once,
+// which bounds the threads by the size of the program.
+func (re *Regexp) add(m *machine, q *queue, pc, start, pos int, s string) {
+	m.stack = append(m.stack[:0], pc)
+	for len(m.stack) > 0 {
+		pc := m.stack[len(m.stack)-1]
+		m.stack = m.stack[:len(m.stack)-1]
+		if q.contains(pc) {
+			continue
+		}
+		q.insert(pc, start)
+		in := &re.prog[pc]
+		switch in.op {
+		case opSplit:
+			// Pushed last, out is followed first
+			m.stack = append(m.stack, in.alt, in.out)
+		case opJump:
+			m.stack = append(m.stack, in.out)
+		case opBegin:
+			if pos == 0 {
+				m.stack = append(m.stack, in.out)
+			}
+		case opEnd:
+			if pos == len(s) {
+				m.stack = append(m.stack, in.out)
+			}
+		}
+	}
+}
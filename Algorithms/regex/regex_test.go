@@ -0,0 +1,179 @@
+package regex
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// check compares a pattern with regexp on a text
+func check(t *testing.T, pattern, s string) {
+	t.Helper()
+	re, err := Compile(pattern)
+	if err != nil {
+		t.Fatalf("Compile(%q) = %v", pattern, err)
+	}
+	std := regexp.MustCompile(pattern)
+	if got, want := re.MatchString(s), std.MatchString(s); got != want {
+		t.Errorf("%q MatchString(%q) = %v, want %v", pattern, s, got, want)
+	}
+	if got, want := re.FindStringIndex(s), std.FindStringIndex(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("%q FindStringIndex(%q) = %v, want %v", pattern, s, got, want)
+	}
+	if got, want := re.FindAllStringIndex(s, -1), std.FindAllStringIndex(s, -1); !reflect.DeepEqual(got, want) {
+		t.Errorf("%q FindAllStringIndex(%q) = %v, want %v", pattern, s, got, want)
+	}
+}
+
+// TestRegexp tests hand-picked patterns against regexp
+func TestRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		texts   []string
+	}{
+		{"abc", []string{"", "abc", "xxabcxx", "ab", "abcabc"}},
+		{"a|b|", []string{"", "c", "ab", "cab"}},
+		{"(a|ab)(c|bcd)(d*)", []string{"abcd", "acd", "abbcd"}},
+		{"a*", []string{"", "baaab", "aaa"}},
+		{"(a*)*", []string{"", "b", "aab"}},
+		{"(a*)+b", []string{"aab", "b", "ac"}},
+		{"(|a)*", []string{"aa", "b"}},
+		{"(|a)+", []string{"aa", "b"}},
+		{"x(a?)*y", []string{"xy", "xaay", "xab"}},
+		{"a{2}", []string{"a", "aaaaa"}},
+		{"a{2,}", []string{"a", "aaaaa"}},
+		{"(ab){1,3}c", []string{"abc", "ababababc", "ac"}},
+		{"a{,2}", []string{"a{,2}", "aa"}},
+		{"a{x}", []string{"a{x}"}},
+		{"^ab", []string{"ab", "cab", "abab"}},
+		{"ab$", []string{"ab", "abc", "abab"}},
+		{"^$", []string{"", "a"}},
+		{"^|$", []string{"", "abc"}},
+		{"a$|b", []string{"ba", "bab"}},
+		{"[a-c]+", []string{"xabcdcba", "-"}},
+		{"[^a-c]+", []string{"abxyc\n", "abc"}},
+		{"[]a]+", []string{"a]]b"}},
+		{"[^]a]", []string{"]a", "]ab"}},
+		{"[a-]+|[-z]", []string{"--a", "z-"}},
+		{"[a-c-e]+", []string{"b-e d"}},
+		{`[\d\s]+`, []string{"a1 2\t3b"}},
+		{`[\w.]+@[\w.]+`, []string{"mail bob.smith@example.com now"}},
+		{`\d+\.\d*`, []string{"pi is 3.14159", "1."}},
+		{`\D\S\W`, []string{"a1 b!!", "ab  "}},
+		{`\s+`, []string{"a \t\n\f\r\vb"}},
+		{`[\n\t\-\]]+`, []string{"a\n\t-]b"}},
+		{`\(\)\*\+\?\[\{\|\^\$\\`, []string{`x()*+?[{|^$\y`}},
+		{".+", []string{"ab\ncd", "\n"}},
+		{"(?:ab)+", []string{"ababx"}},
+		{"é+|[α-ω]+", []string{"caféé", "λόγος"}},
+		{".", []string{"\xff", "a\xffb", "é"}},
+		{"[^a]", []string{"a\xff"}},
+		{"", []string{"", "abc", "é"}},
+		{"()", []string{"ab"}},
+		{"(a|b)*c(a|b)*", []string{"ababcbab"}},
+	}
+	for _, tt := range tests {
+		if _, err := regexp.Compile(tt.pattern); err != nil {
+			t.Fatalf("regexp rejects %q", tt.pattern)
+		}
+		for _, s := range tt.texts {
+			check(t, tt.pattern, s)
+		}
+	}
+}
+
+// randomPattern returns a random pattern of the supported syntax over a
+// small alphabet, so that matches are frequent
+func randomPattern(rng *rand.Rand, depth int) string {
+	if depth <= 0 {
+		return []string{"a", "b", ".", "[ab]", "[^a]", `\w`, "^", "$", ""}[rng.IntN(9)]
+	}
+	switch rng.IntN(7) {
+	case 0, 1:
+		return randomPattern(rng, depth-1) + randomPattern(rng, depth-1)
+	case 2:
+		return randomPattern(rng, depth-1) + "|" + randomPattern(rng, depth-1)
+	case 3:
+		quantifiers := []string{"*", "+", "?", "{2}", "{0,2}", "{1,}"}
+		return "(" + randomPattern(rng, depth-1) + ")" + quantifiers[rng.IntN(len(quantifiers))]
+	case 4:
+		return "(?:" + randomPattern(rng, depth-1) + ")"
+	}
+	return randomPattern(rng, 0)
+}
+
+// TestRandom tests random patterns and texts against regexp
+func TestRandom(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 11))
+	for range 3000 {
+		pattern := randomPattern(rng, 1+rng.IntN(4))
+		var b strings.Builder
+		for range rng.IntN(12) {
+			b.WriteByte("abc\n"[rng.IntN(4)])
+		}
+		check(t, pattern, b.String())
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+// TestPathological tests patterns that take exponential time to
+// backtrack: matching a?^n a^n against a^n, and (a|a)*b against a^n
+func TestPathological(t *testing.T) {
+	const n = 30
+	patterns := map[string]bool{
+		strings.Repeat("a?", n) + strings.Repeat("a", n): true,
+		"^(a|a)*b$":     false,
+		"^(a*)*b$":      false,
+		"(x+x+)+y":      false,
+		"^(\\w+\\s?)*$": true,
+	}
+	s := strings.Repeat("a", n)
+	for pattern, want := range patterns {
+		re := MustCompile(pattern)
+		begin := time.Now()
+		if got := re.MatchString(s); got != want {
+			t.Errorf("%q MatchString(a^%d) = %v, want %v", pattern, n, got, want)
+		}
+		if elapsed := time.Since(begin); elapsed > time.Second {
+			t.Errorf("%q took %v", pattern, elapsed)
+		}
+	}
+}
+
+// TestConcurrent tests a Regexp shared by goroutines; run with -race
+func TestConcurrent(t *testing.T) {
+	re := MustCompile(`(\w+)@(\w+)\.com`)
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			s := strings.Repeat("x", g) + " user@example.com"
+			want := []int{g + 1, len(s)}
+			for range 200 {
+				if got := re.FindStringIndex(s); !reflect.DeepEqual(got, want) {
+					t.Errorf("FindStringIndex(%q) = %v, want %v", s, got, want)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// BenchmarkMatch measures matching an email-like pattern against a
+// kilobyte of text
+func BenchmarkMatch(b *testing.B) {
+	re := MustCompile(`[\w.]+@[\w.]+\.(com|org)`)
+	s := strings.Repeat("lorem ipsum dolor sit amet ", 40) + "mail bob@example.org"
+	b.SetBytes(int64(len(s)))
+	for b.Loop() {
+		if !re.MatchString(s) {
+			b.Fatal("no match")
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package searching
+
+import "cmp"
+
+// LowerBound returns the index of the first element of sorted s that is not
+// less than target, or len(s) if there is none: the first position where
+// target could be inserted keeping s sorted (C++ std::lower_bound)
+// Time Complexity: O(log n), Space Complexity: O(1)
+func LowerBound[T cmp.Ordered](s []T, target T) int {
+	return LowerBoundFunc(s, target, cmp.Compare[T])
+}
+
+// LowerBoundFunc returns the index of the first element e of s with
+// compare(e, target) >= 0, or len(s). The compare function takes an element
+// and the target, which may be of different types, such as a record and
+// its key; s must be sorted in the order compare defines.
+func LowerBoundFunc[E, T any](s []E, target T, compare func(E, T) int) int {
+	return SearchMonotonic(func(i int) bool { return compare(s[i], target) >= 0 }, 0, len(s))
+}
+
+// UpperBound returns the index of the first element of sorted s that is
+// greater than target, or len(s) if there is none: the last position where
+// target could be inserted keeping s sorted (C++ std::upper_bound)
+// Time Complexity: O(log n), Space Complexity: O(1)
+func UpperBound[T cmp.Ordered](s []T, target T) int {
+	return UpperBoundFunc(s, target, cmp.Compare[T])
+}
+
+// UpperBoundFunc returns the index of the first element e of s with
+// compare(e, target) > 0, or len(s)
+func UpperBoundFunc[E, T any](s []E, target T, compare func(E, T) int) int {
+	return SearchMonotonic(func(i int) bool { return compare(s[i], target) > 0 }, 0, len(s))
+}
+
+// EqualRange returns the bounds of the run of elements of sorted s equal to
+// target: s[lo:hi], empty with lo == hi at target's insertion point if it
+// is absent (C++ std::equal_range)
+// Time Complexity: O(log n), Space Complexity: O(1)
+func EqualRange[T cmp.Ordered](s []T, target T) (lo, hi int) {
+	return EqualRangeFunc(s, target, cmp.Compare[T])
+}
+
+// EqualRangeFunc returns the bounds of the run of elements e of s with
+// compare(e, target) == 0
+func EqualRangeFunc[E, T any](s []E, target T, compare func(E, T) int) (lo, hi int) {
+	lo = LowerBoundFunc(s, target, compare)
+	// The run starts at lo, so only the rest of s needs searching
+	hi = lo + UpperBoundFunc(s[lo:], target, compare)
+	return lo, hi
+}
+
+// SearchMonotonic returns the smallest i in [lo, hi) for which f(i) is true,
+// or hi if there is none, calling f O(log(hi - lo)) times. f must be
+// monotonic over the range: false up to some index and true from it on.
+// This is binary search over an answer space rather than a slice, such as
+// the least capacity for which a schedule fits. If hi <= lo it returns lo.
+// Time Complexity: O(log(hi - lo)) calls of f, Space Complexity: O(1)
+func SearchMonotonic(f func(int) bool, lo, hi int) int {
+	for lo < hi {
+		// Secure: halve the distance in uint, where it cannot overflow
+		// even when the range spans the whole int range
+		mid := lo + int((uint(hi)-uint(lo))/2)
+		if f(mid) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
@@ -0,0 +1,117 @@
+package searching
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestBounds tests LowerBound, UpperBound and EqualRange against a linear scan
+func TestBounds(t *testing.T) {
+	for _, s := range [][]int{{}, {4}, {1, 1, 1}, {1, 3, 3, 3, 5, 8, 8, 13}} {
+		for target := -1; target <= 15; target++ {
+			lower, upper := len(s), len(s)
+			for i := len(s) - 1; i >= 0; i-- {
+				if s[i] >= target {
+					lower = i
+				}
+				if s[i] > target {
+					upper = i
+				}
+			}
+			if got := LowerBound(s, target); got != lower {
+				t.Errorf("LowerBound(%v, %d) = %d, want %d", s, target, got, lower)
+			}
+			if got := UpperBound(s, target); got != upper {
+				t.Errorf("UpperBound(%v, %d) = %d, want %d", s, target, got, upper)
+			}
+			if lo, hi := EqualRange(s, target); lo != lower || hi != upper {
+				t.Errorf("EqualRange(%v, %d) = %d, %d, want %d, %d", s, target, lo, hi, lower, upper)
+			}
+		}
+	}
+}
+
+// TestBoundsFunc tests searching records by a key of a different type
+func TestBoundsFunc(t *testing.T) {
+	type person struct {
+		name string
+		age  int
+	}
+	people := []person{{"ann", 21}, {"bob", 30}, {"cy", 30}, {"di", 30}, {"ed", 44}}
+	byAge := func(p person, age int) int { return cmp.Compare(p.age, age) }
+
+	if lo, hi := EqualRangeFunc(people, 30, byAge); lo != 1 || hi != 4 {
+		t.Errorf("EqualRangeFunc(30) = %d, %d, want 1, 4", lo, hi)
+	}
+	if got := LowerBoundFunc(people, 31, byAge); got != 4 {
+		t.Errorf("LowerBoundFunc(31) = %d, want 4", got)
+	}
+	if got := UpperBoundFunc(people, 44, byAge); got != 5 {
+		t.Errorf("UpperBoundFunc(44) = %d, want 5", got)
+	}
+
+	// Case-insensitive order
+	words := []string{"Apple", "banana", "Cherry"}
+	fold := func(a, b string) int { return cmp.Compare(strings.ToLower(a), strings.ToLower(b)) }
+	if got := LowerBoundFunc(words, "BANANA", fold); got != 1 {
+		t.Errorf("LowerBoundFunc(BANANA) = %d, want 1", got)
+	}
+}
+
+// TestSearchMonotonic tests binary search over answer spaces
+func TestSearchMonotonic(t *testing.T) {
+	// The least integer square root bound: smallest r with r*r >= n
+	for _, n := range []int{0, 1, 2, 15, 16, 17, 1 << 40} {
+		r := SearchMonotonic(func(r int) bool { return r*r >= n }, 0, 1<<21)
+		if r*r < n || (r > 0 && (r-1)*(r-1) >= n) {
+			t.Errorf("SearchMonotonic for ceil sqrt(%d) = %d", n, r)
+		}
+	}
+
+	// "Binary search the answer": the least capacity that ships the
+	// packages in order within the given days
+	weights := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	fits := func(capacity int) bool {
+		days, load := 1, 0
+		for _, w := range weights {
+			if w > capacity {
+				return false
+			}
+			if load+w > capacity {
+				days, load = days+1, 0
+			}
+			load += w
+		}
+		return days <= 5
+	}
+	if got := SearchMonotonic(fits, 1, 55); got != 15 {
+		t.Errorf("least capacity = %d, want 15", got)
+	}
+
+	if got := SearchMonotonic(func(int) bool { return false }, 3, 10); got != 10 {
+		t.Errorf("SearchMonotonic with no true index = %d, want 10", got)
+	}
+	if got := SearchMonotonic(func(int) bool { return true }, 3, 10); got != 3 {
+		t.Errorf("SearchMonotonic with all true = %d, want 3", got)
+	}
+	if got := SearchMonotonic(func(int) bool { return true }, 5, 2); got != 5 {
+		t.Errorf("SearchMonotonic over an empty range = %d, want 5", got)
+	}
+
+	// Secure: the midpoint does not overflow over the whole int range
+	for _, x := range []int{math.MinInt, -1, 0, 1, math.MaxInt - 1} {
+		if got := SearchMonotonic(func(i int) bool { return i >= x }, math.MinInt, math.MaxInt); got != x {
+			t.Errorf("SearchMonotonic over the int range for %d = %d", x, got)
+		}
+	}
+
+	// Agrees with slices.BinarySearch on a slice
+	s := []int{1, 4, 4, 9}
+	want, _ := slices.BinarySearch(s, 4)
+	if got := SearchMonotonic(func(i int) bool { return s[i] >= 4 }, 0, len(s)); got != want {
+		t.Errorf("SearchMonotonic over a slice = %d, want %d", got, want)
+	}
+}
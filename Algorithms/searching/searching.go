@@ -0,0 +1,178 @@
+// Package searching provides generic search algorithms. The searches over
+// sorted slices return the index of the target, or -1 if it is absent, and
+// expect s to be in ascending order.
+package searching
+
+import (
+	"cmp"
+	"math"
+)
+
+// Integer constraint for InterpolationSearch
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// LinearSearch searches for target in s, which need not be sorted
+// Time Complexity: O(n), Space Complexity: O(1)
+func LinearSearch[T comparable](s []T, target T) int {
+	for i, v := range s {
+		if v == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// BinarySearch searches for target in sorted s using binary search. With
+// duplicates it returns the first occurrence.
+// Time Complexity: O(log n), Space Complexity: O(1)
+func BinarySearch[T cmp.Ordered](s []T, target T) int {
+	i := LowerBound(s, target)
+	if i < len(s) && s[i] == target {
+		return i
+	}
+	return -1
+}
+
+// InterpolationSearch searches for target in sorted s by estimating its
+// position from the values at the ends of the range
+// Time Complexity: O(log log n) average for uniform values, O(n) worst,
+// Space Complexity: O(1)
+func InterpolationSearch[T Integer](s []T, target T) int {
+	left, right := 0, len(s)-1
+	for left <= right && target >= s[left] && target <= s[right] {
+		// Secure: prevent division by zero
+		if s[right] == s[left] {
+			if s[left] == target {
+				return left
+			}
+			return -1
+		}
+
+		// Secure: estimate in floating point, where the product of the
+		// spreads cannot overflow, and clamp to the range
+		ratio := (float64(target) - float64(s[left])) / (float64(s[right]) - float64(s[left]))
+		pos := min(max(left+int(ratio*float64(right-left)), left), right)
+
+		switch {
+		case s[pos] == target:
+			return pos
+		case s[pos] < target:
+			left = pos + 1
+		default:
+			right = pos - 1
+		}
+	}
+	return -1
+}
+
+// ExponentialSearch searches for target in sorted s by doubling a bound
+// until it passes target, then binary searching below it. It is fastest
+// when target is near the start. With duplicates it returns the first
+// occurrence.
+// Time Complexity: O(log i), i is the target's index, Space Complexity: O(1)
+func ExponentialSearch[T cmp.Ordered](s []T, target T) int {
+	i := ExponentialSearchFunc(len(s), func(i int) bool { return s[i] < target })
+	if i < len(s) && s[i] == target {
+		return i
+	}
+	return -1
+}
+
+// ExponentialSearchFunc gallops over any sorted random-access sequence of n
+// elements, such as an index on disk, and returns the first index i in
+// [0, n) for which less(i) is false, or n if there is none. less(i) reports
+// whether element i is less than the target, and must be true up to some
+// index and false from it on. Only the indices it probes are accessed.
+// Time Complexity: O(log i) calls of less, i is the result,
+// Space Complexity: O(1)
+func ExponentialSearchFunc(n int, less func(i int) bool) int {
+	// The answer lies in [lo, hi]: double hi while element hi is still less
+	lo, hi := 0, min(1, n)
+	for hi < n && less(hi) {
+		lo = hi + 1
+		// Secure: grow towards n without overflowing
+		hi += min(hi, n-hi)
+	}
+	return SearchMonotonic(func(i int) bool { return !less(i) }, lo, hi)
+}
+
+// JumpSearch searches for target in sorted s by jumping ahead in blocks of
+// √n and then scanning the block that may hold it. With duplicates it
+// returns the first occurrence.
+// Time Complexity: O(√n), Space Complexity: O(1)
+func JumpSearch[T cmp.Ordered](s []T, target T) int {
+	n := len(s)
+	step := max(int(math.Sqrt(float64(n))), 1)
+
+	// Find the first block whose last element is not less than target;
+	// every index is checked against n before it is read
+	start := 0
+	for start < n && s[min(start+step, n)-1] < target {
+		start += step
+	}
+
+	// Linear search for target in that block
+	for i := start; i < min(start+step, n); i++ {
+		if s[i] == target {
+			return i
+		}
+		if s[i] > target {
+			break
+		}
+	}
+	return -1
+}
+
+// TernarySearch searches for target in sorted s by splitting the range into
+// thirds
+// Time Complexity: O(log₃ n), Space Complexity: O(1)
+func TernarySearch[T cmp.Ordered](s []T, target T) int {
+	left, right := 0, len(s)-1
+	for left <= right {
+		// Divide the range into three parts
+		mid1 := left + (right-left)/3
+		mid2 := right - (right-left)/3
+
+		switch {
+		case s[mid1] == target:
+			return mid1
+		case s[mid2] == target:
+			return mid2
+		case target < s[mid1]:
+			right = mid1 - 1
+		case target > s[mid2]:
+			left = mid2 + 1
+		default:
+			left, right = mid1+1, mid2-1
+		}
+	}
+	return -1
+}
+
+// FindFirst returns the index of the first occurrence of target in sorted s,
+// or -1
+// Time Complexity: O(log n), Space Complexity: O(1)
+func FindFirst[T cmp.Ordered](s []T, target T) int {
+	return BinarySearch(s, target)
+}
+
+// FindLast returns the index of the last occurrence of target in sorted s,
+// or -1
+// Time Complexity: O(log n), Space Complexity: O(1)
+func FindLast[T cmp.Ordered](s []T, target T) int {
+	i := UpperBound(s, target) - 1
+	if i >= 0 && s[i] == target {
+		return i
+	}
+	return -1
+}
+
+// CountOccurrences counts the occurrences of target in sorted s
+// Time Complexity: O(log n), Space Complexity: O(1)
+func CountOccurrences[T cmp.Ordered](s []T, target T) int {
+	lo, hi := EqualRange(s, target)
+	return hi - lo
+}
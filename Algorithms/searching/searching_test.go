@@ -0,0 +1,140 @@
+package searching
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// TestSearches tests every search over sorted slices against
+// slices.BinarySearch. With duplicates any matching index is accepted,
+// except from the searches that promise the first.
+func TestSearches(t *testing.T) {
+	first := map[string]bool{
+		"LinearSearch": true, "BinarySearch": true, "ExponentialSearch": true, "JumpSearch": true, "FindFirst": true,
+	}
+	searches := map[string]func([]int, int) int{
+		"LinearSearch": LinearSearch[int], "BinarySearch": BinarySearch[int],
+		"InterpolationSearch": InterpolationSearch[int], "ExponentialSearch": ExponentialSearch[int],
+		"JumpSearch": JumpSearch[int], "TernarySearch": TernarySearch[int], "FindFirst": FindFirst[int],
+	}
+	for _, s := range [][]int{
+		{},
+		{7},
+		{2, 5, 8, 12, 16, 23, 38, 45, 67, 78, 89, 95},
+		{1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024},
+		{-50, -10, -10, 0, 3, 3, 3, 9},
+		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 2},
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+	} {
+		for target := -60; target <= 1030; target++ {
+			want, found := slices.BinarySearch(s, target)
+			if !found {
+				want = -1
+			}
+			for name, search := range searches {
+				got := search(s, target)
+				if !first[name] && got >= 0 && want >= 0 && s[got] == target {
+					continue
+				}
+				if got != want {
+					t.Errorf("%s(%v, %d) = %d, want %d", name, s, target, got, want)
+				}
+			}
+		}
+	}
+
+	// Secure: interpolation does not overflow across the full int range
+	wide := []int{math.MinInt, -1, 0, 1, math.MaxInt}
+	for i, v := range wide {
+		if got := InterpolationSearch(wide, v); got != i {
+			t.Errorf("InterpolationSearch(%v, %d) = %d, want %d", wide, v, got, i)
+		}
+	}
+}
+
+// TestOccurrences tests FindFirst, FindLast and CountOccurrences with duplicates
+func TestOccurrences(t *testing.T) {
+	s := []int{1, 2, 2, 2, 3, 5, 5}
+	tests := []struct {
+		target, first, last, count int
+	}{
+		{2, 1, 3, 3}, {5, 5, 6, 2}, {1, 0, 0, 1}, {4, -1, -1, 0}, {0, -1, -1, 0}, {9, -1, -1, 0},
+	}
+	for _, tt := range tests {
+		if got := FindFirst(s, tt.target); got != tt.first {
+			t.Errorf("FindFirst(%d) = %d, want %d", tt.target, got, tt.first)
+		}
+		if got := FindLast(s, tt.target); got != tt.last {
+			t.Errorf("FindLast(%d) = %d, want %d", tt.target, got, tt.last)
+		}
+		if got := CountOccurrences(s, tt.target); got != tt.count {
+			t.Errorf("CountOccurrences(%d) = %d, want %d", tt.target, got, tt.count)
+		}
+	}
+}
+
+// TestJumpSearchBounds tests that jump search stays within s for every
+// length and target, including lengths around perfect squares and input
+// that is not sorted
+func TestJumpSearchBounds(t *testing.T) {
+	for n := range 40 {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = 2 * i
+		}
+		for target := -1; target <= 2*n+1; target++ {
+			want := -1
+			if target >= 0 && target%2 == 0 && target < 2*n {
+				want = target / 2
+			}
+			if got := JumpSearch(s, target); got != want {
+				t.Errorf("JumpSearch(%d elements, %d) = %d, want %d", n, target, got, want)
+			}
+		}
+		slices.Reverse(s)
+		for target := -1; target <= 2*n+1; target++ {
+			JumpSearch(s, target)
+		}
+	}
+}
+
+// TestExponentialSearchFunc tests galloping over access functions rather
+// than slices
+func TestExponentialSearchFunc(t *testing.T) {
+	s := []int{1, 3, 3, 5, 8, 13}
+	for target := 0; target <= 14; target++ {
+		want, _ := slices.BinarySearch(s, target)
+		if got := ExponentialSearchFunc(len(s), func(i int) bool { return s[i] < target }); got != want {
+			t.Errorf("ExponentialSearchFunc(%d) = %d, want %d", target, got, want)
+		}
+	}
+	if got := ExponentialSearchFunc(0, func(int) bool { panic("probed an empty sequence") }); got != 0 {
+		t.Errorf("ExponentialSearchFunc over nothing = %d, want 0", got)
+	}
+
+	// A virtual sequence of squares the size of the int range, which only
+	// exists as a function: the first i with i*i >= target. i*i fits in an
+	// int up to 3037000499.
+	for _, tt := range []struct{ target, want int }{
+		{0, 0}, {1, 1}, {50, 8}, {1 << 40, 1 << 20}, {math.MaxInt, 3037000500},
+	} {
+		probes := 0
+		got := ExponentialSearchFunc(math.MaxInt, func(i int) bool {
+			probes++
+			return i <= 3037000499 && i*i < tt.target
+		})
+		if got != tt.want {
+			t.Errorf("ExponentialSearchFunc for square root of %d = %d, want %d", tt.target, got, tt.want)
+		}
+		if probes > 2*64 {
+			t.Errorf("ExponentialSearchFunc for square root of %d probed %d times", tt.target, probes)
+		}
+	}
+
+	probes := 0
+	ExponentialSearchFunc(1<<40, func(i int) bool { probes++; return i < 3 })
+	if probes > 6 {
+		t.Errorf("ExponentialSearchFunc for index 3 probed %d times, want at most 6", probes)
+	}
+}
@@ -0,0 +1,225 @@
+package sorting
+
+import "cmp"
+
+// BubbleSort sorts s using bubble sort. It is stable.
+// Time Complexity: O(n²), Space Complexity: O(1)
+func BubbleSort[T cmp.Ordered](s []T) {
+	BubbleSortFunc(s, cmp.Compare[T])
+}
+
+// BubbleSortFunc sorts s using bubble sort with a comparator. It is stable.
+func BubbleSortFunc[T any](s []T, compare func(a, b T) int) {
+	n := len(s)
+	for i := 0; i < n-1; i++ {
+		swapped := false
+		for j := 0; j < n-i-1; j++ {
+			if compare(s[j], s[j+1]) > 0 {
+				s[j], s[j+1] = s[j+1], s[j]
+				swapped = true
+			}
+		}
+		// Optimized: break if no swaps occurred
+		if !swapped {
+			break
+		}
+	}
+}
+
+// SelectionSort sorts s using selection sort. It is not stable.
+// Time Complexity: O(n²), Space Complexity: O(1)
+func SelectionSort[T cmp.Ordered](s []T) {
+	SelectionSortFunc(s, cmp.Compare[T])
+}
+
+// SelectionSortFunc sorts s using selection sort with a comparator. It is
+// not stable.
+func SelectionSortFunc[T any](s []T, compare func(a, b T) int) {
+	n := len(s)
+	for i := 0; i < n-1; i++ {
+		minIdx := i
+		for j := i + 1; j < n; j++ {
+			if compare(s[j], s[minIdx]) < 0 {
+				minIdx = j
+			}
+		}
+		if minIdx != i {
+			s[i], s[minIdx] = s[minIdx], s[i]
+		}
+	}
+}
+
+// InsertionSort sorts s using insertion sort. It is stable.
+// Time Complexity: O(n²), Space Complexity: O(1)
+func InsertionSort[T cmp.Ordered](s []T) {
+	InsertionSortFunc(s, cmp.Compare[T])
+}
+
+// InsertionSortFunc sorts s using insertion sort with a comparator. It is
+// stable.
+func InsertionSortFunc[T any](s []T, compare func(a, b T) int) {
+	for i := 1; i < len(s); i++ {
+		key := s[i]
+		j := i - 1
+
+		// Secure: bounds checking
+		for j >= 0 && compare(s[j], key) > 0 {
+			s[j+1] = s[j]
+			j--
+		}
+		s[j+1] = key
+	}
+}
+
+// ShellSort sorts s using shell sort with halving gaps. It is not stable.
+// Time Complexity: O(n²) worst case, Space Complexity: O(1)
+func ShellSort[T cmp.Ordered](s []T) {
+	ShellSortFunc(s, cmp.Compare[T])
+}
+
+// ShellSortFunc sorts s using shell sort with a comparator. It is not stable.
+func ShellSortFunc[T any](s []T, compare func(a, b T) int) {
+	n := len(s)
+	// Start with large gap, then reduce
+	for gap := n / 2; gap > 0; gap /= 2 {
+		// Do gapped insertion sort
+		for i := gap; i < n; i++ {
+			temp := s[i]
+			var j int
+
+			// Secure: bounds checking
+			for j = i; j >= gap && compare(s[j-gap], temp) > 0; j -= gap {
+				s[j] = s[j-gap]
+			}
+			s[j] = temp
+		}
+	}
+}
+
+// MergeSort sorts s using top-down merge sort. It is stable.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func MergeSort[T cmp.Ordered](s []T) {
+	MergeSortFunc(s, cmp.Compare[T])
+}
+
+// MergeSortFunc sorts s using merge sort with a comparator. It is stable.
+func MergeSortFunc[T any](s []T, compare func(a, b T) int) {
+	if len(s) <= 1 {
+		return
+	}
+	mergeSort(s, make([]T, len(s)), compare)
+}
+
+// mergeSort sorts s using buf, of the same length, as scratch space
+func mergeSort[T any](s, buf []T, compare func(a, b T) int) {
+	if len(s) <= 1 {
+		return
+	}
+	mid := len(s) / 2
+	mergeSort(s[:mid], buf[:mid], compare)
+	mergeSort(s[mid:], buf[mid:], compare)
+	merge(s, mid, buf, compare)
+}
+
+// merge merges the sorted halves s[:mid] and s[mid:]. Ties are taken from
+// the left half, which keeps the sort stable.
+func merge[T any](s []T, mid int, buf []T, compare func(a, b T) int) {
+	// Already in order: nothing to merge
+	if compare(s[mid-1], s[mid]) <= 0 {
+		return
+	}
+
+	copy(buf, s)
+	i, j, k := 0, mid, 0
+	for i < mid && j < len(s) {
+		if compare(buf[j], buf[i]) < 0 {
+			s[k] = buf[j]
+			j++
+		} else {
+			s[k] = buf[i]
+			i++
+		}
+		k++
+	}
+	k += copy(s[k:], buf[i:mid])
+	copy(s[k:], buf[j:])
+}
+
+// QuickSort sorts s using quick sort with the last element as pivot. It is
+// not stable.
+// Time Complexity: O(n log n) average, O(n²) worst, Space Complexity: O(log n)
+func QuickSort[T cmp.Ordered](s []T) {
+	QuickSortFunc(s, cmp.Compare[T])
+}
+
+// QuickSortFunc sorts s using quick sort with a comparator. It is not stable.
+func QuickSortFunc[T any](s []T, compare func(a, b T) int) {
+	for len(s) > 1 {
+		p := partition(s, compare)
+		// Recurse into the smaller side and loop on the larger one, so
+		// the stack depth stays O(log n)
+		if p < len(s)-p-1 {
+			QuickSortFunc(s[:p], compare)
+			s = s[p+1:]
+		} else {
+			QuickSortFunc(s[p+1:], compare)
+			s = s[:p]
+		}
+	}
+}
+
+// partition partitions s around its last element and returns the pivot's
+// final index (Lomuto scheme)
+func partition[T any](s []T, compare func(a, b T) int) int {
+	high := len(s) - 1
+	pivot := s[high]
+	i := 0
+	for j := 0; j < high; j++ {
+		if compare(s[j], pivot) <= 0 {
+			s[i], s[j] = s[j], s[i]
+			i++
+		}
+	}
+	s[i], s[high] = s[high], s[i]
+	return i
+}
+
+// HeapSort sorts s using heap sort. It is not stable.
+// Time Complexity: O(n log n), Space Complexity: O(1)
+func HeapSort[T cmp.Ordered](s []T) {
+	HeapSortFunc(s, cmp.Compare[T])
+}
+
+// HeapSortFunc sorts s using heap sort with a comparator. It is not stable.
+func HeapSortFunc[T any](s []T, compare func(a, b T) int) {
+	n := len(s)
+	// Build max heap
+	for i := n/2 - 1; i >= 0; i-- {
+		siftDown(s, i, n, compare)
+	}
+
+	// Extract elements from heap
+	for i := n - 1; i > 0; i-- {
+		s[0], s[i] = s[i], s[0]
+		siftDown(s, 0, i, compare)
+	}
+}
+
+// siftDown restores the max-heap property of s[:n] below index i
+func siftDown[T any](s []T, i, n int, compare func(a, b T) int) {
+	for {
+		largest := i
+		left, right := 2*i+1, 2*i+2
+		if left < n && compare(s[left], s[largest]) > 0 {
+			largest = left
+		}
+		if right < n && compare(s[right], s[largest]) > 0 {
+			largest = right
+		}
+		if largest == i {
+			return
+		}
+		s[i], s[largest] = s[largest], s[i]
+		i = largest
+	}
+}
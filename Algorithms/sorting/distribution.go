@@ -0,0 +1,129 @@
+package sorting
+
+import "math"
+
+// maxCountingRange bounds the count table of CountingSort
+const maxCountingRange = 1 << 24
+
+// CountingSort sorts integers by counting the occurrences of each value
+// between the minimum and the maximum, which may be negative. It returns
+// ErrRangeTooLarge, leaving s unchanged, if that range exceeds 2^24 values.
+// Time Complexity: O(n + k), Space Complexity: O(k), k is the value range
+func CountingSort[T Integer](s []T) error {
+	if len(s) <= 1 {
+		return nil
+	}
+
+	lo, hi := s[0], s[0]
+	for _, v := range s {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+
+	// Secure: compute the spread in uint64, where it cannot overflow; sign
+	// extension makes the difference right for signed types too
+	spread := uint64(hi) - uint64(lo)
+	if spread >= maxCountingRange {
+		return ErrRangeTooLarge
+	}
+
+	count := make([]int, spread+1)
+	for _, v := range s {
+		count[uint64(v)-uint64(lo)]++
+	}
+	i := 0
+	for offset, c := range count {
+		v := lo + T(offset)
+		for ; c > 0; c-- {
+			s[i] = v
+			i++
+		}
+	}
+	return nil
+}
+
+// RadixSort sorts integers, including negative ones, with a least
+// significant digit radix sort on bytes. It is stable.
+// Time Complexity: O(w * (n + 256)), w is the size of T in bytes,
+// Space Complexity: O(n)
+func RadixSort[T Integer](s []T) {
+	if len(s) <= 1 {
+		return
+	}
+
+	// Flipping the sign bit maps signed values onto unsigned keys in the
+	// same order
+	var zero T
+	var flip uint64
+	if ^zero < zero {
+		flip = 1 << 63
+	}
+	key := func(v T) uint64 { return uint64(v) ^ flip }
+
+	buf := make([]T, len(s))
+	src, dst := s, buf
+	for shift := 0; shift < 64; shift += 8 {
+		var count [256]int
+		for _, v := range src {
+			count[byte(key(v)>>shift)]++
+		}
+		// A digit shared by every value leaves the order unchanged
+		if count[byte(key(src[0])>>shift)] == len(src) {
+			continue
+		}
+
+		pos := 0
+		for d := range count {
+			count[d], pos = pos, pos+count[d]
+		}
+		for _, v := range src {
+			d := byte(key(v) >> shift)
+			dst[count[d]] = v
+			count[d]++
+		}
+		src, dst = dst, src
+	}
+	if &src[0] != &s[0] {
+		copy(s, src)
+	}
+}
+
+// BucketSort sorts floating-point numbers of any range by spreading them
+// over len(s) buckets between the minimum and the maximum and insertion
+// sorting each bucket. NaNs sort first, as with cmp.Compare. It is stable.
+// Time Complexity: O(n) average for uniform input, O(n²) worst,
+// Space Complexity: O(n)
+func BucketSort[T Float](s []T) {
+	n := len(s)
+	if n <= 1 {
+		return
+	}
+
+	nans := []T{}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range s {
+		if v != v {
+			nans = append(nans, v)
+			continue
+		}
+		lo, hi = min(lo, float64(v)), max(hi, float64(v))
+	}
+
+	buckets := make([][]T, n)
+	for _, v := range s {
+		if v != v {
+			continue
+		}
+		index := 0
+		// Secure: infinities and a zero spread all land in the first bucket
+		if width := hi - lo; width > 0 && !math.IsInf(width, 0) {
+			index = min(int(float64(n)*((float64(v)-lo)/width)), n-1)
+		}
+		buckets[index] = append(buckets[index], v)
+	}
+
+	i := copy(s, nans)
+	for _, bucket := range buckets {
+		InsertionSort(bucket)
+		i += copy(s[i:], bucket)
+	}
+}
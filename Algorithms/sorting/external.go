@@ -0,0 +1,283 @@
+package sorting
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Defaults for ExternalOptions fields left at their zero value
+const (
+	defaultRunMemory = 64 << 20
+	defaultFanIn     = 64
+)
+
+// recordOverhead approximates the memory each record costs beyond its bytes:
+// the slice header that holds it
+const recordOverhead = 24
+
+// ExternalOptions configures ExternalSort. The zero value sorts
+// newline-delimited records bytewise using 64 MiB runs.
+type ExternalOptions struct {
+	Delimiter    byte                  // Record delimiter, '\n' if zero
+	NulDelimited bool                  // Split records on '\x00', overriding Delimiter
+	RunMemory    int                   // Bytes of records sorted in memory per run, 64 MiB if zero
+	FanIn        int                   // Runs merged at once, at least 2; 64 if zero
+	TempDir      string                // Directory for run files, os.TempDir() if empty
+	Compare      func(a, b []byte) int // Record order, bytes.Compare if nil
+}
+
+// ExternalSort sorts the records read from r into w using bounded memory.
+// Records are read until RunMemory bytes are held, sorted and written to a
+// temporary run file; the runs are then merged FanIn at a time with a
+// min-heap, in as many passes as needed. Every record is written followed by
+// the delimiter, including a final record that lacked one. The sort is
+// stable. Run files are removed before ExternalSort returns.
+// Time Complexity: O(n log n), Space Complexity: O(RunMemory) memory and
+// O(n) disk
+func ExternalSort(r io.Reader, w io.Writer, opts ExternalOptions) (err error) {
+	delim := opts.Delimiter
+	if opts.NulDelimited {
+		delim = 0
+	} else if delim == 0 {
+		delim = '\n'
+	}
+	runMemory := opts.RunMemory
+	if runMemory <= 0 {
+		runMemory = defaultRunMemory
+	}
+	fanIn := opts.FanIn
+	if fanIn == 0 {
+		fanIn = defaultFanIn
+	}
+	if fanIn < 2 {
+		return fmt.Errorf("external sort: fan-in %d is less than 2", fanIn)
+	}
+	compare := opts.Compare
+	if compare == nil {
+		compare = bytes.Compare
+	}
+
+	s := &externalSorter{delim: delim, compare: compare, tempDir: opts.TempDir}
+	defer func() {
+		if s.dir != "" {
+			err = errors.Join(err, os.RemoveAll(s.dir))
+		}
+		if err != nil {
+			err = fmt.Errorf("external sort: %w", err)
+		}
+	}()
+
+	// Split the input into sorted runs
+	in := bufio.NewReader(r)
+	records := [][]byte{}
+	size := 0
+	var runs []string
+	for {
+		record, readErr := in.ReadBytes(delim)
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		if len(record) > 0 {
+			record = bytes.TrimSuffix(record, []byte{delim})
+			records = append(records, record)
+			size += len(record) + recordOverhead
+		}
+		if size >= runMemory || (readErr == io.EOF && len(runs) > 0 && len(records) > 0) {
+			run, err := s.writeRun(records)
+			if err != nil {
+				return err
+			}
+			runs = append(runs, run)
+			records, size = records[:0], 0
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	out := bufio.NewWriter(w)
+	// Optimized: input that fits in one run never touches the disk
+	if len(runs) == 0 {
+		SortStableFunc(records, compare)
+		if err := s.writeRecords(out, records); err != nil {
+			return err
+		}
+		return out.Flush()
+	}
+
+	// Merge consecutive groups of runs until one pass can finish the sort;
+	// merging runs in input order keeps the sort stable
+	for len(runs) > fanIn {
+		merged := []string{}
+		for group := range slices.Chunk(runs, fanIn) {
+			run, err := s.mergeToRun(group)
+			if err != nil {
+				return err
+			}
+			merged = append(merged, run)
+		}
+		runs = merged
+	}
+	if err := s.merge(out, runs); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// externalSorter holds the state shared by the phases of ExternalSort
+type externalSorter struct {
+	delim   byte
+	compare func(a, b []byte) int
+	tempDir string
+	dir     string // Directory holding the run files, created on first use
+	runs    int    // Run files created so far, for naming
+}
+
+// create creates a new, empty run file
+func (s *externalSorter) create() (*os.File, error) {
+	if s.dir == "" {
+		dir, err := os.MkdirTemp(s.tempDir, "extsort-")
+		if err != nil {
+			return nil, err
+		}
+		s.dir = dir
+	}
+	s.runs++
+	return os.Create(filepath.Join(s.dir, fmt.Sprintf("run-%06d", s.runs)))
+}
+
+// writeRecords writes each record followed by the delimiter
+func (s *externalSorter) writeRecords(w *bufio.Writer, records [][]byte) error {
+	for _, record := range records {
+		w.Write(record)
+		// A failed write is kept by w and returned by every later one
+		if err := w.WriteByte(s.delim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRun sorts records and writes them to a new run file, returning its path
+func (s *externalSorter) writeRun(records [][]byte) (string, error) {
+	SortStableFunc(records, s.compare)
+	f, err := s.create()
+	if err != nil {
+		return "", err
+	}
+	w := bufio.NewWriter(f)
+	err = s.writeRecords(w, records)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// mergeToRun merges runs into a new run file, returning its path, and
+// removes the merged runs
+func (s *externalSorter) mergeToRun(runs []string) (string, error) {
+	f, err := s.create()
+	if err != nil {
+		return "", err
+	}
+	w := bufio.NewWriter(f)
+	err = s.merge(w, runs)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, run := range runs {
+		os.Remove(run)
+	}
+	return f.Name(), nil
+}
+
+// merge merges the sorted runs into w with a k-way min-heap merge
+func (s *externalSorter) merge(w *bufio.Writer, runs []string) error {
+	readers := make([]*bufio.Reader, len(runs))
+	for i, run := range runs {
+		f, err := os.Open(run)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers[i] = bufio.NewReader(f)
+	}
+
+	// next reads the following record of run i onto the heap
+	q := &runQueue{compare: s.compare}
+	next := func(i int) error {
+		record, err := readers[i].ReadBytes(s.delim)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		heap.Push(q, runItem{record: record[:len(record)-1], run: i})
+		return nil
+	}
+	for i := range readers {
+		if err := next(i); err != nil {
+			return err
+		}
+	}
+
+	for q.Len() > 0 {
+		item := heap.Pop(q).(runItem)
+		w.Write(item.record)
+		if err := w.WriteByte(s.delim); err != nil {
+			return err
+		}
+		if err := next(item.run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runItem is the current record of a run being merged
+type runItem struct {
+	record []byte
+	run    int
+}
+
+// runQueue is a min-heap of run records, for container/heap. Equal records
+// are ordered by run, which keeps the merge stable.
+type runQueue struct {
+	items   []runItem
+	compare func(a, b []byte) int
+}
+
+func (q *runQueue) Len() int { return len(q.items) }
+func (q *runQueue) Less(i, j int) bool {
+	if c := q.compare(q.items[i].record, q.items[j].record); c != 0 {
+		return c < 0
+	}
+	return q.items[i].run < q.items[j].run
+}
+func (q *runQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *runQueue) Push(x any)    { q.items = append(q.items, x.(runItem)) }
+func (q *runQueue) Pop() any {
+	item := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return item
+}
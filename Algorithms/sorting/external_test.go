@@ -0,0 +1,121 @@
+package sorting
+
+import (
+	"bytes"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestExternalSort tests external sorting in memory, with many runs and with
+// several merge passes, against slices.Sort
+func TestExternalSort(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	lines := make([]string, 5000)
+	for i := range lines {
+		lines[i] = strconv.Itoa(rng.IntN(1000)) + strings.Repeat("x", rng.IntN(5))
+	}
+	input := strings.Join(lines, "\n") + "\n"
+	want := slices.Clone(lines)
+	slices.Sort(want)
+	wantOutput := strings.Join(want, "\n") + "\n"
+
+	for _, opts := range []ExternalOptions{
+		{},                            // one run in memory
+		{RunMemory: 4096},             // many runs, one merge pass
+		{RunMemory: 1024, FanIn: 3},   // several merge passes
+		{RunMemory: 1, FanIn: 2},      // one record per run
+		{RunMemory: 50000, FanIn: 64}, // a few runs
+	} {
+		dir := t.TempDir()
+		opts.TempDir = dir
+		var out bytes.Buffer
+		if err := ExternalSort(strings.NewReader(input), &out, opts); err != nil {
+			t.Fatalf("ExternalSort with %+v failed: %v", opts, err)
+		}
+		if out.String() != wantOutput {
+			t.Errorf("ExternalSort with RunMemory %d, FanIn %d: wrong output", opts.RunMemory, opts.FanIn)
+		}
+		// Secure: no run files are left behind
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("ExternalSort with RunMemory %d left %d temporary entries", opts.RunMemory, len(entries))
+		}
+	}
+}
+
+// TestExternalSortRecords tests delimiters, empty records, a missing final
+// delimiter, custom comparators and stability
+func TestExternalSortRecords(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+		opts              ExternalOptions
+	}{
+		{"empty", "", "", ExternalOptions{}},
+		{"no final delimiter", "b\na\nc", "a\nb\nc\n", ExternalOptions{}},
+		{"empty records", "b\n\na\n\n", "\n\na\nb\n", ExternalOptions{RunMemory: 1}},
+		{"comma delimited", "3,1,2,", "1,2,3,", ExternalOptions{Delimiter: ','}},
+		{"NUL delimited", "b\n2\x00a\n1\x00", "a\n1\x00b\n2\x00", ExternalOptions{NulDelimited: true, RunMemory: 1}},
+		{"numeric", "10\n9\n100\n", "9\n10\n100\n", ExternalOptions{Compare: func(a, b []byte) int {
+			x, _ := strconv.Atoi(string(a))
+			y, _ := strconv.Atoi(string(b))
+			return x - y
+		}}},
+		// Records compare by their first byte only, so equal ones keep their
+		// input order across runs and merge passes
+		{"stable", "b1\na1\nb2\na2\nb3\na3\n", "a1\na2\na3\nb1\nb2\nb3\n", ExternalOptions{
+			RunMemory: 1, FanIn: 2, Compare: func(a, b []byte) int { return int(a[0]) - int(b[0]) },
+		}},
+	}
+	for _, tt := range tests {
+		tt.opts.TempDir = t.TempDir()
+		var out bytes.Buffer
+		if err := ExternalSort(strings.NewReader(tt.input), &out, tt.opts); err != nil {
+			t.Errorf("%s: ExternalSort failed: %v", tt.name, err)
+		} else if out.String() != tt.want {
+			t.Errorf("%s: ExternalSort = %q, want %q", tt.name, out.String(), tt.want)
+		}
+	}
+}
+
+// TestExternalSortErrors tests that read, write and option errors are
+// reported and the run files still removed
+func TestExternalSortErrors(t *testing.T) {
+	errRead := errors.New("read failed")
+	dir := t.TempDir()
+	r := iotest.TimeoutReader(strings.NewReader(strings.Repeat("line\n", 10000)))
+	if err := ExternalSort(r, &bytes.Buffer{}, ExternalOptions{RunMemory: 100, TempDir: dir}); !errors.Is(err, iotest.ErrTimeout) {
+		t.Errorf("ExternalSort of a failing reader: got %v, want %v", err, iotest.ErrTimeout)
+	}
+	if err := ExternalSort(iotest.ErrReader(errRead), &bytes.Buffer{}, ExternalOptions{}); !errors.Is(err, errRead) {
+		t.Errorf("ExternalSort of an error reader: got %v, want %v", err, errRead)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("ExternalSort left %d temporary entries after a read error", len(entries))
+	}
+
+	errWrite := errors.New("write failed")
+	input := strings.Repeat("line\n", 10000)
+	if err := ExternalSort(strings.NewReader(input), failingWriter{errWrite}, ExternalOptions{RunMemory: 100, TempDir: dir}); !errors.Is(err, errWrite) {
+		t.Errorf("ExternalSort to a failing writer: got %v, want %v", err, errWrite)
+	}
+
+	if err := ExternalSort(strings.NewReader(input), &bytes.Buffer{}, ExternalOptions{FanIn: 1}); err == nil {
+		t.Error("ExternalSort with a fan-in of 1 should fail")
+	}
+
+	missing := filepath.Join(dir, "missing")
+	if err := ExternalSort(strings.NewReader(input), &bytes.Buffer{}, ExternalOptions{RunMemory: 100, TempDir: missing}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ExternalSort with a missing temporary directory: got %v, want ErrNotExist", err)
+	}
+}
+
+// failingWriter fails every write with err
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
@@ -0,0 +1,98 @@
+package sorting
+
+import (
+	"cmp"
+	"math/bits"
+)
+
+// insertionThreshold is the length below which IntroSort switches to
+// insertion sort, which is faster on short runs
+const insertionThreshold = 12
+
+// IntroSort sorts s with introsort: quick sort with median-of-three (or, for
+// long runs, ninther) pivots and three-way partitioning, switching to heap
+// sort past a recursion depth of 2*log2(n) and to insertion sort for short
+// runs. Sorted, reversed and duplicate-heavy inputs stay O(n log n). It is
+// not stable.
+// Time Complexity: O(n log n), Space Complexity: O(log n)
+func IntroSort[T cmp.Ordered](s []T) {
+	IntroSortFunc(s, cmp.Compare[T])
+}
+
+// IntroSortFunc sorts s with introsort using a comparator. It is not stable.
+func IntroSortFunc[T any](s []T, compare func(a, b T) int) {
+	introSort(s, compare, 2*bits.Len(uint(len(s))))
+}
+
+// introSort sorts s, falling back to heap sort once depth reaches zero
+func introSort[T any](s []T, compare func(a, b T) int, depth int) {
+	for len(s) > insertionThreshold {
+		if depth == 0 {
+			HeapSortFunc(s, compare)
+			return
+		}
+		depth--
+
+		lt, gt := partition3(s, choosePivot(s, compare), compare)
+		// Recurse into the smaller side and loop on the larger one, so
+		// the stack depth stays O(log n)
+		if lt < len(s)-gt {
+			introSort(s[:lt], compare, depth)
+			s = s[gt:]
+		} else {
+			introSort(s[gt:], compare, depth)
+			s = s[:lt]
+		}
+	}
+	InsertionSortFunc(s, compare)
+}
+
+// choosePivot returns the index of the median of three elements, or for
+// long slices the median of three such medians (Tukey's ninther)
+func choosePivot[T any](s []T, compare func(a, b T) int) int {
+	n := len(s)
+	a, b, c := 0, n/2, n-1
+	if n > 128 {
+		step := n / 8
+		a = median3(s, a, a+step, a+2*step, compare)
+		b = median3(s, b-step, b, b+step, compare)
+		c = median3(s, c-2*step, c-step, c, compare)
+	}
+	return median3(s, a, b, c, compare)
+}
+
+// median3 returns whichever of the indices i, j and k holds the median value
+func median3[T any](s []T, i, j, k int, compare func(a, b T) int) int {
+	if compare(s[j], s[i]) < 0 {
+		i, j = j, i
+	}
+	if compare(s[k], s[j]) < 0 {
+		j = k
+		if compare(s[j], s[i]) < 0 {
+			j = i
+		}
+	}
+	return j
+}
+
+// partition3 rearranges s into elements less than, equal to and greater
+// than s[pivot] (Dijkstra's Dutch national flag) and returns the bounds of
+// the equal run: s[lt:gt]
+func partition3[T any](s []T, pivot int, compare func(a, b T) int) (lt, gt int) {
+	p := s[pivot]
+	lt, i, gt := 0, 0, len(s)
+	for i < gt {
+		switch c := compare(s[i], p); {
+		case c < 0:
+			s[lt], s[i] = s[i], s[lt]
+			lt++
+			i++
+		case c > 0:
+			gt--
+			s[i], s[gt] = s[gt], s[i]
+		default:
+			i++
+		}
+	}
+	return lt, gt
+}
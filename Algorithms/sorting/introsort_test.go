@@ -0,0 +1,104 @@
+package sorting
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// patterns generates inputs that defeat naive pivot choices
+var patterns = []struct {
+	name string
+	make func(n int) []int
+}{
+	{"Random", func(n int) []int {
+		rng := rand.New(rand.NewPCG(1, uint64(n)))
+		s := make([]int, n)
+		for i := range s {
+			s[i] = rng.Int()
+		}
+		return s
+	}},
+	{"Sorted", func(n int) []int {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = i
+		}
+		return s
+	}},
+	{"Reversed", func(n int) []int {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = n - i
+		}
+		return s
+	}},
+	{"FewUnique", func(n int) []int {
+		rng := rand.New(rand.NewPCG(2, uint64(n)))
+		s := make([]int, n)
+		for i := range s {
+			s[i] = rng.IntN(4)
+		}
+		return s
+	}},
+	{"OrganPipe", func(n int) []int {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = min(i, n-i)
+		}
+		return s
+	}},
+}
+
+// TestIntroSortPatterns tests introsort on adversarial inputs, and the heap
+// sort fallback by starting with no depth to spare
+func TestIntroSortPatterns(t *testing.T) {
+	for _, p := range patterns {
+		for _, n := range []int{0, 1, 12, 13, 100, 10000} {
+			s := p.make(n)
+			want := slices.Clone(s)
+			slices.Sort(want)
+
+			got := slices.Clone(s)
+			IntroSort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("IntroSort of %s input of %d: wrong order", p.name, n)
+			}
+
+			got = slices.Clone(s)
+			introSort(got, func(a, b int) int { return a - b }, 0)
+			if !slices.Equal(got, want) {
+				t.Errorf("Heap sort fallback on %s input of %d: wrong order", p.name, n)
+			}
+		}
+	}
+}
+
+// benchmarkSort runs sort over every input pattern at size n
+func benchmarkSort(b *testing.B, n int, sortFn func([]int)) {
+	for _, p := range patterns {
+		input := p.make(n)
+		s := make([]int, n)
+		b.Run(fmt.Sprintf("%s/%d", p.name, n), func(b *testing.B) {
+			for b.Loop() {
+				copy(s, input)
+				sortFn(s)
+			}
+		})
+	}
+}
+
+func BenchmarkIntroSort(b *testing.B)  { benchmarkSort(b, 100000, IntroSort[int]) }
+func BenchmarkHeapSort(b *testing.B)   { benchmarkSort(b, 100000, HeapSort[int]) }
+func BenchmarkMergeSort(b *testing.B)  { benchmarkSort(b, 100000, MergeSort[int]) }
+func BenchmarkSlicesSort(b *testing.B) { benchmarkSort(b, 100000, slices.Sort[[]int]) }
+func BenchmarkSortInts(b *testing.B)   { benchmarkSort(b, 100000, sort.Ints) }
+func BenchmarkRadixSort(b *testing.B)  { benchmarkSort(b, 100000, RadixSort[int]) }
+func BenchmarkShellSort(b *testing.B)  { benchmarkSort(b, 100000, ShellSort[int]) }
+
+// The last-element pivot of QuickSort is quadratic on sorted and
+// duplicate-heavy input, so it is measured on smaller inputs
+func BenchmarkQuickSort(b *testing.B)      { benchmarkSort(b, 10000, QuickSort[int]) }
+func BenchmarkIntroSortSmall(b *testing.B) { benchmarkSort(b, 10000, IntroSort[int]) }
@@ -0,0 +1,77 @@
+package sorting
+
+import (
+	"cmp"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// parallelCutoff is the length below which the parallel sorts sort
+// sequentially, where starting a goroutine costs more than it saves
+const parallelCutoff = 1 << 13
+
+// ParallelMergeSort sorts s with merge sort, sorting the halves in separate
+// goroutines up to runtime.NumCPU() at a time. The result is identical to
+// MergeSort. It is stable.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func ParallelMergeSort[T cmp.Ordered](s []T) {
+	ParallelMergeSortFunc(s, cmp.Compare[T])
+}
+
+// ParallelMergeSortFunc sorts s with parallel merge sort using a comparator.
+// It is stable.
+func ParallelMergeSortFunc[T any](s []T, compare func(a, b T) int) {
+	if len(s) <= 1 {
+		return
+	}
+	parallelMergeSort(s, make([]T, len(s)), compare, runtime.NumCPU())
+}
+
+// parallelMergeSort sorts s using buf as scratch space, splitting procs
+// goroutines between the halves
+func parallelMergeSort[T any](s, buf []T, compare func(a, b T) int, procs int) {
+	if procs <= 1 || len(s) < parallelCutoff {
+		mergeSort(s, buf, compare)
+		return
+	}
+	mid := len(s) / 2
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		parallelMergeSort(s[:mid], buf[:mid], compare, procs/2)
+	})
+	parallelMergeSort(s[mid:], buf[mid:], compare, procs-procs/2)
+	wg.Wait()
+	merge(s, mid, buf, compare)
+}
+
+// ParallelQuickSort sorts s with introsort, sorting both sides of each
+// partition in separate goroutines up to runtime.NumCPU() at a time. The
+// pivots are chosen as in IntroSort, so the result is identical to it. It
+// is not stable.
+// Time Complexity: O(n log n), Space Complexity: O(log n)
+func ParallelQuickSort[T cmp.Ordered](s []T) {
+	ParallelQuickSortFunc(s, cmp.Compare[T])
+}
+
+// ParallelQuickSortFunc sorts s with parallel introsort using a comparator.
+// It is not stable.
+func ParallelQuickSortFunc[T any](s []T, compare func(a, b T) int) {
+	parallelQuickSort(s, compare, 2*bits.Len(uint(len(s))), runtime.NumCPU())
+}
+
+// parallelQuickSort partitions s and sorts the sides concurrently, splitting
+// procs goroutines between them
+func parallelQuickSort[T any](s []T, compare func(a, b T) int, depth, procs int) {
+	if procs <= 1 || depth == 0 || len(s) < parallelCutoff {
+		introSort(s, compare, depth)
+		return
+	}
+	lt, gt := partition3(s, choosePivot(s, compare), compare)
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		parallelQuickSort(s[:lt], compare, depth-1, procs/2)
+	})
+	parallelQuickSort(s[gt:], compare, depth-1, procs-procs/2)
+	wg.Wait()
+}
@@ -0,0 +1,59 @@
+package sorting
+
+import (
+	"cmp"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestParallelSorts tests that the parallel sorts give exactly the results
+// of their serial versions, above and below the sequential cutoff. The
+// internal functions are called with several procs so that the goroutines
+// run even on a single CPU.
+func TestParallelSorts(t *testing.T) {
+	byKey := func(a, b record) int { return cmp.Compare(a.key, b.key) }
+	rng := rand.New(rand.NewPCG(5, 6))
+	for _, n := range []int{0, 1, 100, parallelCutoff - 1, parallelCutoff, 100000} {
+		input := randomRecords(rng, n)
+
+		want := slices.Clone(input)
+		MergeSortFunc(want, byKey)
+		for _, procs := range []int{1, 3, 8} {
+			got := slices.Clone(input)
+			parallelMergeSort(got, make([]record, n), byKey, procs)
+			if !slices.Equal(got, want) {
+				t.Errorf("Parallel merge sort of %d records on %d procs differs from MergeSortFunc", n, procs)
+			}
+		}
+
+		want = slices.Clone(input)
+		IntroSortFunc(want, byKey)
+		for _, procs := range []int{1, 3, 8} {
+			got := slices.Clone(input)
+			parallelQuickSort(got, byKey, 2*bits.Len(uint(n)), procs)
+			if !slices.Equal(got, want) {
+				t.Errorf("Parallel quick sort of %d records on %d procs differs from IntroSortFunc", n, procs)
+			}
+		}
+	}
+
+	for _, p := range patterns {
+		s := p.make(100000)
+		want := slices.Clone(s)
+		slices.Sort(want)
+		for name, sort := range map[string]func([]int){
+			"ParallelMergeSort": ParallelMergeSort[int], "ParallelQuickSort": ParallelQuickSort[int],
+		} {
+			got := slices.Clone(s)
+			sort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("%s of %s input: wrong order", name, p.name)
+			}
+		}
+	}
+}
+
+func BenchmarkParallelMergeSort(b *testing.B) { benchmarkSort(b, 100000, ParallelMergeSort[int]) }
+func BenchmarkParallelQuickSort(b *testing.B) { benchmarkSort(b, 100000, ParallelQuickSort[int]) }
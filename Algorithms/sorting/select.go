@@ -0,0 +1,161 @@
+package sorting
+
+import (
+	"cmp"
+	"math/bits"
+)
+
+// QuickSelect returns the k-th smallest element of s, counting from zero. It
+// rearranges s so that s[k] holds that element, with no greater element
+// before it and no smaller one after it. Pivots are chosen as in IntroSort;
+// past a depth of 2*log2(n) it switches to median-of-medians pivots, which
+// bounds the worst case (introselect). It returns ErrIndexRange, leaving s
+// unchanged, if k is outside s.
+// Time Complexity: O(n), Space Complexity: O(log n)
+func QuickSelect[T cmp.Ordered](s []T, k int) (T, error) {
+	return QuickSelectFunc(s, k, cmp.Compare[T])
+}
+
+// QuickSelectFunc returns the k-th smallest element of s in the order
+// defined by compare
+func QuickSelectFunc[T any](s []T, k int, compare func(a, b T) int) (T, error) {
+	// Secure: bounds checking
+	if k < 0 || k >= len(s) {
+		var zero T
+		return zero, ErrIndexRange
+	}
+	selectKth(s, k, compare, 2*bits.Len(uint(len(s))))
+	return s[k], nil
+}
+
+// MedianOfMedians returns the k-th smallest element of s like QuickSelect,
+// but always picks the pivot as the median of the medians of groups of
+// five, which guarantees linear time at the cost of a larger constant.
+// Time Complexity: O(n), Space Complexity: O(log n)
+func MedianOfMedians[T cmp.Ordered](s []T, k int) (T, error) {
+	return MedianOfMediansFunc(s, k, cmp.Compare[T])
+}
+
+// MedianOfMediansFunc returns the k-th smallest element of s in the order
+// defined by compare using median-of-medians pivots
+func MedianOfMediansFunc[T any](s []T, k int, compare func(a, b T) int) (T, error) {
+	// Secure: bounds checking
+	if k < 0 || k >= len(s) {
+		var zero T
+		return zero, ErrIndexRange
+	}
+	selectKth(s, k, compare, 0)
+	return s[k], nil
+}
+
+// selectKth moves the k-th smallest element of s to s[k], choosing
+// median-of-medians pivots once depth reaches zero
+func selectKth[T any](s []T, k int, compare func(a, b T) int, depth int) {
+	for len(s) > insertionThreshold {
+		var pivot int
+		if depth > 0 {
+			pivot = choosePivot(s, compare)
+			depth--
+		} else {
+			pivot = medianOfMedians(s, compare)
+		}
+
+		// Narrow down to the side holding index k
+		lt, gt := partition3(s, pivot, compare)
+		switch {
+		case k < lt:
+			s = s[:lt]
+		case k >= gt:
+			s, k = s[gt:], k-gt
+		default:
+			return
+		}
+	}
+	InsertionSortFunc(s, compare)
+}
+
+// medianOfMedians moves the median of each group of five elements to the
+// front of s and returns the index of the median of those medians
+func medianOfMedians[T any](s []T, compare func(a, b T) int) int {
+	groups := 0
+	for i := 0; i < len(s); i += 5 {
+		group := s[i:min(i+5, len(s))]
+		InsertionSortFunc(group, compare)
+		s[groups], group[len(group)/2] = group[len(group)/2], s[groups]
+		groups++
+	}
+	selectKth(s[:groups], groups/2, compare, 0)
+	return groups / 2
+}
+
+// TopK keeps the k largest values pushed to it, using a min-heap of size k
+// whose root is the smallest value kept
+type TopK[T any] struct {
+	k       int
+	heap    []T
+	compare func(a, b T) int
+}
+
+// NewTopK creates a TopK keeping the k largest of a stream of ordered values
+func NewTopK[T cmp.Ordered](k int) *TopK[T] {
+	return NewTopKFunc(k, cmp.Compare[T])
+}
+
+// NewTopKFunc creates a TopK keeping the k largest values in the order
+// defined by compare. A k below zero keeps nothing.
+func NewTopKFunc[T any](k int, compare func(a, b T) int) *TopK[T] {
+	k = max(k, 0)
+	return &TopK[T]{k: k, heap: make([]T, 0, min(k, 1024)), compare: compare}
+}
+
+// Len returns the number of values kept, at most k
+func (t *TopK[T]) Len() int {
+	return len(t.heap)
+}
+
+// Push offers v to t, which keeps it if it is among the k largest so far.
+// Time Complexity: O(log k)
+func (t *TopK[T]) Push(v T) {
+	// Reversing the comparison turns the max-heap helpers into a min-heap
+	reversed := func(a, b T) int { return t.compare(b, a) }
+	if len(t.heap) < t.k {
+		t.heap = append(t.heap, v)
+		siftUp(t.heap, len(t.heap)-1, reversed)
+		return
+	}
+	if t.k > 0 && t.compare(v, t.heap[0]) > 0 {
+		t.heap[0] = v
+		siftDown(t.heap, 0, len(t.heap), reversed)
+	}
+}
+
+// Min returns the smallest value kept, which a value must exceed to enter a
+// full TopK, and false if t is empty
+func (t *TopK[T]) Min() (T, bool) {
+	if len(t.heap) == 0 {
+		var zero T
+		return zero, false
+	}
+	return t.heap[0], true
+}
+
+// Sorted returns the values kept, largest first
+// Time Complexity: O(k log k), Space Complexity: O(k)
+func (t *TopK[T]) Sorted() []T {
+	values := make([]T, len(t.heap))
+	copy(values, t.heap)
+	IntroSortFunc(values, func(a, b T) int { return t.compare(b, a) })
+	return values
+}
+
+// siftUp restores the max-heap property of s above index i
+func siftUp[T any](s []T, i int, compare func(a, b T) int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if compare(s[i], s[parent]) <= 0 {
+			return
+		}
+		s[i], s[parent] = s[parent], s[i]
+		i = parent
+	}
+}
@@ -0,0 +1,104 @@
+package sorting
+
+import (
+	"cmp"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestSelection tests QuickSelect and MedianOfMedians against a sorted copy,
+// including the partial order they leave around index k
+func TestSelection(t *testing.T) {
+	selections := map[string]func([]int, int) (int, error){
+		"QuickSelect": QuickSelect[int], "MedianOfMedians": MedianOfMedians[int],
+	}
+	for _, p := range patterns {
+		for _, n := range []int{1, 2, 12, 13, 100, 5000} {
+			input := p.make(n)
+			sorted := slices.Clone(input)
+			slices.Sort(sorted)
+			for name, sel := range selections {
+				for _, k := range []int{0, n / 3, n / 2, n - 1} {
+					s := slices.Clone(input)
+					got, err := sel(s, k)
+					if err != nil || got != sorted[k] {
+						t.Errorf("%s(%s input of %d, %d) = %d, %v, want %d", name, p.name, n, k, got, err, sorted[k])
+						continue
+					}
+					if slices.Max(s[:k+1]) != got || slices.Min(s[k:]) != got {
+						t.Errorf("%s(%s input of %d, %d): not partitioned around k", name, p.name, n, k)
+					}
+				}
+			}
+		}
+	}
+
+	// The introselect fallback is exercised by starting with no depth
+	rng := rand.New(rand.NewPCG(9, 10))
+	s := make([]int, 1000)
+	for i := range s {
+		s[i] = rng.IntN(100)
+	}
+	sorted := slices.Clone(s)
+	slices.Sort(sorted)
+	byValue := func(a, b int) int { return cmp.Compare(a, b) }
+	for k := range s {
+		selectKth(s, k, byValue, 0)
+		if s[k] != sorted[k] {
+			t.Fatalf("selectKth with no depth, k = %d: got %d, want %d", k, s[k], sorted[k])
+		}
+	}
+
+	for name, sel := range selections {
+		for _, k := range []int{-1, 3} {
+			if _, err := sel([]int{1, 2, 3}, k); !errors.Is(err, ErrIndexRange) {
+				t.Errorf("%s with k = %d: got %v, want ErrIndexRange", name, k, err)
+			}
+		}
+		if _, err := sel(nil, 0); !errors.Is(err, ErrIndexRange) {
+			t.Errorf("%s of an empty slice: got %v, want ErrIndexRange", name, err)
+		}
+	}
+}
+
+// TestTopK tests streaming top-k against sorting the whole stream
+func TestTopK(t *testing.T) {
+	rng := rand.New(rand.NewPCG(11, 12))
+	stream := make([]int, 10000)
+	for i := range stream {
+		stream[i] = rng.IntN(5000)
+	}
+	want := slices.Clone(stream)
+	slices.SortFunc(want, func(a, b int) int { return b - a })
+
+	for _, k := range []int{0, 1, 10, 500, 20000} {
+		top := NewTopK[int](k)
+		for _, v := range stream {
+			top.Push(v)
+		}
+		n := min(k, len(stream))
+		if top.Len() != n || !slices.Equal(top.Sorted(), want[:n]) {
+			t.Errorf("TopK(%d): got %d values, wrong or misordered", k, top.Len())
+		}
+		if smallest, ok := top.Min(); ok != (n > 0) || (ok && smallest != want[n-1]) {
+			t.Errorf("TopK(%d).Min() = %d, %v", k, smallest, ok)
+		}
+	}
+
+	// A reversed comparator keeps the k smallest
+	bottom := NewTopKFunc(3, func(a, b string) int { return cmp.Compare(b, a) })
+	for _, w := range []string{"pear", "fig", "apple", "kiwi", "date"} {
+		bottom.Push(w)
+	}
+	if got := bottom.Sorted(); !slices.Equal(got, []string{"apple", "date", "fig"}) {
+		t.Errorf("TopKFunc smallest 3 = %v", got)
+	}
+
+	negative := NewTopK[int](-1)
+	negative.Push(1)
+	if negative.Len() != 0 {
+		t.Error("TopK with negative k kept a value")
+	}
+}
@@ -0,0 +1,76 @@
+// Package sorting provides generic sorting and selection algorithms. Each
+// comparison sort comes in two forms: XxxSort for cmp.Ordered element types
+// and XxxSortFunc taking a comparator that returns a negative number, zero
+// or a positive number as a is less than, equal to or greater than b, like
+// cmp.Compare.
+//
+// A stable sort keeps equal elements in their original order. Stable:
+// BubbleSort, InsertionSort, MergeSort, ParallelMergeSort, CountingSort,
+// RadixSort, BucketSort and SortStable. Not stable: SelectionSort,
+// ShellSort, QuickSort, HeapSort, IntroSort, ParallelQuickSort and Sort.
+package sorting
+
+import (
+	"cmp"
+	"errors"
+)
+
+// Integer constraint for the distribution sorts
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float constraint for BucketSort
+type Float interface {
+	~float32 | ~float64
+}
+
+var (
+	// ErrRangeTooLarge is returned by CountingSort when the spread of the
+	// values would need too large a count table
+	ErrRangeTooLarge = errors.New("value range too large for counting sort")
+	// ErrIndexRange is returned by the selection algorithms when k is not an
+	// index of the slice
+	ErrIndexRange = errors.New("index out of range")
+)
+
+// Sort sorts s in ascending order using IntroSort. It is not stable.
+// Time Complexity: O(n log n), Space Complexity: O(log n)
+func Sort[T cmp.Ordered](s []T) {
+	IntroSortFunc(s, cmp.Compare[T])
+}
+
+// SortFunc sorts s in the order defined by compare using IntroSort. It is
+// not stable.
+func SortFunc[T any](s []T, compare func(a, b T) int) {
+	IntroSortFunc(s, compare)
+}
+
+// SortStable sorts s in ascending order, keeping equal elements in their
+// original order.
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func SortStable[T cmp.Ordered](s []T) {
+	MergeSortFunc(s, cmp.Compare[T])
+}
+
+// SortStableFunc sorts s in the order defined by compare, keeping equal
+// elements in their original order
+func SortStableFunc[T any](s []T, compare func(a, b T) int) {
+	MergeSortFunc(s, compare)
+}
+
+// IsSorted reports whether s is in ascending order
+func IsSorted[T cmp.Ordered](s []T) bool {
+	return IsSortedFunc(s, cmp.Compare[T])
+}
+
+// IsSortedFunc reports whether s is in the order defined by compare
+func IsSortedFunc[T any](s []T, compare func(a, b T) int) bool {
+	for i := 1; i < len(s); i++ {
+		if compare(s[i], s[i-1]) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package sorting
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// comparisonSorts lists the comparison sorts and whether each is stable
+var comparisonSorts = []struct {
+	name   string
+	sort   func([]record, func(a, b record) int)
+	stable bool
+}{
+	{"BubbleSort", BubbleSortFunc[record], true},
+	{"SelectionSort", SelectionSortFunc[record], false},
+	{"InsertionSort", InsertionSortFunc[record], true},
+	{"ShellSort", ShellSortFunc[record], false},
+	{"MergeSort", MergeSortFunc[record], true},
+	{"QuickSort", QuickSortFunc[record], false},
+	{"HeapSort", HeapSortFunc[record], false},
+	{"IntroSort", IntroSortFunc[record], false},
+	{"Sort", SortFunc[record], false},
+	{"SortStable", SortStableFunc[record], true},
+}
+
+// record is a key with its original position, for checking stability
+type record struct {
+	key, pos int
+}
+
+// randomRecords returns n records with keys drawn from a small range, so
+// that many are equal
+func randomRecords(rng *rand.Rand, n int) []record {
+	records := make([]record, n)
+	for i := range records {
+		records[i] = record{key: rng.IntN(n/4 + 1), pos: i}
+	}
+	return records
+}
+
+// TestComparisonSorts tests every comparison sort against slices.SortStableFunc
+func TestComparisonSorts(t *testing.T) {
+	byKey := func(a, b record) int { return cmp.Compare(a.key, b.key) }
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, n := range []int{0, 1, 2, 3, 10, 100, 1000} {
+		input := randomRecords(rng, n)
+		want := slices.Clone(input)
+		slices.SortStableFunc(want, byKey)
+
+		for _, tt := range comparisonSorts {
+			got := slices.Clone(input)
+			tt.sort(got, byKey)
+			if !IsSortedFunc(got, byKey) {
+				t.Errorf("%s of %d records: not sorted", tt.name, n)
+			}
+			if tt.stable && !slices.Equal(got, want) {
+				t.Errorf("%s of %d records: not stable", tt.name, n)
+			}
+		}
+	}
+
+	// The Ordered forms agree with the comparator forms
+	ints := []int{5, -3, 9, 0, -3, 7}
+	for name, sort := range map[string]func([]int){
+		"BubbleSort": BubbleSort[int], "SelectionSort": SelectionSort[int], "InsertionSort": InsertionSort[int],
+		"ShellSort": ShellSort[int], "MergeSort": MergeSort[int], "QuickSort": QuickSort[int],
+		"HeapSort": HeapSort[int], "IntroSort": IntroSort[int], "Sort": Sort[int], "SortStable": SortStable[int],
+	} {
+		got := slices.Clone(ints)
+		sort(got)
+		if !slices.Equal(got, []int{-3, -3, 0, 5, 7, 9}) {
+			t.Errorf("%s = %v", name, got)
+		}
+	}
+}
+
+// TestCountingSort tests counting sort over negative values and its range limit
+func TestCountingSort(t *testing.T) {
+	s := []int{4, -2, 2, 8, -3, 3, 1, -2}
+	if err := CountingSort(s); err != nil {
+		t.Fatalf("CountingSort failed: %v", err)
+	}
+	if !slices.Equal(s, []int{-3, -2, -2, 1, 2, 3, 4, 8}) {
+		t.Errorf("CountingSort = %v", s)
+	}
+
+	bytes := []int8{127, -128, 0, -1, 1}
+	if err := CountingSort(bytes); err != nil || !slices.Equal(bytes, []int8{-128, -1, 0, 1, 127}) {
+		t.Errorf("CountingSort of int8 = %v, %v", bytes, err)
+	}
+
+	wide := []int64{math.MinInt64, math.MaxInt64}
+	if err := CountingSort(wide); !errors.Is(err, ErrRangeTooLarge) {
+		t.Errorf("CountingSort of the full int64 range: got %v, want ErrRangeTooLarge", err)
+	}
+}
+
+// TestRadixSort tests radix sort over signed and unsigned types
+func TestRadixSort(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	ints := make([]int, 1000)
+	for i := range ints {
+		ints[i] = int(rng.Uint64())
+	}
+	ints = append(ints, math.MinInt, math.MaxInt, 0, -1)
+	want := slices.Clone(ints)
+	slices.Sort(want)
+	RadixSort(ints)
+	if !slices.Equal(ints, want) {
+		t.Error("RadixSort of int differs from slices.Sort")
+	}
+
+	small := []int16{300, -300, 5, -5, 0, math.MinInt16}
+	RadixSort(small)
+	if !slices.Equal(small, []int16{math.MinInt16, -300, -5, 0, 5, 300}) {
+		t.Errorf("RadixSort of int16 = %v", small)
+	}
+
+	unsigned := []uint32{math.MaxUint32, 0, 1 << 31, 7}
+	RadixSort(unsigned)
+	if !slices.Equal(unsigned, []uint32{0, 7, 1 << 31, math.MaxUint32}) {
+		t.Errorf("RadixSort of uint32 = %v", unsigned)
+	}
+}
+
+// TestBucketSort tests bucket sort outside [0, 1), with infinities and NaNs
+func TestBucketSort(t *testing.T) {
+	s := []float64{0.897, -12.5, 0.656, 100, 0.1234, 0.665, -0.3434, 0.897}
+	want := slices.Clone(s)
+	slices.Sort(want)
+	BucketSort(s)
+	if !slices.Equal(s, want) {
+		t.Errorf("BucketSort = %v, want %v", s, want)
+	}
+
+	special := []float64{1, math.Inf(1), math.NaN(), -2, math.Inf(-1)}
+	BucketSort(special)
+	if !math.IsNaN(special[0]) || !slices.Equal(special[1:], []float64{math.Inf(-1), -2, 1, math.Inf(1)}) {
+		t.Errorf("BucketSort with special values = %v", special)
+	}
+}
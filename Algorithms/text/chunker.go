@@ -0,0 +1,157 @@
+package text
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// Defaults for ChunkerOptions fields left at zero
+const (
+	defaultChunkMin    = 2 << 10
+	defaultChunkAvg    = 8 << 10
+	defaultChunkMax    = 64 << 10
+	defaultChunkWindow = 48
+)
+
+// ChunkerOptions configures a Chunker. The zero value gives chunks of 2 KiB
+// to 64 KiB, averaging about 8 KiB, cut on a 48-byte window.
+type ChunkerOptions struct {
+	MinSize int // Smallest chunk but the last, at least Window
+	AvgSize int // Target average chunk size, rounded down to a power of two
+	MaxSize int // Largest chunk
+	Window  int // Bytes hashed to decide each cut
+}
+
+// Chunk is a piece of the input of a Chunker
+type Chunk struct {
+	Offset int64  // Position of the chunk in the input
+	Data   []byte // The chunk's bytes, owned by the caller
+}
+
+// Chunker splits a stream into content-defined chunks: it cuts wherever the
+// RollingHash of the last Window bytes has its low bits zero, so that a cut
+// depends only on nearby content. An insertion or deletion then changes the
+// chunks around it but not the ones after, which lets identical data be
+// found by chunk hash for deduplication. As in FastCDC, cuts are made
+// harder before AvgSize and easier after it, which narrows the spread of
+// chunk sizes.
+type Chunker struct {
+	r          io.Reader
+	opts       ChunkerOptions
+	hash       *RollingHash
+	maskStrict uint64 // Cut condition before AvgSize bytes
+	maskLoose  uint64 // Cut condition from AvgSize bytes on
+	buf        []byte
+	start, end int // Unread bytes are buf[start:end]
+	offset     int64
+	err        error // Error ending the input, io.EOF when it ran out
+}
+
+// NewChunker creates a Chunker reading from r. It returns an error if the
+// options are inconsistent.
+func NewChunker(r io.Reader, opts ChunkerOptions) (*Chunker, error) {
+	if opts.Window == 0 {
+		opts.Window = defaultChunkWindow
+	}
+	if opts.MinSize == 0 {
+		opts.MinSize = defaultChunkMin
+	}
+	if opts.AvgSize == 0 {
+		opts.AvgSize = defaultChunkAvg
+	}
+	if opts.MaxSize == 0 {
+		opts.MaxSize = defaultChunkMax
+	}
+	// Secure: validate the sizes, so that every chunk is well formed
+	if opts.Window < 1 || opts.MinSize < opts.Window || opts.AvgSize < opts.MinSize || opts.MaxSize < opts.AvgSize {
+		return nil, fmt.Errorf("chunker: need 1 <= Window <= MinSize <= AvgSize <= MaxSize, have %d, %d, %d, %d",
+			opts.Window, opts.MinSize, opts.AvgSize, opts.MaxSize)
+	}
+
+	// A cut on k zero bits comes every 2^k bytes on average
+	avgBits := bits.Len(uint(opts.AvgSize)) - 1
+	return &Chunker{
+		r:          r,
+		opts:       opts,
+		hash:       NewRollingHash(opts.Window),
+		maskStrict: 1<<(avgBits+1) - 1,
+		maskLoose:  1<<max(avgBits-1, 0) - 1,
+		buf:        make([]byte, opts.MaxSize),
+	}, nil
+}
+
+// Next returns the next chunk, or io.EOF once the input is exhausted. Every
+// chunk but the last is between MinSize and MaxSize bytes.
+func (c *Chunker) Next() (Chunk, error) {
+	if err := c.fill(); err != nil {
+		return Chunk{}, err
+	}
+	if c.start == c.end {
+		return Chunk{}, c.err
+	}
+
+	n := c.cut(c.buf[c.start:c.end])
+	chunk := Chunk{Offset: c.offset, Data: append([]byte(nil), c.buf[c.start:c.start+n]...)}
+	c.start += n
+	c.offset += int64(n)
+	return chunk, nil
+}
+
+// maxEmptyReads is how many reads in a row may return no data and no error
+// before Chunker gives up with io.ErrNoProgress, as bufio does
+const maxEmptyReads = 100
+
+// fill reads until MaxSize bytes are buffered or the input ends, and
+// returns the error that ended the input unless it is io.EOF
+func (c *Chunker) fill() error {
+	if c.err == nil && c.end-c.start < c.opts.MaxSize {
+		// Move the unread bytes to the front to make room
+		c.end = copy(c.buf, c.buf[c.start:c.end])
+		c.start = 0
+	}
+	for empty := 0; c.err == nil && c.end < len(c.buf); {
+		n, err := c.r.Read(c.buf[c.end:])
+		c.end += n
+		switch {
+		case err != nil:
+			c.err = err
+		case n > 0:
+			empty = 0
+		default:
+			if empty++; empty == maxEmptyReads {
+				c.err = io.ErrNoProgress
+			}
+		}
+	}
+	if c.err != io.EOF {
+		return c.err
+	}
+	return nil
+}
+
+// cut returns the length of the chunk at the start of data: the first cut
+// point at or after MinSize, or MaxSize, or all of data if shorter
+func (c *Chunker) cut(data []byte) int {
+	if len(data) <= c.opts.MinSize {
+		return len(data)
+	}
+	limit := min(len(data), c.opts.MaxSize)
+
+	// The hash at a cut point depends only on the Window bytes before it
+	c.hash.Reset()
+	for i := c.opts.MinSize - c.opts.Window; i < limit; i++ {
+		h := c.hash.Roll(data[i])
+		if i+1 < c.opts.MinSize {
+			continue
+		}
+		mask := c.maskStrict
+		if i+1 >= c.opts.AvgSize {
+			mask = c.maskLoose
+		}
+		if h&mask == 0 {
+			return i + 1
+		}
+	}
+	return limit
+}
@@ -0,0 +1,143 @@
+package text
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"testing"
+	"testing/iotest"
+)
+
+// chunkAll returns every chunk of data
+func chunkAll(t *testing.T, r io.Reader, opts ChunkerOptions) []Chunk {
+	t.Helper()
+	c, err := NewChunker(r, opts)
+	if err != nil {
+		t.Fatalf("NewChunker failed: %v", err)
+	}
+	chunks := []Chunk{}
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+// randomBytes returns n pseudo-random bytes
+func randomBytes(rng *rand.Rand, n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(rng.Uint32())
+	}
+	return data
+}
+
+// TestChunker tests that chunks cover the input in order within the size
+// limits, whatever the reads deliver
+func TestChunker(t *testing.T) {
+	rng := rand.New(rand.NewPCG(11, 12))
+	data := randomBytes(rng, 1<<20)
+	opts := ChunkerOptions{}
+
+	for name, r := range map[string]io.Reader{
+		"whole":     bytes.NewReader(data),
+		"one byte":  iotest.OneByteReader(bytes.NewReader(data)),
+		"half":      iotest.HalfReader(bytes.NewReader(data)),
+		"data, EOF": iotest.DataErrReader(bytes.NewReader(data)),
+	} {
+		chunks := chunkAll(t, r, opts)
+		var joined []byte
+		for i, chunk := range chunks {
+			if chunk.Offset != int64(len(joined)) {
+				t.Fatalf("%s: chunk %d at offset %d, want %d", name, i, chunk.Offset, len(joined))
+			}
+			if n := len(chunk.Data); n > defaultChunkMax || (n < defaultChunkMin && i < len(chunks)-1) {
+				t.Errorf("%s: chunk %d has %d bytes", name, i, n)
+			}
+			joined = append(joined, chunk.Data...)
+		}
+		if !bytes.Equal(joined, data) {
+			t.Fatalf("%s: chunks do not rebuild the input", name)
+		}
+		// Content-defined: the boundaries do not depend on the reads
+		if want := chunkAll(t, bytes.NewReader(data), opts); len(chunks) != len(want) {
+			t.Errorf("%s: %d chunks, want %d", name, len(chunks), len(want))
+		}
+		// Normalized chunking keeps the average near AvgSize
+		if avg := len(data) / len(chunks); avg < defaultChunkAvg/2 || avg > 2*defaultChunkAvg {
+			t.Errorf("%s: average chunk size %d, want about %d", name, avg, defaultChunkAvg)
+		}
+	}
+
+	if chunks := chunkAll(t, bytes.NewReader(nil), opts); len(chunks) != 0 {
+		t.Errorf("empty input gave %d chunks", len(chunks))
+	}
+	if chunks := chunkAll(t, bytes.NewReader([]byte("tiny")), opts); len(chunks) != 1 || string(chunks[0].Data) != "tiny" {
+		t.Errorf("short input gave %d chunks", len(chunks))
+	}
+	// Input without any cut point is split at MaxSize
+	zeros := make([]byte, 3*defaultChunkMax+5)
+	if chunks := chunkAll(t, bytes.NewReader(zeros), ChunkerOptions{}); len(chunks) < 3 {
+		t.Errorf("uniform input gave %d chunks", len(chunks))
+	}
+}
+
+// TestChunkerDeduplication tests that an edit near the start of the input
+// leaves the later chunks unchanged
+func TestChunkerDeduplication(t *testing.T) {
+	rng := rand.New(rand.NewPCG(13, 14))
+	data := randomBytes(rng, 1<<20)
+	edited := append(append(append([]byte{}, data[:1000]...), "inserted bytes"...), data[1000:]...)
+
+	seen := map[[32]byte]bool{}
+	original := chunkAll(t, bytes.NewReader(data), ChunkerOptions{})
+	for _, chunk := range original {
+		seen[sha256.Sum256(chunk.Data)] = true
+	}
+	shared := 0
+	for _, chunk := range chunkAll(t, bytes.NewReader(edited), ChunkerOptions{}) {
+		if seen[sha256.Sum256(chunk.Data)] {
+			shared++
+		}
+	}
+	if shared < len(original)-3 {
+		t.Errorf("after an insertion %d of %d chunks are shared, want all but the edited ones", shared, len(original))
+	}
+}
+
+// TestChunkerErrors tests invalid options and read errors
+func TestChunkerErrors(t *testing.T) {
+	for _, opts := range []ChunkerOptions{
+		{MinSize: 16, Window: 32},
+		{MinSize: 4096, AvgSize: 1024},
+		{AvgSize: 1 << 20},
+		{Window: -1},
+	} {
+		if _, err := NewChunker(bytes.NewReader(nil), opts); err == nil {
+			t.Errorf("NewChunker with %+v should fail", opts)
+		}
+	}
+
+	errRead := errors.New("read failed")
+	c, _ := NewChunker(io.MultiReader(bytes.NewReader(make([]byte, 100)), iotest.ErrReader(errRead)), ChunkerOptions{})
+	if _, err := c.Next(); !errors.Is(err, errRead) {
+		t.Errorf("Next after a read error: got %v, want %v", err, errRead)
+	}
+
+	c, _ = NewChunker(emptyReader{}, ChunkerOptions{})
+	if _, err := c.Next(); !errors.Is(err, io.ErrNoProgress) {
+		t.Errorf("Next on a reader making no progress: got %v, want io.ErrNoProgress", err)
+	}
+}
+
+// emptyReader returns no data and no error
+type emptyReader struct{}
+
+func (emptyReader) Read([]byte) (int, error) { return 0, nil }
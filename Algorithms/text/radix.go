@@ -0,0 +1,202 @@
+package text
+
+import (
+	"slices"
+	"strings"
+)
+
+// RadixTree maps string keys to values of type T like Trie, but compresses
+// each chain of single-child nodes into one edge labelled with a string, so
+// it holds at most 2k nodes for k keys whatever their length
+type RadixTree[T any] struct {
+	root radixNode[T]
+	size int
+}
+
+// radixNode is a radix tree node. prefix is the label of the edge into it;
+// the children's labels start with distinct bytes and are kept in order.
+type radixNode[T any] struct {
+	prefix   string
+	children []*radixNode[T]
+	value    T
+	ok       bool // Whether a key ends here
+}
+
+// find returns the index of the child whose label starts with b, or where
+// one would be inserted, and whether it exists
+func (n *radixNode[T]) find(b byte) (int, bool) {
+	return slices.BinarySearchFunc(n.children, b, func(c *radixNode[T], b byte) int {
+		return int(c.prefix[0]) - int(b)
+	})
+}
+
+// NewRadixTree creates an empty radix tree
+func NewRadixTree[T any]() *RadixTree[T] {
+	return &RadixTree[T]{}
+}
+
+// Len returns the number of keys
+func (t *RadixTree[T]) Len() int {
+	return t.size
+}
+
+// Insert sets the value of key and reports whether key is new, splitting the
+// edge where key leaves it
+// Time Complexity: O(m), Space Complexity: O(1), at most two new nodes
+func (t *RadixTree[T]) Insert(key string, value T) bool {
+	n := &t.root
+	for key != "" {
+		i, found := n.find(key[0])
+		if !found {
+			leaf := &radixNode[T]{prefix: key, value: value, ok: true}
+			n.children = slices.Insert(n.children, i, leaf)
+			t.size++
+			return true
+		}
+
+		child := n.children[i]
+		common := commonPrefixLen(child.prefix, key)
+		if common < len(child.prefix) {
+			// Split the edge: a new node takes the shared part
+			mid := &radixNode[T]{prefix: child.prefix[:common], children: []*radixNode[T]{child}}
+			child.prefix = child.prefix[common:]
+			n.children[i] = mid
+			child = mid
+		}
+		n, key = child, key[common:]
+	}
+
+	added := !n.ok
+	n.value, n.ok = value, true
+	if added {
+		t.size++
+	}
+	return added
+}
+
+// commonPrefixLen returns the length of the longest common prefix of a and b
+func commonPrefixLen(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// Get returns the value of key and whether it is present
+// Time Complexity: O(m)
+func (t *RadixTree[T]) Get(key string) (T, bool) {
+	n := &t.root
+	for key != "" {
+		i, found := n.find(key[0])
+		if !found || !strings.HasPrefix(key, n.children[i].prefix) {
+			var zero T
+			return zero, false
+		}
+		n, key = n.children[i], key[len(n.children[i].prefix):]
+	}
+	return n.value, n.ok
+}
+
+// Delete removes key and reports whether it was present. The node left
+// behind is removed if it has no children, or merged into its child if it
+// has one, and likewise its parent, so that the tree stays compressed.
+// Time Complexity: O(m)
+func (t *RadixTree[T]) Delete(key string) bool {
+	var parent *radixNode[T]
+	n := &t.root
+	for key != "" {
+		i, found := n.find(key[0])
+		if !found || !strings.HasPrefix(key, n.children[i].prefix) {
+			return false
+		}
+		parent, n, key = n, n.children[i], key[len(n.children[i].prefix):]
+	}
+	if !n.ok {
+		return false
+	}
+	var zero T
+	n.value, n.ok = zero, false
+	t.size--
+
+	if parent == nil {
+		return true
+	}
+	if len(n.children) == 0 {
+		i, _ := parent.find(n.prefix[0])
+		parent.children = slices.Delete(parent.children, i, i+1)
+		// The parent may be left as a keyless node with one child
+		n = parent
+	}
+	if n != &t.root && !n.ok && len(n.children) == 1 {
+		n.merge()
+	}
+	return true
+}
+
+// merge absorbs the only child of n, which holds no key, into n
+func (n *radixNode[T]) merge() {
+	child := n.children[0]
+	n.prefix += child.prefix
+	n.children, n.value, n.ok = child.children, child.value, child.ok
+}
+
+// LongestPrefixMatch returns the longest key that is a prefix of s, with its
+// value. ok is false if no key is a prefix of s.
+// Time Complexity: O(len(s))
+func (t *RadixTree[T]) LongestPrefixMatch(s string) (key string, value T, ok bool) {
+	n, rest := &t.root, s
+	for {
+		if n.ok {
+			key, value, ok = s[:len(s)-len(rest)], n.value, true
+		}
+		if rest == "" {
+			break
+		}
+		i, found := n.find(rest[0])
+		if !found || !strings.HasPrefix(rest, n.children[i].prefix) {
+			break
+		}
+		n, rest = n.children[i], rest[len(n.children[i].prefix):]
+	}
+	return key, value, ok
+}
+
+// WalkPrefix calls fn for each key starting with prefix, with its value, in
+// increasing key order, and stops early if fn returns false
+// Time Complexity: O(m + nodes below the prefix)
+func (t *RadixTree[T]) WalkPrefix(prefix string, fn func(key string, value T) bool) {
+	n, rest := &t.root, prefix
+	for rest != "" {
+		i, found := n.find(rest[0])
+		if !found {
+			return
+		}
+		child := n.children[i]
+		switch {
+		case strings.HasPrefix(rest, child.prefix):
+			n, rest = child, rest[len(child.prefix):]
+		case strings.HasPrefix(child.prefix, rest):
+			// The prefix ends inside the edge: every key below matches
+			child.walk(prefix[:len(prefix)-len(rest)]+child.prefix, fn)
+			return
+		default:
+			return
+		}
+	}
+	n.walk(prefix, fn)
+}
+
+// walk visits n and its descendants in key order, key being the key of n,
+// and returns false once fn has
+func (n *radixNode[T]) walk(key string, fn func(string, T) bool) bool {
+	if n.ok && !fn(key, n.value) {
+		return false
+	}
+	for _, child := range n.children {
+		if !child.walk(key+child.prefix, fn) {
+			return false
+		}
+	}
+	return true
+}
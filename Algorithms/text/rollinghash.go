@@ -0,0 +1,83 @@
+package text
+
+import "math/bits"
+
+// Parameters of RollingHash: a Mersenne prime modulus, which allows a cheap
+// reduction, and a base larger than any byte
+const (
+	hashMod  = 1<<61 - 1
+	hashBase = 1_000_003
+)
+
+// RollingHash is a polynomial hash of the last window bytes written to it,
+// modulo 2^61 - 1. Adding a byte and dropping the one leaving the window
+// takes O(1), which makes it the basis of Rabin-Karp search and of
+// content-defined chunking.
+type RollingHash struct {
+	ring []byte // The bytes in the window, oldest at next once full
+	next int
+	full bool
+	pow  uint64 // hashBase^(window-1), the weight of the oldest byte
+	hash uint64
+}
+
+// NewRollingHash creates a rolling hash over windows of the given number of
+// bytes, at least one
+func NewRollingHash(window int) *RollingHash {
+	window = max(window, 1)
+	pow := uint64(1)
+	for range window - 1 {
+		pow = mulMod(pow, hashBase)
+	}
+	return &RollingHash{ring: make([]byte, window), pow: pow}
+}
+
+// Window returns the window size in bytes
+func (h *RollingHash) Window() int {
+	return len(h.ring)
+}
+
+// Len returns the number of bytes in the window, which is the window size
+// once that many have been rolled in
+func (h *RollingHash) Len() int {
+	if h.full {
+		return len(h.ring)
+	}
+	return h.next
+}
+
+// Roll adds b to the window, dropping the oldest byte if it is full, and
+// returns the new hash
+// Time Complexity: O(1)
+func (h *RollingHash) Roll(b byte) uint64 {
+	if h.full {
+		// Subtract the oldest byte's term; adding hashMod keeps it unsigned
+		h.hash = (h.hash + hashMod - mulMod(uint64(h.ring[h.next]), h.pow)) % hashMod
+	}
+	h.hash = (mulMod(h.hash, hashBase) + uint64(b)) % hashMod
+	h.ring[h.next] = b
+	h.next++
+	if h.next == len(h.ring) {
+		h.next, h.full = 0, true
+	}
+	return h.hash
+}
+
+// Sum64 returns the hash of the bytes in the window
+func (h *RollingHash) Sum64() uint64 {
+	return h.hash
+}
+
+// Reset empties the window
+func (h *RollingHash) Reset() {
+	h.next, h.full, h.hash = 0, false, 0
+}
+
+// mulMod returns a*b mod 2^61 - 1 for a and b below the modulus
+func mulMod(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	// The product is below 2^122; since 2^61 ≡ 1, the sum of its high and
+	// low 61-bit halves is congruent to it and at most twice the modulus
+	sum := (hi<<3 | lo>>61) + lo&hashMod
+	return sum % hashMod
+}
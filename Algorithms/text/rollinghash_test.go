@@ -0,0 +1,57 @@
+package text
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// TestRollingHash tests that the rolled hash always equals the hash of the
+// window computed from scratch
+func TestRollingHash(t *testing.T) {
+	rng := rand.New(rand.NewPCG(9, 10))
+	data := []byte(randomText(rng, 2000, "\x00\x01ab\xfe\xff"))
+	for _, window := range []int{1, 2, 7, 48, 300} {
+		h := NewRollingHash(window)
+		for i, b := range data {
+			got := h.Roll(b)
+
+			fresh := NewRollingHash(window)
+			for _, c := range data[max(0, i+1-window) : i+1] {
+				fresh.Roll(c)
+			}
+			if got != fresh.Sum64() || got != h.Sum64() {
+				t.Fatalf("window %d, byte %d: rolled hash %d, from scratch %d", window, i, got, fresh.Sum64())
+			}
+			if h.Len() != min(i+1, window) {
+				t.Fatalf("window %d, byte %d: Len() = %d", window, i, h.Len())
+			}
+		}
+	}
+
+	h := NewRollingHash(0)
+	if h.Window() != 1 {
+		t.Errorf("NewRollingHash(0).Window() = %d, want 1", h.Window())
+	}
+	h.Roll('x')
+	h.Reset()
+	if h.Len() != 0 || h.Sum64() != 0 {
+		t.Errorf("after Reset, Len() = %d, Sum64() = %d", h.Len(), h.Sum64())
+	}
+}
+
+// TestMulMod tests the Mersenne reduction against the edge of its range
+func TestMulMod(t *testing.T) {
+	const m = hashMod
+	tests := []struct{ a, b, want uint64 }{
+		{0, m - 1, 0},
+		{1, m - 1, m - 1},
+		{m - 1, m - 1, 1}, // (-1)² ≡ 1
+		{2, 1 << 60, 1},   // 2^61 ≡ 1
+		{hashBase, hashBase, hashBase * hashBase},
+	}
+	for _, tt := range tests {
+		if got := mulMod(tt.a, tt.b); got != tt.want {
+			t.Errorf("mulMod(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
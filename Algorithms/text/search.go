@@ -0,0 +1,172 @@
+// Package text provides string algorithms and structures: substring search,
+// suffix arrays, tries and radix trees, longest common, repeated and
+// palindromic substrings, rolling hashes and content-defined chunking.
+// Strings are treated as byte sequences, and positions are byte offsets.
+package text
+
+// KMPSearch finds all occurrences of pattern in text using the
+// Knuth-Morris-Pratt algorithm
+// Time Complexity: O(n + m), Space Complexity: O(m)
+func KMPSearch(text, pattern string) []int {
+	n, m := len(text), len(pattern)
+
+	// Secure: validate input
+	if m == 0 || n < m {
+		return nil
+	}
+
+	// Build failure function (LPS array)
+	lps := buildLPS(pattern)
+
+	result := []int{}
+	j := 0
+	for i := 0; i < n; i++ {
+		for j > 0 && text[i] != pattern[j] {
+			j = lps[j-1]
+		}
+		if text[i] == pattern[j] {
+			j++
+		}
+		if j == m {
+			// Pattern found
+			result = append(result, i-m+1)
+			j = lps[j-1]
+		}
+	}
+	return result
+}
+
+// buildLPS builds the length of the longest proper prefix of each prefix of
+// pattern which is also its suffix
+func buildLPS(pattern string) []int {
+	lps := make([]int, len(pattern))
+	length := 0
+	for i := 1; i < len(pattern); i++ {
+		for length > 0 && pattern[i] != pattern[length] {
+			length = lps[length-1]
+		}
+		if pattern[i] == pattern[length] {
+			length++
+		}
+		lps[i] = length
+	}
+	return lps
+}
+
+// RabinKarpSearch finds all occurrences of pattern in text by comparing a
+// RollingHash of each window with the pattern's hash, confirming matches
+// byte by byte
+// Time Complexity: O(n + m) average, O(n * m) worst, Space Complexity: O(m)
+func RabinKarpSearch(text, pattern string) []int {
+	n, m := len(text), len(pattern)
+
+	// Secure: validate input
+	if m == 0 || n < m {
+		return nil
+	}
+
+	h := NewRollingHash(m)
+	for i := range m {
+		h.Roll(pattern[i])
+	}
+	patternHash := h.Sum64()
+	h.Reset()
+
+	result := []int{}
+	// Slide the window over text
+	for i := range n {
+		if h.Roll(text[i]) == patternHash && i >= m-1 && text[i-m+1:i+1] == pattern {
+			result = append(result, i-m+1)
+		}
+	}
+	return result
+}
+
+// BoyerMooreSearch finds all occurrences of pattern in text using the
+// Boyer-Moore bad character rule
+// Time Complexity: O(n * m) worst, O(n/m) best, Space Complexity: O(1)
+func BoyerMooreSearch(text, pattern string) []int {
+	n, m := len(text), len(pattern)
+
+	// Secure: validate input
+	if m == 0 || n < m {
+		return nil
+	}
+
+	// Build bad character table: the last index of each byte in pattern
+	var badChar [256]int
+	for i := range badChar {
+		badChar[i] = -1
+	}
+	for i := 0; i < m; i++ {
+		badChar[pattern[i]] = i
+	}
+
+	result := []int{}
+	s := 0
+	for s <= n-m {
+		// Match pattern from right to left
+		j := m - 1
+		for j >= 0 && text[s+j] == pattern[j] {
+			j--
+		}
+
+		if j < 0 {
+			// Pattern found: align the next byte with its last occurrence
+			result = append(result, s)
+			if s+m < n {
+				s += m - badChar[text[s+m]]
+			} else {
+				s++
+			}
+		} else {
+			// Shift pattern
+			s += max(1, j-badChar[text[s+j]])
+		}
+	}
+	return result
+}
+
+// ZAlgorithm finds all occurrences of pattern in text using the Z-function
+// of pattern followed by text
+// Time Complexity: O(n + m), Space Complexity: O(n + m)
+func ZAlgorithm(text, pattern string) []int {
+	m := len(pattern)
+
+	// Secure: validate input
+	if m == 0 || len(text) < m {
+		return nil
+	}
+
+	// With no separator between the two, a Z-value of at least m at a
+	// text position is a match, whatever bytes text contains
+	z := zFunction(pattern + text)
+	result := []int{}
+	for i := m; i+m <= len(z); i++ {
+		if z[i] >= m {
+			result = append(result, i-m)
+		}
+	}
+	return result
+}
+
+// zFunction returns for each index i of s the length of the longest common
+// prefix of s and s[i:]
+func zFunction(s string) []int {
+	n := len(s)
+	z := make([]int, n)
+	l, r := 0, 0
+	for i := 1; i < n; i++ {
+		if i < r {
+			z[i] = min(r-i, z[i-l])
+		}
+		// Expand
+		for i+z[i] < n && s[z[i]] == s[i+z[i]] {
+			z[i]++
+		}
+		if i+z[i] > r {
+			l, r = i, i+z[i]
+		}
+	}
+	return z
+}
@@ -0,0 +1,67 @@
+package text
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// naiveSearch returns every position of pattern in text by direct comparison
+func naiveSearch(text, pattern string) []int {
+	if pattern == "" || len(text) < len(pattern) {
+		return nil
+	}
+	result := []int{}
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		if text[i:i+len(pattern)] == pattern {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// randomText returns n bytes drawn from alphabet
+func randomText(rng *rand.Rand, n int, alphabet string) string {
+	var b strings.Builder
+	for range n {
+		b.WriteByte(alphabet[rng.IntN(len(alphabet))])
+	}
+	return b.String()
+}
+
+// TestSearches tests every substring search against a naive search
+func TestSearches(t *testing.T) {
+	searches := map[string]func(string, string) []int{
+		"KMPSearch": KMPSearch, "RabinKarpSearch": RabinKarpSearch,
+		"BoyerMooreSearch": BoyerMooreSearch, "ZAlgorithm": ZAlgorithm,
+		"SuffixArray.Lookup": func(text, pattern string) []int { return NewSuffixArray(text).Lookup(pattern) },
+	}
+	tests := []struct{ text, pattern string }{
+		{"ABABDABACDABABCABCABABC", "ABABCABAB"},
+		{"ABABDABACDABABCABCABABC", "ABC"},
+		{"aaaaaa", "aa"},
+		{"abc", ""},
+		{"", "a"},
+		{"ab", "abc"},
+		{"a$b$a", "$a"},
+		{"\x00\xff\x00\xff", "\xff\x00"},
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		text := randomText(rng, rng.IntN(60), "ab")
+		tests = append(tests, struct{ text, pattern string }{text, randomText(rng, 1+rng.IntN(4), "ab")})
+	}
+
+	for _, tt := range tests {
+		want := naiveSearch(tt.text, tt.pattern)
+		for name, search := range searches {
+			got := search(tt.text, tt.pattern)
+			if len(got) != 0 || len(want) != 0 {
+				if !slices.Equal(got, want) {
+					t.Errorf("%s(%q, %q) = %v, want %v", name, tt.text, tt.pattern, got, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package text
+
+import "slices"
+
+// lcsDPLimit bounds the product of the lengths up to which
+// LongestCommonSubstring uses dynamic programming; past it the O(n log n)
+// suffix array is faster
+const lcsDPLimit = 1 << 20
+
+// LongestCommonSubstring returns the longest common substring of s1 and s2;
+// of several, the one that occurs first in s1. Short inputs use dynamic
+// programming over two rows, long ones a suffix array of s1 and s2 joined
+// by a separator.
+// Time Complexity: O(m * n) up to lcsDPLimit, O((m + n) log(m + n)) past it,
+// Space Complexity: O(min(m * n, m + n))
+func LongestCommonSubstring(s1, s2 string) string {
+	// Secure: validate input
+	if len(s1) == 0 || len(s2) == 0 {
+		return ""
+	}
+	if len(s1)*len(s2) <= lcsDPLimit {
+		return longestCommonSubstringDP(s1, s2)
+	}
+	return longestCommonSubstringSA(s1, s2)
+}
+
+// longestCommonSubstringDP finds the longest common substring with the
+// classic table of common suffix lengths, keeping only its last row
+func longestCommonSubstringDP(s1, s2 string) string {
+	prev, cur := make([]int, len(s2)+1), make([]int, len(s2)+1)
+	maxLength, endIndex := 0, 0
+	for i := 1; i <= len(s1); i++ {
+		for j := 1; j <= len(s2); j++ {
+			if s1[i-1] == s2[j-1] {
+				cur[j] = prev[j-1] + 1
+				if cur[j] > maxLength {
+					maxLength, endIndex = cur[j], i
+				}
+			} else {
+				cur[j] = 0
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return s1[endIndex-maxLength : endIndex]
+}
+
+// longestCommonSubstringSA finds the longest common substring as the
+// longest common prefix of a suffix of s1 and a suffix of s2 adjacent in
+// the suffix array of s1, a separator and s2. The separator is a symbol no
+// byte equals, so no common prefix runs across it.
+func longestCommonSubstringSA(s1, s2 string) string {
+	m := len(s1)
+	symbols := make([]int32, 0, m+1+len(s2))
+	for i := range m {
+		symbols = append(symbols, int32(s1[i]))
+	}
+	symbols = append(symbols, 256)
+	for i := range len(s2) {
+		symbols = append(symbols, int32(s2[i]))
+	}
+	sa := buildSuffixArray(symbols, 257)
+	lcp := buildLCP(symbols, sa)
+
+	maxLength := 0
+	for i := 1; i < len(sa); i++ {
+		if (sa[i] < m) != (sa[i-1] < m) {
+			maxLength = max(maxLength, lcp[i])
+		}
+	}
+	if maxLength == 0 {
+		return ""
+	}
+
+	// Of the runs of suffixes sharing a longest common substring and
+	// drawing from both strings, take the earliest start in s1
+	start := longestRunStart(sa, lcp, maxLength, func(run []int) bool {
+		return slices.Min(run) < m && slices.Max(run) > m
+	})
+	return s1[start : start+maxLength]
+}
+
+// LongestRepeatedSubstring returns the longest substring occurring at least
+// twice in s, occurrences possibly overlapping; of several, the one that
+// occurs first
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func LongestRepeatedSubstring(s string) string {
+	return NewSuffixArray(s).LongestRepeatedSubstring()
+}
+
+// LongestPalindromicSubstring finds the longest palindromic substring by
+// expanding around each center
+// Time Complexity: O(n²), Space Complexity: O(1)
+func LongestPalindromicSubstring(s string) string {
+	n := len(s)
+
+	// Secure: validate input
+	if n == 0 {
+		return ""
+	}
+
+	start, maxLen := 0, 1
+
+	// Expand around center
+	expandAroundCenter := func(left, right int) int {
+		// Secure: bounds checking
+		for left >= 0 && right < n && s[left] == s[right] {
+			left--
+			right++
+		}
+		return right - left - 1
+	}
+
+	for i := 0; i < n; i++ {
+		// Odd length palindromes
+		len1 := expandAroundCenter(i, i)
+		// Even length palindromes
+		len2 := expandAroundCenter(i, i+1)
+
+		length := max(len1, len2)
+		if length > maxLen {
+			maxLen = length
+			start = i - (length-1)/2
+		}
+	}
+	return s[start : start+maxLen]
+}
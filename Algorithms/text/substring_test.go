@@ -0,0 +1,79 @@
+package text
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// TestLongestCommonSubstring tests the dynamic programming and suffix
+// array methods against each other and known answers, including ties
+func TestLongestCommonSubstring(t *testing.T) {
+	tests := []struct{ s1, s2, want string }{
+		{"ABCDGH", "ACDGHR", "CDGH"},
+		{"abc", "xyz", ""},
+		{"", "abc", ""},
+		{"xabxcd", "cdab", "ab"}, // ab and cd tie; ab comes first in s1
+		{"aaa", "aa", "aa"},
+		{"ab\xffcd", "zz\xffcdzz", "\xffcd"},
+	}
+	for _, tt := range tests {
+		if got := LongestCommonSubstring(tt.s1, tt.s2); got != tt.want {
+			t.Errorf("LongestCommonSubstring(%q, %q) = %q, want %q", tt.s1, tt.s2, got, tt.want)
+		}
+		if tt.s1 == "" || tt.s2 == "" {
+			continue
+		}
+		if got := longestCommonSubstringSA(tt.s1, tt.s2); got != tt.want {
+			t.Errorf("longestCommonSubstringSA(%q, %q) = %q, want %q", tt.s1, tt.s2, got, tt.want)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(5, 6))
+	for range 300 {
+		s1 := randomText(rng, 1+rng.IntN(40), "abc")
+		s2 := randomText(rng, 1+rng.IntN(40), "abc")
+		if dp, sa := longestCommonSubstringDP(s1, s2), longestCommonSubstringSA(s1, s2); dp != sa {
+			t.Fatalf("LongestCommonSubstring(%q, %q): dynamic programming %q, suffix array %q", s1, s2, dp, sa)
+		}
+	}
+
+	// Megabyte inputs, far past what the O(m * n) table could hold
+	s1 := randomText(rng, 1<<20, "abcdefgh") + "needle in a haystack"
+	s2 := "a haystack with a needle in it" + randomText(rng, 1<<20, "ijklmnop")
+	if got := LongestCommonSubstring(s1, s2); got != "needle in " {
+		t.Errorf("LongestCommonSubstring of megabyte inputs = %q, want %q", got, "needle in ")
+	}
+}
+
+// TestLongestRepeatedSubstring tests repeated substrings, overlapping and
+// tied
+func TestLongestRepeatedSubstring(t *testing.T) {
+	tests := []struct{ s, want string }{
+		{"", ""},
+		{"abc", ""},
+		{"banana", "ana"},
+		{"aaaa", "aaa"},
+		{"xyzabcxyzabc", "xyzabc"},
+		{"cdxabycdzab", "cd"}, // ab and cd tie; cd occurs first
+		{"zzbaqba", "ba"},
+	}
+	for _, tt := range tests {
+		if got := LongestRepeatedSubstring(tt.s); got != tt.want {
+			t.Errorf("LongestRepeatedSubstring(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+// TestLongestPalindromicSubstring tests palindromes of odd and even length
+func TestLongestPalindromicSubstring(t *testing.T) {
+	tests := []struct{ s, want string }{
+		{"", ""}, {"a", "a"}, {"forgeeksskeegfor", "geeksskeeg"}, {"abacdfgdcaba", "aba"}, {"cbbd", "bb"},
+		{strings.Repeat("ab", 50), strings.Repeat("ab", 49) + "a"},
+	}
+	for _, tt := range tests {
+		if got := LongestPalindromicSubstring(tt.s); got != tt.want {
+			t.Errorf("LongestPalindromicSubstring(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package text
+
+import (
+	"slices"
+	"strings"
+
+	"hellogolang/Algorithms/searching"
+)
+
+// SuffixArray indexes a text by the sorted order of its suffixes, with the
+// longest common prefix (LCP) of each suffix and the one before it, for
+// substring queries in O(m log n)
+type SuffixArray struct {
+	text string
+	sa   []int // sa[i] is the start of the i-th smallest suffix
+	lcp  []int // lcp[i] is the LCP of suffixes sa[i-1] and sa[i]; lcp[0] is 0
+}
+
+// NewSuffixArray builds the suffix array of text by prefix doubling with
+// radix sorting, and its LCP array with Kasai's algorithm
+// Time Complexity: O(n log n), Space Complexity: O(n)
+func NewSuffixArray(text string) *SuffixArray {
+	symbols := make([]int32, len(text))
+	for i := range len(text) {
+		symbols[i] = int32(text[i])
+	}
+	sa := buildSuffixArray(symbols, 256)
+	return &SuffixArray{text: text, sa: sa, lcp: buildLCP(symbols, sa)}
+}
+
+// Len returns the length of the indexed text
+func (a *SuffixArray) Len() int {
+	return len(a.text)
+}
+
+// Suffixes returns the starts of the suffixes in sorted order. The slice
+// belongs to a and must not be modified.
+func (a *SuffixArray) Suffixes() []int {
+	return a.sa
+}
+
+// LCP returns the longest common prefix of each suffix in sorted order and
+// the one before it. The slice belongs to a and must not be modified.
+func (a *SuffixArray) LCP() []int {
+	return a.lcp
+}
+
+// Lookup returns the positions of every occurrence of pattern in the text,
+// in increasing order
+// Time Complexity: O(m log n + k log k), k is the number of occurrences
+func (a *SuffixArray) Lookup(pattern string) []int {
+	lo, hi := a.find(pattern)
+	if lo == hi {
+		return nil
+	}
+	result := slices.Clone(a.sa[lo:hi])
+	slices.Sort(result)
+	return result
+}
+
+// Count returns the number of occurrences of pattern in the text
+// Time Complexity: O(m log n)
+func (a *SuffixArray) Count(pattern string) int {
+	lo, hi := a.find(pattern)
+	return hi - lo
+}
+
+// find returns the range of sorted suffixes that start with pattern
+func (a *SuffixArray) find(pattern string) (lo, hi int) {
+	// Secure: validate input
+	if pattern == "" {
+		return 0, 0
+	}
+	suffix := func(i int) string { return a.text[a.sa[i]:] }
+	lo = searching.SearchMonotonic(func(i int) bool { return suffix(i) >= pattern }, 0, len(a.sa))
+	hi = searching.SearchMonotonic(func(i int) bool {
+		return !strings.HasPrefix(suffix(i), pattern)
+	}, lo, len(a.sa))
+	return lo, hi
+}
+
+// LongestRepeatedSubstring returns the longest substring occurring at least
+// twice in the text, occurrences possibly overlapping. Of several, it
+// returns the one that occurs first.
+// Time Complexity: O(n), Space Complexity: O(1)
+func (a *SuffixArray) LongestRepeatedSubstring() string {
+	length := 0
+	if len(a.lcp) > 0 {
+		length = slices.Max(a.lcp)
+	}
+	if length == 0 {
+		return ""
+	}
+	start := longestRunStart(a.sa, a.lcp, length, func([]int) bool { return true })
+	return a.text[start : start+length]
+}
+
+// longestRunStart returns the smallest suffix start in any run of sorted
+// suffixes sharing a prefix of at least length that accept approves
+func longestRunStart(sa, lcp []int, length int, accept func(run []int) bool) int {
+	best := -1
+	for i := 1; i < len(sa); {
+		if lcp[i] < length {
+			i++
+			continue
+		}
+		// The run is sa[from:i], every suffix in it sharing the prefix
+		from := i - 1
+		for i < len(sa) && lcp[i] >= length {
+			i++
+		}
+		if run := sa[from:i]; accept(run) {
+			if start := slices.Min(run); best < 0 || start < best {
+				best = start
+			}
+		}
+	}
+	return best
+}
+
+// buildSuffixArray sorts the suffixes of s, whose symbols are below
+// alphabet, by prefix doubling: after the round for k, rank orders the
+// suffixes by their first 2k symbols. Each round radix sorts by the pair
+// (rank[i], rank[i+k]).
+func buildSuffixArray(s []int32, alphabet int) []int {
+	n := len(s)
+	sa := make([]int, n)
+	if n <= 1 {
+		return sa
+	}
+	rank, next, tmp := make([]int, n), make([]int, n), make([]int, n)
+	count := make([]int, max(alphabet, n))
+
+	// countingSort stably sorts order by rank into sa
+	countingSort := func(order []int, classes int) {
+		clear(count[:classes])
+		for _, p := range order {
+			count[rank[p]]++
+		}
+		sum := 0
+		for c := range classes {
+			count[c], sum = sum, sum+count[c]
+		}
+		for _, p := range order {
+			sa[count[rank[p]]] = p
+			count[rank[p]]++
+		}
+	}
+
+	for i, c := range s {
+		rank[i], tmp[i] = int(c), i
+	}
+	countingSort(tmp, alphabet)
+
+	classes := alphabet
+	for k := 1; ; k *= 2 {
+		// Order by the second half: suffixes too short to have one first,
+		// then the rest in the order of the suffix k after them
+		order := tmp[:0]
+		for i := n - k; i < n; i++ {
+			order = append(order, i)
+		}
+		for _, p := range sa {
+			if p >= k {
+				order = append(order, p-k)
+			}
+		}
+		countingSort(order, classes)
+
+		// Rank by the pair; equal pairs share a rank
+		second := func(i int) int {
+			if i+k < n {
+				return rank[i+k]
+			}
+			return -1
+		}
+		next[sa[0]], classes = 0, 1
+		for i := 1; i < n; i++ {
+			cur, prev := sa[i], sa[i-1]
+			if rank[cur] != rank[prev] || second(cur) != second(prev) {
+				classes++
+			}
+			next[cur] = classes - 1
+		}
+		rank, next = next, rank
+		if classes == n {
+			return sa
+		}
+	}
+}
+
+// buildLCP computes the LCP array of s from its suffix array with Kasai's
+// algorithm, which reuses all but one symbol of each match for the next
+// suffix in text order
+// Time Complexity: O(n), Space Complexity: O(n)
+func buildLCP(s []int32, sa []int) []int {
+	n := len(s)
+	lcp := make([]int, n)
+	rank := make([]int, n)
+	for i, p := range sa {
+		rank[p] = i
+	}
+	h := 0
+	for p := range n {
+		if rank[p] == 0 {
+			h = 0
+			continue
+		}
+		q := sa[rank[p]-1]
+		for p+h < n && q+h < n && s[p+h] == s[q+h] {
+			h++
+		}
+		lcp[rank[p]] = h
+		if h > 0 {
+			h--
+		}
+	}
+	return lcp
+}
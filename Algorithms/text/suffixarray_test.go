@@ -0,0 +1,62 @@
+package text
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestSuffixArray tests the suffix and LCP arrays against sorting the
+// suffixes directly
+func TestSuffixArray(t *testing.T) {
+	tests := []string{"", "a", "banana", "mississippi", "aaaaaaaa", "abcabcabc", "\xff\x00\xff\x00"}
+	rng := rand.New(rand.NewPCG(3, 4))
+	for range 100 {
+		tests = append(tests, randomText(rng, rng.IntN(200), "abc"))
+	}
+
+	for _, s := range tests {
+		want := make([]int, len(s))
+		for i := range want {
+			want[i] = i
+		}
+		slices.SortFunc(want, func(a, b int) int { return strings.Compare(s[a:], s[b:]) })
+
+		a := NewSuffixArray(s)
+		if !slices.Equal(a.Suffixes(), want) {
+			t.Errorf("NewSuffixArray(%q).Suffixes() = %v, want %v", s, a.Suffixes(), want)
+			continue
+		}
+		for i := 1; i < len(want); i++ {
+			lcp := 0
+			for x, y := s[want[i-1]:], s[want[i]:]; lcp < len(x) && lcp < len(y) && x[lcp] == y[lcp]; {
+				lcp++
+			}
+			if a.LCP()[i] != lcp {
+				t.Errorf("NewSuffixArray(%q).LCP()[%d] = %d, want %d", s, i, a.LCP()[i], lcp)
+			}
+		}
+	}
+
+	a := NewSuffixArray("banana")
+	if got := a.Lookup("ana"); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("Lookup(ana) = %v, want [1 3]", got)
+	}
+	if a.Count("a") != 3 || a.Count("nab") != 0 || a.Count("") != 0 || a.Len() != 6 {
+		t.Errorf("Count(a) = %d, Count(nab) = %d, Count() = %d, Len() = %d", a.Count("a"), a.Count("nab"), a.Count(""), a.Len())
+	}
+}
+
+// TestLargeSuffixArray tests that a megabyte of repetitive text, the worst
+// case for naive suffix sorting, builds quickly
+func TestLargeSuffixArray(t *testing.T) {
+	s := strings.Repeat("ab", 1<<19)
+	a := NewSuffixArray(s)
+	if got := a.LongestRepeatedSubstring(); len(got) != len(s)-2 {
+		t.Errorf("LongestRepeatedSubstring of (ab)^n has length %d, want %d", len(got), len(s)-2)
+	}
+	if a.Count("ba") != 1<<19-1 {
+		t.Errorf("Count(ba) = %d, want %d", a.Count("ba"), 1<<19-1)
+	}
+}
@@ -0,0 +1,148 @@
+package text
+
+import "slices"
+
+// Trie maps string keys to values of type T with one node per key byte,
+// answering prefix queries in time proportional to the key length
+type Trie[T any] struct {
+	root trieNode[T]
+	size int
+}
+
+// trieNode is a trie node; labels holds the byte leading to each child, in
+// increasing order
+type trieNode[T any] struct {
+	labels   []byte
+	children []*trieNode[T]
+	value    T
+	ok       bool // Whether a key ends here
+}
+
+// child returns the child reached by b, or nil
+func (n *trieNode[T]) child(b byte) *trieNode[T] {
+	if i, found := slices.BinarySearch(n.labels, b); found {
+		return n.children[i]
+	}
+	return nil
+}
+
+// NewTrie creates an empty trie
+func NewTrie[T any]() *Trie[T] {
+	return &Trie[T]{}
+}
+
+// Len returns the number of keys
+func (t *Trie[T]) Len() int {
+	return t.size
+}
+
+// Insert sets the value of key and reports whether key is new
+// Time Complexity: O(m), Space Complexity: O(m), at most m new nodes
+func (t *Trie[T]) Insert(key string, value T) bool {
+	n := &t.root
+	for i := range len(key) {
+		j, found := slices.BinarySearch(n.labels, key[i])
+		if !found {
+			n.labels = slices.Insert(n.labels, j, key[i])
+			n.children = slices.Insert(n.children, j, &trieNode[T]{})
+		}
+		n = n.children[j]
+	}
+	added := !n.ok
+	n.value, n.ok = value, true
+	if added {
+		t.size++
+	}
+	return added
+}
+
+// Get returns the value of key and whether it is present
+// Time Complexity: O(m)
+func (t *Trie[T]) Get(key string) (T, bool) {
+	n := &t.root
+	for i := 0; i < len(key) && n != nil; i++ {
+		n = n.child(key[i])
+	}
+	if n == nil || !n.ok {
+		var zero T
+		return zero, false
+	}
+	return n.value, true
+}
+
+// Delete removes key, pruning the nodes left without keys below them, and
+// reports whether it was present
+// Time Complexity: O(m)
+func (t *Trie[T]) Delete(key string) bool {
+	// Record the path to prune it afterwards
+	path := []*trieNode[T]{&t.root}
+	for i := range len(key) {
+		next := path[i].child(key[i])
+		if next == nil {
+			return false
+		}
+		path = append(path, next)
+	}
+	n := path[len(key)]
+	if !n.ok {
+		return false
+	}
+	var zero T
+	n.value, n.ok = zero, false
+	t.size--
+
+	for i := len(key); i > 0 && !path[i].ok && len(path[i].children) == 0; i-- {
+		parent := path[i-1]
+		j, _ := slices.BinarySearch(parent.labels, key[i-1])
+		parent.labels = slices.Delete(parent.labels, j, j+1)
+		parent.children = slices.Delete(parent.children, j, j+1)
+	}
+	return true
+}
+
+// LongestPrefixMatch returns the longest key that is a prefix of s, with its
+// value, as a router matches an address against its routes. ok is false if
+// no key is a prefix of s.
+// Time Complexity: O(len(s))
+func (t *Trie[T]) LongestPrefixMatch(s string) (key string, value T, ok bool) {
+	n := &t.root
+	for i := 0; ; i++ {
+		if n.ok {
+			key, value, ok = s[:i], n.value, true
+		}
+		if i == len(s) {
+			break
+		}
+		if n = n.child(s[i]); n == nil {
+			break
+		}
+	}
+	return key, value, ok
+}
+
+// WalkPrefix calls fn for each key starting with prefix, with its value, in
+// increasing key order, and stops early if fn returns false
+// Time Complexity: O(m + nodes below the prefix)
+func (t *Trie[T]) WalkPrefix(prefix string, fn func(key string, value T) bool) {
+	n := &t.root
+	for i := 0; i < len(prefix) && n != nil; i++ {
+		n = n.child(prefix[i])
+	}
+	if n != nil {
+		n.walk([]byte(prefix), fn)
+	}
+}
+
+// walk visits n and its descendants in key order, key being the key of n,
+// and returns false once fn has
+func (n *trieNode[T]) walk(key []byte, fn func(string, T) bool) bool {
+	if n.ok && !fn(string(key), n.value) {
+		return false
+	}
+	for i, child := range n.children {
+		if !child.walk(append(key, n.labels[i]), fn) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package text
+
+import (
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// prefixTree is the interface shared by Trie and RadixTree
+type prefixTree interface {
+	Len() int
+	Insert(key string, value int) bool
+	Get(key string) (int, bool)
+	Delete(key string) bool
+	LongestPrefixMatch(s string) (string, int, bool)
+	WalkPrefix(prefix string, fn func(key string, value int) bool)
+}
+
+var prefixTrees = map[string]func() prefixTree{
+	"Trie":      func() prefixTree { return NewTrie[int]() },
+	"RadixTree": func() prefixTree { return NewRadixTree[int]() },
+}
+
+// TestPrefixTrees tests random inserts, deletes and queries on both trees
+// against a map
+func TestPrefixTrees(t *testing.T) {
+	for name, newTree := range prefixTrees {
+		rng := rand.New(rand.NewPCG(7, 8))
+		tree, want := newTree(), map[string]int{}
+		for step := range 3000 {
+			key := randomText(rng, rng.IntN(6), "abc")
+			if rng.IntN(3) == 0 {
+				_, present := want[key]
+				if got := tree.Delete(key); got != present {
+					t.Fatalf("%s step %d: Delete(%q) = %v, want %v", name, step, key, got, present)
+				}
+				delete(want, key)
+			} else {
+				_, present := want[key]
+				if got := tree.Insert(key, step); got == present {
+					t.Fatalf("%s step %d: Insert(%q) = %v, want %v", name, step, key, got, !present)
+				}
+				want[key] = step
+			}
+			if tree.Len() != len(want) {
+				t.Fatalf("%s step %d: Len() = %d, want %d", name, step, tree.Len(), len(want))
+			}
+
+			probe := randomText(rng, rng.IntN(7), "abc")
+			checkPrefixTree(t, name, tree, want, probe)
+			if radix, ok := tree.(*RadixTree[int]); ok {
+				checkCompressed(t, &radix.root, true)
+			}
+		}
+	}
+}
+
+// checkPrefixTree checks Get, LongestPrefixMatch and WalkPrefix for probe
+func checkPrefixTree(t *testing.T, name string, tree prefixTree, want map[string]int, probe string) {
+	t.Helper()
+	wantValue, present := want[probe]
+	if got, ok := tree.Get(probe); got != wantValue || ok != present {
+		t.Fatalf("%s: Get(%q) = %d, %v, want %d, %v", name, probe, got, ok, wantValue, present)
+	}
+
+	wantKey, wantOK := "", false
+	for key := range want {
+		if strings.HasPrefix(probe, key) && (!wantOK || len(key) > len(wantKey)) {
+			wantKey, wantOK = key, true
+		}
+	}
+	if key, value, ok := tree.LongestPrefixMatch(probe); ok != wantOK || key != wantKey || (ok && value != want[key]) {
+		t.Fatalf("%s: LongestPrefixMatch(%q) = %q, %d, %v, want %q, %v", name, probe, key, value, ok, wantKey, wantOK)
+	}
+
+	wantKeys := []string{}
+	for _, key := range slices.Sorted(maps.Keys(want)) {
+		if strings.HasPrefix(key, probe) {
+			wantKeys = append(wantKeys, key)
+		}
+	}
+	gotKeys := []string{}
+	tree.WalkPrefix(probe, func(key string, value int) bool {
+		if value != want[key] {
+			t.Fatalf("%s: WalkPrefix(%q) visited %q = %d, want %d", name, probe, key, value, want[key])
+		}
+		gotKeys = append(gotKeys, key)
+		return true
+	})
+	if !slices.Equal(gotKeys, wantKeys) {
+		t.Fatalf("%s: WalkPrefix(%q) = %v, want %v", name, probe, gotKeys, wantKeys)
+	}
+}
+
+// checkCompressed checks that no node below the root is a keyless node with
+// fewer than two children
+func checkCompressed(t *testing.T, n *radixNode[int], root bool) {
+	t.Helper()
+	if !root && !n.ok && len(n.children) < 2 {
+		t.Fatalf("RadixTree node %q holds no key and has %d children", n.prefix, len(n.children))
+	}
+	for _, child := range n.children {
+		checkCompressed(t, child, false)
+	}
+}
+
+// TestPrefixTreeQueries tests autocomplete and routing style queries
+func TestPrefixTreeQueries(t *testing.T) {
+	for name, newTree := range prefixTrees {
+		tree := newTree()
+		for i, word := range []string{"car", "card", "care", "careful", "cat", "dog", ""} {
+			tree.Insert(word, i)
+		}
+
+		// Autocomplete, stopping after three suggestions
+		got := []string{}
+		tree.WalkPrefix("car", func(key string, _ int) bool {
+			got = append(got, key)
+			return len(got) < 3
+		})
+		if !slices.Equal(got, []string{"car", "card", "care"}) {
+			t.Errorf("%s: first three completions of car = %v", name, got)
+		}
+		got = got[:0]
+		tree.WalkPrefix("ca", func(key string, _ int) bool { got = append(got, key); return true })
+		if !slices.Equal(got, []string{"car", "card", "care", "careful", "cat"}) {
+			t.Errorf("%s: completions of ca = %v", name, got)
+		}
+
+		// Routing: the most specific route
+		if key, _, ok := tree.LongestPrefixMatch("carefully"); !ok || key != "careful" {
+			t.Errorf("%s: LongestPrefixMatch(carefully) = %q, %v", name, key, ok)
+		}
+		if key, value, ok := tree.LongestPrefixMatch("zebra"); !ok || key != "" || value != 6 {
+			t.Errorf("%s: LongestPrefixMatch(zebra) = %q, %d, %v, want the empty key", name, key, value, ok)
+		}
+
+		if !tree.Delete("care") || tree.Delete("care") || tree.Delete("ca") {
+			t.Errorf("%s: Delete reported the wrong presence", name)
+		}
+		if _, ok := tree.Get("careful"); !ok || tree.Len() != 6 {
+			t.Errorf("%s: careful lost after deleting care, Len() = %d", name, tree.Len())
+		}
+	}
+}
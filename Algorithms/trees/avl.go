@@ -0,0 +1,175 @@
+package trees
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// AVLTree is a binary search tree in which the heights of the two subtrees
+// of every node differ by at most one, so its height is below 1.44 log₂ n.
+// Insertions and deletions restore the balance with at most two rotations
+// per node on the path back to the root.
+type AVLTree[K, V any] struct {
+	tree[K, V]
+}
+
+// NewAVL creates an empty AVL tree ordering keys by cmp.Compare
+func NewAVL[K cmp.Ordered, V any]() *AVLTree[K, V] {
+	return NewAVLFunc[K, V](cmp.Compare[K])
+}
+
+// NewAVLFunc creates an empty AVL tree ordering keys by compare, which
+// returns a negative number, zero or a positive number as a is less than,
+// equal to or greater than b
+func NewAVLFunc[K, V any](compare func(a, b K) int) *AVLTree[K, V] {
+	return &AVLTree[K, V]{tree[K, V]{compare: compare}}
+}
+
+// Insert sets the value of key and reports whether key is new
+// Time Complexity: O(log n), Space Complexity: O(log n)
+func (t *AVLTree[K, V]) Insert(key K, value V) bool {
+	added := false
+	t.root = t.insert(t.root, key, value, &added)
+	if added {
+		t.size++
+	}
+	return added
+}
+
+// insert inserts key below n and returns the rebalanced subtree
+func (t *AVLTree[K, V]) insert(n *node[K, V], key K, value V, added *bool) *node[K, V] {
+	if n == nil {
+		*added = true
+		return &node[K, V]{key: key, value: value, height: 1}
+	}
+	switch c := t.compare(key, n.key); {
+	case c < 0:
+		n.left = t.insert(n.left, key, value, added)
+	case c > 0:
+		n.right = t.insert(n.right, key, value, added)
+	default:
+		n.value = value
+		return n
+	}
+	return rebalance(n)
+}
+
+// Delete removes key and reports whether it was present
+// Time Complexity: O(log n), Space Complexity: O(log n)
+func (t *AVLTree[K, V]) Delete(key K) bool {
+	removed := false
+	t.root = t.delete(t.root, key, &removed)
+	if removed {
+		t.size--
+	}
+	return removed
+}
+
+// delete removes key from below n and returns the rebalanced subtree. A
+// node with two children takes the place of its successor, which is then
+// deleted from the right subtree.
+func (t *AVLTree[K, V]) delete(n *node[K, V], key K, removed *bool) *node[K, V] {
+	if n == nil {
+		return nil
+	}
+	switch c := t.compare(key, n.key); {
+	case c < 0:
+		n.left = t.delete(n.left, key, removed)
+	case c > 0:
+		n.right = t.delete(n.right, key, removed)
+	default:
+		*removed = true
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		successor := leftmost(n.right)
+		n.key, n.value = successor.key, successor.value
+		n.right = t.delete(n.right, successor.key, new(bool))
+	}
+	return rebalance(n)
+}
+
+// height returns the height of n, 0 for nil
+func height[K, V any](n *node[K, V]) int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+// update recomputes the height of n from its children
+func update[K, V any](n *node[K, V]) {
+	n.height = 1 + max(height(n.left), height(n.right))
+}
+
+// rebalance restores the balance of n, whose subtrees are balanced and
+// differ in height by at most two, and returns the new root of the
+// subtree. A subtree leaning the opposite way to its parent is first
+// rotated to lean the same way, so a single rotation then balances both.
+func rebalance[K, V any](n *node[K, V]) *node[K, V] {
+	update(n)
+	switch balance := height(n.left) - height(n.right); {
+	case balance > 1:
+		if height(n.left.left) < height(n.left.right) {
+			n.left = rotateLeft(n.left)
+		}
+		return rotateRight(n)
+	case balance < -1:
+		if height(n.right.right) < height(n.right.left) {
+			n.right = rotateRight(n.right)
+		}
+		return rotateLeft(n)
+	}
+	return n
+}
+
+// rotateLeft lifts the right child of n into its place
+func rotateLeft[K, V any](n *node[K, V]) *node[K, V] {
+	r := n.right
+	n.right, r.left = r.left, n
+	update(n)
+	update(r)
+	return r
+}
+
+// rotateRight lifts the left child of n into its place
+func rotateRight[K, V any](n *node[K, V]) *node[K, V] {
+	l := n.left
+	n.left, l.right = l.right, n
+	update(n)
+	update(l)
+	return l
+}
+
+// Check verifies the invariants of the tree: keys in order, heights
+// recorded correctly, and subtree heights differing by at most one. It
+// returns an error wrapping ErrInvariant describing the first violation.
+// Time Complexity: O(n), Space Complexity: O(log n)
+func (t *AVLTree[K, V]) Check() error {
+	if err := t.checkOrder(); err != nil {
+		return err
+	}
+	var check func(n *node[K, V]) error
+	check = func(n *node[K, V]) error {
+		if n == nil {
+			return nil
+		}
+		if err := check(n.left); err != nil {
+			return err
+		}
+		if err := check(n.right); err != nil {
+			return err
+		}
+		if want := 1 + max(height(n.left), height(n.right)); n.height != want {
+			return fmt.Errorf("%w: node %v records height %d, want %d", ErrInvariant, n.key, n.height, want)
+		}
+		if balance := height(n.left) - height(n.right); balance < -1 || balance > 1 {
+			return fmt.Errorf("%w: node %v has balance %d", ErrInvariant, n.key, balance)
+		}
+		return nil
+	}
+	return check(t.root)
+}
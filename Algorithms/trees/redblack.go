@@ -0,0 +1,269 @@
+package trees
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// RedBlackTree is a binary search tree whose nodes are colored red or
+// black so that the root is black, no red node has a red child, and every
+// path from a node down to a missing child passes the same number of black
+// nodes. The longest path is then at most twice the shortest, so its
+// height is at most 2 log₂(n+1). Insertions rotate at most twice and
+// deletions at most three times; otherwise they recolor up the path.
+type RedBlackTree[K, V any] struct {
+	tree[K, V]
+}
+
+// NewRedBlack creates an empty red-black tree ordering keys by cmp.Compare
+func NewRedBlack[K cmp.Ordered, V any]() *RedBlackTree[K, V] {
+	return NewRedBlackFunc[K, V](cmp.Compare[K])
+}
+
+// NewRedBlackFunc creates an empty red-black tree ordering keys by
+// compare, which returns a negative number, zero or a positive number as a
+// is less than, equal to or greater than b
+func NewRedBlackFunc[K, V any](compare func(a, b K) int) *RedBlackTree[K, V] {
+	return &RedBlackTree[K, V]{tree[K, V]{compare: compare}}
+}
+
+// isRed reports whether n is red; missing children are black
+func isRed[K, V any](n *node[K, V]) bool {
+	return n != nil && n.red
+}
+
+// Insert sets the value of key and reports whether key is new
+// Time Complexity: O(log n), Space Complexity: O(1)
+func (t *RedBlackTree[K, V]) Insert(key K, value V) bool {
+	var parent *node[K, V]
+	link := &t.root
+	for *link != nil {
+		parent = *link
+		switch c := t.compare(key, parent.key); {
+		case c < 0:
+			link = &parent.left
+		case c > 0:
+			link = &parent.right
+		default:
+			parent.value = value
+			return false
+		}
+	}
+	n := &node[K, V]{key: key, value: value, parent: parent, red: true}
+	*link = n
+	t.size++
+	t.insertFixup(n)
+	return true
+}
+
+// insertFixup restores the coloring after the red node n is added, when
+// its parent may also be red. A red uncle lets the grandparent take the
+// red up the tree; a black uncle ends the repair with one or two
+// rotations.
+func (t *RedBlackTree[K, V]) insertFixup(n *node[K, V]) {
+	for isRed(n.parent) {
+		parent := n.parent
+		grandparent := parent.parent // Exists, since the root is black
+		if parent == grandparent.left {
+			if uncle := grandparent.right; isRed(uncle) {
+				parent.red, uncle.red, grandparent.red = false, false, true
+				n = grandparent
+				continue
+			}
+			if n == parent.right {
+				n = parent
+				t.rotateLeft(n)
+				parent = n.parent
+			}
+			parent.red, grandparent.red = false, true
+			t.rotateRight(grandparent)
+		} else {
+			if uncle := grandparent.left; isRed(uncle) {
+				parent.red, uncle.red, grandparent.red = false, false, true
+				n = grandparent
+				continue
+			}
+			if n == parent.left {
+				n = parent
+				t.rotateRight(n)
+				parent = n.parent
+			}
+			parent.red, grandparent.red = false, true
+			t.rotateLeft(grandparent)
+		}
+	}
+	t.root.red = false
+}
+
+// Delete removes key and reports whether it was present
+// Time Complexity: O(log n), Space Complexity: O(1)
+func (t *RedBlackTree[K, V]) Delete(key K) bool {
+	n := t.find(key)
+	if n == nil {
+		return false
+	}
+	t.size--
+
+	// A node with two children swaps places with its successor, which has
+	// no left child, so the node actually removed has at most one child
+	if n.left != nil && n.right != nil {
+		successor := leftmost(n.right)
+		n.key, n.value = successor.key, successor.value
+		n = successor
+	}
+	child := n.left
+	if child == nil {
+		child = n.right
+	}
+	parent := n.parent
+	if child != nil {
+		child.parent = parent
+	}
+	t.replaceChild(parent, n, child)
+
+	// Removing a black node shortens the paths through it by one black
+	if !n.red {
+		t.deleteFixup(child, parent)
+	}
+	return true
+}
+
+// deleteFixup restores the black heights after a black node is removed
+// from below parent, leaving x, possibly nil, one black short. A red x is
+// simply blackened; otherwise the sibling's subtree lends a black by
+// rotation, or, with no red to lend, the shortage moves up to the parent.
+func (t *RedBlackTree[K, V]) deleteFixup(x, parent *node[K, V]) {
+	for x != t.root && !isRed(x) {
+		if x == parent.left {
+			// The sibling exists: its side has a black more than x's
+			sibling := parent.right
+			if sibling.red {
+				sibling.red, parent.red = false, true
+				t.rotateLeft(parent)
+				sibling = parent.right
+			}
+			if !isRed(sibling.left) && !isRed(sibling.right) {
+				sibling.red = true
+				x, parent = parent, parent.parent
+				continue
+			}
+			if !isRed(sibling.right) {
+				sibling.left.red, sibling.red = false, true
+				t.rotateRight(sibling)
+				sibling = parent.right
+			}
+			sibling.red, parent.red, sibling.right.red = parent.red, false, false
+			t.rotateLeft(parent)
+		} else {
+			sibling := parent.left
+			if sibling.red {
+				sibling.red, parent.red = false, true
+				t.rotateRight(parent)
+				sibling = parent.left
+			}
+			if !isRed(sibling.left) && !isRed(sibling.right) {
+				sibling.red = true
+				x, parent = parent, parent.parent
+				continue
+			}
+			if !isRed(sibling.left) {
+				sibling.right.red, sibling.red = false, true
+				t.rotateLeft(sibling)
+				sibling = parent.left
+			}
+			sibling.red, parent.red, sibling.left.red = parent.red, false, false
+			t.rotateRight(parent)
+		}
+		x = t.root
+	}
+	if x != nil {
+		x.red = false
+	}
+}
+
+// replaceChild makes child take the place of old below parent, or at the
+// root if parent is nil
+func (t *RedBlackTree[K, V]) replaceChild(parent, old, child *node[K, V]) {
+	switch {
+	case parent == nil:
+		t.root = child
+	case parent.left == old:
+		parent.left = child
+	default:
+		parent.right = child
+	}
+}
+
+// rotateLeft lifts the right child of n into its place
+func (t *RedBlackTree[K, V]) rotateLeft(n *node[K, V]) {
+	r := n.right
+	n.right = r.left
+	if r.left != nil {
+		r.left.parent = n
+	}
+	r.parent = n.parent
+	t.replaceChild(n.parent, n, r)
+	r.left, n.parent = n, r
+}
+
+// rotateRight lifts the left child of n into its place
+func (t *RedBlackTree[K, V]) rotateRight(n *node[K, V]) {
+	l := n.left
+	n.left = l.right
+	if l.right != nil {
+		l.right.parent = n
+	}
+	l.parent = n.parent
+	t.replaceChild(n.parent, n, l)
+	l.right, n.parent = n, l
+}
+
+// Check verifies the invariants of the tree: keys in order, parent links
+// consistent, a black root, no red node with a red child, and equal black
+// heights. It returns an error wrapping ErrInvariant describing the first
+// violation.
+// Time Complexity: O(n), Space Complexity: O(log n)
+func (t *RedBlackTree[K, V]) Check() error {
+	if err := t.checkOrder(); err != nil {
+		return err
+	}
+	if isRed(t.root) {
+		return fmt.Errorf("%w: the root is red", ErrInvariant)
+	}
+	if t.root != nil && t.root.parent != nil {
+		return fmt.Errorf("%w: the root has a parent", ErrInvariant)
+	}
+	// blackHeight returns the number of black nodes on each path down from
+	// n, checking that it is the same for every path
+	var blackHeight func(n *node[K, V]) (int, error)
+	blackHeight = func(n *node[K, V]) (int, error) {
+		if n == nil {
+			return 1, nil
+		}
+		for _, c := range []*node[K, V]{n.left, n.right} {
+			if c != nil && c.parent != n {
+				return 0, fmt.Errorf("%w: node %v has the wrong parent", ErrInvariant, c.key)
+			}
+			if n.red && isRed(c) {
+				return 0, fmt.Errorf("%w: red node %v has a red child", ErrInvariant, n.key)
+			}
+		}
+		left, err := blackHeight(n.left)
+		if err != nil {
+			return 0, err
+		}
+		right, err := blackHeight(n.right)
+		if err != nil {
+			return 0, err
+		}
+		if left != right {
+			return 0, fmt.Errorf("%w: node %v has black heights %d and %d", ErrInvariant, n.key, left, right)
+		}
+		if !n.red {
+			left++
+		}
+		return left, nil
+	}
+	_, err := blackHeight(t.root)
+	return err
+}
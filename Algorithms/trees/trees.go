@@ -0,0 +1,242 @@
+// Package trees provides balanced binary search trees mapping ordered keys
+// to values: AVL trees, kept balanced by height, and red-black trees, kept
+// balanced by coloring. Both search, insert and delete in O(log n), iterate
+// in key order, scan ranges of keys, and check their own invariants for
+// use in tests. Both implement OrderedMap.
+package trees
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// ErrInvariant is returned by the Check methods when a tree's structure
+// violates one of its invariants
+var ErrInvariant = errors.New("tree invariant violated")
+
+// OrderedMap is a map whose keys are kept in order
+type OrderedMap[K, V any] interface {
+	// Len returns the number of keys
+	Len() int
+	// Get returns the value of key and whether it is present
+	Get(key K) (V, bool)
+	// Insert sets the value of key and reports whether key is new
+	Insert(key K, value V) bool
+	// Delete removes key and reports whether it was present
+	Delete(key K) bool
+	// Min returns the least key and its value, or ok false if empty
+	Min() (key K, value V, ok bool)
+	// Max returns the greatest key and its value, or ok false if empty
+	Max() (key K, value V, ok bool)
+	// Floor returns the greatest key at most key, or ok false if none
+	Floor(key K) (K, V, bool)
+	// Ceiling returns the least key at least key, or ok false if none
+	Ceiling(key K) (K, V, bool)
+	// All iterates over the keys and values in increasing key order
+	All() iter.Seq2[K, V]
+	// Range iterates over the keys in [lo, hi) in increasing order
+	Range(lo, hi K) iter.Seq2[K, V]
+}
+
+// NewMap creates an empty ordered map, a red-black tree
+func NewMap[K cmp.Ordered, V any]() OrderedMap[K, V] {
+	return NewRedBlack[K, V]()
+}
+
+// node is a node of either tree: an AVL tree keeps its height, and a
+// red-black tree its parent and color
+type node[K, V any] struct {
+	key                 K
+	value               V
+	left, right, parent *node[K, V]
+	height              int
+	red                 bool
+}
+
+// tree holds what both trees share: the searches and iteration that do
+// not change the tree
+type tree[K, V any] struct {
+	root    *node[K, V]
+	size    int
+	compare func(a, b K) int
+}
+
+// Len returns the number of keys
+func (t *tree[K, V]) Len() int {
+	return t.size
+}
+
+// find returns the node holding key, or nil
+func (t *tree[K, V]) find(key K) *node[K, V] {
+	n := t.root
+	for n != nil {
+		switch c := t.compare(key, n.key); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// Get returns the value of key and whether it is present
+// Time Complexity: O(log n)
+func (t *tree[K, V]) Get(key K) (V, bool) {
+	if n := t.find(key); n != nil {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Min returns the least key and its value, or ok false if the tree is
+// empty
+// Time Complexity: O(log n)
+func (t *tree[K, V]) Min() (key K, value V, ok bool) {
+	if t.root == nil {
+		return key, value, false
+	}
+	n := leftmost(t.root)
+	return n.key, n.value, true
+}
+
+// Max returns the greatest key and its value, or ok false if the tree is
+// empty
+// Time Complexity: O(log n)
+func (t *tree[K, V]) Max() (key K, value V, ok bool) {
+	n := t.root
+	if n == nil {
+		return key, value, false
+	}
+	for n.right != nil {
+		n = n.right
+	}
+	return n.key, n.value, true
+}
+
+// Floor returns the greatest key at most key, with its value, or ok false
+// if every key is greater
+// Time Complexity: O(log n)
+func (t *tree[K, V]) Floor(key K) (K, V, bool) {
+	var best *node[K, V]
+	for n := t.root; n != nil; {
+		if t.compare(n.key, key) <= 0 {
+			best, n = n, n.right
+		} else {
+			n = n.left
+		}
+	}
+	return entry(best)
+}
+
+// Ceiling returns the least key at least key, with its value, or ok false
+// if every key is less
+// Time Complexity: O(log n)
+func (t *tree[K, V]) Ceiling(key K) (K, V, bool) {
+	var best *node[K, V]
+	for n := t.root; n != nil; {
+		if t.compare(n.key, key) >= 0 {
+			best, n = n, n.left
+		} else {
+			n = n.right
+		}
+	}
+	return entry(best)
+}
+
+// entry returns the key and value of n, or ok false if n is nil
+func entry[K, V any](n *node[K, V]) (key K, value V, ok bool) {
+	if n == nil {
+		return key, value, false
+	}
+	return n.key, n.value, true
+}
+
+// leftmost returns the node with the least key below n
+func leftmost[K, V any](n *node[K, V]) *node[K, V] {
+	for n.left != nil {
+		n = n.left
+	}
+	return n
+}
+
+// All iterates over the keys and values in increasing key order. The tree
+// must not be modified during the iteration.
+// Time Complexity: O(n) for the whole iteration, Space Complexity: O(log n)
+func (t *tree[K, V]) All() iter.Seq2[K, V] {
+	return t.ascend(nil, nil)
+}
+
+// Range iterates over the keys in [lo, hi), with their values, in
+// increasing key order. The tree must not be modified during the
+// iteration.
+// Time Complexity: O(log n + k) for k keys, Space Complexity: O(log n)
+func (t *tree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return t.ascend(&lo, &hi)
+}
+
+// ascend iterates over the keys from lo up to hi, either bound being
+// absent if nil, with a stack holding the path of nodes still to visit
+func (t *tree[K, V]) ascend(lo, hi *K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		stack := []*node[K, V]{}
+		for n := t.root; n != nil; {
+			if lo != nil && t.compare(n.key, *lo) < 0 {
+				n = n.right
+			} else {
+				stack = append(stack, n)
+				n = n.left
+			}
+		}
+		for len(stack) > 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if hi != nil && t.compare(n.key, *hi) >= 0 {
+				return
+			}
+			if !yield(n.key, n.value) {
+				return
+			}
+			for c := n.right; c != nil; c = c.left {
+				stack = append(stack, c)
+			}
+		}
+	}
+}
+
+// Height returns the number of nodes on the longest path from the root, 0
+// for an empty tree
+// Time Complexity: O(n), Space Complexity: O(log n)
+func (t *tree[K, V]) Height() int {
+	var height func(n *node[K, V]) int
+	height = func(n *node[K, V]) int {
+		if n == nil {
+			return 0
+		}
+		return 1 + max(height(n.left), height(n.right))
+	}
+	return height(t.root)
+}
+
+// checkOrder checks that the keys strictly increase in order and that
+// there are Len of them
+func (t *tree[K, V]) checkOrder() error {
+	count := 0
+	var prev K
+	for key := range t.All() {
+		if count > 0 && t.compare(prev, key) >= 0 {
+			return fmt.Errorf("%w: key %v follows %v", ErrInvariant, key, prev)
+		}
+		prev = key
+		count++
+	}
+	if count != t.size {
+		return fmt.Errorf("%w: %d keys, but Len is %d", ErrInvariant, count, t.size)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package trees
+
+import (
+	"cmp"
+	"errors"
+	"maps"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// checker is an ordered map that can check its invariants
+type checker interface {
+	OrderedMap[int, int]
+	Check() error
+	Height() int
+}
+
+// implementations returns a fresh tree of each kind
+func implementations() map[string]func() checker {
+	return map[string]func() checker{
+		"avl":      func() checker { return NewAVL[int, int]() },
+		"redblack": func() checker { return NewRedBlack[int, int]() },
+	}
+}
+
+// TestRandomOperations tests inserts, deletes and queries against a map,
+// checking the invariants after every change
+func TestRandomOperations(t *testing.T) {
+	for name, newTree := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(1, 2))
+			tr := newTree()
+			want := map[int]int{}
+			for step := range 5000 {
+				key := rng.IntN(300)
+				if rng.IntN(3) == 0 {
+					_, present := want[key]
+					delete(want, key)
+					if got := tr.Delete(key); got != present {
+						t.Fatalf("step %d: Delete(%d) = %t, want %t", step, key, got, present)
+					}
+				} else {
+					_, present := want[key]
+					want[key] = step
+					if got := tr.Insert(key, step); got == present {
+						t.Fatalf("step %d: Insert(%d) = %t, want %t", step, key, got, !present)
+					}
+				}
+				if err := tr.Check(); err != nil {
+					t.Fatalf("step %d: %v", step, err)
+				}
+				if tr.Len() != len(want) {
+					t.Fatalf("step %d: Len = %d, want %d", step, tr.Len(), len(want))
+				}
+				probe := rng.IntN(310) - 5
+				_, present := want[probe]
+				if v, ok := tr.Get(probe); v != want[probe] || ok != present {
+					t.Fatalf("step %d: Get(%d) = %d, %t", step, probe, v, ok)
+				}
+			}
+
+			keys := slices.Sorted(maps.Keys(want))
+			gotKeys := []int{}
+			for k, v := range tr.All() {
+				if v != want[k] {
+					t.Fatalf("All yields %d: %d, want %d", k, v, want[k])
+				}
+				gotKeys = append(gotKeys, k)
+			}
+			if !slices.Equal(gotKeys, keys) {
+				t.Fatalf("All yields %v, want %v", gotKeys, keys)
+			}
+		})
+	}
+}
+
+// TestQueries tests Min, Max, Floor, Ceiling and Range against a sorted
+// slice of the keys
+func TestQueries(t *testing.T) {
+	for name, newTree := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			tr := newTree()
+			if _, _, ok := tr.Min(); ok {
+				t.Error("Min of an empty tree reports a key")
+			}
+			if _, _, ok := tr.Max(); ok {
+				t.Error("Max of an empty tree reports a key")
+			}
+			rng := rand.New(rand.NewPCG(3, 4))
+			keys := []int{}
+			for range 200 {
+				k := rng.IntN(1000)
+				if tr.Insert(k, -k) {
+					keys = append(keys, k)
+				}
+			}
+			slices.Sort(keys)
+
+			if k, v, ok := tr.Min(); !ok || k != keys[0] || v != -k {
+				t.Errorf("Min = %d, %d, %t, want %d", k, v, ok, keys[0])
+			}
+			if k, _, ok := tr.Max(); !ok || k != keys[len(keys)-1] {
+				t.Errorf("Max = %d, %t, want %d", k, ok, keys[len(keys)-1])
+			}
+			for x := -5; x < 1005; x++ {
+				i, found := slices.BinarySearch(keys, x)
+				k, _, ok := tr.Ceiling(x)
+				if ok != (i < len(keys)) || (ok && k != keys[i]) {
+					t.Fatalf("Ceiling(%d) = %d, %t", x, k, ok)
+				}
+				if !found {
+					i--
+				}
+				k, _, ok = tr.Floor(x)
+				if ok != (i >= 0) || (ok && k != keys[i]) {
+					t.Fatalf("Floor(%d) = %d, %t", x, k, ok)
+				}
+			}
+
+			for range 300 {
+				lo, hi := rng.IntN(1100)-50, rng.IntN(1100)-50
+				want := []int{}
+				for _, k := range keys {
+					if lo <= k && k < hi {
+						want = append(want, k)
+					}
+				}
+				got := []int{}
+				for k := range tr.Range(lo, hi) {
+					got = append(got, k)
+				}
+				if !slices.Equal(got, want) {
+					t.Fatalf("Range(%d, %d) = %v, want %v", lo, hi, got, want)
+				}
+			}
+
+			// Stopping early
+			count := 0
+			for range tr.All() {
+				count++
+				if count == 3 {
+					break
+				}
+			}
+			if count != 3 {
+				t.Errorf("breaking out of All after 3 keys visited %d", count)
+			}
+		})
+	}
+}
+
+// TestHeight tests that sorted insertion, the worst case for an
+// unbalanced tree, stays within each tree's height bound
+func TestHeight(t *testing.T) {
+	const n = 1 << 14
+	avl, rb := NewAVL[int, int](), NewRedBlack[int, int]()
+	for i := range n {
+		avl.Insert(i, i)
+		rb.Insert(n-i, i)
+	}
+	log := bits.Len(n)
+	if h := avl.Height(); float64(h) > 1.45*float64(log) {
+		t.Errorf("AVL height %d for %d sorted keys exceeds 1.44 log n", h, n)
+	}
+	if h := rb.Height(); h > 2*log {
+		t.Errorf("red-black height %d for %d sorted keys exceeds 2 log n", h, n)
+	}
+	for i := range n / 2 {
+		avl.Delete(i)
+		rb.Delete(n - i)
+	}
+	if err := avl.Check(); err != nil {
+		t.Error(err)
+	}
+	if err := rb.Check(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestFunc tests trees ordered by a comparator, here reversing the order
+// of strings
+func TestFunc(t *testing.T) {
+	reverse := func(a, b string) int { return cmp.Compare(b, a) }
+	var m OrderedMap[string, int] = NewAVLFunc[string, int](reverse)
+	for i, s := range []string{"b", "d", "a", "c"} {
+		m.Insert(s, i)
+	}
+	got := []string{}
+	for k := range m.All() {
+		got = append(got, k)
+	}
+	if !slices.Equal(got, []string{"d", "c", "b", "a"}) {
+		t.Errorf("All in reverse order = %v", got)
+	}
+	m = NewRedBlackFunc[string, int](reverse)
+	m.Insert("x", 1)
+	if k, _, ok := m.Floor("a"); !ok || k != "x" {
+		t.Errorf("Floor(a) in reverse order = %q, %t, want x", k, ok)
+	}
+	if _, ok := NewMap[string, int]().(*RedBlackTree[string, int]); !ok {
+		t.Error("NewMap is not a red-black tree")
+	}
+}
+
+// TestCheck tests that Check reports trees broken by hand
+func TestCheck(t *testing.T) {
+	avl := NewAVL[int, int]()
+	rb := NewRedBlack[int, int]()
+	for i := range 10 {
+		avl.Insert(i, i)
+		rb.Insert(i, i)
+	}
+
+	avl.root.left.key = 100
+	if err := avl.Check(); !errors.Is(err, ErrInvariant) {
+		t.Errorf("Check of keys out of order = %v, want ErrInvariant", err)
+	}
+	avl.root.left.key = avl.root.key - 2
+	avl.root.height++
+	if err := avl.Check(); !errors.Is(err, ErrInvariant) {
+		t.Errorf("Check of a wrong height = %v, want ErrInvariant", err)
+	}
+	avl.root.height--
+	avl.size++
+	if err := avl.Check(); !errors.Is(err, ErrInvariant) {
+		t.Errorf("Check of a wrong size = %v, want ErrInvariant", err)
+	}
+
+	if err := rb.Check(); err != nil {
+		t.Fatal(err)
+	}
+	rb.root.red = true
+	if err := rb.Check(); !errors.Is(err, ErrInvariant) {
+		t.Errorf("Check of a red root = %v, want ErrInvariant", err)
+	}
+	rb.root.red = false
+	leaf := leftmost(rb.root)
+	leaf.red = !leaf.red
+	if err := rb.Check(); !errors.Is(err, ErrInvariant) {
+		t.Errorf("Check of a recolored leaf = %v, want ErrInvariant", err)
+	}
+}
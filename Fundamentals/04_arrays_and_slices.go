@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"slices"
+
+	"hellogolang/Advanced/xslices"
 )
 
 // Arrays and Slices demonstrates array and slice operations
@@ -173,52 +175,21 @@ func sliceCopying() {
 func sliceFiltering() {
 	numbers := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 
-	// Filter even numbers
-	evens := filterEven(numbers)
+	// Filter even numbers with the generic helpers of the xslices package
+	evens := xslices.Filter(numbers, func(n int) bool { return n%2 == 0 })
 	fmt.Printf("Original: %v\n", numbers)
 	fmt.Printf("Evens: %v\n", evens)
 
 	// Filter using function
 	isOdd := func(n int) bool { return n%2 != 0 }
-	odds := filter(numbers, isOdd)
+	odds := xslices.Filter(numbers, isOdd)
 	fmt.Printf("Odds: %v\n", odds)
 
 	// Map transformation
-	doubled := mapSlice(numbers, func(n int) int { return n * 2 })
+	doubled := xslices.Map(numbers, func(n int) int { return n * 2 })
 	fmt.Printf("Doubled: %v\n", doubled)
 }
 
-// filterEven filters even numbers from a slice
-func filterEven(numbers []int) []int {
-	var result []int
-	for _, n := range numbers {
-		if n%2 == 0 {
-			result = append(result, n)
-		}
-	}
-	return result
-}
-
-// filter filters a slice using a predicate function
-func filter(numbers []int, predicate func(int) bool) []int {
-	var result []int
-	for _, n := range numbers {
-		if predicate(n) {
-			result = append(result, n)
-		}
-	}
-	return result
-}
-
-// mapSlice transforms each element using a function
-func mapSlice(numbers []int, transform func(int) int) []int {
-	result := make([]int, len(numbers))
-	for i, n := range numbers {
-		result[i] = transform(n)
-	}
-	return result
-}
-
 // multidimensionalSlices demonstrates multi-dimensional slices
 func multidimensionalSlices() {
 	// 2D slice
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"maps"
 	"slices"
+
+	"hellogolang/Advanced/collections"
 )
 
 // Maps demonstrates map operations and patterns
@@ -170,37 +172,26 @@ func mapPatterns() {
 	}
 	fmt.Printf("Grouped by age: %v\n", byAge)
 
-	// Set simulation (map[string]bool or map[string]struct{})
-	set1 := map[string]bool{
-		"a": true,
-		"b": true,
-		"c": true,
-	}
-	set2 := map[string]bool{
-		"b": true,
-		"c": true,
-		"d": true,
-	}
+	// Sets: a map[string]struct{} works, but the collections package
+	// wraps one with the set operations
+	set1 := collections.NewSet("a", "b", "c")
+	set2 := collections.NewSet("b", "c", "d")
 
 	// Check membership
-	if set1["a"] {
+	if set1.Contains("a") {
 		fmt.Println("'a' is in set1")
 	}
 
-	// Intersection
-	intersection := make(map[string]bool)
-	for key := range set1 {
-		if set2[key] {
-			intersection[key] = true
-		}
-	}
-	fmt.Printf("Intersection: %v\n", intersection)
+	fmt.Printf("Intersection: %v\n", set1.Intersect(set2))
+	fmt.Printf("Union: %v\n", set1.Union(set2))
+	fmt.Printf("Difference: %v\n", set1.Difference(set2))
 
-	// Union
-	union := make(map[string]bool)
-	maps.Copy(union, set1)
-	maps.Copy(union, set2)
-	fmt.Printf("Union: %v\n", union)
+	// Ordered sets keep their values sorted; multisets count repeats
+	ordered := collections.NewOrderedSet(5, 1, 9, 3)
+	next, _ := ordered.Ceiling(4)
+	fmt.Printf("Ordered: %v, first at least 4: %d\n", ordered, next)
+	fruits := collections.NewMultiset(words...)
+	fmt.Printf("Count of apple: %d, most common: %v\n", fruits.Count("apple"), fruits.MostCommon(2))
 
 	// Map of maps
 	matrix := map[string]map[string]int{
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+
+	"hellogolang/Advanced/xslices"
 )
 
 // Generics demonstrates generic types and functions (Go 1.18+)
@@ -33,12 +35,12 @@ func genericFunctions() {
 	sum := add(10, 20)
 	fmt.Printf("add(10, 20) = %d\n", sum)
 
-	// Generic slice operations
+	// Generic slice operations, from the xslices package
 	numbers := []int{1, 2, 3, 4, 5}
-	doubled := mapSlice(numbers, func(x int) int { return x * 2 })
+	doubled := xslices.Map(numbers, func(x int) int { return x * 2 })
 	fmt.Printf("Doubled: %v\n", doubled)
 
-	filtered := filterSlice(numbers, func(x int) bool { return x%2 == 0 })
+	filtered := xslices.Filter(numbers, func(x int) bool { return x%2 == 0 })
 	fmt.Printf("Filtered evens: %v\n", filtered)
 }
 
@@ -63,26 +65,6 @@ func add[T Numeric](a, b T) T {
 	return a + b
 }
 
-// mapSlice applies a function to each element
-func mapSlice[T any](slice []T, fn func(T) T) []T {
-	result := make([]T, len(slice))
-	for i, v := range slice {
-		result[i] = fn(v)
-	}
-	return result
-}
-
-// filterSlice filters elements based on predicate
-func filterSlice[T any](slice []T, predicate func(T) bool) []T {
-	var result []T
-	for _, v := range slice {
-		if predicate(v) {
-			result = append(result, v)
-		}
-	}
-	return result
-}
-
 // genericTypes demonstrates generic types
 func genericTypes() {
 	// Generic stack
@@ -2,12 +2,17 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 	"time"
+
+	"hellogolang/Advanced/csvcodec"
+	"hellogolang/Advanced/jsonx"
 )
 
 // Standard Library demonstrates common standard library packages
@@ -18,6 +23,8 @@ func main() {
 	bytesPackage()
 	timePackage()
 	jsonPackage()
+	jsonStreaming()
+	csvPackage()
 	ioPackage()
 	osPackage()
 }
@@ -203,6 +210,136 @@ func jsonPackage() {
 	fmt.Printf("Map JSON: %s\n", string(jsonMap))
 }
 
+// jsonStreaming demonstrates the jsonx package: newline-delimited JSON,
+// decoding a large array one element at a time, JSON Pointer and JSON
+// Patch
+func jsonStreaming() {
+	type Event struct {
+		ID   int    `json:"id"`
+		Kind string `json:"kind"`
+	}
+
+	// NDJSON holds one value per line, so logs can be appended and read
+	// back a record at a time
+	var buf bytes.Buffer
+	w := jsonx.NewWriter[Event](&buf)
+	w.Write(Event{ID: 1, Kind: "login"})
+	w.Write(Event{ID: 2, Kind: "logout"})
+	w.Flush()
+	fmt.Printf("NDJSON:\n%s", buf.String())
+	for e, err := range jsonx.NewReader[Event](&buf, jsonx.ReaderOptions{}).All() {
+		if err != nil {
+			fmt.Printf("Read NDJSON: %v\n", err)
+			break
+		}
+		fmt.Printf("Read: %+v\n", e)
+	}
+	r := jsonx.NewReader[Event](strings.NewReader("{\"id\": 1}\n{\"id\": \"x\"}\n"), jsonx.ReaderOptions{})
+	var err error
+	for err == nil {
+		_, err = r.Read()
+	}
+	fmt.Printf("Bad line: %v\n", err)
+
+	// A top-level array is decoded element by element, so only one element
+	// is in memory at a time
+	count := 0
+	for e, err := range jsonx.Elements[Event](strings.NewReader(`[{"id": 1}, {"id": 2}, {"id": 3}]`)) {
+		if err != nil {
+			fmt.Printf("Decode array: %v\n", err)
+			break
+		}
+		count += e.ID
+	}
+	fmt.Printf("Sum of streamed IDs: %d\n", count)
+
+	// A JSON Pointer names a value within a document
+	var doc any
+	json.Unmarshal([]byte(`{"user": {"name": "Alice", "roles": ["admin", "dev"]}}`), &doc)
+	role, _ := jsonx.Resolve(doc, "/user/roles/1")
+	fmt.Printf("/user/roles/1 = %v\n", role)
+
+	// A JSON Patch is a list of edits; Diff finds one between two
+	// documents and ApplyJSON applies it, all or nothing
+	before := []byte(`{"name": "Alice", "roles": ["admin", "dev"], "active": true}`)
+	after := []byte(`{"name": "Alice", "roles": ["dev"], "active": false, "team": "core"}`)
+	patch, err := jsonx.DiffJSON(before, after)
+	if err != nil {
+		fmt.Printf("Diff: %v\n", err)
+		return
+	}
+	fmt.Printf("Patch: %s\n", patch)
+	result, err := jsonx.ApplyJSON(before, patch)
+	fmt.Printf("Applied: %s, %v\n", result, err)
+	_, err = jsonx.ApplyJSON(before, []byte(`[{"op": "remove", "path": "/roles/0"}, {"op": "test", "path": "/active", "value": false}]`))
+	fmt.Printf("Failed test: %v\n", err)
+}
+
+// csvPackage demonstrates encoding/csv, and the csvcodec package, which
+// maps rows to structs through csv tags as encoding/json does with json
+// tags
+func csvPackage() {
+	// Rows of strings with encoding/csv
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"name", "age"})
+	w.Write([]string{"Alice, Jr.", "30"})
+	w.Flush()
+
+	// Structs with csvcodec: columns are named by tags, times use a layout
+	// and a nil pointer is an empty cell
+	type Employee struct {
+		Name    string    `csv:"name"`
+		Age     int       `csv:"age"`
+		Hired   time.Time `csv:"hired,layout=2006-01-02"`
+		Manager *string   `csv:"manager"`
+		Salary  float64   `csv:"-"`
+	}
+	boss := "Alice"
+	employees := []Employee{
+		{Name: "Alice", Age: 30, Hired: time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "Bob", Age: 25, Hired: time.Date(2022, 9, 15, 0, 0, 0, 0, time.UTC), Manager: &boss, Salary: 1},
+	}
+	var buf bytes.Buffer
+	if err := csvcodec.Marshal(&buf, employees, csvcodec.Options{}); err != nil {
+		fmt.Printf("Marshal CSV: %v\n", err)
+		return
+	}
+	fmt.Printf("CSV:\n%s", buf.String())
+
+	// Columns are matched by name, so their order does not matter
+	input := "manager,hired,age,name\n,2020-01-06,41,Carol\nCarol,2024-02-29,22,Dan\n"
+	decoded, err := csvcodec.Unmarshal[Employee](strings.NewReader(input), csvcodec.Options{})
+	if err != nil {
+		fmt.Printf("Unmarshal CSV: %v\n", err)
+		return
+	}
+	for _, e := range decoded {
+		fmt.Printf("Decoded: %s, %d, hired %s, has manager: %t\n", e.Name, e.Age, e.Hired.Format(time.DateOnly), e.Manager != nil)
+	}
+
+	// The header is validated, and bad cells name their line and column
+	_, err = csvcodec.Unmarshal[Employee](strings.NewReader("name,age,hired\nEve,29,2020-01-01\n"), csvcodec.Options{})
+	fmt.Printf("Invalid header: %v\n", err)
+	_, err = csvcodec.Unmarshal[Employee](strings.NewReader("name,age,hired,manager\nEve,x,2020-01-01,\n"), csvcodec.Options{})
+	fmt.Printf("Invalid cell: %v\n", err)
+
+	// Large files stream row by row, through an iterator or a channel
+	total := 0
+	for e, err := range csvcodec.NewDecoder[Employee](strings.NewReader(input), csvcodec.Options{}).All() {
+		if err != nil {
+			fmt.Printf("Decode CSV: %v\n", err)
+			break
+		}
+		total += e.Age
+	}
+	rows, errc := csvcodec.NewDecoder[Employee](strings.NewReader(input), csvcodec.Options{}).Stream(context.Background(), 16)
+	count := 0
+	for range rows {
+		count++
+	}
+	fmt.Printf("Streamed %d rows, total age %d, error %v\n", count, total, <-errc)
+}
+
 // ioPackage demonstrates io package
 func ioPackage() {
 	// Copy
@@ -301,6 +438,7 @@ func standardLibrarySummary() {
 	fmt.Println("  - bytes: Byte slice operations")
 	fmt.Println("  - time: Time and date operations")
 	fmt.Println("  - encoding/json: JSON encoding/decoding")
+	fmt.Println("  - encoding/csv: CSV reading/writing")
 	fmt.Println("  - io: I/O primitives")
 	fmt.Println("  - os: OS interface")
 	fmt.Println("  - net/http: HTTP client/server")
@@ -1,35 +1,108 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
 
+	"hellogolang/Projects/Binutils/disasm"
 	"hellogolang/Projects/Binutils/elf"
 )
 
 // Objdump - Object file dumper (GNU objdump equivalent)
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <file>\n", os.Args[0])
+	options, files, err := parseObjdumpOptions(os.Args[1:])
+	if err != nil || len(files) == 0 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Usage: %s [-d|-D] [-j section] [--no-show-raw-insn] <file>...\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	file, err := os.Open(os.Args[1])
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	failed := false
+	for _, filename := range files {
+		if err := dumpFile(filename, options); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			failed = true
+		}
+	}
+
+	if failed {
 		os.Exit(1)
 	}
+}
+
+// ObjdumpOptions represents objdump options
+type ObjdumpOptions struct {
+	Disassemble    bool     // -d: disassemble executable sections
+	DisassembleAll bool     // -D: disassemble every section with contents
+	Sections       []string // -j: restrict output to the named sections
+	NoRawInsn      bool     // --no-show-raw-insn
+}
+
+// parseObjdumpOptions parses command line options and returns the input files
+func parseObjdumpOptions(args []string) (ObjdumpOptions, []string, error) {
+	opts := ObjdumpOptions{}
+	files := []string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-d" || arg == "--disassemble":
+			opts.Disassemble = true
+		case arg == "-D" || arg == "--disassemble-all":
+			opts.DisassembleAll = true
+		case arg == "--no-show-raw-insn":
+			opts.NoRawInsn = true
+		case arg == "-j" || arg == "--section":
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("option %s requires an argument", arg)
+			}
+			i++
+			opts.Sections = append(opts.Sections, args[i])
+		case strings.HasPrefix(arg, "--section="):
+			opts.Sections = append(opts.Sections, strings.TrimPrefix(arg, "--section="))
+		case strings.HasPrefix(arg, "-j") && len(arg) > 2:
+			opts.Sections = append(opts.Sections, arg[2:])
+		case strings.HasPrefix(arg, "-"):
+			return opts, nil, fmt.Errorf("unknown option: %s", arg)
+		default:
+			files = append(files, arg)
+		}
+	}
+
+	return opts, files, nil
+}
+
+// dumpFile opens one file and prints the requested information
+func dumpFile(filename string, options ObjdumpOptions) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
 	defer file.Close()
 
-	if err := dumpObject(file, os.Args[1]); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	if !options.Disassemble && !options.DisassembleAll {
+		return dumpObject(file, filename)
+	}
+
+	elfFile, err := elf.ParseELF(file)
+	if err != nil {
+		return fmt.Errorf("not an ELF file: %w", err)
 	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	return disassembleFile(out, elfFile, filename, options)
 }
 
 // dumpObject dumps object file information
-func dumpObject(r interface{ Seek(int64, int) (int64, error); Read([]byte) (int, error) }, filename string) error {
+func dumpObject(r io.ReadSeeker, filename string) error {
 	elfFile, err := elf.ParseELF(r)
 	if err != nil {
 		return fmt.Errorf("not an ELF file: %w", err)
@@ -99,3 +172,316 @@ func truncateString(s string, maxLen int) string {
 	}
 	return s[:maxLen]
 }
+
+// fileFormat returns the BFD-style target name of an ELF file
+func fileFormat(elfFile *elf.ELF) string {
+	switch elfFile.Header.Machine {
+	case 3:
+		return "elf32-i386"
+	case 62:
+		return "elf64-x86-64"
+	}
+	bits := "32"
+	if elfFile.Header.Class == 2 {
+		bits = "64"
+	}
+	if elfFile.Header.Data == 2 {
+		return "elf" + bits + "-big"
+	}
+	return "elf" + bits + "-little"
+}
+
+// label is a symbol name that marks an address in the disassembly
+type label struct {
+	addr uint64
+	name string
+	rank int // Lower ranks win when several symbols share an address
+}
+
+// symbolTable maps addresses back to symbol names, one sorted list of labels
+// per section
+type symbolTable struct {
+	elfFile  *elf.ELF
+	sections map[int][]label
+}
+
+// newSymbolTable collects the labels of every section. Functions are
+// preferred over other typed symbols, typed symbols over untyped ones and
+// global symbols over local ones.
+func newSymbolTable(elfFile *elf.ELF) *symbolTable {
+	table := &symbolTable{elfFile: elfFile, sections: map[int][]label{}}
+
+	for i, sym := range elfFile.Symbols {
+		symType := sym.Info & 0x0f
+		if i == 0 || sym.Name == "" || symType == 3 || symType == 4 { // STT_SECTION, STT_FILE
+			continue
+		}
+		if sym.Shndx == elf.SHN_UNDEF || sym.Shndx >= elf.SHN_LORESERVE {
+			continue
+		}
+
+		rank := 0
+		switch symType {
+		case 2, 10: // STT_FUNC, STT_GNU_IFUNC
+		case 0: // STT_NOTYPE
+			rank += 4
+		default:
+			rank += 2
+		}
+		if sym.Info>>4 == 0 { // STB_LOCAL
+			rank++
+		}
+		shndx := int(sym.Shndx)
+		table.sections[shndx] = append(table.sections[shndx], label{addr: sym.Value, name: sym.Name, rank: rank})
+	}
+	table.addPLTLabels()
+
+	for shndx, labels := range table.sections {
+		sort.SliceStable(labels, func(i, j int) bool {
+			if labels[i].addr != labels[j].addr {
+				return labels[i].addr < labels[j].addr
+			}
+			if labels[i].rank != labels[j].rank {
+				return labels[i].rank < labels[j].rank
+			}
+			return labels[i].name < labels[j].name
+		})
+
+		// Keep the preferred label at each address
+		unique := labels[:0]
+		for _, l := range labels {
+			if len(unique) == 0 || unique[len(unique)-1].addr != l.addr {
+				unique = append(unique, l)
+			}
+		}
+		table.sections[shndx] = unique
+	}
+
+	return table
+}
+
+// addPLTLabels labels each PLT entry name@plt, as GNU objdump does. An
+// entry jumps through a GOT slot, and the JUMP_SLOT or GLOB_DAT relocation
+// filling that slot names the function.
+func (t *symbolTable) addPLTLabels() {
+	arch, err := disasm.ArchForMachine(t.elfFile.Header.Machine)
+	if err != nil {
+		return
+	}
+
+	slots := map[uint64]string{}
+	for i := range t.elfFile.Sections {
+		section := &t.elfFile.Sections[i]
+		if section.Type != elf.SHT_RELA && section.Type != elf.SHT_REL {
+			continue
+		}
+		symbols := t.elfFile.RelocationSymbols(section)
+		relocations, err := t.elfFile.Relocations(section)
+		if err != nil {
+			continue
+		}
+		for _, rel := range relocations {
+			// JUMP_SLOT and GLOB_DAT share their numbers on x86 and x86-64
+			if rel.Type != elf.R_X86_64_JUMP_SLOT && rel.Type != elf.R_X86_64_GLOB_DAT {
+				continue
+			}
+			if rel.Symbol != 0 && int(rel.Symbol) < len(symbols) && symbols[rel.Symbol].Name != "" {
+				slots[rel.Offset] = symbols[rel.Symbol].Name
+			}
+		}
+	}
+	if len(slots) == 0 {
+		return
+	}
+
+	for i := range t.elfFile.Sections {
+		section := &t.elfFile.Sections[i]
+		if section.Name != ".plt" && section.Name != ".plt.sec" && section.Name != ".plt.got" {
+			continue
+		}
+		// Secure: limit section size
+		code, err := section.ReadAll(16 * 1024 * 1024)
+		if err != nil {
+			continue
+		}
+		for offset := 0; offset < len(code); {
+			addr := section.Addr + uint64(offset)
+			inst, err := disasm.Decode(arch, code[offset:], addr)
+			if err != nil {
+				break
+			}
+			if name, ok := slots[inst.Ref]; inst.HasRef && ok {
+				entry := addr
+				if section.EntSize > 0 {
+					entry -= uint64(offset) % section.EntSize
+				}
+				t.sections[i] = append(t.sections[i], label{addr: entry, name: name + "@plt"})
+			}
+			offset += inst.Len
+		}
+	}
+}
+
+// labelAt returns the label that starts at addr in a section
+func (t *symbolTable) labelAt(shndx int, addr uint64) (string, bool) {
+	labels := t.sections[shndx]
+	i := sort.Search(len(labels), func(i int) bool { return labels[i].addr >= addr })
+	if i < len(labels) && labels[i].addr == addr {
+		return labels[i].name, true
+	}
+	return "", false
+}
+
+// describe formats addr as <symbol+0xoffset>. Addresses in the current
+// section are resolved there first, as GNU objdump does; relocatable files
+// have overlapping section addresses, so no other section is searched.
+func (t *symbolTable) describe(current int, addr uint64) string {
+	shndx := current
+	section := &t.elfFile.Sections[current]
+	if addr < section.Addr || addr >= section.Addr+section.Size {
+		shndx = -1
+		if t.elfFile.Header.Type != 1 { // ET_REL
+			for i := range t.elfFile.Sections {
+				s := &t.elfFile.Sections[i]
+				if s.Flags&elf.SHF_ALLOC != 0 && addr >= s.Addr && addr < s.Addr+s.Size {
+					shndx = i
+					break
+				}
+			}
+		}
+		if shndx < 0 {
+			return ""
+		}
+	}
+
+	name, base := t.elfFile.Sections[shndx].Name, t.elfFile.Sections[shndx].Addr
+	labels := t.sections[shndx]
+	if i := sort.Search(len(labels), func(i int) bool { return labels[i].addr > addr }); i > 0 {
+		name, base = labels[i-1].name, labels[i-1].addr
+	}
+
+	if addr == base {
+		return fmt.Sprintf(" <%s>", name)
+	}
+	return fmt.Sprintf(" <%s+0x%x>", name, addr-base)
+}
+
+// disassembleFile prints the disassembly of the selected sections
+func disassembleFile(out *bufio.Writer, elfFile *elf.ELF, filename string, options ObjdumpOptions) error {
+	arch, err := disasm.ArchForMachine(elfFile.Header.Machine)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\n%s:     file format %s\n\n", filename, fileFormat(elfFile))
+
+	symbols := newSymbolTable(elfFile)
+	for i := range elfFile.Sections {
+		section := &elfFile.Sections[i]
+		if !shouldDisassemble(section, options) {
+			continue
+		}
+
+		// Secure: limit section size
+		code, err := section.ReadAll(256 * 1024 * 1024)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "\nDisassembly of section %s:\n", section.Name)
+		disassembleSection(out, arch, code, i, section, symbols, options)
+	}
+
+	return nil
+}
+
+// shouldDisassemble reports whether a section is selected by the options
+func shouldDisassemble(section *elf.Section, options ObjdumpOptions) bool {
+	if section.Type == elf.SHT_NOBITS || section.Type == elf.SHT_NULL || section.Size == 0 {
+		return false
+	}
+	if len(options.Sections) > 0 {
+		for _, name := range options.Sections {
+			if name == section.Name {
+				return true
+			}
+		}
+		return false
+	}
+	if options.DisassembleAll {
+		return section.Flags&elf.SHF_ALLOC != 0
+	}
+	return section.Flags&elf.SHF_EXECINSTR != 0
+}
+
+// bytesPerLine is the number of instruction bytes shown per line on x86
+const bytesPerLine = 7
+
+// disassembleSection prints the instructions of one section with symbol
+// labels, raw bytes and branch target annotations
+func disassembleSection(out *bufio.Writer, arch disasm.Arch, code []byte, shndx int, section *elf.Section, symbols *symbolTable, options ObjdumpOptions) {
+	// Leading zeros of the highest address are dropped in groups of four,
+	// always keeping at least one, to line the address column up
+	stop := fmt.Sprintf("%016x", section.Addr+section.Size)
+	skip := len(stop) - len(strings.TrimLeft(stop, "0"))
+	if skip > 0 {
+		skip = (skip - 1) &^ 3
+	}
+	address := func(addr uint64) string {
+		text := fmt.Sprintf("%016x", addr)[skip:]
+		trimmed := strings.TrimLeft(text, "0")
+		if trimmed == "" {
+			trimmed = "0"
+		}
+		return strings.Repeat(" ", len(text)-len(trimmed)) + trimmed
+	}
+
+	for offset := 0; offset < len(code); {
+		addr := section.Addr + uint64(offset)
+		name, ok := symbols.labelAt(shndx, addr)
+		if !ok && offset == 0 {
+			name, ok = section.Name, true
+		}
+		if ok {
+			fmt.Fprintf(out, "\n%016x <%s>:\n", addr, name)
+		}
+
+		inst, err := disasm.Decode(arch, code[offset:], addr)
+		if err != nil {
+			// Truncated instruction at the end of the section
+			inst = disasm.Inst{Addr: addr, Len: len(code) - offset, Op: "(bad)"}
+		}
+
+		raw := code[offset : offset+inst.Len]
+		text := inst.String()
+		if inst.HasTarget {
+			text += symbols.describe(shndx, inst.Target)
+		}
+		if inst.HasRef {
+			text += fmt.Sprintf("        # %x%s", inst.Ref, symbols.describe(shndx, inst.Ref))
+		}
+
+		if options.NoRawInsn {
+			fmt.Fprintf(out, "%s:\t%s\n", address(addr), text)
+		} else {
+			first := raw[:min(len(raw), bytesPerLine)]
+			fmt.Fprintf(out, "%s:\t%-*s\t%s\n", address(addr), bytesPerLine*3, hexBytes(first), text)
+			for rest := raw[len(first):]; len(rest) > 0; {
+				line := rest[:min(len(rest), bytesPerLine)]
+				fmt.Fprintf(out, "%s:\t%s\n", address(addr+uint64(len(raw)-len(rest))), hexBytes(line))
+				rest = rest[len(line):]
+			}
+		}
+
+		offset += inst.Len
+	}
+}
+
+// hexBytes formats bytes as space-terminated hex pairs
+func hexBytes(data []byte) string {
+	var sb strings.Builder
+	for _, b := range data {
+		fmt.Fprintf(&sb, "%02x ", b)
+	}
+	return sb.String()
+}
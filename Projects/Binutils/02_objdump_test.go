@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// TestPLTLabels tests the name@plt labels GNU objdump gives PLT entries,
+// through .rela.plt in a lazily bound program and through GLOB_DAT in the
+// .plt.got of testdata/hello
+func TestPLTLabels(t *testing.T) {
+	tests := []struct {
+		file    string
+		section string
+		addr    uint64
+		want    string
+	}{
+		{"dyn/runpath", ".plt", 0x1010, "greet@plt"},
+		{"dyn/lib/libgreet.so.1", ".plt", 0x1010, "far@plt"},
+		{"hello", ".plt.got", 0x1030, "__cxa_finalize@plt"},
+	}
+
+	for _, tt := range tests {
+		file, err := os.Open(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		e, err := elf.ParseELF(file)
+		if err != nil {
+			t.Fatalf("ParseELF(%s): %v", tt.file, err)
+		}
+
+		symbols := newSymbolTable(e)
+		plt, text := -1, -1
+		for i := range e.Sections {
+			switch e.Sections[i].Name {
+			case tt.section:
+				plt = i
+			case ".text":
+				text = i
+			}
+		}
+		if name, ok := symbols.labelAt(plt, tt.addr); !ok || name != tt.want {
+			t.Errorf("%s: label at 0x%x = %q, %v, want %q", tt.file, tt.addr, name, ok, tt.want)
+		}
+		// A call from .text names the entry it goes through
+		if got, want := symbols.describe(text, tt.addr), " <"+tt.want+">"; got != want {
+			t.Errorf("%s: call target 0x%x = %q, want %q", tt.file, tt.addr, got, want)
+		}
+		if got := symbols.describe(plt, tt.addr+6); got != " <"+tt.want+"+0x6>" {
+			t.Errorf("%s: 0x%x = %q, want %s+0x6", tt.file, tt.addr+6, got, tt.want)
+		}
+	}
+}
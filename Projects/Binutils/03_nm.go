@@ -1,99 +1,336 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"sort"
+	"strings"
 
+	"hellogolang/Projects/Binutils/arfile"
+	"hellogolang/Projects/Binutils/demangle"
 	"hellogolang/Projects/Binutils/elf"
+	"hellogolang/Projects/Binutils/filetype"
 )
 
 // Nm - List symbols from object files (GNU nm equivalent)
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <file> [options]\n", os.Args[0])
+	options, files, err := parseNmOptions(os.Args[1:])
+	if err != nil || len(files) == 0 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Usage: %s [-g] [-u] [-n|-p] [-r] [-S] [-a] [-C|--demangle] <file>...\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	file, err := os.Open(os.Args[1])
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	failed := false
+	for _, filename := range files {
+		if len(files) > 1 {
+			fmt.Printf("\n%s:\n", filename)
+		}
+		if err := listFile(filename, options); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			failed = true
+		}
+	}
+
+	if failed {
 		os.Exit(1)
 	}
-	defer file.Close()
+}
+
+// NmOptions represents nm options
+type NmOptions struct {
+	ExternOnly    bool // -g
+	UndefinedOnly bool // -u
+	NumericSort   bool // -n
+	NoSort        bool // -p
+	ReverseSort   bool // -r
+	PrintSize     bool // -S
+	DebugSyms     bool // -a
+	Demangle      bool // -C
+}
+
+// parseNmOptions parses command line options and returns the input files
+func parseNmOptions(args []string) (NmOptions, []string, error) {
+	opts := NmOptions{}
+	files := []string{}
+
+	for _, arg := range args {
+		switch arg {
+		case "-g", "--extern-only":
+			opts.ExternOnly = true
+		case "-u", "--undefined-only":
+			opts.UndefinedOnly = true
+		case "-n", "-v", "--numeric-sort":
+			opts.NumericSort = true
+		case "-p", "--no-sort":
+			opts.NoSort = true
+		case "-r", "--reverse-sort":
+			opts.ReverseSort = true
+		case "-S", "--print-size":
+			opts.PrintSize = true
+		case "-a", "--debug-syms":
+			opts.DebugSyms = true
+		case "-C", "--demangle":
+			opts.Demangle = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return opts, nil, fmt.Errorf("unknown option: %s", arg)
+			}
+			files = append(files, arg)
+		}
+	}
 
-	elfFile, err := elf.ParseELF(file)
+	return opts, files, nil
+}
+
+// listFile prints the symbols of an ELF file, or of each member of an ar
+// archive; input of any other format is refused by name
+func listFile(filename string, options NmOptions) error {
+	file, err := os.Open(filename)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return err
 	}
+	defer file.Close()
 
-	if err := listSymbols(elfFile); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	format, err := filetype.DetectReaderAt(file)
+	if err != nil {
+		return err
 	}
+	switch {
+	case format == nil:
+		return fmt.Errorf("file format not recognized")
+	case format.Name == "ar":
+		return listArchive(filename, file, options)
+	case format.Name != "ELF":
+		return fmt.Errorf("file format not recognized: %s is not an ELF object", format.Name)
+	}
+	return listObject(os.Stdout, file, options)
 }
 
-// listSymbols lists symbols in nm format
-func listSymbols(elfFile *elf.ELF) error {
+// listObject prints the symbols of an ELF object
+func listObject(out io.Writer, r io.ReadSeeker, options NmOptions) error {
+	elfFile, err := elf.ParseELF(r)
+	if err != nil {
+		return err
+	}
+
 	if len(elfFile.Symbols) == 0 {
-		return nil
+		return fmt.Errorf("no symbols")
 	}
 
-	// Sort symbols by value
-	symbols := make([]elf.Symbol, len(elfFile.Symbols))
-	copy(symbols, elfFile.Symbols)
+	for _, entry := range listSymbols(elfFile, options) {
+		fmt.Fprintln(out, entry)
+	}
 
-	sort.Slice(symbols, func(i, j int) bool {
-		return symbols[i].Value < symbols[j].Value
-	})
+	return nil
+}
 
-	// Print symbols
-	for _, sym := range symbols {
-		if sym.Name == "" {
+// listArchive prints the symbols of each ELF member of an archive under
+// its name, as GNU nm does. Members that cannot be listed are reported as
+// archive(member) and the rest are still listed.
+func listArchive(filename string, r io.Reader, options NmOptions) error {
+	ar, err := arfile.NewReader(r)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for h, err := range ar.Members() {
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(ar)
+		if err != nil {
+			return fmt.Errorf("%s: %w", h.Name, err)
+		}
+
+		if format := filetype.Detect(data[:min(len(data), filetype.HeaderSize)]); format == nil || format.Name != "ELF" {
+			fmt.Fprintf(os.Stderr, "%s(%s): file format not recognized\n", filename, h.Name)
+			failed++
 			continue
 		}
+		fmt.Printf("\n%s:\n", h.Name)
+		if err := listObject(os.Stdout, bytes.NewReader(data), options); err != nil {
+			fmt.Fprintf(os.Stderr, "%s(%s): %v\n", filename, h.Name, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("members not listed: %d", failed)
+	}
+	return nil
+}
+
+// nmSymbol is a symbol annotated with its nm type letter
+type nmSymbol struct {
+	elf.Symbol
+	Code byte
+}
+
+// listSymbols selects, sorts and formats symbols in nm format
+func listSymbols(elfFile *elf.ELF, options NmOptions) []string {
+	symbols := []nmSymbol{}
+	for i, sym := range elfFile.Symbols {
+		if i == 0 {
+			continue // Reserved null symbol
+		}
 
-		// Determine symbol type character
-		typeChar := byte('?')
-		switch sym.Type {
-		case "STT_NOTYPE":
-			typeChar = 'U' // Undefined
-		case "STT_OBJECT":
-			typeChar = 'D' // Data
-		case "STT_FUNC":
-			typeChar = 'T' // Text (code)
-		case "STT_SECTION":
-			typeChar = 'S' // Section
-		case "STT_FILE":
-			typeChar = 'A' // Absolute
-		}
-
-		// Determine binding
-		if sym.Binding == "STB_GLOBAL" {
-			typeChar = toUpper(typeChar)
-		} else if sym.Binding == "STB_WEAK" {
-			if typeChar == 'U' {
-				typeChar = 'w'
-			} else {
-				typeChar = toLower(typeChar)
+		symType := sym.Info & 0x0f
+		if !options.DebugSyms && (symType == 3 || symType == 4) { // STT_SECTION, STT_FILE
+			continue
+		}
+		if sym.Name == "" && symType != 3 {
+			continue
+		}
+
+		code := symbolCode(elfFile, sym)
+		if options.UndefinedOnly && code != 'U' && code != 'w' && code != 'v' {
+			continue
+		}
+		if options.ExternOnly && !isExternal(code) {
+			continue
+		}
+
+		if symType == 3 && sym.Name == "" && int(sym.Shndx) < len(elfFile.Sections) {
+			sym.Name = elfFile.Sections[sym.Shndx].Name
+		}
+		symbols = append(symbols, nmSymbol{Symbol: sym, Code: code})
+	}
+
+	if !options.NoSort {
+		sort.SliceStable(symbols, func(i, j int) bool {
+			a, b := symbols[i], symbols[j]
+			if options.NumericSort {
+				// Undefined symbols have no address and come first, as in GNU nm
+				aUndef, bUndef := a.Shndx == elf.SHN_UNDEF, b.Shndx == elf.SHN_UNDEF
+				if aUndef != bUndef {
+					return aUndef
+				}
+				if !aUndef && a.Value != b.Value {
+					return a.Value < b.Value
+				}
 			}
+			return a.Name < b.Name
+		})
+	}
+	if options.ReverseSort {
+		for i, j := 0, len(symbols)-1; i < j; i, j = i+1, j-1 {
+			symbols[i], symbols[j] = symbols[j], symbols[i]
+		}
+	}
+
+	width := 16
+	if elfFile.Class == "ELF32" {
+		width = 8
+	}
+
+	lines := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		name := sym.Name
+		if options.Demangle {
+			name = demangle.Filter(name)
+		}
+
+		undefined := sym.Shndx == elf.SHN_UNDEF
+		value := strings.Repeat(" ", width)
+		if !undefined {
+			value = fmt.Sprintf("%0*x", width, sym.Value)
 		}
 
-		// Print symbol
-		if sym.Value == 0 && sym.Type == "STT_NOTYPE" {
-			fmt.Printf("                 %c %s\n", typeChar, sym.Name)
+		if options.PrintSize && !undefined && sym.Size > 0 {
+			lines = append(lines, fmt.Sprintf("%s %0*x %c %s", value, width, sym.Size, sym.Code, name))
 		} else {
-			if elfFile.Class == "ELF64" {
-				fmt.Printf("%016x %c %s\n", sym.Value, typeChar, sym.Name)
-			} else {
-				fmt.Printf("%08x %c %s\n", uint32(sym.Value), typeChar, sym.Name)
+			lines = append(lines, fmt.Sprintf("%s %c %s", value, sym.Code, name))
+		}
+	}
+
+	return lines
+}
+
+// symbolCode returns the nm type letter for a symbol. Upper case means the
+// symbol is global, lower case that it is local.
+func symbolCode(elfFile *elf.ELF, sym elf.Symbol) byte {
+	binding := sym.Info >> 4
+	symType := sym.Info & 0x0f
+
+	if symType == 10 { // STT_GNU_IFUNC
+		return 'i'
+	}
+
+	if binding == 10 { // STB_GNU_UNIQUE
+		return 'u'
+	}
+
+	if binding == 2 { // STB_WEAK
+		if sym.Shndx == elf.SHN_UNDEF {
+			if symType == 1 { // STT_OBJECT
+				return 'v'
 			}
+			return 'w'
+		}
+		if symType == 1 {
+			return 'V'
 		}
+		return 'W'
 	}
 
-	return nil
+	var code byte
+	switch sym.Shndx {
+	case elf.SHN_UNDEF:
+		return 'U'
+	case elf.SHN_ABS:
+		code = 'a'
+	case elf.SHN_COMMON:
+		code = 'c'
+	default:
+		code = sectionCode(elfFile, sym.Shndx)
+	}
+
+	if binding == 1 && code != '?' { // STB_GLOBAL
+		code = toUpper(code)
+	}
+	return code
+}
+
+// sectionCode classifies a symbol by the section defining it the way GNU
+// nm does: code, then loaded data, then sections without contents, debugging
+// information, and finally other read-only sections such as .comment or the
+// SHT_GROUP section named by a COMDAT group signature
+func sectionCode(elfFile *elf.ELF, shndx uint16) byte {
+	if int(shndx) >= len(elfFile.Sections) {
+		return '?'
+	}
+
+	section := &elfFile.Sections[shndx]
+	writable := section.Flags&elf.SHF_WRITE != 0
+	switch {
+	case section.Flags&elf.SHF_EXECINSTR != 0:
+		return 't'
+	case section.Flags&elf.SHF_ALLOC != 0 && section.Type != elf.SHT_NOBITS:
+		if writable {
+			return 'd'
+		}
+		return 'r'
+	case section.Type == elf.SHT_NOBITS:
+		return 'b'
+	case section.IsDebug():
+		return 'N'
+	case !writable:
+		return 'n'
+	}
+
+	return '?'
+}
+
+// isExternal reports whether a type letter denotes an external symbol
+func isExternal(code byte) bool {
+	return (code >= 'A' && code <= 'Z') || code == 'w' || code == 'v' || code == 'u' || code == 'i'
 }
 
 // toUpper converts character to uppercase
@@ -103,11 +340,3 @@ func toUpper(c byte) byte {
 	}
 	return c
 }
-
-// toLower converts character to lowercase
-func toLower(c byte) byte {
-	if c >= 'A' && c <= 'Z' {
-		return c + 32
-	}
-	return c
-}
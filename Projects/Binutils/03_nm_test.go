@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// TestSymbolCode tests the type letter for each kind of section and each
+// symbol binding against GNU nm
+func TestSymbolCode(t *testing.T) {
+	e := &elf.ELF{Sections: []elf.Section{
+		{},
+		{Name: ".text", Type: elf.SHT_PROGBITS, Flags: elf.SHF_ALLOC | elf.SHF_EXECINSTR},
+		{Name: ".rodata", Type: elf.SHT_PROGBITS, Flags: elf.SHF_ALLOC},
+		{Name: ".data", Type: elf.SHT_PROGBITS, Flags: elf.SHF_ALLOC | elf.SHF_WRITE},
+		{Name: ".bss", Type: elf.SHT_NOBITS, Flags: elf.SHF_ALLOC | elf.SHF_WRITE},
+		{Name: ".debug_info", Type: elf.SHT_PROGBITS},
+		{Name: ".comment", Type: elf.SHT_PROGBITS, Flags: elf.SHF_MERGE | elf.SHF_STRINGS},
+		{Name: ".group", Type: elf.SHT_GROUP},
+		{Name: ".note.GNU-stack", Type: elf.SHT_PROGBITS},
+		{Name: ".note.gnu.property", Type: elf.SHT_NOTE, Flags: elf.SHF_ALLOC},
+		{Name: ".writable", Type: elf.SHT_PROGBITS, Flags: elf.SHF_WRITE},
+		{Name: ".text.stripped", Type: elf.SHT_NOBITS, Flags: elf.SHF_ALLOC | elf.SHF_EXECINSTR},
+	}}
+
+	const (
+		local  = 0 << 4
+		global = 1 << 4
+		weak   = 2 << 4
+		unique = 10 << 4
+
+		notype = 0
+		object = 1
+		fn     = 2
+		ifunc  = 10
+	)
+	tests := []struct {
+		info  byte
+		shndx uint16
+		want  byte
+	}{
+		{local | notype, 1, 't'},
+		{global | fn, 1, 'T'},
+		{global | object, 2, 'R'},
+		{local | object, 3, 'd'},
+		{global | object, 4, 'B'},
+		{local | notype, 5, 'N'},
+		{local | notype, 6, 'n'},
+		// The signature of a COMDAT group may be defined in its SHT_GROUP
+		// section, as g++ does for the C5 constructor
+		{local | notype, 7, 'n'},
+		{global | notype, 7, 'N'},
+		{local | notype, 8, 'n'},
+		{local | object, 9, 'r'},
+		{global | object, 10, '?'},
+		{global | fn, 11, 'T'},
+		{local | notype, 99, '?'},
+
+		{global | notype, elf.SHN_UNDEF, 'U'},
+		{weak | fn, elf.SHN_UNDEF, 'w'},
+		{weak | object, elf.SHN_UNDEF, 'v'},
+		{weak | fn, 1, 'W'},
+		{weak | object, 3, 'V'},
+		{global | ifunc, 1, 'i'},
+		{unique | object, 4, 'u'},
+		{local | notype, elf.SHN_ABS, 'a'},
+		{global | notype, elf.SHN_ABS, 'A'},
+		{global | object, elf.SHN_COMMON, 'C'},
+	}
+
+	for _, tt := range tests {
+		sym := elf.Symbol{Name: "sym", Info: tt.info, Shndx: tt.shndx}
+		if got := symbolCode(e, sym); got != tt.want {
+			t.Errorf("symbolCode(info 0x%02x, shndx %d) = %c, want %c", tt.info, tt.shndx, got, tt.want)
+		}
+	}
+}
+
+// TestListSymbols tests the listing of testdata/hello.o against GNU nm
+func TestListSymbols(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "hello.o"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	e, err := elf.ParseELF(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		options NmOptions
+		want    []string
+	}{
+		{NmOptions{}, []string{
+			"0000000000000000 W _Z5twiceIiET_S0_",
+			"0000000000000000 t _ZL4sfuni",
+			"0000000000000000 W _ZN2ns5PointC1Ei",
+			"0000000000000000 W _ZN2ns5PointC2Ei",
+			"0000000000000000 n _ZN2ns5PointC5Ei",
+			"0000000000000000 W _ZNK2ns5Point3getEv",
+			"0000000000000000 W _ZnwmPv",
+			"0000000000000000 B counter",
+			"000000000000000e T main",
+			"0000000000000000 D table",
+		}},
+		{NmOptions{ExternOnly: true, Demangle: true}, []string{
+			"0000000000000000 W int twice<int>(int)",
+			"0000000000000000 W ns::Point::Point(int)",
+			"0000000000000000 W ns::Point::Point(int)",
+			"0000000000000000 W ns::Point::get() const",
+			"0000000000000000 W operator new(unsigned long, void*)",
+			"0000000000000000 B counter",
+			"000000000000000e T main",
+			"0000000000000000 D table",
+		}},
+		{NmOptions{UndefinedOnly: true}, []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := listSymbols(e, tt.options); !slices.Equal(got, tt.want) {
+			t.Errorf("listSymbols(%+v):\ngot  %q\nwant %q", tt.options, got, tt.want)
+		}
+	}
+}
+
+// TestNumericSort tests -n on an object with undefined references, which
+// GNU nm lists before every defined symbol
+func TestNumericSort(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "ld", "start.o"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	e, err := elf.ParseELF(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"                 U counter",
+		"                 U helper",
+		"                 U value",
+		"0000000000000000 T _start",
+		"0000000000000000 d ptr",
+	}
+	if got := listSymbols(e, NmOptions{NumericSort: true}); !slices.Equal(got, want) {
+		t.Errorf("listSymbols(-n):\ngot  %q\nwant %q", got, want)
+	}
+	slices.Reverse(want)
+	if got := listSymbols(e, NmOptions{NumericSort: true, ReverseSort: true}); !slices.Equal(got, want) {
+		t.Errorf("listSymbols(-n -r):\ngot  %q\nwant %q", got, want)
+	}
+}
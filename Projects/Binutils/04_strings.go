@@ -5,47 +5,140 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"hellogolang/Projects/Binutils/elf"
 )
 
 // Strings - Print printable strings in files (GNU strings equivalent)
 
 func main() {
-	minLen := 4
-	args := os.Args[1:]
-
-	// Parse arguments
-	if len(args) == 0 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [-n length] <file>...\n", os.Args[0])
+	options, files, err := parseStringsOptions(os.Args[1:])
+	if err != nil || len(files) == 0 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Usage: %s [-a|-d] [-n length] [-t d|o|x] [-f] [-U default|locale] <file>...\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	files := []string{}
-	for i := 0; i < len(args); i++ {
-		if args[i] == "-n" && i+1 < len(args) {
-			// Secure: validate length
-			if n, err := parseInt(args[i+1]); err == nil && n > 0 && n <= 100 {
-				minLen = n
-			}
-			i++
-		} else {
-			files = append(files, args[i])
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	failed := false
+	for _, filename := range files {
+		if err := extractStrings(out, filename, options); err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			failed = true
 		}
 	}
 
-	if len(files) == 0 {
-		fmt.Fprintf(os.Stderr, "No files specified\n")
+	if failed {
+		out.Flush()
 		os.Exit(1)
 	}
+}
 
-	for _, filename := range files {
-		if err := extractStrings(filename, minLen); err != nil {
-			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+// StringsOptions represents strings options
+type StringsOptions struct {
+	MinLen    int  // -n
+	Radix     byte // -t: 0 (no offsets), 'd', 'o' or 'x'
+	DataOnly  bool // -d: scan only loadable, initialized ELF sections
+	PrintName bool // -f
+	UTF8      bool // -U locale: accept printable UTF-8 sequences
+}
+
+// parseStringsOptions parses command line options and returns the input files
+func parseStringsOptions(args []string) (StringsOptions, []string, error) {
+	opts := StringsOptions{MinLen: 4}
+	files := []string{}
+
+	// value returns the argument of an option given as "-x value" or "--opt=value"
+	value := func(i *int, name string) (string, error) {
+		if eq := strings.IndexByte(args[*i], '='); eq >= 0 && strings.HasPrefix(args[*i], "--") {
+			return args[*i][eq+1:], nil
+		}
+		if *i+1 >= len(args) {
+			return "", fmt.Errorf("option %s requires an argument", name)
 		}
+		*i++
+		return args[*i], nil
 	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name := arg
+		if eq := strings.IndexByte(arg, '='); eq >= 0 && strings.HasPrefix(arg, "--") {
+			name = arg[:eq]
+		}
+
+		switch name {
+		case "-a", "--all":
+			opts.DataOnly = false
+		case "-d", "--data":
+			opts.DataOnly = true
+		case "-f", "--print-file-name":
+			opts.PrintName = true
+		case "-o":
+			opts.Radix = 'o'
+		case "-n", "--bytes":
+			v, err := value(&i, name)
+			if err != nil {
+				return opts, nil, err
+			}
+			// Secure: validate length
+			n, err := parseInt(v)
+			if err != nil || n <= 0 {
+				return opts, nil, fmt.Errorf("invalid minimum string length: %s", v)
+			}
+			opts.MinLen = n
+		case "-t", "--radix":
+			v, err := value(&i, name)
+			if err != nil {
+				return opts, nil, err
+			}
+			if v != "d" && v != "o" && v != "x" {
+				return opts, nil, fmt.Errorf("invalid radix: %s", v)
+			}
+			opts.Radix = v[0]
+		case "-U", "--unicode":
+			v, err := value(&i, name)
+			if err != nil {
+				return opts, nil, err
+			}
+			switch v {
+			case "d", "default":
+				opts.UTF8 = false
+			case "l", "locale":
+				opts.UTF8 = true
+			default:
+				return opts, nil, fmt.Errorf("unsupported unicode mode: %s", v)
+			}
+		default:
+			// -<number> is shorthand for -n <number>
+			if len(arg) > 1 && arg[0] == '-' && arg[1] >= '0' && arg[1] <= '9' {
+				n, err := parseInt(arg[1:])
+				if err != nil || n <= 0 {
+					return opts, nil, fmt.Errorf("invalid minimum string length: %s", arg[1:])
+				}
+				opts.MinLen = n
+				continue
+			}
+			if strings.HasPrefix(arg, "-") {
+				return opts, nil, fmt.Errorf("unknown option: %s", arg)
+			}
+			files = append(files, arg)
+		}
+	}
+
+	return opts, files, nil
 }
 
 // extractStrings extracts printable strings from file
-func extractStrings(filename string, minLen int) error {
+func extractStrings(out io.Writer, filename string, options StringsOptions) error {
 	file, err := os.Open(filename)
 	if err != nil {
 		return err
@@ -64,35 +157,116 @@ func extractStrings(filename string, minLen int) error {
 		return fmt.Errorf("file too large: %d bytes", stat.Size())
 	}
 
-	reader := bufio.NewReader(file)
-	currentString := []byte{}
+	prefix := ""
+	if options.PrintName {
+		prefix = filename + ": "
+	}
+
+	if options.DataOnly {
+		// Files that are not ELF are scanned whole, as GNU strings does
+		if elfFile, err := elf.ParseELF(file); err == nil {
+			for _, section := range elfFile.Sections {
+				if section.Flags&elf.SHF_ALLOC == 0 || section.Type == elf.SHT_NOBITS || section.Size == 0 {
+					continue
+				}
+				if section.Offset+section.Size > uint64(stat.Size()) {
+					return fmt.Errorf("section %s extends past end of file", section.Name)
+				}
+				r := io.NewSectionReader(file, int64(section.Offset), int64(section.Size))
+				if err := scanStrings(out, r, int64(section.Offset), prefix, options); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return scanStrings(out, file, 0, prefix, options)
+}
+
+// scanStrings prints the printable runs of at least MinLen characters in r.
+// base is the file offset of the first byte of r.
+func scanStrings(out io.Writer, r io.Reader, base int64, prefix string, options StringsOptions) error {
+	reader := bufio.NewReader(r)
+	current := []byte{}
+	runes := 0
+	start := base
+	offset := base
+
+	flush := func() error {
+		if runes >= options.MinLen {
+			if err := printString(out, current, start, prefix, options.Radix); err != nil {
+				return err
+			}
+		}
+		current = current[:0]
+		runes = 0
+		return nil
+	}
 
 	for {
 		b, err := reader.ReadByte()
 		if err == io.EOF {
-			if len(currentString) >= minLen {
-				fmt.Printf("%s\n", string(currentString))
+			return flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		size := 1
+		printable := b == '\t' || (b >= 32 && b <= 126)
+
+		// Multi-byte UTF-8 sequences count as one printable character
+		if !printable && options.UTF8 && b >= 0xc0 {
+			if peek, _ := reader.Peek(utf8.UTFMax - 1); len(peek) > 0 {
+				seq := append([]byte{b}, peek...)
+				if ch, n := utf8.DecodeRune(seq); ch != utf8.RuneError && n > 1 && unicode.IsPrint(ch) {
+					printable = true
+					size = n
+					current = append(current, seq[:n]...)
+					reader.Discard(n - 1)
+				}
 			}
-			break
+		} else if printable {
+			current = append(current, b)
 		}
 
-		// Check if byte is printable ASCII
-		if b >= 32 && b <= 126 {
-			currentString = append(currentString, b)
-		} else {
-			if len(currentString) >= minLen {
-				fmt.Printf("%s\n", string(currentString))
+		if printable {
+			if runes == 0 {
+				start = offset
 			}
-			currentString = currentString[:0]
+			runes++
+		} else if err := flush(); err != nil {
+			return err
 		}
+		offset += int64(size)
 
 		// Secure: limit string length
-		if len(currentString) > 10000 {
-			currentString = currentString[:0]
+		if len(current) > 10000 {
+			if err := flush(); err != nil {
+				return err
+			}
 		}
 	}
+}
 
-	return nil
+// printString prints one string with the optional file name and offset
+func printString(out io.Writer, s []byte, offset int64, prefix string, radix byte) error {
+	var err error
+	switch radix {
+	case 'd':
+		_, err = fmt.Fprintf(out, "%s%7d %s\n", prefix, offset, s)
+	case 'o':
+		_, err = fmt.Fprintf(out, "%s%7o %s\n", prefix, offset, s)
+	case 'x':
+		_, err = fmt.Fprintf(out, "%s%7x %s\n", prefix, offset, s)
+	default:
+		_, err = fmt.Fprintf(out, "%s%s\n", prefix, s)
+	}
+	return err
 }
 
 // parseInt safely parses integer
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"bytes"
+	"io"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -22,10 +25,47 @@ func TestExtractStrings(t *testing.T) {
 	tmpfile.Close()
 
 	// Test extraction
-	err = extractStrings(tmpfile.Name(), 4)
+	var out bytes.Buffer
+	err = extractStrings(&out, tmpfile.Name(), StringsOptions{MinLen: 4})
 	if err != nil {
 		t.Errorf("extractStrings failed: %v", err)
 	}
+	if out.String() != "Hello\nWorld\nTest123\n" {
+		t.Errorf("extractStrings output = %q", out.String())
+	}
+}
+
+// TestScanStringsOffsets tests offset printing and UTF-8 runs
+func TestScanStringsOffsets(t *testing.T) {
+	tests := []struct {
+		data     string
+		options  StringsOptions
+		expected string
+	}{
+		{"ab\x00hello\x01", StringsOptions{MinLen: 4, Radix: 'x'}, "      3 hello\n"},
+		{"\x00\x00\x00\x00\x00\x00\x00\x00tab\tbed", StringsOptions{MinLen: 4, Radix: 'o'}, "     10 tab\tbed\n"},
+		{"h\xc3\xa9llo\x00", StringsOptions{MinLen: 4}, ""},
+		{"h\xc3\xa9llo\x00", StringsOptions{MinLen: 4, UTF8: true}, "h\u00e9llo\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := scanStrings(&out, strings.NewReader(tt.data), 0, "", tt.options); err != nil {
+			t.Fatalf("scanStrings(%q) failed: %v", tt.data, err)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("scanStrings(%q) = %q, expected %q", tt.data, out.String(), tt.expected)
+		}
+	}
+
+	// The base offset is added to reported positions
+	var out bytes.Buffer
+	if err := scanStrings(&out, io.LimitReader(strings.NewReader("data"), 4), 100, "f: ", StringsOptions{MinLen: 4, Radix: 'd'}); err != nil {
+		t.Fatalf("scanStrings failed: %v", err)
+	}
+	if out.String() != "f:     100 data\n" {
+		t.Errorf("scanStrings with base = %q", out.String())
+	}
 }
 
 // TestParseInt tests integer parsing
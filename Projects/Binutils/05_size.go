@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 
 	"hellogolang/Projects/Binutils/elf"
 )
@@ -10,20 +13,101 @@ import (
 // Size - List section sizes (GNU size equivalent)
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <file>...\n", os.Args[0])
+	options, files, err := parseSizeOptions(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Usage: %s [-A|-B] [-d|-o|-x|--radix=N] [-t] [file...]\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	for _, filename := range os.Args[1:] {
-		if err := showSize(filename); err != nil {
+	if len(files) == 0 {
+		files = []string{"a.out"}
+	}
+
+	if options.Format == FormatBerkeley {
+		printBerkeleyHeader(options)
+	}
+
+	failed := false
+	var totals SizeSummary
+	for _, filename := range files {
+		if err := showSize(filename, options, &totals); err != nil {
 			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			failed = true
 		}
 	}
+
+	if options.Totals && options.Format == FormatBerkeley {
+		printBerkeleyRow(totals, "(TOTALS)", options)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// SizeFormat selects the output layout
+type SizeFormat int
+
+const (
+	// FormatBerkeley prints one text/data/bss line per file
+	FormatBerkeley SizeFormat = iota
+	// FormatSysV prints a per-section table for each file
+	FormatSysV
+)
+
+// SizeOptions represents size options
+type SizeOptions struct {
+	Format SizeFormat
+	Radix  int  // 8, 10 or 16
+	Totals bool // -t
 }
 
-// showSize shows section sizes
-func showSize(filename string) error {
+// SizeSummary holds the Berkeley totals of one or more files
+type SizeSummary struct {
+	Text uint64
+	Data uint64
+	BSS  uint64
+}
+
+// parseSizeOptions parses command line options and returns the input files
+func parseSizeOptions(args []string) (SizeOptions, []string, error) {
+	opts := SizeOptions{Format: FormatBerkeley, Radix: 10}
+	files := []string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-A" || arg == "--format=sysv" || arg == "--format=SysV":
+			opts.Format = FormatSysV
+		case arg == "-B" || arg == "--format=berkeley":
+			opts.Format = FormatBerkeley
+		case arg == "-d":
+			opts.Radix = 10
+		case arg == "-o":
+			opts.Radix = 8
+		case arg == "-x":
+			opts.Radix = 16
+		case arg == "-t" || arg == "--totals":
+			opts.Totals = true
+		case strings.HasPrefix(arg, "--radix="):
+			radix, err := strconv.Atoi(strings.TrimPrefix(arg, "--radix="))
+			if err != nil || (radix != 8 && radix != 10 && radix != 16) {
+				return opts, nil, fmt.Errorf("invalid radix: %s", arg)
+			}
+			opts.Radix = radix
+		case strings.HasPrefix(arg, "-"):
+			return opts, nil, fmt.Errorf("unknown option: %s", arg)
+		default:
+			files = append(files, arg)
+		}
+	}
+
+	return opts, files, nil
+}
+
+// showSize prints the sizes of one file and adds them to totals
+func showSize(filename string, options SizeOptions, totals *SizeSummary) error {
 	file, err := os.Open(filename)
 	if err != nil {
 		return err
@@ -35,24 +119,135 @@ func showSize(filename string) error {
 		return fmt.Errorf("not an ELF file: %w", err)
 	}
 
-	var textSize, dataSize, bssSize uint64
+	if options.Format == FormatSysV {
+		printSysV(os.Stdout, elfFile, filename, options)
+		return nil
+	}
+
+	summary := summarizeSections(elfFile)
+	totals.Text += summary.Text
+	totals.Data += summary.Data
+	totals.BSS += summary.BSS
+
+	printBerkeleyRow(summary, filename, options)
+	return nil
+}
+
+// summarizeSections classifies allocated sections into text, data and bss
+// the way GNU size does: code and read-only sections count as text, even
+// without contents as in separate debug files, other sections with contents
+// as data and the rest as bss
+func summarizeSections(elfFile *elf.ELF) SizeSummary {
+	var summary SizeSummary
+
+	for _, section := range elfFile.Sections {
+		if section.Flags&elf.SHF_ALLOC == 0 {
+			continue
+		}
+		switch {
+		case section.Flags&elf.SHF_EXECINSTR != 0 || section.Flags&elf.SHF_WRITE == 0:
+			summary.Text += section.Size
+		case section.Type != elf.SHT_NOBITS:
+			summary.Data += section.Size
+		default:
+			summary.BSS += section.Size
+		}
+	}
+
+	return summary
+}
+
+// printBerkeleyHeader prints the Berkeley column titles
+func printBerkeleyHeader(options SizeOptions) {
+	dec := "dec"
+	if options.Radix == 8 {
+		dec = "oct"
+	}
+	fmt.Printf("%7s\t%7s\t%7s\t%7s\t%7s\t%s\n", "text", "data", "bss", dec, "hex", "filename")
+}
+
+// printBerkeleyRow prints one Berkeley line
+func printBerkeleyRow(summary SizeSummary, name string, options SizeOptions) {
+	total := summary.Text + summary.Data + summary.BSS
+
+	dec := strconv.FormatUint(total, 10)
+	if options.Radix == 8 {
+		dec = strconv.FormatUint(total, 8)
+	}
+
+	fmt.Printf("%7s\t%7s\t%7s\t%7s\t%7x\t%s\n",
+		formatSize(summary.Text, options.Radix),
+		formatSize(summary.Data, options.Radix),
+		formatSize(summary.BSS, options.Radix),
+		dec, total, name)
+}
+
+// sysvSection is one row of the SysV table
+type sysvSection struct {
+	name string
+	size string
+	addr string
+}
+
+// printSysV prints the per-section SysV table of one file. As in GNU size,
+// the name column fits the section names only, so a short name such as
+// .text leaves "section" wider than its column.
+func printSysV(w io.Writer, elfFile *elf.ELF, filename string, options SizeOptions) {
+	rows := []sysvSection{}
+	var total uint64
 
 	for _, section := range elfFile.Sections {
-		switch section.Name {
-		case ".text", ".init", ".fini":
-			textSize += section.Size
-		case ".data", ".rodata", ".sdata":
-			dataSize += section.Size
-		case ".bss", ".sbss":
-			bssSize += section.Size
+		if !isSizedSection(&section) {
+			continue
 		}
+		rows = append(rows, sysvSection{
+			name: section.Name,
+			size: formatSize(section.Size, options.Radix),
+			addr: formatSize(section.Addr, options.Radix),
+		})
+		total += section.Size
 	}
 
-	total := textSize + dataSize + bssSize
+	totalText := formatSize(total, options.Radix)
+	nameWidth, sizeWidth, addrWidth := 0, max(len("size"), len(totalText)), len("addr")
+	for _, row := range rows {
+		nameWidth = max(nameWidth, len(row.name))
+		sizeWidth = max(sizeWidth, len(row.size))
+		addrWidth = max(addrWidth, len(row.addr))
+	}
 
-	fmt.Printf("   text    data     bss     dec     hex filename\n")
-	fmt.Printf("%7d %7d %7d %7d %7x %s\n",
-		textSize, dataSize, bssSize, total, total, filename)
+	fmt.Fprintf(w, "%s  :\n", filename)
+	fmt.Fprintf(w, "%-*s   %*s   %*s\n", nameWidth, "section", sizeWidth, "size", addrWidth, "addr")
+	for _, row := range rows {
+		fmt.Fprintf(w, "%-*s   %*s   %*s\n", nameWidth, row.name, sizeWidth, row.size, addrWidth, row.addr)
+	}
+	fmt.Fprintf(w, "%-*s   %*s\n\n\n", nameWidth, "Total", sizeWidth, totalText)
+}
 
-	return nil
+// isSizedSection reports whether a section is listed by the SysV format.
+// Linker bookkeeping (symbol, string and relocation tables) is skipped unless
+// it is loaded at run time; section groups are listed, as GNU size does.
+func isSizedSection(section *elf.Section) bool {
+	if section.Flags&elf.SHF_ALLOC != 0 {
+		return true
+	}
+
+	switch section.Type {
+	case elf.SHT_NULL, elf.SHT_SYMTAB, elf.SHT_STRTAB, elf.SHT_REL, elf.SHT_RELA,
+		elf.SHT_SYMTAB_SHNDX:
+		return false
+	}
+
+	return true
+}
+
+// formatSize formats a value in the selected radix
+func formatSize(value uint64, radix int) string {
+	switch radix {
+	case 8:
+		return "0" + strconv.FormatUint(value, 8)
+	case 16:
+		return "0x" + strconv.FormatUint(value, 16)
+	}
+	return strconv.FormatUint(value, 10)
 }
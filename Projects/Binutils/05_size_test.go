@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// parseFixture parses a file in testdata
+func parseFixture(t *testing.T, name string) *elf.ELF {
+	t.Helper()
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	e, err := elf.ParseELF(file)
+	if err != nil {
+		t.Fatalf("ParseELF(%s): %v", name, err)
+	}
+	return e
+}
+
+// TestSummarizeSections tests the Berkeley text, data and bss totals of the
+// fixtures against GNU size
+func TestSummarizeSections(t *testing.T) {
+	tests := []struct {
+		file string
+		want SizeSummary
+	}{
+		{"hello.o", SizeSummary{Text: 398, Data: 16, BSS: 4}},
+		{"hello", SizeSummary{Text: 1584, Data: 544, BSS: 8}},
+		{"ld/start.o", SizeSummary{Text: 29, Data: 8, BSS: 0}},
+		{"dyn/lib/libgreet.so.1", SizeSummary{Text: 371, Data: 304, BSS: 0}},
+	}
+	for _, tt := range tests {
+		if got := summarizeSections(parseFixture(t, tt.file)); got != tt.want {
+			t.Errorf("%s: %+v, want %+v", tt.file, got, tt.want)
+		}
+	}
+
+	// objcopy --only-keep-debug turns every loaded section into SHT_NOBITS;
+	// code and read-only data still count as text
+	e := parseFixture(t, "hello")
+	for i := range e.Sections {
+		if e.Sections[i].Flags&elf.SHF_ALLOC != 0 {
+			e.Sections[i].Type = elf.SHT_NOBITS
+		}
+	}
+	if got, want := summarizeSections(e), (SizeSummary{Text: 1584, Data: 0, BSS: 552}); got != want {
+		t.Errorf("hello with --only-keep-debug: %+v, want %+v", got, want)
+	}
+}
+
+// TestPrintSysV tests the SysV table against GNU size -A
+func TestPrintSysV(t *testing.T) {
+	tests := []struct {
+		file     string
+		radix    int
+		expected string
+	}{
+		// Section groups are listed; the name column fits the names only
+		{"ld/start.o", 10, "start.o  :\n" +
+			"section   size   addr\n" +
+			".text     29      0\n" +
+			".data      8      0\n" +
+			".bss       0      0\n" +
+			"Total     37\n\n\n"},
+		{"hello.o", 16, "hello.o  :\n" +
+			"section                      size   addr\n" +
+			".group                        0x8    0x0\n" +
+			".group                        0x8    0x0\n" +
+			".group                        0x8    0x0\n" +
+			".group                        0x8    0x0\n" +
+			".text                        0x67    0x0\n" +
+			".data                        0x10    0x0\n" +
+			".bss                          0x4    0x0\n" +
+			".text._ZnwmPv                0x12    0x0\n" +
+			".text._ZN2ns5PointC2Ei       0x17    0x0\n" +
+			".text._ZNK2ns5Point3getEv    0x10    0x0\n" +
+			".text._Z5twiceIiET_S0_        0xe    0x0\n" +
+			".comment                     0x28    0x0\n" +
+			".note.GNU-stack               0x0    0x0\n" +
+			".eh_frame                    0xe0    0x0\n" +
+			"Total                       0x1ea\n\n\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		printSysV(&out, parseFixture(t, tt.file), filepath.Base(tt.file), SizeOptions{Format: FormatSysV, Radix: tt.radix})
+		if out.String() != tt.expected {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tt.file, out.String(), tt.expected)
+		}
+	}
+}
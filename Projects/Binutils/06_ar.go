@@ -1,323 +1,391 @@
 package main
 
 import (
-	"bytes"
+	"bufio"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hellogolang/Projects/Binutils/arfile"
+	"hellogolang/Projects/Binutils/match"
+	"hellogolang/Projects/Binutils/safepath"
 )
 
 // Ar - Archive utility (GNU ar equivalent)
 
 func main() {
-	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <operation> <archive> [files...]\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "Operations: r (replace), t (table), x (extract), d (delete)\n")
+	options, archiveName, files, err := parseArOptions(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Usage: %s [-]<operation>[modifiers] [relpos] <archive> [files...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Operations: r (replace), m (move), t (table), x (extract), d (delete), s (write symbol index)\n")
+		fmt.Fprintf(os.Stderr, "Modifiers: c (create quietly), u (replace only newer files), v (verbose), s (write symbol index),\n")
+		fmt.Fprintf(os.Stderr, "           D (deterministic: zero timestamps and owners), U (record file metadata, default),\n")
+		fmt.Fprintf(os.Stderr, "           a/b/i <relpos> (insert after/before member relpos)\n")
 		os.Exit(1)
 	}
 
-	operation := os.Args[1]
-	archiveName := os.Args[2]
+	switch options.Operation {
+	case 'r':
+		err = createArchive(archiveName, files, options)
+	case 'm':
+		err = moveMembers(archiveName, files, options)
+	case 't':
+		err = listArchive(archiveName, files, options)
+	case 'x':
+		err = extractArchive(archiveName, files, options)
+	case 'd':
+		err = deleteFromArchive(archiveName, files, options)
+	case 's':
+		err = indexArchive(archiveName, options)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
 
-	switch operation {
-	case "r":
-		if len(os.Args) < 4 {
-			fmt.Fprintf(os.Stderr, "Error: no files specified\n")
-			os.Exit(1)
-		}
-		if err := createArchive(archiveName, os.Args[3:]); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
-	case "t":
-		if err := listArchive(archiveName); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
-	case "x":
-		files := []string{}
-		if len(os.Args) > 3 {
-			files = os.Args[3:]
-		}
-		if err := extractArchive(archiveName, files); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+// ArOptions represents ar options
+type ArOptions struct {
+	Operation     byte   // One of r, m, t, x, d, s
+	Create        bool   // c: do not warn when the archive is created
+	Update        bool   // u: replace only members older than their files
+	Verbose       bool   // v
+	Deterministic bool   // D: zero timestamps, uid/gid 0 and mode 644 in every header
+	Position      byte   // a, b or i: where r and m place members, 0 for the end
+	RelPos        string // Member named by a positional modifier
+}
+
+// parseArOptions parses the GNU-style command line: an operation letter
+// combined with modifiers ("rcs", "-tv"), an optional relpos member for the
+// positional modifiers, the archive and the member files
+func parseArOptions(args []string) (ArOptions, string, []string, error) {
+	opts := ArOptions{}
+	if len(args) == 0 {
+		return opts, "", nil, fmt.Errorf("no operation specified")
+	}
+
+	for _, c := range strings.TrimPrefix(args[0], "-") {
+		switch c {
+		case 'r', 'm', 't', 'x', 'd', 's':
+			// "s" is an operation on its own and a modifier of the others
+			if c == 's' && opts.Operation != 0 {
+				continue
+			}
+			if opts.Operation != 0 && opts.Operation != 's' {
+				return opts, "", nil, fmt.Errorf("two different operation options specified")
+			}
+			opts.Operation = byte(c)
+		case 'c':
+			opts.Create = true
+		case 'u':
+			opts.Update = true
+		case 'v':
+			opts.Verbose = true
+		case 'D':
+			opts.Deterministic = true
+		case 'U':
+			opts.Deterministic = false
+		case 'a', 'b', 'i':
+			opts.Position = byte(c)
+		default:
+			return opts, "", nil, fmt.Errorf("invalid modifier: %c", c)
 		}
-	case "d":
-		if len(os.Args) < 4 {
-			fmt.Fprintf(os.Stderr, "Error: no files specified\n")
-			os.Exit(1)
+	}
+	if opts.Operation == 0 {
+		return opts, "", nil, fmt.Errorf("no operation specified")
+	}
+	// Member dates are all zero in deterministic mode, so u cannot compare them
+	if opts.Deterministic && opts.Update {
+		fmt.Fprintf(os.Stderr, "ar: `u' modifier ignored since `D' is set\n")
+		opts.Update = false
+	}
+	args = args[1:]
+
+	if opts.Position != 0 {
+		if opts.Operation != 'r' && opts.Operation != 'm' {
+			return opts, "", nil, fmt.Errorf("modifier %c is only valid with r and m", opts.Position)
 		}
-		if err := deleteFromArchive(archiveName, os.Args[3:]); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+		if len(args) == 0 {
+			return opts, "", nil, fmt.Errorf("modifier %c requires a member name", opts.Position)
 		}
-	default:
-		fmt.Fprintf(os.Stderr, "Unknown operation: %s\n", operation)
-		os.Exit(1)
+		opts.RelPos = args[0]
+		args = args[1:]
 	}
-}
 
-// ArchiveHeader represents an archive member header
-type ArchiveHeader struct {
-	Name    string
-	Date    int64
-	UID     int
-	GID     int
-	Mode    int
-	Size    int64
-	EndChar [2]byte
+	if len(args) == 0 {
+		return opts, "", nil, fmt.Errorf("no archive specified")
+	}
+	archiveName, files := args[0], args[1:]
+	if len(files) == 0 && (opts.Operation == 'r' || opts.Operation == 'm' || opts.Operation == 'd') {
+		return opts, "", nil, fmt.Errorf("no files specified")
+	}
+	return opts, archiveName, files, nil
 }
 
-// createArchive creates or updates an archive
-func createArchive(archiveName string, files []string) error {
+// createArchive creates or updates an archive (ar r). Existing members
+// are replaced in place and new members are appended, or inserted next to
+// RelPos when a positional modifier is given.
+func createArchive(archiveName string, files []string, options ArOptions) error {
 	// Read existing archive if it exists
-	members := map[string]*ArchiveMember{}
+	var members []*arfile.Member
 	if _, err := os.Stat(archiveName); err == nil {
 		existing, err := readArchive(archiveName)
-		if err == nil {
-			for _, m := range existing {
-				members[m.Header.Name] = m
-			}
+		if err != nil {
+			return err
 		}
+		members = existing
+	} else if !options.Create {
+		fmt.Fprintf(os.Stderr, "ar: creating %s\n", archiveName)
 	}
 
 	// Add or replace files
+	added := []*arfile.Member{}
 	for _, filename := range files {
 		// Secure: validate filename
 		if len(filename) > 255 {
 			return fmt.Errorf("filename too long: %s", filename)
 		}
 
-		data, err := os.ReadFile(filename)
+		stat, err := os.Stat(filename)
 		if err != nil {
-			return fmt.Errorf("failed to read %s: %w", filename, err)
+			return fmt.Errorf("failed to stat %s: %w", filename, err)
+		}
+
+		// Members are stored under their base name, as GNU ar does
+		name := filepath.Base(filename)
+		index := findMember(members, name)
+		if index >= 0 && options.Update && stat.ModTime().Unix() <= members[index].Header.Date {
+			continue
 		}
 
 		// Secure: limit file size
-		if len(data) > 100*1024*1024 { // 100MB
+		if stat.Size() > 100*1024*1024 { // 100MB
 			return fmt.Errorf("file too large: %s", filename)
 		}
 
-		stat, err := os.Stat(filename)
+		data, err := os.ReadFile(filename)
 		if err != nil {
-			return fmt.Errorf("failed to stat %s: %w", filename, err)
+			return fmt.Errorf("failed to read %s: %w", filename, err)
 		}
 
-		members[filename] = &ArchiveMember{
-			Header: ArchiveHeader{
-				Name: filename,
+		member := &arfile.Member{
+			Header: arfile.Header{
+				Name: name,
 				Date: stat.ModTime().Unix(),
 				UID:  0,
 				GID:  0,
-				Mode: 0644,
+				Mode: int(stat.Mode().Perm()),
 				Size: int64(len(data)),
 			},
 			Data: data,
 		}
+
+		// Without a positional modifier a replaced member keeps its place
+		if index >= 0 && options.Position == 0 {
+			members[index] = member
+			verbosef(options, "r - %s\n", name)
+			continue
+		}
+		if index >= 0 {
+			members = append(members[:index], members[index+1:]...)
+			verbosef(options, "r - %s\n", name)
+		} else {
+			verbosef(options, "a - %s\n", name)
+		}
+		added = append(added, member)
 	}
 
 	// Write archive
-	return writeArchive(archiveName, members)
+	return writeArchive(archiveName, insertMembers(members, added, options), options)
 }
 
-// ArchiveMember represents an archive member
-type ArchiveMember struct {
-	Header ArchiveHeader
-	Data   []byte
-}
-
-// readArchive reads an archive file
-func readArchive(archiveName string) ([]*ArchiveMember, error) {
-	file, err := os.Open(archiveName)
+// moveMembers moves members to the end of the archive, or next to RelPos
+// when a positional modifier is given (ar m)
+func moveMembers(archiveName string, files []string, options ArOptions) error {
+	members, err := readArchive(archiveName)
 	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	// Read magic
-	magic := make([]byte, 8)
-	if _, err := file.Read(magic); err != nil {
-		return nil, fmt.Errorf("failed to read magic: %w", err)
+		return err
 	}
 
-	if string(magic) != "!<arch>\n" {
-		return nil, fmt.Errorf("invalid archive magic")
+	moved := []*arfile.Member{}
+	for _, name := range files {
+		index := findMember(members, name)
+		if index < 0 {
+			return fmt.Errorf("%s: no such member", name)
+		}
+		moved = append(moved, members[index])
+		members = append(members[:index], members[index+1:]...)
+		verbosef(options, "m - %s\n", name)
 	}
 
-	var members []*ArchiveMember
-
-	for {
-		// Read header (60 bytes)
-		headerBytes := make([]byte, 60)
-		n, err := file.Read(headerBytes)
-		if err == io.EOF || n < 60 {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to read header: %w", err)
-		}
+	return writeArchive(archiveName, insertMembers(members, moved, options), options)
+}
 
-		// Parse header
-		header := ArchiveHeader{}
-		header.Name = trimSpace(string(headerBytes[0:16]))
-		header.Date, _ = parseInt64(trimSpace(string(headerBytes[16:28])))
-		header.UID, _ = parseIntSafe(trimSpace(string(headerBytes[28:34])))
-		header.GID, _ = parseIntSafe(trimSpace(string(headerBytes[34:40])))
-		header.Mode, _ = parseIntOctal(trimSpace(string(headerBytes[40:48])))
-		header.Size, _ = parseInt64(trimSpace(string(headerBytes[48:58])))
-		copy(header.EndChar[:], headerBytes[58:60])
-
-		// Secure: validate size
-		if header.Size < 0 || header.Size > 100*1024*1024 {
-			return nil, fmt.Errorf("invalid member size: %d", header.Size)
+// insertMembers inserts members after (a) or before (b, i) the member named
+// RelPos. Members are appended when there is no positional modifier or
+// RelPos is not in the archive, as GNU ar does.
+func insertMembers(members, inserted []*arfile.Member, options ArOptions) []*arfile.Member {
+	at := len(members)
+	if options.Position != 0 {
+		if index := findMember(members, options.RelPos); index >= 0 {
+			at = index
+			if options.Position == 'a' {
+				at++
+			}
 		}
+	}
 
-		// Read data
-		data := make([]byte, header.Size)
-		if _, err := io.ReadFull(file, data); err != nil {
-			return nil, fmt.Errorf("failed to read member data: %w", err)
-		}
+	result := make([]*arfile.Member, 0, len(members)+len(inserted))
+	result = append(result, members[:at]...)
+	result = append(result, inserted...)
+	return append(result, members[at:]...)
+}
 
-		// Skip padding (even byte boundary)
-		if header.Size%2 != 0 {
-			file.Read(make([]byte, 1))
+// findMember returns the index of the first member with the given name, or -1
+func findMember(members []*arfile.Member, name string) int {
+	for i, member := range members {
+		if member.Header.Name == name {
+			return i
 		}
-
-		members = append(members, &ArchiveMember{
-			Header: header,
-			Data:   data,
-		})
 	}
+	return -1
+}
 
-	return members, nil
+// verbosef prints a progress line in verbose mode
+func verbosef(options ArOptions, format string, args ...interface{}) {
+	if options.Verbose {
+		fmt.Printf(format, args...)
+	}
 }
 
-// writeArchive writes an archive file
-func writeArchive(archiveName string, members map[string]*ArchiveMember) error {
-	file, err := os.Create(archiveName)
+// readArchive reads an archive file. GNU extended names ("/N" entries
+// pointing into the "//" member) and BSD "#1/N" names are resolved; the
+// symbol index and the extended-name table are not returned as members.
+func readArchive(archiveName string) ([]*arfile.Member, error) {
+	file, err := os.Open(archiveName)
 	if err != nil {
-		return fmt.Errorf("failed to create archive: %w", err)
+		return nil, err
 	}
 	defer file.Close()
 
-	// Write magic
-	if _, err := file.WriteString("!<arch>\n"); err != nil {
-		return fmt.Errorf("failed to write magic: %w", err)
+	r, err := arfile.NewReader(file)
+	if err != nil {
+		return nil, err
 	}
 
-	// Write members
-	for _, member := range members {
-		// Write header
-		header := formatHeader(member.Header)
-		if _, err := file.Write(header); err != nil {
-			return fmt.Errorf("failed to write header: %w", err)
-		}
-
-		// Write data
-		if _, err := file.Write(member.Data); err != nil {
-			return fmt.Errorf("failed to write data: %w", err)
+	var members []*arfile.Member
+	for h, err := range r.Members() {
+		if err != nil {
+			return nil, err
 		}
-
-		// Write padding if needed
-		if len(member.Data)%2 != 0 {
-			if _, err := file.Write([]byte{'\n'}); err != nil {
-				return fmt.Errorf("failed to write padding: %w", err)
-			}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read member data: %w", err)
 		}
+		members = append(members, &arfile.Member{Header: *h, Data: data})
 	}
 
-	return nil
+	return members, nil
 }
 
-// formatHeader formats archive header
-func formatHeader(h ArchiveHeader) []byte {
-	header := make([]byte, 60)
-	copy(header[0:16], padString(h.Name, 16))
-	copy(header[16:28], padString(fmt.Sprintf("%d", h.Date), 12))
-	copy(header[28:34], padString(fmt.Sprintf("%d", h.UID), 6))
-	copy(header[34:40], padString(fmt.Sprintf("%d", h.GID), 6))
-	copy(header[40:48], padString(fmt.Sprintf("%o", h.Mode), 8))
-	copy(header[48:58], padString(fmt.Sprintf("%d", h.Size), 10))
-	copy(header[58:60], []byte("`\n"))
-	return header
-}
+// writeArchive writes an archive file with arfile.WriteArchive: a GNU
+// symbol index of the object members first, as GNU ar writes by default,
+// then the extended-name table and the members in the given order. In
+// deterministic mode equal inputs give byte-identical archives.
+func writeArchive(archiveName string, ordered []*arfile.Member, options ArOptions) error {
+	if options.Deterministic {
+		for _, member := range ordered {
+			h := &member.Header
+			h.Date, h.UID, h.GID, h.Mode = 0, 0, 0, 0644
+		}
+	}
 
-// padString pads string to specified length
-func padString(s string, length int) []byte {
-	result := make([]byte, length)
-	copy(result, []byte(s))
-	for i := len(s); i < length; i++ {
-		result[i] = ' '
+	file, err := os.Create(archiveName)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
 	}
-	return result
-}
+	defer file.Close()
 
-// trimSpace trims whitespace
-func trimSpace(s string) string {
-	start := 0
-	end := len(s)
-	for start < end && (s[start] == ' ' || s[start] == '\t') {
-		start++
+	w := bufio.NewWriter(file)
+	if err := arfile.WriteArchive(w, ordered); err != nil {
+		return err
 	}
-	for end > start && (s[end-1] == ' ' || s[end-1] == '\t') {
-		end--
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
 	}
-	return s[start:end]
-}
-
-// parseInt64 safely parses int64
-func parseInt64(s string) (int64, error) {
-	return strconv.ParseInt(s, 10, 64)
-}
-
-// parseIntSafe safely parses integer
-func parseIntSafe(s string) (int, error) {
-	return strconv.Atoi(s)
+	return nil
 }
 
-// parseIntOctal safely parses octal integer
-func parseIntOctal(s string) (int, error) {
-	return strconv.Atoi(s)
+// indexArchive rewrites an archive with a fresh symbol index (ar s)
+func indexArchive(archiveName string, options ArOptions) error {
+	members, err := readArchive(archiveName)
+	if err != nil {
+		return err
+	}
+	return writeArchive(archiveName, members, options)
 }
 
-// listArchive lists archive contents
-func listArchive(archiveName string) error {
+// listArchive lists archive contents, or only the named members. The
+// verbose listing has the mode, owner, size and date of each member, like
+// ar tv.
+func listArchive(archiveName string, files []string, options ArOptions) error {
+	filter, err := memberFilter(files)
+	if err != nil {
+		return err
+	}
 	members, err := readArchive(archiveName)
 	if err != nil {
 		return err
 	}
 
 	for _, member := range members {
-		fmt.Println(member.Header.Name)
+		if !filter.Empty() && !filter.Match(member.Header.Name) {
+			continue
+		}
+		if !options.Verbose {
+			fmt.Println(member.Header.Name)
+			continue
+		}
+		h := member.Header
+		mode := os.FileMode(h.Mode).Perm().String()[1:]
+		date := time.Unix(h.Date, 0).Format("Jan _2 15:04 2006")
+		fmt.Printf("%s %d/%d %6d %s %s\n", mode, h.UID, h.GID, h.Size, date, h.Name)
 	}
 
 	return nil
 }
 
 // extractArchive extracts files from archive
-func extractArchive(archiveName string, files []string) error {
+func extractArchive(archiveName string, files []string, options ArOptions) error {
+	filter, err := memberFilter(files)
+	if err != nil {
+		return err
+	}
 	members, err := readArchive(archiveName)
 	if err != nil {
 		return err
 	}
 
-	extractAll := len(files) == 0
-
 	for _, member := range members {
-		if extractAll || contains(files, member.Header.Name) {
+		if filter.Empty() || filter.Match(member.Header.Name) {
 			// Secure: validate filename
 			if len(member.Header.Name) == 0 || len(member.Header.Name) > 255 {
 				continue
 			}
 
 			// Secure: prevent path traversal
-			if containsPathTraversal(member.Header.Name) {
+			path, err := safepath.Join(".", member.Header.Name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ar: skipping %s: %v\n", member.Header.Name, err)
 				continue
 			}
 
-			if err := os.WriteFile(member.Header.Name, member.Data, os.FileMode(member.Header.Mode)); err != nil {
+			verbosef(options, "x - %s\n", member.Header.Name)
+			if err := os.WriteFile(path, member.Data, os.FileMode(member.Header.Mode)); err != nil {
 				return fmt.Errorf("failed to write %s: %w", member.Header.Name, err)
 			}
 		}
@@ -327,47 +395,36 @@ func extractArchive(archiveName string, files []string) error {
 }
 
 // deleteFromArchive deletes files from archive
-func deleteFromArchive(archiveName string, files []string) error {
-	members, err := readArchive(archiveName)
+func deleteFromArchive(archiveName string, files []string, options ArOptions) error {
+	filter, err := memberFilter(files)
 	if err != nil {
 		return err
 	}
-
-	// Create map of files to delete
-	deleteMap := make(map[string]bool)
-	for _, f := range files {
-		deleteMap[f] = true
+	members, err := readArchive(archiveName)
+	if err != nil {
+		return err
 	}
 
 	// Filter out deleted members
-	newMembers := make(map[string]*ArchiveMember)
+	newMembers := []*arfile.Member{}
 	for _, member := range members {
-		if !deleteMap[member.Header.Name] {
-			newMembers[member.Header.Name] = member
+		if filter.Match(member.Header.Name) {
+			verbosef(options, "d - %s\n", member.Header.Name)
+			continue
 		}
+		newMembers = append(newMembers, member)
 	}
 
-	return writeArchive(archiveName, newMembers)
+	return writeArchive(archiveName, newMembers, options)
 }
 
-// contains checks if slice contains string
-func contains(slice []string, s string) bool {
-	for _, item := range slice {
-		if item == s {
-			return true
-		}
-	}
-	return false
-}
-
-// containsPathTraversal checks for path traversal attacks
-func containsPathTraversal(path string) bool {
-	// Secure: check for dangerous patterns
-	dangerous := []string{"../", "..\\", "/", "\\"}
-	for _, pattern := range dangerous {
-		if bytes.Contains([]byte(path), []byte(pattern)) {
-			return true
-		}
+// memberFilter compiles the member names of t, x and d, which may be glob
+// patterns such as 'lib*.o', quoted to keep the shell from expanding them
+// against the current directory; a name starting with ! excludes members
+func memberFilter(files []string) (*match.Filter, error) {
+	filter, err := match.NewFilter(files...)
+	if err != nil {
+		return nil, fmt.Errorf("bad member pattern: %w", err)
 	}
-	return false
+	return filter, nil
 }
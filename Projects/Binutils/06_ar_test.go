@@ -1,8 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 // TestCreateArchive tests archive creation
@@ -28,13 +31,13 @@ func TestCreateArchive(t *testing.T) {
 	defer os.Remove(archiveName)
 
 	// Create archive
-	err = createArchive(archiveName, []string{tmpfile1.Name(), tmpfile2.Name()})
+	err = createArchive(archiveName, []string{tmpfile1.Name(), tmpfile2.Name()}, ArOptions{Operation: 'r', Create: true})
 	if err != nil {
 		t.Fatalf("createArchive failed: %v", err)
 	}
 
 	// List archive
-	err = listArchive(archiveName)
+	err = listArchive(archiveName, nil, ArOptions{Operation: 't'})
 	if err != nil {
 		t.Errorf("listArchive failed: %v", err)
 	}
@@ -66,23 +69,226 @@ func TestReadArchive(t *testing.T) {
 	}
 }
 
-// TestContainsPathTraversal tests path traversal detection
-func TestContainsPathTraversal(t *testing.T) {
-	tests := []struct {
-		path     string
-		expected bool
-	}{
-		{"../file.txt", true},
-		{"..\\file.txt", true},
-		{"/etc/passwd", true},
-		{"normal_file.txt", false},
-		{"subdir/file.txt", false},
-	}
-
-	for _, tt := range tests {
-		result := containsPathTraversal(tt.path)
-		if result != tt.expected {
-			t.Errorf("containsPathTraversal(%s) = %v, expected %v", tt.path, result, tt.expected)
+// TestLongNames tests that GNU extended names round-trip and that BSD
+// "#1/N" names are read
+func TestLongNames(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"short.o", "a_member_name_longer_than_sixteen.o"}
+	paths := []string{}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("data "+name), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
 		}
+		paths = append(paths, path)
+	}
+
+	archiveName := filepath.Join(dir, "long.a")
+	if err := createArchive(archiveName, paths, ArOptions{Operation: 'r', Create: true}); err != nil {
+		t.Fatalf("createArchive failed: %v", err)
+	}
+
+	members, err := readArchive(archiveName)
+	if err != nil {
+		t.Fatalf("readArchive failed: %v", err)
+	}
+	found := map[string]string{}
+	for _, m := range members {
+		found[m.Header.Name] = string(m.Data)
+	}
+	for _, name := range names {
+		if found[name] != "data "+name {
+			t.Errorf("member %q = %q, want %q", name, found[name], "data "+name)
+		}
+	}
+
+	// BSD archives store long names at the start of the member data
+	bsd := "!<arch>\n" + fmt.Sprintf("%-16s%-12s%-6s%-6s%-8s%-10s`\n", "#1/21", "0", "0", "0", "644", "25") +
+		"bsd_style_long_name.odata"
+	bsdName := filepath.Join(dir, "bsd.a")
+	if err := os.WriteFile(bsdName, []byte(bsd), 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	members, err = readArchive(bsdName)
+	if err != nil {
+		t.Fatalf("readArchive failed: %v", err)
+	}
+	if len(members) != 1 || members[0].Header.Name != "bsd_style_long_name.o" || string(members[0].Data) != "data" {
+		t.Errorf("BSD member not decoded: %+v", members)
+	}
+}
+
+// TestParseArOptions tests combined operation and modifier strings
+func TestParseArOptions(t *testing.T) {
+	opts, archive, files, err := parseArOptions([]string{"rcsv", "lib.a", "a.o"})
+	if err != nil {
+		t.Fatalf("parseArOptions failed: %v", err)
+	}
+	if opts.Operation != 'r' || !opts.Create || !opts.Verbose || archive != "lib.a" || len(files) != 1 {
+		t.Errorf("Unexpected result: %+v %s %v", opts, archive, files)
+	}
+
+	opts, archive, _, err = parseArOptions([]string{"-rb", "x.o", "lib.a", "a.o"})
+	if err != nil {
+		t.Fatalf("parseArOptions failed: %v", err)
+	}
+	if opts.Position != 'b' || opts.RelPos != "x.o" || archive != "lib.a" {
+		t.Errorf("Unexpected result: %+v %s", opts, archive)
+	}
+
+	for _, args := range [][]string{{"rt", "lib.a"}, {"rz", "lib.a", "a.o"}, {"ta", "x.o", "lib.a"}, {"r", "lib.a"}} {
+		if _, _, _, err := parseArOptions(args); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
+
+// TestMemberOrder tests that members keep their order and that positional
+// modifiers insert next to the named member
+func TestMemberOrder(t *testing.T) {
+	dir := t.TempDir()
+	paths := map[string]string{}
+	for _, name := range []string{"a.o", "b.o", "c.o", "d.o"} {
+		paths[name] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[name], []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	archiveName := filepath.Join(dir, "order.a")
+
+	order := func() []string {
+		members, err := readArchive(archiveName)
+		if err != nil {
+			t.Fatalf("readArchive failed: %v", err)
+		}
+		names := []string{}
+		for _, m := range members {
+			names = append(names, m.Header.Name)
+		}
+		return names
+	}
+	check := func(step string, expected ...string) {
+		names := order()
+		if len(names) != len(expected) {
+			t.Fatalf("%s: expected %v, got %v", step, expected, names)
+		}
+		for i := range names {
+			if names[i] != expected[i] {
+				t.Fatalf("%s: expected %v, got %v", step, expected, names)
+			}
+		}
+	}
+
+	opts := ArOptions{Operation: 'r', Create: true}
+	if err := createArchive(archiveName, []string{paths["c.o"], paths["a.o"]}, opts); err != nil {
+		t.Fatalf("createArchive failed: %v", err)
+	}
+	check("create", "c.o", "a.o")
+
+	// Replacing keeps the position; new members go to the end
+	if err := createArchive(archiveName, []string{paths["c.o"], paths["b.o"]}, opts); err != nil {
+		t.Fatalf("createArchive failed: %v", err)
+	}
+	check("replace", "c.o", "a.o", "b.o")
+
+	opts.Position, opts.RelPos = 'b', "a.o"
+	if err := createArchive(archiveName, []string{paths["d.o"]}, opts); err != nil {
+		t.Fatalf("createArchive failed: %v", err)
+	}
+	check("insert before", "c.o", "d.o", "a.o", "b.o")
+
+	opts = ArOptions{Operation: 'm', Position: 'a', RelPos: "b.o"}
+	if err := moveMembers(archiveName, []string{"c.o"}, opts); err != nil {
+		t.Fatalf("moveMembers failed: %v", err)
+	}
+	check("move after", "d.o", "a.o", "b.o", "c.o")
+
+	// With u, a member newer than its file is not replaced
+	if err := os.WriteFile(paths["a.o"], []byte("changed"), 0644); err != nil {
+		t.Fatalf("Failed to write a.o: %v", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(paths["a.o"], old, old); err != nil {
+		t.Fatalf("Failed to set time: %v", err)
+	}
+	if err := createArchive(archiveName, []string{paths["a.o"]}, ArOptions{Operation: 'r', Update: true}); err != nil {
+		t.Fatalf("createArchive failed: %v", err)
+	}
+	members, _ := readArchive(archiveName)
+	if string(members[1].Data) != "a.o" {
+		t.Errorf("u replaced an up-to-date member: %q", members[1].Data)
+	}
+}
+
+// TestDeterministicArchive tests that D mode makes archives depend only on
+// member contents
+func TestDeterministicArchive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.o")
+	if err := os.WriteFile(path, []byte("contents"), 0600); err != nil {
+		t.Fatalf("Failed to write a.o: %v", err)
+	}
+
+	opts := ArOptions{Operation: 'r', Create: true, Deterministic: true}
+	build := func(name string, mtime time.Time) []byte {
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Failed to set time: %v", err)
+		}
+		archiveName := filepath.Join(dir, name)
+		if err := createArchive(archiveName, []string{path}, opts); err != nil {
+			t.Fatalf("createArchive failed: %v", err)
+		}
+		data, err := os.ReadFile(archiveName)
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		return data
+	}
+
+	first := build("first.a", time.Unix(1000000, 0))
+	second := build("second.a", time.Unix(2000000, 0))
+	if string(first) != string(second) {
+		t.Error("Deterministic archives differ")
+	}
+
+	members, err := readArchive(filepath.Join(dir, "first.a"))
+	if err != nil {
+		t.Fatalf("readArchive failed: %v", err)
+	}
+	h := members[0].Header
+	if h.Date != 0 || h.UID != 0 || h.GID != 0 || h.Mode != 0644 {
+		t.Errorf("Header not normalized: %+v", h)
+	}
+}
+
+// TestMemberPatterns tests that t, x and d select members by glob patterns
+func TestMemberPatterns(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"libfoo.o", "libbar.o", "main.o"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		paths = append(paths, path)
+	}
+	archiveName := filepath.Join(dir, "lib.a")
+	if err := createArchive(archiveName, paths, ArOptions{Operation: 'r', Create: true}); err != nil {
+		t.Fatalf("createArchive failed: %v", err)
+	}
+
+	if err := deleteFromArchive(archiveName, []string{"lib*.o", "!libbar.o"}, ArOptions{Operation: 'd'}); err != nil {
+		t.Fatalf("deleteFromArchive failed: %v", err)
+	}
+	members, err := readArchive(archiveName)
+	if err != nil {
+		t.Fatalf("readArchive failed: %v", err)
+	}
+	if len(members) != 2 || members[0].Header.Name != "libbar.o" || members[1].Header.Name != "main.o" {
+		t.Errorf("Members after deleting lib*.o except libbar.o: %v", members)
+	}
+
+	if err := listArchive(archiveName, []string{"[lib"}, ArOptions{Operation: 't'}); err == nil {
+		t.Error("listArchive accepted a malformed pattern")
 	}
 }
@@ -2,24 +2,33 @@ package main
 
 import (
 	"fmt"
-	"io"
 	"os"
+	"strings"
 
 	"hellogolang/Projects/Binutils/elf"
+	"hellogolang/Projects/Binutils/match"
 )
 
 // Objcopy - Copy and translate object files (GNU objcopy equivalent)
 
 func main() {
-	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <input> <output> [options]\n", os.Args[0])
+	options, files, err := parseOptions(os.Args[1:])
+	if err != nil || len(files) < 1 || len(files) > 2 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] <input> [output]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options: -R/--remove-section <pattern>, -j/--only-section <pattern>,\n")
+		fmt.Fprintf(os.Stderr, "         --add-section <name>=<file>, --rename-section <old>=<new>[,<flags>],\n")
+		fmt.Fprintf(os.Stderr, "         --set-section-flags <name>=<flags>, -S/--strip-all, -g/--strip-debug\n")
 		os.Exit(1)
 	}
 
-	inputFile := os.Args[1]
-	outputFile := os.Args[2]
-
-	options := parseOptions(os.Args[3:])
+	inputFile := files[0]
+	outputFile := inputFile
+	if len(files) == 2 {
+		outputFile = files[1]
+	}
 
 	if err := copyObject(inputFile, outputFile, options); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -31,43 +40,117 @@ func main() {
 type CopyOptions struct {
 	StripAll      bool
 	StripDebug    bool
-	StripSymbols  []string
-	KeepSymbols   []string
-	AddSection    map[string][]byte
 	RemoveSection []string
+	OnlySection   []string
+	AddSection    []SectionSource
+	RenameSection map[string]SectionRename
+	SectionFlags  map[string]string
+}
+
+// SectionSource names a new section and the file providing its contents
+type SectionSource struct {
+	Name string
+	File string
+}
+
+// SectionRename describes a --rename-section request
+type SectionRename struct {
+	NewName string
+	Flags   string // optional, same syntax as --set-section-flags
 }
 
-// parseOptions parses command line options
-func parseOptions(args []string) CopyOptions {
+// parseOptions parses command line options and returns the positional files
+func parseOptions(args []string) (CopyOptions, []string, error) {
 	opts := CopyOptions{
-		AddSection: make(map[string][]byte),
+		RenameSection: make(map[string]SectionRename),
+		SectionFlags:  make(map[string]string),
+	}
+	files := []string{}
+
+	// value returns the argument following an option
+	value := func(i int) (string, error) {
+		if i+1 >= len(args) {
+			return "", fmt.Errorf("option %s requires an argument", args[i])
+		}
+		return args[i+1], nil
 	}
 
+	// Accept the --option=value spelling as well
+	expanded := make([]string, 0, len(args))
+	for _, arg := range args {
+		if name, rest, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(name, "--") {
+			expanded = append(expanded, name, rest)
+		} else {
+			expanded = append(expanded, arg)
+		}
+	}
+	args = expanded
+
 	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "--strip-all":
+		arg := args[i]
+
+		switch arg {
+		case "-S", "--strip-all":
 			opts.StripAll = true
-		case "--strip-debug":
+		case "-g", "--strip-debug":
 			opts.StripDebug = true
-		case "--strip-symbol":
-			if i+1 < len(args) {
-				opts.StripSymbols = append(opts.StripSymbols, args[i+1])
-				i++
+		case "-R", "--remove-section":
+			v, err := value(i)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.RemoveSection = append(opts.RemoveSection, v)
+			i++
+		case "-j", "--only-section":
+			v, err := value(i)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.OnlySection = append(opts.OnlySection, v)
+			i++
+		case "--add-section":
+			v, err := value(i)
+			if err != nil {
+				return opts, nil, err
+			}
+			name, file, ok := strings.Cut(v, "=")
+			if !ok || name == "" || file == "" {
+				return opts, nil, fmt.Errorf("bad --add-section argument: %s", v)
+			}
+			opts.AddSection = append(opts.AddSection, SectionSource{Name: name, File: file})
+			i++
+		case "--rename-section":
+			v, err := value(i)
+			if err != nil {
+				return opts, nil, err
+			}
+			oldName, rest, ok := strings.Cut(v, "=")
+			if !ok || oldName == "" || rest == "" {
+				return opts, nil, fmt.Errorf("bad --rename-section argument: %s", v)
 			}
-		case "--keep-symbol":
-			if i+1 < len(args) {
-				opts.KeepSymbols = append(opts.KeepSymbols, args[i+1])
-				i++
+			newName, flags, _ := strings.Cut(rest, ",")
+			opts.RenameSection[oldName] = SectionRename{NewName: newName, Flags: flags}
+			i++
+		case "--set-section-flags":
+			v, err := value(i)
+			if err != nil {
+				return opts, nil, err
 			}
-		case "--remove-section":
-			if i+1 < len(args) {
-				opts.RemoveSection = append(opts.RemoveSection, args[i+1])
-				i++
+			name, flags, ok := strings.Cut(v, "=")
+			if !ok || name == "" {
+				return opts, nil, fmt.Errorf("bad --set-section-flags argument: %s", v)
 			}
+			opts.SectionFlags[name] = flags
+			i++
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return opts, nil, fmt.Errorf("unknown option: %s", arg)
+			}
+			files = append(files, arg)
 		}
 	}
 
-	return opts
+	return opts, files, nil
 }
 
 // copyObject copies and modifies object file
@@ -79,130 +162,182 @@ func copyObject(inputFile, outputFile string, options CopyOptions) error {
 	}
 	defer input.Close()
 
+	stat, err := input.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat input: %w", err)
+	}
+
 	// Parse ELF
 	elfFile, err := elf.ParseELF(input)
 	if err != nil {
 		return fmt.Errorf("failed to parse ELF: %w", err)
 	}
 
-	// Apply transformations
-	if options.StripAll {
-		elfFile.Symbols = []elf.Symbol{}
-		// Remove debug sections
-		for i := len(elfFile.Sections) - 1; i >= 0; i-- {
-			if isDebugSection(elfFile.Sections[i].Name) {
-				elfFile.Sections = append(elfFile.Sections[:i], elfFile.Sections[i+1:]...)
-			}
-		}
+	if err := elfFile.LoadSectionData(input); err != nil {
+		return fmt.Errorf("failed to read sections: %w", err)
 	}
 
-	if options.StripDebug {
-		// Remove debug sections only
-		for i := len(elfFile.Sections) - 1; i >= 0; i-- {
-			if isDebugSection(elfFile.Sections[i].Name) {
-				elfFile.Sections = append(elfFile.Sections[:i], elfFile.Sections[i+1:]...)
-			}
-		}
+	if err := transformSections(elfFile, options); err != nil {
+		return err
 	}
 
-	// Strip specific symbols
-	if len(options.StripSymbols) > 0 {
-		newSymbols := []elf.Symbol{}
-		for _, sym := range elfFile.Symbols {
-			if !containsString(options.StripSymbols, sym.Name) {
-				newSymbols = append(newSymbols, sym)
-			}
-		}
-		elfFile.Symbols = newSymbols
+	// Write output, keeping the input's permissions so executables stay executable
+	output, err := os.OpenFile(outputFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, stat.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create output: %w", err)
 	}
 
-	// Keep only specified symbols
-	if len(options.KeepSymbols) > 0 {
-		newSymbols := []elf.Symbol{}
-		for _, sym := range elfFile.Symbols {
-			if containsString(options.KeepSymbols, sym.Name) {
-				newSymbols = append(newSymbols, sym)
-			}
-		}
-		elfFile.Symbols = newSymbols
+	if err := elf.WriteELF(output, elfFile); err != nil {
+		output.Close()
+		return err
+	}
+
+	return output.Close()
+}
+
+// transformSections applies the requested section edits to elfFile
+func transformSections(elfFile *elf.ELF, options CopyOptions) error {
+	// Section patterns may hold wildcards, as in -j '.text.*', and a
+	// pattern starting with ! exempts the sections it matches
+	only, err := match.NewFilter(options.OnlySection...)
+	if err != nil {
+		return fmt.Errorf("bad --only-section pattern: %w", err)
+	}
+	remove, err := match.NewFilter(options.RemoveSection...)
+	if err != nil {
+		return fmt.Errorf("bad --remove-section pattern: %w", err)
 	}
 
 	// Remove sections
-	if len(options.RemoveSection) > 0 {
-		for _, sectionName := range options.RemoveSection {
-			for i := len(elfFile.Sections) - 1; i >= 0; i-- {
-				if elfFile.Sections[i].Name == sectionName {
-					elfFile.Sections = append(elfFile.Sections[:i], elfFile.Sections[i+1:]...)
-					break
+	elfFile.RemoveSections(func(s *elf.Section) bool {
+		if !only.Empty() && !only.Match(s.Name) {
+			return true
+		}
+		if remove.Match(s.Name) {
+			return true
+		}
+		if (options.StripAll || options.StripDebug) && s.IsDebug() {
+			return true
+		}
+		if options.StripAll && (s.Type == elf.SHT_SYMTAB || isSymbolStringTable(elfFile, s)) {
+			return true
+		}
+		return false
+	})
+
+	// Rename sections and update their flags
+	for i := range elfFile.Sections {
+		section := &elfFile.Sections[i]
+
+		if rename, ok := options.RenameSection[section.Name]; ok {
+			section.Name = rename.NewName
+			if rename.Flags != "" {
+				if err := applySectionFlags(section, rename.Flags); err != nil {
+					return err
 				}
 			}
 		}
+
+		if flags, ok := options.SectionFlags[section.Name]; ok {
+			if err := applySectionFlags(section, flags); err != nil {
+				return err
+			}
+		}
 	}
 
-	// Write output
-	return writeELF(outputFile, elfFile)
-}
+	// Add sections
+	for _, src := range options.AddSection {
+		// Secure: validate section name
+		if len(src.Name) > 255 {
+			return fmt.Errorf("section name too long: %s", src.Name)
+		}
 
-// isDebugSection checks if section is a debug section
-func isDebugSection(name string) bool {
-	debugSections := []string{
-		".debug", ".zdebug", ".comment", ".note",
-	}
-	for _, prefix := range debugSections {
-		if len(name) >= len(prefix) && name[:len(prefix)] == prefix {
-			return true
+		data, err := os.ReadFile(src.File)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", src.File, err)
 		}
+
+		// Secure: limit section size
+		if len(data) > 100*1024*1024 { // 100MB
+			return fmt.Errorf("section contents too large: %s", src.File)
+		}
+
+		section := elf.Section{
+			Name:      src.Name,
+			Type:      elf.SHT_PROGBITS,
+			AddrAlign: 1,
+			Data:      data,
+		}
+		if flags, ok := options.SectionFlags[src.Name]; ok {
+			if err := applySectionFlags(&section, flags); err != nil {
+				return err
+			}
+		}
+		elfFile.AddSection(section)
 	}
-	return false
+
+	return nil
 }
 
-// containsString checks if slice contains string
-func containsString(slice []string, s string) bool {
-	for _, item := range slice {
-		if item == s {
+// isSymbolStringTable reports whether s is the string table of a .symtab
+func isSymbolStringTable(elfFile *elf.ELF, s *elf.Section) bool {
+	if s.Type != elf.SHT_STRTAB {
+		return false
+	}
+	for i := range elfFile.Sections {
+		section := &elfFile.Sections[i]
+		if section.Type == elf.SHT_SYMTAB && int(section.Link) < len(elfFile.Sections) &&
+			&elfFile.Sections[section.Link] == s {
 			return true
 		}
 	}
 	return false
 }
 
-// writeELF writes ELF file (simplified - in production use proper ELF writer)
-func writeELF(filename string, elfFile *elf.ELF) error {
-	// For this implementation, we'll do a simple copy
-	// In a full implementation, we would rebuild the ELF file properly
-	input, err := os.Open(filename)
-	if err != nil {
-		// Create new file
-		output, err := os.Create(filename)
-		if err != nil {
-			return err
-		}
-		defer output.Close()
-
-		// Write minimal ELF header
-		header := []byte{
-			0x7f, 'E', 'L', 'F', // Magic
-			2,                   // 64-bit
-			1,                   // Little endian
-			1,                   // Version
-			0,                   // OS/ABI
-			0, 0, 0, 0, 0, 0, 0, // Padding
-		}
+// applySectionFlags sets section flags from a GNU-style comma separated list
+// such as "alloc,load,readonly,code"
+func applySectionFlags(section *elf.Section, spec string) error {
+	var flags uint64
+	readonly := false
+	alloc := false
 
-		if _, err := output.Write(header); err != nil {
-			return err
+	for _, flag := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(strings.ToLower(flag)) {
+		case "alloc":
+			alloc = true
+			flags |= elf.SHF_ALLOC
+		case "load", "contents":
+			if section.Type == elf.SHT_NOBITS {
+				section.Type = elf.SHT_PROGBITS
+				section.Data = make([]byte, section.Size)
+			}
+		case "noload":
+			if section.Type != elf.SHT_NOBITS {
+				section.Type = elf.SHT_NOBITS
+				section.Data = nil
+			}
+		case "readonly":
+			readonly = true
+		case "code":
+			flags |= elf.SHF_EXECINSTR
+		case "merge":
+			flags |= elf.SHF_MERGE
+		case "strings":
+			flags |= elf.SHF_STRINGS
+		case "exclude":
+			flags |= elf.SHF_EXCLUDE
+		case "data", "rom", "share", "debug", "":
+			// Accepted for compatibility; these have no ELF flag bit
+		default:
+			return fmt.Errorf("unrecognized section flag: %s", flag)
 		}
-
-		return nil
 	}
-	defer input.Close()
 
-	output, err := os.Create(filename)
-	if err != nil {
-		return err
+	// Allocated sections are writable unless explicitly marked readonly
+	if alloc && !readonly {
+		flags |= elf.SHF_WRITE
 	}
-	defer output.Close()
 
-	_, err = io.Copy(output, input)
-	return err
+	section.Flags = flags
+	return nil
 }
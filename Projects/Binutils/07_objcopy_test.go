@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// openObject parses an ELF file with its section data loaded
+func openObject(t *testing.T, filename string) *elf.ELF {
+	t.Helper()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := elf.ParseELF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseELF(%s): %v", filename, err)
+	}
+	if err := e.LoadSectionData(bytes.NewReader(data)); err != nil {
+		t.Fatalf("LoadSectionData(%s): %v", filename, err)
+	}
+	return e
+}
+
+// comdatGroups returns the member section names of every section group,
+// keyed by the name of its signature symbol
+func comdatGroups(e *elf.ELF) map[string][]string {
+	groups := map[string][]string{}
+	for _, section := range e.Sections {
+		if section.Type != elf.SHT_GROUP {
+			continue
+		}
+		signature := "?"
+		if int(section.Info) < len(e.Symbols) {
+			signature = e.Symbols[section.Info].Name
+		}
+		members := []string{}
+		for off := 4; off+4 <= len(section.Data); off += 4 {
+			member := binary.LittleEndian.Uint32(section.Data[off:])
+			if int(member) < len(e.Sections) {
+				members = append(members, e.Sections[member].Name)
+			} else {
+				members = append(members, "?")
+			}
+		}
+		groups[signature] = members
+	}
+	return groups
+}
+
+// helloGroups are the COMDAT groups g++ emits for testdata/hello.cpp
+var helloGroups = map[string][]string{
+	"_ZnwmPv":             {".text._ZnwmPv"},
+	"_ZN2ns5PointC5Ei":    {".text._ZN2ns5PointC2Ei"},
+	"_ZNK2ns5Point3getEv": {".text._ZNK2ns5Point3getEv"},
+	"_Z5twiceIiET_S0_":    {".text._Z5twiceIiET_S0_"},
+}
+
+// TestCopyObject tests copies of a C++ object, unchanged and with sections
+// removed, checking that COMDAT groups still name their own sections
+func TestCopyObject(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join("testdata", "hello.o")
+	want := openObject(t, input)
+
+	output := filepath.Join(dir, "copy.o")
+	if err := copyObject(input, output, CopyOptions{}); err != nil {
+		t.Fatalf("copyObject: %v", err)
+	}
+	got := openObject(t, output)
+	if len(got.Sections) != len(want.Sections) {
+		t.Fatalf("Copy has %d sections, want %d", len(got.Sections), len(want.Sections))
+	}
+	for i := range want.Sections {
+		if i == int(want.Header.ShStrndx) {
+			continue // Section names are laid out anew
+		}
+		if got.Sections[i].Name != want.Sections[i].Name || !bytes.Equal(got.Sections[i].Data, want.Sections[i].Data) {
+			t.Errorf("Copy section %d = %s, want %s with the same contents", i, got.Sections[i].Name, want.Sections[i].Name)
+		}
+	}
+	if groups := comdatGroups(got); len(groups) != len(helloGroups) {
+		t.Errorf("Copy groups = %v, want %v", groups, helloGroups)
+	}
+
+	output = filepath.Join(dir, "removed.o")
+	options := CopyOptions{RemoveSection: []string{".text._ZnwmPv", ".eh_frame"}}
+	if err := copyObject(input, output, options); err != nil {
+		t.Fatalf("copyObject -R: %v", err)
+	}
+	got = openObject(t, output)
+	for _, section := range got.Sections {
+		if section.Name == ".text._ZnwmPv" || section.Name == ".rela.eh_frame" {
+			t.Errorf("%s was not removed", section.Name)
+		}
+	}
+	groups := comdatGroups(got)
+	if _, ok := groups["_ZnwmPv"]; ok || len(groups) != len(helloGroups)-1 {
+		t.Errorf("Groups after removal = %v, want the group of _ZnwmPv gone", groups)
+	}
+	for signature, members := range groups {
+		if !slices.Equal(members, helloGroups[signature]) {
+			t.Errorf("Group %s = %q, want %q", signature, members, helloGroups[signature])
+		}
+	}
+}
+
+// TestAddAllocSection tests that a section added and made allocatable is
+// laid out after the segments rather than at its zero offset, over the ELF
+// header
+func TestAddAllocSection(t *testing.T) {
+	dir := t.TempDir()
+	contents := filepath.Join(dir, "x.bin")
+	if err := os.WriteFile(contents, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join("testdata", "hello")
+	output := filepath.Join(dir, "hello")
+	options := CopyOptions{
+		AddSection:   []SectionSource{{Name: ".x", File: contents}},
+		SectionFlags: map[string]string{".x": "alloc"},
+	}
+	if err := copyObject(input, output, options); err != nil {
+		t.Fatalf("copyObject: %v", err)
+	}
+
+	want, got := openObject(t, input), openObject(t, output)
+	if !slices.Equal(got.Segments, want.Segments) {
+		t.Errorf("Segments = %+v, want %+v", got.Segments, want.Segments)
+	}
+	var end uint64
+	for _, seg := range got.Segments {
+		end = max(end, seg.Offset+seg.FileSz)
+	}
+	for i := range want.Sections {
+		if i != int(want.Header.ShStrndx) && (got.Sections[i].Offset != want.Sections[i].Offset ||
+			!bytes.Equal(got.Sections[i].Data, want.Sections[i].Data)) {
+			t.Errorf("Section %d %s moved or changed", i, want.Sections[i].Name)
+		}
+	}
+	found := false
+	for _, section := range got.Sections {
+		if section.Name != ".x" {
+			continue
+		}
+		found = true
+		if section.Flags&elf.SHF_ALLOC == 0 || !bytes.Equal(section.Data, []byte("hello")) {
+			t.Errorf(".x = %+v, want allocated with the added contents", section)
+		}
+		if section.Offset < end {
+			t.Errorf(".x at offset 0x%x, inside the segments ending at 0x%x", section.Offset, end)
+		}
+	}
+	if !found {
+		t.Error(".x was not added")
+	}
+}
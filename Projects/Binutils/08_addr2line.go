@@ -1,75 +1,128 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
+	"hellogolang/Projects/Binutils/demangle"
+	"hellogolang/Projects/Binutils/dwarf"
 	"hellogolang/Projects/Binutils/elf"
 )
 
 // Addr2line - Convert addresses to file/line (GNU addr2line equivalent)
 
 func main() {
-	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "Usage: %s -e <executable> <address>...\n", os.Args[0])
-		os.Exit(1)
-	}
-
-	executable := ""
-	addresses := []uint64{}
-
-	// Parse arguments
-	for i := 1; i < len(os.Args); i++ {
-		if os.Args[i] == "-e" && i+1 < len(os.Args) {
-			executable = os.Args[i+1]
-			i++
-		} else {
-			// Parse address
-			addr, err := parseAddress(os.Args[i])
-			if err == nil {
-				addresses = append(addresses, addr)
-			}
-		}
-	}
-
-	if executable == "" {
-		fmt.Fprintf(os.Stderr, "Error: no executable specified\n")
-		os.Exit(1)
-	}
-
-	if len(addresses) == 0 {
-		fmt.Fprintf(os.Stderr, "Error: no addresses specified\n")
+	options, addresses, err := parseAddr2lineOptions(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Usage: %s [-e <executable>] [-f] [-C] [-a] [-s] [-p] [address...]\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	file, err := os.Open(executable)
+	file, err := os.Open(options.Executable)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 	defer file.Close()
 
-	elfFile, err := elf.ParseELF(file)
+	resolver, err := newResolver(file)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	for _, addr := range addresses {
-		file, line := addrToLine(elfFile, addr)
-		if file != "" {
-			fmt.Printf("%s:%d\n", file, line)
-		} else {
-			fmt.Printf("??:0\n")
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	// Without addresses on the command line, read them from stdin
+	if len(addresses) == 0 {
+		if err := translateStream(os.Stdin, out, resolver, options); err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
+		return
+	}
+
+	for _, arg := range addresses {
+		translate(out, resolver, arg, options)
 	}
 }
 
+// Addr2lineOptions represents addr2line options
+type Addr2lineOptions struct {
+	Executable string // -e
+	Functions  bool   // -f
+	Demangle   bool   // -C
+	Addresses  bool   // -a
+	Basenames  bool   // -s
+	Pretty     bool   // -p
+}
+
+// parseAddr2lineOptions parses command line options and returns the addresses
+func parseAddr2lineOptions(args []string) (Addr2lineOptions, []string, error) {
+	opts := Addr2lineOptions{Executable: "a.out"}
+	addresses := []string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-e" || arg == "--exe":
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("option %s requires an argument", arg)
+			}
+			opts.Executable = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--exe="):
+			opts.Executable = strings.TrimPrefix(arg, "--exe=")
+		case arg == "-f" || arg == "--functions":
+			opts.Functions = true
+		case arg == "-C" || arg == "--demangle":
+			opts.Demangle = true
+		case arg == "-a" || arg == "--addresses":
+			opts.Addresses = true
+		case arg == "-s" || arg == "--basenames":
+			opts.Basenames = true
+		case arg == "-p" || arg == "--pretty-print":
+			opts.Pretty = true
+		case len(arg) > 2 && arg[0] == '-' && !strings.HasPrefix(arg, "--"):
+			// Combined short flags such as -fC
+			for _, c := range arg[1:] {
+				switch c {
+				case 'f':
+					opts.Functions = true
+				case 'C':
+					opts.Demangle = true
+				case 'a':
+					opts.Addresses = true
+				case 's':
+					opts.Basenames = true
+				case 'p':
+					opts.Pretty = true
+				default:
+					return opts, nil, fmt.Errorf("unknown option: -%c", c)
+				}
+			}
+		case strings.HasPrefix(arg, "-"):
+			return opts, nil, fmt.Errorf("unknown option: %s", arg)
+		default:
+			addresses = append(addresses, arg)
+		}
+	}
+
+	return opts, addresses, nil
+}
+
 // parseAddress parses address string
 func parseAddress(s string) (uint64, error) {
 	// Remove 0x prefix if present
-	if len(s) > 2 && s[0:2] == "0x" {
+	if len(s) > 2 && (s[0:2] == "0x" || s[0:2] == "0X") {
 		s = s[2:]
 	}
 
@@ -86,16 +139,139 @@ func parseAddress(s string) (uint64, error) {
 	return val, nil
 }
 
-// addrToLine converts address to file:line
-func addrToLine(elfFile *elf.ELF, addr uint64) (string, int) {
-	// Find section containing address
-	for _, section := range elfFile.Sections {
-		if addr >= section.Addr && addr < section.Addr+section.Size {
-			// In a full implementation, we would parse DWARF debug info
-			// For now, return section name as file
-			return section.Name, 0
+// resolver maps addresses to source positions and function names
+type resolver struct {
+	elfFile *elf.ELF
+	debug   *dwarf.Data // nil when the file has no usable DWARF
+}
+
+// newResolver parses the ELF file and its debugging information
+func newResolver(file io.ReadSeeker) (*resolver, error) {
+	elfFile, err := elf.ParseELF(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// Missing or unreadable DWARF is not fatal: symbols still give function names
+	debug, _ := dwarf.Load(elfFile)
+
+	return &resolver{elfFile: elfFile, debug: debug}, nil
+}
+
+// lookupLine returns the source position of addr
+func (r *resolver) lookupLine(addr uint64) (dwarf.LineInfo, bool) {
+	if r.debug == nil {
+		return dwarf.LineInfo{}, false
+	}
+	return r.debug.FindLine(addr)
+}
+
+// lookupFunction returns the name of the function containing addr. Like
+// GNU addr2line it gives the mangled name: the DWARF linkage name, or the
+// symbol table's for a function without one, as a static C++ function is.
+// The DWARF name is the last resort.
+func (r *resolver) lookupFunction(addr uint64) (string, bool) {
+	var fn dwarf.Function
+	if r.debug != nil {
+		fn, _ = r.debug.FindFunction(addr)
+		if fn.LinkageName != "" {
+			return fn.LinkageName, true
+		}
+	}
+	if name, ok := r.lookupSymbol(addr); ok {
+		return name, true
+	}
+	return fn.Name, fn.Name != ""
+}
+
+// lookupSymbol returns the name of the function symbol covering addr
+func (r *resolver) lookupSymbol(addr uint64) (string, bool) {
+	var best *elf.Symbol
+	for i := range r.elfFile.Symbols {
+		sym := &r.elfFile.Symbols[i]
+		symType := sym.Info & 0x0f
+		if symType != 2 && symType != 10 { // STT_FUNC, STT_GNU_IFUNC
+			continue
+		}
+		if sym.Shndx == elf.SHN_UNDEF || sym.Name == "" || addr < sym.Value {
+			continue
+		}
+		if addr >= sym.Value+max(sym.Size, 1) {
+			continue
+		}
+		if best == nil || sym.Value > best.Value {
+			best = sym
+		}
+	}
+
+	if best == nil {
+		return "", false
+	}
+	return best.Name, true
+}
+
+// translateStream resolves one address per input line
+func translateStream(in io.Reader, out *bufio.Writer, r *resolver, options Addr2lineOptions) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		for _, field := range strings.Fields(scanner.Text()) {
+			translate(out, r, field, options)
+		}
+		// Flush per line so addr2line can be driven interactively
+		if err := out.Flush(); err != nil {
+			return err
 		}
 	}
+	return scanner.Err()
+}
+
+// translate prints the function and file:line for one address
+func translate(out *bufio.Writer, r *resolver, arg string, options Addr2lineOptions) {
+	addr, err := parseAddress(arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid address %q: %v\n", arg, err)
+		return
+	}
+
+	separator := "\n"
+	if options.Pretty {
+		separator = ": "
+	}
+
+	if options.Addresses {
+		fmt.Fprintf(out, "0x%016x%s", addr, separator)
+	}
 
-	return "", 0
+	if options.Functions {
+		name, ok := r.lookupFunction(addr)
+		if !ok {
+			name = "??"
+		} else if options.Demangle {
+			name = demangle.Filter(name)
+		}
+		if options.Pretty {
+			fmt.Fprintf(out, "%s at ", name)
+		} else {
+			fmt.Fprintf(out, "%s\n", name)
+		}
+	}
+
+	// An unknown file or line is shown as ?? or ?
+	info, ok := r.lookupLine(addr)
+	if !ok {
+		fmt.Fprintf(out, "??:?\n")
+		return
+	}
+
+	filename := info.File
+	if filename == "" {
+		filename = "??"
+	} else if options.Basenames {
+		filename = filepath.Base(filename)
+	}
+	if info.Line == 0 {
+		fmt.Fprintf(out, "%s:?\n", filename)
+		return
+	}
+	fmt.Fprintf(out, "%s:%d\n", filename, info.Line)
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestTranslate tests function names and lines for testdata/hello, built
+// with g++ -g -O0, against the output of GNU addr2line -f -s and -f -s -C
+func TestTranslate(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r, err := newResolver(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.debug == nil {
+		t.Fatal("No DWARF found in testdata/hello")
+	}
+
+	tests := []struct {
+		symbol    string
+		mangled   string
+		demangled string
+		line      int
+	}{
+		{"main", "main", "main", 24},
+		{"_ZN2ns5PointC2Ei", "_ZN2ns5PointC2Ei", "ns::Point::Point(int)", 12},
+		{"_ZNK2ns5Point3getEv", "_ZNK2ns5Point3getEv", "ns::Point::get() const", 13},
+		{"_Z5twiceIiET_S0_", "_Z5twiceIiET_S0_", "int twice<int>(int)", 19},
+		// A static function has no linkage name in DWARF
+		{"_ZL4sfuni", "_ZL4sfuni", "sfun(int)", 17},
+	}
+
+	for _, tt := range tests {
+		var addr uint64
+		for _, sym := range r.elfFile.Symbols {
+			if sym.Name == tt.symbol {
+				addr = sym.Value
+			}
+		}
+		if addr == 0 {
+			t.Errorf("No symbol %s in testdata/hello", tt.symbol)
+			continue
+		}
+
+		for _, demangle := range []bool{false, true} {
+			var buf bytes.Buffer
+			out := bufio.NewWriter(&buf)
+			options := Addr2lineOptions{Functions: true, Basenames: true, Demangle: demangle}
+			translate(out, r, fmt.Sprintf("0x%x", addr), options)
+			out.Flush()
+
+			name := tt.mangled
+			if demangle {
+				name = tt.demangled
+			}
+			if want := fmt.Sprintf("%s\nhello.cpp:%d\n", name, tt.line); buf.String() != want {
+				t.Errorf("%s with -C %v: got %q, want %q", tt.symbol, demangle, buf.String(), want)
+			}
+		}
+	}
+
+	// An address without line information
+	for _, options := range []Addr2lineOptions{{}, {Functions: true}} {
+		var buf bytes.Buffer
+		out := bufio.NewWriter(&buf)
+		translate(out, r, "0x0", options)
+		out.Flush()
+
+		want := "??:?\n"
+		if options.Functions {
+			want = "??\n??:?\n"
+		}
+		if buf.String() != want {
+			t.Errorf("0x0 with %+v: got %q, want %q", options, buf.String(), want)
+		}
+	}
+}
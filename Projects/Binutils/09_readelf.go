@@ -1,58 +1,192 @@
 package main
 
 import (
+	"encoding/binary"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 
+	"hellogolang/Projects/Binutils/demangle"
 	"hellogolang/Projects/Binutils/elf"
 )
 
 // Readelf - Display information about ELF files (GNU readelf equivalent)
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <option> <elf-file>\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "Options: -h (header), -S (sections), -s (symbols), -l (segments)\n")
+	options, files, err := parseReadelfOptions(os.Args[1:])
+	if err != nil || len(files) == 0 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Usage: %s <option(s)> <elf-file>...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options: -h (header), -S (sections), -s (symbols), -l (segments), -d (dynamic), -n (notes),\n")
+		fmt.Fprintf(os.Stderr, "         -r (relocations), -I (hash histogram), -a (all), --dyn-syms, -x <section> (hex dump),\n")
+		fmt.Fprintf(os.Stderr, "         -p <section> (string dump), -C (demangle symbol names)\n")
 		os.Exit(1)
 	}
 
-	option := "-h"
-	filename := ""
+	failed := false
+	for _, filename := range files {
+		if len(files) > 1 {
+			fmt.Printf("\nFile: %s\n", filename)
+		}
+		if err := readelfFile(filename, options); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			failed = true
+		}
+	}
 
-	if len(os.Args) == 2 {
-		filename = os.Args[1]
-	} else {
-		option = os.Args[1]
-		filename = os.Args[2]
+	if failed {
+		os.Exit(1)
 	}
+}
+
+// ReadelfOptions represents readelf options
+type ReadelfOptions struct {
+	FileHeader     bool        // -h
+	SectionHeaders bool        // -S
+	ProgramHeaders bool        // -l
+	Dynamic        bool        // -d
+	Relocs         bool        // -r
+	Symbols        bool        // -s
+	DynSyms        bool        // --dyn-syms
+	Notes          bool        // -n
+	Histogram      bool        // -I
+	HexDump        sectionList // -x
+	StringDump     sectionList // -p
+	Demangle       bool        // -C
+}
+
+// sectionList collects the sections named by repeated -x or -p options
+type sectionList []string
+
+// String returns the sections as a comma-separated list
+func (l *sectionList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set adds a section name or number to the list
+func (l *sectionList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
 
+// parseReadelfOptions parses command line options and returns the input
+// files. Every option has a short and a long name, as in GNU readelf; with
+// no display option the file header is shown.
+func parseReadelfOptions(args []string) (ReadelfOptions, []string, error) {
+	opts := ReadelfOptions{}
+	all := false
+
+	fs := flag.NewFlagSet("readelf", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	boolFlag := func(p *bool, short, long string) {
+		if short != "" {
+			fs.BoolVar(p, short, false, "")
+		}
+		fs.BoolVar(p, long, false, "")
+	}
+	boolFlag(&opts.FileHeader, "h", "file-header")
+	boolFlag(&opts.SectionHeaders, "S", "section-headers")
+	boolFlag(&opts.ProgramHeaders, "l", "program-headers")
+	boolFlag(&opts.Dynamic, "d", "dynamic")
+	boolFlag(&opts.Relocs, "r", "relocs")
+	boolFlag(&opts.Symbols, "s", "symbols")
+	boolFlag(&opts.DynSyms, "", "dyn-syms")
+	boolFlag(&opts.Notes, "n", "notes")
+	boolFlag(&opts.Histogram, "I", "histogram")
+	boolFlag(&opts.Demangle, "C", "demangle")
+	boolFlag(&all, "a", "all")
+	fs.Var(&opts.HexDump, "x", "")
+	fs.Var(&opts.HexDump, "hex-dump", "")
+	fs.Var(&opts.StringDump, "p", "")
+	fs.Var(&opts.StringDump, "string-dump", "")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, nil, err
+	}
+
+	if all {
+		opts.FileHeader, opts.SectionHeaders, opts.ProgramHeaders = true, true, true
+		opts.Dynamic, opts.Relocs, opts.Symbols, opts.Notes = true, true, true, true
+		opts.Histogram = true
+	}
+	if !opts.SectionHeaders && !opts.ProgramHeaders && !opts.Dynamic && !opts.Relocs && !opts.Symbols &&
+		!opts.DynSyms && !opts.Notes && !opts.Histogram && len(opts.HexDump) == 0 && len(opts.StringDump) == 0 {
+		opts.FileHeader = true
+	}
+
+	return opts, fs.Args(), nil
+}
+
+// readelfFile displays the selected parts of one file, in the order GNU
+// readelf uses
+func readelfFile(filename string, options ReadelfOptions) error {
 	file, err := os.Open(filename)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 	defer file.Close()
 
 	elfFile, err := elf.ParseELF(file)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
-	switch option {
-	case "-h", "--file-header":
+	// Inconsistent headers are reported but do not stop the display
+	if err := elf.Validate(elfFile); err != nil {
+		for _, problem := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "readelf: Warning: %s\n", problem)
+		}
+	}
+
+	if options.FileHeader {
 		showFileHeader(elfFile, filename)
-	case "-S", "--section-headers":
+	}
+	if options.SectionHeaders {
+		if options.FileHeader {
+			fmt.Println()
+		}
 		showSectionHeaders(elfFile)
-	case "-s", "--symbols":
-		showSymbols(elfFile)
-	case "-l", "--program-headers":
+	}
+	if options.ProgramHeaders {
+		fmt.Println()
 		showProgramHeaders(elfFile)
-	case "-a", "--all":
-		showAll(elfFile, filename)
-	default:
-		showFileHeader(elfFile, filename)
 	}
+	if options.Dynamic {
+		showDynamic(elfFile)
+	}
+	if options.Relocs {
+		if err := showRelocations(os.Stdout, elfFile, options.Demangle); err != nil {
+			return err
+		}
+	}
+	if options.Symbols {
+		fmt.Println()
+		showSymbols(elfFile, options.Demangle)
+	} else if options.DynSyms {
+		showDynamicSymbols(elfFile, options.Demangle)
+	}
+	if options.Histogram {
+		if err := showHistogram(elfFile); err != nil {
+			return err
+		}
+	}
+	if len(options.HexDump) > 0 || len(options.StringDump) > 0 {
+		if err := showSectionDumps(os.Stdout, elfFile, options.HexDump, options.StringDump); err != nil {
+			return err
+		}
+	}
+	if options.Notes {
+		if err := showNotes(file, elfFile); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // truncateString truncates string to max length
@@ -112,25 +246,115 @@ func showSectionHeaders(elfFile *elf.ELF) {
 	}
 }
 
-// showSymbols shows symbol table
-func showSymbols(elfFile *elf.ELF) {
-	if len(elfFile.Symbols) == 0 {
+// showSymbols shows the dynamic and static symbol tables
+func showSymbols(elfFile *elf.ELF, demangleNames bool) {
+	if len(elfFile.Symbols) == 0 && len(elfFile.DynamicSymbols) == 0 {
 		fmt.Println("No symbol table found")
 		return
 	}
 
-	fmt.Printf("Symbol table '.symtab' contains %d entries:\n", len(elfFile.Symbols))
+	if len(elfFile.DynamicSymbols) > 0 {
+		showSymbolTable(".dynsym", elfFile.DynamicSymbols, demangleNames)
+		if len(elfFile.Symbols) > 0 {
+			fmt.Println()
+		}
+	}
+	if len(elfFile.Symbols) > 0 {
+		showSymbolTable(".symtab", elfFile.Symbols, demangleNames)
+	}
+}
+
+// showDynamicSymbols shows the dynamic symbol table
+func showDynamicSymbols(elfFile *elf.ELF, demangleNames bool) {
+	if len(elfFile.DynamicSymbols) == 0 {
+		fmt.Println("No dynamic symbol table found")
+		return
+	}
+	showSymbolTable(".dynsym", elfFile.DynamicSymbols, demangleNames)
+}
+
+// showSymbolTable shows the entries of one symbol table, with C++ names
+// decoded if demangleNames is set
+func showSymbolTable(name string, symbols []elf.Symbol, demangleNames bool) {
+	fmt.Printf("Symbol table '%s' contains %d entries:\n", name, len(symbols))
 	fmt.Printf("   Num:    Value          Size Type    Bind   Vis      Ndx Name\n")
 
-	for i, sym := range elfFile.Symbols {
+	for i, sym := range symbols {
 		ndx := "UND"
 		if sym.Shndx != 0 {
 			ndx = fmt.Sprintf("%3d", sym.Shndx)
 		}
 
+		symName := sym.Name
+		if demangleNames {
+			symName = demangle.Filter(symName)
+		}
 		fmt.Printf("%6d: %016x %5d %-7s %-6s DEFAULT %3s %s\n",
-			i, sym.Value, sym.Size, sym.Type, sym.Binding, ndx, sym.Name)
+			i, sym.Value, sym.Size, sym.Type, sym.Binding, ndx, symName)
+	}
+}
+
+// showHistogram prints the bucket list length histogram of each symbol
+// hash table, as readelf -I does, and warns about dynamic symbols that a
+// lookup through the table would not find
+func showHistogram(elfFile *elf.ELF) error {
+	tables, err := elfFile.HashTables()
+	if err != nil {
+		return err
+	}
+
+	symbols := elfFile.DynamicSymbols
+	for _, table := range tables {
+		lengths, err := table.ChainLengths()
+		if err != nil {
+			return fmt.Errorf("%s: %w", table.Section, err)
+		}
+		if len(lengths) == 0 {
+			continue
+		}
+
+		counts := []int{0}
+		total := 0
+		for _, length := range lengths {
+			for len(counts) <= length {
+				counts = append(counts, 0)
+			}
+			counts[length]++
+			total += length
+		}
+
+		buckets := "buckets"
+		if len(lengths) == 1 {
+			buckets = "bucket"
+		}
+		if table.GNU {
+			fmt.Printf("\nHistogram for `%s' bucket list length (total of %d %s):\n", table.Section, len(lengths), buckets)
+		} else {
+			fmt.Printf("\nHistogram for bucket list length (total of %d %s):\n", len(lengths), buckets)
+		}
+		fmt.Println(" Length  Number     % of total  Coverage")
+		fmt.Printf("      0  %-10d (%5.1f%%)\n", counts[0], float64(counts[0])*100/float64(len(lengths)))
+		covered := 0
+		for length := 1; length < len(counts); length++ {
+			covered += counts[length] * length
+			fmt.Printf("%7d  %-10d (%5.1f%%)    %5.1f%%\n", length, counts[length],
+				float64(counts[length])*100/float64(len(lengths)), float64(covered)*100/float64(total))
+		}
+
+		// Every symbol the table covers must be found by a lookup
+		first := uint32(1)
+		if table.GNU {
+			first = table.SymOffset
+		} else if len(table.Chains) != len(symbols) {
+			fmt.Fprintf(os.Stderr, "readelf: Warning: %s has %d chains for %d dynamic symbols\n", table.Section, len(table.Chains), len(symbols))
+		}
+		for i := first; int(i) < len(symbols); i++ {
+			if !table.Reachable(i, symbols[i].Name) {
+				fmt.Fprintf(os.Stderr, "readelf: Warning: Symbol %s (index %d) is not reachable through %s\n", symbols[i].Name, i, table.Section)
+			}
+		}
 	}
+	return nil
 }
 
 // showProgramHeaders shows program headers
@@ -158,15 +382,527 @@ func showProgramHeaders(elfFile *elf.ELF) {
 	}
 }
 
-// showAll shows all information
-func showAll(elfFile *elf.ELF, filename string) {
-	showFileHeader(elfFile, filename)
-	fmt.Println()
-	showSectionHeaders(elfFile)
-	fmt.Println()
-	showSymbols(elfFile)
+// showDynamic shows the entries of the dynamic section
+func showDynamic(elfFile *elf.ELF) {
+	if len(elfFile.Dynamic) == 0 {
+		fmt.Println("\nThere is no dynamic section in this file.")
+		return
+	}
+
+	offset, _ := elfFile.DynamicOffset()
+	fmt.Printf("\nDynamic section at offset 0x%x contains %d entries:\n", offset, len(elfFile.Dynamic))
+	fmt.Printf("  Tag        Type                         Name/Value\n")
+
+	// The type column is wider for 32-bit files, whose tags print shorter
+	tagWidth, typeWidth := 16, 19
+	if elfFile.Class == "ELF32" {
+		tagWidth, typeWidth = 8, 27
+	}
+
+	for _, entry := range elfFile.Dynamic {
+		name := elf.GetDynamicTag(entry.Tag)
+		fmt.Printf(" 0x%0*x (%s)%*s%s\n", tagWidth, uint64(entry.Tag), name,
+			max(typeWidth-len(name), 1), " ", formatDynamicValue(entry))
+	}
+}
+
+// formatDynamicValue formats the value of a dynamic entry the way readelf does
+func formatDynamicValue(entry elf.DynamicEntry) string {
+	switch entry.Tag {
+	case elf.DT_NEEDED:
+		return fmt.Sprintf("Shared library: [%s]", entry.Str)
+	case elf.DT_SONAME:
+		return fmt.Sprintf("Library soname: [%s]", entry.Str)
+	case elf.DT_RPATH:
+		return fmt.Sprintf("Library rpath: [%s]", entry.Str)
+	case elf.DT_RUNPATH:
+		return fmt.Sprintf("Library runpath: [%s]", entry.Str)
+	case elf.DT_FLAGS:
+		return formatFlagNames(entry.Value, []flagName{
+			{elf.DF_ORIGIN, "ORIGIN"}, {elf.DF_SYMBOLIC, "SYMBOLIC"}, {elf.DF_TEXTREL, "TEXTREL"},
+			{elf.DF_BIND_NOW, "BIND_NOW"}, {elf.DF_STATIC_TLS, "STATIC_TLS"},
+		})
+	case elf.DT_FLAGS_1:
+		return "Flags: " + formatFlagNames(entry.Value, []flagName{
+			{elf.DF_1_NOW, "NOW"}, {elf.DF_1_GLOBAL, "GLOBAL"}, {elf.DF_1_GROUP, "GROUP"},
+			{elf.DF_1_NODELETE, "NODELETE"}, {elf.DF_1_INITFIRST, "INITFIRST"}, {elf.DF_1_NOOPEN, "NOOPEN"},
+			{elf.DF_1_ORIGIN, "ORIGIN"}, {elf.DF_1_NODEFLIB, "NODEFLIB"}, {elf.DF_1_PIE, "PIE"},
+		})
+	case elf.DT_PLTREL:
+		switch entry.Value {
+		case elf.DT_REL:
+			return "REL"
+		case elf.DT_RELA:
+			return "RELA"
+		}
+	case elf.DT_PLTRELSZ, elf.DT_RELASZ, elf.DT_RELAENT, elf.DT_STRSZ, elf.DT_SYMENT,
+		elf.DT_RELSZ, elf.DT_RELENT, elf.DT_INIT_ARRAYSZ, elf.DT_FINI_ARRAYSZ,
+		elf.DT_PREINIT_ARRAYSZ, elf.DT_RELRSZ, elf.DT_RELRENT:
+		return fmt.Sprintf("%d (bytes)", entry.Value)
+	case elf.DT_VERDEFNUM, elf.DT_VERNEEDNUM, elf.DT_RELACOUNT, elf.DT_RELCOUNT:
+		return fmt.Sprintf("%d", entry.Value)
+	}
+	return fmt.Sprintf("0x%x", entry.Value)
+}
+
+// showRelocations shows the entries of every relocation section
+func showRelocations(w io.Writer, elfFile *elf.ELF, demangleNames bool) error {
+	// Dynamic symbols are shown with their versions
+	dynamicVersions, err := elfFile.SymbolVersions()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i := range elfFile.Sections {
+		section := &elfFile.Sections[i]
+		if section.Type != elf.SHT_REL && section.Type != elf.SHT_RELA {
+			continue
+		}
+		found = true
+
+		relocations, err := elfFile.Relocations(section)
+		if err != nil {
+			return err
+		}
+		entries := "entries"
+		if len(relocations) == 1 {
+			entries = "entry"
+		}
+		fmt.Fprintf(w, "\nRelocation section '%s' at offset 0x%x contains %d %s:\n",
+			section.Name, section.Offset, len(relocations), entries)
+
+		is32 := elfFile.Class == "ELF32"
+		isRela := section.Type == elf.SHT_RELA
+		switch {
+		case is32 && isRela:
+			fmt.Fprintf(w, " Offset     Info    Type                Sym. Value  Symbol's Name + Addend\n")
+		case is32:
+			fmt.Fprintf(w, " Offset     Info    Type            Sym.Value  Sym. Name\n")
+		case isRela:
+			fmt.Fprintf(w, "  Offset          Info           Type           Sym. Value    Sym. Name + Addend\n")
+		default:
+			fmt.Fprintf(w, "  Offset          Info           Type           Sym. Value    Sym. Name\n")
+		}
+
+		symbols := elfFile.RelocationSymbols(section)
+		var versions []elf.SymbolVersion
+		if int(section.Link) < len(elfFile.Sections) && elfFile.Sections[section.Link].Type == elf.SHT_DYNSYM {
+			versions = dynamicVersions
+		}
+		for _, rel := range relocations {
+			fmt.Fprintln(w, formatRelocation(elfFile, rel, symbols, versions, is32, demangleNames))
+		}
+	}
+
+	if !found {
+		fmt.Fprintf(w, "\nThere are no relocations in this file.\n")
+	}
+	return nil
+}
+
+// formatRelocation formats one relocation line the way readelf does.
+// versions holds the versions of the symbols, if they have any.
+func formatRelocation(elfFile *elf.ELF, rel elf.Relocation, symbols []elf.Symbol, versions []elf.SymbolVersion, is32, demangleNames bool) string {
+	typeName := truncateString(elf.GetRelocationType(elfFile.Header.Machine, rel.Type), 17)
+
+	var line string
+	if is32 {
+		line = fmt.Sprintf("%08x  %08x %-17s ", rel.Offset, rel.Info, typeName)
+	} else {
+		line = fmt.Sprintf("%012x  %012x %-17s ", rel.Offset, rel.Info, typeName)
+	}
+
+	addend := func(sep string) string {
+		if rel.Addend < 0 {
+			return fmt.Sprintf("%s-%s%x", sep, sep, uint64(-rel.Addend))
+		}
+		return fmt.Sprintf("%s+%s%x", sep, sep, uint64(rel.Addend))
+	}
+
+	if rel.Symbol == 0 || int(rel.Symbol) >= len(symbols) {
+		if !rel.HasAddend {
+			return strings.TrimRight(line, " ")
+		}
+		// The symbol value column is left blank
+		width := 19
+		if is32 {
+			width = 11
+		}
+		return line + strings.Repeat(" ", width) + strings.TrimPrefix(addend(""), "+")
+	}
+
+	sym := symbols[rel.Symbol]
+	name := sym.Name
+	if sym.Info&0x0f == 3 && int(sym.Shndx) < len(elfFile.Sections) { // STT_SECTION
+		name = elfFile.Sections[sym.Shndx].Name
+	} else if demangleNames {
+		name = demangle.Filter(name)
+	}
+	// Long names are shortened to fit the line
+	if len(name) > 22 {
+		name = name[:17] + "[...]"
+	}
+	if int(rel.Symbol) < len(versions) {
+		name += versions[rel.Symbol].Suffix()
+	}
+	if is32 {
+		line += fmt.Sprintf("%08x   %s", sym.Value, name)
+	} else {
+		line += fmt.Sprintf("%016x %s", sym.Value, name)
+	}
+	if rel.HasAddend {
+		line += addend(" ")
+	}
+	return line
+}
+
+// showSectionDumps shows the -x and -p dumps. Like GNU readelf it goes
+// through the sections in order, so every section of a repeated name is
+// dumped once, and then warns about the names that matched no section.
+func showSectionDumps(w io.Writer, elfFile *elf.ELF, hexNames, stringNames []string) error {
+	matched := map[string]bool{}
+	requested := func(names []string, index int) bool {
+		found := false
+		for _, name := range names {
+			if dumpSectionMatches(elfFile, index, name) {
+				matched[name] = true
+				found = true
+			}
+		}
+		return found
+	}
+
+	for i := range elfFile.Sections {
+		section := &elfFile.Sections[i]
+		if requested(hexNames, i) {
+			if err := showHexDump(w, elfFile, section); err != nil {
+				return err
+			}
+		}
+		if requested(stringNames, i) {
+			if err := showStringDump(w, elfFile, section); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range slices.Concat(hexNames, stringNames) {
+		if matched[name] {
+			continue
+		}
+		if _, err := strconv.Atoi(name); err == nil {
+			fmt.Fprintf(os.Stderr, "readelf: Warning: Section %s was not dumped because it does not exist!\n", name)
+		} else {
+			fmt.Fprintf(os.Stderr, "readelf: Warning: Section '%s' was not dumped because it does not exist\n", name)
+		}
+	}
+	return nil
+}
+
+// dumpSectionMatches reports whether a -x or -p argument, a section name or
+// number, names the section at index
+func dumpSectionMatches(elfFile *elf.ELF, index int, name string) bool {
+	if n, err := strconv.Atoi(name); err == nil {
+		return n == index
+	}
+	return elfFile.Sections[index].Name == name
+}
+
+// dumpSectionData returns the contents of a section to dump, or nil with a
+// message printed when there is nothing to dump
+func dumpSectionData(w io.Writer, section *elf.Section) ([]byte, error) {
+	if section.Type == elf.SHT_NOBITS || section.Size == 0 {
+		fmt.Fprintf(w, "Section '%s' has no data to dump.\n", section.Name)
+		return nil, nil
+	}
+
+	// Secure: limit dumped section size
+	return section.ReadAll(100 * 1024 * 1024)
+}
+
+// showHexDump shows the contents of a section as hex bytes and characters
+func showHexDump(w io.Writer, elfFile *elf.ELF, section *elf.Section) error {
+	data, err := dumpSectionData(w, section)
+	if data == nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nHex dump of section '%s':\n", section.Name)
+	if hasRelocations(elfFile, section) {
+		fmt.Fprintf(w, " NOTE: This section has relocations against it, but these have NOT been applied to this dump.\n")
+	}
+
+	for off := 0; off < len(data); off += 16 {
+		line := data[off:min(off+16, len(data))]
+		fmt.Fprintf(w, "  0x%08x ", section.Addr+uint64(off))
+		for j := 0; j < 16; j++ {
+			if j < len(line) {
+				fmt.Fprintf(w, "%02x", line[j])
+			} else {
+				fmt.Fprintf(w, "  ")
+			}
+			if j%4 == 3 {
+				fmt.Fprintf(w, " ")
+			}
+		}
+		for _, b := range line {
+			if b >= ' ' && b < 0x7f {
+				fmt.Fprintf(w, "%c", b)
+			} else {
+				fmt.Fprintf(w, ".")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// showStringDump shows the strings in a section with their offsets. Like
+// GNU readelf, a string starts at a printable character and ends at a NUL
+// or a newline; control characters are shown as ^X.
+func showStringDump(w io.Writer, elfFile *elf.ELF, section *elf.Section) error {
+	data, err := dumpSectionData(w, section)
+	if data == nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nString dump of section '%s':\n", section.Name)
+	if hasRelocations(elfFile, section) {
+		fmt.Fprintf(w, "  Note: This section has relocations against it, but these have NOT been applied to this dump.\n")
+	}
+	found := false
+	continuing := false // The last string ended at a newline
+	for off := 0; off < len(data); {
+		if data[off] < ' ' || data[off] >= 0x7f {
+			off++
+			continue
+		}
+		if continuing {
+			fmt.Fprintf(w, "            ")
+			continuing = false
+		} else {
+			fmt.Fprintf(w, "  [%6x]  ", off)
+		}
+
+		var b strings.Builder
+		for off < len(data) {
+			c := data[off]
+			off++
+			if c == 0 {
+				break
+			}
+			if c == '\n' {
+				b.WriteString("\\n")
+				continuing = off < len(data) && data[off] != 0
+				break
+			}
+			if c < ' ' || c == 0x7f {
+				b.WriteByte('^')
+				b.WriteByte(c + 0x40)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+		fmt.Fprintln(w, b.String())
+		found = true
+	}
+	if !found {
+		fmt.Fprintf(w, "  No strings found in this section.")
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// hasRelocations reports whether a relocation section applies to section
+func hasRelocations(elfFile *elf.ELF, section *elf.Section) bool {
+	for i := range elfFile.Sections {
+		rel := &elfFile.Sections[i]
+		if (rel.Type == elf.SHT_REL || rel.Type == elf.SHT_RELA) && int(rel.Info) < len(elfFile.Sections) &&
+			&elfFile.Sections[rel.Info] == section {
+			return true
+		}
+	}
+	return false
+}
+
+// showNotes shows the notes of each note section or segment
+func showNotes(r io.ReadSeeker, elfFile *elf.ELF) error {
+	groups, err := elfFile.Notes(r)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		if group.Section != "" {
+			fmt.Printf("\nDisplaying notes found in: %s\n", group.Section)
+		} else {
+			fmt.Printf("\nDisplaying notes found at file offset 0x%08x with length 0x%08x:\n", group.Offset, group.Size)
+		}
+		fmt.Printf("  Owner                Data size \tDescription\n")
+		for _, note := range group.Notes {
+			fmt.Printf("  %-20s 0x%08x\t%s\n", note.Namespace, len(note.Desc), getNoteType(note))
+			showNoteDesc(note, elfFile)
+		}
+	}
+	return nil
+}
+
+// getNoteType returns the readelf description of a note type
+func getNoteType(note elf.Note) string {
+	switch note.Namespace {
+	case "GNU":
+		switch note.Type {
+		case elf.NT_GNU_ABI_TAG:
+			return "NT_GNU_ABI_TAG (ABI version tag)"
+		case elf.NT_GNU_HWCAP:
+			return "NT_GNU_HWCAP (DSO-supplied software HWCAP info)"
+		case elf.NT_GNU_BUILD_ID:
+			return "NT_GNU_BUILD_ID (unique build ID bitstring)"
+		case elf.NT_GNU_GOLD_VERSION:
+			return "NT_GNU_GOLD_VERSION (gold version)"
+		case elf.NT_GNU_PROPERTY_TYPE_0:
+			return "NT_GNU_PROPERTY_TYPE_0"
+		}
+	case "Go":
+		if note.Type == 4 {
+			return "GO BUILDID"
+		}
+	case "CORE":
+		switch note.Type {
+		case 1:
+			return "NT_PRSTATUS (prstatus structure)"
+		case 2:
+			return "NT_FPREGSET (floating point registers)"
+		case 3:
+			return "NT_PRPSINFO (prpsinfo structure)"
+		case 6:
+			return "NT_AUXV (auxiliary vector)"
+		case 0x46494c45:
+			return "NT_FILE (mapped files)"
+		}
+	}
+	return fmt.Sprintf("Unknown note type: (0x%08x)", note.Type)
+}
+
+// showNoteDesc decodes the descriptor of well-known GNU notes and dumps the
+// bytes of any other note
+func showNoteDesc(note elf.Note, elfFile *elf.ELF) {
+	endian := elfFile.ByteOrder()
+	if note.Namespace == "GNU" {
+		switch note.Type {
+		case elf.NT_GNU_BUILD_ID:
+			fmt.Printf("    Build ID: %x\n", note.Desc)
+			return
+		case elf.NT_GNU_ABI_TAG:
+			if len(note.Desc) >= 16 {
+				osNames := []string{"Linux", "Hurd", "Solaris", "FreeBSD", "NetBSD", "Syllable"}
+				osName := fmt.Sprintf("Unknown (%d)", endian.Uint32(note.Desc))
+				if id := endian.Uint32(note.Desc); id < uint32(len(osNames)) {
+					osName = osNames[id]
+				}
+				fmt.Printf("    OS: %s, ABI: %d.%d.%d\n", osName,
+					endian.Uint32(note.Desc[4:]), endian.Uint32(note.Desc[8:]), endian.Uint32(note.Desc[12:]))
+				return
+			}
+		case elf.NT_GNU_GOLD_VERSION:
+			fmt.Printf("    Version: %s\n", elf.ReadCString(note.Desc))
+			return
+		case elf.NT_GNU_PROPERTY_TYPE_0:
+			align := 8
+			if elfFile.Class == "ELF32" {
+				align = 4
+			}
+			fmt.Printf("      Properties: %s\n", formatGNUProperties(note.Desc, endian, align))
+			return
+		}
+	}
+
+	if len(note.Desc) == 0 {
+		return
+	}
+	fmt.Printf("   description data: ")
+	for _, b := range note.Desc {
+		fmt.Printf("%02x ", b)
+	}
 	fmt.Println()
-	showProgramHeaders(elfFile)
+}
+
+// formatGNUProperties decodes the type/size/value records of a
+// NT_GNU_PROPERTY_TYPE_0 note
+func formatGNUProperties(desc []byte, endian binary.ByteOrder, align int) string {
+	parts := []string{}
+	for pos := 0; pos+8 <= len(desc); {
+		propType := endian.Uint32(desc[pos:])
+		size := int(endian.Uint32(desc[pos+4:]))
+		pos += 8
+		if size > len(desc)-pos {
+			parts = append(parts, "<corrupt GNU_PROPERTY_TYPE>")
+			break
+		}
+		data := desc[pos : pos+size]
+
+		var value uint32
+		if size >= 4 {
+			value = endian.Uint32(data)
+		}
+		switch propType {
+		case 0xc0000002: // GNU_PROPERTY_X86_FEATURE_1_AND
+			parts = append(parts, "x86 feature: "+formatPropertyBits(value, []string{"IBT", "SHSTK", "LAM_U48", "LAM_U57"}))
+		case 0xc0008002: // GNU_PROPERTY_X86_ISA_1_NEEDED
+			parts = append(parts, "x86 ISA needed: "+formatPropertyBits(value, []string{"x86-64-baseline", "x86-64-v2", "x86-64-v3", "x86-64-v4"}))
+		case 0xc0008001: // GNU_PROPERTY_X86_ISA_1_USED
+			parts = append(parts, "x86 ISA used: "+formatPropertyBits(value, []string{"x86-64-baseline", "x86-64-v2", "x86-64-v3", "x86-64-v4"}))
+		case 0xc0000000: // GNU_PROPERTY_AARCH64_FEATURE_1_AND
+			parts = append(parts, "AArch64 feature: "+formatPropertyBits(value, []string{"BTI", "PAC"}))
+		case 1: // GNU_PROPERTY_STACK_SIZE
+			parts = append(parts, fmt.Sprintf("stack size: 0x%x", value))
+		case 2: // GNU_PROPERTY_NO_COPY_ON_PROTECTED
+			parts = append(parts, "no copy on protected")
+		default:
+			parts = append(parts, fmt.Sprintf("<unknown: %x>", propType))
+		}
+
+		// Property records are padded to the word size of the file
+		pos += (size + align - 1) &^ (align - 1)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatPropertyBits names the set bits of a GNU property value
+func formatPropertyBits(value uint32, names []string) string {
+	set := []string{}
+	for i, name := range names {
+		if value&(1<<i) != 0 {
+			set = append(set, name)
+		}
+	}
+	if len(set) == 0 {
+		return "<None>"
+	}
+	return strings.Join(set, ", ")
+}
+
+// flagName names one bit of a flags word
+type flagName struct {
+	bit  uint64
+	name string
+}
+
+// formatFlagNames lists the names of the set bits, followed by any unknown bits
+func formatFlagNames(value uint64, names []flagName) string {
+	parts := []string{}
+	for _, flag := range names {
+		if value&flag.bit != 0 {
+			parts = append(parts, flag.name)
+			value &^= flag.bit
+		}
+	}
+	if value != 0 {
+		parts = append(parts, fmt.Sprintf("0x%x", value))
+	}
+	return strings.Join(parts, " ")
 }
 
 // getSectionType returns section type name
@@ -184,6 +920,8 @@ func getSectionType(t uint32) string {
 		9:  "REL",
 		10: "SHLIB",
 		11: "DYNSYM",
+
+		elf.SHT_GNU_HASH: "GNU_HASH",
 	}
 	if name, ok := types[t]; ok {
 		return name
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// TestDumpGolden tests the -r, -x and -p output against that of GNU
+// readelf 2.40 saved in testdata/readelf
+func TestDumpGolden(t *testing.T) {
+	tests := []struct {
+		file   string
+		golden string
+		relocs bool     // -r
+		hex    []string // -x
+		str    []string // -p
+	}{
+		{"hello.o", "hello.o.r", true, nil, nil},
+		// Dynamic relocations name the versions needed from libc
+		{"hello", "hello.r", true, nil, nil},
+		{"dyn/lib/libversion.so.1", "libversion.so.1.r", true, nil, nil},
+		{"hello.o", "hello.o.x", false, []string{".text", ".data", ".bss"}, nil},
+		// Sections are dumped in section order, not the order asked for
+		{"hello.o", "hello.o.p", false, nil, []string{".strtab", ".comment"}},
+		{"hello", "hello.x", false, []string{".rodata", ".init_array"}, nil},
+		// All three groups are named .group; section 1 is dumped only once
+		{"hello.o", "hello.o.group", false, []string{".group", "1"}, nil},
+	}
+
+	for _, tt := range tests {
+		file, err := os.Open(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := elf.ParseELF(file)
+		if err != nil {
+			file.Close()
+			t.Fatalf("ParseELF(%s): %v", tt.file, err)
+		}
+
+		var got bytes.Buffer
+		if tt.relocs {
+			err = showRelocations(&got, e, false)
+		} else {
+			err = showSectionDumps(&got, e, tt.hex, tt.str)
+		}
+		file.Close()
+		if err != nil {
+			t.Errorf("%s: %v", tt.golden, err)
+			continue
+		}
+		want, err := os.ReadFile(filepath.Join("testdata", "readelf", tt.golden))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != string(want) {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tt.golden, got.String(), want)
+		}
+	}
+}
+
+// TestShowStringDump tests where strings start and end in binary data, and
+// how control characters are shown, against GNU readelf -p
+func TestShowStringDump(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{"\x00abc\x00de\x00", "  [     1]  abc\n  [     5]  de\n"},
+		// Strings start at a printable character but may hold any byte
+		{"\xe8\x01\x00ab\x01\x02\x7fz\xff\x00", "  [     3]  ab^A^B^\xbfz\xff\n"},
+		// A newline ends a string and the next one continues its entry
+		{"ab\ncd\x00\n\x00tail", "  [     0]  ab\\n\n            cd\n  [     8]  tail\n"},
+		{"\x00\x01\x02", "  No strings found in this section."},
+	}
+
+	for _, tt := range tests {
+		e := &elf.ELF{Sections: []elf.Section{
+			{},
+			{Name: ".mystr", Type: elf.SHT_PROGBITS, Size: uint64(len(tt.data)), Data: []byte(tt.data)},
+		}}
+		var got bytes.Buffer
+		if err := showStringDump(&got, e, &e.Sections[1]); err != nil {
+			t.Fatalf("showStringDump(%q): %v", tt.data, err)
+		}
+		if want := "\nString dump of section '.mystr':\n" + tt.expected + "\n"; got.String() != want {
+			t.Errorf("showStringDump(%q) = %q, want %q", tt.data, got.String(), want)
+		}
+	}
+}
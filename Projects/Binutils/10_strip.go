@@ -10,64 +10,188 @@ import (
 // Strip - Discard symbols from object files (GNU strip equivalent)
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <file>...\n", os.Args[0])
+	options, files, err := parseStripOptions(os.Args[1:])
+	if err != nil || len(files) == 0 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Usage: %s [-s|-g|--strip-unneeded] [-o output] <file>...\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	if options.Output != "" && len(files) > 1 {
+		fmt.Fprintf(os.Stderr, "Error: -o may only be used with a single input file\n")
 		os.Exit(1)
 	}
 
-	for _, filename := range os.Args[1:] {
-		if err := stripFile(filename); err != nil {
+	failed := false
+	for _, filename := range files {
+		output := filename
+		if options.Output != "" {
+			output = options.Output
+		}
+		if err := stripFile(filename, output, options); err != nil {
 			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// StripMode selects what strip removes
+type StripMode int
+
+const (
+	// StripAll removes the symbol table and all debugging sections
+	StripAll StripMode = iota
+	// StripDebug removes debugging sections only
+	StripDebug
+	// StripUnneeded removes debugging sections and symbols not needed for relocation
+	StripUnneeded
+)
+
+// StripOptions represents strip options
+type StripOptions struct {
+	Mode   StripMode
+	Output string
+}
+
+// parseStripOptions parses command line options and returns the input files
+func parseStripOptions(args []string) (StripOptions, []string, error) {
+	opts := StripOptions{Mode: StripAll}
+	files := []string{}
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-s", "--strip-all":
+			opts.Mode = StripAll
+		case "-g", "-S", "-d", "--strip-debug":
+			opts.Mode = StripDebug
+		case "--strip-unneeded":
+			opts.Mode = StripUnneeded
+		case "-o":
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("option -o requires an argument")
+			}
+			opts.Output = args[i+1]
+			i++
+		default:
+			if len(args[i]) > 0 && args[i][0] == '-' {
+				return opts, nil, fmt.Errorf("unknown option: %s", args[i])
+			}
+			files = append(files, args[i])
 		}
 	}
+
+	return opts, files, nil
 }
 
 // stripFile strips symbols from file
-func stripFile(filename string) error {
+func stripFile(filename, output string, options StripOptions) error {
 	file, err := os.Open(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
 	elfFile, err := elf.ParseELF(file)
 	if err != nil {
 		return fmt.Errorf("not an ELF file: %w", err)
 	}
 
-	// Remove all symbols
-	elfFile.Symbols = []elf.Symbol{}
+	if err := elfFile.LoadSectionData(file); err != nil {
+		return err
+	}
+
+	if err := stripELF(elfFile, options.Mode); err != nil {
+		return err
+	}
 
-	// Remove debug sections
-	newSections := []elf.Section{}
+	// Write to a temporary file first so a failure never leaves a truncated binary
+	tmpName := output + ".strip.tmp"
+	tmp, err := os.OpenFile(tmpName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, stat.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if err := elf.WriteELF(tmp, elfFile); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	return os.Rename(tmpName, output)
+}
+
+// stripELF removes sections and symbols according to mode
+func stripELF(elfFile *elf.ELF, mode StripMode) error {
+	// Debug sections go first so relocations against them no longer pin symbols
+	elfFile.RemoveSections(func(s *elf.Section) bool {
+		return s.IsDebug()
+	})
+
+	// Relocatable objects still need the symbols their relocations refer to
+	hasRelocations := false
 	for _, section := range elfFile.Sections {
-		if !isDebugSection(section.Name) {
-			newSections = append(newSections, section)
+		if section.Type == elf.SHT_REL || section.Type == elf.SHT_RELA {
+			hasRelocations = true
 		}
 	}
-	elfFile.Sections = newSections
 
-	// Write stripped file
-	// In production, properly rebuild ELF file
-	// For now, create a backup and write modified version
-	backup := filename + ".bak"
-	os.Rename(filename, backup)
-	defer os.Rename(backup, filename)
+	// Section symbols of removed sections are left pointing at SHN_UNDEF
+	orphaned := func(sym elf.SymbolEntry) bool {
+		return sym.Type() == 3 && sym.Shndx == elf.SHN_UNDEF // STT_SECTION
+	}
 
-	// Simplified - in production would properly rebuild ELF
-	// For now, just return success
-	return nil
-}
+	var keep func(sym elf.SymbolEntry) bool
+	switch mode {
+	case StripAll:
+		keep = func(sym elf.SymbolEntry) bool {
+			return sym.Relocation
+		}
+	case StripUnneeded:
+		keep = func(sym elf.SymbolEntry) bool {
+			return sym.Relocation || (hasRelocations && sym.Binding() != 0 && sym.Shndx != elf.SHN_UNDEF)
+		}
+	default:
+		keep = func(sym elf.SymbolEntry) bool {
+			return sym.Relocation || !orphaned(sym)
+		}
+	}
 
-// isDebugSection checks if section is a debug section
-func isDebugSection(name string) bool {
-	debugSections := []string{
-		".debug", ".zdebug", ".comment", ".note",
+	kept, err := elfFile.FilterSymbols(keep)
+	if err != nil {
+		return err
+	}
+	if kept > 0 || mode == StripDebug {
+		return nil
 	}
-	for _, prefix := range debugSections {
-		if len(name) >= len(prefix) && name[:len(prefix)] == prefix {
-			return true
+
+	// Nothing is left in the symbol table, so drop it with its string table
+	var symtabStrings *elf.Section
+	for i := range elfFile.Sections {
+		section := &elfFile.Sections[i]
+		if section.Type == elf.SHT_SYMTAB && section.Link != 0 && int(section.Link) < len(elfFile.Sections) {
+			symtabStrings = &elfFile.Sections[section.Link]
 		}
 	}
-	return false
+
+	elfFile.RemoveSections(func(s *elf.Section) bool {
+		return s.Type == elf.SHT_SYMTAB || s == symtabStrings
+	})
+
+	return nil
 }
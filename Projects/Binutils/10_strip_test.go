@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// TestStripGroupSignatures tests that every strip mode leaves each COMDAT
+// group of a C++ object named by its own signature symbol
+func TestStripGroupSignatures(t *testing.T) {
+	// Group members and signatures g++ emits for testdata/hello.cpp
+	want := map[string]string{
+		".text._ZnwmPv":             "_ZnwmPv",
+		".text._ZN2ns5PointC2Ei":    "_ZN2ns5PointC5Ei",
+		".text._ZNK2ns5Point3getEv": "_ZNK2ns5Point3getEv",
+		".text._Z5twiceIiET_S0_":    "_Z5twiceIiET_S0_",
+	}
+
+	for _, mode := range []StripMode{StripAll, StripDebug, StripUnneeded} {
+		output := filepath.Join(t.TempDir(), "hello.o")
+		if err := stripFile(filepath.Join("testdata", "hello.o"), output, StripOptions{Mode: mode}); err != nil {
+			t.Fatalf("Mode %d: stripFile: %v", mode, err)
+		}
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := elf.ParseELF(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Mode %d: ParseELF: %v", mode, err)
+		}
+		if err := e.LoadSectionData(bytes.NewReader(data)); err != nil {
+			t.Fatalf("Mode %d: LoadSectionData: %v", mode, err)
+		}
+
+		found := 0
+		for _, section := range e.Sections {
+			if section.Type != elf.SHT_GROUP {
+				continue
+			}
+			found++
+			if len(section.Data) != 8 || int(section.Info) >= len(e.Symbols) {
+				t.Errorf("Mode %d: malformed group: info %d, %d bytes", mode, section.Info, len(section.Data))
+				continue
+			}
+			member := e.ByteOrder().Uint32(section.Data[4:])
+			if int(member) >= len(e.Sections) {
+				t.Errorf("Mode %d: group member %d out of range", mode, member)
+				continue
+			}
+			name := e.Sections[member].Name
+			if signature := e.Symbols[section.Info].Name; signature != want[name] {
+				t.Errorf("Mode %d: group of %s has signature %q, want %q", mode, name, signature, want[name])
+			}
+		}
+		if found != len(want) {
+			t.Errorf("Mode %d: %d groups, want %d", mode, found, len(want))
+		}
+	}
+}
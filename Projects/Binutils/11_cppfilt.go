@@ -1,160 +1,111 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
-	"regexp"
 	"strings"
+
+	"hellogolang/Projects/Binutils/demangle"
 )
 
 // C++filt - Demangle C++ symbols (GNU c++filt equivalent)
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <mangled-name>...\n", os.Args[0])
+	options, names, err := parseCppfiltOptions(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Usage: %s [-_] [mangled-name...]\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	for _, name := range os.Args[1:] {
-		demangled := demangle(name)
-		fmt.Println(demangled)
-	}
-}
-
-// demangle demangles C++ symbol name
-func demangle(mangled string) string {
-	// Secure: validate input length
-	if len(mangled) > 10000 {
-		return mangled
-	}
-
-	// Remove leading underscore if present
-	if len(mangled) > 0 && mangled[0] == '_' {
-		mangled = mangled[1:]
-	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
 
-	// Check if it's a mangled name
-	if !strings.HasPrefix(mangled, "_Z") {
-		return mangled // Not mangled
+	// With no names, filter standard input as c++filt does, demangling
+	// each symbol found in the text
+	if len(names) == 0 {
+		if err := filterText(out, os.Stdin, options); err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
-
-	// Simple demangling (full implementation would parse Itanium ABI)
-	// This is a simplified version
-	if strings.HasPrefix(mangled, "_ZN") {
-		// Mangled class member or namespace
-		return demangleClassMember(mangled)
+	for _, name := range names {
+		fmt.Fprintln(out, demangleSymbol(name, options))
 	}
-
-	if strings.HasPrefix(mangled, "_Z") {
-		// Mangled function
-		return demangleFunction(mangled)
-	}
-
-	return mangled
 }
 
-// demangleFunction demangles function name
-func demangleFunction(mangled string) string {
-	// Secure: validate format
-	if len(mangled) < 3 {
-		return mangled
-	}
-
-	// Skip _Z
-	mangled = mangled[2:]
-
-	// Simple pattern matching
-	// Full implementation would properly parse Itanium ABI mangling
-	re := regexp.MustCompile(`^(\d+)([a-zA-Z_][a-zA-Z0-9_]*)`)
-	matches := re.FindStringSubmatch(mangled)
-
-	if len(matches) >= 3 {
-		nameLen := parseIntSafe(matches[1])
-		if nameLen > 0 && nameLen < len(matches[2]) {
-			name := matches[2][:nameLen]
-			// Secure: validate name
-			if isValidIdentifier(name) {
-				return name
-			}
-		}
-	}
-
-	return "_Z" + mangled
+// CppfiltOptions represents c++filt options
+type CppfiltOptions struct {
+	StripUnderscore bool // -_: symbols carry a leading underscore, as on Mach-O
 }
 
-// demangleClassMember demangles class member name
-func demangleClassMember(mangled string) string {
-	// Secure: validate format
-	if len(mangled) < 4 {
-		return mangled
-	}
-
-	// Skip _ZN
-	mangled = mangled[3:]
-
-	// Simple pattern matching for class::member
-	parts := []string{}
-
-	for len(mangled) > 0 {
-		re := regexp.MustCompile(`^(\d+)([a-zA-Z_][a-zA-Z0-9_]*)`)
-		matches := re.FindStringSubmatch(mangled)
-
-		if len(matches) >= 3 {
-			nameLen := parseIntSafe(matches[1])
-			if nameLen > 0 && nameLen <= len(matches[2]) {
-				name := matches[2][:nameLen]
-				if isValidIdentifier(name) {
-					parts = append(parts, name)
-					mangled = mangled[len(matches[0]):]
-					continue
-				}
+// parseCppfiltOptions parses command line options and returns the names
+func parseCppfiltOptions(args []string) (CppfiltOptions, []string, error) {
+	var opts CppfiltOptions
+	names := []string{}
+	for i, arg := range args {
+		switch arg {
+		case "-_", "--strip-underscore":
+			opts.StripUnderscore = true
+		case "-n", "--no-strip-underscore":
+			opts.StripUnderscore = false
+		case "--":
+			return opts, append(names, args[i+1:]...), nil
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return opts, nil, fmt.Errorf("unknown option: %s", arg)
 			}
+			names = append(names, arg)
 		}
-
-		break
 	}
-
-	if len(parts) > 0 {
-		return strings.Join(parts, "::")
-	}
-
-	return "_ZN" + mangled
+	return opts, names, nil
 }
 
-// parseIntSafe safely parses integer
-func parseIntSafe(s string) int {
-	result := 0
-	for _, c := range s {
-		if c < '0' || c > '9' {
-			return 0
-		}
-		result = result*10 + int(c-'0')
-		// Secure: prevent overflow
-		if result > 10000 {
-			return 0
+// demangleSymbol returns the decoded form of a symbol, or the symbol
+// itself if it is not a mangled name
+func demangleSymbol(name string, options CppfiltOptions) string {
+	if options.StripUnderscore && strings.HasPrefix(name, "__Z") {
+		if decoded, err := demangle.Demangle(name[1:]); err == nil {
+			return decoded
 		}
+		return name
 	}
-	return result
+	return demangle.Filter(name)
 }
 
-// isValidIdentifier checks if string is valid identifier
-func isValidIdentifier(s string) bool {
-	if len(s) == 0 || len(s) > 1000 {
-		return false
-	}
-
-	// Check first character
-	if !((s[0] >= 'a' && s[0] <= 'z') || (s[0] >= 'A' && s[0] <= 'Z') || s[0] == '_') {
-		return false
-	}
+// isSymbolByte reports whether c may be part of a symbol in text
+func isSymbolByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '$'
+}
 
-	// Check remaining characters
-	for i := 1; i < len(s); i++ {
-		c := s[i]
-		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_') {
-			return false
+// filterText copies r to w line by line, replacing each mangled symbol
+func filterText(w io.Writer, r io.Reader, options CppfiltOptions) error {
+	scanner := bufio.NewScanner(r)
+	// Secure: bound the line held in memory
+	scanner.Buffer(make([]byte, 0, 4096), demangle.MaxNameLength*4)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var b strings.Builder
+		for i := 0; i < len(line); {
+			if !isSymbolByte(line[i]) {
+				b.WriteByte(line[i])
+				i++
+				continue
+			}
+			j := i
+			for j < len(line) && isSymbolByte(line[j]) {
+				j++
+			}
+			b.WriteString(demangleSymbol(line[i:j], options))
+			i = j
+		}
+		if _, err := fmt.Fprintln(w, b.String()); err != nil {
+			return err
 		}
 	}
-
-	return true
+	return scanner.Err()
 }
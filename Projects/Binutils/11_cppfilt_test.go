@@ -1,68 +1,60 @@
 package main
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 )
 
-// TestDemangle tests symbol demangling
-func TestDemangle(t *testing.T) {
+// TestDemangleSymbol tests symbol demangling
+func TestDemangleSymbol(t *testing.T) {
 	tests := []struct {
 		input    string
+		strip    bool
 		expected string
 	}{
-		{"_Z4testv", "test"},
-		{"normal_symbol", "normal_symbol"},
-		{"_ZN5Class6methodEv", "Class::method"},
+		{"_Z4testv", false, "test()"},
+		{"normal_symbol", false, "normal_symbol"},
+		{"_ZN5Class6methodEv", false, "Class::method()"},
+		{"_ZN5Class6methodEv", true, "Class::method()"},
+		{"__ZN5Class6methodEv", true, "Class::method()"},
+		{"__ZN5Class6methodEv", false, "__ZN5Class6methodEv"},
+		{"_ZN5Class", false, "_ZN5Class"},
 	}
 
 	for _, tt := range tests {
-		result := demangle(tt.input)
-		// Note: Simplified demangling may not match exactly
-		if result == "" {
-			t.Errorf("demangle(%s) returned empty string", tt.input)
+		result := demangleSymbol(tt.input, CppfiltOptions{StripUnderscore: tt.strip})
+		if result != tt.expected {
+			t.Errorf("demangleSymbol(%s, strip %v) = %q, expected %q", tt.input, tt.strip, result, tt.expected)
 		}
 	}
 }
 
-// TestIsValidIdentifier tests identifier validation
-func TestIsValidIdentifier(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected bool
-	}{
-		{"valid_name", true},
-		{"ValidName123", true},
-		{"_valid", true},
-		{"123invalid", false},
-		{"", false},
-		{"valid-name", false},
+// TestFilterText tests that symbols within text are replaced and the rest
+// is copied unchanged
+func TestFilterText(t *testing.T) {
+	input := "0000000000001139 T _ZN2ns5PointC2Ei\n" +
+		"call _ZNSt6vectorIiSaIiEE9push_backERKi@plt; main\n" +
+		"no symbols here\n"
+	expected := "0000000000001139 T ns::Point::Point(int)\n" +
+		"call std::vector<int, std::allocator<int> >::push_back(int const&)@plt; main\n" +
+		"no symbols here\n"
+	var out bytes.Buffer
+	if err := filterText(&out, strings.NewReader(input), CppfiltOptions{}); err != nil {
+		t.Fatal(err)
 	}
-
-	for _, tt := range tests {
-		result := isValidIdentifier(tt.input)
-		if result != tt.expected {
-			t.Errorf("isValidIdentifier(%s) = %v, expected %v", tt.input, result, tt.expected)
-		}
+	if out.String() != expected {
+		t.Errorf("filterText = %q, expected %q", out.String(), expected)
 	}
 }
 
-// TestParseIntSafe tests safe integer parsing
-func TestParseIntSafe(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int
-	}{
-		{"123", 123},
-		{"0", 0},
-		{"9999", 9999},
-		{"abc", 0},
-		{"12345", 0}, // Too large
+// TestParseCppfiltOptions tests option and name parsing
+func TestParseCppfiltOptions(t *testing.T) {
+	opts, names, err := parseCppfiltOptions([]string{"-_", "_Z1fv", "--", "-x"})
+	if err != nil || !opts.StripUnderscore || len(names) != 2 || names[1] != "-x" {
+		t.Errorf("parseCppfiltOptions = %+v, %q, %v", opts, names, err)
 	}
-
-	for _, tt := range tests {
-		result := parseIntSafe(tt.input)
-		if result != tt.expected {
-			t.Errorf("parseIntSafe(%s) = %d, expected %d", tt.input, result, tt.expected)
-		}
+	if _, _, err := parseCppfiltOptions([]string{"-q"}); err == nil {
+		t.Error("parseCppfiltOptions accepted an unknown option")
 	}
 }
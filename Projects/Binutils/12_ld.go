@@ -1,186 +1,629 @@
 package main
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 
+	"hellogolang/Projects/Binutils/arfile"
 	"hellogolang/Projects/Binutils/elf"
 )
 
 // Ld - Linker (GNU ld equivalent - simplified)
+//
+// Relocatable objects are combined the way the default GNU ld script does:
+// input sections are merged by name into .text, .rodata, .data and .bss,
+// and each group of sections with the same permissions starts on a new page.
+// Section addresses and the entry point can be chosen on the command line.
+// Undefined symbols fail the link unless --allow-undefined is given; with -r
+// the objects are merged into one relocatable object instead.
+// The output is a static x86-64 executable with one PT_LOAD segment per
+// group and a section header table.
 
 func main() {
-	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "Usage: %s -o <output> <input>...\n", os.Args[0])
+	options, inputFiles, err := parseLdOptions(os.Args[1:])
+	if err != nil || len(inputFiles) == 0 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: no input files specified\n")
+		}
+		fmt.Fprintf(os.Stderr, "Usage: %s -o <output> [-e entry] [-Ttext addr] [-Tdata addr] [-Tbss addr]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "          [--section-start name=addr] [--allow-undefined] [-r] <input>...\n")
 		os.Exit(1)
 	}
 
-	outputFile := ""
-	inputFiles := []string{}
+	if err := linkFiles(inputFiles, options); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
 
-	// Parse arguments
-	for i := 1; i < len(os.Args); i++ {
-		if os.Args[i] == "-o" && i+1 < len(os.Args) {
-			outputFile = os.Args[i+1]
-			i++
-		} else {
-			inputFiles = append(inputFiles, os.Args[i])
+// LdOptions represents ld options
+type LdOptions struct {
+	Output       string            // -o
+	Entry        string            // -e, --entry: symbol or address
+	SectionStart map[string]uint64 // -Ttext, -Tdata, -Tbss, --section-start
+
+	AllowUndefined bool // --allow-undefined: undefined symbols resolve to zero
+	Relocatable    bool // -r, --relocatable: write a relocatable object
+}
+
+// Default layout of an x86-64 executable, as in the GNU ld script
+const (
+	defaultTextAddress = 0x401000
+	pageSize           = 0x1000
+)
+
+// parseLdOptions parses command line options and returns the input files
+func parseLdOptions(args []string) (LdOptions, []string, error) {
+	opts := LdOptions{Entry: "_start", SectionStart: map[string]uint64{}}
+	files := []string{}
+
+	// value returns the argument of an option given as "-x value", "-xvalue"
+	// or "--opt=value"
+	value := func(i *int, name string) (string, error) {
+		arg := args[*i]
+		if strings.HasPrefix(arg, "--") {
+			if eq := strings.IndexByte(arg, '='); eq >= 0 {
+				return arg[eq+1:], nil
+			}
+		} else if len(arg) > len(name) {
+			return strings.TrimPrefix(arg[len(name):], "="), nil
+		}
+		if *i+1 >= len(args) {
+			return "", fmt.Errorf("option %s requires an argument", name)
 		}
+		*i++
+		return args[*i], nil
 	}
 
-	if outputFile == "" {
-		fmt.Fprintf(os.Stderr, "Error: no output file specified\n")
-		os.Exit(1)
-	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name := arg
+		if eq := strings.IndexByte(arg, '='); eq >= 0 && strings.HasPrefix(arg, "--") {
+			name = arg[:eq]
+		}
 
-	if len(inputFiles) == 0 {
-		fmt.Fprintf(os.Stderr, "Error: no input files specified\n")
-		os.Exit(1)
+		switch {
+		case strings.HasPrefix(name, "-o") && !strings.HasPrefix(name, "--"):
+			v, err := value(&i, "-o")
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.Output = v
+		case name == "--entry" || (strings.HasPrefix(name, "-e") && !strings.HasPrefix(name, "--")):
+			v, err := value(&i, name[:2])
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.Entry = v
+		case strings.HasPrefix(name, "-Ttext") || strings.HasPrefix(name, "-Tdata") || strings.HasPrefix(name, "-Tbss"):
+			option := strings.SplitN(name, "=", 2)[0]
+			v, err := value(&i, option)
+			if err != nil {
+				return opts, nil, err
+			}
+			addr, err := parseAddress(v)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.SectionStart["."+option[2:]] = addr
+		case name == "--allow-undefined":
+			opts.AllowUndefined = true
+		case name == "-r" || name == "--relocatable":
+			opts.Relocatable = true
+		case name == "--section-start":
+			v, err := value(&i, name)
+			if err != nil {
+				return opts, nil, err
+			}
+			section, addrText, ok := strings.Cut(v, "=")
+			if !ok || section == "" {
+				return opts, nil, fmt.Errorf("invalid --section-start argument: %s", v)
+			}
+			addr, err := parseAddress(addrText)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.SectionStart[section] = addr
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return opts, nil, fmt.Errorf("unknown option: %s", arg)
+			}
+			// Secure: validate number of input files
+			if len(files) >= 1000 {
+				return opts, nil, fmt.Errorf("too many input files")
+			}
+			files = append(files, arg)
+		}
 	}
 
-	if err := linkFiles(inputFiles, outputFile); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	if opts.Output == "" {
+		opts.Output = "a.out"
 	}
+	return opts, files, nil
 }
 
-// linkFiles links multiple object files
-func linkFiles(inputFiles []string, outputFile string) error {
-	// Secure: validate number of input files
-	if len(inputFiles) > 1000 {
-		return fmt.Errorf("too many input files")
+// parseAddress parses an address; like GNU ld, addresses are hexadecimal
+// with or without a 0x prefix
+func parseAddress(s string) (uint64, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	addr, err := strconv.ParseUint(digits, 16, 64)
+	if err != nil || digits == "" {
+		return 0, fmt.Errorf("invalid address: %s", s)
 	}
+	return addr, nil
+}
+
+// inputObject is a relocatable object taking part in the link
+type inputObject struct {
+	name     string
+	elf      *elf.ELF
+	sections []*inputSection // Indexed like the object's section headers; nil if not linked
+}
+
+// inputSection is an allocated section of an input object and its place in
+// the output
+type inputSection struct {
+	object  *inputObject
+	section *elf.Section
+	data    []byte
+	output  *outputSection
+	offset  uint64 // Offset within the output section
+	relocs  []elf.Relocation
+}
+
+// outputSection is a section of the linked file, merged from input sections
+type outputSection struct {
+	name   string
+	typ    uint32
+	flags  uint64
+	align  uint64
+	addr   uint64
+	offset uint64 // File offset
+	size   uint64
+	data   []byte
+	inputs []*inputSection
+}
 
-	// Parse all input files
-	objects := []*elf.ELF{}
-	allSymbols := []elf.Symbol{}
-	allSections := []elf.Section{}
+// symbolDef is the definition a global symbol resolved to
+type symbolDef struct {
+	object *inputObject
+	symbol *elf.Symbol
+	common *inputSection // Space allocated in .bss for a COMMON symbol
+}
 
+// linkFiles links object files and archives. Inputs are processed in
+// command line order, so an archive only supplies symbols that objects
+// before it (or members it pulled in) left undefined.
+func linkFiles(inputFiles []string, options LdOptions) error {
+	objects := []*inputObject{}
+	globals := make(map[string]*symbolDef)
 	for _, filename := range inputFiles {
-		file, err := os.Open(filename)
+		loaded, err := loadInputFile(filename, globals)
 		if err != nil {
-			return fmt.Errorf("failed to open %s: %w", filename, err)
+			return err
 		}
+		objects = append(objects, loaded...)
+	}
+	if len(objects) == 0 {
+		return fmt.Errorf("no input objects")
+	}
 
-		elfFile, err := elf.ParseELF(file)
-		file.Close()
+	// A relocatable link keeps undefined and COMMON symbols for a later link
+	if options.Relocatable {
+		sections := mergeSections(objects, globals, false)
+		return writeRelocatable(options.Output, objects, globals, sections)
+	}
 
-		if err != nil {
-			return fmt.Errorf("failed to parse %s: %w", filename, err)
+	if undefined := undefinedSymbols(objects, globals); len(undefined) > 0 && !options.AllowUndefined {
+		for _, u := range undefined {
+			for _, ref := range u.refs {
+				fmt.Fprintf(os.Stderr, "%s: undefined reference to `%s'\n", ref, u.name)
+			}
 		}
+		return fmt.Errorf("link failed: %d undefined symbol(s)", len(undefined))
+	}
 
-		objects = append(objects, elfFile)
-		allSymbols = append(allSymbols, elfFile.Symbols...)
-		allSections = append(allSections, elfFile.Sections...)
+	// Merge sections and assign addresses
+	sections := mergeSections(objects, globals, true)
+	if err := layoutSections(sections, options); err != nil {
+		return err
 	}
 
-	// Resolve symbols
-	resolvedSymbols := resolveSymbols(allSymbols)
+	if err := applyRelocations(objects, globals); err != nil {
+		return err
+	}
 
-	// Merge sections
-	mergedSections := mergeSections(allSections)
+	entry, err := findEntryPoint(options.Entry, globals, sections)
+	if err != nil {
+		return err
+	}
 
 	// Create output ELF
 	outputELF := &elf.ELF{
-		Class:    objects[0].Class,
-		Data:     objects[0].Data,
+		Class:    objects[0].elf.Class,
+		Data:     objects[0].elf.Data,
 		Version:  1,
-		OSABI:    objects[0].OSABI,
+		OSABI:    objects[0].elf.OSABI,
 		Type:     "ET_EXEC",
-		Machine:  objects[0].Machine,
-		Entry:    findEntryPoint(objects),
-		Sections: mergedSections,
-		Symbols:  resolvedSymbols,
+		Machine:  objects[0].elf.Machine,
+		Entry:    entry,
+		Sections: []elf.Section{{}}, // SHT_NULL
+		Segments: buildSegments(sections),
+	}
+	outputELF.Header = objects[0].elf.Header
+	outputELF.Header.Type = 2 // ET_EXEC
+	outputELF.Header.Flags = 0
+	outputELF.Header.ShStrndx = 0 // WriteELF adds .shstrtab
+	outputELF.Header.PhOff64 = 64 // Right after the ELF header
+	for _, out := range sections {
+		outputELF.Sections = append(outputELF.Sections, elf.Section{
+			Name:      out.name,
+			Type:      out.typ,
+			Flags:     out.flags,
+			Addr:      out.addr,
+			Offset:    out.offset,
+			Size:      out.size,
+			AddrAlign: out.align,
+			Data:      out.data,
+		})
 	}
 
 	// Write output
-	return writeLinkedELF(outputFile, outputELF)
+	return writeLinkedELF(options.Output, outputELF)
 }
 
-// resolveSymbols resolves symbol references
-func resolveSymbols(symbols []elf.Symbol) []elf.Symbol {
-	symbolMap := make(map[string]*elf.Symbol)
-	resolved := []elf.Symbol{}
+// loadInputFile reads an object or an archive and adds its symbols to the
+// global symbol table
+func loadInputFile(filename string, globals map[string]*symbolDef) ([]*inputObject, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filename, err)
+	}
+	defer file.Close()
 
-	for i := range symbols {
-		sym := &symbols[i]
-		if existing, exists := symbolMap[sym.Name]; exists {
-			// Resolve conflict (prefer defined over undefined)
-			if sym.Type != "STT_NOTYPE" && existing.Type == "STT_NOTYPE" {
-				symbolMap[sym.Name] = sym
-			}
-		} else {
-			symbolMap[sym.Name] = sym
+	magic := make([]byte, len(arfile.Magic))
+	if _, err := io.ReadFull(file, magic); err == nil && string(magic) == arfile.Magic {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return loadArchive(filename, file, globals)
+	}
+
+	object, err := loadInputObject(filename, file)
+	if err != nil {
+		return nil, err
+	}
+	if err := addSymbols(globals, object); err != nil {
+		return nil, err
+	}
+	return []*inputObject{object}, nil
+}
+
+// archiveMember is a member of an input archive
+type archiveMember struct {
+	name string
+	data []byte
+}
+
+// loadArchive loads the archive members that define symbols which are
+// still undefined, using the archive symbol index. A member may leave new
+// symbols undefined, so the index is scanned again until nothing more is
+// pulled in.
+func loadArchive(filename string, file io.Reader, globals map[string]*symbolDef) ([]*inputObject, error) {
+	r, err := arfile.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	// Members are found by the offset of their header
+	members := make(map[int64]*archiveMember)
+	for h, err := range r.Members() {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to read member %s: %w", filename, h.Name, err)
 		}
+		members[h.Offset] = &archiveMember{name: h.Name, data: data}
 	}
 
-	for _, sym := range symbolMap {
-		resolved = append(resolved, *sym)
+	symbols := r.Symbols()
+	if symbols == nil && len(members) > 0 {
+		return nil, fmt.Errorf("%s: archive has no index; run ranlib to add one", filename)
 	}
 
-	return resolved
+	objects := []*inputObject{}
+	loaded := make(map[int64]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, sym := range symbols {
+			// Weak references do not pull members in
+			def := globals[sym.Name]
+			if def == nil || def.symbol.Shndx != elf.SHN_UNDEF || def.symbol.Info>>4 == 2 || loaded[sym.Offset] { // STB_WEAK
+				continue
+			}
+
+			member := members[sym.Offset]
+			if member == nil {
+				return nil, fmt.Errorf("%s: symbol index refers to a missing member at offset %d", filename, sym.Offset)
+			}
+			loaded[sym.Offset] = true
+
+			object, err := loadInputObject(fmt.Sprintf("%s(%s)", filename, member.name), bytes.NewReader(member.data))
+			if err != nil {
+				return nil, err
+			}
+			if err := addSymbols(globals, object); err != nil {
+				return nil, err
+			}
+			objects = append(objects, object)
+			changed = true
+		}
+	}
+
+	return objects, nil
 }
 
-// mergeSections merges sections from multiple objects
-func mergeSections(sections []elf.Section) []elf.Section {
-	sectionMap := make(map[string]*elf.Section)
-	merged := []elf.Section{}
+// loadInputObject reads a relocatable object and its allocated sections
+func loadInputObject(filename string, file io.ReadSeeker) (*inputObject, error) {
+	elfFile, err := elf.ParseELF(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if elfFile.Type != "ET_REL" {
+		return nil, fmt.Errorf("%s: not a relocatable object", filename)
+	}
+	if elfFile.Class != "ELF64" || elfFile.Header.Machine != elf.EM_X86_64 {
+		return nil, fmt.Errorf("%s: unsupported object format %s %s", filename, elfFile.Class, elfFile.Machine)
+	}
 
-	for i := range sections {
-		section := &sections[i]
-		if existing, exists := sectionMap[section.Name]; exists {
-			// Merge section data
-			existing.Data = append(existing.Data, section.Data...)
-			existing.Size += section.Size
-		} else {
-			sectionMap[section.Name] = section
+	object := &inputObject{
+		name:     filename,
+		elf:      elfFile,
+		sections: make([]*inputSection, len(elfFile.Sections)),
+	}
+	for i := range elfFile.Sections {
+		section := &elfFile.Sections[i]
+		if section.Flags&elf.SHF_ALLOC == 0 || section.Flags&elf.SHF_EXCLUDE != 0 {
+			continue
+		}
+
+		// Secure: limit section size
+		data, err := section.ReadAll(100 * 1024 * 1024)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		object.sections[i] = &inputSection{object: object, section: section, data: data}
+	}
+
+	// Relocations are read now, while the file is open
+	for i := range elfFile.Sections {
+		section := &elfFile.Sections[i]
+		if section.Type == elf.SHT_REL {
+			return nil, fmt.Errorf("%s: SHT_REL relocations are not supported", filename)
+		}
+		if section.Type != elf.SHT_RELA || int(section.Info) >= len(object.sections) || object.sections[section.Info] == nil {
+			continue
+		}
+		relocs, err := elfFile.Relocations(section)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
 		}
+		target := object.sections[section.Info]
+		target.relocs = append(target.relocs, relocs...)
 	}
 
-	for _, section := range sectionMap {
-		merged = append(merged, *section)
+	return object, nil
+}
+
+// addSymbols adds the global symbols of an object to the global symbol
+// table. A defined symbol wins over an undefined one and a global definition
+// over a weak one; two global definitions of the same name are an error.
+// COMMON symbols are merged, keeping the largest size and alignment, unless
+// a real definition exists.
+func addSymbols(globals map[string]*symbolDef, object *inputObject) error {
+	for i := range object.elf.Symbols {
+		sym := &object.elf.Symbols[i]
+		binding := sym.Info >> 4
+		if binding == 0 || sym.Name == "" { // STB_LOCAL
+			continue
+		}
+
+		existing, exists := globals[sym.Name]
+		if !exists {
+			globals[sym.Name] = &symbolDef{object: object, symbol: sym}
+			continue
+		}
+		if sym.Shndx == elf.SHN_UNDEF {
+			continue
+		}
+
+		old := existing.symbol
+		switch {
+		case old.Shndx == elf.SHN_UNDEF:
+			globals[sym.Name] = &symbolDef{object: object, symbol: sym}
+		case sym.Shndx == elf.SHN_COMMON && old.Shndx == elf.SHN_COMMON:
+			if sym.Size > old.Size || sym.Value > old.Value {
+				merged := *old
+				merged.Size = max(old.Size, sym.Size)
+				merged.Value = max(old.Value, sym.Value) // Alignment
+				existing.symbol = &merged
+			}
+		case sym.Shndx == elf.SHN_COMMON:
+			// A real definition takes precedence over a common symbol
+		case old.Shndx == elf.SHN_COMMON, old.Info>>4 == 2 && binding == 1: // STB_WEAK, STB_GLOBAL
+			globals[sym.Name] = &symbolDef{object: object, symbol: sym}
+		case binding == 1 && old.Info>>4 == 1:
+			return fmt.Errorf("%s: multiple definition of `%s'; %s: first defined here",
+				object.name, sym.Name, existing.object.name)
+		}
 	}
 
-	return merged
+	return nil
 }
 
-// findEntryPoint finds entry point from objects
-func findEntryPoint(objects []*elf.ELF) uint64 {
-	for _, obj := range objects {
-		if obj.Entry != 0 {
-			return obj.Entry
+// outputSectionName returns the output section an input section is merged
+// into: .text.*, .rodata.*, .data.* and .bss.* go into their base section
+// and all other sections keep their name
+func outputSectionName(name string) string {
+	for _, base := range []string{".text", ".rodata", ".data", ".bss"} {
+		if name == base || strings.HasPrefix(name, base+".") {
+			return base
 		}
 	}
-	return 0
+	return name
 }
 
-// writeLinkedELF writes linked ELF file
-func writeLinkedELF(filename string, elfFile *elf.ELF) error {
-	// In a full implementation, we would properly construct ELF file
-	// For now, create a minimal executable
-	output, err := os.Create(filename)
-	if err != nil {
-		return err
+// sectionClass orders output sections: code, read-only data, writable data
+// and finally uninitialized data
+func sectionClass(s *outputSection) int {
+	switch {
+	case s.flags&elf.SHF_EXECINSTR != 0:
+		return 0
+	case s.flags&elf.SHF_WRITE == 0:
+		return 1
+	case s.typ != elf.SHT_NOBITS:
+		return 2
 	}
-	defer output.Close()
+	return 3
+}
 
-	// Write ELF header
-	header := createELFHeader(elfFile)
-	if _, err := output.Write(header); err != nil {
-		return err
+// mergeSections groups the input sections into output sections, in the
+// order of sectionClass and then of first appearance. When allocateCommon is
+// set, COMMON symbols are allocated at the end of .bss.
+func mergeSections(objects []*inputObject, globals map[string]*symbolDef, allocateCommon bool) []*outputSection {
+	byName := make(map[string]*outputSection)
+	sections := []*outputSection{}
+
+	output := func(name string, typ uint32, flags uint64) *outputSection {
+		out, exists := byName[name]
+		if !exists {
+			out = &outputSection{name: name, typ: typ, flags: flags, align: 1}
+			byName[name] = out
+			sections = append(sections, out)
+		}
+		// Sections with data turn a .bss that only had NOBITS input into PROGBITS
+		if typ != elf.SHT_NOBITS {
+			out.typ = typ
+		}
+		out.flags |= flags
+		return out
+	}
+	place := func(out *outputSection, input *inputSection, size, align uint64) {
+		if align == 0 {
+			align = 1
+		}
+		out.align = max(out.align, align)
+		input.output = out
+		input.offset = alignUp(out.size, align)
+		out.size = input.offset + size
+		out.inputs = append(out.inputs, input)
+	}
+
+	for _, object := range objects {
+		for _, input := range object.sections {
+			if input == nil {
+				continue
+			}
+			section := input.section
+			out := output(outputSectionName(section.Name), section.Type, section.Flags&(elf.SHF_ALLOC|elf.SHF_WRITE|elf.SHF_EXECINSTR))
+			place(out, input, section.Size, section.AddrAlign)
+		}
+	}
+
+	// Allocate COMMON symbols in a stable order
+	names := make([]string, 0, len(globals))
+	for name, def := range globals {
+		if allocateCommon && def.symbol.Shndx == elf.SHN_COMMON {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := globals[name]
+		out := output(".bss", elf.SHT_NOBITS, elf.SHF_ALLOC|elf.SHF_WRITE)
+		def.common = &inputSection{object: def.object}
+		place(out, def.common, def.symbol.Size, def.symbol.Value)
 	}
 
-	// Write sections
-	for _, section := range elfFile.Sections {
-		if len(section.Data) > 0 {
-			// Secure: validate section size
-			if len(section.Data) > 100*1024*1024 {
-				return fmt.Errorf("section too large: %s", section.Name)
+	// Order by class, keeping the order of appearance within a class
+	ordered := make([]*outputSection, 0, len(sections))
+	for class := 0; class <= 3; class++ {
+		for _, out := range sections {
+			if sectionClass(out) == class {
+				ordered = append(ordered, out)
 			}
+		}
+	}
+
+	// Build the contents of each section with data
+	for _, out := range ordered {
+		if out.typ == elf.SHT_NOBITS {
+			continue
+		}
+		out.data = make([]byte, out.size)
+		for _, input := range out.inputs {
+			copy(out.data[input.offset:], input.data)
+		}
+	}
+
+	return ordered
+}
+
+// layoutSections assigns addresses and file offsets. A section starts at
+// the address given for it on the command line, or follows the previous
+// one; when the permissions change, a new page is started. File offsets are
+// congruent to addresses modulo the page size so the sections can be mapped.
+func layoutSections(sections []*outputSection, options LdOptions) error {
+	addr := uint64(defaultTextAddress)
+	offset := uint64(pageSize) // The first page holds the headers
+	prevFlags := uint64(0)
+
+	for i, out := range sections {
+		if start, ok := options.SectionStart[out.name]; ok {
+			addr = start
+		} else if i > 0 && out.flags != prevFlags {
+			addr = alignUp(addr, pageSize)
+		}
+		addr = alignUp(addr, out.align)
+		if start, ok := options.SectionStart[out.name]; ok && addr != start {
+			return fmt.Errorf("section %s: address 0x%x is not aligned to %d", out.name, start, out.align)
+		}
+
+		// Secure: reject layouts that wrap around the address space
+		if addr+out.size < addr {
+			return fmt.Errorf("section %s does not fit at 0x%x", out.name, addr)
+		}
 
-			if _, err := output.Write(section.Data); err != nil {
-				return err
+		offset = alignUp(offset, out.align)
+		if offset%pageSize != addr%pageSize {
+			offset = alignUp(offset, pageSize) + addr%pageSize
+		}
+
+		out.addr = addr
+		out.offset = offset
+		addr += out.size
+		if out.typ != elf.SHT_NOBITS {
+			offset += out.size
+		}
+		prevFlags = out.flags
+	}
+
+	// Sections must not overlap in memory
+	for i, a := range sections {
+		for _, b := range sections[i+1:] {
+			if a.size > 0 && b.size > 0 && a.addr < b.addr+b.size && b.addr < a.addr+a.size {
+				return fmt.Errorf("section %s [0x%x-0x%x] overlaps section %s [0x%x-0x%x]",
+					a.name, a.addr, a.addr+a.size, b.name, b.addr, b.addr+b.size)
 			}
 		}
 	}
@@ -188,66 +631,460 @@ func writeLinkedELF(filename string, elfFile *elf.ELF) error {
 	return nil
 }
 
-// createELFHeader creates ELF header bytes
-func createELFHeader(elfFile *elf.ELF) []byte {
-	header := make([]byte, 64)
+// symbolAddress returns the final address of a defined symbol
+func symbolAddress(object *inputObject, sym *elf.Symbol, def *symbolDef) (uint64, bool) {
+	switch {
+	case def != nil && def.common != nil:
+		return def.common.output.addr + def.common.offset, true
+	case sym.Shndx == elf.SHN_ABS:
+		return sym.Value, true
+	case sym.Shndx == elf.SHN_UNDEF || sym.Shndx >= elf.SHN_LORESERVE:
+		return 0, false
+	case int(sym.Shndx) < len(object.sections) && object.sections[sym.Shndx] != nil:
+		input := object.sections[sym.Shndx]
+		return input.output.addr + input.offset + sym.Value, true
+	}
+	return 0, false
+}
+
+// findEntryPoint returns the address of the entry symbol. Like GNU ld, an
+// entry that is not a symbol may be an address, and a missing default entry
+// symbol falls back to the start of .text with a warning.
+func findEntryPoint(entry string, globals map[string]*symbolDef, sections []*outputSection) (uint64, error) {
+	if def, ok := globals[entry]; ok {
+		if addr, ok := symbolAddress(def.object, def.symbol, def); ok {
+			return addr, nil
+		}
+	}
+	if addr, err := parseAddress(entry); err == nil {
+		return addr, nil
+	}
+
+	fallback := uint64(0)
+	for _, out := range sections {
+		if out.name == ".text" {
+			fallback = out.addr
+		}
+	}
+	fmt.Fprintf(os.Stderr, "ld: warning: cannot find entry symbol %s; defaulting to %016x\n", entry, fallback)
+	return fallback, nil
+}
+
+// undefinedSymbol is a global symbol without a definition and the objects
+// that refer to it
+type undefinedSymbol struct {
+	name string
+	refs []string
+}
+
+// undefinedSymbols returns the global symbols that no object defines, sorted
+// by name. Symbols that are only referenced weakly may stay undefined.
+func undefinedSymbols(objects []*inputObject, globals map[string]*symbolDef) []undefinedSymbol {
+	refs := make(map[string][]string)
+	for _, object := range objects {
+		for i := range object.elf.Symbols {
+			sym := &object.elf.Symbols[i]
+			if sym.Shndx != elf.SHN_UNDEF || sym.Info>>4 != 1 || sym.Name == "" { // STB_GLOBAL
+				continue
+			}
+			if def := globals[sym.Name]; def != nil && def.symbol.Shndx == elf.SHN_UNDEF {
+				refs[sym.Name] = append(refs[sym.Name], object.name)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	undefined := make([]undefinedSymbol, 0, len(names))
+	for _, name := range names {
+		undefined = append(undefined, undefinedSymbol{name: name, refs: refs[name]})
+	}
+	return undefined
+}
+
+// writeRelocatable writes the merged sections as one relocatable object
+// (ld -r). Local symbols and section symbols are rebased onto the output
+// sections, global symbols appear once with their resolved definition, and
+// the relocations of each output section are collected into one .rela
+// section.
+func writeRelocatable(filename string, objects []*inputObject, globals map[string]*symbolDef, sections []*outputSection) error {
+	outputELF := &elf.ELF{
+		Class:    objects[0].elf.Class,
+		Data:     objects[0].elf.Data,
+		Version:  1,
+		Type:     "ET_REL",
+		Machine:  objects[0].elf.Machine,
+		Sections: []elf.Section{{}}, // SHT_NULL
+	}
+	outputELF.Header = objects[0].elf.Header
+	outputELF.Header.Type = 1 // ET_REL
+	outputELF.Header.ShStrndx = 0
+	outputELF.Header.PhOff64 = 0
+
+	sectionIndex := make(map[*outputSection]uint16)
+	for _, out := range sections {
+		sectionIndex[out] = uint16(len(outputELF.Sections))
+		outputELF.Sections = append(outputELF.Sections, elf.Section{
+			Name:      out.name,
+			Type:      out.typ,
+			Flags:     out.flags,
+			Size:      out.size,
+			AddrAlign: out.align,
+			Data:      out.data,
+		})
+	}
+
+	// Symbol table: the null symbol, one section symbol per output section,
+	// the local symbols of each object, then the globals
+	symtab := newSymbolTableBuilder()
+	for _, out := range sections {
+		symtab.add("", 3, sectionIndex[out], 0, 0) // STB_LOCAL, STT_SECTION
+	}
+
+	// place returns the output section index and value of a defined symbol
+	place := func(object *inputObject, sym *elf.Symbol) (uint16, uint64, bool) {
+		switch {
+		case sym.Shndx == elf.SHN_UNDEF || sym.Shndx >= elf.SHN_LORESERVE:
+			return sym.Shndx, sym.Value, true
+		case int(sym.Shndx) < len(object.sections) && object.sections[sym.Shndx] != nil:
+			input := object.sections[sym.Shndx]
+			return sectionIndex[input.output], input.offset + sym.Value, true
+		}
+		return 0, 0, false
+	}
+
+	localIndex := make([]map[uint32]uint32, len(objects))
+	for n, object := range objects {
+		localIndex[n] = make(map[uint32]uint32)
+		for i := range object.elf.Symbols {
+			sym := &object.elf.Symbols[i]
+			if i == 0 || sym.Info>>4 != 0 || sym.Info&0x0f == 3 { // STB_LOCAL, not STT_SECTION
+				continue
+			}
+			shndx, value, ok := place(object, sym)
+			if !ok {
+				continue // Defined in a section that is not linked
+			}
+			localIndex[n][uint32(i)] = symtab.add(sym.Name, sym.Info, shndx, value, sym.Size)
+		}
+	}
+
+	firstGlobal := symtab.count()
+	names := make([]string, 0, len(globals))
+	for name := range globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	globalIndex := make(map[string]uint32)
+	for _, name := range names {
+		def := globals[name]
+		shndx, value, ok := place(def.object, def.symbol)
+		if !ok {
+			shndx, value = elf.SHN_UNDEF, 0
+		}
+		globalIndex[name] = symtab.add(name, def.symbol.Info, shndx, value, def.symbol.Size)
+	}
+
+	symtabIndex := uint32(len(outputELF.Sections))
+	relaSections := []elf.Section{}
+	for _, out := range sections {
+		var data []byte
+		for _, input := range out.inputs {
+			object := input.object
+			n := 0
+			for n < len(objects) && objects[n] != object {
+				n++
+			}
+			for _, rel := range input.relocs {
+				if int(rel.Symbol) >= len(object.elf.Symbols) {
+					return fmt.Errorf("%s: invalid symbol index %d", object.name, rel.Symbol)
+				}
+				sym := &object.elf.Symbols[rel.Symbol]
+				addend := rel.Addend
+				var index uint32
+				switch {
+				case rel.Symbol == 0:
+				case sym.Info>>4 != 0: // Global or weak
+					index = globalIndex[sym.Name]
+				case sym.Info&0x0f == 3: // STT_SECTION: point at the output section
+					shndx, value, ok := place(object, sym)
+					if !ok {
+						return fmt.Errorf("%s: relocation against a discarded section", object.name)
+					}
+					index = uint32(shndx) // Section symbol k describes output section k
+					addend += int64(value)
+				default:
+					var ok bool
+					if index, ok = localIndex[n][rel.Symbol]; !ok {
+						return fmt.Errorf("%s: relocation against `%s' in a discarded section", object.name, sym.Name)
+					}
+				}
 
-	// Magic
-	header[0] = 0x7f
-	header[1] = 'E'
-	header[2] = 'L'
-	header[3] = 'F'
+				entry := make([]byte, 24)
+				binary.LittleEndian.PutUint64(entry[0:8], input.offset+rel.Offset)
+				binary.LittleEndian.PutUint64(entry[8:16], uint64(index)<<32|uint64(rel.Type))
+				binary.LittleEndian.PutUint64(entry[16:24], uint64(addend))
+				data = append(data, entry...)
+			}
+		}
+		if len(data) == 0 {
+			continue
+		}
+		relaSections = append(relaSections, elf.Section{
+			Name:      ".rela" + out.name,
+			Type:      elf.SHT_RELA,
+			Flags:     elf.SHF_INFO_LINK,
+			Info:      uint32(sectionIndex[out]),
+			Size:      uint64(len(data)),
+			AddrAlign: 8,
+			EntSize:   24,
+			Data:      data,
+		})
+	}
+	symtabIndex += uint32(len(relaSections))
+	for i := range relaSections {
+		relaSections[i].Link = symtabIndex
+	}
+	outputELF.Sections = append(outputELF.Sections, relaSections...)
 
-	// Class (64-bit)
-	header[4] = 2
+	outputELF.Sections = append(outputELF.Sections, elf.Section{
+		Name:      ".symtab",
+		Type:      elf.SHT_SYMTAB,
+		Link:      symtabIndex + 1,
+		Info:      firstGlobal,
+		Size:      uint64(len(symtab.symbols)),
+		AddrAlign: 8,
+		EntSize:   24,
+		Data:      symtab.symbols,
+	}, elf.Section{
+		Name:      ".strtab",
+		Type:      elf.SHT_STRTAB,
+		Size:      uint64(len(symtab.strings)),
+		AddrAlign: 1,
+		Data:      symtab.strings,
+	})
 
-	// Data (little endian)
-	header[5] = 1
+	output, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := elf.WriteELF(output, outputELF); err != nil {
+		output.Close()
+		os.Remove(filename)
+		return err
+	}
+	return output.Close()
+}
 
-	// Version
-	header[6] = 1
+// symbolTableBuilder accumulates the entries of an ELF64 symbol table and
+// its string table
+type symbolTableBuilder struct {
+	symbols []byte
+	strings []byte
+}
 
-	// OS/ABI
-	header[7] = 0
+// newSymbolTableBuilder returns a builder holding the null symbol
+func newSymbolTableBuilder() *symbolTableBuilder {
+	return &symbolTableBuilder{symbols: make([]byte, 24), strings: []byte{0}}
+}
+
+// add appends a symbol and returns its index
+func (b *symbolTableBuilder) add(name string, info byte, shndx uint16, value, size uint64) uint32 {
+	entry := make([]byte, 24)
+	if name != "" {
+		binary.LittleEndian.PutUint32(entry[0:4], uint32(len(b.strings)))
+		b.strings = append(b.strings, name...)
+		b.strings = append(b.strings, 0)
+	}
+	entry[4] = info
+	binary.LittleEndian.PutUint16(entry[6:8], shndx)
+	binary.LittleEndian.PutUint64(entry[8:16], value)
+	binary.LittleEndian.PutUint64(entry[16:24], size)
+	b.symbols = append(b.symbols, entry...)
+	return b.count() - 1
+}
 
-	// Type (executable)
-	binary.LittleEndian.PutUint16(header[16:18], 2)
+// count returns the number of symbols added so far, including the null symbol
+func (b *symbolTableBuilder) count() uint32 {
+	return uint32(len(b.symbols) / 24)
+}
 
-	// Machine (x86_64)
-	binary.LittleEndian.PutUint16(header[18:20], 0x3e)
+// alignUp rounds v up to a multiple of align
+func alignUp(v, align uint64) uint64 {
+	if align <= 1 {
+		return v
+	}
+	return (v + align - 1) / align * align
+}
 
-	// Version
-	binary.LittleEndian.PutUint32(header[20:24], 1)
+// applyRelocations patches the output section contents with the final
+// symbol addresses. S is the symbol address, A the addend and P the address
+// of the patched field, as in the x86-64 psABI.
+func applyRelocations(objects []*inputObject, globals map[string]*symbolDef) error {
+	for _, object := range objects {
+		for _, input := range object.sections {
+			if input == nil || len(input.relocs) == 0 {
+				continue
+			}
+			if input.section.Type == elf.SHT_NOBITS {
+				return fmt.Errorf("%s: relocations against NOBITS section %s", object.name, input.section.Name)
+			}
+
+			for _, rel := range input.relocs {
+				if err := applyRelocation(object, input, rel, globals); err != nil {
+					return fmt.Errorf("%s:(%s+0x%x): %w", object.name, input.section.Name, rel.Offset, err)
+				}
+			}
+		}
+	}
+	return nil
+}
 
-	// Entry point
-	binary.LittleEndian.PutUint64(header[24:32], elfFile.Entry)
+// applyRelocation applies one x86-64 relocation
+func applyRelocation(object *inputObject, input *inputSection, rel elf.Relocation, globals map[string]*symbolDef) error {
+	if rel.Type == elf.R_X86_64_NONE {
+		return nil
+	}
+	if int(rel.Symbol) >= len(object.elf.Symbols) {
+		return fmt.Errorf("invalid symbol index %d", rel.Symbol)
+	}
 
-	// Program header offset
-	binary.LittleEndian.PutUint64(header[32:40], 64)
+	sym := &object.elf.Symbols[rel.Symbol]
+	var def *symbolDef
+	if sym.Info>>4 != 0 { // Not STB_LOCAL
+		def = globals[sym.Name]
+	}
+	s, ok := uint64(0), false
+	if def != nil {
+		s, ok = symbolAddress(def.object, def.symbol, def)
+	} else {
+		s, ok = symbolAddress(object, sym, nil)
+	}
+	switch {
+	case ok:
+	case rel.Symbol == 0, def != nil && def.symbol.Shndx == elf.SHN_UNDEF:
+		// Undefined weak symbols, and undefined symbols allowed by
+		// --allow-undefined, resolve to zero
+	default:
+		return fmt.Errorf("reference to `%s' in a discarded section", sym.Name)
+	}
 
-	// Section header offset
-	binary.LittleEndian.PutUint64(header[40:48], 64+uint64(len(elfFile.Sections))*64)
+	a := uint64(rel.Addend)
+	p := input.output.addr + input.offset + rel.Offset
+	field := input.output.data[input.offset:]
+	size := uint64(4)
+	if rel.Type == elf.R_X86_64_64 || rel.Type == elf.R_X86_64_PC64 {
+		size = 8
+	}
+	if rel.Offset+size > input.section.Size {
+		return fmt.Errorf("relocation outside section")
+	}
+	field = field[rel.Offset : rel.Offset+size]
 
-	// Flags
-	binary.LittleEndian.PutUint32(header[48:52], 0)
+	switch rel.Type {
+	case elf.R_X86_64_64:
+		binary.LittleEndian.PutUint64(field, s+a)
+	case elf.R_X86_64_PC64:
+		binary.LittleEndian.PutUint64(field, s+a-p)
+	case elf.R_X86_64_32:
+		if s+a > 0xffffffff {
+			return fmt.Errorf("relocation truncated to fit: R_X86_64_32 against `%s'", sym.Name)
+		}
+		binary.LittleEndian.PutUint32(field, uint32(s+a))
+	case elf.R_X86_64_32S:
+		if v := int64(s + a); v != int64(int32(v)) {
+			return fmt.Errorf("relocation truncated to fit: R_X86_64_32S against `%s'", sym.Name)
+		}
+		binary.LittleEndian.PutUint32(field, uint32(s+a))
+	case elf.R_X86_64_GOTPCRELX, elf.R_X86_64_REX_GOTPCRELX:
+		// Without a GOT, "mov foo@GOTPCREL(%rip), %reg" is relaxed to
+		// "lea foo(%rip), %reg" as GNU ld does for static links
+		if rel.Offset < 2 || input.output.data[input.offset+rel.Offset-2] != 0x8b {
+			return fmt.Errorf("unsupported GOT relocation against `%s'", sym.Name)
+		}
+		input.output.data[input.offset+rel.Offset-2] = 0x8d
+		fallthrough
+	case elf.R_X86_64_PC32, elf.R_X86_64_PLT32:
+		// Without shared libraries, calls through the PLT go to the symbol
+		if v := int64(s + a - p); v != int64(int32(v)) {
+			return fmt.Errorf("relocation truncated to fit: %s against `%s'",
+				elf.GetRelocationType(elf.EM_X86_64, rel.Type), sym.Name)
+		}
+		binary.LittleEndian.PutUint32(field, uint32(s+a-p))
+	default:
+		return fmt.Errorf("unsupported relocation type %s", elf.GetRelocationType(elf.EM_X86_64, rel.Type))
+	}
+	return nil
+}
 
-	// Header size
-	binary.LittleEndian.PutUint16(header[52:54], 64)
+// buildSegments creates a PT_LOAD segment for each run of sections with the
+// same permissions that is contiguous in the file and in memory, and a
+// PT_GNU_STACK entry asking for a non-executable stack
+func buildSegments(sections []*outputSection) []elf.Segment {
+	segments := []elf.Segment{}
+	var current *elf.Segment
+	var flags uint64
 
-	// Program header size
-	binary.LittleEndian.PutUint16(header[54:56], 56)
+	for _, out := range sections {
+		if out.size == 0 {
+			continue
+		}
+		// A section joins the segment if its file offset keeps the same
+		// distance to its address and no NOBITS section came before it
+		if current != nil && out.flags == flags &&
+			(out.typ == elf.SHT_NOBITS || (current.FileSz == current.MemSz && out.offset-current.Offset == out.addr-current.VAddr)) {
+			current.MemSz = out.addr + out.size - current.VAddr
+			if out.typ != elf.SHT_NOBITS {
+				current.FileSz = out.offset + out.size - current.Offset
+			}
+			continue
+		}
 
-	// Number of program headers
-	binary.LittleEndian.PutUint16(header[56:58], 1)
+		segFlags := uint32(elf.PF_R)
+		if out.flags&elf.SHF_WRITE != 0 {
+			segFlags |= elf.PF_W
+		}
+		if out.flags&elf.SHF_EXECINSTR != 0 {
+			segFlags |= elf.PF_X
+		}
+		segments = append(segments, elf.Segment{
+			Type:   elf.PT_LOAD,
+			Flags:  segFlags,
+			Offset: out.offset,
+			VAddr:  out.addr,
+			PAddr:  out.addr,
+			MemSz:  out.size,
+			Align:  pageSize,
+		})
+		current = &segments[len(segments)-1]
+		if out.typ != elf.SHT_NOBITS {
+			current.FileSz = out.size
+		}
+		flags = out.flags
+	}
 
-	// Section header size
-	binary.LittleEndian.PutUint16(header[58:60], 64)
+	return append(segments, elf.Segment{Type: elf.PT_GNU_STACK, Flags: elf.PF_R | elf.PF_W, Align: 16})
+}
 
-	// Number of section headers
-	binary.LittleEndian.PutUint16(header[60:62], uint16(len(elfFile.Sections)))
+// writeLinkedELF writes the linked executable and marks it executable
+func writeLinkedELF(filename string, elfFile *elf.ELF) error {
+	output, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
 
-	// String table index
-	binary.LittleEndian.PutUint16(header[62:64], 0)
+	if err := elf.WriteELF(output, elfFile); err != nil {
+		output.Close()
+		os.Remove(filename)
+		return err
+	}
+	if err := output.Close(); err != nil {
+		return err
+	}
 
-	return header
+	return os.Chmod(filename, 0755)
 }
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// loadObjects loads fixtures from testdata/ld into one global symbol table
+func loadObjects(t *testing.T, names ...string) ([]*inputObject, map[string]*symbolDef) {
+	t.Helper()
+	objects := []*inputObject{}
+	globals := make(map[string]*symbolDef)
+	for _, name := range names {
+		loaded, err := loadInputFile(filepath.Join("testdata", "ld", name), globals)
+		if err != nil {
+			t.Fatalf("loadInputFile(%s): %v", name, err)
+		}
+		objects = append(objects, loaded...)
+	}
+	return objects, globals
+}
+
+// address returns the final address of a global symbol
+func address(t *testing.T, globals map[string]*symbolDef, name string) uint64 {
+	t.Helper()
+	def := globals[name]
+	if def == nil {
+		t.Fatalf("No symbol %s", name)
+	}
+	addr, ok := symbolAddress(def.object, def.symbol, def)
+	if !ok {
+		t.Fatalf("Symbol %s has no address", name)
+	}
+	return addr
+}
+
+// TestLayoutSections tests the addresses, alignment and file offsets of the
+// output sections, by default and as placed by -Ttext, -Tdata and
+// --section-start
+func TestLayoutSections(t *testing.T) {
+	type placed struct {
+		name         string
+		addr, offset uint64
+		align, size  uint64
+	}
+	tests := []struct {
+		start map[string]uint64
+		want  []placed
+	}{
+		{
+			// Each change of permissions starts a new page
+			start: map[string]uint64{},
+			want: []placed{
+				{".text", 0x401000, 0x1000, 16, 0x26},
+				{".rodata", 0x402000, 0x2000, 4, 4},
+				{".data", 0x403000, 0x3000, 16, 0x14},
+				{".bss", 0x403020, 0x3020, 32, 0x40},
+			},
+		},
+		{
+			// Offsets stay congruent to addresses modulo the page size
+			start: map[string]uint64{".text": 0x500000, ".data": 0x600010},
+			want: []placed{
+				{".text", 0x500000, 0x1000, 16, 0x26},
+				{".rodata", 0x501000, 0x2000, 4, 4},
+				{".data", 0x600010, 0x2010, 16, 0x14},
+				{".bss", 0x600040, 0x2040, 32, 0x40},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		objects, globals := loadObjects(t, "start.o", "helper.o")
+		sections := mergeSections(objects, globals, true)
+		if err := layoutSections(sections, LdOptions{SectionStart: tt.start}); err != nil {
+			t.Fatalf("layoutSections(%v): %v", tt.start, err)
+		}
+		if len(sections) != len(tt.want) {
+			t.Fatalf("%d output sections, want %d", len(sections), len(tt.want))
+		}
+		for i, want := range tt.want {
+			out := sections[i]
+			got := placed{out.name, out.addr, out.offset, out.align, out.size}
+			if got != want {
+				t.Errorf("Start %v: section %d = %+v, want %+v", tt.start, i, got, want)
+			}
+		}
+
+		// Input sections keep their own alignment inside the output section
+		if helper := address(t, globals, "helper"); helper%16 != 0 || helper != sections[0].addr+0x20 {
+			t.Errorf("Start %v: helper at 0x%x, want 16-byte aligned after _start", tt.start, helper)
+		}
+	}
+
+	objects, globals := loadObjects(t, "start.o", "helper.o")
+	sections := mergeSections(objects, globals, true)
+	err := layoutSections(sections, LdOptions{SectionStart: map[string]uint64{".data": 0x403004}})
+	if err == nil || !strings.Contains(err.Error(), "not aligned") {
+		t.Errorf("Misaligned .data: got %v, want an alignment error", err)
+	}
+	sections = mergeSections(objects, globals, true)
+	err = layoutSections(sections, LdOptions{SectionStart: map[string]uint64{".rodata": 0x401010}})
+	if err == nil || !strings.Contains(err.Error(), "overlaps") {
+		t.Errorf(".rodata inside .text: got %v, want an overlap error", err)
+	}
+}
+
+// TestApplyRelocations tests the fields patched by R_X86_64_PLT32,
+// R_X86_64_PC32 and R_X86_64_64 in testdata/ld/start.o
+func TestApplyRelocations(t *testing.T) {
+	objects, globals := loadObjects(t, "start.o", "helper.o")
+	sections := mergeSections(objects, globals, true)
+	if err := layoutSections(sections, LdOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyRelocations(objects, globals); err != nil {
+		t.Fatalf("applyRelocations: %v", err)
+	}
+	text, data := sections[0], sections[2]
+	start := address(t, globals, "_start")
+
+	// call helper: e8 <rel32>, relative to the end of the instruction
+	if got, want := int32(binary.LittleEndian.Uint32(text.data[1:])), int32(address(t, globals, "helper")-(start+5)); got != want {
+		t.Errorf("R_X86_64_PLT32 = %#x, want %#x", got, want)
+	}
+	// add counter(%rip), %eax: 03 05 <rel32>
+	if got, want := int32(binary.LittleEndian.Uint32(text.data[7:])), int32(address(t, globals, "counter")-(start+11)); got != want {
+		t.Errorf("R_X86_64_PC32 = %#x, want %#x", got, want)
+	}
+	// ptr: .quad value
+	if got, want := binary.LittleEndian.Uint64(data.data[0:]), address(t, globals, "value"); got != want {
+		t.Errorf("R_X86_64_64 = %#x, want %#x", got, want)
+	}
+}
+
+// TestSymbolDiagnostics tests that duplicate and undefined symbols are
+// reported with the objects involved
+func TestSymbolDiagnostics(t *testing.T) {
+	globals := make(map[string]*symbolDef)
+	if _, err := loadInputFile(filepath.Join("testdata", "ld", "helper.o"), globals); err != nil {
+		t.Fatal(err)
+	}
+	_, err := loadInputFile(filepath.Join("testdata", "ld", "dup.o"), globals)
+	if err == nil || !strings.Contains(err.Error(), "multiple definition of `helper'") ||
+		!strings.Contains(err.Error(), "helper.o: first defined here") {
+		t.Errorf("Duplicate helper: got %v", err)
+	}
+
+	objects, globals := loadObjects(t, "undef.o", "helper.o")
+	undefined := undefinedSymbols(objects, globals)
+	if len(undefined) != 1 || undefined[0].name != "missing" ||
+		len(undefined[0].refs) != 1 || filepath.Base(undefined[0].refs[0]) != "undef.o" {
+		t.Errorf("undefinedSymbols = %+v, want missing from undef.o", undefined)
+	}
+	objects, globals = loadObjects(t, "start.o", "helper.o")
+	if undefined := undefinedSymbols(objects, globals); len(undefined) != 0 {
+		t.Errorf("undefinedSymbols of a complete link = %+v", undefined)
+	}
+}
+
+// linkFixtures links fixtures from testdata/ld and parses the output with
+// its section data
+func linkFixtures(t *testing.T, options LdOptions, names ...string) (string, *elf.ELF) {
+	t.Helper()
+	inputs := []string{}
+	for _, name := range names {
+		inputs = append(inputs, filepath.Join("testdata", "ld", name))
+	}
+	options.Output = filepath.Join(t.TempDir(), "a.out")
+	if options.SectionStart == nil {
+		options.SectionStart = map[string]uint64{}
+	}
+	if err := linkFiles(inputs, options); err != nil {
+		t.Fatalf("linkFiles: %v", err)
+	}
+	file, err := os.Open(options.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	e, err := elf.ParseELF(file)
+	if err != nil {
+		t.Fatalf("ParseELF of the output: %v", err)
+	}
+	if err := e.LoadSectionData(file); err != nil {
+		t.Fatalf("LoadSectionData of the output: %v", err)
+	}
+	return options.Output, e
+}
+
+// TestLinkExecutable tests the program headers and entry point of a linked
+// executable, and runs it where it can
+func TestLinkExecutable(t *testing.T) {
+	output, e := linkFixtures(t, LdOptions{Entry: "_start"}, "start.o", "helper.o")
+
+	if e.Header.Type != 2 || e.Entry != 0x401000 { // ET_EXEC, _start at the start of .text
+		t.Errorf("Type %d, entry 0x%x, want ET_EXEC at 0x401000", e.Header.Type, e.Entry)
+	}
+	want := []elf.Segment{
+		{Type: elf.PT_LOAD, Flags: elf.PF_R | elf.PF_X, Offset: 0x1000, VAddr: 0x401000, PAddr: 0x401000, FileSz: 0x26, MemSz: 0x26, Align: 0x1000},
+		{Type: elf.PT_LOAD, Flags: elf.PF_R, Offset: 0x2000, VAddr: 0x402000, PAddr: 0x402000, FileSz: 4, MemSz: 4, Align: 0x1000},
+		// .bss follows .data in the same segment, in memory only
+		{Type: elf.PT_LOAD, Flags: elf.PF_R | elf.PF_W, Offset: 0x3000, VAddr: 0x403000, PAddr: 0x403000, FileSz: 0x14, MemSz: 0x60, Align: 0x1000},
+		{Type: elf.PT_GNU_STACK, Flags: elf.PF_R | elf.PF_W, Align: 16},
+	}
+	if len(e.Segments) != len(want) {
+		t.Fatalf("%d program headers, want %d: %+v", len(e.Segments), len(want), e.Segments)
+	}
+	for i := range want {
+		if e.Segments[i] != want[i] {
+			t.Errorf("Program header %d = %+v, want %+v", i, e.Segments[i], want[i])
+		}
+	}
+
+	// The entry may be another symbol or an address
+	if _, e := linkFixtures(t, LdOptions{Entry: "helper"}, "start.o", "helper.o"); e.Entry != 0x401020 {
+		t.Errorf("-e helper: entry 0x%x, want 0x401020", e.Entry)
+	}
+	if _, e := linkFixtures(t, LdOptions{Entry: "0x401005"}, "start.o", "helper.o"); e.Entry != 0x401005 {
+		t.Errorf("-e 0x401005: entry 0x%x", e.Entry)
+	}
+
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("The linked program runs on linux/amd64 only")
+	}
+	var exit *exec.ExitError
+	if err := exec.Command(output).Run(); !errors.As(err, &exit) || exit.ExitCode() != 42 {
+		t.Errorf("Running the program: %v, want exit status 42", err)
+	}
+}
+
+// TestParseLdOptions tests each spelling of the ld options
+func TestParseLdOptions(t *testing.T) {
+	defaults := func(edit func(*LdOptions)) LdOptions {
+		opts := LdOptions{Output: "a.out", Entry: "_start", SectionStart: map[string]uint64{}}
+		edit(&opts)
+		return opts
+	}
+	tests := []struct {
+		args    []string
+		want    LdOptions
+		wantErr string
+	}{
+		{[]string{"a.o"}, defaults(func(*LdOptions) {}), ""},
+		{[]string{"-o", "prog", "a.o"}, defaults(func(o *LdOptions) { o.Output = "prog" }), ""},
+		{[]string{"-oprog", "a.o"}, defaults(func(o *LdOptions) { o.Output = "prog" }), ""},
+		{[]string{"-e", "main", "a.o"}, defaults(func(o *LdOptions) { o.Entry = "main" }), ""},
+		{[]string{"--entry=main", "a.o"}, defaults(func(o *LdOptions) { o.Entry = "main" }), ""},
+		{[]string{"-Ttext", "0x500000", "-Tdata=600000", "-Tbss", "700000", "a.o"}, defaults(func(o *LdOptions) {
+			o.SectionStart = map[string]uint64{".text": 0x500000, ".data": 0x600000, ".bss": 0x700000}
+		}), ""},
+		{[]string{"--section-start", ".init=0x400800", "a.o"}, defaults(func(o *LdOptions) {
+			o.SectionStart = map[string]uint64{".init": 0x400800}
+		}), ""},
+		{[]string{"--section-start=.fini=400900", "a.o"}, defaults(func(o *LdOptions) {
+			o.SectionStart = map[string]uint64{".fini": 0x400900}
+		}), ""},
+		{[]string{"--allow-undefined", "a.o"}, defaults(func(o *LdOptions) { o.AllowUndefined = true }), ""},
+		{[]string{"-r", "a.o"}, defaults(func(o *LdOptions) { o.Relocatable = true }), ""},
+		{[]string{"--relocatable", "a.o"}, defaults(func(o *LdOptions) { o.Relocatable = true }), ""},
+		{[]string{"-Ttext", "xyz", "a.o"}, LdOptions{}, "invalid address"},
+		{[]string{"--section-start", "0x1000", "a.o"}, LdOptions{}, "invalid --section-start"},
+		{[]string{"a.o", "-o"}, LdOptions{}, "requires an argument"},
+		{[]string{"--gc-sections", "a.o"}, LdOptions{}, "unknown option"},
+	}
+
+	for _, tt := range tests {
+		opts, files, err := parseLdOptions(tt.args)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseLdOptions(%q): got %v, want error %q", tt.args, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(opts, tt.want) || len(files) != 1 || files[0] != "a.o" {
+			t.Errorf("parseLdOptions(%q) = %+v, %q, %v\n  want %+v", tt.args, opts, files, err, tt.want)
+		}
+	}
+}
+
+// TestLinkModes tests undefined symbol handling with and without
+// --allow-undefined, and relocatable output with -r
+func TestLinkModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  []string
+		options LdOptions
+		wantErr string
+		check   func(t *testing.T, output string, e *elf.ELF)
+	}{
+		{
+			name:    "undefined",
+			inputs:  []string{"undef.o", "helper.o"},
+			options: LdOptions{Entry: "caller"},
+			wantErr: "1 undefined symbol(s)",
+		},
+		{
+			name:    "allow undefined",
+			inputs:  []string{"undef.o", "helper.o"},
+			options: LdOptions{Entry: "caller", AllowUndefined: true},
+			check: func(t *testing.T, _ string, e *elf.ELF) {
+				if e.Header.Type != 2 || e.Entry != 0x401000 {
+					t.Errorf("Type %d, entry 0x%x, want ET_EXEC at caller", e.Header.Type, e.Entry)
+				}
+			},
+		},
+		{
+			name:    "relocatable keeps undefined symbols",
+			inputs:  []string{"undef.o"},
+			options: LdOptions{Relocatable: true},
+			check: func(t *testing.T, _ string, e *elf.ELF) {
+				undefined := false
+				for _, sym := range e.Symbols {
+					undefined = undefined || sym.Name == "missing" && sym.Shndx == elf.SHN_UNDEF
+				}
+				if e.Header.Type != 1 || !undefined { // ET_REL
+					t.Errorf("Type %d, missing undefined %v, want an ET_REL keeping it", e.Header.Type, undefined)
+				}
+			},
+		},
+		{
+			name:    "relocatable links again",
+			inputs:  []string{"start.o", "helper.o"},
+			options: LdOptions{Relocatable: true},
+			check: func(t *testing.T, output string, e *elf.ELF) {
+				relocations := 0
+				for i := range e.Sections {
+					if e.Sections[i].Type == elf.SHT_RELA {
+						rels, err := e.Relocations(&e.Sections[i])
+						if err != nil {
+							t.Fatal(err)
+						}
+						relocations += len(rels)
+					}
+				}
+				if e.Header.Type != 1 || len(e.Segments) != 0 || relocations != 4 {
+					t.Errorf("Type %d, %d segments, %d relocations, want ET_REL with 4 relocations",
+						e.Header.Type, len(e.Segments), relocations)
+				}
+
+				// The merged object links on its own to the same program
+				final := output + ".exe"
+				if err := linkFiles([]string{output}, LdOptions{Output: final, Entry: "_start"}); err != nil {
+					t.Fatalf("Linking the -r output: %v", err)
+				}
+				if runtime.GOOS == "linux" && runtime.GOARCH == "amd64" {
+					var exit *exec.ExitError
+					if err := exec.Command(final).Run(); !errors.As(err, &exit) || exit.ExitCode() != 42 {
+						t.Errorf("Running the program: %v, want exit status 42", err)
+					}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr != "" {
+				inputs := []string{}
+				for _, name := range tt.inputs {
+					inputs = append(inputs, filepath.Join("testdata", "ld", name))
+				}
+				tt.options.Output = filepath.Join(t.TempDir(), "a.out")
+				err := linkFiles(inputs, tt.options)
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("linkFiles: got %v, want error %q", err, tt.wantErr)
+				}
+				return
+			}
+			output, e := linkFixtures(t, tt.options, tt.inputs...)
+			tt.check(t, output, e)
+		})
+	}
+}
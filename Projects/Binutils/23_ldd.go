@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// Ldd - Print shared library dependencies (ldd equivalent)
+//
+// Unlike ldd, the program is never executed: dependencies are resolved by
+// reading DT_NEEDED entries and searching the library path the way the
+// dynamic loader does.
+
+func main() {
+	files := []string{}
+	for _, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, "-") {
+			fmt.Fprintf(os.Stderr, "Error: unknown option: %s\n", arg)
+			files = nil
+			break
+		}
+		files = append(files, arg)
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s <file>...\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	searchDirs := loaderConfigDirs("/etc/ld.so.conf", 0)
+
+	failed := false
+	for _, filename := range files {
+		if len(files) > 1 {
+			fmt.Printf("%s:\n", filename)
+		}
+		if err := listDependencies(os.Stdout, filename, searchDirs); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// sharedObject is a loaded ELF object and the paths it contributes to the
+// library search
+type sharedObject struct {
+	path    string
+	class   string
+	machine uint16
+	needed  []string
+	rpath   []string
+	runpath []string
+}
+
+// loadObject reads the dynamic information of one ELF file
+func loadObject(path string) (*sharedObject, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	elfFile, err := elf.ParseELF(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("not an ELF file: %w", err)
+	}
+
+	interp, err := elfFile.Interpreter(file)
+	if err != nil {
+		return nil, "", err
+	}
+
+	object := &sharedObject{
+		path:    path,
+		class:   elfFile.Class,
+		machine: elfFile.Header.Machine,
+		needed:  elfFile.Needed(),
+	}
+
+	// $ORIGIN expands to the directory holding the object
+	origin := filepath.Dir(path)
+	if rpath, ok := elfFile.DynamicString(elf.DT_RPATH); ok {
+		object.rpath = expandSearchPath(rpath, origin)
+	}
+	if runpath, ok := elfFile.DynamicString(elf.DT_RUNPATH); ok {
+		object.runpath = expandSearchPath(runpath, origin)
+	}
+
+	if len(elfFile.Dynamic) == 0 {
+		return object, interp, errNotDynamic
+	}
+	return object, interp, nil
+}
+
+// errNotDynamic reports a file without a dynamic section
+var errNotDynamic = fmt.Errorf("not a dynamic executable")
+
+// listDependencies prints the libraries loaded for a program in load order
+func listDependencies(w io.Writer, filename string, searchDirs []string) error {
+	root, interp, err := loadObject(filename)
+	if err == errNotDynamic {
+		fmt.Fprintf(w, "\tnot a dynamic executable\n")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The interpreter is listed last and is never loaded twice
+	loaded := map[string]bool{}
+	if interp != "" {
+		loaded[filepath.Base(interp)] = true
+	}
+
+	// Libraries are loaded breadth-first, each name only once
+	queue := []*sharedObject{root}
+	for len(queue) > 0 {
+		object := queue[0]
+		queue = queue[1:]
+
+		for _, name := range object.needed {
+			if loaded[name] {
+				continue
+			}
+			loaded[name] = true
+
+			path, ok := findLibrary(name, object, root, searchDirs)
+			if !ok {
+				fmt.Fprintf(w, "\t%s => not found\n", name)
+				continue
+			}
+			fmt.Fprintf(w, "\t%s => %s\n", name, path)
+
+			// Secure: limit the number of loaded libraries
+			if len(loaded) > 4096 {
+				return fmt.Errorf("too many dependencies")
+			}
+
+			lib, _, err := loadObject(path)
+			if err != nil && err != errNotDynamic {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			queue = append(queue, lib)
+		}
+	}
+
+	if interp != "" {
+		fmt.Fprintf(w, "\t%s\n", interp)
+	}
+	return nil
+}
+
+// findLibrary searches for a DT_NEEDED entry in the dynamic loader's order:
+// DT_RPATH (unless the object has DT_RUNPATH), LD_LIBRARY_PATH, DT_RUNPATH,
+// the ld.so.conf directories and finally the default directories
+func findLibrary(name string, object, root *sharedObject, searchDirs []string) (string, bool) {
+	// Names with a slash are paths and are not searched for
+	if strings.Contains(name, "/") {
+		return name, fileExists(name)
+	}
+
+	dirs := []string{}
+	if len(object.runpath) == 0 {
+		dirs = append(dirs, object.rpath...)
+		if object != root {
+			dirs = append(dirs, root.rpath...)
+		}
+	}
+	if env := os.Getenv("LD_LIBRARY_PATH"); env != "" {
+		dirs = append(dirs, strings.FieldsFunc(env, func(r rune) bool { return r == ':' || r == ';' })...)
+	}
+	dirs = append(dirs, object.runpath...)
+	dirs = append(dirs, searchDirs...)
+	if root.class == "ELF64" {
+		dirs = append(dirs, "/lib64", "/usr/lib64")
+	}
+	dirs = append(dirs, "/lib", "/usr/lib")
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if isCompatible(path, root) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// isCompatible reports whether path is an ELF object the program can load:
+// libraries for another class or machine are skipped, as ld.so does
+func isCompatible(path string, root *sharedObject) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	elfFile, err := elf.ParseELF(file)
+	if err != nil {
+		return false
+	}
+	return elfFile.Class == root.class && elfFile.Header.Machine == root.machine
+}
+
+// expandSearchPath splits a colon-separated RPATH or RUNPATH and expands $ORIGIN
+func expandSearchPath(value, origin string) []string {
+	dirs := []string{}
+	for _, dir := range strings.Split(value, ":") {
+		if dir == "" {
+			continue
+		}
+		dir = strings.ReplaceAll(dir, "${ORIGIN}", origin)
+		dir = strings.ReplaceAll(dir, "$ORIGIN", origin)
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// loaderConfigDirs reads the library directories listed in an ld.so.conf
+// file, following include directives
+func loaderConfigDirs(path string, depth int) []string {
+	// Secure: limit include nesting
+	if depth > 8 {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	dirs := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if hash := strings.IndexByte(line, '#'); hash >= 0 {
+			line = line[:hash]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(line, "include"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			for _, pattern := range strings.Fields(rest) {
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(filepath.Dir(path), pattern)
+				}
+				matches, _ := filepath.Glob(pattern)
+				for _, match := range matches {
+					dirs = append(dirs, loaderConfigDirs(match, depth+1)...)
+				}
+			}
+			continue
+		}
+		dirs = append(dirs, line)
+	}
+	return dirs
+}
+
+// fileExists reports whether path names a regular file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// copyFile copies src into dir under the same name
+func copyFile(t *testing.T, src, dir string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, filepath.Base(src)), data, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestListDependencies tests the library search order on the programs in
+// testdata/dyn, which need libgreet.so.1 through $ORIGIN/lib; libgreet.so.1
+// in turn needs libfar.so.1 without a search path of its own. The results
+// for the fixtures themselves match glibc's ldd.
+func TestListDependencies(t *testing.T) {
+	dir := filepath.Join("testdata", "dyn")
+	lib := filepath.Join(dir, "lib")
+	const interp = "\t/lib64/ld-linux-x86-64.so.2\n"
+
+	// A copy of both libraries, and a directory holding a libfar.so.1
+	// that is not an ELF file
+	copies := t.TempDir()
+	copyFile(t, filepath.Join(lib, "libgreet.so.1"), copies)
+	copyFile(t, filepath.Join(lib, "libfar.so.1"), copies)
+	bogus := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bogus, "libfar.so.1"), []byte("not ELF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		file       string
+		env        string // LD_LIBRARY_PATH
+		searchDirs []string
+		expected   string
+	}{
+		// A RUNPATH only applies to the object's own dependencies
+		{"runpath", "runpath", "", nil,
+			"\tlibgreet.so.1 => testdata/dyn/lib/libgreet.so.1\n\tlibfar.so.1 => not found\n" + interp},
+		// while an RPATH is also searched for those of its libraries
+		{"rpath", "rpath", "", nil,
+			"\tlibgreet.so.1 => testdata/dyn/lib/libgreet.so.1\n\tlibfar.so.1 => testdata/dyn/lib/libfar.so.1\n" + interp},
+		// LD_LIBRARY_PATH comes before RUNPATH but after RPATH
+		{"runpath with LD_LIBRARY_PATH", "runpath", copies, nil,
+			"\tlibgreet.so.1 => " + filepath.Join(copies, "libgreet.so.1") + "\n\tlibfar.so.1 => " + filepath.Join(copies, "libfar.so.1") + "\n" + interp},
+		{"rpath with LD_LIBRARY_PATH", "rpath", copies, nil,
+			"\tlibgreet.so.1 => testdata/dyn/lib/libgreet.so.1\n\tlibfar.so.1 => testdata/dyn/lib/libfar.so.1\n" + interp},
+		// ld.so.conf directories come last, skipping files that are not ELF
+		{"search dirs", "runpath", "", []string{bogus, copies},
+			"\tlibgreet.so.1 => testdata/dyn/lib/libgreet.so.1\n\tlibfar.so.1 => " + filepath.Join(copies, "libfar.so.1") + "\n" + interp},
+		{"library", "lib/libgreet.so.1", "", nil, "\tlibfar.so.1 => not found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("LD_LIBRARY_PATH", tt.env)
+		var out bytes.Buffer
+		if err := listDependencies(&out, filepath.Join(dir, tt.file), tt.searchDirs); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if out.String() != tt.expected {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tt.name, out.String(), tt.expected)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := listDependencies(&out, filepath.Join("testdata", "hello.o"), nil); err != nil || out.String() != "\tnot a dynamic executable\n" {
+		t.Errorf("Object file: %q, %v", out.String(), err)
+	}
+}
+
+// TestExpandSearchPath tests splitting and $ORIGIN expansion
+func TestExpandSearchPath(t *testing.T) {
+	got := expandSearchPath("$ORIGIN/lib:${ORIGIN}/../lib::/opt/lib", "/usr/bin")
+	want := []string{"/usr/bin/lib", "/usr/bin/../lib", "/opt/lib"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expandSearchPath = %q, want %q", got, want)
+	}
+}
+
+// TestLoaderConfigDirs tests comments and relative include globs
+func TestLoaderConfigDirs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ld.so.conf":          "# comment\ninclude ld.so.conf.d/*.conf\n/usr/local/lib # trailing\n\n",
+		"ld.so.conf.d/a.conf": "/opt/a\n",
+		"ld.so.conf.d/b.conf": "/opt/b\n\t/opt/c\n",
+		"ld.so.conf.d/b.txt":  "/ignored\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := loaderConfigDirs(filepath.Join(dir, "ld.so.conf"), 0)
+	want := []string{"/opt/a", "/opt/b", "/opt/c", "/usr/local/lib"}
+	if !slices.Equal(got, want) {
+		t.Errorf("loaderConfigDirs = %q, want %q", got, want)
+	}
+}
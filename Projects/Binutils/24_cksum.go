@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"hellogolang/Algorithms/hashing"
+	"hellogolang/Projects/Binutils/arfile"
+	"hellogolang/Projects/Binutils/match"
+)
+
+// Cksum - Print checksums of files or archive members (GNU cksum equivalent)
+//
+// The default is the CRC of POSIX cksum; -a selects another checksum or
+// hash. With -m the first file is an ar archive, and each member is summed
+// on its own, optionally only those matching the patterns that follow.
+
+func main() {
+	options, files, err := parseCksumOptions(os.Args[1:])
+	if err != nil || (options.Members && len(files) == 0) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Usage: %s [-a %s] [--untagged] [file...]\n", os.Args[0], strings.Join(digestNames(), "|"))
+		fmt.Fprintf(os.Stderr, "       %s [-a algorithm] [--untagged] -m <archive> [pattern...]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	if options.Members {
+		if err := sumMembers(out, files[0], files[1:], options); err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, "%s: %v\n", files[0], err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	failed := false
+	for _, filename := range files {
+		if err := sumFile(out, filename, options); err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		out.Flush()
+		os.Exit(1)
+	}
+}
+
+// CksumOptions represents cksum options
+type CksumOptions struct {
+	Algorithm string // -a: a key of digestAlgorithms
+	Untagged  bool   // --untagged: "digest  name" rather than "TAG (name) = digest"
+	Members   bool   // -m: sum the members of an archive
+}
+
+// digestAlgorithm is a checksum or hash cksum can compute
+type digestAlgorithm struct {
+	tag     string // Name in tagged output
+	new     func() hash.Hash
+	decimal bool // Printed as "crc size name", as POSIX cksum does
+}
+
+// digestAlgorithms are the algorithms of -a, named as GNU cksum names those
+// it has
+var digestAlgorithms = map[string]digestAlgorithm{
+	"crc":     {tag: "CRC", new: func() hash.Hash { return hashing.NewCksum() }, decimal: true},
+	"crc32b":  {tag: "CRC32B", new: func() hash.Hash { return hashing.NewIEEE() }, decimal: true},
+	"crc32c":  {tag: "CRC32C", new: func() hash.Hash { return hashing.New32(hashing.CastagnoliTable()) }},
+	"crc64":   {tag: "CRC64", new: func() hash.Hash { return hashing.New64(hashing.ECMATable()) }},
+	"adler32": {tag: "ADLER32", new: func() hash.Hash { return hashing.NewAdler32() }},
+	"xxh64":   {tag: "XXH64", new: func() hash.Hash { return hashing.NewXXH64(0) }},
+}
+
+// digestNames returns the names of the algorithms of -a in order
+func digestNames() []string {
+	names := make([]string, 0, len(digestAlgorithms))
+	for name := range digestAlgorithms {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// parseCksumOptions parses command line options and returns the files
+func parseCksumOptions(args []string) (CksumOptions, []string, error) {
+	opts := CksumOptions{Algorithm: "crc"}
+	files := []string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-a" || arg == "--algorithm" || strings.HasPrefix(arg, "--algorithm="):
+			v, ok := strings.CutPrefix(arg, "--algorithm=")
+			if !ok {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("option %s requires an argument", arg)
+				}
+				i++
+				v = args[i]
+			}
+			if _, ok := digestAlgorithms[v]; !ok {
+				return opts, nil, fmt.Errorf("unknown algorithm: %s", v)
+			}
+			opts.Algorithm = v
+		case arg == "--untagged":
+			opts.Untagged = true
+		case arg == "--tag":
+			opts.Untagged = false
+		case arg == "-m" || arg == "--members":
+			opts.Members = true
+		case arg == "--":
+			files = append(files, args[i+1:]...)
+			return opts, files, nil
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			return opts, nil, fmt.Errorf("unknown option: %s", arg)
+		default:
+			files = append(files, arg)
+		}
+	}
+
+	return opts, files, nil
+}
+
+// digest reads r to the end through the algorithm of options, returning
+// the line cksum prints for it
+func digest(r io.Reader, name string, options CksumOptions) (string, error) {
+	alg := digestAlgorithms[options.Algorithm]
+	h := alg.new()
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return "", err
+	}
+	return formatDigest(alg, h, size, name, options), nil
+}
+
+// formatDigest formats a finished digest. The CRCs of POSIX print in
+// decimal with the size, leaving out the name of standard input, and the
+// rest in hexadecimal, tagged with the algorithm unless --untagged.
+func formatDigest(alg digestAlgorithm, h hash.Hash, size int64, name string, options CksumOptions) string {
+	if alg.decimal {
+		sum := h.(hash.Hash32).Sum32()
+		if name == "-" {
+			return fmt.Sprintf("%d %d\n", sum, size)
+		}
+		return fmt.Sprintf("%d %d %s\n", sum, size, name)
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if options.Untagged {
+		return fmt.Sprintf("%s  %s\n", sum, name)
+	}
+	return fmt.Sprintf("%s (%s) = %s\n", alg.tag, name, sum)
+}
+
+// sumFile prints the digest of a file, or of standard input for "-"
+func sumFile(out io.Writer, filename string, options CksumOptions) error {
+	var r io.Reader = os.Stdin
+	if filename != "-" {
+		file, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		// Secure: refuse directories, which read as an error only on some systems
+		if stat, err := file.Stat(); err != nil {
+			return err
+		} else if stat.IsDir() {
+			return fmt.Errorf("is a directory")
+		}
+		r = file
+	}
+
+	line, err := digest(r, filename, options)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, line)
+	return err
+}
+
+// sumMembers prints the digest of each member of an archive, named
+// archive(member) as nm names them, or of those matching patterns. Member
+// data is streamed, so members of any size up to the reader's limit are
+// summed without being held in memory.
+func sumMembers(out io.Writer, archiveName string, patterns []string, options CksumOptions) error {
+	filter, err := match.NewFilter(patterns...)
+	if err != nil {
+		return fmt.Errorf("bad member pattern: %w", err)
+	}
+
+	file, err := os.Open(archiveName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r, err := arfile.NewReader(file)
+	if err != nil {
+		return err
+	}
+	for h, err := range r.Members() {
+		if err != nil {
+			return err
+		}
+		if !filter.Empty() && !filter.Match(h.Name) {
+			continue
+		}
+		line, err := digest(r, fmt.Sprintf("%s(%s)", archiveName, h.Name), options)
+		if err != nil {
+			return fmt.Errorf("%s: %w", h.Name, err)
+		}
+		if _, err := io.WriteString(out, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hellogolang/Projects/Binutils/arfile"
+)
+
+// TestSumFile tests the output of each format
+func TestSumFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "abc.txt")
+	if err := os.WriteFile(name, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		options  CksumOptions
+		expected string
+	}{
+		{CksumOptions{Algorithm: "crc"}, "1219131554 3 " + name + "\n"},
+		{CksumOptions{Algorithm: "crc32b"}, "891568578 3 " + name + "\n"},
+		{CksumOptions{Algorithm: "xxh64"}, "XXH64 (" + name + ") = 44bc2cf5ad770999\n"},
+		{CksumOptions{Algorithm: "adler32", Untagged: true}, "024d0127  " + name + "\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := sumFile(&out, name, tt.options); err != nil {
+			t.Fatalf("sumFile(%+v) failed: %v", tt.options, err)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("sumFile(%+v) = %q, want %q", tt.options, out.String(), tt.expected)
+		}
+	}
+
+	if err := sumFile(&bytes.Buffer{}, t.TempDir(), CksumOptions{Algorithm: "crc"}); err == nil {
+		t.Error("sumFile accepted a directory")
+	}
+}
+
+// TestSumMembers tests summing archive members, filtered by pattern
+func TestSumMembers(t *testing.T) {
+	archiveName := filepath.Join(t.TempDir(), "lib.a")
+	file, err := os.Create(archiveName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := []*arfile.Member{
+		{Header: arfile.Header{Name: "abc.o", Mode: 0644, Size: 3}, Data: []byte("abc")},
+		{Header: arfile.Header{Name: "empty.o", Mode: 0644}, Data: nil},
+		{Header: arfile.Header{Name: "notes.txt", Mode: 0644, Size: 9}, Data: []byte("123456789")},
+	}
+	if err := arfile.WriteArchive(file, members); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	var out bytes.Buffer
+	if err := sumMembers(&out, archiveName, []string{"*.o"}, CksumOptions{Algorithm: "crc"}); err != nil {
+		t.Fatalf("sumMembers failed: %v", err)
+	}
+	expected := "1219131554 3 " + archiveName + "(abc.o)\n" +
+		"4294967295 0 " + archiveName + "(empty.o)\n"
+	if out.String() != expected {
+		t.Errorf("sumMembers = %q, want %q", out.String(), expected)
+	}
+
+	out.Reset()
+	if err := sumMembers(&out, archiveName, nil, CksumOptions{Algorithm: "crc"}); err != nil {
+		t.Fatalf("sumMembers failed: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "930766865 9 "+archiveName+"(notes.txt)\n") {
+		t.Errorf("sumMembers without patterns = %q", out.String())
+	}
+
+	if err := sumMembers(&out, archiveName, []string{"[bad"}, CksumOptions{Algorithm: "crc"}); err == nil {
+		t.Error("sumMembers accepted a bad pattern")
+	}
+}
+
+// TestParseCksumOptions tests option parsing
+func TestParseCksumOptions(t *testing.T) {
+	opts, files, err := parseCksumOptions([]string{"--algorithm=xxh64", "--untagged", "-m", "lib.a", "-", "--", "-x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts != (CksumOptions{Algorithm: "xxh64", Untagged: true, Members: true}) {
+		t.Errorf("options = %+v", opts)
+	}
+	if strings.Join(files, " ") != "lib.a - -x" {
+		t.Errorf("files = %q", files)
+	}
+	for _, args := range [][]string{{"-a"}, {"-a", "md5"}, {"-q"}} {
+		if _, _, err := parseCksumOptions(args); err == nil {
+			t.Errorf("parseCksumOptions(%q) succeeded", args)
+		}
+	}
+}
@@ -0,0 +1,592 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// Hexdump - Dump files in hexadecimal and convert dumps back (xxd equivalent)
+//
+// The default layout is that of xxd; -C gives the canonical layout of
+// hexdump -C, and -p bare hex. -r reads any of them back to binary,
+// writing each line at its offset, so a dump of one section (-j) can be
+// edited and patched back into the file it came from.
+
+func main() {
+	options, files, err := parseHexdumpOptions(os.Args[1:])
+	if err != nil || len(files) > 2 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Usage: %s [-C|-p] [-c cols] [-g bytes] [-s [-]offset] [-n length] [-j section] [-u] [-v] [infile [outfile]]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s -r [-p] [-s offset] [infile [outfile]]\n", os.Args[0])
+		os.Exit(1)
+	}
+	infile, outfile := "-", "-"
+	if len(files) > 0 {
+		infile = files[0]
+	}
+	if len(files) > 1 {
+		outfile = files[1]
+	}
+
+	if options.Reverse {
+		err = reverseFile(infile, outfile, options)
+	} else {
+		err = dumpFile(infile, outfile, options)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", infile, err)
+		os.Exit(1)
+	}
+}
+
+// HexdumpOptions represents hexdump options
+type HexdumpOptions struct {
+	Cols      int    // -c: bytes per line, 0 for the default of the layout
+	Group     int    // -g: bytes per group in the xxd layout, 0 for no grouping
+	Skip      int64  // -s: start offset, negative to count from the end; with -r, added to offsets
+	Length    int64  // -n, -l: bytes to dump, negative for all
+	Canonical bool   // -C: hexdump -C layout
+	Plain     bool   // -p: hex only, no offsets or characters
+	Upper     bool   // -u: upper case hex digits
+	NoSqueeze bool   // -v: print repeated lines of the canonical layout
+	Reverse   bool   // -r: convert a dump back to binary
+	Section   string // -j: dump only one ELF section
+}
+
+const (
+	// maxCols bounds the bytes per line, as xxd does
+	maxCols = 256
+	// maxReverseOffset bounds the offsets of a dump read back, so that a
+	// corrupt offset cannot create a huge sparse file or pad stdout forever
+	maxReverseOffset = 1 << 32
+	// maxDumpLine bounds the length of a line of a dump read back
+	maxDumpLine = 64 * 1024
+)
+
+// parseHexdumpOptions parses command line options and returns the files
+func parseHexdumpOptions(args []string) (HexdumpOptions, []string, error) {
+	opts := HexdumpOptions{Group: 2, Length: -1}
+	files := []string{}
+
+	// value returns the argument of an option
+	value := func(i *int, name string) (string, error) {
+		if *i+1 >= len(args) {
+			return "", fmt.Errorf("option %s requires an argument", name)
+		}
+		*i++
+		return args[*i], nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-C":
+			opts.Canonical = true
+		case "-p", "-ps":
+			opts.Plain = true
+		case "-u":
+			opts.Upper = true
+		case "-v":
+			opts.NoSqueeze = true
+		case "-r":
+			opts.Reverse = true
+		case "-c", "-g", "-s", "-n", "-l", "-j":
+			v, err := value(&i, arg)
+			if err != nil {
+				return opts, nil, err
+			}
+			if arg == "-j" {
+				opts.Section = v
+				continue
+			}
+			// Secure: validate numbers, accepting 0x and 0o prefixes
+			n, err := strconv.ParseInt(v, 0, 64)
+			if err != nil {
+				return opts, nil, fmt.Errorf("invalid number for %s: %s", arg, v)
+			}
+			switch arg {
+			case "-c":
+				if n <= 0 || n > maxCols {
+					return opts, nil, fmt.Errorf("invalid number of columns: %s (1-%d)", v, maxCols)
+				}
+				opts.Cols = int(n)
+			case "-g":
+				if n < 0 || n > maxCols {
+					return opts, nil, fmt.Errorf("invalid group size: %s", v)
+				}
+				opts.Group = int(n)
+			case "-s":
+				opts.Skip = n
+			default:
+				if n < 0 {
+					return opts, nil, fmt.Errorf("invalid length: %s", v)
+				}
+				opts.Length = n
+			}
+		default:
+			if strings.HasPrefix(arg, "-") && arg != "-" {
+				return opts, nil, fmt.Errorf("unknown option: %s", arg)
+			}
+			files = append(files, arg)
+		}
+	}
+
+	if opts.Canonical && opts.Plain {
+		return opts, nil, fmt.Errorf("-C and -p are mutually exclusive")
+	}
+	if opts.Reverse && (opts.Section != "" || opts.Length >= 0 || opts.Skip < 0) {
+		return opts, nil, fmt.Errorf("-r takes only -p and a non-negative -s")
+	}
+	if opts.Cols == 0 {
+		opts.Cols = 16
+		if opts.Plain {
+			opts.Cols = 30
+		}
+	}
+	return opts, files, nil
+}
+
+// dumpFile dumps the selected range of a file, or of standard input for
+// "-", to outfile or standard output. Offsets shown are offsets in the
+// file, also for a section, so that -r writes the dump back in place.
+func dumpFile(infile, outfile string, options HexdumpOptions) error {
+	out, closeOut, err := createOutput(outfile, os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+
+	if infile == "-" {
+		if options.Section != "" || options.Skip < 0 {
+			return fmt.Errorf("standard input cannot be searched for sections or seeked from its end")
+		}
+		// Secure: skip by reading, as a pipe cannot seek
+		if _, err := io.CopyN(io.Discard, os.Stdin, options.Skip); err != nil && err != io.EOF {
+			return err
+		}
+		var r io.Reader = os.Stdin
+		if options.Length >= 0 {
+			r = io.LimitReader(r, options.Length)
+		}
+		err = dumpHex(w, r, options.Skip, options)
+	} else {
+		err = dumpRegularFile(w, infile, options)
+	}
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := closeOut(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// dumpRegularFile dumps the selected range of a file that can seek
+func dumpRegularFile(w io.Writer, filename string, options HexdumpOptions) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() {
+		return fmt.Errorf("is a directory")
+	}
+
+	start, end := int64(0), stat.Size()
+	if options.Section != "" {
+		start, end, err = sectionRange(file, options.Section, stat.Size())
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.Skip < 0 {
+		start = max(end+options.Skip, start)
+	} else {
+		start = min(start+options.Skip, end)
+	}
+	if options.Length >= 0 && options.Length < end-start {
+		end = start + options.Length
+	}
+	return dumpHex(w, io.NewSectionReader(file, start, end-start), start, options)
+}
+
+// sectionRange returns the file range of an ELF section
+func sectionRange(file *os.File, name string, size int64) (int64, int64, error) {
+	elfFile, err := elf.ParseELF(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, section := range elfFile.Sections {
+		if section.Name != name {
+			continue
+		}
+		if section.Type == elf.SHT_NOBITS {
+			return 0, 0, fmt.Errorf("section %s has no data in the file", name)
+		}
+		// Secure: validate the section lies within the file
+		if section.Offset > uint64(size) || section.Size > uint64(size)-section.Offset {
+			return 0, 0, fmt.Errorf("section %s extends past end of file", name)
+		}
+		return int64(section.Offset), int64(section.Offset + section.Size), nil
+	}
+	return 0, 0, fmt.Errorf("no section named %s", name)
+}
+
+// dumpHex writes r as a dump whose first byte is at offset base. In the
+// canonical layout a run of lines equal to the one before is printed as a
+// single *, unless -v, and the offset after the last byte ends the dump,
+// as hexdump -C does.
+func dumpHex(w io.Writer, r io.Reader, base int64, options HexdumpOptions) error {
+	cols := options.Cols
+	data := make([]byte, cols)
+	prev := make([]byte, 0, cols)
+	line := make([]byte, 0, 4*cols+32)
+	offset := base
+	squeezed := false
+
+	for {
+		n, err := io.ReadFull(r, data)
+		if n > 0 {
+			if options.Canonical && !options.NoSqueeze && n == cols && string(data) == string(prev) {
+				if !squeezed {
+					if _, err := io.WriteString(w, "*\n"); err != nil {
+						return err
+					}
+					squeezed = true
+				}
+			} else {
+				line = formatLine(line[:0], offset, data[:n], options)
+				if _, err := w.Write(line); err != nil {
+					return err
+				}
+				squeezed = false
+				prev = append(prev[:0], data[:n]...)
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.Canonical && offset > base {
+		_, err := fmt.Fprintf(w, "%08x\n", offset)
+		return err
+	}
+	return nil
+}
+
+// formatLine appends one line of a dump to buf. The character column of
+// the xxd layout starts at a fixed position, so a short last line is
+// padded to it.
+func formatLine(buf []byte, offset int64, data []byte, options HexdumpOptions) []byte {
+	digits := "0123456789abcdef"
+	if options.Upper {
+		digits = "0123456789ABCDEF"
+	}
+
+	switch {
+	case options.Plain:
+		for _, b := range data {
+			buf = append(buf, digits[b>>4], digits[b&0xf])
+		}
+		return append(buf, '\n')
+
+	case options.Canonical:
+		buf = fmt.Appendf(buf, "%08x ", offset)
+		for i := range options.Cols {
+			if i%8 == 0 {
+				buf = append(buf, ' ')
+			}
+			if i < len(data) {
+				buf = append(buf, digits[data[i]>>4], digits[data[i]&0xf], ' ')
+			} else {
+				buf = append(buf, "   "...)
+			}
+		}
+		buf = append(buf, " |"...)
+		buf = appendPrintable(buf, data)
+		return append(buf, "|\n"...)
+	}
+
+	buf = fmt.Appendf(buf, "%08x: ", offset)
+	for i := range options.Cols {
+		if i < len(data) {
+			buf = append(buf, digits[data[i]>>4], digits[data[i]&0xf])
+		} else {
+			buf = append(buf, ' ', ' ')
+		}
+		if options.Group > 0 && (i+1)%options.Group == 0 && i < options.Cols-1 {
+			buf = append(buf, ' ')
+		}
+	}
+	buf = append(buf, ' ', ' ')
+	buf = appendPrintable(buf, data)
+	return append(buf, '\n')
+}
+
+// appendPrintable appends data with bytes outside printable ASCII shown as .
+func appendPrintable(buf, data []byte) []byte {
+	for _, b := range data {
+		if b < 0x20 || b > 0x7e {
+			b = '.'
+		}
+		buf = append(buf, b)
+	}
+	return buf
+}
+
+// createOutput opens outfile for writing, or returns standard output for
+// "-", with a function that closes it
+func createOutput(outfile string, flag int) (*os.File, func() error, error) {
+	if outfile == "-" {
+		return os.Stdout, func() error { return nil }, nil
+	}
+	file, err := os.OpenFile(outfile, os.O_WRONLY|os.O_CREATE|flag, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
+
+// reverseFile converts a dump back to binary. Written to a file, each line
+// lands at its offset and the file is not truncated, so a partial dump
+// patches the bytes it covers; written to standard output, offsets must
+// not go backwards, and gaps are filled with zeros.
+func reverseFile(infile, outfile string, options HexdumpOptions) error {
+	in := os.Stdin
+	if infile != "-" {
+		file, err := os.Open(infile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	out, closeOut, err := createOutput(outfile, 0)
+	if err != nil {
+		return err
+	}
+	var w io.WriterAt = out
+	var seq *sequentialWriter
+	if outfile == "-" {
+		seq = &sequentialWriter{w: bufio.NewWriter(out)}
+		w = seq
+	}
+
+	err = reverseHex(in, w, options)
+	if seq != nil {
+		if flushErr := seq.w.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	if closeErr := closeOut(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// sequentialWriter adapts a stream to io.WriterAt for offsets that never
+// go backwards, writing zeros over gaps
+type sequentialWriter struct {
+	w   *bufio.Writer
+	pos int64
+}
+
+// errBackwards is returned when a dump written to a stream goes back
+var errBackwards = errors.New("offsets go backwards; write to a file to patch")
+
+func (s *sequentialWriter) WriteAt(p []byte, off int64) (int, error) {
+	if off < s.pos {
+		return 0, errBackwards
+	}
+	for ; s.pos < off; s.pos++ {
+		if err := s.w.WriteByte(0); err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.w.Write(p)
+	s.pos += int64(n)
+	return n, err
+}
+
+// reverseHex reads a dump in any of the layouts and writes its bytes
+// through w, at each line's offset plus -s. A line of the xxd layout is
+// an offset and a colon followed by hex up to two spaces in a row; a line
+// of the canonical layout is an offset followed by hex up to a |, and a *
+// repeats the line before it up to the next offset. With -p, all hex
+// digits are data, whitespace anywhere is ignored, and there are no
+// offsets.
+func reverseHex(in io.Reader, w io.WriterAt, options HexdumpOptions) error {
+	scanner := bufio.NewScanner(in)
+	// Secure: bound the line length
+	scanner.Buffer(make([]byte, 0, 4096), maxDumpLine)
+
+	if options.Plain {
+		return reversePlain(scanner, w, options.Skip)
+	}
+
+	var last []byte   // Data of the line before
+	var lastEnd int64 // Offset after it
+	repeat := false
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" {
+			continue
+		}
+		if line == "*" {
+			repeat = true
+			continue
+		}
+
+		offset, data, err := parseDumpLine(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		// Secure: bound offsets before seeking or padding to them
+		offset += options.Skip
+		if offset > maxReverseOffset {
+			return fmt.Errorf("line %d: offset %#x too large", n, offset)
+		}
+
+		if repeat && len(last) > 0 {
+			for ; lastEnd+int64(len(last)) <= offset; lastEnd += int64(len(last)) {
+				if _, err := w.WriteAt(last, lastEnd); err != nil {
+					return err
+				}
+			}
+		}
+		repeat = false
+		if len(data) == 0 {
+			continue
+		}
+		if _, err := w.WriteAt(data, offset); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		last = data
+		lastEnd = offset + int64(len(data))
+	}
+	return scanner.Err()
+}
+
+// parseDumpLine parses a line of the xxd or canonical layout into its
+// offset and data. A line with only an offset, which ends a canonical
+// dump, has no data.
+func parseDumpLine(line string) (int64, []byte, error) {
+	end := strings.IndexAny(line, ": \t")
+	if end < 0 {
+		end = len(line)
+	}
+	offset, err := strconv.ParseInt(line[:end], 16, 64)
+	if err != nil || offset < 0 {
+		return 0, nil, fmt.Errorf("invalid offset %q", line[:end])
+	}
+	rest := line[end:]
+
+	if tail, ok := strings.CutPrefix(rest, ":"); ok {
+		// xxd: hex up to the two spaces before the characters
+		tail = strings.TrimPrefix(tail, " ")
+		if i := strings.Index(tail, "  "); i >= 0 {
+			tail = tail[:i]
+		}
+		data, err := decodeHexFields(tail)
+		return offset, data, err
+	}
+	// Canonical: hex up to the | before the characters
+	if i := strings.IndexByte(rest, '|'); i >= 0 {
+		rest = rest[:i]
+	}
+	data, err := decodeHexFields(rest)
+	return offset, data, err
+}
+
+// decodeHexFields decodes hex separated by whitespace, each field a whole
+// number of bytes
+func decodeHexFields(s string) ([]byte, error) {
+	var data []byte
+	for _, field := range strings.Fields(s) {
+		if len(field)%2 != 0 {
+			return nil, fmt.Errorf("odd number of hex digits in %q", field)
+		}
+		for i := 0; i < len(field); i += 2 {
+			hi, lo := unhex(field[i]), unhex(field[i+1])
+			if hi < 0 || lo < 0 {
+				return nil, fmt.Errorf("invalid hex %q", field[i:i+2])
+			}
+			data = append(data, byte(hi<<4|lo))
+		}
+	}
+	return data, nil
+}
+
+// reversePlain decodes a plain dump, in which a byte may be split across
+// lines
+func reversePlain(scanner *bufio.Scanner, w io.WriterAt, offset int64) error {
+	high := -1 // First digit of a byte not yet complete
+	for n := 1; scanner.Scan(); n++ {
+		var data []byte
+		for i := 0; i < len(scanner.Text()); i++ {
+			c := scanner.Text()[i]
+			if c == ' ' || c == '\t' || c == '\r' {
+				continue
+			}
+			d := unhex(c)
+			if d < 0 {
+				return fmt.Errorf("line %d: invalid hex digit %q", n, c)
+			}
+			if high < 0 {
+				high = d
+				continue
+			}
+			data = append(data, byte(high<<4|d))
+			high = -1
+		}
+		// Secure: bound the output as for offsets
+		if offset+int64(len(data)) > maxReverseOffset {
+			return fmt.Errorf("line %d: output too large", n)
+		}
+		if _, err := w.WriteAt(data, offset); err != nil {
+			return err
+		}
+		offset += int64(len(data))
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if high >= 0 {
+		return fmt.Errorf("odd number of hex digits")
+	}
+	return nil
+}
+
+// unhex returns the value of a hex digit, or -1
+func unhex(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return -1
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDumpHex tests each layout against output of xxd and hexdump -C
+func TestDumpHex(t *testing.T) {
+	data := "hello world, this is a test of xxd\x00\x01\x02"
+	tests := []struct {
+		options  HexdumpOptions
+		expected string
+	}{
+		{HexdumpOptions{Cols: 16, Group: 2},
+			"00000000: 6865 6c6c 6f20 776f 726c 642c 2074 6869  hello world, thi\n" +
+				"00000010: 7320 6973 2061 2074 6573 7420 6f66 2078  s is a test of x\n" +
+				"00000020: 7864 0001 02                             xd...\n"},
+		{HexdumpOptions{Cols: 8, Group: 1},
+			"00000000: 68 65 6c 6c 6f 20 77 6f  hello wo\n" +
+				"00000008: 72 6c 64 2c 20 74 68 69  rld, thi\n" +
+				"00000010: 73 20 69 73 20 61 20 74  s is a t\n" +
+				"00000018: 65 73 74 20 6f 66 20 78  est of x\n" +
+				"00000020: 78 64 00 01 02           xd...\n"},
+		{HexdumpOptions{Cols: 30, Plain: true},
+			"68656c6c6f20776f726c642c207468697320697320612074657374206f66\n" +
+				"20787864000102\n"},
+		{HexdumpOptions{Cols: 16, Canonical: true},
+			"00000000  68 65 6c 6c 6f 20 77 6f  72 6c 64 2c 20 74 68 69  |hello world, thi|\n" +
+				"00000010  73 20 69 73 20 61 20 74  65 73 74 20 6f 66 20 78  |s is a test of x|\n" +
+				"00000020  78 64 00 01 02                                    |xd...|\n" +
+				"00000025\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := dumpHex(&out, strings.NewReader(data), 0, tt.options); err != nil {
+			t.Fatalf("dumpHex(%+v) failed: %v", tt.options, err)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("dumpHex(%+v) =\n%s\nwant\n%s", tt.options, out.String(), tt.expected)
+		}
+	}
+}
+
+// TestDumpSqueeze tests that repeated canonical lines collapse to *
+func TestDumpSqueeze(t *testing.T) {
+	data := string(make([]byte, 64)) + "x"
+	var out bytes.Buffer
+	if err := dumpHex(&out, strings.NewReader(data), 0, HexdumpOptions{Cols: 16, Canonical: true}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "00000000  00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|\n" +
+		"*\n" +
+		"00000040  78                                                |x|\n" +
+		"00000041\n"
+	if out.String() != expected {
+		t.Errorf("squeezed dump =\n%s\nwant\n%s", out.String(), expected)
+	}
+}
+
+// TestReverseHex tests that every layout reads back to the bytes dumped
+func TestReverseHex(t *testing.T) {
+	data := []byte(strings.Repeat("\x00", 100) + "ELF\x7f" + strings.Repeat("ab", 50) + "\xff")
+	layouts := []HexdumpOptions{
+		{Cols: 16, Group: 2},
+		{Cols: 7, Group: 3},
+		{Cols: 16, Group: 0, Upper: true},
+		{Cols: 30, Plain: true},
+		{Cols: 16, Canonical: true},
+		{Cols: 16, Canonical: true, NoSqueeze: true},
+	}
+	for _, options := range layouts {
+		var dump bytes.Buffer
+		if err := dumpHex(&dump, bytes.NewReader(data), 0, options); err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		w := &sequentialWriter{w: bufio.NewWriter(&out)}
+		if err := reverseHex(&dump, w, options); err != nil {
+			t.Fatalf("reverseHex(%+v) failed: %v", options, err)
+		}
+		w.w.Flush()
+		if !bytes.Equal(out.Bytes(), data) {
+			t.Errorf("reverseHex(%+v) = %x, want %x", options, out.Bytes(), data)
+		}
+	}
+}
+
+// TestReversePatch tests that a partial dump patches a file in place
+func TestReversePatch(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.bin")
+	if err := os.WriteFile(target, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dump := filepath.Join(dir, "patch.hex")
+	if err := os.WriteFile(dump, []byte("00000004: 4142  AB\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reverseFile(dump, target, HexdumpOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(target); string(got) != "0123AB6789" {
+		t.Errorf("patched file = %q, want %q", got, "0123AB6789")
+	}
+}
+
+// TestReverseInvalid tests malformed dumps
+func TestReverseInvalid(t *testing.T) {
+	for _, dump := range []string{
+		"zz: 4142\n",
+		"00000000: 414\n",
+		"00000000: 41g2\n",
+		"fffffffff: 41\n",
+		"00000010: 41\n00000000: 42\n", // Backwards on a stream
+	} {
+		var out bytes.Buffer
+		w := &sequentialWriter{w: bufio.NewWriter(&out)}
+		if err := reverseHex(strings.NewReader(dump), w, HexdumpOptions{}); err == nil {
+			t.Errorf("reverseHex(%q) succeeded", dump)
+		}
+	}
+	var out bytes.Buffer
+	w := &sequentialWriter{w: bufio.NewWriter(&out)}
+	err := reverseHex(strings.NewReader("00000010: 41\n00000000: 42\n"), w, HexdumpOptions{})
+	if !errors.Is(err, errBackwards) {
+		t.Errorf("backwards offsets gave %v, want %v", err, errBackwards)
+	}
+	if err := reverseHex(strings.NewReader("414"), w, HexdumpOptions{Plain: true}); err == nil {
+		t.Error("an odd plain dump was accepted")
+	}
+}
+
+// TestParseHexdumpOptions tests option parsing and defaults
+func TestParseHexdumpOptions(t *testing.T) {
+	opts, files, err := parseHexdumpOptions([]string{"-p", "-s", "0x10", "-l", "32", "in", "out"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Cols != 30 || opts.Skip != 16 || opts.Length != 32 || len(files) != 2 {
+		t.Errorf("options = %+v, files = %q", opts, files)
+	}
+	for _, args := range [][]string{{"-c", "0"}, {"-c", "300"}, {"-C", "-p"}, {"-r", "-j", ".text"}, {"-s"}, {"-x"}} {
+		if _, _, err := parseHexdumpOptions(args); err == nil {
+			t.Errorf("parseHexdumpOptions(%q) succeeded", args)
+		}
+	}
+}
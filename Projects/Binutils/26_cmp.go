@@ -0,0 +1,481 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// Cmp - Compare two files byte by byte, and ELF files by structure (GNU cmp equivalent)
+//
+// The first difference is reported as GNU cmp reports it. When both files
+// are ELF, cmp then names the section holding that byte and lists what
+// differs between the files: header fields, sections added, removed,
+// resized or changed, and symbols added, removed or changed. The exit
+// status is 0 for identical files, 1 for different ones and 2 for trouble.
+
+func main() {
+	options, files, err := parseCmpOptions(os.Args[1:])
+	if err != nil || len(files) != 2 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Usage: %s [-l|-s] [-n bytes] [--no-elf] <file1> <file2>\n", os.Args[0])
+		os.Exit(2)
+	}
+
+	differ, err := compareFiles(os.Stdout, os.Stderr, files[0], files[1], options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	if differ {
+		os.Exit(1)
+	}
+}
+
+// CmpOptions represents cmp options
+type CmpOptions struct {
+	List   bool  // -l: list every differing byte
+	Silent bool  // -s: print nothing, report only through the exit status
+	Limit  int64 // -n: compare at most this many bytes, negative for all
+	NoELF  bool  // --no-elf: compare bytes only
+}
+
+// parseCmpOptions parses command line options and returns the files
+func parseCmpOptions(args []string) (CmpOptions, []string, error) {
+	opts := CmpOptions{Limit: -1}
+	files := []string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-l", "--verbose":
+			opts.List = true
+		case "-s", "--quiet", "--silent":
+			opts.Silent = true
+		case "--no-elf":
+			opts.NoELF = true
+		case "-n", "--bytes":
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("option %s requires an argument", arg)
+			}
+			i++
+			// Secure: validate the limit
+			n, err := strconv.ParseInt(args[i], 0, 64)
+			if err != nil || n < 0 {
+				return opts, nil, fmt.Errorf("invalid byte limit: %s", args[i])
+			}
+			opts.Limit = n
+		default:
+			if strings.HasPrefix(arg, "-") && arg != "-" {
+				return opts, nil, fmt.Errorf("unknown option: %s", arg)
+			}
+			files = append(files, arg)
+		}
+	}
+
+	if opts.List && opts.Silent {
+		return opts, nil, fmt.Errorf("-l and -s are mutually exclusive")
+	}
+	return opts, files, nil
+}
+
+// compareFiles compares two files, printing differences to out and the
+// end of the shorter file to errOut, and reports whether they differ
+func compareFiles(out, errOut io.Writer, name1, name2 string, options CmpOptions) (bool, error) {
+	file1, err := openCompared(name1)
+	if err != nil {
+		return false, err
+	}
+	defer file1.Close()
+	file2, err := openCompared(name2)
+	if err != nil {
+		return false, err
+	}
+	defer file2.Close()
+
+	var list func(offset int64, a, b byte)
+	if options.List {
+		list = func(offset int64, a, b byte) {
+			fmt.Fprintf(out, "%d %3o %3o\n", offset+1, a, b)
+		}
+	}
+	result, err := compareBytes(file1, file2, options.Limit, list)
+	if err != nil {
+		return false, err
+	}
+	if !result.differ() {
+		return false, nil
+	}
+	if options.Silent {
+		return true, nil
+	}
+
+	if result.First >= 0 && !options.List {
+		fmt.Fprintf(out, "%s %s differ: byte %d, line %d\n", name1, name2, result.First+1, result.Line)
+	}
+	if result.Short != 0 {
+		shorter := name1
+		if result.Short == 2 {
+			shorter = name2
+		}
+		if result.Length == 0 {
+			fmt.Fprintf(errOut, "cmp: EOF on %s which is empty\n", shorter)
+		} else {
+			fmt.Fprintf(errOut, "cmp: EOF on %s after byte %d\n", shorter, result.Length)
+		}
+	}
+
+	if options.NoELF {
+		return true, nil
+	}
+	// Files that are not both ELF get the byte comparison only
+	elf1, err1 := elf.ParseELF(file1)
+	elf2, err2 := elf.ParseELF(file2)
+	if err1 != nil || err2 != nil {
+		return true, nil
+	}
+	if result.First >= 0 {
+		fmt.Fprintf(out, "byte %d is in %s\n", result.First+1, locateOffset(elf1, uint64(result.First)))
+	}
+	differences, err := compareELF(elf1, elf2)
+	if err != nil {
+		return true, err
+	}
+	if len(differences) > 0 {
+		fmt.Fprintln(out, "ELF differences:")
+		for _, line := range differences {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+	}
+	return true, nil
+}
+
+// openCompared opens a file to compare, refusing directories
+func openCompared(name string) (*os.File, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	// Secure: a directory reads as an error only on some systems
+	if stat, err := file.Stat(); err != nil || stat.IsDir() {
+		file.Close()
+		if err == nil {
+			err = fmt.Errorf("%s: is a directory", name)
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// cmpResult is the outcome of a byte comparison
+type cmpResult struct {
+	First  int64 // Offset of the first differing byte, -1 if none
+	Line   int64 // Line of the first differing byte, counting from 1
+	Short  int   // 1 or 2 if that file ended before the other, else 0
+	Length int64 // Bytes in the shorter file
+}
+
+// differ reports whether the comparison found a difference
+func (r cmpResult) differ() bool { return r.First >= 0 || r.Short != 0 }
+
+// compareBytes compares two streams up to limit bytes, or to the end if
+// limit is negative. It stops at the first difference unless list is set,
+// in which case it calls list for every differing byte. Equal blocks are
+// skipped with one bytes.Equal, so identical files compare at memory speed.
+// Time Complexity: O(n)
+func compareBytes(a, b io.Reader, limit int64, list func(offset int64, a, b byte)) (cmpResult, error) {
+	const blockSize = 32 * 1024
+	bufA, bufB := make([]byte, blockSize), make([]byte, blockSize)
+	result := cmpResult{First: -1, Line: 1}
+
+	for offset := int64(0); limit < 0 || offset < limit; {
+		n := blockSize
+		if limit >= 0 {
+			n = int(min(int64(n), limit-offset))
+		}
+		na, errA := io.ReadFull(a, bufA[:n])
+		if errA != nil && errA != io.EOF && errA != io.ErrUnexpectedEOF {
+			return result, errA
+		}
+		nb, errB := io.ReadFull(b, bufB[:n])
+		if errB != nil && errB != io.EOF && errB != io.ErrUnexpectedEOF {
+			return result, errB
+		}
+
+		m := min(na, nb)
+		if !bytes.Equal(bufA[:m], bufB[:m]) {
+			for i := range m {
+				if bufA[i] == bufB[i] {
+					continue
+				}
+				if result.First < 0 {
+					result.First = offset + int64(i)
+					result.Line += int64(bytes.Count(bufA[:i], []byte{'\n'}))
+					if list == nil {
+						return result, nil
+					}
+				}
+				list(offset+int64(i), bufA[i], bufB[i])
+			}
+		}
+		if result.First < 0 {
+			result.Line += int64(bytes.Count(bufA[:m], []byte{'\n'}))
+		}
+		offset += int64(m)
+
+		if na != nb {
+			result.Short, result.Length = 1, offset
+			if nb < na {
+				result.Short = 2
+			}
+			return result, nil
+		}
+		if na < n {
+			break
+		}
+	}
+	return result, nil
+}
+
+// locateOffset describes the part of an ELF file holding a file offset
+func locateOffset(e *elf.ELF, offset uint64) string {
+	for i, section := range e.Sections {
+		if i == 0 || section.Type == elf.SHT_NOBITS {
+			continue
+		}
+		if offset >= section.Offset && offset-section.Offset < section.Size {
+			return fmt.Sprintf("section %s at offset 0x%x", section.Name, offset-section.Offset)
+		}
+	}
+
+	ehSize, phEntSize, shEntSize := uint64(64), uint64(56), uint64(64)
+	if e.Class == "ELF32" {
+		ehSize, phEntSize, shEntSize = 52, 32, 40
+	}
+	h := e.Header
+	switch {
+	case offset < ehSize:
+		return "the ELF header"
+	case h.PhOff64 != 0 && offset >= h.PhOff64 && offset-h.PhOff64 < uint64(h.PhNum)*phEntSize:
+		return fmt.Sprintf("program header %d", (offset-h.PhOff64)/phEntSize)
+	case h.ShOff64 != 0 && offset >= h.ShOff64 && offset-h.ShOff64 < uint64(len(e.Sections))*shEntSize:
+		return fmt.Sprintf("section header %d", (offset-h.ShOff64)/shEntSize)
+	}
+	return "no section (padding)"
+}
+
+// compareELF lists the structural differences between two ELF files:
+// header fields, then sections and symbols matched by name. Symbols whose
+// only change is their address, as every symbol's is after code before it
+// grows, are counted rather than listed.
+func compareELF(a, b *elf.ELF) ([]string, error) {
+	var lines []string
+	field := func(name string, x, y any) {
+		if x != y {
+			lines = append(lines, fmt.Sprintf("%s: %v -> %v", name, x, y))
+		}
+	}
+	field("class", a.Class, b.Class)
+	field("data", a.Data, b.Data)
+	field("OS/ABI", a.OSABI, b.OSABI)
+	field("type", a.Type, b.Type)
+	field("machine", a.Machine, b.Machine)
+	field("entry point", hexValue(a.Entry), hexValue(b.Entry))
+	field("flags", hexValue(uint64(a.Header.Flags)), hexValue(uint64(b.Header.Flags)))
+
+	sectionLines, err := compareSections(a, b)
+	if err != nil {
+		return nil, err
+	}
+	lines = append(lines, sectionLines...)
+	lines = append(lines, compareSymbols("symbol", a, b, a.Symbols, b.Symbols)...)
+	lines = append(lines, compareSymbols("dynamic symbol", a, b, a.DynamicSymbols, b.DynamicSymbols)...)
+	return lines, nil
+}
+
+// hexValue formats an address or flags for a difference
+type hexValue uint64
+
+func (v hexValue) String() string { return fmt.Sprintf("0x%x", uint64(v)) }
+
+// compareSections lists sections only in one file, and what changed in
+// those in both
+func compareSections(a, b *elf.ELF) ([]string, error) {
+	var lines []string
+	keysA, keysB := sectionKeys(a), sectionKeys(b)
+	for i, key := range keysA {
+		j, ok := indexOf(keysB, key)
+		if !ok {
+			lines = append(lines, fmt.Sprintf("section %s: only in first file", key))
+			continue
+		}
+		sa, sb := &a.Sections[i], &b.Sections[j]
+		var changes []string
+		if sa.Type != sb.Type {
+			changes = append(changes, fmt.Sprintf("type %d -> %d", sa.Type, sb.Type))
+		}
+		if sa.Flags != sb.Flags {
+			changes = append(changes, fmt.Sprintf("flags 0x%x -> 0x%x", sa.Flags, sb.Flags))
+		}
+		if sa.Addr != sb.Addr {
+			changes = append(changes, fmt.Sprintf("address 0x%x -> 0x%x", sa.Addr, sb.Addr))
+		}
+		if sa.Size != sb.Size {
+			changes = append(changes, fmt.Sprintf("size 0x%x -> 0x%x", sa.Size, sb.Size))
+		} else if sa.Type != elf.SHT_NOBITS && sb.Type != elf.SHT_NOBITS {
+			same, err := sameContents(sa, sb)
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				changes = append(changes, "contents differ")
+			}
+		}
+		if len(changes) > 0 {
+			lines = append(lines, fmt.Sprintf("section %s: %s", key, strings.Join(changes, ", ")))
+		}
+	}
+	for _, key := range keysB {
+		if _, ok := indexOf(keysA, key); !ok {
+			lines = append(lines, fmt.Sprintf("section %s: only in second file", key))
+		}
+	}
+	return lines, nil
+}
+
+// sectionKeys names each section for matching across files. Names that
+// repeat, such as .group or the .rela sections of COMDAT groups, are
+// numbered by occurrence. The null section gets an empty key.
+func sectionKeys(e *elf.ELF) []string {
+	keys := make([]string, len(e.Sections))
+	seen := map[string]int{}
+	for i, section := range e.Sections {
+		if i == 0 {
+			continue
+		}
+		keys[i] = occurrenceKey(section.Name, seen)
+	}
+	return keys
+}
+
+// occurrenceKey returns name, numbered from its second occurrence on
+func occurrenceKey(name string, seen map[string]int) string {
+	seen[name]++
+	if n := seen[name]; n > 1 {
+		return fmt.Sprintf("%s#%d", name, n)
+	}
+	return name
+}
+
+// indexOf returns the index of the first element equal to key, skipping
+// the empty key of the null section
+func indexOf(keys []string, key string) (int, bool) {
+	if key == "" {
+		return 0, true
+	}
+	for i, k := range keys {
+		if k == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// sameContents compares the contents of two sections of equal size,
+// streaming them so that large sections are not held in memory
+func sameContents(a, b *elf.Section) (bool, error) {
+	result, err := compareBytes(a.Open(), b.Open(), int64(a.Size), nil)
+	if err != nil {
+		return false, fmt.Errorf("section %s: %w", a.Name, err)
+	}
+	return !result.differ(), nil
+}
+
+// compareSymbols lists named symbols only in one table, and changes to
+// the size, type, binding or section of those in both
+func compareSymbols(kind string, a, b *elf.ELF, symsA, symsB []elf.Symbol) []string {
+	var lines []string
+	tableA, keysA := symbolTable(symsA)
+	tableB, keysB := symbolTable(symsB)
+
+	moved := 0
+	for _, key := range keysA {
+		sa := tableA[key]
+		sb, ok := tableB[key]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("%s %s: only in first file", kind, key))
+			continue
+		}
+		var changes []string
+		if sa.Size != sb.Size {
+			changes = append(changes, fmt.Sprintf("size %d -> %d", sa.Size, sb.Size))
+		}
+		if sa.Type != sb.Type {
+			changes = append(changes, fmt.Sprintf("type %s -> %s", sa.Type, sb.Type))
+		}
+		if sa.Binding != sb.Binding {
+			changes = append(changes, fmt.Sprintf("binding %s -> %s", sa.Binding, sb.Binding))
+		}
+		if secA, secB := symbolSection(a, sa), symbolSection(b, sb); secA != secB {
+			changes = append(changes, fmt.Sprintf("section %s -> %s", secA, secB))
+		}
+		if len(changes) > 0 {
+			lines = append(lines, fmt.Sprintf("%s %s: %s", kind, key, strings.Join(changes, ", ")))
+		} else if sa.Value != sb.Value {
+			moved++
+		}
+	}
+	for _, key := range keysB {
+		if _, ok := tableA[key]; !ok {
+			lines = append(lines, fmt.Sprintf("%s %s: only in second file", kind, key))
+		}
+	}
+	switch {
+	case moved == 1:
+		lines = append(lines, fmt.Sprintf("1 %s changed address only", kind))
+	case moved > 1:
+		lines = append(lines, fmt.Sprintf("%d %ss changed address only", moved, kind))
+	}
+	return lines
+}
+
+// symbolTable indexes the named symbols of a table by name, numbering
+// names that repeat, such as local symbols of different source files, and
+// returns the keys in table order
+func symbolTable(symbols []elf.Symbol) (map[string]*elf.Symbol, []string) {
+	table := map[string]*elf.Symbol{}
+	keys := []string{}
+	seen := map[string]int{}
+	for i := range symbols {
+		if symbols[i].Name == "" {
+			continue
+		}
+		key := occurrenceKey(symbols[i].Name, seen)
+		table[key] = &symbols[i]
+		keys = append(keys, key)
+	}
+	return table, keys
+}
+
+// symbolSection names the section a symbol is defined in
+func symbolSection(e *elf.ELF, sym *elf.Symbol) string {
+	switch sym.Shndx {
+	case elf.SHN_UNDEF:
+		return "UND"
+	case elf.SHN_ABS:
+		return "ABS"
+	case elf.SHN_COMMON:
+		return "COM"
+	}
+	if int(sym.Shndx) < len(e.Sections) {
+		return e.Sections[sym.Shndx].Name
+	}
+	return fmt.Sprintf("%d", sym.Shndx)
+}
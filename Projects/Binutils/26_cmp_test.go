@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// TestCompareBytes tests the first difference, its line, short files and
+// the byte limit
+func TestCompareBytes(t *testing.T) {
+	long := strings.Repeat("line\n", 20000)
+	tests := []struct {
+		a, b  string
+		limit int64
+		want  cmpResult
+	}{
+		{"same", "same", -1, cmpResult{First: -1, Line: 1}},
+		{"ab\ncd", "ab\nce", -1, cmpResult{First: 4, Line: 2}},
+		{"ab\ncd", "ab\nce", 4, cmpResult{First: -1, Line: 2}},
+		{"abc", "ab", -1, cmpResult{First: -1, Line: 1, Short: 2, Length: 2}},
+		{"", "x", -1, cmpResult{First: -1, Line: 1, Short: 1}},
+		{long + "x", long + "y", -1, cmpResult{First: int64(len(long)), Line: 20001}},
+	}
+	for _, tt := range tests {
+		got, err := compareBytes(strings.NewReader(tt.a), strings.NewReader(tt.b), tt.limit, nil)
+		if err != nil || got != tt.want {
+			t.Errorf("compareBytes(%.10q, %.10q, %d) = %+v, %v, want %+v", tt.a, tt.b, tt.limit, got, err, tt.want)
+		}
+	}
+
+	var offsets []int64
+	list := func(offset int64, a, b byte) { offsets = append(offsets, offset) }
+	if _, err := compareBytes(strings.NewReader("axcxe"), strings.NewReader("abcde"), -1, list); err != nil {
+		t.Fatal(err)
+	}
+	if len(offsets) != 2 || offsets[0] != 1 || offsets[1] != 3 {
+		t.Errorf("listed offsets %v, want [1 3]", offsets)
+	}
+}
+
+// TestCompareFiles tests the messages for different and short files
+func TestCompareFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a, b, c := write("a", "ab\ncd"), write("b", "ab\nce"), write("c", "ab")
+
+	var out, errOut bytes.Buffer
+	differ, err := compareFiles(&out, &errOut, a, b, CmpOptions{Limit: -1})
+	if err != nil || !differ || out.String() != a+" "+b+" differ: byte 5, line 2\n" {
+		t.Errorf("compareFiles = %v, %v, %q", differ, err, out.String())
+	}
+
+	out.Reset()
+	differ, err = compareFiles(&out, &errOut, a, c, CmpOptions{Limit: -1})
+	if err != nil || !differ || errOut.String() != "cmp: EOF on "+c+" after byte 2\n" {
+		t.Errorf("compareFiles with a short file = %v, %v, %q", differ, err, errOut.String())
+	}
+
+	out.Reset()
+	differ, err = compareFiles(&out, &errOut, a, b, CmpOptions{Limit: -1, Silent: true})
+	if err != nil || !differ || out.Len() != 0 {
+		t.Errorf("compareFiles -s = %v, %v, %q", differ, err, out.String())
+	}
+
+	if differ, err := compareFiles(&out, &errOut, a, a, CmpOptions{Limit: -1}); err != nil || differ {
+		t.Errorf("a file differs from itself: %v, %v", differ, err)
+	}
+	if _, err := compareFiles(&out, &errOut, a, dir, CmpOptions{Limit: -1}); err == nil {
+		t.Error("compareFiles accepted a directory")
+	}
+}
+
+// testELF returns an in-memory ELF object with sections and symbols
+func testELF(text string, symbols ...elf.Symbol) *elf.ELF {
+	return &elf.ELF{
+		Class:   "ELF64",
+		Type:    "ET_REL",
+		Machine: "EM_X86_64",
+		Sections: []elf.Section{
+			{},
+			{Name: ".text", Type: 1, Offset: 0x40, Size: uint64(len(text)), Data: []byte(text)},
+			{Name: ".bss", Type: elf.SHT_NOBITS, Size: 16},
+		},
+		Symbols: symbols,
+	}
+}
+
+// TestCompareELF tests structural differences between ELF files
+func TestCompareELF(t *testing.T) {
+	a := testELF("\x55\xc3",
+		elf.Symbol{Name: "main", Size: 2, Type: "STT_FUNC", Binding: "STB_GLOBAL", Shndx: 1},
+		elf.Symbol{Name: "old", Size: 4, Type: "STT_OBJECT", Binding: "STB_LOCAL", Shndx: 2},
+		elf.Symbol{Name: "moved", Value: 8, Type: "STT_FUNC", Binding: "STB_GLOBAL", Shndx: 1},
+	)
+	b := testELF("\x90\xc3",
+		elf.Symbol{Name: "main", Size: 2, Type: "STT_FUNC", Binding: "STB_WEAK", Shndx: 1},
+		elf.Symbol{Name: "new", Type: "STT_NOTYPE", Binding: "STB_GLOBAL", Shndx: elf.SHN_UNDEF},
+		elf.Symbol{Name: "moved", Value: 16, Type: "STT_FUNC", Binding: "STB_GLOBAL", Shndx: 1},
+	)
+	b.Entry = 0x10
+	b.Sections = append(b.Sections, elf.Section{Name: ".comment", Type: 1, Size: 1, Data: []byte{0}})
+
+	got, err := compareELF(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"entry point: 0x0 -> 0x10",
+		"section .text: contents differ",
+		"section .comment: only in second file",
+		"symbol main: binding STB_GLOBAL -> STB_WEAK",
+		"symbol old: only in first file",
+		"symbol new: only in second file",
+		"1 symbol changed address only",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("compareELF =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	if same, err := compareELF(a, a); err != nil || len(same) != 0 {
+		t.Errorf("compareELF of a file with itself = %q, %v", same, err)
+	}
+}
+
+// TestLocateOffset tests naming the part of a file holding an offset
+func TestLocateOffset(t *testing.T) {
+	e := testELF("\x55\xc3")
+	e.Header.ShOff64 = 0x100
+	tests := map[uint64]string{
+		0x10:  "the ELF header",
+		0x41:  "section .text at offset 0x1",
+		0x80:  "no section (padding)",
+		0x140: "section header 1",
+	}
+	for offset, want := range tests {
+		if got := locateOffset(e, offset); got != want {
+			t.Errorf("locateOffset(%#x) = %q, want %q", offset, got, want)
+		}
+	}
+}
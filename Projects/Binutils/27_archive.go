@@ -0,0 +1,683 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hellogolang/Algorithms/compress"
+	"hellogolang/Projects/Binutils/arfile"
+	"hellogolang/Projects/Binutils/cpiofile"
+	"hellogolang/Projects/Binutils/filetype"
+	"hellogolang/Projects/Binutils/match"
+	"hellogolang/Projects/Binutils/safepath"
+	"hellogolang/Projects/Binutils/tarfile"
+)
+
+// Archive - List, extract and create tar, cpio and ar archives (GNU tar equivalent)
+//
+// The format of an archive being read is detected from its contents, and a
+// gzip-compressed archive is decompressed as it is read; one being created
+// is tar unless --format or the extension of its name (.cpio, .a) says
+// otherwise, and is compressed with -z or a name ending in .gz or .tgz.
+// Before anything is extracted its name is checked: absolute names, ".."
+// components, writes through symbolic links and links pointing outside
+// the destination are refused.
+
+func main() {
+	options, args, err := parseArchiveOptions(os.Args[1:])
+	if err != nil || options.Mode == 0 || (options.Mode == 'c' && len(args) == 0) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Usage: %s -t [-v] [-f archive] [pattern...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s -x [-v] [-f archive] [-C dir] [pattern...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s -c [-vz] [-f archive] [--format tar|cpio|ar] <path>...\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	if options.Mode == 'c' {
+		err = createFile(options, args)
+	} else {
+		err = readFile(options, args)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", options.File, err)
+		os.Exit(1)
+	}
+}
+
+// ArchiveOptions represents archive options
+type ArchiveOptions struct {
+	Mode      byte   // 't' list, 'x' extract or 'c' create
+	Verbose   bool   // -v
+	File      string // -f: the archive, "-" for standard input or output
+	Directory string // -C: where to extract
+	Format    string // --format: tar, cpio or ar, for create
+	Gzip      bool   // -z: compress the archive created
+}
+
+// archiveFormats are the formats of --format
+var archiveFormats = []string{"tar", "cpio", "ar"}
+
+// parseArchiveOptions parses command line options and returns the patterns
+// or paths that follow them. Short options may be bundled as tar allows:
+// "-cvf out.tar" takes the archive name from the next argument.
+func parseArchiveOptions(args []string) (ArchiveOptions, []string, error) {
+	opts := ArchiveOptions{File: "-", Directory: "."}
+	rest := []string{}
+
+	setMode := func(mode byte) error {
+		if opts.Mode != 0 && opts.Mode != mode {
+			return fmt.Errorf("only one of -t, -x and -c may be given")
+		}
+		opts.Mode = mode
+		return nil
+	}
+	setValue := func(letter byte, value string) error {
+		switch letter {
+		case 'f':
+			opts.File = value
+		case 'C':
+			opts.Directory = value
+		case 'F':
+			for _, format := range archiveFormats {
+				if value == format {
+					opts.Format = value
+					return nil
+				}
+			}
+			return fmt.Errorf("unknown format: %s", value)
+		}
+		return nil
+	}
+	long := map[string]byte{
+		"--list": 't', "--extract": 'x', "--create": 'c', "--verbose": 'v',
+		"--gzip": 'z', "--file": 'f', "--directory": 'C', "--format": 'F',
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "--") {
+			name, value, hasValue := strings.Cut(arg, "=")
+			letter, ok := long[name]
+			if !ok {
+				return opts, nil, fmt.Errorf("unknown option: %s", arg)
+			}
+			switch letter {
+			case 'v':
+				opts.Verbose = true
+			case 'z':
+				opts.Gzip = true
+			case 't', 'x', 'c':
+				if err := setMode(letter); err != nil {
+					return opts, nil, err
+				}
+			default:
+				if !hasValue {
+					if i+1 >= len(args) {
+						return opts, nil, fmt.Errorf("option %s requires an argument", arg)
+					}
+					i++
+					value = args[i]
+				}
+				if err := setValue(letter, value); err != nil {
+					return opts, nil, err
+				}
+			}
+			continue
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			rest = append(rest, arg)
+			continue
+		}
+
+		for j := 1; j < len(arg); j++ {
+			switch letter := arg[j]; letter {
+			case 't', 'x', 'c':
+				if err := setMode(letter); err != nil {
+					return opts, nil, err
+				}
+			case 'v':
+				opts.Verbose = true
+			case 'z':
+				opts.Gzip = true
+			case 'f', 'C':
+				value := arg[j+1:]
+				if value == "" {
+					if i+1 >= len(args) {
+						return opts, nil, fmt.Errorf("option -%c requires an argument", letter)
+					}
+					i++
+					value = args[i]
+				}
+				if err := setValue(letter, value); err != nil {
+					return opts, nil, err
+				}
+				j = len(arg)
+			default:
+				return opts, nil, fmt.Errorf("unknown option: -%c", letter)
+			}
+		}
+	}
+
+	return opts, rest, nil
+}
+
+// archiveEntry is a member of an archive of any format. Type uses the tar
+// type letters, which cover what cpio and ar can store.
+type archiveEntry struct {
+	Name     string
+	Type     byte
+	Linkname string
+	Mode     int
+	Owner    string // User and group names, "uid/gid" when not stored
+	Size     int64
+	Date     int64
+}
+
+// cpioTypes maps cpio file types to tar type letters
+var cpioTypes = map[int]byte{
+	cpiofile.TypeReg: tarfile.TypeReg, cpiofile.TypeDir: tarfile.TypeDir,
+	cpiofile.TypeSymlink: tarfile.TypeSymlink, cpiofile.TypeChar: tarfile.TypeChar,
+	cpiofile.TypeBlock: tarfile.TypeBlock, cpiofile.TypeFifo: tarfile.TypeFifo,
+}
+
+// convertMembers adapts the member iterator of one format to entries
+func convertMembers[H any](members iter.Seq2[H, error], convert func(H) *archiveEntry) iter.Seq2[*archiveEntry, error] {
+	return func(yield func(*archiveEntry, error) bool) {
+		for h, err := range members {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(convert(h), nil) {
+				return
+			}
+		}
+	}
+}
+
+// openEntries detects the format of an archive and returns its entries and
+// the reader their data is read from while the loop body runs
+func openEntries(r *bufio.Reader) (iter.Seq2[*archiveEntry, error], io.Reader, error) {
+	header, _ := r.Peek(filetype.HeaderSize)
+	format := filetype.Detect(header)
+	if format == nil {
+		return nil, nil, fmt.Errorf("archive format not recognized")
+	}
+
+	owner := func(uname, gname string, uid, gid int) string {
+		if uname == "" || gname == "" {
+			return fmt.Sprintf("%d/%d", uid, gid)
+		}
+		return uname + "/" + gname
+	}
+	switch format.Name {
+	case "tar":
+		tr := tarfile.NewReader(r)
+		return convertMembers(tr.Members(), func(h *tarfile.Header) *archiveEntry {
+			return &archiveEntry{
+				Name: h.Name, Type: h.Type, Linkname: h.Linkname, Mode: h.Mode,
+				Owner: owner(h.Uname, h.Gname, h.UID, h.GID), Size: h.Size, Date: h.Date,
+			}
+		}), tr, nil
+	case "cpio":
+		cr := cpiofile.NewReader(r)
+		return convertMembers(cr.Members(), func(h *cpiofile.Header) *archiveEntry {
+			return &archiveEntry{
+				Name: h.Name, Type: cpioTypes[h.Type], Linkname: h.Linkname, Mode: h.Mode,
+				Owner: owner("", "", h.UID, h.GID), Size: h.Size, Date: h.Date,
+			}
+		}), cr, nil
+	case "ar":
+		ar, err := arfile.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return convertMembers(ar.Members(), func(h *arfile.Header) *archiveEntry {
+			return &archiveEntry{
+				Name: h.Name, Type: tarfile.TypeReg, Mode: h.Mode & 0o7777,
+				Owner: owner("", "", h.UID, h.GID), Size: h.Size, Date: h.Date,
+			}
+		}), ar, nil
+	}
+	return nil, nil, fmt.Errorf("not an archive: %s data", format.Name)
+}
+
+// readFile lists or extracts the archive of options.File
+func readFile(options ArchiveOptions, patterns []string) error {
+	in := os.Stdin
+	if options.File != "-" {
+		file, err := os.Open(options.File)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	return readArchive(out, in, patterns, options)
+}
+
+// readArchive lists or extracts the members of an archive, or those
+// matching patterns. A pattern naming a directory selects what is in it.
+// A member that cannot be extracted is reported and the rest still are.
+func readArchive(out io.Writer, r io.Reader, patterns []string, options ArchiveOptions) error {
+	filter, err := match.NewFilter(patterns...)
+	if err != nil {
+		return fmt.Errorf("bad member pattern: %w", err)
+	}
+	in := bufio.NewReader(r)
+	header, _ := in.Peek(filetype.HeaderSize)
+	compressed := false
+	if format := filetype.Detect(header); format != nil && format.Name == "gzip" {
+		zr := gunzip(in)
+		defer zr.Close()
+		in, compressed = bufio.NewReader(zr), true
+	}
+	entries, data, err := openEntries(in)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for e, err := range entries {
+		if err != nil {
+			return err
+		}
+		if !filter.Empty() && !selected(filter, e.Name) {
+			continue
+		}
+		if options.Mode == 't' {
+			listEntry(out, e, options.Verbose)
+			continue
+		}
+		if options.Verbose {
+			fmt.Fprintln(out, e.Name)
+		}
+		if err := extractEntry(options.Directory, e, data); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", e.Name, err)
+			failed++
+		}
+	}
+	// Reading to the end checks the trailer of a compressed archive
+	if compressed {
+		if _, err := io.Copy(io.Discard, in); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("members not extracted: %d", failed)
+	}
+	return nil
+}
+
+// gunzip returns a reader of the decompressed data of a gzip stream, which
+// a goroutine decompresses as it is read. Closing the reader stops it.
+func gunzip(r io.Reader) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := compress.GzipDecode(pw, r)
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// gzipTo returns a writer whose data a goroutine compresses to w, and a
+// function that ends the data, or abandons it after the error passed,
+// and returns once the compressed stream is complete
+func gzipTo(w io.Writer) (io.Writer, func(error) error) {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := compress.GzipEncode(w, pr, compress.GzipHeader{})
+		pr.CloseWithError(err)
+		done <- err
+	}()
+	return pw, func(err error) error {
+		pw.CloseWithError(err)
+		if gzipErr := <-done; err == nil {
+			err = gzipErr
+		}
+		return err
+	}
+}
+
+// selected reports whether a member or a directory holding it matches
+func selected(filter *match.Filter, name string) bool {
+	name = strings.TrimSuffix(name, "/")
+	for {
+		if filter.Match(name) {
+			return true
+		}
+		slash := strings.LastIndexByte(name, '/')
+		if slash < 0 {
+			return false
+		}
+		name = name[:slash]
+	}
+}
+
+// listEntry prints a member name, or with verbose a line like tar tv:
+// mode, owner, size, date and name, with the target of a link
+func listEntry(out io.Writer, e *archiveEntry, verbose bool) {
+	if !verbose {
+		fmt.Fprintln(out, e.Name)
+		return
+	}
+	kind := map[byte]byte{
+		tarfile.TypeDir: 'd', tarfile.TypeSymlink: 'l', tarfile.TypeLink: 'h',
+		tarfile.TypeChar: 'c', tarfile.TypeBlock: 'b', tarfile.TypeFifo: 'p',
+	}[e.Type]
+	if kind == 0 {
+		kind = '-'
+	}
+	mode := string(kind) + os.FileMode(e.Mode).Perm().String()[1:]
+	date := time.Unix(e.Date, 0).Format("2006-01-02 15:04")
+
+	line := fmt.Sprintf("%s %s %8d %s %s", mode, e.Owner, e.Size, date, e.Name)
+	switch e.Type {
+	case tarfile.TypeSymlink:
+		line += " -> " + e.Linkname
+	case tarfile.TypeLink:
+		line += " link to " + e.Linkname
+	}
+	fmt.Fprintln(out, line)
+}
+
+// extractEntry creates a member under root. Whatever is at its path is
+// replaced, except a directory by a directory. Set-user-ID and set-group-ID
+// bits are dropped.
+func extractEntry(root string, e *archiveEntry, data io.Reader) error {
+	// Secure: prevent path traversal
+	path, err := safepath.Join(root, e.Name)
+	if err != nil {
+		return err
+	}
+	perm := os.FileMode(e.Mode).Perm()
+
+	if e.Type == tarfile.TypeDir {
+		if err := os.MkdirAll(path, perm|0o700); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(path); err == nil && !info.IsDir() {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	switch e.Type {
+	case tarfile.TypeSymlink:
+		// Secure: a link out of the destination would let later writes escape
+		if err := safepath.CheckLink(e.Name, e.Linkname); err != nil {
+			return err
+		}
+		return os.Symlink(e.Linkname, path)
+	case tarfile.TypeLink:
+		target, err := safepath.Join(root, e.Linkname)
+		if err != nil {
+			return err
+		}
+		return os.Link(target, path)
+	case tarfile.TypeChar, tarfile.TypeBlock, tarfile.TypeFifo, 0:
+		return fmt.Errorf("special files are not extracted")
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	date := time.Unix(e.Date, 0)
+	return os.Chtimes(path, date, date)
+}
+
+// createFile writes an archive of paths to options.File, removing it
+// again if writing fails
+func createFile(options ArchiveOptions, paths []string) error {
+	name := options.File
+	switch filepath.Ext(name) {
+	case ".gz":
+		options.Gzip = true
+		name = strings.TrimSuffix(name, ".gz")
+	case ".tgz":
+		options.Gzip = true
+	}
+	if options.Format == "" {
+		options.Format = "tar"
+		switch filepath.Ext(name) {
+		case ".cpio":
+			options.Format = "cpio"
+		case ".a":
+			options.Format = "ar"
+		}
+	}
+	if options.File == "-" {
+		out := bufio.NewWriter(os.Stdout)
+		if err := createArchive(out, os.Stderr, nil, paths, options); err != nil {
+			return err
+		}
+		return out.Flush()
+	}
+
+	file, err := os.Create(options.File)
+	if err != nil {
+		return err
+	}
+	self, err := file.Stat()
+	if err == nil {
+		out := bufio.NewWriter(file)
+		if err = createArchive(out, os.Stdout, self, paths, options); err == nil {
+			err = out.Flush()
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(options.File)
+	}
+	return err
+}
+
+// archiveWriter is the writer of any of the formats created
+type archiveWriter interface {
+	io.Writer
+	Close() error
+}
+
+// createArchive writes an archive of the files and directory trees under
+// paths, naming verbose progress on log. Stored names are relative: a
+// leading "/" or "../" is removed, as tar does. The archive itself, self,
+// is skipped when it is found among the files. An ar archive holds only
+// the regular files, under their base names.
+func createArchive(w io.Writer, log io.Writer, self os.FileInfo, paths []string, options ArchiveOptions) error {
+	if options.Gzip {
+		zw, finish := gzipTo(w)
+		options.Gzip = false
+		return finish(createArchive(zw, log, self, paths, options))
+	}
+	if options.Format == "ar" {
+		return createAr(w, log, paths, options)
+	}
+
+	var aw archiveWriter
+	var writeHeader func(name string, info fs.FileInfo, link string) error
+	switch options.Format {
+	case "cpio":
+		cw := cpiofile.NewWriter(w)
+		aw = cw
+		writeHeader = func(name string, info fs.FileInfo, link string) error {
+			h := &cpiofile.Header{Name: name, Mode: int(info.Mode().Perm()), Date: info.ModTime().Unix(), Linkname: link}
+			switch {
+			case info.IsDir():
+				h.Type = cpiofile.TypeDir
+			case link != "":
+				h.Type = cpiofile.TypeSymlink
+			default:
+				h.Type, h.Size = cpiofile.TypeReg, info.Size()
+			}
+			return cw.WriteHeader(h)
+		}
+	default:
+		tw := tarfile.NewWriter(w)
+		aw = tw
+		writeHeader = func(name string, info fs.FileInfo, link string) error {
+			h := &tarfile.Header{Name: name, Mode: int(info.Mode().Perm()), Date: info.ModTime().Unix(), Linkname: link}
+			switch {
+			case info.IsDir():
+				h.Type = tarfile.TypeDir
+			case link != "":
+				h.Type = tarfile.TypeSymlink
+			default:
+				h.Type, h.Size = tarfile.TypeReg, info.Size()
+			}
+			return tw.WriteHeader(h)
+		}
+	}
+
+	warned := false
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if self != nil && os.SameFile(info, self) {
+				fmt.Fprintf(os.Stderr, "%s: file is the archive; not dumped\n", path)
+				return nil
+			}
+			name, trimmed := memberName(path)
+			if trimmed && !warned {
+				fmt.Fprintf(os.Stderr, "removing leading '/' and '../' from member names\n")
+				warned = true
+			}
+			if name == "" {
+				return nil
+			}
+
+			link := ""
+			switch {
+			case info.Mode()&fs.ModeSymlink != 0:
+				if link, err = os.Readlink(path); err != nil {
+					return err
+				}
+			case !info.IsDir() && !info.Mode().IsRegular():
+				fmt.Fprintf(os.Stderr, "%s: special file not archived\n", path)
+				return nil
+			}
+			if options.Verbose {
+				if info.IsDir() {
+					fmt.Fprintln(log, name+"/")
+				} else {
+					fmt.Fprintln(log, name)
+				}
+			}
+			if err := writeHeader(name, info, link); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			return copyFile(aw, path, info.Size())
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return aw.Close()
+}
+
+// memberName turns a path into a relative slash-separated member name,
+// reporting whether a leading "/" or ".." components were removed. The
+// name of the current directory itself is empty.
+func memberName(path string) (string, bool) {
+	name := filepath.ToSlash(filepath.Clean(path))
+	trimmed := false
+	for {
+		switch {
+		case strings.HasPrefix(name, "/"):
+			name = name[1:]
+		case name == "..":
+			name = ""
+		case strings.HasPrefix(name, "../"):
+			name = name[3:]
+		default:
+			if name == "." {
+				name = ""
+			}
+			return name, trimmed
+		}
+		trimmed = true
+	}
+}
+
+// copyFile writes the size bytes of a file that its header announced
+func copyFile(w io.Writer, path string, size int64) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	n, err := io.CopyN(w, file, size)
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: file shrank by %d bytes while being archived", path, size-n)
+	}
+	return err
+}
+
+// createAr writes an ar archive of the regular files among paths, with a
+// symbol index when they include objects
+func createAr(w io.Writer, log io.Writer, paths []string, options ArchiveOptions) error {
+	members := []*arfile.Member{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			fmt.Fprintf(os.Stderr, "%s: not a regular file; not archived\n", path)
+			continue
+		}
+		// Secure: limit file size
+		if info.Size() > arfile.MaxMemberSize {
+			return fmt.Errorf("%s: file too large", path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if options.Verbose {
+			fmt.Fprintln(log, filepath.Base(path))
+		}
+		members = append(members, &arfile.Member{
+			Header: arfile.Header{Name: filepath.Base(path), Date: info.ModTime().Unix(), Mode: int(info.Mode().Perm())},
+			Data:   data,
+		})
+	}
+	return arfile.WriteArchive(w, members)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hellogolang/Algorithms/compress"
+	"hellogolang/Projects/Binutils/match"
+	"hellogolang/Projects/Binutils/tarfile"
+)
+
+// TestParseArchiveOptions tests bundled and long options
+func TestParseArchiveOptions(t *testing.T) {
+	opts, rest, err := parseArchiveOptions([]string{"-czvf", "out.tar", "--format=cpio", "src", "-C", "dir"})
+	if err != nil || opts.Mode != 'c' || !opts.Verbose || !opts.Gzip || opts.File != "out.tar" || opts.Format != "cpio" || opts.Directory != "dir" {
+		t.Errorf("parseArchiveOptions = %+v, %v", opts, err)
+	}
+	if len(rest) != 1 || rest[0] != "src" {
+		t.Errorf("rest = %v, want [src]", rest)
+	}
+
+	opts, _, err = parseArchiveOptions([]string{"--extract", "--file", "a.cpio", "-Cout"})
+	if err != nil || opts.Mode != 'x' || opts.File != "a.cpio" || opts.Directory != "out" {
+		t.Errorf("parseArchiveOptions = %+v, %v", opts, err)
+	}
+
+	for _, args := range [][]string{{"-tx"}, {"-f"}, {"--format=zip"}, {"-q"}, {"--bogus"}} {
+		if _, _, err := parseArchiveOptions(args); err == nil {
+			t.Errorf("parseArchiveOptions(%v) succeeded", args)
+		}
+	}
+}
+
+// TestMemberName tests the relative names stored for paths
+func TestMemberName(t *testing.T) {
+	tests := []struct {
+		path, name string
+		trimmed    bool
+	}{
+		{"src/a.txt", "src/a.txt", false},
+		{"./src/", "src", false},
+		{".", "", false},
+		{"/etc/hosts", "etc/hosts", true},
+		{"../../x/y", "x/y", true},
+		{"..", "", true},
+	}
+	for _, tt := range tests {
+		name, trimmed := memberName(tt.path)
+		if name != tt.name || trimmed != tt.trimmed {
+			t.Errorf("memberName(%q) = %q, %v, want %q, %v", tt.path, name, trimmed, tt.name, tt.trimmed)
+		}
+	}
+}
+
+// TestSelected tests that a directory pattern selects its contents
+func TestSelected(t *testing.T) {
+	filter, _ := match.NewFilter("src/sub", "*.txt")
+	for name, want := range map[string]bool{
+		"src/sub/": true, "src/sub/bin": true, "a.txt": true, "src/subway": false, "src/bin": false,
+	} {
+		if got := selected(filter, name); got != want {
+			t.Errorf("selected(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// TestArchiveRoundTrip tests creating and extracting each format
+func TestArchiveRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{"a.txt": "hello\n", "sub/b.bin": strings.Repeat("\x00\xff", 700)}
+	for name, data := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(data), 0o640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Symlink("../a.txt", filepath.Join(src, "sub", "link"))
+	t.Chdir(src)
+
+	for _, format := range archiveFormats {
+		var archive, log bytes.Buffer
+		options := ArchiveOptions{Mode: 'c', Format: format, Verbose: true}
+		paths := []string{"a.txt", "sub"}
+		if format == "ar" {
+			paths = []string{"a.txt", filepath.Join("sub", "b.bin")}
+		}
+		if err := createArchive(&archive, &log, nil, paths, options); err != nil {
+			t.Fatalf("%s: createArchive failed: %v", format, err)
+		}
+
+		dst := t.TempDir()
+		var listing bytes.Buffer
+		options = ArchiveOptions{Mode: 'x', Directory: dst, Verbose: true}
+		if err := readArchive(&listing, bytes.NewReader(archive.Bytes()), nil, options); err != nil {
+			t.Fatalf("%s: readArchive failed: %v", format, err)
+		}
+		// Only tar marks directories with a trailing slash
+		if strings.ReplaceAll(listing.String(), "/\n", "\n") != strings.ReplaceAll(log.String(), "/\n", "\n") {
+			t.Errorf("%s: extracted\n%s\ncreated\n%s", format, listing.String(), log.String())
+		}
+
+		root := dst
+		if format == "ar" {
+			files = map[string]string{"a.txt": files["a.txt"], "b.bin": files["sub/b.bin"]}
+		} else if target, err := os.Readlink(filepath.Join(root, "sub", "link")); err != nil || target != "../a.txt" {
+			t.Errorf("%s: link = %q, %v", format, target, err)
+		}
+		for name, want := range files {
+			path := filepath.Join(root, filepath.FromSlash(name))
+			data, err := os.ReadFile(path)
+			if err != nil || string(data) != want {
+				t.Errorf("%s: %s = %q, %v", format, name, data, err)
+			}
+			if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0o640 {
+				t.Errorf("%s: %s mode %v, want 0640", format, name, info.Mode())
+			}
+		}
+	}
+}
+
+// TestArchiveGzip tests that a compressed archive is detected and read
+func TestArchiveGzip(t *testing.T) {
+	src := t.TempDir()
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte(strings.Repeat("compress me\n", 1000)), 0o644)
+	t.Chdir(src)
+
+	var archive, listing bytes.Buffer
+	if err := createArchive(&archive, io.Discard, nil, []string{"a.txt"}, ArchiveOptions{Mode: 'c', Format: "tar", Gzip: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(archive.Bytes(), []byte("\x1f\x8b")) || archive.Len() > 1000 {
+		t.Errorf("archive of %d bytes is not compressed", archive.Len())
+	}
+	if err := readArchive(&listing, bytes.NewReader(archive.Bytes()), nil, ArchiveOptions{Mode: 't'}); err != nil || listing.String() != "a.txt\n" {
+		t.Errorf("readArchive = %q, %v", listing.String(), err)
+	}
+
+	// Damage to the trailer shows only once the members have been read
+	damaged := bytes.Clone(archive.Bytes())
+	damaged[len(damaged)-1] ^= 1
+	if err := readArchive(io.Discard, bytes.NewReader(damaged), nil, ArchiveOptions{Mode: 't'}); !errors.Is(err, compress.ErrCorrupt) {
+		t.Errorf("readArchive of a damaged trailer = %v, want ErrCorrupt", err)
+	}
+}
+
+// TestExtractUnsafe tests that names and links leading outside the
+// destination are refused while the other members are extracted
+func TestExtractUnsafe(t *testing.T) {
+	outside := t.TempDir()
+	var archive bytes.Buffer
+	tarfile.WriteArchive(&archive, []*tarfile.Member{
+		{Header: tarfile.Header{Name: "../escape", Mode: 0o644}, Data: []byte("x")},
+		{Header: tarfile.Header{Name: "/absolute", Mode: 0o644}, Data: []byte("x")},
+		{Header: tarfile.Header{Name: "out", Type: tarfile.TypeSymlink, Linkname: outside}},
+		{Header: tarfile.Header{Name: "here", Type: tarfile.TypeSymlink, Linkname: "."}},
+		{Header: tarfile.Header{Name: "here/through", Mode: 0o644}, Data: []byte("x")},
+		{Header: tarfile.Header{Name: "hard", Type: tarfile.TypeLink, Linkname: "../../etc/passwd"}},
+		{Header: tarfile.Header{Name: "suid", Mode: 0o4755}, Data: []byte("ok")},
+	})
+
+	dst := t.TempDir()
+	err := readArchive(io.Discard, &archive, nil, ArchiveOptions{Mode: 'x', Directory: dst})
+	if err == nil || !strings.Contains(err.Error(), "members not extracted: 5") {
+		t.Errorf("readArchive = %v, want 5 members refused", err)
+	}
+	entries, _ := os.ReadDir(outside)
+	if len(entries) != 0 {
+		t.Errorf("files written outside the destination: %v", entries)
+	}
+	info, err := os.Stat(filepath.Join(dst, "suid"))
+	if err != nil || info.Mode() != 0o755 {
+		t.Errorf("suid extracted as %v, %v, want mode 0755", info, err)
+	}
+}
+
+// TestReadArchiveRefusesOtherFormats tests that non-archives are named
+func TestReadArchiveRefusesOtherFormats(t *testing.T) {
+	err := readArchive(io.Discard, strings.NewReader("\x7fELF\x02\x01\x01"), nil, ArchiveOptions{Mode: 't'})
+	if err == nil || !strings.Contains(err.Error(), "ELF") {
+		t.Errorf("readArchive of an ELF file = %v", err)
+	}
+	if err := readArchive(io.Discard, strings.NewReader("text"), nil, ArchiveOptions{Mode: 't'}); err == nil {
+		t.Error("readArchive accepted text")
+	}
+}
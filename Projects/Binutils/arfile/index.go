@@ -0,0 +1,133 @@
+// Package arfile implements the Unix ar archive format as written by GNU ar,
+// including the "/" symbol index used by linkers to find archive members.
+package arfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// Symbol is one entry of an archive symbol index: a symbol name and the
+// file offset of the header of the member that defines it
+type Symbol struct {
+	Name   string
+	Offset int64
+}
+
+// IndexName is the member name of the GNU symbol index
+const IndexName = "/"
+
+// maxIndexSymbols bounds the number of entries in a symbol index
+const maxIndexSymbols = 1000000
+
+// ObjectSymbols returns the names an ELF object contributes to the symbol
+// index: its defined global and weak symbols, including common symbols.
+// Data that is not an ELF object contributes nothing.
+func ObjectSymbols(data []byte) []string {
+	if len(data) < 4 || string(data[:4]) != "\x7fELF" {
+		return nil
+	}
+
+	elfFile, err := elf.ParseELF(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	names := []string{}
+	for i, sym := range elfFile.Symbols {
+		binding := sym.Info >> 4
+		symType := sym.Info & 0x0f
+		if i == 0 || sym.Name == "" || sym.Shndx == elf.SHN_UNDEF {
+			continue
+		}
+		if binding != 1 && binding != 2 && binding != 10 { // STB_GLOBAL, STB_WEAK, STB_GNU_UNIQUE
+			continue
+		}
+		if symType == 3 || symType == 4 { // STT_SECTION, STT_FILE
+			continue
+		}
+		names = append(names, sym.Name)
+	}
+	return names
+}
+
+// IndexSize returns the size of the encoded index for the given symbols of
+// each member, including the padding to an even size
+func IndexSize(symbols [][]string) int64 {
+	size := int64(4)
+	for _, names := range symbols {
+		for _, name := range names {
+			size += 4 + int64(len(name)) + 1
+		}
+	}
+	return size + size%2
+}
+
+// EncodeIndex encodes a GNU symbol index: a big-endian symbol count, the
+// big-endian header offset of the defining member of each symbol, then the
+// NUL-terminated names. symbols[i] holds the symbols defined by the member
+// whose header is at memberOffsets[i] in the final archive.
+func EncodeIndex(symbols [][]string, memberOffsets []int64) ([]byte, error) {
+	if len(symbols) != len(memberOffsets) {
+		return nil, fmt.Errorf("got %d symbol lists for %d members", len(symbols), len(memberOffsets))
+	}
+
+	count := 0
+	for _, names := range symbols {
+		count += len(names)
+	}
+	// Secure: limit symbol count
+	if count > maxIndexSymbols {
+		return nil, fmt.Errorf("too many symbols for archive index: %d", count)
+	}
+
+	data := make([]byte, 4, IndexSize(symbols))
+	binary.BigEndian.PutUint32(data, uint32(count))
+	for i, names := range symbols {
+		if memberOffsets[i] > 0xffffffff {
+			return nil, fmt.Errorf("archive too large for a 32-bit symbol index")
+		}
+		for range names {
+			data = binary.BigEndian.AppendUint32(data, uint32(memberOffsets[i]))
+		}
+	}
+	for _, names := range symbols {
+		for _, name := range names {
+			data = append(data, name...)
+			data = append(data, 0)
+		}
+	}
+	if len(data)%2 != 0 {
+		data = append(data, 0)
+	}
+	return data, nil
+}
+
+// DecodeIndex parses the contents of a GNU symbol index member
+func DecodeIndex(data []byte) ([]Symbol, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("symbol index too short")
+	}
+
+	count := int(binary.BigEndian.Uint32(data))
+	// Secure: the offsets must fit in the member
+	if count > maxIndexSymbols || 4+4*count > len(data) {
+		return nil, fmt.Errorf("invalid symbol count: %d", count)
+	}
+
+	symbols := make([]Symbol, count)
+	names := data[4+4*count:]
+	for i := range symbols {
+		symbols[i].Offset = int64(binary.BigEndian.Uint32(data[4+4*i:]))
+		end := bytes.IndexByte(names, 0)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated symbol name")
+		}
+		symbols[i].Name = string(names[:end])
+		names = names[end+1:]
+	}
+	return symbols, nil
+}
@@ -0,0 +1,41 @@
+package arfile
+
+import "testing"
+
+// TestIndexRoundTrip tests that an encoded index decodes to the same symbols
+func TestIndexRoundTrip(t *testing.T) {
+	symbols := [][]string{{"foo", "bar"}, nil, {"longer_function_name"}}
+	offsets := []int64{100, 200, 300}
+
+	data, err := EncodeIndex(symbols, offsets)
+	if err != nil {
+		t.Fatalf("EncodeIndex failed: %v", err)
+	}
+	if int64(len(data)) != IndexSize(symbols) {
+		t.Errorf("Expected size %d, got %d", IndexSize(symbols), len(data))
+	}
+	if len(data)%2 != 0 {
+		t.Errorf("Index size %d is not even", len(data))
+	}
+
+	decoded, err := DecodeIndex(data)
+	if err != nil {
+		t.Fatalf("DecodeIndex failed: %v", err)
+	}
+	expected := []Symbol{{"foo", 100}, {"bar", 100}, {"longer_function_name", 300}}
+	if len(decoded) != len(expected) {
+		t.Fatalf("Expected %d symbols, got %d", len(expected), len(decoded))
+	}
+	for i, sym := range expected {
+		if decoded[i] != sym {
+			t.Errorf("Symbol %d: expected %v, got %v", i, sym, decoded[i])
+		}
+	}
+}
+
+// TestEncodeIndexMismatch tests that offsets must match the member count
+func TestEncodeIndexMismatch(t *testing.T) {
+	if _, err := EncodeIndex([][]string{{"foo"}}, nil); err == nil {
+		t.Error("Expected error for mismatched offsets")
+	}
+}
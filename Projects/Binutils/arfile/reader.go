@@ -0,0 +1,227 @@
+package arfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// Magic is the signature at the start of every archive
+const Magic = "!<arch>\n"
+
+// headerSize is the size of a member header
+const headerSize = 60
+
+// MaxMemberSize bounds the size of a single member
+const MaxMemberSize = 100 * 1024 * 1024
+
+// Header is the metadata of one archive member
+type Header struct {
+	Name   string // Member name, with GNU and BSD extended names resolved
+	Date   int64
+	UID    int
+	GID    int
+	Mode   int
+	Size   int64
+	Offset int64 // File offset of the member header, as stored in the symbol index
+}
+
+// Reader reads the members of an archive in order. The symbol index and
+// the GNU extended-name table are consumed by Next and are not returned as
+// members; the index is available from Symbols.
+type Reader struct {
+	r         *bufio.Reader
+	offset    int64 // File offset of the next unread byte
+	remaining int64 // Unread bytes of the current member
+	pad       int64 // Padding after the current member
+	longNames []byte
+	symbols   []Symbol
+}
+
+// NewReader checks the archive signature and returns a reader positioned
+// before the first member
+func NewReader(r io.Reader) (*Reader, error) {
+	ar := &Reader{r: bufio.NewReader(r)}
+
+	magic := make([]byte, len(Magic))
+	if _, err := io.ReadFull(ar.r, magic); err != nil {
+		return nil, fmt.Errorf("failed to read magic: %w", err)
+	}
+	if string(magic) != Magic {
+		return nil, fmt.Errorf("invalid archive magic")
+	}
+	ar.offset = int64(len(Magic))
+
+	return ar, nil
+}
+
+// Symbols returns the GNU symbol index. The index is the first member, so it
+// is available once Next has been called.
+func (ar *Reader) Symbols() []Symbol {
+	return ar.symbols
+}
+
+// Next advances to the next member and returns its header. It returns
+// io.EOF at the end of the archive.
+func (ar *Reader) Next() (*Header, error) {
+	for {
+		// Skip what is left of the previous member
+		unread := ar.remaining + ar.pad
+		ar.remaining, ar.pad = 0, 0
+		if err := ar.skip(unread); err != nil {
+			return nil, err
+		}
+
+		headerBytes := make([]byte, headerSize)
+		if _, err := io.ReadFull(ar.r, headerBytes); err == io.EOF {
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		if string(headerBytes[58:60]) != "`\n" {
+			return nil, fmt.Errorf("invalid member header at offset %d", ar.offset)
+		}
+
+		header := &Header{Offset: ar.offset}
+		ar.offset += headerSize
+
+		rawName := strings.TrimRight(string(headerBytes[0:16]), " ")
+		header.Date, _ = strconv.ParseInt(field(headerBytes[16:28]), 10, 64)
+		header.UID, _ = strconv.Atoi(field(headerBytes[28:34]))
+		header.GID, _ = strconv.Atoi(field(headerBytes[34:40]))
+		mode, _ := strconv.ParseInt(field(headerBytes[40:48]), 8, 32)
+		header.Mode = int(mode)
+		size, err := strconv.ParseInt(field(headerBytes[48:58]), 10, 64)
+
+		// Secure: validate size
+		if err != nil || size < 0 || size > MaxMemberSize {
+			return nil, fmt.Errorf("invalid member size: %q", field(headerBytes[48:58]))
+		}
+		header.Size = size
+		ar.remaining = size
+		ar.pad = size % 2
+
+		switch {
+		case rawName == IndexName || rawName == "/SYM64/":
+			data, err := ar.readAll()
+			if err != nil {
+				return nil, err
+			}
+			if rawName == IndexName {
+				if ar.symbols, err = DecodeIndex(data); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		case rawName == "__.SYMDEF" || rawName == "__.SYMDEF SORTED":
+			continue // BSD symbol index
+		case rawName == "//":
+			if ar.longNames, err = ar.readAll(); err != nil {
+				return nil, err
+			}
+			continue
+		case strings.HasPrefix(rawName, "#1/"):
+			// BSD: the name is stored at the start of the data
+			n, err := strconv.Atoi(rawName[3:])
+			if err != nil || n < 0 || int64(n) > size {
+				return nil, fmt.Errorf("invalid BSD member name: %s", rawName)
+			}
+			name := make([]byte, n)
+			if _, err := io.ReadFull(ar, name); err != nil {
+				return nil, fmt.Errorf("failed to read member name: %w", err)
+			}
+			header.Name = strings.TrimRight(string(name), "\x00")
+			header.Size -= int64(n)
+		case len(rawName) > 1 && rawName[0] == '/':
+			if header.Name, err = ar.longName(rawName[1:]); err != nil {
+				return nil, err
+			}
+		default:
+			header.Name = strings.TrimSuffix(rawName, "/")
+		}
+
+		return header, nil
+	}
+}
+
+// Members returns an iterator over the member headers, for use in a range
+// loop. The data of each member can be read from the reader while the loop
+// body runs. Iteration stops after the first error, which is yielded.
+func (ar *Reader) Members() iter.Seq2[*Header, error] {
+	return func(yield func(*Header, error) bool) {
+		for {
+			h, err := ar.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(h, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Read reads from the data of the current member
+func (ar *Reader) Read(p []byte) (int, error) {
+	if ar.remaining == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > ar.remaining {
+		p = p[:ar.remaining]
+	}
+	n, err := ar.r.Read(p)
+	ar.remaining -= int64(n)
+	ar.offset += int64(n)
+	if err == io.EOF && ar.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// readAll reads the rest of the current member
+func (ar *Reader) readAll() ([]byte, error) {
+	data := make([]byte, ar.remaining)
+	if _, err := io.ReadFull(ar, data); err != nil {
+		return nil, fmt.Errorf("failed to read member data: %w", err)
+	}
+	return data, nil
+}
+
+// skip discards n bytes
+func (ar *Reader) skip(n int64) error {
+	for n > 0 {
+		discarded, err := ar.r.Discard(int(min(n, 1<<30)))
+		n -= int64(discarded)
+		ar.offset += int64(discarded)
+		if err == io.EOF && n <= 1 {
+			return nil // The padding byte after the last member may be missing
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read member data: %w", err)
+		}
+	}
+	return nil
+}
+
+// longName resolves a GNU "/offset" name in the extended-name table, where
+// each name ends with "/\n"
+func (ar *Reader) longName(offsetText string) (string, error) {
+	offset, err := strconv.Atoi(offsetText)
+	if err != nil || offset < 0 || offset >= len(ar.longNames) {
+		return "", fmt.Errorf("invalid extended name offset: /%s", offsetText)
+	}
+	name := ar.longNames[offset:]
+	if end := bytes.IndexByte(name, '\n'); end >= 0 {
+		name = name[:end]
+	}
+	return strings.TrimSuffix(string(name), "/"), nil
+}
+
+// field returns a header field without its space padding
+func field(b []byte) string {
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,85 @@
+package arfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// member formats a member header and its padded data
+func member(name string, data string) string {
+	header := fmt.Sprintf("%-16s%-12s%-6s%-6s%-8s%-10d`\n", name, "0", "0", "0", "644", len(data))
+	if len(data)%2 != 0 {
+		data += "\n"
+	}
+	return header + data
+}
+
+// TestReader tests that names are resolved, special members are consumed
+// and header offsets match the symbol index
+func TestReader(t *testing.T) {
+	longName := "a_member_name_longer_than_sixteen.o"
+	names := longName + "/\n"
+
+	// The index points at the second regular member; odd sizes are padded
+	padded := func(n int64) int64 { return n + n%2 }
+	indexSize := IndexSize([][]string{{"sym"}})
+	second := int64(len(Magic)) + 60 + padded(indexSize) + 60 + padded(int64(len(names))) + 60 + padded(5)
+	index, err := EncodeIndex([][]string{{"sym"}}, []int64{second})
+	if err != nil {
+		t.Fatalf("EncodeIndex failed: %v", err)
+	}
+
+	archive := Magic + member(IndexName, string(index)) + member("//", names) +
+		member("short.o/", "abcde") + member("/0", "xyz") + member("#1/8", "bsd.o\x00\x00\x00data")
+
+	r, err := NewReader(bytes.NewReader([]byte(archive)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	expected := []struct {
+		name string
+		data string
+	}{{"short.o", "abcde"}, {longName, "xyz"}, {"bsd.o", "data"}}
+	for i, want := range expected {
+		h, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next %d failed: %v", i, err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Read %d failed: %v", i, err)
+		}
+		if h.Name != want.name || string(data) != want.data || h.Size != int64(len(want.data)) {
+			t.Errorf("Member %d: got %q %q (size %d), want %q %q", i, h.Name, data, h.Size, want.name, want.data)
+		}
+		if i == 1 && h.Offset != second {
+			t.Errorf("Member offset %d, want %d", h.Offset, second)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+
+	symbols := r.Symbols()
+	if len(symbols) != 1 || symbols[0].Name != "sym" || symbols[0].Offset != second {
+		t.Errorf("Unexpected symbols: %v", symbols)
+	}
+}
+
+// TestReaderInvalid tests that malformed archives are rejected
+func TestReaderInvalid(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader([]byte("not an archive"))); err == nil {
+		t.Error("Expected error for bad magic")
+	}
+
+	r, err := NewReader(bytes.NewReader([]byte(Magic + member("/99", "x"))))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if _, err := r.Next(); err == nil {
+		t.Error("Expected error for a missing extended name")
+	}
+}
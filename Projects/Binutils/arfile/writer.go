@@ -0,0 +1,252 @@
+package arfile
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxShortName is the longest member name stored in the header itself; the
+// header holds 16 bytes and GNU names end with a slash
+const maxShortName = 15
+
+// Member is an archive member held in memory
+type Member struct {
+	Header Header
+	Data   []byte
+}
+
+// Writer writes archive members in order. Each member is started with
+// WriteHeader and its data written with Write; the padding to an even
+// offset is added when the next member starts or the writer is closed.
+type Writer struct {
+	w         io.Writer
+	offset    int64 // File offset of the next byte to write
+	remaining int64 // Unwritten bytes of the current member
+	pad       int64 // Padding owed after the current member
+	longNames map[string]int
+}
+
+// NewWriter writes the archive signature and returns a writer positioned
+// before the first member
+func NewWriter(w io.Writer) (*Writer, error) {
+	if _, err := io.WriteString(w, Magic); err != nil {
+		return nil, fmt.Errorf("failed to write magic: %w", err)
+	}
+	return &Writer{w: w, offset: int64(len(Magic))}, nil
+}
+
+// Offset returns the file offset at which the next member header will be
+// written
+func (aw *Writer) Offset() int64 {
+	return aw.offset + aw.remaining + aw.pad
+}
+
+// WriteNames writes the GNU "//" extended-name member holding every name
+// too long for a member header. It must come before the members that use
+// it; names that fit in the header are ignored.
+func (aw *Writer) WriteNames(names []string) error {
+	table := NamesTable(names)
+	if len(table) == 0 {
+		return nil
+	}
+
+	aw.longNames = make(map[string]int)
+	offset := 0
+	for _, name := range names {
+		if len(name) > maxShortName {
+			if _, ok := aw.longNames[name]; !ok {
+				aw.longNames[name] = offset
+				offset += len(name) + 2
+			}
+		}
+	}
+
+	return aw.WriteMember(&Header{Name: "//", Size: int64(len(table))}, table)
+}
+
+// WriteHeader finishes the current member and writes the header of the
+// next one. The symbol index and the "//" table are written with their
+// special names; other names get the GNU trailing slash, or point into the
+// extended-name table when they are too long for the header.
+func (aw *Writer) WriteHeader(h *Header) error {
+	if aw.remaining > 0 {
+		return fmt.Errorf("member written short: %d bytes missing", aw.remaining)
+	}
+	if err := aw.writePadding(); err != nil {
+		return err
+	}
+	// Secure: validate size
+	if h.Size < 0 || h.Size > MaxMemberSize {
+		return fmt.Errorf("invalid member size: %d", h.Size)
+	}
+
+	name := h.Name
+	special := name == "//" || name == "/SYM64/"
+	switch {
+	case name == IndexName || special:
+	case len(name) > maxShortName:
+		offset, ok := aw.longNames[name]
+		if !ok {
+			return fmt.Errorf("member name not in extended-name table: %s", name)
+		}
+		name = "/" + strconv.Itoa(offset)
+	default:
+		name += "/"
+	}
+
+	header := make([]byte, 0, headerSize)
+	header = appendField(header, name, 16)
+	if special {
+		header = appendField(header, "", 32) // The "//" table has no metadata
+	} else {
+		header = appendField(header, strconv.FormatInt(h.Date, 10), 12)
+		header = appendField(header, strconv.Itoa(h.UID), 6)
+		header = appendField(header, strconv.Itoa(h.GID), 6)
+		header = appendField(header, strconv.FormatInt(int64(h.Mode), 8), 8)
+	}
+	header = appendField(header, strconv.FormatInt(h.Size, 10), 10)
+	// Secure: a value too wide for its field would shift the rest
+	if len(header) != headerSize-2 {
+		return fmt.Errorf("header field too wide for member %s", h.Name)
+	}
+	header = append(header, "`\n"...)
+
+	if _, err := aw.w.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	aw.offset += headerSize
+	aw.remaining = h.Size
+	aw.pad = h.Size % 2
+	return nil
+}
+
+// Write writes data of the current member. Writing more than the size
+// given in its header is an error.
+func (aw *Writer) Write(p []byte) (int, error) {
+	if int64(len(p)) > aw.remaining {
+		return 0, fmt.Errorf("write exceeds member size by %d bytes", int64(len(p))-aw.remaining)
+	}
+	n, err := aw.w.Write(p)
+	aw.remaining -= int64(n)
+	aw.offset += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("failed to write data: %w", err)
+	}
+	return n, nil
+}
+
+// WriteMember writes a header and all of its data
+func (aw *Writer) WriteMember(h *Header, data []byte) error {
+	header := *h
+	header.Size = int64(len(data))
+	if err := aw.WriteHeader(&header); err != nil {
+		return err
+	}
+	_, err := aw.Write(data)
+	return err
+}
+
+// Close finishes the last member. It does not close the underlying writer.
+func (aw *Writer) Close() error {
+	if aw.remaining > 0 {
+		return fmt.Errorf("member written short: %d bytes missing", aw.remaining)
+	}
+	return aw.writePadding()
+}
+
+// writePadding writes the newline that aligns the next member
+func (aw *Writer) writePadding() error {
+	if aw.pad == 0 {
+		return nil
+	}
+	if _, err := aw.w.Write([]byte{'\n'}); err != nil {
+		return fmt.Errorf("failed to write padding: %w", err)
+	}
+	aw.offset += aw.pad
+	aw.pad = 0
+	return nil
+}
+
+// NamesTable returns the contents of the "//" member for the given member
+// names: each name too long for a header, ending with "/\n", padded to an
+// even size. It is empty when every name fits in a header.
+func NamesTable(names []string) []byte {
+	var table []byte
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if len(name) > maxShortName && !seen[name] {
+			seen[name] = true
+			table = append(table, name+"/\n"...)
+		}
+	}
+	if len(table)%2 != 0 {
+		table = append(table, '\n')
+	}
+	return table
+}
+
+// WriteArchive writes a complete archive in GNU format: the symbol index of
+// the object members when any defines symbols, the extended-name table when
+// a name needs it, then the members in order
+func WriteArchive(w io.Writer, members []*Member) error {
+	names := make([]string, len(members))
+	symbols := make([][]string, len(members))
+	hasSymbols := false
+	for i, member := range members {
+		names[i] = member.Header.Name
+		symbols[i] = ObjectSymbols(member.Data)
+		hasSymbols = hasSymbols || len(symbols[i]) > 0
+	}
+	table := NamesTable(names)
+
+	// Lay out the archive to find the member offsets the index points to
+	offset := int64(len(Magic))
+	if hasSymbols {
+		offset += headerSize + IndexSize(symbols)
+	}
+	if len(table) > 0 {
+		offset += headerSize + int64(len(table))
+	}
+	offsets := make([]int64, len(members))
+	for i, member := range members {
+		offsets[i] = offset
+		offset += headerSize + int64(len(member.Data)) + int64(len(member.Data)%2)
+	}
+
+	aw, err := NewWriter(w)
+	if err != nil {
+		return err
+	}
+	if hasSymbols {
+		index, err := EncodeIndex(symbols, offsets)
+		if err != nil {
+			return err
+		}
+		if err := aw.WriteMember(&Header{Name: IndexName}, index); err != nil {
+			return err
+		}
+	}
+	if err := aw.WriteNames(names); err != nil {
+		return err
+	}
+	for i, member := range members {
+		if aw.Offset() != offsets[i] {
+			return fmt.Errorf("member %s at offset %d, expected %d", member.Header.Name, aw.Offset(), offsets[i])
+		}
+		if err := aw.WriteMember(&member.Header, member.Data); err != nil {
+			return err
+		}
+	}
+	return aw.Close()
+}
+
+// appendField appends s left-aligned in a space-padded field of the given
+// width
+func appendField(b []byte, s string, width int) []byte {
+	b = append(b, s...)
+	for i := len(s); i < width; i++ {
+		b = append(b, ' ')
+	}
+	return b
+}
@@ -0,0 +1,80 @@
+package arfile
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// TestWriteArchiveRoundTrip tests that archives written by WriteArchive read
+// back with the same names, metadata and data
+func TestWriteArchiveRoundTrip(t *testing.T) {
+	members := []*Member{
+		{Header: Header{Name: "short.o", Date: 1700000000, UID: 1000, GID: 100, Mode: 0644}, Data: []byte("odd")},
+		{Header: Header{Name: "a_member_name_longer_than_sixteen.o", Mode: 0600}, Data: []byte("even")},
+		{Header: Header{Name: "another_long_member_name.txt", Mode: 0755}, Data: []byte("x")},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, members); err != nil {
+		t.Fatalf("WriteArchive failed: %v", err)
+	}
+	if buf.Len()%2 != 0 {
+		t.Errorf("Archive size %d is not even", buf.Len())
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	i := 0
+	for h, err := range r.Members() {
+		if err != nil {
+			t.Fatalf("Members failed: %v", err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		want := members[i].Header
+		want.Size, want.Offset = int64(len(members[i].Data)), h.Offset
+		if *h != want || !bytes.Equal(data, members[i].Data) {
+			t.Errorf("Member %d: got %+v %q, want %+v %q", i, *h, data, want, members[i].Data)
+		}
+		i++
+	}
+	if i != len(members) {
+		t.Errorf("Read %d members, want %d", i, len(members))
+	}
+	if r.Symbols() != nil {
+		t.Errorf("Expected no symbol index for non-object members")
+	}
+}
+
+// TestWriterErrors tests that the writer rejects inconsistent members
+func TestWriterErrors(t *testing.T) {
+	aw, err := NewWriter(io.Discard)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+
+	if err := aw.WriteHeader(&Header{Name: "a_member_name_longer_than_sixteen.o"}); err == nil {
+		t.Error("Expected error for a long name without an extended-name table")
+	}
+	if err := aw.WriteHeader(&Header{Name: "big.o", UID: 12345678}); err == nil {
+		t.Error("Expected error for a field too wide for the header")
+	}
+
+	if err := aw.WriteHeader(&Header{Name: "a.o", Size: 2}); err != nil {
+		t.Fatalf("WriteHeader failed: %v", err)
+	}
+	if _, err := aw.Write([]byte("abc")); err == nil {
+		t.Error("Expected error for data beyond the member size")
+	}
+	if _, err := aw.Write([]byte("a")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := aw.Close(); err == nil {
+		t.Error("Expected error for a member written short")
+	}
+}
@@ -0,0 +1,377 @@
+// Package binfile opens object files and executables in any supported
+// format - ELF, Mach-O or PE/COFF - and presents their sections and symbols
+// in one format-independent view, so that inspection tools need not know
+// which format they are reading.
+package binfile
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"hellogolang/Projects/Binutils/elf"
+	"hellogolang/Projects/Binutils/filetype"
+	"hellogolang/Projects/Binutils/macho"
+	"hellogolang/Projects/Binutils/pe"
+)
+
+// Formats returned by Detect and recorded in File
+const (
+	FormatELF   = "ELF"
+	FormatMachO = "Mach-O"
+	FormatPE    = "PE/COFF"
+)
+
+// File is an object file or executable of any supported format. The
+// format-specific parse is kept in exactly one of ELF, MachO and PE.
+type File struct {
+	Format   string
+	Class    string // Word size variant, e.g. "ELF64", "MachO64" or "PE32+"
+	Type     string // Format-specific file type, e.g. "ET_EXEC" or "MH_OBJECT"
+	Machine  string
+	Entry    uint64
+	Sections []Section
+	Symbols  []Symbol
+
+	ELF   *elf.ELF
+	MachO *macho.MachO
+	PE    *pe.PE
+
+	closer io.Closer
+}
+
+// Section is a section of any format. Kind classifies the contents with the
+// letters nm uses: 'T' code, 'D' writable data, 'R' read-only data, 'B'
+// uninitialized data and 'N' anything not loaded into memory.
+type Section struct {
+	Name   string
+	Addr   uint64
+	Size   uint64
+	Offset uint64
+	Kind   byte
+
+	readAll func(limit uint64) ([]byte, error)
+}
+
+// Symbol is a symbol of any format. Kind is the nm symbol type letter,
+// lower case for local symbols; Section is empty for undefined, absolute
+// and common symbols.
+type Symbol struct {
+	Name    string
+	Value   uint64 // Address, or size for common symbols
+	Section string
+	Kind    byte
+}
+
+// Detect returns the format of a file from its first bytes, or "" if it is
+// not a supported format
+func Detect(header []byte) string {
+	if f := filetype.Detect(header); f != nil {
+		switch f.Name {
+		case "ELF":
+			return FormatELF
+		case "Mach-O", "Mach-O universal":
+			return FormatMachO
+		case "PE":
+			return FormatPE
+		}
+		return ""
+	}
+
+	// COFF object files start directly with the machine type
+	if len(header) >= 4 && pe.IsCOFFMachine(binary.LittleEndian.Uint16(header)) {
+		return FormatPE
+	}
+	return ""
+}
+
+// Open opens and parses a file, detecting its format. The file stays open
+// for reading section contents until Close is called.
+func Open(name string) (*File, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := NewFile(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f.closer = file
+	return f, nil
+}
+
+// NewFile parses a file from r, detecting its format. Section contents are
+// read from r on demand.
+func NewFile(r io.ReadSeeker) (*File, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	header := make([]byte, filetype.HeaderSize)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	header = header[:n]
+	switch Detect(header) {
+	case FormatELF:
+		elfFile, err := elf.ParseELF(r)
+		if err != nil {
+			return nil, err
+		}
+		return fromELF(elfFile), nil
+	case FormatMachO:
+		machoFile, err := macho.ParseMachO(r)
+		if err != nil {
+			return nil, err
+		}
+		return fromMachO(machoFile), nil
+	case FormatPE:
+		peFile, err := pe.ParsePE(r)
+		if err != nil {
+			return nil, err
+		}
+		return fromPE(peFile), nil
+	}
+	if f := filetype.Detect(header); f != nil {
+		return nil, fmt.Errorf("file format not recognized: %s is not an object file", f.Name)
+	}
+	return nil, fmt.Errorf("file format not recognized")
+}
+
+// Close closes the file opened by Open
+func (f *File) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+// Section returns the section with the given name, or nil
+func (f *File) Section(name string) *Section {
+	for i := range f.Sections {
+		if f.Sections[i].Name == name {
+			return &f.Sections[i]
+		}
+	}
+	return nil
+}
+
+// ReadAll reads the contents of the section. limit bounds the section size
+// so that corrupt headers cannot force huge allocations. Uninitialized
+// sections read as nil.
+func (s *Section) ReadAll(limit uint64) ([]byte, error) {
+	if s.readAll == nil {
+		return nil, nil
+	}
+	return s.readAll(limit)
+}
+
+// fromELF builds the common view of an ELF file
+func fromELF(e *elf.ELF) *File {
+	f := &File{Format: FormatELF, Class: e.Class, Type: e.Type, Machine: e.Machine, Entry: e.Entry, ELF: e}
+
+	kinds := make([]byte, len(e.Sections))
+	for i := range e.Sections {
+		section := &e.Sections[i]
+		switch {
+		case section.Flags&elf.SHF_ALLOC == 0:
+			kinds[i] = 'N'
+		case section.Flags&elf.SHF_EXECINSTR != 0:
+			kinds[i] = 'T'
+		case section.Type == elf.SHT_NOBITS:
+			kinds[i] = 'B'
+		case section.Flags&elf.SHF_WRITE != 0:
+			kinds[i] = 'D'
+		default:
+			kinds[i] = 'R'
+		}
+		if i == 0 {
+			continue // The null section
+		}
+		f.Sections = append(f.Sections, Section{
+			Name:    section.Name,
+			Addr:    section.Addr,
+			Size:    section.Size,
+			Offset:  section.Offset,
+			Kind:    kinds[i],
+			readAll: section.ReadAll,
+		})
+	}
+
+	for i, sym := range e.Symbols {
+		symType := sym.Info & 0x0f
+		if i == 0 || symType == 3 || symType == 4 { // STT_SECTION, STT_FILE
+			continue
+		}
+		binding := sym.Info >> 4
+		symbol := Symbol{Name: sym.Name, Value: sym.Value}
+		switch {
+		case sym.Shndx == elf.SHN_UNDEF:
+			symbol.Kind = 'U'
+			if binding == 2 { // STB_WEAK
+				symbol.Kind = 'w'
+			}
+		case sym.Shndx == elf.SHN_ABS:
+			symbol.Kind = 'A'
+		case sym.Shndx == elf.SHN_COMMON:
+			symbol.Kind, symbol.Value = 'C', sym.Size
+		case int(sym.Shndx) < len(e.Sections):
+			symbol.Section = e.Sections[sym.Shndx].Name
+			symbol.Kind = kinds[sym.Shndx]
+			if binding == 2 { // STB_WEAK
+				symbol.Kind = 'W'
+			}
+		default:
+			symbol.Kind = '?'
+		}
+		if binding == 0 { // STB_LOCAL
+			symbol.Kind = toLower(symbol.Kind)
+		}
+		f.Symbols = append(f.Symbols, symbol)
+	}
+	return f
+}
+
+// fromMachO builds the common view of a Mach-O file. Section names are
+// written "segment,section" as Apple's tools do.
+func fromMachO(m *macho.MachO) *File {
+	f := &File{Format: FormatMachO, Class: m.Class, Type: m.Type, Machine: m.Machine, Entry: m.Entry, MachO: m}
+
+	for i := range m.Sections {
+		section := &m.Sections[i]
+		kind := byte('D')
+		switch {
+		case section.Segment == "__DWARF":
+			kind = 'N'
+		case section.IsCode():
+			kind = 'T'
+		case section.IsZeroFill():
+			kind = 'B'
+		case section.Segment == "__TEXT" || section.Segment == "__DATA_CONST":
+			kind = 'R'
+		}
+		f.Sections = append(f.Sections, Section{
+			Name:    section.Segment + "," + section.Name,
+			Addr:    section.Addr,
+			Size:    section.Size,
+			Offset:  uint64(section.Offset),
+			Kind:    kind,
+			readAll: section.ReadAll,
+		})
+	}
+
+	for _, sym := range m.Symbols {
+		if sym.Type&macho.N_STAB != 0 {
+			continue // Debugging entry
+		}
+		symbol := Symbol{Name: sym.Name, Value: sym.Value}
+		switch sym.Type & macho.N_TYPE {
+		case macho.N_UNDF, macho.N_PBUD:
+			symbol.Kind = 'U'
+			if sym.Value != 0 && sym.Type&macho.N_EXT != 0 {
+				symbol.Kind = 'C'
+			}
+		case macho.N_ABS:
+			symbol.Kind = 'A'
+		case macho.N_INDR:
+			symbol.Kind = 'I'
+		case macho.N_SECT:
+			if n := int(sym.Sect); n >= 1 && n <= len(f.Sections) {
+				symbol.Section = f.Sections[n-1].Name
+				symbol.Kind = f.Sections[n-1].Kind
+			} else {
+				symbol.Kind = '?'
+			}
+		default:
+			symbol.Kind = '?'
+		}
+		if sym.Type&macho.N_EXT == 0 {
+			symbol.Kind = toLower(symbol.Kind)
+		}
+		f.Symbols = append(f.Symbols, symbol)
+	}
+	return f
+}
+
+// fromPE builds the common view of a PE image or COFF object. Addresses
+// include the image base, as the loaded program sees them.
+func fromPE(p *pe.PE) *File {
+	f := &File{Format: FormatPE, Class: p.Class, Type: p.Type, Machine: p.Machine, Entry: p.Entry, PE: p}
+
+	var imageBase uint64
+	if p.OptionalHeader != nil {
+		imageBase = p.OptionalHeader.ImageBase
+	}
+	for i := range p.Sections {
+		section := &p.Sections[i]
+		c := section.Characteristics
+		kind := byte('R')
+		switch {
+		case c&pe.IMAGE_SCN_MEM_DISCARDABLE != 0 || strings.HasPrefix(section.Name, ".debug"):
+			kind = 'N'
+		case c&(pe.IMAGE_SCN_CNT_CODE|pe.IMAGE_SCN_MEM_EXECUTE) != 0:
+			kind = 'T'
+		case c&pe.IMAGE_SCN_CNT_UNINITIALIZED_DATA != 0:
+			kind = 'B'
+		case c&pe.IMAGE_SCN_MEM_WRITE != 0:
+			kind = 'D'
+		}
+		size := uint64(section.VirtualSize)
+		if size == 0 {
+			size = uint64(section.SizeOfRawData) // Object files leave VirtualSize zero
+		}
+		f.Sections = append(f.Sections, Section{
+			Name:    section.Name,
+			Addr:    imageBase + uint64(section.VirtualAddress),
+			Size:    size,
+			Offset:  uint64(section.PointerToRawData),
+			Kind:    kind,
+			readAll: section.ReadAll,
+		})
+	}
+
+	for _, sym := range p.Symbols {
+		if sym.StorageClass == pe.IMAGE_SYM_CLASS_FILE || sym.SectionNumber == pe.IMAGE_SYM_DEBUG {
+			continue
+		}
+		symbol := Symbol{Name: sym.Name, Value: uint64(sym.Value)}
+		switch {
+		case sym.SectionNumber == pe.IMAGE_SYM_UNDEFINED:
+			symbol.Kind = 'U'
+			if sym.Value != 0 {
+				symbol.Kind = 'C' // Common symbols record their size
+			}
+		case sym.SectionNumber == pe.IMAGE_SYM_ABSOLUTE:
+			symbol.Kind = 'A'
+		case sym.SectionNumber > 0 && int(sym.SectionNumber) <= len(f.Sections):
+			section := &f.Sections[sym.SectionNumber-1]
+			symbol.Section = section.Name
+			symbol.Kind = section.Kind
+			symbol.Value += section.Addr
+		default:
+			symbol.Kind = '?'
+		}
+		switch sym.StorageClass {
+		case pe.IMAGE_SYM_CLASS_EXTERNAL:
+		case pe.IMAGE_SYM_CLASS_WEAK_EXTERNAL:
+			symbol.Kind = 'w'
+		default:
+			symbol.Kind = toLower(symbol.Kind)
+		}
+		f.Symbols = append(f.Symbols, symbol)
+	}
+	return f
+}
+
+// toLower returns the lower-case form of a symbol type letter
+func toLower(kind byte) byte {
+	if kind >= 'A' && kind <= 'Z' {
+		return kind + 'a' - 'A'
+	}
+	return kind
+}
@@ -0,0 +1,74 @@
+package binfile
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestDetect tests format detection from the first bytes of a file
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"\x7fELF\x02\x01\x01\x00", FormatELF},
+		{"\xcf\xfa\xed\xfe\x07\x00\x00\x01", FormatMachO}, // 64-bit little-endian
+		{"\xfe\xed\xfa\xce\x00\x00\x00\x12", FormatMachO}, // 32-bit big-endian
+		{"\xca\xfe\xba\xbe\x00\x00\x00\x02", FormatMachO}, // Universal, two slices
+		{"\xca\xfe\xba\xbe\x00\x00\x00\x34", ""},          // Java class file
+		{"MZ\x90\x00\x03\x00\x00\x00", FormatPE},
+		{"\x64\x86\x03\x00\x00\x00\x00\x00", FormatPE}, // x86-64 COFF object
+		{"#!/bin/sh", ""},
+		{"\x7fEL", ""},
+	}
+	for _, tt := range tests {
+		if got := Detect([]byte(tt.header)); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+// TestOpenExecutable opens the running test binary, which is ELF, Mach-O
+// or PE depending on the platform
+func TestOpenExecutable(t *testing.T) {
+	name, err := os.Executable()
+	if err != nil {
+		t.Skipf("Executable path unavailable: %v", err)
+	}
+	f, err := Open(name)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	if (f.ELF != nil) != (f.Format == FormatELF) || (f.MachO != nil) != (f.Format == FormatMachO) || (f.PE != nil) != (f.Format == FormatPE) {
+		t.Errorf("Format %s does not match the parsed file", f.Format)
+	}
+
+	// The entry point lies in a code section with contents
+	var code *Section
+	for i := range f.Sections {
+		s := &f.Sections[i]
+		if s.Kind == 'T' && f.Entry >= s.Addr && f.Entry < s.Addr+s.Size {
+			code = s
+		}
+	}
+	if code == nil {
+		t.Fatalf("Entry point %#x is not in a code section", f.Entry)
+	}
+	if data, err := code.ReadAll(1 << 30); err != nil || len(data) == 0 {
+		t.Errorf("Failed to read %s: %v", code.Name, err)
+	}
+	if f.Section(code.Name) == nil {
+		t.Errorf("Section(%q) not found", code.Name)
+	}
+
+	if _, err := Open(os.DevNull); err == nil {
+		t.Error("Expected error for an empty file")
+	}
+	if _, err := NewFile(bytes.NewReader([]byte("!<arch>\n"))); err == nil || !strings.Contains(err.Error(), "ar is not an object file") {
+		t.Errorf("NewFile of an archive = %v, want its format named", err)
+	}
+}
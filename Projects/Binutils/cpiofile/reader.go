@@ -0,0 +1,214 @@
+package cpiofile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// ErrChecksum is returned when the data of a member of a crc archive does
+// not match the checksum in its header
+var ErrChecksum = errors.New("cpio data checksum mismatch")
+
+// Reader reads the members of a cpio archive in order. Hard links are
+// reported as stored: in newc, the data of a set of links is kept with the
+// last of them and the others have no data.
+type Reader struct {
+	r         *bufio.Reader
+	offset    int64 // File offset of the next unread byte
+	remaining int64 // Unread bytes of the current member
+	pad       int64 // Padding after the current member
+	checksum  uint32
+	wantSum   int64 // Checksum of the current member, or -1
+}
+
+// NewReader returns a reader positioned before the first member
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), wantSum: -1}
+}
+
+// Next advances to the next member and returns its header. It returns
+// io.EOF at the trailer, or at the end of the input between members.
+func (cr *Reader) Next() (*Header, error) {
+	if err := cr.skip(cr.remaining + cr.pad); err != nil {
+		return nil, err
+	}
+
+	magic := make([]byte, 6)
+	if _, err := io.ReadFull(cr.r, magic); err == io.EOF {
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	h := &Header{Offset: cr.offset}
+	var nameSize, check int64
+	var err error
+	switch string(magic) {
+	case MagicNewc, MagicCRC:
+		nameSize, check, err = cr.readNewc(h)
+	case MagicODC:
+		nameSize, err = cr.readODC(h)
+	default:
+		return nil, fmt.Errorf("invalid header magic %q at offset %d", magic, h.Offset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid header at offset %d: %w", h.Offset, err)
+	}
+
+	// Secure: validate the name size; it counts the terminating NUL
+	if nameSize < 1 || nameSize > MaxNameSize {
+		return nil, fmt.Errorf("invalid name size %d at offset %d", nameSize, h.Offset)
+	}
+	name := make([]byte, nameSize)
+	if _, err := io.ReadFull(cr.r, name); err != nil {
+		return nil, fmt.Errorf("failed to read member name: %w", err)
+	}
+	cr.offset += nameSize
+	h.Name = strings.TrimRight(string(name), "\x00")
+	if h.Name == "" {
+		return nil, fmt.Errorf("empty member name at offset %d", h.Offset)
+	}
+	if string(magic) != MagicODC {
+		if err := cr.skip(padding(cr.offset)); err != nil {
+			return nil, err
+		}
+	}
+	if h.Name == Trailer {
+		return nil, io.EOF
+	}
+
+	cr.remaining = h.Size
+	cr.pad = 0
+	if string(magic) != MagicODC {
+		cr.pad = padding(h.Size)
+	}
+	cr.checksum, cr.wantSum = 0, -1
+	if string(magic) == MagicCRC {
+		cr.wantSum = check
+	}
+
+	if h.Type == TypeSymlink {
+		// Secure: bound the link target held in memory
+		if h.Size > MaxNameSize {
+			return nil, fmt.Errorf("symbolic link target of %d bytes at offset %d", h.Size, h.Offset)
+		}
+		target := make([]byte, h.Size)
+		if _, err := io.ReadFull(cr, target); err != nil {
+			return nil, fmt.Errorf("failed to read link target: %w", err)
+		}
+		h.Linkname = string(target)
+		h.Size = 0
+	}
+	return h, nil
+}
+
+// readNewc reads the rest of a newc or crc header, returning its name size
+// and checksum
+func (cr *Reader) readNewc(h *Header) (int64, int64, error) {
+	b := make([]byte, newcHeaderSize-6)
+	if _, err := io.ReadFull(cr.r, b); err != nil {
+		return 0, 0, fmt.Errorf("failed to read header: %w", err)
+	}
+	cr.offset += newcHeaderSize
+
+	var mode, uid, gid, nlink, devMajor, devMinor, rdevMajor, rdevMinor, nameSize, check int64
+	fields := []*int64{&h.Ino, &mode, &uid, &gid, &nlink, &h.Date, &h.Size, &devMajor, &devMinor, &rdevMajor, &rdevMinor, &nameSize, &check}
+	if err := parseHex(b, fields); err != nil {
+		return 0, 0, err
+	}
+	h.Type, h.Mode = int(mode&typeMask), int(mode&0o7777)
+	h.UID, h.GID, h.Nlink = int(uid), int(gid), int(nlink)
+	h.Devmajor, h.Devminor = int(rdevMajor), int(rdevMinor)
+	return nameSize, check, nil
+}
+
+// readODC reads the rest of an odc header, returning its name size
+func (cr *Reader) readODC(h *Header) (int64, error) {
+	b := make([]byte, odcHeaderSize-6)
+	if _, err := io.ReadFull(cr.r, b); err != nil {
+		return 0, fmt.Errorf("failed to read header: %w", err)
+	}
+	cr.offset += odcHeaderSize
+
+	// dev, ino, mode, uid, gid, nlink, rdev, mtime, namesize, filesize
+	widths := []int{6, 6, 6, 6, 6, 6, 6, 11, 6, 11}
+	values := make([]int64, len(widths))
+	pos := 0
+	for i, width := range widths {
+		v, err := parseOctal(b[pos : pos+width])
+		if err != nil {
+			return 0, err
+		}
+		values[i] = v
+		pos += width
+	}
+	h.Ino = values[1]
+	h.Type, h.Mode = int(values[2]&typeMask), int(values[2]&0o7777)
+	h.UID, h.GID, h.Nlink = int(values[3]), int(values[4]), int(values[5])
+	// The old 16-bit device number has an 8-bit major
+	h.Devmajor, h.Devminor = int(values[6]>>8), int(values[6]&0xff)
+	h.Date, h.Size = values[7], values[9]
+	return values[8], nil
+}
+
+// Members returns an iterator over the member headers, for use in a range
+// loop. The data of each member can be read from the reader while the loop
+// body runs. Iteration stops after the first error, which is yielded.
+func (cr *Reader) Members() iter.Seq2[*Header, error] {
+	return func(yield func(*Header, error) bool) {
+		for {
+			h, err := cr.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(h, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Read reads from the data of the current member. In a crc archive, the
+// read that reaches the end of the data returns ErrChecksum if the data
+// does not match.
+func (cr *Reader) Read(p []byte) (int, error) {
+	if cr.remaining == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > cr.remaining {
+		p = p[:cr.remaining]
+	}
+	n, err := cr.r.Read(p)
+	cr.remaining -= int64(n)
+	cr.offset += int64(n)
+	if cr.wantSum >= 0 {
+		for _, c := range p[:n] {
+			cr.checksum += uint32(c)
+		}
+		if cr.remaining == 0 && int64(cr.checksum) != cr.wantSum {
+			return n, fmt.Errorf("%w: %08x, header has %08x", ErrChecksum, cr.checksum, cr.wantSum)
+		}
+	}
+	if err == io.EOF && cr.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// skip discards n bytes
+func (cr *Reader) skip(n int64) error {
+	cr.remaining, cr.pad = 0, 0
+	for n > 0 {
+		discarded, err := cr.r.Discard(int(min(n, 1<<30)))
+		n -= int64(discarded)
+		cr.offset += int64(discarded)
+		if err != nil {
+			return fmt.Errorf("failed to read member data: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package cpiofile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// odcMember formats an odc header, name and data
+func odcMember(name string, mode int, data string) string {
+	return fmt.Sprintf("%s%06o%06o%06o%06o%06o%06o%06o%011o%06o%011o%s\x00%s",
+		MagicODC, 0, 7, mode, 1000, 100, 1, 0x0103, 1600000000, len(name)+1, len(data), name, data)
+}
+
+// TestReaderODC tests the old portable format
+func TestReaderODC(t *testing.T) {
+	archive := odcMember("bin", TypeDir|0o755, "") +
+		odcMember("bin/sh", TypeSymlink|0o777, "busybox") +
+		odcMember("dev/null", TypeChar|0o666, "") +
+		odcMember("odd", TypeReg|0o644, "abc") +
+		odcMember(Trailer, 0, "")
+
+	r := NewReader(strings.NewReader(archive))
+	var got []string
+	for h, err := range r.Members() {
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		got = append(got, fmt.Sprintf("%s %o %o %d/%d %d,%d %q %q", h.Name, h.Type, h.Mode, h.UID, h.GID, h.Devmajor, h.Devminor, h.Linkname, data))
+	}
+	expected := []string{
+		`bin 40000 755 1000/100 1,3 "" ""`,
+		`bin/sh 120000 777 1000/100 1,3 "busybox" ""`,
+		`dev/null 20000 666 1000/100 1,3 "" ""`,
+		`odd 100000 644 1000/100 1,3 "" "abc"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("members:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+// TestReaderChecksum tests the data checksum of the crc format
+func TestReaderChecksum(t *testing.T) {
+	var buf bytes.Buffer
+	WriteArchive(&buf, []*Member{{Header: Header{Name: "f"}, Data: []byte("data")}})
+	sum := int('d') + int('a') + int('t') + int('a')
+
+	for check, wantErr := range map[int]bool{sum: false, sum + 1: true} {
+		archive := bytes.Clone(buf.Bytes())
+		copy(archive, MagicCRC)
+		copy(archive[102:], fmt.Sprintf("%08x", check))
+
+		r := NewReader(bytes.NewReader(archive))
+		if _, err := r.Next(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := io.ReadAll(r)
+		if errors.Is(err, ErrChecksum) != wantErr {
+			t.Errorf("checksum %x: Read = %v, want error %v", check, err, wantErr)
+		}
+	}
+}
+
+// TestReaderInvalid tests that malformed archives are rejected
+func TestReaderInvalid(t *testing.T) {
+	var valid bytes.Buffer
+	WriteArchive(&valid, []*Member{{Header: Header{Name: "f"}, Data: []byte("data")}})
+
+	for name, archive := range map[string]string{
+		"magic":     "070703" + valid.String()[6:],
+		"hex":       valid.String()[:20] + "zz" + valid.String()[22:],
+		"name size": odcMember("x", TypeReg, "")[:59] + "777777" + "x\x00",
+		"no name":   odcMember("", TypeReg, ""),
+		"truncated": valid.String()[:newcHeaderSize+2+2],
+		"link":      odcMember("l", TypeSymlink, strings.Repeat("x", MaxNameSize+1)),
+	} {
+		r := NewReader(strings.NewReader(archive))
+		var err error
+		for _, err = range r.Members() {
+			if err == nil {
+				_, err = io.ReadAll(r)
+			}
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package cpiofile
+
+import (
+	"fmt"
+	"io"
+)
+
+// Member is an archive member held in memory
+type Member struct {
+	Header Header
+	Data   []byte
+}
+
+// Writer writes newc archive members in order. Each member is started with
+// WriteHeader and its data written with Write; the padding to a multiple
+// of four is added when the next member starts or the writer is closed.
+type Writer struct {
+	w         io.Writer
+	offset    int64 // File offset of the next byte to write
+	remaining int64 // Unwritten bytes of the current member
+	pad       int64 // Padding owed after the current member
+	ino       int64 // Last inode number assigned
+}
+
+// NewWriter returns a writer positioned before the first member
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Offset returns the file offset at which the next member header will be
+// written
+func (cw *Writer) Offset() int64 {
+	return cw.offset + cw.remaining + cw.pad
+}
+
+// WriteHeader finishes the current member and writes the header of the
+// next one. A symbolic link is written with its Linkname as data and must
+// have a zero Size. Members without an inode number get a fresh one, so
+// that readers do not take them for hard links of each other.
+func (cw *Writer) WriteHeader(h *Header) error {
+	if cw.remaining > 0 {
+		return fmt.Errorf("member written short: %d bytes missing", cw.remaining)
+	}
+	if err := cw.writePadding(); err != nil {
+		return err
+	}
+	// Secure: validate size and name
+	size := h.Size
+	if h.Type == TypeSymlink {
+		if size != 0 || len(h.Linkname) > MaxNameSize {
+			return fmt.Errorf("invalid symbolic link %s", h.Name)
+		}
+		size = int64(len(h.Linkname))
+	}
+	if size < 0 || size > 0xffffffff {
+		return fmt.Errorf("invalid member size: %d", size)
+	}
+	if h.Name == "" || len(h.Name)+1 > MaxNameSize {
+		return fmt.Errorf("invalid member name: %q", h.Name)
+	}
+
+	typ := h.Type
+	if typ == 0 {
+		typ = TypeReg
+	}
+	ino := h.Ino
+	if ino == 0 {
+		cw.ino++
+		ino = cw.ino
+	}
+	nlink := h.Nlink
+	if nlink == 0 {
+		nlink = 1
+		if typ == TypeDir {
+			nlink = 2
+		}
+	}
+	values := []int64{
+		ino, int64(typ&typeMask | h.Mode&0o7777), int64(h.UID), int64(h.GID), int64(nlink), h.Date, size,
+		0, 0, int64(h.Devmajor), int64(h.Devminor), int64(len(h.Name) + 1), 0,
+	}
+	if err := cw.writeNewc(h.Name, values); err != nil {
+		return err
+	}
+
+	cw.remaining = size
+	cw.pad = padding(size)
+	if h.Type == TypeSymlink {
+		if _, err := cw.Write([]byte(h.Linkname)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNewc writes a newc header with its name and the padding after it
+func (cw *Writer) writeNewc(name string, values []int64) error {
+	header := make([]byte, 0, newcHeaderSize+len(name)+4)
+	header = append(header, MagicNewc...)
+	for _, v := range values {
+		// Secure: a value too wide for its field would shift the rest
+		if v < 0 || v > 0xffffffff {
+			return fmt.Errorf("header field too wide for member %s: %d", name, v)
+		}
+		header = fmt.Appendf(header, "%08x", v)
+	}
+	header = append(header, name...)
+	header = append(header, 0)
+	for range padding(int64(len(header))) {
+		header = append(header, 0)
+	}
+
+	if _, err := cw.w.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	cw.offset += int64(len(header))
+	return nil
+}
+
+// Write writes data of the current member. Writing more than the size
+// given in its header is an error.
+func (cw *Writer) Write(p []byte) (int, error) {
+	if int64(len(p)) > cw.remaining {
+		return 0, fmt.Errorf("write exceeds member size by %d bytes", int64(len(p))-cw.remaining)
+	}
+	n, err := cw.w.Write(p)
+	cw.remaining -= int64(n)
+	cw.offset += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("failed to write data: %w", err)
+	}
+	return n, nil
+}
+
+// WriteMember writes a header and all of its data
+func (cw *Writer) WriteMember(h *Header, data []byte) error {
+	header := *h
+	header.Size = int64(len(data))
+	if err := cw.WriteHeader(&header); err != nil {
+		return err
+	}
+	_, err := cw.Write(data)
+	return err
+}
+
+// Close finishes the last member and writes the trailer. It does not close
+// the underlying writer.
+func (cw *Writer) Close() error {
+	if cw.remaining > 0 {
+		return fmt.Errorf("member written short: %d bytes missing", cw.remaining)
+	}
+	if err := cw.writePadding(); err != nil {
+		return err
+	}
+	return cw.writeNewc(Trailer, []int64{0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, int64(len(Trailer) + 1), 0})
+}
+
+// writePadding writes the zeros that align the next header
+func (cw *Writer) writePadding() error {
+	if cw.pad == 0 {
+		return nil
+	}
+	if _, err := cw.w.Write(make([]byte, cw.pad)); err != nil {
+		return fmt.Errorf("failed to write padding: %w", err)
+	}
+	cw.offset += cw.pad
+	cw.pad = 0
+	return nil
+}
+
+// WriteArchive writes a complete archive of the members in order
+func WriteArchive(w io.Writer, members []*Member) error {
+	cw := NewWriter(w)
+	for _, member := range members {
+		if err := cw.WriteMember(&member.Header, member.Data); err != nil {
+			return err
+		}
+	}
+	return cw.Close()
+}
@@ -0,0 +1,76 @@
+package cpiofile
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// TestWriteArchiveRoundTrip tests that members read back unchanged and
+// that headers and data stay aligned
+func TestWriteArchiveRoundTrip(t *testing.T) {
+	members := []*Member{
+		{Header: Header{Name: "etc", Type: TypeDir, Mode: 0o755, Date: 1700000000}},
+		{Header: Header{Name: "etc/hostname", Type: TypeReg, Mode: 0o644, UID: 1, GID: 2, Date: 1700000000}, Data: []byte("box\n")},
+		{Header: Header{Name: "init", Type: TypeSymlink, Mode: 0o777, Linkname: "sbin/init"}},
+		{Header: Header{Name: "dev/console", Type: TypeChar, Mode: 0o600, Devmajor: 5, Devminor: 1}},
+		{Header: Header{Name: "odd-size", Mode: 0o600}, Data: []byte("12345")},
+	}
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, members); err != nil {
+		t.Fatalf("WriteArchive failed: %v", err)
+	}
+	if buf.Len()%4 != 0 {
+		t.Errorf("archive of %d bytes is not aligned", buf.Len())
+	}
+
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	i := 0
+	for h, err := range r.Members() {
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		want := members[i].Header
+		if want.Type == 0 {
+			want.Type = TypeReg
+		}
+		want.Ino, want.Nlink, want.Offset = int64(i+1), h.Nlink, h.Offset
+		want.Size = int64(len(members[i].Data))
+		if *h != want {
+			t.Errorf("member %d = %+v, want %+v", i, *h, want)
+		}
+		if data, err := io.ReadAll(r); err != nil || !bytes.Equal(data, members[i].Data) {
+			t.Errorf("member %s data = %q, %v", h.Name, data, err)
+		}
+		i++
+	}
+	if i != len(members) {
+		t.Errorf("read %d members, want %d", i, len(members))
+	}
+}
+
+// TestWriterErrors tests size and name validation
+func TestWriterErrors(t *testing.T) {
+	cw := NewWriter(io.Discard)
+	if err := cw.WriteHeader(&Header{Name: "a", Size: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cw.Write([]byte("12345")); err == nil {
+		t.Error("Write accepted more than the member size")
+	}
+	if err := cw.Close(); err == nil {
+		t.Error("Close accepted a short member")
+	}
+
+	for _, h := range []Header{
+		{Name: "", Size: 0},
+		{Name: "big", Size: 1 << 32},
+		{Name: "link", Type: TypeSymlink, Size: 1, Linkname: "x"},
+		{Name: "uid", UID: -1},
+	} {
+		cw := NewWriter(io.Discard)
+		if err := cw.WriteHeader(&h); err == nil {
+			t.Errorf("WriteHeader(%+v) succeeded", h)
+		}
+	}
+}
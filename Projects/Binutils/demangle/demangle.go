@@ -0,0 +1,745 @@
+// Package demangle decodes C++ symbol names mangled under the Itanium C++
+// ABI, the scheme GCC and Clang use on ELF and Mach-O systems: the symbol
+// _ZN3foo3barEPKc is the function foo::bar(char const*). It covers
+// functions and variables, nested and local names, constructors,
+// destructors and operators, substitutions, template arguments and
+// parameters, qualified, pointer, reference, array, function and
+// pointer-to-member types, the special names of vtables, typeinfo, guard
+// variables and thunks, and the clone suffixes GCC appends. The output
+// follows GNU c++filt, so a pointer to a constant char is "char const*".
+//
+// Expressions, as in template arguments computed from other arguments and
+// in decltype, are not covered: names using them are reported with
+// ErrUnsupported, and Filter leaves them mangled.
+package demangle
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// MaxNameLength bounds the length of a name decoded
+	MaxNameLength = 1 << 14
+	// maxOutput bounds the bytes that substitutions and template
+	// parameters may copy, as each reference repeats an earlier part
+	maxOutput = 1 << 16
+	// maxDepth bounds the nesting of types and encodings
+	maxDepth = 256
+)
+
+var (
+	// ErrNotMangled is returned for a name without the _Z prefix
+	ErrNotMangled = errors.New("not a mangled C++ name")
+	// ErrInvalid is returned for a name that does not follow the grammar
+	ErrInvalid = errors.New("invalid mangled name")
+	// ErrUnsupported is returned for a name using manglings not covered
+	ErrUnsupported = errors.New("unsupported mangling")
+)
+
+// Demangle decodes a mangled name. A symbol version suffix, as in
+// _ZNSt8ios_base4InitC1Ev@GLIBCXX_3.4, is kept after the decoded name.
+// Time Complexity: O(n) for a name of n bytes, plus the output
+func Demangle(name string) (string, error) {
+	// Secure: bound the input
+	if len(name) > MaxNameLength {
+		return "", fmt.Errorf("%w: longer than %d bytes", ErrInvalid, MaxNameLength)
+	}
+	mangled, version, versioned := strings.Cut(name, "@")
+	if !strings.HasPrefix(mangled, "_Z") {
+		return "", ErrNotMangled
+	}
+
+	p := &parser{s: mangled, pos: 2, packIndex: -1}
+	out := p.encoding(true)
+	out += p.cloneSuffixes()
+	if p.err != nil {
+		return "", p.err
+	}
+	if versioned {
+		out += "@" + version
+	}
+	return out, nil
+}
+
+// Filter returns the decoded form of name, or name itself if it is not a
+// mangled name that Demangle decodes, as nm -C and c++filt print symbols
+func Filter(name string) string {
+	if out, err := Demangle(name); err == nil {
+		return out
+	}
+	return name
+}
+
+// parser decodes a mangled name by recursive descent over the grammar of
+// the ABI. Errors are sticky: after the first, peek returns 0 so that
+// every loop ends, and the partial results are discarded.
+type parser struct {
+	s    string
+	pos  int
+	err  error
+	subs []node // Candidates for substitution, S_ first
+	// templateArgs are the arguments of the function template being
+	// decoded, which T_, T0_ and so on refer to
+	templateArgs []node
+	// packIndex selects the element of a pack a template parameter stands
+	// for while a pack expansion is decoded, or is -1; packLen is the
+	// length of the last pack referred to
+	packIndex, packLen int
+	expanded           int // Bytes copied by substitutions and template parameters
+	depth              int
+}
+
+// node is a decoded name or type. A type is printed as left then right,
+// with room between them for a declarator: a pointer to a function prints
+// as the return type, then "(*", then ")" and the parameters.
+type node struct {
+	left, right string
+	wrapped     bool   // A declarator in parentheses is already between left and right
+	nested      bool   // A function whose parameters are inside the declarator of its return type
+	base        string // For a name, its last component without template arguments
+	args        []node // For a name ending in template arguments, those arguments
+	noReturn    bool   // A constructor, destructor or conversion, whose encoding has no return type
+	pack        []node // For a template argument pack, its elements
+	isPack      bool
+	cv          string // The qualifiers of the type, which apply once
+	ref         string // For a reference type, & or &&
+	param       int    // For a template parameter as a substitution, its index plus one
+	referee     *node  // For a reference type, the type referred to
+}
+
+// String returns the node as printed on its own
+func (n node) String() string {
+	return n.left + n.right
+}
+
+// peek returns the next byte, or 0 at the end of the name or after an error
+func (p *parser) peek() byte {
+	return p.peekAt(0)
+}
+
+// peekAt returns the byte i past the next, or 0 past the end
+func (p *parser) peekAt(i int) byte {
+	if p.err != nil || p.pos+i >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos+i]
+}
+
+// consume skips the next byte if it is c
+func (p *parser) consume(c byte) bool {
+	if p.peek() == c && c != 0 {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect skips the next byte, which must be c
+func (p *parser) expect(c byte) {
+	if !p.consume(c) {
+		p.fail("expected %q", c)
+	}
+}
+
+// fail records an error at the current offset, unless one is recorded
+func (p *parser) fail(format string, args ...any) {
+	if p.err == nil {
+		p.err = fmt.Errorf("%w: %s at offset %d", ErrInvalid, fmt.Sprintf(format, args...), p.pos)
+	}
+}
+
+// unsupported records that the name uses a mangling not covered
+func (p *parser) unsupported(what string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("%w: %s at offset %d", ErrUnsupported, what, p.pos)
+	}
+}
+
+// enter counts a level of nesting, failing when there are too many
+func (p *parser) enter() bool {
+	if p.err != nil {
+		return false
+	}
+	// Secure: bound the recursion a crafted name can cause
+	if p.depth >= maxDepth {
+		p.fail("nested more than %d deep", maxDepth)
+		return false
+	}
+	p.depth++
+	return true
+}
+
+// leave ends a level of nesting
+func (p *parser) leave() {
+	p.depth--
+}
+
+// expand accounts for a copy of an earlier node and returns it
+func (p *parser) expand(n node) node {
+	p.expanded += len(n.left) + len(n.right)
+	// Secure: each reference may double the output, so bound the total
+	if p.expanded > maxOutput {
+		p.fail("expands past %d bytes", maxOutput)
+	}
+	return n
+}
+
+// addSub makes a node a candidate for later substitutions
+func (p *parser) addSub(n node) {
+	if p.err == nil {
+		p.subs = append(p.subs, n)
+	}
+}
+
+// number reads a non-negative decimal number
+func (p *parser) number() int {
+	start, n := p.pos, 0
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		n = n*10 + int(c-'0')
+		p.pos++
+		// Secure: stop before the number can overflow; lengths and
+		// indexes are checked against what they refer to
+		if n > math.MaxInt32 {
+			p.fail("number too large")
+			return 0
+		}
+	}
+	if p.pos == start {
+		p.fail("expected a number")
+	}
+	return n
+}
+
+// encoding reads the encoding of a function or variable, or a special
+// name: <name> [<return type>] [<parameter types>]. The return type of a
+// function template is printed only if withReturn is set.
+func (p *parser) encoding(withReturn bool) string {
+	if !p.enter() {
+		return ""
+	}
+	defer p.leave()
+	if c := p.peek(); c == 'T' || c == 'G' {
+		return p.specialName()
+	}
+
+	saved := p.templateArgs
+	defer func() { p.templateArgs = saved }()
+	n, quals := p.name()
+	if n.args != nil {
+		p.templateArgs = n.args
+	}
+	// A variable has no parameters; nor has the encoding of a function
+	// whose local name follows
+	if c := p.peek(); c == 0 || c == 'E' || c == '.' {
+		return n.String()
+	}
+	var ret node
+	if n.args != nil && !n.noReturn {
+		if ret = p.typ(); !withReturn {
+			ret = node{}
+		}
+	}
+	function := n.String() + p.parameters() + quals
+	switch {
+	case ret.wrapped:
+		// A return type of pointer to function or array surrounds the
+		// function, as in void (*f<int>())(int)
+		return ret.left + function + ret.right
+	case ret.left != "":
+		return ret.String() + " " + function
+	}
+	return function
+}
+
+// parameters reads parameter types up to the end of an encoding or the E
+// of a function type, a lone v meaning none, and returns them as a list
+func (p *parser) parameters() string {
+	atEnd := func(i int) bool {
+		c := p.peekAt(i)
+		return c == 0 || c == 'E' || c == '.' || (c == 'R' || c == 'O') && p.peekAt(i+1) == 'E'
+	}
+	if p.peek() == 'v' && atEnd(1) {
+		p.pos++
+		return "()"
+	}
+	params := []string{}
+	for !atEnd(0) {
+		params = append(params, p.typ().String())
+	}
+	list, _ := joinList(params)
+	return "(" + list + ")"
+}
+
+// name reads a name, returning it and the qualifiers of a member function
+func (p *parser) name() (node, string) {
+	switch p.peek() {
+	case 'N':
+		return p.nestedName()
+	case 'Z':
+		return p.localName()
+	}
+
+	var n node
+	if p.peek() == 'S' && p.peekAt(1) != 't' {
+		n = p.substitution()
+		if p.peek() != 'I' {
+			return n, ""
+		}
+	} else {
+		std := p.peek() == 'S'
+		if std {
+			p.pos += 2
+		}
+		n = p.unqualifiedName(node{})
+		if std {
+			n.left = "std::" + n.left
+		}
+		if p.peek() != 'I' {
+			return n, ""
+		}
+		// An unscoped template name is a candidate for substitution
+		p.addSub(n)
+	}
+	return p.withTemplateArgs(n), ""
+}
+
+// nestedName reads N [qualifiers] <prefix>... <unqualified name> E. Each
+// prefix is a candidate for substitution; the whole name is not.
+func (p *parser) nestedName() (node, string) {
+	p.pos++ // N
+	quals := p.cvQualifiers()
+	switch {
+	case p.consume('R'):
+		quals += " &"
+	case p.consume('O'):
+		quals += " &&"
+	}
+
+	var n node
+	for c := p.peek(); c != 'E' && c != 0; c = p.peek() {
+		substituted := false
+		switch {
+		case c == 'S' && p.peekAt(1) == 't':
+			p.pos += 2
+			n, substituted = node{left: "std"}, true
+		case c == 'S':
+			n, substituted = p.substitution(), true
+		case c == 'I':
+			if n.left == "" {
+				p.fail("template arguments without a template")
+				continue
+			}
+			n = p.withTemplateArgs(n)
+		case c == 'T':
+			n, _ = p.templateParam()
+		case c == 'D' && (p.peekAt(1) == 't' || p.peekAt(1) == 'T'):
+			p.unsupported("decltype")
+		case c == 'M':
+			// The prefix is a variable or data member whose initializer
+			// a closure type following belongs to
+			p.pos++
+			continue
+		default:
+			component := p.unqualifiedName(n)
+			if n.left != "" {
+				component.left = n.left + "::" + component.left
+			}
+			n = component
+		}
+		if !substituted && p.peek() != 'E' {
+			p.addSub(n)
+		}
+	}
+	p.expect('E')
+	return n, quals
+}
+
+// localName reads Z <encoding> E <entity> [<discriminator>], an entity
+// declared inside a function, which c++filt prints without the return type
+// of the function
+func (p *parser) localName() (node, string) {
+	p.pos++ // Z
+	function := p.encoding(false)
+	p.expect('E')
+	if p.consume('s') {
+		p.discriminator()
+		return node{left: function + "::string literal"}, ""
+	}
+	if p.peek() == 'd' {
+		p.unsupported("default argument scope")
+		return node{}, ""
+	}
+	n, quals := p.name()
+	p.discriminator()
+	n.left = function + "::" + n.left
+	return n, quals
+}
+
+// discriminator skips _ <digit> or __ <number> _, which numbers entities
+// of the same name in one function and is not printed
+func (p *parser) discriminator() {
+	if !p.consume('_') {
+		return
+	}
+	if p.consume('_') {
+		p.number()
+		p.expect('_')
+		return
+	}
+	p.number()
+}
+
+// unqualifiedName reads one component of a name. A constructor or
+// destructor is named after the class, the last component of enclosing.
+func (p *parser) unqualifiedName(enclosing node) node {
+	var n node
+	switch c := p.peek(); {
+	case c >= '0' && c <= '9':
+		id := p.sourceName()
+		n = node{left: id, base: id}
+	case c == 'L':
+		// A name with internal linkage
+		p.pos++
+		id := p.sourceName()
+		p.discriminator()
+		n = node{left: id, base: id}
+	case c == 'C' || c == 'D':
+		n = p.ctorDtorName(enclosing)
+	case c == 'U':
+		// A constructor of an unnamed type is named after the class
+		// enclosing it, as c++filt prints it
+		n = p.unnamedType()
+		n.base = enclosing.base
+	case c >= 'a' && c <= 'z':
+		n = p.operatorName()
+	default:
+		p.fail("unexpected %q in name", c)
+		return n
+	}
+	// ABI tags follow the name they mark
+	for p.consume('B') {
+		n.left += "[abi:" + p.sourceName() + "]"
+	}
+	return n
+}
+
+// sourceName reads <length> <identifier>
+func (p *parser) sourceName() string {
+	n := p.number()
+	if p.err != nil {
+		return ""
+	}
+	if n == 0 || n > len(p.s)-p.pos {
+		p.fail("identifier of %d bytes", n)
+		return ""
+	}
+	id := p.s[p.pos : p.pos+n]
+	p.pos += n
+	// GCC names anonymous namespaces _GLOBAL__N_1 and the like
+	if len(id) >= 10 && strings.HasPrefix(id, "_GLOBAL_") && strings.IndexByte("._$", id[8]) >= 0 && id[9] == 'N' {
+		return "(anonymous namespace)"
+	}
+	return id
+}
+
+// ctorDtorName reads C1 to C5 or D0 to D5, the variants of constructors
+// and destructors the ABI distinguishes, which print alike
+func (p *parser) ctorDtorName(enclosing node) node {
+	kind := p.peek()
+	if p.peekAt(1) == 'I' {
+		p.unsupported("inheriting constructor")
+		return node{}
+	}
+	if v := p.peekAt(1); v < '0' || v > '5' || kind == 'C' && v == '0' {
+		p.fail("unexpected %q in name", kind)
+		return node{}
+	}
+	p.pos += 2
+	if enclosing.base == "" {
+		p.fail("constructor or destructor outside a class")
+		return node{}
+	}
+	name := enclosing.base
+	if kind == 'D' {
+		name = "~" + name
+	}
+	return node{left: name, base: enclosing.base, noReturn: true}
+}
+
+// unnamedType reads Ut [<number>] _, an unnamed class, or Ul <parameter
+// types> E [<number>] _, the closure type of a lambda
+func (p *parser) unnamedType() node {
+	kind := p.peekAt(1)
+	p.pos += 2
+	params := ""
+	switch kind {
+	case 't':
+	case 'l':
+		params = p.parameters()
+		p.expect('E')
+	default:
+		p.unsupported("unnamed type")
+		return node{}
+	}
+	index := 1
+	if !p.consume('_') {
+		index = p.number() + 2
+		p.expect('_')
+	}
+	if kind == 't' {
+		return node{left: fmt.Sprintf("{unnamed type#%d}", index)}
+	}
+	return node{left: fmt.Sprintf("{lambda%s#%d}", params, index)}
+}
+
+// operators maps the two-letter codes of operators to their symbols
+var operators = map[string]string{
+	"nw": "new", "na": "new[]", "dl": "delete", "da": "delete[]", "aw": "co_await",
+	"ps": "+", "ng": "-", "ad": "&", "de": "*", "co": "~",
+	"pl": "+", "mi": "-", "ml": "*", "dv": "/", "rm": "%", "an": "&", "or": "|", "eo": "^",
+	"aS": "=", "pL": "+=", "mI": "-=", "mL": "*=", "dV": "/=", "rM": "%=",
+	"aN": "&=", "oR": "|=", "eO": "^=", "ls": "<<", "rs": ">>", "lS": "<<=", "rS": ">>=",
+	"eq": "==", "ne": "!=", "lt": "<", "gt": ">", "le": "<=", "ge": ">=", "ss": "<=>",
+	"nt": "!", "aa": "&&", "oo": "||", "pp": "++", "mm": "--", "cm": ",",
+	"pm": "->*", "pt": "->", "cl": "()", "ix": "[]", "qu": "?",
+}
+
+// operatorName reads an operator: a two-letter code, cv <type> for a
+// conversion, or li <source name> for a literal suffix
+func (p *parser) operatorName() node {
+	if p.pos+2 > len(p.s) {
+		p.fail("truncated operator")
+		return node{}
+	}
+	code := p.s[p.pos : p.pos+2]
+	p.pos += 2
+	switch code {
+	case "cv":
+		return node{left: "operator " + p.typ().String(), noReturn: true}
+	case "li":
+		return node{left: `operator"" ` + p.sourceName()}
+	}
+	symbol, ok := operators[code]
+	if !ok {
+		p.pos -= 2
+		p.fail("unknown operator %q", code)
+		return node{}
+	}
+	if symbol[0] >= 'a' && symbol[0] <= 'z' {
+		return node{left: "operator " + symbol}
+	}
+	return node{left: "operator" + symbol}
+}
+
+// standardSubstitution is an abbreviation for a common name in std: how it
+// prints, in full as c++filt prints it, and the class a constructor or
+// destructor after it is named after
+type standardSubstitution struct {
+	name, base string
+}
+
+// standardSubstitutions are the abbreviations St is not among: it starts a
+// name in std rather than standing for one
+var standardSubstitutions = map[byte]standardSubstitution{
+	'a': {"std::allocator", "allocator"},
+	'b': {"std::basic_string", "basic_string"},
+	's': {"std::basic_string<char, std::char_traits<char>, std::allocator<char> >", "basic_string"},
+	'i': {"std::basic_istream<char, std::char_traits<char> >", "basic_istream"},
+	'o': {"std::basic_ostream<char, std::char_traits<char> >", "basic_ostream"},
+	'd': {"std::basic_iostream<char, std::char_traits<char> >", "basic_iostream"},
+}
+
+// substitution reads S_, S <base 36 number> _ or a standard abbreviation,
+// and returns the node it refers to
+func (p *parser) substitution() node {
+	p.pos++ // S
+	if std, ok := standardSubstitutions[p.peek()]; ok {
+		p.pos++
+		return node{left: std.name, base: std.base}
+	}
+
+	index := 0
+	if !p.consume('_') {
+		seq := 0
+		for c := p.peek(); c != '_'; c = p.peek() {
+			switch {
+			case c >= '0' && c <= '9':
+				seq = seq*36 + int(c-'0')
+			case c >= 'A' && c <= 'Z':
+				seq = seq*36 + int(c-'A') + 10
+			default:
+				p.fail("unexpected %q in substitution", c)
+				return node{}
+			}
+			p.pos++
+			// Secure: stop before the number can overflow
+			if seq > len(p.s) {
+				p.fail("substitution out of range")
+				return node{}
+			}
+		}
+		p.pos++
+		index = seq + 1
+	}
+	if index >= len(p.subs) {
+		p.fail("substitution %d of %d", index, len(p.subs))
+		return node{}
+	}
+	if sub := p.subs[index]; sub.param > 0 {
+		return p.resolveParam(sub.param - 1)
+	}
+	return p.expand(p.subs[index])
+}
+
+// templateParam reads T_ or T <number> _, a reference to an argument of
+// the function template being decoded
+func (p *parser) templateParam() (node, int) {
+	p.pos++ // T
+	index := 0
+	if !p.consume('_') {
+		index = p.number() + 1
+		p.expect('_')
+	}
+	return p.resolveParam(index), index
+}
+
+// resolveParam returns the argument template parameter index stands for,
+// or while a pack expansion is decoded the element of the pack it selects
+func (p *parser) resolveParam(index int) node {
+	if p.err != nil {
+		return node{}
+	}
+	if index >= len(p.templateArgs) {
+		p.fail("template parameter %d of %d", index, len(p.templateArgs))
+		return node{}
+	}
+	arg := p.templateArgs[index]
+	if arg.isPack {
+		p.packLen = len(arg.pack)
+		if p.packIndex >= 0 {
+			// Secure: the packs of one expansion may differ in length
+			if p.packIndex >= len(arg.pack) {
+				p.fail("pack of %d in an expansion of %d", len(arg.pack), p.packIndex+1)
+				return node{}
+			}
+			arg = arg.pack[p.packIndex]
+		}
+	}
+	return p.expand(arg)
+}
+
+// specialName reads the name of data or code the compiler generates for a
+// class, function or variable: vtables, typeinfo, thunks, clones for
+// transactional memory and guard variables
+func (p *parser) specialName() string {
+	prefixes := map[string]string{
+		"TV": "vtable for ", "TT": "VTT for ", "TI": "typeinfo for ", "TS": "typeinfo name for ",
+	}
+	code := p.s[p.pos:min(p.pos+2, len(p.s))]
+	if prefix, ok := prefixes[code]; ok {
+		p.pos += 2
+		return prefix + p.typ().String()
+	}
+
+	var prefix string
+	switch code {
+	case "Th", "Tv":
+		p.pos++
+		p.callOffset()
+		if code == "Th" {
+			prefix = "non-virtual thunk to "
+		} else {
+			prefix = "virtual thunk to "
+		}
+		return prefix + p.encoding(true)
+	case "Tc":
+		p.pos += 2
+		p.callOffset()
+		p.callOffset()
+		return "covariant return thunk to " + p.encoding(true)
+	case "TC":
+		// The vtable of a base during construction of a derived class
+		p.pos += 2
+		derived := p.typ().String()
+		p.number()
+		p.expect('_')
+		return "construction vtable for " + p.typ().String() + "-in-" + derived
+	case "GT":
+		p.pos += 2
+		switch {
+		case p.consume('t'):
+			return "transaction clone for " + p.encoding(true)
+		case p.consume('n'):
+			return "non-transaction clone for " + p.encoding(true)
+		}
+		p.fail("unexpected %q in special name", p.peek())
+		return ""
+	case "TH", "TW", "GV":
+		p.pos += 2
+		prefix = map[string]string{
+			"TH": "TLS init function for ", "TW": "TLS wrapper function for ", "GV": "guard variable for ",
+		}[code]
+		n, _ := p.name()
+		return prefix + n.String()
+	}
+	p.unsupported("special name " + code)
+	return ""
+}
+
+// callOffset skips h <offset> _ or v <offset> _ <offset> _, the adjustment
+// a thunk makes to this
+func (p *parser) callOffset() {
+	offset := func() {
+		p.consume('n')
+		p.number()
+		p.expect('_')
+	}
+	switch {
+	case p.consume('h'):
+		offset()
+	case p.consume('v'):
+		offset()
+		offset()
+	default:
+		p.fail("expected a call offset")
+	}
+}
+
+// cloneSuffixes reads the suffixes GCC gives copies of a function it
+// specializes or splits, such as .constprop.0 or .cold, printing each as
+// c++filt does
+func (p *parser) cloneSuffixes() string {
+	out := ""
+	isDigit := func(i int) bool { return i < len(p.s) && p.s[i] >= '0' && p.s[i] <= '9' }
+	for p.err == nil && p.pos < len(p.s) {
+		start := p.pos
+		if p.s[p.pos] != '.' || p.pos+1 == len(p.s) {
+			p.fail("unexpected %q after the name", p.s[p.pos:])
+			break
+		}
+		p.pos++
+		if isDigit(p.pos) {
+			for isDigit(p.pos) {
+				p.pos++
+			}
+		} else {
+			for p.pos < len(p.s) && (p.s[p.pos] == '_' || p.s[p.pos]|0x20 >= 'a' && p.s[p.pos]|0x20 <= 'z') {
+				p.pos++
+			}
+		}
+		if p.pos == start+1 {
+			p.fail("unexpected %q after the name", p.s[start:])
+			break
+		}
+		// Numbers join the suffix before them
+		for p.pos+1 < len(p.s) && p.s[p.pos] == '.' && isDigit(p.pos+1) {
+			for p.pos++; isDigit(p.pos); p.pos++ {
+			}
+		}
+		out += " [clone " + p.s[start:p.pos] + "]"
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package demangle
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestDemangle tests names taken from GCC output and libstdc++, each
+// expected as GNU c++filt prints it
+func TestDemangle(t *testing.T) {
+	tests := []struct{ name, want string }{
+		// Functions, nested names and substitutions
+		{"_Z7counterv", "counter()"},
+		{"_ZN2ns5Point5countE", "ns::Point::count"},
+		{"_ZNK2ns5PointeqERKS0_", "ns::Point::operator==(ns::Point const&) const"},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", "std::vector<int, std::allocator<int> >::push_back(int const&)"},
+		{"_ZNSolsEPFRSoS_E", "std::basic_ostream<char, std::char_traits<char> >::operator<<(std::basic_ostream<char, std::char_traits<char> >& (*)(std::basic_ostream<char, std::char_traits<char> >&))"},
+		{"_Z4nameRKSt3mapINSt7__cxx1112basic_stringIcSt11char_traitsIcESaIcEEESt6vectorIiSaIiEESt4lessIS5_ESaISt4pairIKS5_S8_EEE",
+			"name(std::map<std::__cxx11::basic_string<char, std::char_traits<char>, std::allocator<char> >, std::vector<int, std::allocator<int> >, std::less<std::__cxx11::basic_string<char, std::char_traits<char>, std::allocator<char> > >, std::allocator<std::pair<std::__cxx11::basic_string<char, std::char_traits<char>, std::allocator<char> > const, std::vector<int, std::allocator<int> > > > > const&)"},
+		{"_ZN2ns12_GLOBAL__N_16hiddenEi", "ns::(anonymous namespace)::hidden(int)"},
+		{"_ZN3FooB5cxx11Ev", "Foo[abi:cxx11]()"},
+
+		// Constructors, destructors and operators
+		{"_ZN2ns5PointC1Ei", "ns::Point::Point(int)"},
+		{"_ZN2ns5PointD2Ev", "ns::Point::~Point()"},
+		{"_ZNSaIcEC1Ev", "std::allocator<char>::allocator()"},
+		{"_ZN2ns5PointpLEi", "ns::Point::operator+=(int)"},
+		{"_ZNK2ns5PointcvbEv", "ns::Point::operator bool() const"},
+		{"_ZN1AltIiEEvv", "void A::operator< <int>()"},
+		{"_Znwm", "operator new(unsigned long)"},
+		{"_ZdlPvm", "operator delete(void*, unsigned long)"},
+		{"_ZNO1A1fEv", "A::f() &&"},
+
+		// Templates: return types, parameters, literals and packs
+		{"_ZSt4endlIcSt11char_traitsIcEERSt13basic_ostreamIT_T0_ES6_", "std::basic_ostream<char, std::char_traits<char> >& std::endl<char, std::char_traits<char> >(std::basic_ostream<char, std::char_traits<char> >&)"},
+		{"_ZN2ns3maxIiEET_S1_S1_", "int ns::max<int>(int, int)"},
+		{"_ZN2ns3logIJiNSt7__cxx1112basic_stringIcSt11char_traitsIcESaIcEEEEEEvPKcDpOT_", "void ns::log<int, std::__cxx11::basic_string<char, std::char_traits<char>, std::allocator<char> > >(char const*, int&&, std::__cxx11::basic_string<char, std::char_traits<char>, std::allocator<char> >&&)"},
+		{"_ZN4llvm10make_errorINS_16RuntimeDyldErrorEJRA31_KcEEENS_5ErrorEDpOT0_", "llvm::Error llvm::make_error<llvm::RuntimeDyldError, char const (&) [31]>(char const (&) [31])"},
+		{"_ZN5Outer5InnerIJEE1fEv", "Outer::Inner<>::f()"},
+		{"_ZN2ns5FixedILi3ELb1EE1fEv", "ns::Fixed<3, true>::f()"},
+		{"_Z1fILb0ELj7ELln1ELc65EEvv", "void f<false, 7u, -1l, (char)65>()"},
+		{"_Z1fILZ1gvEEvv", "void f<g()>()"},
+		{"_ZNKSt8functionIFvvEEclEv", "std::function<void ()>::operator()() const"},
+
+		// Declarators
+		{"_ZN2ns4takeEPFvicERA4_iPKPKcMNS_5PointEiMS8_KFviE", "ns::take(void (*)(int, char), int (&) [4], char const* const*, int ns::Point::*, void (ns::Point::*)(int) const)"},
+		{"_Z1fPFPFivEvE", "f(int (*(*)())())"},
+		{"_ZN1AIiE1fIcEEPFviEv", "void (*A<int>::f<char>())(int)"},
+		{"_Z1fRA2_A3_i", "f(int (&) [2][3])"},
+		{"_Z1fPVKi", "f(int const volatile*)"},
+		{"_Z1fCdDnDF16_u7__int80", "f(double _Complex, decltype(nullptr), _Float16, __int80)"},
+
+		// Local names and closures
+		{"_ZZ7countervE5calls", "counter()::calls"},
+		{"_ZZ7countervENKUliE_clEi", "counter()::{lambda(int)#1}::operator()(int) const"},
+		{"_ZZ4mainENKUlvE0_clEv", "main::{lambda()#2}::operator()() const"},
+		{"_ZN1AUt_C2Ev", "A::{unnamed type#1}::A()"},
+		{"_ZN15FLAGS_nofromenvMUlvE_4_FUNEv", "FLAGS_nofromenv::{lambda()#1}::_FUN()"},
+
+		// Special names, clones and versions
+		{"_ZTVN2ns5PointE", "vtable for ns::Point"},
+		{"_ZTIN2ns5PointE", "typeinfo for ns::Point"},
+		{"_ZTSN2ns5PointE", "typeinfo name for ns::Point"},
+		{"_ZGVZ7countervE5calls", "guard variable for counter()::calls"},
+		{"_ZThn16_N2ns5PointD1Ev", "non-virtual thunk to ns::Point::~Point()"},
+		{"_ZTv0_n24_NSdD0Ev", "virtual thunk to std::basic_iostream<char, std::char_traits<char> >::~basic_iostream()"},
+		{"_ZGTtnam", "transaction clone for operator new[](unsigned long)"},
+		{"_ZN2ns4takeEPFvicE.part.0.cold", "ns::take(void (*)(int, char)) [clone .part.0] [clone .cold]"},
+		{"_ZNSt8ios_base4InitC1Ev@@GLIBCXX_3.4", "std::ios_base::Init::Init()@@GLIBCXX_3.4"},
+	}
+	for _, tt := range tests {
+		got, err := Demangle(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("Demangle(%s) = %q, %v\n  want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+// TestDemangleErrors tests that names outside the grammar, or using
+// manglings not covered, are rejected with the matching error
+func TestDemangleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		want error
+	}{
+		{"main", ErrNotMangled},
+		{"_GLOBAL__sub_I_main", ErrNotMangled},
+		{"_Z", ErrInvalid},
+		{"_ZN3foo", ErrInvalid},
+		{"_Z3fo", ErrInvalid},
+		{"_Z1fS_", ErrInvalid},    // Substitution of nothing
+		{"_Z1fT_", ErrInvalid},    // Template parameter of no template
+		{"_ZC1Ev", ErrInvalid},    // Constructor outside a class
+		{"_Z1fv.", ErrInvalid},    // Empty clone suffix
+		{"_Z1fvjunk", ErrInvalid}, // Unknown type
+		{"_Z99999999999999999999fv", ErrInvalid},
+		{"_Z1fIiEDTcl1gfp_EET_", ErrUnsupported},
+		{"_Z3fooILi2EEvRAplT_Li1E_i", ErrUnsupported},
+		{"_Z1fILi1EXadL_Z1gvEEEvv", ErrUnsupported},
+		{"_Z" + strings.Repeat("P", MaxNameLength), ErrInvalid},
+	}
+	for _, tt := range tests {
+		if got, err := Demangle(tt.name); !errors.Is(err, tt.want) {
+			t.Errorf("Demangle(%.40s) = %q, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+// TestDemangleBounds tests that nesting and substitutions that would
+// expand exponentially are cut off
+func TestDemangleBounds(t *testing.T) {
+	deep := "_Z1f" + strings.Repeat("P", maxDepth+1) + "i"
+	if _, err := Demangle(deep); !errors.Is(err, ErrInvalid) {
+		t.Errorf("%d pointers deep: %v, want ErrInvalid", maxDepth+1, err)
+	}
+
+	// Each pair in St4pairIS_S_E doubles the one before
+	name := "_Z1fSt4pairIiiE"
+	for i := range 20 {
+		name += "St4pairIS" + seqID(2*i) + "_S" + seqID(2*i) + "_E"
+	}
+	if _, err := Demangle(name); !errors.Is(err, ErrInvalid) {
+		t.Errorf("doubling substitutions: %v, want ErrInvalid", err)
+	}
+}
+
+// seqID returns the base 36 number of substitution i+1, as S<seq-id>_
+// refers to it
+func seqID(i int) string {
+	const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	s := ""
+	for {
+		s = string(digits[i%36]) + s
+		if i /= 36; i == 0 {
+			return s
+		}
+	}
+}
+
+// TestFilter tests that names Demangle rejects are returned unchanged
+func TestFilter(t *testing.T) {
+	for name, want := range map[string]string{
+		"_ZN2ns5PointD1Ev":     "ns::Point::~Point()",
+		"printf":               "printf",
+		"_Z1fIiEDTcl1gfp_EET_": "_Z1fIiEDTcl1gfp_EET_",
+		"_ZN3foo":              "_ZN3foo",
+	} {
+		if got := Filter(name); got != want {
+			t.Errorf("Filter(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// FuzzDemangle tests that no name makes Demangle panic or loop
+func FuzzDemangle(f *testing.F) {
+	f.Add("_ZNSt6vectorIiSaIiEE9push_backERKi")
+	f.Add("_ZN4llvm10make_errorINS_16RuntimeDyldErrorEJRA31_KcEEENS_5ErrorEDpOT0_")
+	f.Add("_ZZ7countervENKUliE_clEi")
+	f.Add("_ZTv0_n24_NSdD0Ev")
+	f.Fuzz(func(t *testing.T, name string) {
+		Demangle(name)
+	})
+}
@@ -0,0 +1,439 @@
+package demangle
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// builtinTypes maps the one-letter codes of fundamental types to their names
+var builtinTypes = map[byte]string{
+	'v': "void", 'w': "wchar_t", 'b': "bool", 'c': "char", 'a': "signed char", 'h': "unsigned char",
+	's': "short", 't': "unsigned short", 'i': "int", 'j': "unsigned int", 'l': "long", 'm': "unsigned long",
+	'x': "long long", 'y': "unsigned long long", 'n': "__int128", 'o': "unsigned __int128",
+	'f': "float", 'd': "double", 'e': "long double", 'g': "__float128", 'z': "...",
+}
+
+// builtinDTypes maps the codes of fundamental types that follow a D
+var builtinDTypes = map[byte]string{
+	'd': "decimal64", 'e': "decimal128", 'f': "decimal32", 'h': "half",
+	'i': "char32_t", 's': "char16_t", 'u': "char8_t",
+	'a': "auto", 'c': "decltype(auto)", 'n': "decltype(nullptr)",
+}
+
+// isFunction reports whether n is a function type not yet wrapped in a
+// declarator
+func (n node) isFunction() bool {
+	return !n.wrapped && (n.nested || strings.HasPrefix(n.right, " ("))
+}
+
+// declarator returns n with a pointer, reference or pointer to member op
+// applied. A function or array type takes it in parentheses, between its
+// return or element type and its parameters or bounds.
+func (n node) declarator(op string) node {
+	if n.right == "" || n.wrapped {
+		n.left += op
+		n.cv = ""
+		return n
+	}
+	n.cv = ""
+	if n.nested {
+		n.left += "(" + op
+		n.right = ")" + n.right
+		n.wrapped, n.nested = true, false
+		return n
+	}
+	n.left += " (" + op
+	if strings.HasPrefix(n.right, " [") {
+		n.right = ") " + strings.TrimPrefix(n.right, " ")
+	} else {
+		n.right = ")" + strings.TrimPrefix(n.right, " ")
+	}
+	n.wrapped = true
+	return n
+}
+
+// reference returns a reference to n. A reference to a reference, as a
+// template parameter of reference type makes, collapses to an lvalue
+// reference unless both are rvalue references.
+func (n node) reference(op string) node {
+	if n.referee != nil {
+		if n.ref == "&" {
+			op = "&"
+		}
+		n = *n.referee
+	}
+	referee := n
+	n = n.declarator(op)
+	n.ref, n.referee = op, &referee
+	return n
+}
+
+// qualified returns n with qualifiers applied, which follow the
+// parameters of a function type and the rest of any other type. A
+// qualifier the type has already, as a template parameter may, is dropped.
+func (n node) qualified(quals string) node {
+	for _, q := range strings.SplitAfter(quals, " ")[1:] {
+		q = " " + strings.TrimSpace(q)
+		if strings.Contains(n.cv, q) {
+			continue
+		}
+		n.cv += q
+		if n.isFunction() {
+			n.right += q
+		} else {
+			n.left += q
+		}
+	}
+	return n
+}
+
+// joinList returns items as a comma-separated list, and the last byte
+// c++filt would have printed. Like c++filt, it drops the separator before
+// an empty item, as an empty pack prints, but not the space it printed.
+func joinList(items []string) (string, byte) {
+	list, last := "", byte(0)
+	for i, item := range items {
+		if i > 0 && item == "" {
+			last = ' '
+			continue
+		}
+		if i > 0 {
+			list += ", "
+		}
+		list += item
+		if item != "" {
+			last = item[len(item)-1]
+		}
+	}
+	return list, last
+}
+
+// cvQualifiers reads [r] [V] [K] and returns them as printed after a type
+func (p *parser) cvQualifiers() string {
+	restrict, volatile, konst := p.consume('r'), p.consume('V'), p.consume('K')
+	quals := ""
+	if konst {
+		quals += " const"
+	}
+	if volatile {
+		quals += " volatile"
+	}
+	if restrict {
+		quals += " restrict"
+	}
+	return quals
+}
+
+// typ reads a type. Every type but a fundamental one or a bare
+// substitution becomes a candidate for substitution.
+func (p *parser) typ() node {
+	if !p.enter() {
+		return node{}
+	}
+	defer p.leave()
+
+	c := p.peek()
+	if name, ok := builtinTypes[c]; ok {
+		p.pos++
+		return node{left: name}
+	}
+	var n node
+	switch c {
+	case 'r', 'V', 'K':
+		quals := p.cvQualifiers()
+		if c, next := p.peek(), p.peekAt(1); c == 'F' || c == 'D' && strings.IndexByte("oOw", next) >= 0 {
+			// The qualifiers of a member function type apply to this, so
+			// the unqualified type is no candidate for substitution
+			n = p.functionType().qualified(quals)
+			break
+		}
+		n = p.typ().qualified(quals)
+	case 'P':
+		p.pos++
+		n = p.typ().declarator("*")
+	case 'R':
+		p.pos++
+		n = p.typ().reference("&")
+	case 'O':
+		p.pos++
+		n = p.typ().reference("&&")
+	case 'C', 'G':
+		p.pos++
+		n = p.typ()
+		if c == 'C' {
+			n.left += " _Complex"
+		} else {
+			n.left += " _Imaginary"
+		}
+	case 'F':
+		n = p.functionType()
+	case 'A':
+		n = p.arrayType()
+	case 'M':
+		n = p.memberPointerType()
+	case 'T':
+		var index int
+		n, index = p.templateParam()
+		// The candidate is the parameter, which stands for the argument of
+		// the template being decoded where it is substituted
+		p.addSub(node{param: index + 1})
+		if p.peek() != 'I' {
+			return n
+		}
+		// A template template parameter and its arguments
+		n = p.withTemplateArgs(n)
+	case 'D':
+		var ok bool
+		if n, ok = p.dType(); !ok {
+			return n
+		}
+	case 'S':
+		if p.peekAt(1) != 't' {
+			n = p.substitution()
+			if p.peek() != 'I' {
+				return n
+			}
+			n = p.withTemplateArgs(n)
+			break
+		}
+		n, _ = p.name()
+	case 'u':
+		// A vendor extended type
+		p.pos++
+		return node{left: p.sourceName()}
+	case 'N', 'Z', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		n, _ = p.name()
+	default:
+		p.fail("unexpected %q in type", c)
+		return node{}
+	}
+	n.noReturn = false
+	p.addSub(n)
+	return n
+}
+
+// dType reads a type whose code starts with D, reporting whether it is a
+// candidate for substitution
+func (p *parser) dType() (node, bool) {
+	c := p.peekAt(1)
+	if name, ok := builtinDTypes[c]; ok {
+		p.pos += 2
+		return node{left: name}, false
+	}
+	switch c {
+	case 'F':
+		// _FloatN, as DF16_
+		p.pos += 2
+		bits := p.number()
+		p.expect('_')
+		return node{left: fmt.Sprintf("_Float%d", bits)}, false
+	case 'p':
+		p.pos += 2
+		return p.packExpansion(), true
+	case 'o', 'O', 'w':
+		return p.functionType(), true
+	case 't', 'T':
+		p.unsupported("decltype")
+	case 'v':
+		p.unsupported("vector type")
+	default:
+		p.fail("unexpected D%c in type", c)
+	}
+	return node{}, false
+}
+
+// packExpansion reads the pattern of a pack expansion, and returns a pack
+// of the pattern decoded once for each element of the packs it refers to
+func (p *parser) packExpansion() node {
+	savedIndex, savedLen := p.packIndex, p.packLen
+	defer func() { p.packIndex, p.packLen = savedIndex, savedLen }()
+
+	start, subs := p.pos, len(p.subs)
+	p.packIndex, p.packLen = -1, -1
+	pattern := p.typ()
+	if p.packLen < 0 {
+		// A pattern referring to no pack prints as itself
+		return pattern
+	}
+	end, after := p.pos, slices.Clone(p.subs)
+	n := node{isPack: true}
+	printed := []string{}
+	for i := range p.packLen {
+		// Secure: each pass over the pattern counts toward the bound
+		p.expanded += end - start
+		p.pos, p.subs, p.packIndex = start, p.subs[:subs], i
+		elem := p.typ()
+		n.pack = append(n.pack, elem)
+		printed = append(printed, elem.String())
+	}
+	p.pos, p.subs = end, after
+	n.left, _ = joinList(printed)
+	return n
+}
+
+// functionType reads [<exception spec>] F [Y] <return type> <parameter
+// types> [<ref qualifier>] E
+func (p *parser) functionType() node {
+	noexcept := ""
+	switch {
+	case p.peek() == 'D' && p.peekAt(1) == 'o':
+		p.pos += 2
+		noexcept = " noexcept"
+	case p.peek() == 'D' && p.peekAt(1) == 'O':
+		p.unsupported("computed noexcept")
+		return node{}
+	case p.peek() == 'D' && p.peekAt(1) == 'w':
+		p.unsupported("dynamic exception specification")
+		return node{}
+	}
+	p.expect('F')
+	p.consume('Y') // extern "C" does not print
+	ret := p.typ()
+	params := p.parameters()
+	switch {
+	case p.consume('R'):
+		params += " &"
+	case p.consume('O'):
+		params += " &&"
+	}
+	p.expect('E')
+	if ret.wrapped {
+		// A function returning a pointer to a function or array takes its
+		// parameters inside the declarator of its return type
+		return node{left: ret.left, right: params + noexcept + ret.right, nested: true}
+	}
+	return node{left: ret.String(), right: " " + params + noexcept}
+}
+
+// arrayType reads A [<bound>] _ <element type>
+func (p *parser) arrayType() node {
+	p.pos++ // A
+	bound := ""
+	if p.peek() != '_' {
+		if c := p.peek(); c < '0' || c > '9' {
+			p.unsupported("array bound expression")
+			return node{}
+		}
+		bound = fmt.Sprint(p.number())
+	}
+	p.expect('_')
+	elem := p.typ()
+	if elem.wrapped || elem.isFunction() {
+		p.fail("array of functions")
+		return node{}
+	}
+	return node{left: elem.left, right: " [" + bound + "]" + strings.TrimPrefix(elem.right, " ")}
+}
+
+// memberPointerType reads M <class type> <member type>
+func (p *parser) memberPointerType() node {
+	p.pos++ // M
+	class := p.typ().String()
+	member := p.typ()
+	if member.right == "" {
+		member.left += " " + class + "::*"
+		return member
+	}
+	return member.declarator(class + "::*")
+}
+
+// withTemplateArgs reads I <template args> E and applies them to n
+func (p *parser) withTemplateArgs(n node) node {
+	p.expect('I')
+	args := []node{}
+	for c := p.peek(); c != 'E' && c != 0; c = p.peek() {
+		args = append(args, p.templateArg())
+	}
+	p.expect('E')
+
+	printed := []string{}
+	for _, arg := range args {
+		printed = append(printed, arg.String())
+	}
+	list, last := joinList(printed)
+	// Keep > > and operator< < from reading as one token
+	if last == '>' {
+		list += " "
+	}
+	if strings.HasSuffix(n.left, "<") {
+		n.left += " "
+	}
+	n.left += "<" + list + ">"
+	n.args = args
+	return n
+}
+
+// templateArg reads one template argument: a type, a literal, or a pack
+func (p *parser) templateArg() node {
+	switch p.peek() {
+	case 'L':
+		return node{left: p.literal()}
+	case 'J', 'I': // Older compilers wrote I for a pack
+		p.pos++
+		n := node{isPack: true}
+		printed := []string{}
+		for c := p.peek(); c != 'E' && c != 0; c = p.peek() {
+			arg := p.templateArg()
+			n.pack = append(n.pack, arg)
+			printed = append(printed, arg.String())
+		}
+		p.expect('E')
+		n.left, _ = joinList(printed)
+		return n
+	case 'X':
+		p.unsupported("expression")
+		return node{}
+	}
+	return p.typ()
+}
+
+// integerSuffixes are how c++filt marks literals of the integer types
+// other than int
+var integerSuffixes = map[byte]string{
+	'j': "u", 'l': "l", 'm': "ul", 'x': "ll", 'y': "ull",
+}
+
+// literal reads L <type> <value> E, or L <mangled name> E for the address
+// of an entity
+func (p *parser) literal() string {
+	p.pos++ // L
+	if p.consume('_') {
+		p.expect('Z')
+		out := p.encoding(true)
+		p.expect('E')
+		return out
+	}
+	if p.peek() == 'Z' {
+		p.pos++
+		out := p.encoding(true)
+		p.expect('E')
+		return out
+	}
+
+	code := p.peek()
+	typ := p.typ().String()
+	start := p.pos
+	p.consume('n')
+	for c := p.peek(); c != 'E' && c != 0; c = p.peek() {
+		p.pos++
+	}
+	value := strings.Replace(p.s[start:p.pos], "n", "-", 1)
+	p.expect('E')
+	if value == "" {
+		p.unsupported("literal without a value")
+		return ""
+	}
+	switch {
+	case code == 'b' && value == "0":
+		return "false"
+	case code == 'b' && value == "1":
+		return "true"
+	case code == 'i':
+		return value
+	}
+	if suffix, ok := integerSuffixes[code]; ok {
+		return value + suffix
+	}
+	return "(" + typ + ")" + value
+}
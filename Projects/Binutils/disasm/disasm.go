@@ -0,0 +1,84 @@
+// Package disasm decodes machine instructions for objdump-style listings.
+// Instructions are rendered in AT&T syntax, as GNU objdump does by default.
+package disasm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Arch selects the instruction set to decode
+type Arch int
+
+const (
+	// ArchX86 is 32-bit x86 (i386)
+	ArchX86 Arch = iota
+	// ArchX86_64 is 64-bit x86 (AMD64)
+	ArchX86_64
+)
+
+// String returns the name of an architecture
+func (a Arch) String() string {
+	switch a {
+	case ArchX86:
+		return "i386"
+	case ArchX86_64:
+		return "i386:x86-64"
+	}
+	return fmt.Sprintf("arch(%d)", int(a))
+}
+
+// ELF machine numbers of the supported architectures
+const (
+	machine386   = 3
+	machineX8664 = 62
+)
+
+// ArchForMachine returns the architecture for an ELF e_machine value
+func ArchForMachine(machine uint16) (Arch, error) {
+	switch machine {
+	case machine386:
+		return ArchX86, nil
+	case machineX8664:
+		return ArchX86_64, nil
+	}
+	return 0, fmt.Errorf("unsupported machine type %d", machine)
+}
+
+// Inst is one decoded instruction
+type Inst struct {
+	Addr uint64
+	Len  int
+	Op   string   // Mnemonic, including prefixes such as "lock" or "rep"
+	Args []string // Operands in AT&T order (source first)
+
+	// Target is the destination of a relative branch or call
+	Target    uint64
+	HasTarget bool
+
+	// Ref is the address of a RIP-relative memory operand
+	Ref    uint64
+	HasRef bool
+}
+
+// String formats the instruction the way objdump does: the mnemonic padded
+// to six columns followed by the comma-separated operands
+func (i Inst) String() string {
+	if len(i.Args) == 0 {
+		return i.Op
+	}
+	return fmt.Sprintf("%-6s %s", i.Op, strings.Join(i.Args, ","))
+}
+
+// Decode decodes the instruction at the start of code, which is loaded at
+// addr. Undefined opcodes decode as a one-byte "(bad)" instruction; an error
+// is returned only when code ends in the middle of an instruction.
+func Decode(arch Arch, code []byte, addr uint64) (Inst, error) {
+	switch arch {
+	case ArchX86:
+		return decodeX86(code, addr, 32)
+	case ArchX86_64:
+		return decodeX86(code, addr, 64)
+	}
+	return Inst{}, fmt.Errorf("unsupported architecture %d", arch)
+}
@@ -0,0 +1,1234 @@
+package disasm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errTruncated = errors.New("truncated instruction")
+
+// Register names indexed by register number (including REX extensions)
+var (
+	regs64 = []string{"rax", "rcx", "rdx", "rbx", "rsp", "rbp", "rsi", "rdi",
+		"r8", "r9", "r10", "r11", "r12", "r13", "r14", "r15"}
+	regs32 = []string{"eax", "ecx", "edx", "ebx", "esp", "ebp", "esi", "edi",
+		"r8d", "r9d", "r10d", "r11d", "r12d", "r13d", "r14d", "r15d"}
+	regs16 = []string{"ax", "cx", "dx", "bx", "sp", "bp", "si", "di",
+		"r8w", "r9w", "r10w", "r11w", "r12w", "r13w", "r14w", "r15w"}
+	regs8 = []string{"al", "cl", "dl", "bl", "spl", "bpl", "sil", "dil",
+		"r8b", "r9b", "r10b", "r11b", "r12b", "r13b", "r14b", "r15b"}
+	regs8Legacy = []string{"al", "cl", "dl", "bl", "ah", "ch", "dh", "bh"}
+	segRegs     = []string{"es", "cs", "ss", "ds", "fs", "gs", "?", "?"}
+)
+
+// x86Decoder holds the state of one instruction being decoded
+type x86Decoder struct {
+	code []byte
+	pos  int
+	addr uint64
+	mode int // 32 or 64
+	err  error
+
+	// Prefixes
+	opsize int  // number of 0x66 prefixes
+	adsize bool // 0x67
+	lock   bool
+	rep    byte // 0xf3 or 0xf2
+	seg    byte // segment override prefix
+	rex    byte
+
+	usedOpsize bool // an operand-size prefix changed the operand size
+	usedRep    bool
+	usedSeg    bool
+
+	// ModRM
+	hasModRM     bool
+	mod, reg, rm byte
+
+	// Operand bookkeeping
+	regOperand bool // a register operand fixes the operand size
+	memSize    int  // size of the memory operand, 0 if there is none
+	ripRel     bool
+	ripDisp    int64
+
+	vex  bool
+	vexL bool // 256-bit vector length
+	vvvv int
+
+	inst Inst
+}
+
+// decodeX86 decodes one x86 instruction in 32- or 64-bit mode
+func decodeX86(code []byte, addr uint64, mode int) (Inst, error) {
+	// Secure: an x86 instruction is at most 15 bytes long
+	if len(code) > 15 {
+		code = code[:15]
+	}
+
+	d := &x86Decoder{code: code, addr: addr, mode: mode}
+	d.inst.Addr = addr
+
+	d.decode()
+	if d.err != nil {
+		if len(code) == 15 {
+			// Ran past the architectural limit rather than the end of the data
+			return badInst(addr), nil
+		}
+		return Inst{}, d.err
+	}
+
+	d.inst.Len = d.pos
+	if d.ripRel {
+		d.inst.Ref = d.addr + uint64(d.pos) + uint64(d.ripDisp)
+		d.inst.HasRef = true
+	}
+	return d.inst, nil
+}
+
+// badInst is the result for an undefined opcode
+func badInst(addr uint64) Inst {
+	return Inst{Addr: addr, Len: 1, Op: "(bad)"}
+}
+
+// u8 consumes one byte
+func (d *x86Decoder) u8() byte {
+	if d.pos >= len(d.code) {
+		if d.err == nil {
+			d.err = errTruncated
+		}
+		return 0
+	}
+	b := d.code[d.pos]
+	d.pos++
+	return b
+}
+
+// uint reads a little-endian value of size bytes
+func (d *x86Decoder) uint(size int) uint64 {
+	var v uint64
+	for i := 0; i < size; i++ {
+		v |= uint64(d.u8()) << (8 * i)
+	}
+	return v
+}
+
+// signExtend sign-extends the low size bytes of v
+func signExtend(v uint64, size int) int64 {
+	shift := uint(64 - 8*size)
+	return int64(v<<shift) >> shift
+}
+
+// mask truncates v to size bytes
+func mask(v uint64, size int) uint64 {
+	if size >= 8 {
+		return v
+	}
+	return v & (1<<(8*uint(size)) - 1)
+}
+
+// opSize returns the operand size selected by REX.W and 0x66
+func (d *x86Decoder) opSize() int {
+	if d.rex&8 != 0 {
+		return 8
+	}
+	if d.opsize > 0 {
+		d.usedOpsize = true
+		return 2
+	}
+	return 4
+}
+
+// stackSize returns the operand size of stack operations and near branches
+func (d *x86Decoder) stackSize() int {
+	if d.opsize > 0 {
+		d.usedOpsize = true
+		return 2
+	}
+	if d.mode == 64 {
+		return 8
+	}
+	return 4
+}
+
+// addrSize returns the address size in bytes
+func (d *x86Decoder) addrSize() int {
+	if d.mode == 64 {
+		if d.adsize {
+			return 4
+		}
+		return 8
+	}
+	return 4
+}
+
+// modrm reads the ModRM byte once
+func (d *x86Decoder) modrm() {
+	if d.hasModRM {
+		return
+	}
+	b := d.u8()
+	d.mod, d.reg, d.rm = b>>6, (b>>3)&7, b&7
+	d.hasModRM = true
+}
+
+func (d *x86Decoder) regField() int { return int(d.reg) | int(d.rex&4)<<1 }
+func (d *x86Decoder) rmField() int  { return int(d.rm) | int(d.rex&1)<<3 }
+
+// regName returns the AT&T name of a general-purpose register
+func (d *x86Decoder) regName(n, size int) string {
+	switch size {
+	case 1:
+		if d.rex == 0 && n < 8 {
+			return "%" + regs8Legacy[n]
+		}
+		return "%" + regs8[n]
+	case 2:
+		return "%" + regs16[n]
+	case 4:
+		return "%" + regs32[n]
+	}
+	return "%" + regs64[n]
+}
+
+// xmmName returns the name of a vector register
+func (d *x86Decoder) xmmName(n int) string {
+	if d.vexL {
+		return fmt.Sprintf("%%ymm%d", n)
+	}
+	return fmt.Sprintf("%%xmm%d", n)
+}
+
+// formatDisp formats a signed displacement
+func formatDisp(disp int64) string {
+	if disp < 0 {
+		return fmt.Sprintf("-0x%x", uint64(-disp))
+	}
+	return fmt.Sprintf("0x%x", disp)
+}
+
+// memory decodes the memory operand described by ModRM (and SIB)
+func (d *x86Decoder) memory(size int) string {
+	d.memSize = size
+	regSize := d.addrSize()
+
+	base, index := "", ""
+	scale := 1
+	dispSize := 0
+	rip := false
+
+	switch {
+	case d.rm == 4:
+		sib := d.u8()
+		scale = 1 << (sib >> 6)
+		idx := int((sib>>3)&7) | int(d.rex&2)<<2
+		if idx != 4 {
+			index = d.regName(idx, regSize)
+		}
+		if sib&7 == 5 && d.mod == 0 {
+			dispSize = 4 // No base register
+		} else {
+			base = d.regName(int(sib&7)|int(d.rex&1)<<3, regSize)
+		}
+	case d.rm == 5 && d.mod == 0:
+		dispSize = 4
+		if d.mode == 64 {
+			rip = true
+			base = "%rip"
+			if d.adsize {
+				base = "%eip"
+			}
+		}
+	default:
+		base = d.regName(d.rmField(), regSize)
+	}
+
+	switch d.mod {
+	case 1:
+		dispSize = 1
+	case 2:
+		dispSize = 4
+	}
+	var disp int64
+	if dispSize > 0 {
+		disp = signExtend(d.uint(dispSize), dispSize)
+	}
+
+	var b strings.Builder
+	if d.seg != 0 && (d.mode == 32 || d.seg == 0x64 || d.seg == 0x65) {
+		b.WriteString("%" + segmentName(d.seg) + ":")
+		d.usedSeg = true
+	}
+
+	if dispSize > 0 {
+		if base == "" && index == "" {
+			b.WriteString(fmt.Sprintf("0x%x", mask(uint64(disp), regSize)))
+		} else {
+			b.WriteString(formatDisp(disp))
+		}
+	}
+	if rip {
+		d.ripRel = true
+		d.ripDisp = disp
+	}
+
+	if base != "" || index != "" {
+		b.WriteString("(" + base)
+		if index != "" {
+			b.WriteString(fmt.Sprintf(",%s,%d", index, scale))
+		}
+		b.WriteString(")")
+	}
+
+	return b.String()
+}
+
+// segmentName returns the name of a segment override prefix
+func segmentName(prefix byte) string {
+	switch prefix {
+	case 0x26:
+		return "es"
+	case 0x2e:
+		return "cs"
+	case 0x36:
+		return "ss"
+	case 0x3e:
+		return "ds"
+	case 0x64:
+		return "fs"
+	case 0x65:
+		return "gs"
+	}
+	return ""
+}
+
+// sizeOf returns the byte size denoted by an operand size letter
+func (d *x86Decoder) sizeOf(letter byte) int {
+	switch letter {
+	case 'b':
+		return 1
+	case 'w':
+		return 2
+	case 'd':
+		return 4
+	case 'q':
+		return 8
+	case 'y':
+		if d.rex&8 != 0 {
+			return 8
+		}
+		return 4
+	case 's':
+		return d.stackSize()
+	}
+	return d.opSize()
+}
+
+// immediate reads an immediate operand and formats it for an operand of
+// the given size, sign-extending narrower encodings
+func (d *x86Decoder) immediate(encSize, opSize int, signed bool) string {
+	v := d.uint(encSize)
+	if signed {
+		v = uint64(signExtend(v, encSize))
+	}
+	return fmt.Sprintf("$0x%x", mask(v, opSize))
+}
+
+// operand decodes one operand from its specification. Specifications follow
+// the Intel manual's notation: a letter for the addressing method and one
+// for the size, such as Ev (ModRM r/m, operand size) or Ib (8-bit immediate).
+func (d *x86Decoder) operand(spec string) string {
+	indirect := strings.HasPrefix(spec, "*")
+	spec = strings.TrimPrefix(spec, "*")
+
+	var text string
+	switch spec {
+	case "AL":
+		d.regOperand = true
+		return "%al"
+	case "CL":
+		d.regOperand = true
+		return "%cl"
+	case "DX":
+		return "(%dx)"
+	case "rAX":
+		d.regOperand = true
+		return d.regName(0, d.opSize())
+	case "eAX":
+		d.regOperand = true
+		if d.opsize > 0 {
+			d.usedOpsize = true
+			return "%ax"
+		}
+		return "%eax"
+	case "1":
+		return "" // objdump omits the implicit shift count
+	case "Ib":
+		return d.immediate(1, 1, false)
+	case "Iw":
+		return d.immediate(2, 2, false)
+	case "Ibv":
+		return d.immediate(1, d.opSize(), true)
+	case "Ibs":
+		return d.immediate(1, d.stackSize(), true)
+	case "Iz", "Izs":
+		size := d.opSize()
+		if spec == "Izs" {
+			size = d.stackSize()
+		}
+		enc := 4
+		if size == 2 {
+			enc = 2
+		}
+		return d.immediate(enc, size, true)
+	case "Iv":
+		size := d.opSize()
+		return d.immediate(size, size, false)
+	case "Jb", "Jz":
+		enc := 1
+		if spec == "Jz" {
+			enc = 4
+			if d.mode == 32 && d.opsize > 0 {
+				enc = 2
+			}
+		}
+		rel := signExtend(d.uint(enc), enc)
+		target := d.addr + uint64(d.pos) + uint64(rel)
+		if d.mode == 32 {
+			target = mask(target, 4)
+		}
+		d.inst.Target = target
+		d.inst.HasTarget = true
+		return fmt.Sprintf("%x", target)
+	case "M":
+		d.modrm()
+		if d.mod == 3 {
+			d.err = errBadOperand
+			return ""
+		}
+		text = d.memory(d.opSize())
+	case "Sw":
+		d.modrm()
+		return "%" + segRegs[d.reg]
+	case "V":
+		d.modrm()
+		d.regOperand = true
+		return d.xmmName(d.regField())
+	case "U":
+		d.modrm()
+		d.regOperand = true
+		return d.xmmName(d.rmField())
+	case "W":
+		d.modrm()
+		if d.mod == 3 {
+			d.regOperand = true
+			return d.xmmName(d.rmField())
+		}
+		text = d.memory(16)
+	case "H":
+		d.regOperand = true
+		return d.xmmName(d.vvvv)
+	case "X":
+		// Always a 128-bit register or memory, whatever the vector length
+		d.modrm()
+		if d.mod == 3 {
+			d.regOperand = true
+			return fmt.Sprintf("%%xmm%d", d.rmField())
+		}
+		text = d.memory(16)
+	case "XMM0":
+		d.regOperand = true
+		return "%xmm0"
+	case "By":
+		d.regOperand = true
+		return d.regName(d.vvvv, d.sizeOf('y'))
+	default:
+		if len(spec) != 2 {
+			d.err = errBadOperand
+			return ""
+		}
+		size := d.sizeOf(spec[1])
+		switch spec[0] {
+		case 'E':
+			d.modrm()
+			if d.mod == 3 {
+				d.regOperand = true
+				text = d.regName(d.rmField(), size)
+			} else {
+				text = d.memory(size)
+			}
+		case 'G':
+			d.modrm()
+			d.regOperand = true
+			text = d.regName(d.regField(), size)
+		case 'Z':
+			d.regOperand = true
+			text = d.regName(int(d.code[d.pos-1]&7)|int(d.rex&1)<<3, size)
+		case 'O':
+			// Absolute memory offset (moffs)
+			text = fmt.Sprintf("0x%x", d.uint(d.addrSize()))
+			if d.seg != 0 {
+				text = "%" + segmentName(d.seg) + ":" + text
+				d.usedSeg = true
+			}
+			d.memSize = size
+		default:
+			d.err = errBadOperand
+			return ""
+		}
+	}
+
+	if indirect {
+		return "*" + text
+	}
+	return text
+}
+
+var errBadOperand = errors.New("bad operand")
+
+// apply decodes the operands of an instruction given as "name spec,spec"
+// and stores them in AT&T order
+func (d *x86Decoder) apply(form string) {
+	name, specs, _ := strings.Cut(form, " ")
+	args := []string{}
+	if specs != "" {
+		for _, spec := range strings.Split(specs, ",") {
+			if arg := d.operand(spec); arg != "" {
+				args = append(args, arg)
+			}
+			if d.err != nil {
+				return
+			}
+		}
+	}
+
+	// AT&T lists the source operands first
+	for i, j := 0, len(args)-1; i < j; i, j = i+1, j-1 {
+		args[i], args[j] = args[j], args[i]
+	}
+
+	// Memory operands whose size no register implies get a size suffix
+	if d.memSize > 0 && !d.regOperand && !noSuffix[name] {
+		name += sizeSuffix(d.memSize)
+	}
+	if d.memSize > 0 && suffixWithVector[name] {
+		name += sizeSuffix(d.memSize)
+	}
+
+	d.inst.Op = name
+	d.inst.Args = args
+}
+
+// sizeSuffix returns the AT&T suffix for an operand size
+func sizeSuffix(size int) string {
+	switch size {
+	case 1:
+		return "b"
+	case 2:
+		return "w"
+	case 4:
+		return "l"
+	case 8:
+		return "q"
+	}
+	return ""
+}
+
+// decode decodes prefixes, the opcode and the operands
+func (d *x86Decoder) decode() {
+	for d.err == nil {
+		b := d.u8()
+		switch b {
+		case 0x66:
+			d.opsize++
+			continue
+		case 0x67:
+			d.adsize = true
+			continue
+		case 0xf0:
+			d.lock = true
+			continue
+		case 0xf2, 0xf3:
+			d.rep = b
+			continue
+		case 0x26, 0x2e, 0x36, 0x3e, 0x64, 0x65:
+			d.seg = b
+			continue
+		}
+		if d.mode == 64 && b >= 0x40 && b <= 0x4f {
+			d.rex = b
+			b = d.u8()
+		}
+		if d.err != nil {
+			return
+		}
+		d.opcode(b)
+		break
+	}
+	if d.err == errBadOperand {
+		d.err = nil
+		d.inst = badInst(d.addr)
+		d.pos = 1
+		return
+	}
+	if d.err != nil {
+		return
+	}
+	d.addPrefixes()
+}
+
+// addPrefixes prepends prefixes that did not change the meaning of the
+// instruction to the mnemonic, as objdump does
+func (d *x86Decoder) addPrefixes() {
+	if d.inst.Op == "(bad)" {
+		return
+	}
+
+	prefixes := []string{}
+	extra := d.opsize
+	if d.usedOpsize {
+		extra--
+	}
+	for i := 0; i < extra; i++ {
+		prefixes = append(prefixes, "data16")
+	}
+	if d.seg != 0 && !d.usedSeg {
+		indirect := len(d.inst.Args) == 1 && strings.HasPrefix(d.inst.Args[0], "*")
+		if d.seg == 0x3e && indirect {
+			prefixes = append(prefixes, "notrack")
+		} else {
+			prefixes = append(prefixes, segmentName(d.seg))
+		}
+	}
+	if d.lock {
+		prefixes = append(prefixes, "lock")
+	}
+	if d.rep != 0 && !d.usedRep {
+		switch {
+		case d.rep == 0xf2 && isBranch(d.inst.Op):
+			prefixes = append(prefixes, "bnd")
+		case d.rep == 0xf2:
+			prefixes = append(prefixes, "repnz")
+		default:
+			prefixes = append(prefixes, "repz")
+		}
+	}
+
+	if len(prefixes) > 0 {
+		d.inst.Op = strings.Join(prefixes, " ") + " " + d.inst.Op
+	}
+}
+
+// isBranch reports whether a mnemonic transfers control
+func isBranch(op string) bool {
+	return op == "call" || op == "ret" || strings.HasPrefix(op, "j")
+}
+
+// opcode decodes a one-byte opcode
+func (d *x86Decoder) opcode(b byte) {
+	switch {
+	case b == 0x0f:
+		d.twoByte(d.u8())
+		return
+	case d.mode == 64 && (b == 0xc4 || b == 0xc5):
+		d.vexPrefix(b)
+		return
+	case d.mode == 64 && b == 0x62:
+		d.evexPrefix()
+		return
+	case d.mode == 32 && b >= 0x40 && b <= 0x4f:
+		if b < 0x48 {
+			d.apply("inc Zv")
+		} else {
+			d.apply("dec Zv")
+		}
+		return
+	case b >= 0x70 && b <= 0x7f:
+		d.apply("j" + conditions[b&0xf] + " Jb")
+		return
+	case b >= 0xd8 && b <= 0xdf:
+		d.x87(b)
+		return
+	}
+
+	switch b {
+	case 0x63:
+		if d.mode == 64 && d.rex&8 != 0 {
+			d.apply("movslq Gv,Ed")
+		} else if d.mode == 64 {
+			d.apply("movsxd Gv,Ed")
+		} else {
+			d.apply("arpl Ew,Gw")
+		}
+		return
+	case 0x90:
+		switch {
+		case d.rex&1 != 0:
+			d.apply("xchg Zv,rAX")
+		case d.rep == 0xf3:
+			d.usedRep = true
+			d.apply("pause")
+		case d.opsize > 0:
+			d.apply("xchg rAX,rAX")
+		default:
+			d.apply("nop")
+		}
+		return
+	case 0x98:
+		d.apply([]string{"", "", "cbtw", "", "cwtl", "", "", "", "cltq"}[d.opSize()])
+		return
+	case 0x99:
+		d.apply([]string{"", "", "cwtd", "", "cltd", "", "", "", "cqto"}[d.opSize()])
+		return
+	case 0xa4, 0xa5, 0xa6, 0xa7, 0xaa, 0xab, 0xac, 0xad, 0xae, 0xaf:
+		d.stringOp(b)
+		return
+	case 0xb8, 0xb9, 0xba, 0xbb, 0xbc, 0xbd, 0xbe, 0xbf:
+		if d.rex&8 != 0 {
+			d.apply("movabs Zv,Iv")
+		} else {
+			d.apply("mov Zv,Iv")
+		}
+		return
+	case 0xa0, 0xa1, 0xa2, 0xa3:
+		name := "mov"
+		if d.mode == 64 {
+			name = "movabs"
+		}
+		d.apply(name + " " + []string{"AL,Ob", "rAX,Ov", "Ob,AL", "Ov,rAX"}[b-0xa0])
+		d.regOperand = true
+		return
+	case 0xe3:
+		if d.mode == 64 {
+			d.apply("jrcxz Jb")
+		} else {
+			d.apply("jecxz Jb")
+		}
+		return
+	}
+
+	form, ok := oneByteOps[b]
+	if !ok {
+		d.err = errBadOperand
+		return
+	}
+
+	if group, ok := groups[strings.SplitN(form, " ", 2)[0]]; ok {
+		d.modrm()
+		entry := group[d.reg]
+		if entry == "" {
+			d.err = errBadOperand
+			return
+		}
+		// Group entries without operands inherit those of the opcode
+		if !strings.Contains(entry, " ") {
+			if _, specs, found := strings.Cut(form, " "); found {
+				entry += " " + specs
+			}
+		}
+		d.apply(entry)
+		return
+	}
+
+	d.apply(form)
+}
+
+// stringOp decodes the string instructions (movs, cmps, stos, lods, scas)
+func (d *x86Decoder) stringOp(b byte) {
+	size := 1
+	if b&1 != 0 {
+		size = d.opSize()
+	}
+
+	src, dst := "%ds:(%rsi)", "%es:(%rdi)"
+	if d.mode == 32 || d.adsize {
+		src, dst = "%ds:(%esi)", "%es:(%edi)"
+	}
+	if d.seg != 0 {
+		src = "%" + segmentName(d.seg) + strings.TrimPrefix(src, "%ds")
+		d.usedSeg = true
+	}
+	acc := d.regName(0, size)
+
+	var name string
+	var args []string
+	repeat := "rep"
+	switch b &^ 1 {
+	case 0xa4:
+		name, args = "movs"+sizeSuffix(size), []string{src, dst}
+	case 0xa6:
+		name, args, repeat = "cmps"+sizeSuffix(size), []string{dst, src}, "repz"
+	case 0xaa:
+		name, args = "stos", []string{acc, dst}
+	case 0xac:
+		name, args = "lods", []string{src, acc}
+	case 0xae:
+		name, args, repeat = "scas", []string{dst, acc}, "repz"
+	}
+
+	switch d.rep {
+	case 0xf3:
+		name = repeat + " " + name
+		d.usedRep = true
+	case 0xf2:
+		name = "repnz " + name
+		d.usedRep = true
+	}
+
+	d.inst.Op = name
+	d.inst.Args = args
+}
+
+// twoByte decodes an opcode following 0x0f
+func (d *x86Decoder) twoByte(b byte) {
+	switch {
+	case b >= 0x40 && b <= 0x4f:
+		d.apply("cmov" + conditions[b&0xf] + " Gv,Ev")
+		return
+	case b >= 0x80 && b <= 0x8f:
+		d.apply("j" + conditions[b&0xf] + " Jz")
+		return
+	case b >= 0x90 && b <= 0x9f:
+		d.apply("set" + conditions[b&0xf] + " Eb")
+		return
+	case b >= 0xc8 && b <= 0xcf:
+		d.apply("bswap Zv")
+		return
+	}
+
+	switch b {
+	case 0x01:
+		d.modrm()
+		switch {
+		case d.mod == 3 && d.reg == 2 && d.rm == 0:
+			d.apply("xgetbv")
+		case d.mod == 3 && d.reg == 7 && d.rm == 0:
+			d.apply("swapgs")
+		case d.mod == 3 && d.reg == 7 && d.rm == 1:
+			d.apply("rdtscp")
+		default:
+			d.err = errBadOperand
+		}
+		return
+	case 0x1e:
+		d.modrm()
+		if d.rep == 0xf3 && d.mod == 3 && d.reg == 7 && (d.rm == 2 || d.rm == 3) {
+			d.usedRep = true
+			d.apply([]string{"endbr64", "endbr32"}[d.rm-2])
+			return
+		}
+		d.apply("nop Ev")
+		return
+	case 0x38, 0x3a:
+		d.threeByte(b)
+		return
+	case 0xc2:
+		d.compare()
+		return
+	case 0xae:
+		d.modrm()
+		if d.mod == 3 {
+			switch d.reg {
+			case 5:
+				d.apply("lfence")
+			case 6:
+				d.apply("mfence")
+			case 7:
+				d.apply("sfence")
+			default:
+				d.err = errBadOperand
+			}
+			return
+		}
+		name := []string{"fxsave", "fxrstor", "ldmxcsr", "stmxcsr", "xsave", "xrstor", "xsaveopt", "clflush"}[d.reg]
+		d.apply(name + " M")
+		return
+	case 0xb8:
+		if d.rep != 0xf3 {
+			d.err = errBadOperand
+			return
+		}
+		d.usedRep = true
+		d.apply("popcnt Gv,Ev")
+		return
+	case 0xbc, 0xbd:
+		if d.rep == 0xf3 {
+			d.usedRep = true
+			d.apply([]string{"tzcnt", "lzcnt"}[b-0xbc] + " Gv,Ev")
+			return
+		}
+		d.apply([]string{"bsf", "bsr"}[b-0xbc] + " Gv,Ev")
+		return
+	case 0xb6, 0xb7, 0xbe, 0xbf:
+		src := 1
+		if b&1 != 0 {
+			src = 2
+		}
+		name := "movz"
+		if b >= 0xbe {
+			name = "movs"
+		}
+		name += sizeSuffix(src) + sizeSuffix(d.opSize())
+		d.apply(name + " Gv,E" + []string{"", "b", "w"}[src])
+		return
+	}
+
+	if forms, ok := sseOps[b]; ok {
+		d.sse(b, forms)
+		return
+	}
+
+	form, ok := twoByteOps[b]
+	if !ok {
+		d.err = errBadOperand
+		return
+	}
+
+	if group, ok := groups[strings.SplitN(form, " ", 2)[0]]; ok {
+		d.modrm()
+		entry := group[d.reg]
+		if entry == "" {
+			d.err = errBadOperand
+			return
+		}
+		if !strings.Contains(entry, " ") {
+			if _, specs, found := strings.Cut(form, " "); found {
+				entry += " " + specs
+			}
+		}
+		d.apply(entry)
+		return
+	}
+
+	d.apply(form)
+}
+
+// prefixIndex selects the column of an SSE table from the mandatory prefix:
+// none, 0x66, 0xf3 or 0xf2
+func (d *x86Decoder) prefixIndex() int {
+	switch {
+	case d.rep == 0xf3:
+		d.usedRep = true
+		return 2
+	case d.rep == 0xf2:
+		d.usedRep = true
+		return 3
+	case d.opsize > 0:
+		d.usedOpsize = true
+		return 1
+	}
+	return 0
+}
+
+// sse decodes an SSE instruction whose meaning depends on the mandatory prefix
+func (d *x86Decoder) sse(b byte, forms [4]string) {
+	index := d.prefixIndex()
+	form := forms[index]
+	if form == "" {
+		d.err = errBadOperand
+		return
+	}
+
+	// movd and movq share an opcode distinguished by REX.W
+	if strings.HasPrefix(form, "movd/q") {
+		name := "movd"
+		if d.rex&8 != 0 {
+			name = "movq"
+		}
+		form = name + strings.TrimPrefix(form, "movd/q")
+	}
+
+	// Register forms of the low/high moves are different instructions
+	if index == 0 && (b == 0x12 || b == 0x16) {
+		d.modrm()
+		if d.mod == 3 {
+			form = map[byte]string{0x12: "movhlps V,U", 0x16: "movlhps V,U"}[b]
+		}
+	}
+
+	// Shift-by-immediate groups select the operation with ModRM.reg
+	if b >= 0x71 && b <= 0x73 {
+		d.modrm()
+		names := map[byte][8]string{
+			0x71: {2: "psrlw", 4: "psraw", 6: "psllw"},
+			0x72: {2: "psrld", 4: "psrad", 6: "pslld"},
+			0x73: {2: "psrlq", 3: "psrldq", 6: "psllq", 7: "pslldq"},
+		}[b]
+		if names[d.reg] == "" || d.mod != 3 {
+			d.err = errBadOperand
+			return
+		}
+		form = names[d.reg] + " U,Ib"
+		if d.vex {
+			// The VEX form writes the register named by VEX.vvvv
+			form = "v" + names[d.reg] + " H,U,Ib"
+		}
+	}
+
+	d.applySIMD(form)
+}
+
+// applySIMD decodes a vector instruction, converting it to its VEX form
+// when needed and folding comparison predicates into the mnemonic
+func (d *x86Decoder) applySIMD(form string) {
+	if d.vex && !strings.HasPrefix(form, "v") {
+		form = vexForm(form)
+	}
+
+	name, specs, _ := strings.Cut(form, " ")
+	if !strings.HasSuffix(specs, ",Ip") {
+		d.apply(form)
+		return
+	}
+
+	// The immediate selects a predicate that objdump shows in the mnemonic
+	d.apply(name + " " + strings.TrimSuffix(specs, ",Ip"))
+	if d.err != nil {
+		return
+	}
+	imm := d.u8()
+	base := strings.TrimPrefix(strings.TrimPrefix(name, "v"), "cmp")
+
+	var predicate string
+	switch {
+	case base == "pclmulqdq":
+		predicate = map[byte]string{0x00: "lqlq", 0x01: "hqlq", 0x10: "lqhq", 0x11: "hqhq"}[imm]
+		if predicate != "" {
+			d.inst.Op = strings.TrimSuffix(d.inst.Op, "qdq") + predicate + "dq"
+		}
+	case imm < 8:
+		predicate = []string{"eq", "lt", "le", "unord", "neq", "nlt", "nle", "ord"}[imm]
+		d.inst.Op = strings.TrimSuffix(d.inst.Op, base) + predicate + base
+	}
+	if predicate == "" {
+		d.inst.Args = append([]string{fmt.Sprintf("$0x%x", imm)}, d.inst.Args...)
+	}
+}
+
+// vexForm converts an SSE form into its VEX-encoded equivalent: the mnemonic
+// gains a "v" prefix and non-move operations take an extra source register
+func vexForm(form string) string {
+	name, specs, _ := strings.Cut(form, " ")
+	if !strings.HasPrefix(name, "mov") && !strings.HasPrefix(name, "cvtt") &&
+		!strings.HasPrefix(name, "ucomi") && !strings.HasPrefix(name, "comi") &&
+		!strings.HasPrefix(name, "pshuf") && !strings.HasPrefix(name, "pmovmsk") &&
+		!strings.HasPrefix(name, "ptest") && strings.HasPrefix(specs, "V,") {
+		specs = "V,H," + strings.TrimPrefix(specs, "V,")
+	}
+	return "v" + name + " " + specs
+}
+
+// skip decodes the length of an instruction this decoder cannot name, so
+// that the following instructions stay in sync
+func (d *x86Decoder) skip(immSize int) {
+	d.modrm()
+	if d.mod != 3 {
+		d.memory(16)
+	}
+	d.uint(immSize)
+	d.inst.Op = "(bad)"
+	d.inst.Args = nil
+}
+
+// vexPrefix decodes a VEX-encoded (AVX) instruction
+func (d *x86Decoder) vexPrefix(b byte) {
+	d.vex = true
+	pp := byte(0)
+	mapSelect := byte(1)
+
+	p1 := d.u8()
+	if b == 0xc5 {
+		// Two-byte VEX: R vvvv L pp, map 0F
+		if p1&0x80 == 0 {
+			d.rex |= 4
+		}
+		d.vvvv = int(^p1>>3) & 0xf
+		d.vexL = p1&4 != 0
+		pp = p1 & 3
+	} else {
+		p2 := d.u8()
+		if p1&0x80 == 0 {
+			d.rex |= 4
+		}
+		if p1&0x40 == 0 {
+			d.rex |= 2
+		}
+		if p1&0x20 == 0 {
+			d.rex |= 1
+		}
+		mapSelect = p1 & 0x1f
+		if p2&0x80 != 0 {
+			d.rex |= 8
+		}
+		d.vvvv = int(^p2>>3) & 0xf
+		d.vexL = p2&4 != 0
+		pp = p2 & 3
+	}
+	if d.rex != 0 {
+		d.rex |= 0x40
+	}
+	d.setImpliedPrefix(pp)
+
+	op := d.u8()
+	if d.err != nil {
+		return
+	}
+
+	switch mapSelect {
+	case 1:
+		if op == 0x77 && pp == 0 {
+			if d.vexL {
+				d.apply("vzeroall")
+			} else {
+				d.apply("vzeroupper")
+			}
+			return
+		}
+		if forms, ok := sseOps[op]; ok {
+			d.sse(op, forms)
+		} else if op == 0xc2 {
+			d.compare()
+		} else if op >= 0x70 && op <= 0x73 || op >= 0xc4 && op <= 0xc6 {
+			d.skip(1)
+		} else {
+			d.skip(0)
+		}
+	case 2, 3:
+		d.threeByteOp(mapSelect, op)
+	default:
+		d.err = errBadOperand
+	}
+}
+
+// setImpliedPrefix applies the mandatory prefix encoded in a VEX or EVEX
+// pp field
+func (d *x86Decoder) setImpliedPrefix(pp byte) {
+	switch pp {
+	case 1:
+		d.opsize = 1
+	case 2:
+		d.rep = 0xf3
+	case 3:
+		d.rep = 0xf2
+	}
+}
+
+// evexPrefix decodes the length of an EVEX-encoded (AVX-512) instruction
+func (d *x86Decoder) evexPrefix() {
+	p0 := d.u8()
+	p1 := d.u8()
+	d.u8()
+	if p0&0x40 == 0 {
+		d.rex |= 2
+	}
+	if p0&0x20 == 0 {
+		d.rex |= 1
+	}
+	d.setImpliedPrefix(p1 & 3)
+
+	op := d.u8()
+	immSize := 0
+	switch p0 & 7 {
+	case 1:
+		if op >= 0x70 && op <= 0x73 || op == 0xc2 || op >= 0xc4 && op <= 0xc6 {
+			immSize = 1
+		}
+	case 3:
+		immSize = 1
+	}
+	d.skip(immSize)
+	d.usedOpsize, d.usedRep = true, true
+}
+
+// threeByte decodes opcodes following 0x0f 0x38 and 0x0f 0x3a
+func (d *x86Decoder) threeByte(escape byte) {
+	op := d.u8()
+	if d.err != nil {
+		return
+	}
+	mapSelect := byte(2)
+	if escape == 0x3a {
+		mapSelect = 3
+	}
+	d.threeByteOp(mapSelect, op)
+}
+
+// threeByteOp decodes an instruction of the 0F38 (map 2) or 0F3A (map 3)
+// opcode maps, in legacy or VEX encoding
+func (d *x86Decoder) threeByteOp(mapSelect, op byte) {
+	table := threeByte38
+	immSize := 0
+	if mapSelect == 3 {
+		table = threeByte3A
+		immSize = 1
+	}
+
+	if forms, ok := table[op]; ok {
+		form := forms[d.prefixIndex()]
+		// Entries named with a leading "v" and BMI entries exist only in VEX form
+		vexOnly := strings.HasPrefix(form, "v") || isGeneralVEX(form)
+		if form != "" && (d.vex || !vexOnly) {
+			if name, rest, found := strings.Cut(form, "d/q"); found {
+				if d.rex&8 != 0 {
+					form = name + "q" + rest
+				} else {
+					form = name + "d" + rest
+				}
+			}
+			if isGeneralVEX(form) {
+				d.apply(form)
+			} else {
+				d.applySIMD(form)
+			}
+			return
+		}
+	}
+
+	d.prefixIndex()
+	d.skip(immSize)
+}
+
+// isGeneralVEX reports whether a form is a VEX-encoded general-purpose
+// register instruction (BMI), which keeps its name and operands as given
+func isGeneralVEX(form string) bool {
+	name, _, _ := strings.Cut(form, " ")
+	return bmiOps[name]
+}
+
+// compare decodes the packed and scalar comparisons (0x0f 0xc2)
+func (d *x86Decoder) compare() {
+	name := []string{"cmpps", "cmppd", "cmpss", "cmpsd"}[d.prefixIndex()]
+	d.applySIMD(name + " V,W,Ip")
+}
+
+// x87 decodes a floating-point instruction
+func (d *x86Decoder) x87(b byte) {
+	d.modrm()
+	if d.mod != 3 {
+		name := x87Memory[b-0xd8][d.reg]
+		if name == "" {
+			d.err = errBadOperand
+			return
+		}
+		d.inst.Op = name
+		d.inst.Args = []string{d.memory(0)}
+		return
+	}
+
+	if form := x87Stack[b-0xd8][d.reg]; form != "" {
+		name, specs, _ := strings.Cut(form, " ")
+		d.inst.Op = name
+		d.inst.Args = strings.Split(strings.ReplaceAll(specs, "(i)", fmt.Sprintf("(%d)", d.rm)), ",")
+		return
+	}
+
+	name, ok := x87Register[uint16(b)<<8|uint16(0xc0|d.reg<<3|d.rm)]
+	if !ok {
+		d.err = errBadOperand
+		return
+	}
+	d.inst.Op = name
+	if name == "fnstsw" {
+		d.inst.Args = []string{"%ax"}
+	}
+}
@@ -0,0 +1,315 @@
+package disasm
+
+// conditions are the condition-code suffixes of jcc, setcc and cmovcc
+var conditions = []string{"o", "no", "b", "ae", "e", "ne", "be", "a",
+	"s", "ns", "p", "np", "l", "ge", "le", "g"}
+
+// oneByteOps maps one-byte opcodes to "mnemonic operands" forms. Operands
+// use Intel order and Intel-manual notation; a mnemonic starting with "grp"
+// selects an entry of groups with ModRM.reg.
+var oneByteOps = map[byte]string{
+	0x68: "push Izs", 0x69: "imul Gv,Ev,Iz", 0x6a: "push Ibs", 0x6b: "imul Gv,Ev,Ibv",
+	0x80: "grp1 Eb,Ib", 0x81: "grp1 Ev,Iz", 0x83: "grp1 Ev,Ibv",
+	0x84: "test Eb,Gb", 0x85: "test Ev,Gv", 0x86: "xchg Eb,Gb", 0x87: "xchg Ev,Gv",
+	0x88: "mov Eb,Gb", 0x89: "mov Ev,Gv", 0x8a: "mov Gb,Eb", 0x8b: "mov Gv,Ev",
+	0x8c: "mov Ev,Sw", 0x8d: "lea Gv,M", 0x8e: "mov Sw,Ew", 0x8f: "grp1a",
+	0x9b: "fwait", 0x9c: "pushf", 0x9d: "popf", 0x9e: "sahf", 0x9f: "lahf",
+	0xa8: "test AL,Ib", 0xa9: "test rAX,Iz",
+	0xc0: "grp2 Eb,Ib", 0xc1: "grp2 Ev,Ib", 0xc2: "ret Iw", 0xc3: "ret",
+	0xc6: "grp11 Eb,Ib", 0xc7: "grp11 Ev,Iz", 0xc8: "enter Iw,Ib", 0xc9: "leave",
+	0xca: "lret Iw", 0xcb: "lret", 0xcc: "int3", 0xcd: "int Ib",
+	0xd0: "grp2 Eb,1", 0xd1: "grp2 Ev,1", 0xd2: "grp2 Eb,CL", 0xd3: "grp2 Ev,CL",
+	0xe0: "loopne Jb", 0xe1: "loope Jb", 0xe2: "loop Jb",
+	0xe4: "in AL,Ib", 0xe5: "in eAX,Ib", 0xe6: "out Ib,AL", 0xe7: "out Ib,eAX",
+	0xe8: "call Jz", 0xe9: "jmp Jz", 0xeb: "jmp Jb",
+	0xec: "in AL,DX", 0xed: "in eAX,DX", 0xee: "out DX,AL", 0xef: "out DX,eAX",
+	0xf4: "hlt", 0xf5: "cmc", 0xf6: "grp3b", 0xf7: "grp3v",
+	0xf8: "clc", 0xf9: "stc", 0xfa: "cli", 0xfb: "sti", 0xfc: "cld", 0xfd: "std",
+	0xfe: "grp4", 0xff: "grp5",
+}
+
+// twoByteOps maps opcodes following 0x0f that are not handled specially
+var twoByteOps = map[byte]string{
+	0x05: "syscall", 0x0b: "ud2", 0x0d: "grpP", 0x18: "grp16", 0x1f: "nop Ev",
+	0x31: "rdtsc", 0xa2: "cpuid",
+	0xa3: "bt Ev,Gv", 0xa4: "shld Ev,Gv,Ib", 0xa5: "shld Ev,Gv,CL",
+	0xab: "bts Ev,Gv", 0xac: "shrd Ev,Gv,Ib", 0xad: "shrd Ev,Gv,CL", 0xaf: "imul Gv,Ev",
+	0xb0: "cmpxchg Eb,Gb", 0xb1: "cmpxchg Ev,Gv", 0xb3: "btr Ev,Gv",
+	0xba: "grp8 Ev,Ib", 0xbb: "btc Ev,Gv", 0xc0: "xadd Eb,Gb", 0xc1: "xadd Ev,Gv",
+}
+
+// groups holds the opcode extensions selected by ModRM.reg. Entries without
+// operands take the operands of the opcode that selected the group.
+var groups = map[string][8]string{
+	"grp1":  {"add", "or", "adc", "sbb", "and", "sub", "xor", "cmp"},
+	"grp1a": {"pop Es"},
+	"grp2":  {"rol", "ror", "rcl", "rcr", "shl", "shr", "shl", "sar"},
+	"grp3b": {"test Eb,Ib", "test Eb,Ib", "not Eb", "neg Eb", "mul Eb", "imul Eb", "div Eb", "idiv Eb"},
+	"grp3v": {"test Ev,Iz", "test Ev,Iz", "not Ev", "neg Ev", "mul Ev", "imul Ev", "div Ev", "idiv Ev"},
+	"grp4":  {"inc Eb", "dec Eb"},
+	"grp5":  {"inc Ev", "dec Ev", "call *Es", "lcall *M", "jmp *Es", "ljmp *M", "push Es"},
+	"grp11": {"mov"},
+	"grp8":  {4: "bt", 5: "bts", 6: "btr", 7: "btc"},
+	"grp16": {"prefetchnta M", "prefetcht0 M", "prefetcht1 M", "prefetcht2 M"},
+	"grpP":  {1: "prefetchw M"},
+}
+
+// sseOps maps opcodes following 0x0f to their forms without a mandatory
+// prefix and with 0x66, 0xf3 and 0xf2. V is the ModRM.reg vector register,
+// W a vector register or memory, U a vector register in ModRM.rm and H the
+// extra VEX source register.
+var sseOps = map[byte][4]string{
+	0x10: {"movups V,W", "movupd V,W", "movss V,W", "movsd V,W"},
+	0x11: {"movups W,V", "movupd W,V", "movss W,V", "movsd W,V"},
+	0x12: {"movlps V,W", "movlpd V,W", "movsldup V,W", "movddup V,W"},
+	0x13: {"movlps W,V", "movlpd W,V", "", ""},
+	0x14: {"unpcklps V,W", "unpcklpd V,W", "", ""},
+	0x15: {"unpckhps V,W", "unpckhpd V,W", "", ""},
+	0x16: {"movhps V,W", "movhpd V,W", "movshdup V,W", ""},
+	0x17: {"movhps W,V", "movhpd W,V", "", ""},
+	0x28: {"movaps V,W", "movapd V,W", "", ""},
+	0x29: {"movaps W,V", "movapd W,V", "", ""},
+	0x2a: {"", "", "cvtsi2ss V,Ey", "cvtsi2sd V,Ey"},
+	0x2b: {"movntps W,V", "movntpd W,V", "", ""},
+	0x2c: {"", "", "cvttss2si Gy,W", "cvttsd2si Gy,W"},
+	0x2d: {"", "", "cvtss2si Gy,W", "cvtsd2si Gy,W"},
+	0x2e: {"ucomiss V,W", "ucomisd V,W", "", ""},
+	0x2f: {"comiss V,W", "comisd V,W", "", ""},
+	0x50: {"movmskps Gd,U", "movmskpd Gd,U", "", ""},
+	0x51: {"sqrtps V,W", "sqrtpd V,W", "sqrtss V,W", "sqrtsd V,W"},
+	0x52: {"rsqrtps V,W", "", "rsqrtss V,W", ""},
+	0x53: {"rcpps V,W", "", "rcpss V,W", ""},
+	0x54: {"andps V,W", "andpd V,W", "", ""},
+	0x55: {"andnps V,W", "andnpd V,W", "", ""},
+	0x56: {"orps V,W", "orpd V,W", "", ""},
+	0x57: {"xorps V,W", "xorpd V,W", "", ""},
+	0x58: {"addps V,W", "addpd V,W", "addss V,W", "addsd V,W"},
+	0x59: {"mulps V,W", "mulpd V,W", "mulss V,W", "mulsd V,W"},
+	0x5a: {"cvtps2pd V,W", "cvtpd2ps V,W", "cvtss2sd V,W", "cvtsd2ss V,W"},
+	0x5b: {"cvtdq2ps V,W", "cvtps2dq V,W", "cvttps2dq V,W", ""},
+	0x5c: {"subps V,W", "subpd V,W", "subss V,W", "subsd V,W"},
+	0x5d: {"minps V,W", "minpd V,W", "minss V,W", "minsd V,W"},
+	0x5e: {"divps V,W", "divpd V,W", "divss V,W", "divsd V,W"},
+	0x5f: {"maxps V,W", "maxpd V,W", "maxss V,W", "maxsd V,W"},
+	0x6e: {"", "movd/q V,Ey", "", ""},
+	0x6f: {"", "movdqa V,W", "movdqu V,W", ""},
+	0x70: {"", "pshufd V,W,Ib", "pshufhw V,W,Ib", "pshuflw V,W,Ib"},
+	0x71: {"", "grp12", "", ""},
+	0x72: {"", "grp13", "", ""},
+	0x73: {"", "grp14", "", ""},
+	0x7e: {"", "movd/q Ey,V", "movq V,W", ""},
+	0x7f: {"", "movdqa W,V", "movdqu W,V", ""},
+	0xc6: {"shufps V,W,Ib", "shufpd V,W,Ib", "", ""},
+	0xc4: {"", "pinsrw V,Ed,Ib", "", ""},
+	0xc5: {"", "pextrw Gd,U,Ib", "", ""},
+	0xd6: {"", "movq W,V", "", ""},
+	0xd7: {"", "pmovmskb Gd,U", "", ""},
+	0xe7: {"", "movntdq W,V", "", ""},
+}
+
+// threeByte38 holds the 0x0f 0x38 opcode map in the layout of sseOps.
+// Mnemonics starting with "v" exist only in VEX form.
+var threeByte38 = map[byte][4]string{
+	0x00: {"", "pshufb V,W", "", ""},
+	0x01: {"", "phaddw V,W", "", ""},
+	0x02: {"", "phaddd V,W", "", ""},
+	0x04: {"", "pmaddubsw V,W", "", ""},
+	0x08: {"", "psignb V,W", "", ""},
+	0x0b: {"", "pmulhrsw V,W", "", ""},
+	0x17: {"", "ptest V,W", "", ""},
+	0x18: {"", "vbroadcastss V,X", "", ""},
+	0x19: {"", "vbroadcastsd V,X", "", ""},
+	0x1c: {"", "pabsb V,W", "", ""},
+	0x1d: {"", "pabsw V,W", "", ""},
+	0x1e: {"", "pabsd V,W", "", ""},
+	0x20: {"", "pmovsxbw V,X", "", ""},
+	0x21: {"", "pmovsxbd V,X", "", ""},
+	0x22: {"", "pmovsxbq V,X", "", ""},
+	0x23: {"", "pmovsxwd V,X", "", ""},
+	0x24: {"", "pmovsxwq V,X", "", ""},
+	0x25: {"", "pmovsxdq V,X", "", ""},
+	0x28: {"", "pmuldq V,W", "", ""},
+	0x29: {"", "pcmpeqq V,W", "", ""},
+	0x2b: {"", "packusdw V,W", "", ""},
+	0x30: {"", "pmovzxbw V,X", "", ""},
+	0x31: {"", "pmovzxbd V,X", "", ""},
+	0x32: {"", "pmovzxbq V,X", "", ""},
+	0x33: {"", "pmovzxwd V,X", "", ""},
+	0x34: {"", "pmovzxwq V,X", "", ""},
+	0x35: {"", "pmovzxdq V,X", "", ""},
+	0x36: {"", "vpermd V,H,W", "", ""},
+	0x37: {"", "pcmpgtq V,W", "", ""},
+	0x38: {"", "pminsb V,W", "", ""},
+	0x39: {"", "pminsd V,W", "", ""},
+	0x3a: {"", "pminuw V,W", "", ""},
+	0x3b: {"", "pminud V,W", "", ""},
+	0x3c: {"", "pmaxsb V,W", "", ""},
+	0x3d: {"", "pmaxsd V,W", "", ""},
+	0x3e: {"", "pmaxuw V,W", "", ""},
+	0x3f: {"", "pmaxud V,W", "", ""},
+	0x40: {"", "pmulld V,W", "", ""},
+	0x45: {"", "vpsrlvd/q V,H,W", "", ""},
+	0x46: {"", "vpsravd V,H,W", "", ""},
+	0x47: {"", "vpsllvd/q V,H,W", "", ""},
+	0x58: {"", "vpbroadcastd V,X", "", ""},
+	0x59: {"", "vpbroadcastq V,X", "", ""},
+	0x5a: {"", "vbroadcasti128 V,X", "", ""},
+	0x78: {"", "vpbroadcastb V,X", "", ""},
+	0x79: {"", "vpbroadcastw V,X", "", ""},
+	0xc8: {"sha1nexte V,W", "", "", ""},
+	0xc9: {"sha1msg1 V,W", "", "", ""},
+	0xca: {"sha1msg2 V,W", "", "", ""},
+	0xcb: {"sha256rnds2 V,W,XMM0", "", "", ""},
+	0xcc: {"sha256msg1 V,W", "", "", ""},
+	0xcd: {"sha256msg2 V,W", "", "", ""},
+	0xdb: {"", "aesimc V,W", "", ""},
+	0xdc: {"", "aesenc V,W", "", ""},
+	0xdd: {"", "aesenclast V,W", "", ""},
+	0xde: {"", "aesdec V,W", "", ""},
+	0xdf: {"", "aesdeclast V,W", "", ""},
+	0xf0: {"movbe Gv,M", "", "", ""},
+	0xf1: {"movbe M,Gv", "", "", ""},
+	0xf2: {"andn Gy,By,Ey", "", "", ""},
+	0xf5: {"bzhi Gy,Ey,By", "", "pext Gy,By,Ey", "pdep Gy,By,Ey"},
+	0xf6: {"", "adcx Gy,Ey", "adox Gy,Ey", "mulx Gy,By,Ey"},
+	0xf7: {"bextr Gy,Ey,By", "shlx Gy,Ey,By", "sarx Gy,Ey,By", "shrx Gy,Ey,By"},
+}
+
+// threeByte3A holds the 0x0f 0x3a opcode map; every entry takes an 8-bit
+// immediate. Ip is an immediate that objdump folds into the mnemonic.
+var threeByte3A = map[byte][4]string{
+	0x00: {"", "vpermq V,W,Ib", "", ""},
+	0x01: {"", "vpermpd V,W,Ib", "", ""},
+	0x02: {"", "vpblendd V,H,W,Ib", "", ""},
+	0x06: {"", "vperm2f128 V,H,W,Ib", "", ""},
+	0x08: {"", "roundps V,W,Ib", "", ""},
+	0x09: {"", "roundpd V,W,Ib", "", ""},
+	0x0a: {"", "roundss V,W,Ib", "", ""},
+	0x0b: {"", "roundsd V,W,Ib", "", ""},
+	0x0c: {"", "blendps V,W,Ib", "", ""},
+	0x0d: {"", "blendpd V,W,Ib", "", ""},
+	0x0e: {"", "pblendw V,W,Ib", "", ""},
+	0x0f: {"", "palignr V,W,Ib", "", ""},
+	0x14: {"", "pextrb Ed,V,Ib", "", ""},
+	0x16: {"", "pextrd/q Ey,V,Ib", "", ""},
+	0x17: {"", "extractps Ed,V,Ib", "", ""},
+	0x18: {"", "vinsertf128 V,H,X,Ib", "", ""},
+	0x19: {"", "vextractf128 X,V,Ib", "", ""},
+	0x20: {"", "pinsrb V,Ed,Ib", "", ""},
+	0x22: {"", "pinsrd/q V,Ey,Ib", "", ""},
+	0x38: {"", "vinserti128 V,H,X,Ib", "", ""},
+	0x39: {"", "vextracti128 X,V,Ib", "", ""},
+	0x44: {"", "pclmulqdq V,W,Ip", "", ""},
+	0x46: {"", "vperm2i128 V,H,W,Ib", "", ""},
+	0x60: {"", "pcmpestrm V,W,Ib", "", ""},
+	0x61: {"", "pcmpestri V,W,Ib", "", ""},
+	0x62: {"", "pcmpistrm V,W,Ib", "", ""},
+	0x63: {"", "pcmpistri V,W,Ib", "", ""},
+	0xcc: {"sha1rnds4 V,W,Ib", "", "", ""},
+	0xdf: {"", "aeskeygenassist V,W,Ib", "", ""},
+	0xf0: {"", "", "", "rorx Gy,Ey,Ib"},
+}
+
+// bmiOps lists the VEX-encoded general-purpose register instructions
+var bmiOps = map[string]bool{
+	"andn": true, "bextr": true, "bzhi": true, "mulx": true, "pdep": true,
+	"pext": true, "rorx": true, "sarx": true, "shlx": true, "shrx": true,
+}
+
+func init() {
+	// Arithmetic opcodes 0x00-0x3f share one layout per operation
+	alu := []string{"add", "or", "adc", "sbb", "and", "sub", "xor", "cmp"}
+	for i, name := range alu {
+		base := byte(i * 8)
+		oneByteOps[base] = name + " Eb,Gb"
+		oneByteOps[base+1] = name + " Ev,Gv"
+		oneByteOps[base+2] = name + " Gb,Eb"
+		oneByteOps[base+3] = name + " Gv,Ev"
+		oneByteOps[base+4] = name + " AL,Ib"
+		oneByteOps[base+5] = name + " rAX,Iz"
+	}
+
+	for i := byte(0); i < 8; i++ {
+		oneByteOps[0x50+i] = "push Zs"
+		oneByteOps[0x58+i] = "pop Zs"
+		oneByteOps[0xb0+i] = "mov Zb,Ib"
+		if i > 0 {
+			oneByteOps[0x90+i] = "xchg Zv,rAX"
+		}
+	}
+
+	// Integer SSE2 operations exist only with the 0x66 prefix
+	integer := map[byte]string{
+		0x60: "punpcklbw", 0x61: "punpcklwd", 0x62: "punpckldq", 0x63: "packsswb",
+		0x64: "pcmpgtb", 0x65: "pcmpgtw", 0x66: "pcmpgtd", 0x67: "packuswb",
+		0x68: "punpckhbw", 0x69: "punpckhwd", 0x6a: "punpckhdq", 0x6b: "packssdw",
+		0x6c: "punpcklqdq", 0x6d: "punpckhqdq", 0x74: "pcmpeqb", 0x75: "pcmpeqw",
+		0x76: "pcmpeqd", 0xd4: "paddq", 0xd5: "pmullw", 0xda: "pminub", 0xdb: "pand",
+		0xde: "pmaxub", 0xdf: "pandn", 0xe0: "pavgb", 0xe3: "pavgw", 0xeb: "por",
+		0xef: "pxor", 0xf4: "pmuludq", 0xf8: "psubb", 0xf9: "psubw", 0xfa: "psubd",
+		0xfb: "psubq", 0xfc: "paddb", 0xfd: "paddw", 0xfe: "paddd",
+	}
+	for op, name := range integer {
+		sseOps[op] = [4]string{"", name + " V,W", "", ""}
+	}
+}
+
+// noSuffix lists mnemonics that never take an operand-size suffix
+var noSuffix = map[string]bool{
+	"call": true, "jmp": true, "lcall": true, "ljmp": true, "push": true, "pop": true,
+	"lea": true, "fxsave": true, "fxrstor": true, "ldmxcsr": true, "stmxcsr": true,
+	"xsave": true, "xrstor": true, "xsaveopt": true, "clflush": true,
+	"prefetchnta": true, "prefetcht0": true, "prefetcht1": true, "prefetcht2": true,
+	"prefetchw": true,
+	"seto":      true, "setno": true, "setb": true, "setae": true, "sete": true, "setne": true,
+	"setbe": true, "seta": true, "sets": true, "setns": true, "setp": true, "setnp": true,
+	"setl": true, "setge": true, "setle": true, "setg": true,
+}
+
+// suffixWithVector lists mnemonics that take a size suffix for a memory
+// operand even though a vector register is present
+var suffixWithVector = map[string]bool{
+	"cvtsi2ss": true, "cvtsi2sd": true, "vcvtsi2ss": true, "vcvtsi2sd": true,
+}
+
+// x87Memory holds the memory forms of opcodes 0xd8-0xdf by ModRM.reg
+var x87Memory = [8][8]string{
+	{"fadds", "fmuls", "fcoms", "fcomps", "fsubs", "fsubrs", "fdivs", "fdivrs"},
+	{"flds", "", "fsts", "fstps", "fldenv", "fldcw", "fnstenv", "fnstcw"},
+	{"fiaddl", "fimull", "ficoml", "ficompl", "fisubl", "fisubrl", "fidivl", "fidivrl"},
+	{"fildl", "fisttpl", "fistl", "fistpl", "", "fldt", "", "fstpt"},
+	{"faddl", "fmull", "fcoml", "fcompl", "fsubl", "fsubrl", "fdivl", "fdivrl"},
+	{"fldl", "fisttpll", "fstl", "fstpl", "frstor", "", "fnsave", "fnstsw"},
+	{"fiadds", "fimuls", "ficoms", "ficomps", "fisubs", "fisubrs", "fidivs", "fidivrs"},
+	{"filds", "fisttps", "fists", "fistps", "fbld", "fildll", "fbstp", "fistpll"},
+}
+
+// x87Stack holds the register forms of opcodes 0xd8-0xdf by ModRM.reg, with
+// (i) standing for the register in ModRM.rm. As in GNU as, the AT&T names of
+// the reversed subtractions and divisions with a %st(i) destination are
+// swapped relative to Intel's.
+var x87Stack = [8][8]string{
+	{"fadd %st(i),%st", "fmul %st(i),%st", "fcom %st(i)", "fcomp %st(i)",
+		"fsub %st(i),%st", "fsubr %st(i),%st", "fdiv %st(i),%st", "fdivr %st(i),%st"},
+	{"fld %st(i)", "fxch %st(i)"},
+	{"fcmovb %st(i),%st", "fcmove %st(i),%st", "fcmovbe %st(i),%st", "fcmovu %st(i),%st"},
+	{"fcmovnb %st(i),%st", "fcmovne %st(i),%st", "fcmovnbe %st(i),%st", "fcmovnu %st(i),%st",
+		"", "fucomi %st(i),%st", "fcomi %st(i),%st"},
+	{"fadd %st,%st(i)", "fmul %st,%st(i)", "", "",
+		"fsub %st,%st(i)", "fsubr %st,%st(i)", "fdiv %st,%st(i)", "fdivr %st,%st(i)"},
+	{"ffree %st(i)", "", "fst %st(i)", "fstp %st(i)", "fucom %st(i)", "fucomp %st(i)"},
+	{"faddp %st,%st(i)", "fmulp %st,%st(i)", "", "",
+		"fsubp %st,%st(i)", "fsubrp %st,%st(i)", "fdivp %st,%st(i)", "fdivrp %st,%st(i)"},
+	{"", "", "", "", "", "fucomip %st(i),%st", "fcomip %st(i),%st"},
+}
+
+// x87Register holds register forms without operands, keyed by opcode and
+// ModRM byte
+var x87Register = map[uint16]string{
+	0xd9d0: "fnop", 0xd9e0: "fchs", 0xd9e1: "fabs", 0xd9e4: "ftst", 0xd9e5: "fxam",
+	0xd9e8: "fld1", 0xd9e9: "fldl2t", 0xd9ea: "fldl2e", 0xd9eb: "fldpi",
+	0xd9ec: "fldlg2", 0xd9ed: "fldln2", 0xd9ee: "fldz", 0xd9f0: "f2xm1",
+	0xd9f1: "fyl2x", 0xd9f8: "fprem", 0xd9fa: "fsqrt", 0xd9fc: "frndint",
+	0xd9fd: "fscale", 0xd9fe: "fsin", 0xd9ff: "fcos", 0xdbe2: "fnclex",
+	0xdbe3: "fninit", 0xdae9: "fucompp", 0xded9: "fcompp", 0xdfe0: "fnstsw",
+}
@@ -0,0 +1,143 @@
+package disasm
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestDecodeX86_64 tests decoding against GNU objdump output
+func TestDecodeX86_64(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"55", "push   %rbp"},
+		{"4889e5", "mov    %rsp,%rbp"},
+		{"897dfc", "mov    %edi,-0x4(%rbp)"},
+		{"4883ec08", "sub    $0x8,%rsp"},
+		{"488d3d10000000", "lea    0x10(%rip),%rdi"},
+		{"8b4c9808", "mov    0x8(%rax,%rbx,4),%ecx"},
+		{"c70001000000", "movl   $0x1,(%rax)"},
+		{"0fb606", "movzbl (%rsi),%eax"},
+		{"4863d2", "movslq %edx,%rdx"},
+		{"31c0", "xor    %eax,%eax"},
+		{"84c0", "test   %al,%al"},
+		{"807f2000", "cmpb   $0x0,0x20(%rdi)"},
+		{"48c1e003", "shl    $0x3,%rax"},
+		{"d3fa", "sar    %cl,%edx"},
+		{"486bc618", "imul   $0x18,%rsi,%rax"},
+		{"48f77c2408", "idivq  0x8(%rsp)"},
+		{"ffd0", "call   *%rax"},
+		{"ff64d010", "jmp    *0x10(%rax,%rdx,8)"},
+		{"480f45c2", "cmovne %rdx,%rax"},
+		{"0f94c0", "sete   %al"},
+		{"f0480fb10a", "lock cmpxchg %rcx,(%rdx)"},
+		{"f348ab", "rep stos %rax,%es:(%rdi)"},
+		{"c3", "ret"},
+		{"660f1f0400", "nopw   (%rax,%rax,1)"},
+		{"f30f1efa", "endbr64"},
+		{"0f05", "syscall"},
+		{"f20f10442408", "movsd  0x8(%rsp),%xmm0"},
+		{"660fefc9", "pxor   %xmm1,%xmm1"},
+		{"f20f2ac0", "cvtsi2sd %eax,%xmm0"},
+		{"f30f6f16", "movdqu (%rsi),%xmm2"},
+		{"660f70c81b", "pshufd $0x1b,%xmm0,%xmm1"},
+		{"c5fe6f07", "vmovdqu (%rdi),%ymm0"},
+		{"c5edefd9", "vpxor  %ymm1,%ymm2,%ymm3"},
+		{"c5f574d0", "vpcmpeqb %ymm0,%ymm1,%ymm2"},
+		{"c5f877", "vzeroupper"},
+		{"660f3800c1", "pshufb %xmm1,%xmm0"},
+		{"660f38dcc1", "aesenc %xmm1,%xmm0"},
+		{"660f3a44c100", "pclmullqlqdq %xmm1,%xmm0"},
+		{"c4e3fbf0d803", "rorx   $0x3,%rax,%rbx"},
+		{"c4e2f1f7d0", "shlx   %rcx,%rax,%rdx"},
+		{"dd442408", "fldl   0x8(%rsp)"},
+		{"dec1", "faddp  %st,%st(1)"},
+		{"ddd8", "fstp   %st(0)"},
+	}
+
+	for _, tt := range tests {
+		code, err := hex.DecodeString(tt.code)
+		if err != nil {
+			t.Fatalf("bad test vector %s: %v", tt.code, err)
+		}
+		inst, err := Decode(ArchX86_64, code, 0)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.code, err)
+			continue
+		}
+		if got := inst.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.code, got, tt.want)
+		}
+		if inst.Len != len(code) {
+			t.Errorf("%s: length %d, want %d", tt.code, inst.Len, len(code))
+		}
+	}
+}
+
+// TestDecodeTargets tests branch targets and RIP-relative references
+func TestDecodeTargets(t *testing.T) {
+	// call with a 32-bit displacement
+	inst, err := Decode(ArchX86_64, []byte{0xe8, 0x10, 0x00, 0x00, 0x00}, 0x1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !inst.HasTarget || inst.Target != 0x1015 {
+		t.Errorf("call target = %#x (%v), want 0x1015", inst.Target, inst.HasTarget)
+	}
+	if got := inst.String(); got != "call   1015" {
+		t.Errorf("call = %q, want %q", got, "call   1015")
+	}
+
+	// jne with a negative 8-bit displacement
+	inst, err = Decode(ArchX86_64, []byte{0x75, 0xfe}, 0x2000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !inst.HasTarget || inst.Target != 0x2000 {
+		t.Errorf("jne target = %#x, want 0x2000", inst.Target)
+	}
+
+	// lea 0x10(%rip),%rdi
+	inst, err = Decode(ArchX86_64, []byte{0x48, 0x8d, 0x3d, 0x10, 0x00, 0x00, 0x00}, 0x3000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !inst.HasRef || inst.Ref != 0x3017 {
+		t.Errorf("lea reference = %#x (%v), want 0x3017", inst.Ref, inst.HasRef)
+	}
+}
+
+// TestDecodeX86 tests 32-bit operand and address defaults
+func TestDecodeX86(t *testing.T) {
+	tests := []struct {
+		code []byte
+		want string
+	}{
+		{[]byte{0x55}, "push   %ebp"},
+		{[]byte{0x89, 0xe5}, "mov    %esp,%ebp"},
+		{[]byte{0x8b, 0x45, 0x08}, "mov    0x8(%ebp),%eax"},
+		{[]byte{0x40}, "inc    %eax"},
+	}
+
+	for _, tt := range tests {
+		inst, err := Decode(ArchX86, tt.code, 0)
+		if err != nil {
+			t.Errorf("% x: unexpected error: %v", tt.code, err)
+			continue
+		}
+		if got := inst.String(); got != tt.want {
+			t.Errorf("% x: got %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+// TestDecodeTruncated tests that a partial instruction is an error
+func TestDecodeTruncated(t *testing.T) {
+	if _, err := Decode(ArchX86_64, []byte{0x48, 0x8b}, 0); err == nil {
+		t.Error("expected error for truncated instruction")
+	}
+	if _, err := Decode(ArchX86_64, []byte{0xe8, 0x00}, 0); err == nil {
+		t.Error("expected error for truncated displacement")
+	}
+}
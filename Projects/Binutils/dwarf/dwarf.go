@@ -0,0 +1,357 @@
+package dwarf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"hellogolang/Projects/Binutils/elf"
+)
+
+// Data holds the DWARF debugging sections of one object file
+type Data struct {
+	order binary.ByteOrder
+
+	abbrev     []byte
+	info       []byte
+	line       []byte
+	str        []byte
+	lineStr    []byte
+	strOffsets []byte
+	addr       []byte
+	ranges     []byte
+	rngLists   []byte
+
+	units []*Unit
+}
+
+// Sections maps the DWARF section names to the fields of Data
+var sectionNames = []string{
+	".debug_abbrev", ".debug_info", ".debug_line", ".debug_str", ".debug_line_str",
+	".debug_str_offsets", ".debug_addr", ".debug_ranges", ".debug_rnglists",
+}
+
+// New parses DWARF data from raw section contents keyed by section name.
+// Only .debug_info and .debug_abbrev are required.
+func New(sections map[string][]byte, order binary.ByteOrder) (*Data, error) {
+	d := &Data{
+		order:      order,
+		abbrev:     sections[".debug_abbrev"],
+		info:       sections[".debug_info"],
+		line:       sections[".debug_line"],
+		str:        sections[".debug_str"],
+		lineStr:    sections[".debug_line_str"],
+		strOffsets: sections[".debug_str_offsets"],
+		addr:       sections[".debug_addr"],
+		ranges:     sections[".debug_ranges"],
+		rngLists:   sections[".debug_rnglists"],
+	}
+
+	if len(d.info) == 0 {
+		return nil, fmt.Errorf("no .debug_info section")
+	}
+	if len(d.abbrev) == 0 {
+		return nil, fmt.Errorf("no .debug_abbrev section")
+	}
+
+	if err := d.parseUnits(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Load reads the DWARF sections of a parsed ELF file
+func Load(f *elf.ELF) (*Data, error) {
+	sections := make(map[string][]byte)
+
+	for i := range f.Sections {
+		section := &f.Sections[i]
+		if !containsName(sectionNames, section.Name) || section.Type == elf.SHT_NOBITS {
+			continue
+		}
+
+		// Secure: validate section size
+		data, err := section.ReadAll(512 * 1024 * 1024) // 512MB limit
+		if err != nil {
+			return nil, err
+		}
+		sections[section.Name] = data
+	}
+
+	return New(sections, f.ByteOrder())
+}
+
+// Units returns the compilation units in .debug_info order
+func (d *Data) Units() []*Unit {
+	return d.units
+}
+
+// LineInfo is the source position of an address
+type LineInfo struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// FindLine returns the source position of the instruction at addr
+func (d *Data) FindLine(addr uint64) (LineInfo, bool) {
+	for _, unit := range d.units {
+		if len(unit.ranges) > 0 && !unit.Contains(addr) {
+			continue
+		}
+
+		rows, err := d.LineTable(unit)
+		if err != nil {
+			continue
+		}
+
+		if row, ok := lookupRow(rows, addr); ok {
+			return LineInfo{File: row.File, Line: row.Line, Column: row.Column}, true
+		}
+	}
+
+	return LineInfo{}, false
+}
+
+// Function names the subprogram covering an address
+type Function struct {
+	Name        string // DW_AT_name, as written in the source
+	LinkageName string // Mangled name as in the symbol table; empty for C functions and C++ ones with internal linkage
+}
+
+// FindFunction returns the names of the subprogram whose code covers addr
+func (d *Data) FindFunction(addr uint64) (Function, bool) {
+	for _, unit := range d.units {
+		if len(unit.ranges) > 0 && !unit.Contains(addr) {
+			continue
+		}
+
+		var best *Entry
+		var walk func(e *Entry)
+		walk = func(e *Entry) {
+			if e.Tag == TagSubprogram {
+				for _, r := range d.entryRanges(unit, e) {
+					if addr >= r[0] && addr < r[1] {
+						best = e
+					}
+				}
+			}
+			for _, child := range e.Children {
+				walk(child)
+			}
+		}
+		walk(unit.Root)
+
+		if best != nil {
+			fn := Function{Name: unit.entryName(best, 0), LinkageName: unit.linkageName(best, 0)}
+			if fn.Name != "" || fn.LinkageName != "" {
+				return fn, true
+			}
+		}
+	}
+
+	return Function{}, false
+}
+
+// lookupRow finds the row covering addr in a line table ordered by sequence
+func lookupRow(rows []LineRow, addr uint64) (LineRow, bool) {
+	// Each sequence is sorted by address and ends with an end_sequence row
+	start := 0
+	for i, row := range rows {
+		if !row.EndSequence {
+			continue
+		}
+		seq := rows[start : i+1]
+		start = i + 1
+		if len(seq) < 2 || addr < seq[0].Address || addr >= row.Address {
+			continue
+		}
+
+		n := sort.Search(len(seq), func(j int) bool { return seq[j].Address > addr })
+		if n > 0 {
+			return seq[n-1], true
+		}
+	}
+
+	return LineRow{}, false
+}
+
+// containsName checks if slice contains string
+func containsName(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// reader decodes DWARF primitive values from a byte slice
+type reader struct {
+	data  []byte
+	off   int
+	order binary.ByteOrder
+	err   error
+}
+
+// fail records the first decoding error
+func (r *reader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+}
+
+// bytes consumes n bytes
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.off+n > len(r.data) {
+		r.fail("truncated data at offset 0x%x", r.off)
+		r.off = len(r.data)
+		return nil
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *reader) u8() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) u16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return r.order.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) u32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return r.order.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) u64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return r.order.Uint64(b)
+	}
+	return 0
+}
+
+// uint reads an unsigned value of the given byte size
+func (r *reader) uint(size int) uint64 {
+	switch size {
+	case 1:
+		return uint64(r.u8())
+	case 2:
+		return uint64(r.u16())
+	case 3:
+		b := r.bytes(3)
+		if b == nil {
+			return 0
+		}
+		if r.order == binary.BigEndian {
+			return uint64(b[0])<<16 | uint64(b[1])<<8 | uint64(b[2])
+		}
+		return uint64(b[2])<<16 | uint64(b[1])<<8 | uint64(b[0])
+	case 4:
+		return uint64(r.u32())
+	case 8:
+		return r.u64()
+	}
+	r.fail("unsupported value size %d", size)
+	return 0
+}
+
+// uleb reads an unsigned LEB128 value
+func (r *reader) uleb() uint64 {
+	var result uint64
+	var shift uint
+	for {
+		b := r.u8()
+		if r.err != nil {
+			return 0
+		}
+		if shift < 64 {
+			result |= uint64(b&0x7f) << shift
+		}
+		shift += 7
+		if b&0x80 == 0 {
+			return result
+		}
+	}
+}
+
+// sleb reads a signed LEB128 value
+func (r *reader) sleb() int64 {
+	var result int64
+	var shift uint
+	var b byte
+	for {
+		b = r.u8()
+		if r.err != nil {
+			return 0
+		}
+		if shift < 64 {
+			result |= int64(b&0x7f) << shift
+		}
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	if shift < 64 && b&0x40 != 0 {
+		result |= -1 << shift
+	}
+	return result
+}
+
+// cstring reads a NUL-terminated string
+func (r *reader) cstring() string {
+	if r.err != nil {
+		return ""
+	}
+	for i := r.off; i < len(r.data); i++ {
+		if r.data[i] == 0 {
+			s := string(r.data[r.off:i])
+			r.off = i + 1
+			return s
+		}
+	}
+	r.fail("unterminated string at offset 0x%x", r.off)
+	r.off = len(r.data)
+	return ""
+}
+
+// unitLength reads an initial length field and reports whether the
+// 64-bit DWARF format is in use
+func (r *reader) unitLength() (uint64, bool) {
+	length := uint64(r.u32())
+	if length == 0xffffffff {
+		return r.u64(), true
+	}
+	return length, false
+}
+
+// offset reads a section offset in the 32- or 64-bit DWARF format
+func (r *reader) offset(is64 bool) uint64 {
+	if is64 {
+		return r.u64()
+	}
+	return uint64(r.u32())
+}
+
+// stringAt returns the NUL-terminated string at off in a string section
+func stringAt(section []byte, off uint64) (string, error) {
+	if off >= uint64(len(section)) {
+		return "", fmt.Errorf("string offset 0x%x out of range", off)
+	}
+	return elf.ReadCString(section[off:]), nil
+}
@@ -0,0 +1,653 @@
+package dwarf
+
+import (
+	"fmt"
+)
+
+// Tag identifies the kind of a debugging information entry (DW_TAG_*)
+type Tag uint32
+
+// Common tags
+const (
+	TagArrayType         Tag = 0x01
+	TagLexicalBlock      Tag = 0x0b
+	TagMember            Tag = 0x0d
+	TagPointerType       Tag = 0x0f
+	TagCompileUnit       Tag = 0x11
+	TagStructureType     Tag = 0x13
+	TagTypedef           Tag = 0x16
+	TagInlinedSubroutine Tag = 0x1d
+	TagFormalParameter   Tag = 0x05
+	TagBaseType          Tag = 0x24
+	TagSubprogram        Tag = 0x2e
+	TagVariable          Tag = 0x34
+	TagNamespace         Tag = 0x39
+	TagPartialUnit       Tag = 0x3c
+	TagSkeletonUnit      Tag = 0x4a
+)
+
+// Attr identifies an attribute of an entry (DW_AT_*)
+type Attr uint32
+
+// Common attributes
+const (
+	AttrSibling         Attr = 0x01
+	AttrName            Attr = 0x03
+	AttrByteSize        Attr = 0x0b
+	AttrStmtList        Attr = 0x10
+	AttrLowpc           Attr = 0x11
+	AttrHighpc          Attr = 0x12
+	AttrLanguage        Attr = 0x13
+	AttrCompDir         Attr = 0x1b
+	AttrProducer        Attr = 0x25
+	AttrAbstractOrigin  Attr = 0x31
+	AttrDeclFile        Attr = 0x3a
+	AttrDeclLine        Attr = 0x3b
+	AttrExternal        Attr = 0x3f
+	AttrSpecification   Attr = 0x47
+	AttrType            Attr = 0x49
+	AttrRanges          Attr = 0x55
+	AttrLinkageName     Attr = 0x6e
+	AttrStrOffsetsBase  Attr = 0x72
+	AttrAddrBase        Attr = 0x73
+	AttrRnglistsBase    Attr = 0x74
+	AttrMIPSLinkageName Attr = 0x2007
+)
+
+// Form describes how an attribute value is encoded (DW_FORM_*)
+type Form uint32
+
+// Attribute forms from DWARF 2 through 5
+const (
+	FormAddr          Form = 0x01
+	FormBlock2        Form = 0x03
+	FormBlock4        Form = 0x04
+	FormData2         Form = 0x05
+	FormData4         Form = 0x06
+	FormData8         Form = 0x07
+	FormString        Form = 0x08
+	FormBlock         Form = 0x09
+	FormBlock1        Form = 0x0a
+	FormData1         Form = 0x0b
+	FormFlag          Form = 0x0c
+	FormSdata         Form = 0x0d
+	FormStrp          Form = 0x0e
+	FormUdata         Form = 0x0f
+	FormRefAddr       Form = 0x10
+	FormRef1          Form = 0x11
+	FormRef2          Form = 0x12
+	FormRef4          Form = 0x13
+	FormRef8          Form = 0x14
+	FormRefUdata      Form = 0x15
+	FormIndirect      Form = 0x16
+	FormSecOffset     Form = 0x17
+	FormExprloc       Form = 0x18
+	FormFlagPresent   Form = 0x19
+	FormStrx          Form = 0x1a
+	FormAddrx         Form = 0x1b
+	FormRefSup4       Form = 0x1c
+	FormStrpSup       Form = 0x1d
+	FormData16        Form = 0x1e
+	FormLineStrp      Form = 0x1f
+	FormRefSig8       Form = 0x20
+	FormImplicitConst Form = 0x21
+	FormLoclistx      Form = 0x22
+	FormRnglistx      Form = 0x23
+	FormRefSup8       Form = 0x24
+	FormStrx1         Form = 0x25
+	FormStrx2         Form = 0x26
+	FormStrx3         Form = 0x27
+	FormStrx4         Form = 0x28
+	FormAddrx1        Form = 0x29
+	FormAddrx2        Form = 0x2a
+	FormAddrx3        Form = 0x2b
+	FormAddrx4        Form = 0x2c
+)
+
+// Field is one attribute of an entry. Val holds a uint64 for addresses,
+// constants, flags, offsets and references, an int64 for signed constants,
+// a string for string forms and a []byte for blocks.
+type Field struct {
+	Attr Attr
+	Form Form
+	Val  interface{}
+}
+
+// Entry is a debugging information entry
+type Entry struct {
+	Offset   uint64 // offset within .debug_info
+	Tag      Tag
+	Fields   []Field
+	Children []*Entry
+}
+
+// Val returns the value of attribute a, or nil when absent
+func (e *Entry) Val(a Attr) interface{} {
+	for _, f := range e.Fields {
+		if f.Attr == a {
+			return f.Val
+		}
+	}
+	return nil
+}
+
+// field returns the attribute a
+func (e *Entry) field(a Attr) (Field, bool) {
+	for _, f := range e.Fields {
+		if f.Attr == a {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Unit is a compilation unit from .debug_info
+type Unit struct {
+	Offset   uint64
+	Version  uint16
+	AddrSize int
+	Is64     bool
+	Root     *Entry
+
+	Name    string
+	CompDir string
+
+	entries map[uint64]*Entry
+	ranges  [][2]uint64
+	lines   []LineRow
+	lineErr error
+	loaded  bool
+}
+
+// Contains reports whether addr falls inside one of the unit's code ranges
+func (u *Unit) Contains(addr uint64) bool {
+	for _, r := range u.ranges {
+		if addr >= r[0] && addr < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// abbrev is one entry of an abbreviation table
+type abbrev struct {
+	tag      Tag
+	children bool
+	specs    []attrSpec
+}
+
+// attrSpec is an attribute specification inside an abbreviation
+type attrSpec struct {
+	attr     Attr
+	form     Form
+	implicit int64
+}
+
+// parseAbbrevs parses the abbreviation table starting at off
+func (d *Data) parseAbbrevs(off uint64) (map[uint64]*abbrev, error) {
+	if off >= uint64(len(d.abbrev)) {
+		return nil, fmt.Errorf("abbreviation offset 0x%x out of range", off)
+	}
+
+	r := &reader{data: d.abbrev, off: int(off), order: d.order}
+	table := make(map[uint64]*abbrev)
+
+	for {
+		code := r.uleb()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if code == 0 {
+			return table, nil
+		}
+
+		a := &abbrev{tag: Tag(r.uleb()), children: r.u8() != 0}
+		for {
+			attr, form := Attr(r.uleb()), Form(r.uleb())
+			if r.err != nil {
+				return nil, r.err
+			}
+			if attr == 0 && form == 0 {
+				break
+			}
+			spec := attrSpec{attr: attr, form: form}
+			if form == FormImplicitConst {
+				spec.implicit = r.sleb()
+			}
+			a.specs = append(a.specs, spec)
+		}
+		table[code] = a
+
+		// Secure: bound the table size
+		if len(table) > 1<<20 {
+			return nil, fmt.Errorf("abbreviation table too large")
+		}
+	}
+}
+
+// parseUnits parses every compilation unit in .debug_info
+func (d *Data) parseUnits() error {
+	r := &reader{data: d.info, order: d.order}
+
+	for r.off < len(d.info) {
+		unitOff := uint64(r.off)
+		length, is64 := r.unitLength()
+		if r.err != nil {
+			return r.err
+		}
+		if length == 0 || length > uint64(len(d.info)-r.off) {
+			return fmt.Errorf("unit at 0x%x: bad length %d", unitOff, length)
+		}
+		end := r.off + int(length)
+
+		unit := &Unit{Offset: unitOff, Is64: is64, entries: make(map[uint64]*Entry)}
+		unit.Version = r.u16()
+		if unit.Version < 2 || unit.Version > 5 {
+			return fmt.Errorf("unit at 0x%x: unsupported DWARF version %d", unitOff, unit.Version)
+		}
+
+		var abbrevOff uint64
+		if unit.Version >= 5 {
+			unitType := r.u8()
+			unit.AddrSize = int(r.u8())
+			abbrevOff = r.offset(is64)
+			switch unitType {
+			case 0x02, 0x06: // DW_UT_type, DW_UT_split_type
+				r.u64()
+				r.offset(is64)
+			case 0x04, 0x05: // DW_UT_skeleton, DW_UT_split_compile
+				r.u64()
+			}
+		} else {
+			abbrevOff = r.offset(is64)
+			unit.AddrSize = int(r.u8())
+		}
+		if r.err != nil {
+			return fmt.Errorf("unit at 0x%x: %w", unitOff, r.err)
+		}
+		if unit.AddrSize != 4 && unit.AddrSize != 8 {
+			return fmt.Errorf("unit at 0x%x: unsupported address size %d", unitOff, unit.AddrSize)
+		}
+
+		abbrevs, err := d.parseAbbrevs(abbrevOff)
+		if err != nil {
+			return fmt.Errorf("unit at 0x%x: %w", unitOff, err)
+		}
+
+		body := &reader{data: d.info[:end], off: r.off, order: d.order}
+		if err := d.parseEntries(unit, body, abbrevs); err != nil {
+			return fmt.Errorf("unit at 0x%x: %w", unitOff, err)
+		}
+
+		if unit.Root != nil {
+			d.resolveIndexed(unit)
+			if name, ok := unit.Root.Val(AttrName).(string); ok {
+				unit.Name = name
+			}
+			if dir, ok := unit.Root.Val(AttrCompDir).(string); ok {
+				unit.CompDir = dir
+			}
+			unit.ranges = d.entryRanges(unit, unit.Root)
+		}
+
+		d.units = append(d.units, unit)
+		r.off = end
+	}
+
+	return nil
+}
+
+// parseEntries decodes the entry tree of a unit
+func (d *Data) parseEntries(unit *Unit, r *reader, abbrevs map[uint64]*abbrev) error {
+	var stack []*Entry
+
+	for r.off < len(r.data) {
+		off := uint64(r.off)
+		code := r.uleb()
+		if r.err != nil {
+			return r.err
+		}
+		if code == 0 {
+			// Null entry closes the current sibling list
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		a, ok := abbrevs[code]
+		if !ok {
+			return fmt.Errorf("entry at 0x%x: unknown abbreviation code %d", off, code)
+		}
+
+		entry := &Entry{Offset: off, Tag: a.tag, Fields: make([]Field, 0, len(a.specs))}
+		for _, spec := range a.specs {
+			val, form := d.readForm(unit, r, spec.form, spec.implicit)
+			if r.err != nil {
+				return fmt.Errorf("entry at 0x%x: %w", off, r.err)
+			}
+			entry.Fields = append(entry.Fields, Field{Attr: spec.attr, Form: form, Val: val})
+		}
+
+		// Secure: bound the number of entries per unit
+		if len(unit.entries) > 10000000 {
+			return fmt.Errorf("too many entries")
+		}
+		unit.entries[off] = entry
+
+		if len(stack) == 0 {
+			if unit.Root != nil {
+				// Some producers pad units after the root; ignore stray entries
+				continue
+			}
+			unit.Root = entry
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, entry)
+		}
+		if a.children {
+			stack = append(stack, entry)
+		}
+	}
+
+	return nil
+}
+
+// readForm decodes one attribute value. Reference forms are converted to
+// absolute .debug_info offsets.
+func (d *Data) readForm(unit *Unit, r *reader, form Form, implicit int64) (interface{}, Form) {
+	switch form {
+	case FormAddr:
+		return r.uint(unit.AddrSize), form
+	case FormData1, FormFlag, FormStrx1, FormAddrx1:
+		return uint64(r.u8()), form
+	case FormData2, FormStrx2, FormAddrx2:
+		return uint64(r.u16()), form
+	case FormStrx3, FormAddrx3:
+		return r.uint(3), form
+	case FormData4, FormStrx4, FormAddrx4, FormRefSup4:
+		return uint64(r.u32()), form
+	case FormData8, FormRefSig8, FormRefSup8:
+		return r.u64(), form
+	case FormData16:
+		return r.bytes(16), form
+	case FormSdata:
+		return r.sleb(), form
+	case FormUdata, FormStrx, FormAddrx, FormLoclistx, FormRnglistx:
+		return r.uleb(), form
+	case FormImplicitConst:
+		return implicit, form
+	case FormFlagPresent:
+		return uint64(1), form
+	case FormString:
+		return r.cstring(), form
+	case FormStrp, FormLineStrp, FormStrpSup:
+		off := r.offset(unit.Is64)
+		section := d.str
+		if form == FormLineStrp {
+			section = d.lineStr
+		}
+		if form == FormStrpSup {
+			return off, form
+		}
+		s, err := stringAt(section, off)
+		if err != nil {
+			r.fail("%v", err)
+		}
+		return s, form
+	case FormSecOffset:
+		return r.offset(unit.Is64), form
+	case FormRefAddr:
+		if unit.Version <= 2 {
+			return r.uint(unit.AddrSize), form
+		}
+		return r.offset(unit.Is64), form
+	case FormRef1:
+		return unit.Offset + uint64(r.u8()), form
+	case FormRef2:
+		return unit.Offset + uint64(r.u16()), form
+	case FormRef4:
+		return unit.Offset + uint64(r.u32()), form
+	case FormRef8:
+		return unit.Offset + r.u64(), form
+	case FormRefUdata:
+		return unit.Offset + r.uleb(), form
+	case FormBlock1:
+		return r.bytes(int(r.u8())), form
+	case FormBlock2:
+		return r.bytes(int(r.u16())), form
+	case FormBlock4:
+		return r.bytes(int(r.u32())), form
+	case FormBlock, FormExprloc:
+		n := r.uleb()
+		if n > uint64(len(r.data)) {
+			r.fail("block length %d out of range", n)
+			return nil, form
+		}
+		return r.bytes(int(n)), form
+	case FormIndirect:
+		return d.readForm(unit, r, Form(r.uleb()), 0)
+	}
+
+	r.fail("unsupported form 0x%x", uint32(form))
+	return nil, form
+}
+
+// resolveIndexed replaces DWARF 5 string and address indices with their
+// values once the unit's str_offsets_base and addr_base are known
+func (d *Data) resolveIndexed(unit *Unit) {
+	strBase, _ := unit.Root.Val(AttrStrOffsetsBase).(uint64)
+	addrBase, _ := unit.Root.Val(AttrAddrBase).(uint64)
+	offSize := uint64(4)
+	if unit.Is64 {
+		offSize = 8
+	}
+
+	for _, entry := range unit.entries {
+		for i := range entry.Fields {
+			field := &entry.Fields[i]
+			index, ok := field.Val.(uint64)
+			if !ok {
+				continue
+			}
+
+			switch field.Form {
+			case FormStrx, FormStrx1, FormStrx2, FormStrx3, FormStrx4:
+				pos := strBase + index*offSize
+				if pos+offSize > uint64(len(d.strOffsets)) {
+					continue
+				}
+				r := &reader{data: d.strOffsets, off: int(pos), order: d.order}
+				if s, err := stringAt(d.str, r.offset(unit.Is64)); err == nil {
+					field.Val = s
+				}
+			case FormAddrx, FormAddrx1, FormAddrx2, FormAddrx3, FormAddrx4:
+				pos := addrBase + index*uint64(unit.AddrSize)
+				if pos+uint64(unit.AddrSize) > uint64(len(d.addr)) {
+					continue
+				}
+				r := &reader{data: d.addr, off: int(pos), order: d.order}
+				field.Val = r.uint(unit.AddrSize)
+			}
+		}
+	}
+}
+
+// entryName returns the name of an entry, following specification and
+// abstract origin references the way debuggers do
+func (u *Unit) entryName(e *Entry, depth int) string {
+	if name, ok := e.Val(AttrName).(string); ok {
+		return name
+	}
+	if depth > 8 {
+		return ""
+	}
+	for _, a := range []Attr{AttrSpecification, AttrAbstractOrigin} {
+		if ref, ok := e.Val(a).(uint64); ok {
+			if target, ok := u.entries[ref]; ok {
+				return u.entryName(target, depth+1)
+			}
+		}
+	}
+	return ""
+}
+
+// linkageName returns the mangled name of an entry, following
+// specification and abstract origin references as entryName does. An
+// out-of-line copy of an inline or member function keeps it on the
+// declaration it refers to.
+func (u *Unit) linkageName(e *Entry, depth int) string {
+	for _, a := range []Attr{AttrLinkageName, AttrMIPSLinkageName} {
+		if name, ok := e.Val(a).(string); ok {
+			return name
+		}
+	}
+	if depth > 8 {
+		return ""
+	}
+	for _, a := range []Attr{AttrSpecification, AttrAbstractOrigin} {
+		if ref, ok := e.Val(a).(uint64); ok {
+			if target, ok := u.entries[ref]; ok {
+				return u.linkageName(target, depth+1)
+			}
+		}
+	}
+	return ""
+}
+
+// EntryName returns the name of an entry in the unit
+func (u *Unit) EntryName(e *Entry) string {
+	return u.entryName(e, 0)
+}
+
+// entryRanges returns the [low, high) address ranges covered by an entry
+func (d *Data) entryRanges(unit *Unit, e *Entry) [][2]uint64 {
+	low, hasLow := e.Val(AttrLowpc).(uint64)
+	if hasLow {
+		if high, ok := e.field(AttrHighpc); ok {
+			if v, ok := high.Val.(uint64); ok {
+				if isAddressForm(high.Form) {
+					return [][2]uint64{{low, v}}
+				}
+				// Constant class: high_pc is an offset from low_pc
+				return [][2]uint64{{low, low + v}}
+			}
+		}
+	}
+
+	rangesField, ok := e.field(AttrRanges)
+	if !ok {
+		return nil
+	}
+	off, ok := rangesField.Val.(uint64)
+	if !ok {
+		return nil
+	}
+
+	base := uint64(0)
+	if rootLow, ok := unit.Root.Val(AttrLowpc).(uint64); ok {
+		base = rootLow
+	}
+
+	if unit.Version >= 5 {
+		if rangesField.Form == FormRnglistx {
+			rnglistsBase, _ := unit.Root.Val(AttrRnglistsBase).(uint64)
+			offSize := uint64(4)
+			if unit.Is64 {
+				offSize = 8
+			}
+			pos := rnglistsBase + off*offSize
+			if pos+offSize > uint64(len(d.rngLists)) {
+				return nil
+			}
+			r := &reader{data: d.rngLists, off: int(pos), order: d.order}
+			off = rnglistsBase + r.offset(unit.Is64)
+		}
+		return d.readRngList(unit, off, base)
+	}
+	return d.readRanges(unit, off, base)
+}
+
+// readRanges decodes a DWARF 2-4 .debug_ranges list
+func (d *Data) readRanges(unit *Unit, off, base uint64) [][2]uint64 {
+	if off >= uint64(len(d.ranges)) {
+		return nil
+	}
+
+	r := &reader{data: d.ranges, off: int(off), order: d.order}
+	maxAddr := uint64(1)<<(8*uint(unit.AddrSize)) - 1
+	if unit.AddrSize == 8 {
+		maxAddr = ^uint64(0)
+	}
+
+	var result [][2]uint64
+	for r.err == nil {
+		start, end := r.uint(unit.AddrSize), r.uint(unit.AddrSize)
+		if r.err != nil || (start == 0 && end == 0) {
+			break
+		}
+		if start == maxAddr {
+			base = end // Base address selection entry
+			continue
+		}
+		result = append(result, [2]uint64{base + start, base + end})
+	}
+	return result
+}
+
+// readRngList decodes a DWARF 5 .debug_rnglists list
+func (d *Data) readRngList(unit *Unit, off, base uint64) [][2]uint64 {
+	if off >= uint64(len(d.rngLists)) {
+		return nil
+	}
+
+	r := &reader{data: d.rngLists, off: int(off), order: d.order}
+	addrBase, _ := unit.Root.Val(AttrAddrBase).(uint64)
+	addrx := func(index uint64) uint64 {
+		pos := addrBase + index*uint64(unit.AddrSize)
+		if pos+uint64(unit.AddrSize) > uint64(len(d.addr)) {
+			return 0
+		}
+		ar := &reader{data: d.addr, off: int(pos), order: d.order}
+		return ar.uint(unit.AddrSize)
+	}
+
+	var result [][2]uint64
+	for r.err == nil {
+		switch r.u8() {
+		case 0x00: // DW_RLE_end_of_list
+			return result
+		case 0x01: // DW_RLE_base_addressx
+			base = addrx(r.uleb())
+		case 0x02: // DW_RLE_startx_endx
+			start, end := addrx(r.uleb()), addrx(r.uleb())
+			result = append(result, [2]uint64{start, end})
+		case 0x03: // DW_RLE_startx_length
+			start := addrx(r.uleb())
+			result = append(result, [2]uint64{start, start + r.uleb()})
+		case 0x04: // DW_RLE_offset_pair
+			start, end := r.uleb(), r.uleb()
+			result = append(result, [2]uint64{base + start, base + end})
+		case 0x05: // DW_RLE_base_address
+			base = r.uint(unit.AddrSize)
+		case 0x06: // DW_RLE_start_end
+			start, end := r.uint(unit.AddrSize), r.uint(unit.AddrSize)
+			result = append(result, [2]uint64{start, end})
+		case 0x07: // DW_RLE_start_length
+			start := r.uint(unit.AddrSize)
+			result = append(result, [2]uint64{start, start + r.uleb()})
+		default:
+			return result
+		}
+	}
+	return result
+}
+
+// isAddressForm reports whether form belongs to the address class
+func isAddressForm(form Form) bool {
+	switch form {
+	case FormAddr, FormAddrx, FormAddrx1, FormAddrx2, FormAddrx3, FormAddrx4:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,395 @@
+package dwarf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// appendULEB encodes v as unsigned LEB128
+func appendULEB(b []byte, v uint64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+// appendSLEB encodes v as signed LEB128
+func appendSLEB(b []byte, v int64) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+// TestReadForm tests the decoding of every supported attribute form, and
+// that each one fails on data cut short instead of reading past it
+func TestReadForm(t *testing.T) {
+	d := &Data{str: []byte("\x00main\x00"), lineStr: []byte("\x00a.c\x00")}
+
+	v4 := &Unit{Offset: 0x100, Version: 4, AddrSize: 8}
+	v4x32 := &Unit{Offset: 0x100, Version: 4, AddrSize: 4}
+	v4x64 := &Unit{Offset: 0x100, Version: 4, AddrSize: 8, Is64: true}
+	v2 := &Unit{Offset: 0x100, Version: 2, AddrSize: 4}
+	v5 := &Unit{Offset: 0x100, Version: 5, AddrSize: 8}
+
+	le, be := binary.ByteOrder(binary.LittleEndian), binary.ByteOrder(binary.BigEndian)
+	tests := []struct {
+		name     string
+		unit     *Unit
+		order    binary.ByteOrder
+		form     Form
+		data     []byte
+		want     interface{}
+		wantForm Form // Differs from form only through DW_FORM_indirect
+	}{
+		{"addr", v4, le, FormAddr, []byte{0x10, 0x32, 0x54, 0x76, 0, 0, 0, 0}, uint64(0x76543210), FormAddr},
+		{"addr 32-bit", v4x32, be, FormAddr, []byte{0x76, 0x54, 0x32, 0x10}, uint64(0x76543210), FormAddr},
+		{"data1", v4, le, FormData1, []byte{0xfe}, uint64(0xfe), FormData1},
+		{"data2", v4, be, FormData2, []byte{0x12, 0x34}, uint64(0x1234), FormData2},
+		{"data4", v4, le, FormData4, []byte{0x78, 0x56, 0x34, 0x12}, uint64(0x12345678), FormData4},
+		{"data8", v4, le, FormData8, []byte{8, 7, 6, 5, 4, 3, 2, 1}, uint64(0x0102030405060708), FormData8},
+		{"data16", v5, le, FormData16, bytes.Repeat([]byte{0xab}, 16), bytes.Repeat([]byte{0xab}, 16), FormData16},
+		{"sdata", v4, le, FormSdata, []byte{0x80, 0x7f}, int64(-128), FormSdata},
+		{"udata", v4, le, FormUdata, []byte{0xe5, 0x8e, 0x26}, uint64(624485), FormUdata},
+		{"flag", v4, le, FormFlag, []byte{1}, uint64(1), FormFlag},
+		{"flag_present", v4, le, FormFlagPresent, nil, uint64(1), FormFlagPresent},
+		{"implicit_const", v5, le, FormImplicitConst, nil, int64(-3), FormImplicitConst},
+
+		{"string", v4, le, FormString, []byte("main\x00"), "main", FormString},
+		{"strp", v4, le, FormStrp, []byte{1, 0, 0, 0}, "main", FormStrp},
+		{"strp 64-bit", v4x64, be, FormStrp, []byte{0, 0, 0, 0, 0, 0, 0, 1}, "main", FormStrp},
+		{"line_strp", v5, le, FormLineStrp, []byte{1, 0, 0, 0}, "a.c", FormLineStrp},
+		{"strp_sup", v5, le, FormStrpSup, []byte{0x20, 0, 0, 0}, uint64(0x20), FormStrpSup},
+
+		// Indices stay as read until resolveIndexed knows the unit's bases
+		{"strx", v5, le, FormStrx, []byte{0x81, 0x01}, uint64(0x81), FormStrx},
+		{"strx1", v5, le, FormStrx1, []byte{2}, uint64(2), FormStrx1},
+		{"strx2", v5, le, FormStrx2, []byte{2, 1}, uint64(0x102), FormStrx2},
+		{"strx3", v5, le, FormStrx3, []byte{3, 2, 1}, uint64(0x010203), FormStrx3},
+		{"strx3 big-endian", v5, be, FormStrx3, []byte{1, 2, 3}, uint64(0x010203), FormStrx3},
+		{"strx4", v5, le, FormStrx4, []byte{4, 3, 2, 1}, uint64(0x01020304), FormStrx4},
+		{"addrx", v5, le, FormAddrx, []byte{0x05}, uint64(5), FormAddrx},
+		{"addrx1", v5, le, FormAddrx1, []byte{1}, uint64(1), FormAddrx1},
+		{"addrx2", v5, be, FormAddrx2, []byte{1, 2}, uint64(0x102), FormAddrx2},
+		{"addrx3", v5, le, FormAddrx3, []byte{3, 2, 1}, uint64(0x010203), FormAddrx3},
+		{"addrx4", v5, le, FormAddrx4, []byte{4, 3, 2, 1}, uint64(0x01020304), FormAddrx4},
+		{"loclistx", v5, le, FormLoclistx, []byte{3}, uint64(3), FormLoclistx},
+		{"rnglistx", v5, le, FormRnglistx, []byte{4}, uint64(4), FormRnglistx},
+
+		{"sec_offset", v4, le, FormSecOffset, []byte{0x40, 0, 0, 0}, uint64(0x40), FormSecOffset},
+		{"sec_offset 64-bit", v4x64, le, FormSecOffset, []byte{0x40, 0, 0, 0, 0, 0, 0, 0}, uint64(0x40), FormSecOffset},
+		// DWARF 2 sizes ref_addr as an address, later versions as an offset
+		{"ref_addr DWARF 2", v2, le, FormRefAddr, []byte{0x34, 0x12, 0, 0}, uint64(0x1234), FormRefAddr},
+		{"ref_addr DWARF 4", v4, le, FormRefAddr, []byte{0x34, 0x12, 0, 0}, uint64(0x1234), FormRefAddr},
+		{"ref_addr 64-bit", v4x64, le, FormRefAddr, []byte{0x34, 0x12, 0, 0, 0, 0, 0, 0}, uint64(0x1234), FormRefAddr},
+		{"ref_sup4", v5, le, FormRefSup4, []byte{0x10, 0, 0, 0}, uint64(0x10), FormRefSup4},
+		{"ref_sup8", v5, le, FormRefSup8, []byte{0x10, 0, 0, 0, 0, 0, 0, 0}, uint64(0x10), FormRefSup8},
+		{"ref_sig8", v4, le, FormRefSig8, []byte{8, 7, 6, 5, 4, 3, 2, 1}, uint64(0x0102030405060708), FormRefSig8},
+
+		// Unit-relative references become .debug_info offsets
+		{"ref1", v4, le, FormRef1, []byte{0x2a}, uint64(0x12a), FormRef1},
+		{"ref2", v4, be, FormRef2, []byte{0x01, 0x2a}, uint64(0x22a), FormRef2},
+		{"ref4", v4, le, FormRef4, []byte{0x2a, 0, 0, 0}, uint64(0x12a), FormRef4},
+		{"ref8", v4, le, FormRef8, []byte{0x2a, 0, 0, 0, 0, 0, 0, 0}, uint64(0x12a), FormRef8},
+		{"ref_udata", v4, le, FormRefUdata, []byte{0x80, 0x01}, uint64(0x180), FormRefUdata},
+
+		{"block1", v4, le, FormBlock1, []byte{2, 0x91, 0x68}, []byte{0x91, 0x68}, FormBlock1},
+		{"block2", v4, be, FormBlock2, []byte{0, 2, 0x91, 0x68}, []byte{0x91, 0x68}, FormBlock2},
+		{"block4", v4, le, FormBlock4, []byte{2, 0, 0, 0, 0x91, 0x68}, []byte{0x91, 0x68}, FormBlock4},
+		{"block", v4, le, FormBlock, []byte{2, 0x91, 0x68}, []byte{0x91, 0x68}, FormBlock},
+		{"exprloc", v4, le, FormExprloc, []byte{2, 0x91, 0x68}, []byte{0x91, 0x68}, FormExprloc},
+
+		// The form is read from the data, and reported in place of indirect
+		{"indirect data2", v4, le, FormIndirect, []byte{byte(FormData2), 0x34, 0x12}, uint64(0x1234), FormData2},
+		{"indirect ref4", v4, le, FormIndirect, []byte{byte(FormRef4), 0x2a, 0, 0, 0}, uint64(0x12a), FormRef4},
+	}
+
+	for _, tt := range tests {
+		d.order = tt.order
+		r := &reader{data: tt.data, order: tt.order}
+		val, form := d.readForm(tt.unit, r, tt.form, -3)
+		if r.err != nil {
+			t.Errorf("%s: %v", tt.name, r.err)
+			continue
+		}
+		if !reflect.DeepEqual(val, tt.want) || form != tt.wantForm {
+			t.Errorf("%s: %#v (form 0x%x), want %#v (form 0x%x)", tt.name, val, form, tt.want, tt.wantForm)
+		}
+		if r.off != len(tt.data) {
+			t.Errorf("%s: consumed %d bytes, want %d", tt.name, r.off, len(tt.data))
+		}
+
+		for n := 0; n < len(tt.data); n++ {
+			r := &reader{data: tt.data[:n], order: tt.order}
+			d.readForm(tt.unit, r, tt.form, -3)
+			if r.err == nil {
+				t.Errorf("%s: truncated to %d bytes without an error", tt.name, n)
+			}
+			if r.off > n {
+				t.Errorf("%s: truncated to %d bytes, read to offset %d", tt.name, n, r.off)
+			}
+		}
+	}
+
+	d.order = binary.LittleEndian
+	bad := []struct {
+		name string
+		form Form
+		data []byte
+	}{
+		{"strp past .debug_str", FormStrp, []byte{0x40, 0, 0, 0}},
+		{"line_strp past .debug_line_str", FormLineStrp, []byte{0x40, 0, 0, 0}},
+		{"huge block", FormBlock, []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+		{"huge block4", FormBlock4, []byte{0xff, 0xff, 0xff, 0xff}},
+		{"unsupported form", 0x99, []byte{0}},
+		{"unsupported indirect form", FormIndirect, []byte{0x99, 0x01, 0}},
+	}
+	for _, tt := range bad {
+		r := &reader{data: tt.data, order: binary.LittleEndian}
+		d.readForm(v5, r, tt.form, 0)
+		if r.err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+// infoSections builds a DWARF 5 unit with 32-bit addresses followed by a
+// DWARF 4 unit in the 64-bit format, sharing one abbreviation table. The
+// first unit names itself and its subprogram through .debug_str_offsets and
+// takes its addresses from .debug_addr.
+func infoSections() map[string][]byte {
+	var abbrev []byte
+	spec := func(attr Attr, form Form) {
+		abbrev = appendULEB(appendULEB(abbrev, uint64(attr)), uint64(form))
+	}
+	// 1: DWARF 5 compile unit
+	abbrev = append(abbrev, 1, byte(TagCompileUnit), 1)
+	spec(AttrName, FormStrx1)
+	spec(AttrStrOffsetsBase, FormSecOffset)
+	spec(AttrAddrBase, FormSecOffset)
+	spec(AttrLowpc, FormAddrx)
+	spec(AttrHighpc, FormData4)
+	spec(AttrLanguage, FormImplicitConst)
+	abbrev = appendSLEB(abbrev, 0x1d) // DW_LANG_C11
+	abbrev = append(abbrev, 0, 0)
+	// 2: subprogram
+	abbrev = append(abbrev, 2, byte(TagSubprogram), 0)
+	spec(AttrName, FormStrx)
+	spec(AttrLowpc, FormAddrx1)
+	spec(AttrHighpc, FormData4)
+	spec(AttrDeclLine, FormImplicitConst)
+	abbrev = appendSLEB(abbrev, -3)
+	spec(AttrType, FormRef4)
+	abbrev = append(abbrev, 0, 0)
+	// 3: base type
+	abbrev = append(abbrev, 3, byte(TagBaseType), 0)
+	spec(AttrName, FormStrp)
+	spec(AttrByteSize, FormData1)
+	abbrev = append(abbrev, 0, 0)
+	// 4: DWARF 4 compile unit
+	abbrev = append(abbrev, 4, byte(TagCompileUnit), 1)
+	spec(AttrName, FormString)
+	spec(AttrCompDir, FormLineStrp)
+	abbrev = append(abbrev, 0, 0)
+	// 5: variable referring to the first unit and to its own
+	abbrev = append(abbrev, 5, byte(TagVariable), 0)
+	spec(AttrName, FormString)
+	spec(AttrType, FormRefAddr)
+	spec(AttrSpecification, FormRefUdata)
+	abbrev = append(abbrev, 0, 0)
+	abbrev = append(abbrev, 0)
+
+	str := []byte("\x00int\x00a.c\x00main\x00")
+	// .debug_str_offsets and .debug_addr after their 8-byte headers
+	strOffsets := []byte{12, 0, 0, 0, 5, 0, 0, 0, 5, 0, 0, 0, 9, 0, 0, 0}
+	addr := []byte{12, 0, 0, 0, 5, 0, 4, 0, 0x00, 0x10, 0, 0, 0x20, 0x10, 0, 0}
+
+	le := binary.LittleEndian
+	body := []byte{1, 0}               // a.c
+	body = le.AppendUint32(body, 8)    // str_offsets_base
+	body = le.AppendUint32(body, 8)    // addr_base
+	body = append(body, 0)             // low_pc 0x1000
+	body = le.AppendUint32(body, 0x40) // high_pc
+	body = append(body, 2, 1, 1)       // main at 0x1020
+	body = le.AppendUint32(body, 0x10) // high_pc
+	body = le.AppendUint32(body, 0x26) // type
+	body = append(body, 3)             // int
+	body = le.AppendUint32(body, 1)    // name
+	body = append(body, 4, 0)          // byte_size, end of children
+	info := le.AppendUint32(nil, uint32(8+len(body)))
+	info = le.AppendUint16(info, 5)
+	info = append(info, 0x01, 4) // DW_UT_compile, address size
+	info = le.AppendUint32(info, 0)
+	info = append(info, body...)
+
+	body = append([]byte{4}, "b.c\x00"...)
+	body = le.AppendUint64(body, 1) // comp_dir
+	body = append(body, 5)
+	body = append(body, "x\x00"...)
+	body = le.AppendUint64(body, 0x26) // int in the first unit
+	body = append(body, 0x17, 0)       // the unit's own root
+	info = le.AppendUint32(info, 0xffffffff)
+	info = le.AppendUint64(info, uint64(2+8+1+len(body)))
+	info = le.AppendUint16(info, 4)
+	info = le.AppendUint64(info, 0)
+	info = append(info, 8)
+	info = append(info, body...)
+
+	return map[string][]byte{
+		".debug_abbrev":      abbrev,
+		".debug_info":        info,
+		".debug_str":         str,
+		".debug_line_str":    []byte("\x00/src\x00"),
+		".debug_str_offsets": strOffsets,
+		".debug_addr":        addr,
+	}
+}
+
+// TestParseUnits tests unit headers, abbreviations with implicit constants,
+// the resolution of indexed strings and addresses and of references
+func TestParseUnits(t *testing.T) {
+	d, err := New(infoSections(), binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	units := d.Units()
+	if len(units) != 2 {
+		t.Fatalf("got %d units, want 2", len(units))
+	}
+
+	u := units[0]
+	if u.Offset != 0 || u.Version != 5 || u.AddrSize != 4 || u.Is64 || u.Name != "a.c" {
+		t.Errorf("unit 0 = offset 0x%x, version %d, address size %d, 64-bit %v, name %q",
+			u.Offset, u.Version, u.AddrSize, u.Is64, u.Name)
+	}
+	if lang := u.Root.Val(AttrLanguage); lang != int64(0x1d) {
+		t.Errorf("language = %#v, want DW_LANG_C11", lang)
+	}
+	if !u.Contains(0x1000) || !u.Contains(0x103f) || u.Contains(0x1040) {
+		t.Errorf("unit ranges %v, want [0x1000, 0x1040)", u.ranges)
+	}
+	if len(u.Root.Children) != 2 {
+		t.Fatalf("root has %d children, want 2", len(u.Root.Children))
+	}
+
+	sub, base := u.Root.Children[0], u.Root.Children[1]
+	if sub.Tag != TagSubprogram || sub.Val(AttrName) != "main" || sub.Val(AttrLowpc) != uint64(0x1020) {
+		t.Errorf("subprogram = %+v", sub)
+	}
+	if line := sub.Val(AttrDeclLine); line != int64(-3) {
+		t.Errorf("decl_line = %#v, want -3", line)
+	}
+	if ref := sub.Val(AttrType); ref != base.Offset || base.Offset != 0x26 {
+		t.Errorf("type reference 0x%x, base type at 0x%x, want 0x26", ref, base.Offset)
+	}
+	if base.Tag != TagBaseType || base.Val(AttrName) != "int" || base.Val(AttrByteSize) != uint64(4) {
+		t.Errorf("base type = %+v", base)
+	}
+	if fn, ok := d.FindFunction(0x1028); !ok || fn.Name != "main" {
+		t.Errorf("FindFunction(0x1028) = %+v, %v, want main", fn, ok)
+	}
+
+	u = units[1]
+	if u.Version != 4 || u.AddrSize != 8 || !u.Is64 || u.Name != "b.c" || u.CompDir != "/src" {
+		t.Errorf("unit 1 = version %d, address size %d, 64-bit %v, name %q, directory %q",
+			u.Version, u.AddrSize, u.Is64, u.Name, u.CompDir)
+	}
+	if len(u.Root.Children) != 1 {
+		t.Fatalf("root has %d children, want 1", len(u.Root.Children))
+	}
+	x := u.Root.Children[0]
+	if ref := x.Val(AttrType); ref != uint64(0x26) {
+		t.Errorf("ref_addr = %#v, want 0x26", ref)
+	}
+	if ref := x.Val(AttrSpecification); ref != u.Root.Offset {
+		t.Errorf("ref_udata = %#v, want the root at 0x%x", ref, u.Root.Offset)
+	}
+
+	// Indices past .debug_str_offsets or .debug_addr are left unresolved
+	sections := infoSections()
+	sections[".debug_str_offsets"] = sections[".debug_str_offsets"][:12]
+	sections[".debug_addr"] = sections[".debug_addr"][:12]
+	d, err = New(sections, binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("New with short index sections failed: %v", err)
+	}
+	sub = d.Units()[0].Root.Children[0]
+	if sub.Val(AttrName) != uint64(1) || sub.Val(AttrLowpc) != uint64(1) {
+		t.Errorf("unresolved subprogram = %+v", sub)
+	}
+}
+
+// TestParseUnitsErrors tests that malformed or truncated sections give an
+// error instead of a panic or a partial result
+func TestParseUnitsErrors(t *testing.T) {
+	valid := infoSections()
+	info, abbrev := valid[".debug_info"], valid[".debug_abbrev"]
+
+	with := func(name string, data []byte) map[string][]byte {
+		sections := infoSections()
+		sections[name] = data
+		return sections
+	}
+	patch := func(off int, b ...byte) []byte {
+		data := bytes.Clone(info)
+		copy(data[off:], b)
+		return data
+	}
+
+	type errorCase struct {
+		name     string
+		sections map[string][]byte
+		want     string
+	}
+	tests := []errorCase{
+		{"no .debug_info", with(".debug_info", nil), "no .debug_info"},
+		{"no .debug_abbrev", with(".debug_abbrev", nil), "no .debug_abbrev"},
+		{"zero length", with(".debug_info", patch(0, 0, 0, 0, 0)), "bad length"},
+		{"length past the section", with(".debug_info", patch(0, 0xff, 0, 0, 0)), "bad length"},
+		{"DWARF 1", with(".debug_info", patch(4, 1, 0)), "unsupported DWARF version 1"},
+		{"DWARF 6", with(".debug_info", patch(4, 6, 0)), "unsupported DWARF version 6"},
+		{"address size", with(".debug_info", patch(7, 2)), "unsupported address size 2"},
+		{"abbreviation offset", with(".debug_info", patch(8, 0xff)), "abbreviation offset"},
+		{"abbreviation code", with(".debug_info", patch(12, 9)), "unknown abbreviation code 9"},
+		{"attribute form", with(".debug_abbrev", bytes.Replace(abbrev, []byte{byte(AttrByteSize), byte(FormData1)}, []byte{byte(AttrByteSize), 0x7f}, 1)), "unsupported form 0x7f"},
+		{"strp", with(".debug_str", []byte{0}), "string offset"},
+	}
+
+	// Every cut of either section ends inside a unit or an abbreviation,
+	// except the one between the two units
+	first := 4 + int(binary.LittleEndian.Uint32(info))
+	for n := 1; n < len(info); n++ {
+		if n != first {
+			tests = append(tests, errorCase{"info truncated", with(".debug_info", info[:n]), ""})
+		}
+	}
+	for n := 1; n < len(abbrev); n++ {
+		tests = append(tests, errorCase{"abbreviations truncated", with(".debug_abbrev", abbrev[:n]), ""})
+	}
+
+	// An attribute cut short by the unit length, rather than the section
+	short := bytes.Clone(info)
+	binary.LittleEndian.PutUint32(short, binary.LittleEndian.Uint32(short)-2)
+	tests = append(tests, errorCase{"attribute past the unit", with(".debug_info", short), "truncated data"})
+
+	for _, tt := range tests {
+		_, err := New(tt.sections, binary.LittleEndian)
+		if err == nil {
+			t.Errorf("%s (%d bytes): no error", tt.name, len(tt.sections[".debug_info"]))
+		} else if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %q, want %q", tt.name, err, tt.want)
+		}
+	}
+}
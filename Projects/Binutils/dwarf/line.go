@@ -0,0 +1,333 @@
+package dwarf
+
+import (
+	"fmt"
+	"path"
+)
+
+// LineRow is one row of the line-number matrix
+type LineRow struct {
+	Address     uint64
+	File        string
+	Line        int
+	Column      int
+	IsStmt      bool
+	EndSequence bool
+}
+
+// lineHeader holds the fields of a line program header
+type lineHeader struct {
+	version       uint16
+	is64          bool
+	addrSize      int
+	minInstLength uint8
+	maxOpsPerInst uint8
+	defaultIsStmt bool
+	lineBase      int8
+	lineRange     uint8
+	opcodeBase    uint8
+	opcodeLengths []uint8
+	directories   []string
+	files         []string
+	programEnd    int
+}
+
+// LineTable decodes the line-number program of a unit. Rows are returned in
+// program order: one or more address-sorted sequences, each terminated by a
+// row with EndSequence set.
+func (d *Data) LineTable(unit *Unit) ([]LineRow, error) {
+	if unit.loaded {
+		return unit.lines, unit.lineErr
+	}
+	unit.loaded = true
+
+	off, ok := unit.Root.Val(AttrStmtList).(uint64)
+	if !ok {
+		unit.lineErr = fmt.Errorf("unit %s has no line table", unit.Name)
+		return nil, unit.lineErr
+	}
+	if off >= uint64(len(d.line)) {
+		unit.lineErr = fmt.Errorf("line table offset 0x%x out of range", off)
+		return nil, unit.lineErr
+	}
+
+	r := &reader{data: d.line, off: int(off), order: d.order}
+	header, err := d.parseLineHeader(r, unit)
+	if err != nil {
+		unit.lineErr = fmt.Errorf("line table at 0x%x: %w", off, err)
+		return nil, unit.lineErr
+	}
+
+	r.data = d.line[:header.programEnd]
+	unit.lines, unit.lineErr = runLineProgram(r, header)
+	return unit.lines, unit.lineErr
+}
+
+// parseLineHeader decodes a DWARF 2-5 line program header
+func (d *Data) parseLineHeader(r *reader, unit *Unit) (*lineHeader, error) {
+	h := &lineHeader{addrSize: unit.AddrSize}
+
+	length, is64 := r.unitLength()
+	h.is64 = is64
+	if r.err != nil || length > uint64(len(r.data)-r.off) {
+		return nil, fmt.Errorf("bad unit length")
+	}
+	h.programEnd = r.off + int(length)
+
+	h.version = r.u16()
+	if h.version < 2 || h.version > 5 {
+		return nil, fmt.Errorf("unsupported line table version %d", h.version)
+	}
+	if h.version >= 5 {
+		h.addrSize = int(r.u8())
+		r.u8() // segment_selector_size
+	}
+
+	headerLength := r.offset(is64)
+	programStart := r.off + int(headerLength)
+	if headerLength > uint64(h.programEnd-r.off) {
+		return nil, fmt.Errorf("bad header length")
+	}
+
+	h.minInstLength = r.u8()
+	h.maxOpsPerInst = 1
+	if h.version >= 4 {
+		h.maxOpsPerInst = r.u8()
+	}
+	h.defaultIsStmt = r.u8() != 0
+	h.lineBase = int8(r.u8())
+	h.lineRange = r.u8()
+	h.opcodeBase = r.u8()
+	if h.lineRange == 0 {
+		return nil, fmt.Errorf("line_range is zero")
+	}
+	if h.opcodeBase > 0 {
+		h.opcodeLengths = r.bytes(int(h.opcodeBase) - 1)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	compDir := unit.CompDir
+	if h.version >= 5 {
+		dirs, err := d.readEntryFormats(r, unit, h)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			h.directories = append(h.directories, dir.name)
+		}
+		files, err := d.readEntryFormats(r, unit, h)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			h.files = append(h.files, joinPath(h.directories, int(file.dir), file.name, compDir))
+		}
+	} else {
+		// Directory 0 is the compilation directory
+		h.directories = []string{compDir}
+		for {
+			dir := r.cstring()
+			if dir == "" || r.err != nil {
+				break
+			}
+			h.directories = append(h.directories, dir)
+		}
+		// File 0 is unused before DWARF 5
+		h.files = []string{""}
+		for {
+			name := r.cstring()
+			if name == "" || r.err != nil {
+				break
+			}
+			dir := r.uleb()
+			r.uleb() // modification time
+			r.uleb() // length
+			h.files = append(h.files, joinPath(h.directories, int(dir), name, compDir))
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	r.off = programStart
+	return h, nil
+}
+
+// fileEntry is a DWARF 5 directory or file name entry
+type fileEntry struct {
+	name string
+	dir  uint64
+}
+
+// readEntryFormats decodes a DWARF 5 entry-format description followed by
+// the entries it describes
+func (d *Data) readEntryFormats(r *reader, unit *Unit, h *lineHeader) ([]fileEntry, error) {
+	type format struct {
+		content uint64
+		form    Form
+	}
+
+	formatCount := int(r.u8())
+	formats := make([]format, formatCount)
+	for i := range formats {
+		formats[i] = format{content: r.uleb(), form: Form(r.uleb())}
+	}
+
+	count := r.uleb()
+	if r.err != nil {
+		return nil, r.err
+	}
+	// Secure: bound the number of entries
+	if count > 1<<20 {
+		return nil, fmt.Errorf("too many file entries: %d", count)
+	}
+
+	// Values are decoded with the line table's offset size
+	formUnit := &Unit{Offset: unit.Offset, Version: 5, AddrSize: h.addrSize, Is64: h.is64}
+
+	entries := make([]fileEntry, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var entry fileEntry
+		for _, f := range formats {
+			val, _ := d.readForm(formUnit, r, f.form, 0)
+			if r.err != nil {
+				return nil, r.err
+			}
+			switch f.content {
+			case 0x1: // DW_LNCT_path
+				entry.name, _ = val.(string)
+			case 0x2: // DW_LNCT_directory_index
+				entry.dir, _ = val.(uint64)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// joinPath builds the path of a file from its directory index
+func joinPath(dirs []string, index int, name, compDir string) string {
+	if path.IsAbs(name) {
+		return name
+	}
+	dir := ""
+	if index >= 0 && index < len(dirs) {
+		dir = dirs[index]
+	}
+	if dir != "" && !path.IsAbs(dir) && compDir != "" && dir != compDir {
+		dir = path.Join(compDir, dir)
+	}
+	if dir == "" {
+		return name
+	}
+	return path.Join(dir, name)
+}
+
+// runLineProgram executes the line-number state machine
+func runLineProgram(r *reader, h *lineHeader) ([]LineRow, error) {
+	var rows []LineRow
+
+	type state struct {
+		address uint64
+		file    int
+		line    int
+		column  int
+		isStmt  bool
+	}
+	reset := func() state {
+		return state{file: 1, line: 1, isStmt: h.defaultIsStmt}
+	}
+	st := reset()
+
+	emit := func(end bool) {
+		file := ""
+		if st.file >= 0 && st.file < len(h.files) {
+			file = h.files[st.file]
+		}
+		rows = append(rows, LineRow{
+			Address:     st.address,
+			File:        file,
+			Line:        st.line,
+			Column:      st.column,
+			IsStmt:      st.isStmt,
+			EndSequence: end,
+		})
+	}
+
+	advance := func(opAdvance uint64) {
+		st.address += opAdvance * uint64(h.minInstLength)
+	}
+
+	for r.off < len(r.data) && r.err == nil {
+		// Secure: bound the size of the decoded matrix
+		if len(rows) > 50000000 {
+			return nil, fmt.Errorf("line table too large")
+		}
+
+		opcode := r.u8()
+		if opcode >= h.opcodeBase {
+			// Special opcode
+			adjusted := int(opcode - h.opcodeBase)
+			advance(uint64(adjusted / int(h.lineRange)))
+			st.line += int(h.lineBase) + adjusted%int(h.lineRange)
+			emit(false)
+			continue
+		}
+
+		switch opcode {
+		case 0: // Extended opcode
+			length := r.uleb()
+			if length == 0 || length > uint64(len(r.data)-r.off) {
+				return nil, fmt.Errorf("bad extended opcode length %d", length)
+			}
+			end := r.off + int(length)
+			switch r.u8() {
+			case 1: // DW_LNE_end_sequence
+				emit(true)
+				st = reset()
+			case 2: // DW_LNE_set_address
+				st.address = r.uint(int(length) - 1)
+			case 3: // DW_LNE_define_file
+				name := r.cstring()
+				dir := r.uleb()
+				h.files = append(h.files, joinPath(h.directories, int(dir), name, ""))
+			}
+			r.off = end
+		case 1: // DW_LNS_copy
+			emit(false)
+		case 2: // DW_LNS_advance_pc
+			advance(r.uleb())
+		case 3: // DW_LNS_advance_line
+			st.line += int(r.sleb())
+		case 4: // DW_LNS_set_file
+			st.file = int(r.uleb())
+		case 5: // DW_LNS_set_column
+			st.column = int(r.uleb())
+		case 6: // DW_LNS_negate_stmt
+			st.isStmt = !st.isStmt
+		case 7: // DW_LNS_set_basic_block
+		case 8: // DW_LNS_const_add_pc
+			advance(uint64((255 - int(h.opcodeBase)) / int(h.lineRange)))
+		case 9: // DW_LNS_fixed_advance_pc
+			st.address += uint64(r.u16())
+		case 10, 11: // DW_LNS_set_prologue_end, DW_LNS_set_epilogue_begin
+		case 12: // DW_LNS_set_isa
+			r.uleb()
+		default:
+			// Unknown standard opcode: skip its ULEB operands
+			if int(opcode) <= len(h.opcodeLengths) {
+				for i := uint8(0); i < h.opcodeLengths[opcode-1]; i++ {
+					r.uleb()
+				}
+			}
+		}
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return rows, nil
+}
@@ -0,0 +1,94 @@
+package dwarf
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// TestLEB128 tests LEB128 decoding
+func TestLEB128(t *testing.T) {
+	r := &reader{data: []byte{0xe5, 0x8e, 0x26, 0x7f, 0x80, 0x7f}, order: binary.LittleEndian}
+
+	if v := r.uleb(); v != 624485 {
+		t.Errorf("uleb = %d, want 624485", v)
+	}
+	if v := r.sleb(); v != -1 {
+		t.Errorf("sleb = %d, want -1", v)
+	}
+	if v := r.sleb(); v != -128 {
+		t.Errorf("sleb = %d, want -128", v)
+	}
+	if r.err != nil {
+		t.Errorf("unexpected error: %v", r.err)
+	}
+}
+
+// TestLineProgram tests decoding of a DWARF 4 line table
+func TestLineProgram(t *testing.T) {
+	header := []byte{
+		0x04, 0x00, // version
+		0x00, 0x00, 0x00, 0x00, // header_length, patched below
+		0x01,                               // minimum_instruction_length
+		0x01,                               // maximum_operations_per_instruction
+		0x01,                               // default_is_stmt
+		0xfb,                               // line_base = -5
+		0x0e,                               // line_range = 14
+		0x0d,                               // opcode_base = 13
+		0, 1, 1, 1, 1, 0, 0, 0, 1, 0, 0, 1, // standard_opcode_lengths
+		's', 'r', 'c', 0, 0, // include_directories
+		'a', '.', 'c', 0, 0x01, 0x00, 0x00, // file_names
+		0,
+	}
+	binary.LittleEndian.PutUint32(header[2:], uint32(len(header)-6))
+
+	program := []byte{
+		0x00, 0x09, 0x02, 0x00, 0x10, 0, 0, 0, 0, 0, 0, // set_address 0x1000
+		0x05, 0x03, // set_column 3
+		0x03, 0x09, // advance_line 9 -> line 10
+		0x01,       // copy
+		0x4b,       // special: address +4, line +1
+		0x02, 0x04, // advance_pc 4
+		0x00, 0x01, 0x01, // end_sequence
+	}
+
+	table := append(header, program...)
+	data := make([]byte, 4, 4+len(table))
+	binary.LittleEndian.PutUint32(data, uint32(len(table)))
+	data = append(data, table...)
+
+	d := &Data{line: data, order: binary.LittleEndian}
+	unit := &Unit{AddrSize: 8, CompDir: "/build"}
+
+	r := &reader{data: d.line, order: d.order}
+	h, err := d.parseLineHeader(r, unit)
+	if err != nil {
+		t.Fatalf("parseLineHeader failed: %v", err)
+	}
+	r.data = d.line[:h.programEnd]
+
+	rows, err := runLineProgram(r, h)
+	if err != nil {
+		t.Fatalf("runLineProgram failed: %v", err)
+	}
+
+	expected := []LineRow{
+		{Address: 0x1000, File: "/build/src/a.c", Line: 10, Column: 3, IsStmt: true},
+		{Address: 0x1004, File: "/build/src/a.c", Line: 11, Column: 3, IsStmt: true},
+		{Address: 0x1008, File: "/build/src/a.c", Line: 11, Column: 3, IsStmt: true, EndSequence: true},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("got %d rows, want %d: %+v", len(rows), len(expected), rows)
+	}
+	for i := range expected {
+		if rows[i] != expected[i] {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], expected[i])
+		}
+	}
+
+	if row, ok := lookupRow(rows, 0x1006); !ok || row.Line != 11 {
+		t.Errorf("lookupRow(0x1006) = %+v, %v", row, ok)
+	}
+	if _, ok := lookupRow(rows, 0x1008); ok {
+		t.Errorf("lookupRow(0x1008) should be past the end of the sequence")
+	}
+}
@@ -0,0 +1,217 @@
+package elf
+
+// Section header types (sh_type)
+const (
+	SHT_NULL     = 0
+	SHT_PROGBITS = 1
+	SHT_SYMTAB   = 2
+	SHT_STRTAB   = 3
+	SHT_RELA     = 4
+	SHT_HASH     = 5
+	SHT_DYNAMIC  = 6
+	SHT_NOTE     = 7
+	SHT_NOBITS   = 8
+	SHT_REL      = 9
+	SHT_SHLIB    = 10
+	SHT_DYNSYM   = 11
+
+	SHT_GROUP        = 17
+	SHT_SYMTAB_SHNDX = 18
+
+	SHT_GNU_HASH    = 0x6ffffff6
+	SHT_GNU_verdef  = 0x6ffffffd
+	SHT_GNU_verneed = 0x6ffffffe
+	SHT_GNU_versym  = 0x6fffffff
+)
+
+// Section header flags (sh_flags)
+const (
+	SHF_WRITE     = 0x1
+	SHF_ALLOC     = 0x2
+	SHF_EXECINSTR = 0x4
+	SHF_MERGE     = 0x10
+	SHF_STRINGS   = 0x20
+	SHF_INFO_LINK = 0x40
+	SHF_GROUP     = 0x200
+	SHF_EXCLUDE   = 0x80000000
+)
+
+// Section group flags, the first word of an SHT_GROUP section
+const (
+	GRP_COMDAT = 0x1
+)
+
+// Special section indices (st_shndx)
+const (
+	SHN_UNDEF     = 0
+	SHN_LORESERVE = 0xff00
+	SHN_ABS       = 0xfff1
+	SHN_COMMON    = 0xfff2
+	SHN_XINDEX    = 0xffff
+)
+
+// Program header types (p_type)
+const (
+	PT_NULL    = 0
+	PT_LOAD    = 1
+	PT_DYNAMIC = 2
+	PT_INTERP  = 3
+	PT_NOTE    = 4
+
+	PT_GNU_STACK = 0x6474e551
+)
+
+// Program header flags (p_flags)
+const (
+	PF_X = 0x1
+	PF_W = 0x2
+	PF_R = 0x4
+)
+
+// Dynamic section tags (d_tag)
+const (
+	DT_NULL            = 0
+	DT_NEEDED          = 1
+	DT_PLTRELSZ        = 2
+	DT_PLTGOT          = 3
+	DT_HASH            = 4
+	DT_STRTAB          = 5
+	DT_SYMTAB          = 6
+	DT_RELA            = 7
+	DT_RELASZ          = 8
+	DT_RELAENT         = 9
+	DT_STRSZ           = 10
+	DT_SYMENT          = 11
+	DT_INIT            = 12
+	DT_FINI            = 13
+	DT_SONAME          = 14
+	DT_RPATH           = 15
+	DT_SYMBOLIC        = 16
+	DT_REL             = 17
+	DT_RELSZ           = 18
+	DT_RELENT          = 19
+	DT_PLTREL          = 20
+	DT_DEBUG           = 21
+	DT_TEXTREL         = 22
+	DT_JMPREL          = 23
+	DT_BIND_NOW        = 24
+	DT_INIT_ARRAY      = 25
+	DT_FINI_ARRAY      = 26
+	DT_INIT_ARRAYSZ    = 27
+	DT_FINI_ARRAYSZ    = 28
+	DT_RUNPATH         = 29
+	DT_FLAGS           = 30
+	DT_PREINIT_ARRAY   = 32
+	DT_PREINIT_ARRAYSZ = 33
+	DT_SYMTAB_SHNDX    = 34
+	DT_RELRSZ          = 35
+	DT_RELR            = 36
+	DT_RELRENT         = 37
+	DT_GNU_HASH        = 0x6ffffef5
+	DT_VERSYM          = 0x6ffffff0
+	DT_RELACOUNT       = 0x6ffffff9
+	DT_RELCOUNT        = 0x6ffffffa
+	DT_FLAGS_1         = 0x6ffffffb
+	DT_VERDEF          = 0x6ffffffc
+	DT_VERDEFNUM       = 0x6ffffffd
+	DT_VERNEED         = 0x6ffffffe
+	DT_VERNEEDNUM      = 0x6fffffff
+)
+
+// DT_FLAGS values
+const (
+	DF_ORIGIN     = 0x1
+	DF_SYMBOLIC   = 0x2
+	DF_TEXTREL    = 0x4
+	DF_BIND_NOW   = 0x8
+	DF_STATIC_TLS = 0x10
+)
+
+// DT_FLAGS_1 values
+const (
+	DF_1_NOW       = 0x1
+	DF_1_GLOBAL    = 0x2
+	DF_1_GROUP     = 0x4
+	DF_1_NODELETE  = 0x8
+	DF_1_INITFIRST = 0x20
+	DF_1_NOOPEN    = 0x40
+	DF_1_ORIGIN    = 0x80
+	DF_1_NODEFLIB  = 0x800
+	DF_1_PIE       = 0x8000000
+)
+
+// GNU note types (n_type for the "GNU" namespace)
+const (
+	NT_GNU_ABI_TAG         = 1
+	NT_GNU_HWCAP           = 2
+	NT_GNU_BUILD_ID        = 3
+	NT_GNU_GOLD_VERSION    = 4
+	NT_GNU_PROPERTY_TYPE_0 = 5
+)
+
+// Machine types (e_machine)
+const (
+	EM_386    = 3
+	EM_X86_64 = 62
+)
+
+// x86-64 relocation types
+const (
+	R_X86_64_NONE          = 0
+	R_X86_64_64            = 1
+	R_X86_64_PC32          = 2
+	R_X86_64_GOT32         = 3
+	R_X86_64_PLT32         = 4
+	R_X86_64_COPY          = 5
+	R_X86_64_GLOB_DAT      = 6
+	R_X86_64_JUMP_SLOT     = 7
+	R_X86_64_RELATIVE      = 8
+	R_X86_64_GOTPCREL      = 9
+	R_X86_64_32            = 10
+	R_X86_64_32S           = 11
+	R_X86_64_16            = 12
+	R_X86_64_PC16          = 13
+	R_X86_64_8             = 14
+	R_X86_64_PC8           = 15
+	R_X86_64_DTPMOD64      = 16
+	R_X86_64_DTPOFF64      = 17
+	R_X86_64_TPOFF64       = 18
+	R_X86_64_TLSGD         = 19
+	R_X86_64_TLSLD         = 20
+	R_X86_64_DTPOFF32      = 21
+	R_X86_64_GOTTPOFF      = 22
+	R_X86_64_TPOFF32       = 23
+	R_X86_64_PC64          = 24
+	R_X86_64_GOTOFF64      = 25
+	R_X86_64_GOTPC32       = 26
+	R_X86_64_SIZE32        = 32
+	R_X86_64_SIZE64        = 33
+	R_X86_64_IRELATIVE     = 37
+	R_X86_64_GOTPCRELX     = 41
+	R_X86_64_REX_GOTPCRELX = 42
+)
+
+// i386 relocation types
+const (
+	R_386_NONE         = 0
+	R_386_32           = 1
+	R_386_PC32         = 2
+	R_386_GOT32        = 3
+	R_386_PLT32        = 4
+	R_386_COPY         = 5
+	R_386_GLOB_DAT     = 6
+	R_386_JMP_SLOT     = 7
+	R_386_RELATIVE     = 8
+	R_386_GOTOFF       = 9
+	R_386_GOTPC        = 10
+	R_386_TLS_TPOFF    = 14
+	R_386_16           = 20
+	R_386_PC16         = 21
+	R_386_8            = 22
+	R_386_PC8          = 23
+	R_386_TLS_DTPMOD32 = 35
+	R_386_TLS_DTPOFF32 = 36
+	R_386_TLS_TPOFF32  = 37
+	R_386_IRELATIVE    = 42
+	R_386_GOT32X       = 43
+)
@@ -0,0 +1,255 @@
+package elf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// DynamicEntry represents one entry of the dynamic section
+type DynamicEntry struct {
+	Tag   int64
+	Value uint64
+	Str   string // Resolved for DT_NEEDED, DT_SONAME, DT_RPATH and DT_RUNPATH
+}
+
+// dynamicTagNames maps dynamic tags to their names without the DT_ prefix
+var dynamicTagNames = map[int64]string{
+	DT_NULL:            "NULL",
+	DT_NEEDED:          "NEEDED",
+	DT_PLTRELSZ:        "PLTRELSZ",
+	DT_PLTGOT:          "PLTGOT",
+	DT_HASH:            "HASH",
+	DT_STRTAB:          "STRTAB",
+	DT_SYMTAB:          "SYMTAB",
+	DT_RELA:            "RELA",
+	DT_RELASZ:          "RELASZ",
+	DT_RELAENT:         "RELAENT",
+	DT_STRSZ:           "STRSZ",
+	DT_SYMENT:          "SYMENT",
+	DT_INIT:            "INIT",
+	DT_FINI:            "FINI",
+	DT_SONAME:          "SONAME",
+	DT_RPATH:           "RPATH",
+	DT_SYMBOLIC:        "SYMBOLIC",
+	DT_REL:             "REL",
+	DT_RELSZ:           "RELSZ",
+	DT_RELENT:          "RELENT",
+	DT_PLTREL:          "PLTREL",
+	DT_DEBUG:           "DEBUG",
+	DT_TEXTREL:         "TEXTREL",
+	DT_JMPREL:          "JMPREL",
+	DT_BIND_NOW:        "BIND_NOW",
+	DT_INIT_ARRAY:      "INIT_ARRAY",
+	DT_FINI_ARRAY:      "FINI_ARRAY",
+	DT_INIT_ARRAYSZ:    "INIT_ARRAYSZ",
+	DT_FINI_ARRAYSZ:    "FINI_ARRAYSZ",
+	DT_RUNPATH:         "RUNPATH",
+	DT_FLAGS:           "FLAGS",
+	DT_PREINIT_ARRAY:   "PREINIT_ARRAY",
+	DT_PREINIT_ARRAYSZ: "PREINIT_ARRAYSZ",
+	DT_SYMTAB_SHNDX:    "SYMTAB_SHNDX",
+	DT_RELRSZ:          "RELRSZ",
+	DT_RELR:            "RELR",
+	DT_RELRENT:         "RELRENT",
+	DT_GNU_HASH:        "GNU_HASH",
+	DT_VERSYM:          "VERSYM",
+	DT_RELACOUNT:       "RELACOUNT",
+	DT_RELCOUNT:        "RELCOUNT",
+	DT_FLAGS_1:         "FLAGS_1",
+	DT_VERDEF:          "VERDEF",
+	DT_VERDEFNUM:       "VERDEFNUM",
+	DT_VERNEED:         "VERNEED",
+	DT_VERNEEDNUM:      "VERNEEDNUM",
+}
+
+// GetDynamicTag returns the name of a dynamic tag without the DT_ prefix
+func GetDynamicTag(tag int64) string {
+	if name, ok := dynamicTagNames[tag]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", uint64(tag))
+}
+
+// Needed returns the DT_NEEDED libraries in load order
+func (e *ELF) Needed() []string {
+	needed := []string{}
+	for _, entry := range e.Dynamic {
+		if entry.Tag == DT_NEEDED {
+			needed = append(needed, entry.Str)
+		}
+	}
+	return needed
+}
+
+// DynamicString returns the string of the first entry with the given tag,
+// such as DT_SONAME or DT_RUNPATH
+func (e *ELF) DynamicString(tag int64) (string, bool) {
+	for _, entry := range e.Dynamic {
+		if entry.Tag == tag {
+			return entry.Str, true
+		}
+	}
+	return "", false
+}
+
+// DynamicValue returns the value of the first entry with the given tag
+func (e *ELF) DynamicValue(tag int64) (uint64, bool) {
+	for _, entry := range e.Dynamic {
+		if entry.Tag == tag {
+			return entry.Value, true
+		}
+	}
+	return 0, false
+}
+
+// Interpreter returns the program interpreter named by PT_INTERP
+func (e *ELF) Interpreter(r io.ReadSeeker) (string, error) {
+	for _, seg := range e.Segments {
+		if seg.Type != PT_INTERP {
+			continue
+		}
+		// Secure: an interpreter path is never this long
+		if seg.FileSz > 4096 {
+			return "", fmt.Errorf("interpreter path too long: %d bytes", seg.FileSz)
+		}
+		data, err := readAt(r, seg.Offset, seg.FileSz)
+		if err != nil {
+			return "", fmt.Errorf("failed to read interpreter: %w", err)
+		}
+		return ReadCString(data), nil
+	}
+	return "", nil
+}
+
+// DynamicOffset returns the file offset of the dynamic section
+func (e *ELF) DynamicOffset() (uint64, bool) {
+	for _, section := range e.Sections {
+		if section.Type == SHT_DYNAMIC {
+			return section.Offset, true
+		}
+	}
+	for _, seg := range e.Segments {
+		if seg.Type == PT_DYNAMIC {
+			return seg.Offset, true
+		}
+	}
+	return 0, false
+}
+
+// parseDynamic parses the dynamic section and resolves its strings. The
+// section headers are used when present; otherwise the PT_DYNAMIC segment
+// and the DT_STRTAB address are followed, as the dynamic loader does.
+func parseDynamic(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
+	var offset, size uint64
+	var strtab StringTable
+	found := false
+
+	for i := range elf.Sections {
+		section := &elf.Sections[i]
+		if section.Type != SHT_DYNAMIC {
+			continue
+		}
+		offset, size, found = section.Offset, section.Size, true
+		if int(section.Link) < len(elf.Sections) && elf.Sections[section.Link].Type == SHT_STRTAB {
+			data, err := elf.Sections[section.Link].ReadAll(100 * 1024 * 1024)
+			if err != nil {
+				return err
+			}
+			strtab = data
+		}
+		break
+	}
+
+	// Separate debug files keep PT_DYNAMIC but turn .dynamic into SHT_NOBITS,
+	// so the segment is only trusted when there are no section headers
+	if !found && len(elf.Sections) == 0 {
+		for _, seg := range elf.Segments {
+			if seg.Type == PT_DYNAMIC {
+				offset, size, found = seg.Offset, seg.FileSz, true
+				break
+			}
+		}
+	}
+	if !found || size == 0 {
+		return nil // Statically linked
+	}
+
+	// Secure: limit dynamic section size
+	if size > 1024*1024 {
+		return fmt.Errorf("dynamic section too large: %d", size)
+	}
+	data, err := readAt(r, offset, size)
+	if err != nil {
+		return fmt.Errorf("failed to read dynamic section: %w", err)
+	}
+
+	entrySize := 16
+	if elf.Class == "ELF32" {
+		entrySize = 8
+	}
+	for pos := 0; pos+entrySize <= len(data); pos += entrySize {
+		var entry DynamicEntry
+		if entrySize == 8 {
+			entry.Tag = int64(int32(endian.Uint32(data[pos:])))
+			entry.Value = uint64(endian.Uint32(data[pos+4:]))
+		} else {
+			entry.Tag = int64(endian.Uint64(data[pos:]))
+			entry.Value = endian.Uint64(data[pos+8:])
+		}
+		elf.Dynamic = append(elf.Dynamic, entry)
+		if entry.Tag == DT_NULL {
+			break
+		}
+	}
+
+	if strtab == nil {
+		addr, hasAddr := elf.DynamicValue(DT_STRTAB)
+		strsz, hasSize := elf.DynamicValue(DT_STRSZ)
+		if hasAddr && hasSize {
+			// Secure: validate string table size
+			if strsz > 100*1024*1024 {
+				return fmt.Errorf("string table too large: %d", strsz)
+			}
+			if off, ok := elf.addressToOffset(addr); ok {
+				if strtab, err = readAt(r, off, strsz); err != nil {
+					return fmt.Errorf("failed to read dynamic string table: %w", err)
+				}
+			}
+		}
+	}
+
+	for i := range elf.Dynamic {
+		switch elf.Dynamic[i].Tag {
+		case DT_NEEDED, DT_SONAME, DT_RPATH, DT_RUNPATH:
+			if elf.Dynamic[i].Value <= 0xffffffff {
+				elf.Dynamic[i].Str, _ = strtab.Lookup(uint32(elf.Dynamic[i].Value))
+			}
+		}
+	}
+
+	return nil
+}
+
+// addressToOffset maps a virtual address to a file offset through the
+// PT_LOAD segments
+func (e *ELF) addressToOffset(addr uint64) (uint64, bool) {
+	for _, seg := range e.Segments {
+		if seg.Type == PT_LOAD && addr >= seg.VAddr && addr < seg.VAddr+seg.FileSz {
+			return seg.Offset + (addr - seg.VAddr), true
+		}
+	}
+	return 0, false
+}
+
+// readAt reads size bytes at offset
+func readAt(r io.ReadSeeker, offset, size uint64) ([]byte, error) {
+	if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, shortRead(err)
+	}
+	return data, nil
+}
@@ -0,0 +1,157 @@
+package elf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// dynamicImage32 builds an ELF32 shared object without section headers: a
+// PT_LOAD segment maps the whole file at address 0x1000 and PT_DYNAMIC
+// points at the dynamic entries, which the loader follows to the strings
+func dynamicImage32(endian binary.ByteOrder, data byte) []byte {
+	const dynOff, strOff = 116, 180
+	strtab := "\x00libc.so.6\x00libm.so.6\x00libx.so.1\x00$ORIGIN/../lib\x00"
+	entries := [][2]uint32{
+		{DT_NEEDED, 1},
+		{DT_NEEDED, 11},
+		{DT_SONAME, 21},
+		{DT_RUNPATH, 31},
+		{DT_STRTAB, 0x1000 + strOff},
+		{DT_STRSZ, uint32(len(strtab))},
+		{DT_VERNEEDNUM, 2},
+		{DT_NULL, 0},
+	}
+
+	image := make([]byte, strOff+len(strtab))
+	copy(image, []byte{0x7f, 'E', 'L', 'F', 1, data, 1})
+	endian.PutUint16(image[16:], 3) // ET_DYN
+	endian.PutUint16(image[18:], EM_386)
+	endian.PutUint32(image[20:], 1)
+	endian.PutUint32(image[28:], 52) // e_phoff
+	endian.PutUint16(image[40:], 52) // e_ehsize
+	endian.PutUint16(image[42:], 32) // e_phentsize
+	endian.PutUint16(image[44:], 2)  // e_phnum
+
+	load := image[52:]
+	endian.PutUint32(load[0:], PT_LOAD)
+	endian.PutUint32(load[8:], 0x1000)
+	endian.PutUint32(load[16:], uint32(len(image)))
+	endian.PutUint32(load[20:], uint32(len(image)))
+	dynamic := image[84:]
+	endian.PutUint32(dynamic[0:], PT_DYNAMIC)
+	endian.PutUint32(dynamic[4:], dynOff)
+	endian.PutUint32(dynamic[8:], 0x1000+dynOff)
+	endian.PutUint32(dynamic[16:], uint32(8*len(entries)))
+	endian.PutUint32(dynamic[20:], uint32(8*len(entries)))
+
+	for i, entry := range entries {
+		endian.PutUint32(image[dynOff+8*i:], entry[0])
+		endian.PutUint32(image[dynOff+8*i+4:], entry[1])
+	}
+	copy(image[strOff:], strtab)
+	return image
+}
+
+// TestParseDynamic tests the dynamic entries and their strings, found
+// through the section headers of the fixtures in testdata/dyn and through
+// PT_DYNAMIC and DT_STRTAB when there are no section headers
+func TestParseDynamic(t *testing.T) {
+	dir := filepath.Join("..", "testdata", "dyn")
+	open := func(t *testing.T, data []byte) *ELF {
+		t.Helper()
+		e, err := ParseELF(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("ParseELF: %v", err)
+		}
+		return e
+	}
+	read := func(t *testing.T, name string) []byte {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	// The section headers of a program are removed as sstrip does
+	runpath := read(t, "runpath")
+	stripped := slices.Clone(runpath)
+	clear(stripped[0x28:0x30]) // e_shoff
+	clear(stripped[0x3c:0x40]) // e_shnum, e_shstrndx
+
+	tests := []struct {
+		name    string
+		data    []byte
+		needed  []string
+		strings map[int64]string // DT_SONAME, DT_RPATH and DT_RUNPATH
+	}{
+		{"runpath", runpath, []string{"libgreet.so.1"}, map[int64]string{DT_RUNPATH: "$ORIGIN/lib"}},
+		{"rpath", read(t, "rpath"), []string{"libgreet.so.1"}, map[int64]string{DT_RPATH: "$ORIGIN/lib"}},
+		{"libgreet.so.1", read(t, "lib/libgreet.so.1"), []string{"libfar.so.1"}, map[int64]string{DT_SONAME: "libgreet.so.1"}},
+		{"runpath without sections", stripped, []string{"libgreet.so.1"}, map[int64]string{DT_RUNPATH: "$ORIGIN/lib"}},
+		{"ELF32 LSB", dynamicImage32(binary.LittleEndian, 1), []string{"libc.so.6", "libm.so.6"},
+			map[int64]string{DT_SONAME: "libx.so.1", DT_RUNPATH: "$ORIGIN/../lib"}},
+		{"ELF32 MSB", dynamicImage32(binary.BigEndian, 2), []string{"libc.so.6", "libm.so.6"},
+			map[int64]string{DT_SONAME: "libx.so.1", DT_RUNPATH: "$ORIGIN/../lib"}},
+	}
+
+	for _, tt := range tests {
+		e := open(t, tt.data)
+		if len(e.Dynamic) == 0 || e.Dynamic[len(e.Dynamic)-1].Tag != DT_NULL {
+			t.Errorf("%s: dynamic entries %+v do not end at DT_NULL", tt.name, e.Dynamic)
+			continue
+		}
+		if got := e.Needed(); !slices.Equal(got, tt.needed) {
+			t.Errorf("%s: Needed() = %q, want %q", tt.name, got, tt.needed)
+		}
+		for _, tag := range []int64{DT_SONAME, DT_RPATH, DT_RUNPATH} {
+			want, wantOK := tt.strings[tag]
+			if got, ok := e.DynamicString(tag); got != want || ok != wantOK {
+				t.Errorf("%s: DynamicString(%s) = %q, %v, want %q, %v", tt.name, GetDynamicTag(tag), got, ok, want, wantOK)
+			}
+		}
+	}
+
+	e := open(t, dynamicImage32(binary.BigEndian, 2))
+	if value, ok := e.DynamicValue(DT_VERNEEDNUM); value != 2 || !ok {
+		t.Errorf("DynamicValue(DT_VERNEEDNUM) = %d, %v, want 2, true", value, ok)
+	}
+	if offset, ok := e.DynamicOffset(); offset != 116 || !ok {
+		t.Errorf("DynamicOffset() = %d, %v, want 116, true", offset, ok)
+	}
+
+	// The program interpreter is read from PT_INTERP
+	interp, err := open(t, runpath).Interpreter(bytes.NewReader(runpath))
+	if err != nil || interp != "/lib64/ld-linux-x86-64.so.2" {
+		t.Errorf("Interpreter() = %q, %v", interp, err)
+	}
+
+	// An object file has no dynamic section
+	if e := open(t, testImage(t)); len(e.Dynamic) != 0 || len(e.Needed()) != 0 {
+		t.Errorf("Object file has dynamic entries %+v", e.Dynamic)
+	}
+}
+
+// TestGetDynamicTag tests tag names, including the OS-specific range
+func TestGetDynamicTag(t *testing.T) {
+	tests := []struct {
+		tag  int64
+		want string
+	}{
+		{DT_NEEDED, "NEEDED"},
+		{DT_RUNPATH, "RUNPATH"},
+		{DT_GNU_HASH, "GNU_HASH"},
+		{DT_FLAGS_1, "FLAGS_1"},
+		{0x6ffffe00, "0x6ffffe00"},
+	}
+	for _, tt := range tests {
+		if got := GetDynamicTag(tt.tag); got != tt.want {
+			t.Errorf("GetDynamicTag(0x%x) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package elf
 
 import (
+	"bufio"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -19,7 +20,12 @@ type ELF struct {
 	Sections    []Section
 	Segments    []Segment
 	Symbols     []Symbol
-	StringTable []byte
+	StringTable StringTable // Section header string table (.shstrtab)
+
+	DynamicSymbols []Symbol       // .dynsym
+	Dynamic        []DynamicEntry // .dynamic, up to and including DT_NULL
+
+	size int64 // Size of the file given to ParseELF, for Validate
 }
 
 // ELFHeader represents ELF file header
@@ -48,17 +54,21 @@ type ELFHeader struct {
 
 // Section represents an ELF section
 type Section struct {
-	Name      string
-	Type      uint32
-	Flags     uint64
-	Addr      uint64
-	Offset    uint64
-	Size      uint64
-	Link      uint32
-	Info      uint32
-	AddrAlign uint64
-	EntSize   uint64
-	Data      []byte
+	Name       string
+	NameOffset uint32 // sh_name: offset of the name in the section header string table
+	Type       uint32
+	Flags      uint64
+	Addr       uint64
+	Offset     uint64
+	Size       uint64
+	Link       uint32
+	Info       uint32
+	AddrAlign  uint64
+	EntSize    uint64
+	Data       []byte // Loaded contents; nil until loaded, see Open and ReadAll
+
+	reader io.ReadSeeker // File the section was parsed from
+	added  bool          // Made by AddSection, so Offset is not yet its place in any file
 }
 
 // Segment represents an ELF program segment
@@ -85,83 +95,118 @@ type Symbol struct {
 	Binding string
 }
 
-// ParseELF parses an ELF file
+// ParseELF parses an ELF file. Malformed input is reported with
+// ErrBadMagic, ErrTruncated or *ErrBadOffset, which callers can tell apart
+// from I/O failures with errors.Is and errors.As.
 func ParseELF(r io.ReadSeeker) (*ELF, error) {
 	elf := &ELF{}
 
-	// Read ELF header
-	var header ELFHeader
-	if err := binary.Read(r, binary.LittleEndian, &header.Magic); err != nil {
-		return nil, fmt.Errorf("failed to read magic: %w", err)
-	}
-
-	// Validate ELF magic
-	if header.Magic[0] != 0x7f || header.Magic[1] != 'E' || header.Magic[2] != 'L' || header.Magic[3] != 'F' {
-		return nil, fmt.Errorf("invalid ELF magic")
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
 	}
+	elf.size = size
 
-	// Read rest of header
-	if _, err := r.Seek(4, io.SeekStart); err != nil {
+	// Read the identification bytes plus the largest (64-bit) header
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
 		return nil, fmt.Errorf("failed to seek: %w", err)
 	}
 
-	if err := binary.Read(r, binary.LittleEndian, &header.Class); err != nil {
-		return nil, fmt.Errorf("failed to read class: %w", err)
+	headerBytes := make([]byte, 64)
+	n, err := io.ReadFull(r, headerBytes)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read magic: %w", shortRead(err))
 	}
-
-	// Determine endianness
-	var endian binary.ByteOrder = binary.LittleEndian
-	if header.Data == 2 {
-		endian = binary.BigEndian
+	if n < 4 {
+		return nil, fmt.Errorf("failed to read magic: %w", ErrTruncated)
 	}
 
-	// Read remaining header fields
-	if _, err := r.Seek(4, io.SeekStart); err != nil {
-		return nil, fmt.Errorf("failed to seek: %w", err)
+	var header ELFHeader
+	copy(header.Magic[:], headerBytes[0:4])
+
+	// Validate ELF magic
+	if header.Magic[0] != 0x7f || header.Magic[1] != 'E' || header.Magic[2] != 'L' || header.Magic[3] != 'F' {
+		return nil, ErrBadMagic
+	}
+	if n < 16 {
+		return nil, fmt.Errorf("failed to read identification: %w", ErrTruncated)
 	}
 
-	headerBytes := make([]byte, 64)
-	if _, err := r.Read(headerBytes); err != nil && err != io.EOF {
-		return nil, fmt.Errorf("failed to read header: %w", err)
+	header.Class = headerBytes[4]
+	header.Data = headerBytes[5]
+	header.Version = headerBytes[6]
+	header.OSABI = headerBytes[7]
+	header.ABIVersion = headerBytes[8]
+	copy(header.Padding[:], headerBytes[9:16])
+
+	// Parse data encoding
+	var endian binary.ByteOrder
+	if header.Data == 1 {
+		elf.Data = "Little Endian"
+		endian = binary.LittleEndian
+	} else if header.Data == 2 {
+		elf.Data = "Big Endian"
+		endian = binary.BigEndian
+	} else {
+		return nil, fmt.Errorf("invalid data encoding: %d", header.Data)
 	}
 
 	// Parse class
 	if header.Class == 1 {
 		elf.Class = "ELF32"
 		// Parse 32-bit header
-		if len(headerBytes) >= 52 {
-			elf.Type = GetELFType(endian.Uint16(headerBytes[16:18]))
-			elf.Machine = GetMachine(endian.Uint16(headerBytes[18:20]))
-			elf.Version = endian.Uint32(headerBytes[20:24])
-			elf.Entry = uint64(endian.Uint32(headerBytes[24:28]))
+		if n < 52 {
+			return nil, fmt.Errorf("failed to read ELF32 header: %w", ErrTruncated)
 		}
+		header.Type = endian.Uint16(headerBytes[16:18])
+		header.Machine = endian.Uint16(headerBytes[18:20])
+		header.Version32 = endian.Uint32(headerBytes[20:24])
+		header.Entry64 = uint64(endian.Uint32(headerBytes[24:28]))
+		header.PhOff64 = uint64(endian.Uint32(headerBytes[28:32]))
+		header.ShOff64 = uint64(endian.Uint32(headerBytes[32:36]))
+		header.Flags = endian.Uint32(headerBytes[36:40])
+		header.EhSize = endian.Uint16(headerBytes[40:42])
+		header.PhentSize = endian.Uint16(headerBytes[42:44])
+		header.PhNum = endian.Uint16(headerBytes[44:46])
+		header.ShentSize = endian.Uint16(headerBytes[46:48])
+		header.ShNum = endian.Uint16(headerBytes[48:50])
+		header.ShStrndx = endian.Uint16(headerBytes[50:52])
 	} else if header.Class == 2 {
 		elf.Class = "ELF64"
 		// Parse 64-bit header
-		if len(headerBytes) >= 64 {
-			elf.Type = GetELFType(endian.Uint16(headerBytes[16:18]))
-			elf.Machine = GetMachine(endian.Uint16(headerBytes[18:20]))
-			elf.Version = endian.Uint32(headerBytes[20:24])
-			elf.Entry = endian.Uint64(headerBytes[24:32])
+		if n < 64 {
+			return nil, fmt.Errorf("failed to read ELF64 header: %w", ErrTruncated)
 		}
+		header.Type = endian.Uint16(headerBytes[16:18])
+		header.Machine = endian.Uint16(headerBytes[18:20])
+		header.Version32 = endian.Uint32(headerBytes[20:24])
+		header.Entry64 = endian.Uint64(headerBytes[24:32])
+		header.PhOff64 = endian.Uint64(headerBytes[32:40])
+		header.ShOff64 = endian.Uint64(headerBytes[40:48])
+		header.Flags = endian.Uint32(headerBytes[48:52])
+		header.EhSize = endian.Uint16(headerBytes[52:54])
+		header.PhentSize = endian.Uint16(headerBytes[54:56])
+		header.PhNum = endian.Uint16(headerBytes[56:58])
+		header.ShentSize = endian.Uint16(headerBytes[58:60])
+		header.ShNum = endian.Uint16(headerBytes[60:62])
+		header.ShStrndx = endian.Uint16(headerBytes[62:64])
 	} else {
 		return nil, fmt.Errorf("invalid ELF class: %d", header.Class)
 	}
 
-	// Parse data encoding
-	if header.Data == 1 {
-		elf.Data = "Little Endian"
-	} else if header.Data == 2 {
-		elf.Data = "Big Endian"
-	} else {
-		return nil, fmt.Errorf("invalid data encoding: %d", header.Data)
-	}
-
-	// Parse OS/ABI
-	elf.OSABI = GetOSABI(headerBytes[7])
+	elf.Type = GetELFType(header.Type)
+	elf.Machine = GetMachine(header.Machine)
+	elf.Version = header.Version32
+	elf.Entry = header.Entry64
+	elf.OSABI = GetOSABI(header.OSABI)
 
 	elf.Header = header
 
+	// Parse program headers
+	if err := parseSegments(r, elf, endian); err != nil {
+		return nil, fmt.Errorf("failed to parse segments: %w", err)
+	}
+
 	// Parse sections
 	if err := parseSections(r, elf, endian); err != nil {
 		return nil, fmt.Errorf("failed to parse sections: %w", err)
@@ -169,13 +214,92 @@ func ParseELF(r io.ReadSeeker) (*ELF, error) {
 
 	// Parse symbols
 	if err := parseSymbols(r, elf, endian); err != nil {
-		// Symbols are optional, so we don't fail
-		_ = err
+		return nil, fmt.Errorf("failed to parse symbols: %w", err)
+	}
+
+	// Parse dynamic linking information
+	if err := parseDynamic(r, elf, endian); err != nil {
+		return nil, fmt.Errorf("failed to parse dynamic section: %w", err)
 	}
 
 	return elf, nil
 }
 
+// ByteOrder returns the byte order declared in the ELF identification
+func (e *ELF) ByteOrder() binary.ByteOrder {
+	if e.Header.Data == 2 {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// parseSegments parses ELF program headers
+func parseSegments(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
+	if elf.Header.PhOff64 == 0 || elf.Header.PhNum == 0 {
+		return nil // No program headers
+	}
+
+	// Secure: validate segment count
+	if elf.Header.PhNum > 10000 {
+		return fmt.Errorf("invalid segment count: %d", elf.Header.PhNum)
+	}
+
+	// Secure: the table must lie within the file
+	entrySize := uint64(56)
+	if elf.Class == "ELF32" {
+		entrySize = 32
+	}
+	if beyond(elf.Header.PhOff64, uint64(elf.Header.PhNum)*entrySize, elf.size) {
+		return &ErrBadOffset{Section: "program headers", Offset: elf.Header.PhOff64}
+	}
+
+	if _, err := r.Seek(int64(elf.Header.PhOff64), io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to program headers: %w", err)
+	}
+
+	elf.Segments = make([]Segment, elf.Header.PhNum)
+
+	for i := range elf.Segments {
+		var seg Segment
+
+		if elf.Class == "ELF32" {
+			// 32-bit program header (32 bytes)
+			phBytes := make([]byte, 32)
+			if _, err := io.ReadFull(r, phBytes); err != nil {
+				return fmt.Errorf("failed to read program header: %w", shortRead(err))
+			}
+
+			seg.Type = endian.Uint32(phBytes[0:4])
+			seg.Offset = uint64(endian.Uint32(phBytes[4:8]))
+			seg.VAddr = uint64(endian.Uint32(phBytes[8:12]))
+			seg.PAddr = uint64(endian.Uint32(phBytes[12:16]))
+			seg.FileSz = uint64(endian.Uint32(phBytes[16:20]))
+			seg.MemSz = uint64(endian.Uint32(phBytes[20:24]))
+			seg.Flags = endian.Uint32(phBytes[24:28])
+			seg.Align = uint64(endian.Uint32(phBytes[28:32]))
+		} else {
+			// 64-bit program header (56 bytes)
+			phBytes := make([]byte, 56)
+			if _, err := io.ReadFull(r, phBytes); err != nil {
+				return fmt.Errorf("failed to read program header: %w", shortRead(err))
+			}
+
+			seg.Type = endian.Uint32(phBytes[0:4])
+			seg.Flags = endian.Uint32(phBytes[4:8])
+			seg.Offset = endian.Uint64(phBytes[8:16])
+			seg.VAddr = endian.Uint64(phBytes[16:24])
+			seg.PAddr = endian.Uint64(phBytes[24:32])
+			seg.FileSz = endian.Uint64(phBytes[32:40])
+			seg.MemSz = endian.Uint64(phBytes[40:48])
+			seg.Align = endian.Uint64(phBytes[48:56])
+		}
+
+		elf.Segments[i] = seg
+	}
+
+	return nil
+}
+
 // parseSections parses ELF sections
 func parseSections(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
 	// Secure: validate section header offset
@@ -183,15 +307,24 @@ func parseSections(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
 		return nil // No sections
 	}
 
-	if _, err := r.Seek(int64(elf.Header.ShOff64), io.SeekStart); err != nil {
-		return fmt.Errorf("failed to seek to sections: %w", err)
-	}
-
 	// Secure: validate section count
 	if elf.Header.ShNum == 0 || elf.Header.ShNum > 10000 {
 		return fmt.Errorf("invalid section count: %d", elf.Header.ShNum)
 	}
 
+	// Secure: the table must lie within the file
+	entrySize := uint64(64)
+	if elf.Class == "ELF32" {
+		entrySize = 40
+	}
+	if beyond(elf.Header.ShOff64, uint64(elf.Header.ShNum)*entrySize, elf.size) {
+		return &ErrBadOffset{Section: "section headers", Offset: elf.Header.ShOff64}
+	}
+
+	if _, err := r.Seek(int64(elf.Header.ShOff64), io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to sections: %w", err)
+	}
+
 	elf.Sections = make([]Section, elf.Header.ShNum)
 
 	// Read section headers
@@ -201,11 +334,11 @@ func parseSections(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
 		if elf.Class == "ELF32" {
 			// 32-bit section header (40 bytes)
 			shBytes := make([]byte, 40)
-			if _, err := r.Read(shBytes); err != nil {
-				return fmt.Errorf("failed to read section header: %w", err)
+			if _, err := io.ReadFull(r, shBytes); err != nil {
+				return fmt.Errorf("failed to read section header: %w", shortRead(err))
 			}
 
-			section.Name = fmt.Sprintf("section_%d", i)
+			section.NameOffset = endian.Uint32(shBytes[0:4])
 			section.Type = endian.Uint32(shBytes[4:8])
 			section.Flags = uint64(endian.Uint32(shBytes[8:12]))
 			section.Addr = uint64(endian.Uint32(shBytes[12:16]))
@@ -218,11 +351,11 @@ func parseSections(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
 		} else {
 			// 64-bit section header (64 bytes)
 			shBytes := make([]byte, 64)
-			if _, err := r.Read(shBytes); err != nil {
-				return fmt.Errorf("failed to read section header: %w", err)
+			if _, err := io.ReadFull(r, shBytes); err != nil {
+				return fmt.Errorf("failed to read section header: %w", shortRead(err))
 			}
 
-			section.Name = fmt.Sprintf("section_%d", i)
+			section.NameOffset = endian.Uint32(shBytes[0:4])
 			section.Type = endian.Uint32(shBytes[4:8])
 			section.Flags = endian.Uint64(shBytes[8:16])
 			section.Addr = endian.Uint64(shBytes[16:24])
@@ -234,47 +367,44 @@ func parseSections(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
 			section.EntSize = endian.Uint64(shBytes[56:64])
 		}
 
+		section.reader = r
 		elf.Sections[i] = section
 	}
 
 	// Read string table for section names
-	if elf.Header.ShStrndx < elf.Header.ShNum {
-		strSection := elf.Sections[elf.Header.ShStrndx]
+	if shstrndx := elf.shstrndx(); shstrndx > 0 && shstrndx < len(elf.Sections) {
+		strSection := &elf.Sections[shstrndx]
 		if strSection.Offset > 0 && strSection.Size > 0 {
 			// Secure: validate string table size
-			if strSection.Size > 100*1024*1024 { // 100MB limit
-				return fmt.Errorf("string table too large: %d", strSection.Size)
-			}
-
-			if _, err := r.Seek(int64(strSection.Offset), io.SeekStart); err != nil {
-				return fmt.Errorf("failed to seek to string table: %w", err)
-			}
-
-			elf.StringTable = make([]byte, strSection.Size)
-			if _, err := r.Read(elf.StringTable); err != nil && err != io.EOF {
+			table, err := strSection.ReadAll(100 * 1024 * 1024) // 100MB limit
+			if err != nil {
 				return fmt.Errorf("failed to read string table: %w", err)
 			}
-
-			// Update section names
-			for i := range elf.Sections {
-				nameOffset := endian.Uint32([]byte{
-					elf.Sections[i].Name[0],
-					elf.Sections[i].Name[1],
-					elf.Sections[i].Name[2],
-					elf.Sections[i].Name[3],
-				})
-				if nameOffset < uint32(len(elf.StringTable)) {
-					elf.Sections[i].Name = ReadCString(elf.StringTable[nameOffset:])
-				}
-			}
+			elf.StringTable = table
 		}
 	}
 
+	for i := range elf.Sections {
+		elf.Sections[i].Name = elf.SectionName(i)
+	}
+
 	return nil
 }
 
-// parseSymbols parses ELF symbols
+// parseSymbols parses the static (.symtab) and dynamic (.dynsym) symbol tables
 func parseSymbols(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
+	for i := range elf.Sections {
+		if elf.Sections[i].Type != SHT_DYNSYM {
+			continue
+		}
+		symbols, err := readSymbols(elf, endian, &elf.Sections[i])
+		if err != nil {
+			return err
+		}
+		elf.DynamicSymbols = symbols
+		break
+	}
+
 	// Find .symtab section
 	var symtabSection *Section
 	for i := range elf.Sections {
@@ -288,17 +418,23 @@ func parseSymbols(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
 		return nil // No symbol table
 	}
 
-	// Secure: validate symbol table size
-	if symtabSection.Size == 0 || symtabSection.EntSize == 0 {
-		return nil
+	symbols, err := readSymbols(elf, endian, symtabSection)
+	if err != nil {
+		return err
 	}
+	elf.Symbols = symbols
+	return nil
+}
 
-	if symtabSection.Size > 100*1024*1024 { // 100MB limit
-		return fmt.Errorf("symbol table too large: %d", symtabSection.Size)
+// readSymbols reads the symbols of a symbol table section
+func readSymbols(elf *ELF, endian binary.ByteOrder, symtabSection *Section) ([]Symbol, error) {
+	// Secure: validate symbol table size
+	if symtabSection.Type == SHT_NOBITS || symtabSection.Size == 0 || symtabSection.EntSize == 0 {
+		return nil, nil
 	}
 
-	if _, err := r.Seek(int64(symtabSection.Offset), io.SeekStart); err != nil {
-		return fmt.Errorf("failed to seek to symbols: %w", err)
+	if symtabSection.Size > 100*1024*1024 { // 100MB limit
+		return nil, fmt.Errorf("symbol table too large: %d", symtabSection.Size)
 	}
 
 	numSymbols := symtabSection.Size / symtabSection.EntSize
@@ -307,34 +443,27 @@ func parseSymbols(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
 		numSymbols = 100000
 	}
 
-	elf.Symbols = make([]Symbol, numSymbols)
+	symbols := make([]Symbol, numSymbols)
 
-	// Find string table for symbol names
+	// Find string table for symbol names; sh_link of the table holds its index
 	var strtabSection *Section
-	for i := range elf.Sections {
-		if elf.Sections[i].Type == 3 && elf.Sections[i].Link == uint32(symtabSection.Info) { // SHT_STRTAB
-			strtabSection = &elf.Sections[i]
-			break
-		}
+	if int(symtabSection.Link) < len(elf.Sections) && elf.Sections[symtabSection.Link].Type == 3 { // SHT_STRTAB
+		strtabSection = &elf.Sections[symtabSection.Link]
 	}
 
-	var strtab []byte
+	var strtab StringTable
 	if strtabSection != nil && strtabSection.Size > 0 {
 		// Secure: validate string table size
-		if strtabSection.Size > 100*1024*1024 {
-			return fmt.Errorf("string table too large: %d", strtabSection.Size)
-		}
-
-		if _, err := r.Seek(int64(strtabSection.Offset), io.SeekStart); err != nil {
-			return fmt.Errorf("failed to seek to string table: %w", err)
-		}
-
-		strtab = make([]byte, strtabSection.Size)
-		if _, err := r.Read(strtab); err != nil && err != io.EOF {
-			return fmt.Errorf("failed to read string table: %w", err)
+		table, err := strtabSection.ReadAll(100 * 1024 * 1024)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read string table: %w", err)
 		}
+		strtab = table
 	}
 
+	// Symbols are streamed rather than loaded as a whole
+	r := bufio.NewReader(symtabSection.Open())
+
 	// Read symbols
 	for i := uint64(0); i < numSymbols; i++ {
 		var symbol Symbol
@@ -342,14 +471,11 @@ func parseSymbols(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
 		if elf.Class == "ELF32" {
 			// 32-bit symbol (16 bytes)
 			symBytes := make([]byte, 16)
-			if _, err := r.Read(symBytes); err != nil {
-				break
+			if _, err := io.ReadFull(r, symBytes); err != nil {
+				return nil, symbolReadError(symtabSection, err)
 			}
 
-			nameIdx := endian.Uint32(symBytes[0:4])
-			if nameIdx < uint32(len(strtab)) {
-				symbol.Name = ReadCString(strtab[nameIdx:])
-			}
+			symbol.Name, _ = strtab.Lookup(endian.Uint32(symBytes[0:4]))
 			symbol.Value = uint64(endian.Uint32(symBytes[4:8]))
 			symbol.Size = uint64(endian.Uint32(symBytes[8:12]))
 			symbol.Info = symBytes[12]
@@ -358,14 +484,11 @@ func parseSymbols(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
 		} else {
 			// 64-bit symbol (24 bytes)
 			symBytes := make([]byte, 24)
-			if _, err := r.Read(symBytes); err != nil {
-				break
+			if _, err := io.ReadFull(r, symBytes); err != nil {
+				return nil, symbolReadError(symtabSection, err)
 			}
 
-			nameIdx := endian.Uint32(symBytes[0:4])
-			if nameIdx < uint32(len(strtab)) {
-				symbol.Name = ReadCString(strtab[nameIdx:])
-			}
+			symbol.Name, _ = strtab.Lookup(endian.Uint32(symBytes[0:4]))
 			symbol.Info = symBytes[4]
 			symbol.Other = symBytes[5]
 			symbol.Shndx = endian.Uint16(symBytes[6:8])
@@ -376,10 +499,19 @@ func parseSymbols(r io.ReadSeeker, elf *ELF, endian binary.ByteOrder) error {
 		symbol.Type = GetSymbolType(symbol.Info & 0x0f)
 		symbol.Binding = GetSymbolBinding((symbol.Info >> 4) & 0x0f)
 
-		elf.Symbols[i] = symbol
+		symbols[i] = symbol
 	}
 
-	return nil
+	return symbols, nil
+}
+
+// symbolReadError reports a failed read of a symbol table; running out of
+// data means the table extends past the end of the file
+func symbolReadError(symtabSection *Section, err error) error {
+	if shortRead(err) == ErrTruncated {
+		return &ErrBadOffset{Section: symtabSection.Name, Offset: symtabSection.Offset}
+	}
+	return fmt.Errorf("failed to read symbol table %s: %w", symtabSection.Name, err)
 }
 
 // ReadCString reads a null-terminated string
@@ -0,0 +1,136 @@
+package elf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ErrBadMagic is returned when the input does not start with the ELF
+// identification bytes
+var ErrBadMagic = errors.New("invalid ELF magic")
+
+// ErrTruncated is returned when the file ends inside one of its headers
+var ErrTruncated = errors.New("file truncated")
+
+// ErrBadOffset reports a section, header table or segment whose contents
+// lie outside the file
+type ErrBadOffset struct {
+	Section string
+	Offset  uint64
+}
+
+// Error describes the bad offset
+func (e *ErrBadOffset) Error() string {
+	return fmt.Sprintf("%s: offset 0x%x lies outside the file", e.Section, e.Offset)
+}
+
+// shortRead turns the end-of-file errors of io.ReadFull into ErrTruncated,
+// leaving genuine I/O failures as they are
+func shortRead(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTruncated
+	}
+	return err
+}
+
+// beyond reports whether size bytes at offset run past the end of a file
+// of fileSize bytes
+func beyond(offset, size uint64, fileSize int64) bool {
+	return offset > uint64(fileSize) || size > uint64(fileSize)-offset
+}
+
+// Validate cross-checks the headers of a parsed file: section and segment
+// contents against the file size, overlapping sections, and the sh_link and
+// sh_info fields. It returns every problem found, joined with errors.Join.
+// File bounds are only checked for files read by ParseELF.
+func Validate(e *ELF) error {
+	var errs []error
+	size := e.size
+
+	if size > 0 {
+		if e.Header.ShNum > 0 && beyond(e.Header.ShOff64, uint64(e.Header.ShNum)*uint64(e.Header.ShentSize), size) {
+			errs = append(errs, &ErrBadOffset{Section: "section headers", Offset: e.Header.ShOff64})
+		}
+		if e.Header.PhNum > 0 && beyond(e.Header.PhOff64, uint64(e.Header.PhNum)*uint64(e.Header.PhentSize), size) {
+			errs = append(errs, &ErrBadOffset{Section: "program headers", Offset: e.Header.PhOff64})
+		}
+		for i, seg := range e.Segments {
+			if seg.FileSz > 0 && beyond(seg.Offset, seg.FileSz, size) {
+				errs = append(errs, &ErrBadOffset{Section: fmt.Sprintf("segment %d", i), Offset: seg.Offset})
+			}
+		}
+	}
+
+	if len(e.Sections) > 0 && e.shstrndx() >= len(e.Sections) {
+		errs = append(errs, fmt.Errorf("section header string table index %d out of range", e.shstrndx()))
+	}
+
+	// Sections that occupy file space, for the bounds and overlap checks
+	var placed []*Section
+	for i := 1; i < len(e.Sections); i++ {
+		section := &e.Sections[i]
+		if section.Type == SHT_NULL || section.Type == SHT_NOBITS || section.Size == 0 {
+			continue
+		}
+		if size > 0 && beyond(section.Offset, section.Size, size) {
+			errs = append(errs, &ErrBadOffset{Section: section.Name, Offset: section.Offset})
+			continue
+		}
+		placed = append(placed, section)
+	}
+	sort.SliceStable(placed, func(i, j int) bool { return placed[i].Offset < placed[j].Offset })
+	for i := 1; i < len(placed); i++ {
+		prev, cur := placed[i-1], placed[i]
+		if cur.Offset < prev.Offset+prev.Size {
+			errs = append(errs, fmt.Errorf("section %s overlaps section %s", cur.Name, prev.Name))
+		}
+	}
+
+	for i := 1; i < len(e.Sections); i++ {
+		if err := validateLinks(e, &e.Sections[i]); err != nil {
+			errs = append(errs, fmt.Errorf("section %s: %w", e.Sections[i].Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateLinks checks that the sh_link and sh_info fields of a section
+// refer to sections of the right type
+func validateLinks(e *ELF, section *Section) error {
+	count := uint32(len(e.Sections))
+	if section.Link >= count {
+		return fmt.Errorf("sh_link %d out of range", section.Link)
+	}
+	linked := e.Sections[section.Link].Type
+
+	switch section.Type {
+	case SHT_SYMTAB, SHT_DYNSYM:
+		if linked != SHT_STRTAB {
+			return fmt.Errorf("sh_link %d is not a string table", section.Link)
+		}
+		// sh_info is one past the last local symbol
+		if section.EntSize > 0 && uint64(section.Info) > section.Size/section.EntSize {
+			return fmt.Errorf("sh_info %d exceeds the %d symbols", section.Info, section.Size/section.EntSize)
+		}
+	case SHT_DYNAMIC:
+		if linked != SHT_STRTAB {
+			return fmt.Errorf("sh_link %d is not a string table", section.Link)
+		}
+	case SHT_HASH, SHT_GNU_HASH:
+		if linked != SHT_DYNSYM && linked != SHT_SYMTAB {
+			return fmt.Errorf("sh_link %d is not a symbol table", section.Link)
+		}
+	case SHT_REL, SHT_RELA:
+		// Dynamic relocations of static executables have no symbol table
+		if section.Link != 0 && linked != SHT_DYNSYM && linked != SHT_SYMTAB {
+			return fmt.Errorf("sh_link %d is not a symbol table", section.Link)
+		}
+		if section.Flags&SHF_INFO_LINK != 0 && section.Info >= count {
+			return fmt.Errorf("sh_info %d out of range", section.Info)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package elf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// testImage builds a small relocatable ELF64 file with a symbol table
+func testImage(t testing.TB) []byte {
+	symbols := make([]byte, 48)
+	symbols[24] = 1      // st_name: "main"
+	symbols[24+4] = 0x12 // STB_GLOBAL, STT_FUNC
+	symbols[24+6] = 1    // st_shndx: .text
+	e := &ELF{
+		Class:  "ELF64",
+		Header: ELFHeader{Magic: [4]byte{0x7f, 'E', 'L', 'F'}, Class: 2, Data: 1, Version: 1, Type: 1, Machine: 62, Version32: 1},
+		Sections: []Section{
+			{},
+			{Name: ".text", Type: SHT_PROGBITS, Flags: SHF_ALLOC | SHF_EXECINSTR, AddrAlign: 16, Size: 4, Data: []byte{0x31, 0xc0, 0xc3, 0x90}},
+			{Name: ".strtab", Type: SHT_STRTAB, AddrAlign: 1, Size: 6, Data: []byte("\x00main\x00")},
+			{Name: ".symtab", Type: SHT_SYMTAB, AddrAlign: 8, EntSize: 24, Link: 2, Info: 1, Size: 48, Data: symbols},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteELF(&buf, e); err != nil {
+		t.Fatalf("WriteELF failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// failingReader fails every read, as a broken disk would
+type failingReader struct{ io.ReadSeeker }
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("input/output error") }
+
+// TestParseErrors tests that malformed input is reported with the typed errors
+func TestParseErrors(t *testing.T) {
+	image := testImage(t)
+	e, err := ParseELF(bytes.NewReader(image))
+	if err != nil {
+		t.Fatalf("ParseELF failed: %v", err)
+	}
+	if len(e.Symbols) != 2 || e.Symbols[1].Name != "main" {
+		t.Fatalf("Symbols = %v, want main", e.Symbols)
+	}
+
+	if _, err := ParseELF(bytes.NewReader(nil)); !errors.Is(err, ErrTruncated) {
+		t.Errorf("Empty file: got %v, want ErrTruncated", err)
+	}
+	if _, err := ParseELF(bytes.NewReader([]byte("MZ\x90\x00\x03\x00\x00\x00"))); !errors.Is(err, ErrBadMagic) {
+		t.Errorf("PE file: got %v, want ErrBadMagic", err)
+	}
+	if _, err := ParseELF(bytes.NewReader(image[:40])); !errors.Is(err, ErrTruncated) {
+		t.Errorf("Short header: got %v, want ErrTruncated", err)
+	}
+
+	// Cutting the file loses the section header table at its end
+	var bad *ErrBadOffset
+	_, err = ParseELF(bytes.NewReader(image[:len(image)-1]))
+	if !errors.As(err, &bad) || bad.Section != "section headers" || bad.Offset != e.Header.ShOff64 {
+		t.Errorf("Truncated section headers: got %v, want ErrBadOffset", err)
+	}
+
+	// A symbol table running past the end of the file
+	broken := bytes.Clone(image)
+	symtab := e.Header.ShOff64 + 3*64
+	broken[symtab+32] = 0xff // sh_size
+	broken[symtab+33] = 0xff
+	_, err = ParseELF(bytes.NewReader(broken))
+	if !errors.As(err, &bad) || bad.Section != ".symtab" {
+		t.Errorf("Oversized symbol table: got %v, want ErrBadOffset for .symtab", err)
+	}
+
+	_, err = ParseELF(failingReader{bytes.NewReader(image)})
+	if err == nil || errors.Is(err, ErrTruncated) || errors.Is(err, ErrBadMagic) {
+		t.Errorf("I/O failure: got %v, want a plain error", err)
+	}
+}
+
+// TestValidate tests the cross-checks of section headers
+func TestValidate(t *testing.T) {
+	image := testImage(t)
+	parse := func() *ELF {
+		e, err := ParseELF(bytes.NewReader(image))
+		if err != nil {
+			t.Fatalf("ParseELF failed: %v", err)
+		}
+		return e
+	}
+
+	if err := Validate(parse()); err != nil {
+		t.Fatalf("Validate of a well-formed file: %v", err)
+	}
+
+	e := parse()
+	e.Sections[1].Size = uint64(len(image))
+	var bad *ErrBadOffset
+	if err := Validate(e); !errors.As(err, &bad) || bad.Section != ".text" {
+		t.Errorf("Section past the end of the file: got %v, want ErrBadOffset for .text", err)
+	}
+
+	e = parse()
+	e.Sections[2].Offset = e.Sections[1].Offset + 1
+	if err := Validate(e); err == nil {
+		t.Error("Overlapping sections not reported")
+	}
+
+	e = parse()
+	e.Sections[3].Link = 10
+	e.Sections[3].Info = 3
+	err := Validate(e)
+	if err == nil {
+		t.Fatal("Bad sh_link not reported")
+	}
+	if errors.As(err, &bad) {
+		t.Errorf("Bad sh_link reported as ErrBadOffset: %v", err)
+	}
+
+	e = parse()
+	e.Sections[3].Link = 1
+	if err := Validate(e); err == nil {
+		t.Error("Symbol table linked to .text not reported")
+	}
+}
+
+// FuzzParseELF checks that no input makes ParseELF or Validate panic
+func FuzzParseELF(f *testing.F) {
+	image := testImage(f)
+	f.Add(image)
+	f.Add(image[:64])
+	f.Fuzz(func(t *testing.T, data []byte) {
+		e, err := ParseELF(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		Validate(e)
+		e.HashTables()
+		for i := range e.Sections {
+			e.Relocations(&e.Sections[i])
+		}
+	})
+}
@@ -0,0 +1,248 @@
+package elf
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// HashTable is a dynamic symbol hash table read from an SHT_HASH or
+// SHT_GNU_HASH section. The dynamic loader finds a symbol by hashing its
+// name, picking a bucket and walking that bucket's chain.
+type HashTable struct {
+	Section string
+	GNU     bool
+
+	// Buckets holds, for each bucket, the index of the first symbol of its
+	// chain; 0 marks an empty bucket
+	Buckets []uint32
+
+	// Chains holds, for SysV tables, the index of the next symbol for each
+	// symbol. For GNU tables it holds the hash of each symbol from SymOffset
+	// on, with the low bit set on the last symbol of a chain.
+	Chains []uint32
+
+	SymOffset  uint32   // GNU: index of the first symbol in the table
+	BloomShift uint32   // GNU: shift giving the second Bloom filter bit
+	Bloom      []uint64 // GNU: Bloom filter words of BloomBits bits
+	BloomBits  uint32
+}
+
+// HashTables reads the SHT_HASH and SHT_GNU_HASH sections of the file
+func (e *ELF) HashTables() ([]*HashTable, error) {
+	tables := []*HashTable{}
+	for i := range e.Sections {
+		section := &e.Sections[i]
+		if section.Type != SHT_HASH && section.Type != SHT_GNU_HASH {
+			continue
+		}
+
+		// Secure: validate section size
+		data, err := section.ReadAll(100 * 1024 * 1024)
+		if err != nil {
+			return nil, err
+		}
+
+		var table *HashTable
+		if section.Type == SHT_HASH {
+			table, err = ParseHashTable(data, e.ByteOrder())
+		} else {
+			table, err = ParseGNUHashTable(data, e.ByteOrder(), e.Class)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", section.Name, err)
+		}
+		table.Section = section.Name
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// ParseHashTable parses the contents of a SysV SHT_HASH section: nbucket,
+// nchain, the buckets and the chains, all 32-bit words
+func ParseHashTable(data []byte, endian binary.ByteOrder) (*HashTable, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("hash table too short")
+	}
+	nbucket := uint64(endian.Uint32(data[0:4]))
+	nchain := uint64(endian.Uint32(data[4:8]))
+
+	// Secure: both arrays must fit in the section
+	if (2+nbucket+nchain)*4 > uint64(len(data)) {
+		return nil, fmt.Errorf("hash table truncated: %d buckets and %d chains in %d bytes", nbucket, nchain, len(data))
+	}
+
+	table := &HashTable{
+		Buckets: make([]uint32, nbucket),
+		Chains:  make([]uint32, nchain),
+	}
+	words := data[8:]
+	for i := range table.Buckets {
+		table.Buckets[i] = endian.Uint32(words[4*i:])
+	}
+	words = words[4*nbucket:]
+	for i := range table.Chains {
+		table.Chains[i] = endian.Uint32(words[4*i:])
+	}
+	return table, nil
+}
+
+// ParseGNUHashTable parses the contents of an SHT_GNU_HASH section: a
+// header of nbuckets, symoffset, bloom_size and bloom_shift, the Bloom
+// filter of class-sized words, the buckets and the hash values, which run
+// to the end of the section
+func ParseGNUHashTable(data []byte, endian binary.ByteOrder, class string) (*HashTable, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("GNU hash table too short")
+	}
+	nbuckets := uint64(endian.Uint32(data[0:4]))
+	bloomSize := uint64(endian.Uint32(data[8:12]))
+	table := &HashTable{
+		GNU:        true,
+		SymOffset:  endian.Uint32(data[4:8]),
+		BloomShift: endian.Uint32(data[12:16]),
+		BloomBits:  64,
+	}
+	if class == "ELF32" {
+		table.BloomBits = 32
+	}
+	wordSize := uint64(table.BloomBits / 8)
+
+	// Secure: the filter and the buckets must fit in the section
+	if 16+bloomSize*wordSize+nbuckets*4 > uint64(len(data)) {
+		return nil, fmt.Errorf("GNU hash table truncated: %d buckets and %d Bloom words in %d bytes", nbuckets, bloomSize, len(data))
+	}
+
+	words := data[16:]
+	table.Bloom = make([]uint64, bloomSize)
+	for i := range table.Bloom {
+		if wordSize == 8 {
+			table.Bloom[i] = endian.Uint64(words[8*i:])
+		} else {
+			table.Bloom[i] = uint64(endian.Uint32(words[4*i:]))
+		}
+	}
+	words = words[bloomSize*wordSize:]
+	table.Buckets = make([]uint32, nbuckets)
+	for i := range table.Buckets {
+		table.Buckets[i] = endian.Uint32(words[4*i:])
+	}
+	words = words[nbuckets*4:]
+	table.Chains = make([]uint32, len(words)/4)
+	for i := range table.Chains {
+		table.Chains[i] = endian.Uint32(words[4*i:])
+	}
+	return table, nil
+}
+
+// ChainLengths returns the number of symbols in each bucket. It fails if a
+// chain leaves the table or, for SysV tables, loops.
+func (h *HashTable) ChainLengths() ([]int, error) {
+	lengths := make([]int, len(h.Buckets))
+	for b, first := range h.Buckets {
+		if first == 0 {
+			continue
+		}
+		length := 0
+		err := h.walk(first, func(uint32) bool {
+			length++
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("bucket %d: %w", b, err)
+		}
+		lengths[b] = length
+	}
+	return lengths, nil
+}
+
+// Reachable reports whether a lookup of name finds the symbol at index,
+// following the same steps as the dynamic loader
+func (h *HashTable) Reachable(index uint32, name string) bool {
+	if len(h.Buckets) == 0 {
+		return false
+	}
+
+	if !h.GNU {
+		found := false
+		h.walk(h.Buckets[ELFHash(name)%uint32(len(h.Buckets))], func(i uint32) bool {
+			found = i == index
+			return !found
+		})
+		return found
+	}
+
+	// The Bloom filter must have both bits of the hash set
+	hash := GNUHash(name)
+	if len(h.Bloom) > 0 {
+		word := h.Bloom[(hash/h.BloomBits)%uint32(len(h.Bloom))]
+		mask := uint64(1)<<(hash%h.BloomBits) | uint64(1)<<((hash>>h.BloomShift)%h.BloomBits)
+		if word&mask != mask {
+			return false
+		}
+	}
+	found := false
+	h.walk(h.Buckets[hash%uint32(len(h.Buckets))], func(i uint32) bool {
+		found = i == index && h.Chains[i-h.SymOffset]|1 == hash|1
+		return !found
+	})
+	return found
+}
+
+// walk calls fn with each symbol index of the chain starting at first until
+// fn returns false or the chain ends
+func (h *HashTable) walk(first uint32, fn func(index uint32) bool) error {
+	if first == 0 {
+		return nil
+	}
+
+	if h.GNU {
+		if first < h.SymOffset {
+			return fmt.Errorf("chain starts at symbol %d, below the first hashed symbol %d", first, h.SymOffset)
+		}
+		for i := first; ; i++ {
+			if uint64(i-h.SymOffset) >= uint64(len(h.Chains)) {
+				return fmt.Errorf("chain runs past the end of the table at symbol %d", i)
+			}
+			if !fn(i) || h.Chains[i-h.SymOffset]&1 != 0 {
+				return nil
+			}
+		}
+	}
+
+	// Secure: a chain longer than the table must contain a loop
+	steps := 0
+	for i := first; i != 0; i = h.Chains[i] {
+		if uint64(i) >= uint64(len(h.Chains)) {
+			return fmt.Errorf("chain refers to symbol %d beyond nchain %d", i, len(h.Chains))
+		}
+		if steps++; steps > len(h.Chains) {
+			return fmt.Errorf("chain loops at symbol %d", i)
+		}
+		if !fn(i) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ELFHash returns the SysV ELF hash of a symbol name
+func ELFHash(name string) uint32 {
+	var h uint32
+	for i := 0; i < len(name); i++ {
+		h = h<<4 + uint32(name[i])
+		if g := h & 0xf0000000; g != 0 {
+			h ^= g >> 24
+		}
+		h &= 0x0fffffff
+	}
+	return h
+}
+
+// GNUHash returns the GNU hash (Bernstein's djb2) of a symbol name
+func GNUHash(name string) uint32 {
+	h := uint32(5381)
+	for i := 0; i < len(name); i++ {
+		h = h*33 + uint32(name[i])
+	}
+	return h
+}
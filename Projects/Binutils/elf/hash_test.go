@@ -0,0 +1,123 @@
+package elf
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// words encodes 32-bit little-endian words
+func words(values ...uint32) []byte {
+	data := make([]byte, 0, 4*len(values))
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, v)
+	}
+	return data
+}
+
+// TestHashFunctions tests the SysV and GNU hash functions against known values
+func TestHashFunctions(t *testing.T) {
+	if h := ELFHash("printf"); h != 0x077905a6 {
+		t.Errorf("ELFHash(printf) = %#x, want 0x077905a6", h)
+	}
+	if h := GNUHash(""); h != 5381 {
+		t.Errorf("GNUHash(\"\") = %d, want 5381", h)
+	}
+	if h := GNUHash("printf"); h != 0x156b2bb8 {
+		t.Errorf("GNUHash(printf) = %#x, want 0x156b2bb8", h)
+	}
+}
+
+// TestSysVHashTable tests chain lengths and lookups in a SysV hash table
+func TestSysVHashTable(t *testing.T) {
+	names := []string{"", "alpha", "beta", "gamma"}
+
+	// Two buckets; chain each symbol onto its bucket
+	buckets := make([]uint32, 2)
+	chains := make([]uint32, len(names))
+	for i := 1; i < len(names); i++ {
+		b := ELFHash(names[i]) % 2
+		chains[i] = buckets[b]
+		buckets[b] = uint32(i)
+	}
+	data := words(append(append([]uint32{2, uint32(len(names))}, buckets...), chains...)...)
+
+	table, err := ParseHashTable(data, binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("ParseHashTable failed: %v", err)
+	}
+	lengths, err := table.ChainLengths()
+	if err != nil {
+		t.Fatalf("ChainLengths failed: %v", err)
+	}
+	if lengths[0]+lengths[1] != 3 {
+		t.Errorf("Chain lengths %v do not cover 3 symbols", lengths)
+	}
+	for i := 1; i < len(names); i++ {
+		if !table.Reachable(uint32(i), names[i]) {
+			t.Errorf("Symbol %s not reachable", names[i])
+		}
+	}
+
+	// A chain that points back to itself
+	table.Chains[table.Buckets[0]] = table.Buckets[0]
+	if table.Buckets[0] != 0 {
+		if _, err := table.ChainLengths(); err == nil {
+			t.Error("Expected error for a looping chain")
+		}
+	}
+
+	if _, err := ParseHashTable(words(100, 100, 0), binary.LittleEndian); err == nil {
+		t.Error("Expected error for a truncated table")
+	}
+}
+
+// TestGNUHashTable tests chain lengths, the Bloom filter and lookups in a
+// GNU hash table
+func TestGNUHashTable(t *testing.T) {
+	// Symbols 0 and 1 are not hashed; 2 and 3 share the only bucket
+	names := []string{"", "undefined", "alpha", "beta"}
+	var bloom uint64
+	var values []uint32
+	for i, name := range names[2:] {
+		h := GNUHash(name)
+		bloom |= 1<<(h%64) | 1<<((h>>6)%64)
+		if i == 1 {
+			h |= 1 // Last symbol of the chain
+		} else {
+			h &^= 1
+		}
+		values = append(values, h)
+	}
+
+	data := words(1, 2, 1, 6)
+	data = binary.LittleEndian.AppendUint64(data, bloom)
+	data = append(data, words(append([]uint32{2}, values...)...)...)
+
+	table, err := ParseGNUHashTable(data, binary.LittleEndian, "ELF64")
+	if err != nil {
+		t.Fatalf("ParseGNUHashTable failed: %v", err)
+	}
+	if table.SymOffset != 2 || table.BloomShift != 6 || len(table.Chains) != 2 {
+		t.Fatalf("Unexpected table: %+v", table)
+	}
+	if lengths, err := table.ChainLengths(); err != nil || len(lengths) != 1 || lengths[0] != 2 {
+		t.Errorf("ChainLengths = %v, %v; want [2]", lengths, err)
+	}
+	for i := 2; i < len(names); i++ {
+		if !table.Reachable(uint32(i), names[i]) {
+			t.Errorf("Symbol %s not reachable", names[i])
+		}
+	}
+
+	// Without the Bloom filter bits no lookup succeeds
+	table.Bloom[0] = 0
+	if table.Reachable(2, "alpha") {
+		t.Error("Symbol reachable through an empty Bloom filter")
+	}
+
+	// A chain that never ends runs off the table
+	table.Chains[1] &^= 1
+	if _, err := table.ChainLengths(); err == nil {
+		t.Error("Expected error for an unterminated chain")
+	}
+}
@@ -0,0 +1,125 @@
+package elf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Note represents one entry of a note section or segment
+type Note struct {
+	Namespace string // Owner name, such as "GNU" or "CORE"
+	Type      uint32
+	Desc      []byte
+}
+
+// NoteGroup holds the notes of one SHT_NOTE section or, for files without
+// section headers, one PT_NOTE segment
+type NoteGroup struct {
+	Section string // Empty for segments
+	Offset  uint64
+	Size    uint64
+	Notes   []Note
+}
+
+// Notes reads all notes of the file. Note sections are used when the file
+// has them; otherwise the PT_NOTE segments are read.
+func (e *ELF) Notes(r io.ReadSeeker) ([]NoteGroup, error) {
+	groups := []NoteGroup{}
+	endian := e.ByteOrder()
+
+	for i := range e.Sections {
+		section := &e.Sections[i]
+		if section.Type != SHT_NOTE {
+			continue
+		}
+		// Secure: validate section size
+		data, err := section.ReadAll(100 * 1024 * 1024)
+		if err != nil {
+			return nil, err
+		}
+		notes, err := ParseNotes(data, endian, section.AddrAlign)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", section.Name, err)
+		}
+		groups = append(groups, NoteGroup{Section: section.Name, Offset: section.Offset, Size: section.Size, Notes: notes})
+	}
+	if len(e.Sections) > 0 {
+		return groups, nil
+	}
+
+	for _, seg := range e.Segments {
+		if seg.Type != PT_NOTE {
+			continue
+		}
+		// Secure: validate segment size
+		if seg.FileSz > 100*1024*1024 {
+			return nil, fmt.Errorf("note segment too large: %d", seg.FileSz)
+		}
+		data, err := readAt(r, seg.Offset, seg.FileSz)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read note segment: %w", err)
+		}
+		notes, err := ParseNotes(data, endian, seg.Align)
+		if err != nil {
+			return nil, fmt.Errorf("segment at 0x%x: %w", seg.Offset, err)
+		}
+		groups = append(groups, NoteGroup{Offset: seg.Offset, Size: seg.FileSz, Notes: notes})
+	}
+
+	return groups, nil
+}
+
+// ParseNotes decodes a sequence of notes. Names and descriptors are padded
+// to 4 bytes, or to 8 bytes in 8-byte aligned note sections such as
+// .note.gnu.property.
+func ParseNotes(data []byte, endian binary.ByteOrder, align uint64) ([]Note, error) {
+	pad := uint64(4)
+	if align == 8 {
+		pad = 8
+	}
+
+	notes := []Note{}
+	for pos := uint64(0); pos < uint64(len(data)); {
+		if pos+12 > uint64(len(data)) {
+			return notes, fmt.Errorf("note header truncated: %d bytes left", uint64(len(data))-pos)
+		}
+		nameSize := uint64(endian.Uint32(data[pos:]))
+		descSize := uint64(endian.Uint32(data[pos+4:]))
+		noteType := endian.Uint32(data[pos+8:])
+		pos += 12
+
+		nameEnd := pos + nameSize
+		descStart := alignUp(nameEnd, pad)
+		descEnd := descStart + descSize
+		// Secure: sizes come from the file and must stay inside the data
+		if nameSize > uint64(len(data)) || descSize > uint64(len(data)) || descEnd > uint64(len(data)) {
+			return notes, fmt.Errorf("note extends past end of data")
+		}
+
+		notes = append(notes, Note{
+			Namespace: ReadCString(data[pos:nameEnd]),
+			Type:      noteType,
+			Desc:      data[descStart:descEnd],
+		})
+		pos = alignUp(descEnd, pad)
+	}
+
+	return notes, nil
+}
+
+// BuildID returns the descriptor of the NT_GNU_BUILD_ID note, if any
+func (e *ELF) BuildID(r io.ReadSeeker) ([]byte, bool, error) {
+	groups, err := e.Notes(r)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, group := range groups {
+		for _, note := range group.Notes {
+			if note.Namespace == "GNU" && note.Type == NT_GNU_BUILD_ID {
+				return note.Desc, true, nil
+			}
+		}
+	}
+	return nil, false, nil
+}
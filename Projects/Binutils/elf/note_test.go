@@ -0,0 +1,146 @@
+package elf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// appendNote encodes a note with its name NUL-terminated, padding the data
+// after both name and descriptor to a multiple of pad bytes
+func appendNote(data []byte, endian binary.ByteOrder, name string, noteType uint32, desc []byte, pad int) []byte {
+	header := make([]byte, 12)
+	endian.PutUint32(header[0:], uint32(len(name)+1))
+	endian.PutUint32(header[4:], uint32(len(desc)))
+	endian.PutUint32(header[8:], noteType)
+	data = append(data, header...)
+	data = append(data, name...)
+	data = append(data, make([]byte, 1+(pad-(len(data)+1)%pad)%pad)...)
+	data = append(data, desc...)
+	return append(data, make([]byte, (pad-len(data)%pad)%pad)...)
+}
+
+// TestParseNotes tests the padding of names and descriptors, and that
+// sizes reaching past the data give an error
+func TestParseNotes(t *testing.T) {
+	buildID, _ := hex.DecodeString("34683a480b9137b77576c460b919083ca4b6a119")
+	abiTag := appendNote(nil, binary.LittleEndian, "GNU", NT_GNU_ABI_TAG, []byte{0, 0, 0, 0, 3, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0}, 4)
+
+	tests := []struct {
+		name   string
+		endian binary.ByteOrder
+		data   []byte
+		align  uint64
+		want   []Note
+	}{
+		{"build ID", binary.LittleEndian, appendNote(nil, binary.LittleEndian, "GNU", NT_GNU_BUILD_ID, buildID, 4), 4,
+			[]Note{{"GNU", NT_GNU_BUILD_ID, buildID}}},
+		{"ABI tag", binary.LittleEndian, abiTag, 4,
+			[]Note{{"GNU", NT_GNU_ABI_TAG, []byte{0, 0, 0, 0, 3, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0}}}},
+		// A 5-byte name and a 3-byte descriptor are padded to 8 and 4 bytes
+		{"padding", binary.LittleEndian, appendNote(appendNote(nil, binary.LittleEndian, "CORE", 1, []byte{1, 2, 3}, 4), binary.LittleEndian, "Go", 4, []byte("id"), 4), 4,
+			[]Note{{"CORE", 1, []byte{1, 2, 3}}, {"Go", 4, []byte("id")}}},
+		// .note.gnu.property is padded to 8 bytes
+		{"8-byte alignment", binary.LittleEndian, appendNote(appendNote(nil, binary.LittleEndian, "CORE", 1, []byte{1, 2, 3, 4, 5}, 8), binary.LittleEndian, "GNU", NT_GNU_PROPERTY_TYPE_0, []byte{9}, 8), 8,
+			[]Note{{"CORE", 1, []byte{1, 2, 3, 4, 5}}, {"GNU", NT_GNU_PROPERTY_TYPE_0, []byte{9}}}},
+		{"big-endian", binary.BigEndian, appendNote(nil, binary.BigEndian, "GNU", NT_GNU_BUILD_ID, buildID, 4), 4,
+			[]Note{{"GNU", NT_GNU_BUILD_ID, buildID}}},
+		{"empty", binary.LittleEndian, nil, 4, []Note{}},
+	}
+	for _, tt := range tests {
+		notes, err := ParseNotes(tt.data, tt.endian, tt.align)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !slices.EqualFunc(notes, tt.want, func(a, b Note) bool {
+			return a.Namespace == b.Namespace && a.Type == b.Type && bytes.Equal(a.Desc, b.Desc)
+		}) {
+			t.Errorf("%s: notes %+v, want %+v", tt.name, notes, tt.want)
+		}
+	}
+
+	// Truncated notes keep those before them and never read past the data
+	bad := []struct {
+		name string
+		data []byte
+		kept int // Notes returned with the error
+	}{
+		{"truncated header", abiTag[:8], 0},
+		{"header after a note", append(slices.Clone(abiTag), 4, 0, 0, 0), 1},
+		{"truncated descriptor", abiTag[:len(abiTag)-1], 0},
+		{"truncated name", abiTag[:14], 0},
+		{"huge name", append([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 1, 0, 0, 0}, "GNU\x00"...), 0},
+		{"huge descriptor", append([]byte{4, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 1, 0, 0, 0}, "GNU\x00"...), 0},
+	}
+	for _, tt := range bad {
+		notes, err := ParseNotes(tt.data, binary.LittleEndian, 4)
+		if err == nil {
+			t.Errorf("%s: ParseNotes succeeded, want an error", tt.name)
+		}
+		if len(notes) != tt.kept {
+			t.Errorf("%s: %d notes before the error, want %d", tt.name, len(notes), tt.kept)
+		}
+	}
+}
+
+// TestNotes tests the notes of testdata/hello, read from its note sections
+// and, with the section headers removed, from its PT_NOTE segments
+func TestNotes(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "testdata", "hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stripped := slices.Clone(data)
+	clear(stripped[0x28:0x30]) // e_shoff
+	clear(stripped[0x3c:0x40]) // e_shnum, e_shstrndx
+
+	// As listed by GNU readelf -n
+	wantID := "34683a480b9137b77576c460b919083ca4b6a119"
+	wantSections := []string{".note.gnu.property", ".note.gnu.build-id", ".note.ABI-tag"}
+	wantTypes := []uint32{NT_GNU_PROPERTY_TYPE_0, NT_GNU_BUILD_ID, NT_GNU_ABI_TAG}
+
+	for _, image := range [][]byte{data, stripped} {
+		e, err := ParseELF(bytes.NewReader(image))
+		if err != nil {
+			t.Fatalf("ParseELF: %v", err)
+		}
+		segments := len(e.Sections) == 0
+		groups, err := e.Notes(bytes.NewReader(image))
+		if err != nil {
+			t.Fatalf("Notes (segments %v): %v", segments, err)
+		}
+
+		sections := []string{}
+		types := []uint32{}
+		for _, group := range groups {
+			sections = append(sections, group.Section)
+			for _, note := range group.Notes {
+				if note.Namespace != "GNU" {
+					t.Errorf("Note owner %q, want GNU", note.Namespace)
+				}
+				types = append(types, note.Type)
+			}
+		}
+		if !slices.Equal(types, wantTypes) {
+			t.Errorf("Note types (segments %v) = %v, want %v", segments, types, wantTypes)
+		}
+		if segments {
+			// The property note has a segment of its own for its alignment
+			if len(groups) != 2 || groups[0].Section != "" {
+				t.Errorf("Note segments %+v, want 2", groups)
+			}
+		} else if !slices.Equal(sections, wantSections) {
+			t.Errorf("Note sections %q, want %q", sections, wantSections)
+		}
+
+		id, ok, err := e.BuildID(bytes.NewReader(image))
+		if err != nil || !ok || hex.EncodeToString(id) != wantID {
+			t.Errorf("BuildID (segments %v) = %x, %v, %v, want %s", segments, id, ok, err, wantID)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package elf
+
+import (
+	"fmt"
+)
+
+// Relocation is one entry of a SHT_REL or SHT_RELA section
+type Relocation struct {
+	Offset    uint64
+	Info      uint64 // Raw r_info field
+	Type      uint32
+	Symbol    uint32 // Index into the symbol table named by the section's sh_link
+	Addend    int64
+	HasAddend bool // Entry comes from a SHT_RELA section
+}
+
+// relocationNamesX86_64 maps x86-64 relocation types to their names
+var relocationNamesX86_64 = map[uint32]string{
+	R_X86_64_NONE:          "R_X86_64_NONE",
+	R_X86_64_64:            "R_X86_64_64",
+	R_X86_64_PC32:          "R_X86_64_PC32",
+	R_X86_64_GOT32:         "R_X86_64_GOT32",
+	R_X86_64_PLT32:         "R_X86_64_PLT32",
+	R_X86_64_COPY:          "R_X86_64_COPY",
+	R_X86_64_GLOB_DAT:      "R_X86_64_GLOB_DAT",
+	R_X86_64_JUMP_SLOT:     "R_X86_64_JUMP_SLOT",
+	R_X86_64_RELATIVE:      "R_X86_64_RELATIVE",
+	R_X86_64_GOTPCREL:      "R_X86_64_GOTPCREL",
+	R_X86_64_32:            "R_X86_64_32",
+	R_X86_64_32S:           "R_X86_64_32S",
+	R_X86_64_16:            "R_X86_64_16",
+	R_X86_64_PC16:          "R_X86_64_PC16",
+	R_X86_64_8:             "R_X86_64_8",
+	R_X86_64_PC8:           "R_X86_64_PC8",
+	R_X86_64_DTPMOD64:      "R_X86_64_DTPMOD64",
+	R_X86_64_DTPOFF64:      "R_X86_64_DTPOFF64",
+	R_X86_64_TPOFF64:       "R_X86_64_TPOFF64",
+	R_X86_64_TLSGD:         "R_X86_64_TLSGD",
+	R_X86_64_TLSLD:         "R_X86_64_TLSLD",
+	R_X86_64_DTPOFF32:      "R_X86_64_DTPOFF32",
+	R_X86_64_GOTTPOFF:      "R_X86_64_GOTTPOFF",
+	R_X86_64_TPOFF32:       "R_X86_64_TPOFF32",
+	R_X86_64_PC64:          "R_X86_64_PC64",
+	R_X86_64_GOTOFF64:      "R_X86_64_GOTOFF64",
+	R_X86_64_GOTPC32:       "R_X86_64_GOTPC32",
+	R_X86_64_SIZE32:        "R_X86_64_SIZE32",
+	R_X86_64_SIZE64:        "R_X86_64_SIZE64",
+	R_X86_64_IRELATIVE:     "R_X86_64_IRELATIVE",
+	R_X86_64_GOTPCRELX:     "R_X86_64_GOTPCRELX",
+	R_X86_64_REX_GOTPCRELX: "R_X86_64_REX_GOTPCRELX",
+}
+
+// relocationNames386 maps i386 relocation types to their names
+var relocationNames386 = map[uint32]string{
+	R_386_NONE:         "R_386_NONE",
+	R_386_32:           "R_386_32",
+	R_386_PC32:         "R_386_PC32",
+	R_386_GOT32:        "R_386_GOT32",
+	R_386_PLT32:        "R_386_PLT32",
+	R_386_COPY:         "R_386_COPY",
+	R_386_GLOB_DAT:     "R_386_GLOB_DAT",
+	R_386_JMP_SLOT:     "R_386_JUMP_SLOT",
+	R_386_RELATIVE:     "R_386_RELATIVE",
+	R_386_GOTOFF:       "R_386_GOTOFF",
+	R_386_GOTPC:        "R_386_GOTPC",
+	R_386_TLS_TPOFF:    "R_386_TLS_TPOFF",
+	R_386_16:           "R_386_16",
+	R_386_PC16:         "R_386_PC16",
+	R_386_8:            "R_386_8",
+	R_386_PC8:          "R_386_PC8",
+	R_386_TLS_DTPMOD32: "R_386_TLS_DTPMOD32",
+	R_386_TLS_DTPOFF32: "R_386_TLS_DTPOFF32",
+	R_386_TLS_TPOFF32:  "R_386_TLS_TPOFF32",
+	R_386_IRELATIVE:    "R_386_IRELATIVE",
+	R_386_GOT32X:       "R_386_GOT32X",
+}
+
+// GetRelocationType returns the name of a relocation type for a machine
+func GetRelocationType(machine uint16, t uint32) string {
+	var names map[uint32]string
+	switch machine {
+	case EM_X86_64:
+		names = relocationNamesX86_64
+	case EM_386:
+		names = relocationNames386
+	}
+	if name, ok := names[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("R_UNKNOWN(%d)", t)
+}
+
+// Relocations decodes the entries of a SHT_REL or SHT_RELA section
+func (e *ELF) Relocations(section *Section) ([]Relocation, error) {
+	if section.Type != SHT_REL && section.Type != SHT_RELA {
+		return nil, fmt.Errorf("section %s is not a relocation section", section.Name)
+	}
+
+	// Secure: limit relocation table size
+	data, err := section.ReadAll(100 * 1024 * 1024)
+	if err != nil {
+		return nil, err
+	}
+
+	endian := e.ByteOrder()
+	entSize := 16
+	if e.Class == "ELF32" {
+		entSize = 8
+	}
+	if section.Type == SHT_RELA {
+		entSize += entSize / 2 // r_addend has the same width as r_offset
+	}
+
+	relocations := make([]Relocation, 0, len(data)/entSize)
+	for off := 0; off+entSize <= len(data); off += entSize {
+		entry := data[off : off+entSize]
+		var rel Relocation
+		if e.Class == "ELF32" {
+			rel.Offset = uint64(endian.Uint32(entry[0:4]))
+			rel.Info = uint64(endian.Uint32(entry[4:8]))
+			rel.Symbol = uint32(rel.Info >> 8)
+			rel.Type = uint32(rel.Info & 0xff)
+			if section.Type == SHT_RELA {
+				rel.Addend = int64(int32(endian.Uint32(entry[8:12])))
+			}
+		} else {
+			rel.Offset = endian.Uint64(entry[0:8])
+			rel.Info = endian.Uint64(entry[8:16])
+			rel.Symbol = uint32(rel.Info >> 32)
+			rel.Type = uint32(rel.Info)
+			if section.Type == SHT_RELA {
+				rel.Addend = int64(endian.Uint64(entry[16:24]))
+			}
+		}
+		rel.HasAddend = section.Type == SHT_RELA
+		relocations = append(relocations, rel)
+	}
+
+	return relocations, nil
+}
+
+// RelocationSymbols returns the symbol table a relocation section refers to
+// through its sh_link: the dynamic symbols or the regular symbol table
+func (e *ELF) RelocationSymbols(section *Section) []Symbol {
+	if int(section.Link) >= len(e.Sections) || section.Link == 0 {
+		return nil
+	}
+	if e.Sections[section.Link].Type == SHT_DYNSYM {
+		return e.DynamicSymbols
+	}
+	return e.Symbols
+}
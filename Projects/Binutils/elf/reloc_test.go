@@ -0,0 +1,100 @@
+package elf
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// TestRelocations tests decoding REL and RELA entries of both classes and
+// byte orders: the split of r_info into symbol and type, and the sign of
+// the addend
+func TestRelocations(t *testing.T) {
+	tests := []struct {
+		class string
+		data  byte // EI_DATA: 1 little-endian, 2 big-endian
+		typ   uint32
+	}{
+		{"ELF64", 1, SHT_RELA},
+		{"ELF64", 2, SHT_RELA},
+		{"ELF64", 1, SHT_REL},
+		{"ELF32", 1, SHT_RELA},
+		{"ELF32", 2, SHT_REL},
+	}
+	// Each table holds a call with addend -4 and a 64-bit pointer with
+	// addend 0x10, against symbols whose index needs the full r_sym width
+	want := []Relocation{
+		{Offset: 0x11, Type: 4, Symbol: 0x123, Addend: -4},
+		{Offset: 0x20, Type: 1, Symbol: 0xabcd, Addend: 0x10},
+	}
+
+	for _, tt := range tests {
+		e := &ELF{Class: tt.class, Header: ELFHeader{Data: tt.data}}
+		endian := e.ByteOrder().(binary.AppendByteOrder)
+		var data []byte
+		for _, rel := range want {
+			if tt.class == "ELF32" {
+				data = endian.AppendUint32(data, uint32(rel.Offset))
+				data = endian.AppendUint32(data, rel.Symbol<<8|rel.Type)
+				if tt.typ == SHT_RELA {
+					data = endian.AppendUint32(data, uint32(int32(rel.Addend)))
+				}
+			} else {
+				data = endian.AppendUint64(data, rel.Offset)
+				data = endian.AppendUint64(data, uint64(rel.Symbol)<<32|uint64(rel.Type))
+				if tt.typ == SHT_RELA {
+					data = endian.AppendUint64(data, uint64(rel.Addend))
+				}
+			}
+		}
+		// A trailing partial entry is ignored
+		data = append(data, 0xff, 0xff)
+		section := &Section{Name: ".rela.text", Type: tt.typ, Size: uint64(len(data)), Data: data}
+
+		got, err := e.Relocations(section)
+		if err != nil {
+			t.Fatalf("%s data %d type %d: Relocations: %v", tt.class, tt.data, tt.typ, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s data %d type %d: %d relocations, want %d", tt.class, tt.data, tt.typ, len(got), len(want))
+		}
+		for i, rel := range want {
+			rel.HasAddend = tt.typ == SHT_RELA
+			if !rel.HasAddend {
+				rel.Addend = 0
+			}
+			if tt.class == "ELF32" {
+				rel.Info = uint64(rel.Symbol<<8 | rel.Type)
+			} else {
+				rel.Info = uint64(rel.Symbol)<<32 | uint64(rel.Type)
+			}
+			if got[i] != rel {
+				t.Errorf("%s data %d type %d: entry %d = %+v, want %+v", tt.class, tt.data, tt.typ, i, got[i], rel)
+			}
+		}
+	}
+
+	e := &ELF{Class: "ELF64"}
+	if _, err := e.Relocations(&Section{Name: ".text", Type: SHT_PROGBITS}); err == nil {
+		t.Error("Relocations of .text succeeded, want an error")
+	}
+}
+
+// TestGetRelocationType tests relocation names for each machine
+func TestGetRelocationType(t *testing.T) {
+	tests := []struct {
+		machine uint16
+		typ     uint32
+		want    string
+	}{
+		{EM_X86_64, R_X86_64_PC32, "R_X86_64_PC32"},
+		{EM_X86_64, R_X86_64_PLT32, "R_X86_64_PLT32"},
+		{EM_386, R_386_JMP_SLOT, "R_386_JUMP_SLOT"},
+		{EM_X86_64, 200, "R_UNKNOWN(200)"},
+		{0, R_X86_64_64, "R_UNKNOWN(1)"},
+	}
+	for _, tt := range tests {
+		if got := GetRelocationType(tt.machine, tt.typ); got != tt.want {
+			t.Errorf("GetRelocationType(%d, %d) = %q, want %q", tt.machine, tt.typ, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package elf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Open returns a reader over the contents of the section. Unless the data
+// has been loaded into Data, reads go straight to the file given to
+// ParseELF, so large sections can be streamed without loading them.
+func (s *Section) Open() io.ReadSeeker {
+	if s.Data != nil || s.Type == SHT_NOBITS || s.reader == nil {
+		return bytes.NewReader(s.Data)
+	}
+	if ra, ok := s.reader.(io.ReaderAt); ok {
+		return io.NewSectionReader(ra, int64(s.Offset), int64(s.Size))
+	}
+	return &seekingReader{r: s.reader, base: int64(s.Offset), size: int64(s.Size)}
+}
+
+// ReadAll reads the contents of the section into memory. limit bounds the
+// section size so that corrupt headers cannot force huge allocations.
+func (s *Section) ReadAll(limit uint64) ([]byte, error) {
+	if s.Data != nil || s.Type == SHT_NOBITS {
+		return s.Data, nil
+	}
+	if s.reader == nil {
+		return nil, fmt.Errorf("section %s has no data source", s.Name)
+	}
+
+	// Secure: validate section size
+	if s.Size > limit {
+		return nil, fmt.Errorf("section %s too large: %d", s.Name, s.Size)
+	}
+
+	// Secure: check the contents lie within the file before allocating
+	fileSize, err := readerSize(s.reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read section %s: %w", s.Name, err)
+	}
+	if beyond(s.Offset, s.Size, fileSize) {
+		return nil, &ErrBadOffset{Section: s.Name, Offset: s.Offset}
+	}
+
+	data := make([]byte, s.Size)
+	if _, err := io.ReadFull(s.Open(), data); err != nil {
+		if shortRead(err) == ErrTruncated {
+			return nil, &ErrBadOffset{Section: s.Name, Offset: s.Offset}
+		}
+		return nil, fmt.Errorf("failed to read section %s: %w", s.Name, err)
+	}
+	return data, nil
+}
+
+// readerSize returns the size of the file behind r, leaving its position
+// unchanged
+func readerSize(r io.ReadSeeker) (int64, error) {
+	pos, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := r.Seek(pos, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// seekingReader reads a window of a file that only supports Seek and Read.
+// It seeks before every read, so several readers can share the file.
+type seekingReader struct {
+	r    io.ReadSeeker
+	base int64
+	size int64
+	pos  int64
+}
+
+// Read reads from the current position within the window
+func (sr *seekingReader) Read(p []byte) (int, error) {
+	if sr.pos >= sr.size {
+		return 0, io.EOF
+	}
+	if remaining := sr.size - sr.pos; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	if _, err := sr.r.Seek(sr.base+sr.pos, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := sr.r.Read(p)
+	sr.pos += int64(n)
+	if err == io.EOF && sr.pos < sr.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// Seek moves the position within the window
+func (sr *seekingReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += sr.pos
+	case io.SeekEnd:
+		offset += sr.size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position: %d", offset)
+	}
+	sr.pos = offset
+	return offset, nil
+}
@@ -0,0 +1,99 @@
+package elf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// seekOnly hides the ReadAt method of a reader, so sections are read
+// through seekingReader
+type seekOnly struct{ io.ReadSeeker }
+
+// TestSectionRead tests Open and ReadAll on files read through io.ReaderAt
+// and through Seek and Read alone
+func TestSectionRead(t *testing.T) {
+	image := testImage(t)
+	readers := map[string]func() io.ReadSeeker{
+		"ReaderAt":  func() io.ReadSeeker { return bytes.NewReader(image) },
+		"Seek only": func() io.ReadSeeker { return seekOnly{bytes.NewReader(image)} },
+	}
+
+	for name, reader := range readers {
+		parse := func() *ELF {
+			e, err := ParseELF(reader())
+			if err != nil {
+				t.Fatalf("%s: ParseELF failed: %v", name, err)
+			}
+			return e
+		}
+
+		// .text is streamed from the file, and seeks stay in the section
+		e := parse()
+		text := &e.Sections[1]
+		data, err := text.ReadAll(1024)
+		if err != nil || !bytes.Equal(data, []byte{0x31, 0xc0, 0xc3, 0x90}) {
+			t.Errorf("%s: ReadAll(.text) = %x, %v", name, data, err)
+		}
+		r := text.Open()
+		if pos, err := r.Seek(-2, io.SeekEnd); pos != 2 || err != nil {
+			t.Errorf("%s: Seek(-2, SeekEnd) = %d, %v, want 2", name, pos, err)
+		}
+		if rest, err := io.ReadAll(r); !bytes.Equal(rest, []byte{0xc3, 0x90}) || err != nil {
+			t.Errorf("%s: read after seek = %x, %v, want c390", name, rest, err)
+		}
+		if _, err := r.Seek(-1, io.SeekStart); err == nil {
+			t.Errorf("%s: Seek to a negative position succeeded", name)
+		}
+
+		// SHT_NOBITS occupies no file bytes, wherever its offset points
+		e = parse()
+		bss := &e.Sections[1]
+		bss.Type = SHT_NOBITS
+		bss.Offset = 1 << 40
+		bss.Size = 0x1000
+		if data, err := bss.ReadAll(1024); len(data) != 0 || err != nil {
+			t.Errorf("%s: ReadAll(SHT_NOBITS) = %d bytes, %v, want none", name, len(data), err)
+		}
+		if n, err := io.Copy(io.Discard, bss.Open()); n != 0 || err != nil {
+			t.Errorf("%s: Open(SHT_NOBITS) read %d bytes, %v, want none", name, n, err)
+		}
+
+		// Contents outside the file are an error, never a panic
+		tests := []struct {
+			name   string
+			offset uint64
+			size   uint64
+		}{
+			{"offset past the end", uint64(len(image)) + 16, 4},
+			{"size past the end", 0, uint64(len(image)) + 1},
+			{"offset overflowing int64", 1 << 63, 4},
+			{"size overflowing the offset", 16, ^uint64(0) - 8},
+		}
+		for _, tt := range tests {
+			e = parse()
+			section := &e.Sections[1]
+			section.Offset, section.Size = tt.offset, tt.size
+			var bad *ErrBadOffset
+			if _, err := section.ReadAll(^uint64(0)); !errors.As(err, &bad) || bad.Section != ".text" {
+				t.Errorf("%s: %s: got %v, want ErrBadOffset for .text", name, tt.name, err)
+			}
+		}
+
+		e = parse()
+		if _, err := e.Sections[1].ReadAll(3); err == nil {
+			t.Errorf("%s: ReadAll over the limit succeeded", name)
+		}
+	}
+
+	// Loaded data is used without a file
+	section := &Section{Name: ".data", Type: SHT_PROGBITS, Size: 2, Data: []byte{1, 2}}
+	if data, err := section.ReadAll(1); !bytes.Equal(data, []byte{1, 2}) || err != nil {
+		t.Errorf("ReadAll of loaded data = %x, %v", data, err)
+	}
+	section = &Section{Name: ".data", Type: SHT_PROGBITS, Size: 2}
+	if _, err := section.ReadAll(1024); err == nil {
+		t.Error("ReadAll without data or file succeeded")
+	}
+}
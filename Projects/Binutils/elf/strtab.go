@@ -0,0 +1,36 @@
+package elf
+
+import "fmt"
+
+// StringTable is the contents of a SHT_STRTAB section: NUL-terminated
+// strings addressed by their byte offset
+type StringTable []byte
+
+// Lookup returns the string that starts at offset
+func (t StringTable) Lookup(offset uint32) (string, bool) {
+	if uint64(offset) >= uint64(len(t)) {
+		return "", false
+	}
+	return ReadCString(t[offset:]), true
+}
+
+// SectionName resolves the name of section i through the section header
+// string table. Names that cannot be resolved are reported as "section_<i>".
+func (e *ELF) SectionName(i int) string {
+	if i < 0 || i >= len(e.Sections) {
+		return ""
+	}
+	if name, ok := e.StringTable.Lookup(e.Sections[i].NameOffset); ok {
+		return name
+	}
+	return fmt.Sprintf("section_%d", i)
+}
+
+// shstrndx returns the index of the section header string table. Files with
+// more than SHN_LORESERVE sections store it in the sh_link field of section 0.
+func (e *ELF) shstrndx() int {
+	if e.Header.ShStrndx == SHN_XINDEX && len(e.Sections) > 0 {
+		return int(e.Sections[0].Link)
+	}
+	return int(e.Header.ShStrndx)
+}
@@ -0,0 +1,48 @@
+package elf
+
+import "testing"
+
+// TestStringTableLookup tests string table offsets, including ones that
+// point into the middle of a string or past the end of the table
+func TestStringTableLookup(t *testing.T) {
+	table := StringTable("\x00.text\x00.rela.text\x00")
+
+	tests := []struct {
+		offset uint32
+		want   string
+		ok     bool
+	}{
+		{0, "", true},
+		{1, ".text", true},
+		{7, ".rela.text", true},
+		{12, ".text", true},
+		{17, "", true},
+		{18, "", false},
+		{0xffffffff, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := table.Lookup(tt.offset)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%d) = %q, %v; want %q, %v", tt.offset, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestSectionName tests name resolution through the section header string table
+func TestSectionName(t *testing.T) {
+	e := &ELF{
+		StringTable: StringTable("\x00.text\x00.data\x00"),
+		Sections:    []Section{{NameOffset: 0}, {NameOffset: 1}, {NameOffset: 7}, {NameOffset: 100}},
+	}
+
+	want := []string{"", ".text", ".data", "section_3"}
+	for i, name := range want {
+		if got := e.SectionName(i); got != name {
+			t.Errorf("SectionName(%d) = %q, want %q", i, got, name)
+		}
+	}
+	if got := e.SectionName(4); got != "" {
+		t.Errorf("SectionName(4) = %q, want empty", got)
+	}
+}
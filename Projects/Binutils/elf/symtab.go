@@ -0,0 +1,211 @@
+package elf
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// SymbolEntry is a raw symbol table entry as seen by FilterSymbols
+type SymbolEntry struct {
+	Index      int
+	Name       string
+	Info       byte
+	Shndx      uint16
+	Relocation bool // referenced by at least one relocation
+	Signature  bool // names a section group, as for a COMDAT group
+}
+
+// Binding returns the symbol binding (STB_*) encoded in Info
+func (s SymbolEntry) Binding() byte {
+	return s.Info >> 4
+}
+
+// Type returns the symbol type (STT_*) encoded in Info
+func (s SymbolEntry) Type() byte {
+	return s.Info & 0x0f
+}
+
+// FilterSymbols rewrites the loaded .symtab keeping entry 0 and every symbol
+// for which keep returns true. Locals stay ahead of globals as ELF requires,
+// the linked string table is rebuilt and relocations and section groups
+// referring to the symbol table are renumbered. The signature of a section
+// group is kept whatever keep returns, since the group is matched by its
+// name at link time. It returns the number of symbols kept, excluding entry 0.
+func (e *ELF) FilterSymbols(keep func(sym SymbolEntry) bool) (int, error) {
+	symtabIndex := -1
+	for i := range e.Sections {
+		if e.Sections[i].Type == SHT_SYMTAB {
+			symtabIndex = i
+			break
+		}
+	}
+	if symtabIndex < 0 {
+		return 0, nil // No symbol table
+	}
+
+	symtab := &e.Sections[symtabIndex]
+	if int(symtab.Link) >= len(e.Sections) || e.Sections[symtab.Link].Type != SHT_STRTAB {
+		return 0, fmt.Errorf("symbol table has no string table")
+	}
+	strtab := &e.Sections[symtab.Link]
+	if uint64(len(symtab.Data)) != symtab.Size || uint64(len(strtab.Data)) != strtab.Size {
+		return 0, fmt.Errorf("symbol table data not loaded")
+	}
+
+	endian := e.ByteOrder()
+	entSize, nameOff, infoOff, shndxOff := 24, 0, 4, 6
+	if e.Class == "ELF32" {
+		entSize, nameOff, infoOff, shndxOff = 16, 0, 12, 14
+	}
+	count := len(symtab.Data) / entSize
+	if count == 0 {
+		return 0, nil
+	}
+
+	// Collect the symbols referenced by relocations
+	referenced := make([]bool, count)
+	for i := range e.Sections {
+		section := &e.Sections[i]
+		if (section.Type != SHT_REL && section.Type != SHT_RELA) || int(section.Link) != symtabIndex {
+			continue
+		}
+		err := forEachRelocation(section, e.Class, endian, func(sym uint32, _ []byte) {
+			if int(sym) < count {
+				referenced[sym] = true
+			}
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Collect the symbols naming section groups
+	signature := make([]bool, count)
+	for i := range e.Sections {
+		section := &e.Sections[i]
+		if section.Type == SHT_GROUP && int(section.Link) == symtabIndex && int(section.Info) < count {
+			signature[section.Info] = true
+		}
+	}
+
+	// Decide which entries survive, locals first
+	var locals, globals []int
+	for i := 1; i < count; i++ {
+		entry := symtab.Data[i*entSize : (i+1)*entSize]
+		name, _ := StringTable(strtab.Data).Lookup(endian.Uint32(entry[nameOff:]))
+		sym := SymbolEntry{
+			Index:      i,
+			Name:       name,
+			Info:       entry[infoOff],
+			Shndx:      endian.Uint16(entry[shndxOff:]),
+			Relocation: referenced[i],
+			Signature:  signature[i],
+		}
+		if !keep(sym) && !signature[i] {
+			if referenced[i] {
+				return 0, fmt.Errorf("symbol %q is needed by relocations", name)
+			}
+			continue
+		}
+		if sym.Binding() == 0 { // STB_LOCAL
+			locals = append(locals, i)
+		} else {
+			globals = append(globals, i)
+		}
+	}
+
+	// Rebuild the symbol and string tables
+	order := append([]int{0}, append(locals, globals...)...)
+	newIndex := make([]uint32, count)
+	newSymtab := make([]byte, 0, len(order)*entSize)
+	newStrtab := []byte{0}
+	for n, old := range order {
+		newIndex[old] = uint32(n)
+		entry := append([]byte(nil), symtab.Data[old*entSize:(old+1)*entSize]...)
+		offset := endian.Uint32(entry[nameOff:])
+		if name, ok := StringTable(strtab.Data).Lookup(offset); old != 0 && offset != 0 && ok {
+			endian.PutUint32(entry[nameOff:], uint32(len(newStrtab)))
+			newStrtab = append(newStrtab, name...)
+			newStrtab = append(newStrtab, 0)
+		} else {
+			endian.PutUint32(entry[nameOff:], 0)
+		}
+		newSymtab = append(newSymtab, entry...)
+	}
+
+	for i := range e.Sections {
+		section := &e.Sections[i]
+		if (section.Type != SHT_REL && section.Type != SHT_RELA) || int(section.Link) != symtabIndex {
+			continue
+		}
+		err := forEachRelocation(section, e.Class, endian, func(sym uint32, info []byte) {
+			if int(sym) < count {
+				setRelocationSymbol(info, e.Class, endian, newIndex[sym])
+			}
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for i := range e.Sections {
+		section := &e.Sections[i]
+		if section.Type == SHT_GROUP && int(section.Link) == symtabIndex && int(section.Info) < count {
+			section.Info = newIndex[section.Info]
+		}
+	}
+
+	symtab.Data = newSymtab
+	symtab.Size = uint64(len(newSymtab))
+	symtab.Info = uint32(1 + len(locals)) // index of the first non-local symbol
+	strtab.Data = newStrtab
+	strtab.Size = uint64(len(newStrtab))
+
+	// Keep the decoded symbol list in step with the table
+	if len(e.Symbols) == count {
+		symbols := make([]Symbol, 0, len(order))
+		for _, old := range order {
+			symbols = append(symbols, e.Symbols[old])
+		}
+		e.Symbols = symbols
+	}
+
+	return len(order) - 1, nil
+}
+
+// forEachRelocation calls fn with the symbol index and the raw r_info field
+// of every entry in a loaded SHT_REL or SHT_RELA section
+func forEachRelocation(section *Section, class string, endian binary.ByteOrder, fn func(sym uint32, info []byte)) error {
+	entSize, infoOff, infoSize := 16, 8, 8
+	if class == "ELF32" {
+		entSize, infoOff, infoSize = 8, 4, 4
+	}
+	if section.Type == SHT_RELA {
+		entSize += infoSize // r_addend has the same width as r_info
+	}
+	if uint64(len(section.Data)) != section.Size {
+		return fmt.Errorf("relocation section %s not loaded", section.Name)
+	}
+
+	for off := 0; off+entSize <= len(section.Data); off += entSize {
+		info := section.Data[off+infoOff : off+infoOff+infoSize]
+		var sym uint32
+		if class == "ELF32" {
+			sym = endian.Uint32(info) >> 8
+		} else {
+			sym = uint32(endian.Uint64(info) >> 32)
+		}
+		fn(sym, info)
+	}
+
+	return nil
+}
+
+// setRelocationSymbol replaces the symbol index stored in a raw r_info field
+func setRelocationSymbol(info []byte, class string, endian binary.ByteOrder, sym uint32) {
+	if class == "ELF32" {
+		endian.PutUint32(info, sym<<8|endian.Uint32(info)&0xff)
+		return
+	}
+	endian.PutUint64(info, uint64(sym)<<32|endian.Uint64(info)&0xffffffff)
+}
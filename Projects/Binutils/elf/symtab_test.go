@@ -0,0 +1,57 @@
+package elf
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestFilterSymbols tests that dropping symbols renumbers relocations and
+// the signatures of section groups, and that signatures are always kept
+func TestFilterSymbols(t *testing.T) {
+	tests := []struct {
+		name    string
+		keep    func(sym SymbolEntry) bool
+		symbols []string
+	}{
+		{"keep all", func(SymbolEntry) bool { return true }, []string{"", "tmp", "", "a", "b"}},
+		{"drop locals", func(sym SymbolEntry) bool { return sym.Binding() != 0 }, []string{"", "a", "b"}},
+		{"drop all", func(SymbolEntry) bool { return false }, []string{"", "a", "b"}},
+	}
+
+	for _, tt := range tests {
+		e := groupObject()
+		var seen []SymbolEntry
+		kept, err := e.FilterSymbols(func(sym SymbolEntry) bool {
+			seen = append(seen, sym)
+			return tt.keep(sym)
+		})
+		if err != nil {
+			t.Fatalf("%s: FilterSymbols: %v", tt.name, err)
+		}
+		if kept != len(tt.symbols)-1 {
+			t.Errorf("%s: kept %d symbols, want %d", tt.name, kept, len(tt.symbols)-1)
+		}
+		for _, sym := range seen {
+			if sym.Signature != (sym.Name == "a" || sym.Name == "b") || sym.Relocation != (sym.Name == "a") {
+				t.Errorf("%s: entry %+v", tt.name, sym)
+			}
+		}
+
+		out := roundTrip(t, e)
+		names := []string{}
+		for _, sym := range out.Symbols {
+			names = append(names, sym.Name)
+		}
+		if !slices.Equal(names, tt.symbols) {
+			t.Errorf("%s: symbols %q, want %q", tt.name, names, tt.symbols)
+		}
+		if got := groups(out); !slices.Equal(got["a"], []string{".text.a"}) || !slices.Equal(got["b"], []string{".text.b", ".rela.text.b"}) {
+			t.Errorf("%s: groups %v", tt.name, got)
+		}
+
+		relocations, err := out.Relocations(&out.Sections[5])
+		if err != nil || len(relocations) != 1 || out.Symbols[relocations[0].Symbol].Name != "a" {
+			t.Errorf("%s: relocation %+v, %v, want one against a", tt.name, relocations, err)
+		}
+	}
+}
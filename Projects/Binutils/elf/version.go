@@ -0,0 +1,187 @@
+package elf
+
+import "fmt"
+
+// Symbol version index flags and reserved values (.gnu.version)
+const (
+	VER_NDX_LOCAL  = 0
+	VER_NDX_GLOBAL = 1
+	VERSYM_HIDDEN  = 0x8000
+	VERSYM_VERSION = 0x7fff
+	VER_FLG_BASE   = 0x1
+)
+
+// SymbolVersion is the version a dynamic symbol is bound to: an entry of
+// .gnu.version names a definition in .gnu.version_d or a need in
+// .gnu.version_r
+type SymbolVersion struct {
+	Name   string // Empty for local and unversioned global symbols
+	Hidden bool   // A defined symbol that is not the default version
+	Needed bool   // Required of another object, from .gnu.version_r
+}
+
+// Suffix returns the version as GNU tools append it to a symbol name:
+// @@NAME for the default version of a defined symbol, @NAME for a hidden
+// or needed one, and nothing for an unversioned symbol
+func (v SymbolVersion) Suffix() string {
+	switch {
+	case v.Name == "":
+		return ""
+	case v.Hidden || v.Needed:
+		return "@" + v.Name
+	}
+	return "@@" + v.Name
+}
+
+// SymbolVersions returns the version of each dynamic symbol, indexed as
+// DynamicSymbols, or nil if the file has no .gnu.version section. Like GNU
+// readelf, a defined symbol is looked up among the definitions first and
+// then among the needs, as copy-relocated data is defined yet needed.
+func (e *ELF) SymbolVersions() ([]SymbolVersion, error) {
+	var versym, verdef, verneed *Section
+	for i := range e.Sections {
+		switch section := &e.Sections[i]; section.Type {
+		case SHT_GNU_versym:
+			versym = section
+		case SHT_GNU_verdef:
+			verdef = section
+		case SHT_GNU_verneed:
+			verneed = section
+		}
+	}
+	if versym == nil {
+		return nil, nil
+	}
+
+	// Secure: validate section size
+	data, err := versym.ReadAll(16 * 1024 * 1024)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := e.versionDefinitions(verdef)
+	if err != nil {
+		return nil, err
+	}
+	needs, err := e.versionNeeds(verneed)
+	if err != nil {
+		return nil, err
+	}
+
+	endian := e.ByteOrder()
+	versions := make([]SymbolVersion, min(len(data)/2, len(e.DynamicSymbols)))
+	for i := range versions {
+		index := endian.Uint16(data[2*i:])
+		if index == VER_NDX_LOCAL {
+			continue
+		}
+		if e.DynamicSymbols[i].Shndx != SHN_UNDEF && index != VERSYM_HIDDEN|VER_NDX_GLOBAL && defs != nil {
+			if name, ok := defs[index&VERSYM_VERSION]; ok {
+				// ld defines an absolute symbol named after each version,
+				// which GNU tools print without the version again
+				if e.DynamicSymbols[i].Shndx != SHN_ABS || e.DynamicSymbols[i].Name != name {
+					versions[i] = SymbolVersion{Name: name, Hidden: index&VERSYM_HIDDEN != 0}
+				}
+				continue
+			}
+		}
+		if name, ok := needs[index&VERSYM_VERSION]; ok {
+			versions[i] = SymbolVersion{Name: name, Needed: true}
+		}
+	}
+	return versions, nil
+}
+
+// versionDefinitions maps the version indices of an SHT_GNU_verdef section
+// to their names. The base definition, naming the file itself, gives no
+// version to the symbols of index 1, so it is left out.
+func (e *ELF) versionDefinitions(section *Section) (map[uint16]string, error) {
+	if section == nil {
+		return nil, nil
+	}
+	data, strtab, err := e.versionSection(section)
+	if err != nil {
+		return nil, err
+	}
+
+	// Elf_Verdef: vd_version, vd_flags, vd_ndx, vd_cnt, vd_hash, vd_aux,
+	// vd_next; Elf_Verdaux: vda_name, vda_next
+	endian := e.ByteOrder()
+	defs := map[uint16]string{}
+	for off, n := uint64(0), 0; n < len(data)/20; n++ {
+		if off+20 > uint64(len(data)) {
+			return nil, fmt.Errorf("section %s: definition at 0x%x truncated", section.Name, off)
+		}
+		entry := data[off:]
+		flags, index := endian.Uint16(entry[2:]), endian.Uint16(entry[4:])
+		aux, next := off+uint64(endian.Uint32(entry[12:])), endian.Uint32(entry[16:])
+		if aux+8 > uint64(len(data)) {
+			return nil, fmt.Errorf("section %s: definition at 0x%x has its name outside the section", section.Name, off)
+		}
+		if index != VER_NDX_GLOBAL || flags&VER_FLG_BASE == 0 {
+			defs[index], _ = strtab.Lookup(endian.Uint32(data[aux:]))
+		}
+		if next == 0 {
+			break
+		}
+		off += uint64(next)
+	}
+	return defs, nil
+}
+
+// versionNeeds maps the version indices of an SHT_GNU_verneed section to
+// the names of the versions needed
+func (e *ELF) versionNeeds(section *Section) (map[uint16]string, error) {
+	if section == nil {
+		return nil, nil
+	}
+	data, strtab, err := e.versionSection(section)
+	if err != nil {
+		return nil, err
+	}
+
+	// Elf_Verneed: vn_version, vn_cnt, vn_file, vn_aux, vn_next;
+	// Elf_Vernaux: vna_hash, vna_flags, vna_other, vna_name, vna_next
+	endian := e.ByteOrder()
+	needs := map[uint16]string{}
+	for off, n := uint64(0), 0; n < len(data)/16; n++ {
+		if off+16 > uint64(len(data)) {
+			return nil, fmt.Errorf("section %s: need at 0x%x truncated", section.Name, off)
+		}
+		entry := data[off:]
+		count := int(endian.Uint16(entry[2:]))
+		aux, next := off+uint64(endian.Uint32(entry[8:])), endian.Uint32(entry[12:])
+		for j := 0; j < count; j++ {
+			if aux+16 > uint64(len(data)) {
+				return nil, fmt.Errorf("section %s: need at 0x%x truncated", section.Name, aux)
+			}
+			needs[endian.Uint16(data[aux+6:])], _ = strtab.Lookup(endian.Uint32(data[aux+8:]))
+			auxNext := endian.Uint32(data[aux+12:])
+			if auxNext == 0 {
+				break
+			}
+			aux += uint64(auxNext)
+		}
+		if next == 0 {
+			break
+		}
+		off += uint64(next)
+	}
+	return needs, nil
+}
+
+// versionSection reads a version section and the string table it links to
+func (e *ELF) versionSection(section *Section) ([]byte, StringTable, error) {
+	if section.Link == 0 || int(section.Link) >= len(e.Sections) {
+		return nil, nil, fmt.Errorf("section %s: bad string table link %d", section.Name, section.Link)
+	}
+	// Secure: validate section size
+	data, err := section.ReadAll(16 * 1024 * 1024)
+	if err != nil {
+		return nil, nil, err
+	}
+	strtab, err := e.Sections[section.Link].ReadAll(16 * 1024 * 1024)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, StringTable(strtab), nil
+}
@@ -0,0 +1,102 @@
+package elf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSymbolVersions tests the versions of the dynamic symbols of a program
+// needing versions of libc and of a library defining versions of its own,
+// against GNU readelf --dyn-syms
+func TestSymbolVersions(t *testing.T) {
+	tests := []struct {
+		file     string
+		versions map[string]string // Symbol name to name with suffix
+	}{
+		{"hello", map[string]string{
+			"__libc_start_main": "__libc_start_main@GLIBC_2.34",
+			"__cxa_finalize":    "__cxa_finalize@GLIBC_2.2.5",
+			"__gmon_start__":    "__gmon_start__",
+		}},
+		{filepath.Join("dyn", "lib", "libversion.so.1"), map[string]string{
+			"counter": "counter@@V2",
+			"current": "current@@V2",
+			"V1":      "V1",
+		}},
+	}
+
+	for _, tt := range tests {
+		file, err := os.Open(filepath.Join("..", "testdata", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := ParseELF(file)
+		if err != nil {
+			file.Close()
+			t.Fatalf("ParseELF(%s): %v", tt.file, err)
+		}
+		versions, err := e.SymbolVersions()
+		file.Close()
+		if err != nil {
+			t.Fatalf("SymbolVersions(%s): %v", tt.file, err)
+		}
+		if len(versions) != len(e.DynamicSymbols) {
+			t.Fatalf("%s: %d versions for %d dynamic symbols", tt.file, len(versions), len(e.DynamicSymbols))
+		}
+		found := 0
+		for i, sym := range e.DynamicSymbols {
+			want, ok := tt.versions[sym.Name]
+			if !ok {
+				continue
+			}
+			found++
+			if got := sym.Name + versions[i].Suffix(); got != want {
+				t.Errorf("%s: symbol %d = %s, want %s", tt.file, i, got, want)
+			}
+		}
+		if found != len(tt.versions) {
+			t.Errorf("%s: found %d of the symbols %v", tt.file, found, tt.versions)
+		}
+	}
+}
+
+// TestHiddenVersion tests that get has both its default version and the
+// hidden one it was first defined at
+func TestHiddenVersion(t *testing.T) {
+	file, err := os.Open(filepath.Join("..", "testdata", "dyn", "lib", "libversion.so.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	e, err := ParseELF(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, err := e.SymbolVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[SymbolVersion]bool{}
+	for i, sym := range e.DynamicSymbols {
+		if sym.Name == "get" {
+			got[versions[i]] = true
+		}
+	}
+	if len(got) != 2 || !got[SymbolVersion{Name: "V2"}] || !got[SymbolVersion{Name: "V1", Hidden: true}] {
+		t.Errorf("Versions of get = %v, want V2 and hidden V1", got)
+	}
+
+	// A program built without versioned libraries has no .gnu.version
+	file, err = os.Open(filepath.Join("..", "testdata", "dyn", "runpath"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if e, err = ParseELF(file); err != nil {
+		t.Fatal(err)
+	}
+	if versions, err := e.SymbolVersions(); versions != nil || err != nil {
+		t.Errorf("SymbolVersions of runpath = %v, %v, want none", versions, err)
+	}
+}
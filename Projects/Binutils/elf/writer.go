@@ -0,0 +1,417 @@
+package elf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxOutputSize bounds the size of an ELF image built by WriteELF
+const maxOutputSize = 1 << 32
+
+// IsDebug reports whether the section only carries debugging information
+func (s *Section) IsDebug() bool {
+	prefixes := []string{".debug", ".zdebug", ".gnu.debuglto_", ".stab", ".line"}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadSectionData reads the contents of every section that occupies file space.
+// Sections must be loaded before the file can be rewritten with WriteELF.
+func (e *ELF) LoadSectionData(r io.ReadSeeker) error {
+	for i := range e.Sections {
+		section := &e.Sections[i]
+		if section.Type == SHT_NULL || section.Type == SHT_NOBITS || section.Size == 0 {
+			continue
+		}
+
+		if section.reader == nil {
+			section.reader = r
+		}
+
+		// Secure: validate section size
+		data, err := section.ReadAll(100 * 1024 * 1024) // 100MB limit
+		if err != nil {
+			return err
+		}
+		section.Data = data
+	}
+
+	return nil
+}
+
+// AddSection appends a section and returns its index
+func (e *ELF) AddSection(s Section) int {
+	if len(e.Sections) == 0 {
+		// Index 0 is always the reserved null section
+		e.Sections = append(e.Sections, Section{})
+	}
+	if s.Type != SHT_NOBITS {
+		s.Size = uint64(len(s.Data))
+	}
+	s.added = true
+	e.Sections = append(e.Sections, s)
+	return len(e.Sections) - 1
+}
+
+// RemoveSections deletes every section for which remove returns true.
+// Relocation sections applying to a removed section are dropped with it, as
+// are section groups left with no members, and section indices stored in
+// sh_link, sh_info, e_shstrndx, the symbol tables and the member lists of
+// section groups are renumbered so the remaining file stays consistent.
+func (e *ELF) RemoveSections(remove func(s *Section) bool) {
+	if len(e.Sections) == 0 {
+		return
+	}
+
+	removed := make([]bool, len(e.Sections))
+	for i := 1; i < len(e.Sections); i++ {
+		removed[i] = remove(&e.Sections[i])
+	}
+
+	// A relocation section is meaningless once its target is gone
+	for i := 1; i < len(e.Sections); i++ {
+		section := &e.Sections[i]
+		if section.Type != SHT_REL && section.Type != SHT_RELA {
+			continue
+		}
+		if section.Info != 0 && int(section.Info) < len(removed) && removed[section.Info] {
+			removed[i] = true
+		}
+	}
+
+	// A COMDAT group with none of its members left would name nothing
+	endian := e.ByteOrder()
+	for i := 1; i < len(e.Sections); i++ {
+		section := &e.Sections[i]
+		if section.Type != SHT_GROUP || removed[i] || len(section.Data) < 4 {
+			continue
+		}
+		empty := true
+		for off := 4; off+4 <= len(section.Data); off += 4 {
+			member := endian.Uint32(section.Data[off:])
+			if int(member) < len(removed) && !removed[member] {
+				empty = false
+			}
+		}
+		removed[i] = empty
+	}
+
+	// Build the old index -> new index map; removed sections map to 0
+	indexMap := make([]uint32, len(e.Sections))
+	kept := make([]Section, 0, len(e.Sections))
+	for i, section := range e.Sections {
+		if removed[i] {
+			continue
+		}
+		indexMap[i] = uint32(len(kept))
+		kept = append(kept, section)
+	}
+
+	remap := func(index uint32) uint32 {
+		if int(index) < len(indexMap) {
+			return indexMap[index]
+		}
+		return 0
+	}
+
+	for i := range kept {
+		section := &kept[i]
+		section.Link = remap(section.Link)
+		if section.Type == SHT_REL || section.Type == SHT_RELA || section.Flags&SHF_INFO_LINK != 0 {
+			section.Info = remap(section.Info)
+		}
+		if section.Type == SHT_SYMTAB || section.Type == SHT_DYNSYM {
+			remapSymbolSections(section, e.Class, endian, remap)
+		}
+		if section.Type == SHT_GROUP {
+			remapGroupMembers(section, endian, remap)
+		}
+	}
+
+	for i := range e.Symbols {
+		if e.Symbols[i].Shndx != SHN_UNDEF && e.Symbols[i].Shndx < SHN_LORESERVE {
+			e.Symbols[i].Shndx = uint16(remap(uint32(e.Symbols[i].Shndx)))
+		}
+	}
+
+	e.Header.ShStrndx = uint16(remap(uint32(e.Header.ShStrndx)))
+	e.Sections = kept
+}
+
+// remapSymbolSections rewrites st_shndx of every symbol in a loaded symbol table
+func remapSymbolSections(section *Section, class string, endian binary.ByteOrder, remap func(uint32) uint32) {
+	entSize, shndxOff := 24, 6
+	if class == "ELF32" {
+		entSize, shndxOff = 16, 14
+	}
+
+	for off := 0; off+entSize <= len(section.Data); off += entSize {
+		field := section.Data[off+shndxOff : off+shndxOff+2]
+		shndx := endian.Uint16(field)
+		if shndx != SHN_UNDEF && shndx < SHN_LORESERVE {
+			endian.PutUint16(field, uint16(remap(uint32(shndx))))
+		}
+	}
+}
+
+// remapGroupMembers rewrites the member list of a loaded section group,
+// dropping the members that were removed. The flags word is kept as is.
+func remapGroupMembers(section *Section, endian binary.ByteOrder, remap func(uint32) uint32) {
+	if len(section.Data) < 4 {
+		return
+	}
+	data := append([]byte(nil), section.Data[:4]...)
+	for off := 4; off+4 <= len(section.Data); off += 4 {
+		if member := remap(endian.Uint32(section.Data[off:])); member != 0 {
+			word := make([]byte, 4)
+			endian.PutUint32(word, member)
+			data = append(data, word...)
+		}
+	}
+	section.Data = data
+	section.Size = uint64(len(data))
+}
+
+// WriteELF serializes e to w. Allocated sections that lie inside a segment
+// keep their file offsets so the program headers stay valid; all other
+// sections are laid out after them, followed by a regenerated .shstrtab and
+// the section header table.
+func WriteELF(w io.Writer, e *ELF) error {
+	is32 := e.Class == "ELF32"
+	endian := e.ByteOrder()
+
+	ehSize, phEntSize, shEntSize, wordAlign := uint64(64), uint64(56), uint64(64), uint64(8)
+	if is32 {
+		ehSize, phEntSize, shEntSize, wordAlign = 52, 32, 40, 4
+	}
+
+	sections := append([]Section(nil), e.Sections...)
+	if len(sections) > 0 && sections[0].Type != SHT_NULL {
+		return fmt.Errorf("section 0 must be SHT_NULL")
+	}
+
+	// Regenerate the section name string table
+	shstrndx := int(e.Header.ShStrndx)
+	if len(sections) > 0 && (shstrndx == 0 || shstrndx >= len(sections) || sections[shstrndx].Type != SHT_STRTAB) {
+		sections = append(sections, Section{Name: ".shstrtab", Type: SHT_STRTAB, AddrAlign: 1})
+		shstrndx = len(sections) - 1
+	}
+	nameOffsets := make([]uint32, len(sections))
+	if len(sections) > 0 {
+		shstrtab := []byte{0}
+		for i := 1; i < len(sections); i++ {
+			nameOffsets[i] = uint32(len(shstrtab))
+			shstrtab = append(shstrtab, sections[i].Name...)
+			shstrtab = append(shstrtab, 0)
+		}
+		sections[shstrndx].Data = shstrtab
+		sections[shstrndx].Size = uint64(len(shstrtab))
+	}
+
+	for i := 1; i < len(sections); i++ {
+		if sections[i].Type != SHT_NOBITS && uint64(len(sections[i].Data)) != sections[i].Size {
+			return fmt.Errorf("section %s: data not loaded (%d of %d bytes)",
+				sections[i].Name, len(sections[i].Data), sections[i].Size)
+		}
+	}
+
+	// Lay out the program headers and the segment contents first
+	phOff := uint64(0)
+	end := ehSize
+	if len(e.Segments) > 0 {
+		phOff = e.Header.PhOff64
+		if phOff < ehSize {
+			phOff = alignUp(ehSize, wordAlign)
+		}
+		end = max(end, phOff+uint64(len(e.Segments))*phEntSize)
+		for _, seg := range e.Segments {
+			end = max(end, seg.Offset+seg.FileSz)
+		}
+	}
+
+	offsets := make([]uint64, len(sections))
+	pinned := make([]bool, len(sections))
+	for i := 1; i < len(sections); i++ {
+		if isPinned(&sections[i], e.Segments) {
+			pinned[i] = true
+			offsets[i] = sections[i].Offset
+			end = max(end, offsets[i]+fileSize(&sections[i]))
+		}
+	}
+
+	for i := 1; i < len(sections); i++ {
+		if pinned[i] {
+			continue
+		}
+		align := sections[i].AddrAlign
+		if align == 0 {
+			align = 1
+		}
+		end = alignUp(end, align)
+		offsets[i] = end
+		end += fileSize(&sections[i])
+	}
+
+	shOff := uint64(0)
+	total := end
+	if len(sections) > 0 {
+		shOff = alignUp(end, wordAlign)
+		total = shOff + uint64(len(sections))*shEntSize
+	}
+
+	// Secure: refuse to build unreasonably large images
+	if total > maxOutputSize {
+		return fmt.Errorf("output too large: %d bytes", total)
+	}
+	if len(sections) > 0xff00 || len(e.Segments) > 0xffff {
+		return fmt.Errorf("too many sections or segments")
+	}
+
+	buf := make([]byte, total)
+
+	// ELF identification and header
+	copy(buf[0:4], []byte{0x7f, 'E', 'L', 'F'})
+	buf[4] = 2
+	if is32 {
+		buf[4] = 1
+	}
+	buf[5] = 1
+	if e.Header.Data == 2 {
+		buf[5] = 2
+	}
+	buf[6] = 1
+	buf[7] = e.Header.OSABI
+	buf[8] = e.Header.ABIVersion
+
+	version := e.Header.Version32
+	if version == 0 {
+		version = 1
+	}
+	endian.PutUint16(buf[16:18], e.Header.Type)
+	endian.PutUint16(buf[18:20], e.Header.Machine)
+	endian.PutUint32(buf[20:24], version)
+	if is32 {
+		endian.PutUint32(buf[24:28], uint32(e.Entry))
+		endian.PutUint32(buf[28:32], uint32(phOff))
+		endian.PutUint32(buf[32:36], uint32(shOff))
+		endian.PutUint32(buf[36:40], e.Header.Flags)
+		endian.PutUint16(buf[40:42], uint16(ehSize))
+		endian.PutUint16(buf[42:44], uint16(phEntSize))
+		endian.PutUint16(buf[44:46], uint16(len(e.Segments)))
+		endian.PutUint16(buf[46:48], uint16(shEntSize))
+		endian.PutUint16(buf[48:50], uint16(len(sections)))
+		endian.PutUint16(buf[50:52], uint16(shstrndx))
+	} else {
+		endian.PutUint64(buf[24:32], e.Entry)
+		endian.PutUint64(buf[32:40], phOff)
+		endian.PutUint64(buf[40:48], shOff)
+		endian.PutUint32(buf[48:52], e.Header.Flags)
+		endian.PutUint16(buf[52:54], uint16(ehSize))
+		endian.PutUint16(buf[54:56], uint16(phEntSize))
+		endian.PutUint16(buf[56:58], uint16(len(e.Segments)))
+		endian.PutUint16(buf[58:60], uint16(shEntSize))
+		endian.PutUint16(buf[60:62], uint16(len(sections)))
+		endian.PutUint16(buf[62:64], uint16(shstrndx))
+	}
+
+	// Program headers
+	for i, seg := range e.Segments {
+		ph := buf[phOff+uint64(i)*phEntSize:]
+		if is32 {
+			endian.PutUint32(ph[0:4], seg.Type)
+			endian.PutUint32(ph[4:8], uint32(seg.Offset))
+			endian.PutUint32(ph[8:12], uint32(seg.VAddr))
+			endian.PutUint32(ph[12:16], uint32(seg.PAddr))
+			endian.PutUint32(ph[16:20], uint32(seg.FileSz))
+			endian.PutUint32(ph[20:24], uint32(seg.MemSz))
+			endian.PutUint32(ph[24:28], seg.Flags)
+			endian.PutUint32(ph[28:32], uint32(seg.Align))
+		} else {
+			endian.PutUint32(ph[0:4], seg.Type)
+			endian.PutUint32(ph[4:8], seg.Flags)
+			endian.PutUint64(ph[8:16], seg.Offset)
+			endian.PutUint64(ph[16:24], seg.VAddr)
+			endian.PutUint64(ph[24:32], seg.PAddr)
+			endian.PutUint64(ph[32:40], seg.FileSz)
+			endian.PutUint64(ph[40:48], seg.MemSz)
+			endian.PutUint64(ph[48:56], seg.Align)
+		}
+	}
+
+	// Section contents and headers
+	for i := 1; i < len(sections); i++ {
+		section := &sections[i]
+		if section.Type != SHT_NOBITS {
+			copy(buf[offsets[i]:], section.Data)
+		}
+
+		sh := buf[shOff+uint64(i)*shEntSize:]
+		endian.PutUint32(sh[0:4], nameOffsets[i])
+		endian.PutUint32(sh[4:8], section.Type)
+		if is32 {
+			endian.PutUint32(sh[8:12], uint32(section.Flags))
+			endian.PutUint32(sh[12:16], uint32(section.Addr))
+			endian.PutUint32(sh[16:20], uint32(offsets[i]))
+			endian.PutUint32(sh[20:24], uint32(section.Size))
+			endian.PutUint32(sh[24:28], section.Link)
+			endian.PutUint32(sh[28:32], section.Info)
+			endian.PutUint32(sh[32:36], uint32(section.AddrAlign))
+			endian.PutUint32(sh[36:40], uint32(section.EntSize))
+		} else {
+			endian.PutUint64(sh[8:16], section.Flags)
+			endian.PutUint64(sh[16:24], section.Addr)
+			endian.PutUint64(sh[24:32], offsets[i])
+			endian.PutUint64(sh[32:40], section.Size)
+			endian.PutUint32(sh[40:44], section.Link)
+			endian.PutUint32(sh[44:48], section.Info)
+			endian.PutUint64(sh[48:56], section.AddrAlign)
+			endian.PutUint64(sh[56:64], section.EntSize)
+		}
+	}
+
+	if _, err := w.Write(buf); err != nil {
+		return fmt.Errorf("failed to write ELF: %w", err)
+	}
+
+	return nil
+}
+
+// isPinned reports whether an allocated section lies inside a segment and so
+// must keep its original file offset. A section made by AddSection has no
+// offset yet and is laid out after the pinned ones.
+func isPinned(s *Section, segments []Segment) bool {
+	if s.Flags&SHF_ALLOC == 0 || s.added {
+		return false
+	}
+	for _, seg := range segments {
+		if seg.Type == PT_NULL {
+			continue
+		}
+		if s.Offset >= seg.Offset && s.Offset+fileSize(s) <= seg.Offset+seg.FileSz {
+			return true
+		}
+	}
+	return false
+}
+
+// fileSize returns the number of bytes a section occupies in the file
+func fileSize(s *Section) uint64 {
+	if s.Type == SHT_NOBITS {
+		return 0
+	}
+	return uint64(len(s.Data))
+}
+
+// alignUp rounds v up to a multiple of align
+func alignUp(v, align uint64) uint64 {
+	if align <= 1 {
+		return v
+	}
+	return (v + align - 1) / align * align
+}
@@ -0,0 +1,181 @@
+package elf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+// symbolEntry encodes one little-endian ELF64 symbol
+func symbolEntry(name uint32, info byte, shndx uint16) []byte {
+	entry := make([]byte, 24)
+	binary.LittleEndian.PutUint32(entry[0:], name)
+	entry[4] = info
+	binary.LittleEndian.PutUint16(entry[6:], shndx)
+	return entry
+}
+
+// groupObject builds a relocatable ELF64 object with two COMDAT groups, as
+// g++ emits for inline functions: [1] holds .text.a and [2] holds .text.b
+// with its relocations. Group signatures are the global symbols a and b,
+// behind a local symbol and a section symbol.
+func groupObject() *ELF {
+	strtab := []byte("\x00tmp\x00a\x00b\x00")
+	symtab := slices.Concat(
+		make([]byte, 24),
+		symbolEntry(1, 0x00, 3), // tmp: STB_LOCAL, STT_NOTYPE in .text.a
+		symbolEntry(0, 0x03, 4), // STT_SECTION for .text.b
+		symbolEntry(5, 0x22, 3), // a: STB_WEAK, STT_FUNC
+		symbolEntry(7, 0x22, 4), // b: STB_WEAK, STT_FUNC
+	)
+	rela := make([]byte, 24)
+	binary.LittleEndian.PutUint64(rela[0:], 1)
+	binary.LittleEndian.PutUint64(rela[8:], 3<<32|R_X86_64_PLT32) // call a
+	binary.LittleEndian.PutUint64(rela[16:], ^uint64(3))          // -4
+
+	sections := []Section{
+		{},
+		{Name: ".group", Type: SHT_GROUP, Link: 6, Info: 3, AddrAlign: 4, EntSize: 4, Data: words(GRP_COMDAT, 3)},
+		{Name: ".group", Type: SHT_GROUP, Link: 6, Info: 4, AddrAlign: 4, EntSize: 4, Data: words(GRP_COMDAT, 4, 5)},
+		{Name: ".text.a", Type: SHT_PROGBITS, Flags: SHF_ALLOC | SHF_EXECINSTR | SHF_GROUP, AddrAlign: 1, Data: []byte{0xc3}},
+		{Name: ".text.b", Type: SHT_PROGBITS, Flags: SHF_ALLOC | SHF_EXECINSTR | SHF_GROUP, AddrAlign: 1, Data: []byte{0xe8, 0, 0, 0, 0, 0xc3}},
+		{Name: ".rela.text.b", Type: SHT_RELA, Flags: SHF_INFO_LINK | SHF_GROUP, Link: 6, Info: 4, AddrAlign: 8, EntSize: 24, Data: rela},
+		{Name: ".symtab", Type: SHT_SYMTAB, Link: 7, Info: 3, AddrAlign: 8, EntSize: 24, Data: symtab},
+		{Name: ".strtab", Type: SHT_STRTAB, AddrAlign: 1, Data: strtab},
+		{Name: ".shstrtab", Type: SHT_STRTAB, AddrAlign: 1},
+	}
+	for i := range sections {
+		sections[i].Size = uint64(len(sections[i].Data))
+	}
+	return &ELF{
+		Class:    "ELF64",
+		Header:   ELFHeader{Magic: [4]byte{0x7f, 'E', 'L', 'F'}, Class: 2, Data: 1, Version: 1, Type: 1, Machine: EM_X86_64, Version32: 1, ShStrndx: 8},
+		Sections: sections,
+	}
+}
+
+// roundTrip writes e and parses the result back with its section data
+func roundTrip(t *testing.T, e *ELF) *ELF {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteELF(&buf, e); err != nil {
+		t.Fatalf("WriteELF failed: %v", err)
+	}
+	out, err := ParseELF(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ParseELF of written file: %v", err)
+	}
+	if err := out.LoadSectionData(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("LoadSectionData of written file: %v", err)
+	}
+	if err := Validate(out); err != nil {
+		t.Errorf("Validate of written file: %v", err)
+	}
+	return out
+}
+
+// groupMembers returns the names of the members of a section group
+func groupMembers(e *ELF, group *Section) []string {
+	names := []string{}
+	for off := 4; off+4 <= len(group.Data); off += 4 {
+		member := binary.LittleEndian.Uint32(group.Data[off:])
+		if int(member) >= len(e.Sections) {
+			names = append(names, "?")
+			continue
+		}
+		names = append(names, e.Sections[member].Name)
+	}
+	return names
+}
+
+// groups returns the members of every section group, keyed by signature
+func groups(e *ELF) map[string][]string {
+	found := map[string][]string{}
+	for i := range e.Sections {
+		group := &e.Sections[i]
+		if group.Type != SHT_GROUP {
+			continue
+		}
+		signature := "?"
+		if int(group.Info) < len(e.Symbols) {
+			signature = e.Symbols[group.Info].Name
+		}
+		found[signature] = groupMembers(e, group)
+	}
+	return found
+}
+
+// TestWriteRoundTrip tests that a written object parses back to the same
+// sections, symbols and groups
+func TestWriteRoundTrip(t *testing.T) {
+	in := groupObject()
+	out := roundTrip(t, in)
+
+	if len(out.Sections) != len(in.Sections) {
+		t.Fatalf("%d sections, want %d", len(out.Sections), len(in.Sections))
+	}
+	for i := 1; i < len(in.Sections)-1; i++ { // .shstrtab is regenerated
+		want, got := &in.Sections[i], &out.Sections[i]
+		if got.Name != want.Name || got.Type != want.Type || got.Flags != want.Flags ||
+			got.Link != want.Link || got.Info != want.Info || !bytes.Equal(got.Data, want.Data) {
+			t.Errorf("Section %d = %+v, want %+v", i, got, want)
+		}
+	}
+	if got := groups(out); len(got) != 2 || !slices.Equal(got["a"], []string{".text.a"}) ||
+		!slices.Equal(got["b"], []string{".text.b", ".rela.text.b"}) {
+		t.Errorf("Groups = %v", got)
+	}
+}
+
+// TestRemoveSections tests that removing sections renumbers the member
+// lists of section groups and drops groups left empty
+func TestRemoveSections(t *testing.T) {
+	tests := []struct {
+		remove   string
+		sections []string
+		groups   map[string][]string
+	}{
+		{
+			// The group of a goes with its only member
+			remove:   ".text.a",
+			sections: []string{"", ".group", ".text.b", ".rela.text.b", ".symtab", ".strtab", ".shstrtab"},
+			groups:   map[string][]string{"b": {".text.b", ".rela.text.b"}},
+		},
+		{
+			// Relocations go with the section they apply to
+			remove:   ".text.b",
+			sections: []string{"", ".group", ".text.a", ".symtab", ".strtab", ".shstrtab"},
+			groups:   map[string][]string{"a": {".text.a"}},
+		},
+		{
+			remove:   ".rela.text.b",
+			sections: []string{"", ".group", ".group", ".text.a", ".text.b", ".symtab", ".strtab", ".shstrtab"},
+			groups:   map[string][]string{"a": {".text.a"}, "b": {".text.b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		e := groupObject()
+		e.RemoveSections(func(s *Section) bool { return s.Name == tt.remove })
+		out := roundTrip(t, e)
+
+		names := []string{}
+		for _, section := range out.Sections {
+			names = append(names, section.Name)
+		}
+		if !slices.Equal(names, tt.sections) {
+			t.Errorf("Remove %s: sections %q, want %q", tt.remove, names, tt.sections)
+		}
+		got := groups(out)
+		if len(got) != len(tt.groups) {
+			t.Errorf("Remove %s: groups %v, want %v", tt.remove, got, tt.groups)
+			continue
+		}
+		for signature, members := range tt.groups {
+			if !slices.Equal(got[signature], members) {
+				t.Errorf("Remove %s: group %s = %q, want %q", tt.remove, signature, got[signature], members)
+			}
+		}
+	}
+}
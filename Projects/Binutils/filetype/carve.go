@@ -0,0 +1,103 @@
+package filetype
+
+import (
+	"io"
+	"iter"
+	"slices"
+)
+
+// carveBlock is the bytes Carve scans per read
+const carveBlock = 64 * 1024
+
+// Found is a file found embedded in a larger one
+type Found struct {
+	Offset int64
+	Format *Format
+	Length int64 // Bytes from Offset the file takes, 0 if its format cannot tell
+}
+
+// Carve scans the first size bytes of r for the magic of every registered
+// format at every offset, yielding each match its format's check accepts,
+// in offset order. Files inside others are reported too: an ELF object in
+// an ar archive is found along with the archive. Only the header of each
+// candidate is read beyond the scan itself. Iteration stops after the
+// first read error, which is yielded.
+// Time Complexity: O(n * k) for n bytes and k formats sharing a first byte
+func (reg *Registry) Carve(r io.ReaderAt, size int64) iter.Seq2[Found, error] {
+	return func(yield func(Found, error) bool) {
+		// Index the formats by the first byte of each magic
+		var byFirst [256][]*Format
+		overlap := 0
+		for _, f := range reg.Formats() {
+			for _, magic := range f.Magic {
+				if !slices.Contains(byFirst[magic[0]], f) {
+					byFirst[magic[0]] = append(byFirst[magic[0]], f)
+				}
+				overlap = max(overlap, len(magic))
+			}
+		}
+
+		buf := make([]byte, carveBlock+overlap)
+		for base := int64(0); base < size; base += carveBlock {
+			// Read past the block so that a magic crossing its end is seen
+			n, err := r.ReadAt(buf[:min(int64(len(buf)), size-base)], base)
+			if err != nil && err != io.EOF {
+				yield(Found{}, err)
+				return
+			}
+			for i := range min(n, carveBlock) {
+				for _, f := range byFirst[buf[i]] {
+					found, ok, err := reg.carveAt(r, buf[i:n], base+int64(i), size, f)
+					if err != nil {
+						yield(Found{}, err)
+						return
+					}
+					if ok && !yield(found, nil) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// carveAt checks for a file of format f whose magic starts at pos, given
+// the bytes scanned from there
+func (reg *Registry) carveAt(r io.ReaderAt, scanned []byte, pos, size int64, f *Format) (Found, bool, error) {
+	start := pos - int64(f.MagicOffset)
+	if start < 0 {
+		return Found{}, false, nil
+	}
+	magicFound := false
+	for _, magic := range f.Magic {
+		if len(scanned) >= len(magic) && string(scanned[:len(magic)]) == magic {
+			magicFound = true
+			break
+		}
+	}
+	if !magicFound {
+		return Found{}, false, nil
+	}
+
+	header, err := readHeader(r, start)
+	if err != nil {
+		return Found{}, false, err
+	}
+	// Secure: the header read must not run past the bytes scanned
+	header = header[:min(int64(len(header)), size-start)]
+	if f.Check != nil && !f.Check(header) {
+		return Found{}, false, nil
+	}
+	found := Found{Offset: start, Format: f}
+	if f.Length != nil {
+		if length, ok := f.Length(r, start, size-start); ok {
+			found.Length = length
+		}
+	}
+	return found, true, nil
+}
+
+// Carve scans r for embedded files of the formats of the default registry
+func Carve(r io.ReaderAt, size int64) iter.Seq2[Found, error] {
+	return defaultRegistry.Carve(r, size)
+}
@@ -0,0 +1,117 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testELF64 returns a little-endian ELF64 file with one section of data
+// after its section header table
+func testELF64() []byte {
+	const shOff, shNum = 64, 2
+	data := make([]byte, 64+shNum*64+8)
+	copy(data, "\x7fELF\x02\x01\x01")
+	le := binary.LittleEndian
+	le.PutUint64(data[40:], shOff)
+	le.PutUint16(data[52:], 64)
+	le.PutUint16(data[58:], 64)
+	le.PutUint16(data[60:], shNum)
+	section := data[shOff+64:]
+	le.PutUint32(section[4:], 1)                    // SHT_PROGBITS
+	le.PutUint64(section[24:], uint64(len(data)-8)) // sh_offset
+	le.PutUint64(section[32:], 8)                   // sh_size
+	copy(data[len(data)-8:], "payload!")
+	return data
+}
+
+// testPNG returns a PNG file with a header and an end chunk
+func testPNG() []byte {
+	var b bytes.Buffer
+	b.WriteString("\x89PNG\r\n\x1a\n")
+	for _, chunk := range []struct{ kind, data string }{{"IHDR", strings.Repeat("\x00", 13)}, {"IEND", ""}} {
+		binary.Write(&b, binary.BigEndian, uint32(len(chunk.data)))
+		b.WriteString(chunk.kind + chunk.data + "CRC!")
+	}
+	return b.Bytes()
+}
+
+// testAr returns an ar archive holding the given members
+func testAr(members ...[]byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("!<arch>\n")
+	for i, data := range members {
+		fmt.Fprintf(&b, "%-16s%-12d%-6d%-6d%-8o%-10d`\n", fmt.Sprintf("m%d.o/", i), 0, 0, 0, 0644, len(data))
+		b.Write(data)
+		if len(data)%2 == 1 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.Bytes()
+}
+
+// TestCarve tests finding embedded files and their lengths
+func TestCarve(t *testing.T) {
+	elfFile, png := testELF64(), testPNG()
+	archive := testAr(elfFile, []byte("odd"))
+
+	var blob bytes.Buffer
+	blob.WriteString("junk MZ junk \x1f\x8b\x01 ")
+	offsets := map[string]int64{}
+	offsets["PNG"] = int64(blob.Len())
+	blob.Write(png)
+	// Put the archive across a block boundary
+	blob.Write(make([]byte, carveBlock-blob.Len()-20))
+	offsets["ar"] = int64(blob.Len())
+	blob.Write(archive)
+	blob.WriteString(" trailing")
+
+	var got []string
+	for found, err := range Carve(bytes.NewReader(blob.Bytes()), int64(blob.Len())) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%s@%d+%d", found.Format.Name, found.Offset, found.Length))
+	}
+	expected := []string{
+		fmt.Sprintf("PNG@%d+%d", offsets["PNG"], len(png)),
+		fmt.Sprintf("ar@%d+%d", offsets["ar"], len(archive)),
+		fmt.Sprintf("ELF@%d+%d", offsets["ar"]+8+60, len(elfFile)),
+	}
+	if strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("Carve found %v, want %v", got, expected)
+	}
+}
+
+// TestCarveTruncated tests that a file cut short is found without a length
+func TestCarveTruncated(t *testing.T) {
+	data := testELF64()
+	data = data[:len(data)-4]
+	var got []Found
+	for found, err := range Carve(bytes.NewReader(data), int64(len(data))) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, found)
+	}
+	if len(got) != 1 || got[0].Format.Name != "ELF" || got[0].Length != 0 {
+		t.Errorf("Carve of a truncated ELF = %+v", got)
+	}
+}
+
+// TestCarveStop tests that a range loop may stop early
+func TestCarveStop(t *testing.T) {
+	data := bytes.Repeat(testPNG(), 10)
+	count := 0
+	for range Carve(bytes.NewReader(data), int64(len(data))) {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("stopped after %d files, want 3", count)
+	}
+}
@@ -0,0 +1,167 @@
+// Package filetype identifies file formats by their magic numbers - the
+// fixed bytes every file of a format starts with - so that tools can
+// refuse input of the wrong kind with a useful message, or pick the parser
+// for what they were given. Formats live in a Registry; the built-in ones
+// (ELF, ar, tar, cpio, gzip, zip, PNG, PDF, Mach-O and PE) are in the
+// default registry, which the package-level functions use and Register
+// extends. Carve finds files embedded anywhere in a larger one, such as a
+// firmware image.
+package filetype
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// HeaderSize is the most leading bytes any format is checked against;
+// Detect given fewer may miss formats whose checks look further
+const HeaderSize = 512
+
+// ErrInvalidFormat is returned by Register for a format that cannot be
+// matched: no name, no magic, or a magic beyond HeaderSize
+var ErrInvalidFormat = errors.New("invalid format")
+
+// Format describes a file format and how to recognize it
+type Format struct {
+	Name string // Short name, e.g. "ELF" or "gzip"
+	MIME string // Media type, e.g. "application/gzip"
+
+	// Magic lists the byte strings the format may have at MagicOffset;
+	// one of them must match
+	Magic       []string
+	MagicOffset int
+
+	// Check, if set, further validates a header whose magic matched. It
+	// is given up to HeaderSize bytes from the start of the file and must
+	// not assume any more than the magic is present.
+	Check func(header []byte) bool
+
+	// Length, if set, returns the length of a file of the format starting
+	// at off in r, reading no more than limit bytes, or false if it cannot
+	// tell. Carve uses it to report the extent of what it finds.
+	Length func(r io.ReaderAt, off, limit int64) (int64, bool)
+}
+
+// String returns the name of the format
+func (f *Format) String() string { return f.Name }
+
+// matches reports whether a header starts with the format
+func (f *Format) matches(header []byte) bool {
+	for _, magic := range f.Magic {
+		end := f.MagicOffset + len(magic)
+		if end <= len(header) && string(header[f.MagicOffset:end]) == magic {
+			return f.Check == nil || f.Check(header)
+		}
+	}
+	return false
+}
+
+// Registry is an ordered set of formats. Detection tries them in the order
+// they were registered, so a format that refines another, sharing its
+// magic, must be registered first. A Registry is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	formats []*Format
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry { return &Registry{} }
+
+// Register adds a format, tried after those already registered. Names
+// must be unique.
+func (reg *Registry) Register(f Format) error {
+	if f.Name == "" || len(f.Magic) == 0 || f.MagicOffset < 0 {
+		return fmt.Errorf("%w: %q needs a name and a magic", ErrInvalidFormat, f.Name)
+	}
+	for _, magic := range f.Magic {
+		// Secure: every magic must fit the header Detect reads
+		if magic == "" || f.MagicOffset+len(magic) > HeaderSize {
+			return fmt.Errorf("%w: %q has a magic outside the first %d bytes", ErrInvalidFormat, f.Name, HeaderSize)
+		}
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for _, g := range reg.formats {
+		if g.Name == f.Name {
+			return fmt.Errorf("%w: %q is already registered", ErrInvalidFormat, f.Name)
+		}
+	}
+	f.Magic = append([]string(nil), f.Magic...)
+	reg.formats = append(reg.formats, &f)
+	return nil
+}
+
+// Formats returns the registered formats in detection order
+func (reg *Registry) Formats() []*Format {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return append([]*Format(nil), reg.formats...)
+}
+
+// Lookup returns the format with the given name, or nil
+func (reg *Registry) Lookup(name string) *Format {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	for _, f := range reg.formats {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Detect returns the format of a file from its leading bytes, or nil if
+// none matches
+// Time Complexity: O(f) magic comparisons for f formats
+func (reg *Registry) Detect(header []byte) *Format {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	for _, f := range reg.formats {
+		if f.matches(header) {
+			return f
+		}
+	}
+	return nil
+}
+
+// DetectReaderAt reads the header of r and returns its format, or nil if
+// none matches
+func (reg *Registry) DetectReaderAt(r io.ReaderAt) (*Format, error) {
+	header, err := readHeader(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	return reg.Detect(header), nil
+}
+
+// readHeader reads up to HeaderSize bytes at off, fewer at the end of r
+func readHeader(r io.ReaderAt, off int64) ([]byte, error) {
+	header := make([]byte, HeaderSize)
+	n, err := r.ReadAt(header, off)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return header[:n], nil
+}
+
+// defaultRegistry holds the built-in formats
+var defaultRegistry = newDefaultRegistry()
+
+// Register adds a format to the default registry
+func Register(f Format) error { return defaultRegistry.Register(f) }
+
+// Formats returns the formats of the default registry
+func Formats() []*Format { return defaultRegistry.Formats() }
+
+// Lookup returns the format of the default registry with the given name
+func Lookup(name string) *Format { return defaultRegistry.Lookup(name) }
+
+// Detect returns the format of a file from its leading bytes using the
+// default registry, or nil
+func Detect(header []byte) *Format { return defaultRegistry.Detect(header) }
+
+// DetectReaderAt returns the format of r using the default registry, or nil
+func DetectReaderAt(r io.ReaderAt) (*Format, error) { return defaultRegistry.DetectReaderAt(r) }
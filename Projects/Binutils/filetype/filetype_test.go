@@ -0,0 +1,114 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// peHeader returns an MZ header pointing at a PE signature
+func peHeader() []byte {
+	h := make([]byte, 0x88)
+	copy(h, "MZ")
+	binary.LittleEndian.PutUint32(h[0x3c:], 0x80)
+	copy(h[0x80:], "PE\x00\x00")
+	return h
+}
+
+// TestDetect tests the built-in formats and near misses
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		header []byte
+		want   string
+	}{
+		{[]byte("\x7fELF\x02\x01\x01\x00"), "ELF"},
+		{[]byte("\x7fELF\x07\x01"), ""},
+		{[]byte("\x7fELF"), "ELF"},
+		{[]byte("!<arch>\nfoo.o/"), "ar"},
+		{[]byte("!<thin>\n"), "ar"},
+		{append(make([]byte, 257), "ustar\x0000"...), "tar"},
+		{append(make([]byte, 257), "ustar  \x00"...), "tar"},
+		{[]byte("070701000000"), "cpio"},
+		{[]byte("\xcf\xfa\xed\xfe\x07\x00\x00\x01"), "Mach-O"},
+		{[]byte("\xfe\xed\xfa\xce"), "Mach-O"},
+		{[]byte("\xca\xfe\xba\xbe\x00\x00\x00\x02"), "Mach-O universal"},
+		{[]byte("\xca\xfe\xba\xbe\x00\x00\x00\x34"), ""}, // Java class file
+		{peHeader(), "PE"},
+		{append([]byte("MZ"), make([]byte, 0x80)...), ""}, // No PE signature
+		{[]byte("\x1f\x8b\x08\x00"), "gzip"},
+		{[]byte("\x1f\x8b\x07\x00"), ""},
+		{[]byte("PK\x03\x04\x14\x00"), "zip"},
+		{[]byte("\x89PNG\r\n\x1a\n"), "PNG"},
+		{[]byte("%PDF-1.7\n"), "PDF"},
+		{[]byte("%PDF-x.y"), ""},
+		{[]byte("plain text"), ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if f := Detect(tt.header); f != nil {
+			got = f.Name
+		}
+		if got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+
+	f, err := DetectReaderAt(bytes.NewReader([]byte("%PDF-2.0")))
+	if err != nil || f == nil || f.MIME != "application/pdf" {
+		t.Errorf("DetectReaderAt = %v, %v, want PDF", f, err)
+	}
+}
+
+// TestRegistry tests registering formats, their order and their validation
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	if reg.Detect([]byte("\x7fELF")) != nil {
+		t.Error("an empty registry detected a format")
+	}
+
+	// A refinement registered first wins over the format it refines
+	if err := reg.Register(Format{Name: "tiny", Magic: []string{"TINY"}, Check: func(h []byte) bool {
+		return len(h) >= 5 && h[4] == '!'
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Register(Format{Name: "any", Magic: []string{"TIN"}}); err != nil {
+		t.Fatal(err)
+	}
+	if f := reg.Detect([]byte("TINY!")); f == nil || f.Name != "tiny" {
+		t.Errorf("Detect(TINY!) = %v, want tiny", f)
+	}
+	if f := reg.Detect([]byte("TINY?")); f == nil || f.Name != "any" {
+		t.Errorf("Detect(TINY?) = %v, want any", f)
+	}
+
+	// Magic away from the start, as tar has
+	if err := reg.Register(Format{Name: "offset", Magic: []string{"ustar"}, MagicOffset: 257}); err != nil {
+		t.Fatal(err)
+	}
+	header := make([]byte, 512)
+	copy(header[257:], "ustar")
+	if f := reg.Detect(header); f == nil || f.Name != "offset" {
+		t.Errorf("Detect with a magic at 257 = %v", f)
+	}
+	if reg.Lookup("offset") == nil || len(reg.Formats()) != 3 {
+		t.Errorf("Lookup or Formats lost a format: %v", reg.Formats())
+	}
+
+	for _, bad := range []Format{
+		{Name: "", Magic: []string{"x"}},
+		{Name: "nomagic"},
+		{Name: "empty", Magic: []string{""}},
+		{Name: "far", Magic: []string{"x"}, MagicOffset: HeaderSize},
+		{Name: "any", Magic: []string{"dup"}},
+	} {
+		if err := reg.Register(bad); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("Register(%+v) = %v, want %v", bad, err, ErrInvalidFormat)
+		}
+	}
+	if err := Register(Format{Name: "ELF", Magic: []string{"\x7fELF"}}); err == nil {
+		t.Error("the default registry accepted a second ELF")
+	}
+}
@@ -0,0 +1,245 @@
+package filetype
+
+import (
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// newDefaultRegistry returns a registry of the built-in formats
+func newDefaultRegistry() *Registry {
+	reg := NewRegistry()
+	for _, f := range builtinFormats() {
+		if err := reg.Register(f); err != nil {
+			panic("filetype: " + err.Error())
+		}
+	}
+	return reg
+}
+
+// builtinFormats returns the formats the default registry starts with
+func builtinFormats() []Format {
+	return []Format{
+		{
+			Name: "ELF", MIME: "application/x-elf",
+			Magic: []string{"\x7fELF"},
+			Check: func(h []byte) bool {
+				// Class and byte order, when present, must be known values
+				return len(h) < 6 || (h[4] == 1 || h[4] == 2) && (h[5] == 1 || h[5] == 2)
+			},
+			Length: elfLength,
+		},
+		{
+			Name: "ar", MIME: "application/x-archive",
+			Magic:  []string{"!<arch>\n", "!<thin>\n"},
+			Length: arLength,
+		},
+		{
+			Name: "tar", MIME: "application/x-tar",
+			Magic:       []string{"ustar\x0000", "ustar  \x00"},
+			MagicOffset: 257,
+		},
+		{
+			Name: "cpio", MIME: "application/x-cpio",
+			Magic: []string{"070701", "070702", "070707"},
+		},
+		{
+			Name: "Mach-O", MIME: "application/x-mach-binary",
+			Magic: []string{"\xfe\xed\xfa\xce", "\xfe\xed\xfa\xcf", "\xce\xfa\xed\xfe", "\xcf\xfa\xed\xfe"},
+		},
+		{
+			Name: "Mach-O universal", MIME: "application/x-mach-binary",
+			Magic: []string{"\xca\xfe\xba\xbe"},
+			Check: func(h []byte) bool {
+				// Java class files share the magic; their version is at least 45
+				return len(h) < 8 || binary.BigEndian.Uint32(h[4:]) < 45
+			},
+		},
+		{
+			Name: "PE", MIME: "application/vnd.microsoft.portable-executable",
+			Magic: []string{"MZ"},
+			Check: checkPE,
+		},
+		{
+			Name: "gzip", MIME: "application/gzip",
+			Magic: []string{"\x1f\x8b"},
+			Check: func(h []byte) bool {
+				// Method 8 (deflate) is the only one defined; flag bits 5-7 are reserved
+				return len(h) < 4 || h[2] == 8 && h[3]&0xe0 == 0
+			},
+		},
+		{
+			Name: "zip", MIME: "application/zip",
+			Magic: []string{"PK\x03\x04", "PK\x05\x06", "PK\x07\x08"},
+		},
+		{
+			Name: "PNG", MIME: "image/png",
+			Magic:  []string{"\x89PNG\r\n\x1a\n"},
+			Length: pngLength,
+		},
+		{
+			Name: "PDF", MIME: "application/pdf",
+			Magic: []string{"%PDF-"},
+			Check: func(h []byte) bool {
+				return len(h) < 7 || (h[5] >= '1' && h[5] <= '9' && h[6] == '.')
+			},
+		},
+	}
+}
+
+// checkPE accepts an MZ header only if the PE signature it points to is
+// there, when the header reaches it; a bare MZ is two common bytes
+func checkPE(h []byte) bool {
+	if len(h) < 0x40 {
+		return true
+	}
+	peOffset := int64(binary.LittleEndian.Uint32(h[0x3c:]))
+	if peOffset+4 > int64(len(h)) {
+		return false
+	}
+	return string(h[peOffset:peOffset+4]) == "PE\x00\x00"
+}
+
+// readFull reads exactly len(p) bytes at off
+func readFull(r io.ReaderAt, p []byte, off int64) bool {
+	n, _ := r.ReadAt(p, off)
+	return n == len(p)
+}
+
+// elfLength returns the end of the furthest part of an ELF file: its
+// program and section header tables and the file contents of its segments
+// and sections
+func elfLength(r io.ReaderAt, off, limit int64) (int64, bool) {
+	h := make([]byte, 64)
+	if !readFull(r, h[:52], off) {
+		return 0, false
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if h[5] == 2 {
+		order = binary.BigEndian
+	}
+	is64 := h[4] == 2
+	if is64 && !readFull(r, h, off) {
+		return 0, false
+	}
+
+	var phOff, shOff uint64
+	var phEntSize, phNum, shEntSize, shNum uint16
+	end := uint64(52)
+	if is64 {
+		end = 64
+		phOff, shOff = order.Uint64(h[32:]), order.Uint64(h[40:])
+		phEntSize, phNum = order.Uint16(h[54:]), order.Uint16(h[56:])
+		shEntSize, shNum = order.Uint16(h[58:]), order.Uint16(h[60:])
+		if (phNum > 0 && phEntSize < 56) || (shNum > 0 && shEntSize < 64) {
+			return 0, false
+		}
+	} else {
+		phOff, shOff = uint64(order.Uint32(h[28:])), uint64(order.Uint32(h[32:]))
+		phEntSize, phNum = order.Uint16(h[42:]), order.Uint16(h[44:])
+		shEntSize, shNum = order.Uint16(h[46:]), order.Uint16(h[48:])
+		if (phNum > 0 && phEntSize < 32) || (shNum > 0 && shEntSize < 40) {
+			return 0, false
+		}
+	}
+
+	// table reads a header table, bounded by limit, and returns each
+	// entry's file range
+	table := func(tableOff uint64, entSize, num uint16, rangeOf func(e []byte) (uint64, uint64, bool)) bool {
+		size := uint64(entSize) * uint64(num)
+		// Secure: the table must lie within the bytes available
+		if num == 0 || tableOff > uint64(limit) || size > uint64(limit)-tableOff {
+			return num == 0
+		}
+		end = max(end, tableOff+size)
+		data := make([]byte, size)
+		if !readFull(r, data, off+int64(tableOff)) {
+			return false
+		}
+		for i := range uint64(num) {
+			start, length, ok := rangeOf(data[i*uint64(entSize):])
+			if !ok {
+				continue
+			}
+			// Secure: a part past the bytes available means a truncated file
+			if start > uint64(limit) || length > uint64(limit)-start {
+				return false
+			}
+			end = max(end, start+length)
+		}
+		return true
+	}
+	segment := func(e []byte) (uint64, uint64, bool) {
+		if is64 {
+			return order.Uint64(e[8:]), order.Uint64(e[32:]), true
+		}
+		return uint64(order.Uint32(e[4:])), uint64(order.Uint32(e[16:])), true
+	}
+	section := func(e []byte) (uint64, uint64, bool) {
+		const shtNobits = 8
+		if order.Uint32(e[4:]) == shtNobits {
+			return 0, 0, false
+		}
+		if is64 {
+			return order.Uint64(e[24:]), order.Uint64(e[32:]), true
+		}
+		return uint64(order.Uint32(e[16:])), uint64(order.Uint32(e[20:])), true
+	}
+	if !table(phOff, phEntSize, phNum, segment) || !table(shOff, shEntSize, shNum, section) {
+		return 0, false
+	}
+	return int64(end), true
+}
+
+// maxPNGChunks bounds the chunks walked in a PNG file
+const maxPNGChunks = 1 << 20
+
+// pngLength walks the chunks of a PNG file to the end of its IEND chunk
+func pngLength(r io.ReaderAt, off, limit int64) (int64, bool) {
+	pos := int64(8)
+	chunk := make([]byte, 8)
+	for range maxPNGChunks {
+		if !readFull(r, chunk, off+pos) {
+			return 0, false
+		}
+		length := int64(binary.BigEndian.Uint32(chunk))
+		// Secure: chunk lengths are at most 2^31-1
+		if length > 1<<31-1 {
+			return 0, false
+		}
+		pos += 12 + length // Length, type, data and CRC
+		if pos > limit {
+			return 0, false
+		}
+		if string(chunk[4:]) == "IEND" {
+			return pos, true
+		}
+	}
+	return 0, false
+}
+
+// arLength walks the member headers of an ar archive, which has no
+// trailer: it ends where the next header would not be valid
+func arLength(r io.ReaderAt, off, limit int64) (int64, bool) {
+	magic := make([]byte, 8)
+	if !readFull(r, magic, off) || string(magic) != "!<arch>\n" {
+		return 0, false // A thin archive's members are not inside it
+	}
+	pos := int64(8)
+	header := make([]byte, 60)
+	for pos < limit {
+		if !readFull(r, header, off+pos) || string(header[58:]) != "`\n" {
+			break
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil || size < 0 || size > limit-pos-60 {
+			break
+		}
+		pos += 60 + size
+		if pos%2 == 1 && pos < limit {
+			pos++ // Members are padded to even offsets
+		}
+	}
+	return pos, true
+}
@@ -0,0 +1,89 @@
+package macho
+
+// Header magic numbers, as read in the byte order of the file
+const (
+	MH_MAGIC    = 0xfeedface // 32-bit
+	MH_MAGIC_64 = 0xfeedfacf // 64-bit
+	FAT_MAGIC   = 0xcafebabe // Universal binary, always big-endian
+)
+
+// CPU types (cputype)
+const (
+	CPU_ARCH_ABI64 = 0x01000000
+
+	CPU_TYPE_X86       = 7
+	CPU_TYPE_X86_64    = CPU_TYPE_X86 | CPU_ARCH_ABI64
+	CPU_TYPE_ARM       = 12
+	CPU_TYPE_ARM64     = CPU_TYPE_ARM | CPU_ARCH_ABI64
+	CPU_TYPE_POWERPC   = 18
+	CPU_TYPE_POWERPC64 = CPU_TYPE_POWERPC | CPU_ARCH_ABI64
+)
+
+// File types (filetype)
+const (
+	MH_OBJECT      = 0x1
+	MH_EXECUTE     = 0x2
+	MH_CORE        = 0x4
+	MH_PRELOAD     = 0x5
+	MH_DYLIB       = 0x6
+	MH_DYLINKER    = 0x7
+	MH_BUNDLE      = 0x8
+	MH_DSYM        = 0xa
+	MH_KEXT_BUNDLE = 0xb
+)
+
+// Load command types (cmd)
+const (
+	LC_REQ_DYLD = 0x80000000
+
+	LC_SEGMENT         = 0x1
+	LC_SYMTAB          = 0x2
+	LC_UNIXTHREAD      = 0x5
+	LC_DYSYMTAB        = 0xb
+	LC_LOAD_DYLIB      = 0xc
+	LC_ID_DYLIB        = 0xd
+	LC_LOAD_DYLINKER   = 0xe
+	LC_SEGMENT_64      = 0x19
+	LC_UUID            = 0x1b
+	LC_LOAD_WEAK_DYLIB = 0x18 | LC_REQ_DYLD
+	LC_REEXPORT_DYLIB  = 0x1f | LC_REQ_DYLD
+	LC_MAIN            = 0x28 | LC_REQ_DYLD
+)
+
+// Section types (the low byte of the section flags)
+const (
+	SECTION_TYPE = 0x000000ff
+
+	S_REGULAR               = 0x0
+	S_ZEROFILL              = 0x1
+	S_CSTRING_LITERALS      = 0x2
+	S_GB_ZEROFILL           = 0xc
+	S_THREAD_LOCAL_ZEROFILL = 0x12
+)
+
+// Section attributes (the high bits of the section flags)
+const (
+	S_ATTR_PURE_INSTRUCTIONS = 0x80000000
+	S_ATTR_SOME_INSTRUCTIONS = 0x00000400
+)
+
+// Symbol type bits (n_type)
+const (
+	N_STAB = 0xe0 // Debugging entry if any of these bits is set
+	N_PEXT = 0x10 // Private external
+	N_TYPE = 0x0e // Mask for the type bits below
+	N_EXT  = 0x01 // External
+
+	N_UNDF = 0x0 // Undefined, or common when the value is non-zero
+	N_ABS  = 0x2 // Absolute
+	N_SECT = 0xe // Defined in section n_sect
+	N_PBUD = 0xc // Prebound undefined
+	N_INDR = 0xa // Indirect
+)
+
+// Thread state flavors used by LC_UNIXTHREAD
+const (
+	x86ThreadState32 = 1
+	x86ThreadState64 = 4
+	armThreadState64 = 6
+)
@@ -0,0 +1,446 @@
+// Package macho parses Mach-O object files, executables and dynamic
+// libraries: the header, segments and sections, the symbol table and the
+// libraries a file links against.
+package macho
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// MachO represents a Mach-O file
+type MachO struct {
+	Class     string // "MachO32" or "MachO64"
+	Data      string
+	Type      string
+	Machine   string
+	Entry     uint64 // Entry point address, 0 if the file has none
+	Header    FileHeader
+	Segments  []Segment
+	Sections  []Section
+	Symbols   []Symbol
+	Libraries []string // Dylibs named by LC_LOAD_DYLIB and related commands
+
+	order binary.ByteOrder
+}
+
+// FileHeader represents the Mach-O header
+type FileHeader struct {
+	Magic      uint32
+	CPUType    uint32
+	CPUSubtype uint32
+	FileType   uint32
+	NCmds      uint32
+	SizeOfCmds uint32
+	Flags      uint32
+}
+
+// Segment represents an LC_SEGMENT or LC_SEGMENT_64 load command
+type Segment struct {
+	Name     string
+	VMAddr   uint64
+	VMSize   uint64
+	FileOff  uint64
+	FileSize uint64
+	MaxProt  uint32
+	InitProt uint32
+	NSects   uint32
+	Flags    uint32
+}
+
+// Section represents a section of a segment. Sections are numbered from 1
+// across all segments, in load command order, as n_sect counts them.
+type Section struct {
+	Name    string
+	Segment string
+	Addr    uint64
+	Size    uint64
+	Offset  uint32
+	Align   uint32 // Power of two
+	RelOff  uint32
+	NReloc  uint32
+	Flags   uint32
+
+	reader io.ReadSeeker // File the section was parsed from
+}
+
+// Symbol represents a symbol table (nlist) entry
+type Symbol struct {
+	Name  string
+	Type  uint8
+	Sect  uint8 // Section number, 0 if the symbol is not in a section
+	Desc  uint16
+	Value uint64
+}
+
+// Limits on the sizes read from headers
+const (
+	maxLoadCommands = 0x10000
+	maxCommandsSize = 16 * 1024 * 1024
+	maxSymbols      = 10000000
+	maxStringTable  = 256 * 1024 * 1024
+)
+
+// ParseMachO parses a Mach-O file. Universal (fat) binaries are rejected;
+// each architecture slice is itself a Mach-O file.
+func ParseMachO(r io.ReadSeeker) (*MachO, error) {
+	m := &MachO{}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	headerBytes := make([]byte, 32)
+	n, err := io.ReadFull(r, headerBytes)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if n < 4 {
+		return nil, fmt.Errorf("failed to read magic: file too short")
+	}
+
+	// The magic number tells both the word size and the byte order
+	headerSize := 28
+	switch magic := binary.BigEndian.Uint32(headerBytes); magic {
+	case MH_MAGIC, MH_MAGIC_64:
+		m.order, m.Data = binary.BigEndian, "Big Endian"
+	case FAT_MAGIC:
+		return nil, fmt.Errorf("universal (fat) Mach-O binaries are not supported")
+	default:
+		m.order, m.Data = binary.LittleEndian, "Little Endian"
+		if magic = binary.LittleEndian.Uint32(headerBytes); magic != MH_MAGIC && magic != MH_MAGIC_64 {
+			return nil, fmt.Errorf("invalid Mach-O magic")
+		}
+	}
+
+	header := &m.Header
+	header.Magic = m.order.Uint32(headerBytes[0:4])
+	m.Class = "MachO32"
+	if header.Magic == MH_MAGIC_64 {
+		m.Class = "MachO64"
+		headerSize = 32 // Adds a reserved word
+	}
+	if n < headerSize {
+		return nil, fmt.Errorf("failed to read header: truncated %s header", m.Class)
+	}
+	header.CPUType = m.order.Uint32(headerBytes[4:8])
+	header.CPUSubtype = m.order.Uint32(headerBytes[8:12])
+	header.FileType = m.order.Uint32(headerBytes[12:16])
+	header.NCmds = m.order.Uint32(headerBytes[16:20])
+	header.SizeOfCmds = m.order.Uint32(headerBytes[20:24])
+	header.Flags = m.order.Uint32(headerBytes[24:28])
+
+	m.Type = GetFileType(header.FileType)
+	m.Machine = GetCPUType(header.CPUType)
+
+	if err := parseLoadCommands(r, m, int64(headerSize)); err != nil {
+		return nil, fmt.Errorf("failed to parse load commands: %w", err)
+	}
+
+	return m, nil
+}
+
+// ByteOrder returns the byte order of the file
+func (m *MachO) ByteOrder() binary.ByteOrder {
+	return m.order
+}
+
+// parseLoadCommands walks the load commands following the header
+func parseLoadCommands(r io.ReadSeeker, m *MachO, offset int64) error {
+	// Secure: validate command count and size
+	if m.Header.NCmds > maxLoadCommands {
+		return fmt.Errorf("invalid load command count: %d", m.Header.NCmds)
+	}
+	if m.Header.SizeOfCmds > maxCommandsSize {
+		return fmt.Errorf("invalid load commands size: %d", m.Header.SizeOfCmds)
+	}
+
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to load commands: %w", err)
+	}
+	commands := make([]byte, m.Header.SizeOfCmds)
+	if _, err := io.ReadFull(r, commands); err != nil {
+		return fmt.Errorf("failed to read load commands: %w", err)
+	}
+
+	var textAddr, entryOff uint64
+	hasMain := false
+	for i := uint32(0); i < m.Header.NCmds; i++ {
+		// Secure: every command must fit in the commands area
+		if len(commands) < 8 {
+			return fmt.Errorf("load command %d truncated", i)
+		}
+		cmd := m.order.Uint32(commands[0:4])
+		size := m.order.Uint32(commands[4:8])
+		if size < 8 || uint64(size) > uint64(len(commands)) {
+			return fmt.Errorf("invalid size %d for load command %d", size, i)
+		}
+		data := commands[:size]
+		commands = commands[size:]
+
+		switch cmd {
+		case LC_SEGMENT, LC_SEGMENT_64:
+			segment, err := parseSegment(r, m, data, cmd == LC_SEGMENT_64)
+			if err != nil {
+				return err
+			}
+			if segment.Name == "__TEXT" {
+				textAddr = segment.VMAddr
+			}
+		case LC_SYMTAB:
+			if len(data) < 24 {
+				return fmt.Errorf("LC_SYMTAB command truncated")
+			}
+			symbols, err := readSymbols(r, m,
+				m.order.Uint32(data[8:12]), m.order.Uint32(data[12:16]),
+				m.order.Uint32(data[16:20]), m.order.Uint32(data[20:24]))
+			if err != nil {
+				return fmt.Errorf("failed to read symbols: %w", err)
+			}
+			m.Symbols = symbols
+		case LC_MAIN:
+			if len(data) < 24 {
+				return fmt.Errorf("LC_MAIN command truncated")
+			}
+			entryOff, hasMain = m.order.Uint64(data[8:16]), true
+		case LC_UNIXTHREAD:
+			m.Entry = threadEntry(m, data)
+		case LC_LOAD_DYLIB, LC_LOAD_WEAK_DYLIB, LC_REEXPORT_DYLIB:
+			if len(data) < 12 {
+				return fmt.Errorf("dylib command truncated")
+			}
+			nameOff := m.order.Uint32(data[8:12])
+			if nameOff < 12 || nameOff >= size {
+				return fmt.Errorf("invalid dylib name offset: %d", nameOff)
+			}
+			m.Libraries = append(m.Libraries, cString(data[nameOff:]))
+		}
+	}
+
+	// LC_MAIN gives the entry point as an offset into __TEXT
+	if hasMain {
+		m.Entry = textAddr + entryOff
+	}
+	return nil
+}
+
+// parseSegment parses a segment command and the section headers that
+// follow it
+func parseSegment(r io.ReadSeeker, m *MachO, data []byte, is64 bool) (Segment, error) {
+	var segment Segment
+	headerSize, sectionSize := 56, 68
+	if is64 {
+		headerSize, sectionSize = 72, 80
+	}
+	if len(data) < headerSize {
+		return segment, fmt.Errorf("segment command truncated")
+	}
+
+	segment.Name = cString(data[8:24])
+	if is64 {
+		segment.VMAddr = m.order.Uint64(data[24:32])
+		segment.VMSize = m.order.Uint64(data[32:40])
+		segment.FileOff = m.order.Uint64(data[40:48])
+		segment.FileSize = m.order.Uint64(data[48:56])
+	} else {
+		segment.VMAddr = uint64(m.order.Uint32(data[24:28]))
+		segment.VMSize = uint64(m.order.Uint32(data[28:32]))
+		segment.FileOff = uint64(m.order.Uint32(data[32:36]))
+		segment.FileSize = uint64(m.order.Uint32(data[36:40]))
+	}
+	fields := data[headerSize-16:]
+	segment.MaxProt = m.order.Uint32(fields[0:4])
+	segment.InitProt = m.order.Uint32(fields[4:8])
+	segment.NSects = m.order.Uint32(fields[8:12])
+	segment.Flags = m.order.Uint32(fields[12:16])
+
+	// Secure: the section headers must fit in the command
+	if uint64(segment.NSects)*uint64(sectionSize) > uint64(len(data)-headerSize) {
+		return segment, fmt.Errorf("invalid section count %d for segment %s", segment.NSects, segment.Name)
+	}
+
+	for i := 0; i < int(segment.NSects); i++ {
+		sh := data[headerSize+i*sectionSize : headerSize+(i+1)*sectionSize]
+		section := Section{
+			Name:    cString(sh[0:16]),
+			Segment: cString(sh[16:32]),
+			reader:  r,
+		}
+		fields := sh[40:]
+		if is64 {
+			section.Addr = m.order.Uint64(sh[32:40])
+			section.Size = m.order.Uint64(sh[40:48])
+			fields = sh[48:]
+		} else {
+			section.Addr = uint64(m.order.Uint32(sh[32:36]))
+			section.Size = uint64(m.order.Uint32(sh[36:40]))
+		}
+		section.Offset = m.order.Uint32(fields[0:4])
+		section.Align = m.order.Uint32(fields[4:8])
+		section.RelOff = m.order.Uint32(fields[8:12])
+		section.NReloc = m.order.Uint32(fields[12:16])
+		section.Flags = m.order.Uint32(fields[16:20])
+		m.Sections = append(m.Sections, section)
+	}
+
+	m.Segments = append(m.Segments, segment)
+	return segment, nil
+}
+
+// readSymbols reads the nlist entries and names of an LC_SYMTAB command
+func readSymbols(r io.ReadSeeker, m *MachO, symOff, nsyms, strOff, strSize uint32) ([]Symbol, error) {
+	// Secure: validate symbol count and string table size
+	if nsyms > maxSymbols {
+		return nil, fmt.Errorf("invalid symbol count: %d", nsyms)
+	}
+	if strSize > maxStringTable {
+		return nil, fmt.Errorf("invalid string table size: %d", strSize)
+	}
+	if nsyms == 0 {
+		return nil, nil
+	}
+
+	entSize := 12
+	if m.Class == "MachO64" {
+		entSize = 16
+	}
+	table := make([]byte, int(nsyms)*entSize)
+	if _, err := r.Seek(int64(symOff), io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to symbol table: %w", err)
+	}
+	if _, err := io.ReadFull(r, table); err != nil {
+		return nil, fmt.Errorf("failed to read symbol table: %w", err)
+	}
+
+	strtab := make([]byte, strSize)
+	if _, err := r.Seek(int64(strOff), io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to string table: %w", err)
+	}
+	if _, err := io.ReadFull(r, strtab); err != nil {
+		return nil, fmt.Errorf("failed to read string table: %w", err)
+	}
+
+	symbols := make([]Symbol, nsyms)
+	for i := range symbols {
+		entry := table[i*entSize : (i+1)*entSize]
+		sym := Symbol{
+			Type: entry[4],
+			Sect: entry[5],
+			Desc: m.order.Uint16(entry[6:8]),
+		}
+		if entSize == 16 {
+			sym.Value = m.order.Uint64(entry[8:16])
+		} else {
+			sym.Value = uint64(m.order.Uint32(entry[8:12]))
+		}
+		// Secure: names outside the string table are left empty
+		if strx := m.order.Uint32(entry[0:4]); strx < strSize {
+			sym.Name = cString(strtab[strx:])
+		}
+		symbols[i] = sym
+	}
+	return symbols, nil
+}
+
+// threadEntry returns the initial program counter of an LC_UNIXTHREAD
+// command, or 0 for thread state flavors it does not know
+func threadEntry(m *MachO, data []byte) uint64 {
+	if len(data) < 16 {
+		return 0
+	}
+	flavor := m.order.Uint32(data[8:12])
+	state := data[16:]
+	switch {
+	case flavor == x86ThreadState64 && m.Header.CPUType == CPU_TYPE_X86_64 && len(state) >= 17*8:
+		return m.order.Uint64(state[16*8:]) // rip follows 16 general registers
+	case flavor == armThreadState64 && m.Header.CPUType == CPU_TYPE_ARM64 && len(state) >= 33*8:
+		return m.order.Uint64(state[32*8:]) // pc follows x0-x28, fp, lr and sp
+	case flavor == x86ThreadState32 && m.Header.CPUType == CPU_TYPE_X86 && len(state) >= 11*4:
+		return uint64(m.order.Uint32(state[10*4:])) // eip
+	}
+	return 0
+}
+
+// ReadAll reads the contents of the section. limit bounds the section size
+// so that corrupt headers cannot force huge allocations. Zero-fill sections
+// have no file contents and read as nil.
+func (s *Section) ReadAll(limit uint64) ([]byte, error) {
+	if s.IsZeroFill() {
+		return nil, nil
+	}
+	if s.reader == nil {
+		return nil, fmt.Errorf("section %s has no data source", s.Name)
+	}
+
+	// Secure: validate section size
+	if s.Size > limit {
+		return nil, fmt.Errorf("section %s too large: %d", s.Name, s.Size)
+	}
+
+	data := make([]byte, s.Size)
+	if _, err := s.reader.Seek(int64(s.Offset), io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to section %s: %w", s.Name, err)
+	}
+	if _, err := io.ReadFull(s.reader, data); err != nil {
+		return nil, fmt.Errorf("failed to read section %s: %w", s.Name, err)
+	}
+	return data, nil
+}
+
+// IsZeroFill reports whether the section occupies no space in the file
+func (s *Section) IsZeroFill() bool {
+	switch s.Flags & SECTION_TYPE {
+	case S_ZEROFILL, S_GB_ZEROFILL, S_THREAD_LOCAL_ZEROFILL:
+		return true
+	}
+	return false
+}
+
+// IsCode reports whether the section holds instructions
+func (s *Section) IsCode() bool {
+	return s.Flags&(S_ATTR_PURE_INSTRUCTIONS|S_ATTR_SOME_INSTRUCTIONS) != 0
+}
+
+// cString returns the NUL-terminated string at the start of data
+func cString(data []byte) string {
+	if end := bytes.IndexByte(data, 0); end >= 0 {
+		return string(data[:end])
+	}
+	return string(data)
+}
+
+// GetFileType returns the Mach-O file type name
+func GetFileType(t uint32) string {
+	types := map[uint32]string{
+		MH_OBJECT:      "MH_OBJECT",
+		MH_EXECUTE:     "MH_EXECUTE",
+		MH_CORE:        "MH_CORE",
+		MH_PRELOAD:     "MH_PRELOAD",
+		MH_DYLIB:       "MH_DYLIB",
+		MH_DYLINKER:    "MH_DYLINKER",
+		MH_BUNDLE:      "MH_BUNDLE",
+		MH_DSYM:        "MH_DSYM",
+		MH_KEXT_BUNDLE: "MH_KEXT_BUNDLE",
+	}
+	if name, ok := types[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("MH_UNKNOWN(%d)", t)
+}
+
+// GetCPUType returns the CPU type name
+func GetCPUType(c uint32) string {
+	cpus := map[uint32]string{
+		CPU_TYPE_X86:       "CPU_TYPE_X86",
+		CPU_TYPE_X86_64:    "CPU_TYPE_X86_64",
+		CPU_TYPE_ARM:       "CPU_TYPE_ARM",
+		CPU_TYPE_ARM64:     "CPU_TYPE_ARM64",
+		CPU_TYPE_POWERPC:   "CPU_TYPE_POWERPC",
+		CPU_TYPE_POWERPC64: "CPU_TYPE_POWERPC64",
+	}
+	if name, ok := cpus[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("CPU_TYPE_UNKNOWN(0x%x)", c)
+}
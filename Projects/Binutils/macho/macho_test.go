@@ -0,0 +1,136 @@
+package macho
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// buildObject builds a little-endian 64-bit Mach-O object with a __TEXT
+// segment holding __text and __bss, an LC_MAIN command and a symbol table
+func buildObject() []byte {
+	le := binary.LittleEndian
+	code := []byte{0xc3} // ret
+	strtab := []byte("\x00_main\x00_ext\x00")
+
+	// Header, segment with two sections, LC_MAIN and LC_SYMTAB
+	segSize := 72 + 2*80
+	cmdsSize := segSize + 24 + 24
+	codeOff := 32 + cmdsSize
+	symOff := codeOff + 8
+	strOff := symOff + 2*16
+
+	b := make([]byte, strOff+len(strtab))
+	le.PutUint32(b[0:], MH_MAGIC_64)
+	le.PutUint32(b[4:], CPU_TYPE_X86_64)
+	le.PutUint32(b[12:], MH_EXECUTE)
+	le.PutUint32(b[16:], 3)
+	le.PutUint32(b[20:], uint32(cmdsSize))
+
+	cmd := b[32:]
+	le.PutUint32(cmd[0:], LC_SEGMENT_64)
+	le.PutUint32(cmd[4:], uint32(segSize))
+	copy(cmd[8:], "__TEXT")
+	le.PutUint64(cmd[24:], 0x100000000)
+	le.PutUint64(cmd[32:], 0x2000)
+	le.PutUint32(cmd[64:], 2)
+	text := cmd[72:]
+	copy(text[0:], "__text")
+	copy(text[16:], "__TEXT")
+	le.PutUint64(text[32:], 0x100000000+uint64(codeOff))
+	le.PutUint64(text[40:], uint64(len(code)))
+	le.PutUint32(text[48:], uint32(codeOff))
+	le.PutUint32(text[64:], S_ATTR_PURE_INSTRUCTIONS)
+	bss := cmd[152:]
+	copy(bss[0:], "__bss")
+	copy(bss[16:], "__TEXT")
+	le.PutUint64(bss[32:], 0x100001000)
+	le.PutUint64(bss[40:], 0x100)
+	le.PutUint32(bss[64:], S_ZEROFILL)
+
+	main := cmd[segSize:]
+	le.PutUint32(main[0:], LC_MAIN)
+	le.PutUint32(main[4:], 24)
+	le.PutUint64(main[8:], uint64(codeOff))
+
+	symtab := cmd[segSize+24:]
+	le.PutUint32(symtab[0:], LC_SYMTAB)
+	le.PutUint32(symtab[4:], 24)
+	le.PutUint32(symtab[8:], uint32(symOff))
+	le.PutUint32(symtab[12:], 2)
+	le.PutUint32(symtab[16:], uint32(strOff))
+	le.PutUint32(symtab[20:], uint32(len(strtab)))
+
+	copy(b[codeOff:], code)
+	sym := b[symOff:]
+	le.PutUint32(sym[0:], 1)
+	sym[4], sym[5] = N_SECT|N_EXT, 1
+	le.PutUint64(sym[8:], 0x100000000+uint64(codeOff))
+	le.PutUint32(sym[16:], 7)
+	sym[20] = N_UNDF | N_EXT
+	copy(b[strOff:], strtab)
+	return b
+}
+
+// TestParseMachO tests parsing of the header, sections, entry point and
+// symbols
+func TestParseMachO(t *testing.T) {
+	data := buildObject()
+	m, err := ParseMachO(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseMachO failed: %v", err)
+	}
+
+	if m.Class != "MachO64" || m.Type != "MH_EXECUTE" || m.Machine != "CPU_TYPE_X86_64" {
+		t.Errorf("Unexpected header: %s %s %s", m.Class, m.Type, m.Machine)
+	}
+	if len(m.Segments) != 1 || m.Segments[0].Name != "__TEXT" || len(m.Sections) != 2 {
+		t.Fatalf("Unexpected segments %v and sections %v", m.Segments, m.Sections)
+	}
+	if want := uint64(0x100000000 + 32 + 72 + 160 + 48); m.Entry != want {
+		t.Errorf("Entry = %#x, want %#x", m.Entry, want)
+	}
+
+	text := &m.Sections[0]
+	if !text.IsCode() || text.IsZeroFill() {
+		t.Errorf("__text flags misclassified: %#x", text.Flags)
+	}
+	if contents, err := text.ReadAll(1024); err != nil || !bytes.Equal(contents, []byte{0xc3}) {
+		t.Errorf("__text contents = %x, %v", contents, err)
+	}
+	if !m.Sections[1].IsZeroFill() {
+		t.Errorf("__bss should be zero-fill")
+	}
+	if _, err := text.ReadAll(0); err == nil {
+		t.Errorf("Expected error for a section over the limit")
+	}
+
+	if len(m.Symbols) != 2 || m.Symbols[0].Name != "_main" || m.Symbols[0].Sect != 1 ||
+		m.Symbols[1].Name != "_ext" || m.Symbols[1].Type&N_TYPE != N_UNDF {
+		t.Errorf("Unexpected symbols: %+v", m.Symbols)
+	}
+}
+
+// TestParseMachOInvalid tests that malformed files are rejected
+func TestParseMachOInvalid(t *testing.T) {
+	if _, err := ParseMachO(bytes.NewReader([]byte("\x7fELF1234"))); err == nil {
+		t.Error("Expected error for bad magic")
+	}
+	if _, err := ParseMachO(bytes.NewReader([]byte{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 1})); err == nil {
+		t.Error("Expected error for a universal binary")
+	}
+
+	// A load command larger than the commands area
+	data := buildObject()
+	binary.LittleEndian.PutUint32(data[32+4:], 0x10000)
+	if _, err := ParseMachO(bytes.NewReader(data)); err == nil {
+		t.Error("Expected error for an oversized load command")
+	}
+
+	// A section count that does not fit in the segment command
+	data = buildObject()
+	binary.LittleEndian.PutUint32(data[32+64:], 100)
+	if _, err := ParseMachO(bytes.NewReader(data)); err == nil {
+		t.Error("Expected error for too many sections")
+	}
+}
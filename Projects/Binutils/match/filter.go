@@ -0,0 +1,50 @@
+package match
+
+import "strings"
+
+// Filter selects names by a list of patterns, as objcopy selects sections
+// for -j and -R: a name is selected if it matches a pattern and no pattern
+// starting with !, which excludes what it matches whatever its position in
+// the list. An empty Filter selects nothing.
+type Filter struct {
+	include []*Pattern
+	exclude []*Pattern
+}
+
+// NewFilter compiles a list of patterns, each of which may start with ! to
+// exclude the names it matches
+func NewFilter(patterns ...string) (*Filter, error) {
+	f := &Filter{}
+	for _, pattern := range patterns {
+		list := &f.include
+		if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+			pattern, list = rest, &f.exclude
+		}
+		p, err := Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		*list = append(*list, p)
+	}
+	return f, nil
+}
+
+// Match reports whether the filter selects name
+func (f *Filter) Match(name string) bool {
+	return matchAny(f.include, name) && !matchAny(f.exclude, name)
+}
+
+// Empty reports whether the filter has no patterns
+func (f *Filter) Empty() bool {
+	return len(f.include) == 0 && len(f.exclude) == 0
+}
+
+// matchAny reports whether name matches any of patterns
+func matchAny(patterns []*Pattern, name string) bool {
+	for _, p := range patterns {
+		if p.Match(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+// Package match implements shell-style glob patterns over names and
+// slash-separated paths, and gitignore rules built on them. Tools use it to
+// select archive members (ar x lib.a 'lib*.o'), sections (objcopy -j
+// '.text.*') and files to walk past.
+//
+// In a pattern, * matches any run of characters other than /, ? matches
+// one such character, and [...] matches one character of a class: [abc],
+// [a-z], or [!a-z] and [^a-z] for its complement. A backslash makes the
+// next character literal. ** as a whole path component matches zero or
+// more components, so a/**/b matches a/b and a/x/y/b; elsewhere it is the
+// same as *. Wildcards match a leading dot like any other character.
+package match
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrBadPattern is returned for a malformed pattern: an unterminated class
+// or a trailing backslash
+var ErrBadPattern = errors.New("syntax error in pattern")
+
+// elemKind is the kind of one element of a path component pattern
+type elemKind uint8
+
+const (
+	elemLiteral elemKind = iota // One given character
+	elemAny                     // ?, any one character
+	elemStar                    // *, any run of characters
+	elemClass                   // [...], one character of a class
+)
+
+// elem is one element of a path component pattern
+type elem struct {
+	kind    elemKind
+	r       rune   // Of a literal
+	ranges  []rune // Of a class: inclusive ranges lo, hi, ...
+	negated bool   // Of a class
+}
+
+// matches reports whether a non-star element matches r
+func (e *elem) matches(r rune) bool {
+	switch e.kind {
+	case elemLiteral:
+		return e.r == r
+	case elemAny:
+		return true
+	case elemClass:
+		for i := 0; i < len(e.ranges); i += 2 {
+			if e.ranges[i] <= r && r <= e.ranges[i+1] {
+				return !e.negated
+			}
+		}
+		return e.negated
+	}
+	return false
+}
+
+// segment is the pattern of one path component, or ** (globstar)
+type segment struct {
+	globstar bool
+	elems    []elem
+}
+
+// Pattern is a compiled glob pattern. It is safe for concurrent use.
+type Pattern struct {
+	src      string
+	segments []segment
+	literal  bool // No wildcards: matches only src itself
+}
+
+// Compile parses a glob pattern
+func Compile(pattern string) (*Pattern, error) {
+	p := &Pattern{src: pattern, literal: !HasMeta(pattern)}
+	if p.literal {
+		return p, nil
+	}
+	for _, part := range strings.Split(pattern, "/") {
+		if part == "**" {
+			// Consecutive globstars are the same as one
+			if n := len(p.segments); n == 0 || !p.segments[n-1].globstar {
+				p.segments = append(p.segments, segment{globstar: true})
+			}
+			continue
+		}
+		elems, err := parseComponent(part)
+		if err != nil {
+			return nil, err
+		}
+		p.segments = append(p.segments, segment{elems: elems})
+	}
+	return p, nil
+}
+
+// MustCompile is like Compile but panics on a malformed pattern, for
+// patterns known to be valid
+func MustCompile(pattern string) *Pattern {
+	p, err := Compile(pattern)
+	if err != nil {
+		panic("match: Compile(" + pattern + "): " + err.Error())
+	}
+	return p
+}
+
+// Match reports whether name matches a glob pattern
+func Match(pattern, name string) (bool, error) {
+	p, err := Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return p.Match(name), nil
+}
+
+// HasMeta reports whether s contains any of the special characters of a
+// pattern, so that it may match names other than itself
+func HasMeta(s string) bool {
+	return strings.ContainsAny(s, `*?[\`)
+}
+
+// String returns the source of the pattern
+func (p *Pattern) String() string { return p.src }
+
+// Match reports whether name matches the pattern
+// Time Complexity: O(n * m) for a name of n characters and a pattern of m
+func (p *Pattern) Match(name string) bool {
+	if p.literal {
+		return name == p.src
+	}
+	return p.matchParts(strings.Split(name, "/"))
+}
+
+// matchPath matches a path already split into components
+func (p *Pattern) matchPath(parts []string) bool {
+	if p.literal {
+		return strings.Join(parts, "/") == p.src
+	}
+	return p.matchParts(parts)
+}
+
+// matchParts matches the components of a path, tracking every component a
+// match of the segments so far may end at, so that globstars never
+// backtrack
+func (p *Pattern) matchParts(parts []string) bool {
+	reach := make([]bool, len(parts)+1)
+	reach[0] = true
+	next := make([]bool, len(parts)+1)
+	for _, seg := range p.segments {
+		clear(next)
+		alive := false
+		for i, ok := range reach {
+			if !ok {
+				continue
+			}
+			if seg.globstar {
+				// Zero or more components from the first reachable one on
+				for j := i; j <= len(parts); j++ {
+					next[j] = true
+				}
+				alive = true
+				break
+			}
+			if i < len(parts) && matchComponent(seg.elems, parts[i]) {
+				next[i+1] = true
+				alive = true
+			}
+		}
+		if !alive {
+			return false
+		}
+		reach, next = next, reach
+	}
+	return reach[len(parts)]
+}
+
+// matchComponent matches a path component, backtracking only to the last
+// star seen: an earlier star can never match more than the last one would
+// Time Complexity: O(n * m) worst case, O(n + m) without stars
+func matchComponent(elems []elem, s string) bool {
+	ei, si := 0, 0
+	star, starAt := -1, 0
+	for si < len(s) {
+		if ei < len(elems) && elems[ei].kind == elemStar {
+			star, starAt = ei, si
+			ei++
+			continue
+		}
+		r, width := utf8.DecodeRuneInString(s[si:])
+		if ei < len(elems) && elems[ei].matches(r) {
+			ei++
+			si += width
+			continue
+		}
+		if star < 0 {
+			return false
+		}
+		// Let the last star absorb one more character and retry
+		_, width = utf8.DecodeRuneInString(s[starAt:])
+		starAt += width
+		ei, si = star+1, starAt
+	}
+	for ei < len(elems) && elems[ei].kind == elemStar {
+		ei++
+	}
+	return ei == len(elems)
+}
+
+// parseComponent parses the pattern of one path component
+func parseComponent(s string) ([]elem, error) {
+	var elems []elem
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '*':
+			// Consecutive stars are the same as one
+			if n := len(elems); n == 0 || elems[n-1].kind != elemStar {
+				elems = append(elems, elem{kind: elemStar})
+			}
+			i++
+		case '?':
+			elems = append(elems, elem{kind: elemAny})
+			i++
+		case '[':
+			e, n, err := parseClass(s[i:])
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, e)
+			i += n
+		default:
+			r, n, err := literal(s[i:])
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, elem{kind: elemLiteral, r: r})
+			i += n
+		}
+	}
+	return elems, nil
+}
+
+// literal decodes a character, or a backslash and the character it
+// escapes, returning it and the bytes consumed
+func literal(s string) (rune, int, error) {
+	if s[0] != '\\' {
+		r, n := utf8.DecodeRuneInString(s)
+		return r, n, nil
+	}
+	if len(s) == 1 {
+		return 0, 0, ErrBadPattern
+	}
+	r, n := utf8.DecodeRuneInString(s[1:])
+	return r, n + 1, nil
+}
+
+// parseClass parses a class starting at [, returning it and the bytes
+// consumed. A ] first in the class is an ordinary character.
+func parseClass(s string) (elem, int, error) {
+	e := elem{kind: elemClass}
+	i := 1
+	if i < len(s) && (s[i] == '!' || s[i] == '^') {
+		e.negated = true
+		i++
+	}
+	for first := true; ; first = false {
+		if i >= len(s) {
+			return elem{}, 0, ErrBadPattern
+		}
+		if s[i] == ']' && !first {
+			return e, i + 1, nil
+		}
+		lo, n, err := literal(s[i:])
+		if err != nil {
+			return elem{}, 0, err
+		}
+		i += n
+		hi := lo
+		if i+1 < len(s) && s[i] == '-' && s[i+1] != ']' {
+			if hi, n, err = literal(s[i+1:]); err != nil {
+				return elem{}, 0, err
+			}
+			i += n + 1
+			if hi < lo {
+				return elem{}, 0, ErrBadPattern
+			}
+		}
+		e.ranges = append(e.ranges, lo, hi)
+	}
+}
@@ -0,0 +1,113 @@
+package match
+
+import (
+	"errors"
+	"math/rand/v2"
+	"path"
+	"strings"
+	"testing"
+)
+
+// TestMatch tests wildcards, classes, escapes and globstars
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"lib*.o", "libfoo.o", true},
+		{"lib*.o", "lib.o", true},
+		{"lib*.o", "libfoo.a", false},
+		{"lib*.o", "dir/libfoo.o", false},
+		{"*", ".hidden", true},
+		{"*", "", true},
+		{"*", "a/b", false},
+		{"?.o", "a.o", true},
+		{"?.o", "ab.o", false},
+		{"?", "é", true},
+		{".text.*", ".text.hot", true},
+		{".text.*", ".text", false},
+		{".text*", ".text", true},
+		{"[abc].o", "b.o", true},
+		{"[a-c][0-9]", "c7", true},
+		{"[!a-c]x", "dx", true},
+		{"[^a-c]x", "ax", false},
+		{"[]a]", "]", true},
+		{"[a-]", "-", true},
+		{`[\]]`, "]", true},
+		{`\*.o`, "*.o", true},
+		{`\*.o`, "a.o", false},
+		{"a*b*c*d", "aXbYcZd", true},
+		{"a*b*c*d", "abcdX", false},
+		{"*a*a*a*a*b", strings.Repeat("a", 40), false},
+		{"**", "a/b/c", true},
+		{"**", "", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/y/c", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/tool/main.go", true},
+		{"src/**", "src/a/b", true},
+		{"src/**", "src", true},
+		{"a/**/**/b", "a/b", true},
+		{"a**b", "axxb", true},
+		{"a**b", "ax/xb", false},
+		{"a/*/c", "a/b/c", true},
+		{"a/*/c", "a/c", false},
+		{"exact.o", "exact.o", true},
+		{"exact.o", "exact.O", false},
+	}
+	for _, tt := range tests {
+		got, err := Match(tt.pattern, tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, %v, want %v", tt.pattern, tt.name, got, err, tt.want)
+		}
+	}
+}
+
+// TestBadPattern tests malformed patterns
+func TestBadPattern(t *testing.T) {
+	for _, pattern := range []string{"[abc", "[", "[!", `a\`, "[z-a]", `[a\`, "x/[a"} {
+		if _, err := Compile(pattern); !errors.Is(err, ErrBadPattern) {
+			t.Errorf("Compile(%q) = %v, want %v", pattern, err, ErrBadPattern)
+		}
+	}
+}
+
+// TestAgainstPath compares patterns without globstars against path.Match,
+// which has the same syntax
+func TestAgainstPath(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 5))
+	atoms := []string{"a", "b", "/", ".", "*", "?", "[ab]", "[^a]", "[a-c]", `\*`, `\a`}
+	for range 20000 {
+		var p, s strings.Builder
+		for range rng.IntN(6) {
+			p.WriteString(atoms[rng.IntN(len(atoms))])
+		}
+		for range rng.IntN(8) {
+			s.WriteByte("abc/.*"[rng.IntN(6)])
+		}
+		pattern, name := p.String(), s.String()
+		// path.Match lets a negated class match /, which no wildcard here does
+		if strings.Contains(pattern, "**") || (strings.Contains(pattern, "[^") && strings.Contains(name, "/")) {
+			continue
+		}
+		want, err := path.Match(pattern, name)
+		if err != nil {
+			continue
+		}
+		if got, err := Match(pattern, name); err != nil || got != want {
+			t.Fatalf("Match(%q, %q) = %v, %v, want %v", pattern, name, got, err, want)
+		}
+	}
+}
+
+// BenchmarkMatch measures a globstar pattern against a deep path
+func BenchmarkMatch(b *testing.B) {
+	p := MustCompile("src/**/test_*.go")
+	name := "src/" + strings.Repeat("pkg/", 20) + "test_parser.go"
+	for b.Loop() {
+		if !p.Match(name) {
+			b.Fatal("no match")
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package match
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxIgnoreLine bounds the length of a line of an ignore file
+const maxIgnoreLine = 64 * 1024
+
+// rule is one line of an ignore file
+type rule struct {
+	pattern *Pattern
+	negate  bool // !pattern: re-include what earlier rules ignore
+	dirOnly bool // pattern/: match directories only
+}
+
+// Ignore is a list of rules in the syntax of .gitignore, deciding which
+// paths a walk skips. It is safe for concurrent use once built.
+//
+//   - Blank lines and lines starting with # are skipped; \# and \! escape a
+//     leading # or !, and trailing spaces are dropped unless escaped
+//   - A rule starting with ! re-includes paths an earlier rule ignores, but
+//     nothing inside an ignored directory
+//   - A rule ending with / matches directories only
+//   - A rule with a / at its start or middle matches paths relative to the
+//     directory of the ignore file; otherwise it matches a name at any depth
+//   - A trailing /** matches everything inside a directory, a leading **/
+//     matches in any directory, and /**/ matches zero or more directories
+//
+// The last rule matching a path decides whether it is ignored.
+type Ignore struct {
+	rules []rule
+}
+
+// NewIgnore compiles ignore rules, one per line
+func NewIgnore(lines ...string) (*Ignore, error) {
+	ig := &Ignore{}
+	for i, line := range lines {
+		if err := ig.add(line); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	return ig, nil
+}
+
+// ParseIgnore reads the rules of an ignore file
+func ParseIgnore(r io.Reader) (*Ignore, error) {
+	ig := &Ignore{}
+	scanner := bufio.NewScanner(r)
+	// Secure: Bound the line length, as Scanner does, with a clear error
+	scanner.Buffer(make([]byte, 0, 4096), maxIgnoreLine)
+	for n := 1; scanner.Scan(); n++ {
+		if err := ig.add(scanner.Text()); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ig, nil
+}
+
+// add parses one line of an ignore file
+func (ig *Ignore) add(line string) error {
+	line = trimTrailingSpace(strings.TrimSuffix(line, "\r"))
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	r := rule{}
+	if rest, ok := strings.CutPrefix(line, "!"); ok {
+		r.negate, line = true, rest
+	}
+	if rest, ok := strings.CutSuffix(line, "/"); ok {
+		r.dirOnly, line = true, rest
+	}
+	if line == "" {
+		return nil
+	}
+	if rest, ok := strings.CutPrefix(line, "/"); ok {
+		line = rest
+	} else if !strings.Contains(line, "/") {
+		// A bare name matches at any depth
+		line = "**/" + line
+	}
+	if rest, ok := strings.CutSuffix(line, "/**"); ok {
+		// Everything inside, but not the directory itself
+		line = rest + "/**/*"
+	}
+	p, err := Compile(line)
+	if err != nil {
+		return err
+	}
+	r.pattern = p
+	ig.rules = append(ig.rules, r)
+	return nil
+}
+
+// trimTrailingSpace drops trailing spaces not escaped with a backslash
+func trimTrailingSpace(s string) string {
+	for strings.HasSuffix(s, " ") && !strings.HasSuffix(s, `\ `) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// Ignored reports whether a path, slash-separated and relative to the
+// directory of the ignore file, is ignored. isDir tells whether the path
+// is a directory, for rules ending with /. A path inside an ignored
+// directory is ignored too.
+// Time Complexity: O(d * r) pattern matches for a path of depth d and r rules
+func (ig *Ignore) Ignored(path string, isDir bool) bool {
+	path = strings.Trim(strings.TrimPrefix(path, "./"), "/")
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts); i++ {
+		if ig.match(parts[:i], true) {
+			return true
+		}
+	}
+	return ig.match(parts, isDir)
+}
+
+// match returns the decision of the last rule matching a path
+func (ig *Ignore) match(parts []string, isDir bool) bool {
+	for i := len(ig.rules) - 1; i >= 0; i-- {
+		r := &ig.rules[i]
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.pattern.matchPath(parts) {
+			return !r.negate
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package match
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestIgnore tests the rules of a .gitignore-style file
+func TestIgnore(t *testing.T) {
+	ig, err := ParseIgnore(strings.NewReader(`# Build output
+*.o
+!keep.o
+build/
+/root.txt
+docs/*.html
+logs/**
+**/cache
+a/**/z
+\#hash
+\!bang
+` + "trailing\\ \nspaces   \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"main.o", false, true},
+		{"src/deep/main.o", false, true},
+		{"keep.o", false, false},
+		{"src/keep.o", false, false},
+		{"main.c", false, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"src/build", true, true},
+		{"build/out/keep.o", false, true}, // Inside an ignored directory
+		{"root.txt", false, true},
+		{"src/root.txt", false, false},
+		{"docs/index.html", false, true},
+		{"docs/api/index.html", false, false},
+		{"logs", true, false},
+		{"logs/today/app.log", false, true},
+		{"x/y/cache", true, true},
+		{"a/z", false, true},
+		{"a/b/c/z", false, true},
+		{"#hash", false, true},
+		{"!bang", false, true},
+		{"trailing ", false, true},
+		{"spaces", false, true},
+		{"./main.o", false, true},
+		{"/main.c", false, false},
+	}
+	for _, tt := range tests {
+		if got := ig.Ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+// TestIgnoreOrder tests that the last matching rule decides
+func TestIgnoreOrder(t *testing.T) {
+	ig, err := NewIgnore("!important.log", "*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ig.Ignored("important.log", false) {
+		t.Error("a negation before the rule it undoes took effect")
+	}
+	if _, err := NewIgnore("ok", "[bad"); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("NewIgnore with a bad pattern = %v, want an error on line 2", err)
+	}
+}
+
+// TestFilter tests objcopy-style selection with exclusions
+func TestFilter(t *testing.T) {
+	f, err := NewFilter(".text*", "!.text.unlikely", ".data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		".text": true, ".text.hot": true, ".text.unlikely": false, ".data": true, ".bss": false,
+	} {
+		if got := f.Match(name); got != want {
+			t.Errorf("Match(%q) = %v, want %v", name, got, want)
+		}
+	}
+	empty, _ := NewFilter()
+	if !empty.Empty() || empty.Match("x") {
+		t.Error("an empty filter selects names")
+	}
+	if _, err := NewFilter("!["); err == nil {
+		t.Error("NewFilter accepted a bad pattern")
+	}
+}
@@ -0,0 +1,73 @@
+package pe
+
+// Machine types (Machine)
+const (
+	IMAGE_FILE_MACHINE_UNKNOWN = 0x0
+	IMAGE_FILE_MACHINE_I386    = 0x14c
+	IMAGE_FILE_MACHINE_ARM     = 0x1c0
+	IMAGE_FILE_MACHINE_ARMNT   = 0x1c4
+	IMAGE_FILE_MACHINE_IA64    = 0x200
+	IMAGE_FILE_MACHINE_AMD64   = 0x8664
+	IMAGE_FILE_MACHINE_ARM64   = 0xaa64
+	IMAGE_FILE_MACHINE_RISCV64 = 0x5064
+)
+
+// File characteristics (Characteristics)
+const (
+	IMAGE_FILE_RELOCS_STRIPPED     = 0x0001
+	IMAGE_FILE_EXECUTABLE_IMAGE    = 0x0002
+	IMAGE_FILE_LARGE_ADDRESS_AWARE = 0x0020
+	IMAGE_FILE_32BIT_MACHINE       = 0x0100
+	IMAGE_FILE_DEBUG_STRIPPED      = 0x0200
+	IMAGE_FILE_DLL                 = 0x2000
+)
+
+// Optional header magic numbers
+const (
+	IMAGE_NT_OPTIONAL_HDR32_MAGIC = 0x10b // PE32
+	IMAGE_NT_OPTIONAL_HDR64_MAGIC = 0x20b // PE32+
+)
+
+// Data directory indices
+const (
+	IMAGE_DIRECTORY_ENTRY_EXPORT    = 0
+	IMAGE_DIRECTORY_ENTRY_IMPORT    = 1
+	IMAGE_DIRECTORY_ENTRY_RESOURCE  = 2
+	IMAGE_DIRECTORY_ENTRY_EXCEPTION = 3
+	IMAGE_DIRECTORY_ENTRY_SECURITY  = 4
+	IMAGE_DIRECTORY_ENTRY_BASERELOC = 5
+	IMAGE_DIRECTORY_ENTRY_DEBUG     = 6
+	IMAGE_DIRECTORY_ENTRY_TLS       = 9
+	IMAGE_DIRECTORY_ENTRY_IAT       = 12
+)
+
+// Section characteristics (Characteristics)
+const (
+	IMAGE_SCN_CNT_CODE               = 0x00000020
+	IMAGE_SCN_CNT_INITIALIZED_DATA   = 0x00000040
+	IMAGE_SCN_CNT_UNINITIALIZED_DATA = 0x00000080
+	IMAGE_SCN_LNK_INFO               = 0x00000200
+	IMAGE_SCN_LNK_REMOVE             = 0x00000800
+	IMAGE_SCN_MEM_DISCARDABLE        = 0x02000000
+	IMAGE_SCN_MEM_EXECUTE            = 0x20000000
+	IMAGE_SCN_MEM_READ               = 0x40000000
+	IMAGE_SCN_MEM_WRITE              = 0x80000000
+)
+
+// Special section numbers (SectionNumber)
+const (
+	IMAGE_SYM_UNDEFINED = 0
+	IMAGE_SYM_ABSOLUTE  = -1
+	IMAGE_SYM_DEBUG     = -2
+)
+
+// Symbol storage classes (StorageClass)
+const (
+	IMAGE_SYM_CLASS_EXTERNAL      = 2
+	IMAGE_SYM_CLASS_STATIC        = 3
+	IMAGE_SYM_CLASS_LABEL         = 6
+	IMAGE_SYM_CLASS_FUNCTION      = 101
+	IMAGE_SYM_CLASS_FILE          = 103
+	IMAGE_SYM_CLASS_SECTION       = 104
+	IMAGE_SYM_CLASS_WEAK_EXTERNAL = 105
+)
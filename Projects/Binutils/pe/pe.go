@@ -0,0 +1,432 @@
+// Package pe parses PE/COFF files: Windows executables and DLLs (PE32 and
+// PE32+) as well as plain COFF object files. It reads the file and optional
+// headers, the section table and the COFF symbol table.
+package pe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// PE represents a PE image or COFF object file
+type PE struct {
+	Class          string // "PE32", "PE32+" or "COFF" for object files
+	Type           string
+	Machine        string
+	Entry          uint64 // Entry point address, 0 if the file has none
+	FileHeader     FileHeader
+	OptionalHeader *OptionalHeader // nil for object files
+	Sections       []Section
+	Symbols        []Symbol
+	StringTable    []byte // COFF string table, including its size field
+}
+
+// FileHeader represents the COFF file header
+type FileHeader struct {
+	Machine              uint16
+	NumberOfSections     uint16
+	TimeDateStamp        uint32
+	PointerToSymbolTable uint32
+	NumberOfSymbols      uint32
+	SizeOfOptionalHeader uint16
+	Characteristics      uint16
+}
+
+// OptionalHeader represents the fields of the PE32 and PE32+ optional
+// headers that tools report. Fields that are 32-bit in PE32 are widened.
+type OptionalHeader struct {
+	Magic                 uint16
+	SizeOfCode            uint32
+	AddressOfEntryPoint   uint32
+	BaseOfCode            uint32
+	ImageBase             uint64
+	SectionAlignment      uint32
+	FileAlignment         uint32
+	MajorSubsystemVersion uint16
+	MinorSubsystemVersion uint16
+	SizeOfImage           uint32
+	SizeOfHeaders         uint32
+	CheckSum              uint32
+	Subsystem             uint16
+	DllCharacteristics    uint16
+	SizeOfStackReserve    uint64
+	SizeOfHeapReserve     uint64
+	DataDirectory         []DataDirectory
+}
+
+// DataDirectory locates a table such as the import or export directory
+type DataDirectory struct {
+	VirtualAddress uint32
+	Size           uint32
+}
+
+// Section represents a section table entry
+type Section struct {
+	Name                 string // Long names are resolved through the string table
+	VirtualSize          uint32
+	VirtualAddress       uint32
+	SizeOfRawData        uint32
+	PointerToRawData     uint32
+	PointerToRelocations uint32
+	NumberOfRelocations  uint16
+	Characteristics      uint32
+
+	reader io.ReadSeeker // File the section was parsed from
+}
+
+// Symbol represents a COFF symbol table entry. Auxiliary entries are
+// skipped; Index is the entry's position in the table, counting them.
+type Symbol struct {
+	Name               string
+	Index              int
+	Value              uint32
+	SectionNumber      int16 // 1-based; 0 undefined, -1 absolute, -2 debug
+	Type               uint16
+	StorageClass       uint8
+	NumberOfAuxSymbols uint8
+}
+
+// Sizes of the fixed-size structures
+const (
+	fileHeaderSize    = 20
+	sectionHeaderSize = 40
+	symbolSize        = 18
+)
+
+// Limits on the sizes read from headers
+const (
+	maxSymbols     = 10000000
+	maxStringTable = 256 * 1024 * 1024
+)
+
+// ParsePE parses a PE image, or a COFF object file when the file does not
+// start with an MS-DOS header
+func ParsePE(r io.ReadSeeker) (*PE, error) {
+	p := &PE{}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	dosHeader := make([]byte, 64)
+	n, err := io.ReadFull(r, dosHeader)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	// Images start with an MS-DOS stub pointing to the "PE\0\0" signature;
+	// object files start directly with the COFF header
+	var headerOffset int64
+	switch {
+	case n >= 2 && string(dosHeader[0:2]) == "MZ":
+		if n < 64 {
+			return nil, fmt.Errorf("failed to read header: truncated MS-DOS header")
+		}
+		headerOffset = int64(binary.LittleEndian.Uint32(dosHeader[0x3c:0x40]))
+		signature := make([]byte, 4)
+		if _, err := r.Seek(headerOffset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek to PE header: %w", err)
+		}
+		if _, err := io.ReadFull(r, signature); err != nil || string(signature) != "PE\x00\x00" {
+			return nil, fmt.Errorf("invalid PE signature")
+		}
+		headerOffset += 4
+	case n >= fileHeaderSize && IsCOFFMachine(binary.LittleEndian.Uint16(dosHeader[0:2])):
+		headerOffset = 0
+	default:
+		return nil, fmt.Errorf("invalid PE magic")
+	}
+
+	if _, err := r.Seek(headerOffset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to COFF header: %w", err)
+	}
+	headerBytes := make([]byte, fileHeaderSize)
+	if _, err := io.ReadFull(r, headerBytes); err != nil {
+		return nil, fmt.Errorf("failed to read COFF header: %w", err)
+	}
+	header := &p.FileHeader
+	header.Machine = binary.LittleEndian.Uint16(headerBytes[0:2])
+	header.NumberOfSections = binary.LittleEndian.Uint16(headerBytes[2:4])
+	header.TimeDateStamp = binary.LittleEndian.Uint32(headerBytes[4:8])
+	header.PointerToSymbolTable = binary.LittleEndian.Uint32(headerBytes[8:12])
+	header.NumberOfSymbols = binary.LittleEndian.Uint32(headerBytes[12:16])
+	header.SizeOfOptionalHeader = binary.LittleEndian.Uint16(headerBytes[16:18])
+	header.Characteristics = binary.LittleEndian.Uint16(headerBytes[18:20])
+
+	p.Machine = GetMachine(header.Machine)
+	p.Class = "COFF"
+	p.Type = "Object"
+
+	if header.SizeOfOptionalHeader > 0 {
+		if err := parseOptionalHeader(r, p); err != nil {
+			return nil, fmt.Errorf("failed to parse optional header: %w", err)
+		}
+	}
+
+	sectionOffset := headerOffset + fileHeaderSize + int64(header.SizeOfOptionalHeader)
+	if err := parseSections(r, p, sectionOffset); err != nil {
+		return nil, fmt.Errorf("failed to parse sections: %w", err)
+	}
+
+	if err := parseSymbols(r, p); err != nil {
+		return nil, fmt.Errorf("failed to parse symbols: %w", err)
+	}
+
+	// Section names too long for the header are "/offset" into the string table
+	for i := range p.Sections {
+		section := &p.Sections[i]
+		if len(section.Name) > 1 && section.Name[0] == '/' {
+			if offset, err := strconv.Atoi(section.Name[1:]); err == nil {
+				if name, ok := p.lookupString(uint32(offset)); ok {
+					section.Name = name
+				}
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// parseOptionalHeader parses the PE32 or PE32+ optional header, which
+// follows the COFF header
+func parseOptionalHeader(r io.ReadSeeker, p *PE) error {
+	data := make([]byte, p.FileHeader.SizeOfOptionalHeader)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return fmt.Errorf("failed to read optional header: %w", err)
+	}
+	if len(data) < 2 {
+		return fmt.Errorf("optional header truncated")
+	}
+
+	opt := &OptionalHeader{Magic: binary.LittleEndian.Uint16(data[0:2])}
+	var directories []byte
+	switch opt.Magic {
+	case IMAGE_NT_OPTIONAL_HDR32_MAGIC:
+		if len(data) < 96 {
+			return fmt.Errorf("PE32 optional header truncated")
+		}
+		p.Class = "PE32"
+		opt.ImageBase = uint64(binary.LittleEndian.Uint32(data[28:32]))
+		opt.SizeOfStackReserve = uint64(binary.LittleEndian.Uint32(data[72:76]))
+		opt.SizeOfHeapReserve = uint64(binary.LittleEndian.Uint32(data[80:84]))
+		directories = data[92:]
+	case IMAGE_NT_OPTIONAL_HDR64_MAGIC:
+		if len(data) < 112 {
+			return fmt.Errorf("PE32+ optional header truncated")
+		}
+		p.Class = "PE32+"
+		opt.ImageBase = binary.LittleEndian.Uint64(data[24:32])
+		opt.SizeOfStackReserve = binary.LittleEndian.Uint64(data[72:80])
+		opt.SizeOfHeapReserve = binary.LittleEndian.Uint64(data[88:96])
+		directories = data[108:]
+	default:
+		return fmt.Errorf("invalid optional header magic: 0x%x", opt.Magic)
+	}
+
+	// The fields up to the stack reserve are laid out alike in both forms
+	opt.SizeOfCode = binary.LittleEndian.Uint32(data[4:8])
+	opt.AddressOfEntryPoint = binary.LittleEndian.Uint32(data[16:20])
+	opt.BaseOfCode = binary.LittleEndian.Uint32(data[20:24])
+	opt.SectionAlignment = binary.LittleEndian.Uint32(data[32:36])
+	opt.FileAlignment = binary.LittleEndian.Uint32(data[36:40])
+	opt.MajorSubsystemVersion = binary.LittleEndian.Uint16(data[48:50])
+	opt.MinorSubsystemVersion = binary.LittleEndian.Uint16(data[50:52])
+	opt.SizeOfImage = binary.LittleEndian.Uint32(data[56:60])
+	opt.SizeOfHeaders = binary.LittleEndian.Uint32(data[60:64])
+	opt.CheckSum = binary.LittleEndian.Uint32(data[64:68])
+	opt.Subsystem = binary.LittleEndian.Uint16(data[68:70])
+	opt.DllCharacteristics = binary.LittleEndian.Uint16(data[70:72])
+
+	// Secure: only read the directories that fit in the header
+	count := binary.LittleEndian.Uint32(directories[0:4])
+	directories = directories[4:]
+	for i := uint32(0); i < count && len(directories) >= 8; i++ {
+		opt.DataDirectory = append(opt.DataDirectory, DataDirectory{
+			VirtualAddress: binary.LittleEndian.Uint32(directories[0:4]),
+			Size:           binary.LittleEndian.Uint32(directories[4:8]),
+		})
+		directories = directories[8:]
+	}
+
+	p.OptionalHeader = opt
+	if p.FileHeader.Characteristics&IMAGE_FILE_DLL != 0 {
+		p.Type = "DLL"
+	} else {
+		p.Type = "Executable"
+	}
+	if opt.AddressOfEntryPoint != 0 {
+		p.Entry = opt.ImageBase + uint64(opt.AddressOfEntryPoint)
+	}
+	return nil
+}
+
+// parseSections parses the section table
+func parseSections(r io.ReadSeeker, p *PE, offset int64) error {
+	count := int(p.FileHeader.NumberOfSections)
+	if count == 0 {
+		return nil
+	}
+
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to section table: %w", err)
+	}
+	table := make([]byte, count*sectionHeaderSize)
+	if _, err := io.ReadFull(r, table); err != nil {
+		return fmt.Errorf("failed to read section table: %w", err)
+	}
+
+	p.Sections = make([]Section, count)
+	for i := range p.Sections {
+		sh := table[i*sectionHeaderSize : (i+1)*sectionHeaderSize]
+		p.Sections[i] = Section{
+			Name:                 cString(sh[0:8]),
+			VirtualSize:          binary.LittleEndian.Uint32(sh[8:12]),
+			VirtualAddress:       binary.LittleEndian.Uint32(sh[12:16]),
+			SizeOfRawData:        binary.LittleEndian.Uint32(sh[16:20]),
+			PointerToRawData:     binary.LittleEndian.Uint32(sh[20:24]),
+			PointerToRelocations: binary.LittleEndian.Uint32(sh[24:28]),
+			NumberOfRelocations:  binary.LittleEndian.Uint16(sh[32:34]),
+			Characteristics:      binary.LittleEndian.Uint32(sh[36:40]),
+			reader:               r,
+		}
+	}
+	return nil
+}
+
+// parseSymbols reads the COFF symbol table and the string table that
+// follows it
+func parseSymbols(r io.ReadSeeker, p *PE) error {
+	header := &p.FileHeader
+	if header.PointerToSymbolTable == 0 || header.NumberOfSymbols == 0 {
+		return nil // Images are usually stripped
+	}
+
+	// Secure: validate symbol count
+	if header.NumberOfSymbols > maxSymbols {
+		return fmt.Errorf("invalid symbol count: %d", header.NumberOfSymbols)
+	}
+
+	if _, err := r.Seek(int64(header.PointerToSymbolTable), io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to symbol table: %w", err)
+	}
+	table := make([]byte, int(header.NumberOfSymbols)*symbolSize)
+	if _, err := io.ReadFull(r, table); err != nil {
+		return fmt.Errorf("failed to read symbol table: %w", err)
+	}
+
+	// The string table starts with its own size; it may be missing
+	sizeBytes := make([]byte, 4)
+	if _, err := io.ReadFull(r, sizeBytes); err == nil {
+		size := binary.LittleEndian.Uint32(sizeBytes)
+		// Secure: validate string table size
+		if size > maxStringTable {
+			return fmt.Errorf("invalid string table size: %d", size)
+		}
+		if size > 4 {
+			p.StringTable = make([]byte, size)
+			copy(p.StringTable, sizeBytes)
+			if _, err := io.ReadFull(r, p.StringTable[4:]); err != nil {
+				return fmt.Errorf("failed to read string table: %w", err)
+			}
+		}
+	}
+
+	for i := 0; i < int(header.NumberOfSymbols); i++ {
+		entry := table[i*symbolSize : (i+1)*symbolSize]
+		sym := Symbol{
+			Index:              i,
+			Value:              binary.LittleEndian.Uint32(entry[8:12]),
+			SectionNumber:      int16(binary.LittleEndian.Uint16(entry[12:14])),
+			Type:               binary.LittleEndian.Uint16(entry[14:16]),
+			StorageClass:       entry[16],
+			NumberOfAuxSymbols: entry[17],
+		}
+		if binary.LittleEndian.Uint32(entry[0:4]) == 0 {
+			sym.Name, _ = p.lookupString(binary.LittleEndian.Uint32(entry[4:8]))
+		} else {
+			sym.Name = cString(entry[0:8])
+		}
+		p.Symbols = append(p.Symbols, sym)
+		i += int(sym.NumberOfAuxSymbols)
+	}
+	return nil
+}
+
+// lookupString returns the string at an offset in the string table. The
+// offset counts the 4-byte size field.
+func (p *PE) lookupString(offset uint32) (string, bool) {
+	if offset < 4 || uint64(offset) >= uint64(len(p.StringTable)) {
+		return "", false
+	}
+	return cString(p.StringTable[offset:]), true
+}
+
+// ReadAll reads the contents of the section. limit bounds the section size
+// so that corrupt headers cannot force huge allocations. Sections holding
+// only uninitialized data read as nil.
+func (s *Section) ReadAll(limit uint64) ([]byte, error) {
+	size := s.SizeOfRawData
+	if s.VirtualSize != 0 && s.VirtualSize < size {
+		size = s.VirtualSize // The rest is file alignment padding
+	}
+	if s.PointerToRawData == 0 || size == 0 {
+		return nil, nil
+	}
+	if s.reader == nil {
+		return nil, fmt.Errorf("section %s has no data source", s.Name)
+	}
+
+	// Secure: validate section size
+	if uint64(size) > limit {
+		return nil, fmt.Errorf("section %s too large: %d", s.Name, size)
+	}
+
+	data := make([]byte, size)
+	if _, err := s.reader.Seek(int64(s.PointerToRawData), io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to section %s: %w", s.Name, err)
+	}
+	if _, err := io.ReadFull(s.reader, data); err != nil {
+		return nil, fmt.Errorf("failed to read section %s: %w", s.Name, err)
+	}
+	return data, nil
+}
+
+// IsCOFFMachine reports whether m is a machine type this package knows,
+// which is how object files without an MS-DOS header are recognized
+func IsCOFFMachine(m uint16) bool {
+	switch m {
+	case IMAGE_FILE_MACHINE_I386, IMAGE_FILE_MACHINE_ARM, IMAGE_FILE_MACHINE_ARMNT,
+		IMAGE_FILE_MACHINE_IA64, IMAGE_FILE_MACHINE_AMD64, IMAGE_FILE_MACHINE_ARM64,
+		IMAGE_FILE_MACHINE_RISCV64:
+		return true
+	}
+	return false
+}
+
+// cString returns the NUL-terminated string at the start of data
+func cString(data []byte) string {
+	if end := bytes.IndexByte(data, 0); end >= 0 {
+		return string(data[:end])
+	}
+	return string(data)
+}
+
+// GetMachine returns the machine type name
+func GetMachine(m uint16) string {
+	machines := map[uint16]string{
+		IMAGE_FILE_MACHINE_UNKNOWN: "IMAGE_FILE_MACHINE_UNKNOWN",
+		IMAGE_FILE_MACHINE_I386:    "IMAGE_FILE_MACHINE_I386",
+		IMAGE_FILE_MACHINE_ARM:     "IMAGE_FILE_MACHINE_ARM",
+		IMAGE_FILE_MACHINE_ARMNT:   "IMAGE_FILE_MACHINE_ARMNT",
+		IMAGE_FILE_MACHINE_IA64:    "IMAGE_FILE_MACHINE_IA64",
+		IMAGE_FILE_MACHINE_AMD64:   "IMAGE_FILE_MACHINE_AMD64",
+		IMAGE_FILE_MACHINE_ARM64:   "IMAGE_FILE_MACHINE_ARM64",
+		IMAGE_FILE_MACHINE_RISCV64: "IMAGE_FILE_MACHINE_RISCV64",
+	}
+	if name, ok := machines[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("IMAGE_FILE_MACHINE_UNKNOWN(0x%x)", m)
+}
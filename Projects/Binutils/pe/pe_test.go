@@ -0,0 +1,135 @@
+package pe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// buildObject builds an x86-64 COFF object with a .text section, a section
+// whose name lives in the string table and three symbols, one of them with
+// an auxiliary entry and one with a long name
+func buildObject() []byte {
+	le := binary.LittleEndian
+	code := []byte{0xc3}
+	strtab := []byte("\x00\x00\x00\x00.a_long_section_name\x00external_function_name\x00")
+	le.PutUint32(strtab, uint32(len(strtab)))
+
+	codeOff := fileHeaderSize + 2*sectionHeaderSize
+	symOff := codeOff + 2
+	b := make([]byte, symOff+4*symbolSize+len(strtab))
+
+	le.PutUint16(b[0:], IMAGE_FILE_MACHINE_AMD64)
+	le.PutUint16(b[2:], 2)
+	le.PutUint32(b[8:], uint32(symOff))
+	le.PutUint32(b[12:], 4)
+
+	text := b[fileHeaderSize:]
+	copy(text[0:], ".text")
+	le.PutUint32(text[16:], uint32(len(code)))
+	le.PutUint32(text[20:], uint32(codeOff))
+	le.PutUint32(text[36:], IMAGE_SCN_CNT_CODE|IMAGE_SCN_MEM_EXECUTE|IMAGE_SCN_MEM_READ)
+	long := b[fileHeaderSize+sectionHeaderSize:]
+	copy(long[0:], "/4")
+	le.PutUint32(long[36:], IMAGE_SCN_CNT_INITIALIZED_DATA|IMAGE_SCN_MEM_READ)
+	copy(b[codeOff:], code)
+
+	sym := b[symOff:]
+	copy(sym[0:], ".text")
+	le.PutUint16(sym[12:], 1)
+	sym[16], sym[17] = IMAGE_SYM_CLASS_STATIC, 1 // Followed by an auxiliary entry
+	sym = sym[2*symbolSize:]
+	copy(sym[0:], "main")
+	le.PutUint16(sym[12:], 1)
+	sym[16] = IMAGE_SYM_CLASS_EXTERNAL
+	sym = sym[symbolSize:]
+	le.PutUint32(sym[4:], 25) // Name in the string table
+	sym[16] = IMAGE_SYM_CLASS_EXTERNAL
+	copy(b[symOff+4*symbolSize:], strtab)
+	return b
+}
+
+// TestParseCOFFObject tests parsing of a COFF object file
+func TestParseCOFFObject(t *testing.T) {
+	p, err := ParsePE(bytes.NewReader(buildObject()))
+	if err != nil {
+		t.Fatalf("ParsePE failed: %v", err)
+	}
+
+	if p.Class != "COFF" || p.Type != "Object" || p.Machine != "IMAGE_FILE_MACHINE_AMD64" || p.OptionalHeader != nil {
+		t.Errorf("Unexpected header: %s %s %s", p.Class, p.Type, p.Machine)
+	}
+	if len(p.Sections) != 2 || p.Sections[0].Name != ".text" || p.Sections[1].Name != ".a_long_section_name" {
+		t.Fatalf("Unexpected sections: %+v", p.Sections)
+	}
+	if data, err := p.Sections[0].ReadAll(1024); err != nil || !bytes.Equal(data, []byte{0xc3}) {
+		t.Errorf(".text contents = %x, %v", data, err)
+	}
+
+	want := []struct {
+		name    string
+		index   int
+		section int16
+	}{{".text", 0, 1}, {"main", 2, 1}, {"external_function_name", 3, IMAGE_SYM_UNDEFINED}}
+	if len(p.Symbols) != len(want) {
+		t.Fatalf("Got %d symbols, want %d: %+v", len(p.Symbols), len(want), p.Symbols)
+	}
+	for i, w := range want {
+		sym := p.Symbols[i]
+		if sym.Name != w.name || sym.Index != w.index || sym.SectionNumber != w.section {
+			t.Errorf("Symbol %d = %+v, want %+v", i, sym, w)
+		}
+	}
+}
+
+// TestParsePEImage tests parsing of the MS-DOS stub and the PE32+ optional
+// header of an image
+func TestParsePEImage(t *testing.T) {
+	le := binary.LittleEndian
+	b := make([]byte, 0x80+4+fileHeaderSize+240)
+	copy(b, "MZ")
+	le.PutUint32(b[0x3c:], 0x80)
+	copy(b[0x80:], "PE\x00\x00")
+	header := b[0x84:]
+	le.PutUint16(header[0:], IMAGE_FILE_MACHINE_AMD64)
+	le.PutUint16(header[16:], 240)
+	le.PutUint16(header[18:], IMAGE_FILE_EXECUTABLE_IMAGE|IMAGE_FILE_DLL)
+	opt := header[fileHeaderSize:]
+	le.PutUint16(opt[0:], IMAGE_NT_OPTIONAL_HDR64_MAGIC)
+	le.PutUint32(opt[16:], 0x1000)
+	le.PutUint64(opt[24:], 0x180000000)
+	le.PutUint16(opt[68:], 2)
+	le.PutUint32(opt[108:], 16)
+	le.PutUint32(opt[112+8:], 0x2000) // Import directory
+
+	p, err := ParsePE(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ParsePE failed: %v", err)
+	}
+	if p.Class != "PE32+" || p.Type != "DLL" || p.Entry != 0x180001000 {
+		t.Errorf("Unexpected image: %s %s entry %#x", p.Class, p.Type, p.Entry)
+	}
+	opts := p.OptionalHeader
+	if opts.Subsystem != 2 || len(opts.DataDirectory) != 16 || opts.DataDirectory[IMAGE_DIRECTORY_ENTRY_IMPORT].VirtualAddress != 0x2000 {
+		t.Errorf("Unexpected optional header: %+v", opts)
+	}
+
+	// A signature offset past the end of the file
+	le.PutUint32(b[0x3c:], 0x10000)
+	if _, err := ParsePE(bytes.NewReader(b)); err == nil {
+		t.Error("Expected error for a missing PE signature")
+	}
+}
+
+// TestParsePEInvalid tests that malformed files are rejected
+func TestParsePEInvalid(t *testing.T) {
+	if _, err := ParsePE(bytes.NewReader([]byte("not a PE file at all"))); err == nil {
+		t.Error("Expected error for bad magic")
+	}
+
+	data := buildObject()
+	binary.LittleEndian.PutUint32(data[12:], maxSymbols+1)
+	if _, err := ParsePE(bytes.NewReader(data)); err == nil {
+		t.Error("Expected error for too many symbols")
+	}
+}
@@ -0,0 +1,123 @@
+// Package safepath validates member names read from archives before they
+// become paths on disk. An archive is untrusted input: a name such as
+// "../../etc/passwd" or "/etc/passwd", or a symbolic link extracted earlier
+// pointing outside the destination, would otherwise let extraction write
+// anywhere the user can.
+package safepath
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MaxNameLength bounds the length of a member name
+const MaxNameLength = 4096
+
+// ErrUnsafe is returned for a name that would lead outside the destination
+var ErrUnsafe = errors.New("unsafe path")
+
+// Check reports whether a member name is safe to extract: not empty,
+// relative, without ".." components and without NUL bytes. Both slashes and
+// backslashes separate components, so that names written on Windows are
+// checked as Windows would read them.
+func Check(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: empty name", ErrUnsafe)
+	case len(name) > MaxNameLength:
+		return fmt.Errorf("%w: name longer than %d bytes", ErrUnsafe, MaxNameLength)
+	case strings.IndexByte(name, 0) >= 0:
+		return fmt.Errorf("%w: %q contains a NUL byte", ErrUnsafe, name)
+	case name[0] == '/' || name[0] == '\\':
+		return fmt.Errorf("%w: %s is absolute", ErrUnsafe, name)
+	case len(name) >= 2 && name[1] == ':':
+		return fmt.Errorf("%w: %s has a drive letter", ErrUnsafe, name)
+	}
+	for _, part := range strings.FieldsFunc(name, isSeparator) {
+		if part == ".." {
+			return fmt.Errorf("%w: %s leaves the destination", ErrUnsafe, name)
+		}
+	}
+	return nil
+}
+
+// Clean returns a member name in canonical slash-separated form, without
+// "." components or a trailing slash, after checking it
+func Clean(name string) (string, error) {
+	if err := Check(name); err != nil {
+		return "", err
+	}
+	cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if cleaned == "." {
+		return "", fmt.Errorf("%w: %s names the destination itself", ErrUnsafe, name)
+	}
+	return cleaned, nil
+}
+
+// Join returns the path of a member name under root. It checks the name
+// and that no directory on the way from root is a symbolic link, so that a
+// link extracted from the same archive cannot redirect the write. The last
+// component is not checked: callers replace whatever is there.
+func Join(root, name string) (string, error) {
+	cleaned, err := Clean(name)
+	if err != nil {
+		return "", err
+	}
+
+	dir := root
+	parts := strings.Split(cleaned, "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			break // Nothing below a missing directory exists either
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("%w: %s is below the symbolic link %s", ErrUnsafe, name, part)
+		}
+	}
+	return filepath.Join(root, filepath.FromSlash(cleaned)), nil
+}
+
+// CheckLink reports whether a symbolic link named name may point to
+// target: the target, taken relative to the directory holding the link,
+// must stay inside the destination
+func CheckLink(name, target string) error {
+	cleaned, err := Clean(name)
+	if err != nil {
+		return err
+	}
+	if target == "" || target[0] == '/' || target[0] == '\\' || (len(target) >= 2 && target[1] == ':') {
+		return fmt.Errorf("%w: link %s points to %q", ErrUnsafe, name, target)
+	}
+
+	// Secure: walk the target from the link's directory; going above the
+	// destination at any point is refused even if it comes back down
+	depth := strings.Count(cleaned, "/")
+	for _, part := range strings.FieldsFunc(target, isSeparator) {
+		switch part {
+		case ".":
+		case "..":
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("%w: link %s points to %q", ErrUnsafe, name, target)
+			}
+		default:
+			depth++
+		}
+	}
+	return nil
+}
+
+// isSeparator reports whether r separates path components on any system
+func isSeparator(r rune) bool {
+	return r == '/' || r == '\\'
+}
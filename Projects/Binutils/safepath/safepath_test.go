@@ -0,0 +1,105 @@
+package safepath
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheck tests path traversal detection
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		safe bool
+	}{
+		{"normal_file.txt", true},
+		{"subdir/file.txt", true},
+		{"./a/b/", true},
+		{"a..b", true},
+		{"../file.txt", false},
+		{"..\\file.txt", false},
+		{"a/../../b", false},
+		{"/etc/passwd", false},
+		{"\\windows\\system32", false},
+		{"C:evil", false},
+		{"a\x00b", false},
+		{"", false},
+		{strings.Repeat("a", MaxNameLength+1), false},
+	}
+	for _, tt := range tests {
+		err := Check(tt.name)
+		if (err == nil) != tt.safe {
+			t.Errorf("Check(%q) = %v, want safe %v", tt.name, err, tt.safe)
+		}
+		if err != nil && !errors.Is(err, ErrUnsafe) {
+			t.Errorf("Check(%q) = %v, want %v", tt.name, err, ErrUnsafe)
+		}
+	}
+}
+
+// TestClean tests canonical member names
+func TestClean(t *testing.T) {
+	for name, want := range map[string]string{
+		"a/b/":     "a/b",
+		"./a//b":   "a/b",
+		"dir\\f.o": "dir/f.o",
+	} {
+		if got, err := Clean(name); err != nil || got != want {
+			t.Errorf("Clean(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := Clean("./"); err == nil {
+		t.Error("Clean accepted the destination itself")
+	}
+}
+
+// TestJoin tests that a symbolic link cannot redirect a later member
+func TestJoin(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), filepath.Join(root, "link")); err != nil {
+		t.Skipf("symbolic links unavailable: %v", err)
+	}
+
+	got, err := Join(root, "dir/new/file")
+	if err != nil || got != filepath.Join(root, "dir", "new", "file") {
+		t.Errorf("Join(dir/new/file) = %q, %v", got, err)
+	}
+	// The link itself may be replaced, but not written through
+	if _, err := Join(root, "link"); err != nil {
+		t.Errorf("Join(link) = %v", err)
+	}
+	if _, err := Join(root, "link/file"); !errors.Is(err, ErrUnsafe) {
+		t.Errorf("Join(link/file) = %v, want %v", err, ErrUnsafe)
+	}
+	if _, err := Join(root, "../file"); !errors.Is(err, ErrUnsafe) {
+		t.Errorf("Join(../file) = %v, want %v", err, ErrUnsafe)
+	}
+}
+
+// TestCheckLink tests symbolic link targets
+func TestCheckLink(t *testing.T) {
+	tests := []struct {
+		name, target string
+		safe         bool
+	}{
+		{"a/link", "../b", true},
+		{"a/b/link", "../../c/./d", true},
+		{"link", "target", true},
+		{"link", "../outside", false},
+		{"a/link", "../../outside", false},
+		{"a/link", "../../a/back", false},
+		{"link", "/etc/passwd", false},
+		{"link", "", false},
+		{"../link", "target", false},
+	}
+	for _, tt := range tests {
+		if err := CheckLink(tt.name, tt.target); (err == nil) != tt.safe {
+			t.Errorf("CheckLink(%q, %q) = %v, want safe %v", tt.name, tt.target, err, tt.safe)
+		}
+	}
+}
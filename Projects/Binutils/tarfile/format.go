@@ -0,0 +1,186 @@
+// Package tarfile reads and writes tar archives with the same member-based
+// API as arfile. The reader accepts ustar, pax and GNU archives, including
+// GNU long names and base-256 numbers; the writer produces ustar and adds a
+// pax extended header for any value ustar cannot hold.
+package tarfile
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// blockSize is the unit of every header and of member data padding
+const blockSize = 512
+
+// MaxHeaderSize bounds the data of pax extended headers and GNU long names
+const MaxHeaderSize = 1 << 20
+
+// Member types stored in the typeflag field
+const (
+	TypeReg     = '0'
+	TypeLink    = '1' // Hard link to the earlier member named by Linkname
+	TypeSymlink = '2'
+	TypeChar    = '3'
+	TypeBlock   = '4'
+	TypeDir     = '5'
+	TypeFifo    = '6'
+
+	typePAX       = 'x' // Extended header for the next member
+	typePAXGlobal = 'g' // Extended header for every later member
+	typeGNULong   = 'L' // GNU long name of the next member
+	typeGNULink   = 'K' // GNU long link name of the next member
+)
+
+// Header is the metadata of one archive member
+type Header struct {
+	Name     string
+	Type     byte
+	Linkname string // Target of a symbolic or hard link
+	Mode     int    // Permission bits, with setuid, setgid and sticky
+	UID      int
+	GID      int
+	Uname    string
+	Gname    string
+	Size     int64
+	Date     int64 // Modification time in seconds since the epoch
+	Devmajor int
+	Devminor int
+	Offset   int64 // File offset of the first header of the member
+}
+
+// hasData reports whether members of a type store data after the header;
+// the size of links, directories and devices is ignored
+func hasData(typ byte) bool {
+	switch typ {
+	case TypeLink, TypeSymlink, TypeChar, TypeBlock, TypeDir, TypeFifo:
+		return false
+	}
+	return true
+}
+
+// Field offsets of a ustar header
+const (
+	offName     = 0
+	offMode     = 100
+	offUID      = 108
+	offGID      = 116
+	offSize     = 124
+	offDate     = 136
+	offChecksum = 148
+	offType     = 156
+	offLinkname = 157
+	offMagic    = 257
+	offUname    = 265
+	offGname    = 297
+	offDevmajor = 329
+	offDevminor = 337
+	offPrefix   = 345
+	endPrefix   = 500
+)
+
+// ustarMagic is the POSIX magic and version; GNU tar writes "ustar  \x00"
+const ustarMagic = "ustar\x0000"
+
+// block is one header block
+type block [blockSize]byte
+
+// field returns a NUL-terminated string field
+func field(b []byte) string {
+	if end := bytes.IndexByte(b, 0); end >= 0 {
+		b = b[:end]
+	}
+	return string(b)
+}
+
+// parseNumeric decodes an octal field, or a GNU base-256 one when its top
+// bit is set
+func parseNumeric(b []byte) (int64, error) {
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		// Base-256 is big-endian two's complement after the marker bit
+		negative := b[0]&0x40 != 0
+		var x uint64
+		for i, c := range b {
+			if i == 0 {
+				c &= 0x7f
+			}
+			if negative {
+				c ^= 0xff
+				if i == 0 {
+					c &= 0x7f
+				}
+			}
+			// Secure: the value must fit in 63 bits
+			if x > 1<<55-1 {
+				return 0, fmt.Errorf("numeric field overflows")
+			}
+			x = x<<8 | uint64(c)
+		}
+		if negative {
+			return -int64(x) - 1, nil
+		}
+		return int64(x), nil
+	}
+
+	s := strings.Trim(string(b), " \x00")
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(s, 8, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid numeric field %q", s)
+	}
+	return v, nil
+}
+
+// formatOctal writes v as zero-padded octal digits followed by a NUL,
+// or reports false if it does not fit
+func formatOctal(b []byte, v int64) bool {
+	digits := len(b) - 1
+	if v < 0 || (digits < 21 && v >= 1<<(3*digits)) {
+		return false
+	}
+	s := strconv.FormatInt(v, 8)
+	copy(b, strings.Repeat("0", digits-len(s))+s)
+	b[digits] = 0
+	return true
+}
+
+// checksum returns the unsigned and signed sums of a header with its
+// checksum field taken as spaces; old tars wrote the signed one
+func (b *block) checksum() (unsigned, signed int64) {
+	for i, c := range b {
+		if i >= offChecksum && i < offChecksum+8 {
+			c = ' '
+		}
+		unsigned += int64(c)
+		signed += int64(int8(c))
+	}
+	return unsigned, signed
+}
+
+// isZero reports whether a block is all zeros, as the end of an archive is
+func (b *block) isZero() bool {
+	return *b == block{}
+}
+
+// splitName splits a name into a ustar prefix and name, or reports false
+// if it cannot be split at a slash into parts that fit
+func splitName(name string) (prefix, rest string, ok bool) {
+	if len(name) <= offMode-offName {
+		return "", name, true
+	}
+	for i := len(name) - 1; i > 0; i-- {
+		if name[i] != '/' {
+			continue
+		}
+		if len(name)-i-1 > offMode-offName {
+			return "", "", false
+		}
+		if i <= endPrefix-offPrefix && i < len(name)-1 {
+			return name[:i], name[i+1:], true
+		}
+	}
+	return "", "", false
+}
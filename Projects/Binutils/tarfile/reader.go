@@ -0,0 +1,311 @@
+package tarfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// Reader reads the members of a tar archive in order. Pax extended headers
+// and GNU long names are consumed by Next and applied to the member they
+// describe.
+type Reader struct {
+	r         *bufio.Reader
+	offset    int64 // File offset of the next unread byte
+	remaining int64 // Unread bytes of the current member
+	pad       int64 // Padding after the current member
+	global    map[string]string
+}
+
+// NewReader returns a reader positioned before the first member
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next advances to the next member and returns its header. It returns
+// io.EOF at the end of the archive, marked by a zero block or by the end of
+// the input.
+func (tr *Reader) Next() (*Header, error) {
+	var local map[string]string
+	var longName, longLink string
+	start := int64(-1)
+	for {
+		// Skip what is left of the previous member
+		if err := tr.skip(tr.remaining, tr.pad); err != nil {
+			return nil, err
+		}
+
+		var b block
+		if _, err := io.ReadFull(tr.r, b[:]); err == io.EOF && start < 0 {
+			return nil, io.EOF
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		if b.isZero() {
+			if start >= 0 {
+				return nil, fmt.Errorf("archive ends inside the headers of the member at offset %d", start)
+			}
+			return nil, io.EOF
+		}
+		if start < 0 {
+			start = tr.offset
+		}
+
+		stored, err := parseNumeric(b[offChecksum : offChecksum+8])
+		if unsigned, signed := b.checksum(); err != nil || (stored != unsigned && stored != signed) {
+			return nil, fmt.Errorf("invalid header checksum at offset %d", tr.offset)
+		}
+		tr.offset += blockSize
+
+		h, err := parseHeader(&b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header at offset %d: %w", tr.offset-blockSize, err)
+		}
+		if hasData(h.Type) {
+			tr.remaining = h.Size
+			tr.pad = -h.Size & (blockSize - 1)
+		}
+
+		switch h.Type {
+		case typePAX, typePAXGlobal:
+			data, err := tr.readHeaderData()
+			if err != nil {
+				return nil, err
+			}
+			records, err := parsePAX(data)
+			if err != nil {
+				return nil, err
+			}
+			if h.Type == typePAX {
+				local = records
+				continue
+			}
+			if tr.global == nil {
+				tr.global = make(map[string]string)
+			}
+			for key, value := range records {
+				tr.global[key] = value
+			}
+			continue
+		case typeGNULong, typeGNULink:
+			data, err := tr.readHeaderData()
+			if err != nil {
+				return nil, err
+			}
+			if h.Type == typeGNULong {
+				longName = field(data)
+			} else {
+				longLink = field(data)
+			}
+			continue
+		}
+
+		h.Offset = start
+		if longName != "" {
+			h.Name = longName
+		}
+		if longLink != "" {
+			h.Linkname = longLink
+		}
+		for _, records := range []map[string]string{tr.global, local} {
+			if err := applyPAX(h, records); err != nil {
+				return nil, fmt.Errorf("invalid extended header for %s: %w", h.Name, err)
+			}
+		}
+		if hasData(h.Type) {
+			tr.remaining = h.Size
+			tr.pad = -h.Size & (blockSize - 1)
+		}
+		return h, nil
+	}
+}
+
+// parseHeader decodes the fields of a header block
+func parseHeader(b *block) (*Header, error) {
+	h := &Header{
+		Name:     field(b[offName:offMode]),
+		Type:     b[offType],
+		Linkname: field(b[offLinkname:offMagic]),
+	}
+	numbers := []struct {
+		dst      *int64
+		from, to int
+	}{{&h.Size, offSize, offDate}, {&h.Date, offDate, offChecksum}}
+	for _, n := range numbers {
+		v, err := parseNumeric(b[n.from:n.to])
+		if err != nil {
+			return nil, err
+		}
+		*n.dst = v
+	}
+	// Secure: validate size
+	if h.Size < 0 {
+		return nil, fmt.Errorf("invalid member size: %d", h.Size)
+	}
+	ints := []struct {
+		dst      *int
+		from, to int
+	}{
+		{&h.Mode, offMode, offUID}, {&h.UID, offUID, offGID}, {&h.GID, offGID, offSize},
+		{&h.Devmajor, offDevmajor, offDevminor}, {&h.Devminor, offDevminor, offPrefix},
+	}
+	for _, n := range ints {
+		v, err := parseNumeric(b[n.from:n.to])
+		if err != nil || v > 1<<31-1 || v < 0 {
+			return nil, fmt.Errorf("invalid numeric field at %d", n.from)
+		}
+		*n.dst = int(v)
+	}
+	h.Mode &= 07777
+
+	magic := string(b[offMagic:offUname])
+	if magic == ustarMagic || magic == "ustar  \x00" {
+		h.Uname = field(b[offUname:offGname])
+		h.Gname = field(b[offGname:offDevmajor])
+	}
+	// Only POSIX ustar has a prefix; GNU uses the space for other fields
+	if magic == ustarMagic {
+		if prefix := field(b[offPrefix:endPrefix]); prefix != "" {
+			h.Name = prefix + "/" + h.Name
+		}
+	}
+
+	if h.Type == 0 || h.Type == '7' { // Old regular files and contiguous files
+		h.Type = TypeReg
+		if strings.HasSuffix(h.Name, "/") {
+			h.Type = TypeDir // Pre-POSIX directories
+		}
+	}
+	return h, nil
+}
+
+// parsePAX decodes pax records of the form "length key=value\n", where
+// the length counts the whole record
+func parsePAX(data []byte) (map[string]string, error) {
+	records := make(map[string]string)
+	for len(data) > 0 {
+		space := bytes.IndexByte(data, ' ')
+		if space <= 0 {
+			return nil, fmt.Errorf("invalid pax record")
+		}
+		length, err := strconv.Atoi(string(data[:space]))
+		// Secure: the record must lie within the data and end with a newline
+		if err != nil || length <= space+1 || length > len(data) || data[length-1] != '\n' {
+			return nil, fmt.Errorf("invalid pax record length %q", data[:space])
+		}
+		key, value, ok := strings.Cut(string(data[space+1:length-1]), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid pax record %q", data[:length])
+		}
+		records[key] = value
+		data = data[length:]
+	}
+	return records, nil
+}
+
+// applyPAX overrides header fields with the pax records that set them.
+// Empty values clear a global record and are skipped.
+func applyPAX(h *Header, records map[string]string) error {
+	for key, value := range records {
+		if value == "" {
+			continue
+		}
+		var err error
+		switch key {
+		case "path":
+			h.Name = value
+		case "linkpath":
+			h.Linkname = value
+		case "uname":
+			h.Uname = value
+		case "gname":
+			h.Gname = value
+		case "size":
+			h.Size, err = strconv.ParseInt(value, 10, 64)
+			if err == nil && h.Size < 0 {
+				err = fmt.Errorf("negative size")
+			}
+		case "mtime":
+			// Fractional seconds are dropped
+			seconds, _, _ := strings.Cut(value, ".")
+			h.Date, err = strconv.ParseInt(seconds, 10, 64)
+		case "uid":
+			h.UID, err = strconv.Atoi(value)
+		case "gid":
+			h.GID, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// readHeaderData reads the data of a pax or GNU long-name member
+func (tr *Reader) readHeaderData() ([]byte, error) {
+	// Secure: bound the metadata held in memory
+	if tr.remaining > MaxHeaderSize {
+		return nil, fmt.Errorf("extended header of %d bytes exceeds %d", tr.remaining, MaxHeaderSize)
+	}
+	data := make([]byte, tr.remaining)
+	if _, err := io.ReadFull(tr, data); err != nil {
+		return nil, fmt.Errorf("failed to read extended header: %w", err)
+	}
+	return data, nil
+}
+
+// Members returns an iterator over the member headers, for use in a range
+// loop. The data of each member can be read from the reader while the loop
+// body runs. Iteration stops after the first error, which is yielded.
+func (tr *Reader) Members() iter.Seq2[*Header, error] {
+	return func(yield func(*Header, error) bool) {
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(h, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Read reads from the data of the current member
+func (tr *Reader) Read(p []byte) (int, error) {
+	if tr.remaining == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > tr.remaining {
+		p = p[:tr.remaining]
+	}
+	n, err := tr.r.Read(p)
+	tr.remaining -= int64(n)
+	tr.offset += int64(n)
+	if err == io.EOF && tr.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// skip discards the unread data of the current member and its padding,
+// which may be missing after the last member
+func (tr *Reader) skip(data, pad int64) error {
+	tr.remaining, tr.pad = 0, 0
+	for n := data + pad; n > 0; {
+		discarded, err := tr.r.Discard(int(min(n, 1<<30)))
+		n -= int64(discarded)
+		tr.offset += int64(discarded)
+		if err == io.EOF && n <= pad {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read member data: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package tarfile
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReaderFormats tests archives written by archive/tar in each format
+func TestReaderFormats(t *testing.T) {
+	longName := strings.Repeat("long/", 30) + "name"
+	for _, format := range []tar.Format{tar.FormatUSTAR, tar.FormatPAX, tar.FormatGNU} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		headers := []*tar.Header{
+			{Name: "a.txt", Mode: 0o640, Size: 3, ModTime: time.Unix(1600000000, 0), Uid: 5, Uname: "me", Format: format},
+			{Name: "sub/", Typeflag: tar.TypeDir, Mode: 0o755, ModTime: time.Unix(1600000000, 0), Format: format},
+			{Name: longName, Mode: 0o600, Size: 1, ModTime: time.Unix(1600000000, 0), Format: format},
+		}
+		if format != tar.FormatUSTAR {
+			// Neither fits in ustar: GNU stores them in base-256, pax in records
+			headers = append(headers, &tar.Header{Name: "big", Mode: 0o600, Uid: 1 << 30, ModTime: time.Unix(1600000000, 0), Format: format})
+		}
+		for _, h := range headers {
+			if err := tw.WriteHeader(h); err != nil {
+				t.Fatalf("%v: %v", format, err)
+			}
+			tw.Write(bytes.Repeat([]byte("x"), int(h.Size)))
+		}
+		tw.Close()
+
+		tr := NewReader(&buf)
+		for _, want := range headers {
+			if want.Typeflag == 0 {
+				want.Typeflag = TypeReg
+			}
+			h, err := tr.Next()
+			if err != nil {
+				t.Fatalf("%v: Next failed: %v", format, err)
+			}
+			if h.Name != want.Name || h.Mode != int(want.Mode) || h.UID != want.Uid || h.Uname != want.Uname ||
+				h.Size != want.Size || h.Date != want.ModTime.Unix() || h.Type != want.Typeflag {
+				t.Errorf("%v: read %+v, want %+v", format, h, want)
+			}
+			if data, err := io.ReadAll(tr); err != nil || len(data) != int(want.Size) {
+				t.Errorf("%v: %s data = %q, %v", format, h.Name, data, err)
+			}
+		}
+		if _, err := tr.Next(); err != io.EOF {
+			t.Errorf("%v: expected io.EOF, got %v", format, err)
+		}
+	}
+}
+
+// TestReaderSkip tests that unread data is skipped and that a missing
+// end of archive is accepted
+func TestReaderSkip(t *testing.T) {
+	var buf bytes.Buffer
+	tw := NewWriter(&buf)
+	tw.WriteMember(&Header{Name: "first"}, bytes.Repeat([]byte("a"), 1000))
+	tw.WriteMember(&Header{Name: "second"}, []byte("b"))
+	tw.writePadding()
+
+	tr := NewReader(&buf)
+	var names []string
+	for h, err := range tr.Members() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+	}
+	if strings.Join(names, ",") != "first,second" {
+		t.Errorf("members = %v", names)
+	}
+}
+
+// TestReaderInvalid tests that malformed archives are rejected
+func TestReaderInvalid(t *testing.T) {
+	var valid bytes.Buffer
+	WriteArchive(&valid, []*Member{{Header: Header{Name: "f"}, Data: []byte("data")}})
+
+	badChecksum := bytes.Clone(valid.Bytes())
+	badChecksum[0] = 'g'
+
+	truncated := valid.Bytes()[:blockSize+2]
+
+	var badPAX bytes.Buffer
+	tw := NewWriter(&badPAX)
+	tw.WriteMember(&Header{Name: "PaxHeaders/f", Type: typePAX}, []byte("99 path=f\n"))
+	tw.Close()
+
+	var hugePAX bytes.Buffer
+	tw = NewWriter(&hugePAX)
+	tw.WriteHeader(&Header{Name: "PaxHeaders/f", Type: typePAX, Size: MaxHeaderSize + 1})
+
+	var orphan bytes.Buffer
+	tw = NewWriter(&orphan)
+	tw.WriteMember(&Header{Name: "PaxHeaders/f", Type: typePAX}, []byte(paxRecord("path", "f")))
+	tw.Close()
+
+	for name, data := range map[string][]byte{
+		"checksum":  badChecksum,
+		"truncated": truncated,
+		"pax":       badPAX.Bytes(),
+		"huge pax":  hugePAX.Bytes(),
+		"orphan":    orphan.Bytes(),
+	} {
+		tr := NewReader(bytes.NewReader(data))
+		var err error
+		for _, err = range tr.Members() {
+			if err == nil {
+				_, err = io.ReadAll(tr)
+			}
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
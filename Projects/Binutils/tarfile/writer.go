@@ -0,0 +1,253 @@
+package tarfile
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+)
+
+// Member is an archive member held in memory
+type Member struct {
+	Header Header
+	Data   []byte
+}
+
+// Writer writes archive members in order. Each member is started with
+// WriteHeader and its data written with Write; the padding to a whole
+// block is added when the next member starts or the writer is closed.
+type Writer struct {
+	w         io.Writer
+	offset    int64 // File offset of the next byte to write
+	remaining int64 // Unwritten bytes of the current member
+	pad       int64 // Padding owed after the current member
+}
+
+// NewWriter returns a writer positioned before the first member
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Offset returns the file offset at which the next member header will be
+// written
+func (tw *Writer) Offset() int64 {
+	return tw.offset + tw.remaining + tw.pad
+}
+
+// WriteHeader finishes the current member and writes the header of the
+// next one. Values ustar cannot hold - long names, large sizes, dates
+// outside 1970-2242 - go in a pax extended header written first. Links,
+// directories and devices have no data and must have a zero size.
+func (tw *Writer) WriteHeader(h *Header) error {
+	if tw.remaining > 0 {
+		return fmt.Errorf("member written short: %d bytes missing", tw.remaining)
+	}
+	if err := tw.writePadding(); err != nil {
+		return err
+	}
+	// Secure: validate size
+	if h.Size < 0 || (!hasData(h.Type) && h.Size != 0) {
+		return fmt.Errorf("invalid member size: %d", h.Size)
+	}
+
+	name := h.Name
+	if h.Type == TypeDir && name != "" && name[len(name)-1] != '/' {
+		name += "/"
+	}
+	b, records, err := encodeHeader(h, name)
+	if err != nil {
+		return err
+	}
+	if len(records) > 0 {
+		if err := tw.writePAX(name, records); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tw.w.Write(b[:]); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	tw.offset += blockSize
+	tw.remaining = h.Size
+	tw.pad = -h.Size & (blockSize - 1)
+	return nil
+}
+
+// encodeHeader builds a ustar header block, returning the pax records for
+// the values it could not hold
+func encodeHeader(h *Header, name string) (*block, map[string]string, error) {
+	var b block
+	records := make(map[string]string)
+
+	prefix, rest, ok := splitName(name)
+	if !ok {
+		records["path"] = name
+		prefix, rest = "", name[:min(len(name), offMode-offName)]
+	}
+	copy(b[offName:offMode], rest)
+	copy(b[offPrefix:endPrefix], prefix)
+	if len(h.Linkname) > offMagic-offLinkname {
+		records["linkpath"] = h.Linkname
+	}
+	copy(b[offLinkname:offMagic], h.Linkname)
+	for _, s := range []struct {
+		key, value string
+		from, to   int
+	}{{"uname", h.Uname, offUname, offGname}, {"gname", h.Gname, offGname, offDevmajor}} {
+		// One byte is left for the terminating NUL
+		if len(s.value) >= s.to-s.from {
+			records[s.key] = s.value
+			continue
+		}
+		copy(b[s.from:s.to], s.value)
+	}
+
+	numbers := []struct {
+		key      string
+		value    int64
+		from, to int
+	}{
+		{"uid", int64(h.UID), offUID, offGID},
+		{"gid", int64(h.GID), offGID, offSize},
+		{"size", h.Size, offSize, offDate},
+		{"mtime", h.Date, offDate, offChecksum},
+	}
+	if !hasData(h.Type) {
+		numbers[2].value = 0
+	}
+	for _, n := range numbers {
+		if !formatOctal(b[n.from:n.to], n.value) {
+			records[n.key] = strconv.FormatInt(n.value, 10)
+			formatOctal(b[n.from:n.to], 0)
+		}
+	}
+	for _, n := range []struct {
+		value    int
+		from, to int
+	}{{h.Mode & 07777, offMode, offUID}, {h.Devmajor, offDevmajor, offDevminor}, {h.Devminor, offDevminor, offPrefix}} {
+		if !formatOctal(b[n.from:n.to], int64(n.value)) {
+			return nil, nil, fmt.Errorf("header field too wide for member %s: %d", h.Name, n.value)
+		}
+	}
+
+	b[offType] = h.Type
+	if h.Type == 0 {
+		b[offType] = TypeReg
+	}
+	copy(b[offMagic:offUname], ustarMagic)
+	sum, _ := b.checksum()
+	copy(b[offChecksum:], fmt.Sprintf("%06o\x00 ", sum))
+	return &b, records, nil
+}
+
+// writePAX writes a pax extended header holding records, sorted by key
+func (tw *Writer) writePAX(name string, records map[string]string) error {
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var data []byte
+	for _, key := range keys {
+		data = append(data, paxRecord(key, records[key])...)
+	}
+	// Secure: readers bound extended headers, so writing more is useless
+	if len(data) > MaxHeaderSize {
+		return fmt.Errorf("extended header of %d bytes exceeds %d", len(data), MaxHeaderSize)
+	}
+
+	paxName := path.Join(path.Dir(name), "PaxHeaders", path.Base(name))
+	h := &Header{Name: paxName[:min(len(paxName), offMode-offName)], Type: typePAX, Mode: 0o644, Size: int64(len(data))}
+	b, _, err := encodeHeader(h, h.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := tw.w.Write(b[:]); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	tw.offset += blockSize
+	tw.remaining = h.Size
+	tw.pad = -h.Size & (blockSize - 1)
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+	return tw.writePadding()
+}
+
+// paxRecord formats one record, whose length field counts its own digits
+func paxRecord(key, value string) string {
+	body := " " + key + "=" + value + "\n"
+	length := len(body) + 1
+	for length != len(strconv.Itoa(length))+len(body) {
+		length = len(strconv.Itoa(length)) + len(body)
+	}
+	return strconv.Itoa(length) + body
+}
+
+// Write writes data of the current member. Writing more than the size
+// given in its header is an error.
+func (tw *Writer) Write(p []byte) (int, error) {
+	if int64(len(p)) > tw.remaining {
+		return 0, fmt.Errorf("write exceeds member size by %d bytes", int64(len(p))-tw.remaining)
+	}
+	n, err := tw.w.Write(p)
+	tw.remaining -= int64(n)
+	tw.offset += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("failed to write data: %w", err)
+	}
+	return n, nil
+}
+
+// WriteMember writes a header and all of its data
+func (tw *Writer) WriteMember(h *Header, data []byte) error {
+	header := *h
+	header.Size = int64(len(data))
+	if err := tw.WriteHeader(&header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// Close finishes the last member and writes the two zero blocks that end
+// the archive. It does not close the underlying writer.
+func (tw *Writer) Close() error {
+	if tw.remaining > 0 {
+		return fmt.Errorf("member written short: %d bytes missing", tw.remaining)
+	}
+	if err := tw.writePadding(); err != nil {
+		return err
+	}
+	if _, err := tw.w.Write(make([]byte, 2*blockSize)); err != nil {
+		return fmt.Errorf("failed to write end of archive: %w", err)
+	}
+	tw.offset += 2 * blockSize
+	return nil
+}
+
+// writePadding writes the zeros that fill the last block of a member
+func (tw *Writer) writePadding() error {
+	if tw.pad == 0 {
+		return nil
+	}
+	if _, err := tw.w.Write(make([]byte, tw.pad)); err != nil {
+		return fmt.Errorf("failed to write padding: %w", err)
+	}
+	tw.offset += tw.pad
+	tw.pad = 0
+	return nil
+}
+
+// WriteArchive writes a complete archive of the members in order
+func WriteArchive(w io.Writer, members []*Member) error {
+	tw := NewWriter(w)
+	for _, member := range members {
+		if err := tw.WriteMember(&member.Header, member.Data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
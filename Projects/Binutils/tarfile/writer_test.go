@@ -0,0 +1,121 @@
+package tarfile
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testMembers returns members exercising ustar and each pax fallback
+func testMembers() []*Member {
+	deep := strings.Repeat("directory/", 15) + "file.txt" // Splits into a prefix
+	return []*Member{
+		{Header: Header{Name: "dir", Type: TypeDir, Mode: 0o755, Date: 1700000000}},
+		{Header: Header{Name: "dir/hello.txt", Type: TypeReg, Mode: 0o644, UID: 1000, GID: 1000, Uname: "user", Gname: "group", Date: 1700000000}, Data: []byte("hello\n")},
+		{Header: Header{Name: deep, Type: TypeReg, Mode: 0o600, Date: 1}, Data: bytes.Repeat([]byte{7}, blockSize+1)},
+		{Header: Header{Name: strings.Repeat("n", 300), Type: TypeReg, Mode: 0o644}, Data: []byte("long name")},
+		{Header: Header{Name: "link", Type: TypeSymlink, Linkname: strings.Repeat("../t", 40), Mode: 0o777}},
+		{Header: Header{Name: "hard", Type: TypeLink, Linkname: "dir/hello.txt"}},
+		{Header: Header{Name: "big-ids", Type: TypeReg, UID: 1 << 30, GID: 1 << 22, Uname: strings.Repeat("u", 40), Date: -86400}},
+		{Header: Header{Name: "dev", Type: TypeChar, Devmajor: 1, Devminor: 3, Mode: 0o666}},
+	}
+}
+
+// TestWriteArchiveRoundTrip tests that archives read back through both
+// this package and archive/tar
+func TestWriteArchiveRoundTrip(t *testing.T) {
+	members := testMembers()
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, members); err != nil {
+		t.Fatalf("WriteArchive failed: %v", err)
+	}
+	if buf.Len()%blockSize != 0 {
+		t.Errorf("archive of %d bytes is not whole blocks", buf.Len())
+	}
+
+	tr := NewReader(bytes.NewReader(buf.Bytes()))
+	i := 0
+	for h, err := range tr.Members() {
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		want := members[i].Header
+		if want.Type == TypeDir {
+			want.Name += "/"
+		}
+		want.Offset = h.Offset
+		want.Size = int64(len(members[i].Data))
+		if *h != want {
+			t.Errorf("member %d = %+v, want %+v", i, *h, want)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil || !bytes.Equal(data, members[i].Data) {
+			t.Errorf("member %s data = %q, %v", h.Name, data, err)
+		}
+		i++
+	}
+	if i != len(members) {
+		t.Fatalf("read %d members, want %d", i, len(members))
+	}
+
+	std := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	for _, member := range members {
+		h, err := std.Next()
+		if err != nil {
+			t.Fatalf("archive/tar failed: %v", err)
+		}
+		if strings.TrimSuffix(h.Name, "/") != member.Header.Name || h.Linkname != member.Header.Linkname ||
+			h.Uid != member.Header.UID || h.Uname != member.Header.Uname || h.ModTime.Unix() != member.Header.Date {
+			t.Errorf("archive/tar read %+v for %+v", h, member.Header)
+		}
+		if data, _ := io.ReadAll(std); !bytes.Equal(data, member.Data) {
+			t.Errorf("archive/tar read %q for %s", data, h.Name)
+		}
+	}
+	if _, err := std.Next(); err != io.EOF {
+		t.Errorf("archive/tar found more members: %v", err)
+	}
+}
+
+// TestWriterErrors tests size and field validation
+func TestWriterErrors(t *testing.T) {
+	tw := NewWriter(io.Discard)
+	if err := tw.WriteHeader(&Header{Name: "a", Size: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("12345")); err == nil {
+		t.Error("Write accepted more than the member size")
+	}
+	if err := tw.Close(); err == nil {
+		t.Error("Close accepted a short member")
+	}
+	if _, err := tw.Write([]byte("1234")); err != nil {
+		t.Fatal(err)
+	}
+	if tw.Offset() != 2*blockSize {
+		t.Errorf("Offset = %d, want %d", tw.Offset(), 2*blockSize)
+	}
+
+	for _, h := range []Header{
+		{Name: "d", Type: TypeDir, Size: 1},
+		{Name: "neg", Size: -1},
+		{Name: "dev", Type: TypeBlock, Devmajor: 1 << 21},
+	} {
+		if err := tw.WriteHeader(&h); err == nil {
+			t.Errorf("WriteHeader(%+v) succeeded", h)
+		}
+	}
+}
+
+// TestPAXRecord tests records whose length gains a digit
+func TestPAXRecord(t *testing.T) {
+	for _, value := range []string{"", "x", strings.Repeat("v", 93), strings.Repeat("v", 94), strings.Repeat("v", 1000)} {
+		record := paxRecord("path", value)
+		records, err := parsePAX([]byte(record))
+		if err != nil || records["path"] != value {
+			t.Errorf("paxRecord(%d bytes) = %q does not parse: %v", len(value), record, err)
+		}
+	}
+}
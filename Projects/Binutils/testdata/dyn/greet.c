@@ -0,0 +1,27 @@
+/*
+ * Dynamic linking fixtures for the ldd and elf.Dynamic tests. Neither the
+ * libraries nor the programs use libc, so their dependencies do not depend
+ * on the system. Built with:
+ *
+ *   gcc -shared -fPIC -nostdlib -Wl,-soname,libfar.so.1 -DFAR greet.c -o lib/libfar.so.1
+ *   gcc -shared -fPIC -nostdlib -Wl,-soname,libgreet.so.1 -DGREET greet.c lib/libfar.so.1 -o lib/libgreet.so.1
+ *   gcc -nostdlib -DMAIN greet.c lib/libgreet.so.1 -Wl,-rpath-link,lib,--enable-new-dtags,-rpath,'$ORIGIN/lib' -o runpath
+ *   gcc -nostdlib -DMAIN greet.c lib/libgreet.so.1 -Wl,-rpath-link,lib,--disable-new-dtags,-rpath,'$ORIGIN/lib' -o rpath
+ *
+ * libgreet.so.1 needs libfar.so.1 but has no search path of its own: it is
+ * found through the RPATH of the program, while a RUNPATH is not inherited.
+ */
+
+#ifdef FAR
+int far(void) { return 1; }
+#endif
+
+#ifdef GREET
+int far(void);
+int greet(void) { return far() + 1; }
+#endif
+
+#ifdef MAIN
+int greet(void);
+void _start(void) { greet(); }
+#endif
@@ -0,0 +1,18 @@
+/*
+ * Symbol version fixture for the elf.SymbolVersions and readelf -r tests.
+ * get has a hidden version V1 and a default version V2, and counter and
+ * current are in V2. Built with:
+ *
+ *   printf 'V1 { global: get; local: *; };\nV2 { global: get; counter; current; } V1;\n' > version.map
+ *   gcc -shared -fPIC -nostdlib -Wl,-soname,libversion.so.1 -Wl,--version-script,version.map version.c -o lib/libversion.so.1
+ */
+
+int counter = 1;
+int get_v1(void) { return counter; }
+int get_v2(void) { return counter + 1; }
+__asm__(".symver get_v1, get@V1");
+__asm__(".symver get_v2, get@@V2");
+
+/* Refers to its own default version through a dynamic relocation */
+extern int get(void);
+int (*current)(void) = get;
@@ -0,0 +1,28 @@
+// Test fixture for the Binutils tools: COMDAT groups from inline and template
+// functions, a static function and data in .data and .bss.
+//
+//   g++ -c -O0 hello.cpp -o hello.o
+//   g++ -g -O0 -fdebug-prefix-map=$PWD=. hello.cpp -o hello
+
+#include <new>
+
+namespace ns {
+struct Point {
+    int x;
+    explicit Point(int v) : x(v) {}
+    int get() const { return x; }
+};
+}
+
+static int sfun(int v) { return v * 2; }
+
+template <typename T> T twice(T v) { return v + v; }
+
+int counter;
+int table[4] = {1, 2, 3, 4};
+
+int main() {
+    alignas(ns::Point) char buf[sizeof(ns::Point)];
+    ns::Point *p = new (buf) ns::Point(sfun(counter));
+    return twice(p->get()) + table[1];
+}
@@ -0,0 +1,8 @@
+# Test fixture for 12_ld.go: a second definition of helper, assembled with
+#
+#   as dup.s -o dup.o
+
+	.text
+	.globl	helper
+helper:
+	ret
@@ -0,0 +1,28 @@
+# Test fixture for 12_ld.go: a 16-byte aligned function and data in
+# .rodata, .data and .bss, assembled with
+#
+#   as helper.s -o helper.o
+
+	.text
+	.align	16
+	.globl	helper
+helper:
+	mov	$7, %eax
+	ret
+
+	.section .rodata
+	.globl	value
+	.align	4
+value:
+	.long	30
+
+	.data
+	.globl	counter
+	.align	16
+counter:
+	.long	5
+
+	.bss
+	.align	32
+buffer:
+	.zero	64
@@ -0,0 +1,22 @@
+# Test fixtures for 12_ld.go, assembled with
+#
+#   as start.s -o start.o
+#
+# _start exits with helper() + counter + *ptr, which is 42 once the
+# R_X86_64_PLT32, R_X86_64_PC32 and R_X86_64_64 relocations are applied.
+
+	.text
+	.globl	_start
+_start:
+	call	helper
+	add	counter(%rip), %eax
+	mov	ptr(%rip), %rdi
+	add	(%rdi), %eax
+	mov	%eax, %edi
+	mov	$60, %eax
+	syscall
+
+	.data
+	.align	8
+ptr:
+	.quad	value
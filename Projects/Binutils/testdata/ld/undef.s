@@ -0,0 +1,10 @@
+# Test fixture for 12_ld.go: a reference to a symbol nothing defines,
+# assembled with
+#
+#   as undef.s -o undef.o
+
+	.text
+	.globl	caller
+caller:
+	call	missing
+	ret
@@ -0,0 +1,18 @@
+
+String dump of section '.comment':
+  [     1]  GCC: (Debian 12.2.0-14+deb12u1) 12.2.0
+
+
+String dump of section '.strtab':
+  [     1]  hello.cpp
+  [     b]  _ZL4sfuni
+  [    15]  _ZN2ns5PointC5Ei
+  [    26]  _ZnwmPv
+  [    2e]  _ZN2ns5PointC2Ei
+  [    3f]  _ZN2ns5PointC1Ei
+  [    50]  _ZNK2ns5Point3getEv
+  [    64]  counter
+  [    6c]  table
+  [    72]  main
+  [    77]  _Z5twiceIiET_S0_
+
@@ -0,0 +1,18 @@
+
+Relocation section '.rela.text' at offset 0x450 contains 6 entries:
+  Offset          Info           Type           Sym. Value    Sym. Name + Addend
+000000000024  000900000004 R_X86_64_PLT32    0000000000000000 _ZnwmPv - 4
+00000000002d  000d00000002 R_X86_64_PC32     0000000000000000 counter - 4
+00000000003e  000b00000004 R_X86_64_PLT32    0000000000000000 _ZN2ns5PointC1Ei - 4
+00000000004e  000c00000004 R_X86_64_PLT32    0000000000000000 _ZNK2ns5Point3getEv - 4
+000000000055  001000000004 R_X86_64_PLT32    0000000000000000 _Z5twiceIiET_S0_ - 4
+00000000005b  000e00000002 R_X86_64_PC32     0000000000000000 table + 0
+
+Relocation section '.rela.eh_frame' at offset 0x4e0 contains 6 entries:
+  Offset          Info           Type           Sym. Value    Sym. Name + Addend
+000000000020  000300000002 R_X86_64_PC32     0000000000000000 .text._ZnwmPv + 0
+000000000040  000400000002 R_X86_64_PC32     0000000000000000 .text._ZN2ns5PointC2Ei + 0
+000000000060  000500000002 R_X86_64_PC32     0000000000000000 .text._ZNK2ns5Poi[...] + 0
+000000000080  000200000002 R_X86_64_PC32     0000000000000000 .text + 0
+0000000000a0  000200000002 R_X86_64_PC32     0000000000000000 .text + e
+0000000000c4  000700000002 R_X86_64_PC32     0000000000000000 .text._Z5twiceIiET_S0_ + 0
@@ -0,0 +1,16 @@
+
+Hex dump of section '.text':
+ NOTE: This section has relocations against it, but these have NOT been applied to this dump.
+  0x00000000 554889e5 897dfc8b 45fc01c0 5dc35548 UH...}..E...].UH
+  0x00000010 89e55348 83ec1848 8d45e448 89c6bf04 ..SH...H.E.H....
+  0x00000020 000000e8 00000000 4889c38b 05000000 ........H.......
+  0x00000030 0089c7e8 c8ffffff 89c64889 dfe80000 ..........H.....
+  0x00000040 00004889 5de8488b 45e84889 c7e80000 ..H.].H.E.H.....
+  0x00000050 000089c7 e8000000 008b1500 00000001 ................
+  0x00000060 d0488b5d f8c9c3                     .H.]...
+
+
+Hex dump of section '.data':
+  0x00000000 01000000 02000000 03000000 04000000 ................
+
+Section '.bss' has no data to dump.
@@ -0,0 +1,11 @@
+
+Relocation section '.rela.dyn' at offset 0x520 contains 8 entries:
+  Offset          Info           Type           Sym. Value    Sym. Name + Addend
+000000003e00  000000000008 R_X86_64_RELATIVE                    1120
+000000003e08  000000000008 R_X86_64_RELATIVE                    10e0
+000000004008  000000000008 R_X86_64_RELATIVE                    4008
+000000003fc0  000100000006 R_X86_64_GLOB_DAT 0000000000000000 __libc_start_main@GLIBC_2.34 + 0
+000000003fc8  000200000006 R_X86_64_GLOB_DAT 0000000000000000 _ITM_deregisterTM[...] + 0
+000000003fd0  000300000006 R_X86_64_GLOB_DAT 0000000000000000 __gmon_start__ + 0
+000000003fd8  000400000006 R_X86_64_GLOB_DAT 0000000000000000 _ITM_registerTMCl[...] + 0
+000000003fe0  000500000006 R_X86_64_GLOB_DAT 0000000000000000 __cxa_finalize@GLIBC_2.2.5 + 0
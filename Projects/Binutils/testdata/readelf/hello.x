@@ -0,0 +1,8 @@
+
+Hex dump of section '.rodata':
+  0x00002000 01000200                            ....
+
+
+Hex dump of section '.init_array':
+  0x00003e00 20110000 00000000                    .......
+
@@ -0,0 +1,5 @@
+
+Relocation section '.rela.dyn' at offset 0x3e8 contains 2 entries:
+  Offset          Info           Type           Sym. Value    Sym. Name + Addend
+000000003fe0  000400000006 R_X86_64_GLOB_DAT 0000000000004000 counter@@V2 + 0
+000000004008  000300000001 R_X86_64_64       000000000000100f get@@V2 + 0
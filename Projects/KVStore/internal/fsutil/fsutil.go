@@ -0,0 +1,16 @@
+// Package fsutil holds the file system helpers shared by the store's
+// packages.
+package fsutil
+
+import "os"
+
+// SyncDir syncs the directory dir, making the creation, renaming and
+// removal of files in it durable
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
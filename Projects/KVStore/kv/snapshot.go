@@ -0,0 +1,162 @@
+package kv
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"hellogolang/Projects/KVStore/internal/fsutil"
+	"hellogolang/Projects/KVStore/wal"
+)
+
+// ErrBadSnapshot is returned by Open when the latest snapshot fails its
+// checks
+var ErrBadSnapshot = errors.New("bad snapshot")
+
+// snapshotMagic starts every snapshot file, naming the format and its
+// version
+var snapshotMagic = []byte("KVSNAP01")
+
+// snapshotExt is the extension of snapshot files
+const snapshotExt = ".snap"
+
+// snapshotName returns the file name of the snapshot up to seq
+func snapshotName(seq uint64) string {
+	return fmt.Sprintf("%020d%s", seq, snapshotExt)
+}
+
+// snapshots returns the sequence numbers of the snapshots in dir, in
+// increasing order
+func snapshots(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var seqs []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), snapshotExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		if seq, err := strconv.ParseUint(name, 10, 64); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	slices.Sort(seqs)
+	return seqs, nil
+}
+
+// writeSnapshot writes entries, the whole store as of record seq, to the
+// snapshot file of seq. The file is the magic, then seq, the entry count
+// and each key and value with its length, all as uvarints, and finally
+// the CRC-32C of everything before it. It is written to a temporary file,
+// synced and renamed into place, so a crash leaves either no snapshot or
+// a whole one.
+func writeSnapshot(dir string, seq uint64, entries []Entry) error {
+	tmp, err := os.CreateTemp(dir, "snapshot-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed
+
+	crc := crc32.New(wal.CRCTable)
+	w := bufio.NewWriter(io.MultiWriter(tmp, crc))
+	var buf []byte
+	buf = append(buf, snapshotMagic...)
+	buf = binary.AppendUvarint(buf, seq)
+	buf = binary.AppendUvarint(buf, uint64(len(entries)))
+	w.Write(buf)
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf[:0], uint64(len(e.Key)))
+		buf = append(buf, e.Key...)
+		buf = binary.AppendUvarint(buf, uint64(len(e.Value)))
+		w.Write(buf)
+		w.Write(e.Value)
+	}
+	err = w.Flush()
+	if err == nil {
+		_, err = tmp.Write(crc.Sum(nil))
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, snapshotName(seq))); err != nil {
+		return err
+	}
+	return fsutil.SyncDir(dir)
+}
+
+// readSnapshot reads the snapshot file at path, calling fn with each
+// entry, and returns the sequence number it was taken at
+func readSnapshot(path string, fn func(key string, value []byte)) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	bad := func(what string) error {
+		return fmt.Errorf("%w: %s: %s", ErrBadSnapshot, filepath.Base(path), what)
+	}
+	if len(data) < len(snapshotMagic)+crc32.Size || !strings.HasPrefix(string(data), string(snapshotMagic)) {
+		return 0, bad("not a snapshot")
+	}
+	body, sum := data[:len(data)-crc32.Size], data[len(data)-crc32.Size:]
+	if crc32.Checksum(body, wal.CRCTable) != binary.BigEndian.Uint32(sum) {
+		return 0, bad("checksum mismatch")
+	}
+
+	p := body[len(snapshotMagic):]
+	next := func() (uint64, bool) {
+		v, n := binary.Uvarint(p)
+		if n <= 0 {
+			return 0, false
+		}
+		p = p[n:]
+		return v, true
+	}
+	field := func() ([]byte, bool) {
+		n, ok := next()
+		if !ok || n > uint64(len(p)) {
+			return nil, false
+		}
+		b := p[:n]
+		p = p[n:]
+		return b, true
+	}
+	seq, ok := next()
+	if !ok {
+		return 0, bad("bad header")
+	}
+	count, ok := next()
+	if !ok {
+		return 0, bad("bad header")
+	}
+	for i := uint64(0); i < count; i++ {
+		key, ok := field()
+		if !ok {
+			return 0, bad(fmt.Sprintf("bad key of entry %d", i))
+		}
+		value, ok := field()
+		if !ok {
+			return 0, bad(fmt.Sprintf("bad value of entry %d", i))
+		}
+		fn(string(key), slices.Clone(value))
+	}
+	if len(p) != 0 {
+		return 0, bad("trailing data")
+	}
+	return seq, nil
+}
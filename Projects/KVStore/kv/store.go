@@ -0,0 +1,348 @@
+// Package kv is the key-value store: an ordered in-memory B-tree of keys
+// and values made durable by a write-ahead log. Every Set and Delete is
+// appended to the log before it is applied, and Open rebuilds the tree
+// after a crash from the latest snapshot and the log records after it.
+// Snapshot, run every SnapshotInterval, writes the whole tree to a
+// snapshot file and deletes the log segments it stands in for, so the log
+// does not grow without bound.
+package kv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"hellogolang/Algorithms/datastructures"
+	"hellogolang/Projects/KVStore/internal/fsutil"
+	"hellogolang/Projects/KVStore/wal"
+)
+
+var (
+	// ErrClosed is returned by changes to a closed store
+	ErrClosed = errors.New("store closed")
+	// ErrInvalidKey is returned for an empty key or one over MaxKeySize
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrValueTooLarge is returned for a value over MaxValueSize
+	ErrValueTooLarge = errors.New("value too large")
+)
+
+// Defaults for zero Options fields
+const (
+	DefaultSnapshotInterval = 5 * time.Minute
+	DefaultMaxKeySize       = 1 << 10
+	DefaultMaxValueSize     = 1 << 20
+)
+
+// btreeDegree is the minimum degree of the tree of keys; wide nodes keep
+// it shallow
+const btreeDegree = 32
+
+// Options configures a Store. Zero fields take their defaults.
+type Options struct {
+	// WAL configures the write-ahead log, its sync policy above all
+	WAL wal.Options
+	// SnapshotInterval is how often a snapshot is taken,
+	// DefaultSnapshotInterval if 0 and never if negative
+	SnapshotInterval time.Duration
+	// OnSnapshot, if set, is called after each periodic snapshot with the
+	// sequence number it was taken at and its error
+	OnSnapshot func(seq uint64, err error)
+	// MaxKeySize bounds the bytes of a key, DefaultMaxKeySize if 0 or less
+	MaxKeySize int
+	// MaxValueSize bounds the bytes of a value, DefaultMaxValueSize if 0
+	// or less
+	MaxValueSize int
+}
+
+// Entry is a key and its value
+type Entry struct {
+	Key   string
+	Value []byte
+}
+
+// Store is a durable, ordered key-value store. It is safe for concurrent
+// use.
+type Store struct {
+	dir  string
+	opts Options
+
+	mu     sync.RWMutex
+	tree   *datastructures.BTree[string, []byte] // Values are never modified in place
+	log    *wal.Log
+	closed bool
+
+	snapMu  sync.Mutex // Serializes snapshots
+	snapSeq uint64     // The sequence number of the latest snapshot
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open opens the store in dir, creating it if need be, and recovers its
+// contents: the latest snapshot, then the log records after it. A torn
+// record at the end of the log, left by a crash while writing it, is
+// dropped; any other damage fails Open with wal.ErrCorrupt or
+// ErrBadSnapshot.
+func Open(dir string, opts Options) (*Store, error) {
+	if opts.SnapshotInterval == 0 {
+		opts.SnapshotInterval = DefaultSnapshotInterval
+	}
+	if opts.MaxKeySize <= 0 {
+		opts.MaxKeySize = DefaultMaxKeySize
+	}
+	if opts.MaxValueSize <= 0 {
+		opts.MaxValueSize = DefaultMaxValueSize
+	}
+	if opts.WAL.MaxRecordSize <= 0 {
+		// Room for the largest key and value and the record's fields
+		opts.WAL.MaxRecordSize = opts.MaxKeySize + opts.MaxValueSize + 32
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	// Temporary files of snapshots a crash interrupted
+	if leftovers, err := filepath.Glob(filepath.Join(dir, "snapshot-*.tmp")); err == nil {
+		for _, f := range leftovers {
+			os.Remove(f)
+		}
+	}
+
+	s := &Store{dir: dir, opts: opts, tree: datastructures.NewBTree[string, []byte](btreeDegree)}
+	snaps, err := snapshots(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(snaps) > 0 {
+		latest := filepath.Join(dir, snapshotName(snaps[len(snaps)-1]))
+		if s.snapSeq, err = readSnapshot(latest, func(key string, value []byte) {
+			s.tree.Insert(key, value)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	last, err := wal.Replay(dir, s.snapSeq, opts.WAL, func(r wal.Record) error {
+		s.apply(r.Op, r.Key, r.Value)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("replaying the log: %w", err)
+	}
+	if s.log, err = wal.Open(dir, last+1, opts.WAL); err != nil {
+		return nil, err
+	}
+
+	if opts.SnapshotInterval > 0 {
+		s.stop, s.done = make(chan struct{}), make(chan struct{})
+		go s.snapshotLoop()
+	}
+	return s, nil
+}
+
+// apply applies a change to the tree
+func (s *Store) apply(op wal.Op, key string, value []byte) {
+	switch op {
+	case wal.OpSet:
+		s.tree.Insert(key, value)
+	case wal.OpDelete:
+		s.tree.Delete(key)
+	}
+}
+
+// checkKey returns ErrInvalidKey if key is empty or too long
+func (s *Store) checkKey(key string) error {
+	if key == "" || len(key) > s.opts.MaxKeySize {
+		return fmt.Errorf("%w: %d bytes, want 1 to %d", ErrInvalidKey, len(key), s.opts.MaxKeySize)
+	}
+	return nil
+}
+
+// Get returns a copy of the value of key and whether it is present
+func (s *Store) Get(key string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.tree.Get(key)
+	return slices.Clone(v), ok
+}
+
+// Set sets key to a copy of value, once the change is in the log
+func (s *Store) Set(key string, value []byte) error {
+	if err := s.checkKey(key); err != nil {
+		return err
+	}
+	if len(value) > s.opts.MaxValueSize {
+		return fmt.Errorf("%w: %d bytes, at most %d", ErrValueTooLarge, len(value), s.opts.MaxValueSize)
+	}
+	value = slices.Clone(value)
+	if value == nil {
+		value = []byte{}
+	}
+	return s.change(wal.OpSet, key, value)
+}
+
+// Delete removes key, once the change is in the log, and reports whether
+// it was present. Deleting an absent key writes nothing.
+func (s *Store) Delete(key string) (bool, error) {
+	if err := s.checkKey(key); err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	_, ok := s.tree.Get(key)
+	s.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	// A racing Delete may have removed it since; logging it again is
+	// harmless
+	return true, s.change(wal.OpDelete, key, nil)
+}
+
+// change logs a change and applies it. The lock is held across both, so
+// the log's order is the order changes are applied in.
+func (s *Store) change(op wal.Op, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if _, err := s.log.Append(op, key, value); err != nil {
+		return err
+	}
+	s.apply(op, key, value)
+	return nil
+}
+
+// Scan returns the entries whose keys start with prefix, in key order, at
+// most limit of them, or all if limit is 0 or less. The values are
+// copies.
+func (s *Store) Scan(prefix string, limit int) []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seq := s.tree.All()
+	if end, ok := prefixEnd(prefix); ok {
+		seq = s.tree.Range(prefix, end)
+	}
+	var out []Entry
+	for k, v := range seq {
+		if limit > 0 && len(out) == limit {
+			break
+		}
+		if strings.HasPrefix(k, prefix) {
+			out = append(out, Entry{Key: k, Value: slices.Clone(v)})
+		}
+	}
+	return out
+}
+
+// prefixEnd returns the least key above every key starting with prefix,
+// or false if there is none, as for "" or a prefix of 0xFF bytes
+func prefixEnd(prefix string) (string, bool) {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xFF {
+			b[i]++
+			return string(b[:i+1]), true
+		}
+	}
+	return "", false
+}
+
+// Len returns the number of keys
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Len()
+}
+
+// Snapshot writes the whole store to a snapshot file and deletes the
+// older snapshots and the log segments the new one stands in for,
+// returning the sequence number of the last record it holds. The log is
+// rotated and the entries copied under the store's lock, so changes are
+// blocked only that long, not while the file is written.
+func (s *Store) Snapshot() (uint64, error) {
+	s.snapMu.Lock()
+	defer s.snapMu.Unlock()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return 0, ErrClosed
+	}
+	boundary, err := s.log.Rotate()
+	if err != nil {
+		s.mu.Unlock()
+		return 0, err
+	}
+	seq := boundary - 1
+	var entries []Entry
+	if seq != s.snapSeq {
+		entries = make([]Entry, 0, s.tree.Len())
+		for k, v := range s.tree.All() {
+			entries = append(entries, Entry{Key: k, Value: v})
+		}
+	}
+	s.mu.Unlock()
+	if seq == s.snapSeq {
+		return seq, nil // Nothing changed since the last one
+	}
+
+	if err := writeSnapshot(s.dir, seq, entries); err != nil {
+		return 0, err
+	}
+	s.snapSeq = seq
+	// The new snapshot is durable, so what it replaces can go
+	if err := s.log.RemoveBefore(boundary); err != nil {
+		return seq, err
+	}
+	snaps, err := snapshots(s.dir)
+	if err != nil {
+		return seq, err
+	}
+	for _, old := range snaps {
+		if old < seq {
+			if err := os.Remove(filepath.Join(s.dir, snapshotName(old))); err != nil {
+				return seq, err
+			}
+		}
+	}
+	return seq, fsutil.SyncDir(s.dir)
+}
+
+// snapshotLoop takes a snapshot every SnapshotInterval until the store
+// is closed
+func (s *Store) snapshotLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.SnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			seq, err := s.Snapshot()
+			if s.opts.OnSnapshot != nil {
+				s.opts.OnSnapshot(seq, err)
+			}
+		}
+	}
+}
+
+// Close stops the periodic snapshots and closes the log, syncing it
+func (s *Store) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	s.closed = true
+	s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+	}
+	return s.log.Close()
+}
@@ -0,0 +1,256 @@
+package kv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hellogolang/Projects/KVStore/wal"
+)
+
+// open opens a store in dir without periodic snapshots
+func open(t *testing.T, dir string) *Store {
+	t.Helper()
+	s, err := Open(dir, Options{SnapshotInterval: -1, WAL: wal.Options{Sync: wal.SyncNever}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return s
+}
+
+// TestSetGetDelete tests the basic operations
+func TestSetGetDelete(t *testing.T) {
+	s := open(t, t.TempDir())
+	defer s.Close()
+
+	if _, ok := s.Get("a"); ok {
+		t.Error("Get of an absent key found it")
+	}
+	value := []byte("1")
+	if err := s.Set("a", value); err != nil {
+		t.Fatal(err)
+	}
+	value[0] = 'x' // The store keeps its own copy
+	if v, ok := s.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("Get = %q, %v, want 1", v, ok)
+	}
+	if err := s.Set("a", nil); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := s.Get("a"); !ok || len(v) != 0 {
+		t.Errorf("Get of an empty value = %q, %v", v, ok)
+	}
+	if ok, err := s.Delete("a"); !ok || err != nil {
+		t.Errorf("Delete = %v, %v, want true", ok, err)
+	}
+	if ok, err := s.Delete("a"); ok || err != nil {
+		t.Errorf("second Delete = %v, %v, want false", ok, err)
+	}
+	if s.Len() != 0 {
+		t.Errorf("Len = %d, want 0", s.Len())
+	}
+}
+
+// TestScan tests prefix scans and their limit
+func TestScan(t *testing.T) {
+	s := open(t, t.TempDir())
+	defer s.Close()
+	for _, k := range []string{"user:2", "user:1", "users", "user;", "item:1", "user\xff"} {
+		s.Set(k, []byte(k))
+	}
+
+	keys := func(entries []Entry) string {
+		var out string
+		for _, e := range entries {
+			out += e.Key + " "
+		}
+		return out
+	}
+	tests := []struct {
+		prefix string
+		limit  int
+		want   string
+	}{
+		{"user:", 0, "user:1 user:2 "},
+		{"user", 0, "user:1 user:2 user; users user\xff "},
+		{"user", 2, "user:1 user:2 "},
+		{"", 2, "item:1 user:1 "},
+		{"user\xff", 0, "user\xff "},
+		{"none", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := keys(s.Scan(tt.prefix, tt.limit)); got != tt.want {
+			t.Errorf("Scan(%q, %d) = %q, want %q", tt.prefix, tt.limit, got, tt.want)
+		}
+	}
+}
+
+// TestLimits tests key and value validation and a closed store
+func TestLimits(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{SnapshotInterval: -1, MaxKeySize: 4, MaxValueSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("", nil); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Set of an empty key = %v, want ErrInvalidKey", err)
+	}
+	if err := s.Set("toolong", nil); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Set of a long key = %v, want ErrInvalidKey", err)
+	}
+	if err := s.Set("k", make([]byte, 9)); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Set of a large value = %v, want ErrValueTooLarge", err)
+	}
+	if err := s.Set("k", make([]byte, 8)); err != nil {
+		t.Errorf("Set at the limits = %v", err)
+	}
+	s.Close()
+	if err := s.Set("k", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Set after Close = %v, want ErrClosed", err)
+	}
+	if _, err := s.Delete("k"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Delete after Close = %v, want ErrClosed", err)
+	}
+	if err := s.Close(); !errors.Is(err, ErrClosed) {
+		t.Errorf("second Close = %v, want ErrClosed", err)
+	}
+}
+
+// TestRecovery tests that changes survive a reopen, with and without a
+// clean Close
+func TestRecovery(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir)
+	for i := range 100 {
+		s.Set(fmt.Sprintf("k%03d", i), []byte{byte(i)})
+	}
+	for i := 0; i < 100; i += 2 {
+		s.Delete(fmt.Sprintf("k%03d", i))
+	}
+	s.Close()
+
+	s = open(t, dir)
+	if s.Len() != 50 {
+		t.Errorf("Len after reopen = %d, want 50", s.Len())
+	}
+	if v, ok := s.Get("k051"); !ok || v[0] != 51 {
+		t.Errorf("Get(k051) = %v, %v", v, ok)
+	}
+	// Abandoned without Close, as a crash leaves it; the records are
+	// written, just not synced
+	s.Set("crash", []byte("survives"))
+
+	s = open(t, dir)
+	defer s.Close()
+	if v, ok := s.Get("crash"); !ok || string(v) != "survives" {
+		t.Errorf("Get after a crash = %q, %v", v, ok)
+	}
+}
+
+// TestSnapshot tests that a snapshot replaces the log it covers and the
+// store recovers from it and the records after it
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir)
+	for i := range 10 {
+		s.Set(fmt.Sprintf("k%d", i), []byte("before"))
+	}
+	seq, err := s.Snapshot()
+	if err != nil || seq != 10 {
+		t.Fatalf("Snapshot = %d, %v, want 10", seq, err)
+	}
+	if again, err := s.Snapshot(); err != nil || again != 10 {
+		t.Errorf("Snapshot with no changes = %d, %v, want 10", again, err)
+	}
+	s.Set("k0", []byte("after"))
+	s.Delete("k1")
+	if seq, err := s.Snapshot(); err != nil || seq != 12 {
+		t.Fatalf("second Snapshot = %d, %v, want 12", seq, err)
+	}
+	s.Set("k2", []byte("logged"))
+	s.Close()
+
+	if snaps, _ := snapshots(dir); len(snaps) != 1 || snaps[0] != 12 {
+		t.Errorf("snapshots %v, want only 12", snaps)
+	}
+	if segs, _ := filepath.Glob(filepath.Join(dir, "*.wal")); len(segs) != 1 {
+		t.Errorf("log segments %v, want one", segs)
+	}
+
+	s = open(t, dir)
+	defer s.Close()
+	want := map[string]string{"k0": "after", "k2": "logged", "k9": "before"}
+	for k, v := range want {
+		if got, ok := s.Get(k); !ok || string(got) != v {
+			t.Errorf("Get(%s) = %q, %v, want %q", k, got, ok, v)
+		}
+	}
+	if _, ok := s.Get("k1"); ok {
+		t.Error("deleted k1 came back")
+	}
+	if s.Len() != 9 {
+		t.Errorf("Len = %d, want 9", s.Len())
+	}
+}
+
+// TestBadSnapshot tests that a damaged snapshot fails Open
+func TestBadSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir)
+	s.Set("k", []byte("v"))
+	seq, _ := s.Snapshot()
+	s.Close()
+
+	path := filepath.Join(dir, snapshotName(seq))
+	data, _ := os.ReadFile(path)
+	data[len(snapshotMagic)+2] ^= 0x01
+	os.WriteFile(path, data, 0o600)
+	if _, err := Open(dir, Options{SnapshotInterval: -1}); !errors.Is(err, ErrBadSnapshot) {
+		t.Errorf("Open with a damaged snapshot = %v, want ErrBadSnapshot", err)
+	}
+}
+
+// TestSnapshotLoop tests that snapshots are taken periodically
+func TestSnapshotLoop(t *testing.T) {
+	taken := make(chan uint64, 1)
+	s, err := Open(t.TempDir(), Options{
+		SnapshotInterval: 10 * time.Millisecond,
+		OnSnapshot: func(seq uint64, err error) {
+			if err == nil && seq > 0 {
+				select {
+				case taken <- seq:
+				default:
+				}
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.Set("k", nil)
+	if seq := <-taken; seq != 1 {
+		t.Errorf("periodic snapshot at %d, want 1", seq)
+	}
+}
+
+// TestPrefixEnd tests the bound of prefix scans
+func TestPrefixEnd(t *testing.T) {
+	tests := []struct {
+		prefix, want string
+		ok           bool
+	}{
+		{"", "", false},
+		{"a", "b", true},
+		{"ab", "ac", true},
+		{"a\xff", "b", true},
+		{"\xff\xff", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := prefixEnd(tt.prefix); got != tt.want || ok != tt.ok {
+			t.Errorf("prefixEnd(%q) = %q, %v, want %q, %v", tt.prefix, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"hellogolang/Advanced/logx"
+	"hellogolang/Advanced/netserver"
+	"hellogolang/Projects/KVStore/kv"
+	"hellogolang/Projects/KVStore/server"
+	"hellogolang/Projects/KVStore/wal"
+)
+
+// KVStore - A durable key-value store served over TCP with a text protocol
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:7070", "address to listen on")
+	dir := flag.String("dir", "kvdata", "directory of the log and snapshots")
+	syncFlag := flag.String("sync", "always", "when the log is synced: always, interval or never")
+	syncInterval := flag.Duration("sync-interval", time.Second, "how often the log is synced with -sync interval")
+	snapshotInterval := flag.Duration("snapshot-interval", kv.DefaultSnapshotInterval, "how often a snapshot is taken, never if negative")
+	maxConns := flag.Int("max-conns", netserver.DefaultMaxConns, "connections served at once")
+	flag.Parse()
+
+	logger := logx.New(os.Stderr, logx.Options{})
+	policy, err := parseSync(*syncFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	started := time.Now()
+	store, err := kv.Open(*dir, kv.Options{
+		WAL:              wal.Options{Sync: policy, SyncInterval: *syncInterval},
+		SnapshotInterval: *snapshotInterval,
+		OnSnapshot: func(seq uint64, err error) {
+			if err != nil {
+				logger.Error("snapshot failed", "err", err)
+				return
+			}
+			logger.Info("snapshot taken", "seq", seq)
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: opening %s: %v\n", *dir, err)
+		os.Exit(1)
+	}
+	logger.Info("store recovered", "dir", *dir, "keys", store.Len(), "elapsed", time.Since(started))
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		store.Close()
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	srv := netserver.New(server.Handler(store, server.Options{}), netserver.Options{
+		MaxConns: *maxConns,
+		OnError: func(c *netserver.Conn, err error) {
+			if c == nil {
+				logger.Error("accept failed", "err", err)
+				return
+			}
+			logger.Error("connection failed", "conn", c.ID(), "err", err)
+		},
+	})
+	logger.Info("listening", "addr", listener.Addr().String())
+
+	// Run returns on SIGINT or SIGTERM once connections drain; closing the
+	// store then syncs the log
+	failed := false
+	if err := srv.Run(context.Background(), listener, 5*time.Second); err != nil {
+		logger.Error("server stopped", "err", err)
+		failed = true
+	}
+	if err := store.Close(); err != nil {
+		logger.Error("closing the store", "err", err)
+		failed = true
+	}
+	logger.Info("stopped")
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// parseSync parses the -sync flag
+func parseSync(s string) (wal.SyncPolicy, error) {
+	switch s {
+	case "always":
+		return wal.SyncAlways, nil
+	case "interval":
+		return wal.SyncInterval, nil
+	case "never":
+		return wal.SyncNever, nil
+	}
+	return 0, fmt.Errorf("unknown sync policy %q", s)
+}
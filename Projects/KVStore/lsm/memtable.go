@@ -0,0 +1,99 @@
+// Package lsm is a log-structured merge-tree storage engine, the on-disk
+// alternative to the store in package kv for data larger than memory.
+// Writes go to the write-ahead log and a Memtable, a skip list in memory.
+// Once the memtable reaches MemtableSize it is frozen, a fresh one takes
+// the writes, and the frozen one is flushed to an SSTable: an immutable
+// file of sorted blocks with a sparse index and a Bloom filter. Flushed
+// tables form level 0, where key ranges overlap; when L0Limit of them
+// accumulate, a compaction merges them with the single level 1 table into
+// a new one, dropping overwritten values and deleted keys. Reads consult
+// the memtables, then the tables from newest to oldest, and Merge
+// combines them all into one ordered stream for scans.
+package lsm
+
+import (
+	"iter"
+	"sync/atomic"
+
+	"hellogolang/Algorithms/datastructures"
+)
+
+// Entry is a key and its value, or the tombstone left by deleting it,
+// which hides the older values of the key until a compaction drops both
+type Entry struct {
+	Key       string
+	Value     []byte
+	Tombstone bool
+}
+
+// memtableOverhead approximates the memory each entry of a memtable takes
+// beyond its key and value: its skip list node and links
+const memtableOverhead = 64
+
+// slot is the value of a key in a memtable
+type slot struct {
+	value     []byte
+	tombstone bool
+}
+
+// Memtable holds the latest writes in memory, in key order. It is safe
+// for concurrent use.
+type Memtable struct {
+	list *datastructures.SkipList[string, slot]
+	size atomic.Int64
+}
+
+// NewMemtable creates an empty memtable
+func NewMemtable() *Memtable {
+	return &Memtable{list: datastructures.NewSkipList[string, slot]()}
+}
+
+// Put sets key to value, keeping value, which must not be modified after
+func (m *Memtable) Put(key string, value []byte) {
+	m.list.Insert(key, slot{value: value})
+	m.size.Add(int64(len(key) + len(value) + memtableOverhead))
+}
+
+// Delete leaves a tombstone for key
+func (m *Memtable) Delete(key string) {
+	m.list.Insert(key, slot{tombstone: true})
+	m.size.Add(int64(len(key) + memtableOverhead))
+}
+
+// Get returns the entry of key, a tombstone if it was deleted, and
+// whether the memtable holds either
+func (m *Memtable) Get(key string) (Entry, bool) {
+	s, ok := m.list.Get(key)
+	if !ok {
+		return Entry{}, false
+	}
+	return Entry{Key: key, Value: s.value, Tombstone: s.tombstone}, true
+}
+
+// Len returns the number of keys, tombstones included
+func (m *Memtable) Len() int {
+	return m.list.Len()
+}
+
+// Size returns the bytes written to the memtable, overwritten entries
+// included, which bounds the memory it takes
+func (m *Memtable) Size() int {
+	return int(m.size.Load())
+}
+
+// Range iterates over the entries with keys in [lo, hi) in key order,
+// with no upper bound if hi is "". It never yields an error; the error is
+// there to merge memtables with tables.
+func (m *Memtable) Range(lo, hi string) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		seq := m.list.From(lo)
+		if hi != "" {
+			seq = m.list.Range(lo, hi)
+		}
+		for k, s := range seq {
+			if !yield(Entry{Key: k, Value: s.value, Tombstone: s.tombstone}, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package lsm
+
+import (
+	"container/heap"
+	"iter"
+)
+
+// Merge merges sources, each in increasing key order, into one sequence
+// in increasing key order. Where several sources hold a key, the entry of
+// the first of them wins and the others are skipped, so sources are
+// passed newest first. The first error from a source is yielded and ends
+// the merge. Tombstones are yielded like other entries.
+// Time Complexity: O(n log k) for n entries from k sources
+func Merge(sources ...iter.Seq2[Entry, error]) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		h := make(mergeHeap, 0, len(sources))
+		for i, src := range sources {
+			next, stop := iter.Pull2(src)
+			defer stop()
+			c := &cursor{next: next, rank: i}
+			if c.advance() {
+				if c.err != nil {
+					yield(Entry{}, c.err)
+					return
+				}
+				h = append(h, c)
+			}
+		}
+		heap.Init(&h)
+		for len(h) > 0 {
+			top := h[0]
+			e := top.entry
+			if !yield(e, nil) {
+				return
+			}
+			// Move every cursor past the key just yielded, the shadowed
+			// entries of later sources included
+			for len(h) > 0 && h[0].entry.Key == e.Key {
+				c := h[0]
+				if !c.advance() {
+					heap.Pop(&h)
+					continue
+				}
+				if c.err != nil {
+					yield(Entry{}, c.err)
+					return
+				}
+				heap.Fix(&h, 0)
+			}
+		}
+	}
+}
+
+// cursor is a source of Merge and its current entry
+type cursor struct {
+	next  func() (Entry, error, bool)
+	rank  int // Position among the sources; lower wins on equal keys
+	entry Entry
+	err   error
+}
+
+// advance moves to the next entry, reporting false at the end
+func (c *cursor) advance() bool {
+	var ok bool
+	c.entry, c.err, ok = c.next()
+	return ok
+}
+
+// mergeHeap is a min-heap of cursors ordered by key, then rank, for
+// container/heap
+type mergeHeap []*cursor
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].entry.Key != h[j].entry.Key {
+		return h[i].entry.Key < h[j].entry.Key
+	}
+	return h[i].rank < h[j].rank
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(*cursor)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,75 @@
+package lsm
+
+import (
+	"errors"
+	"iter"
+	"strings"
+	"testing"
+)
+
+// source returns a merge source of the entries, yielding err after them
+// if it is not nil
+func source(err error, entries ...Entry) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		for _, e := range entries {
+			if !yield(e, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(Entry{}, err)
+		}
+	}
+}
+
+// TestMerge tests that the first source holding a key wins
+func TestMerge(t *testing.T) {
+	newest := source(nil, Entry{Key: "b", Value: []byte("new")}, Entry{Key: "d", Tombstone: true})
+	middle := source(nil, Entry{Key: "a", Value: []byte("mid")}, Entry{Key: "b", Value: []byte("mid")}, Entry{Key: "e", Value: []byte("mid")})
+	oldest := source(nil, Entry{Key: "a", Value: []byte("old")}, Entry{Key: "c", Value: []byte("old")}, Entry{Key: "d", Value: []byte("old")})
+
+	var got []string
+	for e, err := range Merge(newest, middle, oldest) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Tombstone {
+			got = append(got, e.Key+"=deleted")
+		} else {
+			got = append(got, e.Key+"="+string(e.Value))
+		}
+	}
+	want := "a=mid b=new c=old d=deleted e=mid"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Merge = %v, want %s", got, want)
+	}
+
+	// Stopping early stops every source
+	n := 0
+	for range Merge(newest, middle, oldest) {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	for range Merge() {
+		t.Error("Merge of nothing yielded")
+	}
+}
+
+// TestMergeError tests that an error from a source ends the merge
+func TestMergeError(t *testing.T) {
+	boom := errors.New("boom")
+	var keys []string
+	var got error
+	for e, err := range Merge(source(nil, Entry{Key: "a"}, Entry{Key: "z"}), source(boom, Entry{Key: "b"})) {
+		if err != nil {
+			got = err
+			break
+		}
+		keys = append(keys, e.Key)
+	}
+	if got != boom || strings.Join(keys, "") != "ab" {
+		t.Errorf("merge gave %v then %v, want a b then boom", keys, got)
+	}
+}
@@ -0,0 +1,445 @@
+package lsm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+
+	"hellogolang/Algorithms/probabilistic"
+	"hellogolang/Projects/KVStore/wal"
+)
+
+var (
+	// ErrBadTable is returned for an SSTable that fails its checks
+	ErrBadTable = errors.New("bad sstable")
+	// ErrOutOfOrder is returned by TableWriter.Add for a key not above the
+	// one before
+	ErrOutOfOrder = errors.New("keys out of order")
+)
+
+// Defaults for zero TableOptions fields
+const (
+	DefaultBlockSize         = 4 << 10
+	DefaultFalsePositiveRate = 0.01
+)
+
+// tableMagic ends every SSTable, naming the format and its version
+var tableMagic = [8]byte{'K', 'V', 'S', 'S', 'T', '0', '0', '1'}
+
+// footerSize is the size of the fixed footer at the end of a table:
+// seq, count and index offset as uint64s, index length and CRC, Bloom
+// filter length and CRC and the footer's own CRC as uint32s, then the
+// magic, all little-endian
+const footerSize = 8 + 8 + 8 + 4 + 4 + 4 + 4 + 4 + 8
+
+// TableOptions configures the writing of an SSTable. Zero fields take
+// their defaults.
+type TableOptions struct {
+	// BlockSize is the size data blocks are cut at, DefaultBlockSize if 0
+	// or less. A read fetches one block, so smaller blocks make point
+	// reads cheaper and the index larger.
+	BlockSize int
+	// FalsePositiveRate is the Bloom filter's, DefaultFalsePositiveRate if
+	// not between 0 and 1
+	FalsePositiveRate float64
+}
+
+// blockHandle is an entry of a table's index: the first key of a block
+// and where it is
+type blockHandle struct {
+	firstKey string
+	offset   uint64
+	length   uint64
+	crc      uint32
+}
+
+// TableWriter writes an SSTable: data blocks of entries in key order, each
+// the key length, key, a tombstone flag, the value length and value, with
+// lengths as uvarints; an index of the first key, offset, length and
+// CRC-32C of every block; the Bloom filter of all keys; and the footer
+// locating them.
+type TableWriter struct {
+	w    io.Writer
+	opts TableOptions
+
+	block    []byte
+	first    string // First key of the block
+	index    []byte
+	hashes   []uint64 // Of every key, for the Bloom filter
+	last     string
+	offset   uint64 // Bytes written
+	count    uint64
+	err      error
+	finished bool
+}
+
+// NewTableWriter returns a writer of an SSTable to w
+func NewTableWriter(w io.Writer, opts TableOptions) *TableWriter {
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = DefaultBlockSize
+	}
+	if !(opts.FalsePositiveRate > 0 && opts.FalsePositiveRate < 1) {
+		opts.FalsePositiveRate = DefaultFalsePositiveRate
+	}
+	return &TableWriter{w: w, opts: opts}
+}
+
+// Add adds an entry, whose key must be above the key of the one before,
+// or ErrOutOfOrder is returned
+func (tw *TableWriter) Add(e Entry) error {
+	if tw.err != nil {
+		return tw.err
+	}
+	if tw.count > 0 && e.Key <= tw.last {
+		return fmt.Errorf("%w: %q after %q", ErrOutOfOrder, e.Key, tw.last)
+	}
+	if len(tw.block) == 0 {
+		tw.first = e.Key
+	}
+	tw.block = binary.AppendUvarint(tw.block, uint64(len(e.Key)))
+	tw.block = append(tw.block, e.Key...)
+	if e.Tombstone {
+		tw.block = append(tw.block, 1)
+	} else {
+		tw.block = append(tw.block, 0)
+		tw.block = binary.AppendUvarint(tw.block, uint64(len(e.Value)))
+		tw.block = append(tw.block, e.Value...)
+	}
+	tw.hashes = append(tw.hashes, probabilistic.Hash([]byte(e.Key)))
+	tw.last = e.Key
+	tw.count++
+	if len(tw.block) >= tw.opts.BlockSize {
+		tw.flushBlock()
+	}
+	return tw.err
+}
+
+// flushBlock writes the current block and indexes it
+func (tw *TableWriter) flushBlock() {
+	if len(tw.block) == 0 {
+		return
+	}
+	tw.index = binary.AppendUvarint(tw.index, uint64(len(tw.first)))
+	tw.index = append(tw.index, tw.first...)
+	tw.index = binary.AppendUvarint(tw.index, tw.offset)
+	tw.index = binary.AppendUvarint(tw.index, uint64(len(tw.block)))
+	tw.index = binary.LittleEndian.AppendUint32(tw.index, crc32.Checksum(tw.block, wal.CRCTable))
+	tw.write(tw.block)
+	tw.block = tw.block[:0]
+}
+
+// write writes p, keeping the first error
+func (tw *TableWriter) write(p []byte) {
+	if tw.err != nil {
+		return
+	}
+	_, tw.err = tw.w.Write(p)
+	tw.offset += uint64(len(p))
+}
+
+// Finish writes the last block, the index, the Bloom filter and the
+// footer, recording seq, the sequence number of the last write the table
+// holds
+func (tw *TableWriter) Finish(seq uint64) error {
+	if tw.finished {
+		return errors.New("table already finished")
+	}
+	tw.finished = true
+	tw.flushBlock()
+
+	bloom := probabilistic.NewBloom(len(tw.hashes), tw.opts.FalsePositiveRate)
+	for _, h := range tw.hashes {
+		bloom.AddHash(h)
+	}
+	filter, _ := bloom.MarshalBinary()
+	indexOffset := tw.offset
+	tw.write(tw.index)
+	tw.write(filter)
+
+	footer := make([]byte, 0, footerSize)
+	footer = binary.LittleEndian.AppendUint64(footer, seq)
+	footer = binary.LittleEndian.AppendUint64(footer, tw.count)
+	footer = binary.LittleEndian.AppendUint64(footer, indexOffset)
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(tw.index)))
+	footer = binary.LittleEndian.AppendUint32(footer, crc32.Checksum(tw.index, wal.CRCTable))
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(filter)))
+	footer = binary.LittleEndian.AppendUint32(footer, crc32.Checksum(filter, wal.CRCTable))
+	footer = binary.LittleEndian.AppendUint32(footer, crc32.Checksum(footer, wal.CRCTable))
+	footer = append(footer, tableMagic[:]...)
+	tw.write(footer)
+	return tw.err
+}
+
+// Table reads an SSTable. Its index and Bloom filter are kept in memory
+// and its blocks read from the file as needed. It is safe for concurrent
+// use.
+type Table struct {
+	file  *os.File
+	path  string
+	seq   uint64
+	count uint64
+	index []blockHandle
+	bloom *probabilistic.Bloom
+
+	// A Tree counts the readers of its tables, so that a table replaced by
+	// a compaction is closed and removed only once the last is done
+	refs     atomic.Int32
+	obsolete atomic.Bool
+}
+
+// OpenTable opens the SSTable at path, checking its footer, index and
+// Bloom filter
+func OpenTable(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := readTable(f, path)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	t.refs.Store(1)
+	return t, nil
+}
+
+// readTable reads the footer, index and Bloom filter of the table in f
+func readTable(f *os.File, path string) (*Table, error) {
+	bad := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s: %s", ErrBadTable, filepath.Base(path), fmt.Sprintf(format, args...))
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := uint64(info.Size())
+	if size < footerSize {
+		return nil, bad("%d bytes, too short", size)
+	}
+	footer := make([]byte, footerSize)
+	if _, err := f.ReadAt(footer, int64(size-footerSize)); err != nil {
+		return nil, err
+	}
+	if [8]byte(footer[footerSize-8:]) != tableMagic {
+		return nil, bad("not an sstable")
+	}
+	le := binary.LittleEndian
+	if crc32.Checksum(footer[:40], wal.CRCTable) != le.Uint32(footer[40:]) {
+		return nil, bad("footer checksum mismatch")
+	}
+	t := &Table{file: f, path: path, seq: le.Uint64(footer), count: le.Uint64(footer[8:])}
+	indexOffset, indexLen, bloomLen := le.Uint64(footer[16:]), uint64(le.Uint32(footer[24:])), uint64(le.Uint32(footer[32:]))
+	// Secure: the lengths are checked against the file before anything is
+	// allocated for them
+	if indexOffset > size-footerSize || indexLen+bloomLen != size-footerSize-indexOffset {
+		return nil, bad("index and filter out of bounds")
+	}
+	meta := make([]byte, indexLen+bloomLen)
+	if _, err := f.ReadAt(meta, int64(indexOffset)); err != nil {
+		return nil, err
+	}
+	index, filter := meta[:indexLen], meta[indexLen:]
+	if crc32.Checksum(index, wal.CRCTable) != le.Uint32(footer[28:]) {
+		return nil, bad("index checksum mismatch")
+	}
+	if crc32.Checksum(filter, wal.CRCTable) != le.Uint32(footer[36:]) {
+		return nil, bad("filter checksum mismatch")
+	}
+	t.bloom = new(probabilistic.Bloom)
+	if err := t.bloom.UnmarshalBinary(filter); err != nil {
+		return nil, bad("%v", err)
+	}
+
+	var next uint64 // Blocks must tile the data from offset 0
+	for len(index) > 0 {
+		var h blockHandle
+		key, rest, ok := readField(index)
+		if !ok {
+			return nil, bad("bad index entry %d", len(t.index))
+		}
+		h.firstKey = string(key)
+		var n1, n2 int
+		h.offset, n1 = binary.Uvarint(rest)
+		if n1 > 0 {
+			h.length, n2 = binary.Uvarint(rest[n1:])
+		}
+		if n1 <= 0 || n2 <= 0 || len(rest) < n1+n2+4 {
+			return nil, bad("bad index entry %d", len(t.index))
+		}
+		h.crc = le.Uint32(rest[n1+n2:])
+		index = rest[n1+n2+4:]
+		if h.offset != next || h.length == 0 || h.length > indexOffset-h.offset {
+			return nil, bad("block %d out of bounds", len(t.index))
+		}
+		if len(t.index) > 0 && h.firstKey <= t.index[len(t.index)-1].firstKey {
+			return nil, bad("index out of order at block %d", len(t.index))
+		}
+		next = h.offset + h.length
+		t.index = append(t.index, h)
+	}
+	if next != indexOffset {
+		return nil, bad("blocks do not reach the index")
+	}
+	return t, nil
+}
+
+// readField splits a uvarint length and that many bytes off the front of
+// p
+func readField(p []byte) (field, rest []byte, ok bool) {
+	n, w := binary.Uvarint(p)
+	if w <= 0 || n > uint64(len(p)-w) {
+		return nil, nil, false
+	}
+	return p[w : w+int(n)], p[w+int(n):], true
+}
+
+// Seq returns the sequence number of the last write the table holds
+func (t *Table) Seq() uint64 {
+	return t.seq
+}
+
+// Len returns the number of entries, tombstones included
+func (t *Table) Len() int {
+	return int(t.count)
+}
+
+// block returns the index of the block that would hold key: the last
+// whose first key is at most key, or -1 if key is below them all
+func (t *Table) block(key string) int {
+	return sort.Search(len(t.index), func(i int) bool { return t.index[i].firstKey > key }) - 1
+}
+
+// readBlock reads block i and checks its checksum
+func (t *Table) readBlock(i int) ([]byte, error) {
+	h := t.index[i]
+	data := make([]byte, h.length)
+	if _, err := t.file.ReadAt(data, int64(h.offset)); err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(data, wal.CRCTable) != h.crc {
+		return nil, fmt.Errorf("%w: %s: block %d checksum mismatch", ErrBadTable, filepath.Base(t.path), i)
+	}
+	return data, nil
+}
+
+// entries iterates over the entries of a block, calling yield with each
+// until it returns false, and returns ErrBadTable if the block is
+// malformed
+func (t *Table) entries(i int, data []byte, yield func(Entry) bool) error {
+	for len(data) > 0 {
+		key, rest, ok := readField(data)
+		if !ok || len(rest) == 0 {
+			return fmt.Errorf("%w: %s: bad entry in block %d", ErrBadTable, filepath.Base(t.path), i)
+		}
+		e := Entry{Key: string(key), Tombstone: rest[0] == 1}
+		data = rest[1:]
+		if !e.Tombstone {
+			if e.Value, data, ok = readField(data); !ok {
+				return fmt.Errorf("%w: %s: bad value in block %d", ErrBadTable, filepath.Base(t.path), i)
+			}
+		}
+		if !yield(e) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Get returns the entry of key, a tombstone if it was deleted, and
+// whether the table holds either. The Bloom filter answers most lookups
+// of absent keys without reading a block.
+func (t *Table) Get(key string) (Entry, bool, error) {
+	if !t.bloom.Contains([]byte(key)) {
+		return Entry{}, false, nil
+	}
+	i := t.block(key)
+	if i < 0 {
+		return Entry{}, false, nil
+	}
+	data, err := t.readBlock(i)
+	if err != nil {
+		return Entry{}, false, err
+	}
+	var found Entry
+	var ok bool
+	err = t.entries(i, data, func(e Entry) bool {
+		if e.Key >= key {
+			found, ok = e, e.Key == key
+			return false
+		}
+		return true
+	})
+	return found, ok, err
+}
+
+// Range iterates over the entries with keys in [lo, hi) in key order,
+// with no upper bound if hi is "". A read error or damaged block is
+// yielded and ends the iteration.
+func (t *Table) Range(lo, hi string) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		for i := max(t.block(lo), 0); i < len(t.index); i++ {
+			if hi != "" && t.index[i].firstKey >= hi {
+				return
+			}
+			data, err := t.readBlock(i)
+			if err != nil {
+				yield(Entry{}, err)
+				return
+			}
+			done := false
+			err = t.entries(i, data, func(e Entry) bool {
+				switch {
+				case e.Key < lo:
+					return true
+				case hi != "" && e.Key >= hi:
+					done = true
+				default:
+					done = !yield(e, nil)
+				}
+				return !done
+			})
+			if err != nil {
+				yield(Entry{}, err)
+				return
+			}
+			if done {
+				return
+			}
+		}
+	}
+}
+
+// Close closes the table's file. A Tree closes the tables it uses.
+func (t *Table) Close() error {
+	return t.file.Close()
+}
+
+// acquire counts a reader of the table
+func (t *Table) acquire() {
+	t.refs.Add(1)
+}
+
+// release ends a reader of the table, closing it once none is left, and
+// removing it too if it is obsolete
+func (t *Table) release() {
+	if t.refs.Add(-1) == 0 {
+		t.file.Close()
+		if t.obsolete.Load() {
+			os.Remove(t.path)
+		}
+	}
+}
+
+// discard marks the table obsolete and drops the Tree's own reference,
+// so that it is removed once its last reader is done
+func (t *Table) discard() {
+	t.obsolete.Store(true)
+	t.release()
+}
@@ -0,0 +1,189 @@
+package lsm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildTable writes the entries to a table file and opens it
+func buildTable(t *testing.T, entries []Entry, opts TableOptions) *Table {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := NewTableWriter(&buf, opts)
+	for _, e := range entries {
+		if err := tw.Add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Finish(42); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "t.sst")
+	os.WriteFile(path, buf.Bytes(), 0o600)
+	table, err := OpenTable(path)
+	if err != nil {
+		t.Fatalf("OpenTable: %v", err)
+	}
+	t.Cleanup(func() { table.Close() })
+	return table
+}
+
+// numbered returns n entries with keys k0000 up, every third a tombstone
+func numbered(n int) []Entry {
+	entries := make([]Entry, n)
+	for i := range entries {
+		entries[i] = Entry{Key: fmt.Sprintf("k%04d", i), Value: fmt.Appendf(nil, "value %d", i)}
+		if i%3 == 2 {
+			entries[i] = Entry{Key: entries[i].Key, Tombstone: true}
+		}
+	}
+	return entries
+}
+
+// TestTableGet tests point lookups across many small blocks
+func TestTableGet(t *testing.T) {
+	entries := numbered(500)
+	table := buildTable(t, entries, TableOptions{BlockSize: 64})
+	if table.Seq() != 42 || table.Len() != 500 {
+		t.Errorf("Seq %d and Len %d, want 42 and 500", table.Seq(), table.Len())
+	}
+	if len(table.index) < 50 {
+		t.Errorf("%d blocks, want many", len(table.index))
+	}
+	for _, want := range entries {
+		got, ok, err := table.Get(want.Key)
+		if err != nil || !ok || got.Tombstone != want.Tombstone || !bytes.Equal(got.Value, want.Value) {
+			t.Fatalf("Get(%s) = %+v, %v, %v, want %+v", want.Key, got, ok, err, want)
+		}
+	}
+	for _, key := range []string{"a", "k", "k0000x", "k0499x", "z"} {
+		if _, ok, err := table.Get(key); ok || err != nil {
+			t.Errorf("Get(%s) = %v, %v, want absent", key, ok, err)
+		}
+	}
+}
+
+// TestTableRange tests iterating over ranges of keys
+func TestTableRange(t *testing.T) {
+	entries := numbered(100)
+	table := buildTable(t, entries, TableOptions{BlockSize: 100})
+	tests := []struct {
+		lo, hi      string
+		first, last int // Indexes of the entries expected, last exclusive
+	}{
+		{"", "", 0, 100},
+		{"k0010", "k0020", 10, 20},
+		{"k0010x", "k0020", 11, 20},
+		{"a", "k0005", 0, 5},
+		{"k0095", "", 95, 100},
+		{"k0100", "", 100, 100},
+		{"k0050", "k0050", 50, 50},
+	}
+	for _, tt := range tests {
+		i := tt.first
+		for e, err := range table.Range(tt.lo, tt.hi) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i >= tt.last || e.Key != entries[i].Key || e.Tombstone != entries[i].Tombstone {
+				t.Fatalf("Range(%q, %q) yielded %s at %d", tt.lo, tt.hi, e.Key, i)
+			}
+			i++
+		}
+		if i != tt.last {
+			t.Errorf("Range(%q, %q) stopped at %d, want %d", tt.lo, tt.hi, i, tt.last)
+		}
+	}
+}
+
+// TestTableBloom tests that the filter spares reading blocks for most
+// absent keys
+func TestTableBloom(t *testing.T) {
+	table := buildTable(t, numbered(1000), TableOptions{FalsePositiveRate: 0.01})
+	passed := 0
+	for i := range 1000 {
+		if table.bloom.Contains(fmt.Appendf(nil, "absent%d", i)) {
+			passed++
+		}
+	}
+	if passed > 30 {
+		t.Errorf("%d of 1000 absent keys passed the filter", passed)
+	}
+}
+
+// TestTableWriterOrder tests that keys must increase
+func TestTableWriterOrder(t *testing.T) {
+	tw := NewTableWriter(new(bytes.Buffer), TableOptions{})
+	tw.Add(Entry{Key: "b"})
+	for _, key := range []string{"a", "b"} {
+		if err := tw.Add(Entry{Key: key}); !errors.Is(err, ErrOutOfOrder) {
+			t.Errorf("Add(%s) after b = %v, want ErrOutOfOrder", key, err)
+		}
+	}
+}
+
+// TestTableCorrupt tests that damage anywhere in a table is detected
+func TestTableCorrupt(t *testing.T) {
+	var buf bytes.Buffer
+	tw := NewTableWriter(&buf, TableOptions{BlockSize: 64})
+	for _, e := range numbered(50) {
+		tw.Add(e)
+	}
+	tw.Finish(1)
+	good := buf.Bytes()
+	dir := t.TempDir()
+
+	for _, offset := range []int{0, len(good) / 2, len(good) - footerSize - 5, len(good) - footerSize + 3, len(good) - 1} {
+		data := bytes.Clone(good)
+		data[offset] ^= 0x40
+		path := filepath.Join(dir, fmt.Sprintf("%d.sst", offset))
+		os.WriteFile(path, data, 0o600)
+		table, err := OpenTable(path)
+		if err == nil {
+			// Damage to a block shows when it is read
+			for _, err = range table.Range("", "") {
+				if err != nil {
+					break
+				}
+			}
+			table.Close()
+		}
+		if !errors.Is(err, ErrBadTable) {
+			t.Errorf("byte %d flipped: %v, want ErrBadTable", offset, err)
+		}
+	}
+
+	path := filepath.Join(dir, "short.sst")
+	os.WriteFile(path, good[:footerSize-1], 0o600)
+	if _, err := OpenTable(path); !errors.Is(err, ErrBadTable) {
+		t.Errorf("truncated table: %v, want ErrBadTable", err)
+	}
+}
+
+// FuzzOpenTable tests that no input makes opening and reading a table
+// panic
+func FuzzOpenTable(f *testing.F) {
+	var buf bytes.Buffer
+	tw := NewTableWriter(&buf, TableOptions{BlockSize: 16})
+	for _, e := range numbered(5) {
+		tw.Add(e)
+	}
+	tw.Finish(1)
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(t.TempDir(), "f.sst")
+		os.WriteFile(path, data, 0o600)
+		table, err := OpenTable(path)
+		if err != nil {
+			return
+		}
+		defer table.Close()
+		table.Get("k0001")
+		for range table.Range("", "") {
+		}
+	})
+}
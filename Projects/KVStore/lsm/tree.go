@@ -0,0 +1,528 @@
+package lsm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"hellogolang/Projects/KVStore/internal/fsutil"
+	"hellogolang/Projects/KVStore/wal"
+)
+
+var (
+	// ErrClosed is returned by operations on a closed tree
+	ErrClosed = errors.New("tree closed")
+	// ErrInvalidKey is returned for an empty key
+	ErrInvalidKey = errors.New("invalid key")
+)
+
+// Defaults for zero Options fields
+const (
+	DefaultMemtableSize = 4 << 20
+	DefaultL0Limit      = 4
+)
+
+// tableExt is the extension of SSTable files
+const tableExt = ".sst"
+
+// Options configures a Tree. Zero fields take their defaults.
+type Options struct {
+	// WAL configures the write-ahead log
+	WAL wal.Options
+	// MemtableSize is the bytes written to a memtable before it is
+	// flushed, DefaultMemtableSize if 0 or less
+	MemtableSize int
+	// L0Limit is the number of level 0 tables that triggers a compaction,
+	// DefaultL0Limit if 0 or less
+	L0Limit int
+	// Table configures the SSTables written
+	Table TableOptions
+}
+
+// Stats describes the state of a tree
+type Stats struct {
+	MemtableKeys  int  // Keys in the memtable taking writes
+	MemtableBytes int  // Bytes written to it
+	Flushing      bool // Whether a full memtable is being flushed
+	L0Tables      int  // Tables in level 0
+	L1Entries     int  // Entries in the level 1 table
+}
+
+// Tree is an LSM-tree storage engine. It is safe for concurrent use.
+type Tree struct {
+	dir  string
+	opts Options
+
+	mu      sync.RWMutex
+	flushed *sync.Cond // Signaled when imm is flushed or fails to be
+	mem     *Memtable  // Takes the writes
+	imm     *Memtable  // Full and being flushed, or nil
+	l0      []*Table   // Newest first
+	l1      *Table     // Or nil
+	log     *wal.Log
+	err     error // A failed flush, which stops all writes
+	closed  bool
+
+	compactMu sync.Mutex // Serializes compactions
+}
+
+// Open opens the tree in dir, creating it if need be, and recovers the
+// writes not yet in a table from the log. Files left by a flush or
+// compaction that a crash interrupted are removed.
+func Open(dir string, opts Options) (*Tree, error) {
+	if opts.MemtableSize <= 0 {
+		opts.MemtableSize = DefaultMemtableSize
+	}
+	if opts.L0Limit <= 0 {
+		opts.L0Limit = DefaultL0Limit
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	if leftovers, err := filepath.Glob(filepath.Join(dir, "table-*.tmp")); err == nil {
+		for _, f := range leftovers {
+			os.Remove(f)
+		}
+	}
+
+	t := &Tree{dir: dir, opts: opts, mem: NewMemtable()}
+	t.flushed = sync.NewCond(&t.mu)
+	flushedSeq, err := t.openTables()
+	if err != nil {
+		return nil, err
+	}
+	last, err := wal.Replay(dir, flushedSeq, opts.WAL, func(r wal.Record) error {
+		if r.Op == wal.OpDelete {
+			t.mem.Delete(r.Key)
+		} else {
+			t.mem.Put(r.Key, r.Value)
+		}
+		return nil
+	})
+	if err == nil {
+		t.log, err = wal.Open(dir, last+1, opts.WAL)
+	}
+	if err != nil {
+		t.closeTables()
+		return nil, err
+	}
+	return t, nil
+}
+
+// tableName returns the file name of the table of level holding the
+// writes up to seq
+func tableName(level int, seq uint64) string {
+	return fmt.Sprintf("L%d-%020d%s", level, seq, tableExt)
+}
+
+// openTables opens the tables in the directory, returning the sequence
+// number of the last write they hold. A level 1 table stands in for every
+// level 0 table up to its sequence number and every older level 1 table,
+// which only a crash in the middle of a compaction leaves behind.
+func (t *Tree) openTables() (uint64, error) {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return 0, err
+	}
+	var levels [2][]uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), tableExt)
+		if !ok || len(name) < 3 || name[0] != 'L' || name[2] != '-' || (name[1] != '0' && name[1] != '1') {
+			continue
+		}
+		if seq, err := strconv.ParseUint(name[3:], 10, 64); err == nil {
+			levels[name[1]-'0'] = append(levels[name[1]-'0'], seq)
+		}
+	}
+	slices.Sort(levels[0])
+	slices.Sort(levels[1])
+
+	var flushed uint64
+	if n := len(levels[1]); n > 0 {
+		flushed = levels[1][n-1]
+		for _, seq := range levels[1][:n-1] {
+			os.Remove(filepath.Join(t.dir, tableName(1, seq)))
+		}
+		if t.l1, err = OpenTable(filepath.Join(t.dir, tableName(1, flushed))); err != nil {
+			return 0, err
+		}
+	}
+	for _, seq := range levels[0] {
+		path := filepath.Join(t.dir, tableName(0, seq))
+		if seq <= flushed {
+			os.Remove(path)
+			continue
+		}
+		table, err := OpenTable(path)
+		if err != nil {
+			t.closeTables()
+			return 0, err
+		}
+		t.l0 = slices.Insert(t.l0, 0, table)
+		flushed = seq
+	}
+	return flushed, nil
+}
+
+// closeTables drops the tree's references to its tables
+func (t *Tree) closeTables() {
+	for _, table := range t.l0 {
+		table.release()
+	}
+	if t.l1 != nil {
+		t.l1.release()
+	}
+}
+
+// Put sets key to a copy of value, once the change is in the log
+func (t *Tree) Put(key string, value []byte) error {
+	value = slices.Clone(value)
+	if value == nil {
+		value = []byte{}
+	}
+	return t.write(wal.OpSet, key, value)
+}
+
+// Delete removes key, once the change is in the log, by writing a
+// tombstone for it
+func (t *Tree) Delete(key string) error {
+	return t.write(wal.OpDelete, key, nil)
+}
+
+// write logs a change and applies it to the memtable, flushing the
+// memtable if that fills it. A failed flush is returned by the write that
+// started it, whose change is nonetheless logged and applied, and by all
+// later writes: reopening the tree recovers the unflushed changes from the
+// log.
+func (t *Tree) write(op wal.Op, key string, value []byte) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	t.mu.Lock()
+	// With a memtable full and another still flushing, writes wait, so
+	// they cannot outrun the flushes
+	for t.imm != nil && t.err == nil && !t.closed && t.mem.Size() >= t.opts.MemtableSize {
+		t.flushed.Wait()
+	}
+	if t.closed {
+		t.mu.Unlock()
+		return ErrClosed
+	}
+	if t.err != nil {
+		t.mu.Unlock()
+		return t.err
+	}
+	if _, err := t.log.Append(op, key, value); err != nil {
+		t.mu.Unlock()
+		return err
+	}
+	if op == wal.OpDelete {
+		t.mem.Delete(key)
+	} else {
+		t.mem.Put(key, value)
+	}
+	if t.imm != nil || t.mem.Size() < t.opts.MemtableSize {
+		t.mu.Unlock()
+		return nil
+	}
+	imm, boundary, err := t.freezeLocked()
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return t.flush(imm, boundary)
+}
+
+// freezeLocked makes the memtable the one being flushed, giving the
+// writes a new one, and rotates the log so that the frozen memtable holds
+// exactly the records before the boundary returned. The caller holds the
+// lock and has checked that no memtable is being flushed.
+func (t *Tree) freezeLocked() (*Memtable, uint64, error) {
+	boundary, err := t.log.Rotate()
+	if err != nil {
+		t.err = fmt.Errorf("flushing the memtable: %w", err)
+		return nil, 0, t.err
+	}
+	t.imm, t.mem = t.mem, NewMemtable()
+	return t.imm, boundary, nil
+}
+
+// flush writes imm to a level 0 table, then drops the log records before
+// boundary, which the table now holds, and compacts level 0 if it is full
+func (t *Tree) flush(imm *Memtable, boundary uint64) error {
+	table, err := t.writeTable(0, boundary-1, imm.Range("", ""), false)
+	t.mu.Lock()
+	if err != nil {
+		t.err = fmt.Errorf("flushing the memtable: %w", err)
+		t.flushed.Broadcast()
+		t.mu.Unlock()
+		return t.err
+	}
+	t.l0 = slices.Insert(t.l0, 0, table)
+	t.imm = nil
+	t.flushed.Broadcast()
+	err = t.log.RemoveBefore(boundary)
+	full := len(t.l0) >= t.opts.L0Limit && !t.closed
+	t.mu.Unlock()
+	if err == nil && full {
+		err = t.Compact()
+	}
+	return err
+}
+
+// Flush flushes the memtable to a level 0 table, if it holds anything,
+// waiting for a flush already under way first
+func (t *Tree) Flush() error {
+	t.mu.Lock()
+	for t.imm != nil && t.err == nil && !t.closed {
+		t.flushed.Wait()
+	}
+	switch {
+	case t.closed:
+		t.mu.Unlock()
+		return ErrClosed
+	case t.err != nil:
+		t.mu.Unlock()
+		return t.err
+	case t.mem.Len() == 0:
+		t.mu.Unlock()
+		return nil
+	}
+	imm, boundary, err := t.freezeLocked()
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return t.flush(imm, boundary)
+}
+
+// Compact merges the level 0 tables and the level 1 table into a new
+// level 1 table, keeping only the latest entry of each key and dropping
+// deleted keys, as the bottom level needs no tombstones. Flushes carry on
+// meanwhile; readers of the old tables finish with them before they are
+// removed.
+func (t *Tree) Compact() error {
+	t.compactMu.Lock()
+	defer t.compactMu.Unlock()
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return ErrClosed
+	}
+	inputs, base := slices.Clone(t.l0), t.l1
+	t.mu.RUnlock()
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	sources := make([]iter.Seq2[Entry, error], 0, len(inputs)+1)
+	for _, table := range inputs {
+		sources = append(sources, table.Range("", ""))
+	}
+	if base != nil {
+		sources = append(sources, base.Range("", ""))
+	}
+	table, err := t.writeTable(1, inputs[0].Seq(), Merge(sources...), true)
+	if err != nil {
+		return fmt.Errorf("compacting: %w", err)
+	}
+
+	t.mu.Lock()
+	// Tables flushed since the compaction began are ahead of the inputs
+	t.l0 = slices.Clip(t.l0[:len(t.l0)-len(inputs)])
+	t.l1 = table
+	t.mu.Unlock()
+	for _, old := range inputs {
+		old.discard()
+	}
+	if base != nil {
+		base.discard()
+	}
+	return nil
+}
+
+// writeTable writes entries to the table of level holding the writes up
+// to seq, skipping tombstones if dropTombstones is set, and opens it. The
+// table is written to a temporary file, synced and renamed into place, so
+// a crash leaves either no table or a whole one.
+func (t *Tree) writeTable(level int, seq uint64, entries iter.Seq2[Entry, error], dropTombstones bool) (*Table, error) {
+	tmp, err := os.CreateTemp(t.dir, "table-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed
+
+	build := func() error {
+		w := bufio.NewWriter(tmp)
+		tw := NewTableWriter(w, t.opts.Table)
+		for e, err := range entries {
+			if err != nil {
+				return err
+			}
+			if dropTombstones && e.Tombstone {
+				continue
+			}
+			if err := tw.Add(e); err != nil {
+				return err
+			}
+		}
+		if err := tw.Finish(seq); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		return tmp.Sync()
+	}
+	err = build()
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(t.dir, tableName(level, seq))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+	if err := fsutil.SyncDir(t.dir); err != nil {
+		return nil, err
+	}
+	return OpenTable(path)
+}
+
+// view is what a read sees: the memtables and tables at one moment, the
+// tables held open until it is released
+type view struct {
+	mem, imm *Memtable
+	tables   []*Table // Newest first
+}
+
+// view returns the current view, or ErrClosed
+func (t *Tree) view() (*view, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return nil, ErrClosed
+	}
+	v := &view{mem: t.mem, imm: t.imm, tables: slices.Clone(t.l0)}
+	if t.l1 != nil {
+		v.tables = append(v.tables, t.l1)
+	}
+	for _, table := range v.tables {
+		table.acquire()
+	}
+	return v, nil
+}
+
+// release releases the tables of the view
+func (v *view) release() {
+	for _, table := range v.tables {
+		table.release()
+	}
+}
+
+// Get returns a copy of the value of key and whether it is present,
+// looking in the memtables and then the tables from newest to oldest
+// until one holds an entry for it
+func (t *Tree) Get(key string) ([]byte, bool, error) {
+	v, err := t.view()
+	if err != nil {
+		return nil, false, err
+	}
+	defer v.release()
+	for _, m := range []*Memtable{v.mem, v.imm} {
+		if m == nil {
+			continue
+		}
+		if e, ok := m.Get(key); ok {
+			return slices.Clone(e.Value), !e.Tombstone, nil
+		}
+	}
+	for _, table := range v.tables {
+		e, ok, err := table.Get(key)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return e.Value, !e.Tombstone, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// Scan returns the entries with keys in [lo, hi) in key order, with no
+// upper bound if hi is "", at most limit of them, or all if limit is 0 or
+// less. The values are copies.
+func (t *Tree) Scan(lo, hi string, limit int) ([]Entry, error) {
+	v, err := t.view()
+	if err != nil {
+		return nil, err
+	}
+	defer v.release()
+	sources := []iter.Seq2[Entry, error]{v.mem.Range(lo, hi)}
+	if v.imm != nil {
+		sources = append(sources, v.imm.Range(lo, hi))
+	}
+	for _, table := range v.tables {
+		sources = append(sources, table.Range(lo, hi))
+	}
+	var out []Entry
+	for e, err := range Merge(sources...) {
+		if err != nil {
+			return nil, err
+		}
+		if limit > 0 && len(out) == limit {
+			break
+		}
+		if !e.Tombstone {
+			out = append(out, Entry{Key: e.Key, Value: slices.Clone(e.Value)})
+		}
+	}
+	return out, nil
+}
+
+// Stats returns the state of the tree
+func (t *Tree) Stats() Stats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	s := Stats{
+		MemtableKeys:  t.mem.Len(),
+		MemtableBytes: t.mem.Size(),
+		Flushing:      t.imm != nil,
+		L0Tables:      len(t.l0),
+	}
+	if t.l1 != nil {
+		s.L1Entries = t.l1.Len()
+	}
+	return s
+}
+
+// Close waits for a flush or compaction under way and closes the log,
+// syncing it, and the tables. The memtable is not flushed; the log holds
+// its writes for the next Open.
+func (t *Tree) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return ErrClosed
+	}
+	t.closed = true
+	for t.imm != nil && t.err == nil {
+		t.flushed.Wait()
+	}
+	t.flushed.Broadcast() // Writers waiting for room see the tree closed
+	t.mu.Unlock()
+
+	t.compactMu.Lock()
+	defer t.compactMu.Unlock()
+	t.closeTables()
+	return t.log.Close()
+}
@@ -0,0 +1,260 @@
+package lsm
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+
+	"hellogolang/Projects/KVStore/wal"
+)
+
+// small are options making a tree flush and compact after a few writes
+var small = Options{
+	WAL:          wal.Options{Sync: wal.SyncNever},
+	MemtableSize: 2 << 10,
+	L0Limit:      3,
+	Table:        TableOptions{BlockSize: 256},
+}
+
+// openTree opens a tree in dir
+func openTree(t *testing.T, dir string, opts Options) *Tree {
+	t.Helper()
+	tree, err := Open(dir, opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return tree
+}
+
+// check compares the tree with the map it should hold, by Get and Scan
+func check(t *testing.T, tree *Tree, want map[string]string) {
+	t.Helper()
+	for k, v := range want {
+		got, ok, err := tree.Get(k)
+		if err != nil || !ok || string(got) != v {
+			t.Fatalf("Get(%s) = %q, %v, %v, want %q", k, got, ok, err, v)
+		}
+	}
+	entries, err := tree.Scan("", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := slices.Sorted(maps.Keys(want))
+	if len(entries) != len(keys) {
+		t.Fatalf("Scan returned %d entries, want %d", len(entries), len(keys))
+	}
+	for i, e := range entries {
+		if e.Key != keys[i] || string(e.Value) != want[e.Key] {
+			t.Fatalf("Scan entry %d = %s=%q, want %s=%q", i, e.Key, e.Value, keys[i], want[keys[i]])
+		}
+	}
+}
+
+// TestMemtable tests the memtable on its own
+func TestMemtable(t *testing.T) {
+	m := NewMemtable()
+	m.Put("b", []byte("2"))
+	m.Put("a", []byte("1"))
+	m.Delete("c")
+	if e, ok := m.Get("c"); !ok || !e.Tombstone {
+		t.Errorf("Get of a deleted key = %+v, %v, want a tombstone", e, ok)
+	}
+	if _, ok := m.Get("d"); ok {
+		t.Error("Get of an absent key found it")
+	}
+	if m.Len() != 3 || m.Size() != 3+2+3*memtableOverhead {
+		t.Errorf("Len %d and Size %d", m.Len(), m.Size())
+	}
+	var keys string
+	for e := range m.Range("b", "") {
+		keys += e.Key
+	}
+	for e := range m.Range("", "b") {
+		keys += e.Key
+	}
+	if keys != "bca" {
+		t.Errorf("ranges yielded %q, want bc then a", keys)
+	}
+}
+
+// TestTreeRandom tests random writes against a map, across flushes,
+// compactions and reopens
+func TestTreeRandom(t *testing.T) {
+	dir := t.TempDir()
+	tree := openTree(t, dir, small)
+	rng := rand.New(rand.NewPCG(1, 2))
+	want := map[string]string{}
+	for step := range 3000 {
+		key := fmt.Sprintf("key%03d", rng.IntN(300))
+		if rng.IntN(4) == 0 {
+			delete(want, key)
+			if err := tree.Delete(key); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			value := fmt.Sprintf("value %d", step)
+			want[key] = value
+			if err := tree.Put(key, []byte(value)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if step%1000 == 999 {
+			check(t, tree, want)
+			if err := tree.Close(); err != nil {
+				t.Fatal(err)
+			}
+			tree = openTree(t, dir, small)
+			check(t, tree, want)
+		}
+	}
+	defer tree.Close()
+	stats := tree.Stats()
+	if stats.L1Entries == 0 || stats.L0Tables >= small.L0Limit {
+		t.Errorf("no compaction seen: %+v", stats)
+	}
+
+	if err := tree.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := tree.Stats(); stats.L0Tables != 0 || stats.MemtableKeys != 0 || stats.L1Entries != len(want) {
+		t.Errorf("after flushing and compacting: %+v, want %d entries in level 1", stats, len(want))
+	}
+	check(t, tree, want)
+}
+
+// TestTreeScan tests bounded scans over the memtable and tables together
+func TestTreeScan(t *testing.T) {
+	tree := openTree(t, t.TempDir(), small)
+	defer tree.Close()
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		tree.Put(k, []byte("old"))
+	}
+	tree.Flush()
+	tree.Put("b", []byte("new"))
+	tree.Delete("c")
+	tree.Put("f", []byte("new"))
+
+	tests := []struct {
+		lo, hi string
+		limit  int
+		want   string
+	}{
+		{"", "", 0, "a=old b=new d=old e=old f=new "},
+		{"b", "e", 0, "b=new d=old "},
+		{"b", "", 2, "b=new d=old "},
+		{"c", "d", 0, ""},
+	}
+	for _, tt := range tests {
+		entries, err := tree.Scan(tt.lo, tt.hi, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		for _, e := range entries {
+			got += e.Key + "=" + string(e.Value) + " "
+		}
+		if got != tt.want {
+			t.Errorf("Scan(%q, %q, %d) = %q, want %q", tt.lo, tt.hi, tt.limit, got, tt.want)
+		}
+	}
+	if _, ok, _ := tree.Get("c"); ok {
+		t.Error("deleted c found")
+	}
+}
+
+// TestTreeCrash tests recovery without Close, and from the files a crash
+// in the middle of a compaction leaves
+func TestTreeCrash(t *testing.T) {
+	dir := t.TempDir()
+	tree := openTree(t, dir, small)
+	want := map[string]string{}
+	for i := range 200 {
+		k, v := fmt.Sprintf("k%03d", i), fmt.Sprintf("v%d", i)
+		tree.Put(k, []byte(v))
+		want[k] = v
+	}
+	tree.Flush()
+	// The level 0 tables as they were before the compaction, restored
+	// after it as if the crash came before they were removed
+	saved := map[string][]byte{}
+	for _, table := range tree.l0 {
+		saved[table.path], _ = os.ReadFile(table.path)
+	}
+	if err := tree.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	tree.Put("after", []byte("crash"))
+	want["after"] = "crash"
+	for path, data := range saved {
+		os.WriteFile(path, data, 0o600)
+	}
+
+	// Abandoned without Close
+	tree = openTree(t, dir, small)
+	defer tree.Close()
+	if stats := tree.Stats(); stats.L0Tables != 0 {
+		t.Errorf("%d level 0 tables survived the compaction", stats.L0Tables)
+	}
+	check(t, tree, want)
+}
+
+// TestTreeConcurrent tests writers and readers racing flushes and
+// compactions
+func TestTreeConcurrent(t *testing.T) {
+	tree := openTree(t, t.TempDir(), small)
+	defer tree.Close()
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Go(func() {
+			for i := range 300 {
+				k := fmt.Sprintf("w%d-%03d", w, i)
+				if err := tree.Put(k, []byte(k)); err != nil {
+					t.Error(err)
+					return
+				}
+				if v, ok, err := tree.Get(k); err != nil || !ok || string(v) != k {
+					t.Errorf("Get(%s) = %q, %v, %v", k, v, ok, err)
+					return
+				}
+			}
+		})
+	}
+	wg.Go(func() {
+		for range 50 {
+			if _, err := tree.Scan("w", "", 0); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	})
+	wg.Wait()
+	if entries, _ := tree.Scan("", "", 0); len(entries) != 1200 {
+		t.Errorf("%d entries, want 1200", len(entries))
+	}
+}
+
+// TestTreeClosed tests operations on a closed tree and an empty key
+func TestTreeClosed(t *testing.T) {
+	tree := openTree(t, t.TempDir(), Options{})
+	if err := tree.Put("", nil); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put of an empty key = %v, want ErrInvalidKey", err)
+	}
+	tree.Close()
+	if err := tree.Put("k", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Put after Close = %v, want ErrClosed", err)
+	}
+	if _, _, err := tree.Get("k"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get after Close = %v, want ErrClosed", err)
+	}
+	if err := tree.Close(); !errors.Is(err, ErrClosed) {
+		t.Errorf("second Close = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,171 @@
+// Package server serves a kv.Store over TCP with a line-based text
+// protocol, on top of netserver. Each request is one line of words
+// separated by spaces and each reply one or more lines:
+//
+//	GET key            VALUE value | NOT_FOUND
+//	SET key value      OK
+//	DEL key            DELETED | NOT_FOUND
+//	SCAN [prefix] [n]  ENTRY key value ... then END count
+//	PING               PONG
+//	QUIT               BYE, then the connection closes
+//
+// A value is the rest of the line after its key, spaces included, so it
+// cannot hold a newline. Failures reply ERR and a message and leave the
+// connection open, except for a line over MaxLineSize, which closes it.
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"hellogolang/Advanced/netserver"
+	"hellogolang/Projects/KVStore/kv"
+)
+
+// DefaultMaxLineSize is the line limit for a zero Options.MaxLineSize
+const DefaultMaxLineSize = 64 << 10
+
+// Limits on the entries a SCAN returns: DefaultScanLimit without a
+// limit, and never more than MaxScanLimit
+const (
+	DefaultScanLimit = 100
+	MaxScanLimit     = 1000
+)
+
+// Options configures a Handler. Zero fields take their defaults.
+type Options struct {
+	// MaxLineSize bounds the bytes of a request line, DefaultMaxLineSize
+	// if 0 or less
+	MaxLineSize int
+}
+
+// Handler returns the netserver handler serving store
+func Handler(store *kv.Store, opts Options) netserver.Handler {
+	if opts.MaxLineSize <= 0 {
+		opts.MaxLineSize = DefaultMaxLineSize
+	}
+	return netserver.HandlerFunc(func(ctx context.Context, c *netserver.Conn) {
+		serve(store, c, opts)
+	})
+}
+
+// serve answers the requests on rw until the client quits or goes away
+func serve(store *kv.Store, rw io.ReadWriter, opts Options) {
+	scanner := bufio.NewScanner(rw)
+	// The larger of the buffer and the limit bounds a line, so the buffer
+	// starts no larger than the limit
+	scanner.Buffer(make([]byte, 0, min(4096, opts.MaxLineSize)), opts.MaxLineSize)
+	w := bufio.NewWriter(rw)
+	for scanner.Scan() {
+		quit := execute(store, w, scanner.Text())
+		if w.Flush() != nil || quit {
+			return
+		}
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		fmt.Fprintf(w, "ERR line longer than %d bytes\n", opts.MaxLineSize)
+		w.Flush()
+	}
+}
+
+// execute runs one request line, writing its reply to w, and reports
+// whether the connection should close
+func execute(store *kv.Store, w io.Writer, line string) bool {
+	line = strings.TrimSuffix(line, "\r")
+	cmd, args, _ := strings.Cut(line, " ")
+	switch strings.ToUpper(cmd) {
+	case "GET":
+		key, ok := oneWord(args)
+		if !ok {
+			return usage(w, "GET key")
+		}
+		if v, ok := store.Get(key); ok {
+			fmt.Fprintf(w, "VALUE %s\n", v)
+		} else {
+			io.WriteString(w, "NOT_FOUND\n")
+		}
+	case "SET":
+		key, value, ok := strings.Cut(args, " ")
+		if !ok || key == "" {
+			return usage(w, "SET key value")
+		}
+		if err := store.Set(key, []byte(value)); err != nil {
+			return fail(w, err)
+		}
+		io.WriteString(w, "OK\n")
+	case "DEL":
+		key, ok := oneWord(args)
+		if !ok {
+			return usage(w, "DEL key")
+		}
+		deleted, err := store.Delete(key)
+		if err != nil {
+			return fail(w, err)
+		}
+		if deleted {
+			io.WriteString(w, "DELETED\n")
+		} else {
+			io.WriteString(w, "NOT_FOUND\n")
+		}
+	case "SCAN":
+		scan(store, w, args)
+	case "PING":
+		io.WriteString(w, "PONG\n")
+	case "QUIT":
+		io.WriteString(w, "BYE\n")
+		return true
+	case "":
+		// Blank lines are ignored, as telnet users send them
+	default:
+		fmt.Fprintf(w, "ERR unknown command %q\n", cmd)
+	}
+	return false
+}
+
+// scan answers SCAN [prefix] [limit]
+func scan(store *kv.Store, w io.Writer, args string) {
+	fields := strings.Fields(args)
+	if len(fields) > 2 {
+		usage(w, "SCAN [prefix] [limit]")
+		return
+	}
+	prefix, limit := "", DefaultScanLimit
+	if len(fields) > 0 {
+		prefix = fields[0]
+	}
+	if len(fields) == 2 {
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 || n > MaxScanLimit {
+			fmt.Fprintf(w, "ERR limit must be 1 to %d\n", MaxScanLimit)
+			return
+		}
+		limit = n
+	}
+	entries := store.Scan(prefix, limit)
+	for _, e := range entries {
+		fmt.Fprintf(w, "ENTRY %s %s\n", e.Key, e.Value)
+	}
+	fmt.Fprintf(w, "END %d\n", len(entries))
+}
+
+// oneWord returns args if it is a single non-empty word
+func oneWord(args string) (string, bool) {
+	return args, args != "" && !strings.Contains(args, " ")
+}
+
+// usage replies with the usage of a command
+func usage(w io.Writer, syntax string) bool {
+	fmt.Fprintf(w, "ERR usage: %s\n", syntax)
+	return false
+}
+
+// fail replies with err
+func fail(w io.Writer, err error) bool {
+	fmt.Fprintf(w, "ERR %v\n", err)
+	return false
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"hellogolang/Advanced/netserver"
+	"hellogolang/Projects/KVStore/kv"
+)
+
+// newStore opens a store in a temporary directory, closed with the test
+func newStore(t *testing.T) *kv.Store {
+	t.Helper()
+	store, err := kv.Open(t.TempDir(), kv.Options{SnapshotInterval: -1, MaxKeySize: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// TestExecute tests the replies to each command, in sequence on one store
+func TestExecute(t *testing.T) {
+	store := newStore(t)
+	tests := []struct {
+		line, want string
+	}{
+		{"PING", "PONG\n"},
+		{"GET a", "NOT_FOUND\n"},
+		{"SET a hello world", "OK\n"},
+		{"get a", "VALUE hello world\n"},
+		{"SET b ", "OK\n"},
+		{"GET b", "VALUE \n"},
+		{"SET a:1 x\r", "OK\n"},
+		{"SCAN", "ENTRY a hello world\nENTRY a:1 x\nENTRY b \nEND 3\n"},
+		{"SCAN a: 5", "ENTRY a:1 x\nEND 1\n"},
+		{"SCAN a 1", "ENTRY a hello world\nEND 1\n"},
+		{"SCAN z", "END 0\n"},
+		{"DEL a", "DELETED\n"},
+		{"DEL a", "NOT_FOUND\n"},
+		{"", ""},
+		{"GET", "ERR usage: GET key\n"},
+		{"GET a b", "ERR usage: GET key\n"},
+		{"SET a", "ERR usage: SET key value\n"},
+		{"DEL", "ERR usage: DEL key\n"},
+		{"SCAN a 0", "ERR limit must be 1 to 1000\n"},
+		{"SCAN a b c", "ERR usage: SCAN [prefix] [limit]\n"},
+		{"FLUSH", "ERR unknown command \"FLUSH\"\n"},
+		{"SET averyveryverylongkey v", "ERR invalid key: 20 bytes, want 1 to 16\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if quit := execute(store, &out, tt.line); quit {
+			t.Errorf("%q closed the connection", tt.line)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%q replied %q, want %q", tt.line, out.String(), tt.want)
+		}
+	}
+
+	var out bytes.Buffer
+	if !execute(store, &out, "QUIT") || out.String() != "BYE\n" {
+		t.Errorf("QUIT replied %q without closing", out.String())
+	}
+}
+
+// TestServe tests a client session over TCP, ended by an over-long line
+func TestServe(t *testing.T) {
+	store := newStore(t)
+	srv := netserver.New(Handler(store, Options{MaxLineSize: 64}), netserver.Options{})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	send := func(line string) string {
+		t.Helper()
+		if _, err := conn.Write([]byte(line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		reply, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reply to %.20q: %v", line, err)
+		}
+		return reply
+	}
+
+	if got := send("SET k v"); got != "OK\n" {
+		t.Errorf("SET replied %q", got)
+	}
+	if got := send("GET k"); got != "VALUE v\n" {
+		t.Errorf("GET replied %q", got)
+	}
+	if got := send("SET k " + strings.Repeat("x", 100)); !strings.HasPrefix(got, "ERR line longer") {
+		t.Errorf("an over-long line replied %q", got)
+	}
+	if _, err := r.ReadString('\n'); err == nil {
+		t.Error("connection still open after an over-long line")
+	}
+	if v, _ := store.Get("k"); string(v) != "v" {
+		t.Errorf("k = %q after the over-long line, want v", v)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	srv.Shutdown(ctx)
+}
@@ -0,0 +1,424 @@
+// Package wal is the write-ahead log of the key-value store. Every change
+// is appended to the log as a Record, framed by its length and CRC-32C,
+// before it is applied, so that Replay can rebuild the store after a
+// crash. The log is a series of segment files in one directory, each
+// named by the sequence number of its first record; Rotate starts a new
+// segment so that a snapshot can stand in for the older ones, which
+// RemoveBefore then deletes.
+//
+// A crash while appending leaves a torn record at the end of the last
+// segment. Replay cuts it off and carries on from the last whole record;
+// damage anywhere else is reported as ErrCorrupt rather than silently
+// dropping the records after it.
+package wal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hellogolang/Projects/KVStore/internal/fsutil"
+)
+
+var (
+	// ErrCorrupt is returned by Replay for damage other than a torn
+	// record at the end of the log
+	ErrCorrupt = errors.New("wal corrupt")
+	// ErrClosed is returned by writes to a closed log
+	ErrClosed = errors.New("wal closed")
+	// ErrRecordTooLarge is returned by Append for a record over
+	// MaxRecordSize
+	ErrRecordTooLarge = errors.New("wal record too large")
+)
+
+// SyncPolicy says when appended records are forced to stable storage
+type SyncPolicy int
+
+// The sync policies, from safest to fastest
+const (
+	// SyncAlways syncs every record before Append returns, so an
+	// acknowledged write survives a power failure
+	SyncAlways SyncPolicy = iota
+	// SyncInterval syncs every SyncInterval, so a power failure loses at
+	// most that much; a crash of the process alone loses nothing
+	SyncInterval
+	// SyncNever leaves syncing to the operating system
+	SyncNever
+)
+
+// Defaults for zero Options fields
+const (
+	DefaultSyncInterval  = 100 * time.Millisecond
+	DefaultMaxRecordSize = 16 << 20
+)
+
+// Options configures a Log. Zero fields take their defaults.
+type Options struct {
+	// Sync is when records are synced, SyncAlways by default
+	Sync SyncPolicy
+	// SyncInterval is how often SyncInterval syncs, DefaultSyncInterval if
+	// 0 or less
+	SyncInterval time.Duration
+	// MaxRecordSize bounds the payload of a record written or read,
+	// DefaultMaxRecordSize if 0 or less
+	MaxRecordSize int
+}
+
+// withDefaults returns o with its zero fields set to their defaults
+func (o Options) withDefaults() Options {
+	if o.SyncInterval <= 0 {
+		o.SyncInterval = DefaultSyncInterval
+	}
+	if o.MaxRecordSize <= 0 {
+		o.MaxRecordSize = DefaultMaxRecordSize
+	}
+	return o
+}
+
+// segmentExt is the extension of segment files
+const segmentExt = ".wal"
+
+// segmentName returns the file name of the segment starting at seq
+func segmentName(seq uint64) string {
+	return fmt.Sprintf("%020d%s", seq, segmentExt)
+}
+
+// segments returns the first sequence numbers of the segments in dir, in
+// increasing order
+func segments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var seqs []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		if seq, err := strconv.ParseUint(name, 10, 64); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	slices.Sort(seqs)
+	return seqs, nil
+}
+
+// Replay calls fn with every record in the log in dir whose sequence
+// number is above after, in order, and returns the last sequence number
+// seen, or after if there is none. A torn record at the end of the last
+// segment is cut off, truncating the file. A bad record elsewhere, or a
+// gap in the sequence, is ErrCorrupt. An error from fn stops the replay
+// and is returned.
+func Replay(dir string, after uint64, opts Options, fn func(Record) error) (uint64, error) {
+	opts = opts.withDefaults()
+	seqs, err := segments(dir)
+	if err != nil {
+		return 0, err
+	}
+	last := after
+	for i, first := range seqs {
+		isLast := i == len(seqs)-1
+		// A segment wholly covered by a later one starting at or below
+		// after holds nothing to replay
+		if !isLast && seqs[i+1] <= after+1 {
+			continue
+		}
+		if last, err = replaySegment(filepath.Join(dir, segmentName(first)), last, isLast, opts, fn); err != nil {
+			return 0, err
+		}
+	}
+	return last, nil
+}
+
+// replaySegment replays one segment, as Replay does
+func replaySegment(path string, last uint64, isLast bool, opts Options, fn func(Record) error) (uint64, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		rec, n, err := readRecord(r, opts.MaxRecordSize)
+		switch {
+		case err == nil:
+		case errors.Is(err, errTorn) && isLast:
+			// A crash cut this record short; everything before it stands
+			if err := f.Truncate(offset); err != nil {
+				return 0, err
+			}
+			return last, f.Sync()
+		case errors.Is(err, errTorn):
+			return 0, fmt.Errorf("%w: %s at offset %d: bad record", ErrCorrupt, filepath.Base(path), offset)
+		case err == io.EOF:
+			return last, nil
+		default:
+			return 0, fmt.Errorf("%s at offset %d: %w", filepath.Base(path), offset, err)
+		}
+		offset += int64(n)
+		if rec.Seq <= last {
+			continue
+		}
+		if rec.Seq != last+1 {
+			return 0, fmt.Errorf("%w: %s at offset %d: record %d follows %d", ErrCorrupt, filepath.Base(path), offset, rec.Seq, last)
+		}
+		if err := fn(rec); err != nil {
+			return 0, err
+		}
+		last = rec.Seq
+	}
+}
+
+// segmentFile is the open segment a Log appends to: an *os.File, which
+// tests replace to inject failures
+type segmentFile interface {
+	io.WriteCloser
+	Truncate(size int64) error
+	Sync() error
+}
+
+// Log appends records to the segments in a directory. It is safe for
+// concurrent use.
+type Log struct {
+	dir  string
+	opts Options
+
+	mu      sync.Mutex
+	file    segmentFile
+	buf     []byte // Reused to frame each record
+	size    int64  // Bytes in the current segment
+	nextSeq uint64
+	dirty   bool  // Records written since the last sync
+	err     error // A failed write that could not be undone, or a failed sync, failing every later one
+	closed  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open opens the log in dir, creating the directory if need be, to
+// append records from nextSeq on: the last sequence number Replay
+// returned, plus one. Records go on the end of the last segment, or a new
+// one if there is none. Replay must have been called first, to cut off
+// any torn record.
+func Open(dir string, nextSeq uint64, opts Options) (*Log, error) {
+	opts = opts.withDefaults()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	seqs, err := segments(dir)
+	if err != nil {
+		return nil, err
+	}
+	l := &Log{dir: dir, opts: opts, nextSeq: max(nextSeq, 1)}
+	var f *os.File
+	if len(seqs) > 0 {
+		f, err = os.OpenFile(filepath.Join(dir, segmentName(seqs[len(seqs)-1])), os.O_WRONLY|os.O_APPEND, 0)
+	} else {
+		f, err = l.create(l.nextSeq)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	l.file, l.size = f, info.Size()
+	if opts.Sync == SyncInterval {
+		l.stop, l.done = make(chan struct{}), make(chan struct{})
+		go l.syncLoop()
+	}
+	return l, nil
+}
+
+// create creates the segment starting at seq and syncs the directory, so
+// the new file survives a crash
+func (l *Log) create(seq uint64) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(l.dir, segmentName(seq)), os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := fsutil.SyncDir(l.dir); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Append writes a record of op on key and value, returning its sequence
+// number; with SyncAlways it is on stable storage when Append returns
+func (l *Log) Append(op Op, key string, value []byte) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+	if l.err != nil {
+		return 0, l.err
+	}
+	l.buf = appendRecord(l.buf[:0], Record{Seq: l.nextSeq, Op: op, Key: key, Value: value})
+	if len(l.buf)-recordHeaderSize > l.opts.MaxRecordSize {
+		return 0, fmt.Errorf("%w: %d bytes, at most %d", ErrRecordTooLarge, len(l.buf)-recordHeaderSize, l.opts.MaxRecordSize)
+	}
+	// One write per record, so a crash tears at most the last one
+	if _, err := l.file.Write(l.buf); err != nil {
+		// A partial record would hide every record after it from Replay,
+		// so it is cut off, or if that fails the log takes no more
+		if terr := l.file.Truncate(l.size); terr != nil {
+			l.err = fmt.Errorf("wal unusable after a failed write: %w", err)
+		}
+		return 0, err
+	}
+	l.size += int64(len(l.buf))
+	l.dirty = true
+	if l.opts.Sync == SyncAlways {
+		if err := l.syncLocked(); err != nil {
+			// The record is not acknowledged, so it is cut off where it can
+			// be; the failed sync has already stopped the log taking more,
+			// so its sequence number is never given to another record
+			l.size -= int64(len(l.buf))
+			l.file.Truncate(l.size)
+			return 0, err
+		}
+	}
+	seq := l.nextSeq
+	l.nextSeq++
+	return seq, nil
+}
+
+// Sync forces the records appended so far to stable storage
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	return l.syncLocked()
+}
+
+// syncLocked syncs the current segment if it has unsynced records. A
+// failed sync fails the log: the kernel may have dropped the unsynced
+// pages, so a later sync succeeding would not mean they are stored.
+func (l *Log) syncLocked() error {
+	if l.err != nil {
+		return l.err
+	}
+	if !l.dirty {
+		return nil
+	}
+	if err := l.file.Sync(); err != nil {
+		l.err = fmt.Errorf("wal unusable after a failed sync: %w", err)
+		return l.err
+	}
+	l.dirty = false
+	return nil
+}
+
+// syncLoop syncs every SyncInterval until the log is closed
+func (l *Log) syncLoop() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.opts.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			// A failed sync fails the log, reported by the next Append
+			// and by Close
+			l.Sync()
+		}
+	}
+}
+
+// NextSeq returns the sequence number the next record will get
+func (l *Log) NextSeq() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.nextSeq
+}
+
+// Rotate syncs and closes the current segment and starts a new one,
+// returning the sequence number of its first record: every record before
+// it is in the older segments. The caller typically holds its own lock
+// over Rotate, so that the state it snapshots matches the boundary.
+func (l *Log) Rotate() (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+	if err := l.syncLocked(); err != nil {
+		return 0, err
+	}
+	seqs, err := segments(l.dir)
+	if err != nil {
+		return 0, err
+	}
+	// The current segment is empty and already starts at the boundary
+	if len(seqs) > 0 && seqs[len(seqs)-1] == l.nextSeq {
+		return l.nextSeq, nil
+	}
+	f, err := l.create(l.nextSeq)
+	if err != nil {
+		return 0, err
+	}
+	old := l.file
+	l.file, l.size = f, 0
+	return l.nextSeq, old.Close()
+}
+
+// RemoveBefore deletes the segments holding only records before seq, as
+// when a snapshot up to seq-1 is durable. The current segment is always
+// kept.
+func (l *Log) RemoveBefore(seq uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	seqs, err := segments(l.dir)
+	if err != nil {
+		return err
+	}
+	removed := false
+	for i := 0; i+1 < len(seqs) && seqs[i+1] <= seq; i++ {
+		if err := os.Remove(filepath.Join(l.dir, segmentName(seqs[i]))); err != nil {
+			return err
+		}
+		removed = true
+	}
+	if removed {
+		return fsutil.SyncDir(l.dir)
+	}
+	return nil
+}
+
+// Close syncs and closes the log
+func (l *Log) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return ErrClosed
+	}
+	l.closed = true
+	err := l.syncLocked()
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.mu.Unlock()
+	if l.stop != nil {
+		close(l.stop)
+		<-l.done
+	}
+	return err
+}
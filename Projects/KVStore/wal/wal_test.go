@@ -0,0 +1,255 @@
+package wal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// collect replays the log in dir after seq, returning the records
+func collect(t *testing.T, dir string, after uint64) ([]Record, uint64) {
+	t.Helper()
+	var recs []Record
+	last, err := Replay(dir, after, Options{}, func(r Record) error {
+		recs = append(recs, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	return recs, last
+}
+
+// writeLog appends n set records to a new log in dir and closes it
+func writeLog(t *testing.T, dir string, n int) {
+	t.Helper()
+	l, err := Open(dir, 1, Options{Sync: SyncNever})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range n {
+		if _, err := l.Append(OpSet, string(rune('a'+i)), []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAppendReplay tests that records replay as appended, across reopens
+func TestAppendReplay(t *testing.T) {
+	dir := t.TempDir()
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNever} {
+		_, last := collect(t, dir, 0)
+		l, err := Open(dir, last+1, Options{Sync: policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		seq, err := l.Append(OpSet, "key", []byte("value"))
+		if err != nil || seq != last+1 {
+			t.Fatalf("Append = %d, %v, want %d", seq, err, last+1)
+		}
+		if _, err := l.Append(OpDelete, "key", nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recs, last := collect(t, dir, 0)
+	if len(recs) != 6 || last != 6 {
+		t.Fatalf("replayed %d records up to %d, want 6 up to 6", len(recs), last)
+	}
+	for i, r := range recs {
+		wantOp := OpSet
+		if i%2 == 1 {
+			wantOp = OpDelete
+		}
+		if r.Seq != uint64(i+1) || r.Op != wantOp || r.Key != "key" {
+			t.Errorf("record %d = %+v", i, r)
+		}
+	}
+	if string(recs[0].Value) != "value" || len(recs[1].Value) != 0 {
+		t.Errorf("values %q and %q", recs[0].Value, recs[1].Value)
+	}
+	if recs, _ := collect(t, dir, 4); len(recs) != 2 || recs[0].Seq != 5 {
+		t.Errorf("replay after 4 gave %+v", recs)
+	}
+}
+
+// TestTornTail tests that a record cut short by a crash is dropped and
+// cut off, and appending carries on after the last whole record
+func TestTornTail(t *testing.T) {
+	dir := t.TempDir()
+	writeLog(t, dir, 3)
+	path := filepath.Join(dir, segmentName(1))
+	info, _ := os.Stat(path)
+	whole := info.Size()
+	// Half a record, as a crash in the middle of a write leaves
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.Write(appendRecord(nil, Record{Seq: 4, Op: OpSet, Key: "d", Value: []byte("lost")})[:10])
+	f.Close()
+
+	recs, last := collect(t, dir, 0)
+	if len(recs) != 3 || last != 3 {
+		t.Errorf("replayed %d records up to %d, want 3 up to 3", len(recs), last)
+	}
+	if info, _ := os.Stat(path); info.Size() != whole {
+		t.Errorf("segment is %d bytes after replay, want %d", info.Size(), whole)
+	}
+
+	l, err := Open(dir, last+1, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Append(OpSet, "d", []byte("kept"))
+	l.Close()
+	if recs, _ := collect(t, dir, 0); len(recs) != 4 || string(recs[3].Value) != "kept" {
+		t.Errorf("after appending, replayed %+v", recs)
+	}
+}
+
+// TestBadChecksum tests that a flipped bit is a torn tail in the last
+// segment and corruption in an earlier one
+func TestBadChecksum(t *testing.T) {
+	dir := t.TempDir()
+	writeLog(t, dir, 3)
+	path := filepath.Join(dir, segmentName(1))
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 0x01
+	os.WriteFile(path, data, 0o600)
+
+	// Rotating leaves the damaged segment behind a newer one
+	l, err := Open(dir, 4, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Rotate()
+	l.Append(OpSet, "z", nil)
+	l.Close()
+	if _, err := Replay(dir, 0, Options{}, func(Record) error { return nil }); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("damage before the last segment gave %v, want ErrCorrupt", err)
+	}
+}
+
+// TestRotate tests that segments before a boundary are removed and the
+// rest still replay
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, 1, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Append(OpSet, "a", nil)
+	l.Append(OpSet, "b", nil)
+	boundary, err := l.Rotate()
+	if err != nil || boundary != 3 {
+		t.Fatalf("Rotate = %d, %v, want 3", boundary, err)
+	}
+	// Rotating an empty segment starts no other
+	if again, err := l.Rotate(); err != nil || again != 3 {
+		t.Errorf("second Rotate = %d, %v, want 3", again, err)
+	}
+	l.Append(OpSet, "c", nil)
+	l.Sync()
+	if seqs, _ := segments(dir); len(seqs) != 2 {
+		t.Errorf("segments %v, want two", seqs)
+	}
+	if err := l.RemoveBefore(boundary); err != nil {
+		t.Fatal(err)
+	}
+	if seqs, _ := segments(dir); len(seqs) != 1 || seqs[0] != 3 {
+		t.Errorf("segments %v after RemoveBefore, want [3]", seqs)
+	}
+	if recs, _ := collect(t, dir, 2); len(recs) != 1 || recs[0].Key != "c" {
+		t.Errorf("replayed %+v, want c", recs)
+	}
+	// Without the snapshot standing in for 1 and 2, the gap is corruption
+	if _, err := Replay(dir, 0, Options{}, func(Record) error { return nil }); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("replay from 0 gave %v, want ErrCorrupt", err)
+	}
+}
+
+// failingSync is a segment whose syncs fail, as on a full or failing disk
+type failingSync struct{ segmentFile }
+
+func (failingSync) Sync() error { return errors.New("injected sync failure") }
+
+// TestSyncFailure tests that a failed sync with SyncAlways drops the
+// unacknowledged record and fails the log, so no sequence number is given
+// to two records and Replay returns only acknowledged writes
+func TestSyncFailure(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, 1, Options{Sync: SyncAlways})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Append(OpSet, "a", []byte("kept")); err != nil {
+		t.Fatal(err)
+	}
+	l.file = failingSync{l.file}
+	if _, err := l.Append(OpSet, "b", []byte("failed")); err == nil {
+		t.Fatal("Append with a failing sync succeeded")
+	}
+	if _, err := l.Append(OpSet, "c", []byte("refused")); err == nil {
+		t.Error("Append after a failed sync succeeded")
+	}
+	if err := l.Sync(); err == nil {
+		t.Error("Sync after a failed sync succeeded")
+	}
+	if err := l.Close(); err == nil {
+		t.Error("Close after a failed sync succeeded")
+	}
+
+	recs, last := collect(t, dir, 0)
+	if len(recs) != 1 || last != 1 || recs[0].Key != "a" {
+		t.Fatalf("replayed %+v up to %d, want only a", recs, last)
+	}
+
+	// Reopened, the log carries on after the last acknowledged record
+	l, err = Open(dir, last+1, Options{Sync: SyncAlways})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq, err := l.Append(OpSet, "d", nil); err != nil || seq != 2 {
+		t.Errorf("Append after reopening = %d, %v, want 2", seq, err)
+	}
+	l.Close()
+	if recs, _ := collect(t, dir, 0); len(recs) != 2 || recs[1].Key != "d" {
+		t.Errorf("replayed %+v, want a and d", recs)
+	}
+}
+
+// TestLimits tests the record size limit and a closed log
+func TestLimits(t *testing.T) {
+	l, err := Open(t.TempDir(), 1, Options{MaxRecordSize: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Append(OpSet, "k", make([]byte, 32)); !errors.Is(err, ErrRecordTooLarge) {
+		t.Errorf("Append of a large record = %v, want ErrRecordTooLarge", err)
+	}
+	if seq, err := l.Append(OpSet, "k", []byte("v")); err != nil || seq != 1 {
+		t.Errorf("Append after a refusal = %d, %v, want 1", seq, err)
+	}
+	l.Close()
+	if _, err := l.Append(OpSet, "k", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Append after Close = %v, want ErrClosed", err)
+	}
+}
+
+// FuzzReadRecord tests that no input makes reading panic or allocate
+// beyond the limit
+func FuzzReadRecord(f *testing.F) {
+	f.Add(appendRecord(nil, Record{Seq: 1, Op: OpSet, Key: "k", Value: []byte("v")}))
+	f.Add([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, segmentName(1)), data, 0o600)
+		Replay(dir, 0, Options{MaxRecordSize: 1 << 10}, func(Record) error { return nil })
+	})
+}
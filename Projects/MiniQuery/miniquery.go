@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"hellogolang/Projects/MiniQuery/query"
+)
+
+// MiniQuery - Runs SQL SELECT queries over CSV and JSON files
+
+func main() {
+	db := query.NewDB()
+	flag.Func("t", "load a table from a .csv, .json or .ndjson file at `[name=]path`, named by its base name unless given a name; repeatable", func(arg string) error {
+		return load(db, arg)
+	})
+	format := flag.String("format", "table", "output format: table, csv or json")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -t [name=]path ... [flags] 'SELECT ...'\nWith no query, it is read from standard input.\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var sql string
+	if flag.NArg() > 0 {
+		sql = strings.Join(flag.Args(), " ")
+	} else {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: reading the query: %v\n", err)
+			os.Exit(1)
+		}
+		sql = string(data)
+	}
+
+	write, ok := writers[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q\n", *format)
+		flag.Usage()
+		os.Exit(2)
+	}
+	result, err := db.Query(sql)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	out := bufio.NewWriter(os.Stdout)
+	if err := write(out, result); err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// load adds the table of a -t flag
+func load(db *query.DB, arg string) error {
+	name, path, named := strings.Cut(arg, "=")
+	if !named {
+		path = arg
+	}
+	table, err := query.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	if named {
+		table.Name = name
+	}
+	return db.Add(table)
+}
+
+// writers format a result by the name of their format
+var writers = map[string]func(w io.Writer, r *query.Result) error{
+	"table": writeTable,
+	"csv":   writeCSV,
+	"json":  writeJSON,
+}
+
+// oneLine replaces the characters that would break a table's layout
+var oneLine = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+// writeTable writes aligned columns under a header, then the row count
+func writeTable(w io.Writer, r *query.Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	cells := make([]string, len(r.Columns))
+	rule := make([]string, len(r.Columns))
+	for i, c := range r.Columns {
+		cells[i] = oneLine.Replace(c)
+		rule[i] = strings.Repeat("-", len(cells[i]))
+	}
+	fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	fmt.Fprintln(tw, strings.Join(rule, "\t"))
+	for _, row := range r.Rows {
+		for i, v := range row {
+			// Secure: Keep cells on one line and in their column
+			cells[i] = oneLine.Replace(v.String())
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "(%d rows)\n", len(r.Rows))
+	return err
+}
+
+// writeCSV writes a header record and a record per row, NULL as empty
+func writeCSV(w io.Writer, r *query.Result) error {
+	cw := csv.NewWriter(w)
+	cw.Write(r.Columns)
+	record := make([]string, len(r.Columns))
+	for _, row := range r.Rows {
+		for i, v := range row {
+			record[i] = ""
+			if !v.IsNull() {
+				record[i] = v.String()
+			}
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON writes an array of objects, their keys in column order
+func writeJSON(w io.Writer, r *query.Result) error {
+	var b strings.Builder
+	b.WriteString("[")
+	for n, row := range r.Rows {
+		if n > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for i, v := range row {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			key, _ := json.Marshal(r.Columns[i])
+			value, err := json.Marshal(v.Any())
+			if err != nil {
+				// An infinite or NaN float has no JSON form
+				value = []byte("null")
+			}
+			b.Write(key)
+			b.WriteString(": ")
+			b.Write(value)
+		}
+		b.WriteString("}")
+	}
+	if len(r.Rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
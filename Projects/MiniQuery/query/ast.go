@@ -0,0 +1,269 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Select is a parsed query:
+//
+//	SELECT items FROM table [[INNER | LEFT [OUTER]] JOIN table ON cond]
+//	[WHERE cond] [ORDER BY expr [ASC | DESC], ...] [LIMIT n [OFFSET m]]
+type Select struct {
+	Items   []SelectItem
+	From    TableRef
+	Join    *Join // Or nil
+	Where   Expr  // Or nil
+	OrderBy []OrderItem
+	Limit   int64 // -1 for no limit
+	Offset  int64
+}
+
+// SelectItem is an output column: an expression and its alias, or a star
+// selecting every column of Table, or of all tables if Table is ""
+type SelectItem struct {
+	Expr  Expr
+	Alias string
+	Star  bool
+	Table string
+}
+
+// TableRef names a table and the alias it is referred to by, its name if
+// Alias is ""
+type TableRef struct {
+	Name  string
+	Alias string
+}
+
+// ref returns the name columns of the table are qualified by
+func (t TableRef) ref() string {
+	if t.Alias != "" {
+		return t.Alias
+	}
+	return t.Name
+}
+
+// JoinKind is the kind of a join
+type JoinKind uint8
+
+// The kinds of joins
+const (
+	// InnerJoin pairs the rows of both tables that meet the condition
+	InnerJoin JoinKind = iota
+	// LeftJoin also keeps each left row meeting no right row, with NULLs
+	// for the right columns
+	LeftJoin
+)
+
+// Join is the second table of a query and how its rows pair with the
+// first's
+type Join struct {
+	Kind  JoinKind
+	Table TableRef
+	On    Expr
+}
+
+// OrderItem is a sort key
+type OrderItem struct {
+	Expr Expr
+	Desc bool
+}
+
+// Expr is an expression: a *Literal, *ColumnRef, *Unary, *Binary,
+// *IsNull, *In, *Between, *Like or *Call
+type Expr interface {
+	// String formats the expression as SQL, fully parenthesized
+	String() string
+	expr()
+}
+
+// Literal is a constant
+type Literal struct{ Value Value }
+
+// ColumnRef names a column, qualified by a table if Table is not ""
+type ColumnRef struct{ Table, Name string }
+
+// Unary is NOT or a sign applied to an operand
+type Unary struct {
+	Op string // NOT, - or +
+	X  Expr
+}
+
+// Binary is an operator applied to two operands
+type Binary struct {
+	Op   string // AND, OR, =, !=, <, <=, >, >=, +, -, *, /, % or ||
+	L, R Expr
+}
+
+// IsNull is X IS NULL, or X IS NOT NULL if Not
+type IsNull struct {
+	X   Expr
+	Not bool
+}
+
+// In is X IN (List...), or X NOT IN if Not
+type In struct {
+	X    Expr
+	List []Expr
+	Not  bool
+}
+
+// Between is X BETWEEN Lo AND Hi, or X NOT BETWEEN if Not
+type Between struct {
+	X, Lo, Hi Expr
+	Not       bool
+}
+
+// Like is X LIKE Pattern, or X NOT LIKE if Not. In the pattern % matches
+// any run of characters and _ any one character.
+type Like struct {
+	X, Pattern Expr
+	Not        bool
+}
+
+// Call is a function call, its name upper-cased
+type Call struct {
+	Name string
+	Args []Expr
+}
+
+func (*Literal) expr()   {}
+func (*ColumnRef) expr() {}
+func (*Unary) expr()     {}
+func (*Binary) expr()    {}
+func (*IsNull) expr()    {}
+func (*In) expr()        {}
+func (*Between) expr()   {}
+func (*Like) expr()      {}
+func (*Call) expr()      {}
+
+// negation returns " NOT" if not is set
+func negation(not bool) string {
+	if not {
+		return " NOT"
+	}
+	return ""
+}
+
+// quoteIdent quotes a name if it is not a plain identifier or is a
+// keyword
+func quoteIdent(name string) string {
+	plain := name != "" && isIdentStart(name) && !keywords[strings.ToUpper(name)]
+	for i := range name {
+		if !isIdentPart(name[i:]) {
+			plain = false
+		}
+	}
+	if plain {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (e *Literal) String() string { return e.Value.literal() }
+
+func (e *ColumnRef) String() string {
+	if e.Table != "" {
+		return quoteIdent(e.Table) + "." + quoteIdent(e.Name)
+	}
+	return quoteIdent(e.Name)
+}
+
+func (e *Unary) String() string {
+	if e.Op == "NOT" {
+		return "(NOT " + e.X.String() + ")"
+	}
+	// The space keeps a minus from folding into a number literal
+	return "(" + e.Op + " " + e.X.String() + ")"
+}
+
+func (e *Binary) String() string {
+	return "(" + e.L.String() + " " + e.Op + " " + e.R.String() + ")"
+}
+
+func (e *IsNull) String() string {
+	return "(" + e.X.String() + " IS" + negation(e.Not) + " NULL)"
+}
+
+func (e *In) String() string {
+	items := make([]string, len(e.List))
+	for i, x := range e.List {
+		items[i] = x.String()
+	}
+	return "(" + e.X.String() + negation(e.Not) + " IN (" + strings.Join(items, ", ") + "))"
+}
+
+func (e *Between) String() string {
+	return "(" + e.X.String() + negation(e.Not) + " BETWEEN " + e.Lo.String() + " AND " + e.Hi.String() + ")"
+}
+
+func (e *Like) String() string {
+	return "(" + e.X.String() + negation(e.Not) + " LIKE " + e.Pattern.String() + ")"
+}
+
+func (e *Call) String() string {
+	args := make([]string, len(e.Args))
+	for i, x := range e.Args {
+		args[i] = x.String()
+	}
+	return e.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// String formats the query as SQL that parses back to the same query
+func (s *Select) String() string {
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	for i, item := range s.Items {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		switch {
+		case item.Star && item.Table != "":
+			b.WriteString(quoteIdent(item.Table) + ".*")
+		case item.Star:
+			b.WriteString("*")
+		default:
+			b.WriteString(item.Expr.String())
+			if item.Alias != "" {
+				b.WriteString(" AS " + quoteIdent(item.Alias))
+			}
+		}
+	}
+	b.WriteString(" FROM " + s.From.String())
+	if s.Join != nil {
+		if s.Join.Kind == LeftJoin {
+			b.WriteString(" LEFT")
+		}
+		fmt.Fprintf(&b, " JOIN %s ON %s", s.Join.Table, s.Join.On)
+	}
+	if s.Where != nil {
+		b.WriteString(" WHERE " + s.Where.String())
+	}
+	for i, o := range s.OrderBy {
+		if i == 0 {
+			b.WriteString(" ORDER BY ")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString(o.Expr.String())
+		if o.Desc {
+			b.WriteString(" DESC")
+		}
+	}
+	if s.Limit >= 0 {
+		b.WriteString(" LIMIT " + strconv.FormatInt(s.Limit, 10))
+	}
+	if s.Offset > 0 {
+		b.WriteString(" OFFSET " + strconv.FormatInt(s.Offset, 10))
+	}
+	return b.String()
+}
+
+// String formats the table reference as SQL
+func (t TableRef) String() string {
+	if t.Alias != "" {
+		return quoteIdent(t.Name) + " AS " + quoteIdent(t.Alias)
+	}
+	return quoteIdent(t.Name)
+}
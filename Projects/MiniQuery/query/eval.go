@@ -0,0 +1,547 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrType is returned for an operator or function applied to values
+	// of the wrong kind
+	ErrType = errors.New("type mismatch")
+	// ErrUnknownColumn is returned for a column no table has
+	ErrUnknownColumn = errors.New("unknown column")
+	// ErrAmbiguousColumn is returned for an unqualified column more than
+	// one table has
+	ErrAmbiguousColumn = errors.New("ambiguous column")
+	// ErrUnknownFunction is returned for a call of an undefined function
+	ErrUnknownFunction = errors.New("unknown function")
+	// ErrArguments is returned for a call with the wrong number of
+	// arguments
+	ErrArguments = errors.New("wrong number of arguments")
+	// ErrDivideByZero is returned for division or remainder by zero
+	ErrDivideByZero = errors.New("division by zero")
+	// ErrOverflow is returned for integer arithmetic out of range
+	ErrOverflow = errors.New("integer overflow")
+)
+
+// evalFunc evaluates a bound expression against a row of the scope it
+// was bound in
+type evalFunc func(row []Value) (Value, error)
+
+// scopeTable is a table whose columns a row holds from offset on
+type scopeTable struct {
+	ref     string
+	columns []string
+	offset  int
+}
+
+// scope is the tables a row is the concatenation of
+type scope []scopeTable
+
+// width returns the length of a row of the scope
+func (sc scope) width() int {
+	last := sc[len(sc)-1]
+	return last.offset + len(last.columns)
+}
+
+// resolve finds the row index of a column. Names of tables and columns
+// match without regard to case.
+func (sc scope) resolve(c *ColumnRef) (int, error) {
+	found, tableFound := -1, false
+	for _, t := range sc {
+		if c.Table != "" {
+			if !strings.EqualFold(c.Table, t.ref) {
+				continue
+			}
+			tableFound = true
+		}
+		for i, name := range t.columns {
+			if !strings.EqualFold(c.Name, name) {
+				continue
+			}
+			if found >= 0 {
+				return 0, fmt.Errorf("%w: %s", ErrAmbiguousColumn, c)
+			}
+			found = t.offset + i
+		}
+	}
+	switch {
+	case c.Table != "" && !tableFound:
+		return 0, fmt.Errorf("%w: %s", ErrUnknownTable, c.Table)
+	case found < 0:
+		return 0, fmt.Errorf("%w: %s", ErrUnknownColumn, c)
+	}
+	return found, nil
+}
+
+// bind resolves the columns of an expression and returns a function
+// evaluating it, so that names are looked up once rather than per row
+func bind(e Expr, sc scope) (evalFunc, error) {
+	switch e := e.(type) {
+	case *Literal:
+		v := e.Value
+		return func([]Value) (Value, error) { return v, nil }, nil
+	case *ColumnRef:
+		i, err := sc.resolve(e)
+		if err != nil {
+			return nil, err
+		}
+		return func(row []Value) (Value, error) { return row[i], nil }, nil
+	case *Unary:
+		x, err := bind(e.X, sc)
+		if err != nil {
+			return nil, err
+		}
+		op := unaryOps[e.Op]
+		return func(row []Value) (Value, error) {
+			v, err := x(row)
+			if err != nil || v.IsNull() {
+				return v, err
+			}
+			return op(v)
+		}, nil
+	case *Binary:
+		l, err := bind(e.L, sc)
+		if err != nil {
+			return nil, err
+		}
+		r, err := bind(e.R, sc)
+		if err != nil {
+			return nil, err
+		}
+		switch e.Op {
+		case "AND":
+			return logical(l, r, false), nil
+		case "OR":
+			return logical(l, r, true), nil
+		}
+		op := binaryOps[e.Op]
+		return func(row []Value) (Value, error) {
+			a, err := l(row)
+			if err != nil {
+				return Null, err
+			}
+			b, err := r(row)
+			if err != nil || a.IsNull() || b.IsNull() {
+				return Null, err
+			}
+			return op(a, b)
+		}, nil
+	case *IsNull:
+		x, err := bind(e.X, sc)
+		if err != nil {
+			return nil, err
+		}
+		return func(row []Value) (Value, error) {
+			v, err := x(row)
+			return Bool(v.IsNull() != e.Not), err
+		}, nil
+	case *In:
+		return bindIn(e, sc)
+	case *Between:
+		// X BETWEEN Lo AND Hi is X >= Lo AND X <= Hi, with X evaluated once
+		fs, err := bindAll(sc, e.X, e.Lo, e.Hi)
+		if err != nil {
+			return nil, err
+		}
+		return func(row []Value) (Value, error) {
+			vs, err := evalAll(fs, row)
+			if err != nil {
+				return Null, err
+			}
+			lo, err := compareOp(vs[0], vs[1], ">=")
+			if err != nil {
+				return Null, err
+			}
+			hi, err := compareOp(vs[0], vs[2], "<=")
+			if err != nil {
+				return Null, err
+			}
+			return negate(and3(lo, hi), e.Not), nil
+		}, nil
+	case *Like:
+		fs, err := bindAll(sc, e.X, e.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return func(row []Value) (Value, error) {
+			vs, err := evalAll(fs, row)
+			if err != nil || vs[0].IsNull() || vs[1].IsNull() {
+				return Null, err
+			}
+			if vs[0].kind != KindString || vs[1].kind != KindString {
+				return Null, fmt.Errorf("%w: LIKE needs strings, got %s and %s", ErrType, vs[0].kind, vs[1].kind)
+			}
+			return Bool(like(vs[0].s, vs[1].s) != e.Not), nil
+		}, nil
+	case *Call:
+		return bindCall(e, sc)
+	}
+	return nil, fmt.Errorf("query: unknown expression %T", e)
+}
+
+// bindAll binds several expressions
+func bindAll(sc scope, es ...Expr) ([]evalFunc, error) {
+	fs := make([]evalFunc, len(es))
+	for i, e := range es {
+		var err error
+		if fs[i], err = bind(e, sc); err != nil {
+			return nil, err
+		}
+	}
+	return fs, nil
+}
+
+// evalAll evaluates several bound expressions
+func evalAll(fs []evalFunc, row []Value) ([]Value, error) {
+	vs := make([]Value, len(fs))
+	for i, f := range fs {
+		var err error
+		if vs[i], err = f(row); err != nil {
+			return nil, err
+		}
+	}
+	return vs, nil
+}
+
+// truth returns a condition's value as true, false or NULL, failing for
+// any other kind
+func truth(v Value) (Value, error) {
+	if v.kind != KindBool && v.kind != KindNull {
+		return Null, fmt.Errorf("%w: condition is %s, not bool", ErrType, v.kind)
+	}
+	return v, nil
+}
+
+// negate inverts a three-valued boolean if not is set; NULL stays NULL
+func negate(v Value, not bool) Value {
+	if not && !v.IsNull() {
+		return Bool(v.i == 0)
+	}
+	return v
+}
+
+// and3 is three-valued AND: false if either is false, else NULL if
+// either is NULL
+func and3(a, b Value) Value {
+	switch {
+	case a == Bool(false) || b == Bool(false):
+		return Bool(false)
+	case a.IsNull() || b.IsNull():
+		return Null
+	}
+	return Bool(true)
+}
+
+// logical returns three-valued AND, or OR if or is set, evaluating the
+// right operand only if the left does not decide the result
+func logical(l, r evalFunc, or bool) evalFunc {
+	decides := Bool(or)
+	return func(row []Value) (Value, error) {
+		a, err := l(row)
+		if err == nil {
+			a, err = truth(a)
+		}
+		if err != nil || a == decides {
+			return a, err
+		}
+		b, err := r(row)
+		if err == nil {
+			b, err = truth(b)
+		}
+		switch {
+		case err != nil:
+			return Null, err
+		case b == decides:
+			return b, nil
+		case a.IsNull() || b.IsNull():
+			return Null, nil
+		}
+		return Bool(!or), nil
+	}
+}
+
+// unaryOps are the unary operators on a non-NULL operand
+var unaryOps = map[string]func(Value) (Value, error){
+	"NOT": func(v Value) (Value, error) {
+		v, err := truth(v)
+		return negate(v, true), err
+	},
+	"-": func(v Value) (Value, error) {
+		switch v.kind {
+		case KindInt:
+			if v.i == math.MinInt64 {
+				return Null, fmt.Errorf("%w: -(%d)", ErrOverflow, v.i)
+			}
+			return Int(-v.i), nil
+		case KindFloat:
+			return Float(-v.f), nil
+		}
+		return Null, fmt.Errorf("%w: cannot negate %s", ErrType, v.kind)
+	},
+	"+": func(v Value) (Value, error) {
+		if !v.numeric() {
+			return Null, fmt.Errorf("%w: unary + of %s", ErrType, v.kind)
+		}
+		return v, nil
+	},
+}
+
+// binaryOps are the operators other than AND and OR on non-NULL operands
+var binaryOps = map[string]func(a, b Value) (Value, error){
+	"=":  func(a, b Value) (Value, error) { return compareOp(a, b, "=") },
+	"!=": func(a, b Value) (Value, error) { return compareOp(a, b, "!=") },
+	"<":  func(a, b Value) (Value, error) { return compareOp(a, b, "<") },
+	"<=": func(a, b Value) (Value, error) { return compareOp(a, b, "<=") },
+	">":  func(a, b Value) (Value, error) { return compareOp(a, b, ">") },
+	">=": func(a, b Value) (Value, error) { return compareOp(a, b, ">=") },
+	"+":  func(a, b Value) (Value, error) { return arith(a, b, "+") },
+	"-":  func(a, b Value) (Value, error) { return arith(a, b, "-") },
+	"*":  func(a, b Value) (Value, error) { return arith(a, b, "*") },
+	"/":  func(a, b Value) (Value, error) { return arith(a, b, "/") },
+	"%":  func(a, b Value) (Value, error) { return arith(a, b, "%") },
+	"||": func(a, b Value) (Value, error) { return String(a.String() + b.String()), nil },
+}
+
+// compareOp applies a comparison operator, NULL if either operand is.
+// Values of kinds that do not compare are unequal, but cannot be ordered.
+func compareOp(a, b Value, op string) (Value, error) {
+	if a.IsNull() || b.IsNull() {
+		return Null, nil
+	}
+	c, err := compare(a, b)
+	if err != nil {
+		switch op {
+		case "=":
+			return Bool(false), nil
+		case "!=":
+			return Bool(true), nil
+		}
+		return Null, err
+	}
+	switch op {
+	case "=":
+		return Bool(c == 0), nil
+	case "!=":
+		return Bool(c != 0), nil
+	case "<":
+		return Bool(c < 0), nil
+	case "<=":
+		return Bool(c <= 0), nil
+	case ">":
+		return Bool(c > 0), nil
+	}
+	return Bool(c >= 0), nil
+}
+
+// arith applies an arithmetic operator to two numbers. Integers stay
+// integers, failing rather than wrapping on overflow, and divide
+// truncating toward zero; an integer and a float give a float.
+func arith(a, b Value, op string) (Value, error) {
+	if !a.numeric() || !b.numeric() {
+		return Null, fmt.Errorf("%w: %s %s %s", ErrType, a.kind, op, b.kind)
+	}
+	if a.kind == KindInt && b.kind == KindInt {
+		return intArith(a.i, b.i, op)
+	}
+	x, y := a.float(), b.float()
+	switch op {
+	case "+":
+		return Float(x + y), nil
+	case "-":
+		return Float(x - y), nil
+	case "*":
+		return Float(x * y), nil
+	}
+	if y == 0 {
+		return Null, ErrDivideByZero
+	}
+	if op == "/" {
+		return Float(x / y), nil
+	}
+	return Float(math.Mod(x, y)), nil
+}
+
+// intArith applies an arithmetic operator to two integers
+func intArith(x, y int64, op string) (Value, error) {
+	var r int64
+	overflow := false
+	switch op {
+	case "+":
+		r = x + y
+		overflow = (r > x) != (y > 0)
+	case "-":
+		r = x - y
+		overflow = (r < x) != (y > 0)
+	case "*":
+		r = x * y
+		overflow = x != 0 && (r/x != y || (x == -1 && y == math.MinInt64))
+	case "/", "%":
+		if y == 0 {
+			return Null, ErrDivideByZero
+		}
+		if x == math.MinInt64 && y == -1 {
+			if op == "%" {
+				return Int(0), nil
+			}
+			overflow = true
+			break
+		}
+		if op == "/" {
+			r = x / y
+		} else {
+			r = x % y
+		}
+	}
+	if overflow {
+		return Null, fmt.Errorf("%w: %d %s %d", ErrOverflow, x, op, y)
+	}
+	return Int(r), nil
+}
+
+// bindIn binds X [NOT] IN (list): true if X equals an item, else NULL if
+// X or an item is NULL, else false
+func bindIn(e *In, sc scope) (evalFunc, error) {
+	fs, err := bindAll(sc, append([]Expr{e.X}, e.List...)...)
+	if err != nil {
+		return nil, err
+	}
+	return func(row []Value) (Value, error) {
+		x, err := fs[0](row)
+		if err != nil || x.IsNull() {
+			return Null, err
+		}
+		result := Bool(false)
+		for _, f := range fs[1:] {
+			v, err := f(row)
+			if err != nil {
+				return Null, err
+			}
+			eq, _ := compareOp(x, v, "=")
+			if eq == Bool(true) {
+				return negate(eq, e.Not), nil
+			}
+			if eq.IsNull() {
+				result = Null
+			}
+		}
+		return negate(result, e.Not), nil
+	}, nil
+}
+
+// like reports whether s matches pattern, where % matches any run of
+// characters and _ any one character.
+//
+// Time Complexity: O(n*m) for a string of n and a pattern of m runes
+func like(s, pattern string) bool {
+	// On a mismatch, retry from the last % with it matching one more rune
+	star, retry := -1, 0
+	i, j := 0, 0
+	for i < len(s) {
+		if j < len(pattern) {
+			switch pc, pn := utf8.DecodeRuneInString(pattern[j:]); pc {
+			case '%':
+				star, retry = j+pn, i
+				j += pn
+				continue
+			case '_':
+				_, n := utf8.DecodeRuneInString(s[i:])
+				i, j = i+n, j+pn
+				continue
+			default:
+				if sc, n := utf8.DecodeRuneInString(s[i:]); sc == pc {
+					i, j = i+n, j+pn
+					continue
+				}
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		_, n := utf8.DecodeRuneInString(s[retry:])
+		retry += n
+		i, j = retry, star
+	}
+	for j < len(pattern) && pattern[j] == '%' {
+		j++
+	}
+	return j == len(pattern)
+}
+
+// function is a built-in function: its arity, -1 for one or more
+// arguments, and its implementation
+type function struct {
+	arity int
+	call  func(args []Value) (Value, error)
+}
+
+// functions are the built-in functions. Each but COALESCE returns NULL
+// for a NULL argument.
+var functions = map[string]function{
+	"LOWER":  {1, stringFunc("LOWER", func(s string) Value { return String(strings.ToLower(s)) })},
+	"UPPER":  {1, stringFunc("UPPER", func(s string) Value { return String(strings.ToUpper(s)) })},
+	"LENGTH": {1, stringFunc("LENGTH", func(s string) Value { return Int(int64(utf8.RuneCountInString(s))) })},
+	"ABS": {1, func(args []Value) (Value, error) {
+		v := args[0]
+		switch {
+		case v.IsNull():
+			return Null, nil
+		case v.kind == KindInt && v.i == math.MinInt64:
+			return Null, fmt.Errorf("%w: ABS(%d)", ErrOverflow, v.i)
+		case v.kind == KindInt:
+			return Int(max(v.i, -v.i)), nil
+		case v.kind == KindFloat:
+			return Float(math.Abs(v.f)), nil
+		}
+		return Null, fmt.Errorf("%w: ABS of %s", ErrType, v.kind)
+	}},
+	"COALESCE": {-1, func(args []Value) (Value, error) {
+		for _, v := range args {
+			if !v.IsNull() {
+				return v, nil
+			}
+		}
+		return Null, nil
+	}},
+}
+
+// stringFunc adapts a function of one string
+func stringFunc(name string, f func(string) Value) func([]Value) (Value, error) {
+	return func(args []Value) (Value, error) {
+		switch v := args[0]; v.kind {
+		case KindNull:
+			return Null, nil
+		case KindString:
+			return f(v.s), nil
+		default:
+			return Null, fmt.Errorf("%w: %s of %s", ErrType, name, v.kind)
+		}
+	}
+}
+
+// bindCall binds a function call, checking the function and its number
+// of arguments
+func bindCall(e *Call, sc scope) (evalFunc, error) {
+	fn, ok := functions[e.Name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFunction, e.Name)
+	}
+	if fn.arity >= 0 && len(e.Args) != fn.arity || fn.arity < 0 && len(e.Args) == 0 {
+		return nil, fmt.Errorf("%w: %s takes %d, got %d", ErrArguments, e.Name, max(fn.arity, 1), len(e.Args))
+	}
+	fs, err := bindAll(sc, e.Args...)
+	if err != nil {
+		return nil, err
+	}
+	return func(row []Value) (Value, error) {
+		args, err := evalAll(fs, row)
+		if err != nil {
+			return Null, err
+		}
+		return fn.call(args)
+	}, nil
+}
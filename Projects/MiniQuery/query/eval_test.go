@@ -0,0 +1,197 @@
+package query
+
+import (
+	"errors"
+	"testing"
+)
+
+// evalScope has one table t with columns i = 7, f = 2.5, s = 'Héllo' and
+// n = NULL
+var (
+	evalScope = scope{{ref: "t", columns: []string{"i", "f", "s", "n"}}}
+	evalRow   = []Value{Int(7), Float(2.5), String("Héllo"), Null}
+)
+
+// eval parses and evaluates an expression against evalRow
+func eval(src string) (Value, error) {
+	stmt, err := Parse("SELECT " + src + " FROM t")
+	if err != nil {
+		return Null, err
+	}
+	f, err := bind(stmt.Items[0].Expr, evalScope)
+	if err != nil {
+		return Null, err
+	}
+	return f(evalRow)
+}
+
+// TestEval tests the value of expressions
+func TestEval(t *testing.T) {
+	tests := []struct {
+		expr string
+		want Value
+	}{
+		{"i + 1", Int(8)},
+		{"i - 10", Int(-3)},
+		{"i * f", Float(17.5)},
+		{"i / 2", Int(3)},
+		{"-i / 2", Int(-3)},
+		{"i % 4", Int(3)},
+		{"f / 2", Float(1.25)},
+		{"i / 2.0", Float(3.5)},
+		{"-9223372036854775808 % -1", Int(0)},
+		{"i + n", Null},
+		{"-n", Null},
+		{"s || i", String("Héllo7")},
+		{"s || n", Null},
+		{"i = 7.0", Bool(true)},
+		{"i = '7'", Bool(false)},
+		{"i != '7'", Bool(true)},
+		{"s > 'H'", Bool(true)},
+		{"T.I <= 6", Bool(false)},
+		{"n = n", Null},
+		{"n IS NULL", Bool(true)},
+		{"i IS NOT NULL", Bool(true)},
+
+		// Three-valued logic
+		{"n = 1 AND FALSE", Bool(false)},
+		{"n = 1 AND TRUE", Null},
+		{"n = 1 OR TRUE", Bool(true)},
+		{"n = 1 OR FALSE", Null},
+		{"NOT (n = 1)", Null},
+		{"NOT i = 7", Bool(false)},
+		// The right side is not evaluated once the left decides
+		{"FALSE AND 1 / 0 = 1", Bool(false)},
+		{"TRUE OR i + 'x' = 1", Bool(true)},
+
+		{"i IN (1, 7)", Bool(true)},
+		{"i IN (1, 2)", Bool(false)},
+		{"i IN (1, NULL)", Null},
+		{"i NOT IN (1, 2)", Bool(true)},
+		{"i IN (7, NULL)", Bool(true)},
+		{"n IN (1)", Null},
+		{"i BETWEEN 1 AND 7", Bool(true)},
+		{"f NOT BETWEEN 1 AND 2", Bool(true)},
+		{"i BETWEEN n AND 5", Bool(false)},
+		{"i BETWEEN n AND 10", Null},
+
+		{"s LIKE 'H%o'", Bool(true)},
+		{"s LIKE 'h%'", Bool(false)},
+		{"s LIKE '_é___'", Bool(true)},
+		{"s LIKE '%l%l%'", Bool(true)},
+		{"s LIKE '%'", Bool(true)},
+		{"s LIKE 'Hell'", Bool(false)},
+		{"s NOT LIKE '%x%'", Bool(true)},
+		{"n LIKE '%'", Null},
+
+		{"LOWER(s)", String("héllo")},
+		{"upper(s)", String("HÉLLO")},
+		{"LENGTH(s)", Int(5)},
+		{"LENGTH(n)", Null},
+		{"ABS(-i)", Int(7)},
+		{"ABS(-f)", Float(2.5)},
+		{"COALESCE(n, n, i)", Int(7)},
+		{"COALESCE(n)", Null},
+	}
+	for _, tt := range tests {
+		got, err := eval(tt.expr)
+		if err != nil || got != tt.want {
+			t.Errorf("%s = %v (%s), %v, want %v (%s)", tt.expr, got, got.Kind(), err, tt.want, tt.want.Kind())
+		}
+	}
+}
+
+// TestEvalErrors tests expressions failing to bind or evaluate
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want error
+	}{
+		{"x", ErrUnknownColumn},
+		{"t.x", ErrUnknownColumn},
+		{"u.i", ErrUnknownTable},
+		{"NOSUCH(i)", ErrUnknownFunction},
+		{"LOWER(s, s)", ErrArguments},
+		{"COALESCE()", ErrArguments},
+		{"i + s", ErrType},
+		{"s < i", ErrType},
+		{"-s", ErrType},
+		{"NOT i", ErrType},
+		{"i AND TRUE", ErrType},
+		{"TRUE AND s", ErrType},
+		{"i LIKE 'x'", ErrType},
+		{"LOWER(i)", ErrType},
+		{"ABS(s)", ErrType},
+		{"i / 0", ErrDivideByZero},
+		{"f % 0", ErrDivideByZero},
+		{"9223372036854775807 + 1", ErrOverflow},
+		{"-9223372036854775808 - 1", ErrOverflow},
+		{"4611686018427387904 * 2", ErrOverflow},
+		{"-1 * -9223372036854775808", ErrOverflow},
+		{"-9223372036854775808 / -1", ErrOverflow},
+		{"-(-9223372036854775808)", ErrOverflow},
+		{"ABS(-9223372036854775808)", ErrOverflow},
+	}
+	for _, tt := range tests {
+		if _, err := eval(tt.expr); !errors.Is(err, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.expr, err, tt.want)
+		}
+	}
+}
+
+// TestAmbiguous tests columns resolved across two tables
+func TestAmbiguous(t *testing.T) {
+	sc := scope{
+		{ref: "a", columns: []string{"id", "x"}},
+		{ref: "b", columns: []string{"ID", "y"}, offset: 2},
+	}
+	if _, err := sc.resolve(&ColumnRef{Name: "id"}); !errors.Is(err, ErrAmbiguousColumn) {
+		t.Errorf("unqualified id: %v, want ErrAmbiguousColumn", err)
+	}
+	for ref, want := range map[ColumnRef]int{{"b", "id"}: 2, {"", "Y"}: 3, {"A", "x"}: 1} {
+		if i, err := sc.resolve(&ref); i != want || err != nil {
+			t.Errorf("%s = %d, %v, want %d", &ref, i, err, want)
+		}
+	}
+}
+
+// TestValues tests ordering, hashing and CSV inference of values
+func TestValues(t *testing.T) {
+	ordered := []Value{Null, Bool(false), Bool(true), Int(-1), Float(0.5), Int(1), String(""), String("a")}
+	for i := range ordered {
+		for j := range ordered {
+			if got, want := order(ordered[i], ordered[j]), min(max(i-j, -1), 1); got != want {
+				t.Errorf("order(%v, %v) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+
+	if Int(3).hashKey() != Float(3).hashKey() || Int(1).hashKey() == Bool(true).hashKey() ||
+		Int(1).hashKey() == String("1").hashKey() || Float(0.5).hashKey() == Int(0).hashKey() {
+		t.Error("hash keys do not follow equality")
+	}
+
+	infer := map[string]Value{
+		"":                     Null,
+		"42":                   Int(42),
+		"-7":                   Int(-7),
+		"007":                  String("007"),
+		"+7":                   String("+7"),
+		"3.25":                 Float(3.25),
+		"-0.5":                 Float(-0.5),
+		"1e3":                  String("1e3"),
+		".5":                   String(".5"),
+		"NaN":                  String("NaN"),
+		"true":                 Bool(true),
+		"FALSE":                Bool(false),
+		"yes":                  String("yes"),
+		" 1":                   String(" 1"),
+		"1.5.2":                String("1.5.2"),
+		"99999999999999999999": String("99999999999999999999"),
+	}
+	for s, want := range infer {
+		if got := inferValue(s); got != want {
+			t.Errorf("inferValue(%q) = %v (%s), want %v (%s)", s, got, got.Kind(), want, want.Kind())
+		}
+	}
+}
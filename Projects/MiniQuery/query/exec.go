@@ -0,0 +1,495 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"hellogolang/Algorithms/sorting"
+)
+
+var (
+	// ErrUnknownTable is returned for a table the database does not hold
+	ErrUnknownTable = errors.New("unknown table")
+	// ErrDuplicateTable is returned for a join of two tables by the same
+	// name, which needs an alias to tell them apart
+	ErrDuplicateTable = errors.New("duplicate table name")
+)
+
+// DB is a set of tables to query, looked up without regard to case. It is
+// not safe for concurrent use while tables are added.
+type DB struct {
+	tables map[string]*Table
+}
+
+// Result is the output of a query
+type Result struct {
+	Columns []string
+	Rows    [][]Value
+}
+
+// NewDB returns an empty database
+func NewDB() *DB {
+	return &DB{tables: make(map[string]*Table)}
+}
+
+// Add adds a table, replacing any of the same name
+func (db *DB) Add(t *Table) error {
+	if t.Name == "" || len(t.Columns) == 0 {
+		return fmt.Errorf("%w: table %q needs a name and columns", ErrBadSource, t.Name)
+	}
+	for i, row := range t.Rows {
+		if len(row) != len(t.Columns) {
+			return fmt.Errorf("%w: %s row %d has %d values for %d columns", ErrBadSource, t.Name, i+1, len(row), len(t.Columns))
+		}
+	}
+	db.tables[strings.ToLower(t.Name)] = t
+	return nil
+}
+
+// Table returns the table of a name
+func (db *DB) Table(name string) (*Table, bool) {
+	t, ok := db.tables[strings.ToLower(name)]
+	return t, ok
+}
+
+// Query parses and runs a query
+func (db *DB) Query(sql string) (*Result, error) {
+	stmt, err := Parse(sql)
+	if err != nil {
+		return nil, err
+	}
+	return db.Execute(stmt)
+}
+
+// Execute runs a parsed query: it joins, filters, projects, sorts and then
+// applies the offset and limit
+func (db *DB) Execute(s *Select) (*Result, error) {
+	left, ok := db.Table(s.From.Name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTable, s.From.Name)
+	}
+	sc := scope{{ref: s.From.ref(), columns: left.Columns}}
+	rows := left.Rows
+	if s.Join != nil {
+		right, ok := db.Table(s.Join.Table.Name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTable, s.Join.Table.Name)
+		}
+		if strings.EqualFold(s.From.ref(), s.Join.Table.ref()) {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateTable, s.Join.Table.ref())
+		}
+		sc = append(sc, scopeTable{ref: s.Join.Table.ref(), columns: right.Columns, offset: len(left.Columns)})
+		var err error
+		if rows, err = join(left.Rows, right.Rows, s.Join, sc); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.Where != nil {
+		where, err := bind(s.Where, sc)
+		if err != nil {
+			return nil, err
+		}
+		if rows, err = filter(rows, where); err != nil {
+			return nil, err
+		}
+	}
+
+	columns, project, err := projection(s.Items, sc)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := orderKeys(s, columns, sc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each output row carries its sort keys until it is sorted
+	type sortRow struct {
+		out, keys []Value
+	}
+	out := make([]sortRow, 0, len(rows))
+	for _, row := range rows {
+		values, err := evalAll(project, row)
+		if err != nil {
+			return nil, err
+		}
+		r := sortRow{out: values, keys: make([]Value, len(keys))}
+		for i, k := range keys {
+			if r.keys[i], err = k(row, values); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, r)
+	}
+	if len(keys) > 0 {
+		sorting.MergeSortFunc(out, func(a, b sortRow) int {
+			for i, o := range s.OrderBy {
+				if c := order(a.keys[i], b.keys[i]); c != 0 {
+					if o.Desc {
+						return -c
+					}
+					return c
+				}
+			}
+			return 0
+		})
+	}
+
+	start := min(s.Offset, int64(len(out)))
+	end := int64(len(out))
+	if s.Limit >= 0 {
+		end = min(end, start+s.Limit)
+	}
+	result := &Result{Columns: names(columns), Rows: make([][]Value, 0, end-start)}
+	for _, r := range out[start:end] {
+		result.Rows = append(result.Rows, r.out)
+	}
+	return result, nil
+}
+
+// filter keeps the rows for which a condition is true, not false or NULL
+func filter(rows [][]Value, cond evalFunc) ([][]Value, error) {
+	var kept [][]Value
+	for _, row := range rows {
+		ok, err := holds(cond, row)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			kept = append(kept, row)
+		}
+	}
+	return kept, nil
+}
+
+// holds reports whether a condition is true for a row
+func holds(cond evalFunc, row []Value) (bool, error) {
+	v, err := cond(row)
+	if err == nil {
+		v, err = truth(v)
+	}
+	return v == Bool(true), err
+}
+
+// column is an output column: its name, and the alias it was given if any
+type column struct {
+	name, alias string
+}
+
+// names returns the names of output columns
+func names(columns []column) []string {
+	s := make([]string, len(columns))
+	for i, c := range columns {
+		s[i] = c.name
+	}
+	return s
+}
+
+// projection binds the select list, expanding stars. A star over both
+// tables of a join qualifies the names they share.
+func projection(items []SelectItem, sc scope) ([]column, []evalFunc, error) {
+	shared := map[string]int{}
+	for _, t := range sc {
+		for _, name := range t.columns {
+			shared[strings.ToLower(name)]++
+		}
+	}
+	var columns []column
+	var fs []evalFunc
+	for _, item := range items {
+		if !item.Star {
+			f, err := bind(item.Expr, sc)
+			if err != nil {
+				return nil, nil, err
+			}
+			name := item.Alias
+			if name == "" {
+				name = columnName(item.Expr)
+			}
+			columns = append(columns, column{name, item.Alias})
+			fs = append(fs, f)
+			continue
+		}
+		found := false
+		for _, t := range sc {
+			if item.Table != "" && !strings.EqualFold(item.Table, t.ref) {
+				continue
+			}
+			found = true
+			for i, name := range t.columns {
+				if item.Table == "" && shared[strings.ToLower(name)] > 1 {
+					name = t.ref + "." + name
+				}
+				at := t.offset + i
+				columns = append(columns, column{name: name})
+				fs = append(fs, func(row []Value) (Value, error) { return row[at], nil })
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("%w: %s", ErrUnknownTable, item.Table)
+		}
+	}
+	return columns, fs, nil
+}
+
+// columnName names an output column without an alias: a column by its
+// name, anything else by its SQL
+func columnName(e Expr) string {
+	switch e := e.(type) {
+	case *ColumnRef:
+		return e.Name
+	case *Literal, *Call:
+		return e.String()
+	}
+	// The rest are fully parenthesized
+	s := e.String()
+	return s[1 : len(s)-1]
+}
+
+// sortKey computes a sort key from a source row and its output row
+type sortKey func(row, out []Value) (Value, error)
+
+// orderKeys binds ORDER BY. A key may be the alias of an output column, an
+// integer literal giving the position of one from 1, or an expression
+// over the source tables.
+func orderKeys(s *Select, columns []column, sc scope) ([]sortKey, error) {
+	keys := make([]sortKey, len(s.OrderBy))
+	for i, o := range s.OrderBy {
+		if at, ok, err := outputColumn(o.Expr, columns); err != nil {
+			return nil, err
+		} else if ok {
+			keys[i] = func(_, out []Value) (Value, error) { return out[at], nil }
+			continue
+		}
+		f, err := bind(o.Expr, sc)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = func(row, _ []Value) (Value, error) { return f(row) }
+	}
+	return keys, nil
+}
+
+// outputColumn finds the output column an ORDER BY key names, if any
+func outputColumn(e Expr, columns []column) (int, bool, error) {
+	switch e := e.(type) {
+	case *Literal:
+		if e.Value.kind != KindInt {
+			break
+		}
+		if e.Value.i < 1 || e.Value.i > int64(len(columns)) {
+			return 0, false, fmt.Errorf("%w: ORDER BY position %d of %d columns", ErrUnknownColumn, e.Value.i, len(columns))
+		}
+		return int(e.Value.i - 1), true, nil
+	case *ColumnRef:
+		if e.Table != "" {
+			break
+		}
+		at := -1
+		for i, c := range columns {
+			if c.alias == "" || !strings.EqualFold(c.alias, e.Name) {
+				continue
+			}
+			if at >= 0 {
+				return 0, false, fmt.Errorf("%w: %s", ErrAmbiguousColumn, e.Name)
+			}
+			at = i
+		}
+		return at, at >= 0, nil
+	}
+	return 0, false, nil
+}
+
+// join pairs the rows of two tables. Equalities between an expression of
+// the left table and one of the right, ANDed into the condition, make it
+// a hash join: the right rows are hashed on their side and each left row
+// looks up its matches. Otherwise every pair is tried.
+//
+// Time Complexity: O(n + m + matches) for a hash join, O(n*m) otherwise
+func join(left, right [][]Value, j *Join, sc scope) ([][]Value, error) {
+	width, offset := sc.width(), sc[1].offset
+	leftKeys, rightKeys, residual, err := splitJoin(j.On, sc)
+	if err != nil {
+		return nil, err
+	}
+	var cond evalFunc
+	if residual != nil {
+		if cond, err = bind(residual, sc); err != nil {
+			return nil, err
+		}
+	}
+	// pair concatenates two rows, NULLs standing in for a missing right row
+	pair := func(l, r []Value) []Value {
+		row := make([]Value, width)
+		copy(row, l)
+		copy(row[offset:], r)
+		return row
+	}
+
+	var matches func(l []Value) ([][]Value, error)
+	if len(leftKeys) > 0 {
+		buckets := map[string][][]Value{}
+		for _, r := range right {
+			key, ok, err := hashRow(rightKeys, pair(nil, r))
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				buckets[key] = append(buckets[key], r)
+			}
+		}
+		matches = func(l []Value) ([][]Value, error) {
+			key, ok, err := hashRow(leftKeys, pair(l, nil))
+			if err != nil || !ok {
+				return nil, err
+			}
+			return buckets[key], nil
+		}
+	} else {
+		matches = func([]Value) ([][]Value, error) { return right, nil }
+	}
+
+	var out [][]Value
+	for _, l := range left {
+		candidates, err := matches(l)
+		if err != nil {
+			return nil, err
+		}
+		matched := false
+		for _, r := range candidates {
+			row := pair(l, r)
+			if cond != nil {
+				ok, err := holds(cond, row)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+			}
+			out = append(out, row)
+			matched = true
+		}
+		if !matched && j.Kind == LeftJoin {
+			out = append(out, pair(l, nil))
+		}
+	}
+	return out, nil
+}
+
+// hashRow evaluates join keys into a hash key. A NULL key equals nothing,
+// so the row cannot match.
+func hashRow(keys []evalFunc, row []Value) (string, bool, error) {
+	var b strings.Builder
+	for _, k := range keys {
+		v, err := k(row)
+		if err != nil || v.IsNull() {
+			return "", false, err
+		}
+		// Length-prefixed, so no two key lists encode the same
+		h := v.hashKey()
+		fmt.Fprintf(&b, "%d:%s", len(h), h)
+	}
+	return b.String(), true, nil
+}
+
+// splitJoin splits a join condition into hash keys, equalities between a
+// left and a right expression, and the residual condition of everything
+// else ANDed, nil if nothing else
+func splitJoin(on Expr, sc scope) (leftKeys, rightKeys []evalFunc, residual Expr, err error) {
+	for _, e := range conjuncts(on, nil) {
+		if eq, ok := e.(*Binary); ok && eq.Op == "=" {
+			l, errL := sides(eq.L, sc)
+			r, errR := sides(eq.R, sc)
+			if err := errors.Join(errL, errR); err != nil {
+				return nil, nil, nil, err
+			}
+			if r == sideLeft && l == sideRight {
+				eq = &Binary{"=", eq.R, eq.L}
+				l, r = r, l
+			}
+			if l == sideLeft && r == sideRight {
+				lf, err := bind(eq.L, sc)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				rf, err := bind(eq.R, sc)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				leftKeys = append(leftKeys, lf)
+				rightKeys = append(rightKeys, rf)
+				continue
+			}
+		}
+		if residual == nil {
+			residual = e
+		} else {
+			residual = &Binary{"AND", residual, e}
+		}
+	}
+	return leftKeys, rightKeys, residual, nil
+}
+
+// conjuncts flattens a tree of ANDs into its operands
+func conjuncts(e Expr, list []Expr) []Expr {
+	if b, ok := e.(*Binary); ok && b.Op == "AND" {
+		return conjuncts(b.R, conjuncts(b.L, list))
+	}
+	return append(list, e)
+}
+
+// The tables an expression reads, as bits
+const (
+	sideLeft  = 1 << iota // Reads the first table
+	sideRight             // Reads the second table
+)
+
+// sides reports which tables of a join an expression reads
+func sides(e Expr, sc scope) (int, error) {
+	switch e := e.(type) {
+	case *ColumnRef:
+		i, err := sc.resolve(e)
+		if err != nil {
+			return 0, err
+		}
+		if i < sc[1].offset {
+			return sideLeft, nil
+		}
+		return sideRight, nil
+	case *Literal:
+		return 0, nil
+	}
+	side := 0
+	for _, child := range children(e) {
+		s, err := sides(child, sc)
+		if err != nil {
+			return 0, err
+		}
+		side |= s
+	}
+	return side, nil
+}
+
+// children returns the operands of an expression
+func children(e Expr) []Expr {
+	switch e := e.(type) {
+	case *Unary:
+		return []Expr{e.X}
+	case *Binary:
+		return []Expr{e.L, e.R}
+	case *IsNull:
+		return []Expr{e.X}
+	case *In:
+		return append([]Expr{e.X}, e.List...)
+	case *Between:
+		return []Expr{e.X, e.Lo, e.Hi}
+	case *Like:
+		return []Expr{e.X, e.Pattern}
+	case *Call:
+		return e.Args
+	}
+	return nil
+}
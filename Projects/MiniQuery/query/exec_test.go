@@ -0,0 +1,183 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testDB holds employees and departments, loaded from CSV and JSON
+func testDB(t *testing.T) *DB {
+	t.Helper()
+	employees, err := LoadCSV("employees", strings.NewReader(`id,name,dept,salary,manager
+1,Ada,10,120000,
+2,Alan,10,95000,1
+3,Grace,20,130000,
+4,Edsger,30,88000.5,3
+5,Barbara,,99000,3
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	depts, err := LoadJSON("depts", strings.NewReader(`[
+		{"id": 10, "name": "Research", "floor": 2},
+		{"id": 20, "name": "Compilers", "floor": 3},
+		{"id": 40, "name": "Empty"},
+		{"id": 10.0, "name": "Research annex"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := NewDB()
+	for _, table := range []*Table{employees, depts} {
+		if err := db.Add(table); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+// format renders a result as a header line and one line per row
+func format(r *Result) string {
+	var b strings.Builder
+	b.WriteString(strings.Join(r.Columns, "|"))
+	for _, row := range r.Rows {
+		b.WriteString("\n")
+		for i, v := range row {
+			if i > 0 {
+				b.WriteString("|")
+			}
+			b.WriteString(v.String())
+		}
+	}
+	return b.String()
+}
+
+// TestQuery tests queries end to end against the test database
+func TestQuery(t *testing.T) {
+	db := testDB(t)
+	tests := []struct {
+		sql, want string
+	}{
+		{"SELECT name FROM employees WHERE salary > 100000",
+			"name\nAda\nGrace"},
+		{"SELECT name, salary / 1000 AS k FROM Employees WHERE dept IS NULL OR dept = 30 ORDER BY k DESC",
+			"name|k\nBarbara|99\nEdsger|88.0005"},
+		{"SELECT id, name FROM employees ORDER BY dept DESC, name LIMIT 3",
+			"id|name\n4|Edsger\n3|Grace\n1|Ada"},
+		{"SELECT id FROM employees ORDER BY dept, id LIMIT 2 OFFSET 1",
+			"id\n1\n2"},
+		{"SELECT id FROM employees LIMIT 5 OFFSET 10",
+			"id"},
+		{"SELECT id FROM employees ORDER BY 1 DESC LIMIT 0",
+			"id"},
+		// NULLs sort first, and the sort is stable
+		{"SELECT name, dept FROM employees ORDER BY dept",
+			"name|dept\nBarbara|NULL\nAda|10\nAlan|10\nGrace|20\nEdsger|30"},
+		// A key that is not an output column, and one by position
+		{"SELECT upper(name) FROM employees WHERE name LIKE '%a%' ORDER BY salary, 1",
+			"UPPER(name)\nALAN\nBARBARA\nADA\nGRACE"},
+		{"SELECT id * 2 + 1, 'x' || name FROM employees WHERE id BETWEEN 2 AND 3",
+			"(id * 2) + 1|'x' || name\n5|xAlan\n7|xGrace"},
+		// Hash join, with the integral float key matching an integer
+		{"SELECT e.name, d.name FROM employees e JOIN depts d ON e.dept = d.id ORDER BY e.id, d.name",
+			"name|name\nAda|Research\nAda|Research annex\nAlan|Research\nAlan|Research annex\nGrace|Compilers"},
+		// Keys on either side of =, a residual condition, and a LEFT join
+		// keeping unmatched rows
+		{"SELECT e.name, floor FROM employees e LEFT JOIN depts d ON d.id = e.dept AND d.floor > 2 ORDER BY e.id",
+			"name|floor\nAda|NULL\nAlan|NULL\nGrace|3\nEdsger|NULL\nBarbara|NULL"},
+		// A join with no equality runs as a nested loop
+		{"SELECT e.name, d.name FROM employees e JOIN depts d ON e.dept < d.id AND d.id = 40 ORDER BY e.id",
+			"name|name\nAda|Empty\nAlan|Empty\nGrace|Empty\nEdsger|Empty"},
+		// A star over both tables qualifies shared names
+		{"SELECT * FROM employees JOIN depts ON dept = depts.id WHERE depts.id = 20",
+			"employees.id|employees.name|dept|salary|manager|depts.id|depts.name|floor\n3|Grace|20|130000|NULL|20|Compilers|3"},
+		{"SELECT d.*, e.id FROM depts d JOIN employees e ON d.id = e.dept WHERE e.id = 3",
+			"id|name|floor|id\n20|Compilers|3|3"},
+		// A self join needs aliases
+		{"SELECT e.name, m.name AS boss FROM employees e JOIN employees m ON e.manager = m.id ORDER BY e.id",
+			"name|boss\nAlan|Ada\nEdsger|Grace\nBarbara|Grace"},
+		// ORDER BY an alias, also naming a source column
+		{"SELECT -salary AS salary FROM employees ORDER BY salary LIMIT 1",
+			"salary\n-130000"},
+	}
+	for _, tt := range tests {
+		r, err := db.Query(tt.sql)
+		if err != nil {
+			t.Errorf("%s: %v", tt.sql, err)
+			continue
+		}
+		if got := format(r); got != tt.want {
+			t.Errorf("%s =\n%s\nwant\n%s", tt.sql, got, tt.want)
+		}
+	}
+}
+
+// TestQueryErrors tests queries that parse but fail to run
+func TestQueryErrors(t *testing.T) {
+	db := testDB(t)
+	tests := []struct {
+		sql  string
+		want error
+	}{
+		{"SELECT * FROM nosuch", ErrUnknownTable},
+		{"SELECT * FROM employees JOIN nosuch ON 1 = 1", ErrUnknownTable},
+		{"SELECT nosuch.* FROM employees", ErrUnknownTable},
+		{"SELECT x FROM employees", ErrUnknownColumn},
+		{"SELECT id FROM employees JOIN depts ON dept = depts.id", ErrAmbiguousColumn},
+		{"SELECT id FROM employees e JOIN depts d ON nosuch = d.id", ErrUnknownColumn},
+		{"SELECT * FROM employees JOIN employees ON 1 = 1", ErrDuplicateTable},
+		{"SELECT * FROM employees WHERE name", ErrType},
+		{"SELECT * FROM employees WHERE salary / (id - 3) > 0", ErrDivideByZero},
+		{"SELECT * FROM employees ORDER BY 0", ErrUnknownColumn},
+		{"SELECT id AS a, name AS a FROM employees ORDER BY a", ErrAmbiguousColumn},
+		{"SELECT * FROM employees e JOIN depts d ON e.dept = d.id AND e.name", ErrType},
+		{"SELECT * FROM employees e JOIN depts d ON e.dept = d.id AND e.name + 1 = 0", ErrType},
+	}
+	for _, tt := range tests {
+		if _, err := db.Query(tt.sql); !errors.Is(err, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.sql, err, tt.want)
+		}
+	}
+
+	var syntax *SyntaxError
+	if _, err := db.Query("SELECT FROM employees"); !errors.As(err, &syntax) {
+		t.Errorf("bad syntax: %v, want a *SyntaxError", err)
+	}
+	if err := db.Add(&Table{Name: "t", Columns: []string{"a"}, Rows: [][]Value{{Null, Null}}}); !errors.Is(err, ErrBadSource) {
+		t.Errorf("Add of a ragged table: %v, want ErrBadSource", err)
+	}
+}
+
+// TestHashJoinMatchesNestedLoop tests that the hash join finds the rows a
+// nested loop over every pair does, for keys of mixed kinds and NULLs
+func TestHashJoinMatchesNestedLoop(t *testing.T) {
+	keys := []Value{Null, Int(1), Float(1), Float(1.5), String("1"), Bool(true), Int(2)}
+	l := &Table{Name: "l", Columns: []string{"k", "n"}}
+	r := &Table{Name: "r", Columns: []string{"k", "n"}}
+	for i := range 40 {
+		l.Rows = append(l.Rows, []Value{keys[i%len(keys)], Int(int64(i))})
+		r.Rows = append(r.Rows, []Value{keys[(i*3)%len(keys)], Int(int64(i))})
+	}
+	db := NewDB()
+	db.Add(l)
+	db.Add(r)
+	for _, kind := range []string{"JOIN", "LEFT JOIN"} {
+		// The OR hides the equality from the hash join
+		hashed, err := db.Query(fmt.Sprintf("SELECT l.n, r.n FROM l %s r ON l.k = r.k AND l.n >= 0", kind))
+		if err != nil {
+			t.Fatal(err)
+		}
+		looped, err := db.Query(fmt.Sprintf("SELECT l.n, r.n FROM l %s r ON l.k = r.k OR 1 = 0", kind))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format(hashed) != format(looped) {
+			t.Errorf("%s: hash join\n%s\nnested loop\n%s", kind, format(hashed), format(looped))
+		}
+		if len(hashed.Rows) == 0 {
+			t.Errorf("%s: no rows", kind)
+		}
+	}
+}
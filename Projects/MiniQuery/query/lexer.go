@@ -0,0 +1,184 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SyntaxError is a query that does not lex or parse
+type SyntaxError struct {
+	Pos int // Byte offset in the query
+	Msg string
+}
+
+// Error describes the error and where it is
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at offset %d: %s", e.Pos, e.Msg)
+}
+
+// tokenKind is the class of a token
+type tokenKind uint8
+
+const (
+	tokEOF     tokenKind = iota
+	tokIdent             // A name, or a keyword if not quoted
+	tokKeyword           // A reserved word, its text upper-cased
+	tokNumber
+	tokString // A quoted string, its text unquoted
+	tokOp     // Punctuation and operators
+)
+
+// token is a lexeme of a query
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// String describes the token for error messages
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return fmt.Sprintf("string %q", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// keywords are the reserved words, which are names only when quoted
+var keywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "ORDER": true, "BY": true,
+	"ASC": true, "DESC": true, "LIMIT": true, "OFFSET": true, "AS": true,
+	"AND": true, "OR": true, "NOT": true, "NULL": true, "IS": true,
+	"IN": true, "BETWEEN": true, "LIKE": true, "JOIN": true, "INNER": true,
+	"LEFT": true, "OUTER": true, "ON": true, "TRUE": true, "FALSE": true,
+}
+
+// operators are the multi-character operators, tried before single ones
+var operators = []string{"<=", ">=", "<>", "!=", "||"}
+
+// lex splits a query into tokens, ending with tokEOF
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && strings.HasPrefix(src[i:], "--"):
+			// A comment runs to the end of the line
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case isIdentStart(src[i:]):
+			start := i
+			for i < len(src) && isIdentPart(src[i:]) {
+				_, n := utf8.DecodeRuneInString(src[i:])
+				i += n
+			}
+			word := src[start:i]
+			if upper := strings.ToUpper(word); keywords[upper] {
+				toks = append(toks, token{tokKeyword, upper, start})
+			} else {
+				toks = append(toks, token{tokIdent, word, start})
+			}
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			i = scanNumber(src, i)
+			toks = append(toks, token{tokNumber, src[start:i], start})
+		case c == '\'':
+			text, end, err := scanQuoted(src, i, '\'')
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{tokString, text, i})
+			i = end
+		case c == '"' || c == '`':
+			text, end, err := scanQuoted(src, i, c)
+			if err != nil {
+				return nil, err
+			}
+			if text == "" {
+				return nil, &SyntaxError{i, "empty quoted name"}
+			}
+			toks = append(toks, token{tokIdent, text, i})
+			i = end
+		default:
+			op := string(c)
+			for _, long := range operators {
+				if strings.HasPrefix(src[i:], long) {
+					op = long
+					break
+				}
+			}
+			if len(op) == 1 && !strings.ContainsRune(",.()*+-/%=<>", rune(c)) {
+				r, _ := utf8.DecodeRuneInString(src[i:])
+				return nil, &SyntaxError{i, fmt.Sprintf("unexpected character %q", r)}
+			}
+			toks = append(toks, token{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{tokEOF, "", len(src)}), nil
+}
+
+// isIdentStart reports whether s starts with a letter or underscore
+func isIdentStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// isIdentPart reports whether s starts with a letter, digit or underscore
+func isIdentPart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// scanNumber returns the end of the number starting at i: digits, an
+// optional fraction and an optional exponent
+func scanNumber(src string, i int) int {
+	digits := func() {
+		for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+			i++
+		}
+	}
+	digits()
+	if i < len(src) && src[i] == '.' {
+		i++
+		digits()
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && src[j] >= '0' && src[j] <= '9' {
+			i = j
+			digits()
+		}
+	}
+	return i
+}
+
+// scanQuoted reads the quoted text starting at i, where a doubled quote
+// stands for one, returning the text and the offset after it
+func scanQuoted(src string, i int, quote byte) (string, int, error) {
+	var b strings.Builder
+	for j := i + 1; j < len(src); j++ {
+		if src[j] != quote {
+			b.WriteByte(src[j])
+			continue
+		}
+		if j+1 < len(src) && src[j+1] == quote {
+			b.WriteByte(quote)
+			j++
+			continue
+		}
+		return b.String(), j + 1, nil
+	}
+	return "", 0, &SyntaxError{i, "unterminated quote"}
+}
@@ -0,0 +1,522 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxDepth bounds the nesting of expressions, so a hostile query cannot
+// exhaust the stack
+const maxDepth = 200
+
+// parser is a recursive descent parser over the tokens of a query
+type parser struct {
+	toks  []token
+	pos   int
+	depth int
+}
+
+// Parse parses a SELECT query. Errors are *SyntaxError.
+//
+// Operators bind from loosest to tightest: OR; AND; NOT; comparisons with
+// IS, IN, BETWEEN and LIKE; + - and ||; * / and %; unary signs.
+func Parse(sql string) (*Select, error) {
+	toks, err := lex(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	stmt, err := p.selectStmt()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("unexpected %s after the query", p.peek())
+	}
+	return stmt, nil
+}
+
+// peek returns the current token
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+// next consumes and returns the current token
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// errorf returns a syntax error at the current token
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{p.peek().pos, fmt.Sprintf(format, args...)}
+}
+
+// keyword consumes the current token if it is one of the keywords, and
+// returns which
+func (p *parser) keyword(words ...string) (string, bool) {
+	t := p.peek()
+	if t.kind == tokKeyword {
+		for _, w := range words {
+			if t.text == w {
+				p.pos++
+				return w, true
+			}
+		}
+	}
+	return "", false
+}
+
+// op consumes the current token if it is the operator
+func (p *parser) op(op string) bool {
+	if t := p.peek(); t.kind == tokOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// isOp reports whether the token ahead of the current one by n is the
+// operator
+func (p *parser) isOp(n int, op string) bool {
+	i := min(p.pos+n, len(p.toks)-1)
+	return p.toks[i].kind == tokOp && p.toks[i].text == op
+}
+
+// expect consumes the keyword or operator, or fails
+func (p *parser) expect(text string) error {
+	t := p.peek()
+	if (t.kind == tokKeyword || t.kind == tokOp) && t.text == text {
+		p.pos++
+		return nil
+	}
+	return p.errorf("expected %s, found %s", text, t)
+}
+
+// ident consumes a name
+func (p *parser) ident(what string) (string, error) {
+	t := p.peek()
+	if t.kind != tokIdent {
+		return "", p.errorf("expected %s, found %s", what, t)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// selectStmt parses a whole query
+func (p *parser) selectStmt() (*Select, error) {
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	s := &Select{Limit: -1}
+	for {
+		item, err := p.selectItem()
+		if err != nil {
+			return nil, err
+		}
+		s.Items = append(s.Items, item)
+		if !p.op(",") {
+			break
+		}
+	}
+
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	var err error
+	if s.From, err = p.tableRef(); err != nil {
+		return nil, err
+	}
+	if s.Join, err = p.join(); err != nil {
+		return nil, err
+	}
+
+	if _, ok := p.keyword("WHERE"); ok {
+		if s.Where, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := p.keyword("ORDER"); ok {
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			var o OrderItem
+			if o.Expr, err = p.expr(); err != nil {
+				return nil, err
+			}
+			if dir, ok := p.keyword("ASC", "DESC"); ok {
+				o.Desc = dir == "DESC"
+			}
+			s.OrderBy = append(s.OrderBy, o)
+			if !p.op(",") {
+				break
+			}
+		}
+	}
+	if _, ok := p.keyword("LIMIT"); ok {
+		if s.Limit, err = p.count("LIMIT"); err != nil {
+			return nil, err
+		}
+		if _, ok := p.keyword("OFFSET"); ok {
+			if s.Offset, err = p.count("OFFSET"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// selectItem parses *, table.*, or an expression with an optional alias
+func (p *parser) selectItem() (SelectItem, error) {
+	if p.op("*") {
+		return SelectItem{Star: true}, nil
+	}
+	// table.* needs two tokens of lookahead past the name
+	if t := p.peek(); t.kind == tokIdent && p.isOp(1, ".") && p.isOp(2, "*") {
+		p.pos += 3
+		return SelectItem{Star: true, Table: t.text}, nil
+	}
+	e, err := p.expr()
+	if err != nil {
+		return SelectItem{}, err
+	}
+	item := SelectItem{Expr: e}
+	if _, ok := p.keyword("AS"); ok {
+		if item.Alias, err = p.ident("an alias"); err != nil {
+			return SelectItem{}, err
+		}
+	} else if p.peek().kind == tokIdent {
+		item.Alias = p.next().text
+	}
+	return item, nil
+}
+
+// tableRef parses a table name with an optional alias
+func (p *parser) tableRef() (TableRef, error) {
+	name, err := p.ident("a table name")
+	if err != nil {
+		return TableRef{}, err
+	}
+	ref := TableRef{Name: name}
+	if _, ok := p.keyword("AS"); ok {
+		if ref.Alias, err = p.ident("an alias"); err != nil {
+			return TableRef{}, err
+		}
+	} else if p.peek().kind == tokIdent {
+		ref.Alias = p.next().text
+	}
+	return ref, nil
+}
+
+// join parses an optional JOIN clause
+func (p *parser) join() (*Join, error) {
+	j := &Join{Kind: InnerJoin}
+	switch kind, _ := p.keyword("JOIN", "INNER", "LEFT"); kind {
+	case "":
+		return nil, nil
+	case "INNER":
+		if err := p.expect("JOIN"); err != nil {
+			return nil, err
+		}
+	case "LEFT":
+		j.Kind = LeftJoin
+		p.keyword("OUTER")
+		if err := p.expect("JOIN"); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if j.Table, err = p.tableRef(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("ON"); err != nil {
+		return nil, err
+	}
+	if j.On, err = p.expr(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// count parses the non-negative integer of LIMIT or OFFSET
+func (p *parser) count(clause string) (int64, error) {
+	t := p.peek()
+	n, err := strconv.ParseInt(t.text, 10, 64)
+	if t.kind != tokNumber || err != nil {
+		return 0, p.errorf("%s needs a non-negative integer, found %s", clause, t)
+	}
+	p.pos++
+	return n, nil
+}
+
+// expr parses an expression
+func (p *parser) expr() (Expr, error) {
+	// Secure: Bound recursion, which every nesting passes through here, not
+	// or unary
+	if p.depth++; p.depth > maxDepth {
+		return nil, p.errorf("expression nested too deeply")
+	}
+	defer func() { p.depth-- }()
+	return p.or()
+}
+
+// or parses OR, the loosest operator
+func (p *parser) or() (Expr, error) {
+	l, err := p.and()
+	for err == nil {
+		if _, ok := p.keyword("OR"); !ok {
+			return l, nil
+		}
+		var r Expr
+		if r, err = p.and(); err == nil {
+			l = &Binary{"OR", l, r}
+		}
+	}
+	return nil, err
+}
+
+// and parses AND
+func (p *parser) and() (Expr, error) {
+	l, err := p.not()
+	for err == nil {
+		if _, ok := p.keyword("AND"); !ok {
+			return l, nil
+		}
+		var r Expr
+		if r, err = p.not(); err == nil {
+			l = &Binary{"AND", l, r}
+		}
+	}
+	return nil, err
+}
+
+// not parses prefix NOT
+func (p *parser) not() (Expr, error) {
+	if _, ok := p.keyword("NOT"); ok {
+		if p.depth++; p.depth > maxDepth {
+			return nil, p.errorf("expression nested too deeply")
+		}
+		defer func() { p.depth-- }()
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{"NOT", x}, nil
+	}
+	return p.comparison()
+}
+
+// comparison parses a comparison, IS [NOT] NULL, [NOT] IN, [NOT] BETWEEN
+// or [NOT] LIKE. These do not chain: a = b = c is an error.
+func (p *parser) comparison() (Expr, error) {
+	l, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokOp {
+		switch t.text {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.pos++
+			r, err := p.additive()
+			if err != nil {
+				return nil, err
+			}
+			op := t.text
+			if op == "<>" {
+				op = "!="
+			}
+			return &Binary{op, l, r}, nil
+		}
+		return l, nil
+	}
+
+	if _, ok := p.keyword("IS"); ok {
+		_, neg := p.keyword("NOT")
+		if err := p.expect("NULL"); err != nil {
+			return nil, err
+		}
+		return &IsNull{l, neg}, nil
+	}
+	// NOT here belongs to IN, BETWEEN or LIKE
+	neg := false
+	if t := p.peek(); t.kind == tokKeyword && t.text == "NOT" {
+		if n := p.toks[p.pos+1]; n.kind == tokKeyword && (n.text == "IN" || n.text == "BETWEEN" || n.text == "LIKE") {
+			p.pos++
+			neg = true
+		}
+	}
+	switch kw, _ := p.keyword("IN", "BETWEEN", "LIKE"); kw {
+	case "IN":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		list, err := p.exprList()
+		if err != nil {
+			return nil, err
+		}
+		return &In{l, list, neg}, nil
+	case "BETWEEN":
+		lo, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		hi, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		return &Between{l, lo, hi, neg}, nil
+	case "LIKE":
+		pattern, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		return &Like{l, pattern, neg}, nil
+	}
+	return l, nil
+}
+
+// additive parses + - and ||
+func (p *parser) additive() (Expr, error) {
+	l, err := p.multiplicative()
+	for err == nil {
+		t := p.peek()
+		if t.kind != tokOp || (t.text != "+" && t.text != "-" && t.text != "||") {
+			return l, nil
+		}
+		p.pos++
+		var r Expr
+		if r, err = p.multiplicative(); err == nil {
+			l = &Binary{t.text, l, r}
+		}
+	}
+	return nil, err
+}
+
+// multiplicative parses * / and %
+func (p *parser) multiplicative() (Expr, error) {
+	l, err := p.unary()
+	for err == nil {
+		t := p.peek()
+		if t.kind != tokOp || (t.text != "*" && t.text != "/" && t.text != "%") {
+			return l, nil
+		}
+		p.pos++
+		var r Expr
+		if r, err = p.unary(); err == nil {
+			l = &Binary{t.text, l, r}
+		}
+	}
+	return nil, err
+}
+
+// unary parses a sign before an operand. A minus directly before a
+// number literal folds into it, so the smallest integer can be written.
+func (p *parser) unary() (Expr, error) {
+	t := p.peek()
+	if t.kind != tokOp || (t.text != "-" && t.text != "+") {
+		return p.primary()
+	}
+	p.pos++
+	if p.depth++; p.depth > maxDepth {
+		return nil, p.errorf("expression nested too deeply")
+	}
+	defer func() { p.depth-- }()
+	if n := p.peek(); t.text == "-" && n.kind == tokNumber && n.pos == t.pos+1 {
+		p.pos++
+		return p.number("-" + n.text)
+	}
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &Unary{t.text, x}, nil
+}
+
+// primary parses a literal, column, function call or parenthesized
+// expression
+func (p *parser) primary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return p.number(t.text)
+	case tokString:
+		return &Literal{String(t.text)}, nil
+	case tokKeyword:
+		switch t.text {
+		case "NULL":
+			return &Literal{Null}, nil
+		case "TRUE":
+			return &Literal{Bool(true)}, nil
+		case "FALSE":
+			return &Literal{Bool(false)}, nil
+		}
+	case tokIdent:
+		if p.op("(") {
+			args, err := p.exprList()
+			if err != nil {
+				return nil, err
+			}
+			return &Call{strings.ToUpper(t.text), args}, nil
+		}
+		if p.op(".") {
+			name, err := p.ident("a column name")
+			if err != nil {
+				return nil, err
+			}
+			return &ColumnRef{t.text, name}, nil
+		}
+		return &ColumnRef{Name: t.text}, nil
+	case tokOp:
+		if t.text == "(" {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expect(")")
+		}
+	}
+	return nil, &SyntaxError{t.pos, fmt.Sprintf("expected an expression, found %s", t)}
+}
+
+// exprList parses expressions separated by commas up to a closing
+// parenthesis, the opening one already consumed
+func (p *parser) exprList() ([]Expr, error) {
+	var list []Expr
+	if p.op(")") {
+		return list, nil
+	}
+	for {
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		if p.op(")") {
+			return list, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// number parses a number literal: an integer if it has no point or
+// exponent and fits, otherwise a float
+func (p *parser) number(text string) (Expr, error) {
+	if !strings.ContainsAny(text, ".eE") {
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return &Literal{Int(i)}, nil
+		}
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, &SyntaxError{p.toks[p.pos-1].pos, fmt.Sprintf("bad number %s", text)}
+	}
+	return &Literal{Float(f)}, nil
+}
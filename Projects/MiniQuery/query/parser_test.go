@@ -0,0 +1,124 @@
+package query
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestParse tests the parsed form of queries, as their canonical SQL
+func TestParse(t *testing.T) {
+	tests := []struct {
+		sql, want string
+	}{
+		{"select * from t", "SELECT * FROM t"},
+		{"SELECT a, b AS x, c y FROM t", "SELECT a, b AS x, c AS y FROM t"},
+		{"SELECT t.*, u.a FROM t JOIN u ON t.id = u.id", "SELECT t.*, u.a FROM t JOIN u ON (t.id = u.id)"},
+		{"SELECT * FROM t AS a LEFT OUTER JOIN u b ON a.x = b.y", "SELECT * FROM t AS a LEFT JOIN u AS b ON (a.x = b.y)"},
+		{"SELECT * FROM t INNER JOIN u ON x <> y", "SELECT * FROM t JOIN u ON (x != y)"},
+		{"SELECT a + b * c - d FROM t", "SELECT ((a + (b * c)) - d) FROM t"},
+		{"SELECT (a + b) * c FROM t", "SELECT ((a + b) * c) FROM t"},
+		{"SELECT a || 'it''s' FROM t", "SELECT (a || 'it''s') FROM t"},
+		{"SELECT -5, - 5, -a, -(-5), 1.5, .5e1, 9223372036854775808 FROM t",
+			"SELECT -5, (- 5), (- a), (- -5), 1.5, 5.0, 9.223372036854776e+18 FROM t"},
+		{"SELECT * FROM t WHERE a = 1 OR b = 2 AND NOT c", "SELECT * FROM t WHERE ((a = 1) OR ((b = 2) AND (NOT c)))"},
+		{"SELECT * FROM t WHERE NOT a IS NULL", "SELECT * FROM t WHERE (NOT (a IS NULL))"},
+		{"SELECT * FROM t WHERE a IS NOT NULL AND b NOT IN (1, 'x', NULL)",
+			"SELECT * FROM t WHERE ((a IS NOT NULL) AND (b NOT IN (1, 'x', NULL)))"},
+		{"SELECT * FROM t WHERE a BETWEEN 1 AND 2 + 3 AND b NOT LIKE 'x%'",
+			"SELECT * FROM t WHERE ((a BETWEEN 1 AND (2 + 3)) AND (b NOT LIKE 'x%'))"},
+		{"SELECT lower(name), coalesce(a, b, 0) FROM t", "SELECT LOWER(name), COALESCE(a, b, 0) FROM t"},
+		{`SELECT "select", "a""b", ` + "`two words`" + ` FROM "from"`, `SELECT "select", "a""b", "two words" FROM "from"`},
+		{"SELECT a FROM t ORDER BY a DESC, b ASC, 2 LIMIT 10 OFFSET 5", "SELECT a FROM t ORDER BY a DESC, b, 2 LIMIT 10 OFFSET 5"},
+		{"SELECT TRUE, false, null FROM t -- comment\n LIMIT 0", "SELECT TRUE, FALSE, NULL FROM t LIMIT 0"},
+	}
+	for _, tt := range tests {
+		stmt, err := Parse(tt.sql)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.sql, err)
+			continue
+		}
+		got := stmt.String()
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.sql, got, tt.want)
+			continue
+		}
+		// The canonical form parses back to itself
+		again, err := Parse(got)
+		if err != nil || again.String() != got {
+			t.Errorf("reparsing %s = %v, %v", got, again, err)
+		}
+	}
+}
+
+// TestParseErrors tests rejected queries and where they are rejected
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		sql string
+		pos int
+	}{
+		{"", 0},
+		{"SELECT", 6},
+		{"SELECT a", 8},
+		{"SELECT a FROM", 13},
+		{"SELECT a FROM t WHERE", 21},
+		{"SELECT a FROM t WHERE a = = 1", 26},
+		{"SELECT a FROM t WHERE a = 1 = 2", 28},
+		{"SELECT a FROM t LIMIT -1", 22},
+		{"SELECT a FROM t LIMIT x", 22},
+		{"SELECT a FROM t extra junk", 22},
+		{"SELECT a FROM t JOIN u", 22},
+		{"SELECT a FROM t LEFT u", 21},
+		{"SELECT (a FROM t", 10},
+		{"SELECT f(a, FROM t", 12},
+		{"SELECT a FROM t WHERE a IS 1", 27},
+		{"SELECT 'open FROM t", 7},
+		{`SELECT "" FROM t`, 7},
+		{"SELECT a ! b FROM t", 9},
+		{"SELECT a FROM t WHERE b BETWEEN 1 OR 2", 34},
+		{"SELECT 1e999 FROM t", 7},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.sql)
+		var syntax *SyntaxError
+		if !errors.As(err, &syntax) || syntax.Pos != tt.pos {
+			t.Errorf("Parse(%q) = %v, want a syntax error at %d", tt.sql, err, tt.pos)
+		}
+	}
+}
+
+// TestParseDepth tests that deep nesting fails rather than overflowing
+// the stack
+func TestParseDepth(t *testing.T) {
+	for _, open := range []string{"(", "NOT ", "- "} {
+		sql := "SELECT " + strings.Repeat(open, 100000) + "a FROM t"
+		if _, err := Parse(sql); err == nil || !strings.Contains(err.Error(), "nested too deeply") {
+			t.Errorf("%q nested: %v", open, err)
+		}
+	}
+	sql := "SELECT " + strings.Repeat("(", 50) + "a" + strings.Repeat(")", 50) + " FROM t"
+	if _, err := Parse(sql); err != nil {
+		t.Errorf("modest nesting: %v", err)
+	}
+}
+
+// FuzzParse tests that any input parses or fails cleanly, and that what
+// parses formats to SQL parsing back the same
+func FuzzParse(f *testing.F) {
+	f.Add("SELECT a, b + 1 AS c FROM t JOIN u ON t.id = u.id WHERE a LIKE 'x%' ORDER BY 2 DESC LIMIT 3")
+	f.Add("SELECT * FROM t WHERE a NOT BETWEEN -1 AND 1 OR b IN (1, NULL)")
+	f.Fuzz(func(t *testing.T, sql string) {
+		stmt, err := Parse(sql)
+		if err != nil {
+			return
+		}
+		got := stmt.String()
+		again, err := Parse(got)
+		if err != nil {
+			t.Fatalf("%s does not parse: %v", got, err)
+		}
+		if again.String() != got {
+			t.Fatalf("%s reparses as %s", got, again)
+		}
+	})
+}
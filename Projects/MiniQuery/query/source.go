@@ -0,0 +1,217 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrBadSource is returned for input that does not load as a table
+var ErrBadSource = errors.New("bad source")
+
+// Table is a named set of rows, each holding one value per column
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    [][]Value
+}
+
+// LoadFile reads a table from a .csv, .json, .ndjson or .jsonl file, named by the
+// file's base name without its extension
+func LoadFile(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ext := filepath.Ext(path)
+	name := strings.TrimSuffix(filepath.Base(path), ext)
+	switch strings.ToLower(ext) {
+	case ".csv":
+		return LoadCSV(name, f)
+	case ".json", ".ndjson", ".jsonl":
+		return LoadJSON(name, f)
+	}
+	return nil, fmt.Errorf("%w: %s: unknown extension %q", ErrBadSource, path, ext)
+}
+
+// LoadCSV reads a table from CSV whose first record names the columns.
+// Cells are typed by their text: empty is NULL, and integers, decimals and
+// true or false become numbers and booleans if they print back the same.
+func LoadCSV(name string, r io.Reader) (*Table, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: %s has no header", ErrBadSource, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrBadSource, name, err)
+	}
+	// The header may start with a byte order mark from a spreadsheet
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	t := &Table{Name: name, Columns: header}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return t, nil
+		}
+		if err != nil {
+			// Including a record with the wrong number of fields
+			return nil, fmt.Errorf("%w: %s: %v", ErrBadSource, name, err)
+		}
+		row := make([]Value, len(record))
+		for i, cell := range record {
+			row[i] = inferValue(cell)
+		}
+		t.Rows = append(t.Rows, row)
+	}
+}
+
+// LoadJSON reads a table from a JSON array of objects, or from a stream
+// of objects such as one per line. The columns are the keys in the order
+// first seen, and a row lacking a key holds NULL there. Integral numbers
+// become integers, and arrays and objects become their JSON text.
+func LoadJSON(name string, r io.Reader) (*Table, error) {
+	br := bufio.NewReader(r)
+	first, err := firstByte(br)
+	if err == io.EOF {
+		return &Table{Name: name}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+	array := first == '['
+	if array {
+		dec.Token()
+	}
+
+	t := &Table{Name: name}
+	index := map[string]int{}
+	for dec.More() {
+		keys, values, err := decodeObject(dec)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: row %d: %v", ErrBadSource, name, len(t.Rows)+1, err)
+		}
+		row := make([]Value, len(t.Columns), len(t.Columns)+len(keys))
+		for i, key := range keys {
+			col, ok := index[key]
+			if !ok {
+				col = len(t.Columns)
+				index[key] = col
+				t.Columns = append(t.Columns, key)
+				row = append(row, Null)
+			}
+			row[col] = values[i]
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	if array {
+		if tok, err := dec.Token(); err != nil || tok != json.Delim(']') {
+			return nil, fmt.Errorf("%w: %s: unterminated array", ErrBadSource, name)
+		}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("%w: %s: unexpected data after row %d", ErrBadSource, name, len(t.Rows))
+	}
+
+	// Rows read before a column was first seen lack it
+	for i, row := range t.Rows {
+		for len(row) < len(t.Columns) {
+			row = append(row, Null)
+		}
+		t.Rows[i] = row
+	}
+	return t, nil
+}
+
+// firstByte returns the first byte after any white space, leaving it
+// unread
+func firstByte(br *bufio.Reader) (byte, error) {
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			return c, br.UnreadByte()
+		}
+	}
+}
+
+// decodeObject reads an object, returning its keys in order and their
+// values. A repeated key keeps its last value.
+func decodeObject(dec *json.Decoder) ([]string, []Value, error) {
+	if tok, err := dec.Token(); err != nil {
+		return nil, nil, err
+	} else if tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("want an object, found %v", tok)
+	}
+	var keys []string
+	var values []Value
+	seen := map[string]int{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		v, err := jsonValue(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i, ok := seen[key]; ok {
+			values[i] = v
+			continue
+		}
+		seen[key] = len(keys)
+		keys = append(keys, key)
+		values = append(values, v)
+	}
+	// The closing brace
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	return keys, values, nil
+}
+
+// jsonValue converts a JSON value to a Value
+func jsonValue(raw json.RawMessage) (Value, error) {
+	switch raw[0] {
+	case 'n':
+		return Null, nil
+	case 't':
+		return Bool(true), nil
+	case 'f':
+		return Bool(false), nil
+	case '"':
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return String(s), err
+	case '[', '{':
+		var b bytes.Buffer
+		err := json.Compact(&b, raw)
+		return String(b.String()), err
+	}
+	if i, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+		return Int(i), nil
+	}
+	f, err := strconv.ParseFloat(string(raw), 64)
+	if err != nil {
+		return Null, fmt.Errorf("bad number %s", raw)
+	}
+	return Float(f), nil
+}
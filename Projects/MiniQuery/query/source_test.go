@@ -0,0 +1,90 @@
+package query
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestLoadCSV tests the header, typing of cells and malformed input
+func TestLoadCSV(t *testing.T) {
+	table, err := LoadCSV("people", strings.NewReader("\ufeffid,name,score,active\n1,\"Lovelace, Ada\",9.5,true\n2,,007,false\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Table{
+		Name:    "people",
+		Columns: []string{"id", "name", "score", "active"},
+		Rows: [][]Value{
+			{Int(1), String("Lovelace, Ada"), Float(9.5), Bool(true)},
+			{Int(2), Null, String("007"), Bool(false)},
+		},
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("LoadCSV = %+v, want %+v", table, want)
+	}
+
+	for _, bad := range []string{"", "a,b\n1\n", "a\n\"open\n"} {
+		if _, err := LoadCSV("bad", strings.NewReader(bad)); !errors.Is(err, ErrBadSource) {
+			t.Errorf("LoadCSV(%q) = %v, want ErrBadSource", bad, err)
+		}
+	}
+}
+
+// TestLoadJSON tests arrays, streams of objects, columns first seen late,
+// and malformed input
+func TestLoadJSON(t *testing.T) {
+	want := &Table{
+		Name:    "events",
+		Columns: []string{"id", "kind", "tags", "at", "extra"},
+		Rows: [][]Value{
+			{Int(1), String("click"), String(`["a","b"]`), Float(1.5), Null},
+			{Int(2), Null, Null, Null, Bool(true)},
+		},
+	}
+	inputs := []string{
+		`[{"id": 1, "kind": "click", "tags": ["a", "b"], "at": 1.5}, {"extra": true, "id": 2, "kind": null}]`,
+		"{\"id\": 1, \"kind\": \"click\", \"tags\": [\"a\",\"b\"], \"at\": 1.5}\n{\"extra\": true, \"id\": 2, \"kind\": null}\n",
+		// A repeated key keeps its last value
+		`[{"id": 0, "kind": "click", "tags": ["a","b"], "at": 1.5, "id": 1}, {"id": 2, "extra": true}]`,
+	}
+	for _, in := range inputs {
+		table, err := LoadJSON("events", strings.NewReader(in))
+		if err != nil {
+			t.Errorf("LoadJSON(%s): %v", in, err)
+			continue
+		}
+		if !reflect.DeepEqual(table, want) {
+			t.Errorf("LoadJSON(%s) = %+v, want %+v", in, table, want)
+		}
+	}
+
+	for _, bad := range []string{`[1, 2]`, `[{"a": 1}`, `{"a": 1} junk`, `"text"`, `[{"a": 1e999}]`, `[{"a": 1}] [`} {
+		if _, err := LoadJSON("bad", strings.NewReader(bad)); !errors.Is(err, ErrBadSource) {
+			t.Errorf("LoadJSON(%s) = %v, want ErrBadSource", bad, err)
+		}
+	}
+}
+
+// TestLoadFile tests choosing the format by extension
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.csv"), []byte("x\n1\n"), 0o600)
+	os.WriteFile(filepath.Join(dir, "b.JSON"), []byte(`[{"x": 1}]`), 0o600)
+	os.WriteFile(filepath.Join(dir, "c.txt"), []byte("x\n1\n"), 0o600)
+	for _, name := range []string{"a.csv", "b.JSON"} {
+		table, err := LoadFile(filepath.Join(dir, name))
+		if err != nil || table.Name != name[:1] || len(table.Rows) != 1 || table.Rows[0][0] != Int(1) {
+			t.Errorf("LoadFile(%s) = %+v, %v", name, table, err)
+		}
+	}
+	if _, err := LoadFile(filepath.Join(dir, "c.txt")); !errors.Is(err, ErrBadSource) {
+		t.Errorf("LoadFile of .txt = %v, want ErrBadSource", err)
+	}
+	if _, err := LoadFile(filepath.Join(dir, "missing.csv")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadFile of a missing file = %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package query
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of a Value
+type Kind uint8
+
+// The kinds of values
+const (
+	KindNull Kind = iota
+	KindBool
+	KindInt
+	KindFloat
+	KindString
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "bool"
+	case KindInt:
+		return "int"
+	case KindFloat:
+		return "float"
+	case KindString:
+		return "string"
+	}
+	return fmt.Sprintf("kind(%d)", uint8(k))
+}
+
+// Value is a cell of a row or the result of an expression: NULL, a
+// boolean, a 64-bit integer, a float or a string. The zero Value is NULL.
+type Value struct {
+	kind Kind
+	i    int64 // Int, and Bool as 0 or 1
+	f    float64
+	s    string
+}
+
+// Null is the NULL value
+var Null = Value{}
+
+// Int returns an integer value
+func Int(i int64) Value { return Value{kind: KindInt, i: i} }
+
+// Float returns a float value
+func Float(f float64) Value { return Value{kind: KindFloat, f: f} }
+
+// String returns a string value
+func String(s string) Value { return Value{kind: KindString, s: s} }
+
+// Bool returns a boolean value
+func Bool(b bool) Value {
+	v := Value{kind: KindBool}
+	if b {
+		v.i = 1
+	}
+	return v
+}
+
+// Kind returns the kind of v
+func (v Value) Kind() Kind { return v.kind }
+
+// IsNull reports whether v is NULL
+func (v Value) IsNull() bool { return v.kind == KindNull }
+
+// numeric reports whether v is an integer or a float
+func (v Value) numeric() bool { return v.kind == KindInt || v.kind == KindFloat }
+
+// float returns a numeric value as a float64
+func (v Value) float() float64 {
+	if v.kind == KindInt {
+		return float64(v.i)
+	}
+	return v.f
+}
+
+// String formats v for display: NULL, true or false, the number, or the
+// string itself
+func (v Value) String() string {
+	switch v.kind {
+	case KindBool:
+		return strconv.FormatBool(v.i == 1)
+	case KindInt:
+		return strconv.FormatInt(v.i, 10)
+	case KindFloat:
+		return strconv.FormatFloat(v.f, 'g', -1, 64)
+	case KindString:
+		return v.s
+	}
+	return "NULL"
+}
+
+// Any returns v as a Go value: nil, bool, int64, float64 or string
+func (v Value) Any() any {
+	switch v.kind {
+	case KindBool:
+		return v.i == 1
+	case KindInt:
+		return v.i
+	case KindFloat:
+		return v.f
+	case KindString:
+		return v.s
+	}
+	return nil
+}
+
+// literal formats v as an SQL literal
+func (v Value) literal() string {
+	switch v.kind {
+	case KindString:
+		return "'" + strings.ReplaceAll(v.s, "'", "''") + "'"
+	case KindBool:
+		return strings.ToUpper(v.String())
+	case KindFloat:
+		// Keep a decimal point, so the literal parses back as a float
+		s := v.String()
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return s
+	}
+	return v.String()
+}
+
+// compare compares two non-NULL values of comparable kinds: numbers with
+// numbers, strings with strings and booleans with booleans. An integer
+// and a float compare by value.
+func compare(a, b Value) (int, error) {
+	switch {
+	case a.kind == KindInt && b.kind == KindInt:
+		return cmp.Compare(a.i, b.i), nil
+	case a.numeric() && b.numeric():
+		return cmp.Compare(a.float(), b.float()), nil
+	case a.kind == KindString && b.kind == KindString:
+		return strings.Compare(a.s, b.s), nil
+	case a.kind == KindBool && b.kind == KindBool:
+		return cmp.Compare(a.i, b.i), nil
+	}
+	return 0, fmt.Errorf("%w: cannot compare %s with %s", ErrType, a.kind, b.kind)
+}
+
+// rank orders the kinds for sorting: NULL, booleans, numbers, strings
+func (v Value) rank() int {
+	switch v.kind {
+	case KindNull:
+		return 0
+	case KindBool:
+		return 1
+	case KindInt, KindFloat:
+		return 2
+	}
+	return 3
+}
+
+// order is a total order over all values for ORDER BY: NULLs first, then
+// booleans, numbers and strings, each in their natural order
+func order(a, b Value) int {
+	if ra, rb := a.rank(), b.rank(); ra != rb || ra == 0 {
+		return cmp.Compare(ra, rb)
+	}
+	c, _ := compare(a, b)
+	return c
+}
+
+// hashKey encodes a non-NULL value so that values equal under compare,
+// and only they, encode equally: an integral float encodes as the integer
+func (v Value) hashKey() string {
+	switch v.kind {
+	case KindBool:
+		return "b" + strconv.FormatInt(v.i, 10)
+	case KindInt:
+		return "n" + strconv.FormatInt(v.i, 10)
+	case KindFloat:
+		if v.f == math.Trunc(v.f) && v.f >= math.MinInt64 && v.f < math.MaxInt64 {
+			return "n" + strconv.FormatInt(int64(v.f), 10)
+		}
+		return "f" + strconv.FormatFloat(v.f, 'g', -1, 64)
+	}
+	return "s" + v.s
+}
+
+// inferValue parses a CSV cell: empty is NULL, and integers, floats and
+// booleans are recognized only if they format back the same way, so that
+// "007" or "1e3" stay strings rather than losing their spelling
+func inferValue(s string) Value {
+	switch s {
+	case "":
+		return Null
+	case "true", "TRUE", "True":
+		return Bool(true)
+	case "false", "FALSE", "False":
+		return Bool(false)
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(i, 10) == s {
+		return Int(i)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) && isDecimal(s) {
+		return Float(f)
+	}
+	return String(s)
+}
+
+// isDecimal reports whether s is a plain decimal number with a fraction:
+// an optional minus sign, digits, a point and digits
+func isDecimal(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, ok := strings.Cut(s, ".")
+	if !ok || whole == "" || frac == "" || (len(whole) > 1 && whole[0] == '0') {
+		return false
+	}
+	for _, c := range whole + frac {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}